@@ -0,0 +1,208 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sls
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	consumerLibrary "github.com/aliyun/aliyun-log-go-sdk/consumer"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/retry"
+)
+
+const (
+	// keys of the metadata of read responses.
+	readShardKey    = "shard"
+	readProjectKey  = "project"
+	readLogstoreKey = "logstore"
+	readTopicKey    = "topic"
+	readSourceKey   = "source"
+
+	cursorPositionBegin = "begin"
+	cursorPositionEnd   = "end"
+)
+
+// consumerWorker is implemented by *consumerLibrary.ConsumerWorker.
+type consumerWorker interface {
+	Start()
+	StopAndWait()
+}
+
+// logGroupMessage is the payload delivered to the app for each log group.
+type logGroupMessage struct {
+	Topic  string            `json:"topic,omitempty"`
+	Source string            `json:"source,omitempty"`
+	Tags   map[string]string `json:"tags,omitempty"`
+	Logs   []logMessage      `json:"logs"`
+}
+
+type logMessage struct {
+	Time     uint32            `json:"time"`
+	Contents map[string]string `json:"contents"`
+}
+
+func newConsumerWorker(config consumerLibrary.LogHubConfig, do func(int, *sls.LogGroupList) string) consumerWorker {
+	return consumerLibrary.InitConsumerWorker(config, do)
+}
+
+// Read joins the consumer group and delivers the log groups of the logstore to the app.
+// Failed log groups are retried until the app processes them successfully or the binding is closed.
+func (s *AliCloudSlsLogstorage) Read(ctx context.Context, handler bindings.Handler) error {
+	if s.closed.Load() {
+		return errors.New("binding is closed")
+	}
+
+	config, err := s.metadata.logHubConfig()
+	if err != nil {
+		return err
+	}
+
+	// Use a context that is canceled when the binding is closed, so pending retries are interrupted.
+	readCtx, cancel := context.WithCancel(ctx)
+	worker := s.newWorker(config, func(shard int, logGroupList *sls.LogGroupList) string {
+		s.processLogGroups(readCtx, handler, shard, logGroupList)
+		return ""
+	})
+	worker.Start()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		select {
+		case <-ctx.Done():
+		case <-s.closeCh:
+		}
+		cancel()
+		worker.StopAndWait()
+	}()
+
+	return nil
+}
+
+// processLogGroups delivers each log group to the app, retrying failures according to the back off configuration.
+func (s *AliCloudSlsLogstorage) processLogGroups(ctx context.Context, handler bindings.Handler, shard int, logGroupList *sls.LogGroupList) {
+	if logGroupList == nil {
+		return
+	}
+
+	for _, logGroup := range logGroupList.LogGroups {
+		data, err := json.Marshal(newLogGroupMessage(logGroup))
+		if err != nil {
+			s.logger.Errorf("SLS binding error: failed to serialize log group: %v", err)
+			continue
+		}
+
+		res := &bindings.ReadResponse{
+			Data: data,
+			Metadata: map[string]string{
+				readShardKey:    strconv.Itoa(shard),
+				readProjectKey:  s.metadata.Project,
+				readLogstoreKey: s.metadata.Logstore,
+				readTopicKey:    logGroup.GetTopic(),
+				readSourceKey:   logGroup.GetSource(),
+			},
+		}
+
+		err = retry.NotifyRecover(func() error {
+			_, herr := handler(ctx, res)
+			return herr
+		}, s.backOffConfig.NewBackOffWithContext(ctx), func(err error, d time.Duration) {
+			s.logger.Warnf("SLS binding error: failed to process log group from shard %d, retrying in %s: %v", shard, d, err)
+		}, func() {
+			s.logger.Infof("SLS binding: successfully processed log group from shard %d after it previously failed", shard)
+		})
+		if err != nil {
+			s.logger.Errorf("SLS binding error: failed to process log group from shard %d: %v", shard, err)
+		}
+	}
+}
+
+func newLogGroupMessage(logGroup *sls.LogGroup) logGroupMessage {
+	msg := logGroupMessage{
+		Topic:  logGroup.GetTopic(),
+		Source: logGroup.GetSource(),
+		Logs:   make([]logMessage, 0, len(logGroup.Logs)),
+	}
+	if len(logGroup.LogTags) > 0 {
+		msg.Tags = make(map[string]string, len(logGroup.LogTags))
+		for _, tag := range logGroup.LogTags {
+			msg.Tags[tag.GetKey()] = tag.GetValue()
+		}
+	}
+	for _, log := range logGroup.Logs {
+		contents := make(map[string]string, len(log.Contents))
+		for _, c := range log.Contents {
+			contents[c.GetKey()] = c.GetValue()
+		}
+		msg.Logs = append(msg.Logs, logMessage{
+			Time:     log.GetTime(),
+			Contents: contents,
+		})
+	}
+	return msg
+}
+
+// logHubConfig returns the configuration of the consumer group worker.
+func (m SlsLogstorageMetadata) logHubConfig() (consumerLibrary.LogHubConfig, error) {
+	if m.Project == "" || m.Logstore == "" || m.ConsumerGroup == "" {
+		return consumerLibrary.LogHubConfig{}, errors.New("SLS binding error: project, logstore and consumerGroup are required to read from a logstore")
+	}
+
+	config := consumerLibrary.LogHubConfig{
+		Endpoint:                  m.Endpoint,
+		AccessKeyID:               m.AccessKeyID,
+		AccessKeySecret:           m.AccessKeySecret,
+		Project:                   m.Project,
+		Logstore:                  m.Logstore,
+		ConsumerGroupName:         m.ConsumerGroup,
+		ConsumerName:              m.ConsumerName,
+		HeartbeatIntervalInSecond: m.HeartbeatIntervalInSec,
+		DataFetchIntervalInMs:     m.DataFetchIntervalInMs,
+		MaxFetchLogGroupCount:     m.MaxFetchLogGroupCount,
+		AllowLogLevel:             "warn",
+	}
+	if config.ConsumerName == "" {
+		// Each consumer in the group must have a unique name
+		config.ConsumerName, _ = os.Hostname()
+		if config.ConsumerName == "" {
+			config.ConsumerName = m.ConsumerGroup
+		}
+	}
+
+	switch position := strings.ToLower(m.CursorPosition); position {
+	case "", cursorPositionEnd:
+		config.CursorPosition = consumerLibrary.END_CURSOR
+	case cursorPositionBegin:
+		config.CursorPosition = consumerLibrary.BEGIN_CURSOR
+	default:
+		// A Unix timestamp in seconds
+		start, err := strconv.ParseInt(position, 10, 64)
+		if err != nil || start < 0 {
+			return config, fmt.Errorf("SLS binding error: invalid cursorPosition '%s': must be 'begin', 'end' or a Unix timestamp", m.CursorPosition)
+		}
+		config.CursorPosition = consumerLibrary.SPECIAL_TIMER_CURSOR
+		config.CursorStartTime = start
+	}
+
+	return config, nil
+}
@@ -3,28 +3,48 @@ package sls
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	sls "github.com/aliyun/aliyun-log-go-sdk"
+	consumerLibrary "github.com/aliyun/aliyun-log-go-sdk/consumer"
 	"github.com/aliyun/aliyun-log-go-sdk/producer"
 
 	"github.com/dapr/components-contrib/bindings"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
 )
 
 type AliCloudSlsLogstorage struct {
-	logger   logger.Logger
-	producer *producer.Producer
-	metadata SlsLogstorageMetadata
+	logger        logger.Logger
+	producer      *producer.Producer
+	metadata      SlsLogstorageMetadata
+	backOffConfig retry.Config
+	newWorker     func(consumerLibrary.LogHubConfig, func(int, *sls.LogGroupList) string) consumerWorker
+	closed        atomic.Bool
+	closeCh       chan struct{}
+	wg            sync.WaitGroup
 }
 
 type SlsLogstorageMetadata struct {
 	Endpoint        string `json:"endpoint" mapstructure:"endpoint"`
 	AccessKeyID     string `json:"accessKeyID" mapstructure:"accessKeyID"`
 	AccessKeySecret string `json:"accessKeySecret" mapstructure:"accessKeySecret"`
+
+	// Options for reading from a logstore with a consumer group.
+	Project                string `json:"project" mapstructure:"project"`
+	Logstore               string `json:"logstore" mapstructure:"logstore"`
+	ConsumerGroup          string `json:"consumerGroup" mapstructure:"consumerGroup"`
+	ConsumerName           string `json:"consumerName" mapstructure:"consumerName"`
+	CursorPosition         string `json:"cursorPosition" mapstructure:"cursorPosition"`
+	HeartbeatIntervalInSec int    `json:"heartbeatIntervalInSec" mapstructure:"heartbeatIntervalInSec"`
+	DataFetchIntervalInMs  int64  `json:"dataFetchIntervalInMs" mapstructure:"dataFetchIntervalInMs"`
+	MaxFetchLogGroupCount  int    `json:"maxFetchLogGroupCount" mapstructure:"maxFetchLogGroupCount"`
 }
 
 type Callback struct {
@@ -38,6 +58,13 @@ func (s *AliCloudSlsLogstorage) Init(_ context.Context, metadata bindings.Metada
 		return err
 	}
 	s.metadata = *m
+
+	// Default retry configuration is used if no
+	// backOff properties are set.
+	if err = retry.DecodeConfigWithPrefix(&s.backOffConfig, metadata.Properties, "backOff"); err != nil {
+		return fmt.Errorf("SLS binding error: retry configuration error: %w", err)
+	}
+
 	producerConfig := producer.GetDefaultProducerConfig()
 	// the config properties in the component yaml file
 	producerConfig.Endpoint = m.Endpoint
@@ -49,10 +76,12 @@ func (s *AliCloudSlsLogstorage) Init(_ context.Context, metadata bindings.Metada
 	return nil
 }
 
-func NewAliCloudSlsLogstorage(logger logger.Logger) bindings.OutputBinding {
+func NewAliCloudSlsLogstorage(logger logger.Logger) bindings.InputOutputBinding {
 	logger.Debug("initialized Sls log storage binding component")
 	s := &AliCloudSlsLogstorage{
-		logger: logger,
+		logger:    logger,
+		newWorker: newConsumerWorker,
+		closeCh:   make(chan struct{}),
 	}
 	return s
 }
@@ -127,6 +156,20 @@ func (callback *Callback) Fail(result *producer.Result) {
 	callback.s.logger.Info("Log storage failed:", msg)
 }
 
+// Close stops the consumer group workers and the producer.
+func (s *AliCloudSlsLogstorage) Close() error {
+	if !s.closed.CompareAndSwap(false, true) {
+		return errors.New("binding already closed")
+	}
+	close(s.closeCh)
+	s.wg.Wait()
+
+	if s.producer != nil {
+		s.producer.SafeClose()
+	}
+	return nil
+}
+
 // GetComponentMetadata returns the metadata of the component.
 func (s *AliCloudSlsLogstorage) GetComponentMetadata() map[string]string {
 	metadataStruct := SlsLogstorageMetadata{}
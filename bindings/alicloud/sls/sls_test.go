@@ -1,12 +1,21 @@
 package sls
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"testing"
+	"time"
 
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	consumerLibrary "github.com/aliyun/aliyun-log-go-sdk/consumer"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+	"github.com/dapr/kit/retry"
 )
 
 /**
@@ -57,3 +66,112 @@ func TestParseLog(t *testing.T) {
 		}
 	}
 }
+
+func TestLogHubConfig(t *testing.T) {
+	m := SlsLogstorageMetadata{
+		Endpoint:      "ENDPOINT",
+		Project:       "PROJECT",
+		Logstore:      "LOGSTORE",
+		ConsumerGroup: "GROUP",
+		ConsumerName:  "CONSUMER",
+	}
+
+	t.Run("defaults to the end cursor", func(t *testing.T) {
+		config, err := m.logHubConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "PROJECT", config.Project)
+		assert.Equal(t, "GROUP", config.ConsumerGroupName)
+		assert.Equal(t, "CONSUMER", config.ConsumerName)
+		assert.Equal(t, consumerLibrary.END_CURSOR, config.CursorPosition)
+	})
+
+	t.Run("begin cursor", func(t *testing.T) {
+		m := m
+		m.CursorPosition = "begin"
+		config, err := m.logHubConfig()
+		require.NoError(t, err)
+		assert.Equal(t, consumerLibrary.BEGIN_CURSOR, config.CursorPosition)
+	})
+
+	t.Run("timestamp cursor", func(t *testing.T) {
+		m := m
+		m.CursorPosition = "1690000000"
+		config, err := m.logHubConfig()
+		require.NoError(t, err)
+		assert.Equal(t, consumerLibrary.SPECIAL_TIMER_CURSOR, config.CursorPosition)
+		assert.Equal(t, int64(1690000000), config.CursorStartTime)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		m := m
+		m.CursorPosition = "yesterday"
+		_, err := m.logHubConfig()
+		assert.Error(t, err)
+	})
+
+	t.Run("missing consumer group", func(t *testing.T) {
+		m := m
+		m.ConsumerGroup = ""
+		_, err := m.logHubConfig()
+		assert.Error(t, err)
+	})
+}
+
+type fakeConsumerWorker struct {
+	started bool
+	stopped chan struct{}
+}
+
+func (w *fakeConsumerWorker) Start()       { w.started = true }
+func (w *fakeConsumerWorker) StopAndWait() { close(w.stopped) }
+
+func TestRead(t *testing.T) {
+	worker := &fakeConsumerWorker{stopped: make(chan struct{})}
+	var process func(int, *sls.LogGroupList) string
+
+	s := NewAliCloudSlsLogstorage(logger.NewLogger("test")).(*AliCloudSlsLogstorage)
+	s.newWorker = func(_ consumerLibrary.LogHubConfig, do func(int, *sls.LogGroupList) string) consumerWorker {
+		process = do
+		return worker
+	}
+	s.backOffConfig = retry.DefaultConfig()
+	s.backOffConfig.Duration = time.Millisecond
+	s.metadata = SlsLogstorageMetadata{Project: "PROJECT", Logstore: "LOGSTORE", ConsumerGroup: "GROUP"}
+
+	calls := 0
+	var received []*bindings.ReadResponse
+	err := s.Read(context.Background(), func(_ context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("app error")
+		}
+		received = append(received, res)
+		return nil, nil
+	})
+	require.NoError(t, err)
+	require.True(t, worker.started)
+
+	process(2, &sls.LogGroupList{LogGroups: []*sls.LogGroup{{
+		Topic:   ptr.Of("TOPIC"),
+		Source:  ptr.Of("SOURCE"),
+		LogTags: []*sls.LogTag{{Key: ptr.Of("tag"), Value: ptr.Of("value")}},
+		Logs: []*sls.Log{{
+			Time:     ptr.Of(uint32(100)),
+			Contents: []*sls.LogContent{{Key: ptr.Of("k"), Value: ptr.Of("v")}},
+		}},
+	}}})
+
+	// The first delivery failed and was retried.
+	assert.Equal(t, 2, calls)
+	require.Len(t, received, 1)
+	assert.Equal(t, "2", received[0].Metadata["shard"])
+	assert.Equal(t, "TOPIC", received[0].Metadata["topic"])
+	assert.JSONEq(t, `{"topic":"TOPIC","source":"SOURCE","tags":{"tag":"value"},"logs":[{"time":100,"contents":{"k":"v"}}]}`, string(received[0].Data))
+
+	require.NoError(t, s.Close())
+	select {
+	case <-worker.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("worker was not stopped")
+	}
+}
@@ -36,12 +36,15 @@ type DynamoDB struct {
 }
 
 type dynamoDBMetadata struct {
-	Region       string `json:"region" mapstructure:"region"`
-	Endpoint     string `json:"endpoint" mapstructure:"endpoint"`
-	AccessKey    string `json:"accessKey" mapstructure:"accessKey"`
-	SecretKey    string `json:"secretKey" mapstructure:"secretKey"`
-	SessionToken string `json:"sessionToken" mapstructure:"sessionToken"`
-	Table        string `json:"table" mapstructure:"table"`
+	Region               string `json:"region" mapstructure:"region"`
+	Endpoint             string `json:"endpoint" mapstructure:"endpoint"`
+	STSEndpoint          string `json:"stsEndpoint" mapstructure:"stsEndpoint"`
+	UseFIPSEndpoint      bool   `json:"useFipsEndpoint" mapstructure:"useFipsEndpoint"`
+	UseDualStackEndpoint bool   `json:"useDualStackEndpoint" mapstructure:"useDualStackEndpoint"`
+	AccessKey            string `json:"accessKey" mapstructure:"accessKey"`
+	SecretKey            string `json:"secretKey" mapstructure:"secretKey"`
+	SessionToken         string `json:"sessionToken" mapstructure:"sessionToken"`
+	Table                string `json:"table" mapstructure:"table"`
 }
 
 // NewDynamoDB returns a new DynamoDB instance.
@@ -105,7 +108,16 @@ func (d *DynamoDB) getDynamoDBMetadata(spec bindings.Metadata) (*dynamoDBMetadat
 }
 
 func (d *DynamoDB) getClient(metadata *dynamoDBMetadata) (*dynamodb.DynamoDB, error) {
-	sess, err := awsAuth.GetClient(metadata.AccessKey, metadata.SecretKey, metadata.SessionToken, metadata.Region, metadata.Endpoint)
+	sess, err := awsAuth.GetClient(awsAuth.Options{
+		AccessKey:            metadata.AccessKey,
+		SecretKey:            metadata.SecretKey,
+		SessionToken:         metadata.SessionToken,
+		Region:               metadata.Region,
+		Endpoint:             metadata.Endpoint,
+		STSEndpoint:          metadata.STSEndpoint,
+		UseFIPSEndpoint:      metadata.UseFIPSEndpoint,
+		UseDualStackEndpoint: metadata.UseDualStackEndpoint,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -56,14 +56,17 @@ type AWSKinesis struct {
 }
 
 type kinesisMetadata struct {
-	StreamName          string `json:"streamName" mapstructure:"streamName"`
-	ConsumerName        string `json:"consumerName" mapstructure:"consumerName"`
-	Region              string `json:"region" mapstructure:"region"`
-	Endpoint            string `json:"endpoint" mapstructure:"endpoint"`
-	AccessKey           string `json:"accessKey" mapstructure:"accessKey"`
-	SecretKey           string `json:"secretKey" mapstructure:"secretKey"`
-	SessionToken        string `json:"sessionToken" mapstructure:"sessionToken"`
-	KinesisConsumerMode string `json:"mode" mapstructure:"mode"`
+	StreamName           string `json:"streamName" mapstructure:"streamName"`
+	ConsumerName         string `json:"consumerName" mapstructure:"consumerName"`
+	Region               string `json:"region" mapstructure:"region"`
+	Endpoint             string `json:"endpoint" mapstructure:"endpoint"`
+	STSEndpoint          string `json:"stsEndpoint" mapstructure:"stsEndpoint"`
+	UseFIPSEndpoint      bool   `json:"useFipsEndpoint" mapstructure:"useFipsEndpoint"`
+	UseDualStackEndpoint bool   `json:"useDualStackEndpoint" mapstructure:"useDualStackEndpoint"`
+	AccessKey            string `json:"accessKey" mapstructure:"accessKey"`
+	SecretKey            string `json:"secretKey" mapstructure:"secretKey"`
+	SessionToken         string `json:"sessionToken" mapstructure:"sessionToken"`
+	KinesisConsumerMode  string `json:"mode" mapstructure:"mode"`
 }
 
 const (
@@ -126,9 +129,13 @@ func (a *AWSKinesis) Init(ctx context.Context, metadata bindings.Metadata) error
 	}
 
 	if m.KinesisConsumerMode == SharedThroughput {
+		// The region may come from the environment or the shared config
 		kclConfig := config.NewKinesisClientLibConfigWithCredential(m.ConsumerName,
-			m.StreamName, m.Region, m.ConsumerName,
+			m.StreamName, aws.StringValue(client.Config.Region), m.ConsumerName,
 			credentials.NewStaticCredentials(m.AccessKey, m.SecretKey, ""))
+		if m.Endpoint != "" {
+			kclConfig = kclConfig.WithKinesisEndpoint(m.Endpoint).WithDynamoDBEndpoint(m.Endpoint)
+		}
 		a.workerConfig = kclConfig
 	}
 
@@ -356,7 +363,16 @@ func (a *AWSKinesis) waitUntilConsumerExists(ctx aws.Context, input *kinesis.Des
 }
 
 func (a *AWSKinesis) getClient(metadata *kinesisMetadata) (*kinesis.Kinesis, error) {
-	sess, err := awsAuth.GetClient(metadata.AccessKey, metadata.SecretKey, metadata.SessionToken, metadata.Region, metadata.Endpoint)
+	sess, err := awsAuth.GetClient(awsAuth.Options{
+		AccessKey:            metadata.AccessKey,
+		SecretKey:            metadata.SecretKey,
+		SessionToken:         metadata.SessionToken,
+		Region:               metadata.Region,
+		Endpoint:             metadata.Endpoint,
+		STSEndpoint:          metadata.STSEndpoint,
+		UseFIPSEndpoint:      metadata.UseFIPSEndpoint,
+		UseDualStackEndpoint: metadata.UseDualStackEndpoint,
+	})
 	if err != nil {
 		return nil, err
 	}
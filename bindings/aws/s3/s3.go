@@ -14,6 +14,7 @@ limitations under the License.
 package s3
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	b64 "encoding/base64"
@@ -21,16 +22,20 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
 
 	"github.com/dapr/components-contrib/bindings"
 	awsAuth "github.com/dapr/components-contrib/internal/authentication/aws"
@@ -41,15 +46,26 @@ import (
 )
 
 const (
-	metadataDecodeBase64 = "decodeBase64"
-	metadataEncodeBase64 = "encodeBase64"
-	metadataFilePath     = "filePath"
-	metadataPresignTTL   = "presignTTL"
+	metadataDecodeBase64         = "decodeBase64"
+	metadataEncodeBase64         = "encodeBase64"
+	metadataFilePath             = "filePath"
+	metadataPresignTTL           = "presignTTL"
+	metadataPresignMethod        = "presignMethod"
+	metadataPartSizeBytes        = "partSizeBytes"
+	metadataServerSideEncryption = "serverSideEncryption"
+	metadataSSEKMSKeyID          = "sseKMSKeyId"
+	metadataStorageClass         = "storageClass"
+	metadataTags                 = "tags"
+	metadataCacheControl         = "cacheControl"
+	metadataContentDisposition   = "contentDisposition"
 
 	metadataKey = "key"
 
 	defaultMaxResults = 1000
 	presignOperation  = "presign"
+
+	// Maximum number of tags of an object, as enforced by S3.
+	maxObjectTags = 10
 )
 
 // AWSS3 is a binding for an AWS S3 storage bucket.
@@ -62,19 +78,30 @@ type AWSS3 struct {
 }
 
 type s3Metadata struct {
-	Region         string `json:"region" mapstructure:"region"`
-	Endpoint       string `json:"endpoint" mapstructure:"endpoint"`
-	AccessKey      string `json:"accessKey" mapstructure:"accessKey"`
-	SecretKey      string `json:"secretKey" mapstructure:"secretKey"`
-	SessionToken   string `json:"sessionToken" mapstructure:"sessionToken"`
-	Bucket         string `json:"bucket" mapstructure:"bucket"`
-	DecodeBase64   bool   `json:"decodeBase64,string" mapstructure:"decodeBase64"`
-	EncodeBase64   bool   `json:"encodeBase64,string" mapstructure:"encodeBase64"`
-	ForcePathStyle bool   `json:"forcePathStyle,string" mapstructure:"forcePathStyle"`
-	DisableSSL     bool   `json:"disableSSL,string" mapstructure:"disableSSL"`
-	InsecureSSL    bool   `json:"insecureSSL,string" mapstructure:"insecureSSL"`
-	FilePath       string `mapstructure:"filePath"`
-	PresignTTL     string `mapstructure:"presignTTL"`
+	Region               string `json:"region" mapstructure:"region"`
+	Endpoint             string `json:"endpoint" mapstructure:"endpoint"`
+	STSEndpoint          string `json:"stsEndpoint" mapstructure:"stsEndpoint"`
+	UseFIPSEndpoint      bool   `json:"useFipsEndpoint,string" mapstructure:"useFipsEndpoint"`
+	UseDualStackEndpoint bool   `json:"useDualStackEndpoint,string" mapstructure:"useDualStackEndpoint"`
+	AccessKey            string `json:"accessKey" mapstructure:"accessKey"`
+	SecretKey            string `json:"secretKey" mapstructure:"secretKey"`
+	SessionToken         string `json:"sessionToken" mapstructure:"sessionToken"`
+	Bucket               string `json:"bucket" mapstructure:"bucket"`
+	DecodeBase64         bool   `json:"decodeBase64,string" mapstructure:"decodeBase64"`
+	EncodeBase64         bool   `json:"encodeBase64,string" mapstructure:"encodeBase64"`
+	ForcePathStyle       bool   `json:"forcePathStyle,string" mapstructure:"forcePathStyle"`
+	DisableSSL           bool   `json:"disableSSL,string" mapstructure:"disableSSL"`
+	InsecureSSL          bool   `json:"insecureSSL,string" mapstructure:"insecureSSL"`
+	FilePath             string `mapstructure:"filePath"`
+	PresignTTL           string `mapstructure:"presignTTL"`
+	// Size of the parts of multipart uploads, in bytes; objects larger than this are uploaded in multiple parts.
+	PartSizeBytes int64 `mapstructure:"partSizeBytes"`
+	// Server-side encryption algorithm for the objects that are created: "AES256" or "aws:kms".
+	ServerSideEncryption string `mapstructure:"serverSideEncryption"`
+	// ID of the KMS key used for server-side encryption; implies "aws:kms" encryption.
+	SSEKMSKeyID string `mapstructure:"sseKMSKeyId"`
+	// Storage class of the objects that are created, such as "STANDARD_IA" or "GLACIER_IR"; if empty, the bucket's default is used.
+	StorageClass string `mapstructure:"storageClass"`
 }
 
 type createResponse struct {
@@ -166,24 +193,57 @@ func (s *AWSS3) create(ctx context.Context, req *bindings.InvokeRequest) (*bindi
 		s.logger.Debugf("s3 binding error: key not found. generating key %s", key)
 	}
 
-	var r io.Reader
+	var r io.ReadSeeker
 	if metadata.FilePath != "" {
-		r, err = os.Open(metadata.FilePath)
+		var f *os.File
+		f, err = os.Open(metadata.FilePath)
 		if err != nil {
 			return nil, fmt.Errorf("s3 binding error: file read error: %w", err)
 		}
+		defer f.Close()
+		r = f
 	} else {
 		r = strings.NewReader(utils.Unquote(req.Data))
 	}
 
-	if metadata.DecodeBase64 {
-		r = b64.NewDecoder(b64.StdEncoding, r)
+	tagging, err := objectTagging(req.Metadata[metadataTags])
+	if err != nil {
+		return nil, fmt.Errorf("s3 binding error: %w", err)
+	}
+
+	checksum, err := bindings.NewContentChecksum(req.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("s3 binding error: %w", err)
+	}
+
+	// The checksum is verified before uploading, so that objects whose content doesn't match it are never created
+	var sum string
+	if checksum != nil {
+		sum, err = checksum.ComputeAndVerify(metadata.contentReader(r))
+		if err != nil {
+			return nil, fmt.Errorf("s3 binding error: %w", err)
+		}
+		_, err = r.Seek(0, io.SeekStart)
+		if err != nil {
+			return nil, fmt.Errorf("s3 binding error: file read error: %w", err)
+		}
 	}
 
+	// The uploader reads the body in parts, uploading it with a multipart upload if it's larger than a part
 	resultUpload, err := s.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
-		Bucket: ptr.Of(metadata.Bucket),
-		Key:    ptr.Of(key),
-		Body:   r,
+		Bucket:               ptr.Of(metadata.Bucket),
+		Key:                  ptr.Of(key),
+		Body:                 metadata.contentReader(r),
+		ServerSideEncryption: metadata.serverSideEncryption(),
+		SSEKMSKeyId:          metadata.sseKMSKeyID(),
+		StorageClass:         metadata.storageClass(),
+		Tagging:              tagging,
+		CacheControl:         optionalString(req.Metadata[metadataCacheControl]),
+		ContentDisposition:   optionalString(req.Metadata[metadataContentDisposition]),
+	}, func(u *s3manager.Uploader) {
+		if metadata.PartSizeBytes > 0 {
+			u.PartSize = metadata.PartSizeBytes
+		}
 	})
 	if err != nil {
 		return nil, fmt.Errorf("s3 binding error: uploading failed: %w", err)
@@ -191,7 +251,7 @@ func (s *AWSS3) create(ctx context.Context, req *bindings.InvokeRequest) (*bindi
 
 	var presignURL string
 	if metadata.PresignTTL != "" {
-		url, presignErr := s.presignObject(metadata.Bucket, key, metadata.PresignTTL)
+		url, presignErr := s.presignObject(metadata, key, http.MethodGet)
 		if presignErr != nil {
 			return nil, fmt.Errorf("s3 binding error: %s", presignErr)
 		}
@@ -208,11 +268,16 @@ func (s *AWSS3) create(ctx context.Context, req *bindings.InvokeRequest) (*bindi
 		return nil, fmt.Errorf("s3 binding error: error marshalling create response: %w", err)
 	}
 
+	responseMetadata := map[string]string{
+		metadataKey: key,
+	}
+	if checksum != nil {
+		checksum.AddToMetadata(responseMetadata, sum)
+	}
+
 	return &bindings.InvokeResponse{
-		Data: jsonResponse,
-		Metadata: map[string]string{
-			metadataKey: key,
-		},
+		Data:     jsonResponse,
+		Metadata: responseMetadata,
 	}, nil
 }
 
@@ -231,7 +296,12 @@ func (s *AWSS3) presign(ctx context.Context, req *bindings.InvokeRequest) (*bind
 		return nil, fmt.Errorf("s3 binding error: required metadata '%s' missing", metadataPresignTTL)
 	}
 
-	url, err := s.presignObject(metadata.Bucket, key, metadata.PresignTTL)
+	method := strings.ToUpper(req.Metadata[metadataPresignMethod])
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	url, err := s.presignObject(metadata, key, method)
 	if err != nil {
 		return nil, fmt.Errorf("s3 binding error: %w", err)
 	}
@@ -248,16 +318,31 @@ func (s *AWSS3) presign(ctx context.Context, req *bindings.InvokeRequest) (*bind
 	}, nil
 }
 
-func (s *AWSS3) presignObject(bucket, key, ttl string) (string, error) {
-	d, err := time.ParseDuration(ttl)
+// presignObject returns a URL for downloading (GET) or uploading (PUT) the object, valid for the presign TTL.
+// Clients uploading with a PUT URL must send the server-side encryption headers, if any, as they're part of the signature.
+func (s *AWSS3) presignObject(metadata s3Metadata, key string, method string) (string, error) {
+	d, err := time.ParseDuration(metadata.PresignTTL)
 	if err != nil {
-		return "", fmt.Errorf("s3 binding error: cannot parse duration %s: %w", ttl, err)
+		return "", fmt.Errorf("s3 binding error: cannot parse duration %s: %w", metadata.PresignTTL, err)
 	}
 
-	objReq, _ := s.s3Client.GetObjectRequest(&s3.GetObjectInput{
-		Bucket: ptr.Of(bucket),
-		Key:    ptr.Of(key),
-	})
+	var objReq *request.Request
+	switch method {
+	case http.MethodGet:
+		objReq, _ = s.s3Client.GetObjectRequest(&s3.GetObjectInput{
+			Bucket: ptr.Of(metadata.Bucket),
+			Key:    ptr.Of(key),
+		})
+	case http.MethodPut:
+		objReq, _ = s.s3Client.PutObjectRequest(&s3.PutObjectInput{
+			Bucket:               ptr.Of(metadata.Bucket),
+			Key:                  ptr.Of(key),
+			ServerSideEncryption: metadata.serverSideEncryption(),
+			SSEKMSKeyId:          metadata.sseKMSKeyID(),
+		})
+	default:
+		return "", fmt.Errorf("s3 binding error: unsupported presign method %s", method)
+	}
 	url, err := objReq.Presign(d)
 	if err != nil {
 		return "", fmt.Errorf("s3 binding error: failed to presign URL: %w", err)
@@ -277,6 +362,11 @@ func (s *AWSS3) get(ctx context.Context, req *bindings.InvokeRequest) (*bindings
 		return nil, fmt.Errorf("s3 binding error: required metadata '%s' missing", metadataKey)
 	}
 
+	checksum, err := bindings.NewContentChecksum(req.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("s3 binding error: %w", err)
+	}
+
 	buff := &aws.WriteAtBuffer{}
 
 	_, err = s.downloader.DownloadWithContext(ctx,
@@ -290,6 +380,15 @@ func (s *AWSS3) get(ctx context.Context, req *bindings.InvokeRequest) (*bindings
 		return nil, fmt.Errorf("s3 binding error: error downloading S3 object: %w", err)
 	}
 
+	var responseMetadata map[string]string
+	if checksum != nil {
+		sum, err := checksum.ComputeAndVerify(bytes.NewReader(buff.Bytes()))
+		if err != nil {
+			return nil, fmt.Errorf("s3 binding error: %w", err)
+		}
+		responseMetadata = checksum.AddToMetadata(nil, sum)
+	}
+
 	var data []byte
 	if metadata.EncodeBase64 {
 		encoded := b64.StdEncoding.EncodeToString(buff.Bytes())
@@ -300,7 +399,7 @@ func (s *AWSS3) get(ctx context.Context, req *bindings.InvokeRequest) (*bindings
 
 	return &bindings.InvokeResponse{
 		Data:     data,
-		Metadata: nil,
+		Metadata: responseMetadata,
 	}, nil
 }
 
@@ -380,11 +479,107 @@ func (s *AWSS3) parseMetadata(md bindings.Metadata) (*s3Metadata, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = m.validate()
+	if err != nil {
+		return nil, err
+	}
 	return &m, nil
 }
 
+func (metadata *s3Metadata) validate() error {
+	if metadata.PartSizeBytes != 0 && metadata.PartSizeBytes < s3manager.MinUploadPartSize {
+		return fmt.Errorf("%s must be at least %d", metadataPartSizeBytes, s3manager.MinUploadPartSize)
+	}
+
+	if metadata.SSEKMSKeyID != "" && metadata.ServerSideEncryption == "" {
+		metadata.ServerSideEncryption = s3.ServerSideEncryptionAwsKms
+	}
+	switch metadata.ServerSideEncryption {
+	case "", s3.ServerSideEncryptionAes256:
+		if metadata.SSEKMSKeyID != "" {
+			return fmt.Errorf("%s requires %s to be %s", metadataSSEKMSKeyID, metadataServerSideEncryption, s3.ServerSideEncryptionAwsKms)
+		}
+	case s3.ServerSideEncryptionAwsKms:
+		// Nop
+	default:
+		return fmt.Errorf("unsupported %s %s", metadataServerSideEncryption, metadata.ServerSideEncryption)
+	}
+
+	if metadata.StorageClass != "" && !slices.Contains(s3.StorageClass_Values(), metadata.StorageClass) {
+		return fmt.Errorf("unsupported %s %s, must be one of: %s", metadataStorageClass, metadata.StorageClass, strings.Join(s3.StorageClass_Values(), ", "))
+	}
+
+	return nil
+}
+
+func (metadata s3Metadata) storageClass() *string {
+	return optionalString(metadata.StorageClass)
+}
+
+// objectTagging validates the tags of an object, set in the request metadata as a URL-encoded query string such as "project=foo&tier=archive", and returns them in the format expected by S3.
+func objectTagging(tags string) (*string, error) {
+	if tags == "" {
+		return nil, nil
+	}
+	values, err := url.ParseQuery(tags)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s, must be a URL-encoded query string: %w", metadataTags, err)
+	}
+	if len(values) > maxObjectTags {
+		return nil, fmt.Errorf("invalid %s, objects can have at most %d tags", metadataTags, maxObjectTags)
+	}
+	for k, v := range values {
+		if k == "" {
+			return nil, fmt.Errorf("invalid %s, tag keys must not be empty", metadataTags)
+		}
+		if len(v) > 1 {
+			return nil, fmt.Errorf("invalid %s, tag %s is set more than once", metadataTags, k)
+		}
+	}
+	return ptr.Of(values.Encode()), nil
+}
+
+// optionalString returns nil if the value is empty.
+// contentReader returns a reader of the content of an object to create, decoding it from base64 if needed.
+func (metadata s3Metadata) contentReader(r io.Reader) io.Reader {
+	if metadata.DecodeBase64 {
+		return b64.NewDecoder(b64.StdEncoding, r)
+	}
+	return r
+}
+
+func optionalString(val string) *string {
+	if val == "" {
+		return nil
+	}
+	return ptr.Of(val)
+}
+
+func (metadata s3Metadata) serverSideEncryption() *string {
+	if metadata.ServerSideEncryption == "" {
+		return nil
+	}
+	return ptr.Of(metadata.ServerSideEncryption)
+}
+
+func (metadata s3Metadata) sseKMSKeyID() *string {
+	if metadata.SSEKMSKeyID == "" {
+		return nil
+	}
+	return ptr.Of(metadata.SSEKMSKeyID)
+}
+
 func (s *AWSS3) getSession(metadata *s3Metadata) (*session.Session, error) {
-	sess, err := awsAuth.GetClient(metadata.AccessKey, metadata.SecretKey, metadata.SessionToken, metadata.Region, metadata.Endpoint)
+	sess, err := awsAuth.GetClient(awsAuth.Options{
+		AccessKey:            metadata.AccessKey,
+		SecretKey:            metadata.SecretKey,
+		SessionToken:         metadata.SessionToken,
+		Region:               metadata.Region,
+		Endpoint:             metadata.Endpoint,
+		STSEndpoint:          metadata.STSEndpoint,
+		UseFIPSEndpoint:      metadata.UseFIPSEndpoint,
+		UseDualStackEndpoint: metadata.UseDualStackEndpoint,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -412,7 +607,28 @@ func (metadata s3Metadata) mergeWithRequestMetadata(req *bindings.InvokeRequest)
 		merged.PresignTTL = val
 	}
 
-	return merged, nil
+	if val, ok := req.Metadata[metadataPartSizeBytes]; ok && val != "" {
+		partSize, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return merged, fmt.Errorf("invalid %s: %w", metadataPartSizeBytes, err)
+		}
+		merged.PartSizeBytes = partSize
+	}
+
+	if val, ok := req.Metadata[metadataServerSideEncryption]; ok && val != "" {
+		merged.ServerSideEncryption = val
+		merged.SSEKMSKeyID = ""
+	}
+
+	if val, ok := req.Metadata[metadataSSEKMSKeyID]; ok && val != "" {
+		merged.SSEKMSKeyID = val
+	}
+
+	if val, ok := req.Metadata[metadataStorageClass]; ok && val != "" {
+		merged.StorageClass = val
+	}
+
+	return merged, merged.validate()
 }
 
 // GetComponentMetadata returns the metadata of the component.
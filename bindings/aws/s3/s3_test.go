@@ -15,11 +15,17 @@ package s3
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -188,3 +194,265 @@ func TestDeleteOption(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestParseMetadataEncryptionAndPartSize(t *testing.T) {
+	s3 := AWSS3{}
+
+	t.Run("KMS key implies aws:kms", func(t *testing.T) {
+		meta, err := s3.parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"sseKMSKeyId":   "mykey",
+			"partSizeBytes": "10485760",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "aws:kms", meta.ServerSideEncryption)
+		assert.Equal(t, "mykey", meta.SSEKMSKeyID)
+		assert.Equal(t, int64(10485760), meta.PartSizeBytes)
+	})
+
+	t.Run("KMS key with AES256", func(t *testing.T) {
+		_, err := s3.parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"serverSideEncryption": "AES256",
+			"sseKMSKeyId":          "mykey",
+		}}})
+		assert.Error(t, err)
+	})
+
+	t.Run("unsupported encryption", func(t *testing.T) {
+		_, err := s3.parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"serverSideEncryption": "foo",
+		}}})
+		assert.Error(t, err)
+	})
+
+	t.Run("part size too small", func(t *testing.T) {
+		_, err := s3.parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"partSizeBytes": "1024",
+		}}})
+		assert.Error(t, err)
+	})
+
+	t.Run("request overrides", func(t *testing.T) {
+		meta, err := s3.parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"sseKMSKeyId": "mykey",
+		}}})
+		require.NoError(t, err)
+
+		merged, err := meta.mergeWithRequestMetadata(&bindings.InvokeRequest{Metadata: map[string]string{
+			"serverSideEncryption": "AES256",
+			"partSizeBytes":        "6291456",
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, "AES256", merged.ServerSideEncryption)
+		assert.Empty(t, merged.SSEKMSKeyID)
+		assert.Equal(t, int64(6291456), merged.PartSizeBytes)
+
+		_, err = meta.mergeWithRequestMetadata(&bindings.InvokeRequest{Metadata: map[string]string{
+			"partSizeBytes": "foo",
+		}})
+		assert.Error(t, err)
+	})
+}
+
+func TestPresign(t *testing.T) {
+	s3 := NewAWSS3(logger.NewLogger("s3")).(*AWSS3)
+	err := s3.Init(context.Background(), bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"accessKey":   "key",
+		"secretKey":   "secret",
+		"region":      "us-east-1",
+		"bucket":      "mybucket",
+		"sseKMSKeyId": "mykey",
+	}}})
+	require.NoError(t, err)
+
+	presign := func(md map[string]string) (*url.URL, error) {
+		res, err := s3.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: presignOperation,
+			Metadata:  md,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var presigned presignResponse
+		require.NoError(t, json.Unmarshal(res.Data, &presigned))
+		return url.Parse(presigned.PresignURL)
+	}
+
+	t.Run("GET", func(t *testing.T) {
+		u, err := presign(map[string]string{"key": "myobject", "presignTTL": "15m"})
+		require.NoError(t, err)
+		assert.Contains(t, u.Path, "myobject")
+		assert.Equal(t, "900", u.Query().Get("X-Amz-Expires"))
+		assert.NotContains(t, u.Query().Get("X-Amz-SignedHeaders"), "x-amz-server-side-encryption")
+	})
+
+	t.Run("PUT signs the encryption headers", func(t *testing.T) {
+		u, err := presign(map[string]string{"key": "myobject", "presignTTL": "15m", "presignMethod": "put"})
+		require.NoError(t, err)
+		assert.Contains(t, u.Query().Get("X-Amz-SignedHeaders"), "x-amz-server-side-encryption")
+	})
+
+	t.Run("unsupported method", func(t *testing.T) {
+		_, err := presign(map[string]string{"key": "myobject", "presignTTL": "15m", "presignMethod": "DELETE"})
+		assert.Error(t, err)
+	})
+
+	t.Run("missing TTL", func(t *testing.T) {
+		_, err := presign(map[string]string{"key": "myobject"})
+		assert.Error(t, err)
+	})
+}
+
+func TestCreateObjectOptions(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/mybucket/myobject", r.URL.Path)
+		headers = r.Header.Clone()
+		w.Header().Set("ETag", `"abc"`)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	s3 := NewAWSS3(logger.NewLogger("s3")).(*AWSS3)
+	err := s3.Init(context.Background(), bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"accessKey":      "key",
+		"secretKey":      "secret",
+		"region":         "us-east-1",
+		"bucket":         "mybucket",
+		"endpoint":       server.URL,
+		"forcePathStyle": "true",
+		"storageClass":   "STANDARD_IA",
+	}}})
+	require.NoError(t, err)
+
+	t.Run("options set", func(t *testing.T) {
+		_, err := s3.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: bindings.CreateOperation,
+			Data:      []byte("hello"),
+			Metadata: map[string]string{
+				"key":                "myobject",
+				"tags":               "project=dapr&tier=archive",
+				"cacheControl":       "max-age=3600",
+				"contentDisposition": `attachment; filename="hello.txt"`,
+				"storageClass":       "GLACIER_IR",
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "GLACIER_IR", headers.Get("X-Amz-Storage-Class"))
+		assert.Equal(t, "project=dapr&tier=archive", headers.Get("X-Amz-Tagging"))
+		assert.Equal(t, "max-age=3600", headers.Get("Cache-Control"))
+		assert.Equal(t, `attachment; filename="hello.txt"`, headers.Get("Content-Disposition"))
+	})
+
+	t.Run("component defaults", func(t *testing.T) {
+		_, err := s3.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: bindings.CreateOperation,
+			Data:      []byte("hello"),
+			Metadata:  map[string]string{"key": "myobject"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "STANDARD_IA", headers.Get("X-Amz-Storage-Class"))
+		assert.Empty(t, headers.Get("X-Amz-Tagging"))
+		assert.Empty(t, headers.Get("Cache-Control"))
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		for _, md := range []map[string]string{
+			{"key": "myobject", "storageClass": "COLD"},
+			{"key": "myobject", "tags": "a=1&a=2"},
+			{"key": "myobject", "tags": "=1"},
+			{"key": "myobject", "tags": "a=%zz"},
+			{"key": "myobject", "tags": "a=1&b=2&c=3&d=4&e=5&f=6&g=7&h=8&i=9&j=10&k=11"},
+		} {
+			_, err := s3.Invoke(context.Background(), &bindings.InvokeRequest{
+				Operation: bindings.CreateOperation,
+				Data:      []byte("hello"),
+				Metadata:  md,
+			})
+			assert.Error(t, err, md)
+		}
+	})
+}
+
+func TestContentChecksum(t *testing.T) {
+	var uploads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			uploads++
+			w.Header().Set("ETag", `"abc"`)
+			w.WriteHeader(http.StatusOK)
+		case http.MethodGet:
+			w.Header().Set("Content-Length", "5")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("hello"))
+		}
+	}))
+	defer server.Close()
+
+	s3 := NewAWSS3(logger.NewLogger("s3")).(*AWSS3)
+	err := s3.Init(context.Background(), bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"accessKey":      "key",
+		"secretKey":      "secret",
+		"region":         "us-east-1",
+		"bucket":         "mybucket",
+		"endpoint":       server.URL,
+		"forcePathStyle": "true",
+	}}})
+	require.NoError(t, err)
+
+	const helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	t.Run("create returns the checksum", func(t *testing.T) {
+		res, err := s3.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: bindings.CreateOperation,
+			Data:      []byte("aGVsbG8="),
+			Metadata: map[string]string{
+				"key":               "myobject",
+				"decodeBase64":      "true",
+				"checksumAlgorithm": "sha256",
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, helloSHA256, res.Metadata["checksum"])
+		assert.Equal(t, "sha256", res.Metadata["checksumAlgorithm"])
+		assert.Equal(t, 1, uploads)
+	})
+
+	t.Run("create with mismatching checksum isn't uploaded", func(t *testing.T) {
+		uploads = 0
+		_, err := s3.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: bindings.CreateOperation,
+			Data:      []byte("world"),
+			Metadata: map[string]string{
+				"key":      "myobject",
+				"checksum": helloSHA256,
+			},
+		})
+		require.ErrorIs(t, err, bindings.ErrChecksumMismatch)
+		assert.Equal(t, 0, uploads)
+	})
+
+	t.Run("get verifies the checksum", func(t *testing.T) {
+		res, err := s3.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: bindings.GetOperation,
+			Metadata: map[string]string{
+				"key":      "myobject",
+				"checksum": helloSHA256,
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(res.Data))
+		assert.Equal(t, helloSHA256, res.Metadata["checksum"])
+
+		_, err = s3.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: bindings.GetOperation,
+			Metadata: map[string]string{
+				"key":               "myobject",
+				"checksumAlgorithm": "md5",
+				"checksum":          "00000000000000000000000000000000",
+			},
+		})
+		require.ErrorIs(t, err, bindings.ErrChecksumMismatch)
+	})
+}
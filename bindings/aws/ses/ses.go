@@ -44,15 +44,19 @@ type AWSSES struct {
 }
 
 type sesMetadata struct {
-	Region       string `json:"region"`
-	AccessKey    string `json:"accessKey"`
-	SecretKey    string `json:"secretKey"`
-	SessionToken string `json:"sessionToken"`
-	EmailFrom    string `json:"emailFrom"`
-	EmailTo      string `json:"emailTo"`
-	Subject      string `json:"subject"`
-	EmailCc      string `json:"emailCc"`
-	EmailBcc     string `json:"emailBcc"`
+	Region               string `json:"region"`
+	Endpoint             string `json:"endpoint"`
+	STSEndpoint          string `json:"stsEndpoint"`
+	UseFIPSEndpoint      bool   `json:"useFipsEndpoint"`
+	UseDualStackEndpoint bool   `json:"useDualStackEndpoint"`
+	AccessKey            string `json:"accessKey"`
+	SecretKey            string `json:"secretKey"`
+	SessionToken         string `json:"sessionToken"`
+	EmailFrom            string `json:"emailFrom"`
+	EmailTo              string `json:"emailTo"`
+	Subject              string `json:"subject"`
+	EmailCc              string `json:"emailCc"`
+	EmailBcc             string `json:"emailBcc"`
 }
 
 // NewAWSSES creates a new AWSSES binding instance.
@@ -159,7 +163,16 @@ func (metadata sesMetadata) mergeWithRequestMetadata(req *bindings.InvokeRequest
 }
 
 func (a *AWSSES) getClient(metadata *sesMetadata) (*ses.SES, error) {
-	sess, err := awsAuth.GetClient(metadata.AccessKey, metadata.SecretKey, metadata.SessionToken, metadata.Region, "")
+	sess, err := awsAuth.GetClient(awsAuth.Options{
+		AccessKey:            metadata.AccessKey,
+		SecretKey:            metadata.SecretKey,
+		SessionToken:         metadata.SessionToken,
+		Region:               metadata.Region,
+		Endpoint:             metadata.Endpoint,
+		STSEndpoint:          metadata.STSEndpoint,
+		UseFIPSEndpoint:      metadata.UseFIPSEndpoint,
+		UseDualStackEndpoint: metadata.UseDualStackEndpoint,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("SES binding error: error creating AWS session %w", err)
 	}
@@ -29,6 +29,8 @@ func TestParseMetadata(t *testing.T) {
 		m := bindings.Metadata{}
 		m.Properties = map[string]string{
 			"region":       "myRegionForSES",
+			"endpoint":     "http://localhost:4566",
+			"stsEndpoint":  "http://localhost:4567",
 			"accessKey":    "myAccessKeyForSES",
 			"secretKey":    "mySecretKeyForSES",
 			"sessionToken": "mySessionToken",
@@ -42,6 +44,8 @@ func TestParseMetadata(t *testing.T) {
 		smtpMeta, err := r.parseMetadata(m)
 		assert.Nil(t, err)
 		assert.Equal(t, "myRegionForSES", smtpMeta.Region)
+		assert.Equal(t, "http://localhost:4566", smtpMeta.Endpoint)
+		assert.Equal(t, "http://localhost:4567", smtpMeta.STSEndpoint)
 		assert.Equal(t, "myAccessKeyForSES", smtpMeta.AccessKey)
 		assert.Equal(t, "mySecretKeyForSES", smtpMeta.SecretKey)
 		assert.Equal(t, "mySessionToken", smtpMeta.SessionToken)
@@ -36,12 +36,15 @@ type AWSSNS struct {
 }
 
 type snsMetadata struct {
-	TopicArn     string `json:"topicArn"`
-	Region       string `json:"region"`
-	Endpoint     string `json:"endpoint"`
-	AccessKey    string `json:"accessKey"`
-	SecretKey    string `json:"secretKey"`
-	SessionToken string `json:"sessionToken"`
+	TopicArn             string `json:"topicArn"`
+	Region               string `json:"region"`
+	Endpoint             string `json:"endpoint"`
+	STSEndpoint          string `json:"stsEndpoint"`
+	UseFIPSEndpoint      bool   `json:"useFipsEndpoint"`
+	UseDualStackEndpoint bool   `json:"useDualStackEndpoint"`
+	AccessKey            string `json:"accessKey"`
+	SecretKey            string `json:"secretKey"`
+	SessionToken         string `json:"sessionToken"`
 }
 
 type dataPayload struct {
@@ -81,7 +84,16 @@ func (a *AWSSNS) parseMetadata(meta bindings.Metadata) (*snsMetadata, error) {
 }
 
 func (a *AWSSNS) getClient(metadata *snsMetadata) (*sns.SNS, error) {
-	sess, err := awsAuth.GetClient(metadata.AccessKey, metadata.SecretKey, metadata.SessionToken, metadata.Region, metadata.Endpoint)
+	sess, err := awsAuth.GetClient(awsAuth.Options{
+		AccessKey:            metadata.AccessKey,
+		SecretKey:            metadata.SecretKey,
+		SessionToken:         metadata.SessionToken,
+		Region:               metadata.Region,
+		Endpoint:             metadata.Endpoint,
+		STSEndpoint:          metadata.STSEndpoint,
+		UseFIPSEndpoint:      metadata.UseFIPSEndpoint,
+		UseDualStackEndpoint: metadata.UseDualStackEndpoint,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -16,6 +16,7 @@ package sqs
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 	"sync"
 	"sync/atomic"
@@ -26,6 +27,7 @@ import (
 
 	"github.com/dapr/components-contrib/bindings"
 	awsAuth "github.com/dapr/components-contrib/internal/authentication/aws"
+	sqsqueue "github.com/dapr/components-contrib/internal/component/aws/sqs"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
@@ -42,12 +44,16 @@ type AWSSQS struct {
 }
 
 type sqsMetadata struct {
-	QueueName    string `json:"queueName"`
-	Region       string `json:"region"`
-	Endpoint     string `json:"endpoint"`
-	AccessKey    string `json:"accessKey"`
-	SecretKey    string `json:"secretKey"`
-	SessionToken string `json:"sessionToken"`
+	// Name, URL or ARN of the queue.
+	QueueName            string `json:"queueName"`
+	Region               string `json:"region"`
+	Endpoint             string `json:"endpoint"`
+	STSEndpoint          string `json:"stsEndpoint"`
+	UseFIPSEndpoint      bool   `json:"useFipsEndpoint"`
+	UseDualStackEndpoint bool   `json:"useDualStackEndpoint"`
+	AccessKey            string `json:"accessKey"`
+	SecretKey            string `json:"secretKey"`
+	SessionToken         string `json:"sessionToken"`
 }
 
 // NewAWSSQS returns a new AWS SQS instance.
@@ -70,15 +76,17 @@ func (a *AWSSQS) Init(ctx context.Context, metadata bindings.Metadata) error {
 		return err
 	}
 
-	queueName := m.QueueName
-	resultURL, err := client.GetQueueUrlWithContext(ctx, &sqs.GetQueueUrlInput{
-		QueueName: aws.String(queueName),
-	})
+	// The queue can be addressed by name, URL or ARN, to use queues of other accounts
+	queue, err := sqsqueue.ParseQueue(m.QueueName)
+	if err != nil {
+		return fmt.Errorf("invalid queueName: %w", err)
+	}
+	queueURL, err := queue.ResolveURL(ctx, client)
 	if err != nil {
 		return err
 	}
 
-	a.QueueURL = resultURL.QueueUrl
+	a.QueueURL = aws.String(queueURL)
 	a.Client = client
 
 	return nil
@@ -126,9 +134,7 @@ func (a *AWSSQS) Read(ctx context.Context, handler bindings.Handler) error {
 			})
 			if err != nil {
 				a.logger.Errorf("Unable to receive message from queue %q, %v.", *a.QueueURL, err)
-			}
-
-			if len(result.Messages) > 0 {
+			} else if len(result.Messages) > 0 {
 				for _, m := range result.Messages {
 					body := m.Body
 					res := bindings.ReadResponse{
@@ -177,7 +183,16 @@ func (a *AWSSQS) parseSQSMetadata(meta bindings.Metadata) (*sqsMetadata, error)
 }
 
 func (a *AWSSQS) getClient(metadata *sqsMetadata) (*sqs.SQS, error) {
-	sess, err := awsAuth.GetClient(metadata.AccessKey, metadata.SecretKey, metadata.SessionToken, metadata.Region, metadata.Endpoint)
+	sess, err := awsAuth.GetClient(awsAuth.Options{
+		AccessKey:            metadata.AccessKey,
+		SecretKey:            metadata.SecretKey,
+		SessionToken:         metadata.SessionToken,
+		Region:               metadata.Region,
+		Endpoint:             metadata.Endpoint,
+		STSEndpoint:          metadata.STSEndpoint,
+		UseFIPSEndpoint:      metadata.UseFIPSEndpoint,
+		UseDualStackEndpoint: metadata.UseDualStackEndpoint,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -14,6 +14,7 @@ limitations under the License.
 package blobstorage
 
 import (
+	"bytes"
 	"context"
 	b64 "encoding/base64"
 	"encoding/json"
@@ -125,6 +126,14 @@ func (a *AzureBlobStorage) create(ctx context.Context, req *bindings.InvokeReque
 		blobName = id.String()
 	}
 
+	checksum, err := bindings.NewContentChecksum(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	// The checksum options aren't stored as metadata of the blob
+	delete(req.Metadata, bindings.ChecksumAlgorithmMetadataKey)
+	delete(req.Metadata, bindings.ChecksumMetadataKey)
+
 	blobHTTPHeaders, err := storageinternal.CreateBlobHTTPHeadersFromRequest(req.Metadata, nil, a.logger)
 	if err != nil {
 		return nil, err
@@ -143,6 +152,15 @@ func (a *AzureBlobStorage) create(ctx context.Context, req *bindings.InvokeReque
 		req.Data = decoded
 	}
 
+	// The checksum is verified before uploading, so that blobs whose content doesn't match it are never created
+	var sum string
+	if checksum != nil {
+		sum, err = checksum.ComputeAndVerify(bytes.NewReader(req.Data))
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	uploadOptions := azblob.UploadBufferOptions{
 		Metadata:                storageinternal.SanitizeMetadata(a.logger, req.Metadata),
 		HTTPHeaders:             &blobHTTPHeaders,
@@ -167,6 +185,9 @@ func (a *AzureBlobStorage) create(ctx context.Context, req *bindings.InvokeReque
 	createResponseMetadata := map[string]string{
 		"blobName": blobName,
 	}
+	if checksum != nil {
+		checksum.AddToMetadata(createResponseMetadata, sum)
+	}
 
 	return &bindings.InvokeResponse{
 		Data:     b,
@@ -182,6 +203,11 @@ func (a *AzureBlobStorage) get(ctx context.Context, req *bindings.InvokeRequest)
 		return nil, ErrMissingBlobName
 	}
 
+	checksum, err := bindings.NewContentChecksum(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
 	downloadOptions := azblob.DownloadStreamOptions{
 		AccessConditions: &blob.AccessConditions{},
 	}
@@ -197,6 +223,14 @@ func (a *AzureBlobStorage) get(ctx context.Context, req *bindings.InvokeRequest)
 		return nil, fmt.Errorf("error reading az blob: %w", err)
 	}
 
+	var sum string
+	if checksum != nil {
+		sum, err = checksum.ComputeAndVerify(bytes.NewReader(blobData))
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var metadata map[string]string
 	fetchMetadata, err := req.GetMetadataAsBool(metadataKeyIncludeMetadata)
 	if err != nil {
@@ -224,6 +258,10 @@ func (a *AzureBlobStorage) get(ctx context.Context, req *bindings.InvokeRequest)
 		}
 	}
 
+	if checksum != nil {
+		metadata = checksum.AddToMetadata(metadata, sum)
+	}
+
 	return &bindings.InvokeResponse{
 		Data:     blobData,
 		Metadata: metadata,
@@ -0,0 +1,314 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dataexplorer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	kustoerrors "github.com/Azure/azure-kusto-go/kusto/data/errors"
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/data/value"
+	"github.com/Azure/azure-kusto-go/kusto/ingest"
+	"github.com/Azure/azure-kusto-go/kusto/kql"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+
+	"github.com/dapr/components-contrib/bindings"
+	azauth "github.com/dapr/components-contrib/internal/authentication/azure"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// QueryOperation runs a KQL query and returns the rows of the primary result.
+	QueryOperation bindings.OperationKind = "query"
+
+	ingestionModeQueued    = "queued"
+	ingestionModeStreaming = "streaming"
+
+	defaultDataFormat = "json"
+
+	// Invoke metadata keys.
+	tableKey            = "table"
+	dataFormatKey       = "dataFormat"
+	mappingReferenceKey = "mappingReference"
+	ingestionModeKey    = "ingestionMode"
+
+	// Response metadata keys.
+	rowCountKey = "rowCount"
+)
+
+// Metadata keys.
+// Azure AD credentials are parsed separately and not listed here.
+type dataExplorerMetadata struct {
+	// URL of the cluster, for example "https://mycluster.westeurope.kusto.windows.net".
+	// Queued ingestion uses the data management endpoint of the cluster, which has the "ingest-" prefix.
+	ClusterURL string `mapstructure:"clusterURL"`
+	Database   string `mapstructure:"database"`
+	// Default table for ingestion; can be overridden in the request metadata.
+	Table string `mapstructure:"table"`
+	// "queued" (default) or "streaming"; can be overridden in the request metadata.
+	IngestionMode string `mapstructure:"ingestionMode"`
+	// Format of the ingested data, for example "json", "multijson" or "csv"; can be overridden in the request metadata.
+	DataFormat string `mapstructure:"dataFormat"`
+	// Name of the ingestion mapping of the table; can be overridden in the request metadata.
+	MappingReference string `mapstructure:"mappingReference"`
+	// If true, queued ingestion doesn't wait for the data to be aggregated in batches.
+	FlushImmediately bool `mapstructure:"flushImmediately"`
+}
+
+// DataExplorer is an output binding for Azure Data Explorer (Kusto).
+type DataExplorer struct {
+	metadata   dataExplorerMetadata
+	credential azcore.TokenCredential
+	client     *kusto.Client
+
+	// Ingestors are created for each ingestion mode and table on first use, and reused.
+	ingestors     map[ingestorKey]ingest.Ingestor
+	ingestorsLock sync.Mutex
+
+	// HTTP client used by the Kusto client; if nil, the default client of the SDK is used.
+	httpClient *http.Client
+	logger     logger.Logger
+}
+
+// NewDataExplorer creates a new output binding for Azure Data Explorer.
+func NewDataExplorer(logger logger.Logger) bindings.OutputBinding {
+	return &DataExplorer{
+		logger:    logger,
+		ingestors: map[ingestorKey]ingest.Ingestor{},
+	}
+}
+
+// Init is responsible for initializing the binding based on the metadata.
+func (d *DataExplorer) Init(_ context.Context, meta bindings.Metadata) error {
+	m, err := parseMetadata(meta.Properties)
+	if err != nil {
+		return err
+	}
+	d.metadata = m
+
+	settings, err := azauth.NewEnvironmentSettings(meta.Properties)
+	if err != nil {
+		return err
+	}
+	d.credential, err = settings.GetTokenCredential()
+	if err != nil {
+		return err
+	}
+
+	return d.connect()
+}
+
+// connect creates the Kusto client, authenticated with the Azure AD credential.
+func (d *DataExplorer) connect() error {
+	kcsb := kusto.NewConnectionStringBuilder(d.metadata.ClusterURL).WithTokenCredential(d.credential)
+	kcsb.SetConnectorDetails("Dapr", logger.DaprVersion, "", "", false, "")
+
+	var opts []kusto.Option
+	if d.httpClient != nil {
+		opts = append(opts, kusto.WithHttpClient(d.httpClient))
+	}
+	client, err := kusto.New(kcsb, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create the Kusto client: %w", err)
+	}
+	d.client = client
+	return nil
+}
+
+func parseMetadata(md map[string]string) (dataExplorerMetadata, error) {
+	m := dataExplorerMetadata{
+		IngestionMode: ingestionModeQueued,
+		DataFormat:    defaultDataFormat,
+	}
+	err := metadata.DecodeMetadata(md, &m)
+	if err != nil {
+		return m, err
+	}
+
+	if m.ClusterURL == "" {
+		return m, errors.New("missing clusterURL")
+	}
+	m.ClusterURL = strings.TrimSuffix(m.ClusterURL, "/")
+	clusterURL, err := url.Parse(m.ClusterURL)
+	if err != nil || clusterURL.Host == "" {
+		return m, fmt.Errorf("invalid clusterURL '%s'", m.ClusterURL)
+	}
+
+	if m.Database == "" {
+		return m, errors.New("missing database")
+	}
+	if err = validateIngestionMode(m.IngestionMode); err != nil {
+		return m, err
+	}
+	if _, err = parseDataFormat(m.DataFormat); err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+func validateIngestionMode(mode string) error {
+	if mode != ingestionModeQueued && mode != ingestionModeStreaming {
+		return fmt.Errorf("invalid ingestionMode '%s': supported values are '%s' and '%s'", mode, ingestionModeQueued, ingestionModeStreaming)
+	}
+	return nil
+}
+
+// Operations returns the list of operations supported by the binding.
+func (d *DataExplorer) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{bindings.CreateOperation, QueryOperation}
+}
+
+// Invoke ingests data in a table, or runs a query.
+func (d *DataExplorer) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	switch req.Operation { //nolint:exhaustive
+	case bindings.CreateOperation:
+		return nil, d.ingest(ctx, req)
+	case QueryOperation:
+		return d.query(ctx, req)
+	default:
+		return nil, fmt.Errorf("unsupported operation %s", req.Operation)
+	}
+}
+
+func (d *DataExplorer) query(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	if len(req.Data) == 0 {
+		return nil, errors.New("missing query in the request data")
+	}
+
+	// The query comes from the caller, so it can't be built from constants only
+	iter, err := d.client.Query(ctx, d.metadata.Database, kql.New("").AddUnsafe(string(req.Data)))
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer iter.Stop()
+
+	records := []map[string]interface{}{}
+	err = iter.DoOnRowOrError(func(row *table.Row, inlineErr *kustoerrors.Error) error {
+		if inlineErr != nil {
+			return inlineErr
+		}
+		records = append(records, rowRecord(row))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return nil, err
+	}
+	return &bindings.InvokeResponse{
+		Data: data,
+		Metadata: map[string]string{
+			rowCountKey: strconv.Itoa(len(records)),
+		},
+	}, nil
+}
+
+// rowRecord returns a row as a map of column names to values.
+func rowRecord(row *table.Row) map[string]interface{} {
+	res := make(map[string]interface{}, len(row.ColumnTypes))
+	for i, col := range row.ColumnTypes {
+		if i < len(row.Values) {
+			res[col.Name] = jsonValue(row.Values[i])
+		}
+	}
+	return res
+}
+
+// jsonValue returns the value of a column as a value that can be encoded to JSON; null values are returned as nil.
+func jsonValue(v value.Kusto) interface{} {
+	switch v := v.(type) {
+	case value.Bool:
+		if v.Valid {
+			return v.Value
+		}
+	case value.Int:
+		if v.Valid {
+			return v.Value
+		}
+	case value.Long:
+		if v.Valid {
+			return v.Value
+		}
+	case value.Real:
+		if v.Valid {
+			return v.Value
+		}
+	case value.Decimal:
+		if v.Valid {
+			return json.Number(v.Value)
+		}
+	case value.String:
+		if v.Valid {
+			return v.Value
+		}
+	case value.Dynamic:
+		if v.Valid && json.Valid(v.Value) {
+			return json.RawMessage(v.Value)
+		} else if v.Valid {
+			// Raw messages that aren't valid JSON would make the whole response fail to encode
+			return string(v.Value)
+		}
+	case value.DateTime:
+		if v.Valid {
+			return v.Value
+		}
+	case value.Timespan:
+		if v.Valid {
+			return v.Value.String()
+		}
+	case value.GUID:
+		if v.Valid {
+			return v.Value.String()
+		}
+	}
+	return nil
+}
+
+// Close closes the ingestors and the Kusto client.
+func (d *DataExplorer) Close() error {
+	d.ingestorsLock.Lock()
+	defer d.ingestorsLock.Unlock()
+
+	errs := make([]error, 0, len(d.ingestors)+1)
+	for key, ingestor := range d.ingestors {
+		errs = append(errs, ingestor.Close())
+		delete(d.ingestors, key)
+	}
+	if d.client != nil {
+		errs = append(errs, d.client.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (d *DataExplorer) GetComponentMetadata() map[string]string {
+	metadataStruct := dataExplorerMetadata{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.BindingType)
+	return metadataInfo
+}
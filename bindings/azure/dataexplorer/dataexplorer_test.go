@@ -0,0 +1,471 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dataexplorer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/kusto/data/value"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	testClusterHost   = "mycluster.westeurope.kusto.windows.net"
+	testIngestionHost = "ingest-mycluster.westeurope.kusto.windows.net"
+	testBlobHost      = "account.blob.core.windows.net"
+	testQueueHost     = "account.queue.core.windows.net"
+)
+
+type fakeCredential struct{}
+
+func (fakeCredential) GetToken(_ context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "token:" + strings.Join(opts.Scopes, ",")}, nil
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m, err := parseMetadata(map[string]string{
+			"clusterURL": "https://mycluster.westeurope.kusto.windows.net/",
+			"database":   "mydb",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "https://mycluster.westeurope.kusto.windows.net", m.ClusterURL)
+		assert.Equal(t, ingestionModeQueued, m.IngestionMode)
+		assert.Equal(t, "json", m.DataFormat)
+	})
+
+	t.Run("all values", func(t *testing.T) {
+		m, err := parseMetadata(map[string]string{
+			"clusterURL":       "https://mycluster.westeurope.kusto.windows.net",
+			"database":         "mydb",
+			"table":            "mytable",
+			"ingestionMode":    "streaming",
+			"dataFormat":       "csv",
+			"mappingReference": "mymapping",
+			"flushImmediately": "true",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "mytable", m.Table)
+		assert.Equal(t, ingestionModeStreaming, m.IngestionMode)
+		assert.Equal(t, "csv", m.DataFormat)
+		assert.Equal(t, "mymapping", m.MappingReference)
+		assert.True(t, m.FlushImmediately)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := parseMetadata(map[string]string{"database": "mydb"})
+		require.ErrorContains(t, err, "missing clusterURL")
+
+		_, err = parseMetadata(map[string]string{"clusterURL": "mycluster", "database": "mydb"})
+		require.ErrorContains(t, err, "invalid clusterURL")
+
+		_, err = parseMetadata(map[string]string{"clusterURL": "https://mycluster"})
+		require.ErrorContains(t, err, "missing database")
+
+		_, err = parseMetadata(map[string]string{"clusterURL": "https://mycluster", "database": "mydb", "ingestionMode": "batch"})
+		require.ErrorContains(t, err, "invalid ingestionMode")
+
+		_, err = parseMetadata(map[string]string{"clusterURL": "https://mycluster", "database": "mydb", "dataFormat": "xml"})
+		require.ErrorContains(t, err, "invalid dataFormat")
+	})
+}
+
+// streamedRequest is a request to the streaming ingestion API of the cluster.
+type streamedRequest struct {
+	path  string
+	query url.Values
+	data  []byte
+}
+
+// fakeCluster serves the REST APIs of a cluster, its data management endpoint and its storage.
+// The client connects to it whatever the host of the request, which is used to tell the endpoints apart.
+type fakeCluster struct {
+	server *httptest.Server
+
+	lock     sync.Mutex
+	streamed []streamedRequest
+	blocks   map[string][]byte
+	blobs    map[string][]byte
+	messages []map[string]interface{}
+}
+
+func newFakeCluster(t *testing.T) *fakeCluster {
+	c := &fakeCluster{
+		blocks: map[string][]byte{},
+		blobs:  map[string][]byte{},
+	}
+	c.server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			body = gunzip(t, body)
+		}
+		switch r.Host {
+		case testClusterHost:
+			c.serveCluster(t, w, r, body)
+		case testIngestionHost:
+			c.serveIngestion(t, w, r, body)
+		case testBlobHost:
+			c.serveBlob(t, w, r, body)
+		case testQueueHost:
+			c.serveQueue(t, w, r, body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(c.server.Close)
+	return c
+}
+
+// client returns an HTTP client that sends all requests to the fake cluster.
+func (c *fakeCluster) client() *http.Client {
+	addr := c.server.Listener.Addr().String()
+	dialer := &net.Dialer{}
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, addr)
+			},
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true, //nolint:gosec
+			},
+		},
+	}
+}
+
+func (c *fakeCluster) serveCluster(t *testing.T, w http.ResponseWriter, r *http.Request, body []byte) {
+	switch {
+	case r.URL.Path == "/v2/rest/query":
+		assert.Equal(t, "Bearer token:https://kusto.kusto.windows.net/.default", r.Header.Get("Authorization"))
+		var cmd map[string]interface{}
+		_ = json.Unmarshal(body, &cmd)
+		if cmd["db"] != "mydb" || cmd["csl"] != "mytable | take 2" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"code":"BadRequest","message":"bad query","@message":"bad query"}}`))
+			return
+		}
+		// The client requires the frame type to be the first property of each frame, like in the responses of the service
+		w.Write([]byte(`[{"FrameType":"DataSetHeader","IsProgressive":false,"Version":"v2.0"},
+{"FrameType":"DataTable","TableId":0,"TableKind":"PrimaryResult","TableName":"PrimaryResult",` +
+			`"Columns":[{"ColumnName":"name","ColumnType":"string"},{"ColumnName":"value","ColumnType":"long"},{"ColumnName":"props","ColumnType":"dynamic"}],` +
+			`"Rows":[["a",1,{"x":1}],["b",2,null]]},
+{"FrameType":"DataSetCompletion","HasErrors":false,"Cancelled":false}]`))
+	case strings.HasPrefix(r.URL.Path, "/v1/rest/ingest/"):
+		c.streamed = append(c.streamed, streamedRequest{
+			path:  r.URL.Path,
+			query: r.URL.Query(),
+			data:  body,
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (c *fakeCluster) serveIngestion(t *testing.T, w http.ResponseWriter, r *http.Request, body []byte) {
+	if r.URL.Path != "/v1/rest/mgmt" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var cmd map[string]interface{}
+	_ = json.Unmarshal(body, &cmd)
+	switch cmd["csl"] {
+	case ".get ingestion resources":
+		writeTable(w, []string{"ResourceTypeName", "StorageRoot"}, [][]interface{}{
+			{"TempStorage", "https://" + testBlobHost + "/container?sig=secret"},
+			{"SecuredReadyForAggregationQueue", "https://" + testQueueHost + "/queue?sig=secret"},
+			{"FailedIngestionsQueue", "https://" + testQueueHost + "/failed?sig=secret"},
+		})
+	case ".get kusto identity token":
+		writeTable(w, []string{"AuthorizationContext"}, [][]interface{}{{"authcontext"}})
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (c *fakeCluster) serveBlob(t *testing.T, w http.ResponseWriter, r *http.Request, body []byte) {
+	if r.Method != http.MethodPut || !strings.HasPrefix(r.URL.Path, "/container/") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	assert.Equal(t, "secret", r.URL.Query().Get("sig"))
+	switch r.URL.Query().Get("comp") {
+	case "block":
+		c.blocks[r.URL.Query().Get("blockid")] = body
+	case "blocklist":
+		var list struct {
+			Latest []string `xml:"Latest"`
+		}
+		require.NoError(t, xml.Unmarshal(body, &list))
+		var blob []byte
+		for _, id := range list.Latest {
+			blob = append(blob, c.blocks[id]...)
+		}
+		c.blobs[r.URL.Path] = gunzip(t, blob)
+	default:
+		c.blobs[r.URL.Path] = gunzip(t, body)
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (c *fakeCluster) serveQueue(t *testing.T, w http.ResponseWriter, r *http.Request, body []byte) {
+	if r.Method != http.MethodPost || r.URL.Path != "/queue/messages" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	assert.Equal(t, "secret", r.URL.Query().Get("sig"))
+	var qm struct {
+		MessageText string `xml:"MessageText"`
+	}
+	require.NoError(t, xml.Unmarshal(body, &qm))
+	raw, err := base64.StdEncoding.DecodeString(qm.MessageText)
+	require.NoError(t, err)
+	var msg map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &msg))
+	c.messages = append(c.messages, msg)
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?><QueueMessagesList><QueueMessage><MessageId>1</MessageId>` +
+		`<InsertionTime>Mon, 02 Jan 2023 00:00:00 GMT</InsertionTime><ExpirationTime>Mon, 09 Jan 2023 00:00:00 GMT</ExpirationTime>` +
+		`<PopReceipt>receipt</PopReceipt><TimeNextVisible>Mon, 02 Jan 2023 00:00:00 GMT</TimeNextVisible></QueueMessage></QueueMessagesList>`))
+}
+
+func gunzip(t *testing.T, data []byte) []byte {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	res, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return res
+}
+
+func writeTable(w http.ResponseWriter, columns []string, rows [][]interface{}) {
+	cols := make([]map[string]string, len(columns))
+	for i, c := range columns {
+		cols[i] = map[string]string{"ColumnName": c, "DataType": "String", "ColumnType": "string"}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"Tables": []interface{}{
+			map[string]interface{}{
+				"TableName": "Table_0",
+				"Columns":   cols,
+				"Rows":      rows,
+			},
+		},
+	})
+}
+
+func newTestBinding(t *testing.T, c *fakeCluster, props map[string]string) *DataExplorer {
+	md := map[string]string{
+		"clusterURL": "https://" + testClusterHost,
+		"database":   "mydb",
+		"table":      "mytable",
+	}
+	for k, v := range props {
+		md[k] = v
+	}
+	m, err := parseMetadata(md)
+	require.NoError(t, err)
+
+	d := NewDataExplorer(logger.NewLogger("test")).(*DataExplorer)
+	d.metadata = m
+	d.credential = fakeCredential{}
+	d.httpClient = c.client()
+	require.NoError(t, d.connect())
+	t.Cleanup(func() {
+		d.Close()
+	})
+	return d
+}
+
+func TestIngestQueued(t *testing.T) {
+	c := newFakeCluster(t)
+	d := newTestBinding(t, c, map[string]string{
+		"mappingReference": "mymapping",
+		"flushImmediately": "true",
+	})
+
+	res, err := d.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: bindings.CreateOperation,
+		Data:      []byte(`{"name":"a"}`),
+	})
+	require.NoError(t, err)
+	assert.Nil(t, res)
+
+	_, err = d.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: bindings.CreateOperation,
+		Data:      []byte("a,1"),
+		Metadata: map[string]string{
+			tableKey:            "othertable",
+			dataFormatKey:       "csv",
+			mappingReferenceKey: "othermapping",
+		},
+	})
+	require.NoError(t, err)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	require.Len(t, c.messages, 2)
+	require.Len(t, c.blobs, 2)
+	sort.Slice(c.messages, func(i, j int) bool {
+		return c.messages[i]["TableName"].(string) < c.messages[j]["TableName"].(string)
+	})
+
+	msg := c.messages[0]
+	assert.Equal(t, "mydb", msg["DatabaseName"])
+	assert.Equal(t, "mytable", msg["TableName"])
+	assert.Equal(t, true, msg["FlushImmediately"])
+	props := msg["AdditionalProperties"].(map[string]interface{})
+	assert.Equal(t, "authcontext", props["authorizationContext"])
+	assert.Equal(t, "json", props["format"])
+	assert.Equal(t, "mymapping", props["ingestionMappingReference"])
+	blobURL, err := url.Parse(msg["BlobPath"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, testBlobHost, blobURL.Host)
+	assert.Equal(t, []byte(`{"name":"a"}`), c.blobs[blobURL.Path])
+
+	msg = c.messages[1]
+	assert.Equal(t, "othertable", msg["TableName"])
+	props = msg["AdditionalProperties"].(map[string]interface{})
+	assert.Equal(t, "csv", props["format"])
+	assert.Equal(t, "othermapping", props["ingestionMappingReference"])
+
+	// Ingestors are reused for each table
+	assert.Len(t, d.ingestors, 2)
+}
+
+func TestIngestStreaming(t *testing.T) {
+	c := newFakeCluster(t)
+	d := newTestBinding(t, c, map[string]string{
+		"ingestionMode": "streaming",
+	})
+
+	res, err := d.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: bindings.CreateOperation,
+		Data:      []byte(`{"name":"a"}`),
+		Metadata: map[string]string{
+			mappingReferenceKey: "mymapping",
+		},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, res)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	require.Len(t, c.streamed, 1)
+	r := c.streamed[0]
+	assert.Equal(t, "/v1/rest/ingest/mydb/mytable", r.path)
+	assert.Equal(t, "Json", r.query.Get("streamFormat"))
+	assert.Equal(t, "mymapping", r.query.Get("mappingName"))
+	assert.Equal(t, []byte(`{"name":"a"}`), r.data)
+	assert.Empty(t, c.messages)
+}
+
+func TestIngestErrors(t *testing.T) {
+	c := newFakeCluster(t)
+	d := newTestBinding(t, c, map[string]string{"table": ""})
+
+	_, err := d.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: bindings.CreateOperation,
+		Data:      []byte(`{}`),
+	})
+	require.ErrorContains(t, err, "missing table")
+
+	_, err = d.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: bindings.CreateOperation,
+		Metadata:  map[string]string{tableKey: "mytable"},
+	})
+	require.ErrorContains(t, err, "no data to ingest")
+
+	_, err = d.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: bindings.CreateOperation,
+		Data:      []byte(`{}`),
+		Metadata:  map[string]string{tableKey: "mytable", ingestionModeKey: "batch"},
+	})
+	require.ErrorContains(t, err, "invalid ingestionMode")
+
+	_, err = d.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: bindings.CreateOperation,
+		Data:      []byte(`{}`),
+		Metadata:  map[string]string{tableKey: "mytable", dataFormatKey: "xml"},
+	})
+	require.ErrorContains(t, err, "invalid dataFormat")
+}
+
+func TestQuery(t *testing.T) {
+	c := newFakeCluster(t)
+	d := newTestBinding(t, c, nil)
+
+	res, err := d.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: QueryOperation,
+		Data:      []byte("mytable | take 2"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "2", res.Metadata[rowCountKey])
+	assert.JSONEq(t, `[{"name":"a","value":1,"props":{"x":1}},{"name":"b","value":2,"props":null}]`, string(res.Data))
+
+	_, err = d.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: QueryOperation,
+		Data:      []byte("mytable | take 3"),
+	})
+	require.ErrorContains(t, err, "bad query")
+
+	_, err = d.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: QueryOperation,
+	})
+	require.ErrorContains(t, err, "missing query")
+}
+
+func TestJSONValue(t *testing.T) {
+	t.Run("valid dynamic value", func(t *testing.T) {
+		res, err := json.Marshal(jsonValue(value.Dynamic{Value: []byte(`{"x":[1,2]}`), Valid: true}))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"x":[1,2]}`, string(res))
+	})
+
+	t.Run("invalid dynamic value is returned as a string", func(t *testing.T) {
+		res, err := json.Marshal(jsonValue(value.Dynamic{Value: []byte(`{"x":`), Valid: true}))
+		require.NoError(t, err)
+		assert.Equal(t, `"{\"x\":"`, string(res))
+	})
+
+	t.Run("null dynamic value", func(t *testing.T) {
+		res, err := json.Marshal(jsonValue(value.Dynamic{}))
+		require.NoError(t, err)
+		assert.Equal(t, `null`, string(res))
+	})
+}
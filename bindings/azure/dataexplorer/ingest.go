@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dataexplorer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-kusto-go/kusto/ingest"
+
+	"github.com/dapr/components-contrib/bindings"
+)
+
+// Data formats that can be ingested, by name.
+var dataFormats = []ingest.DataFormat{
+	ingest.AVRO, ingest.CSV, ingest.JSON, ingest.MultiJSON, ingest.ORC, ingest.Parquet, ingest.PSV, ingest.Raw,
+	ingest.SCSV, ingest.SOHSV, ingest.SStream, ingest.TSV, ingest.TSVE, ingest.TXT, ingest.W3CLogFile, ingest.SingleJSON,
+}
+
+func parseDataFormat(name string) (ingest.DataFormat, error) {
+	for _, f := range dataFormats {
+		if strings.EqualFold(f.String(), name) {
+			return f, nil
+		}
+	}
+	return ingest.DFUnknown, fmt.Errorf("invalid dataFormat '%s'", name)
+}
+
+// ingestorKey identifies the ingestor of a table for an ingestion mode.
+type ingestorKey struct {
+	mode  string
+	table string
+}
+
+func (d *DataExplorer) ingest(ctx context.Context, req *bindings.InvokeRequest) error {
+	table := d.metadata.Table
+	dataFormat := d.metadata.DataFormat
+	mappingReference := d.metadata.MappingReference
+	mode := d.metadata.IngestionMode
+	if val, ok := req.Metadata[tableKey]; ok && val != "" {
+		table = val
+	}
+	if val, ok := req.Metadata[dataFormatKey]; ok && val != "" {
+		dataFormat = val
+	}
+	if val, ok := req.Metadata[mappingReferenceKey]; ok && val != "" {
+		mappingReference = val
+	}
+	if val, ok := req.Metadata[ingestionModeKey]; ok && val != "" {
+		mode = val
+		if err := validateIngestionMode(mode); err != nil {
+			return err
+		}
+	}
+	if table == "" {
+		return fmt.Errorf("missing table: set it in the component metadata or in the '%s' request metadata", tableKey)
+	}
+	if len(req.Data) == 0 {
+		return errors.New("no data to ingest")
+	}
+	format, err := parseDataFormat(dataFormat)
+	if err != nil {
+		return err
+	}
+
+	// The ingestion mapping sets the format of the data too
+	var opts []ingest.FileOption
+	if mappingReference != "" {
+		opts = append(opts, ingest.IngestionMappingRef(mappingReference, format))
+	} else {
+		opts = append(opts, ingest.FileFormat(format))
+	}
+	if mode == ingestionModeQueued && d.metadata.FlushImmediately {
+		opts = append(opts, ingest.FlushImmediately())
+	}
+
+	ingestor, err := d.getIngestor(mode, table)
+	if err != nil {
+		return err
+	}
+	_, err = ingestor.FromReader(ctx, bytes.NewReader(req.Data), opts...)
+	if err != nil {
+		return fmt.Errorf("%s ingestion in table %s failed: %w", mode, table, err)
+	}
+	return nil
+}
+
+// getIngestor returns the ingestor of a table for an ingestion mode, creating it if needed.
+// Queued ingestion uploads the data to the storage of the data management endpoint, then queues it to be ingested by the cluster.
+// Streaming ingestion sends the data to the cluster directly, and must be enabled on the table or database.
+func (d *DataExplorer) getIngestor(mode string, table string) (ingest.Ingestor, error) {
+	d.ingestorsLock.Lock()
+	defer d.ingestorsLock.Unlock()
+
+	key := ingestorKey{mode: mode, table: table}
+	if ingestor, ok := d.ingestors[key]; ok {
+		return ingestor, nil
+	}
+
+	var (
+		ingestor ingest.Ingestor
+		err      error
+	)
+	if mode == ingestionModeStreaming {
+		ingestor, err = ingest.NewStreaming(d.client, d.metadata.Database, table)
+	} else {
+		ingestor, err = ingest.New(d.client, d.metadata.Database, table)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the %s ingestor for table %s: %w", mode, table, err)
+	}
+	d.ingestors[key] = ingestor
+	return ingestor, nil
+}
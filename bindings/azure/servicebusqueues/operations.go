@@ -0,0 +1,339 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebusqueues
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	servicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	sbadmin "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus/admin"
+	"github.com/google/uuid"
+
+	"github.com/dapr/components-contrib/bindings"
+	impl "github.com/dapr/components-contrib/internal/component/azure/servicebus"
+	"github.com/dapr/components-contrib/internal/utils"
+	"github.com/dapr/kit/ptr"
+)
+
+const (
+	peekLockOperation                  bindings.OperationKind = "peekLock"
+	completeMessageOperation           bindings.OperationKind = "completeMessage"
+	deadLetterMessageOperation         bindings.OperationKind = "deadLetterMessage"
+	getQueueRuntimePropertiesOperation bindings.OperationKind = "getQueueRuntimeProperties"
+	createQueueOperation               bindings.OperationKind = "createQueue"
+	deleteQueueOperation               bindings.OperationKind = "deleteQueue"
+
+	// Request metadata keys
+	maxMessagesKey                = "maxMessages"
+	waitTimeInSecKey              = "waitTimeInSec"
+	lockTokenKey                  = "lockToken"
+	deadLetterReasonKey           = "deadLetterReason"
+	deadLetterErrorDescriptionKey = "deadLetterErrorDescription"
+	queueNameKey                  = "queueName"
+
+	defaultPeekLockWaitTimeInSec = 5
+)
+
+// lockedMessage is a message returned by the peekLock operation.
+type lockedMessage struct {
+	MessageID      string            `json:"messageId"`
+	LockToken      string            `json:"lockToken"`
+	SequenceNumber *int64            `json:"sequenceNumber,omitempty"`
+	DeliveryCount  uint32            `json:"deliveryCount"`
+	LockedUntil    *time.Time        `json:"lockedUntil,omitempty"`
+	Data           []byte            `json:"data"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+}
+
+// queueRuntimeProperties is the response of the getQueueRuntimeProperties operation.
+type queueRuntimeProperties struct {
+	SizeInBytes                    int64     `json:"sizeInBytes"`
+	CreatedAt                      time.Time `json:"createdAt"`
+	UpdatedAt                      time.Time `json:"updatedAt"`
+	AccessedAt                     time.Time `json:"accessedAt"`
+	TotalMessageCount              int64     `json:"totalMessageCount"`
+	ActiveMessageCount             int32     `json:"activeMessageCount"`
+	DeadLetterMessageCount         int32     `json:"deadLetterMessageCount"`
+	ScheduledMessageCount          int32     `json:"scheduledMessageCount"`
+	TransferDeadLetterMessageCount int32     `json:"transferDeadLetterMessageCount"`
+	TransferMessageCount           int32     `json:"transferMessageCount"`
+}
+
+// peekLock receives messages in peek-lock mode, returning them with their lock tokens.
+// Messages are locked until they're settled with completeMessage or deadLetterMessage, or until the lock expires.
+func (a *AzureServiceBusQueues) peekLock(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	if a.metadata.RequireSessions {
+		return nil, errors.New("peekLock is not supported for queues that require sessions")
+	}
+
+	maxMessages, err := positiveIntFromMetadata(req.Metadata, maxMessagesKey, 1)
+	if err != nil {
+		return nil, err
+	}
+	waitTimeInSec, err := positiveIntFromMetadata(req.Metadata, waitTimeInSecKey, defaultPeekLockWaitTimeInSec)
+	if err != nil {
+		return nil, err
+	}
+
+	receiver, err := a.getOpsReceiver()
+	if err != nil {
+		return nil, err
+	}
+
+	// ReceiveMessages blocks until at least one message is received, so the wait is bounded by a timeout
+	receiveCtx, receiveCancel := context.WithTimeout(ctx, time.Duration(waitTimeInSec)*time.Second)
+	msgs, err := receiver.ReceiveMessages(receiveCtx, maxMessages, nil)
+	receiveCancel()
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		if impl.IsNetworkError(err) {
+			a.closeOpsReceiver()
+		}
+		return nil, fmt.Errorf("failed to receive messages: %w", err)
+	}
+
+	res := make([]lockedMessage, len(msgs))
+	for i, msg := range msgs {
+		res[i] = lockedMessage{
+			MessageID:      msg.MessageID,
+			LockToken:      uuid.UUID(msg.LockToken).String(),
+			SequenceNumber: msg.SequenceNumber,
+			DeliveryCount:  msg.DeliveryCount,
+			LockedUntil:    msg.LockedUntil,
+			Data:           msg.Body,
+			Metadata:       messageMetadata(msg),
+		}
+	}
+
+	return jsonResponse(res)
+}
+
+// completeMessage completes a message locked by peekLock, deleting it from the queue.
+func (a *AzureServiceBusQueues) completeMessage(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	msg, err := lockedMessageFromMetadata(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	receiver, err := a.getOpsReceiver()
+	if err != nil {
+		return nil, err
+	}
+
+	err = receiver.CompleteMessage(ctx, msg, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete message: %w", err)
+	}
+	return nil, nil
+}
+
+// deadLetterMessage moves a message locked by peekLock to the dead-letter queue.
+func (a *AzureServiceBusQueues) deadLetterMessage(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	msg, err := lockedMessageFromMetadata(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	receiver, err := a.getOpsReceiver()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &servicebus.DeadLetterOptions{}
+	if val := req.Metadata[deadLetterReasonKey]; val != "" {
+		opts.Reason = ptr.Of(val)
+	}
+	if val := req.Metadata[deadLetterErrorDescriptionKey]; val != "" {
+		opts.ErrorDescription = ptr.Of(val)
+	}
+
+	err = receiver.DeadLetterMessage(ctx, msg, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dead-letter message: %w", err)
+	}
+	return nil, nil
+}
+
+// getQueueRuntimeProperties returns the runtime properties of a queue, such as the number of messages.
+// If the "queueName" metadata isn't set, it returns the properties of the component's queue.
+func (a *AzureServiceBusQueues) getQueueRuntimeProperties(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	adminClient, err := a.getAdminClient()
+	if err != nil {
+		return nil, err
+	}
+
+	queueName := req.Metadata[queueNameKey]
+	if queueName == "" {
+		queueName = a.metadata.QueueName
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(a.metadata.TimeoutInSec)*time.Second)
+	defer cancel()
+	res, err := adminClient.GetQueueRuntimeProperties(ctx, queueName, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get runtime properties of queue %s: %w", queueName, err)
+	}
+	if res == nil {
+		return nil, fmt.Errorf("queue %s does not exist", queueName)
+	}
+
+	return jsonResponse(queueRuntimeProperties{
+		SizeInBytes:                    res.SizeInBytes,
+		CreatedAt:                      res.CreatedAt,
+		UpdatedAt:                      res.UpdatedAt,
+		AccessedAt:                     res.AccessedAt,
+		TotalMessageCount:              res.TotalMessageCount,
+		ActiveMessageCount:             res.ActiveMessageCount,
+		DeadLetterMessageCount:         res.DeadLetterMessageCount,
+		ScheduledMessageCount:          res.ScheduledMessageCount,
+		TransferDeadLetterMessageCount: res.TransferDeadLetterMessageCount,
+		TransferMessageCount:           res.TransferMessageCount,
+	})
+}
+
+// createQueue creates the queue in the "queueName" metadata, using the entity settings of the component's metadata.
+func (a *AzureServiceBusQueues) createQueue(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	adminClient, err := a.getAdminClient()
+	if err != nil {
+		return nil, err
+	}
+
+	queueName := req.Metadata[queueNameKey]
+	if queueName == "" {
+		return nil, fmt.Errorf("metadata property %s is required", queueNameKey)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(a.metadata.TimeoutInSec)*time.Second)
+	defer cancel()
+	_, err = adminClient.CreateQueue(ctx, queueName, &sbadmin.CreateQueueOptions{
+		Properties: a.metadata.CreateQueueProperties(impl.SubscribeOptions{
+			RequireSessions: utils.IsTruthy(req.Metadata[impl.RequireSessionsMetadataKey]),
+		}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create queue %s: %w", queueName, err)
+	}
+	return nil, nil
+}
+
+// deleteQueue deletes the queue in the "queueName" metadata.
+func (a *AzureServiceBusQueues) deleteQueue(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	adminClient, err := a.getAdminClient()
+	if err != nil {
+		return nil, err
+	}
+
+	queueName := req.Metadata[queueNameKey]
+	if queueName == "" {
+		return nil, fmt.Errorf("metadata property %s is required", queueNameKey)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(a.metadata.TimeoutInSec)*time.Second)
+	defer cancel()
+	_, err = adminClient.DeleteQueue(ctx, queueName, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete queue %s: %w", queueName, err)
+	}
+	return nil, nil
+}
+
+func (a *AzureServiceBusQueues) getAdminClient() (*sbadmin.Client, error) {
+	adminClient := a.client.GetAdminClient()
+	if adminClient == nil {
+		return nil, errors.New("entity management is disabled: the operation requires disableEntityManagement to be false")
+	}
+	return adminClient, nil
+}
+
+// getOpsReceiver returns the receiver used by the operations that receive and settle messages, creating it if needed.
+func (a *AzureServiceBusQueues) getOpsReceiver() (*servicebus.Receiver, error) {
+	a.opsReceiverLock.Lock()
+	defer a.opsReceiverLock.Unlock()
+
+	if a.opsReceiver != nil {
+		return a.opsReceiver, nil
+	}
+
+	r, err := a.client.GetClient().NewReceiverForQueue(a.metadata.QueueName, &servicebus.ReceiverOptions{
+		ReceiveMode: servicebus.ReceiveModePeekLock,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create receiver: %w", err)
+	}
+	a.opsReceiver = r
+	return r, nil
+}
+
+func (a *AzureServiceBusQueues) closeOpsReceiver() {
+	a.opsReceiverLock.Lock()
+	defer a.opsReceiverLock.Unlock()
+
+	if a.opsReceiver == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(a.metadata.TimeoutInSec)*time.Second)
+	err := a.opsReceiver.Close(ctx)
+	cancel()
+	if err != nil {
+		a.logger.Warnf("Error closing receiver: %v", err)
+	}
+	a.opsReceiver = nil
+}
+
+// lockedMessageFromMetadata returns a message that can be settled using the lock token in the metadata.
+// Messages that weren't received on the same link are settled by lock token through the management link.
+func lockedMessageFromMetadata(md map[string]string) (*servicebus.ReceivedMessage, error) {
+	val := md[lockTokenKey]
+	if val == "" {
+		return nil, fmt.Errorf("metadata property %s is required", lockTokenKey)
+	}
+	lockToken, err := uuid.Parse(val)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lock token %s: %w", val, err)
+	}
+	return &servicebus.ReceivedMessage{
+		LockToken:      lockToken,
+		RawAMQPMessage: &servicebus.AMQPAnnotatedMessage{},
+	}, nil
+}
+
+func positiveIntFromMetadata(md map[string]string, key string, defaultValue int) (int, error) {
+	val := md[key]
+	if val == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("metadata property %s must be a positive integer", key)
+	}
+	return n, nil
+}
+
+func jsonResponse(v any) (*bindings.InvokeResponse, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &bindings.InvokeResponse{
+		Data: data,
+		Metadata: map[string]string{
+			"contentType": "application/json",
+		},
+	}, nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebusqueues
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	impl "github.com/dapr/components-contrib/internal/component/azure/servicebus"
+	"github.com/dapr/kit/logger"
+)
+
+func TestLockedMessageFromMetadata(t *testing.T) {
+	lockToken := uuid.New()
+	msg, err := lockedMessageFromMetadata(map[string]string{lockTokenKey: lockToken.String()})
+	require.NoError(t, err)
+	assert.Equal(t, [16]byte(lockToken), msg.LockToken)
+	assert.NotNil(t, msg.RawAMQPMessage)
+
+	_, err = lockedMessageFromMetadata(map[string]string{})
+	assert.ErrorContains(t, err, lockTokenKey)
+
+	_, err = lockedMessageFromMetadata(map[string]string{lockTokenKey: "invalid"})
+	assert.ErrorContains(t, err, "invalid lock token")
+}
+
+func TestPositiveIntFromMetadata(t *testing.T) {
+	n, err := positiveIntFromMetadata(map[string]string{}, maxMessagesKey, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	n, err = positiveIntFromMetadata(map[string]string{maxMessagesKey: "10"}, maxMessagesKey, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 10, n)
+
+	for _, val := range []string{"0", "-1", "foo"} {
+		_, err = positiveIntFromMetadata(map[string]string{maxMessagesKey: val}, maxMessagesKey, 1)
+		assert.Error(t, err, val)
+	}
+}
+
+func TestInvokeOperations(t *testing.T) {
+	md := &impl.Metadata{
+		ConnectionString:        "Endpoint=sb://fake.servicebus.windows.net/;SharedAccessKeyName=key;SharedAccessKey=secret",
+		QueueName:               "myqueue",
+		TimeoutInSec:            10,
+		DisableEntityManagement: true,
+	}
+	log := logger.NewLogger("test")
+	client, err := impl.NewClient(md, nil, log)
+	require.NoError(t, err)
+
+	a := NewAzureServiceBusQueues(log).(*AzureServiceBusQueues)
+	a.metadata = md
+	a.client = client
+
+	t.Run("unsupported operation", func(t *testing.T) {
+		_, err := a.Invoke(context.Background(), &bindings.InvokeRequest{Operation: "foo"})
+		assert.ErrorContains(t, err, "unsupported operation")
+	})
+
+	t.Run("admin operations require entity management", func(t *testing.T) {
+		for _, op := range []bindings.OperationKind{getQueueRuntimePropertiesOperation, createQueueOperation, deleteQueueOperation} {
+			_, err := a.Invoke(context.Background(), &bindings.InvokeRequest{
+				Operation: op,
+				Metadata:  map[string]string{queueNameKey: "other"},
+			})
+			assert.ErrorContains(t, err, "entity management is disabled", op)
+		}
+	})
+
+	t.Run("settling requires a lock token", func(t *testing.T) {
+		for _, op := range []bindings.OperationKind{completeMessageOperation, deadLetterMessageOperation} {
+			_, err := a.Invoke(context.Background(), &bindings.InvokeRequest{Operation: op})
+			assert.ErrorContains(t, err, lockTokenKey, op)
+		}
+	})
+
+	t.Run("peekLock is not supported with sessions", func(t *testing.T) {
+		md.RequireSessions = true
+		defer func() { md.RequireSessions = false }()
+		_, err := a.Invoke(context.Background(), &bindings.InvokeRequest{Operation: peekLockOperation})
+		assert.ErrorContains(t, err, "sessions")
+	})
+}
@@ -35,6 +35,7 @@ const (
 	correlationID = "correlationID"
 	label         = "label"
 	id            = "id"
+	sessionID     = "sessionID"
 )
 
 // AzureServiceBusQueues is an input/output binding reading from and sending events to Azure Service Bus queues.
@@ -45,6 +46,10 @@ type AzureServiceBusQueues struct {
 	closed   atomic.Bool
 	wg       sync.WaitGroup
 	closeCh  chan struct{}
+
+	// Receiver used by the peekLock, completeMessage, and deadLetterMessage operations
+	opsReceiver     *servicebus.Receiver
+	opsReceiverLock sync.Mutex
 }
 
 // NewAzureServiceBusQueues returns a new AzureServiceBusQueues instance.
@@ -62,13 +67,16 @@ func (a *AzureServiceBusQueues) Init(ctx context.Context, metadata bindings.Meta
 		return err
 	}
 
-	a.client, err = impl.NewClient(a.metadata, metadata.Properties)
+	a.client, err = impl.NewClient(a.metadata, metadata.Properties, a.logger)
 	if err != nil {
 		return err
 	}
 
 	// Will do nothing if DisableEntityManagement is false
-	err = a.client.EnsureQueue(ctx, a.metadata.QueueName)
+	err = a.client.EnsureQueueWithOptions(ctx, a.metadata.QueueName, impl.SubscribeOptions{
+		RequireSessions:      a.metadata.RequireSessions,
+		MaxConcurrentSesions: a.metadata.MaxConcurrentSessions,
+	})
 	if err != nil {
 		return err
 	}
@@ -79,11 +87,34 @@ func (a *AzureServiceBusQueues) Init(ctx context.Context, metadata bindings.Meta
 func (a *AzureServiceBusQueues) Operations() []bindings.OperationKind {
 	return []bindings.OperationKind{
 		bindings.CreateOperation,
+		peekLockOperation,
+		completeMessageOperation,
+		deadLetterMessageOperation,
+		getQueueRuntimePropertiesOperation,
+		createQueueOperation,
+		deleteQueueOperation,
 	}
 }
 
 func (a *AzureServiceBusQueues) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
-	return a.client.PublishBinding(ctx, req, a.metadata.QueueName, a.logger)
+	switch req.Operation {
+	case bindings.CreateOperation:
+		return a.client.PublishBinding(ctx, req, a.metadata.QueueName, a.logger)
+	case peekLockOperation:
+		return a.peekLock(ctx, req)
+	case completeMessageOperation:
+		return a.completeMessage(ctx, req)
+	case deadLetterMessageOperation:
+		return a.deadLetterMessage(ctx, req)
+	case getQueueRuntimePropertiesOperation:
+		return a.getQueueRuntimeProperties(ctx, req)
+	case createQueueOperation:
+		return a.createQueue(ctx, req)
+	case deleteQueueOperation:
+		return a.deleteQueue(ctx, req)
+	default:
+		return nil, fmt.Errorf("unsupported operation %s", req.Operation)
+	}
 }
 
 func (a *AzureServiceBusQueues) Read(ctx context.Context, handler bindings.Handler) error {
@@ -91,6 +122,17 @@ func (a *AzureServiceBusQueues) Read(ctx context.Context, handler bindings.Handl
 		return errors.New("binding is closed")
 	}
 
+	readCtx, cancel := context.WithCancel(ctx)
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		defer cancel()
+		select {
+		case <-ctx.Done():
+		case <-a.closeCh:
+		}
+	}()
+
 	// Reconnection backoff policy
 	bo := a.client.ReconnectionBackoff()
 
@@ -98,6 +140,7 @@ func (a *AzureServiceBusQueues) Read(ctx context.Context, handler bindings.Handl
 	go func() {
 		defer a.wg.Done()
 		logMsg := "queue " + a.metadata.QueueName
+		handlerFn := a.getHandlerFn(handler)
 
 		// Reconnect loop.
 		for {
@@ -107,39 +150,25 @@ func (a *AzureServiceBusQueues) Read(ctx context.Context, handler bindings.Handl
 				MaxBulkSubCount:       nil,
 				MaxRetriableEPS:       a.metadata.MaxRetriableErrorsPerSec,
 				MaxConcurrentHandlers: a.metadata.MaxConcurrentHandlers,
-				Entity:                "queue " + a.metadata.QueueName,
+				Entity:                logMsg,
 				LockRenewalInSec:      a.metadata.LockRenewalInSec,
-				RequireSessions:       false, // Sessions not supported for queues yet.
+				RequireSessions:       a.metadata.RequireSessions,
+				SessionIdleTimeout:    a.metadata.SessionIdleTimeout,
 			}, a.logger)
 
-			// Blocks until a successful connection (or until context is canceled)
-			receiver, err := sub.Connect(ctx, func() (impl.Receiver, error) {
-				a.logger.Debug("Connecting to " + logMsg)
-				r, rErr := a.client.GetClient().NewReceiverForQueue(a.metadata.QueueName, nil)
-				if rErr != nil {
-					return nil, rErr
-				}
-				return impl.NewMessageReceiver(r), nil
-			})
-			if err != nil {
-				// Realistically, the only time we should get to this point is if the context was canceled, but let's log any other error we may get.
-				if errors.Is(err, context.Canceled) {
-					a.logger.Warnf("Error reading from Azure Service Bus Queue binding: %s", err.Error())
-				}
-				return
+			// Reset the backoff when the subscription is successful and we have received the first message
+			if a.metadata.RequireSessions {
+				sub.ConnectAndReceiveWithSessions(readCtx, handlerFn, func(acceptCtx context.Context) (*servicebus.SessionReceiver, error) {
+					return a.client.GetClient().AcceptNextSessionForQueue(acceptCtx, a.metadata.QueueName, nil)
+				}, bo.Reset, a.metadata.MaxConcurrentSessions, logMsg)
+			} else {
+				a.connectAndReceive(readCtx, sub, handlerFn, bo.Reset, logMsg)
 			}
 
-			// ReceiveAndBlock will only return with an error that it cannot handle internally. The subscription connection is closed when this method returns.
-			// If that occurs, we will log the error and attempt to re-establish the subscription connection until we exhaust the number of reconnect attempts.
-			err = sub.ReceiveBlocking(
-				ctx,
-				a.getHandlerFn(handler),
-				receiver,
-				bo.Reset, // Reset the backoff when the subscription is successful and we have received the first message
-				logMsg,
-			)
-			if err != nil && !errors.Is(err, context.Canceled) {
-				a.logger.Errorf("Error from receiver: %v", err)
+			// If context was canceled, do not attempt to reconnect
+			if readCtx.Err() != nil {
+				a.logger.Debug("Context canceled; will not reconnect")
+				return
 			}
 
 			wait := bo.NextBackOff()
@@ -147,12 +176,9 @@ func (a *AzureServiceBusQueues) Read(ctx context.Context, handler bindings.Handl
 			select {
 			case <-time.After(wait):
 				// nop
-			case <-ctx.Done():
+			case <-readCtx.Done():
 				a.logger.Debug("Context canceled; will not reconnect")
 				return
-			case <-a.closeCh:
-				a.logger.Debug("Component is closing; will not reconnect")
-				return
 			}
 		}
 	}()
@@ -160,6 +186,32 @@ func (a *AzureServiceBusQueues) Read(ctx context.Context, handler bindings.Handl
 	return nil
 }
 
+func (a *AzureServiceBusQueues) connectAndReceive(ctx context.Context, sub *impl.Subscription, handlerFn impl.HandlerFn, onFirstSuccess func(), logMsg string) {
+	// Blocks until a successful connection (or until context is canceled)
+	receiver, err := sub.Connect(ctx, func() (impl.Receiver, error) {
+		a.logger.Debug("Connecting to " + logMsg)
+		r, rErr := a.client.GetClient().NewReceiverForQueue(a.metadata.QueueName, nil)
+		if rErr != nil {
+			return nil, rErr
+		}
+		return impl.NewMessageReceiver(r), nil
+	})
+	if err != nil {
+		// Realistically, the only time we should get to this point is if the context was canceled, but let's log any other error we may get.
+		if !errors.Is(err, context.Canceled) {
+			a.logger.Warnf("Error reading from Azure Service Bus Queue binding: %s", err.Error())
+		}
+		return
+	}
+
+	// ReceiveBlocking will only return with an error that it cannot handle internally. The subscription connection is closed when this method returns.
+	// If that occurs, we will log the error and attempt to re-establish the subscription connection until we exhaust the number of reconnect attempts.
+	err = sub.ReceiveBlocking(ctx, handlerFn, receiver, onFirstSuccess, logMsg)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		a.logger.Errorf("Error from receiver: %v", err)
+	}
+}
+
 func (a *AzureServiceBusQueues) getHandlerFn(handler bindings.Handler) impl.HandlerFn {
 	return func(ctx context.Context, asbMsgs []*servicebus.ReceivedMessage) ([]impl.HandlerResponseItem, error) {
 		if len(asbMsgs) != 1 {
@@ -167,37 +219,54 @@ func (a *AzureServiceBusQueues) getHandlerFn(handler bindings.Handler) impl.Hand
 		}
 
 		msg := asbMsgs[0]
-		metadata := make(map[string]string)
-		metadata[id] = msg.MessageID
-		if msg.CorrelationID != nil {
-			metadata[correlationID] = *msg.CorrelationID
-		}
-		if msg.Subject != nil {
-			metadata[label] = *msg.Subject
-		}
-
-		// Passthrough any custom metadata to the handler.
-		for key, val := range msg.ApplicationProperties {
-			if stringVal, ok := val.(string); ok {
-				// Escape the key and value to ensure they are valid URL query parameters.
-				// This is necessary for them to be sent as HTTP Metadata.
-				metadata[url.QueryEscape(key)] = url.QueryEscape(stringVal)
-			}
-		}
-
 		_, err := handler(ctx, &bindings.ReadResponse{
 			Data:     msg.Body,
-			Metadata: metadata,
+			Metadata: messageMetadata(msg),
 		})
 		return []impl.HandlerResponseItem{}, err
 	}
 }
 
+// messageMetadata returns the metadata of a received message.
+func messageMetadata(msg *servicebus.ReceivedMessage) map[string]string {
+	metadata := make(map[string]string)
+	metadata[id] = msg.MessageID
+	if msg.CorrelationID != nil {
+		metadata[correlationID] = *msg.CorrelationID
+	}
+	if msg.Subject != nil {
+		metadata[label] = *msg.Subject
+	}
+	if msg.SessionID != nil {
+		metadata[sessionID] = *msg.SessionID
+	}
+
+	// Passthrough any custom metadata to the handler.
+	for key, val := range msg.ApplicationProperties {
+		if stringVal, ok := val.(string); ok {
+			// Escape the key and value to ensure they are valid URL query parameters.
+			// This is necessary for them to be sent as HTTP Metadata.
+			metadata[url.QueryEscape(key)] = url.QueryEscape(stringVal)
+		}
+	}
+
+	return metadata
+}
+
+// Ping checks the connection to the Service Bus namespace, or to the queue when entity management is disabled.
+func (a *AzureServiceBusQueues) Ping(ctx context.Context) error {
+	if a.client == nil {
+		return errors.New("component is not initialized")
+	}
+	return a.client.Ping(ctx, a.metadata.QueueName)
+}
+
 func (a *AzureServiceBusQueues) Close() (err error) {
 	if a.closed.CompareAndSwap(false, true) {
 		close(a.closeCh)
 	}
 	a.logger.Debug("Closing component")
+	a.closeOpsReceiver()
 	a.client.Close(a.logger)
 	a.wg.Wait()
 	return nil
@@ -0,0 +1,40 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebusqueues
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dapr/components-contrib/health"
+	impl "github.com/dapr/components-contrib/internal/component/azure/servicebus"
+	"github.com/dapr/kit/logger"
+)
+
+func TestPing(t *testing.T) {
+	a := NewAzureServiceBusQueues(logger.NewLogger("test")).(*AzureServiceBusQueues)
+	assert.Implements(t, (*health.Pinger)(nil), a)
+
+	t.Run("not initialized", func(t *testing.T) {
+		assert.ErrorContains(t, a.Ping(context.Background()), "not initialized")
+	})
+
+	t.Run("closed client", func(t *testing.T) {
+		a.client = &impl.Client{}
+		a.metadata = &impl.Metadata{QueueName: "myqueue"}
+		assert.ErrorContains(t, a.Ping(context.Background()), "closed")
+	})
+}
@@ -29,6 +29,7 @@ import (
 
 	"github.com/dapr/components-contrib/bindings"
 	azauth "github.com/dapr/components-contrib/internal/authentication/azure"
+	"github.com/dapr/components-contrib/internal/utils"
 	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/ptr"
@@ -38,16 +39,35 @@ const (
 	defaultTTL               = 10 * time.Minute
 	defaultVisibilityTimeout = 30 * time.Second
 	defaultPollingInterval   = 10 * time.Second
+
+	// Maximum number of messages that can be received at once.
+	maxNumberOfMessages = 32
+	// Maximum visibility timeout of a message.
+	maxVisibilityDelay = 7 * 24 * time.Hour
+
+	// keys of the request metadata.
+	encodeBase64Key           = "encodeBase64"
+	initialVisibilityDelayKey = "initialVisibilityDelay"
 )
 
 type consumer struct {
 	callback bindings.Handler
 }
 
+// writeOptions are the options of a message written to the queue.
+type writeOptions struct {
+	// Time-to-live of the message; if nil, the default TTL is used.
+	ttl *time.Duration
+	// Time the message is invisible after being enqueued; if nil, it's visible immediately.
+	initialVisibilityDelay *time.Duration
+	// If true, the message is base64-encoded.
+	encodeBase64 bool
+}
+
 // QueueHelper enables injection for testnig.
 type QueueHelper interface {
 	Init(ctx context.Context, metadata bindings.Metadata) (*storageQueuesMetadata, error)
-	Write(ctx context.Context, data []byte, ttl *time.Duration) error
+	Write(ctx context.Context, data []byte, opts writeOptions) error
 	Read(ctx context.Context, consumer *consumer) error
 	Close() error
 }
@@ -57,9 +77,9 @@ type AzureQueueHelper struct {
 	queueClient       *azqueue.QueueClient
 	logger            logger.Logger
 	decodeBase64      bool
-	encodeBase64      bool
 	pollingInterval   time.Duration
 	visibilityTimeout time.Duration
+	numberOfMessages  int32
 }
 
 // Init sets up this helper.
@@ -107,9 +127,9 @@ func (d *AzureQueueHelper) Init(ctx context.Context, meta bindings.Metadata) (*s
 	}
 
 	d.decodeBase64 = m.DecodeBase64
-	d.encodeBase64 = m.EncodeBase64
 	d.pollingInterval = m.PollingInterval
 	d.visibilityTimeout = *m.VisibilityTimeout
+	d.numberOfMessages = m.NumberOfMessages
 	d.queueClient = queueServiceClient.NewQueueClient(m.QueueName)
 
 	createCtx, createCancel := context.WithTimeout(ctx, 2*time.Minute)
@@ -122,10 +142,10 @@ func (d *AzureQueueHelper) Init(ctx context.Context, meta bindings.Metadata) (*s
 	return m, nil
 }
 
-func (d *AzureQueueHelper) Write(ctx context.Context, data []byte, ttl *time.Duration) error {
+func (d *AzureQueueHelper) Write(ctx context.Context, data []byte, opts writeOptions) error {
 	var ttlSeconds *int32
-	if ttl != nil {
-		ttlSeconds = ptr.Of(int32(ttl.Seconds()))
+	if opts.ttl != nil {
+		ttlSeconds = ptr.Of(int32(opts.ttl.Seconds()))
 	} else {
 		ttlSeconds = ptr.Of(int32(defaultTTL.Seconds()))
 	}
@@ -135,20 +155,24 @@ func (d *AzureQueueHelper) Write(ctx context.Context, data []byte, ttl *time.Dur
 		s = string(data)
 	}
 
-	if d.encodeBase64 {
+	if opts.encodeBase64 {
 		s = base64.StdEncoding.EncodeToString([]byte(s))
 	}
 
-	_, err = d.queueClient.EnqueueMessage(ctx, s, &azqueue.EnqueueMessageOptions{
+	enqueueOpts := &azqueue.EnqueueMessageOptions{
 		TimeToLive: ttlSeconds,
-	})
+	}
+	if opts.initialVisibilityDelay != nil {
+		enqueueOpts.VisibilityTimeout = ptr.Of(int32(opts.initialVisibilityDelay.Seconds()))
+	}
+	_, err = d.queueClient.EnqueueMessage(ctx, s, enqueueOpts)
 
 	return err
 }
 
 func (d *AzureQueueHelper) Read(ctx context.Context, consumer *consumer) error {
 	res, err := d.queueClient.DequeueMessages(ctx, &azqueue.DequeueMessagesOptions{
-		NumberOfMessages:  ptr.Of(int32(1)),
+		NumberOfMessages:  ptr.Of(d.numberOfMessages),
 		VisibilityTimeout: ptr.Of(int32(d.visibilityTimeout.Seconds())),
 	})
 	if err != nil {
@@ -162,7 +186,28 @@ func (d *AzureQueueHelper) Read(ctx context.Context, consumer *consumer) error {
 		}
 		return nil
 	}
-	mt := res.Messages[0].MessageText
+	if len(res.Messages) == 1 {
+		return d.handleMessage(ctx, consumer, res.Messages[0])
+	}
+
+	// Messages received together are delivered in parallel; the ones that fail processing become visible again after the visibility timeout
+	errs := make([]error, len(res.Messages))
+	var wg sync.WaitGroup
+	wg.Add(len(res.Messages))
+	for i, msg := range res.Messages {
+		go func(i int, msg *azqueue.DequeuedMessage) {
+			defer wg.Done()
+			errs[i] = d.handleMessage(ctx, consumer, msg)
+		}(i, msg)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// handleMessage delivers a message to the consumer, and deletes it from the queue if it's processed successfully.
+func (d *AzureQueueHelper) handleMessage(ctx context.Context, consumer *consumer, msg *azqueue.DequeuedMessage) error {
+	mt := msg.MessageText
 
 	data := []byte("")
 	if mt != nil {
@@ -177,7 +222,7 @@ func (d *AzureQueueHelper) Read(ctx context.Context, consumer *consumer) error {
 		}
 	}
 
-	_, err = consumer.callback(ctx, &bindings.ReadResponse{
+	_, err := consumer.callback(ctx, &bindings.ReadResponse{
 		Data:     data,
 		Metadata: map[string]string{},
 	})
@@ -185,8 +230,8 @@ func (d *AzureQueueHelper) Read(ctx context.Context, consumer *consumer) error {
 		return err
 	}
 
-	if res.Messages[0].MessageID != nil && res.Messages[0].PopReceipt != nil {
-		_, err = d.queueClient.DeleteMessage(ctx, *res.Messages[0].MessageID, *res.Messages[0].PopReceipt, nil)
+	if msg.MessageID != nil && msg.PopReceipt != nil {
+		_, err = d.queueClient.DeleteMessage(ctx, *msg.MessageID, *msg.PopReceipt, nil)
 		if err != nil {
 			return err
 		}
@@ -229,6 +274,8 @@ type storageQueuesMetadata struct {
 	PollingInterval   time.Duration  `mapstructure:"pollingInterval"`
 	TTL               *time.Duration `mapstructure:"ttlInSeconds"`
 	VisibilityTimeout *time.Duration
+	// Maximum number of messages received at once, which are delivered in parallel.
+	NumberOfMessages int32 `mapstructure:"numberOfMessages"`
 }
 
 func (m *storageQueuesMetadata) GetQueueURL(azEnvSettings azauth.EnvironmentSettings) string {
@@ -264,6 +311,7 @@ func parseMetadata(meta bindings.Metadata) (*storageQueuesMetadata, error) {
 	m := storageQueuesMetadata{
 		PollingInterval:   defaultPollingInterval,
 		VisibilityTimeout: ptr.Of(defaultVisibilityTimeout),
+		NumberOfMessages:  1,
 	}
 	contribMetadata.DecodeMetadata(meta.Properties, &m)
 
@@ -291,6 +339,10 @@ func parseMetadata(meta bindings.Metadata) (*storageQueuesMetadata, error) {
 		return nil, errors.New("invalid value for 'pollingInterval': must be greater than 100ms")
 	}
 
+	if m.NumberOfMessages < 1 || m.NumberOfMessages > maxNumberOfMessages {
+		return nil, fmt.Errorf("invalid value for 'numberOfMessages': must be between 1 and %d", maxNumberOfMessages)
+	}
+
 	ttl, ok, err := contribMetadata.TryGetTTL(meta.Properties)
 	if err != nil {
 		return nil, err
@@ -317,7 +369,25 @@ func (a *AzureStorageQueues) Invoke(ctx context.Context, req *bindings.InvokeReq
 		ttlToUse = &ttl
 	}
 
-	err = a.helper.Write(ctx, req.Data, ttlToUse)
+	opts := writeOptions{
+		ttl:          ttlToUse,
+		encodeBase64: a.metadata.EncodeBase64,
+	}
+	if val, ok := req.Metadata[encodeBase64Key]; ok && val != "" {
+		opts.encodeBase64 = utils.IsTruthy(val)
+	}
+	if val, ok := req.Metadata[initialVisibilityDelayKey]; ok && val != "" {
+		delay, err := parseDelay(val)
+		if err != nil {
+			return nil, err
+		}
+		if opts.ttl != nil && delay >= *opts.ttl {
+			return nil, fmt.Errorf("%s must be lower than the TTL of the message", initialVisibilityDelayKey)
+		}
+		opts.initialVisibilityDelay = &delay
+	}
+
+	err = a.helper.Write(ctx, req.Data, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -325,6 +395,22 @@ func (a *AzureStorageQueues) Invoke(ctx context.Context, req *bindings.InvokeReq
 	return nil, nil
 }
 
+// parseDelay parses the initial visibility delay of a message, as a duration such as "30s" or a number of seconds.
+func parseDelay(val string) (time.Duration, error) {
+	delay, err := time.ParseDuration(val)
+	if err != nil {
+		seconds, serr := strconv.Atoi(val)
+		if serr != nil {
+			return 0, fmt.Errorf("invalid value for '%s': %s", initialVisibilityDelayKey, val)
+		}
+		delay = time.Duration(seconds) * time.Second
+	}
+	if delay < 0 || delay > maxVisibilityDelay {
+		return 0, fmt.Errorf("invalid value for '%s': must be between 0 and %v", initialVisibilityDelayKey, maxVisibilityDelay)
+	}
+	return delay, nil
+}
+
 func (a *AzureStorageQueues) Read(ctx context.Context, handler bindings.Handler) error {
 	if a.closed.Load() {
 		return errors.New("input binding is closed")
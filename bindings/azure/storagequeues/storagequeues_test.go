@@ -45,9 +45,9 @@ func (m *MockHelper) Init(ctx context.Context, metadata bindings.Metadata) (*sto
 	return m.metadata, err
 }
 
-func (m *MockHelper) Write(ctx context.Context, data []byte, ttl *time.Duration) error {
+func (m *MockHelper) Write(ctx context.Context, data []byte, opts writeOptions) error {
 	m.messages <- data
-	retvals := m.Called(data, ttl)
+	retvals := m.Called(data, opts)
 	return retvals.Error(0)
 }
 
@@ -87,8 +87,8 @@ func (m *MockHelper) Close() error {
 
 func TestWriteQueue(t *testing.T) {
 	mm := new(MockHelper)
-	mm.On("Write", mock.AnythingOfType("[]uint8"), mock.MatchedBy(func(in *time.Duration) bool {
-		return in == nil
+	mm.On("Write", mock.AnythingOfType("[]uint8"), mock.MatchedBy(func(in writeOptions) bool {
+		return in.ttl == nil
 	})).Return(nil)
 
 	a := AzureStorageQueues{helper: mm, logger: logger.NewLogger("test"), closeCh: make(chan struct{})}
@@ -109,8 +109,8 @@ func TestWriteQueue(t *testing.T) {
 
 func TestWriteWithTTLInQueue(t *testing.T) {
 	mm := new(MockHelper)
-	mm.On("Write", mock.AnythingOfTypeArgument("[]uint8"), mock.MatchedBy(func(in *time.Duration) bool {
-		return in != nil && *in == time.Second
+	mm.On("Write", mock.AnythingOfTypeArgument("[]uint8"), mock.MatchedBy(func(in writeOptions) bool {
+		return in.ttl != nil && *in.ttl == time.Second
 	})).Return(nil)
 
 	a := AzureStorageQueues{helper: mm, logger: logger.NewLogger("test"), closeCh: make(chan struct{})}
@@ -131,8 +131,8 @@ func TestWriteWithTTLInQueue(t *testing.T) {
 
 func TestWriteWithTTLInWrite(t *testing.T) {
 	mm := new(MockHelper)
-	mm.On("Write", mock.AnythingOfTypeArgument("[]uint8"), mock.MatchedBy(func(in *time.Duration) bool {
-		return in != nil && *in == time.Second
+	mm.On("Write", mock.AnythingOfTypeArgument("[]uint8"), mock.MatchedBy(func(in writeOptions) bool {
+		return in.ttl != nil && *in.ttl == time.Second
 	})).Return(nil)
 
 	a := AzureStorageQueues{helper: mm, logger: logger.NewLogger("test"), closeCh: make(chan struct{})}
@@ -154,6 +154,41 @@ func TestWriteWithTTLInWrite(t *testing.T) {
 	assert.NoError(t, a.Close())
 }
 
+func TestWriteWithOptions(t *testing.T) {
+	mm := new(MockHelper)
+	mm.On("Write", mock.AnythingOfTypeArgument("[]uint8"), mock.MatchedBy(func(in writeOptions) bool {
+		return !in.encodeBase64 && in.initialVisibilityDelay != nil && *in.initialVisibilityDelay == 30*time.Second
+	})).Return(nil)
+
+	a := AzureStorageQueues{helper: mm, logger: logger.NewLogger("test"), closeCh: make(chan struct{})}
+
+	m := bindings.Metadata{}
+	m.Properties = map[string]string{"storageAccessKey": "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==", "queue": "queue1", "storageAccount": "devstoreaccount1", "encodeBase64": "true"}
+
+	err := a.Init(context.Background(), m)
+	require.NoError(t, err)
+
+	for _, delay := range []string{"30s", "30"} {
+		r := bindings.InvokeRequest{
+			Data:     []byte("This is my message"),
+			Metadata: map[string]string{"encodeBase64": "false", "initialVisibilityDelay": delay},
+		}
+		_, err = a.Invoke(context.Background(), &r)
+		require.NoError(t, err)
+	}
+
+	for _, md := range []map[string]string{
+		{"initialVisibilityDelay": "-1s"},
+		{"initialVisibilityDelay": "abc"},
+		{"initialVisibilityDelay": "2m", metadata.TTLMetadataKey: "60"},
+	} {
+		_, err = a.Invoke(context.Background(), &bindings.InvokeRequest{Data: []byte("This is my message"), Metadata: md})
+		require.Error(t, err)
+	}
+
+	assert.NoError(t, a.Close())
+}
+
 // Uncomment this function to write a message to local storage queue
 /* func TestWriteLocalQueue(t *testing.T) {
 
@@ -174,7 +209,7 @@ func TestWriteWithTTLInWrite(t *testing.T) {
 
 func TestReadQueue(t *testing.T) {
 	mm := new(MockHelper)
-	mm.On("Write", mock.AnythingOfType("[]uint8"), mock.AnythingOfType("*time.Duration")).Return(nil)
+	mm.On("Write", mock.AnythingOfType("[]uint8"), mock.AnythingOfType("storagequeues.writeOptions")).Return(nil)
 	mm.On("Read", mock.AnythingOfType("*context.cancelCtx"), mock.AnythingOfType("*storagequeues.consumer")).Return(nil)
 	a := AzureStorageQueues{helper: mm, logger: logger.NewLogger("test"), closeCh: make(chan struct{})}
 
@@ -215,7 +250,7 @@ func TestReadQueue(t *testing.T) {
 
 func TestReadQueueDecode(t *testing.T) {
 	mm := new(MockHelper)
-	mm.On("Write", mock.AnythingOfType("[]uint8"), mock.AnythingOfType("*time.Duration")).Return(nil)
+	mm.On("Write", mock.AnythingOfType("[]uint8"), mock.AnythingOfType("storagequeues.writeOptions")).Return(nil)
 	mm.On("Read", mock.AnythingOfType("*context.cancelCtx"), mock.AnythingOfType("*storagequeues.consumer")).Return(nil)
 
 	a := AzureStorageQueues{helper: mm, logger: logger.NewLogger("test"), closeCh: make(chan struct{})}
@@ -286,7 +321,7 @@ func TestReadQueueDecode(t *testing.T) {
 */
 func TestReadQueueNoMessage(t *testing.T) {
 	mm := new(MockHelper)
-	mm.On("Write", mock.AnythingOfType("[]uint8"), mock.AnythingOfType("*time.Duration")).Return(nil)
+	mm.On("Write", mock.AnythingOfType("[]uint8"), mock.AnythingOfType("storagequeues.writeOptions")).Return(nil)
 	mm.On("Read", mock.AnythingOfType("*context.cancelCtx"), mock.AnythingOfType("*storagequeues.consumer")).Return(nil)
 
 	a := AzureStorageQueues{helper: mm, logger: logger.NewLogger("test"), closeCh: make(chan struct{})}
@@ -400,6 +435,23 @@ func TestParseMetadata(t *testing.T) {
 		})
 	}
 
+	t.Run("numberOfMessages", func(t *testing.T) {
+		props := map[string]string{"accessKey": "myKey", "storageAccountQueue": "queue1", "storageAccount": "devstoreaccount1"}
+
+		meta, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: props}})
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), meta.NumberOfMessages)
+
+		props["numberOfMessages"] = "32"
+		meta, err = parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: props}})
+		require.NoError(t, err)
+		assert.Equal(t, int32(32), meta.NumberOfMessages)
+
+		props["numberOfMessages"] = "33"
+		_, err = parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: props}})
+		require.Error(t, err)
+	})
+
 	t.Run("invalid pollingInterval", func(t *testing.T) {
 		m := bindings.Metadata{Base: metadata.Base{
 			Properties: map[string]string{
@@ -0,0 +1,212 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externaltask
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// externalTask is an external task fetched and locked by the worker.
+// https://docs.camunda.org/manual/7.19/reference/rest/external-task/fetch/
+type externalTask struct {
+	ID                   string                   `json:"id"`
+	TopicName            string                   `json:"topicName"`
+	WorkerID             string                   `json:"workerId"`
+	ActivityID           string                   `json:"activityId"`
+	ActivityInstanceID   string                   `json:"activityInstanceId"`
+	ProcessInstanceID    string                   `json:"processInstanceId"`
+	ProcessDefinitionID  string                   `json:"processDefinitionId"`
+	ProcessDefinitionKey string                   `json:"processDefinitionKey"`
+	BusinessKey          string                   `json:"businessKey"`
+	TenantID             string                   `json:"tenantId"`
+	Retries              *int                     `json:"retries"`
+	Priority             int64                    `json:"priority"`
+	LockExpirationTime   string                   `json:"lockExpirationTime"`
+	Variables            map[string]typedVariable `json:"variables"`
+}
+
+// typedVariable is a process variable as represented by the Camunda REST API.
+type typedVariable struct {
+	Value any    `json:"value"`
+	Type  string `json:"type,omitempty"`
+}
+
+type fetchTopic struct {
+	TopicName    string   `json:"topicName"`
+	LockDuration int64    `json:"lockDuration"`
+	Variables    []string `json:"variables,omitempty"`
+}
+
+type fetchAndLockRequest struct {
+	WorkerID             string       `json:"workerId"`
+	MaxTasks             int          `json:"maxTasks"`
+	UsePriority          bool         `json:"usePriority"`
+	AsyncResponseTimeout int64        `json:"asyncResponseTimeout,omitempty"`
+	Topics               []fetchTopic `json:"topics"`
+}
+
+// completeRequest is the body of the requests to complete a task.
+type completeRequest struct {
+	WorkerID       string                   `json:"workerId"`
+	Variables      map[string]typedVariable `json:"variables,omitempty"`
+	LocalVariables map[string]typedVariable `json:"localVariables,omitempty"`
+}
+
+// failureRequest is the body of the requests to report the failure of a task.
+type failureRequest struct {
+	WorkerID     string `json:"workerId"`
+	ErrorMessage string `json:"errorMessage,omitempty"`
+	ErrorDetails string `json:"errorDetails,omitempty"`
+	Retries      int    `json:"retries"`
+	// Timeout before the task can be fetched again, in milliseconds.
+	RetryTimeout int64 `json:"retryTimeout"`
+}
+
+// bpmnErrorRequest is the body of the requests to report a business error of a task.
+type bpmnErrorRequest struct {
+	WorkerID     string                   `json:"workerId"`
+	ErrorCode    string                   `json:"errorCode"`
+	ErrorMessage string                   `json:"errorMessage,omitempty"`
+	Variables    map[string]typedVariable `json:"variables,omitempty"`
+}
+
+// extendLockRequest is the body of the requests to extend the lock of a task.
+type extendLockRequest struct {
+	WorkerID string `json:"workerId"`
+	// New lock duration, in milliseconds, from the current time.
+	NewDuration int64 `json:"newDuration"`
+}
+
+// camundaClient is a client of the external task endpoints of the Camunda 7 REST API.
+type camundaClient struct {
+	baseURL    string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+func (c *camundaClient) fetchAndLock(ctx context.Context, req fetchAndLockRequest) ([]externalTask, error) {
+	var tasks []externalTask
+	err := c.post(ctx, "/external-task/fetchAndLock", req, &tasks)
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (c *camundaClient) complete(ctx context.Context, taskID string, req completeRequest) error {
+	return c.post(ctx, "/external-task/"+url.PathEscape(taskID)+"/complete", req, nil)
+}
+
+func (c *camundaClient) handleFailure(ctx context.Context, taskID string, req failureRequest) error {
+	return c.post(ctx, "/external-task/"+url.PathEscape(taskID)+"/failure", req, nil)
+}
+
+func (c *camundaClient) handleBpmnError(ctx context.Context, taskID string, req bpmnErrorRequest) error {
+	return c.post(ctx, "/external-task/"+url.PathEscape(taskID)+"/bpmnError", req, nil)
+}
+
+func (c *camundaClient) extendLock(ctx context.Context, taskID string, req extendLockRequest) error {
+	return c.post(ctx, "/external-task/"+url.PathEscape(taskID)+"/extendLock", req, nil)
+}
+
+// post sends a request to the REST API and decodes the response into res, if not nil.
+func (c *camundaClient) post(ctx context.Context, path string, body any, res any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	if c.username != "" {
+		httpReq.SetBasicAuth(c.username, c.password)
+	}
+
+	httpRes, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+
+	if httpRes.StatusCode < 200 || httpRes.StatusCode >= 300 {
+		return responseError(httpRes)
+	}
+	if res == nil {
+		return nil
+	}
+	return json.NewDecoder(httpRes.Body).Decode(res)
+}
+
+// responseError returns the error in a response of the REST API.
+func responseError(res *http.Response) error {
+	var exception struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+	if json.Unmarshal(body, &exception) == nil && exception.Message != "" {
+		return fmt.Errorf("camunda error: status %d: %s: %s", res.StatusCode, exception.Type, exception.Message)
+	}
+	return fmt.Errorf("camunda error: status %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// toTypedVariables converts plain JSON values to process variables.
+// Objects and arrays are stored as JSON variables; the type of the other values is inferred by the engine.
+func toTypedVariables(vars map[string]any) (map[string]typedVariable, error) {
+	if len(vars) == 0 {
+		return nil, nil
+	}
+	res := make(map[string]typedVariable, len(vars))
+	for k, v := range vars {
+		switch v.(type) {
+		case map[string]any, []any:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of variable %s: %w", k, err)
+			}
+			res[k] = typedVariable{Value: string(b), Type: "Json"}
+		default:
+			res[k] = typedVariable{Value: v}
+		}
+	}
+	return res, nil
+}
+
+// fromTypedVariables converts process variables to plain JSON values.
+// JSON variables, whose value is a serialized JSON document, are decoded.
+func fromTypedVariables(vars map[string]typedVariable) map[string]any {
+	res := make(map[string]any, len(vars))
+	for k, v := range vars {
+		if s, ok := v.Value.(string); ok && strings.EqualFold(v.Type, "Json") {
+			var decoded any
+			if json.Unmarshal([]byte(s), &decoded) == nil {
+				res[k] = decoded
+				continue
+			}
+		}
+		res[k] = v.Value
+	}
+	return res
+}
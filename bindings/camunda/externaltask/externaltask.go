@@ -0,0 +1,524 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externaltask
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	CompleteOperation        bindings.OperationKind = "complete"
+	HandleFailureOperation   bindings.OperationKind = "handleFailure"
+	HandleBpmnErrorOperation bindings.OperationKind = "handleBpmnError"
+	ExtendLockOperation      bindings.OperationKind = "extendLock"
+
+	// Keys of the metadata of the requests.
+	taskIDKey   = "taskId"
+	workerIDKey = "workerId"
+
+	defaultLockDuration         = 30 * time.Second
+	defaultMaxTasks             = 10
+	defaultAsyncResponseTimeout = 20 * time.Second
+	defaultPollInterval         = 5 * time.Second
+	defaultRetries              = 3
+	defaultRetryTimeout         = 10 * time.Second
+)
+
+var (
+	ErrMissingRestURL      = errors.New("restURL is a required attribute")
+	ErrMissingTopicName    = errors.New("topicName is required to read external tasks")
+	ErrMissingTaskID       = errors.New("taskId is a required metadata of the request")
+	ErrMissingErrorCode    = errors.New("errorCode is required to report a BPMN error")
+	ErrInvalidLockDuration = errors.New("lockDuration must be positive")
+	ErrInvalidMaxTasks     = errors.New("maxTasks must be positive")
+)
+
+// ExternalTask is a binding for the external tasks of Camunda 7.
+// As an input binding, it fetches and locks the tasks of a topic and delivers them to the app.
+// As an output binding, it completes tasks, and reports their failures and BPMN errors.
+type ExternalTask struct {
+	client   *camundaClient
+	metadata *externalTaskMetadata
+	logger   logger.Logger
+	closed   atomic.Bool
+	closeCh  chan struct{}
+	wg       sync.WaitGroup
+}
+
+// https://docs.camunda.org/manual/7.19/user-guide/process-engine/external-tasks/
+type externalTaskMetadata struct {
+	// Base URL of the REST API, such as "http://localhost:8080/engine-rest".
+	RestURL  string `mapstructure:"restURL"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// ID of the worker that locks the tasks; defaults to "dapr-" followed by the host name.
+	WorkerID string `mapstructure:"workerId"`
+
+	// Topic of the tasks to fetch.
+	TopicName string `mapstructure:"topicName"`
+	// Duration of the lock of the fetched tasks.
+	LockDuration time.Duration `mapstructure:"lockDuration"`
+	// Maximum number of tasks fetched at once.
+	MaxTasks int `mapstructure:"maxTasks"`
+	// If true, tasks with a higher priority are fetched first.
+	UsePriority bool `mapstructure:"usePriority"`
+	// Comma-separated list of the variables to fetch; all of them are fetched if empty.
+	Variables string `mapstructure:"variables"`
+	// Time the engine keeps fetch requests open when there's no task, for long polling; 0 disables long polling.
+	AsyncResponseTimeout *time.Duration `mapstructure:"asyncResponseTimeout"`
+	// Time to wait before fetching tasks again after an error, or when no task was available without long polling.
+	PollInterval time.Duration `mapstructure:"pollInterval"`
+	// If true, tasks are completed with the variables returned by the app; otherwise the app completes them with the output operations.
+	Autocomplete *bool `mapstructure:"autocomplete"`
+	// Number of retries of the tasks that fail the first time.
+	Retries int `mapstructure:"retries"`
+	// Time before a failed task can be fetched again.
+	RetryTimeout time.Duration `mapstructure:"retryTimeout"`
+}
+
+// completePayload is the data of the complete operation.
+type completePayload struct {
+	Variables      map[string]any `json:"variables"`
+	LocalVariables map[string]any `json:"localVariables"`
+}
+
+// failurePayload is the data of the handleFailure operation.
+type failurePayload struct {
+	ErrorMessage string `json:"errorMessage"`
+	ErrorDetails string `json:"errorDetails"`
+	// Number of retries left; when 0, an incident is created.
+	Retries int `json:"retries"`
+	// Timeout before the task can be fetched again, in milliseconds.
+	RetryTimeout int64 `json:"retryTimeout"`
+}
+
+// bpmnErrorPayload is the data of the handleBpmnError operation.
+type bpmnErrorPayload struct {
+	ErrorCode    string         `json:"errorCode"`
+	ErrorMessage string         `json:"errorMessage"`
+	Variables    map[string]any `json:"variables"`
+}
+
+// extendLockPayload is the data of the extendLock operation.
+type extendLockPayload struct {
+	// New duration of the lock, in milliseconds, from the current time.
+	NewDuration int64 `json:"newDuration"`
+}
+
+// NewExternalTask returns a new Camunda 7 external task binding.
+func NewExternalTask(logger logger.Logger) bindings.InputOutputBinding {
+	return &ExternalTask{
+		logger:  logger,
+		closeCh: make(chan struct{}),
+	}
+}
+
+// Init does metadata parsing and creates the client of the REST API.
+func (e *ExternalTask) Init(_ context.Context, meta bindings.Metadata) error {
+	m, err := parseMetadata(meta)
+	if err != nil {
+		return err
+	}
+	e.metadata = m
+
+	e.client = &camundaClient{
+		baseURL:  m.RestURL,
+		username: m.Username,
+		password: m.Password,
+		httpClient: &http.Client{
+			// Fetch requests are kept open for up to asyncResponseTimeout
+			Timeout: *m.AsyncResponseTimeout + 30*time.Second,
+		},
+	}
+
+	return nil
+}
+
+func parseMetadata(meta bindings.Metadata) (*externalTaskMetadata, error) {
+	m := externalTaskMetadata{
+		LockDuration: defaultLockDuration,
+		MaxTasks:     defaultMaxTasks,
+		PollInterval: defaultPollInterval,
+		Retries:      defaultRetries,
+		RetryTimeout: defaultRetryTimeout,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.RestURL == "" {
+		return nil, ErrMissingRestURL
+	}
+	m.RestURL = strings.TrimSuffix(m.RestURL, "/")
+	if m.WorkerID == "" {
+		hostname, _ := os.Hostname()
+		m.WorkerID = "dapr-" + hostname
+	}
+	if m.LockDuration <= 0 {
+		return nil, ErrInvalidLockDuration
+	}
+	if m.MaxTasks <= 0 {
+		return nil, ErrInvalidMaxTasks
+	}
+	if m.AsyncResponseTimeout == nil {
+		d := defaultAsyncResponseTimeout
+		m.AsyncResponseTimeout = &d
+	}
+	if m.Retries < 0 {
+		m.Retries = 0
+	}
+
+	return &m, nil
+}
+
+// Read fetches and locks the tasks of the topic, and delivers them to the handler.
+func (e *ExternalTask) Read(ctx context.Context, handler bindings.Handler) error {
+	if e.closed.Load() {
+		return errors.New("binding is closed")
+	}
+	if e.metadata.TopicName == "" {
+		return ErrMissingTopicName
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	e.wg.Add(2)
+	go func() {
+		defer e.wg.Done()
+		select {
+		case <-e.closeCh:
+		case <-ctx.Done():
+		}
+		cancel()
+	}()
+	go func() {
+		defer e.wg.Done()
+		e.poll(ctx, handler)
+	}()
+
+	return nil
+}
+
+// poll fetches tasks until the context is canceled.
+func (e *ExternalTask) poll(ctx context.Context, handler bindings.Handler) {
+	req := fetchAndLockRequest{
+		WorkerID:             e.metadata.WorkerID,
+		MaxTasks:             e.metadata.MaxTasks,
+		UsePriority:          e.metadata.UsePriority,
+		AsyncResponseTimeout: e.metadata.AsyncResponseTimeout.Milliseconds(),
+		Topics: []fetchTopic{{
+			TopicName:    e.metadata.TopicName,
+			LockDuration: e.metadata.LockDuration.Milliseconds(),
+		}},
+	}
+	for _, v := range strings.Split(e.metadata.Variables, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			req.Topics[0].Variables = append(req.Topics[0].Variables, v)
+		}
+	}
+
+	for ctx.Err() == nil {
+		tasks, err := e.client.fetchAndLock(ctx, req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			e.logger.Errorf("Error fetching external tasks of topic %s: %v", e.metadata.TopicName, err)
+		}
+
+		if len(tasks) == 0 {
+			// With long polling the engine already waited for tasks, unless the request failed
+			if err == nil && *e.metadata.AsyncResponseTimeout > 0 {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(e.metadata.PollInterval):
+			}
+			continue
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(len(tasks))
+		for i := range tasks {
+			go func(task *externalTask) {
+				defer wg.Done()
+				e.handleTask(ctx, handler, task)
+			}(&tasks[i])
+		}
+		wg.Wait()
+	}
+}
+
+// handleTask delivers a task to the handler, then completes it or reports its failure.
+func (e *ExternalTask) handleTask(ctx context.Context, handler bindings.Handler, task *externalTask) {
+	data, err := json.Marshal(fromTypedVariables(task.Variables))
+	if err != nil {
+		// Use a background context because the subscription one may be canceled
+		e.failTask(context.Background(), task, err)
+		return
+	}
+
+	md := map[string]string{
+		"X-Camunda-Task-Id":                task.ID,
+		"X-Camunda-Topic-Name":             task.TopicName,
+		"X-Camunda-Worker-Id":              task.WorkerID,
+		"X-Camunda-Activity-Id":            task.ActivityID,
+		"X-Camunda-Activity-Instance-Id":   task.ActivityInstanceID,
+		"X-Camunda-Process-Instance-Id":    task.ProcessInstanceID,
+		"X-Camunda-Process-Definition-Id":  task.ProcessDefinitionID,
+		"X-Camunda-Process-Definition-Key": task.ProcessDefinitionKey,
+		"X-Camunda-Business-Key":           task.BusinessKey,
+		"X-Camunda-Tenant-Id":              task.TenantID,
+		"X-Camunda-Priority":               strconv.FormatInt(task.Priority, 10),
+		"X-Camunda-Lock-Expiration-Time":   task.LockExpirationTime,
+	}
+	if task.Retries != nil {
+		md["X-Camunda-Retries"] = strconv.Itoa(*task.Retries)
+	}
+
+	result, err := handler(ctx, &bindings.ReadResponse{
+		Data:     data,
+		Metadata: md,
+	})
+	if err != nil {
+		// Use a background context because the subscription one may be canceled
+		e.failTask(context.Background(), task, err)
+		return
+	}
+
+	if e.metadata.Autocomplete != nil && !*e.metadata.Autocomplete {
+		e.logger.Debugf("Auto-completion for external task `%s` of topic `%s` is disabled. Use the complete operation to complete the task from the worker", task.ID, task.TopicName)
+		return
+	}
+
+	var vars map[string]any
+	if len(result) > 0 {
+		err = json.Unmarshal(result, &vars)
+		if err != nil {
+			e.failTask(context.Background(), task, fmt.Errorf("cannot parse variables from binding result %s; got error %w", string(result), err))
+			return
+		}
+	}
+	typedVars, err := toTypedVariables(vars)
+	if err != nil {
+		e.failTask(context.Background(), task, err)
+		return
+	}
+
+	// Use a background context because the subscription one may be canceled
+	err = e.client.complete(context.Background(), task.ID, completeRequest{
+		WorkerID:  e.metadata.WorkerID,
+		Variables: typedVars,
+	})
+	if err != nil {
+		e.logger.Errorf("Cannot complete external task `%s` of topic `%s`; got error: %v", task.ID, task.TopicName, err)
+		return
+	}
+	e.logger.Debugf("Successfully completed external task `%s` of topic `%s`", task.ID, task.TopicName)
+}
+
+// failTask reports the failure of a task, decrementing its retries.
+func (e *ExternalTask) failTask(ctx context.Context, task *externalTask, reason error) {
+	e.logger.Errorf("Failed to complete external task `%s` reason: %v", task.ID, reason)
+
+	// Tasks that never failed have no retries set
+	retries := e.metadata.Retries
+	if task.Retries != nil {
+		retries = *task.Retries - 1
+	}
+	if retries < 0 {
+		retries = 0
+	}
+	err := e.client.handleFailure(ctx, task.ID, failureRequest{
+		WorkerID:     e.metadata.WorkerID,
+		ErrorMessage: reason.Error(),
+		Retries:      retries,
+		RetryTimeout: e.metadata.RetryTimeout.Milliseconds(),
+	})
+	if err != nil {
+		e.logger.Errorf("Cannot report the failure of external task `%s` of topic `%s`; got error: %v", task.ID, task.TopicName, err)
+	}
+}
+
+// Operations returns the operations supported by the output binding.
+func (e *ExternalTask) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{
+		CompleteOperation,
+		HandleFailureOperation,
+		HandleBpmnErrorOperation,
+		ExtendLockOperation,
+	}
+}
+
+// Invoke runs an operation on the task in the "taskId" metadata of the request.
+// The worker ID of the component is used, unless it's overridden with the "workerId" metadata.
+func (e *ExternalTask) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	taskID := req.Metadata[taskIDKey]
+	if taskID == "" {
+		return nil, ErrMissingTaskID
+	}
+	workerID := e.metadata.WorkerID
+	if v := req.Metadata[workerIDKey]; v != "" {
+		workerID = v
+	}
+
+	var err error
+	switch req.Operation { //nolint:exhaustive
+	case CompleteOperation:
+		err = e.complete(ctx, taskID, workerID, req.Data)
+	case HandleFailureOperation:
+		err = e.handleFailure(ctx, taskID, workerID, req.Data)
+	case HandleBpmnErrorOperation:
+		err = e.handleBpmnError(ctx, taskID, workerID, req.Data)
+	case ExtendLockOperation:
+		err = e.extendLock(ctx, taskID, workerID, req.Data)
+	default:
+		return nil, fmt.Errorf("unsupported operation: %v", req.Operation)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &bindings.InvokeResponse{}, nil
+}
+
+func (e *ExternalTask) complete(ctx context.Context, taskID, workerID string, data []byte) error {
+	var payload completePayload
+	if err := unmarshalPayload(data, &payload); err != nil {
+		return err
+	}
+	vars, err := toTypedVariables(payload.Variables)
+	if err != nil {
+		return err
+	}
+	localVars, err := toTypedVariables(payload.LocalVariables)
+	if err != nil {
+		return err
+	}
+	err = e.client.complete(ctx, taskID, completeRequest{
+		WorkerID:       workerID,
+		Variables:      vars,
+		LocalVariables: localVars,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot complete external task %s: %w", taskID, err)
+	}
+	return nil
+}
+
+func (e *ExternalTask) handleFailure(ctx context.Context, taskID, workerID string, data []byte) error {
+	payload := failurePayload{
+		RetryTimeout: e.metadata.RetryTimeout.Milliseconds(),
+	}
+	if err := unmarshalPayload(data, &payload); err != nil {
+		return err
+	}
+	err := e.client.handleFailure(ctx, taskID, failureRequest{
+		WorkerID:     workerID,
+		ErrorMessage: payload.ErrorMessage,
+		ErrorDetails: payload.ErrorDetails,
+		Retries:      payload.Retries,
+		RetryTimeout: payload.RetryTimeout,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot report the failure of external task %s: %w", taskID, err)
+	}
+	return nil
+}
+
+func (e *ExternalTask) handleBpmnError(ctx context.Context, taskID, workerID string, data []byte) error {
+	var payload bpmnErrorPayload
+	if err := unmarshalPayload(data, &payload); err != nil {
+		return err
+	}
+	if payload.ErrorCode == "" {
+		return ErrMissingErrorCode
+	}
+	vars, err := toTypedVariables(payload.Variables)
+	if err != nil {
+		return err
+	}
+	err = e.client.handleBpmnError(ctx, taskID, bpmnErrorRequest{
+		WorkerID:     workerID,
+		ErrorCode:    payload.ErrorCode,
+		ErrorMessage: payload.ErrorMessage,
+		Variables:    vars,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot report a BPMN error for external task %s: %w", taskID, err)
+	}
+	return nil
+}
+
+func (e *ExternalTask) extendLock(ctx context.Context, taskID, workerID string, data []byte) error {
+	payload := extendLockPayload{
+		NewDuration: e.metadata.LockDuration.Milliseconds(),
+	}
+	if err := unmarshalPayload(data, &payload); err != nil {
+		return err
+	}
+	err := e.client.extendLock(ctx, taskID, extendLockRequest{
+		WorkerID:    workerID,
+		NewDuration: payload.NewDuration,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot extend the lock of external task %s: %w", taskID, err)
+	}
+	return nil
+}
+
+// unmarshalPayload decodes the data of a request, if any.
+func unmarshalPayload(data []byte, payload any) error {
+	if len(data) == 0 {
+		return nil
+	}
+	err := json.Unmarshal(data, payload)
+	if err != nil {
+		return fmt.Errorf("invalid request data: %w", err)
+	}
+	return nil
+}
+
+func (e *ExternalTask) Close() error {
+	if e.closed.CompareAndSwap(false, true) {
+		close(e.closeCh)
+	}
+	e.wg.Wait()
+	return nil
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (e *ExternalTask) GetComponentMetadata() map[string]string {
+	metadataStruct := externalTaskMetadata{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.BindingType)
+	return metadataInfo
+}
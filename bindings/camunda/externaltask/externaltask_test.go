@@ -0,0 +1,260 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externaltask
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeCamunda is a fake of the external task endpoints of the REST API.
+type fakeCamunda struct {
+	lock     sync.Mutex
+	tasks    []externalTask
+	requests map[string][]map[string]any
+}
+
+func newFakeCamunda(t *testing.T, tasks ...externalTask) (*fakeCamunda, *httptest.Server) {
+	f := &fakeCamunda{
+		tasks:    tasks,
+		requests: map[string][]map[string]any{},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if user != "demo" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		f.requests[r.URL.Path] = append(f.requests[r.URL.Path], body)
+
+		if r.URL.Path == "/engine-rest/external-task/fetchAndLock" {
+			tasks := f.tasks
+			f.tasks = nil
+			if tasks == nil {
+				tasks = []externalTask{}
+			}
+			json.NewEncoder(w).Encode(tasks)
+			return
+		}
+		if r.URL.Path == "/engine-rest/external-task/missing/complete" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"type":"RestException","message":"External task with id missing does not exist"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func (f *fakeCamunda) getRequests(path string) []map[string]any {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.requests["/engine-rest"+path]
+}
+
+func newBinding(t *testing.T, serverURL string, props map[string]string) *ExternalTask {
+	properties := map[string]string{
+		"restURL":              serverURL + "/engine-rest/",
+		"username":             "demo",
+		"password":             "secret",
+		"workerId":             "worker1",
+		"topicName":            "charge-card",
+		"asyncResponseTimeout": "0",
+		"pollInterval":         "10ms",
+	}
+	for k, v := range props {
+		properties[k] = v
+	}
+	b := NewExternalTask(logger.NewLogger("test")).(*ExternalTask)
+	err := b.Init(context.Background(), bindings.Metadata{Base: metadata.Base{Properties: properties}})
+	require.NoError(t, err)
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"restURL": "http://localhost:8080/engine-rest/",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "http://localhost:8080/engine-rest", m.RestURL)
+		assert.Equal(t, defaultLockDuration, m.LockDuration)
+		assert.Equal(t, defaultMaxTasks, m.MaxTasks)
+		assert.Equal(t, defaultAsyncResponseTimeout, *m.AsyncResponseTimeout)
+		assert.Equal(t, defaultRetries, m.Retries)
+		assert.Contains(t, m.WorkerID, "dapr-")
+	})
+
+	t.Run("missing REST URL", func(t *testing.T) {
+		_, err := parseMetadata(bindings.Metadata{})
+		assert.ErrorIs(t, err, ErrMissingRestURL)
+	})
+
+	t.Run("invalid lock duration", func(t *testing.T) {
+		_, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"restURL":      "http://localhost:8080/engine-rest",
+			"lockDuration": "0",
+		}}})
+		assert.ErrorIs(t, err, ErrInvalidLockDuration)
+	})
+}
+
+func TestVariables(t *testing.T) {
+	typed, err := toTypedVariables(map[string]any{
+		"amount": 42.5,
+		"order":  map[string]any{"id": "1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, typedVariable{Value: 42.5}, typed["amount"])
+	assert.Equal(t, typedVariable{Value: `{"id":"1"}`, Type: "Json"}, typed["order"])
+
+	plain := fromTypedVariables(map[string]typedVariable{
+		"amount": {Value: 42.5, Type: "Double"},
+		"order":  {Value: `{"id":"1"}`, Type: "Json"},
+		"name":   {Value: "{not json}", Type: "String"},
+	})
+	assert.Equal(t, map[string]any{
+		"amount": 42.5,
+		"order":  map[string]any{"id": "1"},
+		"name":   "{not json}",
+	}, plain)
+}
+
+func TestRead(t *testing.T) {
+	retries := 2
+	f, server := newFakeCamunda(t,
+		externalTask{
+			ID:                "task1",
+			TopicName:         "charge-card",
+			ProcessInstanceID: "pi1",
+			Variables:         map[string]typedVariable{"amount": {Value: 10.0, Type: "Double"}},
+		},
+		externalTask{
+			ID:        "task2",
+			TopicName: "charge-card",
+			Retries:   &retries,
+		},
+	)
+	b := newBinding(t, server.URL, map[string]string{"variables": "amount, currency"})
+
+	err := b.Read(context.Background(), func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		if res.Metadata["X-Camunda-Task-Id"] == "task2" {
+			return nil, errors.New("card declined")
+		}
+		assert.Equal(t, "pi1", res.Metadata["X-Camunda-Process-Instance-Id"])
+		assert.JSONEq(t, `{"amount":10}`, string(res.Data))
+		return []byte(`{"charged":true}`), nil
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(f.getRequests("/external-task/task1/complete")) == 1 &&
+			len(f.getRequests("/external-task/task2/failure")) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	fetch := f.getRequests("/external-task/fetchAndLock")[0]
+	assert.Equal(t, "worker1", fetch["workerId"])
+	assert.Equal(t, []any{map[string]any{
+		"topicName":    "charge-card",
+		"lockDuration": float64(30000),
+		"variables":    []any{"amount", "currency"},
+	}}, fetch["topics"])
+
+	complete := f.getRequests("/external-task/task1/complete")[0]
+	assert.Equal(t, map[string]any{"charged": map[string]any{"value": true}}, complete["variables"])
+
+	failure := f.getRequests("/external-task/task2/failure")[0]
+	assert.Equal(t, "card declined", failure["errorMessage"])
+	assert.Equal(t, float64(1), failure["retries"])
+	assert.Equal(t, float64(10000), failure["retryTimeout"])
+}
+
+func TestInvoke(t *testing.T) {
+	f, server := newFakeCamunda(t)
+	b := newBinding(t, server.URL, nil)
+
+	invoke := func(op bindings.OperationKind, taskID string, data string) error {
+		_, err := b.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: op,
+			Data:      []byte(data),
+			Metadata:  map[string]string{"taskId": taskID},
+		})
+		return err
+	}
+
+	t.Run("complete", func(t *testing.T) {
+		err := invoke(CompleteOperation, "task1", `{"variables":{"items":[1,2]}}`)
+		require.NoError(t, err)
+		req := f.getRequests("/external-task/task1/complete")[0]
+		assert.Equal(t, "worker1", req["workerId"])
+		assert.Equal(t, map[string]any{"items": map[string]any{"value": "[1,2]", "type": "Json"}}, req["variables"])
+	})
+
+	t.Run("handleFailure", func(t *testing.T) {
+		err := invoke(HandleFailureOperation, "task1", `{"errorMessage":"boom","retries":0}`)
+		require.NoError(t, err)
+		req := f.getRequests("/external-task/task1/failure")[0]
+		assert.Equal(t, "boom", req["errorMessage"])
+		assert.Equal(t, float64(0), req["retries"])
+	})
+
+	t.Run("handleBpmnError", func(t *testing.T) {
+		err := invoke(HandleBpmnErrorOperation, "task1", `{"errorMessage":"boom"}`)
+		require.ErrorIs(t, err, ErrMissingErrorCode)
+
+		err = invoke(HandleBpmnErrorOperation, "task1", `{"errorCode":"card-declined"}`)
+		require.NoError(t, err)
+		req := f.getRequests("/external-task/task1/bpmnError")[0]
+		assert.Equal(t, "card-declined", req["errorCode"])
+	})
+
+	t.Run("extendLock", func(t *testing.T) {
+		err := invoke(ExtendLockOperation, "task1", "")
+		require.NoError(t, err)
+		req := f.getRequests("/external-task/task1/extendLock")[0]
+		assert.Equal(t, float64(30000), req["newDuration"])
+	})
+
+	t.Run("missing task ID", func(t *testing.T) {
+		err := invoke(CompleteOperation, "", "")
+		require.ErrorIs(t, err, ErrMissingTaskID)
+	})
+
+	t.Run("error from the engine", func(t *testing.T) {
+		err := invoke(CompleteOperation, "missing", "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not exist")
+	})
+}
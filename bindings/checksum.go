@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bindings
+
+import (
+	"crypto/md5" //nolint:gosec
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+const (
+	// ChecksumAlgorithmMetadataKey is the key of the request metadata with the algorithm of the checksum of the content: ChecksumMD5 or ChecksumSHA256.
+	// When it's set, the checksum of the content is returned in the response metadata.
+	ChecksumAlgorithmMetadataKey = "checksumAlgorithm"
+	// ChecksumMetadataKey is the key of the metadata with the hex-encoded checksum of the content.
+	// In requests, it's the expected checksum, and the operation fails if the content doesn't match it.
+	// In responses, it's the checksum computed from the content.
+	ChecksumMetadataKey = "checksum"
+
+	ChecksumMD5    = "md5"
+	ChecksumSHA256 = "sha256"
+)
+
+// ErrChecksumMismatch is returned when the content doesn't match the expected checksum.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ContentChecksum computes and verifies the checksum of the content of an object read or written by a binding.
+type ContentChecksum struct {
+	// Algorithm is ChecksumMD5 or ChecksumSHA256.
+	Algorithm string
+	// Expected is the expected hex-encoded checksum, lowercase; if empty, the checksum is only computed.
+	Expected string
+}
+
+// NewContentChecksum returns the ContentChecksum requested in the metadata of a request, or nil if the request doesn't have one.
+// If only the expected checksum is set, the algorithm defaults to SHA-256.
+func NewContentChecksum(reqMetadata map[string]string) (*ContentChecksum, error) {
+	c := &ContentChecksum{
+		Algorithm: strings.ToLower(reqMetadata[ChecksumAlgorithmMetadataKey]),
+		Expected:  strings.ToLower(reqMetadata[ChecksumMetadataKey]),
+	}
+	if c.Algorithm == "" {
+		if c.Expected == "" {
+			return nil, nil
+		}
+		c.Algorithm = ChecksumSHA256
+	}
+
+	h, err := c.newHash()
+	if err != nil {
+		return nil, err
+	}
+	if c.Expected != "" {
+		b, err := hex.DecodeString(c.Expected)
+		if err != nil || len(b) != h.Size() {
+			return nil, fmt.Errorf("invalid value for '%s': must be a hex-encoded %s checksum", ChecksumMetadataKey, c.Algorithm)
+		}
+	}
+
+	return c, nil
+}
+
+// Compute returns the hex-encoded checksum of the content read from r, until EOF.
+func (c *ContentChecksum) Compute(r io.Reader) (string, error) {
+	h, err := c.newHash()
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(h, r)
+	if err != nil {
+		return "", fmt.Errorf("error computing the checksum of the content: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Verify returns ErrChecksumMismatch if sum doesn't match the expected checksum, if any.
+func (c *ContentChecksum) Verify(sum string) error {
+	if c.Expected != "" && c.Expected != sum {
+		return fmt.Errorf("%w: expected %s %s, content has %s", ErrChecksumMismatch, c.Algorithm, c.Expected, sum)
+	}
+	return nil
+}
+
+// ComputeAndVerify computes the checksum of the content read from r and verifies it, returning it if it matches.
+func (c *ContentChecksum) ComputeAndVerify(r io.Reader) (string, error) {
+	sum, err := c.Compute(r)
+	if err != nil {
+		return "", err
+	}
+	return sum, c.Verify(sum)
+}
+
+// AddToMetadata adds the checksum and its algorithm to the metadata of a response, allocating it if nil.
+func (c *ContentChecksum) AddToMetadata(md map[string]string, sum string) map[string]string {
+	if md == nil {
+		md = make(map[string]string, 2)
+	}
+	md[ChecksumAlgorithmMetadataKey] = c.Algorithm
+	md[ChecksumMetadataKey] = sum
+	return md
+}
+
+func (c *ContentChecksum) newHash() (hash.Hash, error) {
+	switch c.Algorithm {
+	case ChecksumMD5:
+		return md5.New(), nil //nolint:gosec
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	default:
+		return nil, fmt.Errorf("invalid value for '%s': unsupported algorithm '%s'", ChecksumAlgorithmMetadataKey, c.Algorithm)
+	}
+}
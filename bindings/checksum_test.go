@@ -0,0 +1,86 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bindings
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	helloMD5    = "5d41402abc4b2a76b9719d911017c592"
+	helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+)
+
+func TestNewContentChecksum(t *testing.T) {
+	t.Run("not requested", func(t *testing.T) {
+		c, err := NewContentChecksum(map[string]string{"key": "foo"})
+		require.NoError(t, err)
+		assert.Nil(t, c)
+	})
+
+	t.Run("algorithm only", func(t *testing.T) {
+		c, err := NewContentChecksum(map[string]string{ChecksumAlgorithmMetadataKey: "MD5"})
+		require.NoError(t, err)
+		assert.Equal(t, ChecksumMD5, c.Algorithm)
+		assert.Empty(t, c.Expected)
+	})
+
+	t.Run("expected checksum defaults to sha256", func(t *testing.T) {
+		c, err := NewContentChecksum(map[string]string{ChecksumMetadataKey: strings.ToUpper(helloSHA256)})
+		require.NoError(t, err)
+		assert.Equal(t, ChecksumSHA256, c.Algorithm)
+		assert.Equal(t, helloSHA256, c.Expected)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, md := range []map[string]string{
+			{ChecksumAlgorithmMetadataKey: "crc32"},
+			{ChecksumMetadataKey: "zz"},
+			{ChecksumAlgorithmMetadataKey: ChecksumSHA256, ChecksumMetadataKey: helloMD5},
+		} {
+			_, err := NewContentChecksum(md)
+			assert.Error(t, err, md)
+		}
+	})
+}
+
+func TestContentChecksumComputeAndVerify(t *testing.T) {
+	t.Run("compute", func(t *testing.T) {
+		c := &ContentChecksum{Algorithm: ChecksumMD5}
+		sum, err := c.ComputeAndVerify(strings.NewReader("hello"))
+		require.NoError(t, err)
+		assert.Equal(t, helloMD5, sum)
+	})
+
+	t.Run("match", func(t *testing.T) {
+		c := &ContentChecksum{Algorithm: ChecksumSHA256, Expected: helloSHA256}
+		sum, err := c.ComputeAndVerify(strings.NewReader("hello"))
+		require.NoError(t, err)
+		assert.Equal(t, helloSHA256, sum)
+		assert.Equal(t, map[string]string{
+			ChecksumAlgorithmMetadataKey: ChecksumSHA256,
+			ChecksumMetadataKey:          helloSHA256,
+		}, c.AddToMetadata(nil, sum))
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		c := &ContentChecksum{Algorithm: ChecksumSHA256, Expected: helloSHA256}
+		_, err := c.ComputeAndVerify(strings.NewReader("world"))
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+	})
+}
@@ -18,16 +18,22 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
+	"time"
 
 	"github.com/dapr/components-contrib/bindings"
 	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 
 	"github.com/labd/commercetools-go-sdk/platform"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
 )
 
+// Access tokens are renewed this long before they expire, so that requests in flight don't fail.
+const tokenExpiryDelta = time.Minute
+
 type Binding struct {
 	client     *platform.Client
 	logger     logger.Logger
@@ -67,16 +73,19 @@ func (ct *Binding) Init(_ context.Context, metadata bindings.Metadata) error {
 	authURL := fmt.Sprintf("https://auth.%s/oauth/token", baseURLdomain)
 	apiURL := fmt.Sprintf("https://api.%s", baseURLdomain)
 
-	// Create the new client. When an empty value is passed it will use the CTP_*
-	// environment variables to get the value. The HTTPClient arg is optional,
-	// and when empty will automatically be created using the env values.
+	credentials := &clientcredentials.Config{
+		TokenURL:     authURL,
+		ClientID:     commercetoolsM.ClientID,
+		ClientSecret: commercetoolsM.ClientSecret,
+		Scopes:       []string{commercetoolsM.Scopes},
+	}
+
+	// The access token is cached and shared by all the requests, and a new one is requested only when it's about to expire.
+	tokenSource := oauth2.ReuseTokenSourceWithExpiry(nil, credentials.TokenSource(context.Background()), tokenExpiryDelta)
 	client, err := platform.NewClient(&platform.ClientConfig{
 		URL: apiURL,
-		Credentials: &clientcredentials.Config{
-			TokenURL:     authURL,
-			ClientID:     commercetoolsM.ClientID,
-			ClientSecret: commercetoolsM.ClientSecret,
-			Scopes:       []string{commercetoolsM.Scopes},
+		HTTPClient: &http.Client{
+			Transport: &oauth2.Transport{Source: tokenSource},
 		},
 	})
 	if err != nil {
@@ -91,11 +100,31 @@ func (ct *Binding) Init(_ context.Context, metadata bindings.Metadata) error {
 }
 
 func (ct *Binding) Operations() []bindings.OperationKind {
-	return []bindings.OperationKind{bindings.CreateOperation}
+	return []bindings.OperationKind{
+		bindings.CreateOperation,
+		PublishProductOperation,
+		UnpublishProductOperation,
+		CreateCartOperation,
+		ChangeOrderStateOperation,
+		TransitionOrderStateOperation,
+	}
 }
 
 // Invoke is triggered from Dapr.
 func (ct *Binding) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	switch req.Operation {
+	case PublishProductOperation:
+		return ct.publishProduct(ctx, req.Data, true)
+	case UnpublishProductOperation:
+		return ct.publishProduct(ctx, req.Data, false)
+	case CreateCartOperation:
+		return ct.createCart(ctx, req.Data)
+	case ChangeOrderStateOperation:
+		return ct.changeOrderState(ctx, req.Data)
+	case TransitionOrderStateOperation:
+		return ct.transitionOrderState(ctx, req.Data)
+	}
+
 	var reqData Data
 	err := json.Unmarshal(req.Data, &reqData)
 	if err != nil {
@@ -14,12 +14,20 @@ limitations under the License.
 package commercetools
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/dapr/components-contrib/bindings"
 	"github.com/dapr/kit/logger"
 
+	"github.com/labd/commercetools-go-sdk/platform"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseMetadata(t *testing.T) {
@@ -39,3 +47,97 @@ func TestParseMetadata(t *testing.T) {
 		assert.Equal(t, "b", meta.Scopes)
 	})
 }
+
+func TestOperations(t *testing.T) {
+	var (
+		lastPath string
+		lastBody map[string]interface{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastPath = r.URL.Path
+		lastBody = nil
+		_ = json.NewDecoder(r.Body).Decode(&lastBody)
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/proj/carts"):
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id":"cart1","version":1}`)
+		default:
+			fmt.Fprint(w, `{"id":"res1","version":4}`)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := platform.NewClient(&platform.ClientConfig{URL: srv.URL, HTTPClient: srv.Client()})
+	require.NoError(t, err)
+	ct := &Binding{client: client, projectKey: "proj", logger: logger.NewLogger("test")}
+
+	t.Run("publish product by key", func(t *testing.T) {
+		res, err := ct.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: PublishProductOperation,
+			Data:      []byte(`{"key":"shirt","version":3,"scope":"Prices"}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "/proj/products/key=shirt", lastPath)
+		assert.Equal(t, float64(3), lastBody["version"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"action": "publish", "scope": "Prices"}}, lastBody["actions"])
+		assert.Equal(t, "res1", res.Metadata["id"])
+		assert.Equal(t, "4", res.Metadata["version"])
+	})
+
+	t.Run("unpublish product by id", func(t *testing.T) {
+		_, err := ct.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: UnpublishProductOperation,
+			Data:      []byte(`{"id":"p1","version":3}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "/proj/products/p1", lastPath)
+		assert.Equal(t, []interface{}{map[string]interface{}{"action": "unpublish"}}, lastBody["actions"])
+	})
+
+	t.Run("create cart", func(t *testing.T) {
+		res, err := ct.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: CreateCartOperation,
+			Data:      []byte(`{"currency":"EUR","customerEmail":"a@example.com"}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "/proj/carts", lastPath)
+		assert.Equal(t, "EUR", lastBody["currency"])
+		assert.Equal(t, "cart1", res.Metadata["id"])
+	})
+
+	t.Run("change order state by order number", func(t *testing.T) {
+		_, err := ct.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: ChangeOrderStateOperation,
+			Data:      []byte(`{"orderNumber":"1001","version":2,"orderState":"Confirmed"}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "/proj/orders/order-number=1001", lastPath)
+		assert.Equal(t, []interface{}{map[string]interface{}{"action": "changeOrderState", "orderState": "Confirmed"}}, lastBody["actions"])
+	})
+
+	t.Run("transition order state", func(t *testing.T) {
+		_, err := ct.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: TransitionOrderStateOperation,
+			Data:      []byte(`{"id":"o1","version":2,"stateKey":"shipped"}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "/proj/orders/o1", lastPath)
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"action": "transitionState",
+			"state":  map[string]interface{}{"typeId": "state", "key": "shipped"},
+		}}, lastBody["actions"])
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for op, data := range map[bindings.OperationKind]string{
+			PublishProductOperation:       `{"id":"p1","key":"shirt","version":1}`,
+			UnpublishProductOperation:     `{"id":"p1"}`,
+			CreateCartOperation:           `{}`,
+			ChangeOrderStateOperation:     `{"id":"o1","version":1,"orderState":"Shipped"}`,
+			TransitionOrderStateOperation: `{"id":"o1","version":1}`,
+		} {
+			_, err := ct.Invoke(context.Background(), &bindings.InvokeRequest{Operation: op, Data: []byte(data)})
+			assert.Error(t, err, op)
+		}
+	})
+}
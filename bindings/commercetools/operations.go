@@ -0,0 +1,223 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commercetools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/labd/commercetools-go-sdk/platform"
+
+	"github.com/dapr/components-contrib/bindings"
+)
+
+const (
+	// PublishProductOperation publishes the current projection of a product.
+	PublishProductOperation bindings.OperationKind = "publishProduct"
+	// UnpublishProductOperation unpublishes a product.
+	UnpublishProductOperation bindings.OperationKind = "unpublishProduct"
+	// CreateCartOperation creates a cart from a cart draft.
+	CreateCartOperation bindings.OperationKind = "createCart"
+	// ChangeOrderStateOperation changes the state of an order, such as from "Open" to "Confirmed".
+	ChangeOrderStateOperation bindings.OperationKind = "changeOrderState"
+	// TransitionOrderStateOperation transitions an order to a custom workflow state.
+	TransitionOrderStateOperation bindings.OperationKind = "transitionOrderState"
+)
+
+// resourceUpdate identifies the resource updated by an operation, by ID or by key, and its expected version.
+// Orders are identified by order number instead of key.
+type resourceUpdate struct {
+	ID          string `json:"id"`
+	Key         string `json:"key"`
+	OrderNumber string `json:"orderNumber"`
+	Version     int    `json:"version"`
+}
+
+type publishProductRequest struct {
+	resourceUpdate
+	// "All" (default) or "Prices"
+	Scope string `json:"scope"`
+}
+
+type changeOrderStateRequest struct {
+	resourceUpdate
+	OrderState string `json:"orderState"`
+}
+
+type transitionOrderStateRequest struct {
+	resourceUpdate
+	StateID  string `json:"stateId"`
+	StateKey string `json:"stateKey"`
+	Force    bool   `json:"force"`
+}
+
+func (r resourceUpdate) validate(keyField string, key string) error {
+	if (r.ID == "") == (key == "") {
+		return fmt.Errorf("commercetools error: exactly one of `id` and `%s` is required", keyField)
+	}
+	if r.Version <= 0 {
+		return errors.New("commercetools error: `version` is required")
+	}
+	return nil
+}
+
+func (ct *Binding) publishProduct(ctx context.Context, data []byte, publish bool) (*bindings.InvokeResponse, error) {
+	var req publishProductRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("commercetools error: invalid request: %w", err)
+	}
+	if err := req.validate("key", req.Key); err != nil {
+		return nil, err
+	}
+
+	var action platform.ProductUpdateAction = platform.ProductUnpublishAction{}
+	if publish {
+		publishAction := platform.ProductPublishAction{}
+		switch platform.ProductPublishScope(req.Scope) {
+		case "":
+		case platform.ProductPublishScopeAll, platform.ProductPublishScopePrices:
+			scope := platform.ProductPublishScope(req.Scope)
+			publishAction.Scope = &scope
+		default:
+			return nil, fmt.Errorf("commercetools error: invalid publish scope `%s`", req.Scope)
+		}
+		action = publishAction
+	}
+
+	update := platform.ProductUpdate{
+		Version: req.Version,
+		Actions: []platform.ProductUpdateAction{action},
+	}
+	products := ct.client.WithProjectKey(ct.projectKey).Products()
+	var (
+		product *platform.Product
+		err     error
+	)
+	if req.ID != "" {
+		product, err = products.WithId(req.ID).Post(update).Execute(ctx)
+	} else {
+		product, err = products.WithKey(req.Key).Post(update).Execute(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("commercetools error: error updating product: %w", err)
+	}
+
+	return newInvokeResponse(product)
+}
+
+func (ct *Binding) createCart(ctx context.Context, data []byte) (*bindings.InvokeResponse, error) {
+	var draft platform.CartDraft
+	if err := json.Unmarshal(data, &draft); err != nil {
+		return nil, fmt.Errorf("commercetools error: invalid cart draft: %w", err)
+	}
+	if draft.Currency == "" {
+		return nil, errors.New("commercetools error: `currency` is required")
+	}
+
+	cart, err := ct.client.WithProjectKey(ct.projectKey).Carts().Post(draft).Execute(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("commercetools error: error creating cart: %w", err)
+	}
+
+	return newInvokeResponse(cart)
+}
+
+func (ct *Binding) changeOrderState(ctx context.Context, data []byte) (*bindings.InvokeResponse, error) {
+	var req changeOrderStateRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("commercetools error: invalid request: %w", err)
+	}
+	if err := req.validate("orderNumber", req.OrderNumber); err != nil {
+		return nil, err
+	}
+	state := platform.OrderState(req.OrderState)
+	switch state {
+	case platform.OrderStateOpen, platform.OrderStateConfirmed, platform.OrderStateComplete, platform.OrderStateCancelled:
+	default:
+		return nil, fmt.Errorf("commercetools error: invalid order state `%s`", req.OrderState)
+	}
+
+	return ct.updateOrder(ctx, req.resourceUpdate, platform.OrderChangeOrderStateAction{OrderState: state})
+}
+
+func (ct *Binding) transitionOrderState(ctx context.Context, data []byte) (*bindings.InvokeResponse, error) {
+	var req transitionOrderStateRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("commercetools error: invalid request: %w", err)
+	}
+	if err := req.validate("orderNumber", req.OrderNumber); err != nil {
+		return nil, err
+	}
+	if (req.StateID == "") == (req.StateKey == "") {
+		return nil, errors.New("commercetools error: exactly one of `stateId` and `stateKey` is required")
+	}
+
+	action := platform.OrderTransitionStateAction{}
+	if req.StateID != "" {
+		action.State.ID = &req.StateID
+	} else {
+		action.State.Key = &req.StateKey
+	}
+	if req.Force {
+		action.Force = &req.Force
+	}
+
+	return ct.updateOrder(ctx, req.resourceUpdate, action)
+}
+
+func (ct *Binding) updateOrder(ctx context.Context, req resourceUpdate, action platform.OrderUpdateAction) (*bindings.InvokeResponse, error) {
+	update := platform.OrderUpdate{
+		Version: req.Version,
+		Actions: []platform.OrderUpdateAction{action},
+	}
+	orders := ct.client.WithProjectKey(ct.projectKey).Orders()
+	var (
+		order *platform.Order
+		err   error
+	)
+	if req.ID != "" {
+		order, err = orders.WithId(req.ID).Post(update).Execute(ctx)
+	} else {
+		order, err = orders.WithOrderNumber(req.OrderNumber).Post(update).Execute(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("commercetools error: error updating order: %w", err)
+	}
+
+	return newInvokeResponse(order)
+}
+
+// newInvokeResponse returns a response with the resource returned by commercetools, and its ID and version as metadata.
+func newInvokeResponse(resource interface{}) (*bindings.InvokeResponse, error) {
+	b, err := json.Marshal(resource)
+	if err != nil {
+		return nil, fmt.Errorf("commercetools error: error marshalling the response: %w", err)
+	}
+
+	res := &bindings.InvokeResponse{Data: b, Metadata: map[string]string{}}
+	switch r := resource.(type) {
+	case *platform.Product:
+		res.Metadata["id"] = r.ID
+		res.Metadata["version"] = fmt.Sprint(r.Version)
+	case *platform.Cart:
+		res.Metadata["id"] = r.ID
+		res.Metadata["version"] = fmt.Sprint(r.Version)
+	case *platform.Order:
+		res.Metadata["id"] = r.ID
+		res.Metadata["version"] = fmt.Sprint(r.Version)
+	}
+	return res, nil
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/state"
+	cron "github.com/dapr/kit/cron"
+)
+
+// CatchUpPolicy is the policy applied to the runs that were missed while the component was not running.
+type CatchUpPolicy string
+
+const (
+	// CatchUpPolicySkip ignores the missed runs.
+	CatchUpPolicySkip CatchUpPolicy = "skip"
+	// CatchUpPolicyFireOnce delivers a single event for the most recent missed run.
+	CatchUpPolicyFireOnce CatchUpPolicy = "fire-once"
+	// CatchUpPolicyFireAll delivers an event for each missed run, up to maxCatchUpRuns.
+	CatchUpPolicyFireAll CatchUpPolicy = "fire-all"
+)
+
+// maxCatchUpRuns is the maximum number of missed runs of a schedule that are delivered with the fire-all policy.
+// When more runs were missed, only the most recent ones are delivered.
+const maxCatchUpRuns = 1000
+
+// ParseCatchUpPolicy parses the value of the catchUpPolicy metadata property.
+func ParseCatchUpPolicy(val string) (CatchUpPolicy, error) {
+	switch p := CatchUpPolicy(val); p {
+	case "":
+		return CatchUpPolicySkip, nil
+	case CatchUpPolicySkip, CatchUpPolicyFireOnce, CatchUpPolicyFireAll:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid catch-up policy '%s': must be one of '%s', '%s', or '%s'", val, CatchUpPolicySkip, CatchUpPolicyFireOnce, CatchUpPolicyFireAll)
+	}
+}
+
+// lastFireRecord is the record persisted in the state store for each schedule.
+type lastFireRecord struct {
+	LastFire time.Time `json:"lastFire"`
+}
+
+// initStateStore gets the state store where the time of the last run is persisted, if stateStoreName is set.
+func (b *Binding) initStateStore() error {
+	if b.stateStoreName == "" {
+		return nil
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.store != nil {
+		return nil
+	}
+	if b.stateStoreResolver == nil {
+		return errors.New("property stateStoreName is not supported by this version of the runtime")
+	}
+	store, err := b.stateStoreResolver(b.stateStoreName)
+	if err != nil {
+		return fmt.Errorf("failed to get state store %s: %w", b.stateStoreName, err)
+	}
+	b.store = store
+	return nil
+}
+
+// lastFireKey returns the key of the state store record with the time of the last run of a schedule.
+func (b *Binding) lastFireKey(s *namedSchedule) string {
+	return "cron||" + b.name + "||" + s.Name
+}
+
+// loadLastFire returns the persisted time of the last run of a schedule, or the zero time if there's none.
+func (b *Binding) loadLastFire(ctx context.Context, s *namedSchedule) (time.Time, error) {
+	res, err := b.store.Get(ctx, &state.GetRequest{Key: b.lastFireKey(s)})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if res == nil || len(res.Data) == 0 {
+		return time.Time{}, nil
+	}
+
+	var rec lastFireRecord
+	err = json.Unmarshal(res.Data, &rec)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid record: %w", err)
+	}
+	return rec.LastFire, nil
+}
+
+// saveLastFire persists the time of the last run of a schedule, unless a more recent run was already recorded.
+func (b *Binding) saveLastFire(ctx context.Context, s *namedSchedule, t time.Time) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.store == nil || t.Before(b.lastFire[s.Name]) {
+		return nil
+	}
+	b.lastFire[s.Name] = t
+
+	data, err := json.Marshal(lastFireRecord{LastFire: t.UTC()})
+	if err != nil {
+		return err
+	}
+	return b.store.Set(ctx, &state.SetRequest{
+		Key:   b.lastFireKey(s),
+		Value: data,
+	})
+}
+
+// catchUp applies the catch-up policy to the runs of each schedule that were missed since the persisted time of the last run.
+// When there's no record for a schedule, the current time is persisted so runs missed from now on can be caught up.
+func (b *Binding) catchUp(ctx context.Context, handler bindings.Handler) {
+	if b.store == nil {
+		return
+	}
+
+	now := b.clk.Now()
+	for _, s := range b.schedules {
+		last, err := b.loadLastFire(ctx, s)
+		if err != nil {
+			b.logger.Errorf("name: %s, error loading the last run of schedule %s: %v", b.name, s.Name, err)
+			continue
+		}
+		if last.IsZero() {
+			err = b.saveLastFire(ctx, s, now)
+			if err != nil {
+				b.logger.Errorf("name: %s, error persisting the last run of schedule %s: %v", b.name, s.Name, err)
+			}
+			continue
+		}
+
+		var runs []time.Time
+		var dropped int
+		switch b.catchUpPolicy {
+		case CatchUpPolicyFireOnce:
+			runs, dropped = missedRuns(s.spec, last.In(b.location), now, 1)
+		case CatchUpPolicyFireAll:
+			runs, dropped = missedRuns(s.spec, last.In(b.location), now, maxCatchUpRuns)
+		default:
+			runs, dropped = missedRuns(s.spec, last.In(b.location), now, 0)
+		}
+		if len(runs)+dropped == 0 {
+			continue
+		}
+		b.logger.Infof("name: %s, schedule %s missed %d runs since %v, catch-up policy: %s", b.name, s.Name, len(runs)+dropped, last, b.catchUpPolicy)
+		if b.catchUpPolicy == CatchUpPolicySkip {
+			err = b.saveLastFire(ctx, s, now)
+			if err != nil {
+				b.logger.Errorf("name: %s, error persisting the last run of schedule %s: %v", b.name, s.Name, err)
+			}
+			continue
+		}
+		if b.catchUpPolicy == CatchUpPolicyFireAll && dropped > 0 {
+			b.logger.Warnf("name: %s, delivering only the last %d missed runs of schedule %s", b.name, len(runs), s.Name)
+		}
+
+		for _, t := range runs {
+			select {
+			case <-ctx.Done():
+				return
+			case <-b.closeCh:
+				return
+			default:
+			}
+			b.fire(ctx, handler, s, t, true)
+		}
+	}
+}
+
+// missedRuns returns the times after since and not after until at which the schedule should have run.
+// At most limit times are returned, keeping the most recent ones; dropped is the number of runs that were not returned.
+func missedRuns(spec cron.Schedule, since, until time.Time, limit int) (runs []time.Time, dropped int) {
+	for t := spec.Next(since); !t.IsZero() && !t.After(until); t = spec.Next(t) {
+		runs = append(runs, t)
+		if len(runs) > limit {
+			runs = runs[1:]
+			dropped++
+		}
+	}
+	return runs, dropped
+}
@@ -15,6 +15,7 @@ package cron
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
@@ -23,27 +24,78 @@ import (
 	"time"
 
 	"github.com/benbjohnson/clock"
+	"github.com/google/uuid"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/lock"
 	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
 	cron "github.com/dapr/kit/cron"
 	"github.com/dapr/kit/logger"
 )
 
+const (
+	// Name of the schedule set with the "schedule" metadata property.
+	defaultScheduleName = "default"
+
+	// Metadata keys of the events delivered by the binding.
+	timeZoneMetadataKey      = "timeZone"
+	readTimeMetadataKey      = "readTimeUTC"
+	scheduleMetadataKey      = "schedule"
+	catchUpMetadataKey       = "catchUp"
+	scheduledTimeMetadataKey = "scheduledTimeUTC"
+)
+
 // Binding represents Cron input binding.
 type Binding struct {
-	logger   logger.Logger
-	name     string
-	schedule string
-	parser   cron.Parser
-	clk      clock.Clock
-	closed   atomic.Bool
-	closeCh  chan struct{}
-	wg       sync.WaitGroup
+	logger             logger.Logger
+	name               string
+	schedules          []*namedSchedule
+	location           *time.Location
+	catchUpPolicy      CatchUpPolicy
+	stateStoreName     string
+	stateStoreResolver state.StoreResolver
+	store              state.Store
+	singleton          bool
+	singletonTTL       time.Duration
+	lockStoreName      string
+	lockStoreResolver  lock.StoreResolver
+	lockStore          lock.Store
+	instanceID         string
+	lastFire           map[string]time.Time
+	lock               sync.Mutex
+	parser             cron.Parser
+	clk                clock.Clock
+	closed             atomic.Bool
+	closeCh            chan struct{}
+	wg                 sync.WaitGroup
 }
 
 type metadata struct {
-	Schedule string
+	// Schedule of the binding, delivered with the name "default".
+	Schedule string `mapstructure:"schedule"`
+	// List of named schedules, as a JSON array of objects with the "name" and "schedule" properties.
+	Schedules string `mapstructure:"schedules"`
+	// Time zone used to evaluate the schedules, as an IANA name such as "America/New_York".
+	TimeZone string `mapstructure:"timeZone"`
+	// What to do with the runs that were missed while the component was not running.
+	CatchUpPolicy string `mapstructure:"catchUpPolicy" default:"skip"`
+	// Name of the state store where the time of the last run of each schedule is persisted.
+	StateStoreName string `mapstructure:"stateStoreName"`
+	// If true, only one replica of the app receives each run, elected using lockStoreName or stateStoreName.
+	Singleton bool `mapstructure:"singleton"`
+	// Name of the lock store component used to elect the replica that receives each run.
+	LockStoreName string `mapstructure:"lockStoreName"`
+	// How long the election of the replica that receives a run is retained.
+	SingletonTTL time.Duration `mapstructure:"singletonTTL" default:"60s" minValue:"1s"`
+}
+
+// namedSchedule is a schedule of the binding.
+type namedSchedule struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+
+	spec cron.Schedule
 }
 
 // NewCron returns a new Cron event input binding.
@@ -58,7 +110,10 @@ func NewCronWithClock(logger logger.Logger, clk clock.Clock) bindings.InputBindi
 		parser: cron.NewParser(
 			cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
 		),
-		closeCh: make(chan struct{}),
+		location:   time.Local,
+		instanceID: uuid.NewString(),
+		lastFire:   map[string]time.Time{},
+		closeCh:    make(chan struct{}),
 	}
 }
 
@@ -74,41 +129,120 @@ func (b *Binding) Init(ctx context.Context, meta bindings.Metadata) error {
 	if err != nil {
 		return err
 	}
-	if m.Schedule == "" {
+
+	schedules := []*namedSchedule{}
+	if m.Schedule != "" {
+		schedules = append(schedules, &namedSchedule{Name: defaultScheduleName, Schedule: m.Schedule})
+	}
+	if m.Schedules != "" {
+		var list []*namedSchedule
+		err = json.Unmarshal([]byte(m.Schedules), &list)
+		if err != nil {
+			return fmt.Errorf("invalid schedules, must be a JSON array of objects with the 'name' and 'schedule' properties: %w", err)
+		}
+		schedules = append(schedules, list...)
+	}
+	if len(schedules) == 0 {
 		return fmt.Errorf("schedule not set")
 	}
-	_, err = b.parser.Parse(m.Schedule)
+
+	names := make(map[string]struct{}, len(schedules))
+	for _, s := range schedules {
+		if s == nil || s.Name == "" {
+			return errors.New("every schedule must have a name")
+		}
+		if _, ok := names[s.Name]; ok {
+			return fmt.Errorf("duplicate schedule name '%s'", s.Name)
+		}
+		names[s.Name] = struct{}{}
+		s.spec, err = b.parser.Parse(s.Schedule)
+		if err != nil {
+			return fmt.Errorf("invalid schedule format '%s': %w", s.Schedule, err)
+		}
+	}
+	b.schedules = schedules
+
+	if m.TimeZone != "" {
+		b.location, err = time.LoadLocation(m.TimeZone)
+		if err != nil {
+			return fmt.Errorf("invalid time zone '%s': %w", m.TimeZone, err)
+		}
+	}
+
+	b.catchUpPolicy, err = ParseCatchUpPolicy(m.CatchUpPolicy)
 	if err != nil {
-		return fmt.Errorf("invalid schedule format '%s': %w", m.Schedule, err)
+		return err
+	}
+	if b.catchUpPolicy != CatchUpPolicySkip && m.StateStoreName == "" {
+		return fmt.Errorf("catch-up policy '%s' requires stateStoreName to be set", b.catchUpPolicy)
+	}
+	b.stateStoreName = m.StateStoreName
+
+	if m.Singleton && m.LockStoreName == "" && m.StateStoreName == "" {
+		return errors.New("singleton requires lockStoreName or stateStoreName to be set")
+	}
+	b.singleton = m.Singleton
+	b.singletonTTL = m.SingletonTTL
+	if m.Singleton {
+		b.lockStoreName = m.LockStoreName
 	}
-	b.schedule = m.Schedule
 
 	return nil
 }
 
+// SetStateStoreResolver sets the function used to get the state store where the time of the last run is persisted, when stateStoreName is set.
+func (b *Binding) SetStateStoreResolver(resolver state.StoreResolver) {
+	b.lock.Lock()
+	b.stateStoreResolver = resolver
+	b.lock.Unlock()
+}
+
+// SetLockStoreResolver sets the function used to get the lock store used to elect the replica that receives each run, when lockStoreName is set.
+func (b *Binding) SetLockStoreResolver(resolver lock.StoreResolver) {
+	b.lock.Lock()
+	b.lockStoreResolver = resolver
+	b.lock.Unlock()
+}
+
 // Read triggers the Cron scheduler.
 func (b *Binding) Read(ctx context.Context, handler bindings.Handler) error {
 	if b.closed.Load() {
 		return errors.New("binding is closed")
 	}
 
-	c := cron.New(cron.WithParser(b.parser), cron.WithClock(b.clk))
-	id, err := c.AddFunc(b.schedule, func() {
-		b.logger.Debugf("name: %s, schedule fired: %v", b.name, time.Now())
-		handler(ctx, &bindings.ReadResponse{
-			Metadata: map[string]string{
-				"timeZone":    c.Location().String(),
-				"readTimeUTC": time.Now().UTC().String(),
-			},
-		})
-	})
+	err := b.initStateStore()
+	if err != nil {
+		return err
+	}
+	err = b.initLockStore()
 	if err != nil {
-		return fmt.Errorf("name: %s, error scheduling %s: %w", b.name, b.schedule, err)
+		return err
+	}
+	if b.singleton && b.lockStore == nil && !state.FeatureETag.IsPresent(b.store.Features()) {
+		return errors.New("the state store used for singleton scheduling must support ETags")
+	}
+
+	c := cron.New(cron.WithParser(b.parser), cron.WithClock(b.clk), cron.WithLocation(b.location))
+	ids := make([]cron.EntryID, len(b.schedules))
+	for i, s := range b.schedules {
+		s := s
+		ids[i] = c.Schedule(s.spec, cron.FuncJob(func() {
+			now := b.clk.Now()
+			b.logger.Debugf("name: %s, schedule %s fired: %v", b.name, s.Name, now)
+			b.fire(ctx, handler, s, now, false)
+		}))
 	}
 	c.Start()
-	b.logger.Debugf("name: %s, next run: %v", b.name, time.Until(c.Entry(id).Next))
+	for i, s := range b.schedules {
+		b.logger.Debugf("name: %s, schedule: %s, next run: %v", b.name, s.Name, time.Until(c.Entry(ids[i]).Next))
+	}
 
-	b.wg.Add(1)
+	b.wg.Add(2)
+	go func() {
+		defer b.wg.Done()
+		// Deliver the runs that were missed while the component was not running.
+		b.catchUp(ctx, handler)
+	}()
 	go func() {
 		defer b.wg.Done()
 		// Wait for context to be canceled or component to be closed.
@@ -116,13 +250,48 @@ func (b *Binding) Read(ctx context.Context, handler bindings.Handler) error {
 		case <-ctx.Done():
 		case <-b.closeCh:
 		}
-		b.logger.Debugf("name: %s, stopping schedule: %s", b.name, b.schedule)
+		b.logger.Debugf("name: %s, stopping schedules", b.name)
 		c.Stop()
 	}()
 
 	return nil
 }
 
+// fire delivers an event for a run of a schedule and records the time of the run.
+// When singleton is enabled, the event is delivered only if this replica claims the run.
+func (b *Binding) fire(ctx context.Context, handler bindings.Handler, s *namedSchedule, scheduled time.Time, catchUp bool) {
+	claimed, err := b.claimRun(ctx, s, scheduled)
+	if err != nil {
+		b.logger.Errorf("name: %s, error claiming run of schedule %s, the run is not delivered: %v", b.name, s.Name, err)
+		return
+	}
+	if !claimed {
+		b.logger.Debugf("name: %s, run of schedule %s at %v claimed by another replica", b.name, s.Name, scheduled)
+		return
+	}
+
+	md := map[string]string{
+		timeZoneMetadataKey: b.location.String(),
+		readTimeMetadataKey: b.clk.Now().UTC().String(),
+		scheduleMetadataKey: s.Name,
+	}
+	if catchUp {
+		md[catchUpMetadataKey] = "true"
+		md[scheduledTimeMetadataKey] = scheduled.UTC().String()
+	}
+	_, err = handler(ctx, &bindings.ReadResponse{
+		Metadata: md,
+	})
+	if err != nil {
+		b.logger.Errorf("name: %s, error delivering run of schedule %s: %v", b.name, s.Name, err)
+	}
+
+	err = b.saveLastFire(ctx, s, scheduled)
+	if err != nil {
+		b.logger.Errorf("name: %s, error persisting the last run of schedule %s: %v", b.name, s.Name, err)
+	}
+}
+
 func (b *Binding) Close() error {
 	if b.closed.CompareAndSwap(false, true) {
 		close(b.closeCh)
@@ -138,3 +307,9 @@ func (b *Binding) GetComponentMetadata() map[string]string {
 	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.BindingType)
 	return metadataInfo
 }
+
+// GetComponentMetadataSchema returns the schema of the metadata of the component.
+func (b *Binding) GetComponentMetadataSchema() []contribMetadata.MetadataFieldSchema {
+	schema, _ := contribMetadata.GetMetadataSchemaFromStructType(reflect.TypeOf(metadata{}), contribMetadata.BindingType)
+	return schema
+}
@@ -15,15 +15,21 @@ package cron
 
 import (
 	"context"
+	"encoding/json"
 	"os"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/benbjohnson/clock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/lock"
+	"github.com/dapr/components-contrib/state"
+	inmemory "github.com/dapr/components-contrib/state/in-memory"
 	"github.com/dapr/kit/logger"
 )
 
@@ -149,3 +155,400 @@ func TestCronReadWithContextCancellation(t *testing.T) {
 	assert.NoErrorf(t, err, "error on read")
 	assert.NoError(t, c.Close())
 }
+
+func TestCronInitMetadata(t *testing.T) {
+	initTests := []struct {
+		name          string
+		properties    map[string]string
+		errorExpected bool
+	}{
+		{
+			name: "named schedules",
+			properties: map[string]string{
+				"schedules": `[{"name":"fast","schedule":"*/5 * * * * *"},{"name":"hourly","schedule":"@hourly"}]`,
+			},
+		},
+		{
+			name: "schedule and named schedules",
+			properties: map[string]string{
+				"schedule":  "@every 1s",
+				"schedules": `[{"name":"hourly","schedule":"@hourly"}]`,
+			},
+		},
+		{
+			name: "time zone",
+			properties: map[string]string{
+				"schedule": "0 0 9 * * *",
+				"timeZone": "America/New_York",
+			},
+		},
+		{
+			name: "catch-up policy with state store",
+			properties: map[string]string{
+				"schedule":       "@every 1s",
+				"catchUpPolicy":  "fire-all",
+				"stateStoreName": "statestore",
+			},
+		},
+		{
+			name: "singleton with lock store",
+			properties: map[string]string{
+				"schedule":      "@every 1s",
+				"singleton":     "true",
+				"lockStoreName": "lockstore",
+			},
+		},
+		{
+			name: "singleton without stores",
+			properties: map[string]string{
+				"schedule":  "@every 1s",
+				"singleton": "true",
+			},
+			errorExpected: true,
+		},
+		{
+			name:          "no schedule",
+			properties:    map[string]string{},
+			errorExpected: true,
+		},
+		{
+			name: "invalid schedules JSON",
+			properties: map[string]string{
+				"schedules": `{"name":"fast"}`,
+			},
+			errorExpected: true,
+		},
+		{
+			name: "schedule without name",
+			properties: map[string]string{
+				"schedules": `[{"schedule":"@hourly"}]`,
+			},
+			errorExpected: true,
+		},
+		{
+			name: "duplicate schedule names",
+			properties: map[string]string{
+				"schedule":  "@every 1s",
+				"schedules": `[{"name":"default","schedule":"@hourly"}]`,
+			},
+			errorExpected: true,
+		},
+		{
+			name: "invalid named schedule",
+			properties: map[string]string{
+				"schedules": `[{"name":"bad","schedule":"INVALID_SCHEDULE"}]`,
+			},
+			errorExpected: true,
+		},
+		{
+			name: "invalid time zone",
+			properties: map[string]string{
+				"schedule": "@every 1s",
+				"timeZone": "Not/AZone",
+			},
+			errorExpected: true,
+		},
+		{
+			name: "invalid catch-up policy",
+			properties: map[string]string{
+				"schedule":       "@every 1s",
+				"catchUpPolicy":  "sometimes",
+				"stateStoreName": "statestore",
+			},
+			errorExpected: true,
+		},
+		{
+			name: "catch-up policy without state store",
+			properties: map[string]string{
+				"schedule":      "@every 1s",
+				"catchUpPolicy": "fire-once",
+			},
+			errorExpected: true,
+		},
+	}
+
+	for _, test := range initTests {
+		t.Run(test.name, func(t *testing.T) {
+			c := getNewCron()
+			m := bindings.Metadata{}
+			m.Properties = test.properties
+			err := c.Init(context.Background(), m)
+			if test.errorExpected {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCronReadNamedSchedules(t *testing.T) {
+	clk := clock.NewMock()
+	c := getNewCronWithClock(clk)
+	m := bindings.Metadata{}
+	m.Properties = map[string]string{
+		"schedules": `[{"name":"every-second","schedule":"* * * * * *"},{"name":"every-two-seconds","schedule":"*/2 * * * * *"}]`,
+		"timeZone":  "Europe/Rome",
+	}
+	require.NoError(t, c.Init(context.Background(), m))
+
+	var lock sync.Mutex
+	observed := map[string]int{}
+	err := c.Read(context.Background(), func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		assert.Equal(t, "Europe/Rome", res.Metadata["timeZone"])
+		assert.Empty(t, res.Metadata["catchUp"])
+		lock.Lock()
+		observed[res.Metadata["schedule"]]++
+		lock.Unlock()
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		clk.Add(time.Second)
+	}
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return observed["every-second"] == 4 && observed["every-two-seconds"] == 2
+	}, time.Second, time.Millisecond*10)
+	assert.NoError(t, c.Close())
+}
+
+func TestCronCatchUp(t *testing.T) {
+	l := logger.NewLogger("test")
+	policyTests := []struct {
+		policy        string
+		expectedCount int
+	}{
+		{policy: "skip", expectedCount: 0},
+		{policy: "fire-once", expectedCount: 1},
+		{policy: "fire-all", expectedCount: 10},
+	}
+
+	for _, test := range policyTests {
+		t.Run(test.policy, func(t *testing.T) {
+			clk := clock.NewMock()
+			clk.Set(time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC))
+			store := inmemory.NewInMemoryStateStore(l)
+
+			// The last run was 10 seconds ago
+			data, _ := json.Marshal(lastFireRecord{LastFire: clk.Now().Add(-10 * time.Second)})
+			require.NoError(t, store.Set(context.Background(), &state.SetRequest{Key: "cron||mycron||default", Value: data}))
+
+			c := getNewCronWithClock(clk)
+			c.SetStateStoreResolver(func(name string) (state.Store, error) {
+				assert.Equal(t, "statestore", name)
+				return store, nil
+			})
+			m := bindings.Metadata{}
+			m.Name = "mycron"
+			m.Properties = map[string]string{
+				"schedule":       "* * * * * *",
+				"catchUpPolicy":  test.policy,
+				"stateStoreName": "statestore",
+			}
+			require.NoError(t, c.Init(context.Background(), m))
+
+			var lock sync.Mutex
+			scheduled := []string{}
+			err := c.Read(context.Background(), func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+				assert.Equal(t, "true", res.Metadata["catchUp"])
+				assert.Equal(t, "default", res.Metadata["schedule"])
+				lock.Lock()
+				scheduled = append(scheduled, res.Metadata["scheduledTimeUTC"])
+				lock.Unlock()
+				return nil, nil
+			})
+			require.NoError(t, err)
+
+			// The time of the last run is persisted after the catch-up
+			assert.Eventually(t, func() bool {
+				last, err := c.loadLastFire(context.Background(), c.schedules[0])
+				return err == nil && last.Equal(clk.Now())
+			}, time.Second, time.Millisecond*10)
+			require.NoError(t, c.Close())
+
+			lock.Lock()
+			defer lock.Unlock()
+			require.Len(t, scheduled, test.expectedCount)
+			if test.expectedCount > 0 {
+				// The most recent missed run is always delivered
+				assert.Equal(t, clk.Now().UTC().String(), scheduled[len(scheduled)-1])
+			}
+		})
+	}
+}
+
+func TestCronCatchUpFirstRun(t *testing.T) {
+	clk := clock.NewMock()
+	clk.Set(time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC))
+	store := inmemory.NewInMemoryStateStore(logger.NewLogger("test"))
+
+	c := getNewCronWithClock(clk)
+	c.SetStateStoreResolver(func(name string) (state.Store, error) {
+		return store, nil
+	})
+	m := bindings.Metadata{}
+	m.Name = "mycron"
+	m.Properties = map[string]string{
+		"schedule":       "@hourly",
+		"catchUpPolicy":  "fire-all",
+		"stateStoreName": "statestore",
+	}
+	require.NoError(t, c.Init(context.Background(), m))
+
+	var observedCount atomic.Int32
+	err := c.Read(context.Background(), func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		observedCount.Add(1)
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	// Without a persisted run there's nothing to catch up, but the current time is recorded
+	assert.Eventually(t, func() bool {
+		last, err := c.loadLastFire(context.Background(), c.schedules[0])
+		return err == nil && last.Equal(clk.Now())
+	}, time.Second, time.Millisecond*10)
+	require.NoError(t, c.Close())
+	assert.Equal(t, int32(0), observedCount.Load())
+}
+
+func TestCronReadStateStoreNotAvailable(t *testing.T) {
+	c := getNewCron()
+	m := bindings.Metadata{}
+	m.Properties = map[string]string{
+		"schedule":       "@every 1s",
+		"catchUpPolicy":  "fire-once",
+		"stateStoreName": "statestore",
+	}
+	require.NoError(t, c.Init(context.Background(), m))
+
+	err := c.Read(context.Background(), func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		return nil, nil
+	})
+	require.Error(t, err)
+	assert.NoError(t, c.Close())
+}
+
+func TestMissedRuns(t *testing.T) {
+	c := getNewCron()
+	spec, err := c.parser.Parse("*/10 * * * * *")
+	require.NoError(t, err)
+	since := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	runs, dropped := missedRuns(spec, since, since.Add(time.Minute), 100)
+	assert.Len(t, runs, 6)
+	assert.Equal(t, 0, dropped)
+	assert.Equal(t, since.Add(10*time.Second), runs[0])
+	assert.Equal(t, since.Add(time.Minute), runs[5])
+
+	runs, dropped = missedRuns(spec, since, since.Add(time.Minute), 2)
+	assert.Equal(t, []time.Time{since.Add(50 * time.Second), since.Add(time.Minute)}, runs)
+	assert.Equal(t, 4, dropped)
+
+	runs, dropped = missedRuns(spec, since, since.Add(5*time.Second), 2)
+	assert.Empty(t, runs)
+	assert.Equal(t, 0, dropped)
+}
+
+// fakeLockStore is a lock store that keeps locks in memory and never expires them.
+type fakeLockStore struct {
+	lock  sync.Mutex
+	locks map[string]string
+}
+
+func (f *fakeLockStore) InitLockStore(ctx context.Context, metadata lock.Metadata) error {
+	return nil
+}
+
+func (f *fakeLockStore) TryLock(ctx context.Context, req *lock.TryLockRequest) (*lock.TryLockResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if _, ok := f.locks[req.ResourceID]; ok {
+		return &lock.TryLockResponse{Success: false}, nil
+	}
+	f.locks[req.ResourceID] = req.LockOwner
+	return &lock.TryLockResponse{Success: true}, nil
+}
+
+func (f *fakeLockStore) Unlock(ctx context.Context, req *lock.UnlockRequest) (*lock.UnlockResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.locks, req.ResourceID)
+	return &lock.UnlockResponse{Status: lock.Success}, nil
+}
+
+func (f *fakeLockStore) GetComponentMetadata() map[string]string {
+	return map[string]string{}
+}
+
+func TestCronSingleton(t *testing.T) {
+	l := logger.NewLogger("test")
+	storeTests := []struct {
+		name       string
+		properties map[string]string
+	}{
+		{
+			name: "state store",
+			properties: map[string]string{
+				"schedule":       "* * * * * *",
+				"singleton":      "true",
+				"stateStoreName": "statestore",
+			},
+		},
+		{
+			name: "lock store",
+			properties: map[string]string{
+				"schedule":      "* * * * * *",
+				"singleton":     "true",
+				"lockStoreName": "lockstore",
+			},
+		},
+	}
+
+	for _, test := range storeTests {
+		t.Run(test.name, func(t *testing.T) {
+			clk := clock.NewMock()
+			clk.Set(time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC))
+			stateStore := inmemory.NewInMemoryStateStore(l)
+			lockStore := &fakeLockStore{locks: map[string]string{}}
+
+			// Start two replicas of the binding
+			var observedCount atomic.Int32
+			replicas := make([]*Binding, 2)
+			for i := range replicas {
+				c := getNewCronWithClock(clk)
+				c.SetStateStoreResolver(func(name string) (state.Store, error) {
+					return stateStore, nil
+				})
+				c.SetLockStoreResolver(func(name string) (lock.Store, error) {
+					assert.Equal(t, "lockstore", name)
+					return lockStore, nil
+				})
+				m := bindings.Metadata{}
+				m.Name = "mycron"
+				m.Properties = test.properties
+				require.NoError(t, c.Init(context.Background(), m))
+				require.NoError(t, c.Read(context.Background(), func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+					observedCount.Add(1)
+					return nil, nil
+				}))
+				replicas[i] = c
+			}
+
+			for i := 0; i < 5; i++ {
+				clk.Add(time.Second)
+			}
+
+			// Each run is delivered by one replica only
+			assert.Eventually(t, func() bool {
+				return observedCount.Load() == 5
+			}, time.Second, time.Millisecond*10)
+			for _, c := range replicas {
+				require.NoError(t, c.Close())
+			}
+			assert.Equal(t, int32(5), observedCount.Load())
+		})
+	}
+}
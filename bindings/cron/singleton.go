@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dapr/components-contrib/lock"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+)
+
+// initLockStore gets the lock store used to elect the replica that delivers each run, if lockStoreName is set.
+func (b *Binding) initLockStore() error {
+	if b.lockStoreName == "" {
+		return nil
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.lockStore != nil {
+		return nil
+	}
+	if b.lockStoreResolver == nil {
+		return errors.New("property lockStoreName is not supported by this version of the runtime")
+	}
+	store, err := b.lockStoreResolver(b.lockStoreName)
+	if err != nil {
+		return fmt.Errorf("failed to get lock store %s: %w", b.lockStoreName, err)
+	}
+	b.lockStore = store
+	return nil
+}
+
+// runKey returns the key used to elect the replica that delivers the run of a schedule at the given time.
+// The time is truncated to the second, so replicas whose clocks are slightly off still agree on the key.
+func (b *Binding) runKey(s *namedSchedule, scheduled time.Time) string {
+	return "cron||" + b.name + "||" + s.Name + "||run||" + strconv.FormatInt(scheduled.Truncate(time.Second).Unix(), 10)
+}
+
+// claimRun returns true if this replica must deliver the run of a schedule at the given time.
+// When singleton is disabled, every replica delivers every run.
+// Otherwise, the run is claimed with a lock in the lock store, or with a first-write record in the state store.
+// Claims are never released, and expire after singletonTTL.
+func (b *Binding) claimRun(ctx context.Context, s *namedSchedule, scheduled time.Time) (bool, error) {
+	if !b.singleton {
+		return true, nil
+	}
+
+	key := b.runKey(s, scheduled)
+	if b.lockStore != nil {
+		res, err := b.lockStore.TryLock(ctx, &lock.TryLockRequest{
+			ResourceID:      key,
+			LockOwner:       b.instanceID,
+			ExpiryInSeconds: int32(b.singletonTTL / time.Second),
+		})
+		if err != nil {
+			return false, err
+		}
+		return res.Success, nil
+	}
+
+	req := &state.SetRequest{
+		Key:   key,
+		Value: []byte(b.instanceID),
+		Metadata: map[string]string{
+			contribMetadata.TTLMetadataKey: strconv.FormatInt(int64(b.singletonTTL/time.Second), 10),
+		},
+	}
+	req.Options.Concurrency = state.FirstWrite
+	err := b.store.Set(ctx, req)
+	if err != nil {
+		// Another replica claimed the run already
+		var etagErr *state.ETagError
+		if errors.As(err, &etagErr) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
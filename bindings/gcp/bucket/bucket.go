@@ -20,12 +20,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"reflect"
 	"strconv"
 
 	"cloud.google.com/go/storage"
 	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 
@@ -51,6 +54,10 @@ type GCPStorage struct {
 	metadata *gcpMetadata
 	client   *storage.Client
 	logger   logger.Logger
+
+	// httpClient is authenticated with the credentials of the component; it's used for the upload sessions, which aren't supported by the storage client.
+	httpClient     *http.Client
+	uploadEndpoint string
 }
 
 type gcpMetadata struct {
@@ -81,7 +88,10 @@ type createResponse struct {
 
 // NewGCPStorage returns a new GCP storage instance.
 func NewGCPStorage(logger logger.Logger) bindings.OutputBinding {
-	return &GCPStorage{logger: logger}
+	return &GCPStorage{
+		logger:         logger,
+		uploadEndpoint: defaultUploadEndpoint,
+	}
 }
 
 // Init performs connection parsing.
@@ -102,8 +112,14 @@ func (g *GCPStorage) Init(ctx context.Context, metadata bindings.Metadata) error
 		return err
 	}
 
+	creds, err := google.CredentialsFromJSON(ctx, b, storage.ScopeReadWrite)
+	if err != nil {
+		return err
+	}
+
 	g.metadata = m
 	g.client = client
+	g.httpClient = oauth2.NewClient(context.Background(), creds.TokenSource)
 
 	return nil
 }
@@ -124,6 +140,8 @@ func (g *GCPStorage) Operations() []bindings.OperationKind {
 		bindings.GetOperation,
 		bindings.DeleteOperation,
 		bindings.ListOperation,
+		CreateUploadSessionOperation,
+		FinalizeUploadOperation,
 	}
 }
 
@@ -139,6 +157,10 @@ func (g *GCPStorage) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*
 		return g.delete(ctx, req)
 	case bindings.ListOperation:
 		return g.list(ctx, req)
+	case CreateUploadSessionOperation:
+		return g.createUploadSession(ctx, req)
+	case FinalizeUploadOperation:
+		return g.finalizeUpload(ctx, req)
 	default:
 		return nil, fmt.Errorf("unsupported operation %s", req.Operation)
 	}
@@ -164,11 +186,21 @@ func (g *GCPStorage) create(ctx context.Context, req *bindings.InvokeRequest) (*
 		req.Data = []byte(d)
 	}
 
-	var r io.Reader = bytes.NewReader(req.Data)
-	if metadata.DecodeBase64 {
-		r = b64.NewDecoder(b64.StdEncoding, r)
+	checksum, err := bindings.NewContentChecksum(req.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("gcp bucket binding error: %w", err)
+	}
+
+	// The checksum is verified before uploading, so that objects whose content doesn't match it are never created
+	var sum string
+	if checksum != nil {
+		sum, err = checksum.ComputeAndVerify(metadata.contentReader(req.Data))
+		if err != nil {
+			return nil, fmt.Errorf("gcp bucket binding error: %w", err)
+		}
 	}
 
+	r := metadata.contentReader(req.Data)
 	h := g.client.Bucket(g.metadata.Bucket).Object(name).NewWriter(ctx)
 	defer h.Close()
 	if _, err = io.Copy(h, r); err != nil {
@@ -189,8 +221,14 @@ func (g *GCPStorage) create(ctx context.Context, req *bindings.InvokeRequest) (*
 		return nil, fmt.Errorf("gcp binding error. error marshalling create response: %w", err)
 	}
 
+	var responseMetadata map[string]string
+	if checksum != nil {
+		responseMetadata = checksum.AddToMetadata(nil, sum)
+	}
+
 	return &bindings.InvokeResponse{
-		Data: b,
+		Data:     b,
+		Metadata: responseMetadata,
 	}, nil
 }
 
@@ -207,6 +245,11 @@ func (g *GCPStorage) get(ctx context.Context, req *bindings.InvokeRequest) (*bin
 		return nil, fmt.Errorf("gcp bucket binding error: can't read key value")
 	}
 
+	checksum, err := bindings.NewContentChecksum(req.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("gcp bucket binding error: %w", err)
+	}
+
 	var rc io.ReadCloser
 	rc, err = g.client.Bucket(g.metadata.Bucket).Object(key).NewReader(ctx)
 	if err != nil {
@@ -219,6 +262,15 @@ func (g *GCPStorage) get(ctx context.Context, req *bindings.InvokeRequest) (*bin
 		return nil, fmt.Errorf("gcp bucketgcp bucket binding error: io.ReadAll: %v", err)
 	}
 
+	var responseMetadata map[string]string
+	if checksum != nil {
+		sum, err := checksum.ComputeAndVerify(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("gcp bucket binding error: %w", err)
+		}
+		responseMetadata = checksum.AddToMetadata(nil, sum)
+	}
+
 	if metadata.EncodeBase64 {
 		encoded := b64.StdEncoding.EncodeToString(data)
 		data = []byte(encoded)
@@ -226,7 +278,7 @@ func (g *GCPStorage) get(ctx context.Context, req *bindings.InvokeRequest) (*bin
 
 	return &bindings.InvokeResponse{
 		Data:     data,
-		Metadata: nil,
+		Metadata: responseMetadata,
 	}, nil
 }
 
@@ -301,6 +353,15 @@ func (metadata gcpMetadata) mergeWithRequestMetadata(req *bindings.InvokeRequest
 }
 
 // Add backward compatibility. 'key' replace 'name'.
+// contentReader returns a reader of the content of an object to create, decoding it from base64 if needed.
+func (metadata gcpMetadata) contentReader(data []byte) io.Reader {
+	var r io.Reader = bytes.NewReader(data)
+	if metadata.DecodeBase64 {
+		r = b64.NewDecoder(b64.StdEncoding, r)
+	}
+	return r
+}
+
 func (g *GCPStorage) handleBackwardCompatibilityForMetadata(metadata map[string]string) map[string]string {
 	if val, ok := metadata[metadataKeyBC]; ok && val != "" {
 		metadata[metadataKey] = val
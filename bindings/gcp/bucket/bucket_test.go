@@ -15,9 +15,14 @@ package bucket
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"cloud.google.com/go/storage"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/bindings"
 	"github.com/dapr/kit/logger"
@@ -238,3 +243,125 @@ func TestDeleteOption(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestCreateUploadSession(t *testing.T) {
+	var received *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		w.Header().Set("Location", "https://storage.googleapis.com/upload/session/abc")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	gs := GCPStorage{
+		logger:         logger.NewLogger("test"),
+		metadata:       &gcpMetadata{Bucket: "my-bucket"},
+		httpClient:     server.Client(),
+		uploadEndpoint: server.URL,
+	}
+
+	resp, err := gs.createUploadSession(context.Background(), &bindings.InvokeRequest{
+		Data:     []byte(`{"contentType":"image/png","origin":"https://example.com","size":1024}`),
+		Metadata: map[string]string{metadataKey: "photos/cat.png"},
+	})
+	require.NoError(t, err)
+
+	var session uploadSessionResponse
+	require.NoError(t, json.Unmarshal(resp.Data, &session))
+	assert.Equal(t, "photos/cat.png", session.Key)
+	assert.Equal(t, "https://storage.googleapis.com/upload/session/abc", session.SessionURI)
+
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.Equal(t, "/b/my-bucket/o", received.URL.Path)
+	assert.Equal(t, "resumable", received.URL.Query().Get("uploadType"))
+	assert.Equal(t, "photos/cat.png", received.URL.Query().Get("name"))
+	assert.Equal(t, "image/png", received.Header.Get("X-Upload-Content-Type"))
+	assert.Equal(t, "1024", received.Header.Get("X-Upload-Content-Length"))
+	assert.Equal(t, "https://example.com", received.Header.Get("Origin"))
+}
+
+func TestUploadSessionStatus(t *testing.T) {
+	status := statusResumeIncomplete
+	rangeHeader := "bytes=0-262143"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "bytes */*", r.Header.Get("Content-Range"))
+		if rangeHeader != "" {
+			w.Header().Set("Range", rangeHeader)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	gs := GCPStorage{
+		logger:     logger.NewLogger("test"),
+		metadata:   &gcpMetadata{Bucket: "my-bucket"},
+		httpClient: server.Client(),
+	}
+
+	t.Run("incomplete", func(t *testing.T) {
+		resp, err := gs.finalizeUpload(context.Background(), &bindings.InvokeRequest{
+			Data:     []byte(`{"sessionURI":"` + server.URL + `/session"}`),
+			Metadata: map[string]string{metadataKey: "photos/cat.png"},
+		})
+		require.NoError(t, err)
+
+		var res finalizeUploadResponse
+		require.NoError(t, json.Unmarshal(resp.Data, &res))
+		assert.False(t, res.Completed)
+		assert.Equal(t, int64(262144), res.UploadedBytes)
+	})
+
+	t.Run("nothing uploaded", func(t *testing.T) {
+		rangeHeader = ""
+		completed, uploadedBytes, err := gs.uploadSessionStatus(context.Background(), server.URL+"/session")
+		require.NoError(t, err)
+		assert.False(t, completed)
+		assert.Equal(t, int64(0), uploadedBytes)
+	})
+
+	t.Run("completed", func(t *testing.T) {
+		status = http.StatusOK
+		completed, _, err := gs.uploadSessionStatus(context.Background(), server.URL+"/session")
+		require.NoError(t, err)
+		assert.True(t, completed)
+	})
+
+	t.Run("session expired", func(t *testing.T) {
+		status = http.StatusNotFound
+		_, _, err := gs.uploadSessionStatus(context.Background(), server.URL+"/session")
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifyUploadedObject(t *testing.T) {
+	attrs := &storage.ObjectAttrs{
+		Size: 3,
+		// Hashes of "foo"
+		MD5:    []byte{0xac, 0xbd, 0x18, 0xdb, 0x4c, 0xc2, 0xf8, 0x5c, 0xed, 0xef, 0x65, 0x4f, 0xcc, 0xc4, 0xa4, 0xd8},
+		CRC32C: 0xcfc4ae1d,
+	}
+	size := int64(3)
+	wrongSize := int64(4)
+
+	assert.NoError(t, verifyUploadedObject(&finalizeUploadPayload{}, attrs))
+	assert.NoError(t, verifyUploadedObject(&finalizeUploadPayload{
+		Size:   &size,
+		MD5:    "rL0Y20zC+Fzt72VPzMSk2A==",
+		CRC32C: "z8SuHQ==",
+	}, attrs))
+	assert.ErrorContains(t, verifyUploadedObject(&finalizeUploadPayload{Size: &wrongSize}, attrs), "size mismatch")
+	assert.ErrorContains(t, verifyUploadedObject(&finalizeUploadPayload{MD5: "AAAA"}, attrs), "md5 mismatch")
+	assert.ErrorContains(t, verifyUploadedObject(&finalizeUploadPayload{CRC32C: "AAAAAA=="}, attrs), "crc32c mismatch")
+}
+
+func TestFinalizeUploadOption(t *testing.T) {
+	gs := GCPStorage{logger: logger.NewLogger("test")}
+	gs.metadata = &gcpMetadata{}
+
+	t.Run("return error if key is missing", func(t *testing.T) {
+		r := bindings.InvokeRequest{}
+		_, err := gs.finalizeUpload(context.TODO(), &r)
+		assert.Error(t, err)
+	})
+}
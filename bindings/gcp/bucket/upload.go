@@ -0,0 +1,272 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bucket
+
+import (
+	"bytes"
+	"context"
+	b64 "encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/google/uuid"
+
+	"github.com/dapr/components-contrib/bindings"
+)
+
+const (
+	// CreateUploadSessionOperation initiates a resumable upload session, whose URI is returned so clients can upload the object directly in chunks.
+	CreateUploadSessionOperation bindings.OperationKind = "createUploadSession"
+	// FinalizeUploadOperation checks the status of a resumable upload and verifies the uploaded object.
+	FinalizeUploadOperation bindings.OperationKind = "finalizeUpload"
+
+	defaultUploadEndpoint = "https://storage.googleapis.com/upload/storage/v1"
+
+	// Status code returned by the upload sessions that aren't completed.
+	statusResumeIncomplete = 308
+)
+
+type uploadSessionPayload struct {
+	// Content type of the object.
+	ContentType string `json:"contentType"`
+	// Origin of the clients that upload the object, required for cross-origin requests from browsers.
+	Origin string `json:"origin"`
+	// Size of the object, in bytes, if known.
+	Size int64 `json:"size"`
+}
+
+type uploadSessionResponse struct {
+	Key        string `json:"key"`
+	SessionURI string `json:"sessionURI"`
+}
+
+type finalizeUploadPayload struct {
+	// URI of the upload session; when set, the upload must be completed.
+	SessionURI string `json:"sessionURI"`
+	// Expected size of the object, in bytes.
+	Size *int64 `json:"size"`
+	// Expected base64-encoded MD5 hash of the object.
+	MD5 string `json:"md5"`
+	// Expected base64-encoded CRC32C checksum of the object, in big-endian byte order.
+	CRC32C string `json:"crc32c"`
+}
+
+type finalizeUploadResponse struct {
+	Completed     bool   `json:"completed"`
+	UploadedBytes int64  `json:"uploadedBytes,omitempty"`
+	ObjectURL     string `json:"objectURL,omitempty"`
+	Size          int64  `json:"size,omitempty"`
+	MD5           string `json:"md5,omitempty"`
+	CRC32C        string `json:"crc32c,omitempty"`
+}
+
+func (g *GCPStorage) createUploadSession(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	var payload uploadSessionPayload
+	if len(req.Data) > 0 {
+		err := json.Unmarshal(req.Data, &payload)
+		if err != nil {
+			return nil, fmt.Errorf("gcp bucket binding error. invalid upload session payload: %w", err)
+		}
+	}
+
+	var name string
+	if val, ok := req.Metadata[metadataKey]; ok && val != "" {
+		name = val
+	} else {
+		name = uuid.New().String()
+		g.logger.Debugf("key not found. generating name %s", name)
+	}
+
+	body, err := json.Marshal(map[string]string{"contentType": payload.ContentType})
+	if err != nil {
+		return nil, err
+	}
+	u := fmt.Sprintf("%s/b/%s/o?uploadType=resumable&name=%s", g.uploadEndpoint, url.PathEscape(g.metadata.Bucket), url.QueryEscape(name))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	if payload.ContentType != "" {
+		httpReq.Header.Set("X-Upload-Content-Type", payload.ContentType)
+	}
+	if payload.Size > 0 {
+		httpReq.Header.Set("X-Upload-Content-Length", strconv.FormatInt(payload.Size, 10))
+	}
+	if payload.Origin != "" {
+		httpReq.Header.Set("Origin", payload.Origin)
+	}
+
+	res, err := g.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("gcp bucket binding error. error initiating upload session: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("gcp bucket binding error. error initiating upload session: status %d: %s", res.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	sessionURI := res.Header.Get("Location")
+	if sessionURI == "" {
+		return nil, errors.New("gcp bucket binding error. error initiating upload session: no session URI in the response")
+	}
+
+	b, err := json.Marshal(uploadSessionResponse{
+		Key:        name,
+		SessionURI: sessionURI,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gcp binding error. error marshalling upload session response: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: b,
+	}, nil
+}
+
+func (g *GCPStorage) finalizeUpload(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	var key string
+	if val, ok := req.Metadata[metadataKey]; ok && val != "" {
+		key = val
+	} else {
+		return nil, fmt.Errorf("gcp bucket binding error: can't read key value")
+	}
+
+	var payload finalizeUploadPayload
+	if len(req.Data) > 0 {
+		err := json.Unmarshal(req.Data, &payload)
+		if err != nil {
+			return nil, fmt.Errorf("gcp bucket binding error. invalid finalize upload payload: %w", err)
+		}
+	}
+
+	var resp finalizeUploadResponse
+	if payload.SessionURI != "" {
+		completed, uploadedBytes, err := g.uploadSessionStatus(ctx, payload.SessionURI)
+		if err != nil {
+			return nil, err
+		}
+		if !completed {
+			return marshalFinalizeUploadResponse(finalizeUploadResponse{
+				Completed:     false,
+				UploadedBytes: uploadedBytes,
+			})
+		}
+	}
+
+	attrs, err := g.client.Bucket(g.metadata.Bucket).Object(key).Attrs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gcp bucket binding error: error reading object attributes: %w", err)
+	}
+
+	resp.Completed = true
+	resp.Size = attrs.Size
+	resp.ObjectURL = fmt.Sprintf(objectURLBase, g.metadata.Bucket, key)
+	if len(attrs.MD5) > 0 {
+		resp.MD5 = b64.StdEncoding.EncodeToString(attrs.MD5)
+	}
+	resp.CRC32C = encodeCRC32C(attrs.CRC32C)
+
+	err = verifyUploadedObject(&payload, attrs)
+	if err != nil {
+		return nil, fmt.Errorf("gcp bucket binding error: %w", err)
+	}
+
+	return marshalFinalizeUploadResponse(resp)
+}
+
+// uploadSessionStatus queries an upload session for its status.
+// It returns whether the upload is completed and, if it isn't, how many bytes have been persisted.
+func (g *GCPStorage) uploadSessionStatus(ctx context.Context, sessionURI string) (completed bool, uploadedBytes int64, err error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPut, sessionURI, nil)
+	if err != nil {
+		return false, 0, fmt.Errorf("gcp bucket binding error. invalid session URI: %w", err)
+	}
+	httpReq.Header.Set("Content-Range", "bytes */*")
+
+	res, err := g.httpClient.Do(httpReq)
+	if err != nil {
+		return false, 0, fmt.Errorf("gcp bucket binding error. error checking upload session status: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return true, 0, nil
+	case statusResumeIncomplete:
+		// The Range header is "bytes=0-<last byte>", and it's absent if no byte has been persisted yet
+		if r := res.Header.Get("Range"); r != "" {
+			_, last, ok := strings.Cut(r, "-")
+			if !ok {
+				return false, 0, fmt.Errorf("gcp bucket binding error. invalid range in upload session status: %s", r)
+			}
+			lastByte, err := strconv.ParseInt(last, 10, 64)
+			if err != nil {
+				return false, 0, fmt.Errorf("gcp bucket binding error. invalid range in upload session status: %s", r)
+			}
+			uploadedBytes = lastByte + 1
+		}
+		return false, uploadedBytes, nil
+	default:
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return false, 0, fmt.Errorf("gcp bucket binding error. error checking upload session status: status %d: %s", res.StatusCode, strings.TrimSpace(string(msg)))
+	}
+}
+
+// verifyUploadedObject checks that the object matches the size and hashes the client expects.
+func verifyUploadedObject(payload *finalizeUploadPayload, attrs *storage.ObjectAttrs) error {
+	if payload.Size != nil && *payload.Size != attrs.Size {
+		return fmt.Errorf("size mismatch: expected %d bytes, object has %d", *payload.Size, attrs.Size)
+	}
+	if payload.MD5 != "" {
+		md5 := b64.StdEncoding.EncodeToString(attrs.MD5)
+		if payload.MD5 != md5 {
+			return fmt.Errorf("md5 mismatch: expected %s, object has %s", payload.MD5, md5)
+		}
+	}
+	if payload.CRC32C != "" {
+		crc32c := encodeCRC32C(attrs.CRC32C)
+		if payload.CRC32C != crc32c {
+			return fmt.Errorf("crc32c mismatch: expected %s, object has %s", payload.CRC32C, crc32c)
+		}
+	}
+
+	return nil
+}
+
+// encodeCRC32C encodes a CRC32C checksum the way the GCS APIs do: base64, in big-endian byte order.
+func encodeCRC32C(crc32c uint32) string {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, crc32c)
+	return b64.StdEncoding.EncodeToString(b)
+}
+
+func marshalFinalizeUploadResponse(resp finalizeUploadResponse) (*bindings.InvokeResponse, error) {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("gcp binding error. error marshalling finalize upload response: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: b,
+	}, nil
+}
@@ -0,0 +1,641 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firestore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/utils"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// SetOperation creates or overwrites a document.
+	SetOperation bindings.OperationKind = "set"
+	// UpdateOperation updates fields of an existing document.
+	UpdateOperation bindings.OperationKind = "update"
+	// QueryOperation queries the documents of a collection or a collection group.
+	QueryOperation bindings.OperationKind = "query"
+
+	// Request metadata that identifies the document: "path", or "collection" and "id".
+	metadataPath       = "path"
+	metadataCollection = "collection"
+	metadataID         = "id"
+	// Request metadata of the set operation to merge the fields with the ones of the existing document, instead of overwriting it.
+	metadataMerge = "merge"
+	// Request metadata of the update and delete operations with the time the document must have been last updated at, for optimistic concurrency.
+	// It is returned in the response metadata of the get operation.
+	metadataUpdateTime = "updateTime"
+	metadataCreateTime = "createTime"
+)
+
+// Firestore allows performing document operations on GCP Firestore.
+type Firestore struct {
+	metadata *firestoreMetadata
+	client   *firestore.Client
+	logger   logger.Logger
+}
+
+type firestoreMetadata struct {
+	Type                string `json:"type" mapstructure:"type"`
+	ProjectID           string `json:"project_id" mapstructure:"project_id"`
+	PrivateKeyID        string `json:"private_key_id" mapstructure:"private_key_id"`
+	PrivateKey          string `json:"private_key" mapstructure:"private_key"`
+	ClientEmail         string `json:"client_email" mapstructure:"client_email"`
+	ClientID            string `json:"client_id" mapstructure:"client_id"`
+	AuthURI             string `json:"auth_uri" mapstructure:"auth_uri"`
+	TokenURI            string `json:"token_uri" mapstructure:"token_uri"`
+	AuthProviderCertURL string `json:"auth_provider_x509_cert_url" mapstructure:"auth_provider_x509_cert_url"`
+	ClientCertURL       string `json:"client_x509_cert_url" mapstructure:"client_x509_cert_url"`
+	// Address of the Firestore emulator; if set, requests are not authenticated.
+	Endpoint string `json:"-" mapstructure:"endpoint"`
+	// Collection of the documents when requests don't set the "collection" or "path" metadata.
+	Collection string `json:"-" mapstructure:"collection"`
+}
+
+// document is a document returned by the query operation.
+type document struct {
+	ID         string                 `json:"id"`
+	Path       string                 `json:"path"`
+	Data       map[string]interface{} `json:"data"`
+	CreateTime time.Time              `json:"createTime"`
+	UpdateTime time.Time              `json:"updateTime"`
+}
+
+type queryFilter struct {
+	Field string      `json:"field"`
+	Op    string      `json:"op"`
+	Value interface{} `json:"value"`
+}
+
+type queryOrder struct {
+	Field string `json:"field"`
+	// "asc" (default) or "desc".
+	Direction string `json:"direction"`
+}
+
+type queryPayload struct {
+	// Collection to query; it can be a nested collection, like "users/alice/orders".
+	Collection string `json:"collection"`
+	// ID of the collections to query regardless of their parent document, instead of Collection.
+	CollectionGroup string        `json:"collectionGroup"`
+	Where           []queryFilter `json:"where"`
+	OrderBy         []queryOrder  `json:"orderBy"`
+	Select          []string      `json:"select"`
+	Limit           int           `json:"limit"`
+	// Cursors, with the values of the fields in OrderBy.
+	StartAt    []interface{} `json:"startAt"`
+	StartAfter []interface{} `json:"startAfter"`
+	EndAt      []interface{} `json:"endAt"`
+	EndBefore  []interface{} `json:"endBefore"`
+	// Path of the document after which the results start, as returned in nextCursor by a previous query.
+	StartAfterDocument string `json:"startAfterDocument"`
+}
+
+type queryResponse struct {
+	Documents []document `json:"documents"`
+	// Path of the last document, set if the number of results reached the limit; it can be used in startAfterDocument to get the next page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// NewFirestore returns a new GCP Firestore output binding instance.
+func NewFirestore(logger logger.Logger) bindings.OutputBinding {
+	return &Firestore{logger: logger}
+}
+
+// Init performs metadata parsing and creates the client.
+func (f *Firestore) Init(ctx context.Context, metadata bindings.Metadata) error {
+	m, err := parseMetadata(metadata)
+	if err != nil {
+		return err
+	}
+
+	var opts []option.ClientOption
+	switch {
+	case m.Endpoint != "":
+		f.logger.Debugf("Connecting to the Firestore emulator at %s", m.Endpoint)
+		opts = append(opts,
+			option.WithEndpoint(m.Endpoint),
+			option.WithoutAuthentication(),
+			option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		)
+	case m.PrivateKeyID != "":
+		b, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, option.WithCredentialsJSON(b))
+	default:
+		f.logger.Debugf("Using implicit credentials for GCP")
+	}
+
+	client, err := firestore.NewClient(ctx, m.ProjectID, opts...)
+	if err != nil {
+		return fmt.Errorf("gcp firestore binding error: failed to create client: %w", err)
+	}
+
+	f.metadata = m
+	f.client = client
+
+	return nil
+}
+
+func parseMetadata(meta bindings.Metadata) (*firestoreMetadata, error) {
+	m := firestoreMetadata{}
+	err := contribMetadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.ProjectID == "" {
+		return nil, errors.New("gcp firestore binding error: missing project_id")
+	}
+
+	return &m, nil
+}
+
+func (f *Firestore) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{
+		bindings.GetOperation,
+		bindings.CreateOperation,
+		SetOperation,
+		UpdateOperation,
+		bindings.DeleteOperation,
+		QueryOperation,
+	}
+}
+
+func (f *Firestore) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	switch req.Operation {
+	case bindings.GetOperation:
+		return f.get(ctx, req)
+	case bindings.CreateOperation:
+		return f.create(ctx, req)
+	case SetOperation:
+		return f.set(ctx, req)
+	case UpdateOperation:
+		return f.update(ctx, req)
+	case bindings.DeleteOperation:
+		return f.delete(ctx, req)
+	case QueryOperation:
+		return f.query(ctx, req)
+	default:
+		return nil, fmt.Errorf("gcp firestore binding error: unsupported operation %s", req.Operation)
+	}
+}
+
+func (f *Firestore) get(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	ref, err := f.docRef(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := ref.Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, fmt.Errorf("gcp firestore binding error: document %s not found", relativePath(ref))
+	} else if err != nil {
+		return nil, fmt.Errorf("gcp firestore binding error: failed to get document %s: %w", relativePath(ref), err)
+	}
+
+	data, err := json.Marshal(toJSONValue(snap.Data()))
+	if err != nil {
+		return nil, fmt.Errorf("gcp firestore binding error: failed to marshal document %s: %w", relativePath(ref), err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: data,
+		Metadata: map[string]string{
+			metadataID:         ref.ID,
+			metadataPath:       relativePath(ref),
+			metadataCreateTime: snap.CreateTime.Format(time.RFC3339Nano),
+			metadataUpdateTime: snap.UpdateTime.Format(time.RFC3339Nano),
+		},
+	}, nil
+}
+
+// create creates a document, failing if it already exists.
+// If the request doesn't set the id or path of the document, a random id is generated.
+func (f *Firestore) create(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	data, err := decodeDocument(req.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref *firestore.DocumentRef
+	if req.Metadata[metadataPath] == "" && req.Metadata[metadataID] == "" {
+		coll, cerr := f.collectionRef(req.Metadata[metadataCollection])
+		if cerr != nil {
+			return nil, cerr
+		}
+		ref = coll.NewDoc()
+	} else {
+		ref, err = f.docRef(req.Metadata)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := ref.Create(ctx, data)
+	if err != nil {
+		return nil, fmt.Errorf("gcp firestore binding error: failed to create document %s: %w", relativePath(ref), err)
+	}
+
+	return writeResponse(ref, res), nil
+}
+
+// set creates or overwrites a document, or merges the fields with the ones of the document if the merge metadata is set.
+func (f *Firestore) set(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	ref, err := f.docRef(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	data, err := decodeDocument(req.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []firestore.SetOption
+	if utils.IsTruthy(req.Metadata[metadataMerge]) {
+		opts = append(opts, firestore.MergeAll)
+	}
+
+	res, err := ref.Set(ctx, data, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("gcp firestore binding error: failed to set document %s: %w", relativePath(ref), err)
+	}
+
+	return writeResponse(ref, res), nil
+}
+
+// update updates fields of an existing document.
+// The data is a JSON object with the paths of the fields to update, with segments separated by dots, and their values.
+func (f *Firestore) update(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	ref, err := f.docRef(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	data, err := decodeDocument(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("gcp firestore binding error: no fields to update")
+	}
+	preconditions, err := parsePreconditions(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make([]firestore.Update, 0, len(data))
+	for path, value := range data {
+		updates = append(updates, firestore.Update{Path: path, Value: value})
+	}
+
+	res, err := ref.Update(ctx, updates, preconditions...)
+	if err != nil {
+		return nil, fmt.Errorf("gcp firestore binding error: failed to update document %s: %w", relativePath(ref), err)
+	}
+
+	return writeResponse(ref, res), nil
+}
+
+func (f *Firestore) delete(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	ref, err := f.docRef(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	preconditions, err := parsePreconditions(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = ref.Delete(ctx, preconditions...)
+	if err != nil {
+		return nil, fmt.Errorf("gcp firestore binding error: failed to delete document %s: %w", relativePath(ref), err)
+	}
+
+	return nil, nil
+}
+
+func (f *Firestore) query(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	var payload queryPayload
+	dec := json.NewDecoder(bytes.NewReader(req.Data))
+	dec.UseNumber()
+	err := dec.Decode(&payload)
+	if err != nil {
+		return nil, fmt.Errorf("gcp firestore binding error: invalid query: %w", err)
+	}
+
+	q, err := f.buildQuery(ctx, &payload)
+	if err != nil {
+		return nil, err
+	}
+
+	res := queryResponse{
+		Documents: []document{},
+	}
+	it := q.Documents(ctx)
+	defer it.Stop()
+	for {
+		snap, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("gcp firestore binding error: failed to query documents: %w", err)
+		}
+		data, _ := toJSONValue(snap.Data()).(map[string]interface{})
+		res.Documents = append(res.Documents, document{
+			ID:         snap.Ref.ID,
+			Path:       relativePath(snap.Ref),
+			Data:       data,
+			CreateTime: snap.CreateTime,
+			UpdateTime: snap.UpdateTime,
+		})
+	}
+	if payload.Limit > 0 && len(res.Documents) == payload.Limit {
+		res.NextCursor = res.Documents[len(res.Documents)-1].Path
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		return nil, fmt.Errorf("gcp firestore binding error: failed to marshal query results: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: b,
+	}, nil
+}
+
+func (f *Firestore) buildQuery(ctx context.Context, payload *queryPayload) (firestore.Query, error) {
+	var q firestore.Query
+	switch {
+	case payload.Collection != "" && payload.CollectionGroup != "":
+		return q, errors.New("gcp firestore binding error: collection and collectionGroup cannot be set together")
+	case payload.CollectionGroup != "":
+		if strings.Contains(payload.CollectionGroup, "/") {
+			return q, fmt.Errorf("gcp firestore binding error: invalid collection group %s", payload.CollectionGroup)
+		}
+		q = f.client.CollectionGroup(payload.CollectionGroup).Query
+	default:
+		coll, err := f.collectionRef(payload.Collection)
+		if err != nil {
+			return q, err
+		}
+		q = coll.Query
+	}
+
+	for _, w := range payload.Where {
+		if w.Field == "" || w.Op == "" {
+			return q, errors.New("gcp firestore binding error: filters require field and op")
+		}
+		q = q.Where(w.Field, w.Op, fromJSONValue(w.Value))
+	}
+	for _, o := range payload.OrderBy {
+		if o.Field == "" {
+			return q, errors.New("gcp firestore binding error: orderBy requires field")
+		}
+		dir := firestore.Asc
+		switch strings.ToLower(o.Direction) {
+		case "", "asc":
+		case "desc":
+			dir = firestore.Desc
+		default:
+			return q, fmt.Errorf("gcp firestore binding error: invalid direction %s", o.Direction)
+		}
+		q = q.OrderBy(o.Field, dir)
+	}
+	if len(payload.Select) > 0 {
+		q = q.Select(payload.Select...)
+	}
+	if payload.Limit < 0 {
+		return q, errors.New("gcp firestore binding error: limit must not be negative")
+	}
+	if payload.Limit > 0 {
+		q = q.Limit(payload.Limit)
+	}
+
+	starts := 0
+	for _, c := range [][]interface{}{payload.StartAt, payload.StartAfter} {
+		if len(c) > 0 {
+			starts++
+		}
+	}
+	if payload.StartAfterDocument != "" {
+		starts++
+	}
+	if starts > 1 {
+		return q, errors.New("gcp firestore binding error: only one of startAt, startAfter and startAfterDocument can be set")
+	}
+	if len(payload.EndAt) > 0 && len(payload.EndBefore) > 0 {
+		return q, errors.New("gcp firestore binding error: endAt and endBefore cannot be set together")
+	}
+
+	switch {
+	case len(payload.StartAt) > 0:
+		q = q.StartAt(fromJSONValues(payload.StartAt)...)
+	case len(payload.StartAfter) > 0:
+		q = q.StartAfter(fromJSONValues(payload.StartAfter)...)
+	case payload.StartAfterDocument != "":
+		ref := f.client.Doc(payload.StartAfterDocument)
+		if ref == nil {
+			return q, fmt.Errorf("gcp firestore binding error: invalid document path %s", payload.StartAfterDocument)
+		}
+		snap, err := ref.Get(ctx)
+		if err != nil {
+			return q, fmt.Errorf("gcp firestore binding error: failed to get cursor document %s: %w", payload.StartAfterDocument, err)
+		}
+		q = q.StartAfter(snap)
+	}
+	switch {
+	case len(payload.EndAt) > 0:
+		q = q.EndAt(fromJSONValues(payload.EndAt)...)
+	case len(payload.EndBefore) > 0:
+		q = q.EndBefore(fromJSONValues(payload.EndBefore)...)
+	}
+
+	return q, nil
+}
+
+// docRef returns the reference to the document with the path in the request metadata, or with the id in the collection of the request or of the component.
+func (f *Firestore) docRef(md map[string]string) (*firestore.DocumentRef, error) {
+	if path := md[metadataPath]; path != "" {
+		ref := f.client.Doc(path)
+		if ref == nil {
+			return nil, fmt.Errorf("gcp firestore binding error: invalid document path %s", path)
+		}
+		return ref, nil
+	}
+
+	id := md[metadataID]
+	if id == "" {
+		return nil, errors.New("gcp firestore binding error: the request requires the metadata path or id")
+	}
+	coll, err := f.collectionRef(md[metadataCollection])
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(id, "/") {
+		return nil, fmt.Errorf("gcp firestore binding error: invalid document id %s", id)
+	}
+	return coll.Doc(id), nil
+}
+
+// collectionRef returns the reference to the collection, or to the collection of the component if empty.
+func (f *Firestore) collectionRef(collection string) (*firestore.CollectionRef, error) {
+	if collection == "" {
+		collection = f.metadata.Collection
+	}
+	if collection == "" {
+		return nil, errors.New("gcp firestore binding error: the request requires the metadata collection")
+	}
+	coll := f.client.Collection(collection)
+	if coll == nil {
+		return nil, fmt.Errorf("gcp firestore binding error: invalid collection path %s", collection)
+	}
+	return coll, nil
+}
+
+func (f *Firestore) Close() error {
+	if f.client == nil {
+		return nil
+	}
+	return f.client.Close()
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (f *Firestore) GetComponentMetadata() map[string]string {
+	metadataStruct := firestoreMetadata{}
+	metadataInfo := map[string]string{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.BindingType)
+	return metadataInfo
+}
+
+func writeResponse(ref *firestore.DocumentRef, res *firestore.WriteResult) *bindings.InvokeResponse {
+	return &bindings.InvokeResponse{
+		Metadata: map[string]string{
+			metadataID:         ref.ID,
+			metadataPath:       relativePath(ref),
+			metadataUpdateTime: res.UpdateTime.Format(time.RFC3339Nano),
+		},
+	}
+}
+
+// parsePreconditions returns the preconditions of update and delete operations set in the request metadata.
+func parsePreconditions(md map[string]string) ([]firestore.Precondition, error) {
+	val := md[metadataUpdateTime]
+	if val == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, val)
+	if err != nil {
+		return nil, fmt.Errorf("gcp firestore binding error: invalid value for metadata %s: %w", metadataUpdateTime, err)
+	}
+	return []firestore.Precondition{firestore.LastUpdateTime(t)}, nil
+}
+
+// relativePath returns the path of the document relative to the root of the database, like "users/alice".
+func relativePath(ref *firestore.DocumentRef) string {
+	_, path, found := strings.Cut(ref.Path, "/documents/")
+	if !found {
+		return ref.Path
+	}
+	return path
+}
+
+// decodeDocument decodes the fields of a document from JSON, keeping integer numbers as integers.
+func decodeDocument(data []byte) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	err := dec.Decode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("gcp firestore binding error: the data must be a JSON object: %w", err)
+	}
+	if doc == nil {
+		return nil, errors.New("gcp firestore binding error: the data must be a JSON object")
+	}
+	return fromJSONValue(doc).(map[string]interface{}), nil
+}
+
+// fromJSONValue converts numbers decoded from JSON to integers or floating point numbers, as stored by Firestore.
+func fromJSONValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = fromJSONValue(item)
+		}
+		return val
+	case []interface{}:
+		return fromJSONValues(val)
+	default:
+		return v
+	}
+}
+
+func fromJSONValues(values []interface{}) []interface{} {
+	for i, item := range values {
+		values[i] = fromJSONValue(item)
+	}
+	return values
+}
+
+// toJSONValue converts the values of a document read from Firestore to values that can be marshalled to JSON.
+// References to documents are converted to their paths.
+func toJSONValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			res[k] = toJSONValue(item)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(val))
+		for i, item := range val {
+			res[i] = toJSONValue(item)
+		}
+		return res
+	case *firestore.DocumentRef:
+		if val == nil {
+			return nil
+		}
+		return relativePath(val)
+	default:
+		return v
+	}
+}
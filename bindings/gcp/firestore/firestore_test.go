@@ -0,0 +1,410 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package firestore
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "cloud.google.com/go/firestore/apiv1/firestorepb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const testDatabase = "projects/test/databases/(default)/documents"
+
+// fakeFirestore is an in-memory implementation of the Firestore API with the methods used by the binding.
+// Queries only filter documents by collection.
+type fakeFirestore struct {
+	pb.UnimplementedFirestoreServer
+
+	lock    sync.Mutex
+	docs    map[string]*pb.Document
+	queries []*pb.StructuredQuery
+}
+
+func (s *fakeFirestore) BatchGetDocuments(req *pb.BatchGetDocumentsRequest, stream pb.Firestore_BatchGetDocumentsServer) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, name := range req.Documents {
+		res := &pb.BatchGetDocumentsResponse{ReadTime: timestamppb.Now()}
+		if doc, ok := s.docs[name]; ok {
+			res.Result = &pb.BatchGetDocumentsResponse_Found{Found: doc}
+		} else {
+			res.Result = &pb.BatchGetDocumentsResponse_Missing{Missing: name}
+		}
+		if err := stream.Send(res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fakeFirestore) Commit(_ context.Context, req *pb.CommitRequest) (*pb.CommitResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := timestamppb.Now()
+	res := &pb.CommitResponse{CommitTime: now}
+	for _, w := range req.Writes {
+		var name string
+		switch op := w.Operation.(type) {
+		case *pb.Write_Update:
+			name = op.Update.Name
+		case *pb.Write_Delete:
+			name = op.Delete
+		default:
+			return nil, status.Error(codes.Unimplemented, "unsupported write")
+		}
+
+		existing, exists := s.docs[name]
+		switch c := w.GetCurrentDocument().GetConditionType().(type) {
+		case *pb.Precondition_Exists:
+			if c.Exists && !exists {
+				return nil, status.Errorf(codes.NotFound, "document %s not found", name)
+			}
+			if !c.Exists && exists {
+				return nil, status.Errorf(codes.AlreadyExists, "document %s already exists", name)
+			}
+		case *pb.Precondition_UpdateTime:
+			if !exists || !proto.Equal(existing.UpdateTime, c.UpdateTime) {
+				return nil, status.Errorf(codes.FailedPrecondition, "document %s was updated", name)
+			}
+		}
+
+		switch op := w.Operation.(type) {
+		case *pb.Write_Update:
+			doc := proto.Clone(op.Update).(*pb.Document)
+			doc.CreateTime, doc.UpdateTime = now, now
+			if exists {
+				doc.CreateTime = existing.CreateTime
+				if w.UpdateMask != nil {
+					fields := map[string]*pb.Value{}
+					for k, v := range existing.Fields {
+						fields[k] = v
+					}
+					for _, path := range w.UpdateMask.FieldPaths {
+						if v, ok := doc.Fields[path]; ok {
+							fields[path] = v
+						} else {
+							delete(fields, path)
+						}
+					}
+					doc.Fields = fields
+				}
+			}
+			s.docs[name] = doc
+		case *pb.Write_Delete:
+			delete(s.docs, name)
+		}
+		res.WriteResults = append(res.WriteResults, &pb.WriteResult{UpdateTime: now})
+	}
+	return res, nil
+}
+
+func (s *fakeFirestore) RunQuery(req *pb.RunQueryRequest, stream pb.Firestore_RunQueryServer) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	q := req.GetStructuredQuery()
+	s.queries = append(s.queries, q)
+
+	names := make([]string, 0, len(s.docs))
+	for name := range s.docs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	from := q.From[0]
+	sent := int32(0)
+	for _, name := range names {
+		parts := strings.Split(name, "/")
+		parent := strings.Join(parts[:len(parts)-2], "/")
+		if parts[len(parts)-2] != from.CollectionId || (!from.AllDescendants && parent != req.Parent) {
+			continue
+		}
+		if q.Limit != nil && sent == q.Limit.Value {
+			break
+		}
+		err := stream.Send(&pb.RunQueryResponse{Document: s.docs[name], ReadTime: timestamppb.Now()})
+		if err != nil {
+			return err
+		}
+		sent++
+	}
+	return nil
+}
+
+func (s *fakeFirestore) lastQuery() *pb.StructuredQuery {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.queries[len(s.queries)-1]
+}
+
+func newTestFirestore(t *testing.T) (*Firestore, *fakeFirestore) {
+	t.Helper()
+
+	fake := &fakeFirestore{docs: map[string]*pb.Document{}}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	pb.RegisterFirestoreServer(server, fake)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	f := NewFirestore(logger.NewLogger("test")).(*Firestore)
+	err = f.Init(context.Background(), bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"project_id": "test",
+		"endpoint":   lis.Addr().String(),
+		"collection": "orders",
+	}}})
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+
+	return f, fake
+}
+
+func TestParseMetadata(t *testing.T) {
+	m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"project_id": "my-project",
+		"collection": "orders",
+		"endpoint":   "localhost:8080",
+	}}})
+	require.NoError(t, err)
+	assert.Equal(t, "my-project", m.ProjectID)
+	assert.Equal(t, "orders", m.Collection)
+	assert.Equal(t, "localhost:8080", m.Endpoint)
+
+	_, err = parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"collection": "orders",
+	}}})
+	require.Error(t, err)
+}
+
+func TestDocumentOperations(t *testing.T) {
+	f, fake := newTestFirestore(t)
+	ctx := context.Background()
+
+	invoke := func(op bindings.OperationKind, data string, md map[string]string) (*bindings.InvokeResponse, error) {
+		return f.Invoke(ctx, &bindings.InvokeRequest{Operation: op, Data: []byte(data), Metadata: md})
+	}
+
+	t.Run("create with generated id", func(t *testing.T) {
+		res, err := invoke(bindings.CreateOperation, `{"status":"open"}`, nil)
+		require.NoError(t, err)
+		id := res.Metadata["id"]
+		require.NotEmpty(t, id)
+		assert.Equal(t, "orders/"+id, res.Metadata["path"])
+		assert.Contains(t, fake.docs, testDatabase+"/orders/"+id)
+	})
+
+	t.Run("create existing document", func(t *testing.T) {
+		_, err := invoke(bindings.CreateOperation, `{"status":"open"}`, map[string]string{"id": "o1"})
+		require.NoError(t, err)
+		_, err = invoke(bindings.CreateOperation, `{"status":"open"}`, map[string]string{"id": "o1"})
+		require.Error(t, err)
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		_, err := invoke(SetOperation, `{"name":"book","count":3,"price":1.5,"tags":["a","b"],"item":{"sku":"x1"}}`, map[string]string{"path": "users/alice/orders/o2"})
+		require.NoError(t, err)
+
+		res, err := invoke(bindings.GetOperation, "", map[string]string{"path": "users/alice/orders/o2"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"book","count":3,"price":1.5,"tags":["a","b"],"item":{"sku":"x1"}}`, string(res.Data))
+		assert.Equal(t, "o2", res.Metadata["id"])
+		assert.Equal(t, "users/alice/orders/o2", res.Metadata["path"])
+		assert.NotEmpty(t, res.Metadata["createTime"])
+
+		// Integers are stored as integers
+		assert.Equal(t, int64(3), fake.docs[testDatabase+"/users/alice/orders/o2"].Fields["count"].GetIntegerValue())
+	})
+
+	t.Run("set with merge", func(t *testing.T) {
+		_, err := invoke(SetOperation, `{"status":"open","count":1}`, map[string]string{"id": "o3"})
+		require.NoError(t, err)
+		_, err = invoke(SetOperation, `{"status":"closed"}`, map[string]string{"id": "o3", "merge": "true"})
+		require.NoError(t, err)
+
+		res, err := invoke(bindings.GetOperation, "", map[string]string{"id": "o3"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"status":"closed","count":1}`, string(res.Data))
+	})
+
+	t.Run("update with precondition", func(t *testing.T) {
+		_, err := invoke(SetOperation, `{"status":"open","count":1}`, map[string]string{"collection": "carts", "id": "c1"})
+		require.NoError(t, err)
+		res, err := invoke(bindings.GetOperation, "", map[string]string{"collection": "carts", "id": "c1"})
+		require.NoError(t, err)
+		updateTime := res.Metadata["updateTime"]
+
+		time.Sleep(time.Millisecond)
+		_, err = invoke(UpdateOperation, `{"count":2}`, map[string]string{"collection": "carts", "id": "c1", "updateTime": updateTime})
+		require.NoError(t, err)
+		res, err = invoke(bindings.GetOperation, "", map[string]string{"collection": "carts", "id": "c1"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"status":"open","count":2}`, string(res.Data))
+
+		// The document was updated after updateTime
+		_, err = invoke(UpdateOperation, `{"count":3}`, map[string]string{"collection": "carts", "id": "c1", "updateTime": updateTime})
+		require.Error(t, err)
+
+		_, err = invoke(UpdateOperation, `{"count":3}`, map[string]string{"collection": "carts", "id": "missing"})
+		require.Error(t, err)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		_, err := invoke(SetOperation, `{"status":"open"}`, map[string]string{"id": "o4"})
+		require.NoError(t, err)
+		_, err = invoke(bindings.DeleteOperation, "", map[string]string{"id": "o4"})
+		require.NoError(t, err)
+
+		_, err = invoke(bindings.GetOperation, "", map[string]string{"id": "o4"})
+		require.ErrorContains(t, err, "not found")
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, err := invoke(bindings.GetOperation, "", nil)
+		require.Error(t, err)
+		_, err = invoke(bindings.GetOperation, "", map[string]string{"path": "users/alice/orders"})
+		require.Error(t, err)
+		_, err = invoke(SetOperation, `[1,2]`, map[string]string{"id": "o5"})
+		require.Error(t, err)
+		_, err = invoke(UpdateOperation, `{"count":1}`, map[string]string{"id": "o1", "updateTime": "yesterday"})
+		require.Error(t, err)
+		_, err = invoke("list", "", nil)
+		require.Error(t, err)
+	})
+}
+
+func TestQuery(t *testing.T) {
+	f, fake := newTestFirestore(t)
+	ctx := context.Background()
+
+	for _, path := range []string{"users/alice/orders/a1", "users/alice/orders/a2", "users/bob/orders/b1", "orders/o1"} {
+		_, err := f.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: SetOperation,
+			Data:      []byte(`{"status":"open","total":10}`),
+			Metadata:  map[string]string{"path": path},
+		})
+		require.NoError(t, err)
+	}
+
+	query := func(q string) (queryResponse, error) {
+		var res queryResponse
+		out, err := f.Invoke(ctx, &bindings.InvokeRequest{Operation: QueryOperation, Data: []byte(q)})
+		if err != nil {
+			return res, err
+		}
+		err = json.Unmarshal(out.Data, &res)
+		return res, err
+	}
+	paths := func(res queryResponse) []string {
+		paths := make([]string, len(res.Documents))
+		for i, doc := range res.Documents {
+			paths[i] = doc.Path
+		}
+		return paths
+	}
+
+	t.Run("collection", func(t *testing.T) {
+		res, err := query(`{"collection":"users/alice/orders"}`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"users/alice/orders/a1", "users/alice/orders/a2"}, paths(res))
+		assert.Equal(t, map[string]interface{}{"status": "open", "total": float64(10)}, res.Documents[0].Data)
+		assert.Empty(t, res.NextCursor)
+	})
+
+	t.Run("default collection", func(t *testing.T) {
+		res, err := query(`{}`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"orders/o1"}, paths(res))
+	})
+
+	t.Run("collection group with filters and cursor", func(t *testing.T) {
+		res, err := query(`{
+			"collectionGroup": "orders",
+			"where": [{"field": "status", "op": "==", "value": "open"}, {"field": "total", "op": ">=", "value": 5}],
+			"orderBy": [{"field": "total", "direction": "desc"}],
+			"limit": 2
+		}`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"orders/o1", "users/alice/orders/a1"}, paths(res))
+		assert.Equal(t, "users/alice/orders/a1", res.NextCursor)
+
+		q := fake.lastQuery()
+		require.Len(t, q.From, 1)
+		assert.Equal(t, "orders", q.From[0].CollectionId)
+		assert.True(t, q.From[0].AllDescendants)
+		filters := q.GetWhere().GetCompositeFilter().GetFilters()
+		require.Len(t, filters, 2)
+		assert.Equal(t, "status", filters[0].GetFieldFilter().GetField().GetFieldPath())
+		assert.Equal(t, int64(5), filters[1].GetFieldFilter().GetValue().GetIntegerValue())
+		assert.Equal(t, pb.StructuredQuery_DESCENDING, q.OrderBy[0].Direction)
+		assert.Equal(t, int32(2), q.GetLimit().GetValue())
+
+		_, err = query(`{"collectionGroup": "orders", "orderBy": [{"field": "total", "direction": "desc"}], "limit": 2, "startAfterDocument": "` + res.NextCursor + `"}`)
+		require.NoError(t, err)
+		q = fake.lastQuery()
+		require.NotNil(t, q.StartAt)
+		assert.False(t, q.StartAt.Before)
+		// The cursor has the value of the ordered field and the name of the document
+		require.Len(t, q.StartAt.Values, 2)
+		assert.Equal(t, int64(10), q.StartAt.Values[0].GetIntegerValue())
+		assert.Equal(t, testDatabase+"/users/alice/orders/a1", q.StartAt.Values[1].GetReferenceValue())
+	})
+
+	t.Run("value cursors", func(t *testing.T) {
+		_, err := query(`{"collection": "orders", "orderBy": [{"field": "total"}], "startAt": [5], "endBefore": [20]}`)
+		require.NoError(t, err)
+		q := fake.lastQuery()
+		assert.True(t, q.StartAt.Before)
+		assert.Equal(t, int64(5), q.StartAt.Values[0].GetIntegerValue())
+		assert.True(t, q.EndAt.Before)
+		assert.Equal(t, int64(20), q.EndAt.Values[0].GetIntegerValue())
+	})
+
+	t.Run("invalid queries", func(t *testing.T) {
+		for _, q := range []string{
+			`{"collection": "orders", "collectionGroup": "orders"}`,
+			`{"collectionGroup": "users/alice/orders"}`,
+			`{"collection": "users/alice"}`,
+			`{"where": [{"field": "status"}]}`,
+			`{"orderBy": [{"field": "total", "direction": "up"}]}`,
+			`{"limit": -1}`,
+			`{"orderBy": [{"field": "total"}], "startAt": [1], "startAfter": [2]}`,
+			`{"orderBy": [{"field": "total"}], "endAt": [1], "endBefore": [2]}`,
+			`not json`,
+		} {
+			_, err := query(q)
+			assert.Error(t, err, q)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Error code returned by servers implementing Automatic Persisted Queries when the hash is not known.
+const persistedQueryNotFound = "PersistedQueryNotFound"
+
+// graphQLRequest is the body of a request sent to the GraphQL server.
+type graphQLRequest struct {
+	Query         string                 `json:"query,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Extensions    *graphQLExtensions     `json:"extensions,omitempty"`
+}
+
+type graphQLExtensions struct {
+	PersistedQuery *persistedQuery `json:"persistedQuery,omitempty"`
+}
+
+type persistedQuery struct {
+	Version    int    `json:"version"`
+	Sha256Hash string `json:"sha256Hash"`
+}
+
+// graphQLResponse is the body of a response returned by the GraphQL server.
+type graphQLResponse struct {
+	Data   interface{}    `json:"data"`
+	Errors []graphQLError `json:"errors"`
+}
+
+type graphQLError struct {
+	Message    string                 `json:"message"`
+	Extensions map[string]interface{} `json:"extensions"`
+}
+
+func (e graphQLError) isPersistedQueryNotFound() bool {
+	if e.Message == persistedQueryNotFound {
+		return true
+	}
+	code, _ := e.Extensions["code"].(string)
+	return code == persistedQueryNotFound
+}
+
+// errRetriable is returned for failures that may succeed if the request is sent again.
+var errRetriable = errors.New("retriable error")
+
+// persistedQueryHash returns the hash of a query used by Automatic Persisted Queries.
+func persistedQueryHash(query string) string {
+	h := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(h[:])
+}
+
+// withPersistedQuery sets the persisted query extension on the request.
+func (r *graphQLRequest) withPersistedQuery(hash string) {
+	r.Extensions = &graphQLExtensions{
+		PersistedQuery: &persistedQuery{
+			Version:    1,
+			Sha256Hash: hash,
+		},
+	}
+}
+
+// do sends the request to the server and returns the data in the response.
+// Transport errors and 5xx responses are wrapped with errRetriable.
+func (gql *GraphQL) do(ctx context.Context, body *graphQLRequest, header http.Header) (*graphQLResponse, error) {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, gql.endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header = header.Clone()
+	httpReq.Header.Set("Content-Type", "application/json; charset=utf-8")
+	httpReq.Header.Set("Accept", "application/json; charset=utf-8")
+
+	httpRes, err := gql.httpClient.Do(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", errRetriable, err)
+	}
+	defer httpRes.Body.Close()
+
+	resBody, err := io.ReadAll(httpRes.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read response: %w", errRetriable, err)
+	}
+
+	if httpRes.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("%w: server returned status code %d", errRetriable, httpRes.StatusCode)
+	}
+
+	res := &graphQLResponse{}
+	if err = json.Unmarshal(resBody, res); err != nil {
+		if httpRes.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("server returned a non-200 status code: %d", httpRes.StatusCode)
+		}
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return res, nil
+}
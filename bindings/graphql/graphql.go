@@ -16,24 +16,29 @@ package graphql
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
 	"regexp"
 	"strings"
 	"time"
 
-	graphql "github.com/machinebox/graphql"
+	"github.com/cenkalti/backoff/v4"
 
 	"github.com/dapr/components-contrib/bindings"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/retry"
 )
 
 const (
 
 	// keys from request's metadata.
-	commandQuery    = "query"
-	commandMutation = "mutation"
+	commandQuery          = "query"
+	commandMutation       = "mutation"
+	operationNameKey      = "operationName"
+	persistedQueryHashKey = "persistedQueryHash"
 
 	// keys from response's metadata.
 	respOpKey        = "operation"
@@ -47,13 +52,18 @@ const (
 
 type graphQLMetadata struct {
 	Endpoint string `mapstructure:"endpoint"`
+	// PersistedQueries enables Automatic Persisted Queries: the hash of the query is sent first, and the full query only if the server doesn't know it.
+	PersistedQueries bool `mapstructure:"persistedQueries"`
 }
 
 // GraphQL represents GraphQL output bindings.
 type GraphQL struct {
-	client *graphql.Client
-	header map[string]string
-	logger logger.Logger
+	endpoint         string
+	persistedQueries bool
+	httpClient       *http.Client
+	backOffConfig    retry.Config
+	header           map[string]string
+	logger           logger.Logger
 }
 
 // NewGraphQL returns a new GraphQL binding instance.
@@ -75,10 +85,15 @@ func (gql *GraphQL) Init(_ context.Context, meta bindings.Metadata) error {
 		return fmt.Errorf("GraphQL Error: Missing GraphQL URL")
 	}
 
-	// Connect to GraphQL Server
-	client := graphql.NewClient(m.Endpoint)
+	// Transient failures are not retried unless backOff properties are set.
+	gql.backOffConfig = retry.DefaultConfigWithNoRetry()
+	if err = retry.DecodeConfigWithPrefix(&gql.backOffConfig, meta.Properties, "backOff"); err != nil {
+		return fmt.Errorf("GraphQL Error: retry configuration error: %w", err)
+	}
 
-	gql.client = client
+	gql.endpoint = m.Endpoint
+	gql.persistedQueries = m.PersistedQueries
+	gql.httpClient = &http.Client{}
 	gql.header = make(map[string]string)
 	for k, v := range meta.Properties {
 		if strings.HasPrefix(k, "header:") {
@@ -151,40 +166,98 @@ func (gql *GraphQL) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*b
 }
 
 func (gql *GraphQL) runRequest(ctx context.Context, requestKey string, req *bindings.InvokeRequest, response interface{}) error {
-	requestString, ok := req.Metadata[requestKey]
-	if !ok || requestString == "" {
+	requestString := strings.TrimSpace(req.Metadata[requestKey])
+	hash := req.Metadata[persistedQueryHashKey]
+	if requestString == "" && hash == "" {
 		return fmt.Errorf("GraphQL Error: required %q not set", requestKey)
 	}
 
-	// Check that the command is either a query or mutation based on the first keyword.
-	requestString = strings.TrimSpace(requestString)
-	re := regexp.MustCompile(`(?m)` + requestKey + `\b`)
-	matches := re.FindAllStringIndex(requestString, 1)
-	if len(matches) != 1 || matches[0][0] != 0 {
-		return fmt.Errorf("GraphQL Error: command is not a %s", requestKey)
+	if requestString != "" {
+		// Check that the command is either a query or mutation based on the first keyword.
+		re := regexp.MustCompile(`(?m)` + requestKey + `\b`)
+		matches := re.FindAllStringIndex(requestString, 1)
+		if len(matches) != 1 || matches[0][0] != 0 {
+			return fmt.Errorf("GraphQL Error: command is not a %s", requestKey)
+		}
+		if hash == "" && gql.persistedQueries {
+			hash = persistedQueryHash(requestString)
+		}
 	}
 
-	request := graphql.NewRequest(requestString)
+	body := &graphQLRequest{
+		OperationName: req.Metadata[operationNameKey],
+		Variables:     map[string]interface{}{},
+	}
 
+	header := make(http.Header, len(gql.header))
 	for headerKey, headerValue := range gql.header {
-		request.Header.Set(headerKey, headerValue)
+		header.Set(headerKey, headerValue)
 	}
 
 	for k, v := range req.Metadata {
 		if strings.HasPrefix(k, "header:") {
-			request.Header.Set(strings.TrimPrefix(k, "header:"), v)
+			header.Set(strings.TrimPrefix(k, "header:"), v)
 		} else if strings.HasPrefix(k, "variable:") {
-			request.Var(strings.TrimPrefix(k, "variable:"), v)
+			body.Variables[strings.TrimPrefix(k, "variable:")] = v
 		}
 	}
 
-	if err := gql.client.Run(ctx, request, response); err != nil {
+	var (
+		res *graphQLResponse
+		err error
+	)
+	if hash != "" {
+		// Send the hash only first; the server returns PersistedQueryNotFound if it needs the full query.
+		body.withPersistedQuery(hash)
+		res, err = gql.doWithRetry(ctx, body, header)
+		if err != nil {
+			return fmt.Errorf("GraphQL Error: %w", err)
+		}
+		if len(res.Errors) > 0 && res.Errors[0].isPersistedQueryNotFound() {
+			if requestString == "" {
+				return fmt.Errorf("GraphQL Error: persisted query %s not found and %q not set", hash, requestKey)
+			}
+			body.Query = requestString
+			res, err = gql.doWithRetry(ctx, body, header)
+		}
+	} else {
+		body.Query = requestString
+		res, err = gql.doWithRetry(ctx, body, header)
+	}
+	if err != nil {
+		return fmt.Errorf("GraphQL Error: %w", err)
+	}
+
+	if len(res.Errors) > 0 {
+		return fmt.Errorf("GraphQL Error: graphql: %s", res.Errors[0].Message)
+	}
+
+	b, err := json.Marshal(res.Data)
+	if err != nil {
+		return fmt.Errorf("GraphQL Error: %w", err)
+	}
+	if err = json.Unmarshal(b, response); err != nil {
 		return fmt.Errorf("GraphQL Error: %w", err)
 	}
 
 	return nil
 }
 
+// doWithRetry sends the request, retrying transport errors and 5xx responses according to the back off configuration.
+func (gql *GraphQL) doWithRetry(ctx context.Context, body *graphQLRequest, header http.Header) (*graphQLResponse, error) {
+	return retry.NotifyRecoverWithData(func() (*graphQLResponse, error) {
+		res, err := gql.do(ctx, body, header)
+		if err != nil && !errors.Is(err, errRetriable) {
+			return nil, backoff.Permanent(err)
+		}
+		return res, err
+	}, gql.backOffConfig.NewBackOffWithContext(ctx), func(err error, d time.Duration) {
+		gql.logger.Warnf("GraphQL request failed, retrying in %s: %v", d, err)
+	}, func() {
+		gql.logger.Info("GraphQL request succeeded after retrying")
+	})
+}
+
 // GetComponentMetadata returns the metadata of the component.
 func (gql *GraphQL) GetComponentMetadata() map[string]string {
 	metadataStruct := graphQLMetadata{}
@@ -111,3 +111,121 @@ func TestGraphQlRequestHeadersAndVariables(t *testing.T) {
 	_, err = gql.Invoke(context.Background(), req)
 	require.NoError(t, err)
 }
+
+func TestGraphQlPersistedQueries(t *testing.T) {
+	const query = `query Hero { hero { name } }`
+
+	var requests []map[string]interface{}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, body)
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, ok := body["query"]; !ok {
+			w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotFound"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"hero":{"name":"R2-D2"}}}`))
+	}))
+	defer s.Close()
+
+	gql, err := InitBinding(s, map[string]string{"persistedQueries": "true"})
+	require.NoError(t, err)
+
+	res, err := gql.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: QueryOperation,
+		Metadata: map[string]string{
+			"query":         query,
+			"operationName": "Hero",
+		},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"hero":{"name":"R2-D2"}}`, string(res.Data))
+
+	require.Len(t, requests, 2)
+	assert.NotContains(t, requests[0], "query")
+	assert.Equal(t, "Hero", requests[0]["operationName"])
+	assert.Equal(t, map[string]interface{}{
+		"persistedQuery": map[string]interface{}{
+			"version":    float64(1),
+			"sha256Hash": persistedQueryHash(query),
+		},
+	}, requests[0]["extensions"])
+	assert.Equal(t, query, requests[1]["query"])
+
+	t.Run("unknown hash without query", func(t *testing.T) {
+		_, err := gql.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: QueryOperation,
+			Metadata:  map[string]string{"persistedQueryHash": "abc"},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestGraphQlRetry(t *testing.T) {
+	t.Run("retries on 5xx", func(t *testing.T) {
+		calls := 0
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"data":{}}`))
+		}))
+		defer s.Close()
+
+		gql, err := InitBinding(s, map[string]string{
+			"backOffMaxRetries": "3",
+			"backOffDuration":   "1ms",
+		})
+		require.NoError(t, err)
+
+		_, err = gql.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: QueryOperation,
+			Metadata:  map[string]string{"query": "query { hero { name } }"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("does not retry by default", func(t *testing.T) {
+		calls := 0
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer s.Close()
+
+		gql, err := InitBinding(s, nil)
+		require.NoError(t, err)
+
+		_, err = gql.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: QueryOperation,
+			Metadata:  map[string]string{"query": "query { hero { name } }"},
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("does not retry on 4xx", func(t *testing.T) {
+		calls := 0
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer s.Close()
+
+		gql, err := InitBinding(s, map[string]string{"backOffMaxRetries": "3", "backOffDuration": "1ms"})
+		require.NoError(t, err)
+
+		_, err = gql.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: QueryOperation,
+			Metadata:  map[string]string{"query": "query { hero { name } }"},
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+}
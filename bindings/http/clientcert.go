@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dapr/kit/logger"
+)
+
+// clientCertificate provides the mTLS client certificate, reloading it periodically so certificates that are rotated are used for new connections without restarting.
+type clientCertificate struct {
+	load            func(ctx context.Context) (*tls.Certificate, error)
+	refreshInterval time.Duration
+	logger          logger.Logger
+	now             func() time.Time
+
+	lock     sync.Mutex
+	cert     *tls.Certificate
+	loadedAt time.Time
+}
+
+func newClientCertificate(load func(ctx context.Context) (*tls.Certificate, error), refreshInterval time.Duration, logger logger.Logger) *clientCertificate {
+	return &clientCertificate{
+		load:            load,
+		refreshInterval: refreshInterval,
+		logger:          logger,
+		now:             time.Now,
+	}
+}
+
+// get returns the client certificate, reloading it if refreshInterval has passed since it was loaded.
+// If reloading fails, the previous certificate keeps being used until the next attempt.
+func (c *clientCertificate) get(ctx context.Context) (*tls.Certificate, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+	if c.cert != nil && (c.refreshInterval <= 0 || now.Sub(c.loadedAt) < c.refreshInterval) {
+		return c.cert, nil
+	}
+
+	cert, err := c.load(ctx)
+	if err != nil {
+		if c.cert == nil {
+			return nil, err
+		}
+		c.logger.Warnf("Failed to reload the mTLS client certificate, using the previous one: %v", err)
+		c.loadedAt = now
+		return c.cert, nil
+	}
+	c.cert = cert
+	c.loadedAt = now
+	return cert, nil
+}
+
+// GetClientCertificate implements the GetClientCertificate callback of tls.Config.
+func (c *clientCertificate) GetClientCertificate(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return c.get(info.Context())
+}
+
+// loadClientCertificateFromMetadata loads the client certificate and key set in the metadata, as PEM-encoded values or file paths.
+func (h *HTTPSource) loadClientCertificateFromMetadata(_ context.Context) (*tls.Certificate, error) {
+	clientCertBytes, err := h.getPemBytes(MTLSClientCert, h.metadata.MTLSClientCert)
+	if err != nil {
+		return nil, err
+	}
+	clientKeyBytes, err := h.getPemBytes(MTLSClientKey, h.metadata.MTLSClientKey)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.X509KeyPair(clientCertBytes, clientKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	return &cert, nil
+}
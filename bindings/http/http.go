@@ -24,13 +24,19 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 
+	"github.com/cenkalti/backoff/v4"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
 	"github.com/dapr/components-contrib/bindings"
 	"github.com/dapr/components-contrib/internal/utils"
 	"github.com/dapr/components-contrib/metadata"
@@ -47,8 +53,28 @@ const (
 	TraceMetadataKey     = "traceHeaders"
 	securityToken        = "securityToken"
 	securityTokenHeader  = "securityTokenHeader"
+
+	// Timeout for the requests to the OAuth2 token endpoint.
+	oauth2TokenRequestTimeout = 30 * time.Second
+
+	// Metadata keys of the invocations.
+	timeoutMetadataKey              = "timeout"
+	retryCountMetadataKey           = "retryCount"
+	retriableStatusCodesMetadataKey = "retriableStatusCodes"
+
+	// Backoff between the attempts of a request that is retried.
+	retryInitialInterval = 100 * time.Millisecond
+	retryMaxInterval     = 5 * time.Second
 )
 
+// Status codes that are retried by default when retryCount is set.
+var defaultRetriableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
 // HTTPSource is a binding for an http url endpoint invocation
 //
 //revive:disable-next-line
@@ -57,6 +83,14 @@ type HTTPSource struct {
 	client        *http.Client
 	errorIfNot2XX bool
 	logger        logger.Logger
+
+	// OAuth2 client credentials flow, if enabled.
+	oauth2Config *clientcredentials.Config
+	tokenSource  oauth2.TokenSource
+	tokenLock    sync.Mutex
+
+	// mTLS client certificate, if it's reloaded periodically.
+	clientCert *clientCertificate
 }
 
 type httpMetadata struct {
@@ -68,6 +102,17 @@ type httpMetadata struct {
 	SecurityToken       string         `mapstructure:"securityToken"`
 	SecurityTokenHeader string         `mapstructure:"securityTokenHeader"`
 	ResponseTimeout     *time.Duration `mapstructure:"responseTimeout"`
+
+	// How often the client certificate is reloaded from the files, to pick up rotated certificates.
+	MTLSCertRefreshInterval time.Duration `mapstructure:"mtlsCertRefreshInterval" default:"5m"`
+
+	// OAuth2 client credentials flow used to acquire the access tokens sent to the endpoint.
+	OAuth2TokenURL            string `mapstructure:"oauth2TokenURL"`
+	OAuth2ClientID            string `mapstructure:"oauth2ClientID"`
+	OAuth2ClientSecret        string `mapstructure:"oauth2ClientSecret"`
+	OAuth2Scopes              string `mapstructure:"oauth2Scopes"`
+	OAuth2EndpointParamsQuery string `mapstructure:"oauth2EndpointParamsQuery"`
+	OAuth2AuthStyle           int    `mapstructure:"oauth2AuthStyle"`
 }
 
 // NewHTTP returns a new HTTPSource.
@@ -76,7 +121,7 @@ func NewHTTP(logger logger.Logger) bindings.OutputBinding {
 }
 
 // Init performs metadata parsing.
-func (h *HTTPSource) Init(_ context.Context, meta bindings.Metadata) error {
+func (h *HTTPSource) Init(ctx context.Context, meta bindings.Metadata) error {
 	var err error
 	if err = metadata.DecodeMetadata(meta.Properties, &h.metadata); err != nil {
 		return err
@@ -87,12 +132,18 @@ func (h *HTTPSource) Init(_ context.Context, meta bindings.Metadata) error {
 		return err
 	}
 	if h.metadata.MTLSClientCert != "" && h.metadata.MTLSClientKey != "" {
-		err = h.readMTLSClientCertificates(tlsConfig)
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		err = h.readMTLSClientCertificates(ctx, tlsConfig)
 		if err != nil {
 			return err
 		}
 	}
 	if h.metadata.MTLSRenegotiation != "" {
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
 		err = h.setTLSRenegotiation(tlsConfig)
 		if err != nil {
 			return err
@@ -122,27 +173,96 @@ func (h *HTTPSource) Init(_ context.Context, meta bindings.Metadata) error {
 		h.errorIfNot2XX = true
 	}
 
-	return nil
+	return h.initOAuth2()
 }
 
-// readMTLSClientCertificates reads the certificates and key from the metadata and returns a tls.Config.
-func (h *HTTPSource) readMTLSClientCertificates(tlsConfig *tls.Config) error {
-	clientCertBytes, err := h.getPemBytes(MTLSClientCert, h.metadata.MTLSClientCert)
-	if err != nil {
-		return err
+// initOAuth2 configures the OAuth2 client credentials flow, if the token URL is set.
+func (h *HTTPSource) initOAuth2() error {
+	if h.metadata.OAuth2TokenURL == "" {
+		if h.metadata.OAuth2ClientID != "" || h.metadata.OAuth2ClientSecret != "" {
+			return errors.New("oauth2TokenURL is required when the OAuth2 client credentials are set")
+		}
+		return nil
+	}
+	if h.metadata.OAuth2ClientID == "" || h.metadata.OAuth2ClientSecret == "" {
+		return errors.New("oauth2ClientID and oauth2ClientSecret are required when oauth2TokenURL is set")
 	}
-	clientKeyBytes, err := h.getPemBytes(MTLSClientKey, h.metadata.MTLSClientKey)
+	if h.metadata.OAuth2AuthStyle < 0 || h.metadata.OAuth2AuthStyle > 2 {
+		return fmt.Errorf("invalid oauth2AuthStyle %d, accepted values are 0, 1 and 2", h.metadata.OAuth2AuthStyle)
+	}
+
+	endpointParams, err := url.ParseQuery(h.metadata.OAuth2EndpointParamsQuery)
 	if err != nil {
-		return err
+		return fmt.Errorf("invalid oauth2EndpointParamsQuery: %w", err)
+	}
+
+	var scopes []string
+	for _, scope := range strings.Split(h.metadata.OAuth2Scopes, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope != "" {
+			scopes = append(scopes, scope)
+		}
 	}
-	cert, err := tls.X509KeyPair(clientCertBytes, clientKeyBytes)
+
+	h.oauth2Config = &clientcredentials.Config{
+		ClientID:       h.metadata.OAuth2ClientID,
+		ClientSecret:   h.metadata.OAuth2ClientSecret,
+		TokenURL:       h.metadata.OAuth2TokenURL,
+		Scopes:         scopes,
+		EndpointParams: endpointParams,
+		AuthStyle:      oauth2.AuthStyle(h.metadata.OAuth2AuthStyle),
+	}
+	h.resetTokenSource()
+
+	return nil
+}
+
+// resetTokenSource discards the cached token, so a new one is acquired for the next request.
+func (h *HTTPSource) resetTokenSource() {
+	// The token endpoint doesn't use the TLS configuration of the binding, as it's usually a different server
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{
+		Timeout: oauth2TokenRequestTimeout,
+	})
+
+	h.tokenLock.Lock()
+	// The token source caches the token and acquires a new one shortly before it expires
+	h.tokenSource = h.oauth2Config.TokenSource(ctx)
+	h.tokenLock.Unlock()
+}
+
+// token returns the OAuth2 access token to send with the request.
+func (h *HTTPSource) token() (*oauth2.Token, error) {
+	h.tokenLock.Lock()
+	tokenSource := h.tokenSource
+	h.tokenLock.Unlock()
+
+	token, err := tokenSource.Token()
 	if err != nil {
-		return fmt.Errorf("failed to load client certificate: %w", err)
+		return nil, fmt.Errorf("failed to acquire OAuth2 token: %w", err)
 	}
-	if tlsConfig == nil {
-		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	return token, nil
+}
+
+// readMTLSClientCertificates configures the client certificate in the tls.Config.
+// Certificates read from files are reloaded periodically, to pick up rotated certificates.
+func (h *HTTPSource) readMTLSClientCertificates(ctx context.Context, tlsConfig *tls.Config) error {
+	if isValidPEM(h.metadata.MTLSClientCert) && isValidPEM(h.metadata.MTLSClientKey) {
+		// PEM-encoded values set in the metadata can't change, so there's nothing to reload
+		cert, err := h.loadClientCertificateFromMetadata(ctx)
+		if err != nil {
+			return err
+		}
+		tlsConfig.Certificates = []tls.Certificate{*cert}
+		return nil
 	}
-	tlsConfig.Certificates = []tls.Certificate{cert}
+
+	h.clientCert = newClientCertificate(h.loadClientCertificateFromMetadata, h.metadata.MTLSCertRefreshInterval, h.logger)
+	// Load the certificate now, so invalid configurations are reported at initialization
+	_, err := h.clientCert.get(ctx)
+	if err != nil {
+		return err
+	}
+	tlsConfig.GetClientCertificate = h.clientCert.GetClientCertificate
 	return nil
 }
 
@@ -227,6 +347,32 @@ func (h *HTTPSource) Operations() []bindings.OperationKind {
 
 // Invoke performs an HTTP request to the configured HTTP endpoint.
 func (h *HTTPSource) Invoke(parentCtx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	res, err := h.InvokeStream(parentCtx, &bindings.InvokeStreamRequest{
+		Data:      bytes.NewReader(req.Data),
+		Metadata:  req.Metadata,
+		Operation: req.Operation,
+	})
+	if res == nil {
+		return nil, err
+	}
+	defer res.Data.Close()
+
+	// Read the response body. For empty responses (e.g. 204 No Content)
+	// `b` will be an empty slice.
+	b, readErr := io.ReadAll(res.Data)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	return &bindings.InvokeResponse{
+		Data:     b,
+		Metadata: res.Metadata,
+	}, err
+}
+
+// InvokeStream performs an HTTP request to the configured HTTP endpoint, streaming the request and response bodies.
+// Requests with a body are retried only if the body can be read again, which is not the case for streams.
+func (h *HTTPSource) InvokeStream(parentCtx context.Context, req *bindings.InvokeStreamRequest) (*bindings.InvokeStreamResponse, error) {
 	u := h.metadata.URL
 
 	errorIfNot2XX := h.errorIfNot2XX // Default to the component config (default is true)
@@ -248,6 +394,11 @@ func (h *HTTPSource) Invoke(parentCtx context.Context, req *bindings.InvokeReque
 		req.Metadata = make(map[string]string)
 	}
 
+	opts, err := parseInvokeOptions(req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
 	var body io.Reader
 	method := strings.ToUpper(string(req.Operation))
 	// For backward compatibility
@@ -256,23 +407,32 @@ func (h *HTTPSource) Invoke(parentCtx context.Context, req *bindings.InvokeReque
 	}
 	switch method {
 	case "PUT", "POST", "PATCH":
-		body = bytes.NewBuffer(req.Data)
+		body = req.Data
+		if body == nil {
+			body = http.NoBody
+		}
 	case "GET", "HEAD", "DELETE", "OPTIONS", "TRACE":
 	default:
 		return nil, fmt.Errorf("invalid operation: %s", req.Operation)
 	}
 
-	var ctx context.Context
-	if h.metadata.ResponseTimeout == nil {
-		ctx = parentCtx
-	} else {
-		var cancel context.CancelFunc
+	// The context is canceled when the response body is closed
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	switch {
+	case opts.timeout > 0:
+		ctx, cancel = context.WithTimeout(parentCtx, opts.timeout)
+	case h.metadata.ResponseTimeout != nil:
 		ctx, cancel = context.WithTimeout(parentCtx, *h.metadata.ResponseTimeout)
-		defer cancel()
+	default:
+		ctx, cancel = context.WithCancel(parentCtx)
 	}
 
 	request, err := http.NewRequestWithContext(ctx, method, u, body)
 	if err != nil {
+		cancel()
 		return nil, err
 	}
 
@@ -291,6 +451,16 @@ func (h *HTTPSource) Invoke(parentCtx context.Context, req *bindings.InvokeReque
 		request.Header.Set(h.metadata.SecurityTokenHeader, h.metadata.SecurityToken)
 	}
 
+	// Set the OAuth2 access token if the client credentials flow is enabled.
+	if h.oauth2Config != nil {
+		token, err := h.token()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		token.SetAuthHeader(request)
+	}
+
 	// Any metadata keys that start with a capital letter
 	// are treated as request headers
 	for mdKey, mdValue := range req.Metadata {
@@ -300,6 +470,16 @@ func (h *HTTPSource) Invoke(parentCtx context.Context, req *bindings.InvokeReque
 		}
 	}
 
+	// The length of streamed bodies is unknown, unless it's set in the metadata.
+	if cl := request.Header.Get("Content-Length"); cl != "" && body != nil {
+		request.ContentLength, err = strconv.ParseInt(cl, 10, 64)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("invalid Content-Length: %s", cl)
+		}
+		request.Header.Del("Content-Length")
+	}
+
 	// HTTP binding needs to inject traceparent header for proper tracing stack.
 	if tp, ok := req.Metadata[TraceparentHeaderKey]; ok && tp != "" {
 		if _, ok := request.Header[http.CanonicalHeaderKey(TraceparentHeaderKey)]; ok {
@@ -317,17 +497,15 @@ func (h *HTTPSource) Invoke(parentCtx context.Context, req *bindings.InvokeReque
 	}
 
 	// Send the question
-	resp, err := h.client.Do(request)
+	resp, err := h.do(request, opts)
 	if err != nil {
+		cancel()
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	// Read the response body. For empty responses (e.g. 204 No Content)
-	// `b` will be an empty slice.
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	// The token may have been revoked before it expired, so don't use it again
+	if h.oauth2Config != nil && resp.StatusCode == http.StatusUnauthorized {
+		h.resetTokenSource()
 	}
 
 	metadata := make(map[string]string, len(resp.Header)+2)
@@ -346,12 +524,135 @@ func (h *HTTPSource) Invoke(parentCtx context.Context, req *bindings.InvokeReque
 		err = fmt.Errorf("received status code %d", resp.StatusCode)
 	}
 
-	return &bindings.InvokeResponse{
-		Data:     b,
+	return &bindings.InvokeStreamResponse{
+		Data: &cancelOnCloseBody{
+			ReadCloser: resp.Body,
+			cancel:     cancel,
+		},
 		Metadata: metadata,
 	}, err
 }
 
+// do sends the request, retrying it on connection errors and on retriable status codes, as set in the options.
+func (h *HTTPSource) do(request *http.Request, opts invokeOptions) (*http.Response, error) {
+	// Requests with a body that can't be read again can't be retried
+	canRetry := request.Body == nil || request.GetBody != nil
+
+	var bo backoff.BackOff
+	if opts.retryCount > 0 && canRetry {
+		eb := backoff.NewExponentialBackOff()
+		eb.InitialInterval = retryInitialInterval
+		eb.MaxInterval = retryMaxInterval
+		eb.MaxElapsedTime = 0
+		bo = backoff.WithMaxRetries(eb, uint64(opts.retryCount))
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := h.client.Do(request)
+		if bo == nil || !opts.shouldRetry(request.Context(), resp, err) {
+			return resp, err
+		}
+		delay := bo.NextBackOff()
+		if delay == backoff.Stop {
+			return resp, err
+		}
+
+		if err != nil {
+			h.logger.Debugf("Attempt %d of request to %s failed, retrying in %v: %v", attempt, request.URL.Redacted(), delay, err)
+		} else {
+			h.logger.Debugf("Attempt %d of request to %s returned status code %d, retrying in %v", attempt, request.URL.Redacted(), resp.StatusCode, delay)
+			// Drain the body so the connection can be reused
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-request.Context().Done():
+			return nil, request.Context().Err()
+		case <-time.After(delay):
+		}
+
+		if request.GetBody != nil {
+			request.Body, err = request.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// invokeOptions are the options of an invocation set in the request metadata.
+type invokeOptions struct {
+	timeout              time.Duration
+	retryCount           int
+	retriableStatusCodes map[int]struct{}
+}
+
+// parseInvokeOptions parses the timeout, retryCount, and retriableStatusCodes request metadata.
+func parseInvokeOptions(md map[string]string) (opts invokeOptions, err error) {
+	if val := md[timeoutMetadataKey]; val != "" {
+		opts.timeout, err = time.ParseDuration(val)
+		if err != nil {
+			// Values without a unit are seconds
+			seconds, convErr := strconv.Atoi(val)
+			if convErr != nil {
+				return opts, fmt.Errorf("invalid %s: %s", timeoutMetadataKey, val)
+			}
+			opts.timeout = time.Duration(seconds) * time.Second
+		}
+		if opts.timeout <= 0 {
+			return opts, fmt.Errorf("invalid %s: must be greater than 0", timeoutMetadataKey)
+		}
+	}
+
+	if val := md[retryCountMetadataKey]; val != "" {
+		opts.retryCount, err = strconv.Atoi(val)
+		if err != nil || opts.retryCount < 0 {
+			return opts, fmt.Errorf("invalid %s: %s", retryCountMetadataKey, val)
+		}
+	}
+
+	codes := defaultRetriableStatusCodes
+	if val := md[retriableStatusCodesMetadataKey]; val != "" {
+		codes = []int{}
+		for _, c := range strings.Split(val, ",") {
+			code, convErr := strconv.Atoi(strings.TrimSpace(c))
+			if convErr != nil || code < 100 || code > 599 {
+				return opts, fmt.Errorf("invalid %s: %s", retriableStatusCodesMetadataKey, val)
+			}
+			codes = append(codes, code)
+		}
+	}
+	opts.retriableStatusCodes = make(map[int]struct{}, len(codes))
+	for _, code := range codes {
+		opts.retriableStatusCodes[code] = struct{}{}
+	}
+
+	return opts, nil
+}
+
+// shouldRetry returns true if the request failed with a connection error or a retriable status code.
+func (o invokeOptions) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		// Don't retry if the request timed out or was canceled
+		return ctx.Err() == nil
+	}
+	_, ok := o.retriableStatusCodes[resp.StatusCode]
+	return ok
+}
+
+// cancelOnCloseBody is a response body that cancels the context of the request when it's closed.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
 // GetComponentMetadata returns the metadata of the component.
 func (h *HTTPSource) GetComponentMetadata() map[string]string {
 	metadataStruct := httpMetadata{}
@@ -359,3 +660,9 @@ func (h *HTTPSource) GetComponentMetadata() map[string]string {
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.BindingType)
 	return metadataInfo
 }
+
+// GetComponentMetadataSchema returns the schema of the metadata of the component.
+func (h *HTTPSource) GetComponentMetadataSchema() []metadata.MetadataFieldSchema {
+	schema, _ := metadata.GetMetadataSchemaFromStructType(reflect.TypeOf(httpMetadata{}), metadata.BindingType)
+	return schema
+}
@@ -14,17 +14,27 @@ limitations under the License.
 package http
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -208,6 +218,120 @@ func TestSecurityTokenHeaderForwarded(t *testing.T) {
 	})
 }
 
+func TestOAuth2ClientCredentials(t *testing.T) {
+	handler := NewHTTPHandler()
+	s := httptest.NewServer(handler)
+	defer s.Close()
+
+	var tokenRequests atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := tokenRequests.Add(1)
+		r.ParseForm()
+		assert.Equal(t, "client_credentials", r.Form.Get("grant_type"))
+		assert.Equal(t, "read write", r.Form.Get("scope"))
+		assert.Equal(t, "https://api.example.com", r.Form.Get("audience"))
+		clientID, clientSecret, _ := r.BasicAuth()
+		assert.Equal(t, "myclient", clientID)
+		assert.Equal(t, "mysecret", clientSecret)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token%d","token_type":"Bearer","expires_in":3600}`, n)
+	}))
+	defer tokenServer.Close()
+
+	props := map[string]string{
+		"oauth2TokenURL":            tokenServer.URL,
+		"oauth2ClientID":            "myclient",
+		"oauth2ClientSecret":        "mysecret",
+		"oauth2Scopes":              "read, write",
+		"oauth2EndpointParamsQuery": "audience=https://api.example.com",
+		"oauth2AuthStyle":           "2",
+	}
+
+	t.Run("token is cached", func(t *testing.T) {
+		hs, err := InitBinding(s, props)
+		require.NoError(t, err)
+		tokenRequests.Store(0)
+
+		for i := 0; i < 3; i++ {
+			req := TestCase{
+				input:      "GET",
+				operation:  "get",
+				statusCode: 200,
+			}.ToInvokeRequest()
+			_, err = hs.Invoke(context.Background(), &req)
+			require.NoError(t, err)
+			assert.Equal(t, "Bearer token1", handler.Headers["Authorization"])
+		}
+		assert.Equal(t, int32(1), tokenRequests.Load())
+	})
+
+	t.Run("token is discarded after unauthorized response", func(t *testing.T) {
+		hs, err := InitBinding(s, props)
+		require.NoError(t, err)
+		tokenRequests.Store(0)
+
+		req := TestCase{
+			input:      "GET",
+			operation:  "get",
+			statusCode: 401,
+		}.ToInvokeRequest()
+		_, err = hs.Invoke(context.Background(), &req)
+		require.Error(t, err)
+		assert.Equal(t, "Bearer token1", handler.Headers["Authorization"])
+
+		req = TestCase{
+			input:      "GET",
+			operation:  "get",
+			statusCode: 200,
+		}.ToInvokeRequest()
+		_, err = hs.Invoke(context.Background(), &req)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer token2", handler.Headers["Authorization"])
+		assert.Equal(t, int32(2), tokenRequests.Load())
+	})
+
+	t.Run("token endpoint error", func(t *testing.T) {
+		hs, err := InitBinding(s, map[string]string{
+			"oauth2TokenURL":     s.URL + "/token",
+			"oauth2ClientID":     "myclient",
+			"oauth2ClientSecret": "mysecret",
+		})
+		require.NoError(t, err)
+
+		req := TestCase{
+			input:      "GET",
+			operation:  "get",
+			statusCode: 500,
+		}.ToInvokeRequest()
+		_, err = hs.Invoke(context.Background(), &req)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to acquire OAuth2 token")
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		_, err := InitBinding(s, map[string]string{
+			"oauth2TokenURL": tokenServer.URL,
+			"oauth2ClientID": "myclient",
+		})
+		require.Error(t, err)
+
+		_, err = InitBinding(s, map[string]string{
+			"oauth2ClientID":     "myclient",
+			"oauth2ClientSecret": "mysecret",
+		})
+		require.Error(t, err)
+
+		_, err = InitBinding(s, map[string]string{
+			"oauth2TokenURL":     tokenServer.URL,
+			"oauth2ClientID":     "myclient",
+			"oauth2ClientSecret": "mysecret",
+			"oauth2AuthStyle":    "3",
+		})
+		require.Error(t, err)
+	})
+}
+
 func TestTraceHeadersForwarded(t *testing.T) {
 	handler := NewHTTPHandler()
 	s := httptest.NewServer(handler)
@@ -744,3 +868,291 @@ func verifyTimeoutBehavior(t *testing.T, hs bindings.OutputBinding, handler *HTT
 		})
 	}
 }
+
+func TestInvokeStream(t *testing.T) {
+	var received atomic.Int64
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		received.Store(n)
+		assert.Equal(t, int64(1<<20), r.ContentLength)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		// Respond with a body larger than the request
+		for i := 0; i < 4; i++ {
+			w.Write(bytes.Repeat([]byte{'a'}, 1<<20))
+		}
+	}))
+	defer s.Close()
+
+	hs, err := InitBinding(s, nil)
+	require.NoError(t, err)
+
+	res, err := hs.(bindings.StreamingOutputBinding).InvokeStream(context.Background(), &bindings.InvokeStreamRequest{
+		Data:      io.LimitReader(zeroReader{}, 1<<20),
+		Operation: "post",
+		Metadata: map[string]string{
+			"Content-Length": strconv.Itoa(1 << 20),
+			"Content-Type":   "application/octet-stream",
+		},
+	})
+	require.NoError(t, err)
+	n, err := io.Copy(io.Discard, res.Data)
+	require.NoError(t, err)
+	require.NoError(t, res.Data.Close())
+
+	assert.Equal(t, int64(1<<20), received.Load())
+	assert.Equal(t, int64(4<<20), n)
+	assert.Equal(t, "200", res.Metadata["statusCode"])
+	assert.Equal(t, "application/octet-stream", res.Metadata["Content-Type"])
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func TestInvokeRetries(t *testing.T) {
+	var attempts atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "payload", string(b))
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer s.Close()
+
+	hs, err := InitBinding(s, nil)
+	require.NoError(t, err)
+
+	t.Run("retried until success", func(t *testing.T) {
+		attempts.Store(0)
+		res, err := hs.Invoke(context.Background(), &bindings.InvokeRequest{
+			Data:      []byte("payload"),
+			Operation: "post",
+			Metadata:  map[string]string{"retryCount": "3"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "ok", string(res.Data))
+		assert.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		attempts.Store(0)
+		res, err := hs.Invoke(context.Background(), &bindings.InvokeRequest{
+			Data:      []byte("payload"),
+			Operation: "post",
+			Metadata:  map[string]string{"retryCount": "1"},
+		})
+		require.Error(t, err)
+		assert.Equal(t, "503", res.Metadata["statusCode"])
+		assert.Equal(t, int32(2), attempts.Load())
+	})
+
+	t.Run("status code not retriable", func(t *testing.T) {
+		attempts.Store(0)
+		_, err := hs.Invoke(context.Background(), &bindings.InvokeRequest{
+			Data:      []byte("payload"),
+			Operation: "post",
+			Metadata: map[string]string{
+				"retryCount":           "3",
+				"retriableStatusCodes": "500, 502",
+			},
+		})
+		require.Error(t, err)
+		assert.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("streamed body not retried", func(t *testing.T) {
+		attempts.Store(0)
+		_, err := hs.(bindings.StreamingOutputBinding).InvokeStream(context.Background(), &bindings.InvokeStreamRequest{
+			Data:      io.MultiReader(strings.NewReader("payload")),
+			Operation: "post",
+			Metadata:  map[string]string{"retryCount": "3"},
+		})
+		require.Error(t, err)
+		assert.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		for _, md := range []map[string]string{
+			{"retryCount": "-1"},
+			{"retriableStatusCodes": "abc"},
+			{"timeout": "soon"},
+			{"timeout": "0"},
+		} {
+			_, err := hs.Invoke(context.Background(), &bindings.InvokeRequest{
+				Operation: "get",
+				Metadata:  md,
+			})
+			assert.Error(t, err, md)
+		}
+	})
+}
+
+func TestInvokeTimeout(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer s.Close()
+
+	hs, err := InitBinding(s, map[string]string{"responseTimeout": "5s"})
+	require.NoError(t, err)
+
+	// The per-invocation timeout takes precedence over responseTimeout
+	_, err = hs.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: "get",
+		Metadata: map[string]string{
+			"timeout": "100ms",
+		},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "context deadline exceeded")
+
+	_, err = hs.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: "get",
+		Metadata: map[string]string{
+			"timeout": "3",
+		},
+	})
+	require.NoError(t, err)
+}
+
+func TestClientCertificateReload(t *testing.T) {
+	now := time.Now()
+	var loads atomic.Int32
+	var fail atomic.Bool
+	c := newClientCertificate(func(ctx context.Context) (*tls.Certificate, error) {
+		if fail.Load() {
+			return nil, errors.New("simulated")
+		}
+		loads.Add(1)
+		return &tls.Certificate{Certificate: [][]byte{{byte(loads.Load())}}}, nil
+	}, time.Minute, logger.NewLogger("test"))
+	c.now = func() time.Time { return now }
+
+	cert, err := c.get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, byte(1), cert.Certificate[0][0])
+
+	// The certificate is cached until the refresh interval has passed
+	now = now.Add(30 * time.Second)
+	cert, err = c.get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, byte(1), cert.Certificate[0][0])
+
+	now = now.Add(time.Minute)
+	cert, err = c.get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, byte(2), cert.Certificate[0][0])
+
+	// When reloading fails, the previous certificate is used
+	fail.Store(true)
+	now = now.Add(time.Minute)
+	cert, err = c.get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, byte(2), cert.Certificate[0][0])
+}
+
+// generateCertificate returns a PEM-encoded certificate and key with the given common name, signed by the parent if set, or self-signed otherwise.
+func generateCertificate(t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return cert, key, string(certPEM), string(keyPEM)
+}
+
+func TestMTLSClientCertificateFromFiles(t *testing.T) {
+	ca, caKey, caPEM, _ := generateCertificate(t, "ca", true, nil, nil)
+	_, _, serverCertPEM, serverKeyPEM := generateCertificate(t, "localhost", false, ca, caKey)
+	_, _, clientCertPEM, clientKeyPEM := generateCertificate(t, "client-1", false, ca, caKey)
+
+	caPool := x509.NewCertPool()
+	caPool.AddCert(ca)
+	serverCert, err := tls.X509KeyPair([]byte(serverCertPEM), []byte(serverKeyPEM))
+	require.NoError(t, err)
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	s.TLS = &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		ClientCAs:    caPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		Certificates: []tls.Certificate{serverCert},
+	}
+	s.StartTLS()
+	defer s.Close()
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "tls.crt")
+	keyPath := filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certPath, []byte(clientCertPEM), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, []byte(clientKeyPEM), 0o600))
+	hs := NewHTTP(logger.NewLogger("test")).(*HTTPSource)
+	m := bindings.Metadata{Base: metadata.Base{
+		Properties: map[string]string{
+			"url":                     s.URL,
+			"mtlsRootCA":              caPEM,
+			"mtlsClientCert":          certPath,
+			"mtlsClientKey":           keyPath,
+			"mtlsCertRefreshInterval": "1ms",
+		},
+	}}
+	require.NoError(t, hs.Init(context.Background(), m))
+
+	res, err := hs.Invoke(context.Background(), &bindings.InvokeRequest{Operation: "get"})
+	require.NoError(t, err)
+	assert.Equal(t, "client-1", string(res.Data))
+
+	// Rotate the certificate in the files, and it's used for new connections
+	_, _, clientCertPEM, clientKeyPEM = generateCertificate(t, "client-2", false, ca, caKey)
+	require.NoError(t, os.WriteFile(certPath, []byte(clientCertPEM), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, []byte(clientKeyPEM), 0o600))
+	time.Sleep(5 * time.Millisecond)
+	hs.client.CloseIdleConnections()
+
+	res, err = hs.Invoke(context.Background(), &bindings.InvokeRequest{Operation: "get"})
+	require.NoError(t, err)
+	assert.Equal(t, "client-2", string(res.Data))
+
+	t.Run("missing file", func(t *testing.T) {
+		hs := NewHTTP(logger.NewLogger("test")).(*HTTPSource)
+		m.Properties["mtlsClientCert"] = filepath.Join(dir, "missing.crt")
+		defer func() { m.Properties["mtlsClientCert"] = certPath }()
+		require.Error(t, hs.Init(context.Background(), m))
+	})
+}
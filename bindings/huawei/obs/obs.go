@@ -19,8 +19,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"os"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
@@ -31,13 +35,18 @@ import (
 )
 
 const (
-	metadataKey = "key"
-	maxResults  = 1000
+	metadataKey           = "key"
+	metadataPresignTTL    = "presignTTL"
+	metadataPresignMethod = "presignMethod"
+	metadataPartSizeBytes = "partSizeBytes"
+
+	maxResults = 1000
 )
 
 // add operations that are not listed under the standard bindings operations.
 const (
-	UploadOperation bindings.OperationKind = "upload"
+	UploadOperation  bindings.OperationKind = "upload"
+	PresignOperation bindings.OperationKind = "presign"
 )
 
 type HuaweiOBS struct {
@@ -52,11 +61,23 @@ type obsMetadata struct {
 	AccessKey string `json:"accessKey"` // the Huawei Access Key (AK) to access the obs
 	SecretKey string `json:"secretKey"` // the Huawei Secret Key (SK) to access the obs
 	Bucket    string `json:"bucket"`    // the name of the Huawei OBS bucket to write to
+
+	PresignTTL        string `json:"presignTTL"`        // (optional) how long presigned URLs are valid for, such as "15m"; if set, the URL of created objects is returned
+	PartSizeBytes     int64  `json:"partSizeBytes"`     // (optional) size of the parts of multipart uploads; uploaded files larger than this are uploaded in multiple parts
+	UploadConcurrency int    `json:"uploadConcurrency"` // (optional) number of parts uploaded concurrently by multipart uploads
+	EnableCheckpoint  bool   `json:"enableCheckpoint"`  // (optional) records the progress of multipart uploads next to the source file, so that uploading it again resumes where it stopped
 }
 
 type createResponse struct {
 	StatusCode int    `json:"statusCode"`
 	VersionID  string `json:"versionId"`
+	PresignURL string `json:"presignURL,omitempty"`
+}
+
+type presignResponse struct {
+	PresignURL string `json:"presignURL"`
+	// Headers that clients must send with the request, as they're part of the signature
+	SignedHeaders map[string]string `json:"signedHeaders,omitempty"`
 }
 
 type uploadPayload struct {
@@ -112,6 +133,10 @@ func (o *HuaweiOBS) parseMetadata(meta bindings.Metadata) (*obsMetadata, error)
 	if m.SecretKey == "" {
 		return nil, fmt.Errorf("missing the huawei secret key")
 	}
+	err = m.validate()
+	if err != nil {
+		return nil, err
+	}
 
 	o.logger.Debugf("Huawei OBS metadata=[%s]", m)
 	return &m, nil
@@ -124,10 +149,70 @@ func (o *HuaweiOBS) Operations() []bindings.OperationKind {
 		bindings.GetOperation,
 		bindings.DeleteOperation,
 		bindings.ListOperation,
+		PresignOperation,
+	}
+}
+
+func (m obsMetadata) validate() error {
+	if m.PresignTTL != "" {
+		if _, err := m.presignExpires(); err != nil {
+			return err
+		}
+	}
+	if m.PartSizeBytes != 0 && (m.PartSizeBytes < obs.MIN_PART_SIZE || m.PartSizeBytes > obs.MAX_PART_SIZE) {
+		return fmt.Errorf("%s must be between %d and %d bytes", metadataPartSizeBytes, obs.MIN_PART_SIZE, obs.MAX_PART_SIZE)
+	}
+	if m.UploadConcurrency < 0 {
+		return fmt.Errorf("uploadConcurrency must not be negative")
 	}
+	return nil
+}
+
+// presignExpires returns the validity of presigned URLs in seconds.
+func (m obsMetadata) presignExpires() (int, error) {
+	d, err := time.ParseDuration(m.PresignTTL)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse %s %s: %w", metadataPresignTTL, m.PresignTTL, err)
+	}
+	if d < time.Second {
+		return 0, fmt.Errorf("%s must be at least 1s", metadataPresignTTL)
+	}
+	return int(d / time.Second), nil
+}
+
+// partSize returns the size of the parts of multipart uploads.
+func (m obsMetadata) partSize() int64 {
+	if m.PartSizeBytes > 0 {
+		return m.PartSizeBytes
+	}
+	return obs.DEFAULT_PART_SIZE
+}
+
+// mergeWithRequestMetadata returns the metadata of the component, overridden by the metadata of the request.
+func (m obsMetadata) mergeWithRequestMetadata(req *bindings.InvokeRequest) (obsMetadata, error) {
+	merged := m
+
+	if val, ok := req.Metadata[metadataPresignTTL]; ok && val != "" {
+		merged.PresignTTL = val
+	}
+
+	if val, ok := req.Metadata[metadataPartSizeBytes]; ok && val != "" {
+		partSize, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return merged, fmt.Errorf("invalid %s: %w", metadataPartSizeBytes, err)
+		}
+		merged.PartSizeBytes = partSize
+	}
+
+	return merged, merged.validate()
 }
 
 func (o *HuaweiOBS) create(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	metadata, err := o.metadata.mergeWithRequestMetadata(req)
+	if err != nil {
+		return nil, fmt.Errorf("obs binding error. %w", err)
+	}
+
 	d, err := strconv.Unquote(string(req.Data))
 	if err == nil {
 		req.Data = []byte(d)
@@ -153,9 +238,15 @@ func (o *HuaweiOBS) create(ctx context.Context, req *bindings.InvokeRequest) (*b
 		return nil, fmt.Errorf("obs binding error. putobject: %w", err)
 	}
 
+	presignURL, err := o.presignCreated(ctx, metadata, key)
+	if err != nil {
+		return nil, err
+	}
+
 	jsonResponse, err := json.Marshal(createResponse{
 		StatusCode: out.StatusCode,
 		VersionID:  out.VersionId,
+		PresignURL: presignURL,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("obs binding error. error marshalling create response: %w", err)
@@ -167,8 +258,13 @@ func (o *HuaweiOBS) create(ctx context.Context, req *bindings.InvokeRequest) (*b
 }
 
 func (o *HuaweiOBS) upload(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	metadata, err := o.metadata.mergeWithRequestMetadata(req)
+	if err != nil {
+		return nil, fmt.Errorf("obs binding error. %w", err)
+	}
+
 	var payload uploadPayload
-	err := json.Unmarshal(req.Data, &payload)
+	err = json.Unmarshal(req.Data, &payload)
 	if err != nil {
 		return nil, err
 	}
@@ -181,20 +277,43 @@ func (o *HuaweiOBS) upload(ctx context.Context, req *bindings.InvokeRequest) (*b
 		o.logger.Debugf("key not found. generating key %s", key)
 	}
 
-	input := &obs.PutFileInput{}
-	input.Key = key
-	input.Bucket = o.metadata.Bucket
-	input.SourceFile = payload.SourceFile
+	var res createResponse
+	// Files larger than a part are uploaded with a multipart upload, which can resume after failures
+	if info, statErr := os.Stat(payload.SourceFile); statErr == nil && info.Size() > metadata.partSize() {
+		input := &obs.UploadFileInput{}
+		input.Key = key
+		input.Bucket = metadata.Bucket
+		input.UploadFile = payload.SourceFile
+		input.PartSize = metadata.partSize()
+		input.TaskNum = metadata.UploadConcurrency
+		input.EnableCheckpoint = metadata.EnableCheckpoint
+
+		out, err := o.service.UploadFile(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("obs binding error. uploadfile: %w", err)
+		}
+		res.StatusCode = out.StatusCode
+		res.VersionID = out.VersionId
+	} else {
+		input := &obs.PutFileInput{}
+		input.Key = key
+		input.Bucket = metadata.Bucket
+		input.SourceFile = payload.SourceFile
+
+		out, err := o.service.PutFile(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("obs binding error. putfile: %w", err)
+		}
+		res.StatusCode = out.StatusCode
+		res.VersionID = out.VersionId
+	}
 
-	out, err := o.service.PutFile(ctx, input)
+	res.PresignURL, err = o.presignCreated(ctx, metadata, key)
 	if err != nil {
-		return nil, fmt.Errorf("obs binding error. putfile: %w", err)
+		return nil, err
 	}
 
-	jsonResponse, err := json.Marshal(createResponse{
-		StatusCode: out.StatusCode,
-		VersionID:  out.VersionId,
-	})
+	jsonResponse, err := json.Marshal(res)
 	if err != nil {
 		return nil, fmt.Errorf("obs binding error. error marshalling create response: %w", err)
 	}
@@ -204,6 +323,92 @@ func (o *HuaweiOBS) upload(ctx context.Context, req *bindings.InvokeRequest) (*b
 	}, nil
 }
 
+func (o *HuaweiOBS) presign(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	metadata, err := o.metadata.mergeWithRequestMetadata(req)
+	if err != nil {
+		return nil, fmt.Errorf("obs binding error. %w", err)
+	}
+
+	key := req.Metadata[metadataKey]
+	if key == "" {
+		return nil, fmt.Errorf("obs binding error: can't read key value")
+	}
+	if metadata.PresignTTL == "" {
+		return nil, fmt.Errorf("obs binding error: required metadata '%s' missing", metadataPresignTTL)
+	}
+
+	method := strings.ToUpper(req.Metadata[metadataPresignMethod])
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	out, err := o.presignObject(ctx, metadata, key, method)
+	if err != nil {
+		return nil, err
+	}
+
+	res := presignResponse{
+		PresignURL: out.SignedUrl,
+	}
+	// The host header is set by clients from the URL
+	for name := range out.ActualSignedRequestHeaders {
+		if !strings.EqualFold(name, "Host") {
+			if res.SignedHeaders == nil {
+				res.SignedHeaders = map[string]string{}
+			}
+			res.SignedHeaders[name] = out.ActualSignedRequestHeaders.Get(name)
+		}
+	}
+
+	jsonResponse, err := json.Marshal(res)
+	if err != nil {
+		return nil, fmt.Errorf("obs binding error. error marshalling presign response: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: jsonResponse,
+	}, nil
+}
+
+// presignCreated returns a URL for downloading a created object, if the presign TTL is set.
+func (o *HuaweiOBS) presignCreated(ctx context.Context, metadata obsMetadata, key string) (string, error) {
+	if metadata.PresignTTL == "" {
+		return "", nil
+	}
+	out, err := o.presignObject(ctx, metadata, key, http.MethodGet)
+	if err != nil {
+		return "", err
+	}
+	return out.SignedUrl, nil
+}
+
+// presignObject returns a URL for downloading (GET) or uploading (PUT) the object, valid for the presign TTL.
+func (o *HuaweiOBS) presignObject(ctx context.Context, metadata obsMetadata, key string, method string) (*obs.CreateSignedUrlOutput, error) {
+	expires, err := metadata.presignExpires()
+	if err != nil {
+		return nil, fmt.Errorf("obs binding error. %w", err)
+	}
+
+	input := &obs.CreateSignedUrlInput{}
+	input.Bucket = metadata.Bucket
+	input.Key = key
+	input.Expires = expires
+	switch method {
+	case http.MethodGet:
+		input.Method = obs.HttpMethodGet
+	case http.MethodPut:
+		input.Method = obs.HttpMethodPut
+	default:
+		return nil, fmt.Errorf("obs binding error. unsupported presign method %s", method)
+	}
+
+	out, err := o.service.CreateSignedURL(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("obs binding error. error creating signed url: %w", err)
+	}
+	return out, nil
+}
+
 func (o *HuaweiOBS) get(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	var key string
 	if val, ok := req.Metadata[metadataKey]; ok && val != "" {
@@ -272,12 +477,14 @@ func (o *HuaweiOBS) delete(ctx context.Context, req *bindings.InvokeRequest) (*b
 
 func (o *HuaweiOBS) list(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	var payload listPayload
-	err := json.Unmarshal(req.Data, &payload)
-	if err != nil {
-		return nil, err
+	if len(req.Data) > 0 {
+		err := json.Unmarshal(req.Data, &payload)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	if payload.MaxResults == int32(0) {
+	if payload.MaxResults <= 0 || payload.MaxResults > maxResults {
 		payload.MaxResults = maxResults
 	}
 
@@ -294,6 +501,11 @@ func (o *HuaweiOBS) list(ctx context.Context, req *bindings.InvokeRequest) (*bin
 		return nil, fmt.Errorf("obs binding error. error listing obs objects: %w", err)
 	}
 
+	// The next marker is only returned when listing with a delimiter: otherwise, the next page starts after the last key
+	if out.IsTruncated && out.NextMarker == "" && len(out.Contents) > 0 {
+		out.NextMarker = out.Contents[len(out.Contents)-1].Key
+	}
+
 	jsonResponse, err := json.Marshal(out)
 	if err != nil {
 		return nil, fmt.Errorf("obs binding error. list operation. cannot marshal response to json: %w", err)
@@ -316,6 +528,8 @@ func (o *HuaweiOBS) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*b
 		return o.delete(ctx, req)
 	case bindings.ListOperation:
 		return o.list(ctx, req)
+	case PresignOperation:
+		return o.presign(ctx, req)
 	default:
 		return nil, fmt.Errorf("obs binding error. unsupported operation %s", req.Operation)
 	}
@@ -27,6 +27,8 @@ type HuaweiOBSAPI interface {
 	GetObject(ctx context.Context, input *obs.GetObjectInput) (output *obs.GetObjectOutput, err error)
 	DeleteObject(ctx context.Context, input *obs.DeleteObjectInput) (output *obs.DeleteObjectOutput, err error)
 	ListObjects(ctx context.Context, input *obs.ListObjectsInput) (output *obs.ListObjectsOutput, err error)
+	UploadFile(ctx context.Context, input *obs.UploadFileInput) (output *obs.CompleteMultipartUploadOutput, err error)
+	CreateSignedURL(ctx context.Context, input *obs.CreateSignedUrlInput) (output *obs.CreateSignedUrlOutput, err error)
 }
 
 // HuaweiOBSService is a service layer which wraps the actual OBS SDK client to provide the API functions
@@ -54,3 +56,11 @@ func (s *HuaweiOBSService) DeleteObject(ctx context.Context, input *obs.DeleteOb
 func (s *HuaweiOBSService) ListObjects(ctx context.Context, input *obs.ListObjectsInput) (output *obs.ListObjectsOutput, err error) {
 	return s.client.ListObjects(input, obs.WithRequestContext(ctx))
 }
+
+func (s *HuaweiOBSService) UploadFile(ctx context.Context, input *obs.UploadFileInput) (output *obs.CompleteMultipartUploadOutput, err error) {
+	return s.client.UploadFile(input, obs.WithRequestContext(ctx))
+}
+
+func (s *HuaweiOBSService) CreateSignedURL(ctx context.Context, input *obs.CreateSignedUrlInput) (output *obs.CreateSignedUrlOutput, err error) {
+	return s.client.CreateSignedUrl(input, obs.WithRequestContext(ctx))
+}
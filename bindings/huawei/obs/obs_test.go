@@ -19,6 +19,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"testing/iotest"
@@ -33,11 +36,13 @@ import (
 // MockHuaweiOBSService is a mock service layer which mimics the OBS API functions
 // and it implements the HuaweiOBSAPI through stubs.
 type MockHuaweiOBSService struct {
-	PutObjectFn    func(ctx context.Context, input *obs.PutObjectInput) (output *obs.PutObjectOutput, err error)
-	PutFileFn      func(ctx context.Context, input *obs.PutFileInput) (output *obs.PutObjectOutput, err error)
-	GetObjectFn    func(ctx context.Context, input *obs.GetObjectInput) (output *obs.GetObjectOutput, err error)
-	DeleteObjectFn func(ctx context.Context, input *obs.DeleteObjectInput) (output *obs.DeleteObjectOutput, err error)
-	ListObjectsFn  func(ctx context.Context, input *obs.ListObjectsInput) (output *obs.ListObjectsOutput, err error)
+	PutObjectFn       func(ctx context.Context, input *obs.PutObjectInput) (output *obs.PutObjectOutput, err error)
+	PutFileFn         func(ctx context.Context, input *obs.PutFileInput) (output *obs.PutObjectOutput, err error)
+	GetObjectFn       func(ctx context.Context, input *obs.GetObjectInput) (output *obs.GetObjectOutput, err error)
+	DeleteObjectFn    func(ctx context.Context, input *obs.DeleteObjectInput) (output *obs.DeleteObjectOutput, err error)
+	ListObjectsFn     func(ctx context.Context, input *obs.ListObjectsInput) (output *obs.ListObjectsOutput, err error)
+	UploadFileFn      func(ctx context.Context, input *obs.UploadFileInput) (output *obs.CompleteMultipartUploadOutput, err error)
+	CreateSignedURLFn func(ctx context.Context, input *obs.CreateSignedUrlInput) (output *obs.CreateSignedUrlOutput, err error)
 }
 
 func (m *MockHuaweiOBSService) PutObject(ctx context.Context, input *obs.PutObjectInput) (output *obs.PutObjectOutput, err error) {
@@ -60,6 +65,14 @@ func (m *MockHuaweiOBSService) ListObjects(ctx context.Context, input *obs.ListO
 	return m.ListObjectsFn(ctx, input)
 }
 
+func (m *MockHuaweiOBSService) UploadFile(ctx context.Context, input *obs.UploadFileInput) (output *obs.CompleteMultipartUploadOutput, err error) {
+	return m.UploadFileFn(ctx, input)
+}
+
+func (m *MockHuaweiOBSService) CreateSignedURL(ctx context.Context, input *obs.CreateSignedUrlInput) (output *obs.CreateSignedUrlOutput, err error) {
+	return m.CreateSignedURLFn(ctx, input)
+}
+
 func TestParseMetadata(t *testing.T) {
 	obs := NewHuaweiOBS(logger.NewLogger("test")).(*HuaweiOBS)
 
@@ -79,6 +92,50 @@ func TestParseMetadata(t *testing.T) {
 		assert.Equal(t, "dummy-ak", meta.AccessKey)
 		assert.Equal(t, "dummy-sk", meta.SecretKey)
 	})
+
+	t.Run("Has multipart and presign metadata", func(t *testing.T) {
+		m := bindings.Metadata{}
+		m.Properties = map[string]string{
+			"bucket":            "dummy-bucket",
+			"endpoint":          "dummy-endpoint",
+			"accessKey":         "dummy-ak",
+			"secretKey":         "dummy-sk",
+			"presignTTL":        "15m",
+			"partSizeBytes":     "10485760",
+			"uploadConcurrency": "4",
+			"enableCheckpoint":  "true",
+		}
+
+		meta, err := obs.parseMetadata(m)
+		assert.Nil(t, err)
+		assert.Equal(t, "15m", meta.PresignTTL)
+		assert.Equal(t, int64(10485760), meta.PartSizeBytes)
+		assert.Equal(t, 4, meta.UploadConcurrency)
+		assert.True(t, meta.EnableCheckpoint)
+	})
+
+	t.Run("Has invalid multipart and presign metadata", func(t *testing.T) {
+		for _, props := range []map[string]string{
+			{"presignTTL": "15"},
+			{"presignTTL": "500ms"},
+			{"partSizeBytes": "1024"},
+			{"uploadConcurrency": "-1"},
+		} {
+			m := bindings.Metadata{}
+			m.Properties = map[string]string{
+				"bucket":    "dummy-bucket",
+				"endpoint":  "dummy-endpoint",
+				"accessKey": "dummy-ak",
+				"secretKey": "dummy-sk",
+			}
+			for k, v := range props {
+				m.Properties[k] = v
+			}
+
+			_, err := obs.parseMetadata(m)
+			assert.Error(t, err, props)
+		}
+	})
 }
 
 func TestInit(t *testing.T) {
@@ -146,7 +203,7 @@ func TestOperations(t *testing.T) {
 
 	t.Run("Count supported operations", func(t *testing.T) {
 		ops := obs.Operations()
-		assert.Equal(t, 5, len(ops))
+		assert.Equal(t, 6, len(ops))
 	})
 }
 
@@ -360,6 +417,161 @@ func TestUploadOperation(t *testing.T) {
 		_, err := mo.upload(context.Background(), req)
 		assert.NotNil(t, err)
 	})
+
+	t.Run("Successfully upload large file with multipart upload", func(t *testing.T) {
+		sourceFile := filepath.Join(t.TempDir(), "large")
+		err := os.WriteFile(sourceFile, make([]byte, obs.MIN_PART_SIZE+1), 0o600)
+		assert.Nil(t, err)
+
+		var uploaded *obs.UploadFileInput
+		mo := &HuaweiOBS{
+			service: &MockHuaweiOBSService{
+				UploadFileFn: func(ctx context.Context, input *obs.UploadFileInput) (output *obs.CompleteMultipartUploadOutput, err error) {
+					uploaded = input
+					return &obs.CompleteMultipartUploadOutput{
+						BaseModel: obs.BaseModel{
+							StatusCode: 200,
+						},
+						VersionId: "v1",
+					}, nil
+				},
+			},
+			logger: logger.NewLogger("test"),
+			metadata: &obsMetadata{
+				Bucket:            "test",
+				UploadConcurrency: 4,
+				EnableCheckpoint:  true,
+			},
+		}
+
+		req := &bindings.InvokeRequest{
+			Operation: "upload",
+			Metadata: map[string]string{
+				metadataKey:           "test",
+				metadataPartSizeBytes: "102400",
+			},
+			Data: []byte(`{"sourceFile": "` + filepath.ToSlash(sourceFile) + `"}`),
+		}
+
+		out, err := mo.upload(context.Background(), req)
+		assert.Nil(t, err)
+		assert.Equal(t, "test", uploaded.Key)
+		assert.Equal(t, int64(obs.MIN_PART_SIZE), uploaded.PartSize)
+		assert.Equal(t, 4, uploaded.TaskNum)
+		assert.True(t, uploaded.EnableCheckpoint)
+
+		var data createResponse
+		err = json.Unmarshal(out.Data, &data)
+		assert.Nil(t, err)
+		assert.Equal(t, 200, data.StatusCode)
+		assert.Equal(t, "v1", data.VersionID)
+	})
+
+	t.Run("Successfully upload small file with presigned url", func(t *testing.T) {
+		sourceFile := filepath.Join(t.TempDir(), "small")
+		err := os.WriteFile(sourceFile, []byte("test data"), 0o600)
+		assert.Nil(t, err)
+
+		mo := &HuaweiOBS{
+			service: &MockHuaweiOBSService{
+				PutFileFn: func(ctx context.Context, input *obs.PutFileInput) (output *obs.PutObjectOutput, err error) {
+					return &obs.PutObjectOutput{
+						BaseModel: obs.BaseModel{
+							StatusCode: 200,
+						},
+					}, nil
+				},
+				CreateSignedURLFn: func(ctx context.Context, input *obs.CreateSignedUrlInput) (output *obs.CreateSignedUrlOutput, err error) {
+					assert.Equal(t, obs.HttpMethodGet, input.Method)
+					assert.Equal(t, 3600, input.Expires)
+					return &obs.CreateSignedUrlOutput{
+						SignedUrl: "https://test.obs.example.com/test?Signature=sig",
+					}, nil
+				},
+			},
+			logger: logger.NewLogger("test"),
+			metadata: &obsMetadata{
+				Bucket:     "test",
+				PresignTTL: "1h",
+			},
+		}
+
+		req := &bindings.InvokeRequest{
+			Operation: "upload",
+			Metadata: map[string]string{
+				metadataKey: "test",
+			},
+			Data: []byte(`{"sourceFile": "` + filepath.ToSlash(sourceFile) + `"}`),
+		}
+
+		out, err := mo.upload(context.Background(), req)
+		assert.Nil(t, err)
+
+		var data createResponse
+		err = json.Unmarshal(out.Data, &data)
+		assert.Nil(t, err)
+		assert.Equal(t, "https://test.obs.example.com/test?Signature=sig", data.PresignURL)
+	})
+}
+
+func TestPresignOperation(t *testing.T) {
+	var signed *obs.CreateSignedUrlInput
+	mo := &HuaweiOBS{
+		service: &MockHuaweiOBSService{
+			CreateSignedURLFn: func(ctx context.Context, input *obs.CreateSignedUrlInput) (output *obs.CreateSignedUrlOutput, err error) {
+				signed = input
+				return &obs.CreateSignedUrlOutput{
+					SignedUrl: "https://test.obs.example.com/test?Signature=sig",
+					ActualSignedRequestHeaders: http.Header{
+						"Host":         []string{"test.obs.example.com"},
+						"Content-Type": []string{"text/plain"},
+					},
+				}, nil
+			},
+		},
+		logger: logger.NewLogger("test"),
+		metadata: &obsMetadata{
+			Bucket: "test",
+		},
+	}
+
+	t.Run("Successfully presign object for upload", func(t *testing.T) {
+		req := &bindings.InvokeRequest{
+			Operation: "presign",
+			Metadata: map[string]string{
+				metadataKey:           "test",
+				metadataPresignTTL:    "15m",
+				metadataPresignMethod: "put",
+			},
+		}
+
+		out, err := mo.Invoke(context.Background(), req)
+		assert.Nil(t, err)
+		assert.Equal(t, "test", signed.Bucket)
+		assert.Equal(t, "test", signed.Key)
+		assert.Equal(t, obs.HttpMethodPut, signed.Method)
+		assert.Equal(t, 900, signed.Expires)
+
+		var data presignResponse
+		err = json.Unmarshal(out.Data, &data)
+		assert.Nil(t, err)
+		assert.Equal(t, presignResponse{
+			PresignURL:    "https://test.obs.example.com/test?Signature=sig",
+			SignedHeaders: map[string]string{"Content-Type": "text/plain"},
+		}, data)
+	})
+
+	t.Run("Fail presign object with invalid request", func(t *testing.T) {
+		for _, md := range []map[string]string{
+			{metadataPresignTTL: "15m"},
+			{metadataKey: "test"},
+			{metadataKey: "test", metadataPresignTTL: "forever"},
+			{metadataKey: "test", metadataPresignTTL: "15m", metadataPresignMethod: "delete"},
+		} {
+			_, err := mo.Invoke(context.Background(), &bindings.InvokeRequest{Operation: "presign", Metadata: md})
+			assert.NotNil(t, err, md)
+		}
+	})
 }
 
 func TestGetOperation(t *testing.T) {
@@ -628,6 +840,51 @@ func TestListOperation(t *testing.T) {
 		_, err := mo.list(context.Background(), req)
 		assert.Nil(t, err)
 	})
+
+	t.Run("Successfully list pages of objects with prefix", func(t *testing.T) {
+		var listed *obs.ListObjectsInput
+		mo := &HuaweiOBS{
+			service: &MockHuaweiOBSService{
+				ListObjectsFn: func(ctx context.Context, input *obs.ListObjectsInput) (output *obs.ListObjectsOutput, err error) {
+					listed = input
+					return &obs.ListObjectsOutput{
+						BaseModel: obs.BaseModel{
+							StatusCode: 200,
+						},
+						IsTruncated: true,
+						Contents: []obs.Content{
+							{Key: "logs/2"},
+							{Key: "logs/3"},
+						},
+					}, nil
+				},
+			},
+			logger: logger.NewLogger("test"),
+			metadata: &obsMetadata{
+				Bucket: "test",
+			},
+		}
+
+		req := &bindings.InvokeRequest{
+			Operation: "list",
+			Data:      []byte(`{"prefix": "logs/", "marker": "logs/1", "maxResults": 5000}`),
+		}
+
+		out, err := mo.list(context.Background(), req)
+		assert.Nil(t, err)
+		assert.Equal(t, "logs/", listed.Prefix)
+		assert.Equal(t, "logs/1", listed.Marker)
+		assert.Equal(t, maxResults, listed.MaxKeys)
+
+		var data obs.ListObjectsOutput
+		err = json.Unmarshal(out.Data, &data)
+		assert.Nil(t, err)
+		assert.True(t, data.IsTruncated)
+		assert.Equal(t, "logs/3", data.NextMarker)
+
+		_, err = mo.list(context.Background(), &bindings.InvokeRequest{Operation: "list"})
+		assert.Nil(t, err)
+	})
 }
 
 func TestInvoke(t *testing.T) {
@@ -29,8 +29,9 @@ import (
 )
 
 const (
-	publishTopic = "publishTopic"
-	topics       = "topics"
+	publishTopic    = "publishTopic"
+	topics          = "topics"
+	deadLetterTopic = "deadLetterTopic"
 )
 
 type Binding struct {
@@ -71,6 +72,12 @@ func (b *Binding) Init(ctx context.Context, metadata bindings.Metadata) error {
 		b.topics = strings.Split(val, ",")
 	}
 
+	// Messages that fail processing are published to the dead letter topic, after retries if consumeRetryEnabled is set
+	val, ok = metadata.Properties[deadLetterTopic]
+	if ok && val != "" {
+		b.kafka.DeadLetterTopic = val
+	}
+
 	return nil
 }
 
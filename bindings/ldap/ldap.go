@@ -0,0 +1,562 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	b64 "encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// SearchOperation searches entries with a filter.
+	SearchOperation bindings.OperationKind = "search"
+	// BindOperation verifies the credentials of an entry, such as a user.
+	BindOperation bindings.OperationKind = "bind"
+	// AddOperation adds an entry.
+	AddOperation bindings.OperationKind = "add"
+	// ModifyOperation modifies the attributes of an entry.
+	ModifyOperation bindings.OperationKind = "modify"
+
+	defaultTimeout  = 10 * time.Second
+	defaultPageSize = 500
+	defaultFilter   = "(objectClass=*)"
+)
+
+var (
+	ErrMissingURL      = errors.New("url is a required attribute")
+	ErrMissingDN       = errors.New("dn is required")
+	ErrMissingPassword = errors.New("password is required")
+)
+
+// LDAP is a binding to query and modify the entries of an LDAP directory, such as Active Directory.
+type LDAP struct {
+	metadata  *ldapMetadata
+	tlsConfig *tls.Config
+	logger    logger.Logger
+
+	// dial opens a connection to the server; it's replaced in tests.
+	dial func(ctx context.Context) (ldapConn, error)
+}
+
+// ldapConn is the subset of the methods of *ldap.Conn used by the binding.
+type ldapConn interface {
+	Bind(username, password string) error
+	SearchWithPaging(searchRequest *ldap.SearchRequest, pagingSize uint32) (*ldap.SearchResult, error)
+	Add(addRequest *ldap.AddRequest) error
+	Modify(modifyRequest *ldap.ModifyRequest) error
+	Close()
+}
+
+type ldapMetadata struct {
+	// URL of the server, such as "ldaps://ldap.example.com:636" or "ldap://ldap.example.com:389".
+	URL string `mapstructure:"url"`
+	// DN and password the binding authenticates with; if empty, operations are anonymous.
+	BindDN       string `mapstructure:"bindDN"`
+	BindPassword string `mapstructure:"bindPassword"`
+	// If true, connections to "ldap://" URLs are upgraded to TLS with the StartTLS operation.
+	StartTLS bool `mapstructure:"startTLS"`
+	// PEM-encoded certificate of the CA of the server; if empty, the system's CAs are used.
+	CACert string `mapstructure:"caCert"`
+	// PEM-encoded certificate and key for TLS client authentication.
+	ClientCert string `mapstructure:"clientCert"`
+	ClientKey  string `mapstructure:"clientKey"`
+	// If true, the certificate of the server isn't verified. Not recommended in production.
+	SkipTLSVerify bool `mapstructure:"skipTLSVerify"`
+	// Timeout for connecting to the server and for each operation.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Base DN of the searches that don't have one.
+	BaseDN string `mapstructure:"baseDN"`
+	// Number of entries the server returns in each page of the results of the searches.
+	PageSize uint32 `mapstructure:"pageSize"`
+}
+
+// searchPayload is the data of the search operation.
+type searchPayload struct {
+	// Base DN of the search; defaults to the baseDN of the component.
+	BaseDN string `json:"baseDN"`
+	// Filter of the search, such as "(&(objectClass=user)(sAMAccountName=jdoe))"; defaults to all entries.
+	Filter string `json:"filter"`
+	// Scope of the search: "base", "one", or "sub" (the default).
+	Scope string `json:"scope"`
+	// Attributes to return; all user attributes are returned if empty.
+	Attributes []string `json:"attributes"`
+	// Attributes whose values are binary, such as "objectGUID", which are returned base64-encoded.
+	BinaryAttributes []string `json:"binaryAttributes"`
+	// Maximum number of entries to return; 0 means no limit other than the server's.
+	SizeLimit int `json:"sizeLimit"`
+}
+
+type searchResponse struct {
+	Entries []searchEntry `json:"entries"`
+	// True if the results were truncated because of the size limit.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type searchEntry struct {
+	DN         string              `json:"dn"`
+	Attributes map[string][]string `json:"attributes"`
+}
+
+// bindPayload is the data of the bind operation.
+type bindPayload struct {
+	DN       string `json:"dn"`
+	Password string `json:"password"`
+}
+
+type bindResponse struct {
+	Authenticated bool `json:"authenticated"`
+}
+
+// addPayload is the data of the add operation.
+type addPayload struct {
+	DN         string              `json:"dn"`
+	Attributes map[string][]string `json:"attributes"`
+}
+
+// modifyPayload is the data of the modify operation.
+type modifyPayload struct {
+	DN      string         `json:"dn"`
+	Changes []modifyChange `json:"changes"`
+}
+
+type modifyChange struct {
+	// Type of the change: "add", "replace", or "delete".
+	Type      string   `json:"type"`
+	Attribute string   `json:"attribute"`
+	Values    []string `json:"values"`
+}
+
+// NewLDAP returns a new LDAP binding.
+func NewLDAP(logger logger.Logger) bindings.OutputBinding {
+	l := &LDAP{logger: logger}
+	l.dial = l.dialServer
+	return l
+}
+
+// Init does metadata parsing and prepares the TLS configuration.
+func (l *LDAP) Init(_ context.Context, meta bindings.Metadata) error {
+	m, err := parseMetadata(meta)
+	if err != nil {
+		return err
+	}
+	l.metadata = m
+
+	l.tlsConfig, err = m.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(m.URL, "ldaps://") && !m.StartTLS {
+		l.logger.Warn("LDAP connections aren't encrypted: use an ldaps:// URL or enable startTLS")
+	}
+
+	return nil
+}
+
+func parseMetadata(meta bindings.Metadata) (*ldapMetadata, error) {
+	m := ldapMetadata{
+		Timeout:  defaultTimeout,
+		PageSize: defaultPageSize,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.URL == "" {
+		return nil, ErrMissingURL
+	}
+	u, err := url.Parse(m.URL)
+	if err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url %s: must be an ldap:// or ldaps:// URL", m.URL)
+	}
+	if m.StartTLS && u.Scheme == "ldaps" {
+		return nil, errors.New("startTLS can't be enabled with an ldaps:// URL, which is already encrypted")
+	}
+	if m.Timeout <= 0 {
+		return nil, errors.New("timeout must be positive")
+	}
+	if m.PageSize == 0 {
+		m.PageSize = defaultPageSize
+	}
+	if (m.ClientCert == "") != (m.ClientKey == "") {
+		return nil, errors.New("clientCert and clientKey must be set together")
+	}
+
+	return &m, nil
+}
+
+func (m *ldapMetadata) tlsConfig() (*tls.Config, error) {
+	u, _ := url.Parse(m.URL)
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: m.SkipTLSVerify, //nolint:gosec
+	}
+	if m.CACert != "" {
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM([]byte(m.CACert)) {
+			return nil, errors.New("invalid caCert: no PEM-encoded certificate found")
+		}
+	}
+	if m.ClientCert != "" {
+		cert, err := tls.X509KeyPair([]byte(m.ClientCert), []byte(m.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid clientCert or clientKey: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// dialServer opens a connection to the server, encrypted with TLS for ldaps:// URLs or if startTLS is enabled.
+func (l *LDAP) dialServer(ctx context.Context) (ldapConn, error) {
+	dialer := &net.Dialer{Timeout: l.metadata.Timeout}
+	if deadline, ok := ctx.Deadline(); ok {
+		dialer.Deadline = deadline
+	}
+	conn, err := ldap.DialURL(l.metadata.URL, ldap.DialWithDialer(dialer), ldap.DialWithTLSConfig(l.tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(l.metadata.Timeout)
+
+	if l.metadata.StartTLS {
+		err = conn.StartTLS(l.tlsConfig)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("error starting TLS: %w", err)
+		}
+	}
+
+	return conn, nil
+}
+
+// withConn invokes fn with a connection to the server, authenticated as bindDN if set.
+// The connection is closed when fn returns, or when ctx is done.
+func (l *LDAP) withConn(ctx context.Context, authenticate bool, fn func(conn ldapConn) error) error {
+	conn, err := l.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("error connecting to the LDAP server: %w", err)
+	}
+
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		conn.Close()
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	if authenticate && l.metadata.BindDN != "" {
+		err = conn.Bind(l.metadata.BindDN, l.metadata.BindPassword)
+		if err != nil {
+			return fmt.Errorf("error authenticating as %s: %w", l.metadata.BindDN, err)
+		}
+	}
+
+	err = fn(conn)
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// Operations returns the operations supported by the binding.
+func (l *LDAP) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{
+		SearchOperation,
+		BindOperation,
+		AddOperation,
+		ModifyOperation,
+	}
+}
+
+// Invoke performs an LDAP operation.
+func (l *LDAP) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	switch req.Operation {
+	case SearchOperation:
+		return l.search(ctx, req)
+	case BindOperation:
+		return l.bind(ctx, req)
+	case AddOperation:
+		return l.add(ctx, req)
+	case ModifyOperation:
+		return l.modify(ctx, req)
+	default:
+		return nil, fmt.Errorf("unsupported operation %s", req.Operation)
+	}
+}
+
+func (l *LDAP) search(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	var payload searchPayload
+	err := unmarshalPayload(req, &payload)
+	if err != nil {
+		return nil, err
+	}
+	searchReq, err := l.searchRequest(&payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *ldap.SearchResult
+	err = l.withConn(ctx, true, func(conn ldapConn) (err error) {
+		result, err = conn.SearchWithPaging(searchReq, l.metadata.PageSize)
+		return err
+	})
+	truncated := false
+	if err != nil {
+		// When the size limit is exceeded, the entries up to the limit are returned
+		if !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) || result == nil {
+			return nil, fmt.Errorf("error searching LDAP entries: %w", err)
+		}
+		truncated = true
+	}
+
+	resp := searchResponse{
+		Entries:   make([]searchEntry, len(result.Entries)),
+		Truncated: truncated,
+	}
+	binary := make(map[string]struct{}, len(payload.BinaryAttributes))
+	for _, a := range payload.BinaryAttributes {
+		binary[strings.ToLower(a)] = struct{}{}
+	}
+	for i, e := range result.Entries {
+		resp.Entries[i] = convertEntry(e, binary)
+	}
+
+	return marshalResponse(resp)
+}
+
+// searchRequest returns the search request for the payload of a search operation.
+func (l *LDAP) searchRequest(payload *searchPayload) (*ldap.SearchRequest, error) {
+	baseDN := payload.BaseDN
+	if baseDN == "" {
+		baseDN = l.metadata.BaseDN
+	}
+	if baseDN == "" {
+		return nil, errors.New("baseDN is required, in the request or in the metadata of the component")
+	}
+
+	filter := payload.Filter
+	if filter == "" {
+		filter = defaultFilter
+	}
+	_, err := ldap.CompileFilter(filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %s: %w", filter, err)
+	}
+
+	var scope int
+	switch strings.ToLower(payload.Scope) {
+	case "base":
+		scope = ldap.ScopeBaseObject
+	case "one":
+		scope = ldap.ScopeSingleLevel
+	case "sub", "":
+		scope = ldap.ScopeWholeSubtree
+	default:
+		return nil, fmt.Errorf("invalid scope %s: must be base, one, or sub", payload.Scope)
+	}
+
+	if payload.SizeLimit < 0 {
+		return nil, errors.New("sizeLimit must not be negative")
+	}
+
+	return ldap.NewSearchRequest(baseDN, scope, ldap.NeverDerefAliases, payload.SizeLimit, int(l.metadata.Timeout.Seconds()), false, filter, payload.Attributes, nil), nil
+}
+
+// convertEntry converts an entry of the search results, encoding the values of the binary attributes with base64.
+func convertEntry(e *ldap.Entry, binary map[string]struct{}) searchEntry {
+	res := searchEntry{
+		DN:         e.DN,
+		Attributes: make(map[string][]string, len(e.Attributes)),
+	}
+	for _, a := range e.Attributes {
+		if _, ok := binary[strings.ToLower(a.Name)]; ok {
+			values := make([]string, len(a.ByteValues))
+			for i, v := range a.ByteValues {
+				values[i] = b64.StdEncoding.EncodeToString(v)
+			}
+			res.Attributes[a.Name] = values
+		} else {
+			res.Attributes[a.Name] = a.Values
+		}
+	}
+	return res
+}
+
+func (l *LDAP) bind(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	var payload bindPayload
+	err := unmarshalPayload(req, &payload)
+	if err != nil {
+		return nil, err
+	}
+	if payload.DN == "" {
+		return nil, ErrMissingDN
+	}
+	// An empty password would be an unauthenticated bind, which most servers accept for any DN
+	if payload.Password == "" {
+		return nil, ErrMissingPassword
+	}
+
+	authenticated := true
+	err = l.withConn(ctx, false, func(conn ldapConn) error {
+		return conn.Bind(payload.DN, payload.Password)
+	})
+	if err != nil {
+		if !ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, fmt.Errorf("error verifying the credentials of %s: %w", payload.DN, err)
+		}
+		authenticated = false
+	}
+
+	return marshalResponse(bindResponse{Authenticated: authenticated})
+}
+
+func (l *LDAP) add(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	var payload addPayload
+	err := unmarshalPayload(req, &payload)
+	if err != nil {
+		return nil, err
+	}
+	if payload.DN == "" {
+		return nil, ErrMissingDN
+	}
+	if len(payload.Attributes) == 0 {
+		return nil, errors.New("attributes are required")
+	}
+
+	addReq := ldap.NewAddRequest(payload.DN, nil)
+	for name, values := range payload.Attributes {
+		addReq.Attribute(name, values)
+	}
+
+	err = l.withConn(ctx, true, func(conn ldapConn) error {
+		return conn.Add(addReq)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error adding LDAP entry %s: %w", payload.DN, err)
+	}
+
+	return nil, nil
+}
+
+func (l *LDAP) modify(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	var payload modifyPayload
+	err := unmarshalPayload(req, &payload)
+	if err != nil {
+		return nil, err
+	}
+	modifyReq, err := modifyRequest(&payload)
+	if err != nil {
+		return nil, err
+	}
+
+	err = l.withConn(ctx, true, func(conn ldapConn) error {
+		return conn.Modify(modifyReq)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error modifying LDAP entry %s: %w", payload.DN, err)
+	}
+
+	return nil, nil
+}
+
+// modifyRequest returns the modify request for the payload of a modify operation.
+func modifyRequest(payload *modifyPayload) (*ldap.ModifyRequest, error) {
+	if payload.DN == "" {
+		return nil, ErrMissingDN
+	}
+	if len(payload.Changes) == 0 {
+		return nil, errors.New("changes are required")
+	}
+
+	modifyReq := ldap.NewModifyRequest(payload.DN, nil)
+	for _, c := range payload.Changes {
+		if c.Attribute == "" {
+			return nil, errors.New("the attribute of the changes is required")
+		}
+		switch strings.ToLower(c.Type) {
+		case "add":
+			if len(c.Values) == 0 {
+				return nil, fmt.Errorf("values are required to add to attribute %s", c.Attribute)
+			}
+			modifyReq.Add(c.Attribute, c.Values)
+		case "replace":
+			modifyReq.Replace(c.Attribute, c.Values)
+		case "delete":
+			modifyReq.Delete(c.Attribute, c.Values)
+		default:
+			return nil, fmt.Errorf("invalid type %s of the change of attribute %s: must be add, replace, or delete", c.Type, c.Attribute)
+		}
+	}
+
+	return modifyReq, nil
+}
+
+func unmarshalPayload(req *bindings.InvokeRequest, payload any) error {
+	if len(req.Data) == 0 {
+		return nil
+	}
+	err := json.Unmarshal(req.Data, payload)
+	if err != nil {
+		return fmt.Errorf("invalid payload of operation %s: %w", req.Operation, err)
+	}
+	return nil
+}
+
+func marshalResponse(resp any) (*bindings.InvokeResponse, error) {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling response: %w", err)
+	}
+	return &bindings.InvokeResponse{
+		Data: b,
+	}, nil
+}
+
+// Close implements io.Closer; connections are opened for each operation.
+func (l *LDAP) Close() error {
+	return nil
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (l *LDAP) GetComponentMetadata() map[string]string {
+	metadataStruct := ldapMetadata{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.BindingType)
+	return metadataInfo
+}
@@ -0,0 +1,291 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ldap
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+type fakeConn struct {
+	binds    [][2]string
+	bindErr  func(username, password string) error
+	search   *ldap.SearchRequest
+	pageSize uint32
+	result   *ldap.SearchResult
+	err      error
+	added    *ldap.AddRequest
+	modified *ldap.ModifyRequest
+	closed   bool
+}
+
+func (c *fakeConn) Bind(username, password string) error {
+	c.binds = append(c.binds, [2]string{username, password})
+	if c.bindErr != nil {
+		return c.bindErr(username, password)
+	}
+	return nil
+}
+
+func (c *fakeConn) SearchWithPaging(req *ldap.SearchRequest, pagingSize uint32) (*ldap.SearchResult, error) {
+	c.search = req
+	c.pageSize = pagingSize
+	return c.result, c.err
+}
+
+func (c *fakeConn) Add(req *ldap.AddRequest) error {
+	c.added = req
+	return c.err
+}
+
+func (c *fakeConn) Modify(req *ldap.ModifyRequest) error {
+	c.modified = req
+	return c.err
+}
+
+func (c *fakeConn) Close() {
+	c.closed = true
+}
+
+func newTestLDAP(t *testing.T, conn *fakeConn) *LDAP {
+	t.Helper()
+
+	l := NewLDAP(logger.NewLogger("test")).(*LDAP)
+	err := l.Init(context.Background(), bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"url":          "ldaps://ldap.example.com",
+		"bindDN":       "cn=dapr,dc=example,dc=com",
+		"bindPassword": "secret",
+		"baseDN":       "dc=example,dc=com",
+		"pageSize":     "100",
+	}}})
+	require.NoError(t, err)
+	l.dial = func(ctx context.Context) (ldapConn, error) {
+		return conn, nil
+	}
+	return l
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"url": "ldap://ldap.example.com:389",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, defaultTimeout, m.Timeout)
+		assert.Equal(t, uint32(defaultPageSize), m.PageSize)
+		assert.False(t, m.StartTLS)
+	})
+
+	t.Run("all fields", func(t *testing.T) {
+		m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"url":          "ldap://ldap.example.com:389",
+			"bindDN":       "cn=dapr,dc=example,dc=com",
+			"bindPassword": "secret",
+			"startTLS":     "true",
+			"timeout":      "30",
+			"baseDN":       "dc=example,dc=com",
+			"pageSize":     "1000",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "cn=dapr,dc=example,dc=com", m.BindDN)
+		assert.Equal(t, "secret", m.BindPassword)
+		assert.True(t, m.StartTLS)
+		assert.Equal(t, 30*time.Second, m.Timeout)
+		assert.Equal(t, "dc=example,dc=com", m.BaseDN)
+		assert.Equal(t, uint32(1000), m.PageSize)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, props := range []map[string]string{
+			{},
+			{"url": "http://ldap.example.com"},
+			{"url": "ldaps://ldap.example.com", "startTLS": "true"},
+			{"url": "ldap://ldap.example.com", "timeout": "-1s"},
+			{"url": "ldap://ldap.example.com", "clientCert": "cert"},
+		} {
+			_, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: props}})
+			assert.Error(t, err, props)
+		}
+	})
+}
+
+func TestSearch(t *testing.T) {
+	conn := &fakeConn{
+		result: &ldap.SearchResult{
+			Entries: []*ldap.Entry{{
+				DN: "cn=jdoe,ou=users,dc=example,dc=com",
+				Attributes: []*ldap.EntryAttribute{
+					{Name: "mail", Values: []string{"jdoe@example.com"}, ByteValues: [][]byte{[]byte("jdoe@example.com")}},
+					{Name: "objectGUID", Values: []string{"\x01\x02"}, ByteValues: [][]byte{{0x01, 0x02}}},
+				},
+			}},
+		},
+	}
+	l := newTestLDAP(t, conn)
+
+	res, err := l.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: SearchOperation,
+		Data:      []byte(`{"filter":"(sAMAccountName=jdoe)","scope":"one","attributes":["mail","objectGUID"],"binaryAttributes":["objectguid"],"sizeLimit":10}`),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, [][2]string{{"cn=dapr,dc=example,dc=com", "secret"}}, conn.binds)
+	assert.Equal(t, "dc=example,dc=com", conn.search.BaseDN)
+	assert.Equal(t, "(sAMAccountName=jdoe)", conn.search.Filter)
+	assert.Equal(t, ldap.ScopeSingleLevel, conn.search.Scope)
+	assert.Equal(t, 10, conn.search.SizeLimit)
+	assert.Equal(t, uint32(100), conn.pageSize)
+	assert.True(t, conn.closed)
+
+	var resp searchResponse
+	require.NoError(t, json.Unmarshal(res.Data, &resp))
+	assert.Equal(t, searchResponse{Entries: []searchEntry{{
+		DN: "cn=jdoe,ou=users,dc=example,dc=com",
+		Attributes: map[string][]string{
+			"mail":       {"jdoe@example.com"},
+			"objectGUID": {"AQI="},
+		},
+	}}}, resp)
+
+	t.Run("size limit exceeded", func(t *testing.T) {
+		conn.err = ldap.NewError(ldap.LDAPResultSizeLimitExceeded, nil)
+		defer func() { conn.err = nil }()
+
+		res, err := l.Invoke(context.Background(), &bindings.InvokeRequest{Operation: SearchOperation})
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(res.Data, &resp))
+		assert.True(t, resp.Truncated)
+		assert.Len(t, resp.Entries, 1)
+		assert.Equal(t, defaultFilter, conn.search.Filter)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for _, data := range []string{
+			`{"filter":"(cn=jdoe"}`,
+			`{"scope":"all"}`,
+			`{"sizeLimit":-1}`,
+			`not json`,
+		} {
+			_, err := l.Invoke(context.Background(), &bindings.InvokeRequest{Operation: SearchOperation, Data: []byte(data)})
+			assert.Error(t, err, data)
+		}
+	})
+}
+
+func TestBind(t *testing.T) {
+	conn := &fakeConn{
+		bindErr: func(username, password string) error {
+			if password != "correct" {
+				return ldap.NewError(ldap.LDAPResultInvalidCredentials, nil)
+			}
+			return nil
+		},
+	}
+	l := newTestLDAP(t, conn)
+
+	invoke := func(data string) (bindResponse, error) {
+		var resp bindResponse
+		res, err := l.Invoke(context.Background(), &bindings.InvokeRequest{Operation: BindOperation, Data: []byte(data)})
+		if err != nil {
+			return resp, err
+		}
+		return resp, json.Unmarshal(res.Data, &resp)
+	}
+
+	resp, err := invoke(`{"dn":"cn=jdoe,dc=example,dc=com","password":"correct"}`)
+	require.NoError(t, err)
+	assert.True(t, resp.Authenticated)
+	// The binding doesn't authenticate with its own credentials first
+	assert.Equal(t, [][2]string{{"cn=jdoe,dc=example,dc=com", "correct"}}, conn.binds)
+
+	resp, err = invoke(`{"dn":"cn=jdoe,dc=example,dc=com","password":"wrong"}`)
+	require.NoError(t, err)
+	assert.False(t, resp.Authenticated)
+
+	_, err = invoke(`{"dn":"cn=jdoe,dc=example,dc=com"}`)
+	assert.ErrorIs(t, err, ErrMissingPassword)
+	_, err = invoke(`{"password":"correct"}`)
+	assert.ErrorIs(t, err, ErrMissingDN)
+
+	conn.bindErr = func(username, password string) error {
+		return ldap.NewError(ldap.LDAPResultUnavailable, nil)
+	}
+	_, err = invoke(`{"dn":"cn=jdoe,dc=example,dc=com","password":"correct"}`)
+	assert.Error(t, err)
+}
+
+func TestAdd(t *testing.T) {
+	conn := &fakeConn{}
+	l := newTestLDAP(t, conn)
+
+	_, err := l.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: AddOperation,
+		Data:      []byte(`{"dn":"cn=jdoe,ou=users,dc=example,dc=com","attributes":{"objectClass":["inetOrgPerson"],"sn":["Doe"]}}`),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, conn.added)
+	assert.Equal(t, "cn=jdoe,ou=users,dc=example,dc=com", conn.added.DN)
+	assert.ElementsMatch(t, []ldap.Attribute{
+		{Type: "objectClass", Vals: []string{"inetOrgPerson"}},
+		{Type: "sn", Vals: []string{"Doe"}},
+	}, conn.added.Attributes)
+
+	_, err = l.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: AddOperation,
+		Data:      []byte(`{"dn":"cn=jdoe,ou=users,dc=example,dc=com"}`),
+	})
+	assert.Error(t, err)
+}
+
+func TestModify(t *testing.T) {
+	conn := &fakeConn{}
+	l := newTestLDAP(t, conn)
+
+	_, err := l.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: ModifyOperation,
+		Data: []byte(`{"dn":"cn=jdoe,dc=example,dc=com","changes":[
+			{"type":"replace","attribute":"mail","values":["john@example.com"]},
+			{"type":"add","attribute":"memberOf","values":["cn=admins,dc=example,dc=com"]},
+			{"type":"delete","attribute":"description"}
+		]}`),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, conn.modified)
+	assert.Equal(t, []ldap.Change{
+		{Operation: ldap.ReplaceAttribute, Modification: ldap.PartialAttribute{Type: "mail", Vals: []string{"john@example.com"}}},
+		{Operation: ldap.AddAttribute, Modification: ldap.PartialAttribute{Type: "memberOf", Vals: []string{"cn=admins,dc=example,dc=com"}}},
+		{Operation: ldap.DeleteAttribute, Modification: ldap.PartialAttribute{Type: "description"}},
+	}, conn.modified.Changes)
+
+	for _, data := range []string{
+		`{"changes":[{"type":"replace","attribute":"mail","values":["a"]}]}`,
+		`{"dn":"cn=jdoe,dc=example,dc=com"}`,
+		`{"dn":"cn=jdoe,dc=example,dc=com","changes":[{"type":"increment","attribute":"uidNumber"}]}`,
+		`{"dn":"cn=jdoe,dc=example,dc=com","changes":[{"type":"add","attribute":"mail"}]}`,
+		`{"dn":"cn=jdoe,dc=example,dc=com","changes":[{"type":"add","values":["a"]}]}`,
+	} {
+		_, err = l.Invoke(context.Background(), &bindings.InvokeRequest{Operation: ModifyOperation, Data: []byte(data)})
+		assert.Error(t, err, data)
+	}
+}
@@ -15,7 +15,9 @@ package localstorage
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -25,17 +27,27 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	securejoin "github.com/cyphar/filepath-securejoin"
 	"github.com/google/uuid"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/utils"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
 const (
-	fileNameMetadataKey = "fileName"
+	fileNameMetadataKey        = "fileName"
+	patternMetadataKey         = "pattern"
+	recursiveMetadataKey       = "recursive"
+	includeMetadataMetadataKey = "includeMetadata"
+
+	// Keys for the metadata returned in responses
+	sizeMetadataKey    = "size"
+	modTimeMetadataKey = "modTime"
+	sha256MetadataKey  = "sha256"
 )
 
 // List of root paths that are disallowed
@@ -53,12 +65,22 @@ type LocalStorage struct {
 // Metadata defines the metadata.
 type Metadata struct {
 	RootPath string `json:"rootPath"`
+	// If true, requests for file names that point outside of the root path (e.g. "../foo") are rejected.
+	// Otherwise, file names are silently constrained to be within the root path.
+	RejectPathTraversal bool `json:"rejectPathTraversal"`
 }
 
 type createResponse struct {
 	FileName string `json:"fileName"`
 }
 
+type listFileInfo struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	SHA256  string    `json:"sha256"`
+}
+
 // NewLocalStorage returns a new LocalStorage instance.
 func NewLocalStorage(logger logger.Logger) bindings.OutputBinding {
 	return &LocalStorage{logger: logger}
@@ -170,7 +192,7 @@ func (ls *LocalStorage) create(filename string, req *bindings.InvokeRequest) (*b
 		req.Data = decoded
 	}
 
-	absPath, relPath, err := getSecureAbsRelPath(ls.metadata.RootPath, filename)
+	absPath, relPath, err := ls.securePath(filename)
 	if err != nil {
 		return nil, fmt.Errorf("error getting absolute path for file %s: %w", filename, err)
 	}
@@ -194,6 +216,11 @@ func (ls *LocalStorage) create(filename string, req *bindings.InvokeRequest) (*b
 
 	ls.logger.Debugf("wrote file: %s. numBytes: %d", absPath, numBytes)
 
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("error getting stats for file %s: %w", absPath, err)
+	}
+
 	resp := createResponse{
 		FileName: relPath,
 	}
@@ -204,12 +231,13 @@ func (ls *LocalStorage) create(filename string, req *bindings.InvokeRequest) (*b
 	}
 
 	return &bindings.InvokeResponse{
-		Data: b,
+		Data:     b,
+		Metadata: fileMetadata(fi, req.Data),
 	}, nil
 }
 
 func (ls *LocalStorage) get(filename string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
-	absPath, _, err := getSecureAbsRelPath(ls.metadata.RootPath, filename)
+	absPath, _, err := ls.securePath(filename)
 	if err != nil {
 		return nil, fmt.Errorf("error getting absolute path for file %s: %w", filename, err)
 	}
@@ -223,6 +251,14 @@ func (ls *LocalStorage) get(filename string, req *bindings.InvokeRequest) (*bind
 	}
 	defer f.Close()
 
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("error getting stats for file %s: %w", absPath, err)
+	}
+	if fi.IsDir() {
+		return nil, fmt.Errorf("unable to read file as the path specified is a directory: %s", absPath)
+	}
+
 	b, err := io.ReadAll(f)
 	if err != nil {
 		return nil, fmt.Errorf("error reading file %s: %w", absPath, err)
@@ -231,17 +267,26 @@ func (ls *LocalStorage) get(filename string, req *bindings.InvokeRequest) (*bind
 	ls.logger.Debugf("read file: %s. size: %d bytes", absPath, len(b))
 
 	return &bindings.InvokeResponse{
-		Data: b,
+		Data:     b,
+		Metadata: fileMetadata(fi, b),
 	}, nil
 }
 
 func (ls *LocalStorage) delete(filename string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
-	absPath, _, err := getSecureAbsRelPath(ls.metadata.RootPath, filename)
+	absPath, _, err := ls.securePath(filename)
 	if err != nil {
 		return nil, fmt.Errorf("error getting absolute path for file %s: %w", filename, err)
 	}
 
-	err = os.Remove(absPath)
+	if utils.IsTruthy(req.Metadata[recursiveMetadataKey]) {
+		// Never remove the root path itself
+		if absPath == ls.metadata.RootPath {
+			return nil, errors.New("cannot delete the root path")
+		}
+		err = os.RemoveAll(absPath)
+	} else {
+		err = os.Remove(absPath)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error deleting file %s: %w", absPath, err)
 	}
@@ -252,7 +297,7 @@ func (ls *LocalStorage) delete(filename string, req *bindings.InvokeRequest) (*b
 }
 
 func (ls *LocalStorage) list(filename string, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
-	absPath, _, err := getSecureAbsRelPath(ls.metadata.RootPath, filename)
+	absPath, _, err := ls.securePath(filename)
 	if err != nil {
 		return nil, fmt.Errorf("error getting absolute path for file %s: %w", filename, err)
 	}
@@ -266,12 +311,38 @@ func (ls *LocalStorage) list(filename string, req *bindings.InvokeRequest) (*bin
 		return nil, fmt.Errorf("unable to list files as the file specified is not a directory: %s", absPath)
 	}
 
-	files, err := walkPath(absPath)
+	pattern := req.Metadata[patternMetadataKey]
+	if pattern != "" {
+		// Validate the pattern
+		_, err = filepath.Match(pattern, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+	}
+
+	recursive := true
+	if val := req.Metadata[recursiveMetadataKey]; val != "" {
+		recursive = utils.IsTruthy(val)
+	}
+
+	files, err := walkPath(absPath, pattern, recursive)
 	if err != nil {
 		return nil, fmt.Errorf("error listing files in the directory %s: %w", absPath, err)
 	}
 
-	b, err := json.Marshal(files)
+	var res any = files
+	if utils.IsTruthy(req.Metadata[includeMetadataMetadataKey]) {
+		infos := make([]listFileInfo, len(files))
+		for i, file := range files {
+			infos[i], err = getListFileInfo(file)
+			if err != nil {
+				return nil, fmt.Errorf("error reading file %s: %w", file, err)
+			}
+		}
+		res = infos
+	}
+
+	b, err := json.Marshal(res)
 	if err != nil {
 		return nil, fmt.Errorf("error encoding response as JSON: %w", err)
 	}
@@ -281,6 +352,20 @@ func (ls *LocalStorage) list(filename string, req *bindings.InvokeRequest) (*bin
 	}, nil
 }
 
+// securePath returns the absolute and relative path for the file, which is always within the root path.
+// If RejectPathTraversal is enabled, file names that would point outside of the root path return an error.
+func (ls *LocalStorage) securePath(filename string) (absPath string, relPath string, err error) {
+	if ls.metadata.RejectPathTraversal {
+		// Absolute paths are considered relative to the root path
+		rel := strings.TrimLeft(filepath.ToSlash(filename), "/")
+		if rel != "" && !filepath.IsLocal(filepath.FromSlash(rel)) {
+			return "", "", fmt.Errorf("file name %s points outside of the root path", filename)
+		}
+	}
+
+	return getSecureAbsRelPath(ls.metadata.RootPath, filename)
+}
+
 func getSecureAbsRelPath(rootPath string, filename string) (absPath string, relPath string, err error) {
 	absPath, err = securejoin.SecureJoin(rootPath, filename)
 	if err != nil {
@@ -294,19 +379,79 @@ func getSecureAbsRelPath(rootPath string, filename string) (absPath string, relP
 	return
 }
 
-func walkPath(root string) ([]string, error) {
+// walkPath returns the list of files in the root directory.
+// If pattern is not empty, only files matching the glob pattern are returned: patterns without a separator are matched against the file name, while patterns with a separator are matched against the path relative to root.
+func walkPath(root string, pattern string, recursive bool) ([]string, error) {
 	var files []string
 	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if !info.IsDir() {
-			files = append(files, path)
+		if err != nil {
+			return err
 		}
 
+		if info.IsDir() {
+			if !recursive && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if pattern != "" {
+			match := filepath.Base(path)
+			if strings.ContainsRune(filepath.ToSlash(pattern), '/') {
+				match, err = filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+			}
+			ok, _ := filepath.Match(filepath.FromSlash(pattern), match)
+			if !ok {
+				return nil
+			}
+		}
+
+		files = append(files, path)
 		return nil
 	})
 
 	return files, err
 }
 
+// fileMetadata returns the metadata for a file that is included in the response.
+func fileMetadata(fi os.FileInfo, data []byte) map[string]string {
+	sum := sha256.Sum256(data)
+	return map[string]string{
+		sizeMetadataKey:    strconv.FormatInt(fi.Size(), 10),
+		modTimeMetadataKey: fi.ModTime().UTC().Format(time.RFC3339Nano),
+		sha256MetadataKey:  hex.EncodeToString(sum[:]),
+	}
+}
+
+func getListFileInfo(path string) (listFileInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return listFileInfo{}, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return listFileInfo{}, err
+	}
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return listFileInfo{}, err
+	}
+
+	return listFileInfo{
+		Name:    path,
+		Size:    fi.Size(),
+		ModTime: fi.ModTime().UTC(),
+		SHA256:  hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
 // Invoke is called for output bindings.
 func (ls *LocalStorage) Invoke(_ context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
 	filename := req.Metadata[fileNameMetadataKey]
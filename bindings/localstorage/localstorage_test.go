@@ -14,6 +14,10 @@ limitations under the License.
 package localstorage
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -23,6 +27,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/bindings"
+	mdata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/kit/logger"
 )
 
@@ -114,6 +119,112 @@ func TestValidateRootPath(t *testing.T) {
 	}
 }
 
+func TestOperations(t *testing.T) {
+	rootPath := t.TempDir()
+	ls := NewLocalStorage(logger.NewLogger("test")).(*LocalStorage)
+	err := ls.Init(context.Background(), bindings.Metadata{Base: mdata.Base{
+		Properties: map[string]string{"rootPath": rootPath},
+	}})
+	require.NoError(t, err)
+	rootPath = ls.metadata.RootPath
+
+	invoke := func(t *testing.T, op bindings.OperationKind, data string, md map[string]string) *bindings.InvokeResponse {
+		t.Helper()
+		res, err := ls.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: op,
+			Data:      []byte(data),
+			Metadata:  md,
+		})
+		require.NoError(t, err)
+		return res
+	}
+
+	for _, name := range []string{"a.txt", "b.json", "dir/c.txt", "dir/sub/d.txt"} {
+		invoke(t, bindings.CreateOperation, "hello "+name, map[string]string{fileNameMetadataKey: name})
+	}
+
+	t.Run("get returns file metadata", func(t *testing.T) {
+		res := invoke(t, bindings.GetOperation, "", map[string]string{fileNameMetadataKey: "a.txt"})
+		assert.Equal(t, "hello a.txt", string(res.Data))
+		sum := sha256.Sum256([]byte("hello a.txt"))
+		assert.Equal(t, hex.EncodeToString(sum[:]), res.Metadata[sha256MetadataKey])
+		assert.Equal(t, "11", res.Metadata[sizeMetadataKey])
+		assert.NotEmpty(t, res.Metadata[modTimeMetadataKey])
+	})
+
+	list := func(t *testing.T, md map[string]string) []string {
+		t.Helper()
+		res := invoke(t, bindings.ListOperation, "", md)
+		var files []string
+		require.NoError(t, json.Unmarshal(res.Data, &files))
+		for i := range files {
+			files[i], err = filepath.Rel(rootPath, files[i])
+			require.NoError(t, err)
+			files[i] = filepath.ToSlash(files[i])
+		}
+		return files
+	}
+
+	t.Run("list", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"a.txt", "b.json", "dir/c.txt", "dir/sub/d.txt"}, list(t, nil))
+		assert.ElementsMatch(t, []string{"a.txt", "dir/c.txt", "dir/sub/d.txt"}, list(t, map[string]string{patternMetadataKey: "*.txt"}))
+		assert.ElementsMatch(t, []string{"dir/c.txt"}, list(t, map[string]string{patternMetadataKey: "dir/*.txt"}))
+		assert.ElementsMatch(t, []string{"a.txt", "b.json"}, list(t, map[string]string{recursiveMetadataKey: "false"}))
+	})
+
+	t.Run("list with metadata", func(t *testing.T) {
+		res := invoke(t, bindings.ListOperation, "", map[string]string{
+			fileNameMetadataKey:        "dir",
+			recursiveMetadataKey:       "false",
+			includeMetadataMetadataKey: "true",
+		})
+		var files []listFileInfo
+		require.NoError(t, json.Unmarshal(res.Data, &files))
+		require.Len(t, files, 1)
+		sum := sha256.Sum256([]byte("hello dir/c.txt"))
+		assert.Equal(t, filepath.Join(rootPath, "dir", "c.txt"), files[0].Name)
+		assert.Equal(t, int64(15), files[0].Size)
+		assert.Equal(t, hex.EncodeToString(sum[:]), files[0].SHA256)
+	})
+
+	t.Run("delete recursively", func(t *testing.T) {
+		_, err := ls.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: bindings.DeleteOperation,
+			Metadata:  map[string]string{fileNameMetadataKey: "dir"},
+		})
+		require.Error(t, err)
+
+		invoke(t, bindings.DeleteOperation, "", map[string]string{fileNameMetadataKey: "dir", recursiveMetadataKey: "true"})
+		assert.ElementsMatch(t, []string{"a.txt", "b.json"}, list(t, nil))
+
+		_, err = ls.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: bindings.DeleteOperation,
+			Metadata:  map[string]string{fileNameMetadataKey: "/", recursiveMetadataKey: "true"},
+		})
+		require.ErrorContains(t, err, "cannot delete the root path")
+	})
+
+	t.Run("path traversal", func(t *testing.T) {
+		// By default, paths are constrained to the root path
+		res := invoke(t, bindings.GetOperation, "", map[string]string{fileNameMetadataKey: "../../a.txt"})
+		assert.Equal(t, "hello a.txt", string(res.Data))
+
+		ls.metadata.RejectPathTraversal = true
+		defer func() {
+			ls.metadata.RejectPathTraversal = false
+		}()
+
+		_, err := ls.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: bindings.GetOperation,
+			Metadata:  map[string]string{fileNameMetadataKey: "../../a.txt"},
+		})
+		require.ErrorContains(t, err, "points outside of the root path")
+
+		res = invoke(t, bindings.GetOperation, "", map[string]string{fileNameMetadataKey: "/a.txt"})
+		assert.Equal(t, "hello a.txt", string(res.Data))
+	})
+}
+
 func joinWithMustEvalSymlinks(v ...string) string {
 	r, err := filepath.EvalSymlinks(filepath.Join(v...))
 	if err != nil {
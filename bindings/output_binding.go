@@ -28,6 +28,11 @@ type OutputBinding interface {
 	GetComponentMetadata() map[string]string
 }
 
+// StreamingOutputBinding is implemented by output bindings that can send and receive payloads as streams, without buffering them in memory.
+type StreamingOutputBinding interface {
+	InvokeStream(ctx context.Context, req *InvokeStreamRequest) (*InvokeStreamResponse, error)
+}
+
 func PingOutBinding(ctx context.Context, outputBinding OutputBinding) error {
 	// checks if this output binding has the ping option then executes
 	if outputBindingWithPing, ok := outputBinding.(health.Pinger); ok {
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package streams
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+const (
+	defaultProcessingTimeout = 60 * time.Second
+	defaultRedeliverInterval = 15 * time.Second
+	defaultQueueDepth        = 100
+	defaultConcurrency       = 10
+)
+
+type streamsMetadata struct {
+	// Name of the stream.
+	Stream string `mapstructure:"stream"`
+	// Name of the consumer group used to read from the stream; it's created if it doesn't exist.
+	ConsumerGroup string `mapstructure:"consumerGroup"`
+	// Name of the consumer in the group; defaults to the name of the consumer group.
+	ConsumerName string `mapstructure:"consumerName"`
+	// Time after which a message that wasn't acknowledged is redelivered.
+	ProcessingTimeout time.Duration `mapstructure:"processingTimeout"`
+	// Interval for checking for pending messages to redeliver; 0 disables redelivery.
+	RedeliverInterval time.Duration `mapstructure:"redeliverInterval"`
+	// Maximum number of messages read at once and queued for processing.
+	QueueDepth uint `mapstructure:"queueDepth"`
+	// Number of messages processed concurrently.
+	Concurrency uint `mapstructure:"concurrency"`
+	// Approximate maximum length of the stream when adding messages; 0 means unlimited.
+	MaxLenApprox int64 `mapstructure:"maxLenApprox"`
+}
+
+func parseMetadata(properties map[string]string) (streamsMetadata, error) {
+	m := streamsMetadata{
+		ProcessingTimeout: defaultProcessingTimeout,
+		RedeliverInterval: defaultRedeliverInterval,
+		QueueDepth:        defaultQueueDepth,
+		Concurrency:       defaultConcurrency,
+	}
+	err := metadata.DecodeMetadata(properties, &m)
+	if err != nil {
+		return m, err
+	}
+
+	if m.Stream == "" {
+		return m, errors.New("metadata property 'stream' is required")
+	}
+	if m.ConsumerGroup == "" {
+		return m, errors.New("metadata property 'consumerGroup' is required")
+	}
+	if m.ConsumerName == "" {
+		m.ConsumerName = m.ConsumerGroup
+	}
+	if m.QueueDepth == 0 {
+		m.QueueDepth = defaultQueueDepth
+	}
+	if m.Concurrency == 0 {
+		m.Concurrency = defaultConcurrency
+	}
+	if m.ProcessingTimeout <= 0 {
+		return m, errors.New("metadata property 'processingTimeout' must be greater than 0")
+	}
+
+	return m, nil
+}
@@ -0,0 +1,357 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package streams
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/utils"
+)
+
+const (
+	// PendingOperation returns the pending messages of the consumer group.
+	PendingOperation bindings.OperationKind = "pending"
+	// ClaimOperation transfers the ownership of pending messages to a consumer.
+	ClaimOperation bindings.OperationKind = "claim"
+	// TrimOperation removes the oldest messages from the stream.
+	TrimOperation bindings.OperationKind = "trim"
+
+	// Field of the stream entries containing the message
+	dataField = "data"
+
+	// Metadata keys
+	idKey          = "id"
+	streamKey      = "stream"
+	countKey       = "count"
+	consumerKey    = "consumer"
+	startKey       = "start"
+	endKey         = "end"
+	idsKey         = "ids"
+	minIdleTimeKey = "minIdleTime"
+	maxLenKey      = "maxLen"
+	minIDKey       = "minID"
+	approximateKey = "approximate"
+
+	defaultPendingCount = 100
+)
+
+type pendingResponse struct {
+	// Total number of pending messages of the consumer group
+	Count     int64  `json:"count"`
+	LowestID  string `json:"lowestId,omitempty"`
+	HighestID string `json:"highestId,omitempty"`
+	// Number of pending messages of each consumer
+	Consumers map[string]int64 `json:"consumers"`
+	// Pending messages in the requested range
+	Messages []pendingMessage `json:"messages"`
+}
+
+type pendingMessage struct {
+	ID            string `json:"id"`
+	Consumer      string `json:"consumer"`
+	IdleMs        int64  `json:"idleMs"`
+	DeliveryCount int64  `json:"deliveryCount"`
+}
+
+type claimedMessage struct {
+	ID   string `json:"id"`
+	Data []byte `json:"data"`
+}
+
+type trimResponse struct {
+	Deleted int64 `json:"deleted"`
+}
+
+func (r *RedisStreams) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{
+		bindings.CreateOperation,
+		PendingOperation,
+		ClaimOperation,
+		TrimOperation,
+	}
+}
+
+func (r *RedisStreams) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	switch req.Operation {
+	case bindings.CreateOperation:
+		return r.add(ctx, req)
+	case PendingOperation:
+		return r.pending(ctx, req)
+	case ClaimOperation:
+		return r.claim(ctx, req)
+	case TrimOperation:
+		return r.trim(ctx, req)
+	default:
+		return nil, fmt.Errorf("invalid operation type: %s", req.Operation)
+	}
+}
+
+// add adds a message to the stream, returning its ID in the "id" metadata.
+func (r *RedisStreams) add(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	id, err := r.client.XAdd(ctx, r.metadata.Stream, r.metadata.MaxLenApprox, map[string]interface{}{dataField: req.Data})
+	if err != nil {
+		return nil, fmt.Errorf("redis streams binding: error adding message: %w", err)
+	}
+	return &bindings.InvokeResponse{
+		Metadata: map[string]string{idKey: id},
+	}, nil
+}
+
+// pending returns the summary of the pending messages of the consumer group, and the pending messages in the range between the "start" and "end" IDs.
+// The messages can be filtered by "consumer" and "minIdleTime".
+func (r *RedisStreams) pending(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	count := int64(defaultPendingCount)
+	if val := req.Metadata[countKey]; val != "" {
+		n, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("metadata property %s must be a positive integer", countKey)
+		}
+		count = n
+	}
+	minIdleTime, err := durationFromMetadata(req.Metadata, minIdleTimeKey)
+	if err != nil {
+		return nil, err
+	}
+	start := valueOrDefault(req.Metadata[startKey], "-")
+	end := valueOrDefault(req.Metadata[endKey], "+")
+
+	summary, err := r.client.DoRead(ctx, "XPENDING", r.metadata.Stream, r.metadata.ConsumerGroup)
+	if err != nil {
+		return nil, fmt.Errorf("redis streams binding: error retrieving pending messages: %w", err)
+	}
+	res, err := parsePendingSummary(summary)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []interface{}{"XPENDING", r.metadata.Stream, r.metadata.ConsumerGroup, start, end, count}
+	if consumer := req.Metadata[consumerKey]; consumer != "" {
+		args = append(args, consumer)
+	}
+	entries, err := r.client.DoRead(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("redis streams binding: error retrieving pending messages: %w", err)
+	}
+	res.Messages, err = parsePendingMessages(entries, minIdleTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonResponse(res)
+}
+
+// claim transfers the ownership of the pending messages in "ids" that have been idle for at least "minIdleTime" to "consumer", returning them.
+// If "consumer" isn't set, the messages are claimed by the binding's consumer, which redelivers them if they're not acknowledged.
+func (r *RedisStreams) claim(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	var ids []string
+	for _, id := range strings.Split(req.Metadata[idsKey], ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("metadata property %s is required", idsKey)
+	}
+	minIdleTime, err := durationFromMetadata(req.Metadata, minIdleTimeKey)
+	if err != nil {
+		return nil, err
+	}
+	consumer := valueOrDefault(req.Metadata[consumerKey], r.metadata.ConsumerName)
+
+	claimed, err := r.client.XClaimResult(ctx, r.metadata.Stream, r.metadata.ConsumerGroup, consumer, minIdleTime, ids)
+	if err != nil && !errors.Is(err, r.client.GetNilValueError()) {
+		return nil, fmt.Errorf("redis streams binding: error claiming messages: %w", err)
+	}
+
+	res := make([]claimedMessage, len(claimed))
+	for i, msg := range claimed {
+		res[i] = claimedMessage{
+			ID:   msg.ID,
+			Data: messageData(msg),
+		}
+	}
+	return jsonResponse(res)
+}
+
+// trim removes the oldest messages from the stream, keeping "maxLen" messages or those with an ID greater than or equal to "minID".
+func (r *RedisStreams) trim(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	maxLen := req.Metadata[maxLenKey]
+	minID := req.Metadata[minIDKey]
+
+	args := []interface{}{"XTRIM", r.metadata.Stream}
+	switch {
+	case maxLen != "" && minID != "":
+		return nil, fmt.Errorf("metadata properties %s and %s cannot both be set", maxLenKey, minIDKey)
+	case maxLen != "":
+		n, err := strconv.ParseInt(maxLen, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("metadata property %s must be a non-negative integer", maxLenKey)
+		}
+		args = append(args, "MAXLEN")
+	case minID != "":
+		args = append(args, "MINID")
+	default:
+		return nil, fmt.Errorf("one of the metadata properties %s or %s is required", maxLenKey, minIDKey)
+	}
+	if utils.IsTruthy(req.Metadata[approximateKey]) {
+		args = append(args, "~")
+	}
+	args = append(args, valueOrDefault(maxLen, minID))
+
+	deleted, err := r.client.DoRead(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("redis streams binding: error trimming stream: %w", err)
+	}
+	n, err := toInt64(deleted)
+	if err != nil {
+		return nil, err
+	}
+	return jsonResponse(trimResponse{Deleted: n})
+}
+
+// parsePendingSummary parses the response of XPENDING without a range.
+func parsePendingSummary(reply interface{}) (pendingResponse, error) {
+	res := pendingResponse{
+		Consumers: map[string]int64{},
+		Messages:  []pendingMessage{},
+	}
+
+	fields, ok := reply.([]interface{})
+	if !ok || len(fields) < 4 {
+		return res, fmt.Errorf("redis streams binding: unexpected XPENDING reply %v", reply)
+	}
+
+	var err error
+	res.Count, err = toInt64(fields[0])
+	if err != nil {
+		return res, err
+	}
+	res.LowestID, _ = toString(fields[1])
+	res.HighestID, _ = toString(fields[2])
+
+	consumers, _ := fields[3].([]interface{})
+	for _, c := range consumers {
+		pair, ok := c.([]interface{})
+		if !ok || len(pair) < 2 {
+			return res, fmt.Errorf("redis streams binding: unexpected XPENDING consumer %v", c)
+		}
+		name, _ := toString(pair[0])
+		res.Consumers[name], err = toInt64(pair[1])
+		if err != nil {
+			return res, err
+		}
+	}
+
+	return res, nil
+}
+
+// parsePendingMessages parses the response of XPENDING with a range, skipping messages that have been idle for less than minIdleTime.
+func parsePendingMessages(reply interface{}, minIdleTime time.Duration) ([]pendingMessage, error) {
+	entries, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("redis streams binding: unexpected XPENDING reply %v", reply)
+	}
+
+	res := make([]pendingMessage, 0, len(entries))
+	for _, e := range entries {
+		fields, ok := e.([]interface{})
+		if !ok || len(fields) < 4 {
+			return nil, fmt.Errorf("redis streams binding: unexpected XPENDING entry %v", e)
+		}
+
+		var (
+			msg pendingMessage
+			err error
+		)
+		msg.ID, _ = toString(fields[0])
+		msg.Consumer, _ = toString(fields[1])
+		msg.IdleMs, err = toInt64(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		msg.DeliveryCount, err = toInt64(fields[3])
+		if err != nil {
+			return nil, err
+		}
+
+		if time.Duration(msg.IdleMs)*time.Millisecond < minIdleTime {
+			continue
+		}
+		res = append(res, msg)
+	}
+	return res, nil
+}
+
+func toString(val interface{}) (string, bool) {
+	switch v := val.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	default:
+		return "", false
+	}
+}
+
+func toInt64(val interface{}) (int64, error) {
+	switch v := val.(type) {
+	case int64:
+		return v, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	default:
+		return 0, fmt.Errorf("redis streams binding: unexpected integer value %v", val)
+	}
+}
+
+func durationFromMetadata(md map[string]string, key string) (time.Duration, error) {
+	val := md[key]
+	if val == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("metadata property %s must be a non-negative duration", key)
+	}
+	return d, nil
+}
+
+func valueOrDefault(val string, defaultValue string) string {
+	if val == "" {
+		return defaultValue
+	}
+	return val
+}
+
+func jsonResponse(v any) (*bindings.InvokeResponse, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &bindings.InvokeResponse{
+		Data: data,
+		Metadata: map[string]string{
+			"contentType": "application/json",
+		},
+	}, nil
+}
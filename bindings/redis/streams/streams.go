@@ -0,0 +1,270 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package streams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dapr/components-contrib/bindings"
+	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// RedisStreams is an input/output binding for a Redis stream that is used as a work queue.
+// The input binding reads from the stream with a consumer group, redelivering messages that aren't acknowledged within the processing timeout.
+// The output binding adds messages to the stream and exposes operations for managing the consumer group.
+type RedisStreams struct {
+	client         rediscomponent.RedisClient
+	clientSettings *rediscomponent.Settings
+	metadata       streamsMetadata
+	logger         logger.Logger
+	wg             sync.WaitGroup
+	closed         atomic.Bool
+	closeCh        chan struct{}
+}
+
+// NewRedisStreams returns a new Redis Streams binding instance.
+func NewRedisStreams(logger logger.Logger) bindings.InputOutputBinding {
+	return &RedisStreams{
+		logger:  logger,
+		closeCh: make(chan struct{}),
+	}
+}
+
+// Init performs metadata parsing and connection creation.
+func (r *RedisStreams) Init(ctx context.Context, meta bindings.Metadata) (err error) {
+	r.metadata, err = parseMetadata(meta.Properties)
+	if err != nil {
+		return err
+	}
+
+	r.client, r.clientSettings, err = rediscomponent.ParseClientFromProperties(meta.Properties, contribMetadata.BindingType)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.PingResult(ctx)
+	if err != nil {
+		return fmt.Errorf("redis streams binding: error connecting to redis at %s: %s", r.clientSettings.Host, err)
+	}
+
+	return nil
+}
+
+// Read consumes messages from the stream, acknowledging them when the handler succeeds.
+func (r *RedisStreams) Read(ctx context.Context, handler bindings.Handler) error {
+	if r.closed.Load() {
+		return errors.New("binding is closed")
+	}
+
+	err := r.ensureGroup(ctx)
+	if err != nil {
+		return err
+	}
+
+	readCtx, cancel := context.WithCancel(ctx)
+	r.wg.Add(1)
+	go func() {
+		// Stop reading when Close is called, even if the context is not canceled.
+		defer r.wg.Done()
+		defer cancel()
+		select {
+		case <-readCtx.Done():
+		case <-r.closeCh:
+		}
+	}()
+
+	queue := make(chan rediscomponent.RedisXMessage, r.metadata.QueueDepth)
+	r.wg.Add(int(r.metadata.Concurrency) + 2)
+	for i := uint(0); i < r.metadata.Concurrency; i++ {
+		go func() {
+			defer r.wg.Done()
+			for {
+				select {
+				case <-readCtx.Done():
+					return
+				case msg := <-queue:
+					r.processMessage(readCtx, handler, msg)
+				}
+			}
+		}()
+	}
+	go func() {
+		defer r.wg.Done()
+		r.pollNewMessagesLoop(readCtx, queue)
+	}()
+	go func() {
+		defer r.wg.Done()
+		r.reclaimPendingMessagesLoop(readCtx, queue)
+	}()
+
+	return nil
+}
+
+// ensureGroup creates the consumer group, and the stream, if they don't exist.
+func (r *RedisStreams) ensureGroup(ctx context.Context) error {
+	err := r.client.XGroupCreateMkStream(ctx, r.metadata.Stream, r.metadata.ConsumerGroup, "0")
+	// Ignore BUSYGROUP errors
+	if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
+		return fmt.Errorf("redis streams binding: error creating consumer group %s: %w", r.metadata.ConsumerGroup, err)
+	}
+	return nil
+}
+
+// processMessage invokes the handler and acknowledges the message if it succeeds.
+// Otherwise, the message remains in the pending list and is redelivered by reclaimPendingMessagesLoop.
+func (r *RedisStreams) processMessage(ctx context.Context, handler bindings.Handler, msg rediscomponent.RedisXMessage) {
+	r.logger.Debugf("Processing Redis message %s", msg.ID)
+
+	handlerCtx, cancel := context.WithTimeout(ctx, r.metadata.ProcessingTimeout)
+	_, err := handler(handlerCtx, &bindings.ReadResponse{
+		Data: messageData(msg),
+		Metadata: map[string]string{
+			idKey:     msg.ID,
+			streamKey: r.metadata.Stream,
+		},
+	})
+	cancel()
+	if err != nil {
+		r.logger.Errorf("Error processing Redis message %s: %v", msg.ID, err)
+		return
+	}
+
+	// Use the background context in case the read context is already canceled.
+	err = r.client.XAck(context.Background(), r.metadata.Stream, r.metadata.ConsumerGroup, msg.ID)
+	if err != nil {
+		r.logger.Errorf("Error acknowledging Redis message %s: %v", msg.ID, err)
+	}
+}
+
+// pollNewMessagesLoop reads new messages for the consumer and sends them to the queue.
+func (r *RedisStreams) pollNewMessagesLoop(ctx context.Context, queue chan<- rediscomponent.RedisXMessage) {
+	for ctx.Err() == nil {
+		streams, err := r.client.XReadGroupResult(ctx, r.metadata.ConsumerGroup, r.metadata.ConsumerName, []string{r.metadata.Stream, ">"}, int64(r.metadata.QueueDepth), time.Duration(r.clientSettings.ReadTimeout))
+		if err != nil {
+			if !errors.Is(err, r.client.GetNilValueError()) && !errors.Is(err, context.Canceled) {
+				r.logger.Errorf("redis streams binding: error reading from stream %s: %s", r.metadata.Stream, err)
+			}
+			continue
+		}
+
+		for _, s := range streams {
+			enqueueMessages(ctx, queue, s.Messages)
+		}
+	}
+}
+
+// reclaimPendingMessagesLoop periodically claims the pending messages whose processing timed out, and sends them to the queue.
+func (r *RedisStreams) reclaimPendingMessagesLoop(ctx context.Context, queue chan<- rediscomponent.RedisXMessage) {
+	if r.metadata.RedeliverInterval <= 0 {
+		return
+	}
+
+	t := time.NewTicker(r.metadata.RedeliverInterval)
+	defer t.Stop()
+
+	for {
+		r.reclaimPendingMessages(ctx, queue)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (r *RedisStreams) reclaimPendingMessages(ctx context.Context, queue chan<- rediscomponent.RedisXMessage) {
+	pending, err := r.client.XPendingExtResult(ctx, r.metadata.Stream, r.metadata.ConsumerGroup, "-", "+", int64(r.metadata.QueueDepth))
+	if err != nil {
+		if !errors.Is(err, r.client.GetNilValueError()) && !errors.Is(err, context.Canceled) {
+			r.logger.Errorf("redis streams binding: error retrieving pending messages: %v", err)
+		}
+		return
+	}
+
+	ids := make([]string, 0, len(pending))
+	for _, msg := range pending {
+		if msg.Idle >= r.metadata.ProcessingTimeout {
+			ids = append(ids, msg.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	claimed, err := r.client.XClaimResult(ctx, r.metadata.Stream, r.metadata.ConsumerGroup, r.metadata.ConsumerName, r.metadata.ProcessingTimeout, ids)
+	if err != nil && !errors.Is(err, r.client.GetNilValueError()) {
+		r.logger.Errorf("redis streams binding: error claiming pending messages: %v", err)
+		return
+	}
+	enqueueMessages(ctx, queue, claimed)
+}
+
+func enqueueMessages(ctx context.Context, queue chan<- rediscomponent.RedisXMessage, msgs []rediscomponent.RedisXMessage) {
+	for _, msg := range msgs {
+		select {
+		case queue <- msg:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// messageData returns the "data" field of a message.
+func messageData(msg rediscomponent.RedisXMessage) []byte {
+	switch v := msg.Values[dataField].(type) {
+	case string:
+		return []byte(v)
+	case []byte:
+		return v
+	default:
+		return nil
+	}
+}
+
+func (r *RedisStreams) Ping(ctx context.Context) error {
+	if _, err := r.client.PingResult(ctx); err != nil {
+		return fmt.Errorf("redis streams binding: error connecting to redis at %s: %s", r.clientSettings.Host, err)
+	}
+
+	return nil
+}
+
+func (r *RedisStreams) Close() error {
+	defer r.wg.Wait()
+	if r.closed.CompareAndSwap(false, true) {
+		close(r.closeCh)
+	}
+
+	if r.client == nil {
+		return nil
+	}
+	return r.client.Close()
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (r *RedisStreams) GetComponentMetadata() map[string]string {
+	metadataInfo := map[string]string{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(rediscomponent.Settings{}), &metadataInfo, contribMetadata.BindingType)
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(streamsMetadata{}), &metadataInfo, contribMetadata.BindingType)
+	return metadataInfo
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package streams
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
+	"github.com/dapr/kit/logger"
+)
+
+func setupStreams(t *testing.T, md streamsMetadata) (*miniredis.Miniredis, *RedisStreams) {
+	t.Helper()
+
+	s := miniredis.RunT(t)
+	client := rediscomponent.ClientFromV8Client(redis.NewClient(&redis.Options{Addr: s.Addr()}))
+
+	r := NewRedisStreams(logger.NewLogger("test")).(*RedisStreams)
+	r.client = client
+	r.clientSettings = &rediscomponent.Settings{Host: s.Addr()}
+	r.metadata = md
+	t.Cleanup(func() {
+		r.Close()
+	})
+	return s, r
+}
+
+func testMetadata() streamsMetadata {
+	md, _ := parseMetadata(map[string]string{
+		"stream":        "mystream",
+		"consumerGroup": "mygroup",
+	})
+	return md
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		md, err := parseMetadata(map[string]string{
+			"stream":        "mystream",
+			"consumerGroup": "mygroup",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "mygroup", md.ConsumerName)
+		assert.Equal(t, defaultProcessingTimeout, md.ProcessingTimeout)
+		assert.Equal(t, defaultRedeliverInterval, md.RedeliverInterval)
+		assert.Equal(t, uint(defaultQueueDepth), md.QueueDepth)
+		assert.Equal(t, uint(defaultConcurrency), md.Concurrency)
+	})
+
+	t.Run("all properties", func(t *testing.T) {
+		md, err := parseMetadata(map[string]string{
+			"stream":            "mystream",
+			"consumerGroup":     "mygroup",
+			"consumerName":      "myconsumer",
+			"processingTimeout": "30s",
+			"redeliverInterval": "5",
+			"queueDepth":        "10",
+			"concurrency":       "2",
+			"maxLenApprox":      "1000",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "myconsumer", md.ConsumerName)
+		assert.Equal(t, 30*time.Second, md.ProcessingTimeout)
+		assert.Equal(t, 5*time.Second, md.RedeliverInterval)
+		assert.Equal(t, uint(10), md.QueueDepth)
+		assert.Equal(t, uint(2), md.Concurrency)
+		assert.Equal(t, int64(1000), md.MaxLenApprox)
+	})
+
+	t.Run("missing stream", func(t *testing.T) {
+		_, err := parseMetadata(map[string]string{"consumerGroup": "mygroup"})
+		assert.Error(t, err)
+	})
+
+	t.Run("missing consumer group", func(t *testing.T) {
+		_, err := parseMetadata(map[string]string{"stream": "mystream"})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid processing timeout", func(t *testing.T) {
+		_, err := parseMetadata(map[string]string{
+			"stream":            "mystream",
+			"consumerGroup":     "mygroup",
+			"processingTimeout": "0",
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestRead(t *testing.T) {
+	_, r := setupStreams(t, testMetadata())
+
+	received := make(chan *bindings.ReadResponse, 2)
+	var failed atomic.Bool
+	err := r.Read(context.Background(), func(ctx context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		// Fail the first delivery of the second message
+		if string(res.Data) == "fail" && failed.CompareAndSwap(false, true) {
+			return nil, errors.New("handler error")
+		}
+		received <- res
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	_, err = r.Invoke(context.Background(), &bindings.InvokeRequest{Operation: bindings.CreateOperation, Data: []byte("hello")})
+	require.NoError(t, err)
+	res, err := r.Invoke(context.Background(), &bindings.InvokeRequest{Operation: bindings.CreateOperation, Data: []byte("fail")})
+	require.NoError(t, err)
+	failedID := res.Metadata[idKey]
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, []byte("hello"), msg.Data)
+		assert.Equal(t, "mystream", msg.Metadata[streamKey])
+		assert.NotEmpty(t, msg.Metadata[idKey])
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not received")
+	}
+
+	// The failed message remains pending
+	assert.Eventually(t, func() bool {
+		res, err := r.Invoke(context.Background(), &bindings.InvokeRequest{Operation: PendingOperation})
+		if err != nil {
+			return false
+		}
+		var pending pendingResponse
+		require.NoError(t, json.Unmarshal(res.Data, &pending))
+		return pending.Count == 1 && len(pending.Messages) == 1 && pending.Messages[0].ID == failedID
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestPending(t *testing.T) {
+	_, r := setupStreams(t, testMetadata())
+	ctx := context.Background()
+
+	require.NoError(t, r.ensureGroup(ctx))
+	for _, data := range []string{"a", "b", "c"} {
+		_, err := r.Invoke(ctx, &bindings.InvokeRequest{Operation: bindings.CreateOperation, Data: []byte(data)})
+		require.NoError(t, err)
+	}
+	msgs, err := r.client.XReadGroupResult(ctx, "mygroup", "consumer1", []string{"mystream", ">"}, 2, 0)
+	require.NoError(t, err)
+	require.Len(t, msgs[0].Messages, 2)
+	_, err = r.client.XReadGroupResult(ctx, "mygroup", "consumer2", []string{"mystream", ">"}, 1, 0)
+	require.NoError(t, err)
+
+	t.Run("all messages", func(t *testing.T) {
+		res, err := r.Invoke(ctx, &bindings.InvokeRequest{Operation: PendingOperation})
+		require.NoError(t, err)
+
+		var pending pendingResponse
+		require.NoError(t, json.Unmarshal(res.Data, &pending))
+		assert.Equal(t, int64(3), pending.Count)
+		assert.Equal(t, msgs[0].Messages[0].ID, pending.LowestID)
+		assert.Equal(t, map[string]int64{"consumer1": 2, "consumer2": 1}, pending.Consumers)
+		require.Len(t, pending.Messages, 3)
+		assert.Equal(t, "consumer1", pending.Messages[0].Consumer)
+		assert.Equal(t, int64(1), pending.Messages[0].DeliveryCount)
+	})
+
+	t.Run("filter by consumer and count", func(t *testing.T) {
+		res, err := r.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: PendingOperation,
+			Metadata:  map[string]string{consumerKey: "consumer1", countKey: "1"},
+		})
+		require.NoError(t, err)
+
+		var pending pendingResponse
+		require.NoError(t, json.Unmarshal(res.Data, &pending))
+		require.Len(t, pending.Messages, 1)
+		assert.Equal(t, msgs[0].Messages[0].ID, pending.Messages[0].ID)
+	})
+
+	t.Run("invalid count", func(t *testing.T) {
+		_, err := r.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: PendingOperation,
+			Metadata:  map[string]string{countKey: "-1"},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestClaim(t *testing.T) {
+	_, r := setupStreams(t, testMetadata())
+	ctx := context.Background()
+
+	require.NoError(t, r.ensureGroup(ctx))
+	_, err := r.Invoke(ctx, &bindings.InvokeRequest{Operation: bindings.CreateOperation, Data: []byte("hello")})
+	require.NoError(t, err)
+	msgs, err := r.client.XReadGroupResult(ctx, "mygroup", "consumer1", []string{"mystream", ">"}, 1, 0)
+	require.NoError(t, err)
+	id := msgs[0].Messages[0].ID
+
+	res, err := r.Invoke(ctx, &bindings.InvokeRequest{
+		Operation: ClaimOperation,
+		Metadata:  map[string]string{idsKey: id, consumerKey: "consumer2"},
+	})
+	require.NoError(t, err)
+
+	var claimed []claimedMessage
+	require.NoError(t, json.Unmarshal(res.Data, &claimed))
+	require.Len(t, claimed, 1)
+	assert.Equal(t, id, claimed[0].ID)
+	assert.Equal(t, []byte("hello"), claimed[0].Data)
+
+	res, err = r.Invoke(ctx, &bindings.InvokeRequest{Operation: PendingOperation})
+	require.NoError(t, err)
+	var pending pendingResponse
+	require.NoError(t, json.Unmarshal(res.Data, &pending))
+	assert.Equal(t, map[string]int64{"consumer2": 1}, pending.Consumers)
+
+	t.Run("missing ids", func(t *testing.T) {
+		_, err := r.Invoke(ctx, &bindings.InvokeRequest{Operation: ClaimOperation})
+		assert.Error(t, err)
+	})
+}
+
+func TestTrim(t *testing.T) {
+	_, r := setupStreams(t, testMetadata())
+	ctx := context.Background()
+
+	ids := make([]string, 5)
+	for i := range ids {
+		res, err := r.Invoke(ctx, &bindings.InvokeRequest{Operation: bindings.CreateOperation, Data: []byte("msg")})
+		require.NoError(t, err)
+		ids[i] = res.Metadata[idKey]
+	}
+
+	t.Run("max length", func(t *testing.T) {
+		res, err := r.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: TrimOperation,
+			Metadata:  map[string]string{maxLenKey: "3"},
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"deleted":2}`, string(res.Data))
+	})
+
+	t.Run("min ID", func(t *testing.T) {
+		res, err := r.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: TrimOperation,
+			Metadata:  map[string]string{minIDKey: ids[4]},
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"deleted":2}`, string(res.Data))
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := r.Invoke(ctx, &bindings.InvokeRequest{Operation: TrimOperation})
+		assert.Error(t, err)
+		_, err = r.Invoke(ctx, &bindings.InvokeRequest{
+			Operation: TrimOperation,
+			Metadata:  map[string]string{maxLenKey: "3", minIDKey: ids[4]},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestInvokeInvalidOperation(t *testing.T) {
+	_, r := setupStreams(t, testMetadata())
+	_, err := r.Invoke(context.Background(), &bindings.InvokeRequest{Operation: "foo"})
+	assert.Error(t, err)
+}
@@ -15,6 +15,7 @@ package bindings
 
 import (
 	"fmt"
+	"io"
 	"strconv"
 )
 
@@ -25,6 +26,14 @@ type InvokeRequest struct {
 	Operation OperationKind     `json:"operation"`
 }
 
+// InvokeStreamRequest is the object given to a dapr output binding that supports streaming, with the payload as a stream.
+// Data may be nil when there's no payload.
+type InvokeStreamRequest struct {
+	Data      io.Reader
+	Metadata  map[string]string
+	Operation OperationKind
+}
+
 // OperationKind defines an output binding operation.
 type OperationKind string
 
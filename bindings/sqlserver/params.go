@@ -0,0 +1,273 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlserver
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	mssql "github.com/microsoft/go-mssqldb"
+)
+
+// parseParams parses the parameters of a statement, passed as JSON.
+// If the JSON is an array, its values are positional parameters (@p1, @p2, ...); if it's an object, its values are named parameters.
+// Objects with a "typeName" property are table-valued parameters (see tvpParam).
+func parseParams(params string) ([]any, error) {
+	if params == "" {
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(strings.NewReader(params))
+	dec.UseNumber()
+	var raw any
+	err := dec.Decode(&raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	switch v := raw.(type) {
+	case []any:
+		args := make([]any, len(v))
+		for i, val := range v {
+			args[i], err = convertParam(val)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %d: %w", i+1, err)
+			}
+		}
+		return args, nil
+	case map[string]any:
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			return strings.TrimPrefix(names[i], "@") < strings.TrimPrefix(names[j], "@")
+		})
+
+		args := make([]any, len(names))
+		for i, name := range names {
+			val, err := convertParam(v[name])
+			if err != nil {
+				return nil, fmt.Errorf("parameter %s: %w", name, err)
+			}
+			args[i] = sql.Named(strings.TrimPrefix(name, "@"), val)
+		}
+		return args, nil
+	default:
+		return nil, errors.New("parameters must be a JSON array or object")
+	}
+}
+
+// convertParam converts a value decoded from JSON to a value for the driver.
+func convertParam(val any) (any, error) {
+	switch v := val.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	case map[string]any:
+		if _, ok := v["typeName"]; ok {
+			return parseTVP(v)
+		}
+		// Other objects are passed as JSON strings, which can be read with OPENJSON
+		return encodeJSON(v)
+	case []any:
+		return encodeJSON(v)
+	default:
+		// Strings, booleans, and nil
+		return v, nil
+	}
+}
+
+func encodeJSON(v any) (string, error) {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// tvpParam is a table-valued parameter passed as JSON, for example:
+//
+//	{"typeName": "dbo.OrderItems", "columns": [{"name": "id", "type": "int"}, {"name": "name", "type": "nvarchar"}], "rows": [[1, "a"], [2, null]]}
+//
+// Columns must be listed in the same order as in the table type.
+type tvpParam struct {
+	TypeName string      `json:"typeName"`
+	Columns  []tvpColumn `json:"columns"`
+	Rows     [][]any     `json:"rows"`
+}
+
+type tvpColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Go types used for the columns of table-valued parameters, by SQL type.
+// Pointers allow NULL values.
+var tvpColumnTypes = map[string]reflect.Type{
+	"bigint":         reflect.TypeOf((*int64)(nil)),
+	"int":            reflect.TypeOf((*int64)(nil)),
+	"smallint":       reflect.TypeOf((*int64)(nil)),
+	"tinyint":        reflect.TypeOf((*int64)(nil)),
+	"float":          reflect.TypeOf((*float64)(nil)),
+	"real":           reflect.TypeOf((*float64)(nil)),
+	"bit":            reflect.TypeOf((*bool)(nil)),
+	"char":           reflect.TypeOf((*string)(nil)),
+	"varchar":        reflect.TypeOf((*string)(nil)),
+	"nchar":          reflect.TypeOf((*string)(nil)),
+	"nvarchar":       reflect.TypeOf((*string)(nil)),
+	"date":           reflect.TypeOf((*time.Time)(nil)),
+	"datetime":       reflect.TypeOf((*time.Time)(nil)),
+	"datetime2":      reflect.TypeOf((*time.Time)(nil)),
+	"datetimeoffset": reflect.TypeOf((*time.Time)(nil)),
+	"binary":         reflect.TypeOf([]byte(nil)),
+	"varbinary":      reflect.TypeOf([]byte(nil)),
+}
+
+// parseTVP converts a table-valued parameter decoded from JSON to a value for the driver.
+// The driver requires a slice of structs, so a struct type is created with a field for each column.
+func parseTVP(obj map[string]any) (mssql.TVP, error) {
+	// Re-encode the object to decode it into the struct
+	enc, err := json.Marshal(obj)
+	if err != nil {
+		return mssql.TVP{}, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(enc))
+	dec.UseNumber()
+	p := tvpParam{}
+	err = dec.Decode(&p)
+	if err != nil {
+		return mssql.TVP{}, fmt.Errorf("invalid table-valued parameter: %w", err)
+	}
+	if p.TypeName == "" {
+		return mssql.TVP{}, errors.New("table-valued parameter is missing typeName")
+	}
+	if len(p.Columns) == 0 {
+		return mssql.TVP{}, errors.New("table-valued parameter must have at least one column")
+	}
+
+	fields := make([]reflect.StructField, len(p.Columns))
+	for i, col := range p.Columns {
+		t, ok := tvpColumnTypes[strings.ToLower(col.Type)]
+		if !ok {
+			return mssql.TVP{}, fmt.Errorf("column %s has unsupported type %s", col.Name, col.Type)
+		}
+		fields[i] = reflect.StructField{
+			Name: fmt.Sprintf("Col%d", i),
+			Type: t,
+		}
+	}
+	rowType := reflect.StructOf(fields)
+
+	rows := reflect.MakeSlice(reflect.SliceOf(rowType), len(p.Rows), len(p.Rows))
+	for i, row := range p.Rows {
+		if len(row) != len(p.Columns) {
+			return mssql.TVP{}, fmt.Errorf("row %d has %d values, but there are %d columns", i, len(row), len(p.Columns))
+		}
+		for j, val := range row {
+			err = setTVPValue(rows.Index(i).Field(j), val)
+			if err != nil {
+				return mssql.TVP{}, fmt.Errorf("row %d, column %s: %w", i, p.Columns[j].Name, err)
+			}
+		}
+	}
+
+	return mssql.TVP{
+		TypeName: p.TypeName,
+		Value:    rows.Interface(),
+	}, nil
+}
+
+// setTVPValue sets a value decoded from JSON into the field of a table-valued parameter's row.
+func setTVPValue(field reflect.Value, val any) error {
+	if val == nil {
+		// Leave the field as nil (NULL)
+		return nil
+	}
+
+	// Binary columns are passed as base64-encoded strings
+	if field.Type() == reflect.TypeOf([]byte(nil)) {
+		s, ok := val.(string)
+		if !ok {
+			return errors.New("binary values must be base64-encoded strings")
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return fmt.Errorf("invalid base64 value: %w", err)
+		}
+		field.SetBytes(b)
+		return nil
+	}
+
+	ptr := reflect.New(field.Type().Elem())
+	switch target := ptr.Interface().(type) {
+	case *int64:
+		n, ok := val.(json.Number)
+		if !ok {
+			return fmt.Errorf("expected a number, got %T", val)
+		}
+		i, err := n.Int64()
+		if err != nil {
+			return fmt.Errorf("expected an integer: %w", err)
+		}
+		*target = i
+	case *float64:
+		n, ok := val.(json.Number)
+		if !ok {
+			return fmt.Errorf("expected a number, got %T", val)
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return err
+		}
+		*target = f
+	case *bool:
+		b, ok := val.(bool)
+		if !ok {
+			return fmt.Errorf("expected a boolean, got %T", val)
+		}
+		*target = b
+	case *string:
+		s, ok := val.(string)
+		if !ok {
+			return fmt.Errorf("expected a string, got %T", val)
+		}
+		*target = s
+	case *time.Time:
+		s, ok := val.(string)
+		if !ok {
+			return fmt.Errorf("expected a RFC3339 timestamp, got %T", val)
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return fmt.Errorf("expected a RFC3339 timestamp: %w", err)
+		}
+		*target = t
+	}
+	field.Set(ptr)
+	return nil
+}
@@ -0,0 +1,318 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/microsoft/go-mssqldb/msdsn"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/internal/authentication/azure"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// list of operations.
+	execOperation  bindings.OperationKind = "exec"
+	queryOperation bindings.OperationKind = "query"
+	closeOperation bindings.OperationKind = "close"
+
+	// keys from request's metadata.
+	commandSQLKey    = "sql"
+	commandParamsKey = "params"
+
+	// keys from response's metadata.
+	respOpKey           = "operation"
+	respSQLKey          = "sql"
+	respStartTimeKey    = "start-time"
+	respRowsAffectedKey = "rows-affected"
+	respEndTimeKey      = "end-time"
+	respDurationKey     = "duration"
+)
+
+// SQLServer represents Microsoft SQL Server output bindings.
+type SQLServer struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+type sqlServerMetadata struct {
+	// URL is the connection string to connect to SQL Server.
+	URL string `mapstructure:"url"`
+
+	// UseAzureAD enables authenticating with Azure AD; the connection string must not contain credentials.
+	UseAzureAD bool `mapstructure:"useAzureAD"`
+
+	// MaxIdleConns is the maximum number of connections in the idle connection pool.
+	MaxIdleConns int `mapstructure:"maxIdleConns"`
+
+	// MaxOpenConns is the maximum number of open connections to the database.
+	MaxOpenConns int `mapstructure:"maxOpenConns"`
+
+	// ConnMaxLifetime is the maximum amount of time a connection may be reused.
+	ConnMaxLifetime time.Duration `mapstructure:"connMaxLifetime"`
+
+	// ConnMaxIdleTime is the maximum amount of time a connection may be idle.
+	ConnMaxIdleTime time.Duration `mapstructure:"connMaxIdleTime"`
+}
+
+// NewSQLServer returns a new SQL Server output binding.
+func NewSQLServer(logger logger.Logger) bindings.OutputBinding {
+	return &SQLServer{logger: logger}
+}
+
+// Init initializes the SQL Server binding.
+func (s *SQLServer) Init(ctx context.Context, md bindings.Metadata) error {
+	s.logger.Debug("Initializing SQL Server binding")
+
+	// parse metadata
+	meta := sqlServerMetadata{}
+	err := metadata.DecodeMetadata(md.Properties, &meta)
+	if err != nil {
+		return err
+	}
+
+	if meta.URL == "" {
+		return errors.New("missing SQL Server connection string")
+	}
+
+	conn, err := getConnector(meta, md.Properties)
+	if err != nil {
+		return err
+	}
+
+	db := sql.OpenDB(conn)
+	db.SetMaxIdleConns(meta.MaxIdleConns)
+	db.SetMaxOpenConns(meta.MaxOpenConns)
+	db.SetConnMaxIdleTime(meta.ConnMaxIdleTime)
+	db.SetConnMaxLifetime(meta.ConnMaxLifetime)
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("unable to ping the DB: %w", err)
+	}
+
+	s.db = db
+
+	return nil
+}
+
+// getConnector returns the connector from the connection string, using Azure AD if configured.
+func getConnector(meta sqlServerMetadata, properties map[string]string) (*mssql.Connector, error) {
+	config, err := msdsn.Parse(meta.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection string: %w", err)
+	}
+
+	if !meta.UseAzureAD {
+		return mssql.NewConnectorConfig(config), nil
+	}
+
+	azureEnv, err := azure.NewEnvironmentSettings(properties)
+	if err != nil {
+		return nil, err
+	}
+	tokenCred, err := azureEnv.GetTokenCredential()
+	if err != nil {
+		return nil, err
+	}
+	return mssql.NewSecurityTokenConnector(config, func(ctx context.Context) (string, error) {
+		at, err := tokenCred.GetToken(ctx, policy.TokenRequestOptions{
+			Scopes: []string{
+				azureEnv.Cloud.Services[azure.ServiceAzureSQL].Audience,
+			},
+		})
+		if err != nil {
+			return "", err
+		}
+		return at.Token, nil
+	})
+}
+
+// Invoke handles all invoke operations.
+func (s *SQLServer) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	if req == nil {
+		return nil, errors.New("invoke request required")
+	}
+
+	if req.Operation == closeOperation {
+		return nil, s.db.Close()
+	}
+
+	if req.Metadata == nil {
+		return nil, errors.New("metadata required")
+	}
+	s.logger.Debugf("operation: %v", req.Operation)
+
+	stmt, ok := req.Metadata[commandSQLKey]
+	if !ok || stmt == "" {
+		return nil, fmt.Errorf("required metadata not set: %s", commandSQLKey)
+	}
+
+	args, err := parseParams(req.Metadata[commandParamsKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid metadata %s: %w", commandParamsKey, err)
+	}
+
+	startTime := time.Now()
+
+	resp := &bindings.InvokeResponse{
+		Metadata: map[string]string{
+			respOpKey:        string(req.Operation),
+			respSQLKey:       stmt,
+			respStartTimeKey: startTime.Format(time.RFC3339Nano),
+		},
+	}
+
+	switch req.Operation { //nolint:exhaustive
+	case execOperation:
+		r, err := s.exec(ctx, stmt, args...)
+		if err != nil {
+			return nil, err
+		}
+		resp.Metadata[respRowsAffectedKey] = strconv.FormatInt(r, 10)
+
+	case queryOperation:
+		d, err := s.query(ctx, stmt, args...)
+		if err != nil {
+			return nil, err
+		}
+		resp.Data = d
+
+	default:
+		return nil, fmt.Errorf("invalid operation type: %s. Expected %s, %s, or %s",
+			req.Operation, execOperation, queryOperation, closeOperation)
+	}
+
+	endTime := time.Now()
+	resp.Metadata[respEndTimeKey] = endTime.Format(time.RFC3339Nano)
+	resp.Metadata[respDurationKey] = endTime.Sub(startTime).String()
+
+	return resp, nil
+}
+
+// Operations returns list of operations supported by SQL Server binding.
+func (s *SQLServer) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{
+		execOperation,
+		queryOperation,
+		closeOperation,
+	}
+}
+
+// Close will close the DB.
+func (s *SQLServer) Close() error {
+	if s.db != nil {
+		return s.db.Close()
+	}
+
+	return nil
+}
+
+func (s *SQLServer) query(ctx context.Context, stmt string, args ...any) ([]byte, error) {
+	rows, err := s.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+		_ = rows.Err()
+	}()
+
+	result, err := jsonify(rows)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling query result for query: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *SQLServer) exec(ctx context.Context, stmt string, args ...any) (int64, error) {
+	s.logger.Debugf("exec: %s", stmt)
+
+	res, err := s.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return 0, fmt.Errorf("error executing query: %w", err)
+	}
+
+	return res.RowsAffected()
+}
+
+func jsonify(rows *sql.Rows) ([]byte, error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []map[string]any{}
+	for rows.Next() {
+		values := make([]any, len(columnTypes))
+		for i := range values {
+			values[i] = new(any)
+		}
+		err := rows.Scan(values...)
+		if err != nil {
+			return nil, err
+		}
+
+		r := make(map[string]any, len(columnTypes))
+		for i, ct := range columnTypes {
+			r[ct.Name()] = convertValue(ct.DatabaseTypeName(), *(values[i].(*any)))
+		}
+		ret = append(ret, r)
+	}
+
+	return json.Marshal(ret)
+}
+
+// convertValue converts values that the driver returns as bytes to a representation that can be encoded as JSON.
+func convertValue(dbType string, value any) any {
+	b, ok := value.([]byte)
+	if !ok {
+		return value
+	}
+
+	switch dbType {
+	case "DECIMAL", "MONEY", "SMALLMONEY":
+		return string(b)
+	case "UNIQUEIDENTIFIER":
+		var u mssql.UniqueIdentifier
+		if err := u.Scan(b); err == nil {
+			return u.String()
+		}
+	}
+	// Other binary values are encoded as base64 by the JSON encoder
+	return b
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (s *SQLServer) GetComponentMetadata() map[string]string {
+	metadataStruct := sqlServerMetadata{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.BindingType)
+	return metadataInfo
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/kit/logger"
+)
+
+func TestInvoke(t *testing.T) {
+	s, mock := mockDatabase(t)
+	defer s.Close()
+
+	t.Run("exec with positional parameters", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO foo \\(id, name\\) VALUES \\(@p1, @p2\\)").
+			WithArgs(int64(1), "a").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		res, err := s.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: execOperation,
+			Metadata: map[string]string{
+				commandSQLKey:    "INSERT INTO foo (id, name) VALUES (@p1, @p2)",
+				commandParamsKey: `[1, "a"]`,
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "1", res.Metadata[respRowsAffectedKey])
+		assert.Equal(t, string(execOperation), res.Metadata[respOpKey])
+	})
+
+	t.Run("query with named parameters", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "name", "price"}).
+			AddRow(int64(1), "a", []byte("1.50")).
+			AddRow(int64(2), nil, []byte("2.00"))
+		mock.ExpectQuery("SELECT \\* FROM foo WHERE id < @maxId").
+			WithArgs(sql.Named("maxId", int64(3))).
+			WillReturnRows(rows)
+
+		res, err := s.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: queryOperation,
+			Metadata: map[string]string{
+				commandSQLKey:    "SELECT * FROM foo WHERE id < @maxId",
+				commandParamsKey: `{"maxId": 3}`,
+			},
+		})
+		require.NoError(t, err)
+
+		var result []map[string]any
+		require.NoError(t, json.Unmarshal(res.Data, &result))
+		require.Len(t, result, 2)
+		assert.Equal(t, float64(1), result[0]["id"])
+		assert.Equal(t, "a", result[0]["name"])
+		assert.Nil(t, result[1]["name"])
+	})
+
+	t.Run("missing sql", func(t *testing.T) {
+		_, err := s.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: queryOperation,
+			Metadata:  map[string]string{},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		_, err := s.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: queryOperation,
+			Metadata: map[string]string{
+				commandSQLKey:    "SELECT 1",
+				commandParamsKey: `"foo"`,
+			},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid operation", func(t *testing.T) {
+		_, err := s.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: "foo",
+			Metadata: map[string]string{
+				commandSQLKey: "SELECT 1",
+			},
+		})
+		require.Error(t, err)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestParseParams(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		args, err := parseParams("")
+		require.NoError(t, err)
+		assert.Nil(t, args)
+	})
+
+	t.Run("positional", func(t *testing.T) {
+		args, err := parseParams(`[1, 1.5, "a", true, null, {"k": "<v>"}]`)
+		require.NoError(t, err)
+		assert.Equal(t, []any{int64(1), 1.5, "a", true, nil, `{"k":"<v>"}`}, args)
+	})
+
+	t.Run("named", func(t *testing.T) {
+		args, err := parseParams(`{"@b": 2, "a": "x"}`)
+		require.NoError(t, err)
+		assert.Equal(t, []any{sql.Named("a", "x"), sql.Named("b", int64(2))}, args)
+	})
+
+	t.Run("table-valued parameter", func(t *testing.T) {
+		args, err := parseParams(`[{
+			"typeName": "dbo.Items",
+			"columns": [
+				{"name": "id", "type": "int"},
+				{"name": "name", "type": "nvarchar"},
+				{"name": "created", "type": "datetime2"},
+				{"name": "data", "type": "varbinary"}
+			],
+			"rows": [
+				[1, "a", "2023-01-02T03:04:05Z", "aGk="],
+				[2, null, null, null]
+			]
+		}]`)
+		require.NoError(t, err)
+		require.Len(t, args, 1)
+
+		tvp, ok := args[0].(mssql.TVP)
+		require.True(t, ok)
+		assert.Equal(t, "dbo.Items", tvp.TypeName)
+
+		rows := reflect.ValueOf(tvp.Value)
+		require.Equal(t, reflect.Slice, rows.Kind())
+		require.Equal(t, 2, rows.Len())
+		assert.Equal(t, reflect.Struct, rows.Type().Elem().Kind())
+
+		first := rows.Index(0)
+		assert.Equal(t, int64(1), first.Field(0).Elem().Int())
+		assert.Equal(t, "a", first.Field(1).Elem().String())
+		assert.Equal(t, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), first.Field(2).Elem().Interface())
+		assert.Equal(t, []byte("hi"), first.Field(3).Bytes())
+
+		second := rows.Index(1)
+		assert.True(t, second.Field(1).IsNil())
+		assert.True(t, second.Field(2).IsNil())
+	})
+
+	t.Run("table-valued parameter errors", func(t *testing.T) {
+		tests := map[string]string{
+			"missing typeName":    `[{"typeName": "", "columns": [{"name": "id", "type": "int"}], "rows": []}]`,
+			"no columns":          `[{"typeName": "dbo.T", "columns": [], "rows": []}]`,
+			"unsupported type":    `[{"typeName": "dbo.T", "columns": [{"name": "id", "type": "xml"}], "rows": []}]`,
+			"wrong row length":    `[{"typeName": "dbo.T", "columns": [{"name": "id", "type": "int"}], "rows": [[1, 2]]}]`,
+			"wrong value type":    `[{"typeName": "dbo.T", "columns": [{"name": "id", "type": "int"}], "rows": [["a"]]}]`,
+			"non-integer for int": `[{"typeName": "dbo.T", "columns": [{"name": "id", "type": "int"}], "rows": [[1.5]]}]`,
+		}
+		for name, params := range tests {
+			t.Run(name, func(t *testing.T) {
+				_, err := parseParams(params)
+				require.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := parseParams(`[1,`)
+		require.Error(t, err)
+	})
+}
+
+func TestConvertValue(t *testing.T) {
+	assert.Equal(t, "1.50", convertValue("DECIMAL", []byte("1.50")))
+	assert.Equal(t, "1.50", convertValue("MONEY", []byte("1.50")))
+	assert.Equal(t, []byte{1, 2}, convertValue("VARBINARY", []byte{1, 2}))
+	assert.Equal(t, int64(1), convertValue("INT", int64(1)))
+
+	u := mssql.UniqueIdentifier{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F, 0x10}
+	b, err := u.Value()
+	require.NoError(t, err)
+	assert.Equal(t, u.String(), convertValue("UNIQUEIDENTIFIER", b))
+}
+
+func TestInitMissingURL(t *testing.T) {
+	s := NewSQLServer(logger.NewLogger("test"))
+	err := s.Init(context.Background(), bindings.Metadata{})
+	require.Error(t, err)
+}
+
+func mockDatabase(t *testing.T) (*SQLServer, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	s := NewSQLServer(logger.NewLogger("test")).(*SQLServer)
+	s.db = db
+
+	return s, mock
+}
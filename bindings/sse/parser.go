@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sse
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// event is an event received from an event stream.
+type event struct {
+	ID   string
+	Type string
+	Data string
+}
+
+// eventReader reads the events of a "text/event-stream" body, as described in
+// https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation
+type eventReader struct {
+	r *bufio.Reader
+	// ID of the last event, which is kept for the following events without an "id" field.
+	lastEventID string
+	// Reconnection time set by the server with the "retry" field, or 0 if it's not set.
+	retry time.Duration
+}
+
+func newEventReader(r io.Reader, lastEventID string) *eventReader {
+	return &eventReader{
+		r:           bufio.NewReader(r),
+		lastEventID: lastEventID,
+	}
+}
+
+// Next returns the next event of the stream.
+// It returns io.EOF when the stream ends; a partial event at the end of the stream is discarded.
+func (er *eventReader) Next() (*event, error) {
+	var (
+		eventType string
+		data      strings.Builder
+		hasData   bool
+	)
+	for {
+		line, err := er.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		// A blank line dispatches the event
+		if line == "" {
+			if !hasData {
+				eventType = ""
+				continue
+			}
+			if eventType == "" {
+				eventType = "message"
+			}
+			return &event{
+				ID:   er.lastEventID,
+				Type: eventType,
+				Data: data.String(),
+			}, nil
+		}
+
+		// Lines starting with a colon are comments, often used as keep-alives
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				er.lastEventID = value
+			}
+		case "retry":
+			ms, err := strconv.ParseUint(value, 10, 63)
+			if err == nil {
+				er.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
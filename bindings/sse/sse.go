@@ -0,0 +1,337 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	modeSSE      = "sse"
+	modeLongPoll = "longpoll"
+
+	headerMetadataPrefix = "header:"
+	lastEventIDHeader    = "Last-Event-ID"
+
+	// keys of the metadata of the events.
+	eventIDKey   = "id"
+	eventTypeKey = "event"
+	eventURLKey  = "url"
+
+	defaultReconnectInterval = 3 * time.Second
+	defaultPollTimeout       = 90 * time.Second
+)
+
+// Binding is an input binding that delivers the events of a Server-Sent Events endpoint, or the responses of a long-polling endpoint, to the app.
+type Binding struct {
+	logger   logger.Logger
+	metadata sseMetadata
+	header   http.Header
+	client   *http.Client
+	closed   atomic.Bool
+	closeCh  chan struct{}
+	wg       sync.WaitGroup
+}
+
+type sseMetadata struct {
+	// URL of the endpoint.
+	URL string `mapstructure:"url"`
+	// Mode is either "sse" for Server-Sent Events, or "longpoll" for long-polling.
+	Mode string `mapstructure:"mode"`
+	// Time to wait before reconnecting after the connection is lost; it's overridden by the "retry" field of the event stream.
+	ReconnectInterval time.Duration `mapstructure:"reconnectInterval"`
+	// ID of the last event received, sent in the Last-Event-ID header of the first request to resume the stream.
+	LastEventID string `mapstructure:"lastEventID"`
+	// Timeout of each request in long-polling mode.
+	PollTimeout time.Duration `mapstructure:"pollTimeout"`
+	// Time to wait between requests in long-polling mode.
+	PollInterval time.Duration `mapstructure:"pollInterval"`
+}
+
+// NewSSE returns a new Server-Sent Events input binding.
+func NewSSE(logger logger.Logger) bindings.InputBinding {
+	return &Binding{
+		logger:  logger,
+		closeCh: make(chan struct{}),
+	}
+}
+
+// Init initializes the binding.
+func (b *Binding) Init(_ context.Context, meta bindings.Metadata) error {
+	m := sseMetadata{
+		Mode:              modeSSE,
+		ReconnectInterval: defaultReconnectInterval,
+		PollTimeout:       defaultPollTimeout,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return err
+	}
+
+	if m.URL == "" {
+		return errors.New("missing url")
+	}
+	u, err := url.Parse(m.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid url: %s", m.URL)
+	}
+	m.Mode = strings.ToLower(m.Mode)
+	if m.Mode != modeSSE && m.Mode != modeLongPoll {
+		return fmt.Errorf("invalid mode: %s, expected %s or %s", m.Mode, modeSSE, modeLongPoll)
+	}
+	if m.ReconnectInterval < 0 || m.PollTimeout < 0 || m.PollInterval < 0 {
+		return errors.New("reconnectInterval, pollTimeout and pollInterval must not be negative")
+	}
+	b.metadata = m
+
+	// Headers, such as the ones for authentication, are set with the "header:" metadata prefix.
+	b.header = make(http.Header)
+	for k, v := range meta.Properties {
+		if strings.HasPrefix(k, headerMetadataPrefix) {
+			b.header.Set(strings.TrimPrefix(k, headerMetadataPrefix), v)
+		}
+	}
+
+	// Requests have no timeout as the event stream is kept open; long-polling requests have a timeout in their context.
+	b.client = &http.Client{}
+
+	return nil
+}
+
+// Read connects to the endpoint and delivers the events to the handler until the binding is closed.
+// The connection is re-established when it's lost, resuming from the last event received.
+func (b *Binding) Read(ctx context.Context, handler bindings.Handler) error {
+	if b.closed.Load() {
+		return errors.New("binding is closed")
+	}
+
+	readCtx, cancel := context.WithCancel(ctx)
+	b.wg.Add(2)
+
+	go func() {
+		defer b.wg.Done()
+		defer cancel()
+		select {
+		case <-b.closeCh:
+		case <-readCtx.Done():
+		}
+	}()
+
+	go func() {
+		defer b.wg.Done()
+		defer cancel()
+		if b.metadata.Mode == modeLongPoll {
+			b.poll(readCtx, handler)
+		} else {
+			b.stream(readCtx, handler)
+		}
+	}()
+
+	return nil
+}
+
+// stream reads the event stream, reconnecting when the connection is lost.
+func (b *Binding) stream(ctx context.Context, handler bindings.Handler) {
+	lastEventID := b.metadata.LastEventID
+	reconnectInterval := b.metadata.ReconnectInterval
+	for {
+		res, err := b.doRequest(ctx, lastEventID)
+		if err == nil {
+			switch {
+			case res.StatusCode == http.StatusNoContent:
+				// The server asks the client to stop reconnecting
+				res.Body.Close()
+				b.logger.Infof("SSE endpoint %s responded with status %d; the binding stops reading events", b.metadata.URL, res.StatusCode)
+				return
+			case res.StatusCode != http.StatusOK:
+				err = fmt.Errorf("unexpected response status %d", res.StatusCode)
+			case !isEventStream(res.Header.Get("Content-Type")):
+				err = fmt.Errorf("unexpected response content type %q", res.Header.Get("Content-Type"))
+			default:
+				er := newEventReader(res.Body, lastEventID)
+				err = b.readEvents(ctx, er, handler)
+				lastEventID = er.lastEventID
+				if er.retry > 0 {
+					reconnectInterval = er.retry
+				}
+			}
+			res.Body.Close()
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			b.logger.Warnf("Error reading events from SSE endpoint %s, reconnecting in %v: %v", b.metadata.URL, reconnectInterval, err)
+		} else {
+			b.logger.Debugf("SSE endpoint %s closed the connection, reconnecting in %v", b.metadata.URL, reconnectInterval)
+		}
+
+		select {
+		case <-time.After(reconnectInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// readEvents delivers the events of a stream to the handler until the stream ends.
+func (b *Binding) readEvents(ctx context.Context, er *eventReader, handler bindings.Handler) error {
+	for {
+		ev, err := er.Next()
+		if err != nil {
+			return err
+		}
+		b.handle(ctx, handler, []byte(ev.Data), map[string]string{
+			eventIDKey:   ev.ID,
+			eventTypeKey: ev.Type,
+			eventURLKey:  b.metadata.URL,
+		})
+	}
+}
+
+// poll sends long-polling requests, delivering each response with content as an event.
+func (b *Binding) poll(ctx context.Context, handler bindings.Handler) {
+	lastEventID := b.metadata.LastEventID
+	for {
+		wait, err := b.pollOnce(ctx, handler, &lastEventID)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			b.logger.Warnf("Error polling endpoint %s, retrying in %v: %v", b.metadata.URL, b.metadata.ReconnectInterval, err)
+			wait = b.metadata.ReconnectInterval
+		}
+
+		if wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// pollOnce sends a long-polling request and returns the time to wait before the next one.
+// The ID of the event is read from the Last-Event-ID header of the response, and it's sent in the next request.
+func (b *Binding) pollOnce(parentCtx context.Context, handler bindings.Handler, lastEventID *string) (time.Duration, error) {
+	ctx := parentCtx
+	if b.metadata.PollTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(parentCtx, b.metadata.PollTimeout)
+		defer cancel()
+	}
+
+	res, err := b.doRequest(ctx, *lastEventID)
+	if err != nil {
+		// The server didn't respond before the timeout, which means there are no events
+		if errors.Is(err, context.DeadlineExceeded) && parentCtx.Err() == nil {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		data, err := io.ReadAll(res.Body)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read response: %w", err)
+		}
+		if id := res.Header.Get(lastEventIDHeader); id != "" {
+			*lastEventID = id
+		}
+		if len(data) > 0 {
+			b.handle(parentCtx, handler, data, map[string]string{
+				eventIDKey:  res.Header.Get(lastEventIDHeader),
+				eventURLKey: b.metadata.URL,
+			})
+		}
+		return b.metadata.PollInterval, nil
+	case http.StatusNoContent, http.StatusNotModified:
+		// No events before the server timeout
+		return b.metadata.PollInterval, nil
+	default:
+		return 0, fmt.Errorf("unexpected response status %d", res.StatusCode)
+	}
+}
+
+func (b *Binding) doRequest(ctx context.Context, lastEventID string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.metadata.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range b.header {
+		req.Header[k] = v
+	}
+	if b.metadata.Mode == modeSSE {
+		req.Header.Set("Accept", "text/event-stream")
+		req.Header.Set("Cache-Control", "no-cache")
+	}
+	if lastEventID != "" {
+		req.Header.Set(lastEventIDHeader, lastEventID)
+	}
+
+	return b.client.Do(req)
+}
+
+// handle delivers an event to the handler.
+// Events can't be redelivered, so errors of the handler are only logged.
+func (b *Binding) handle(ctx context.Context, handler bindings.Handler, data []byte, md map[string]string) {
+	_, err := handler(ctx, &bindings.ReadResponse{
+		Data:     data,
+		Metadata: md,
+	})
+	if err != nil {
+		b.logger.Errorf("Error invoking handler for event %q from %s: %v", md[eventIDKey], b.metadata.URL, err)
+	}
+}
+
+func isEventStream(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "text/event-stream"
+}
+
+// Close stops reading events.
+func (b *Binding) Close() error {
+	if b.closed.CompareAndSwap(false, true) {
+		close(b.closeCh)
+	}
+	b.wg.Wait()
+	return nil
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (b *Binding) GetComponentMetadata() map[string]string {
+	metadataStruct := sseMetadata{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.BindingType)
+	return metadataInfo
+}
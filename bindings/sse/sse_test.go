@@ -0,0 +1,192 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sse
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+func TestEventReader(t *testing.T) {
+	stream := ": keep-alive\n\n" +
+		"data: first\n\n" +
+		"id: 1\r\nevent: update\r\ndata: line1\r\ndata:line2\r\n\r\n" +
+		"retry: 5000\n\n" +
+		"data: third\n\n" +
+		"data: partial"
+	er := newEventReader(strings.NewReader(stream), "0")
+
+	ev, err := er.Next()
+	require.NoError(t, err)
+	assert.Equal(t, &event{ID: "0", Type: "message", Data: "first"}, ev)
+
+	ev, err = er.Next()
+	require.NoError(t, err)
+	assert.Equal(t, &event{ID: "1", Type: "update", Data: "line1\nline2"}, ev)
+
+	ev, err = er.Next()
+	require.NoError(t, err)
+	assert.Equal(t, &event{ID: "1", Type: "message", Data: "third"}, ev)
+	assert.Equal(t, 5*time.Second, er.retry)
+
+	_, err = er.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestInit(t *testing.T) {
+	t.Run("valid metadata", func(t *testing.T) {
+		b := NewSSE(logger.NewLogger("test")).(*Binding)
+		err := b.Init(context.Background(), newMetadata(map[string]string{
+			"url":                  "https://example.com/events",
+			"header:Authorization": "Bearer token",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, modeSSE, b.metadata.Mode)
+		assert.Equal(t, defaultReconnectInterval, b.metadata.ReconnectInterval)
+		assert.Equal(t, "Bearer token", b.header.Get("Authorization"))
+	})
+
+	t.Run("missing url", func(t *testing.T) {
+		b := NewSSE(logger.NewLogger("test"))
+		err := b.Init(context.Background(), newMetadata(map[string]string{}))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		b := NewSSE(logger.NewLogger("test"))
+		err := b.Init(context.Background(), newMetadata(map[string]string{
+			"url":  "https://example.com/events",
+			"mode": "websocket",
+		}))
+		assert.Error(t, err)
+	})
+}
+
+func TestReadStream(t *testing.T) {
+	var connections atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch connections.Add(1) {
+		case 1:
+			assert.Empty(t, r.Header.Get(lastEventIDHeader))
+			fmt.Fprint(w, "retry: 10\nid: 1\ndata: one\n\nid: 2\ndata: two\n\n")
+		case 2:
+			// The stream is resumed from the last event
+			assert.Equal(t, "2", r.Header.Get(lastEventIDHeader))
+			fmt.Fprint(w, "id: 3\nevent: custom\ndata: three\n\n")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	b := NewSSE(logger.NewLogger("test"))
+	err := b.Init(context.Background(), newMetadata(map[string]string{
+		"url":                  srv.URL,
+		"reconnectInterval":    "1m",
+		"header:Authorization": "Bearer token",
+	}))
+	require.NoError(t, err)
+
+	events := make(chan *bindings.ReadResponse, 10)
+	err = b.Read(context.Background(), func(_ context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		events <- res
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	for _, expect := range []struct{ id, typ, data string }{
+		{"1", "message", "one"},
+		{"2", "message", "two"},
+		{"3", "custom", "three"},
+	} {
+		select {
+		case res := <-events:
+			assert.Equal(t, expect.data, string(res.Data))
+			assert.Equal(t, expect.id, res.Metadata[eventIDKey])
+			assert.Equal(t, expect.typ, res.Metadata[eventTypeKey])
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for event", expect.id)
+		}
+	}
+
+	// The binding stops reconnecting when the server responds with 204 No Content
+	assert.Eventually(t, func() bool {
+		return connections.Load() == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, b.Close())
+}
+
+func TestReadLongPoll(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch requests.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusNoContent)
+		case 2:
+			w.Header().Set(lastEventIDHeader, "a")
+			fmt.Fprint(w, `{"n":1}`)
+		case 3:
+			assert.Equal(t, "a", r.Header.Get(lastEventIDHeader))
+			fmt.Fprint(w, `{"n":2}`)
+		default:
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	b := NewSSE(logger.NewLogger("test"))
+	err := b.Init(context.Background(), newMetadata(map[string]string{
+		"url":  srv.URL,
+		"mode": "longpoll",
+	}))
+	require.NoError(t, err)
+
+	events := make(chan *bindings.ReadResponse, 10)
+	err = b.Read(context.Background(), func(_ context.Context, res *bindings.ReadResponse) ([]byte, error) {
+		events <- res
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	for _, expect := range []string{`{"n":1}`, `{"n":2}`} {
+		select {
+		case res := <-events:
+			assert.Equal(t, expect, string(res.Data))
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for event", expect)
+		}
+	}
+
+	require.NoError(t, b.Close())
+}
+
+func newMetadata(props map[string]string) bindings.Metadata {
+	return bindings.Metadata{Base: metadata.Base{Properties: props}}
+}
@@ -0,0 +1,310 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package temporal
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"go.temporal.io/sdk/client"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// StartOperation starts a workflow.
+	StartOperation bindings.OperationKind = "start"
+	// SignalOperation sends a signal to a running workflow.
+	SignalOperation bindings.OperationKind = "signal"
+	// QueryOperation queries the state of a workflow.
+	QueryOperation bindings.OperationKind = "query"
+
+	// Request metadata.
+	metadataWorkflowID   = "workflowID"
+	metadataRunID        = "runID"
+	metadataWorkflowType = "workflowType"
+	metadataTaskQueue    = "taskQueue"
+	metadataSignalName   = "signalName"
+	metadataQueryType    = "queryType"
+)
+
+// Temporal is an output binding that starts, signals and queries Temporal workflows.
+// Unlike the Temporal workflow component, it doesn't manage the workflows: it's meant for apps that only need to interact with them occasionally.
+type Temporal struct {
+	metadata temporalMetadata
+	client   client.Client
+	logger   logger.Logger
+}
+
+type temporalMetadata struct {
+	// Address of the Temporal frontend service.
+	HostPort string `mapstructure:"hostPort"`
+	// Namespace of the workflows.
+	Namespace string `mapstructure:"namespace"`
+	// Identity of the client, shown in the workflow history.
+	Identity string `mapstructure:"identity"`
+	// Task queue of the workflows started when requests don't set the "taskQueue" metadata.
+	TaskQueue string `mapstructure:"taskQueue"`
+	// Enables TLS, which is enabled automatically when any of the certificates is set.
+	EnableTLS bool `mapstructure:"enableTLS"`
+	// PEM-encoded CA certificate used to verify the server certificate; the system roots are used if empty.
+	CaCert string `mapstructure:"caCert"`
+	// PEM-encoded client certificate and key, for mTLS.
+	ClientCert string `mapstructure:"clientCert"`
+	ClientKey  string `mapstructure:"clientKey"`
+	// Name used to verify the server certificate, if different from the host.
+	ServerName string `mapstructure:"serverName"`
+}
+
+// startResponse is the response of the start operation.
+type startResponse struct {
+	WorkflowID string `json:"workflowID"`
+	RunID      string `json:"runID"`
+}
+
+// NewTemporal returns a new Temporal output binding.
+func NewTemporal(logger logger.Logger) bindings.OutputBinding {
+	return &Temporal{logger: logger}
+}
+
+// Init parses the metadata and connects to Temporal.
+func (t *Temporal) Init(_ context.Context, meta bindings.Metadata) error {
+	m, err := parseMetadata(meta)
+	if err != nil {
+		return err
+	}
+
+	opts := client.Options{
+		HostPort:  m.HostPort,
+		Namespace: m.Namespace,
+		Identity:  m.Identity,
+	}
+	opts.ConnectionOptions.TLS, err = m.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	c, err := client.Dial(opts)
+	if err != nil {
+		return fmt.Errorf("temporal binding error: failed to connect to %s: %w", m.HostPort, err)
+	}
+
+	t.metadata = m
+	t.client = c
+	return nil
+}
+
+func parseMetadata(meta bindings.Metadata) (temporalMetadata, error) {
+	m := temporalMetadata{
+		HostPort:  client.DefaultHostPort,
+		Namespace: client.DefaultNamespace,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return m, err
+	}
+
+	if (m.ClientCert == "") != (m.ClientKey == "") {
+		return m, errors.New("temporal binding error: clientCert and clientKey must be set together")
+	}
+	return m, nil
+}
+
+// tlsConfig returns the TLS configuration, or nil if TLS is disabled.
+func (m temporalMetadata) tlsConfig() (*tls.Config, error) {
+	if !m.EnableTLS && m.CaCert == "" && m.ClientCert == "" {
+		return nil, nil
+	}
+
+	conf := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: m.ServerName,
+	}
+	if m.CaCert != "" {
+		conf.RootCAs = x509.NewCertPool()
+		if !conf.RootCAs.AppendCertsFromPEM([]byte(m.CaCert)) {
+			return nil, errors.New("temporal binding error: invalid caCert")
+		}
+	}
+	if m.ClientCert != "" {
+		cert, err := tls.X509KeyPair([]byte(m.ClientCert), []byte(m.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("temporal binding error: invalid client certificate: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	return conf, nil
+}
+
+// Operations returns the operations supported by the binding.
+func (t *Temporal) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{
+		StartOperation,
+		SignalOperation,
+		QueryOperation,
+	}
+}
+
+// Invoke performs an operation on a workflow.
+// The data of the request, if any, is the JSON-encoded argument of the workflow, signal or query.
+func (t *Temporal) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	switch req.Operation { //nolint:exhaustive
+	case StartOperation:
+		return t.start(ctx, req)
+	case SignalOperation:
+		return t.signal(ctx, req)
+	case QueryOperation:
+		return t.query(ctx, req)
+	default:
+		return nil, fmt.Errorf("temporal binding error: unsupported operation %s", req.Operation)
+	}
+}
+
+func (t *Temporal) start(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	workflowType := req.Metadata[metadataWorkflowType]
+	if workflowType == "" {
+		return nil, fmt.Errorf("temporal binding error: metadata %s is required", metadataWorkflowType)
+	}
+	opts := client.StartWorkflowOptions{
+		ID:        req.Metadata[metadataWorkflowID],
+		TaskQueue: t.metadata.TaskQueue,
+	}
+	if req.Metadata[metadataTaskQueue] != "" {
+		opts.TaskQueue = req.Metadata[metadataTaskQueue]
+	}
+	if opts.TaskQueue == "" {
+		return nil, fmt.Errorf("temporal binding error: metadata %s is required", metadataTaskQueue)
+	}
+
+	args, err := decodeArgs(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	run, err := t.client.ExecuteWorkflow(ctx, opts, workflowType, args...)
+	if err != nil {
+		return nil, fmt.Errorf("temporal binding error: failed to start workflow %s: %w", workflowType, err)
+	}
+
+	res := startResponse{
+		WorkflowID: run.GetID(),
+		RunID:      run.GetRunID(),
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	return &bindings.InvokeResponse{
+		Data: data,
+		Metadata: map[string]string{
+			metadataWorkflowID: res.WorkflowID,
+			metadataRunID:      res.RunID,
+		},
+	}, nil
+}
+
+func (t *Temporal) signal(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	workflowID := req.Metadata[metadataWorkflowID]
+	signalName := req.Metadata[metadataSignalName]
+	if workflowID == "" || signalName == "" {
+		return nil, fmt.Errorf("temporal binding error: metadata %s and %s are required", metadataWorkflowID, metadataSignalName)
+	}
+
+	args, err := decodeArgs(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	var arg interface{}
+	if len(args) > 0 {
+		arg = args[0]
+	}
+	err = t.client.SignalWorkflow(ctx, workflowID, req.Metadata[metadataRunID], signalName, arg)
+	if err != nil {
+		return nil, fmt.Errorf("temporal binding error: failed to signal workflow %s: %w", workflowID, err)
+	}
+	return nil, nil
+}
+
+func (t *Temporal) query(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	workflowID := req.Metadata[metadataWorkflowID]
+	queryType := req.Metadata[metadataQueryType]
+	if workflowID == "" || queryType == "" {
+		return nil, fmt.Errorf("temporal binding error: metadata %s and %s are required", metadataWorkflowID, metadataQueryType)
+	}
+
+	args, err := decodeArgs(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	value, err := t.client.QueryWorkflow(ctx, workflowID, req.Metadata[metadataRunID], queryType, args...)
+	if err != nil {
+		return nil, fmt.Errorf("temporal binding error: failed to query workflow %s: %w", workflowID, err)
+	}
+
+	res := &bindings.InvokeResponse{}
+	if value == nil || !value.HasValue() {
+		return res, nil
+	}
+	var result interface{}
+	err = value.Get(&result)
+	if err != nil {
+		return nil, fmt.Errorf("temporal binding error: failed to decode query result: %w", err)
+	}
+	res.Data, err = json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// decodeArgs returns the arguments of the workflow, signal or query, from the JSON-encoded data of the request.
+func decodeArgs(data []byte) ([]interface{}, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var arg interface{}
+	err := json.Unmarshal(data, &arg)
+	if err != nil {
+		return nil, fmt.Errorf("temporal binding error: data must be JSON: %w", err)
+	}
+	return []interface{}{arg}, nil
+}
+
+// Ping checks the connection to Temporal.
+func (t *Temporal) Ping(ctx context.Context) error {
+	_, err := t.client.CheckHealth(ctx, &client.CheckHealthRequest{})
+	return err
+}
+
+// Close closes the connection to Temporal.
+func (t *Temporal) Close() error {
+	if t.client != nil {
+		t.client.Close()
+	}
+	return nil
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (t *Temporal) GetComponentMetadata() map[string]string {
+	metadataStruct := temporalMetadata{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.BindingType)
+	return metadataInfo
+}
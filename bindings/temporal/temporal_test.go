@@ -0,0 +1,194 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package temporal
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/mocks"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+func newTestTemporal(c client.Client) *Temporal {
+	return &Temporal{
+		metadata: temporalMetadata{TaskQueue: "default-queue"},
+		client:   c,
+		logger:   logger.NewLogger("test"),
+	}
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m, err := parseMetadata(bindings.Metadata{})
+		require.NoError(t, err)
+		assert.Equal(t, client.DefaultHostPort, m.HostPort)
+		assert.Equal(t, client.DefaultNamespace, m.Namespace)
+
+		tlsConf, err := m.tlsConfig()
+		require.NoError(t, err)
+		assert.Nil(t, tlsConf)
+	})
+
+	t.Run("tls", func(t *testing.T) {
+		m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"hostPort":   "temporal:7233",
+			"namespace":  "orders",
+			"enableTLS":  "true",
+			"serverName": "temporal.example.com",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "temporal:7233", m.HostPort)
+		assert.Equal(t, "orders", m.Namespace)
+
+		tlsConf, err := m.tlsConfig()
+		require.NoError(t, err)
+		require.NotNil(t, tlsConf)
+		assert.Equal(t, "temporal.example.com", tlsConf.ServerName)
+		assert.Nil(t, tlsConf.RootCAs)
+	})
+
+	t.Run("invalid certificates", func(t *testing.T) {
+		_, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"clientCert": "cert",
+		}}})
+		require.Error(t, err)
+
+		m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"caCert": "not a certificate",
+		}}})
+		require.NoError(t, err)
+		_, err = m.tlsConfig()
+		require.Error(t, err)
+	})
+}
+
+func TestStart(t *testing.T) {
+	c := &mocks.Client{}
+	run := &mocks.WorkflowRun{}
+	run.On("GetID").Return("order-1")
+	run.On("GetRunID").Return("run-1")
+	c.On("ExecuteWorkflow", mock.Anything, client.StartWorkflowOptions{ID: "order-1", TaskQueue: "orders"}, "ProcessOrder", map[string]interface{}{"amount": float64(10)}).
+		Return(run, nil)
+	tb := newTestTemporal(c)
+
+	res, err := tb.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: StartOperation,
+		Data:      []byte(`{"amount": 10}`),
+		Metadata: map[string]string{
+			"workflowType": "ProcessOrder",
+			"workflowID":   "order-1",
+			"taskQueue":    "orders",
+		},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"workflowID": "order-1", "runID": "run-1"}`, string(res.Data))
+	assert.Equal(t, "run-1", res.Metadata["runID"])
+	c.AssertExpectations(t)
+
+	t.Run("default task queue", func(t *testing.T) {
+		c := &mocks.Client{}
+		c.On("ExecuteWorkflow", mock.Anything, client.StartWorkflowOptions{TaskQueue: "default-queue"}, "ProcessOrder").
+			Return(run, nil)
+		_, err := newTestTemporal(c).Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: StartOperation,
+			Metadata:  map[string]string{"workflowType": "ProcessOrder"},
+		})
+		require.NoError(t, err)
+		c.AssertExpectations(t)
+	})
+
+	t.Run("missing workflow type", func(t *testing.T) {
+		_, err := tb.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: StartOperation,
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		_, err := tb.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: StartOperation,
+			Data:      []byte("not json"),
+			Metadata:  map[string]string{"workflowType": "ProcessOrder"},
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestSignal(t *testing.T) {
+	c := &mocks.Client{}
+	c.On("SignalWorkflow", mock.Anything, "order-1", "", "approve", "yes").Return(nil)
+	c.On("SignalWorkflow", mock.Anything, "order-2", "run-2", "approve", nil).Return(errors.New("not found"))
+	tb := newTestTemporal(c)
+
+	_, err := tb.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: SignalOperation,
+		Data:      []byte(`"yes"`),
+		Metadata:  map[string]string{"workflowID": "order-1", "signalName": "approve"},
+	})
+	require.NoError(t, err)
+
+	_, err = tb.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: SignalOperation,
+		Metadata:  map[string]string{"workflowID": "order-2", "runID": "run-2", "signalName": "approve"},
+	})
+	require.ErrorContains(t, err, "not found")
+	c.AssertExpectations(t)
+
+	_, err = tb.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: SignalOperation,
+		Metadata:  map[string]string{"workflowID": "order-1"},
+	})
+	require.Error(t, err)
+}
+
+func TestQuery(t *testing.T) {
+	value := &mocks.Value{}
+	value.On("HasValue").Return(true)
+	value.On("Get", mock.Anything).Run(func(args mock.Arguments) {
+		*(args.Get(0).(*interface{})) = map[string]interface{}{"status": "shipped"}
+	}).Return(nil)
+	c := &mocks.Client{}
+	c.On("QueryWorkflow", mock.Anything, "order-1", "", "status").Return(value, nil)
+	tb := newTestTemporal(c)
+
+	res, err := tb.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: QueryOperation,
+		Metadata:  map[string]string{"workflowID": "order-1", "queryType": "status"},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status": "shipped"}`, string(res.Data))
+	c.AssertExpectations(t)
+
+	_, err = tb.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: QueryOperation,
+		Metadata:  map[string]string{"queryType": "status"},
+	})
+	require.Error(t, err)
+}
+
+func TestUnsupportedOperation(t *testing.T) {
+	_, err := newTestTemporal(&mocks.Client{}).Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: bindings.DeleteOperation,
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package twitter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dapr/components-contrib/bindings"
+)
+
+const (
+	// The API sends a heartbeat every 20 seconds: the connection is stalled if nothing is received for longer.
+	streamStallTimeout = 30 * time.Second
+	// Maximum size of a tweet in the stream, with its expansions.
+	maxStreamLineSize = 1 << 20
+
+	// Initial intervals before reconnecting to the stream, which are doubled after each failed attempt.
+	networkErrorBackoff = time.Second
+	httpErrorBackoff    = 5 * time.Second
+	rateLimitBackoff    = time.Minute
+)
+
+// streamTweet is a tweet delivered by the filtered stream.
+type streamTweet struct {
+	Data *struct {
+		ID string `json:"id"`
+	} `json:"data"`
+	MatchingRules []struct {
+		ID  string `json:"id"`
+		Tag string `json:"tag"`
+	} `json:"matching_rules"`
+	Errors []json.RawMessage `json:"errors"`
+}
+
+// Read connects to the filtered stream and delivers its tweets to the handler, reconnecting when the connection is lost.
+func (t *Twitter) Read(ctx context.Context, handler bindings.Handler) error {
+	if t.closed.Load() {
+		return errors.New("binding is closed")
+	}
+	if t.streamClient == nil {
+		return ErrMissingAppCredentials
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	t.wg.Add(2)
+	go func() {
+		defer t.wg.Done()
+		select {
+		case <-t.closeCh:
+		case <-ctx.Done():
+		}
+		cancel()
+	}()
+	go func() {
+		defer t.wg.Done()
+		t.streamLoop(ctx, handler)
+	}()
+
+	return nil
+}
+
+// streamLoop consumes the stream until the context is canceled, backing off between reconnections as required by the API.
+func (t *Twitter) streamLoop(ctx context.Context, handler bindings.Handler) {
+	var wait, lastBackoff time.Duration
+	for {
+		connected, err := t.consumeStream(ctx, handler)
+		if ctx.Err() != nil {
+			return
+		}
+
+		var initial time.Duration
+		var apiErr *apiError
+		switch {
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests:
+			initial = rateLimitBackoff
+		case errors.As(err, &apiErr):
+			initial = httpErrorBackoff
+		default:
+			initial = networkErrorBackoff
+		}
+		if connected || lastBackoff != initial {
+			// Backoff restarts after a successful connection, or when the kind of error changes
+			wait = initial
+			lastBackoff = initial
+		} else {
+			wait *= 2
+			if wait > t.metadata.MaxReconnectInterval {
+				wait = t.metadata.MaxReconnectInterval
+			}
+		}
+
+		t.logger.Warnf("Disconnected from the filtered stream: %v. Reconnecting in %v", err, wait)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// consumeStream connects to the stream and delivers its tweets until the connection is lost.
+// It returns whether the connection succeeded, and the error that ended it.
+func (t *Twitter) consumeStream(ctx context.Context, handler bindings.Handler) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.metadata.Endpoint+streamPath+t.streamQuery(), nil)
+	if err != nil {
+		return false, err
+	}
+	res, err := t.streamClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+		return false, &apiError{StatusCode: res.StatusCode, Body: strings.TrimSpace(string(msg))}
+	}
+	t.logger.Info("Connected to the filtered stream")
+
+	// Cancel the request if the stream stalls
+	var stalled atomic.Bool
+	stall := time.AfterFunc(streamStallTimeout, func() {
+		stalled.Store(true)
+		cancel()
+	})
+	defer stall.Stop()
+
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+	for scanner.Scan() {
+		stall.Reset(streamStallTimeout)
+
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			// Heartbeat
+			continue
+		}
+		t.deliverTweet(ctx, handler, bytes.Clone(line))
+	}
+
+	err = scanner.Err()
+	switch {
+	case stalled.Load():
+		err = errors.New("the stream stalled")
+	case err == nil:
+		err = io.EOF
+	}
+	return true, err
+}
+
+// deliverTweet delivers a message of the stream to the handler.
+func (t *Twitter) deliverTweet(ctx context.Context, handler bindings.Handler, data []byte) {
+	var tweet streamTweet
+	err := json.Unmarshal(data, &tweet)
+	if err != nil {
+		t.logger.Errorf("Error parsing message of the filtered stream: %v", err)
+		return
+	}
+	if tweet.Data == nil {
+		// Messages without a tweet report errors, such as disconnections
+		if len(tweet.Errors) > 0 {
+			t.logger.Warnf("Error message from the filtered stream: %s", string(data))
+		}
+		return
+	}
+
+	ruleIDs := make([]string, len(tweet.MatchingRules))
+	tags := make([]string, 0, len(tweet.MatchingRules))
+	for i, r := range tweet.MatchingRules {
+		ruleIDs[i] = r.ID
+		if r.Tag != "" {
+			tags = append(tags, r.Tag)
+		}
+	}
+
+	_, err = handler(ctx, &bindings.ReadResponse{
+		Data: data,
+		Metadata: map[string]string{
+			"id":               tweet.Data.ID,
+			"matchingRuleIds":  strings.Join(ruleIDs, ","),
+			"matchingRuleTags": strings.Join(tags, ","),
+		},
+	})
+	if err != nil {
+		t.logger.Errorf("Error handling tweet %s: %v", tweet.Data.ID, err)
+	}
+}
+
+// streamQuery returns the query string with the fields and expansions of the tweets of the stream.
+func (t *Twitter) streamQuery() string {
+	q := url.Values{}
+	if t.metadata.TweetFields != "" {
+		q.Set("tweet.fields", t.metadata.TweetFields)
+	}
+	if t.metadata.Expansions != "" {
+		q.Set("expansions", t.metadata.Expansions)
+	}
+	if t.metadata.UserFields != "" {
+		q.Set("user.fields", t.metadata.UserFields)
+	}
+	if len(q) == 0 {
+		return ""
+	}
+	return "?" + q.Encode()
+}
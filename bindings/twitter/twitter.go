@@ -0,0 +1,415 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package twitter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// PostOperation posts a tweet, on behalf of the user of the user context.
+	PostOperation bindings.OperationKind = "post"
+	// GetRulesOperation returns the rules of the filtered stream.
+	GetRulesOperation bindings.OperationKind = "getRules"
+	// AddRulesOperation adds rules to the filtered stream.
+	AddRulesOperation bindings.OperationKind = "addRules"
+	// DeleteRulesOperation deletes rules of the filtered stream, by ID or by value.
+	DeleteRulesOperation bindings.OperationKind = "deleteRules"
+
+	// Key of the request metadata that validates changes to the rules without applying them.
+	dryRunKey = "dryRun"
+
+	defaultEndpoint = "https://api.twitter.com"
+
+	tweetsPath       = "/2/tweets"
+	streamPath       = "/2/tweets/search/stream"
+	streamRulesPath  = "/2/tweets/search/stream/rules"
+	appTokenPath     = "/oauth2/token"
+	userTokenPath    = "/2/oauth2/token"
+	maxErrorBodySize = 1024
+)
+
+var (
+	ErrMissingAppCredentials  = errors.New("the filtered stream and its rules require app-only authentication: set bearerToken, or consumerKey and consumerSecret")
+	ErrMissingUserCredentials = errors.New("posting tweets requires user context authentication: set userAccessToken")
+	ErrMissingCredentials     = errors.New("either app-only or user context credentials are required")
+)
+
+// Twitter is a binding for the X (Twitter) API v2.
+// As an input binding, it delivers the tweets of the filtered stream; as an output binding, it posts tweets and manages the rules of the filtered stream.
+type Twitter struct {
+	metadata *twitterMetadata
+	logger   logger.Logger
+
+	// appClient authenticates with app-only credentials, and userClient with the user context; they're nil if the credentials aren't set.
+	appClient  *http.Client
+	userClient *http.Client
+	// streamClient is appClient without a timeout, as the stream is a long-lived request.
+	streamClient *http.Client
+
+	closed  atomic.Bool
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+type twitterMetadata struct {
+	// Bearer token for app-only authentication.
+	BearerToken string `mapstructure:"bearerToken"`
+	// API key and secret of the app, exchanged for a bearer token for app-only authentication.
+	ConsumerKey    string `mapstructure:"consumerKey"`
+	ConsumerSecret string `mapstructure:"consumerSecret"`
+
+	// OAuth 2.0 access token of a user, for user context authentication.
+	UserAccessToken string `mapstructure:"userAccessToken"`
+	// Refresh token of the user, used with the client ID and secret of the app to refresh the access token when it expires.
+	UserRefreshToken string `mapstructure:"userRefreshToken"`
+	ClientID         string `mapstructure:"clientId"`
+	ClientSecret     string `mapstructure:"clientSecret"`
+
+	// Comma-separated fields of the tweets of the stream, such as "created_at,author_id".
+	TweetFields string `mapstructure:"tweetFields"`
+	// Comma-separated expansions of the tweets of the stream, such as "author_id".
+	Expansions string `mapstructure:"expansions"`
+	// Comma-separated fields of the users included with the expansions.
+	UserFields string `mapstructure:"userFields"`
+	// Maximum time to wait before reconnecting to the stream after an error.
+	MaxReconnectInterval time.Duration `mapstructure:"maxReconnectInterval"`
+	// Timeout of the requests other than the stream.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Base URL of the API; used in tests.
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+// NewTwitter returns a new X (Twitter) binding.
+func NewTwitter(logger logger.Logger) bindings.InputOutputBinding {
+	return &Twitter{
+		logger:  logger,
+		closeCh: make(chan struct{}),
+	}
+}
+
+// Init does metadata parsing and creates the HTTP clients for the configured credentials.
+func (t *Twitter) Init(ctx context.Context, meta bindings.Metadata) error {
+	m, err := parseMetadata(meta)
+	if err != nil {
+		return err
+	}
+	t.metadata = m
+
+	// The token sources refresh tokens with this client, outliving the init context
+	baseClient := &http.Client{Timeout: m.Timeout}
+	tokenCtx := context.WithValue(context.Background(), oauth2.HTTPClient, baseClient)
+
+	var appTokens oauth2.TokenSource
+	switch {
+	case m.BearerToken != "":
+		appTokens = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: m.BearerToken})
+	case m.ConsumerKey != "":
+		cfg := clientcredentials.Config{
+			ClientID:     m.ConsumerKey,
+			ClientSecret: m.ConsumerSecret,
+			TokenURL:     m.Endpoint + appTokenPath,
+			AuthStyle:    oauth2.AuthStyleInHeader,
+		}
+		appTokens = cfg.TokenSource(tokenCtx)
+	}
+	if appTokens != nil {
+		t.appClient = &http.Client{
+			Timeout:   m.Timeout,
+			Transport: &oauth2.Transport{Source: appTokens, Base: http.DefaultTransport},
+		}
+		t.streamClient = &http.Client{
+			Transport: &oauth2.Transport{Source: appTokens, Base: http.DefaultTransport},
+		}
+	}
+
+	if m.UserAccessToken != "" {
+		token := &oauth2.Token{
+			AccessToken:  m.UserAccessToken,
+			RefreshToken: m.UserRefreshToken,
+		}
+		var userTokens oauth2.TokenSource
+		if m.UserRefreshToken != "" {
+			// The expiration of the access token is unknown, so it's refreshed before the first request
+			token.Expiry = time.Now()
+			cfg := oauth2.Config{
+				ClientID:     m.ClientID,
+				ClientSecret: m.ClientSecret,
+				Endpoint: oauth2.Endpoint{
+					TokenURL:  m.Endpoint + userTokenPath,
+					AuthStyle: oauth2.AuthStyleInHeader,
+				},
+			}
+			userTokens = cfg.TokenSource(tokenCtx, token)
+		} else {
+			userTokens = oauth2.StaticTokenSource(token)
+		}
+		t.userClient = &http.Client{
+			Timeout:   m.Timeout,
+			Transport: &oauth2.Transport{Source: userTokens, Base: http.DefaultTransport},
+		}
+	}
+
+	return nil
+}
+
+func parseMetadata(meta bindings.Metadata) (*twitterMetadata, error) {
+	m := twitterMetadata{
+		Endpoint:             defaultEndpoint,
+		MaxReconnectInterval: 5 * time.Minute,
+		Timeout:              30 * time.Second,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Endpoint = strings.TrimSuffix(m.Endpoint, "/")
+	if (m.ConsumerKey == "") != (m.ConsumerSecret == "") {
+		return nil, errors.New("consumerKey and consumerSecret must be set together")
+	}
+	if m.UserRefreshToken != "" && m.ClientID == "" {
+		return nil, errors.New("clientId is required to refresh the user access token")
+	}
+	if m.UserRefreshToken != "" && m.UserAccessToken == "" {
+		return nil, errors.New("userAccessToken is required with userRefreshToken")
+	}
+	if m.BearerToken == "" && m.ConsumerKey == "" && m.UserAccessToken == "" {
+		return nil, ErrMissingCredentials
+	}
+	if m.MaxReconnectInterval <= 0 {
+		return nil, errors.New("maxReconnectInterval must be positive")
+	}
+	if m.Timeout <= 0 {
+		return nil, errors.New("timeout must be positive")
+	}
+
+	return &m, nil
+}
+
+// Operations returns the operations supported by the binding.
+func (t *Twitter) Operations() []bindings.OperationKind {
+	return []bindings.OperationKind{
+		PostOperation,
+		GetRulesOperation,
+		AddRulesOperation,
+		DeleteRulesOperation,
+	}
+}
+
+// Invoke posts a tweet or manages the rules of the filtered stream.
+func (t *Twitter) Invoke(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	switch req.Operation {
+	case PostOperation:
+		return t.post(ctx, req)
+	case GetRulesOperation:
+		return t.getRules(ctx)
+	case AddRulesOperation:
+		return t.changeRules(ctx, req, "add")
+	case DeleteRulesOperation:
+		return t.changeRules(ctx, req, "delete")
+	default:
+		return nil, fmt.Errorf("unsupported operation %s", req.Operation)
+	}
+}
+
+// post posts a tweet.
+// The data is the body of the request, such as {"text": "Hello", "reply": {"in_reply_to_tweet_id": "123"}}; data that isn't a JSON object is the text of the tweet.
+func (t *Twitter) post(ctx context.Context, req *bindings.InvokeRequest) (*bindings.InvokeResponse, error) {
+	if t.userClient == nil {
+		return nil, ErrMissingUserCredentials
+	}
+
+	body := bytes.TrimSpace(req.Data)
+	if len(body) == 0 {
+		return nil, errors.New("the text of the tweet is required")
+	}
+	if body[0] != '{' || !json.Valid(body) {
+		var err error
+		body, err = json.Marshal(map[string]string{"text": string(req.Data)})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := t.do(ctx, t.userClient, http.MethodPost, tweetsPath, body)
+	if err != nil {
+		return nil, fmt.Errorf("error posting tweet: %w", err)
+	}
+
+	var created struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	err = json.Unmarshal(res, &created)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the response of the API: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: res,
+		Metadata: map[string]string{
+			"id": created.Data.ID,
+		},
+	}, nil
+}
+
+func (t *Twitter) getRules(ctx context.Context) (*bindings.InvokeResponse, error) {
+	if t.appClient == nil {
+		return nil, ErrMissingAppCredentials
+	}
+
+	res, err := t.do(ctx, t.appClient, http.MethodGet, streamRulesPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting stream rules: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: res,
+	}, nil
+}
+
+// changeRules adds or deletes rules of the filtered stream.
+// To add rules, the data is {"rules": [{"value": "cat has:images", "tag": "cats"}]}; to delete them, it's {"ids": ["123"]} or {"values": ["cat has:images"]}.
+func (t *Twitter) changeRules(ctx context.Context, req *bindings.InvokeRequest, action string) (*bindings.InvokeResponse, error) {
+	if t.appClient == nil {
+		return nil, ErrMissingAppCredentials
+	}
+
+	var body []byte
+	var err error
+	if action == "add" {
+		var payload struct {
+			Rules []json.RawMessage `json:"rules"`
+		}
+		err = json.Unmarshal(req.Data, &payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload of operation %s: %w", req.Operation, err)
+		}
+		if len(payload.Rules) == 0 {
+			return nil, errors.New("rules are required")
+		}
+		body, err = json.Marshal(map[string]any{"add": payload.Rules})
+	} else {
+		var payload struct {
+			IDs    []string `json:"ids,omitempty"`
+			Values []string `json:"values,omitempty"`
+		}
+		err = json.Unmarshal(req.Data, &payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload of operation %s: %w", req.Operation, err)
+		}
+		if len(payload.IDs) == 0 && len(payload.Values) == 0 {
+			return nil, errors.New("ids or values of the rules are required")
+		}
+		body, err = json.Marshal(map[string]any{"delete": payload})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	path := streamRulesPath
+	dryRun, err := req.GetMetadataAsBool(dryRunKey)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		path += "?dry_run=true"
+	}
+
+	res, err := t.do(ctx, t.appClient, http.MethodPost, path, body)
+	if err != nil {
+		return nil, fmt.Errorf("error changing stream rules: %w", err)
+	}
+
+	return &bindings.InvokeResponse{
+		Data: res,
+	}, nil
+}
+
+// apiError is an error response of the API.
+type apiError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Body)
+}
+
+// do sends a request to the API and returns the body of the response.
+// The API returns some errors with a success status, such as invalid rules, in the "errors" field: they're returned along with the response.
+func (t *Twitter) do(ctx context.Context, client *http.Client, method string, path string, body []byte) ([]byte, error) {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, t.metadata.Endpoint+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+		return nil, &apiError{StatusCode: res.StatusCode, Body: strings.TrimSpace(string(msg))}
+	}
+
+	return io.ReadAll(res.Body)
+}
+
+// Close stops the stream.
+func (t *Twitter) Close() error {
+	if t.closed.CompareAndSwap(false, true) {
+		close(t.closeCh)
+	}
+	t.wg.Wait()
+	return nil
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (t *Twitter) GetComponentMetadata() map[string]string {
+	metadataStruct := twitterMetadata{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.BindingType)
+	return metadataInfo
+}
@@ -0,0 +1,290 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+func newTestTwitter(t *testing.T, endpoint string, props map[string]string) *Twitter {
+	t.Helper()
+
+	props["endpoint"] = endpoint
+	tw := NewTwitter(logger.NewLogger("test")).(*Twitter)
+	err := tw.Init(context.Background(), bindings.Metadata{Base: metadata.Base{Properties: props}})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		tw.Close()
+	})
+	return tw
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"bearerToken": "token",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, defaultEndpoint, m.Endpoint)
+		assert.Equal(t, 5*time.Minute, m.MaxReconnectInterval)
+		assert.Equal(t, 30*time.Second, m.Timeout)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, props := range []map[string]string{
+			{},
+			{"consumerKey": "key"},
+			{"userAccessToken": "token", "userRefreshToken": "refresh"},
+			{"userRefreshToken": "refresh", "clientId": "client"},
+			{"bearerToken": "token", "timeout": "0"},
+		} {
+			_, err := parseMetadata(bindings.Metadata{Base: metadata.Base{Properties: props}})
+			assert.Error(t, err, props)
+		}
+	})
+}
+
+func TestPost(t *testing.T) {
+	var (
+		authorization string
+		body          map[string]any
+		refreshes     int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case userTokenPath:
+			refreshes++
+			user, pass, _ := r.BasicAuth()
+			assert.Equal(t, "client", user)
+			assert.Equal(t, "secret", pass)
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "refresh_token", r.Form.Get("grant_type"))
+			assert.Equal(t, "refresh", r.Form.Get("refresh_token"))
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"refreshed","refresh_token":"refresh2","token_type":"bearer","expires_in":7200}`))
+		case tweetsPath:
+			assert.Equal(t, http.MethodPost, r.Method)
+			authorization = r.Header.Get("Authorization")
+			body = nil
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"data":{"id":"1445880548472328192","text":"Hello"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("static user token", func(t *testing.T) {
+		tw := newTestTwitter(t, server.URL, map[string]string{"userAccessToken": "user"})
+
+		res, err := tw.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: PostOperation,
+			Data:      []byte("Hello"),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer user", authorization)
+		assert.Equal(t, map[string]any{"text": "Hello"}, body)
+		assert.Equal(t, "1445880548472328192", res.Metadata["id"])
+
+		_, err = tw.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: PostOperation,
+			Data:      []byte(`{"text":"Hello","reply":{"in_reply_to_tweet_id":"123"}}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"text": "Hello", "reply": map[string]any{"in_reply_to_tweet_id": "123"}}, body)
+
+		_, err = tw.Invoke(context.Background(), &bindings.InvokeRequest{Operation: PostOperation})
+		assert.Error(t, err)
+	})
+
+	t.Run("refreshed user token", func(t *testing.T) {
+		tw := newTestTwitter(t, server.URL, map[string]string{
+			"userAccessToken":  "user",
+			"userRefreshToken": "refresh",
+			"clientId":         "client",
+			"clientSecret":     "secret",
+		})
+
+		for i := 0; i < 2; i++ {
+			_, err := tw.Invoke(context.Background(), &bindings.InvokeRequest{
+				Operation: PostOperation,
+				Data:      []byte("Hello"),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, "Bearer refreshed", authorization)
+		}
+		assert.Equal(t, 1, refreshes)
+	})
+
+	t.Run("app-only credentials", func(t *testing.T) {
+		tw := newTestTwitter(t, server.URL, map[string]string{"bearerToken": "app"})
+		_, err := tw.Invoke(context.Background(), &bindings.InvokeRequest{
+			Operation: PostOperation,
+			Data:      []byte("Hello"),
+		})
+		assert.ErrorIs(t, err, ErrMissingUserCredentials)
+	})
+}
+
+func TestRules(t *testing.T) {
+	var (
+		authorization string
+		query         string
+		body          map[string]any
+		tokens        int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case appTokenPath:
+			tokens++
+			user, pass, _ := r.BasicAuth()
+			assert.Equal(t, "key", user)
+			assert.Equal(t, "secret", pass)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"token_type":"bearer","access_token":"app"}`))
+		case streamRulesPath:
+			authorization = r.Header.Get("Authorization")
+			query = r.URL.RawQuery
+			body = nil
+			if r.Method == http.MethodPost {
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			}
+			w.Write([]byte(`{"data":[{"id":"1","value":"cat has:images","tag":"cats"}],"meta":{"result_count":1}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tw := newTestTwitter(t, server.URL, map[string]string{
+		"consumerKey":    "key",
+		"consumerSecret": "secret",
+	})
+
+	res, err := tw.Invoke(context.Background(), &bindings.InvokeRequest{Operation: GetRulesOperation})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer app", authorization)
+	assert.JSONEq(t, `{"data":[{"id":"1","value":"cat has:images","tag":"cats"}],"meta":{"result_count":1}}`, string(res.Data))
+
+	_, err = tw.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: AddRulesOperation,
+		Data:      []byte(`{"rules":[{"value":"cat has:images","tag":"cats"}]}`),
+		Metadata:  map[string]string{"dryRun": "true"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "dry_run=true", query)
+	assert.Equal(t, map[string]any{"add": []any{map[string]any{"value": "cat has:images", "tag": "cats"}}}, body)
+
+	_, err = tw.Invoke(context.Background(), &bindings.InvokeRequest{
+		Operation: DeleteRulesOperation,
+		Data:      []byte(`{"ids":["1"]}`),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, query)
+	assert.Equal(t, map[string]any{"delete": map[string]any{"ids": []any{"1"}}}, body)
+	assert.Equal(t, 1, tokens)
+
+	for op, data := range map[bindings.OperationKind]string{
+		AddRulesOperation:    `{"rules":[]}`,
+		DeleteRulesOperation: `{}`,
+	} {
+		_, err = tw.Invoke(context.Background(), &bindings.InvokeRequest{Operation: op, Data: []byte(data)})
+		assert.Error(t, err, op)
+	}
+}
+
+func TestStream(t *testing.T) {
+	var (
+		lock        sync.Mutex
+		connections int
+		query       string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, streamPath, r.URL.Path)
+		assert.Equal(t, "Bearer app", r.Header.Get("Authorization"))
+
+		lock.Lock()
+		connections++
+		n := connections
+		query = r.URL.RawQuery
+		lock.Unlock()
+
+		if n == 1 {
+			// The first connection is rejected
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, "\r\n")
+		io.WriteString(w, `{"data":{"id":"1","text":"cat"},"matching_rules":[{"id":"10","tag":"cats"},{"id":"11"}]}`+"\r\n")
+		io.WriteString(w, `{"errors":[{"title":"operational-disconnect"}]}`+"\r\n")
+		io.WriteString(w, `{"data":{"id":"2","text":"dog"},"matching_rules":[{"id":"12","tag":"dogs"}]}`+"\r\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	tw := newTestTwitter(t, server.URL, map[string]string{
+		"bearerToken": "app",
+		"tweetFields": "created_at,author_id",
+	})
+
+	received := make(chan *bindings.ReadResponse, 10)
+	err := tw.Read(context.Background(), func(ctx context.Context, msg *bindings.ReadResponse) ([]byte, error) {
+		received <- msg
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	var msgs []*bindings.ReadResponse
+	for len(msgs) < 2 {
+		select {
+		case msg := <-received:
+			msgs = append(msgs, msg)
+		case <-time.After(15 * time.Second):
+			t.Fatal("tweets not received")
+		}
+	}
+
+	assert.Equal(t, map[string]string{
+		"id":               "1",
+		"matchingRuleIds":  "10,11",
+		"matchingRuleTags": "cats",
+	}, msgs[0].Metadata)
+	assert.JSONEq(t, `{"data":{"id":"1","text":"cat"},"matching_rules":[{"id":"10","tag":"cats"},{"id":"11"}]}`, string(msgs[0].Data))
+	assert.Equal(t, "2", msgs[1].Metadata["id"])
+
+	lock.Lock()
+	assert.Equal(t, 2, connections)
+	assert.Equal(t, "tweet.fields=created_at%2Cauthor_id", query)
+	lock.Unlock()
+
+	require.NoError(t, tw.Close())
+}
@@ -34,7 +34,15 @@ import (
 	"github.com/dapr/kit/logger"
 )
 
-var ErrMissingJobType = errors.New("jobType is a required attribute")
+var (
+	ErrMissingJobType         = errors.New("jobType is a required attribute")
+	ErrInvalidMaxJobsActive   = errors.New("maxJobsActive must not be negative")
+	ErrInvalidConcurrency     = errors.New("concurrency must not be negative")
+	ErrInvalidPollThreshold   = errors.New("pollThreshold must be between 0 and 1")
+	ErrInvalidRetryBackOff    = errors.New("retryBackOff must not be negative")
+	ErrRetryBackOffNotAllowed = errors.New("retryBackOff requires autocomplete to be enabled")
+	ErrInvalidStreamTimeout   = errors.New("streamRequestTimeout must not be negative")
+)
 
 // ZeebeJobWorker allows handling jobs from the Zeebe command engine.
 type ZeebeJobWorker struct {
@@ -59,6 +67,12 @@ type jobWorkerMetadata struct {
 	PollThreshold  float64           `mapstructure:"pollThreshold"`
 	FetchVariables string            `mapstructure:"fetchVariables"`
 	Autocomplete   *bool             `mapstructure:"autocomplete"`
+	RetryBackOff   metadata.Duration `mapstructure:"retryBackOff"`
+	// Comma-separated list of the tenants whose jobs are activated; if empty, the jobs of the default tenant are activated.
+	TenantIds string `mapstructure:"tenantIds"`
+	// If true, jobs are pushed by the gateway as soon as they are available, in addition to polling.
+	StreamEnabled        bool              `mapstructure:"streamEnabled"`
+	StreamRequestTimeout metadata.Duration `mapstructure:"streamRequestTimeout"`
 }
 
 type jobHandler struct {
@@ -66,6 +80,7 @@ type jobHandler struct {
 	logger       logger.Logger
 	ctx          context.Context
 	autocomplete bool
+	retryBackOff time.Duration
 }
 
 // NewZeebeJobWorker returns a new ZeebeJobWorker instance.
@@ -88,6 +103,11 @@ func (z *ZeebeJobWorker) Init(ctx context.Context, metadata bindings.Metadata) e
 		return ErrMissingJobType
 	}
 
+	err = meta.validate()
+	if err != nil {
+		return err
+	}
+
 	client, err := z.clientFactory.Get(metadata)
 	if err != nil {
 		return err
@@ -109,6 +129,7 @@ func (z *ZeebeJobWorker) Read(ctx context.Context, handler bindings.Handler) err
 		logger:       z.logger,
 		ctx:          ctx,
 		autocomplete: z.metadata.Autocomplete == nil || *z.metadata.Autocomplete,
+		retryBackOff: z.metadata.RetryBackOff.Duration,
 	}
 
 	jobWorker := z.getJobWorker(h)
@@ -147,7 +168,44 @@ func (z *ZeebeJobWorker) parseMetadata(meta bindings.Metadata) (*jobWorkerMetada
 	return &m, nil
 }
 
+// validate checks the settings that control how many jobs are activated and how failed jobs are retried.
+func (m *jobWorkerMetadata) validate() error {
+	if m.MaxJobsActive < 0 {
+		return ErrInvalidMaxJobsActive
+	}
+	if m.Concurrency < 0 {
+		return ErrInvalidConcurrency
+	}
+	if m.PollThreshold < 0 || m.PollThreshold > 1 {
+		return ErrInvalidPollThreshold
+	}
+	if m.RetryBackOff.Duration < 0 {
+		return ErrInvalidRetryBackOff
+	}
+	if m.RetryBackOff.Duration > 0 && m.Autocomplete != nil && !*m.Autocomplete {
+		// When autocomplete is disabled jobs are failed by the app, which sets its own back-off
+		return ErrRetryBackOffNotAllowed
+	}
+	if m.StreamRequestTimeout.Duration < 0 {
+		return ErrInvalidStreamTimeout
+	}
+	return nil
+}
+
+// tenantIds returns the tenants whose jobs are activated.
+func (m *jobWorkerMetadata) tenantIds() []string {
+	if m.TenantIds == "" {
+		return nil
+	}
+	return zeebe.VariableStringToArray(m.TenantIds)
+}
+
 func (z *ZeebeJobWorker) getJobWorker(handler jobHandler) worker.JobWorker {
+	if tenantIds := z.metadata.tenantIds(); len(tenantIds) > 0 {
+		// The job worker of the Zeebe client can't activate the jobs of specific tenants
+		return newTenantJobWorker(z.client, z.metadata, tenantIds, handler.handleJob, z.logger)
+	}
+
 	cmd1 := z.client.NewJobWorker()
 	cmd2 := cmd1.JobType(z.metadata.JobType)
 	cmd3 := cmd2.Handler(handler.handleJob)
@@ -175,6 +233,12 @@ func (z *ZeebeJobWorker) getJobWorker(handler jobHandler) worker.JobWorker {
 	if z.metadata.FetchVariables != "" {
 		cmd3 = cmd3.FetchVariables(zeebe.VariableStringToArray(z.metadata.FetchVariables)...)
 	}
+	if z.metadata.StreamEnabled {
+		cmd3 = cmd3.StreamEnabled(true)
+		if z.metadata.StreamRequestTimeout.Duration != time.Duration(0) {
+			cmd3 = cmd3.StreamRequestTimeout(z.metadata.StreamRequestTimeout.Duration)
+		}
+	}
 
 	return cmd3.Open()
 }
@@ -247,7 +311,12 @@ func (h *jobHandler) failJob(ctx context.Context, client worker.JobClient, job e
 	reasonMsg := reason.Error()
 	h.logger.Errorf("Failed to complete job `%d` reason: %s", job.GetKey(), reasonMsg)
 
-	_, err := client.NewFailJobCommand().JobKey(job.GetKey()).Retries(job.Retries - 1).ErrorMessage(reasonMsg).Send(ctx)
+	cmd := client.NewFailJobCommand().JobKey(job.GetKey()).Retries(job.Retries - 1).ErrorMessage(reasonMsg)
+	if h.retryBackOff > 0 {
+		// Delay the next activation of the job, so a failing app is not flooded with retries
+		cmd = cmd.RetryBackoff(h.retryBackOff)
+	}
+	_, err := cmd.Send(ctx)
 	if err != nil {
 		h.logger.Errorf("Cannot fail job `%d` of type `%s`; got error: %s", job.GetKey(), job.Type, err.Error())
 
@@ -17,6 +17,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/camunda/zeebe/clients/go/v8/pkg/zbc"
 	"github.com/stretchr/testify/assert"
@@ -115,3 +116,36 @@ func TestInit(t *testing.T) {
 		assert.NoError(t, jobWorker.Close())
 	})
 }
+
+func TestValidateMetadata(t *testing.T) {
+	testLogger := logger.NewLogger("test")
+
+	testCases := []struct {
+		name       string
+		properties map[string]string
+		expected   error
+	}{
+		{"valid", map[string]string{"jobType": "a", "maxJobsActive": "10", "concurrency": "2", "pollThreshold": "0.5", "retryBackOff": "5s"}, nil},
+		{"negative maxJobsActive", map[string]string{"jobType": "a", "maxJobsActive": "-1"}, ErrInvalidMaxJobsActive},
+		{"negative concurrency", map[string]string{"jobType": "a", "concurrency": "-1"}, ErrInvalidConcurrency},
+		{"pollThreshold too high", map[string]string{"jobType": "a", "pollThreshold": "1.5"}, ErrInvalidPollThreshold},
+		{"negative retryBackOff", map[string]string{"jobType": "a", "retryBackOff": "-5s"}, ErrInvalidRetryBackOff},
+		{"retryBackOff without autocomplete", map[string]string{"jobType": "a", "retryBackOff": "5s", "autocomplete": "false"}, ErrRetryBackOffNotAllowed},
+		{"negative streamRequestTimeout", map[string]string{"jobType": "a", "streamEnabled": "true", "streamRequestTimeout": "-1s"}, ErrInvalidStreamTimeout},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			md := bindings.Metadata{Base: metadata.Base{Properties: tc.properties}}
+			jobWorker := ZeebeJobWorker{clientFactory: &mockClientFactory{}, logger: testLogger, closeCh: make(chan struct{})}
+			err := jobWorker.Init(context.Background(), md)
+			if tc.expected == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, 5*time.Second, jobWorker.metadata.RetryBackOff.Duration)
+			} else {
+				assert.ErrorIs(t, err, tc.expected)
+			}
+			assert.NoError(t, jobWorker.Close())
+		})
+	}
+}
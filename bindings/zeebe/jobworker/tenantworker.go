@@ -0,0 +1,216 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobworker
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/camunda/zeebe/clients/go/v8/pkg/commands"
+	"github.com/camunda/zeebe/clients/go/v8/pkg/entities"
+	"github.com/camunda/zeebe/clients/go/v8/pkg/worker"
+	"github.com/camunda/zeebe/clients/go/v8/pkg/zbc"
+
+	"github.com/dapr/components-contrib/bindings/zeebe"
+	"github.com/dapr/kit/logger"
+)
+
+// tenantJobWorker activates the jobs of specific tenants with the commands of the Zeebe client.
+// It polls for jobs like the job worker of the client, and optionally opens a job stream.
+type tenantJobWorker struct {
+	client    zbc.Client
+	handler   worker.JobHandler
+	logger    logger.Logger
+	tenantIds []string
+
+	jobType              string
+	workerName           string
+	timeout              time.Duration
+	requestTimeout       time.Duration
+	fetchVariables       []string
+	maxJobsActive        int
+	concurrency          int
+	pollInterval         time.Duration
+	streamEnabled        bool
+	streamRequestTimeout time.Duration
+
+	jobs   chan entities.Job
+	active atomic.Int64
+	cancel context.CancelFunc
+	// Wait groups of the goroutines that activate jobs and of the ones that handle them
+	activators sync.WaitGroup
+	handlers   sync.WaitGroup
+}
+
+func newTenantJobWorker(client zbc.Client, meta *jobWorkerMetadata, tenantIds []string, handler worker.JobHandler, logger logger.Logger) *tenantJobWorker {
+	w := &tenantJobWorker{
+		client:               client,
+		handler:              handler,
+		logger:               logger,
+		tenantIds:            tenantIds,
+		jobType:              meta.JobType,
+		workerName:           meta.WorkerName,
+		timeout:              meta.WorkerTimeout.Duration,
+		requestTimeout:       meta.RequestTimeout.Duration,
+		maxJobsActive:        meta.MaxJobsActive,
+		concurrency:          meta.Concurrency,
+		pollInterval:         meta.PollInterval.Duration,
+		streamEnabled:        meta.StreamEnabled,
+		streamRequestTimeout: meta.StreamRequestTimeout.Duration,
+	}
+	if meta.FetchVariables != "" {
+		w.fetchVariables = zeebe.VariableStringToArray(meta.FetchVariables)
+	}
+	if w.workerName == "" {
+		w.workerName = commands.DefaultJobWorkerName
+	}
+	if w.timeout == 0 {
+		w.timeout = commands.DefaultJobTimeout
+	}
+	if w.requestTimeout == 0 {
+		w.requestTimeout = worker.DefaultRequestTimeout
+	}
+	if w.maxJobsActive == 0 {
+		w.maxJobsActive = worker.DefaultJobWorkerMaxJobActive
+	}
+	if w.concurrency == 0 {
+		w.concurrency = worker.DefaultJobWorkerConcurrency
+	}
+	if w.pollInterval == 0 {
+		w.pollInterval = worker.DefaultJobWorkerPollInterval
+	}
+
+	w.jobs = make(chan entities.Job, w.maxJobsActive)
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	w.handlers.Add(w.concurrency)
+	for i := 0; i < w.concurrency; i++ {
+		go w.dispatch()
+	}
+
+	w.activators.Add(1)
+	go w.poll(ctx)
+	if w.streamEnabled {
+		w.activators.Add(1)
+		go w.stream(ctx)
+	}
+
+	// Jobs that were already activated are still handled once the worker is closed
+	go func() {
+		w.activators.Wait()
+		close(w.jobs)
+	}()
+
+	return w
+}
+
+// Close stops activating jobs.
+func (w *tenantJobWorker) Close() {
+	w.cancel()
+}
+
+// AwaitClose waits until the activated jobs have been handled.
+func (w *tenantJobWorker) AwaitClose() {
+	w.handlers.Wait()
+}
+
+// dispatch invokes the handler for the activated jobs.
+func (w *tenantJobWorker) dispatch() {
+	defer w.handlers.Done()
+	for job := range w.jobs {
+		if job.ActivatedJob == nil {
+			// The job stream of the client can send empty jobs when it's reopened
+			continue
+		}
+		w.active.Add(1)
+		w.handler(w.client, job)
+		w.active.Add(-1)
+	}
+}
+
+// poll activates jobs whenever fewer than maxJobsActive are buffered or being handled.
+func (w *tenantJobWorker) poll(ctx context.Context) {
+	defer w.activators.Done()
+	for {
+		activated := 0
+		if available := w.maxJobsActive - int(w.active.Load()) - len(w.jobs); available > 0 {
+			jobs, err := w.activate(ctx, available)
+			if err != nil && ctx.Err() == nil {
+				w.logger.Warnf("Failed to activate jobs of type %s: %v", w.jobType, err)
+			}
+			for _, job := range jobs {
+				w.jobs <- job
+			}
+			activated = len(jobs)
+		}
+
+		if activated > 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.pollInterval):
+		}
+	}
+}
+
+func (w *tenantJobWorker) activate(ctx context.Context, maxJobs int) ([]entities.Job, error) {
+	// The deadline of the context sets the long polling timeout of the request
+	ctx, cancel := context.WithTimeout(ctx, w.requestTimeout)
+	defer cancel()
+
+	cmd := w.client.NewActivateJobsCommand().
+		JobType(w.jobType).
+		MaxJobsToActivate(int32(maxJobs)).
+		TenantIds(w.tenantIds...).
+		Timeout(w.timeout).
+		WorkerName(w.workerName)
+	if len(w.fetchVariables) > 0 {
+		cmd = cmd.FetchVariables(w.fetchVariables...)
+	}
+	return cmd.Send(ctx)
+}
+
+// stream receives the jobs pushed by the gateway, reopening the stream if it's closed.
+func (w *tenantJobWorker) stream(ctx context.Context) {
+	defer w.activators.Done()
+	for {
+		cmd := w.client.NewStreamJobsCommand().
+			JobType(w.jobType).
+			Consumer(w.jobs).
+			TenantIds(w.tenantIds...).
+			Timeout(w.timeout).
+			WorkerName(w.workerName)
+		if len(w.fetchVariables) > 0 {
+			cmd = cmd.FetchVariables(w.fetchVariables...)
+		}
+		err := cmd.RequestTimeout(w.streamRequestTimeout).Send(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			w.logger.Warnf("Job stream for jobs of type %s closed: %v", w.jobType, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.pollInterval):
+		}
+	}
+}
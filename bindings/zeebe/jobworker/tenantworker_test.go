@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobworker
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/camunda/zeebe/clients/go/v8/pkg/commands"
+	"github.com/camunda/zeebe/clients/go/v8/pkg/entities"
+	"github.com/camunda/zeebe/clients/go/v8/pkg/pb"
+	"github.com/camunda/zeebe/clients/go/v8/pkg/worker"
+	"github.com/camunda/zeebe/clients/go/v8/pkg/zbc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeGateway returns the queued jobs from ActivateJobs, and records the requests.
+type fakeGateway struct {
+	pb.GatewayClient
+
+	lock     sync.Mutex
+	jobs     []*pb.ActivatedJob
+	requests []*pb.ActivateJobsRequest
+}
+
+func (g *fakeGateway) ActivateJobs(ctx context.Context, in *pb.ActivateJobsRequest, opts ...grpc.CallOption) (pb.Gateway_ActivateJobsClient, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.requests = append(g.requests, in)
+	n := int(in.MaxJobsToActivate)
+	if n > len(g.jobs) {
+		n = len(g.jobs)
+	}
+	res := &pb.ActivateJobsResponse{Jobs: g.jobs[:n]}
+	g.jobs = g.jobs[n:]
+	return &fakeActivateJobsStream{res: res}, nil
+}
+
+type fakeActivateJobsStream struct {
+	grpc.ClientStream
+	res *pb.ActivateJobsResponse
+}
+
+func (s *fakeActivateJobsStream) Recv() (*pb.ActivateJobsResponse, error) {
+	if s.res == nil {
+		return nil, io.EOF
+	}
+	res := s.res
+	s.res = nil
+	return res, nil
+}
+
+type fakeGatewayClient struct {
+	zbc.Client
+	gateway *fakeGateway
+}
+
+func (c fakeGatewayClient) NewActivateJobsCommand() commands.ActivateJobsCommandStep1 {
+	return commands.NewActivateJobsCommand(c.gateway, func(context.Context, error) bool { return false })
+}
+
+func TestTenantJobWorker(t *testing.T) {
+	gateway := &fakeGateway{
+		jobs: []*pb.ActivatedJob{{Key: 1, TenantId: "tenant-a"}, {Key: 2, TenantId: "tenant-b"}},
+	}
+	meta := &jobWorkerMetadata{
+		JobType:       "fetch-products",
+		WorkerName:    "dapr",
+		MaxJobsActive: 1,
+		PollInterval:  metadata.Duration{Duration: 10 * time.Millisecond},
+	}
+
+	handled := make(chan entities.Job, 2)
+	w := newTenantJobWorker(fakeGatewayClient{gateway: gateway}, meta, []string{"tenant-a", "tenant-b"}, func(client worker.JobClient, job entities.Job) {
+		handled <- job
+	}, logger.NewLogger("test"))
+
+	keys := []int64{}
+	for i := 0; i < 2; i++ {
+		select {
+		case job := <-handled:
+			keys = append(keys, job.Key)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "job was not handled")
+		}
+	}
+	assert.ElementsMatch(t, []int64{1, 2}, keys)
+
+	w.Close()
+	w.AwaitClose()
+
+	gateway.lock.Lock()
+	defer gateway.lock.Unlock()
+	require.NotEmpty(t, gateway.requests)
+	for _, req := range gateway.requests {
+		assert.Equal(t, []string{"tenant-a", "tenant-b"}, req.TenantIds)
+		assert.Equal(t, "fetch-products", req.Type)
+		assert.Equal(t, "dapr", req.Worker)
+		assert.LessOrEqual(t, req.MaxJobsToActivate, int32(1))
+	}
+}
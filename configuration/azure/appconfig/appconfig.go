@@ -30,6 +30,7 @@ import (
 
 	"github.com/dapr/components-contrib/configuration"
 	azauth "github.com/dapr/components-contrib/internal/authentication/azure"
+	"github.com/dapr/components-contrib/internal/utils"
 	contribMetadata "github.com/dapr/components-contrib/metadata"
 
 	"github.com/dapr/kit/logger"
@@ -48,6 +49,13 @@ const (
 	defaultMaxRetryDelay         = time.Second * 120
 	defaultSubscribePollInterval = time.Hour * 24
 	defaultRequestTimeout        = time.Second * 15
+
+	// Request metadata keys
+	labelKey         = "label"
+	snapshotKey      = "snapshot"
+	compositeKeysKey = "compositeKeys"
+	// Separator between the key and the label in composite keys.
+	compositeKeySeparator = "|"
 )
 
 type azAppConfigClient interface {
@@ -58,6 +66,7 @@ type azAppConfigClient interface {
 // ConfigurationStore is a Azure App Configuration store.
 type ConfigurationStore struct {
 	client                azAppConfigClient
+	snapshots             snapshotReader
 	metadata              metadata
 	subscribeCancelCtxMap sync.Map
 
@@ -101,6 +110,10 @@ func (r *ConfigurationStore) Init(_ context.Context, metadata configuration.Meta
 		if err != nil {
 			return err
 		}
+		r.snapshots, err = newSnapshotClientFromConnectionString(r.metadata.ConnectionString, &coreClientOpts)
+		if err != nil {
+			return err
+		}
 	} else {
 		var settings azauth.EnvironmentSettings
 		settings, err = azauth.NewEnvironmentSettings(metadata.Properties)
@@ -118,6 +131,10 @@ func (r *ConfigurationStore) Init(_ context.Context, metadata configuration.Meta
 		if err != nil {
 			return err
 		}
+		r.snapshots, err = newSnapshotClientWithCredential(r.metadata.Host, cred, &coreClientOpts)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -164,7 +181,12 @@ func (r *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequ
 	keys := req.Keys
 	var items map[string]*configuration.Item
 
-	if len(keys) == 0 {
+	if snapshot := r.getSnapshotFromMetadata(req.Metadata); snapshot != "" {
+		var err error
+		if items, err = r.getFromSnapshot(ctx, snapshot, req); err != nil {
+			return &configuration.GetResponse{}, err
+		}
+	} else if len(keys) == 0 {
 		var err error
 		if items, err = r.getAll(ctx, req); err != nil {
 			return &configuration.GetResponse{}, err
@@ -188,10 +210,10 @@ func (r *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequ
 			}
 			item.Value = *resp.Value
 			if resp.Label != nil {
-				item.Metadata["label"] = *resp.Label
+				item.Metadata[labelKey] = *resp.Label
 			}
 
-			items[key] = item
+			items[r.itemKey(key, resp.Label, req.Metadata)] = item
 		}
 	}
 	return &configuration.GetResponse{
@@ -225,10 +247,10 @@ func (r *ConfigurationStore) getAll(ctx context.Context, req *configuration.GetR
 				}
 				item.Value = *setting.Value
 				if setting.Label != nil {
-					item.Metadata["label"] = *setting.Label
+					item.Metadata[labelKey] = *setting.Label
 				}
 
-				items[*setting.Key] = item
+				items[r.itemKey(*setting.Key, setting.Label, req.Metadata)] = item
 			}
 		} else {
 			return nil, fmt.Errorf("failed to load all keys, error is %w", err)
@@ -237,19 +259,108 @@ func (r *ConfigurationStore) getAll(ctx context.Context, req *configuration.GetR
 	return items, nil
 }
 
+// getFromSnapshot returns the items in a snapshot, filtered by the keys and label in the request.
+func (r *ConfigurationStore) getFromSnapshot(ctx context.Context, snapshot string, req *configuration.GetRequest) (map[string]*configuration.Item, error) {
+	if r.snapshots == nil {
+		return nil, errors.New("azure appconfig error: snapshots are not supported by the client")
+	}
+
+	timeoutContext, cancel := context.WithTimeout(ctx, r.metadata.internalRequestTimeout)
+	defer cancel()
+	settings, err := r.snapshots.ListSnapshotSettings(timeoutContext, snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("azure appconfig error: failed to read snapshot: %w", err)
+	}
+
+	// As for the live key-values, if no label is set, requested keys match only the key-values without a label, while all labels are returned when no keys are requested
+	label := r.getLabelFromMetadata(req.Metadata)
+	if label == nil && len(req.Keys) > 0 {
+		label = to.Ptr("")
+	}
+	keys := make(map[string]bool, len(req.Keys))
+	for _, k := range req.Keys {
+		keys[k] = false
+	}
+
+	items := make(map[string]*configuration.Item, len(settings))
+	for _, setting := range settings {
+		if len(req.Keys) > 0 {
+			if _, ok := keys[setting.Key]; !ok {
+				continue
+			}
+		}
+		settingLabel := ""
+		if setting.Label != nil {
+			settingLabel = *setting.Label
+		}
+		if label != nil && *label != "*" && *label != settingLabel {
+			continue
+		}
+		if len(req.Keys) > 0 {
+			keys[setting.Key] = true
+		}
+
+		item := &configuration.Item{
+			Metadata: map[string]string{
+				snapshotKey: snapshot,
+			},
+		}
+		if setting.Value != nil {
+			item.Value = *setting.Value
+		}
+		if setting.Label != nil {
+			item.Metadata[labelKey] = *setting.Label
+		}
+		items[r.itemKey(setting.Key, setting.Label, req.Metadata)] = item
+	}
+
+	for key, found := range keys {
+		if !found {
+			return nil, fmt.Errorf("azure appconfig error: key %s not found in snapshot %s", key, snapshot)
+		}
+	}
+
+	return items, nil
+}
+
+// itemKey returns the key of the item in the response.
+// With composite keys, the label is appended to the key, so key-values with the same key and different labels are all returned.
+func (r *ConfigurationStore) itemKey(key string, label *string, metadata map[string]string) string {
+	if label == nil || *label == "" || !utils.IsTruthy(metadata[compositeKeysKey]) {
+		return key
+	}
+	return key + compositeKeySeparator + *label
+}
+
 func (r *ConfigurationStore) getLabelFromMetadata(metadata map[string]string) *string {
-	if s, ok := metadata["label"]; ok && s != "" {
+	if s, ok := metadata[labelKey]; ok && s != "" {
 		return to.Ptr(s)
 	}
 
 	return nil
 }
 
+// getSnapshotFromMetadata returns the snapshot to read, set in the request or in the component metadata.
+func (r *ConfigurationStore) getSnapshotFromMetadata(metadata map[string]string) string {
+	if s, ok := metadata[snapshotKey]; ok && s != "" {
+		return s
+	}
+	return r.metadata.Snapshot
+}
+
 func (r *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler) (string, error) {
 	sentinelKey := r.getSentinelKeyFromMetadata(req.Metadata)
 	if sentinelKey == "" {
 		return "", fmt.Errorf("azure appconfig error: sentinel key is not provided in metadata")
 	}
+	filter, err := req.GetFilter()
+	if err != nil {
+		return "", fmt.Errorf("azure appconfig error: invalid subscription filter: %w", err)
+	}
+	// Store the parsed filter in a copy of the request
+	subReq := *req
+	subReq.Filter = &filter
+	req = &subReq
 	uuid, err := uuid.NewRandom()
 	if err != nil {
 		return "", fmt.Errorf("azure appconfig error: failed to generate uuid, error is %w", err)
@@ -291,7 +402,11 @@ func (r *ConfigurationStore) doSubscribe(ctx context.Context, req *configuration
 				}
 				r.logger.Errorf("azure appconfig error: fail to get configuration key changes: %s", err)
 			} else {
-				r.handleSubscribedChange(ctx, handler, items, id)
+				// Only notify about the items that match the subscription filter
+				items.Items = req.Filter.FilterItems(items.Items)
+				if len(items.Items) > 0 {
+					r.handleSubscribedChange(ctx, handler, items, id)
+				}
 			}
 		}
 		select {
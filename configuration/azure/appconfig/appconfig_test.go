@@ -142,6 +142,49 @@ func Test_subscribeConfigurationWithProvidedKeys(t *testing.T) {
 	})
 }
 
+func Test_subscribeConfigurationWithFilter(t *testing.T) {
+	s := NewAzureAppConfigurationStore(logger.NewLogger("test")).(*ConfigurationStore)
+
+	s.client = &MockConfigurationStore{}
+	s.metadata.internalRequestTimeout = time.Second
+	s.metadata.internalSubscribePollInterval = 10 * time.Millisecond
+
+	t.Run("only items matching the filter are sent", func(t *testing.T) {
+		events := make(chan *configuration.UpdateEvent, 10)
+		req := configuration.SubscribeRequest{
+			Metadata: map[string]string{
+				"sentinelKey": "test_sentinel_key",
+				"keyPrefixes": "testKey-2",
+			},
+		}
+		subID, err := s.Subscribe(context.Background(), &req, func(ctx context.Context, e *configuration.UpdateEvent) error {
+			events <- e
+			return nil
+		})
+		assert.NoError(t, err)
+		defer s.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: subID})
+
+		select {
+		case e := <-events:
+			assert.Len(t, e.Items, 1)
+			assert.Contains(t, e.Items, "testKey-2")
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for update event")
+		}
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		req := configuration.SubscribeRequest{
+			Metadata: map[string]string{
+				"sentinelKey": "test_sentinel_key",
+				"labels":      "invalid",
+			},
+		}
+		_, err := s.Subscribe(context.Background(), &req, updateEventHandler)
+		assert.Error(t, err)
+	})
+}
+
 func Test_unsubscribeConfigurationWithProvidedKeys(t *testing.T) {
 	s := NewAzureAppConfigurationStore(logger.NewLogger("test")).(*ConfigurationStore)
 
@@ -23,6 +23,8 @@ type metadata struct {
 	RetryDelay            *int   `mapstructure:"retryDelay"`
 	SubscribePollInterval *int   `mapstructure:"subscribePollInterval"`
 	RequestTimeout        *int   `mapstructure:"requestTimeout"`
+	// Snapshot to read the configuration from, unless the request sets another one; if empty, the live key-values are read.
+	Snapshot string `mapstructure:"snapshot"`
 
 	internalRequestTimeout        time.Duration `mapstructure:"-"`
 	internalMaxRetryDelay         time.Duration `mapstructure:"-"`
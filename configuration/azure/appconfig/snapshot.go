@@ -0,0 +1,188 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appconfig
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// The version of azappconfig used by the component does not support snapshots, so they are read with the REST API directly.
+	snapshotAPIVersion = "2023-10-01"
+	// Snapshots can be read only when they are ready (or archived, until they expire).
+	snapshotStatusReady    = "ready"
+	snapshotStatusArchived = "archived"
+)
+
+// snapshotSetting is a key-value in a snapshot.
+type snapshotSetting struct {
+	Key         string  `json:"key"`
+	Label       *string `json:"label"`
+	Value       *string `json:"value"`
+	ContentType *string `json:"content_type"`
+	ETag        *string `json:"etag"`
+}
+
+type snapshotReader interface {
+	ListSnapshotSettings(ctx context.Context, name string) ([]snapshotSetting, error)
+}
+
+// snapshotClient reads the snapshots of an App Configuration store with the REST API.
+type snapshotClient struct {
+	endpoint string
+	pipeline runtime.Pipeline
+}
+
+func newSnapshotClientFromConnectionString(connectionString string, opts *policy.ClientOptions) (*snapshotClient, error) {
+	var (
+		endpoint, id string
+		secret       []byte
+		err          error
+	)
+	for _, seg := range strings.Split(connectionString, ";") {
+		name, val, _ := strings.Cut(seg, "=")
+		switch name {
+		case "Endpoint":
+			endpoint = val
+		case "Id":
+			id = val
+		case "Secret":
+			secret, err = base64.StdEncoding.DecodeString(val)
+			if err != nil {
+				return nil, errors.New("invalid secret in connection string")
+			}
+		}
+	}
+	if endpoint == "" || id == "" || len(secret) == 0 {
+		return nil, errors.New("connection string must contain Endpoint, Id and Secret")
+	}
+
+	return newSnapshotClient(endpoint, &hmacAuthPolicy{credential: id, secret: secret}, opts), nil
+}
+
+func newSnapshotClientWithCredential(endpoint string, cred azcore.TokenCredential, opts *policy.ClientOptions) (*snapshotClient, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	scope := u.Scheme + "://" + u.Host + "/.default"
+	return newSnapshotClient(endpoint, runtime.NewBearerTokenPolicy(cred, []string{scope}, nil), opts), nil
+}
+
+func newSnapshotClient(endpoint string, auth policy.Policy, opts *policy.ClientOptions) *snapshotClient {
+	return &snapshotClient{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		pipeline: runtime.NewPipeline("appconfig-snapshots", logger.DaprVersion, runtime.PipelineOptions{
+			PerRetry: []policy.Policy{auth},
+		}, opts),
+	}
+}
+
+// ListSnapshotSettings returns all the key-values in the snapshot.
+func (c *snapshotClient) ListSnapshotSettings(ctx context.Context, name string) ([]snapshotSetting, error) {
+	var snapshot struct {
+		Status string `json:"status"`
+	}
+	err := c.get(ctx, c.endpoint+"/snapshots/"+url.PathEscape(name)+"?api-version="+snapshotAPIVersion, &snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot %s: %w", name, err)
+	}
+	if snapshot.Status != snapshotStatusReady && snapshot.Status != snapshotStatusArchived {
+		return nil, fmt.Errorf("snapshot %s is not ready: status is %s", name, snapshot.Status)
+	}
+
+	var settings []snapshotSetting
+	next := c.endpoint + "/kv?snapshot=" + url.QueryEscape(name) + "&api-version=" + snapshotAPIVersion
+	for next != "" {
+		var page struct {
+			Items    []snapshotSetting `json:"items"`
+			NextLink string            `json:"@nextLink"`
+		}
+		err = c.get(ctx, next, &page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list key-values of snapshot %s: %w", name, err)
+		}
+		settings = append(settings, page.Items...)
+
+		// The link is relative to the endpoint
+		next = ""
+		if page.NextLink != "" {
+			next = c.endpoint + page.NextLink
+		}
+	}
+
+	return settings, nil
+}
+
+func (c *snapshotClient) get(ctx context.Context, endpoint string, out any) error {
+	req, err := runtime.NewRequest(ctx, http.MethodGet, endpoint)
+	if err != nil {
+		return err
+	}
+	req.Raw().Header.Set("Accept", "application/vnd.microsoft.appconfig.snapshot+json, application/vnd.microsoft.appconfig.kvset+json, application/problem+json")
+
+	res, err := c.pipeline.Do(req)
+	if err != nil {
+		return err
+	}
+	if !runtime.HasStatusCode(res, http.StatusOK) {
+		return runtime.NewResponseError(res)
+	}
+	return runtime.UnmarshalAsJSON(res, out)
+}
+
+// hmacAuthPolicy signs the requests with the credential in the connection string.
+type hmacAuthPolicy struct {
+	credential string
+	secret     []byte
+}
+
+func (p *hmacAuthPolicy) Do(req *policy.Request) (*http.Response, error) {
+	raw := req.Raw()
+
+	pathAndQuery := raw.URL.Path
+	if raw.URL.RawQuery != "" {
+		pathAndQuery += "?" + raw.URL.RawQuery
+	}
+	timestamp := time.Now().UTC().Format(http.TimeFormat)
+	// Requests have no body
+	emptyHash := sha256.Sum256(nil)
+	contentHash := base64.StdEncoding.EncodeToString(emptyHash[:])
+
+	stringToSign := strings.ToUpper(raw.Method) + "\n" + pathAndQuery + "\n" + timestamp + ";" + raw.URL.Host + ";" + contentHash
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(stringToSign))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	raw.Header.Set("x-ms-content-sha256", contentHash)
+	raw.Header.Set("Date", timestamp)
+	raw.Header.Set("Authorization", "HMAC-SHA256 Credential="+p.credential+", SignedHeaders=date;host;x-ms-content-sha256, Signature="+signature)
+
+	return req.Next()
+}
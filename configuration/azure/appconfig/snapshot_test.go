@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appconfig
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/configuration"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+func TestSnapshotClient(t *testing.T) {
+	status := "ready"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "HMAC-SHA256 Credential=myid, SignedHeaders=date;host;x-ms-content-sha256, Signature=") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, snapshotAPIVersion, r.URL.Query().Get("api-version"))
+
+		switch {
+		case r.URL.Path == "/snapshots/release-1":
+			json.NewEncoder(w).Encode(map[string]any{"name": "release-1", "status": status})
+		case r.URL.Path == "/kv" && r.URL.Query().Get("after") == "":
+			assert.Equal(t, "release-1", r.URL.Query().Get("snapshot"))
+			json.NewEncoder(w).Encode(map[string]any{
+				"items":     []map[string]any{{"key": "a", "value": "1"}},
+				"@nextLink": "/kv?snapshot=release-1&api-version=" + snapshotAPIVersion + "&after=a",
+			})
+		case r.URL.Path == "/kv":
+			json.NewEncoder(w).Encode(map[string]any{
+				"items": []map[string]any{{"key": "b", "label": "prod", "value": "2"}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := newSnapshotClientFromConnectionString("Endpoint="+srv.URL+";Id=myid;Secret=c2VjcmV0", nil)
+	require.NoError(t, err)
+
+	t.Run("lists all pages", func(t *testing.T) {
+		settings, err := c.ListSnapshotSettings(context.Background(), "release-1")
+		require.NoError(t, err)
+		require.Len(t, settings, 2)
+		assert.Equal(t, "a", settings[0].Key)
+		assert.Equal(t, "1", *settings[0].Value)
+		assert.Nil(t, settings[0].Label)
+		assert.Equal(t, "b", settings[1].Key)
+		assert.Equal(t, "prod", *settings[1].Label)
+	})
+
+	t.Run("snapshot not found", func(t *testing.T) {
+		_, err := c.ListSnapshotSettings(context.Background(), "release-2")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+	})
+
+	t.Run("snapshot not ready", func(t *testing.T) {
+		status = "provisioning"
+		defer func() { status = "ready" }()
+		_, err := c.ListSnapshotSettings(context.Background(), "release-1")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not ready")
+	})
+
+	t.Run("invalid connection string", func(t *testing.T) {
+		_, err := newSnapshotClientFromConnectionString("Endpoint="+srv.URL+";Id=myid", nil)
+		require.Error(t, err)
+	})
+}
+
+type mockSnapshotReader struct{}
+
+func (m *mockSnapshotReader) ListSnapshotSettings(ctx context.Context, name string) ([]snapshotSetting, error) {
+	return []snapshotSetting{
+		{Key: "color", Value: ptr.Of("blue")},
+		{Key: "color", Label: ptr.Of("prod"), Value: ptr.Of("red")},
+		{Key: "size", Label: ptr.Of("prod"), Value: ptr.Of("10")},
+	}, nil
+}
+
+func TestGetFromSnapshot(t *testing.T) {
+	s := NewAzureAppConfigurationStore(logger.NewLogger("test")).(*ConfigurationStore)
+	s.client = &MockConfigurationStore{}
+	s.snapshots = &mockSnapshotReader{}
+	s.metadata.internalRequestTimeout = defaultRequestTimeout
+
+	get := func(keys []string, metadata map[string]string) (map[string]*configuration.Item, error) {
+		res, err := s.Get(context.Background(), &configuration.GetRequest{Keys: keys, Metadata: metadata})
+		return res.Items, err
+	}
+
+	t.Run("requested keys without label", func(t *testing.T) {
+		items, err := get([]string{"color"}, map[string]string{snapshotKey: "release-1"})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "blue", items["color"].Value)
+		assert.Equal(t, "release-1", items["color"].Metadata[snapshotKey])
+	})
+
+	t.Run("requested keys with label", func(t *testing.T) {
+		items, err := get([]string{"color", "size"}, map[string]string{snapshotKey: "release-1", labelKey: "prod"})
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, "red", items["color"].Value)
+		assert.Equal(t, "10", items["size"].Value)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		_, err := get([]string{"size"}, map[string]string{snapshotKey: "release-1"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "key size not found in snapshot release-1")
+	})
+
+	t.Run("all keys with composite keys", func(t *testing.T) {
+		items, err := get(nil, map[string]string{snapshotKey: "release-1", compositeKeysKey: "true"})
+		require.NoError(t, err)
+		require.Len(t, items, 3)
+		assert.Equal(t, "blue", items["color"].Value)
+		assert.Equal(t, "red", items["color|prod"].Value)
+		assert.Equal(t, "10", items["size|prod"].Value)
+	})
+
+	t.Run("snapshot in component metadata", func(t *testing.T) {
+		s.metadata.Snapshot = "release-1"
+		defer func() { s.metadata.Snapshot = "" }()
+
+		items, err := get([]string{"color"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "blue", items["color"].Value)
+	})
+
+	t.Run("live key-values without snapshot", func(t *testing.T) {
+		items, err := get([]string{"testKey"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "testValue", items["testKey"].Value)
+		assert.Empty(t, items["testKey"].Metadata[snapshotKey])
+	})
+}
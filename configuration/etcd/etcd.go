@@ -0,0 +1,308 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/dapr/components-contrib/configuration"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	requestTimeout = 5 * time.Second
+	// watchRetryDelay is the delay before re-creating a watch that was closed by the server.
+	watchRetryDelay = time.Second
+)
+
+// ConfigurationStore is an etcd configuration store.
+type ConfigurationStore struct {
+	client  *clientv3.Client
+	kv      clientv3.KV
+	watcher clientv3.Watcher
+	prefix  string
+
+	subscriptions     map[string]context.CancelFunc
+	subscriptionsLock sync.Mutex
+	wg                sync.WaitGroup
+
+	logger logger.Logger
+}
+
+// NewEtcdConfigurationStore returns a new etcd configuration store.
+func NewEtcdConfigurationStore(logger logger.Logger) configuration.Store {
+	return &ConfigurationStore{
+		subscriptions: map[string]context.CancelFunc{},
+		logger:        logger,
+	}
+}
+
+// Init parses the metadata and creates the etcd client.
+func (e *ConfigurationStore) Init(ctx context.Context, metadata configuration.Metadata) error {
+	m, err := parseMetadata(metadata)
+	if err != nil {
+		return fmt.Errorf("etcd configuration store: %w", err)
+	}
+
+	tlsConfig, err := m.tlsConfig()
+	if err != nil {
+		return fmt.Errorf("etcd configuration store: %w", err)
+	}
+
+	e.client, err = clientv3.New(clientv3.Config{
+		Endpoints:   m.endpoints(),
+		DialTimeout: m.DialTimeout,
+		Username:    m.Username,
+		Password:    m.Password,
+		TLS:         tlsConfig,
+		Context:     ctx,
+	})
+	if err != nil {
+		return fmt.Errorf("etcd configuration store: error creating client: %w", err)
+	}
+	e.kv = e.client.KV
+	e.watcher = e.client.Watcher
+	e.prefix = m.KeyPrefixPath
+
+	return nil
+}
+
+// Get returns the configuration items with the given keys, or all the items if no key is given.
+// All the items are read from the same revision of the store.
+func (e *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequest) (*configuration.GetResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	items := map[string]*configuration.Item{}
+	if len(req.Keys) == 0 {
+		resp, err := e.kv.Get(ctx, e.prefix, clientv3.WithPrefix())
+		if err != nil {
+			return nil, fmt.Errorf("etcd configuration store: error getting keys with prefix %s: %w", e.prefix, err)
+		}
+		for _, kv := range resp.Kvs {
+			items[e.configKey(kv.Key)] = newItem(kv)
+		}
+		return &configuration.GetResponse{Items: items}, nil
+	}
+
+	var rev int64
+	for _, key := range req.Keys {
+		var opts []clientv3.OpOption
+		if rev > 0 {
+			opts = append(opts, clientv3.WithRev(rev))
+		}
+		resp, err := e.kv.Get(ctx, e.etcdKey(key), opts...)
+		if err != nil {
+			return nil, fmt.Errorf("etcd configuration store: error getting key %s: %w", key, err)
+		}
+		rev = resp.Header.GetRevision()
+		if len(resp.Kvs) > 0 {
+			items[key] = newItem(resp.Kvs[0])
+		}
+	}
+
+	return &configuration.GetResponse{Items: items}, nil
+}
+
+// Set saves the configuration items in a single transaction.
+// The versions of the items are ignored, as etcd uses the modification revisions as versions, and so is their metadata.
+func (e *ConfigurationStore) Set(ctx context.Context, req *configuration.SetRequest) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("etcd configuration store: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	ops := make([]clientv3.Op, 0, len(req.Items))
+	for key, item := range req.Items {
+		ops = append(ops, clientv3.OpPut(e.etcdKey(key), item.Value))
+	}
+	if _, err := e.kv.Txn(ctx).Then(ops...).Commit(); err != nil {
+		return fmt.Errorf("etcd configuration store: error setting keys: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes the configuration items with the given keys in a single transaction.
+func (e *ConfigurationStore) Delete(ctx context.Context, req *configuration.DeleteRequest) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("etcd configuration store: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	ops := make([]clientv3.Op, 0, len(req.Keys))
+	for _, key := range req.Keys {
+		ops = append(ops, clientv3.OpDelete(e.etcdKey(key)))
+	}
+	if _, err := e.kv.Txn(ctx).Then(ops...).Commit(); err != nil {
+		return fmt.Errorf("etcd configuration store: error deleting keys: %w", err)
+	}
+	return nil
+}
+
+// Subscribe watches the given keys, or all the keys if none is given, and invokes the handler with the items changed by each revision.
+// Deleted items are notified with an empty value.
+func (e *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler) (string, error) {
+	id := uuid.New().String()
+	subCtx, cancel := context.WithCancel(ctx)
+
+	e.subscriptionsLock.Lock()
+	e.subscriptions[id] = cancel
+	e.subscriptionsLock.Unlock()
+
+	if len(req.Keys) == 0 {
+		e.wg.Add(1)
+		go e.watch(subCtx, id, e.prefix, []clientv3.OpOption{clientv3.WithPrefix()}, handler)
+		return id, nil
+	}
+
+	for _, key := range req.Keys {
+		e.wg.Add(1)
+		go e.watch(subCtx, id, e.etcdKey(key), nil, handler)
+	}
+	return id, nil
+}
+
+// Unsubscribe stops a subscription.
+func (e *ConfigurationStore) Unsubscribe(ctx context.Context, req *configuration.UnsubscribeRequest) error {
+	e.subscriptionsLock.Lock()
+	defer e.subscriptionsLock.Unlock()
+
+	cancel, ok := e.subscriptions[req.ID]
+	if !ok {
+		return fmt.Errorf("subscription with id %s does not exist", req.ID)
+	}
+	cancel()
+	delete(e.subscriptions, req.ID)
+	return nil
+}
+
+// watch watches a key, or a range of keys, until the context is canceled.
+func (e *ConfigurationStore) watch(ctx context.Context, id string, key string, opts []clientv3.OpOption, handler configuration.UpdateHandler) {
+	defer e.wg.Done()
+
+	// The revision to resume from when the watch must be re-created, so that no change is missed
+	var rev int64
+	for {
+		watchOpts := opts
+		if rev > 0 {
+			watchOpts = append(watchOpts[:len(watchOpts):len(watchOpts)], clientv3.WithRev(rev))
+		}
+
+		for resp := range e.watcher.Watch(clientv3.WithRequireLeader(ctx), key, watchOpts...) {
+			if resp.CompactRevision > 0 {
+				// The revision to resume from was compacted: changes in between are lost
+				e.logger.Warnf("etcd configuration store: watch of %s resumed from compacted revision %d", key, resp.CompactRevision)
+				rev = resp.CompactRevision
+			}
+			if err := resp.Err(); err != nil {
+				e.logger.Errorf("etcd configuration store: error watching %s: %v", key, err)
+				continue
+			}
+			if len(resp.Events) == 0 {
+				continue
+			}
+
+			rev = resp.Header.GetRevision() + 1
+			err := handler(ctx, &configuration.UpdateEvent{
+				ID:    id,
+				Items: e.eventItems(resp.Events),
+			})
+			if err != nil {
+				e.logger.Errorf("etcd configuration store: failed to notify the update of %s: %v", key, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchRetryDelay):
+		}
+	}
+}
+
+// eventItems returns the items changed by watch events.
+func (e *ConfigurationStore) eventItems(events []*clientv3.Event) map[string]*configuration.Item {
+	items := make(map[string]*configuration.Item, len(events))
+	for _, ev := range events {
+		if ev.Type == mvccpb.DELETE {
+			items[e.configKey(ev.Kv.Key)] = &configuration.Item{
+				Version:  strconv.FormatInt(ev.Kv.ModRevision, 10),
+				Metadata: map[string]string{},
+			}
+			continue
+		}
+		items[e.configKey(ev.Kv.Key)] = newItem(ev.Kv)
+	}
+	return items
+}
+
+// etcdKey returns the etcd key storing a configuration item.
+func (e *ConfigurationStore) etcdKey(key string) string {
+	return e.prefix + key
+}
+
+// configKey returns the key of the configuration item stored in an etcd key.
+func (e *ConfigurationStore) configKey(key []byte) string {
+	return strings.TrimPrefix(string(key), e.prefix)
+}
+
+// newItem returns the configuration item stored in an etcd key-value pair, using its modification revision as version.
+func newItem(kv *mvccpb.KeyValue) *configuration.Item {
+	return &configuration.Item{
+		Value:   string(kv.Value),
+		Version: strconv.FormatInt(kv.ModRevision, 10),
+		Metadata: map[string]string{
+			"createRevision": strconv.FormatInt(kv.CreateRevision, 10),
+		},
+	}
+}
+
+// Close stops all the subscriptions and closes the client.
+func (e *ConfigurationStore) Close() error {
+	e.subscriptionsLock.Lock()
+	for id, cancel := range e.subscriptions {
+		cancel()
+		delete(e.subscriptions, id)
+	}
+	e.subscriptionsLock.Unlock()
+	e.wg.Wait()
+
+	if e.client != nil {
+		return e.client.Close()
+	}
+	return nil
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (e *ConfigurationStore) GetComponentMetadata() map[string]string {
+	metadataStruct := metadata{}
+	metadataInfo := map[string]string{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.ConfigurationStoreType)
+	return metadataInfo
+}
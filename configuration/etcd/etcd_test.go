@@ -0,0 +1,275 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/dapr/components-contrib/configuration"
+	mdata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeKV serves Get requests from a static set of keys.
+type fakeKV struct {
+	clientv3.KV
+	kvs map[string]*mvccpb.KeyValue
+	rev int64
+	ops []clientv3.Op
+}
+
+func (f *fakeKV) Get(_ context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	op := clientv3.OpGet(key, opts...)
+	f.ops = append(f.ops, op)
+
+	resp := &clientv3.GetResponse{Header: &etcdserverpb.ResponseHeader{Revision: f.rev}}
+	for k, kv := range f.kvs {
+		if k == key || (len(op.RangeBytes()) > 0 && strings.HasPrefix(k, key)) {
+			resp.Kvs = append(resp.Kvs, kv)
+		}
+	}
+	return resp, nil
+}
+
+func (f *fakeKV) Txn(context.Context) clientv3.Txn {
+	return &fakeTxn{kv: f}
+}
+
+// fakeTxn applies the put and delete operations of a transaction to a fakeKV.
+type fakeTxn struct {
+	clientv3.Txn
+	kv  *fakeKV
+	ops []clientv3.Op
+}
+
+func (f *fakeTxn) Then(ops ...clientv3.Op) clientv3.Txn {
+	f.ops = append(f.ops, ops...)
+	return f
+}
+
+func (f *fakeTxn) Commit() (*clientv3.TxnResponse, error) {
+	f.kv.rev++
+	for _, op := range f.ops {
+		f.kv.ops = append(f.kv.ops, op)
+		key := string(op.KeyBytes())
+		switch {
+		case op.IsPut():
+			f.kv.kvs[key] = &mvccpb.KeyValue{Key: op.KeyBytes(), Value: op.ValueBytes(), ModRevision: f.kv.rev, CreateRevision: f.kv.rev}
+		case op.IsDelete():
+			delete(f.kv.kvs, key)
+		}
+	}
+	return &clientv3.TxnResponse{Header: &etcdserverpb.ResponseHeader{Revision: f.kv.rev}, Succeeded: true}, nil
+}
+
+// fakeWatcher returns a channel per watched key.
+type fakeWatcher struct {
+	clientv3.Watcher
+	chans chan chan clientv3.WatchResponse
+	keys  chan string
+}
+
+func (f *fakeWatcher) Watch(ctx context.Context, key string, _ ...clientv3.OpOption) clientv3.WatchChan {
+	ch := make(chan clientv3.WatchResponse)
+	go func() {
+		<-ctx.Done()
+		close(ch)
+	}()
+	f.keys <- key
+	f.chans <- ch
+	return ch
+}
+
+func newTestStore() (*ConfigurationStore, *fakeKV, *fakeWatcher) {
+	kv := &fakeKV{
+		rev: 10,
+		kvs: map[string]*mvccpb.KeyValue{
+			"app/a": {Key: []byte("app/a"), Value: []byte("1"), ModRevision: 3, CreateRevision: 2},
+			"app/b": {Key: []byte("app/b"), Value: []byte("2"), ModRevision: 5, CreateRevision: 5},
+		},
+	}
+	watcher := &fakeWatcher{
+		chans: make(chan chan clientv3.WatchResponse, 10),
+		keys:  make(chan string, 10),
+	}
+	s := NewEtcdConfigurationStore(logger.NewLogger("test")).(*ConfigurationStore)
+	s.kv = kv
+	s.watcher = watcher
+	s.prefix = "app/"
+	return s, kv, watcher
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("valid metadata", func(t *testing.T) {
+		m, err := parseMetadata(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"endpoints":     "localhost:2379, localhost:2380",
+			"keyPrefixPath": "app/",
+			"username":      "user",
+			"password":      "pass",
+			"tlsEnable":     "true",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"localhost:2379", "localhost:2380"}, m.endpoints())
+		assert.Equal(t, "app/", m.KeyPrefixPath)
+		assert.Equal(t, "user", m.Username)
+		assert.Equal(t, defaultDialTimeout, m.DialTimeout)
+
+		tlsConfig, err := m.tlsConfig()
+		require.NoError(t, err)
+		assert.NotNil(t, tlsConfig)
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		for name, props := range map[string]map[string]string{
+			"missing endpoints":   {},
+			"missing username":    {"endpoints": "localhost:2379", "password": "pass"},
+			"incomplete key pair": {"endpoints": "localhost:2379", "tlsEnable": "true", "cert": "cert"},
+		} {
+			_, err := parseMetadata(configuration.Metadata{Base: mdata.Base{Properties: props}})
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("tls disabled", func(t *testing.T) {
+		m, err := parseMetadata(configuration.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"endpoints": "localhost:2379",
+			"ca":        "invalid",
+		}}})
+		require.NoError(t, err)
+		tlsConfig, err := m.tlsConfig()
+		require.NoError(t, err)
+		assert.Nil(t, tlsConfig)
+	})
+}
+
+func TestGet(t *testing.T) {
+	t.Run("all keys", func(t *testing.T) {
+		s, _, _ := newTestStore()
+		resp, err := s.Get(context.Background(), &configuration.GetRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Items, 2)
+		assert.Equal(t, "1", resp.Items["a"].Value)
+		assert.Equal(t, "3", resp.Items["a"].Version)
+		assert.Equal(t, "2", resp.Items["a"].Metadata["createRevision"])
+		assert.Equal(t, "2", resp.Items["b"].Value)
+	})
+
+	t.Run("keys are read from the same revision", func(t *testing.T) {
+		s, kv, _ := newTestStore()
+		resp, err := s.Get(context.Background(), &configuration.GetRequest{Keys: []string{"b", "missing"}})
+		require.NoError(t, err)
+		require.Len(t, resp.Items, 1)
+		assert.Equal(t, "5", resp.Items["b"].Version)
+
+		require.Len(t, kv.ops, 2)
+		assert.Equal(t, "app/b", string(kv.ops[0].KeyBytes()))
+		assert.Equal(t, int64(0), kv.ops[0].Rev())
+		assert.Equal(t, "app/missing", string(kv.ops[1].KeyBytes()))
+		assert.Equal(t, int64(10), kv.ops[1].Rev())
+	})
+}
+
+func TestSetDelete(t *testing.T) {
+	s, kv, _ := newTestStore()
+
+	err := s.Set(context.Background(), &configuration.SetRequest{Items: map[string]*configuration.Item{
+		"a": {Value: "10", Version: "ignored"},
+		"c": {Value: "3"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "10", string(kv.kvs["app/a"].Value))
+	assert.Equal(t, "3", string(kv.kvs["app/c"].Value))
+
+	resp, err := s.Get(context.Background(), &configuration.GetRequest{Keys: []string{"a"}})
+	require.NoError(t, err)
+	assert.Equal(t, "11", resp.Items["a"].Version)
+
+	err = s.Delete(context.Background(), &configuration.DeleteRequest{Keys: []string{"a", "missing"}})
+	require.NoError(t, err)
+	assert.NotContains(t, kv.kvs, "app/a")
+	assert.Contains(t, kv.kvs, "app/b")
+
+	assert.Error(t, s.Set(context.Background(), &configuration.SetRequest{}))
+	assert.Error(t, s.Delete(context.Background(), &configuration.DeleteRequest{}))
+}
+
+func TestSubscribe(t *testing.T) {
+	s, _, watcher := newTestStore()
+
+	events := make(chan *configuration.UpdateEvent, 10)
+	id, err := s.Subscribe(context.Background(), &configuration.SubscribeRequest{Keys: []string{"a"}}, func(_ context.Context, e *configuration.UpdateEvent) error {
+		events <- e
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "app/a", <-watcher.keys)
+	ch := <-watcher.chans
+
+	ch <- clientv3.WatchResponse{
+		Header: etcdserverpb.ResponseHeader{Revision: 11},
+		Events: []*clientv3.Event{
+			{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte("app/a"), Value: []byte("updated"), ModRevision: 11}},
+		},
+	}
+	select {
+	case e := <-events:
+		assert.Equal(t, id, e.ID)
+		assert.Equal(t, "updated", e.Items["a"].Value)
+		assert.Equal(t, "11", e.Items["a"].Version)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the update")
+	}
+
+	ch <- clientv3.WatchResponse{
+		Header: etcdserverpb.ResponseHeader{Revision: 12},
+		Events: []*clientv3.Event{
+			{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: []byte("app/a"), ModRevision: 12}},
+		},
+	}
+	select {
+	case e := <-events:
+		assert.Equal(t, "", e.Items["a"].Value)
+		assert.Equal(t, "12", e.Items["a"].Version)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the delete")
+	}
+
+	require.NoError(t, s.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id}))
+	assert.Error(t, s.Unsubscribe(context.Background(), &configuration.UnsubscribeRequest{ID: id}))
+	require.NoError(t, s.Close())
+}
+
+func TestSubscribeAllKeys(t *testing.T) {
+	s, _, watcher := newTestStore()
+
+	_, err := s.Subscribe(context.Background(), &configuration.SubscribeRequest{}, func(context.Context, *configuration.UpdateEvent) error {
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "app/", <-watcher.keys)
+	<-watcher.chans
+
+	// Close stops the subscriptions
+	require.NoError(t, s.Close())
+	assert.Empty(t, s.subscriptions)
+}
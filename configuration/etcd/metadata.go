@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/configuration"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+)
+
+const defaultDialTimeout = 5 * time.Second
+
+type metadata struct {
+	// Comma-separated list of etcd endpoints
+	Endpoints string `mapstructure:"endpoints"`
+	// Prefix of the etcd keys storing the configuration items, which is removed from the keys of the items
+	KeyPrefixPath string        `mapstructure:"keyPrefixPath"`
+	Username      string        `mapstructure:"username"`
+	Password      string        `mapstructure:"password"`
+	DialTimeout   time.Duration `mapstructure:"dialTimeout"`
+	// TLS
+	TLSEnable bool   `mapstructure:"tlsEnable"`
+	CA        string `mapstructure:"ca"`
+	Cert      string `mapstructure:"cert"`
+	Key       string `mapstructure:"key"`
+}
+
+func parseMetadata(meta configuration.Metadata) (metadata, error) {
+	m := metadata{
+		DialTimeout: defaultDialTimeout,
+	}
+	if err := contribMetadata.DecodeMetadata(meta.Properties, &m); err != nil {
+		return m, err
+	}
+
+	if len(m.endpoints()) == 0 {
+		return m, errors.New("missing etcd endpoints")
+	}
+	if m.Password != "" && m.Username == "" {
+		return m, errors.New("a username is required when a password is set")
+	}
+	if (m.Cert == "") != (m.Key == "") {
+		return m, errors.New("tls authentication requires both a certificate and a key")
+	}
+	if m.DialTimeout <= 0 {
+		m.DialTimeout = defaultDialTimeout
+	}
+
+	return m, nil
+}
+
+func (m metadata) endpoints() []string {
+	var endpoints []string
+	for _, e := range strings.Split(m.Endpoints, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			endpoints = append(endpoints, e)
+		}
+	}
+	return endpoints
+}
+
+// tlsConfig returns the TLS configuration of the client, or nil if TLS is not enabled.
+func (m metadata) tlsConfig() (*tls.Config, error) {
+	if !m.TLSEnable {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if m.Cert != "" {
+		cert, err := tls.X509KeyPair([]byte(m.Cert), []byte(m.Key))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing X509 key pair: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if m.CA != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(m.CA)) {
+			return nil, errors.New("error parsing CA certificate")
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configuration
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Keys in the subscribe request's metadata that can be used to set a filter.
+const (
+	// FilterKeyPrefixesKey is a comma-separated list of key prefixes.
+	FilterKeyPrefixesKey = "keyPrefixes"
+	// FilterLabelsKey is a comma-separated list of "name=value" pairs that must match the items' metadata.
+	FilterLabelsKey = "labels"
+	// FilterMinVersionKey is the minimum version (inclusive) of items.
+	FilterMinVersionKey = "minVersion"
+	// FilterMaxVersionKey is the maximum version (inclusive) of items.
+	FilterMaxVersionKey = "maxVersion"
+)
+
+// SubscribeFilter restricts the items for which a subscription receives update events.
+type SubscribeFilter struct {
+	// Items must have a key starting with one of these prefixes.
+	KeyPrefixes []string `json:"keyPrefixes,omitempty"`
+	// Items must have all these values in their metadata.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Items must have a version greater than or equal to this.
+	// Versions are compared as integers if both are numeric, or as strings otherwise.
+	// Items without a version are not filtered by version.
+	MinVersion string `json:"minVersion,omitempty"`
+	// Items must have a version lower than or equal to this.
+	MaxVersion string `json:"maxVersion,omitempty"`
+}
+
+// ParseSubscribeFilter returns the filter from the metadata of a subscribe request.
+func ParseSubscribeFilter(md map[string]string) (SubscribeFilter, error) {
+	f := SubscribeFilter{
+		MinVersion: md[FilterMinVersionKey],
+		MaxVersion: md[FilterMaxVersionKey],
+	}
+
+	for _, p := range strings.Split(md[FilterKeyPrefixesKey], ",") {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			f.KeyPrefixes = append(f.KeyPrefixes, p)
+		}
+	}
+
+	for _, l := range strings.Split(md[FilterLabelsKey], ",") {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(l, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return SubscribeFilter{}, fmt.Errorf("invalid label filter '%s': must be in the format 'name=value'", l)
+		}
+		if f.Labels == nil {
+			f.Labels = make(map[string]string)
+		}
+		f.Labels[name] = strings.TrimSpace(value)
+	}
+
+	if f.MinVersion != "" && f.MaxVersion != "" && compareVersions(f.MinVersion, f.MaxVersion) > 0 {
+		return SubscribeFilter{}, fmt.Errorf("invalid version filter: minVersion '%s' is greater than maxVersion '%s'", f.MinVersion, f.MaxVersion)
+	}
+
+	return f, nil
+}
+
+// IsEmpty returns true if the filter does not restrict any item.
+func (f SubscribeFilter) IsEmpty() bool {
+	return len(f.KeyPrefixes) == 0 && len(f.Labels) == 0 && f.MinVersion == "" && f.MaxVersion == ""
+}
+
+// MatchKey returns true if the key matches the key prefixes in the filter.
+func (f SubscribeFilter) MatchKey(key string) bool {
+	if len(f.KeyPrefixes) == 0 {
+		return true
+	}
+	for _, p := range f.KeyPrefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns true if the item with the given key matches the filter.
+func (f SubscribeFilter) Match(key string, item *Item) bool {
+	if !f.MatchKey(key) {
+		return false
+	}
+
+	if item == nil {
+		return len(f.Labels) == 0
+	}
+
+	for name, value := range f.Labels {
+		if v, ok := item.Metadata[name]; !ok || v != value {
+			return false
+		}
+	}
+
+	if item.Version != "" {
+		if f.MinVersion != "" && compareVersions(item.Version, f.MinVersion) < 0 {
+			return false
+		}
+		if f.MaxVersion != "" && compareVersions(item.Version, f.MaxVersion) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FilterItems returns the items that match the filter.
+// If the filter is empty, items is returned as-is.
+func (f SubscribeFilter) FilterItems(items map[string]*Item) map[string]*Item {
+	if f.IsEmpty() {
+		return items
+	}
+
+	res := make(map[string]*Item, len(items))
+	for k, item := range items {
+		if f.Match(k, item) {
+			res[k] = item
+		}
+	}
+	return res
+}
+
+// compareVersions compares two versions, as integers if both are numeric or as strings otherwise.
+func compareVersions(a, b string) int {
+	an, aErr := strconv.ParseInt(a, 10, 64)
+	bn, bErr := strconv.ParseInt(b, 10, 64)
+	if aErr == nil && bErr == nil {
+		switch {
+		case an < bn:
+			return -1
+		case an > bn:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return strings.Compare(a, b)
+}
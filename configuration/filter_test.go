@@ -0,0 +1,111 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSubscribeFilter(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		f, err := ParseSubscribeFilter(map[string]string{})
+		require.NoError(t, err)
+		assert.True(t, f.IsEmpty())
+	})
+
+	t.Run("all fields", func(t *testing.T) {
+		f, err := ParseSubscribeFilter(map[string]string{
+			"keyPrefixes": "app1/, app2/ ,",
+			"labels":      "env=prod, region = eu",
+			"minVersion":  "2",
+			"maxVersion":  "10",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, SubscribeFilter{
+			KeyPrefixes: []string{"app1/", "app2/"},
+			Labels:      map[string]string{"env": "prod", "region": "eu"},
+			MinVersion:  "2",
+			MaxVersion:  "10",
+		}, f)
+	})
+
+	t.Run("invalid label", func(t *testing.T) {
+		_, err := ParseSubscribeFilter(map[string]string{"labels": "env"})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid version range", func(t *testing.T) {
+		_, err := ParseSubscribeFilter(map[string]string{"minVersion": "10", "maxVersion": "9"})
+		require.Error(t, err)
+	})
+}
+
+func TestSubscribeRequestGetFilter(t *testing.T) {
+	req := &SubscribeRequest{
+		Metadata: map[string]string{"keyPrefixes": "a"},
+	}
+	f, err := req.GetFilter()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, f.KeyPrefixes)
+
+	req.Filter = &SubscribeFilter{KeyPrefixes: []string{"b"}}
+	f, err = req.GetFilter()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, f.KeyPrefixes)
+}
+
+func TestSubscribeFilterMatch(t *testing.T) {
+	f := SubscribeFilter{
+		KeyPrefixes: []string{"app1/", "app2/"},
+		Labels:      map[string]string{"env": "prod"},
+		MinVersion:  "2",
+		MaxVersion:  "10",
+	}
+
+	prod := map[string]string{"env": "prod"}
+	tests := []struct {
+		name string
+		key  string
+		item *Item
+		want bool
+	}{
+		{name: "match", key: "app1/foo", item: &Item{Version: "5", Metadata: prod}, want: true},
+		{name: "numeric version comparison", key: "app2/foo", item: &Item{Version: "10", Metadata: prod}, want: true},
+		{name: "no version", key: "app2/foo", item: &Item{Metadata: prod}, want: true},
+		{name: "key prefix mismatch", key: "app3/foo", item: &Item{Version: "5", Metadata: prod}, want: false},
+		{name: "label mismatch", key: "app1/foo", item: &Item{Version: "5", Metadata: map[string]string{"env": "dev"}}, want: false},
+		{name: "missing label", key: "app1/foo", item: &Item{Version: "5"}, want: false},
+		{name: "version too low", key: "app1/foo", item: &Item{Version: "1", Metadata: prod}, want: false},
+		{name: "version too high", key: "app1/foo", item: &Item{Version: "11", Metadata: prod}, want: false},
+		{name: "nil item", key: "app1/foo", item: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, f.Match(tt.key, tt.item))
+		})
+	}
+
+	t.Run("filter items", func(t *testing.T) {
+		items := map[string]*Item{
+			"app1/a": {Version: "3", Metadata: prod},
+			"app1/b": {Version: "30", Metadata: prod},
+			"other":  {Version: "3", Metadata: prod},
+		}
+		assert.Equal(t, map[string]*Item{"app1/a": items["app1/a"]}, f.FilterItems(items))
+		assert.Equal(t, items, SubscribeFilter{}.FilterItems(items))
+	})
+}
@@ -19,4 +19,22 @@ type metadata struct {
 	MaxIdleTimeout   time.Duration `mapstructure:"connMaxIdleTime"`
 	ConnectionString string        `mapstructure:"connectionString"`
 	ConfigTable      string        `mapstructure:"table"`
+	// Comma-separated list of "keyPrefix=table" pairs, to store the keys that start with a prefix in a different table.
+	// Keys that don't match any prefix are stored in ConfigTable.
+	Tables string `mapstructure:"tables"`
+	// If true, the tables, the notification function and the triggers are created at Init if they don't exist.
+	CreateTables bool `mapstructure:"createTables"`
+	// Channel the triggers created by the component send notifications to.
+	// It's the default pgNotifyChannel of subscriptions.
+	NotifyChannel string `mapstructure:"notifyChannel"`
+	// Name of the notification function created by the component.
+	NotifyFunction string `mapstructure:"notifyFunction"`
+	// Name of the triggers created by the component on each table.
+	NotifyTrigger string `mapstructure:"notifyTrigger"`
+}
+
+// tableRoute routes the keys that start with prefix to a table.
+type tableRoute struct {
+	prefix string
+	table  string
 }
@@ -38,6 +38,7 @@ import (
 
 type ConfigurationStore struct {
 	metadata             metadata
+	routes               []tableRoute
 	client               *pgxpool.Pool
 	logger               logger.Logger
 	configLock           sync.Mutex
@@ -48,6 +49,7 @@ type ConfigurationStore struct {
 type subscription struct {
 	channel string
 	keys    []string
+	filter  configuration.SubscribeFilter
 }
 
 type pgResponse struct {
@@ -82,6 +84,12 @@ func (p *ConfigurationStore) Init(parentCtx context.Context, metadata configurat
 	} else {
 		p.metadata = m
 	}
+	routes, err := parseTableRoutes(p.metadata.Tables)
+	if err != nil {
+		p.logger.Error(err)
+		return err
+	}
+	p.routes = routes
 	p.ActiveSubscriptions = make(map[string]*subscription)
 	ctx, cancel := context.WithTimeout(parentCtx, p.metadata.MaxIdleTimeout)
 	defer cancel()
@@ -94,16 +102,11 @@ func (p *ConfigurationStore) Init(parentCtx context.Context, metadata configurat
 	if err != nil {
 		return fmt.Errorf("unable to connect to configuration store: '%w'", err)
 	}
-	// check if table exists
-	exists := false
-	err = p.client.QueryRow(ctx, QueryTableExists, p.metadata.ConfigTable).Scan(&exists)
-	if err != nil {
-		return fmt.Errorf("error in checking if configtable '%s' exists: '%w'", p.metadata.ConfigTable, err)
-	}
-	if !exists {
-		return fmt.Errorf("postgreSQL configuration table '%s' does not exist", p.metadata.ConfigTable)
+	if p.metadata.CreateTables {
+		return p.createTables(ctx)
 	}
-	return nil
+	// check if tables exist
+	return p.checkTables(ctx)
 }
 
 // If version is a valid number, return the number
@@ -140,30 +143,31 @@ func (p *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequ
 		p.logger.Error(err)
 		return nil, err
 	}
-	query, params, err := buildQuery(req, p.metadata.ConfigTable)
+	keysByTable, err := p.keysByTable(req.Keys)
 	if err != nil {
 		p.logger.Error(err)
-		return nil, fmt.Errorf("error in configuration store query: '%w' ", err)
+		return nil, err
 	}
-	rows, err := p.client.Query(ctx, query, params...)
-	if err != nil {
-		// If no rows exist, return an empty response, otherwise return the error.
-		if errors.Is(err, pgx.ErrNoRows) {
-			return &configuration.GetResponse{}, nil
+	var items []pgResponse
+	for table, keys := range keysByTable {
+		query, params, err := buildQuery(&configuration.GetRequest{Keys: keys, Metadata: req.Metadata}, table)
+		if err != nil {
+			p.logger.Error(err)
+			return nil, fmt.Errorf("error in configuration store query: '%w' ", err)
 		}
-		return nil, fmt.Errorf("error in querying configuration store: '%w'", err)
-	}
-	items, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (pgResponse, error) {
-		res := pgResponse{
-			item: new(configuration.Item),
+		rows, err := p.client.Query(ctx, query, params...)
+		if err != nil {
+			// If no rows exist, skip the table, otherwise return the error.
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			return nil, fmt.Errorf("error in querying configuration store: '%w'", err)
 		}
-		if innerErr := row.Scan(&res.key, &res.item.Value, &res.item.Version, &res.item.Metadata); innerErr != nil {
-			return pgResponse{}, fmt.Errorf("error in reading data from configuration store: '%w'", innerErr)
+		tableItems, err := collectItems(rows)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse response from configuration store - %w", err)
 		}
-		return res, nil
-	})
-	if err != nil {
-		return nil, fmt.Errorf("unable to parse response from configuration store - %w", err)
+		items = append(items, tableItems...)
 	}
 	result := getUniqueItemPerKey(items)
 	return &configuration.GetResponse{
@@ -179,6 +183,10 @@ func (p *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.S
 			break
 		}
 	}
+	if pgNotifyChannel == "" && p.metadata.CreateTables {
+		// The triggers created by the component notify this channel
+		pgNotifyChannel = p.metadata.NotifyChannel
+	}
 	if pgNotifyChannel == "" {
 		return "", fmt.Errorf("unable to subscribe to '%s'.pgNotifyChannel attribute cannot be empty", p.metadata.ConfigTable)
 	}
@@ -271,13 +279,22 @@ func (p *ConfigurationStore) handleSubscribedChange(ctx context.Context, handler
 				}
 			}
 		}
+		if !p.isKeyTable(payload, key) {
+			p.logger.Debugf("ignoring notification for %v as it was sent by a table the key is not stored in", key)
+			return
+		}
+		item := &configuration.Item{
+			Value:    value,
+			Version:  version,
+			Metadata: m,
+		}
+		if !p.matchesFilter(subscriptionID, key, item) {
+			p.logger.Debugf("ignoring notification for %v as it does not match the subscription filter", key)
+			return
+		}
 		e := &configuration.UpdateEvent{
 			Items: map[string]*configuration.Item{
-				key: {
-					Value:    value,
-					Version:  version,
-					Metadata: m,
-				},
+				key: item,
 			},
 			ID: subscriptionID,
 		}
@@ -304,15 +321,29 @@ func parseMetadata(cmetadata configuration.Metadata) (metadata, error) {
 	}
 
 	if m.ConfigTable != "" {
-		if !allowedTableNameChars.MatchString(m.ConfigTable) {
-			return m, fmt.Errorf("invalid table name '%s'. non-alphanumerics or upper cased table names are not supported", m.ConfigTable)
+		if err := validateTableName(m.ConfigTable); err != nil {
+			return m, err
 		}
-		if len(m.ConfigTable) > maxIdentifierLength {
-			return m, fmt.Errorf("table name is too long - tableName : '%s'. max allowed field length is %d", m.ConfigTable, maxIdentifierLength)
-		}
-	} else {
+	} else if strings.TrimSpace(m.Tables) == "" {
+		// The default table can be omitted only if keys are routed to other tables
 		return m, fmt.Errorf("missing postgreSQL configuration table name")
 	}
+	if m.NotifyChannel == "" {
+		m.NotifyChannel = defaultNotifyChannel
+	}
+	if m.NotifyFunction == "" {
+		m.NotifyFunction = defaultNotifyFunction
+	}
+	if m.NotifyTrigger == "" {
+		m.NotifyTrigger = defaultNotifyTrigger
+	}
+	if m.CreateTables {
+		for kind, name := range map[string]string{"channel": m.NotifyChannel, "function": m.NotifyFunction, "trigger": m.NotifyTrigger} {
+			if err := validateIdentifier(kind, name); err != nil {
+				return m, err
+			}
+		}
+	}
 	if m.MaxIdleTimeout <= 0 {
 		m.MaxIdleTimeout = defaultMaxConnIdleTime
 	}
@@ -373,6 +404,91 @@ func buildQuery(req *configuration.GetRequest, configTable string) (string, []in
 	return query, params, nil
 }
 
+// Set saves the configuration items in a transaction, replacing the rows of the keys that exist.
+// The triggers created by the component notify the subscribers of the changes.
+func (p *ConfigurationStore) Set(ctx context.Context, req *configuration.SetRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(req.Items))
+	for k := range req.Items {
+		keys = append(keys, k)
+	}
+	if err := validateInput(keys); err != nil {
+		return err
+	}
+	keysByTable, err := p.keysByTable(keys)
+	if err != nil {
+		return err
+	}
+
+	return pgx.BeginFunc(ctx, p.client, func(tx pgx.Tx) error {
+		for table, tableKeys := range keysByTable {
+			for _, key := range tableKeys {
+				item := req.Items[key]
+				var md map[string]string
+				if len(item.Metadata) > 0 {
+					md = item.Metadata
+				}
+				res, err := tx.Exec(ctx, buildUpdateQuery(table), key, item.Value, item.Version, md)
+				if err != nil {
+					return fmt.Errorf("error updating configuration item '%s': %w", key, err)
+				}
+				if res.RowsAffected() > 0 {
+					continue
+				}
+				_, err = tx.Exec(ctx, buildInsertQuery(table), key, item.Value, item.Version, md)
+				if err != nil {
+					return fmt.Errorf("error inserting configuration item '%s': %w", key, err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Delete deletes the rows of the given keys in a transaction.
+func (p *ConfigurationStore) Delete(ctx context.Context, req *configuration.DeleteRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	if err := validateInput(req.Keys); err != nil {
+		return err
+	}
+	keysByTable, err := p.keysByTable(req.Keys)
+	if err != nil {
+		return err
+	}
+
+	return pgx.BeginFunc(ctx, p.client, func(tx pgx.Tx) error {
+		for table, tableKeys := range keysByTable {
+			query, params := buildDeleteQuery(table, tableKeys)
+			if _, err := tx.Exec(ctx, query, params...); err != nil {
+				return fmt.Errorf("error deleting configuration items from table '%s': %w", table, err)
+			}
+		}
+		return nil
+	})
+}
+
+func buildUpdateQuery(configTable string) string {
+	return "UPDATE " + configTable + " SET VALUE = $2, VERSION = $3, METADATA = $4 WHERE KEY = $1"
+}
+
+func buildInsertQuery(configTable string) string {
+	return "INSERT INTO " + configTable + " (KEY, VALUE, VERSION, METADATA) VALUES ($1, $2, $3, $4)"
+}
+
+func buildDeleteQuery(configTable string, keys []string) (string, []interface{}) {
+	paramWildcard := make([]string, len(keys))
+	params := make([]interface{}, len(keys))
+	for i, k := range keys {
+		paramWildcard[i] = "$" + strconv.Itoa(i+1)
+		params[i] = k
+	}
+	return "DELETE FROM " + configTable + " WHERE KEY IN (" + strings.Join(paramWildcard, " , ") + ")", params
+}
+
 func (p *ConfigurationStore) isSubscribed(subscriptionID string, channel string, key string) bool {
 	val := p.ActiveSubscriptions[subscriptionID]
 	if val != nil && val.channel == channel && (slices.Contains(val.keys, key) || len(val.keys) == 0) {
@@ -381,6 +497,11 @@ func (p *ConfigurationStore) isSubscribed(subscriptionID string, channel string,
 	return false
 }
 
+func (p *ConfigurationStore) matchesFilter(subscriptionID string, key string, item *configuration.Item) bool {
+	val := p.ActiveSubscriptions[subscriptionID]
+	return val != nil && val.filter.Match(key, item)
+}
+
 func validateInput(keys []string) error {
 	for _, key := range keys {
 		if !allowedChars.MatchString(key) {
@@ -391,6 +512,10 @@ func validateInput(keys []string) error {
 }
 
 func (p *ConfigurationStore) subscribeToChannel(ctx context.Context, pgNotifyChannel string, req *configuration.SubscribeRequest, handler configuration.UpdateHandler) (string, error) {
+	filter, err := req.GetFilter()
+	if err != nil {
+		return "", fmt.Errorf("invalid subscription filter: %w", err)
+	}
 	p.configLock.Lock()
 	defer p.configLock.Unlock()
 	var subscribeID string
@@ -405,6 +530,7 @@ func (p *ConfigurationStore) subscribeToChannel(ctx context.Context, pgNotifyCha
 	p.ActiveSubscriptions[subscribeID] = &subscription{
 		channel: pgNotifyChannel,
 		keys:    req.Keys,
+		filter:  filter,
 	}
 	go p.doSubscribe(ctx, req, handler, pgNotifyCmd, pgNotifyChannel, subscribeID, stop)
 	return subscribeID, nil
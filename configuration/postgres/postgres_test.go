@@ -112,3 +112,113 @@ func TestValidateInput(t *testing.T) {
 	keys3 := []string{"Name 1=1"}
 	assert.Error(t, validateInput(keys3), "invalid key : 'Name 1=1'")
 }
+
+func TestParseMetadataTables(t *testing.T) {
+	props := func(p map[string]string) configuration.Metadata {
+		m := configuration.Metadata{}
+		m.Properties = p
+		return m
+	}
+
+	t.Run("table can be omitted when tables is set", func(t *testing.T) {
+		m, err := parseMetadata(props(map[string]string{
+			"connectionString": "host=localhost",
+			"tables":           "app1.=app1_config",
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, "", m.ConfigTable)
+		assert.Equal(t, defaultNotifyChannel, m.NotifyChannel)
+		assert.Equal(t, defaultNotifyFunction, m.NotifyFunction)
+		assert.Equal(t, defaultNotifyTrigger, m.NotifyTrigger)
+	})
+
+	t.Run("table and tables missing", func(t *testing.T) {
+		_, err := parseMetadata(props(map[string]string{
+			"connectionString": "host=localhost",
+		}))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid trigger name", func(t *testing.T) {
+		_, err := parseMetadata(props(map[string]string{
+			"connectionString": "host=localhost",
+			"table":            "config",
+			"createTables":     "true",
+			"notifyTrigger":    "config; DROP TABLE config",
+		}))
+		assert.Error(t, err)
+	})
+}
+
+func TestParseTableRoutes(t *testing.T) {
+	routes, err := parseTableRoutes(" app1.=app1_config, app1.db.=db_config ,")
+	assert.NoError(t, err)
+	assert.Equal(t, []tableRoute{
+		{prefix: "app1.", table: "app1_config"},
+		{prefix: "app1.db.", table: "db_config"},
+	}, routes)
+
+	_, err = parseTableRoutes("app1.")
+	assert.Error(t, err)
+	_, err = parseTableRoutes("app1.=App1")
+	assert.Error(t, err)
+}
+
+func TestKeysByTable(t *testing.T) {
+	p := &ConfigurationStore{
+		metadata: metadata{ConfigTable: "config"},
+		routes: []tableRoute{
+			{prefix: "app1.", table: "app1_config"},
+			{prefix: "app1.db.", table: "db_config"},
+			{prefix: "app2.", table: "app1_config"},
+		},
+	}
+
+	res, err := p.keysByTable([]string{"app1.name", "app1.db.host", "app2.name", "other"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"app1_config": {"app1.name", "app2.name"},
+		"db_config":   {"app1.db.host"},
+		"config":      {"other"},
+	}, res)
+
+	res, err = p.keysByTable(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"config":      nil,
+		"app1_config": nil,
+		"db_config":   nil,
+	}, res)
+
+	assert.True(t, p.isKeyTable(map[string]interface{}{"table": "db_config"}, "app1.db.host"))
+	assert.False(t, p.isKeyTable(map[string]interface{}{"table": "app1_config"}, "app1.db.host"))
+	assert.True(t, p.isKeyTable(map[string]interface{}{}, "app1.db.host"))
+
+	// Without a default table, keys must match a prefix
+	p.metadata.ConfigTable = ""
+	_, err = p.keysByTable([]string{"other"})
+	assert.Error(t, err)
+}
+
+func TestBuildWriteQueries(t *testing.T) {
+	assert.Equal(t, "UPDATE cfgtbl SET VALUE = $2, VERSION = $3, METADATA = $4 WHERE KEY = $1", buildUpdateQuery("cfgtbl"))
+	assert.Equal(t, "INSERT INTO cfgtbl (KEY, VALUE, VERSION, METADATA) VALUES ($1, $2, $3, $4)", buildInsertQuery("cfgtbl"))
+
+	query, params := buildDeleteQuery("cfgtbl", []string{"key1", "key2"})
+	assert.Equal(t, "DELETE FROM cfgtbl WHERE KEY IN ($1 , $2)", query)
+	assert.Equal(t, []interface{}{"key1", "key2"}, params)
+}
+
+func TestSetDeleteValidation(t *testing.T) {
+	p := &ConfigurationStore{metadata: metadata{ConfigTable: "config"}}
+
+	err := p.Set(context.Background(), &configuration.SetRequest{})
+	assert.Error(t, err)
+	err = p.Set(context.Background(), &configuration.SetRequest{Items: map[string]*configuration.Item{
+		"Name 1=1": {Value: "v"},
+	}})
+	assert.Error(t, err)
+
+	err = p.Delete(context.Background(), &configuration.DeleteRequest{Keys: []string{"Name 1=1"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"k8s.io/utils/strings/slices"
+
+	"github.com/dapr/components-contrib/configuration"
+)
+
+const (
+	defaultNotifyChannel  = "config"
+	defaultNotifyFunction = "configuration_event"
+	defaultNotifyTrigger  = "config"
+
+	payloadTableKey = "table"
+
+	// Error code returned when creating an object that already exists.
+	pgErrDuplicateObject = "42710"
+)
+
+var allowedIdentifierChars = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// parseTableRoutes parses the "keyPrefix=table" pairs of the tables metadata property.
+func parseTableRoutes(val string) ([]tableRoute, error) {
+	var routes []tableRoute
+	for _, pair := range strings.Split(val, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		prefix, table, ok := strings.Cut(pair, "=")
+		prefix = strings.TrimSpace(prefix)
+		table = strings.TrimSpace(table)
+		if !ok || prefix == "" {
+			return nil, fmt.Errorf("invalid table route '%s': the format is keyPrefix=table", pair)
+		}
+		if err := validateTableName(table); err != nil {
+			return nil, err
+		}
+		routes = append(routes, tableRoute{prefix: prefix, table: table})
+	}
+	return routes, nil
+}
+
+func validateTableName(table string) error {
+	if table == "" || !allowedTableNameChars.MatchString(table) {
+		return fmt.Errorf("invalid table name '%s'. non-alphanumerics or upper cased table names are not supported", table)
+	}
+	if len(table) > maxIdentifierLength {
+		return fmt.Errorf("table name is too long - tableName : '%s'. max allowed field length is %d", table, maxIdentifierLength)
+	}
+	return nil
+}
+
+func validateIdentifier(kind string, name string) error {
+	if !allowedIdentifierChars.MatchString(name) {
+		return fmt.Errorf("invalid %s name '%s'. only lower case letters, digits and underscores are supported", kind, name)
+	}
+	if len(name) > maxIdentifierLength {
+		return fmt.Errorf("%s name is too long - '%s'. max allowed field length is %d", kind, name, maxIdentifierLength)
+	}
+	return nil
+}
+
+// tables returns the names of all the configuration tables.
+func (p *ConfigurationStore) tables() []string {
+	tables := make([]string, 0, len(p.routes)+1)
+	if p.metadata.ConfigTable != "" {
+		tables = append(tables, p.metadata.ConfigTable)
+	}
+	for _, r := range p.routes {
+		if !slices.Contains(tables, r.table) {
+			tables = append(tables, r.table)
+		}
+	}
+	return tables
+}
+
+// tableForKey returns the table of a key: the table of the longest matching prefix, or the default table.
+func (p *ConfigurationStore) tableForKey(key string) (string, error) {
+	table, prefixLen := p.metadata.ConfigTable, -1
+	for _, r := range p.routes {
+		if strings.HasPrefix(key, r.prefix) && len(r.prefix) > prefixLen {
+			table, prefixLen = r.table, len(r.prefix)
+		}
+	}
+	if table == "" {
+		return "", fmt.Errorf("no configuration table for key '%s'", key)
+	}
+	return table, nil
+}
+
+// keysByTable groups the keys by their table.
+// If there are no keys, every table is returned with no keys.
+func (p *ConfigurationStore) keysByTable(keys []string) (map[string][]string, error) {
+	res := map[string][]string{}
+	if len(keys) == 0 {
+		for _, t := range p.tables() {
+			res[t] = nil
+		}
+		return res, nil
+	}
+	for _, k := range keys {
+		t, err := p.tableForKey(k)
+		if err != nil {
+			return nil, err
+		}
+		res[t] = append(res[t], k)
+	}
+	return res, nil
+}
+
+// isKeyTable returns false if the notification was sent by a table other than the table of the key.
+// Notifications that don't include the table name, like the ones sent by custom triggers, are always accepted.
+func (p *ConfigurationStore) isKeyTable(payload map[string]interface{}, key string) bool {
+	table, ok := payload[payloadTableKey].(string)
+	if !ok || len(p.routes) == 0 {
+		return true
+	}
+	keyTable, err := p.tableForKey(key)
+	return err == nil && keyTable == table
+}
+
+// checkTables returns an error if any of the configuration tables doesn't exist.
+func (p *ConfigurationStore) checkTables(ctx context.Context) error {
+	for _, table := range p.tables() {
+		exists := false
+		err := p.client.QueryRow(ctx, QueryTableExists, table).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("error in checking if configtable '%s' exists: '%w'", table, err)
+		}
+		if !exists {
+			return fmt.Errorf("postgreSQL configuration table '%s' does not exist", table)
+		}
+	}
+	return nil
+}
+
+// createTables creates the configuration tables, the notification function and the triggers, if they don't exist.
+// The triggers send the changed rows to the notification channel, in the format expected by subscriptions.
+func (p *ConfigurationStore) createTables(ctx context.Context) error {
+	// The channel is passed to the function as an argument of the trigger, so it can be changed without replacing the function
+	_, err := p.client.Exec(ctx, `CREATE OR REPLACE FUNCTION `+p.metadata.NotifyFunction+`() RETURNS TRIGGER AS $$
+DECLARE
+	data json;
+	notification json;
+BEGIN
+	IF (TG_OP = 'DELETE') THEN
+		data = row_to_json(OLD);
+	ELSE
+		data = row_to_json(NEW);
+	END IF;
+	notification = json_build_object('table', TG_TABLE_NAME, 'action', TG_OP, 'data', data);
+	PERFORM pg_notify(TG_ARGV[0], notification::text);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql`)
+	if err != nil {
+		return fmt.Errorf("error creating notification function '%s': %w", p.metadata.NotifyFunction, err)
+	}
+
+	for _, table := range p.tables() {
+		_, err = p.client.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+table+` (
+	KEY VARCHAR NOT NULL,
+	VALUE VARCHAR NOT NULL,
+	VERSION VARCHAR NOT NULL,
+	METADATA JSON
+)`)
+		if err != nil {
+			return fmt.Errorf("error creating configuration table '%s': %w", table, err)
+		}
+
+		exists := false
+		err = p.client.QueryRow(ctx, queryTriggerExists, p.metadata.NotifyTrigger, table).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("error in checking if trigger '%s' exists: %w", p.metadata.NotifyTrigger, err)
+		}
+		if exists {
+			continue
+		}
+		_, err = p.client.Exec(ctx, `CREATE TRIGGER `+p.metadata.NotifyTrigger+` AFTER INSERT OR UPDATE OR DELETE ON `+table+
+			` FOR EACH ROW EXECUTE PROCEDURE `+p.metadata.NotifyFunction+`('`+p.metadata.NotifyChannel+`')`)
+		// Another instance may have created the trigger concurrently
+		var pgErr *pgconn.PgError
+		if err != nil && !(errors.As(err, &pgErr) && pgErr.Code == pgErrDuplicateObject) {
+			return fmt.Errorf("error creating trigger '%s' on table '%s': %w", p.metadata.NotifyTrigger, table, err)
+		}
+	}
+	return nil
+}
+
+const queryTriggerExists = "SELECT EXISTS (SELECT FROM pg_trigger WHERE tgname = $1 AND tgrelid = $2::regclass)"
+
+// collectItems reads the rows returned by a query of a configuration table.
+func collectItems(rows pgx.Rows) ([]pgResponse, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (pgResponse, error) {
+		res := pgResponse{}
+		res.item = new(configuration.Item)
+		if innerErr := row.Scan(&res.key, &res.item.Value, &res.item.Version, &res.item.Metadata); innerErr != nil {
+			return pgResponse{}, fmt.Errorf("error in reading data from configuration store: '%w'", innerErr)
+		}
+		return res, nil
+	})
+}
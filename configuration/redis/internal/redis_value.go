@@ -34,6 +34,19 @@ func GetRedisValueAndVersion(redisValue string) (string, string) {
 	return valueAndRevision[0], valueAndRevision[1]
 }
 
+// GetRedisValueWithVersion returns the Redis value storing a configuration value and its version, which is parsed by GetRedisValueAndVersion.
+func GetRedisValueWithVersion(value string, version string) string {
+	if version == "" {
+		return value
+	}
+	return value + separator + version
+}
+
+// ContainsSeparator returns true if a string contains the separator of the value and the version.
+func ContainsSeparator(s string) bool {
+	return strings.Contains(s, separator)
+}
+
 func ParseRedisKeyFromChannel(eventChannel string, redisDB int) (string, error) {
 	channelPrefix := keySpacePrefix + fmt.Sprint(redisDB) + "__:"
 	index := strings.Index(eventChannel, channelPrefix)
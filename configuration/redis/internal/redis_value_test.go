@@ -71,6 +71,15 @@ func TestGetRedisValueAndVersion(t *testing.T) {
 	}
 }
 
+func TestGetRedisValueWithVersion(t *testing.T) {
+	if got := GetRedisValueWithVersion("mockValue", ""); got != "mockValue" {
+		t.Errorf("GetRedisValueWithVersion() got = %v, want %v", got, "mockValue")
+	}
+	if got := GetRedisValueWithVersion("mockValue", "v1.0.0"); got != "mockValue||v1.0.0" {
+		t.Errorf("GetRedisValueWithVersion() got = %v, want %v", got, "mockValue||v1.0.0")
+	}
+}
+
 func TestParseRedisKeyFromChannel(t *testing.T) {
 	type args struct {
 		eventChannel string
@@ -156,28 +156,50 @@ func (r *ConfigurationStore) Get(ctx context.Context, req *configuration.GetRequ
 }
 
 func (r *ConfigurationStore) Subscribe(ctx context.Context, req *configuration.SubscribeRequest, handler configuration.UpdateHandler) (string, error) {
+	filter, err := req.GetFilter()
+	if err != nil {
+		return "", fmt.Errorf("invalid subscription filter: %w", err)
+	}
+	// Store the parsed filter in a copy of the request
+	subReq := *req
+	subReq.Filter = &filter
+	req = &subReq
+
 	subscribeID := uuid.New().String()
 	keyStopChanMap := make(map[string]chan struct{})
 	if len(req.Keys) == 0 {
-		// subscribe all keys
-		stop := make(chan struct{})
-		allKeysChannel := internal.GetRedisChannelFromKey("*", r.clientSettings.DB)
-		keyStopChanMap[allKeysChannel] = stop
-		subscribeArgs := &rediscomponent.ConfigurationSubscribeArgs{
-			HandleSubscribedChange: r.handleSubscribedChange,
-			Req:                    req,
-			Handler:                handler,
-			RedisChannel:           allKeysChannel,
-			IsAllKeysChannel:       true,
-			ID:                     subscribeID,
-			Stop:                   stop,
+		// subscribe all keys, or all keys with the given prefixes
+		patterns := []string{"*"}
+		if len(filter.KeyPrefixes) > 0 {
+			patterns = make([]string, len(filter.KeyPrefixes))
+			for i, p := range filter.KeyPrefixes {
+				patterns[i] = escapeRedisPattern(p) + "*"
+			}
+		}
+		for _, pattern := range patterns {
+			stop := make(chan struct{})
+			allKeysChannel := internal.GetRedisChannelFromKey(pattern, r.clientSettings.DB)
+			keyStopChanMap[allKeysChannel] = stop
+			subscribeArgs := &rediscomponent.ConfigurationSubscribeArgs{
+				HandleSubscribedChange: r.handleSubscribedChange,
+				Req:                    req,
+				Handler:                handler,
+				RedisChannel:           allKeysChannel,
+				IsAllKeysChannel:       true,
+				ID:                     subscribeID,
+				Stop:                   stop,
+			}
+			go r.client.ConfigurationSubscribe(ctx, subscribeArgs)
 		}
-		go r.client.ConfigurationSubscribe(ctx, subscribeArgs)
 		r.subscribeStopChanMap.Store(subscribeID, keyStopChanMap)
 		return subscribeID, nil
 	}
 
 	for _, k := range req.Keys {
+		if !filter.MatchKey(k) {
+			continue
+		}
+
 		// subscribe single key
 		stop := make(chan struct{})
 		redisChannel := internal.GetRedisChannelFromKey(k, r.clientSettings.DB)
@@ -216,6 +238,15 @@ func (r *ConfigurationStore) handleSubscribedChange(ctx context.Context, req *co
 		return
 	}
 
+	filter, err := req.GetFilter()
+	if err != nil {
+		r.logger.Errorf("invalid subscription filter: %s", err)
+		return
+	}
+	if !filter.MatchKey(targetKey) {
+		return
+	}
+
 	var items map[string]*configuration.Item
 
 	// get all keys if only one is changed
@@ -233,6 +264,10 @@ func (r *ConfigurationStore) handleSubscribedChange(ctx context.Context, req *co
 			targetKey: {},
 		}
 	}
+	items = filter.FilterItems(items)
+	if len(items) == 0 {
+		return
+	}
 
 	e := &configuration.UpdateEvent{
 		Items: items,
@@ -244,6 +279,46 @@ func (r *ConfigurationStore) handleSubscribedChange(ctx context.Context, req *co
 	}
 }
 
+// Set saves the configuration items in a single MSET command, storing their versions with the values.
+// The metadata of the items is not stored.
+func (r *ConfigurationStore) Set(ctx context.Context, req *configuration.SetRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	args := make([]interface{}, 0, 2*len(req.Items)+1)
+	args = append(args, "MSET")
+	for key, item := range req.Items {
+		if internal.ContainsSeparator(item.Value) || internal.ContainsSeparator(item.Version) {
+			return fmt.Errorf("the value and the version of configuration item %s must not contain '||'", key)
+		}
+		args = append(args, key, internal.GetRedisValueWithVersion(item.Value, item.Version))
+	}
+	if err := r.client.DoWrite(ctx, args...); err != nil {
+		return fmt.Errorf("fail to set configuration items: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes the configuration items with the given keys.
+func (r *ConfigurationStore) Delete(ctx context.Context, req *configuration.DeleteRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.client.Del(ctx, req.Keys...); err != nil {
+		return fmt.Errorf("fail to delete configuration items: %w", err)
+	}
+	return nil
+}
+
+var redisPatternEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+// escapeRedisPattern escapes the characters that have a special meaning in Redis glob-style patterns.
+func escapeRedisPattern(s string) string {
+	return redisPatternEscaper.Replace(s)
+}
+
 // GetComponentMetadata returns the metadata of the component.
 func (r *ConfigurationStore) GetComponentMetadata() map[string]string {
 	metadataStruct := rediscomponent.Settings{}
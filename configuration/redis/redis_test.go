@@ -189,6 +189,40 @@ func TestConfigurationStore_Get(t *testing.T) {
 	}
 }
 
+func TestConfigurationStore_SetDelete(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+	r := &ConfigurationStore{client: c, logger: logger.NewLogger("test")}
+
+	err := r.Set(context.Background(), &configuration.SetRequest{Items: map[string]*configuration.Item{
+		"key1": {Value: "value1", Version: "1"},
+		"key2": {Value: "value2"},
+	}})
+	assert.NoError(t, err)
+	got, _ := s.Get("key1")
+	assert.Equal(t, "value1||1", got)
+	got, _ = s.Get("key2")
+	assert.Equal(t, "value2", got)
+
+	resp, err := r.Get(context.Background(), &configuration.GetRequest{Keys: []string{"key1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "value1", resp.Items["key1"].Value)
+	assert.Equal(t, "1", resp.Items["key1"].Version)
+
+	err = r.Set(context.Background(), &configuration.SetRequest{Items: map[string]*configuration.Item{
+		"key3": {Value: "a||b"},
+	}})
+	assert.Error(t, err)
+	assert.False(t, s.Exists("key3"))
+
+	err = r.Delete(context.Background(), &configuration.DeleteRequest{Keys: []string{"key1", "missing"}})
+	assert.NoError(t, err)
+	assert.False(t, s.Exists("key1"))
+	assert.True(t, s.Exists("key2"))
+
+	assert.Error(t, r.Delete(context.Background(), &configuration.DeleteRequest{}))
+}
+
 func TestParseConnectedSlaves(t *testing.T) {
 	store := &ConfigurationStore{logger: logger.NewLogger("test")}
 
@@ -332,3 +366,8 @@ func setupMiniredis() (*miniredis.Miniredis, redisComponent.RedisClient) {
 
 	return s, redisClient
 }
+
+func TestEscapeRedisPattern(t *testing.T) {
+	assert.Equal(t, "app1/", escapeRedisPattern("app1/"))
+	assert.Equal(t, `a\*b\?c\[d\]e\\f`, escapeRedisPattern(`a*b?c[d]e\f`))
+}
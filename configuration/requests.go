@@ -13,6 +13,11 @@ limitations under the License.
 
 package configuration
 
+import (
+	"errors"
+	"fmt"
+)
+
 // Item represents a configuration item with name, content and other information.
 type Item struct {
 	Value    string            `json:"value,omitempty"`
@@ -30,6 +35,17 @@ type GetRequest struct {
 type SubscribeRequest struct {
 	Keys     []string          `json:"keys"`
 	Metadata map[string]string `json:"metadata"`
+	// Filter restricts the items the subscription receives events for.
+	// If nil, the filter is parsed from the metadata.
+	Filter *SubscribeFilter `json:"filter,omitempty"`
+}
+
+// GetFilter returns the filter for the subscription, parsing it from the metadata if Filter is not set.
+func (r *SubscribeRequest) GetFilter() (SubscribeFilter, error) {
+	if r.Filter != nil {
+		return *r.Filter, nil
+	}
+	return ParseSubscribeFilter(r.Metadata)
 }
 
 // UnsubscribeRequest is the object describing a request to unsubscribe configuration.
@@ -37,6 +53,48 @@ type UnsubscribeRequest struct {
 	ID string `json:"id"`
 }
 
+// SetRequest is the object describing a request to save configuration items.
+// The version of an item is stored as is by the stores that keep the versions set by the clients, and ignored by the stores that assign the versions, like etcd.
+type SetRequest struct {
+	Items    map[string]*Item  `json:"items"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// Validate returns an error if the request has no items or if an item is nil.
+func (r *SetRequest) Validate() error {
+	if len(r.Items) == 0 {
+		return errors.New("no configuration items to set")
+	}
+	for k, item := range r.Items {
+		if k == "" {
+			return errors.New("configuration item key is empty")
+		}
+		if item == nil {
+			return fmt.Errorf("configuration item %s is nil", k)
+		}
+	}
+	return nil
+}
+
+// DeleteRequest is the object describing a request to delete configuration items.
+type DeleteRequest struct {
+	Keys     []string          `json:"keys"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// Validate returns an error if the request has no keys or if a key is empty.
+func (r *DeleteRequest) Validate() error {
+	if len(r.Keys) == 0 {
+		return errors.New("no configuration keys to delete")
+	}
+	for _, k := range r.Keys {
+		if k == "" {
+			return errors.New("configuration item key is empty")
+		}
+	}
+	return nil
+}
+
 // UpdateEvent is the object describing a configuration update event.
 type UpdateEvent struct {
 	ID    string           `json:"id"`
@@ -0,0 +1,33 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetRequestValidate(t *testing.T) {
+	assert.NoError(t, (&SetRequest{Items: map[string]*Item{"a": {Value: "1"}}}).Validate())
+	assert.Error(t, (&SetRequest{}).Validate())
+	assert.Error(t, (&SetRequest{Items: map[string]*Item{"": {Value: "1"}}}).Validate())
+	assert.Error(t, (&SetRequest{Items: map[string]*Item{"a": nil}}).Validate())
+}
+
+func TestDeleteRequestValidate(t *testing.T) {
+	assert.NoError(t, (&DeleteRequest{Keys: []string{"a", "b"}}).Validate())
+	assert.Error(t, (&DeleteRequest{}).Validate())
+	assert.Error(t, (&DeleteRequest{Keys: []string{"a", ""}}).Validate())
+}
@@ -33,5 +33,17 @@ type Store interface {
 	GetComponentMetadata() map[string]string
 }
 
+// Setter is an optional interface implemented by the stores that can save configuration items.
+type Setter interface {
+	// Set saves the configuration items, replacing the items with the same keys.
+	Set(ctx context.Context, req *SetRequest) error
+}
+
+// Deleter is an optional interface implemented by the stores that can delete configuration items.
+type Deleter interface {
+	// Delete deletes the configuration items with the given keys; keys that don't exist are ignored.
+	Delete(ctx context.Context, req *DeleteRequest) error
+}
+
 // UpdateHandler is the handler used to send event to daprd.
 type UpdateHandler func(ctx context.Context, e *UpdateEvent) error
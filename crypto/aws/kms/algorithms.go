@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"github.com/aws/aws-sdk-go/service/kms"
+
+	internals "github.com/dapr/kit/crypto"
+)
+
+const (
+	// AlgorithmSymmetricDefault encrypts data in KMS with a symmetric key.
+	// KMS limits the size of the plaintext to 4KB; use A256GCM for larger messages.
+	AlgorithmSymmetricDefault = kms.EncryptionAlgorithmSpecSymmetricDefault
+
+	// AlgorithmEnvelope encrypts data locally with AES-256-GCM, using a data key generated by KMS with a symmetric key.
+	// The data key, encrypted by KMS, is stored in the ciphertext.
+	AlgorithmEnvelope = internals.Algorithm_A256GCM
+)
+
+// Maps the supported encryption algorithms, with the exception of AlgorithmEnvelope, to their KMS identifiers.
+var encryptionAlgs = map[string]string{
+	AlgorithmSymmetricDefault:        kms.EncryptionAlgorithmSpecSymmetricDefault,
+	internals.Algorithm_RSA_OAEP:     kms.EncryptionAlgorithmSpecRsaesOaepSha1,
+	internals.Algorithm_RSA_OAEP_256: kms.EncryptionAlgorithmSpecRsaesOaepSha256,
+}
+
+// Maps the supported signature algorithms to their KMS identifiers.
+var signatureAlgs = map[string]string{
+	internals.Algorithm_RS256: kms.SigningAlgorithmSpecRsassaPkcs1V15Sha256,
+	internals.Algorithm_RS384: kms.SigningAlgorithmSpecRsassaPkcs1V15Sha384,
+	internals.Algorithm_RS512: kms.SigningAlgorithmSpecRsassaPkcs1V15Sha512,
+	internals.Algorithm_PS256: kms.SigningAlgorithmSpecRsassaPssSha256,
+	internals.Algorithm_PS384: kms.SigningAlgorithmSpecRsassaPssSha384,
+	internals.Algorithm_PS512: kms.SigningAlgorithmSpecRsassaPssSha512,
+	internals.Algorithm_ES256: kms.SigningAlgorithmSpecEcdsaSha256,
+	internals.Algorithm_ES384: kms.SigningAlgorithmSpecEcdsaSha384,
+	internals.Algorithm_ES512: kms.SigningAlgorithmSpecEcdsaSha512,
+}
+
+// GetKMSEncryptionAlgorithm returns the KMS identifier of an encryption algorithm, or an empty string if the algorithm is not supported by KMS.
+func GetKMSEncryptionAlgorithm(algorithm string) string {
+	return encryptionAlgs[algorithm]
+}
+
+// GetKMSSignatureAlgorithm returns the KMS identifier of a signature algorithm, or an empty string if the algorithm is not supported.
+func GetKMSSignatureAlgorithm(algorithm string) string {
+	return signatureAlgs[algorithm]
+}
@@ -0,0 +1,408 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/benbjohnson/clock"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"golang.org/x/exp/maps"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	awsAuth "github.com/dapr/components-contrib/internal/authentication/aws"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	internals "github.com/dapr/kit/crypto"
+	"github.com/dapr/kit/logger"
+)
+
+// Key of the KMS encryption context that contains the associated data, base64-encoded.
+const associatedDataContextKey = "dapr-aad"
+
+type kmsCrypto struct {
+	keyCache     *contribCrypto.PubKeyCache
+	dataKeyCache *dataKeyCache
+	md           kmsMetadata
+	client       kmsiface.KMSAPI
+	clock        clock.Clock
+	logger       logger.Logger
+}
+
+// NewAWSKMSCrypto returns a new AWS KMS crypto provider.
+func NewAWSKMSCrypto(logger logger.Logger) contribCrypto.SubtleCrypto {
+	return &kmsCrypto{
+		clock:  clock.New(),
+		logger: logger,
+	}
+}
+
+// Init creates a AWS KMS client.
+func (k *kmsCrypto) Init(_ context.Context, metadata contribCrypto.Metadata) error {
+	// Init the metadata
+	err := k.md.InitWithMetadata(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to load metadata: %w", err)
+	}
+
+	// Create the caches for public keys and data keys
+	k.keyCache = contribCrypto.NewPubKeyCache(k.getKeyCacheFn)
+	k.dataKeyCache = newDataKeyCache(k.md.dataKeyCacheTTL, k.clock)
+
+	// Init the AWS SDK client
+	sess, err := awsAuth.GetClient(awsAuth.Options{
+		AccessKey:    k.md.AccessKey,
+		SecretKey:    k.md.SecretKey,
+		SessionToken: k.md.SessionToken,
+		Region:       k.md.Region,
+		Endpoint:     k.md.Endpoint,
+	})
+	if err != nil {
+		return err
+	}
+	k.client = kms.New(sess)
+
+	return nil
+}
+
+// Features returns the features available in this crypto provider.
+func (k *kmsCrypto) Features() []contribCrypto.Feature {
+	return []contribCrypto.Feature{} // No Feature supported.
+}
+
+// GetKey returns the public part of a key stored in KMS.
+// This method returns an error if the key is symmetric.
+// The key argument can be a key ID, a key ARN, an alias name ("alias/name") or an alias ARN.
+func (k *kmsCrypto) GetKey(parentCtx context.Context, key string) (pubKey jwk.Key, err error) {
+	return k.keyCache.GetKey(parentCtx, key)
+}
+
+func (k *kmsCrypto) getKeyFromKMS(parentCtx context.Context, key string) (pubKey jwk.Key, err error) {
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	res, err := k.client.GetPublicKeyWithContext(ctx, &kms.GetPublicKeyInput{
+		KeyId:       &key,
+		GrantTokens: k.md.grantTokens,
+	})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key from KMS: %w", err)
+	}
+
+	raw, err := x509.ParsePKIXPublicKey(res.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	pubKey, err = jwk.FromRaw(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWK from public key: %w", err)
+	}
+	_ = pubKey.Set(jwk.KeyIDKey, key)
+	return pubKey, nil
+}
+
+// Handler for the getKeyCacheFn method
+func (k *kmsCrypto) getKeyCacheFn(ctx context.Context, key string) func(resolve func(jwk.Key), reject func(error)) {
+	return func(resolve func(jwk.Key), reject func(error)) {
+		pk, err := k.getKeyFromKMS(ctx, key)
+		if err != nil {
+			reject(err)
+			return
+		}
+		resolve(pk)
+	}
+}
+
+// Encrypt a message and returns the ciphertext.
+// With the A256GCM algorithm, the message is encrypted locally with envelope encryption, and the nonce is generated randomly if not set.
+// With the other algorithms, the message is encrypted by KMS, which limits its size to 4KB.
+func (k *kmsCrypto) Encrypt(parentCtx context.Context, plaintext []byte, algorithm string, key string, nonce []byte, associatedData []byte) (ciphertext []byte, tag []byte, err error) {
+	if algorithm == AlgorithmEnvelope {
+		return k.encryptEnvelope(parentCtx, plaintext, key, nonce, associatedData)
+	}
+
+	kmsAlgorithm := GetKMSEncryptionAlgorithm(algorithm)
+	if kmsAlgorithm == "" {
+		return nil, nil, fmt.Errorf("invalid algorithm: %s", algorithm)
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	res, err := k.client.EncryptWithContext(ctx, &kms.EncryptInput{
+		KeyId:               &key,
+		Plaintext:           plaintext,
+		EncryptionAlgorithm: &kmsAlgorithm,
+		EncryptionContext:   encryptionContext(kmsAlgorithm, associatedData),
+		GrantTokens:         k.md.grantTokens,
+	})
+	cancel()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error from KMS: %w", err)
+	}
+
+	if res.CiphertextBlob == nil {
+		return nil, nil, errors.New("response from KMS does not contain a valid ciphertext")
+	}
+
+	return res.CiphertextBlob, nil, nil
+}
+
+func (k *kmsCrypto) encryptEnvelope(parentCtx context.Context, plaintext []byte, key string, nonce []byte, associatedData []byte) (ciphertext []byte, tag []byte, err error) {
+	if nonce == nil {
+		nonce = make([]byte, envelopeNonceSize)
+		_, err = io.ReadFull(rand.Reader, nonce)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
+		}
+	} else if len(nonce) != envelopeNonceSize {
+		return nil, nil, fmt.Errorf("nonce must be %d bytes", envelopeNonceSize)
+	}
+
+	dk, err := k.getDataKey(parentCtx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dekJWK, err := jwk.FromRaw(dk.plaintext)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create JWK from data key: %w", err)
+	}
+	ciphertext, tag, err = internals.EncryptSymmetric(plaintext, AlgorithmEnvelope, dekJWK, nonce, associatedData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt data: %w", err)
+	}
+
+	ciphertext, err = encodeEnvelope(dk.encrypted, nonce, ciphertext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ciphertext, tag, nil
+}
+
+// getDataKey returns the active data key for the KMS key, generating a new one if needed.
+func (k *kmsCrypto) getDataKey(parentCtx context.Context, key string) (*dataKey, error) {
+	dk := k.dataKeyCache.GetActive(key)
+	if dk != nil {
+		return dk, nil
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	res, err := k.client.GenerateDataKeyWithContext(ctx, &kms.GenerateDataKeyInput{
+		KeyId:       &key,
+		KeySpec:     aws.String(kms.DataKeySpecAes256),
+		GrantTokens: k.md.grantTokens,
+	})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	if res.Plaintext == nil || res.CiphertextBlob == nil {
+		return nil, errors.New("response from KMS does not contain a valid data key")
+	}
+
+	k.dataKeyCache.SetActive(key, res.Plaintext, res.CiphertextBlob)
+	return &dataKey{
+		plaintext: res.Plaintext,
+		encrypted: res.CiphertextBlob,
+	}, nil
+}
+
+// Decrypt a message and returns the plaintext.
+// With the A256GCM algorithm, the nonce stored in the ciphertext is used.
+func (k *kmsCrypto) Decrypt(parentCtx context.Context, ciphertext []byte, algorithm string, key string, nonce []byte, tag []byte, associatedData []byte) (plaintext []byte, err error) {
+	if algorithm == AlgorithmEnvelope {
+		return k.decryptEnvelope(parentCtx, ciphertext, key, tag, associatedData)
+	}
+
+	kmsAlgorithm := GetKMSEncryptionAlgorithm(algorithm)
+	if kmsAlgorithm == "" {
+		return nil, fmt.Errorf("invalid algorithm: %s", algorithm)
+	}
+
+	return k.decryptInKMS(parentCtx, ciphertext, kmsAlgorithm, key, encryptionContext(kmsAlgorithm, associatedData))
+}
+
+func (k *kmsCrypto) decryptInKMS(parentCtx context.Context, ciphertext []byte, kmsAlgorithm string, key string, encryptionContext map[string]*string) (plaintext []byte, err error) {
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	res, err := k.client.DecryptWithContext(ctx, &kms.DecryptInput{
+		KeyId:               &key,
+		CiphertextBlob:      ciphertext,
+		EncryptionAlgorithm: &kmsAlgorithm,
+		EncryptionContext:   encryptionContext,
+		GrantTokens:         k.md.grantTokens,
+	})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("error from KMS: %w", err)
+	}
+
+	if res.Plaintext == nil {
+		return nil, errors.New("response from KMS does not contain a valid plaintext")
+	}
+
+	return res.Plaintext, nil
+}
+
+func (k *kmsCrypto) decryptEnvelope(parentCtx context.Context, data []byte, key string, tag []byte, associatedData []byte) (plaintext []byte, err error) {
+	encryptedKey, nonce, ciphertext, err := decodeEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+
+	dek := k.dataKeyCache.GetDecrypted(encryptedKey)
+	if dek == nil {
+		dek, err = k.decryptInKMS(parentCtx, encryptedKey, kms.EncryptionAlgorithmSpecSymmetricDefault, key, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt data key: %w", err)
+		}
+		k.dataKeyCache.SetDecrypted(dek, encryptedKey)
+	}
+
+	dekJWK, err := jwk.FromRaw(dek)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWK from data key: %w", err)
+	}
+	plaintext, err = internals.DecryptSymmetric(ciphertext, AlgorithmEnvelope, dekJWK, nonce, tag, associatedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+	}
+	return plaintext, nil
+}
+
+// WrapKey wraps a symmetric key.
+func (k *kmsCrypto) WrapKey(parentCtx context.Context, plaintextKey jwk.Key, algorithm string, key string, nonce []byte, associatedData []byte) (wrappedKey []byte, tag []byte, err error) {
+	// Only symmetric keys can be wrapped, as asymmetric keys are usually too large for KMS
+	if plaintextKey.KeyType() != jwa.OctetSeq {
+		return nil, nil, errors.New("cannot wrap asymmetric keys")
+	}
+	plaintext, err := internals.SerializeKey(plaintextKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot serialize key: %w", err)
+	}
+
+	return k.Encrypt(parentCtx, plaintext, algorithm, key, nonce, associatedData)
+}
+
+// UnwrapKey unwraps a key.
+func (k *kmsCrypto) UnwrapKey(parentCtx context.Context, wrappedKey []byte, algorithm string, key string, nonce []byte, tag []byte, associatedData []byte) (plaintextKey jwk.Key, err error) {
+	plaintext, err := k.Decrypt(parentCtx, wrappedKey, algorithm, key, nonce, tag, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only symmetric keys can be wrapped, so no need to try and decode an ASN.1 DER-encoded sequence
+	plaintextKey, err = jwk.FromRaw(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWK from raw key: %w", err)
+	}
+
+	return plaintextKey, nil
+}
+
+// Sign a digest.
+// ECDSA signatures are returned DER-encoded, as generated by KMS.
+func (k *kmsCrypto) Sign(parentCtx context.Context, digest []byte, algorithm string, key string) (signature []byte, err error) {
+	kmsAlgorithm := GetKMSSignatureAlgorithm(algorithm)
+	if kmsAlgorithm == "" {
+		return nil, fmt.Errorf("invalid algorithm: %s", algorithm)
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	res, err := k.client.SignWithContext(ctx, &kms.SignInput{
+		KeyId:            &key,
+		Message:          digest,
+		MessageType:      aws.String(kms.MessageTypeDigest),
+		SigningAlgorithm: &kmsAlgorithm,
+		GrantTokens:      k.md.grantTokens,
+	})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("error from KMS: %w", err)
+	}
+
+	if res.Signature == nil {
+		return nil, errors.New("response from KMS does not contain a valid signature")
+	}
+
+	return res.Signature, nil
+}
+
+// Verify a signature.
+func (k *kmsCrypto) Verify(parentCtx context.Context, digest []byte, signature []byte, algorithm string, key string) (valid bool, err error) {
+	kmsAlgorithm := GetKMSSignatureAlgorithm(algorithm)
+	if kmsAlgorithm == "" {
+		return false, fmt.Errorf("invalid algorithm: %s", algorithm)
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, k.md.RequestTimeout)
+	res, err := k.client.VerifyWithContext(ctx, &kms.VerifyInput{
+		KeyId:            &key,
+		Message:          digest,
+		MessageType:      aws.String(kms.MessageTypeDigest),
+		Signature:        signature,
+		SigningAlgorithm: &kmsAlgorithm,
+		GrantTokens:      k.md.grantTokens,
+	})
+	cancel()
+	if err != nil {
+		// KMS returns an error when the signature is not valid
+		var invalidErr *kms.KMSInvalidSignatureException
+		if errors.As(err, &invalidErr) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error from KMS: %w", err)
+	}
+
+	return aws.BoolValue(res.SignatureValid), nil
+}
+
+// encryptionContext returns the KMS encryption context containing the associated data.
+// Encryption context is only supported with symmetric keys.
+func encryptionContext(kmsAlgorithm string, associatedData []byte) map[string]*string {
+	if len(associatedData) == 0 || kmsAlgorithm != kms.EncryptionAlgorithmSpecSymmetricDefault {
+		return nil
+	}
+	return map[string]*string{
+		associatedDataContextKey: aws.String(base64.StdEncoding.EncodeToString(associatedData)),
+	}
+}
+
+func (kmsCrypto) SupportedEncryptionAlgorithms() []string {
+	algs := append(maps.Keys(encryptionAlgs), AlgorithmEnvelope)
+	sort.Strings(algs)
+	return algs
+}
+
+func (kmsCrypto) SupportedSignatureAlgorithms() []string {
+	algs := maps.Keys(signatureAlgs)
+	sort.Strings(algs)
+	return algs
+}
+
+func (kmsCrypto) GetComponentMetadata() map[string]string {
+	metadataStruct := kmsMetadata{}
+	metadataInfo := map[string]string{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.CryptoType)
+	return metadataInfo
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/benbjohnson/clock"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// fakeKMS is a fake KMS client that "encrypts" data keys by prefixing them with the key ID.
+type fakeKMS struct {
+	kmsiface.KMSAPI
+
+	generateCalls int
+	decryptCalls  int
+	grantTokens   []*string
+	encContext    map[string]*string
+}
+
+func (f *fakeKMS) GenerateDataKeyWithContext(_ aws.Context, in *kms.GenerateDataKeyInput, _ ...request.Option) (*kms.GenerateDataKeyOutput, error) {
+	f.generateCalls++
+	f.grantTokens = in.GrantTokens
+
+	plaintext := make([]byte, 32)
+	_, err := io.ReadFull(rand.Reader, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return &kms.GenerateDataKeyOutput{
+		KeyId:          in.KeyId,
+		Plaintext:      plaintext,
+		CiphertextBlob: append([]byte(*in.KeyId+":"), plaintext...),
+	}, nil
+}
+
+func (f *fakeKMS) EncryptWithContext(_ aws.Context, in *kms.EncryptInput, _ ...request.Option) (*kms.EncryptOutput, error) {
+	f.encContext = in.EncryptionContext
+	return &kms.EncryptOutput{
+		KeyId:          in.KeyId,
+		CiphertextBlob: append([]byte(*in.KeyId+":"), in.Plaintext...),
+	}, nil
+}
+
+func (f *fakeKMS) DecryptWithContext(_ aws.Context, in *kms.DecryptInput, _ ...request.Option) (*kms.DecryptOutput, error) {
+	f.decryptCalls++
+	f.grantTokens = in.GrantTokens
+
+	prefix := []byte(*in.KeyId + ":")
+	if !bytes.HasPrefix(in.CiphertextBlob, prefix) {
+		return nil, errors.New("invalid ciphertext")
+	}
+	return &kms.DecryptOutput{
+		KeyId:     in.KeyId,
+		Plaintext: in.CiphertextBlob[len(prefix):],
+	}, nil
+}
+
+func newTestComponent(t *testing.T, props map[string]string) (*kmsCrypto, *fakeKMS, *clock.Mock) {
+	t.Helper()
+
+	clk := clock.NewMock()
+	k := NewAWSKMSCrypto(logger.NewLogger("test")).(*kmsCrypto)
+	k.clock = clk
+
+	err := k.md.InitWithMetadata(contribCrypto.Metadata{Base: metadata.Base{Properties: props}})
+	require.NoError(t, err)
+	k.keyCache = contribCrypto.NewPubKeyCache(k.getKeyCacheFn)
+	k.dataKeyCache = newDataKeyCache(k.md.dataKeyCacheTTL, clk)
+
+	fake := &fakeKMS{}
+	k.client = fake
+	return k, fake, clk
+}
+
+func TestMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		md := kmsMetadata{}
+		err := md.InitWithMetadata(contribCrypto.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"region": "us-west-2",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, defaultRequestTimeout, md.RequestTimeout)
+		assert.Equal(t, defaultDataKeyCacheTTL, md.dataKeyCacheTTL)
+		assert.Empty(t, md.grantTokens)
+	})
+
+	t.Run("custom values", func(t *testing.T) {
+		md := kmsMetadata{}
+		err := md.InitWithMetadata(contribCrypto.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"region":          "us-west-2",
+			"grantTokens":     "token1, token2,",
+			"dataKeyCacheTTL": "0",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), md.dataKeyCacheTTL)
+		assert.Equal(t, []string{"token1", "token2"}, aws.StringValueSlice(md.grantTokens))
+	})
+
+	t.Run("missing region", func(t *testing.T) {
+		md := kmsMetadata{}
+		err := md.InitWithMetadata(contribCrypto.Metadata{})
+		require.Error(t, err)
+	})
+}
+
+func TestEnvelopeEncryption(t *testing.T) {
+	plaintext := []byte("hello world")
+	aad := []byte("associated data")
+
+	t.Run("round trip with cached data key", func(t *testing.T) {
+		k, fake, _ := newTestComponent(t, map[string]string{
+			"region":      "us-west-2",
+			"grantTokens": "grant",
+		})
+
+		ciphertext1, tag1, err := k.Encrypt(context.Background(), plaintext, AlgorithmEnvelope, "alias/mykey", nil, aad)
+		require.NoError(t, err)
+		ciphertext2, tag2, err := k.Encrypt(context.Background(), plaintext, AlgorithmEnvelope, "alias/mykey", nil, aad)
+		require.NoError(t, err)
+		assert.NotEqual(t, ciphertext1, ciphertext2)
+		assert.Equal(t, 1, fake.generateCalls)
+		assert.Equal(t, []string{"grant"}, aws.StringValueSlice(fake.grantTokens))
+
+		decrypted, err := k.Decrypt(context.Background(), ciphertext1, AlgorithmEnvelope, "alias/mykey", nil, tag1, aad)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+		decrypted, err = k.Decrypt(context.Background(), ciphertext2, AlgorithmEnvelope, "alias/mykey", nil, tag2, aad)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+		assert.Equal(t, 0, fake.decryptCalls)
+
+		_, err = k.Decrypt(context.Background(), ciphertext1, AlgorithmEnvelope, "alias/mykey", nil, tag1, []byte("other"))
+		require.Error(t, err)
+	})
+
+	t.Run("data key expires", func(t *testing.T) {
+		k, fake, clk := newTestComponent(t, map[string]string{
+			"region":          "us-west-2",
+			"dataKeyCacheTTL": "1m",
+		})
+
+		ciphertext, tag, err := k.Encrypt(context.Background(), plaintext, AlgorithmEnvelope, "mykey", nil, nil)
+		require.NoError(t, err)
+		clk.Add(2 * time.Minute)
+
+		_, _, err = k.Encrypt(context.Background(), plaintext, AlgorithmEnvelope, "mykey", nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 2, fake.generateCalls)
+
+		decrypted, err := k.Decrypt(context.Background(), ciphertext, AlgorithmEnvelope, "mykey", nil, tag, nil)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+		assert.Equal(t, 1, fake.decryptCalls)
+	})
+
+	t.Run("caching disabled", func(t *testing.T) {
+		k, fake, _ := newTestComponent(t, map[string]string{
+			"region":          "us-west-2",
+			"dataKeyCacheTTL": "0",
+		})
+
+		for i := 0; i < 2; i++ {
+			ciphertext, tag, err := k.Encrypt(context.Background(), plaintext, AlgorithmEnvelope, "mykey", nil, nil)
+			require.NoError(t, err)
+			decrypted, err := k.Decrypt(context.Background(), ciphertext, AlgorithmEnvelope, "mykey", nil, tag, nil)
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, decrypted)
+		}
+		assert.Equal(t, 2, fake.generateCalls)
+		assert.Equal(t, 2, fake.decryptCalls)
+	})
+
+	t.Run("invalid nonce", func(t *testing.T) {
+		k, _, _ := newTestComponent(t, map[string]string{"region": "us-west-2"})
+
+		_, _, err := k.Encrypt(context.Background(), plaintext, AlgorithmEnvelope, "mykey", []byte("short"), nil)
+		require.Error(t, err)
+	})
+}
+
+func TestEncryptInKMS(t *testing.T) {
+	k, fake, _ := newTestComponent(t, map[string]string{"region": "us-west-2"})
+
+	ciphertext, _, err := k.Encrypt(context.Background(), []byte("hello"), AlgorithmSymmetricDefault, "mykey", nil, []byte("aad"))
+	require.NoError(t, err)
+	require.Contains(t, fake.encContext, associatedDataContextKey)
+
+	decrypted, err := k.Decrypt(context.Background(), ciphertext, AlgorithmSymmetricDefault, "mykey", nil, nil, []byte("aad"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), decrypted)
+
+	_, _, err = k.Encrypt(context.Background(), []byte("hello"), "A128CBC", "mykey", nil, nil)
+	require.Error(t, err)
+}
+
+func TestWrapKey(t *testing.T) {
+	k, _, _ := newTestComponent(t, map[string]string{"region": "us-west-2"})
+
+	raw := make([]byte, 32)
+	_, err := io.ReadFull(rand.Reader, raw)
+	require.NoError(t, err)
+	key, err := jwk.FromRaw(raw)
+	require.NoError(t, err)
+
+	wrapped, tag, err := k.WrapKey(context.Background(), key, AlgorithmEnvelope, "mykey", nil, nil)
+	require.NoError(t, err)
+
+	unwrapped, err := k.UnwrapKey(context.Background(), wrapped, AlgorithmEnvelope, "mykey", nil, tag, nil)
+	require.NoError(t, err)
+	var unwrappedRaw []byte
+	require.NoError(t, unwrapped.Raw(&unwrappedRaw))
+	assert.Equal(t, raw, unwrappedRaw)
+}
+
+func TestDecodeEnvelope(t *testing.T) {
+	nonce := make([]byte, envelopeNonceSize)
+	data, err := encodeEnvelope([]byte("key"), nonce, []byte("ciphertext"))
+	require.NoError(t, err)
+
+	encryptedKey, decodedNonce, ciphertext, err := decodeEnvelope(data)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("key"), encryptedKey)
+	assert.Equal(t, nonce, decodedNonce)
+	assert.Equal(t, []byte("ciphertext"), ciphertext)
+
+	_, _, _, err = decodeEnvelope(data[:10])
+	require.Error(t, err)
+	_, _, _, err = decodeEnvelope(append([]byte{2}, data[1:]...))
+	require.Error(t, err)
+}
+
+func TestSupportedAlgorithms(t *testing.T) {
+	k := kmsCrypto{}
+	assert.Contains(t, k.SupportedEncryptionAlgorithms(), AlgorithmEnvelope)
+	assert.Contains(t, k.SupportedEncryptionAlgorithms(), "RSA-OAEP-256")
+	assert.Contains(t, k.SupportedSignatureAlgorithms(), "ES256")
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+const (
+	envelopeVersion = 1
+	// Size of the nonce used with AES-GCM.
+	envelopeNonceSize = 12
+)
+
+// dataKey is a data key generated by KMS.
+type dataKey struct {
+	// Plaintext data key.
+	plaintext []byte
+	// Data key encrypted with the KMS key.
+	encrypted []byte
+	expires   time.Time
+}
+
+// dataKeyCache caches data keys used for envelope encryption.
+// For every KMS key, there is one active data key that is used to encrypt messages until it expires.
+// Data keys that were decrypted with KMS are cached too, by their encrypted value.
+type dataKeyCache struct {
+	ttl   time.Duration
+	clock clock.Clock
+
+	active    map[string]*dataKey
+	decrypted map[string]*dataKey
+	lock      sync.Mutex
+}
+
+func newDataKeyCache(ttl time.Duration, clk clock.Clock) *dataKeyCache {
+	return &dataKeyCache{
+		ttl:       ttl,
+		clock:     clk,
+		active:    make(map[string]*dataKey),
+		decrypted: make(map[string]*dataKey),
+	}
+}
+
+// GetActive returns the active data key for the KMS key, if any.
+func (c *dataKeyCache) GetActive(keyName string) *dataKey {
+	if c.ttl == 0 {
+		return nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	dk := c.active[keyName]
+	if dk == nil || !c.clock.Now().Before(dk.expires) {
+		return nil
+	}
+	return dk
+}
+
+// SetActive stores a newly-generated data key as the active one for the KMS key.
+func (c *dataKeyCache) SetActive(keyName string, plaintext []byte, encrypted []byte) {
+	if c.ttl == 0 {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.removeExpired()
+	dk := &dataKey{
+		plaintext: plaintext,
+		encrypted: encrypted,
+		expires:   c.clock.Now().Add(c.ttl),
+	}
+	c.active[keyName] = dk
+	c.decrypted[string(encrypted)] = dk
+}
+
+// GetDecrypted returns the plaintext of an encrypted data key, if it's in the cache.
+func (c *dataKeyCache) GetDecrypted(encrypted []byte) []byte {
+	if c.ttl == 0 {
+		return nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	dk := c.decrypted[string(encrypted)]
+	if dk == nil || !c.clock.Now().Before(dk.expires) {
+		return nil
+	}
+	return dk.plaintext
+}
+
+// SetDecrypted stores a data key that was decrypted by KMS.
+func (c *dataKeyCache) SetDecrypted(plaintext []byte, encrypted []byte) {
+	if c.ttl == 0 {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.removeExpired()
+	c.decrypted[string(encrypted)] = &dataKey{
+		plaintext: plaintext,
+		encrypted: encrypted,
+		expires:   c.clock.Now().Add(c.ttl),
+	}
+}
+
+// removeExpired deletes all expired data keys.
+// It must be called while holding the lock.
+func (c *dataKeyCache) removeExpired() {
+	now := c.clock.Now()
+	for k, dk := range c.active {
+		if !now.Before(dk.expires) {
+			delete(c.active, k)
+		}
+	}
+	for k, dk := range c.decrypted {
+		if !now.Before(dk.expires) {
+			delete(c.decrypted, k)
+		}
+	}
+}
+
+// encodeEnvelope returns the ciphertext of a message encrypted with envelope encryption: version, big-endian length of the encrypted data key, encrypted data key, nonce, and encrypted message.
+func encodeEnvelope(encryptedKey []byte, nonce []byte, ciphertext []byte) ([]byte, error) {
+	if len(encryptedKey) > 0xFFFF {
+		return nil, errors.New("encrypted data key is too large")
+	}
+	if len(nonce) != envelopeNonceSize {
+		return nil, errors.New("invalid nonce size")
+	}
+
+	out := make([]byte, 3, 3+len(encryptedKey)+len(nonce)+len(ciphertext))
+	out[0] = envelopeVersion
+	binary.BigEndian.PutUint16(out[1:], uint16(len(encryptedKey)))
+	out = append(out, encryptedKey...)
+	out = append(out, nonce...)
+	out = append(out, ciphertext...)
+	return out, nil
+}
+
+// decodeEnvelope parses a ciphertext created by encodeEnvelope.
+func decodeEnvelope(data []byte) (encryptedKey []byte, nonce []byte, ciphertext []byte, err error) {
+	if len(data) < 3 {
+		return nil, nil, nil, errors.New("ciphertext is too short")
+	}
+	if data[0] != envelopeVersion {
+		return nil, nil, nil, errors.New("ciphertext was not encrypted with envelope encryption")
+	}
+	size := int(binary.BigEndian.Uint16(data[1:]))
+	if size == 0 || len(data) < 3+size+envelopeNonceSize {
+		return nil, nil, nil, errors.New("ciphertext is too short")
+	}
+
+	encryptedKey = data[3 : 3+size]
+	nonce = data[3+size : 3+size+envelopeNonceSize]
+	ciphertext = data[3+size+envelopeNonceSize:]
+	return encryptedKey, nonce, ciphertext, nil
+}
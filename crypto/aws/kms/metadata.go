@@ -0,0 +1,114 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	"github.com/dapr/components-contrib/metadata"
+)
+
+const (
+	defaultRequestTimeout  = 30 * time.Second
+	defaultDataKeyCacheTTL = 5 * time.Minute
+)
+
+type kmsMetadata struct {
+	// AWS region where the keys are stored (required).
+	Region string `json:"region" mapstructure:"region"`
+	// Custom endpoint for KMS, for example to connect to LocalStack.
+	Endpoint string `json:"endpoint" mapstructure:"endpoint"`
+	// AWS access key ID.
+	AccessKey string `json:"accessKey" mapstructure:"accessKey"`
+	// AWS secret access key.
+	SecretKey string `json:"secretKey" mapstructure:"secretKey"`
+	// AWS session token.
+	SessionToken string `json:"sessionToken" mapstructure:"sessionToken"`
+
+	// Comma-separated list of grant tokens that are sent with every request to KMS.
+	GrantTokens string `json:"grantTokens" mapstructure:"grantTokens"`
+
+	// Time data keys used for envelope encryption are cached for, as a Go duration string (e.g. "5m").
+	// Within this time, the same data key is used to encrypt multiple messages, and decrypted data keys are reused.
+	// Set to "0" to generate a new data key for every message and never cache decrypted data keys.
+	// Defaults to "5m".
+	DataKeyCacheTTL *time.Duration `json:"dataKeyCacheTTL" mapstructure:"dataKeyCacheTTL"`
+
+	// Timeout for network requests, as a Go duration string (e.g. "30s")
+	// Defaults to "30s".
+	RequestTimeout time.Duration `json:"requestTimeout" mapstructure:"requestTimeout"`
+
+	// Internal properties
+	grantTokens     []*string
+	dataKeyCacheTTL time.Duration
+}
+
+func (m *kmsMetadata) InitWithMetadata(meta contribCrypto.Metadata) error {
+	m.reset()
+
+	// Decode the metadata
+	err := metadata.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	// Region
+	if m.Region == "" {
+		return errors.New("metadata property 'region' is required")
+	}
+
+	// Set default requestTimeout if empty
+	if m.RequestTimeout < time.Second {
+		m.RequestTimeout = defaultRequestTimeout
+	}
+
+	// Data key cache TTL
+	m.dataKeyCacheTTL = defaultDataKeyCacheTTL
+	if m.DataKeyCacheTTL != nil {
+		if *m.DataKeyCacheTTL < 0 {
+			return errors.New("metadata property 'dataKeyCacheTTL' must not be negative")
+		}
+		m.dataKeyCacheTTL = *m.DataKeyCacheTTL
+	}
+
+	// Grant tokens
+	for _, t := range strings.Split(m.GrantTokens, ",") {
+		t = strings.TrimSpace(t)
+		if t != "" {
+			m.grantTokens = append(m.grantTokens, aws.String(t))
+		}
+	}
+
+	return nil
+}
+
+// Reset the object
+func (m *kmsMetadata) reset() {
+	m.Region = ""
+	m.Endpoint = ""
+	m.AccessKey = ""
+	m.SecretKey = ""
+	m.SessionToken = ""
+	m.GrantTokens = ""
+	m.DataKeyCacheTTL = nil
+	m.RequestTimeout = defaultRequestTimeout
+
+	m.grantTokens = nil
+	m.dataKeyCacheTTL = defaultDataKeyCacheTTL
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyvault
+
+import (
+	"context"
+	"io"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+)
+
+// DefaultEnvelopeWrapAlgorithm is the default algorithm used to wrap the data encryption key of encrypted streams.
+const DefaultEnvelopeWrapAlgorithm = "RSA-OAEP-256"
+
+var _ contribCrypto.SubtleCryptoStream = (*keyvaultCrypto)(nil)
+
+// EncryptStream encrypts all the data read from in and writes the encrypted stream to out, using envelope encryption.
+// Only the data encryption key is sent to Key Vault, and only when the key encryption key can't be used locally.
+func (k *keyvaultCrypto) EncryptStream(ctx context.Context, out io.Writer, in io.Reader, opts contribCrypto.StreamEncryptOptions) error {
+	if opts.Algorithm == "" {
+		opts.Algorithm = DefaultEnvelopeWrapAlgorithm
+	}
+	return contribCrypto.EncryptStream(ctx, k, out, in, opts)
+}
+
+// DecryptStream decrypts a stream created by EncryptStream and writes the plaintext to out.
+func (k *keyvaultCrypto) DecryptStream(ctx context.Context, out io.Writer, in io.Reader, opts contribCrypto.StreamDecryptOptions) error {
+	return contribCrypto.DecryptStream(ctx, k, out, in, opts)
+}
@@ -17,7 +17,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
 
@@ -29,11 +31,17 @@ import (
 	"github.com/dapr/kit/logger"
 )
 
+// keySetProvider returns the current keys.
+type keySetProvider interface {
+	KeySet() jwk.Set
+}
+
 type jwksCrypto struct {
 	contribCrypto.LocalCryptoBaseComponent
 
 	md      jwksMetadata
-	cache   *jwkscache.JWKSCache
+	keys    keySetProvider
+	remote  *remoteKeySet
 	logger  logger.Logger
 	closed  atomic.Bool
 	closeCh chan struct{}
@@ -63,20 +71,26 @@ func (k *jwksCrypto) Init(ctx context.Context, metadata contribCrypto.Metadata)
 		return fmt.Errorf("failed to load metadata: %w", err)
 	}
 
+	// Keys fetched from HTTP(S) endpoints are polled by the component
+	if urls := k.md.urls(); len(urls) > 0 {
+		return k.initRemote(ctx, urls)
+	}
+
 	// Init the JWKS cache
-	k.cache = jwkscache.NewJWKSCache(k.md.JWKS, k.logger)
-	k.cache.SetMinRefreshInterval(k.md.MinRefreshInterval)
-	k.cache.SetRequestTimeout(k.md.RequestTimeout)
+	cache := jwkscache.NewJWKSCache(k.md.JWKS, k.logger)
+	cache.SetMinRefreshInterval(k.md.MinRefreshInterval)
+	cache.SetRequestTimeout(k.md.RequestTimeout)
+	k.keys = cache
 
 	// Start the JWKS cache in background
 	startErrCh := make(chan error)
 	go func() {
-		startErrCh <- k.cache.Start(k.getContext())
+		startErrCh <- cache.Start(k.getContext())
 	}()
 
 	// Wait for the cache to be ready
 	// Here we use the init context
-	err = k.cache.WaitForCacheReady(ctx)
+	err = cache.WaitForCacheReady(ctx)
 	if err != nil {
 		// If we have an initialization error, return
 		return err
@@ -85,6 +99,46 @@ func (k *jwksCrypto) Init(ctx context.Context, metadata contribCrypto.Metadata)
 	return nil
 }
 
+// Init the key set from HTTP(S) endpoints.
+func (k *jwksCrypto) initRemote(ctx context.Context, urls []string) error {
+	for _, u := range urls {
+		if strings.HasPrefix(u, "http://") {
+			k.logger.Warn("Loading JWK from an HTTP endpoint without TLS: this is not recommended on production environments.")
+			break
+		}
+	}
+
+	tlsConfig, err := k.md.tlsConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load TLS configuration: %w", err)
+	}
+	client := &http.Client{
+		Timeout: k.md.RequestTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+		},
+	}
+
+	k.remote = newRemoteKeySet(urls, client, k.md.RequestTimeout, k.md.MinRefreshInterval, k.logger)
+	k.keys = k.remote
+
+	// Fetch the JWKS right away, so we can check they're valid and populate the key set
+	// Here we use the init context
+	err = k.remote.Refresh(ctx)
+	if err != nil {
+		return err
+	}
+
+	pollCtx := k.getContext()
+	k.wg.Add(1)
+	go func() {
+		defer k.wg.Done()
+		k.remote.Run(pollCtx, k.md.RefreshInterval)
+	}()
+
+	return nil
+}
+
 // Returns a context that is canceled when the component is closed.
 func (k *jwksCrypto) getContext() context.Context {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -114,12 +168,20 @@ func (k *jwksCrypto) Features() []contribCrypto.Feature {
 
 // Retrieves a key (public or private or symmetric) from the JWKS
 func (k *jwksCrypto) retrieveKeyFromSecretFn(parentCtx context.Context, kid string) (jwk.Key, error) {
-	jwks := k.cache.KeySet()
+	jwks := k.keys.KeySet()
 	if jwks == nil {
 		return nil, errors.New("no JWKS loaded")
 	}
 
 	key, found := jwks.LookupKeyID(kid)
+	if !found && k.remote != nil {
+		// The key may have been added after the last refresh
+		err := k.remote.RefreshIfStale(parentCtx)
+		if err != nil {
+			k.logger.Warnf("Error while refreshing JWKS: %v", err)
+		}
+		key, found = k.keys.KeySet().LookupKeyID(kid)
+	}
 	if !found {
 		return nil, contribCrypto.ErrKeyNotFound
 	}
@@ -14,7 +14,11 @@ limitations under the License.
 package jwks
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	contribCrypto "github.com/dapr/components-contrib/crypto"
@@ -24,6 +28,7 @@ import (
 const (
 	defaultRequestTimeout     = 30 * time.Second
 	defaultMinRefreshInterval = 10 * time.Minute
+	defaultRefreshInterval    = time.Hour
 )
 
 type jwksMetadata struct {
@@ -31,8 +36,11 @@ type jwksMetadata struct {
 	// - The actual JWKS as a JSON-encoded string (optionally encoded with Base64-standard).
 	// - A URL to a HTTP(S) endpoint returning the JWKS.
 	// - A path to a local file containing the JWKS.
-	// Required.
+	// Required unless "jwksURLs" is set.
 	JWKS string `json:"jwks" mapstructure:"jwks"`
+	// Comma-separated list of HTTP(S) URLs of JWKS endpoints.
+	// The keys of all endpoints, including the one in "jwks" if it's a URL, are merged in a single key set; if the same key ID is returned by multiple endpoints, the first one wins.
+	JWKSURLs []string `json:"jwksURLs" mapstructure:"jwksURLs"`
 	// Timeout for network requests, as a Go duration string (e.g. "30s")
 	// Defaults to "30s".
 	RequestTimeout time.Duration `json:"requestTimeout" mapstructure:"requestTimeout"`
@@ -40,6 +48,16 @@ type jwksMetadata struct {
 	// Only applies when the JWKS is fetched from a HTTP(S) URL.
 	// Defaults to "10m".
 	MinRefreshInterval time.Duration `json:"minRefreshInterval" mapstructure:"minRefreshInterval"`
+	// Interval for polling the JWKS endpoints, as a Go duration string.
+	// Requests are conditional, so JWKS that haven't changed are not downloaded again.
+	// Only applies when the JWKS is fetched from a HTTP(S) URL.
+	// Defaults to "1h".
+	RefreshInterval time.Duration `json:"refreshInterval" mapstructure:"refreshInterval"`
+	// PEM-encoded CA certificate used to verify the JWKS endpoints, in addition to the system's root CAs.
+	CACert string `json:"caCert" mapstructure:"caCert"`
+	// PEM-encoded client certificate and private key, for authenticating with the JWKS endpoints using mutual TLS.
+	ClientCert string `json:"clientCert" mapstructure:"clientCert"`
+	ClientKey  string `json:"clientKey" mapstructure:"clientKey"`
 }
 
 func (m *jwksMetadata) InitWithMetadata(meta contribCrypto.Metadata) error {
@@ -52,9 +70,20 @@ func (m *jwksMetadata) InitWithMetadata(meta contribCrypto.Metadata) error {
 	}
 
 	// Require the JWKS property to not be empty (further validation will be performed by the component)
-	if m.JWKS == "" {
+	if m.JWKS == "" && len(m.JWKSURLs) == 0 {
 		return errors.New("metadata property 'jwks' is required")
 	}
+	if m.JWKS != "" && len(m.JWKSURLs) > 0 && !isURL(m.JWKS) {
+		return errors.New("metadata property 'jwksURLs' can only be used when 'jwks' is empty or is a URL")
+	}
+	for _, u := range m.JWKSURLs {
+		if !isURL(u) {
+			return errors.New("metadata property 'jwksURLs' must contain HTTP(S) URLs only")
+		}
+	}
+	if (m.ClientCert == "") != (m.ClientKey == "") {
+		return errors.New("metadata properties 'clientCert' and 'clientKey' must be set together")
+	}
 
 	// Set default requestTimeout and minRefreshInterval if empty
 	if m.RequestTimeout < time.Millisecond {
@@ -63,13 +92,62 @@ func (m *jwksMetadata) InitWithMetadata(meta contribCrypto.Metadata) error {
 	if m.MinRefreshInterval < time.Second {
 		m.MinRefreshInterval = defaultMinRefreshInterval
 	}
+	if m.RefreshInterval < time.Second {
+		m.RefreshInterval = defaultRefreshInterval
+	}
 
 	return nil
 }
 
+// urls returns the URLs of the JWKS endpoints.
+func (m *jwksMetadata) urls() []string {
+	urls := make([]string, 0, len(m.JWKSURLs)+1)
+	if isURL(m.JWKS) {
+		urls = append(urls, m.JWKS)
+	}
+	return append(urls, m.JWKSURLs...)
+}
+
+// tlsConfig returns the TLS configuration for connecting to the JWKS endpoints.
+func (m *jwksMetadata) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if m.CACert != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(m.CACert)) {
+			return nil, errors.New("failed to parse the CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if m.ClientCert != "" {
+		cert, err := tls.X509KeyPair([]byte(m.ClientCert), []byte(m.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the client certificate and key: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+func isURL(val string) bool {
+	return strings.HasPrefix(val, "https://") || strings.HasPrefix(val, "http://")
+}
+
 // Reset the object
 func (m *jwksMetadata) reset() {
 	m.JWKS = ""
+	m.JWKSURLs = nil
 	m.RequestTimeout = defaultRequestTimeout
 	m.MinRefreshInterval = defaultMinRefreshInterval
+	m.RefreshInterval = defaultRefreshInterval
+	m.CACert = ""
+	m.ClientCert = ""
+	m.ClientKey = ""
 }
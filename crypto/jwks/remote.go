@@ -0,0 +1,201 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+
+	"github.com/dapr/kit/logger"
+)
+
+// remoteKeySet is a key set merged from the JWKS returned by one or more HTTP(S) endpoints.
+// The endpoints are polled using conditional requests, so JWKS that haven't changed are not downloaded again.
+type remoteKeySet struct {
+	urls               []string
+	client             *http.Client
+	requestTimeout     time.Duration
+	minRefreshInterval time.Duration
+	logger             logger.Logger
+
+	// State of each endpoint, in the same order as urls
+	sources     []remoteSource
+	jwks        jwk.Set
+	lastRefresh time.Time
+	lock        sync.RWMutex
+	// Serializes refreshes
+	refreshLock sync.Mutex
+}
+
+// remoteSource contains the last response of a JWKS endpoint.
+type remoteSource struct {
+	etag         string
+	lastModified string
+	jwks         jwk.Set
+}
+
+func newRemoteKeySet(urls []string, client *http.Client, requestTimeout time.Duration, minRefreshInterval time.Duration, logger logger.Logger) *remoteKeySet {
+	return &remoteKeySet{
+		urls:               urls,
+		client:             client,
+		requestTimeout:     requestTimeout,
+		minRefreshInterval: minRefreshInterval,
+		logger:             logger,
+		sources:            make([]remoteSource, len(urls)),
+	}
+}
+
+// KeySet returns the jwk.Set with the current keys.
+func (r *remoteKeySet) KeySet() jwk.Set {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.jwks
+}
+
+// Run polls the endpoints at the given interval until the context is canceled.
+func (r *remoteKeySet) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			err := r.Refresh(ctx)
+			if err != nil {
+				r.logger.Warnf("Error while refreshing JWKS: %v", err)
+			}
+		}
+	}
+}
+
+// RefreshIfStale refreshes the key set if it wasn't refreshed in the last minRefreshInterval.
+// It's invoked when a key isn't found, in case it was added after the last refresh.
+func (r *remoteKeySet) RefreshIfStale(ctx context.Context) error {
+	r.lock.RLock()
+	stale := time.Since(r.lastRefresh) >= r.minRefreshInterval
+	r.lock.RUnlock()
+	if !stale {
+		return nil
+	}
+	return r.Refresh(ctx)
+}
+
+// Refresh fetches the JWKS from all endpoints and updates the key set.
+// Endpoints that can't be reached keep the keys they returned last; an error is returned if any request failed.
+func (r *remoteKeySet) Refresh(ctx context.Context) error {
+	r.refreshLock.Lock()
+	defer r.refreshLock.Unlock()
+
+	var errs []error
+	changed := false
+	for i, u := range r.urls {
+		updated, err := r.fetch(ctx, u, &r.sources[i])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to fetch JWKS from %s: %w", u, err))
+			continue
+		}
+		changed = changed || updated
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.lastRefresh = time.Now()
+	if changed || r.jwks == nil {
+		r.jwks = r.merge()
+	}
+
+	return errors.Join(errs...)
+}
+
+// fetch performs a conditional request to the endpoint, updating src if the JWKS has changed.
+func (r *remoteKeySet) fetch(parentCtx context.Context, u string, src *remoteSource) (bool, error) {
+	ctx, cancel := context.WithTimeout(parentCtx, r.requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if src.jwks != nil {
+		if src.etag != "" {
+			req.Header.Set("If-None-Match", src.etag)
+		}
+		if src.lastModified != "" {
+			req.Header.Set("If-Modified-Since", src.lastModified)
+		}
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotModified && src.jwks != nil:
+		return false, nil
+	case res.StatusCode != http.StatusOK:
+		return false, fmt.Errorf("unexpected response status code %d", res.StatusCode)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return false, fmt.Errorf("failed to read response body: %w", err)
+	}
+	jwks, err := jwk.Parse(body)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	src.jwks = jwks
+	src.etag = res.Header.Get("ETag")
+	src.lastModified = res.Header.Get("Last-Modified")
+	return true, nil
+}
+
+// merge returns a key set containing the keys of all sources.
+// Must be invoked while holding the write lock.
+func (r *remoteKeySet) merge() jwk.Set {
+	merged := jwk.NewSet()
+	for i, src := range r.sources {
+		if src.jwks == nil {
+			continue
+		}
+		for j := 0; j < src.jwks.Len(); j++ {
+			key, ok := src.jwks.Key(j)
+			if !ok {
+				continue
+			}
+			if kid := key.KeyID(); kid != "" {
+				if _, found := merged.LookupKeyID(kid); found {
+					r.logger.Warnf("Ignoring key %s from %s: a key with the same ID was returned by another endpoint", kid, r.urls[i])
+					continue
+				}
+			}
+			_ = merged.AddKey(key)
+		}
+	}
+	return merged
+}
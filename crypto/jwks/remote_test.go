@@ -0,0 +1,235 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// jwksServer serves a JWKS, supporting conditional requests with ETags.
+type jwksServer struct {
+	lock     sync.Mutex
+	body     []byte
+	etag     string
+	requests atomic.Int32
+	notMod   atomic.Int32
+	fail     atomic.Bool
+}
+
+func (s *jwksServer) setKeys(t *testing.T, etag string, kids ...string) {
+	t.Helper()
+
+	set := jwk.NewSet()
+	for _, kid := range kids {
+		key, err := jwk.FromRaw([]byte("secret-" + kid))
+		require.NoError(t, err)
+		require.NoError(t, key.Set(jwk.KeyIDKey, kid))
+		require.NoError(t, set.AddKey(key))
+	}
+	body, err := json.Marshal(set)
+	require.NoError(t, err)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.body = body
+	s.etag = etag
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+	if s.fail.Load() {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if r.Header.Get("If-None-Match") == s.etag {
+		s.notMod.Add(1)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", s.etag)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(s.body)
+}
+
+func TestRemoteKeySet(t *testing.T) {
+	log := logger.NewLogger("test")
+
+	srv1 := &jwksServer{}
+	srv1.setKeys(t, `"v1"`, "a", "shared")
+	ts1 := httptest.NewServer(srv1)
+	defer ts1.Close()
+
+	srv2 := &jwksServer{}
+	srv2.setKeys(t, `"v1"`, "b", "shared")
+	ts2 := httptest.NewServer(srv2)
+	defer ts2.Close()
+
+	r := newRemoteKeySet([]string{ts1.URL, ts2.URL}, ts1.Client(), 5*time.Second, time.Hour, log)
+
+	t.Run("keys of all endpoints are merged", func(t *testing.T) {
+		require.NoError(t, r.Refresh(context.Background()))
+
+		set := r.KeySet()
+		assert.Equal(t, 3, set.Len())
+		for _, kid := range []string{"a", "b"} {
+			_, found := set.LookupKeyID(kid)
+			assert.True(t, found, kid)
+		}
+
+		// The key from the first endpoint wins
+		key, found := set.LookupKeyID("shared")
+		require.True(t, found)
+		var raw []byte
+		require.NoError(t, key.Raw(&raw))
+		assert.Equal(t, "secret-shared", string(raw))
+	})
+
+	t.Run("unchanged JWKS are not downloaded again", func(t *testing.T) {
+		before := r.KeySet()
+		require.NoError(t, r.Refresh(context.Background()))
+		assert.Equal(t, int32(1), srv1.notMod.Load())
+		assert.Equal(t, int32(1), srv2.notMod.Load())
+		assert.Same(t, before, r.KeySet())
+	})
+
+	t.Run("changed JWKS are updated", func(t *testing.T) {
+		srv2.setKeys(t, `"v2"`, "c")
+		require.NoError(t, r.Refresh(context.Background()))
+
+		set := r.KeySet()
+		_, found := set.LookupKeyID("b")
+		assert.False(t, found)
+		_, found = set.LookupKeyID("c")
+		assert.True(t, found)
+	})
+
+	t.Run("keys are retained when an endpoint fails", func(t *testing.T) {
+		srv1.fail.Store(true)
+		defer srv1.fail.Store(false)
+
+		err := r.Refresh(context.Background())
+		require.Error(t, err)
+		assert.ErrorContains(t, err, ts1.URL)
+
+		_, found := r.KeySet().LookupKeyID("a")
+		assert.True(t, found)
+	})
+
+	t.Run("refresh if stale", func(t *testing.T) {
+		requests := srv1.requests.Load()
+		require.NoError(t, r.RefreshIfStale(context.Background()))
+		assert.Equal(t, requests, srv1.requests.Load())
+
+		r.minRefreshInterval = time.Nanosecond
+		require.NoError(t, r.RefreshIfStale(context.Background()))
+		assert.Equal(t, requests+1, srv1.requests.Load())
+	})
+}
+
+func TestInitRemoteWithMutualTLS(t *testing.T) {
+	clientCertPEM, clientKeyPEM, clientCert := generateClientCert(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	srv := &jwksServer{}
+	srv.setKeys(t, `"v1"`, "mykey")
+	ts := httptest.NewUnstartedServer(srv)
+	ts.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	caCertPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	properties := map[string]string{
+		"jwks":   ts.URL,
+		"caCert": string(caCertPEM),
+	}
+
+	t.Run("client certificate is required", func(t *testing.T) {
+		k := NewJWKSCrypto(logger.NewLogger("test"))
+		err := k.Init(context.Background(), contribCrypto.Metadata{Base: metadata.Base{Properties: properties}})
+		require.Error(t, err)
+	})
+
+	t.Run("keys are fetched with the client certificate", func(t *testing.T) {
+		properties["clientCert"] = string(clientCertPEM)
+		properties["clientKey"] = string(clientKeyPEM)
+
+		k := NewJWKSCrypto(logger.NewLogger("test")).(*jwksCrypto)
+		err := k.Init(context.Background(), contribCrypto.Metadata{Base: metadata.Base{Properties: properties}})
+		require.NoError(t, err)
+		defer k.Close()
+
+		key, err := k.retrieveKeyFromSecretFn(context.Background(), "mykey")
+		require.NoError(t, err)
+		assert.Equal(t, "mykey", key.KeyID())
+
+		_, err = k.retrieveKeyFromSecretFn(context.Background(), "notfound")
+		require.ErrorIs(t, err, contribCrypto.ErrKeyNotFound)
+	})
+}
+
+func generateClientCert(t *testing.T) (certPEM []byte, keyPEM []byte, cert *x509.Certificate) {
+	t.Helper()
+
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &pk.PublicKey, pk)
+	require.NoError(t, err)
+	cert, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(pk)
+	require.NoError(t, err)
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, cert
+}
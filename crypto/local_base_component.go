@@ -17,6 +17,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"sync"
 
 	"github.com/lestrrat-go/jwx/v2/jwa"
@@ -212,6 +213,49 @@ func (k LocalCryptoBaseComponent) Verify(parentCtx context.Context, digest []byt
 	return valid, nil
 }
 
+func (k LocalCryptoBaseComponent) EncryptStream(parentCtx context.Context, out io.Writer, in io.Reader, opts StreamEncryptOptions) error {
+	if opts.Algorithm == "" && opts.KeyName != "" {
+		// Pick the algorithm to wrap the data encryption key based on the type of the key
+		key, err := k.RetrieveKeyFn(parentCtx, opts.KeyName)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve the key encryption key: %w", err)
+		}
+		opts.Algorithm, err = defaultStreamWrapAlgorithm(key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return EncryptStream(parentCtx, k, out, in, opts)
+}
+
+func (k LocalCryptoBaseComponent) DecryptStream(parentCtx context.Context, out io.Writer, in io.Reader, opts StreamDecryptOptions) error {
+	return DecryptStream(parentCtx, k, out, in, opts)
+}
+
+// defaultStreamWrapAlgorithm returns the algorithm used to wrap the data encryption key of streams when none is specified.
+func defaultStreamWrapAlgorithm(key jwk.Key) (string, error) {
+	switch key.KeyType() {
+	case jwa.RSA:
+		return internals.Algorithm_RSA_OAEP_256, nil
+	case jwa.OctetSeq:
+		var raw []byte
+		err := key.Raw(&raw)
+		if err != nil {
+			return "", fmt.Errorf("failed to get raw key: %w", err)
+		}
+		switch len(raw) {
+		case 16:
+			return internals.Algorithm_A128KW, nil
+		case 24:
+			return internals.Algorithm_A192KW, nil
+		case 32:
+			return internals.Algorithm_A256KW, nil
+		}
+	}
+	return "", errors.New("the algorithm is required for this key")
+}
+
 func (k LocalCryptoBaseComponent) SupportedEncryptionAlgorithms() []string {
 	supportedAlgsOnce.Do(populateSupportedAlgs)
 	return supportedEncryptionAlgorithms
@@ -0,0 +1,356 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypto
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+const (
+	// DefaultStreamSegmentSize is the default size of the plaintext segments in encrypted streams.
+	DefaultStreamSegmentSize = 64 << 10
+	// MaxStreamSegmentSize is the maximum size of the plaintext segments in encrypted streams.
+	MaxStreamSegmentSize = 16 << 20
+
+	// Version of the stream format, written after the magic bytes; it must be incremented when the format changes.
+	streamVersion byte = 1
+	// Size of the data encryption key, for AES-256-GCM.
+	streamKeySize = 32
+	// Size of the random prefix of the nonces; the rest of the nonce contains the segment sequence number and the last segment flag.
+	streamNoncePrefixSize = 7
+	// Maximum size of the header.
+	streamMaxHeaderSize = 64 << 10
+
+	segmentFlagMore byte = 0
+	segmentFlagLast byte = 1
+)
+
+// Magic bytes at the beginning of encrypted streams ("Dapr crypto stream"), which are the same for all components.
+var streamMagic = []byte("DPCS")
+
+// SubtleCryptoStream is an extension to SubtleCrypto that includes methods to encrypt and decrypt streams of any size, including those that don't fit in memory.
+// Data is encrypted in segments with AES-256-GCM, using a random data encryption key that is wrapped with a key stored in the vault.
+type SubtleCryptoStream interface {
+	// EncryptStream encrypts all the data read from in and writes the encrypted stream to out.
+	EncryptStream(ctx context.Context, out io.Writer, in io.Reader, opts StreamEncryptOptions) error
+	// DecryptStream decrypts a stream created by EncryptStream and writes the plaintext to out.
+	// Plaintext is written to out as soon as each segment is authenticated, so callers must discard the output if an error is returned.
+	DecryptStream(ctx context.Context, out io.Writer, in io.Reader, opts StreamDecryptOptions) error
+}
+
+// StreamEncryptOptions contains the options for EncryptStream.
+type StreamEncryptOptions struct {
+	// Name (or name/version) of the key used to wrap the data encryption key.
+	KeyName string
+	// Algorithm used to wrap the data encryption key.
+	// If empty, components pick a default based on the key.
+	Algorithm string
+	// Size of the plaintext segments, in bytes.
+	// Defaults to DefaultStreamSegmentSize.
+	SegmentSize int
+}
+
+// StreamDecryptOptions contains the options for DecryptStream.
+type StreamDecryptOptions struct {
+	// Name (or name/version) of the key used to unwrap the data encryption key.
+	// If empty, the key name stored in the stream is used.
+	KeyName string
+}
+
+// KeyWrapper is the subset of SubtleCrypto used to wrap and unwrap the data encryption keys of encrypted streams.
+type KeyWrapper interface {
+	WrapKey(ctx context.Context, plaintextKey jwk.Key, algorithm string, keyName string, nonce []byte, associatedData []byte) (wrappedKey []byte, tag []byte, err error)
+	UnwrapKey(ctx context.Context, wrappedKey []byte, algorithm string, keyName string, nonce []byte, tag []byte, associatedData []byte) (plaintextKey jwk.Key, err error)
+}
+
+// streamHeader is stored at the beginning of the encrypted stream, serialized as JSON.
+type streamHeader struct {
+	KeyName     string `json:"kid"`
+	Algorithm   string `json:"alg"`
+	WrappedKey  []byte `json:"wk"`
+	Tag         []byte `json:"wkt,omitempty"`
+	NoncePrefix []byte `json:"np"`
+	SegmentSize int    `json:"cs"`
+}
+
+// EncryptStream encrypts all the data read from in and writes the encrypted stream to out, wrapping the data encryption key with the wrapper.
+// It can be used by components to implement SubtleCryptoStream; opts.Algorithm must be set.
+func EncryptStream(ctx context.Context, wrapper KeyWrapper, out io.Writer, in io.Reader, opts StreamEncryptOptions) error {
+	if opts.KeyName == "" {
+		return errors.New("key name is required")
+	}
+	if opts.Algorithm == "" {
+		return errors.New("algorithm is required")
+	}
+	if opts.SegmentSize == 0 {
+		opts.SegmentSize = DefaultStreamSegmentSize
+	}
+	if opts.SegmentSize < 0 || opts.SegmentSize > MaxStreamSegmentSize {
+		return fmt.Errorf("segment size must be between 1 and %d bytes", MaxStreamSegmentSize)
+	}
+
+	dek := make([]byte, streamKeySize)
+	_, err := io.ReadFull(rand.Reader, dek)
+	if err != nil {
+		return fmt.Errorf("failed to generate data encryption key: %w", err)
+	}
+	dekJWK, err := jwk.FromRaw(dek)
+	if err != nil {
+		return fmt.Errorf("failed to create JWK from data encryption key: %w", err)
+	}
+
+	hdr := streamHeader{
+		KeyName:     opts.KeyName,
+		Algorithm:   opts.Algorithm,
+		NoncePrefix: make([]byte, streamNoncePrefixSize),
+		SegmentSize: opts.SegmentSize,
+	}
+	_, err = io.ReadFull(rand.Reader, hdr.NoncePrefix)
+	if err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	hdr.WrappedKey, hdr.Tag, err = wrapper.WrapKey(ctx, dekJWK, opts.Algorithm, opts.KeyName, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to wrap data encryption key: %w", err)
+	}
+
+	return encryptSegments(out, in, dek, hdr)
+}
+
+// DecryptStream decrypts a stream created by EncryptStream and writes the plaintext to out, unwrapping the data encryption key with the wrapper.
+func DecryptStream(ctx context.Context, wrapper KeyWrapper, out io.Writer, in io.Reader, opts StreamDecryptOptions) error {
+	br := bufio.NewReader(in)
+	hdr, hdrBytes, err := readStreamHeader(br)
+	if err != nil {
+		return err
+	}
+	keyName := opts.KeyName
+	if keyName == "" {
+		keyName = hdr.KeyName
+	}
+
+	dekJWK, err := wrapper.UnwrapKey(ctx, hdr.WrappedKey, hdr.Algorithm, keyName, nil, hdr.Tag, nil)
+	if err != nil {
+		return fmt.Errorf("failed to unwrap data encryption key: %w", err)
+	}
+	var dek []byte
+	err = dekJWK.Raw(&dek)
+	if err != nil {
+		return fmt.Errorf("failed to get data encryption key: %w", err)
+	}
+
+	return decryptSegments(out, br, dek, hdr, hdrBytes)
+}
+
+// encryptSegments writes the header and the encrypted segments.
+// Each segment is encrypted with AES-GCM using the header as associated data, and a nonce that contains the sequence number of the segment and a flag for the last segment, so segments can't be reordered, removed or truncated.
+func encryptSegments(out io.Writer, in io.Reader, dek []byte, hdr streamHeader) error {
+	hdrBytes, err := encodeStreamHeader(hdr)
+	if err != nil {
+		return err
+	}
+	aead, err := newStreamAEAD(dek)
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(hdrBytes)
+	if err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	br := bufio.NewReader(in)
+	buf := make([]byte, hdr.SegmentSize)
+	sealed := make([]byte, 0, hdr.SegmentSize+aead.Overhead())
+	prefix := make([]byte, 5)
+	for seq := uint64(0); ; seq++ {
+		if seq > math.MaxUint32 {
+			return errors.New("stream is too large for the segment size")
+		}
+
+		n, err := io.ReadFull(br, buf)
+		flag := segmentFlagMore
+		switch {
+		case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+			flag = segmentFlagLast
+		case err != nil:
+			return fmt.Errorf("failed to read plaintext: %w", err)
+		default:
+			// Check if there's more data to know if this is the last segment
+			_, err = br.Peek(1)
+			if errors.Is(err, io.EOF) {
+				flag = segmentFlagLast
+			} else if err != nil {
+				return fmt.Errorf("failed to read plaintext: %w", err)
+			}
+		}
+
+		sealed = aead.Seal(sealed[:0], streamNonce(hdr.NoncePrefix, uint32(seq), flag), buf[:n], hdrBytes)
+		prefix[0] = flag
+		binary.BigEndian.PutUint32(prefix[1:], uint32(len(sealed)))
+		_, err = out.Write(prefix)
+		if err == nil {
+			_, err = out.Write(sealed)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write ciphertext: %w", err)
+		}
+
+		if flag == segmentFlagLast {
+			return nil
+		}
+	}
+}
+
+// decryptSegments decrypts the segments that follow the header.
+func decryptSegments(out io.Writer, in io.Reader, dek []byte, hdr streamHeader, hdrBytes []byte) error {
+	aead, err := newStreamAEAD(dek)
+	if err != nil {
+		return err
+	}
+
+	maxSealed := hdr.SegmentSize + aead.Overhead()
+	sealed := make([]byte, maxSealed)
+	plaintext := make([]byte, 0, hdr.SegmentSize)
+	prefix := make([]byte, 5)
+	for seq := uint64(0); ; seq++ {
+		if seq > math.MaxUint32 {
+			return errors.New("stream contains too many segments")
+		}
+
+		_, err = io.ReadFull(in, prefix)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return errors.New("encrypted stream is truncated")
+			}
+			return fmt.Errorf("failed to read ciphertext: %w", err)
+		}
+		flag := prefix[0]
+		size := int(binary.BigEndian.Uint32(prefix[1:]))
+		if (flag != segmentFlagMore && flag != segmentFlagLast) || size < aead.Overhead() || size > maxSealed {
+			return errors.New("encrypted stream is corrupted")
+		}
+
+		_, err = io.ReadFull(in, sealed[:size])
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return errors.New("encrypted stream is truncated")
+			}
+			return fmt.Errorf("failed to read ciphertext: %w", err)
+		}
+		plaintext, err = aead.Open(plaintext[:0], streamNonce(hdr.NoncePrefix, uint32(seq), flag), sealed[:size], hdrBytes)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt segment %d: %w", seq, err)
+		}
+		_, err = out.Write(plaintext)
+		if err != nil {
+			return fmt.Errorf("failed to write plaintext: %w", err)
+		}
+
+		if flag == segmentFlagLast {
+			// There must be no data after the last segment
+			n, _ := in.Read(prefix[:1])
+			if n > 0 {
+				return errors.New("encrypted stream contains data after the last segment")
+			}
+			return nil
+		}
+	}
+}
+
+func newStreamAEAD(dek []byte) (cipher.AEAD, error) {
+	if len(dek) != streamKeySize {
+		return nil, fmt.Errorf("data encryption key must be %d bytes", streamKeySize)
+	}
+	block, err := aes.NewCipher(dek)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// streamNonce returns the nonce for a segment: the random prefix, the big-endian sequence number and the last segment flag.
+func streamNonce(prefix []byte, seq uint32, flag byte) []byte {
+	nonce := make([]byte, streamNoncePrefixSize+5)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[streamNoncePrefixSize:], seq)
+	nonce[streamNoncePrefixSize+4] = flag
+	return nonce
+}
+
+// encodeStreamHeader returns the serialized header: magic, version, big-endian length of the JSON header, and the JSON header.
+func encodeStreamHeader(hdr streamHeader) ([]byte, error) {
+	enc, err := json.Marshal(hdr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize header: %w", err)
+	}
+	if len(enc) > streamMaxHeaderSize {
+		return nil, errors.New("header is too large")
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, len(streamMagic)+5+len(enc)))
+	buf.Write(streamMagic)
+	buf.WriteByte(streamVersion)
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(enc)))
+	buf.Write(enc)
+	return buf.Bytes(), nil
+}
+
+// readStreamHeader reads the header from the stream, returning it parsed and serialized.
+func readStreamHeader(in io.Reader) (hdr streamHeader, hdrBytes []byte, err error) {
+	start := make([]byte, len(streamMagic)+5)
+	_, err = io.ReadFull(in, start)
+	if err != nil {
+		return hdr, nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if !bytes.Equal(start[:len(streamMagic)], streamMagic) {
+		return hdr, nil, errors.New("stream was not encrypted with EncryptStream")
+	}
+	if start[len(streamMagic)] != streamVersion {
+		return hdr, nil, fmt.Errorf("unsupported stream version %d", start[len(streamMagic)])
+	}
+	size := binary.BigEndian.Uint32(start[len(streamMagic)+1:])
+	if size > streamMaxHeaderSize {
+		return hdr, nil, errors.New("header is too large")
+	}
+
+	hdrBytes = make([]byte, len(start)+int(size))
+	copy(hdrBytes, start)
+	_, err = io.ReadFull(in, hdrBytes[len(start):])
+	if err != nil {
+		return hdr, nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	err = json.Unmarshal(hdrBytes[len(start):], &hdr)
+	if err != nil {
+		return hdr, nil, fmt.Errorf("failed to parse header: %w", err)
+	}
+	if len(hdr.NoncePrefix) != streamNoncePrefixSize || hdr.SegmentSize <= 0 || hdr.SegmentSize > MaxStreamSegmentSize || len(hdr.WrappedKey) == 0 {
+		return hdr, nil, errors.New("header is invalid")
+	}
+
+	return hdr, hdrBytes, nil
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encryptTestStream(t *testing.T, dek []byte, plaintext []byte, segmentSize int) []byte {
+	t.Helper()
+
+	hdr := streamHeader{
+		KeyName:     "mykey/1",
+		Algorithm:   "A256KW",
+		WrappedKey:  []byte("wrapped"),
+		NoncePrefix: make([]byte, streamNoncePrefixSize),
+		SegmentSize: segmentSize,
+	}
+	_, err := io.ReadFull(rand.Reader, hdr.NoncePrefix)
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	err = encryptSegments(out, bytes.NewReader(plaintext), dek, hdr)
+	require.NoError(t, err)
+	return out.Bytes()
+}
+
+func decryptTestStream(dek []byte, ciphertext []byte) ([]byte, error) {
+	in := bytes.NewReader(ciphertext)
+	hdr, hdrBytes, err := readStreamHeader(in)
+	if err != nil {
+		return nil, err
+	}
+	out := &bytes.Buffer{}
+	err = decryptSegments(out, in, dek, hdr, hdrBytes)
+	return out.Bytes(), err
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	dek := make([]byte, streamKeySize)
+	_, err := io.ReadFull(rand.Reader, dek)
+	require.NoError(t, err)
+
+	for _, size := range []int{0, 1, 99, 100, 101, 1000} {
+		plaintext := make([]byte, size)
+		_, err = io.ReadFull(rand.Reader, plaintext)
+		require.NoError(t, err)
+
+		ciphertext := encryptTestStream(t, dek, plaintext, 100)
+		decrypted, err := decryptTestStream(dek, ciphertext)
+		require.NoError(t, err, "size %d", size)
+		assert.True(t, bytes.Equal(plaintext, decrypted), "size %d", size)
+	}
+
+	t.Run("header", func(t *testing.T) {
+		ciphertext := encryptTestStream(t, dek, []byte("hello"), 100)
+		hdr, _, err := readStreamHeader(bytes.NewReader(ciphertext))
+		require.NoError(t, err)
+		assert.Equal(t, "mykey/1", hdr.KeyName)
+		assert.Equal(t, "A256KW", hdr.Algorithm)
+		assert.Equal(t, []byte("wrapped"), hdr.WrappedKey)
+		assert.Equal(t, 100, hdr.SegmentSize)
+	})
+
+	t.Run("magic and version", func(t *testing.T) {
+		ciphertext := encryptTestStream(t, dek, []byte("hello"), 100)
+		assert.Equal(t, append([]byte("DPCS"), streamVersion), ciphertext[:5])
+	})
+}
+
+func TestStreamTampering(t *testing.T) {
+	dek := make([]byte, streamKeySize)
+	_, err := io.ReadFull(rand.Reader, dek)
+	require.NoError(t, err)
+
+	plaintext := make([]byte, 250)
+	_, err = io.ReadFull(rand.Reader, plaintext)
+	require.NoError(t, err)
+	ciphertext := encryptTestStream(t, dek, plaintext, 100)
+	hdrLen := len(ciphertext) - 3*(5+16) - 250
+
+	t.Run("wrong key", func(t *testing.T) {
+		otherKey := make([]byte, streamKeySize)
+		_, err := decryptTestStream(otherKey, ciphertext)
+		assert.Error(t, err)
+	})
+
+	t.Run("modified segment", func(t *testing.T) {
+		modified := bytes.Clone(ciphertext)
+		modified[len(modified)-1] ^= 0xff
+		_, err := decryptTestStream(dek, modified)
+		assert.Error(t, err)
+	})
+
+	t.Run("modified header", func(t *testing.T) {
+		modified := bytes.Clone(ciphertext)
+		// Change the key name in the JSON header
+		idx := bytes.Index(modified, []byte("mykey/1"))
+		require.Greater(t, idx, 0)
+		modified[idx+6] = '2'
+		_, err := decryptTestStream(dek, modified)
+		assert.Error(t, err)
+	})
+
+	t.Run("truncated after a segment", func(t *testing.T) {
+		truncated := ciphertext[:hdrLen+5+100+16]
+		_, err := decryptTestStream(dek, truncated)
+		assert.ErrorContains(t, err, "truncated")
+	})
+
+	t.Run("trailing data", func(t *testing.T) {
+		extended := append(bytes.Clone(ciphertext), 0)
+		_, err := decryptTestStream(dek, extended)
+		assert.Error(t, err)
+	})
+
+	t.Run("reordered segments", func(t *testing.T) {
+		segmentLen := 5 + 100 + 16
+		reordered := bytes.Clone(ciphertext)
+		copy(reordered[hdrLen:], ciphertext[hdrLen+segmentLen:hdrLen+2*segmentLen])
+		copy(reordered[hdrLen+segmentLen:], ciphertext[hdrLen:hdrLen+segmentLen])
+		_, err := decryptTestStream(dek, reordered)
+		assert.Error(t, err)
+	})
+
+	t.Run("not an encrypted stream", func(t *testing.T) {
+		_, err := decryptTestStream(dek, []byte("hello world, this is not encrypted"))
+		assert.Error(t, err)
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		modified := bytes.Clone(ciphertext)
+		modified[len(streamMagic)] = streamVersion + 1
+		_, err := decryptTestStream(dek, modified)
+		assert.ErrorContains(t, err, "unsupported stream version")
+	})
+}
+
+func TestLocalCryptoStream(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaJWK, err := jwk.FromRaw(rsaKey)
+	require.NoError(t, err)
+
+	aesKey := make([]byte, 16)
+	_, err = io.ReadFull(rand.Reader, aesKey)
+	require.NoError(t, err)
+	aesJWK, err := jwk.FromRaw(aesKey)
+	require.NoError(t, err)
+
+	keys := map[string]jwk.Key{
+		"rsakey": rsaJWK,
+		"aeskey": aesJWK,
+	}
+	c := LocalCryptoBaseComponent{
+		RetrieveKeyFn: func(_ context.Context, key string) (jwk.Key, error) {
+			k, ok := keys[key]
+			if !ok {
+				return nil, ErrKeyNotFound
+			}
+			return k, nil
+		},
+	}
+
+	plaintext := make([]byte, 1000)
+	_, err = io.ReadFull(rand.Reader, plaintext)
+	require.NoError(t, err)
+
+	for _, keyName := range []string{"rsakey", "aeskey"} {
+		keyName := keyName
+		t.Run(keyName, func(t *testing.T) {
+			encrypted := &bytes.Buffer{}
+			err := c.EncryptStream(context.Background(), encrypted, bytes.NewReader(plaintext), StreamEncryptOptions{
+				KeyName:     keyName,
+				SegmentSize: 300,
+			})
+			require.NoError(t, err)
+
+			hdr, _, err := readStreamHeader(bytes.NewReader(encrypted.Bytes()))
+			require.NoError(t, err)
+			assert.Equal(t, keyName, hdr.KeyName)
+			assert.Equal(t, 300, hdr.SegmentSize)
+
+			decrypted := &bytes.Buffer{}
+			err = c.DecryptStream(context.Background(), decrypted, bytes.NewReader(encrypted.Bytes()), StreamDecryptOptions{})
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(plaintext, decrypted.Bytes()))
+		})
+	}
+
+	t.Run("wrong key", func(t *testing.T) {
+		encrypted := &bytes.Buffer{}
+		err := c.EncryptStream(context.Background(), encrypted, bytes.NewReader(plaintext), StreamEncryptOptions{
+			KeyName: "aeskey",
+		})
+		require.NoError(t, err)
+
+		err = c.DecryptStream(context.Background(), io.Discard, bytes.NewReader(encrypted.Bytes()), StreamDecryptOptions{
+			KeyName: "rsakey",
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid segment size", func(t *testing.T) {
+		err := c.EncryptStream(context.Background(), io.Discard, bytes.NewReader(plaintext), StreamEncryptOptions{
+			KeyName:     "aeskey",
+			SegmentSize: MaxStreamSegmentSize + 1,
+		})
+		assert.Error(t, err)
+	})
+}
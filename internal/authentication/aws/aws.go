@@ -14,27 +14,64 @@ limitations under the License.
 package aws
 
 import (
+	"errors"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
 
 	"github.com/dapr/kit/logger"
 )
 
-func GetClient(accessKey string, secretKey string, sessionToken string, region string, endpoint string) (*session.Session, error) {
+// DefaultEndpointRegion is the region used to sign requests sent to a custom endpoint when no region is configured.
+// S3- and SQS-compatible stacks such as LocalStack or MinIO accept any region, but the SDK requires one.
+const DefaultEndpointRegion = "us-east-1"
+
+// Options contains the options to connect to AWS, which are shared by all the AWS components.
+type Options struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Region       string
+	// Endpoint overrides the endpoint of the services used by the component, for example to connect to LocalStack.
+	Endpoint string
+	// STSEndpoint overrides the endpoint of STS, which is used to obtain credentials when assuming a role.
+	// If empty, STS uses the AWS endpoint of the region, even when Endpoint is set.
+	STSEndpoint string
+	// UseFIPSEndpoint selects the FIPS 140-2 validated endpoints of the services, which are required by FedRAMP.
+	UseFIPSEndpoint bool
+	// UseDualStackEndpoint selects the dual-stack endpoints of the services, which accept both IPv4 and IPv6 connections.
+	UseDualStackEndpoint bool
+}
+
+func GetClient(opts Options) (*session.Session, error) {
+	if opts.Endpoint != "" && (opts.UseFIPSEndpoint || opts.UseDualStackEndpoint) {
+		return nil, errors.New("FIPS and dual-stack endpoints cannot be used together with a custom endpoint")
+	}
+
 	awsConfig := aws.NewConfig()
 
-	if region != "" {
-		awsConfig = awsConfig.WithRegion(region)
+	if opts.Region != "" {
+		awsConfig = awsConfig.WithRegion(opts.Region)
+	}
+
+	if opts.UseFIPSEndpoint {
+		awsConfig.UseFIPSEndpoint = endpoints.FIPSEndpointStateEnabled
 	}
 
-	if accessKey != "" && secretKey != "" {
-		awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentials(accessKey, secretKey, sessionToken))
+	if opts.UseDualStackEndpoint {
+		awsConfig.UseDualStackEndpoint = endpoints.DualStackEndpointStateEnabled
 	}
 
-	if endpoint != "" {
-		awsConfig = awsConfig.WithEndpoint(endpoint)
+	if opts.AccessKey != "" && opts.SecretKey != "" {
+		awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentials(opts.AccessKey, opts.SecretKey, opts.SessionToken))
+	}
+
+	if opts.Endpoint != "" || opts.STSEndpoint != "" {
+		awsConfig = awsConfig.WithEndpointResolver(endpointResolver(opts.Endpoint, opts.STSEndpoint))
 	}
 
 	awsSession, err := session.NewSessionWithOptions(session.Options{
@@ -45,6 +82,11 @@ func GetClient(accessKey string, secretKey string, sessionToken string, region s
 		return nil, err
 	}
 
+	// The region may also be set in the environment or in the shared config
+	if aws.StringValue(awsSession.Config.Region) == "" && (opts.Endpoint != "" || opts.STSEndpoint != "") {
+		awsSession.Config.Region = aws.String(DefaultEndpointRegion)
+	}
+
 	userAgentHandler := request.NamedHandler{
 		Name: "UserAgentHandler",
 		Fn:   request.MakeAddToUserAgentHandler("dapr", logger.DaprVersion),
@@ -53,3 +95,22 @@ func GetClient(accessKey string, secretKey string, sessionToken string, region s
 
 	return awsSession, nil
 }
+
+// endpointResolver returns a resolver that sends the requests of all services but STS to the custom endpoint, and the requests to STS to the custom STS endpoint.
+// STS keeps using the AWS endpoint unless the custom STS endpoint is set, so credentials are never requested from the custom endpoint implicitly.
+// Unlike aws.Config.Endpoint, this applies to the STS clients created by the credential providers of the session too.
+func endpointResolver(endpoint string, stsEndpoint string) endpoints.Resolver {
+	return endpoints.ResolverFunc(func(service string, region string, opts ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
+		url := endpoint
+		if service == sts.EndpointsID {
+			url = stsEndpoint
+		}
+		if url == "" {
+			return endpoints.DefaultResolver().EndpointFor(service, region, opts...)
+		}
+		return endpoints.ResolvedEndpoint{
+			URL:           url,
+			SigningRegion: region,
+		}, nil
+	})
+}
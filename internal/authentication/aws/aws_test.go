@@ -0,0 +1,123 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetClient(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+
+	t.Run("custom endpoint", func(t *testing.T) {
+		sess, err := GetClient(Options{
+			AccessKey: "key",
+			SecretKey: "secret",
+			Endpoint:  "http://localhost:4566",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, DefaultEndpointRegion, aws.StringValue(sess.Config.Region))
+
+		assert.Equal(t, "http://localhost:4566", sqs.New(sess).Endpoint)
+		assert.Equal(t, "http://localhost:4566", s3.New(sess).Endpoint)
+		assert.Equal(t, "https://sts.amazonaws.com", sts.New(sess).Endpoint)
+	})
+
+	t.Run("custom STS endpoint", func(t *testing.T) {
+		sess, err := GetClient(Options{
+			Region:      "eu-west-1",
+			Endpoint:    "http://localhost:4566",
+			STSEndpoint: "http://localhost:4567",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "eu-west-1", aws.StringValue(sess.Config.Region))
+
+		assert.Equal(t, "http://localhost:4566", sqs.New(sess).Endpoint)
+		assert.Equal(t, "http://localhost:4567", sts.New(sess).Endpoint)
+	})
+
+	t.Run("only STS endpoint", func(t *testing.T) {
+		sess, err := GetClient(Options{
+			Region:      "eu-west-1",
+			STSEndpoint: "https://sts.internal.example.com",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "https://sqs.eu-west-1.amazonaws.com", sqs.New(sess).Endpoint)
+		assert.Equal(t, "https://sts.internal.example.com", sts.New(sess).Endpoint)
+	})
+
+	t.Run("AWS endpoints", func(t *testing.T) {
+		sess, err := GetClient(Options{
+			Region: "eu-west-1",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "https://sqs.eu-west-1.amazonaws.com", sqs.New(sess).Endpoint)
+	})
+	t.Run("FIPS endpoints", func(t *testing.T) {
+		sess, err := GetClient(Options{
+			Region:          "us-east-1",
+			UseFIPSEndpoint: true,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "https://sqs-fips.us-east-1.amazonaws.com", sqs.New(sess).Endpoint)
+	})
+
+	t.Run("dual-stack endpoints", func(t *testing.T) {
+		sess, err := GetClient(Options{
+			Region:               "eu-west-1",
+			UseDualStackEndpoint: true,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "https://s3.dualstack.eu-west-1.amazonaws.com", s3.New(sess).Endpoint)
+	})
+
+	t.Run("FIPS with only STS endpoint", func(t *testing.T) {
+		sess, err := GetClient(Options{
+			Region:          "us-east-1",
+			STSEndpoint:     "https://sts.internal.example.com",
+			UseFIPSEndpoint: true,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "https://sqs-fips.us-east-1.amazonaws.com", sqs.New(sess).Endpoint)
+		assert.Equal(t, "https://sts.internal.example.com", sts.New(sess).Endpoint)
+	})
+
+	t.Run("FIPS or dual-stack with custom endpoint", func(t *testing.T) {
+		_, err := GetClient(Options{
+			Endpoint:        "http://localhost:4566",
+			UseFIPSEndpoint: true,
+		})
+		assert.Error(t, err)
+
+		_, err = GetClient(Options{
+			Endpoint:             "http://localhost:4566",
+			UseDualStackEndpoint: true,
+		})
+		assert.Error(t, err)
+	})
+}
@@ -17,6 +17,7 @@ import (
 	"context"
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -340,6 +341,16 @@ func (c CertConfig) decodeCertificate(data []byte, password string) (certificate
 		return certificate, privateKey, nil
 	}
 
+	// Finally, try a base64-encoded PKCS#12 bundle, which is how certificates are returned as secrets by Azure Key Vault.
+	// This allows loading the certificate from a secretKeyRef to another vault.
+	pkcs, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err == nil {
+		certificate, privateKey, err = c.decodePkcs12(pkcs, password)
+		if err == nil && certificate != nil {
+			return certificate, privateKey, nil
+		}
+	}
+
 	return nil, nil, errors.New("certificate is not valid")
 }
 
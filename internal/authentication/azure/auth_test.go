@@ -150,6 +150,28 @@ func TestAuthorizorWithCertBytes(t *testing.T) {
 		assert.NotNil(t, spt)
 	})
 
+	t.Run("Certificate is base64-encoded", func(t *testing.T) {
+		certBytes := getTestCert()
+
+		settings, err := NewEnvironmentSettings(
+			map[string]string{
+				"azureCertificate":         base64.StdEncoding.EncodeToString(certBytes),
+				"azureCertificatePassword": "",
+				"azureClientId":            fakeClientID,
+				"azureTenantId":            fakeTenantID,
+				"vaultName":                "vaultName",
+			},
+		)
+		assert.NoError(t, err)
+
+		testCertConfig, _ := settings.GetClientCert()
+		assert.NotNil(t, testCertConfig)
+
+		spt, err := testCertConfig.GetTokenCredential()
+		assert.NoError(t, err)
+		assert.NotNil(t, spt)
+	})
+
 	t.Run("Certificate is invalid", func(t *testing.T) {
 		certBytes := getTestCert()
 
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sqs contains utilities shared by the components that use Amazon SQS queues.
+package sqs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+// Queue is a reference to an existing SQS queue, which can be addressed by name, URL or ARN.
+type Queue struct {
+	// Name of the queue.
+	Name string
+	// ID of the account that owns the queue, if it's addressed by URL or ARN.
+	AccountID string
+	// URL of the queue, if it's addressed by URL.
+	URL string
+
+	arn string
+}
+
+// ParseQueue parses a reference to a queue.
+// Accepted formats are a queue name, a queue URL such as "https://sqs.us-east-1.amazonaws.com/123456789012/myqueue",
+// and a queue ARN such as "arn:aws:sqs:us-east-1:123456789012:myqueue".
+func ParseQueue(queue string) (Queue, error) {
+	queue = strings.TrimSpace(queue)
+	switch {
+	case queue == "":
+		return Queue{}, errors.New("queue name, URL or ARN is empty")
+	case strings.HasPrefix(queue, "arn:"):
+		// arn:partition:sqs:region:account-id:queue-name
+		parts := strings.Split(queue, ":")
+		if len(parts) != 6 || parts[2] != "sqs" || parts[4] == "" || parts[5] == "" {
+			return Queue{}, fmt.Errorf("invalid SQS queue ARN '%s'", queue)
+		}
+		return Queue{Name: parts[5], AccountID: parts[4], arn: queue}, nil
+	case strings.HasPrefix(queue, "https://") || strings.HasPrefix(queue, "http://"):
+		u, err := url.Parse(queue)
+		if err != nil {
+			return Queue{}, fmt.Errorf("invalid SQS queue URL '%s': %w", queue, err)
+		}
+		// The path is /account-id/queue-name
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return Queue{}, fmt.Errorf("invalid SQS queue URL '%s': the path must contain the account ID and the queue name", queue)
+		}
+		return Queue{Name: parts[1], AccountID: parts[0], URL: queue}, nil
+	default:
+		return Queue{Name: queue}, nil
+	}
+}
+
+// ResolveURL returns the URL of the queue.
+// Queues addressed by name are looked up in the account of the caller.
+func (q Queue) ResolveURL(ctx context.Context, client sqsiface.SQSAPI) (string, error) {
+	if q.URL != "" {
+		return q.URL, nil
+	}
+
+	input := &sqs.GetQueueUrlInput{
+		QueueName: aws.String(q.Name),
+	}
+	if q.AccountID != "" {
+		input.QueueOwnerAWSAccountId = aws.String(q.AccountID)
+	}
+	res, err := client.GetQueueUrlWithContext(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("error getting the URL of queue %s: %w", q.Name, err)
+	}
+	return aws.StringValue(res.QueueUrl), nil
+}
+
+// ARN returns the ARN of the queue.
+// The partition, the region and the account ID of the component are used when they are not part of the reference.
+func (q Queue) ARN(partition string, region string, accountID string) string {
+	if q.arn != "" {
+		return q.arn
+	}
+	if q.AccountID != "" {
+		accountID = q.AccountID
+	}
+	return fmt.Sprintf("arn:%s:sqs:%s:%s:%s", partition, region, accountID, q.Name)
+}
+
+// IsFifo returns true if the queue is a FIFO queue.
+func (q Queue) IsFifo() bool {
+	return strings.HasSuffix(q.Name, ".fifo")
+}
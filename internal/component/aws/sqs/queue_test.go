@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSQS struct {
+	sqsiface.SQSAPI
+	input *sqs.GetQueueUrlInput
+}
+
+func (m *mockSQS) GetQueueUrlWithContext(_ context.Context, input *sqs.GetQueueUrlInput, _ ...request.Option) (*sqs.GetQueueUrlOutput, error) {
+	m.input = input
+	return &sqs.GetQueueUrlOutput{
+		QueueUrl: aws.String("https://sqs.us-east-1.amazonaws.com/" + aws.StringValue(input.QueueOwnerAWSAccountId) + "/" + aws.StringValue(input.QueueName)),
+	}, nil
+}
+
+func TestParseQueue(t *testing.T) {
+	t.Run("name", func(t *testing.T) {
+		q, err := ParseQueue("myqueue")
+		require.NoError(t, err)
+		assert.Equal(t, "myqueue", q.Name)
+		assert.Empty(t, q.AccountID)
+		assert.Equal(t, "arn:aws:sqs:us-east-1:111111111111:myqueue", q.ARN("aws", "us-east-1", "111111111111"))
+		assert.False(t, q.IsFifo())
+
+		m := &mockSQS{}
+		u, err := q.ResolveURL(context.Background(), m)
+		require.NoError(t, err)
+		assert.Equal(t, "https://sqs.us-east-1.amazonaws.com//myqueue", u)
+		assert.Nil(t, m.input.QueueOwnerAWSAccountId)
+	})
+
+	t.Run("URL", func(t *testing.T) {
+		q, err := ParseQueue("http://localhost:4566/000000000000/myqueue.fifo")
+		require.NoError(t, err)
+		assert.Equal(t, "myqueue.fifo", q.Name)
+		assert.Equal(t, "000000000000", q.AccountID)
+		assert.Equal(t, "arn:aws:sqs:us-east-1:000000000000:myqueue.fifo", q.ARN("aws", "us-east-1", "111111111111"))
+		assert.True(t, q.IsFifo())
+
+		m := &mockSQS{}
+		u, err := q.ResolveURL(context.Background(), m)
+		require.NoError(t, err)
+		assert.Equal(t, "http://localhost:4566/000000000000/myqueue.fifo", u)
+		assert.Nil(t, m.input)
+	})
+
+	t.Run("ARN", func(t *testing.T) {
+		q, err := ParseQueue("arn:aws-cn:sqs:cn-north-1:222222222222:myqueue")
+		require.NoError(t, err)
+		assert.Equal(t, "myqueue", q.Name)
+		assert.Equal(t, "222222222222", q.AccountID)
+		assert.Equal(t, "arn:aws-cn:sqs:cn-north-1:222222222222:myqueue", q.ARN("aws", "us-east-1", "111111111111"))
+
+		m := &mockSQS{}
+		u, err := q.ResolveURL(context.Background(), m)
+		require.NoError(t, err)
+		assert.Equal(t, "https://sqs.us-east-1.amazonaws.com/222222222222/myqueue", u)
+		assert.Equal(t, "222222222222", aws.StringValue(m.input.QueueOwnerAWSAccountId))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, queue := range []string{
+			"",
+			"arn:aws:sns:us-east-1:222222222222:mytopic",
+			"arn:aws:sqs:us-east-1::myqueue",
+			"https://sqs.us-east-1.amazonaws.com/myqueue",
+		} {
+			_, err := ParseQueue(queue)
+			assert.Error(t, err, queue)
+		}
+	})
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventhubs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs"
+	"github.com/google/uuid"
+
+	"github.com/dapr/components-contrib/state"
+)
+
+// stateCheckpointStore is a checkpoint store for the Event Hubs processor that persists checkpoints and partition ownership in a Dapr state store.
+// The ownership of all partitions of a consumer group is stored in a single record, which is updated with optimistic concurrency, so the state store must support ETags.
+// Checkpoints are stored in one record per partition.
+type stateCheckpointStore struct {
+	store state.Store
+	now   func() time.Time
+}
+
+// stateOwnership is the ownership of a partition in the state store.
+type stateOwnership struct {
+	OwnerID          string    `json:"ownerID"`
+	LastModifiedTime time.Time `json:"lastModifiedTime"`
+	ETag             string    `json:"etag"`
+}
+
+// stateCheckpoint is the checkpoint of a partition in the state store.
+type stateCheckpoint struct {
+	Offset         *int64 `json:"offset,omitempty"`
+	SequenceNumber *int64 `json:"sequenceNumber,omitempty"`
+}
+
+func newStateCheckpointStore(store state.Store) (*stateCheckpointStore, error) {
+	if !state.FeatureETag.IsPresent(store.Features()) {
+		return nil, errors.New("the state store used for checkpoints must support ETags")
+	}
+	return &stateCheckpointStore{
+		store: store,
+		now:   time.Now,
+	}, nil
+}
+
+// ClaimOwnership claims the partitions whose ownership has not changed since it was listed, and returns the ones that were claimed.
+func (s *stateCheckpointStore) ClaimOwnership(ctx context.Context, partitionOwnership []azeventhubs.Ownership, _ *azeventhubs.ClaimOwnershipOptions) ([]azeventhubs.Ownership, error) {
+	if len(partitionOwnership) == 0 {
+		return nil, nil
+	}
+
+	first := partitionOwnership[0]
+	key := ownershipKey(first.FullyQualifiedNamespace, first.EventHubName, first.ConsumerGroup)
+	owners, etag, err := s.getOwnership(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	claimed := make([]azeventhubs.Ownership, 0, len(partitionOwnership))
+	for _, o := range partitionOwnership {
+		existing, ok := owners[o.PartitionID]
+		// The ownership must not have been modified by another processor since it was listed
+		if (o.ETag == nil && ok) || (o.ETag != nil && (!ok || string(*o.ETag) != existing.ETag)) {
+			continue
+		}
+
+		newETag := azcore.ETag(uuid.NewString())
+		owners[o.PartitionID] = stateOwnership{
+			OwnerID:          o.OwnerID,
+			LastModifiedTime: now,
+			ETag:             string(newETag),
+		}
+		o.LastModifiedTime = now
+		o.ETag = &newETag
+		claimed = append(claimed, o)
+	}
+	if len(claimed) == 0 {
+		return claimed, nil
+	}
+
+	data, err := json.Marshal(owners)
+	if err != nil {
+		return nil, err
+	}
+	req := &state.SetRequest{
+		Key:   key,
+		Value: data,
+		ETag:  etag,
+	}
+	if etag == nil {
+		req.Options.Concurrency = state.FirstWrite
+	}
+	err = s.store.Set(ctx, req)
+	if err != nil {
+		// Another processor updated the ownership in the meanwhile, so none of the partitions were claimed
+		var etagErr *state.ETagError
+		if errors.As(err, &etagErr) {
+			return []azeventhubs.Ownership{}, nil
+		}
+		return nil, fmt.Errorf("failed to save partition ownership: %w", err)
+	}
+
+	return claimed, nil
+}
+
+// ListCheckpoints lists the checkpoints of the partitions that have ever been owned by a processor of the consumer group.
+func (s *stateCheckpointStore) ListCheckpoints(ctx context.Context, fullyQualifiedNamespace string, eventHubName string, consumerGroup string, _ *azeventhubs.ListCheckpointsOptions) ([]azeventhubs.Checkpoint, error) {
+	owners, _, err := s.getOwnership(ctx, ownershipKey(fullyQualifiedNamespace, eventHubName, consumerGroup))
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]azeventhubs.Checkpoint, 0, len(owners))
+	for partitionID := range owners {
+		key := checkpointKey(fullyQualifiedNamespace, eventHubName, consumerGroup, partitionID)
+		getRes, err := s.store.Get(ctx, &state.GetRequest{Key: key})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read checkpoint of partition %s: %w", partitionID, err)
+		}
+		if getRes == nil || len(getRes.Data) == 0 {
+			continue
+		}
+
+		var cp stateCheckpoint
+		err = json.Unmarshal(getRes.Data, &cp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid checkpoint of partition %s: %w", partitionID, err)
+		}
+		res = append(res, azeventhubs.Checkpoint{
+			ConsumerGroup:           consumerGroup,
+			EventHubName:            eventHubName,
+			FullyQualifiedNamespace: fullyQualifiedNamespace,
+			PartitionID:             partitionID,
+			Offset:                  cp.Offset,
+			SequenceNumber:          cp.SequenceNumber,
+		})
+	}
+
+	return res, nil
+}
+
+// ListOwnership lists the ownership of the partitions of the consumer group.
+func (s *stateCheckpointStore) ListOwnership(ctx context.Context, fullyQualifiedNamespace string, eventHubName string, consumerGroup string, _ *azeventhubs.ListOwnershipOptions) ([]azeventhubs.Ownership, error) {
+	owners, _, err := s.getOwnership(ctx, ownershipKey(fullyQualifiedNamespace, eventHubName, consumerGroup))
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]azeventhubs.Ownership, 0, len(owners))
+	for partitionID, o := range owners {
+		etag := azcore.ETag(o.ETag)
+		res = append(res, azeventhubs.Ownership{
+			ConsumerGroup:           consumerGroup,
+			EventHubName:            eventHubName,
+			FullyQualifiedNamespace: fullyQualifiedNamespace,
+			PartitionID:             partitionID,
+			OwnerID:                 o.OwnerID,
+			LastModifiedTime:        o.LastModifiedTime,
+			ETag:                    &etag,
+		})
+	}
+
+	return res, nil
+}
+
+// SetCheckpoint saves the checkpoint of a partition.
+func (s *stateCheckpointStore) SetCheckpoint(ctx context.Context, checkpoint azeventhubs.Checkpoint, _ *azeventhubs.SetCheckpointOptions) error {
+	data, err := json.Marshal(stateCheckpoint{
+		Offset:         checkpoint.Offset,
+		SequenceNumber: checkpoint.SequenceNumber,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.store.Set(ctx, &state.SetRequest{
+		Key:   checkpointKey(checkpoint.FullyQualifiedNamespace, checkpoint.EventHubName, checkpoint.ConsumerGroup, checkpoint.PartitionID),
+		Value: data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint of partition %s: %w", checkpoint.PartitionID, err)
+	}
+	return nil
+}
+
+// getOwnership returns the ownership of the partitions of a consumer group, and the ETag of the record.
+func (s *stateCheckpointStore) getOwnership(ctx context.Context, key string) (map[string]stateOwnership, *string, error) {
+	res, err := s.store.Get(ctx, &state.GetRequest{Key: key})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read partition ownership: %w", err)
+	}
+
+	owners := map[string]stateOwnership{}
+	if res == nil || len(res.Data) == 0 {
+		return owners, nil, nil
+	}
+	err = json.Unmarshal(res.Data, &owners)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid partition ownership: %w", err)
+	}
+	return owners, res.ETag, nil
+}
+
+func ownershipKey(fullyQualifiedNamespace string, eventHubName string, consumerGroup string) string {
+	return "eventhubs-ownership||" + fullyQualifiedNamespace + "||" + eventHubName + "||" + consumerGroup
+}
+
+func checkpointKey(fullyQualifiedNamespace string, eventHubName string, consumerGroup string, partitionID string) string {
+	return "eventhubs-checkpoint||" + fullyQualifiedNamespace + "||" + eventHubName + "||" + consumerGroup + "||" + partitionID
+}
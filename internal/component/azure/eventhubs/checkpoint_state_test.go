@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventhubs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state"
+	inmemory "github.com/dapr/components-contrib/state/in-memory"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+func TestStateCheckpointStore(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("test")
+	store := inmemory.NewInMemoryStateStore(log)
+	require.NoError(t, store.Init(ctx, state.Metadata{}))
+	cs, err := newStateCheckpointStore(store)
+	require.NoError(t, err)
+
+	ownership := func(partitionID string, ownerID string, etag *azcore.ETag) azeventhubs.Ownership {
+		return azeventhubs.Ownership{
+			ConsumerGroup:           "cg",
+			EventHubName:            "hub",
+			FullyQualifiedNamespace: "ns.servicebus.windows.net",
+			PartitionID:             partitionID,
+			OwnerID:                 ownerID,
+			ETag:                    etag,
+		}
+	}
+
+	t.Run("claim new partitions", func(t *testing.T) {
+		claimed, err := cs.ClaimOwnership(ctx, []azeventhubs.Ownership{
+			ownership("0", "a", nil),
+			ownership("1", "a", nil),
+		}, nil)
+		require.NoError(t, err)
+		require.Len(t, claimed, 2)
+
+		owners, err := cs.ListOwnership(ctx, "ns.servicebus.windows.net", "hub", "cg", nil)
+		require.NoError(t, err)
+		require.Len(t, owners, 2)
+		for _, o := range owners {
+			assert.Equal(t, "a", o.OwnerID)
+			assert.NotNil(t, o.ETag)
+		}
+	})
+
+	t.Run("claim with a stale etag fails", func(t *testing.T) {
+		claimed, err := cs.ClaimOwnership(ctx, []azeventhubs.Ownership{
+			ownership("0", "b", ptr.Of(azcore.ETag("stale"))),
+			ownership("1", "b", nil),
+		}, nil)
+		require.NoError(t, err)
+		assert.Empty(t, claimed)
+	})
+
+	t.Run("claim with the current etag succeeds", func(t *testing.T) {
+		owners, err := cs.ListOwnership(ctx, "ns.servicebus.windows.net", "hub", "cg", nil)
+		require.NoError(t, err)
+		var current azeventhubs.Ownership
+		for _, o := range owners {
+			if o.PartitionID == "0" {
+				current = o
+			}
+		}
+
+		current.OwnerID = "b"
+		claimed, err := cs.ClaimOwnership(ctx, []azeventhubs.Ownership{current}, nil)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		assert.Equal(t, "b", claimed[0].OwnerID)
+		assert.NotEqual(t, *current.ETag, *claimed[0].ETag)
+	})
+
+	t.Run("checkpoints", func(t *testing.T) {
+		err := cs.SetCheckpoint(ctx, azeventhubs.Checkpoint{
+			ConsumerGroup:           "cg",
+			EventHubName:            "hub",
+			FullyQualifiedNamespace: "ns.servicebus.windows.net",
+			PartitionID:             "1",
+			Offset:                  ptr.Of(int64(100)),
+			SequenceNumber:          ptr.Of(int64(10)),
+		}, nil)
+		require.NoError(t, err)
+
+		checkpoints, err := cs.ListCheckpoints(ctx, "ns.servicebus.windows.net", "hub", "cg", nil)
+		require.NoError(t, err)
+		require.Len(t, checkpoints, 1)
+		assert.Equal(t, "1", checkpoints[0].PartitionID)
+		assert.Equal(t, int64(100), *checkpoints[0].Offset)
+		assert.Equal(t, int64(10), *checkpoints[0].SequenceNumber)
+
+		checkpoints, err = cs.ListCheckpoints(ctx, "ns.servicebus.windows.net", "hub", "other", nil)
+		require.NoError(t, err)
+		assert.Empty(t, checkpoints)
+	})
+}
+
+func TestCreateStateCheckpointStore(t *testing.T) {
+	aeh := NewAzureEventHubs(logger.NewLogger("test"), false)
+	aeh.metadata = &AzureEventHubsMetadata{CheckpointStateStoreName: "statestore"}
+
+	_, err := aeh.createCheckpointStore(context.Background())
+	require.Error(t, err)
+
+	store := inmemory.NewInMemoryStateStore(logger.NewLogger("test"))
+	aeh.SetStateStoreResolver(func(name string) (state.Store, error) {
+		assert.Equal(t, "statestore", name)
+		return store, nil
+	})
+	cs, err := aeh.createCheckpointStore(context.Background())
+	require.NoError(t, err)
+	assert.IsType(t, &stateCheckpointStore{}, cs)
+}
@@ -31,6 +31,7 @@ import (
 
 	azauth "github.com/dapr/components-contrib/internal/authentication/azure"
 	"github.com/dapr/components-contrib/internal/component/azure/blobstorage"
+	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/retry"
 )
@@ -47,9 +48,18 @@ type AzureEventHubs struct {
 	producers            map[string]*azeventhubs.ProducerClient
 	checkpointStoreCache azeventhubs.CheckpointStore
 	checkpointStoreLock  *sync.RWMutex
+	stateStoreResolver   state.StoreResolver
+	subscriptionsLock    *sync.Mutex
+	subscriptions        map[string]*eventHubsSubscription
 
 	managementCreds azcore.TokenCredential
 
+	// Cancels the background watch of the Geo-DR alias
+	geoDRCancel context.CancelFunc
+	geoDRDone   chan struct{}
+	// Function used to resolve the Geo-DR alias; can be replaced in tests
+	lookupCNAME func(ctx context.Context, host string) (string, error)
+
 	// TODO(@ItalyPaleAle): Remove in Dapr 1.13
 	isFailed atomic.Bool
 }
@@ -62,6 +72,8 @@ func NewAzureEventHubs(logger logger.Logger, isBinding bool) *AzureEventHubs {
 		producersLock:       &sync.RWMutex{},
 		producers:           make(map[string]*azeventhubs.ProducerClient, 1),
 		checkpointStoreLock: &sync.RWMutex{},
+		subscriptionsLock:   &sync.Mutex{},
+		subscriptions:       make(map[string]*eventHubsSubscription),
 	}
 }
 
@@ -98,6 +110,17 @@ func (aeh *AzureEventHubs) Init(metadata map[string]string) error {
 		return fmt.Errorf("failed to decode backoff configuration")
 	}
 
+	if aeh.metadata.GeoDRAlias != "" {
+		aeh.logger.Infof("Connecting through Geo-DR alias %s; connections will be re-established after a failover", aeh.metadata.GeoDRAlias)
+		var geoDRCtx context.Context
+		geoDRCtx, aeh.geoDRCancel = context.WithCancel(context.Background())
+		aeh.geoDRDone = make(chan struct{})
+		go func() {
+			defer close(aeh.geoDRDone)
+			aeh.watchGeoDRAlias(geoDRCtx, aeh.metadata.GeoDRAlias, aeh.metadata.GeoDRRefreshInterval)
+		}()
+	}
+
 	return nil
 }
 
@@ -138,8 +161,52 @@ func (aeh *AzureEventHubs) Publish(ctx context.Context, topic string, messages [
 	return nil
 }
 
+// eventHubsSubscription is an active subscription to a topic, which is restarted when messages are replayed.
+type eventHubsSubscription struct {
+	parentCtx        context.Context
+	getAllProperties bool
+	handler          SubscribeHandler
+	cancel           context.CancelFunc
+	wg               *sync.WaitGroup
+}
+
+// stop stops the subscription and waits for all the partition clients to be closed.
+func (s *eventHubsSubscription) stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
 // Subscribe receives data from Azure Event Hubs in background.
-func (aeh *AzureEventHubs) Subscribe(subscribeCtx context.Context, topic string, getAllProperties bool, handler SubscribeHandler) (err error) {
+func (aeh *AzureEventHubs) Subscribe(subscribeCtx context.Context, topic string, getAllProperties bool, handler SubscribeHandler) error {
+	sub, err := aeh.startSubscription(subscribeCtx, topic, getAllProperties, handler)
+	if err != nil {
+		return err
+	}
+
+	aeh.subscriptionsLock.Lock()
+	aeh.subscriptions[topic] = sub
+	aeh.subscriptionsLock.Unlock()
+	return nil
+}
+
+func (aeh *AzureEventHubs) startSubscription(parentCtx context.Context, topic string, getAllProperties bool, handler SubscribeHandler) (*eventHubsSubscription, error) {
+	subscribeCtx, cancel := context.WithCancel(parentCtx)
+	sub := &eventHubsSubscription{
+		parentCtx:        parentCtx,
+		getAllProperties: getAllProperties,
+		handler:          handler,
+		cancel:           cancel,
+		wg:               &sync.WaitGroup{},
+	}
+	err := aeh.subscribe(subscribeCtx, topic, getAllProperties, handler, sub.wg)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (aeh *AzureEventHubs) subscribe(subscribeCtx context.Context, topic string, getAllProperties bool, handler SubscribeHandler, wg *sync.WaitGroup) (err error) {
 	if aeh.metadata.ConsumerGroup == "" {
 		return errors.New("property consumerID is required to subscribe to an Event Hub topic")
 	}
@@ -215,7 +282,9 @@ func (aeh *AzureEventHubs) Subscribe(subscribeCtx context.Context, topic string,
 	eventHandler := subscribeHandler(subscribeCtx, getAllProperties, retryHandler)
 
 	// Process all partition clients as they come in
+	wg.Add(2)
 	go func() {
+		defer wg.Done()
 		for {
 			// This will block until a new partition client is available
 			// It returns nil if processor.Run terminates or if the context is canceled
@@ -226,7 +295,9 @@ func (aeh *AzureEventHubs) Subscribe(subscribeCtx context.Context, topic string,
 			aeh.logger.Debugf("Received client for partition %s", partitionClient.PartitionID())
 
 			// Once we get a partition client, process the events in a separate goroutine
+			wg.Add(1)
 			go func() {
+				defer wg.Done()
 				processErr := aeh.processEvents(subscribeCtx, topic, partitionClient, eventHandler)
 				// Do not log context.Canceled which happens at shutdown
 				if processErr != nil && !errors.Is(processErr, context.Canceled) {
@@ -238,11 +309,12 @@ func (aeh *AzureEventHubs) Subscribe(subscribeCtx context.Context, topic string,
 
 	// Start the processor
 	go func() {
+		defer wg.Done()
 		// This is a blocking call that runs until the context is canceled
-		err = processor.Run(subscribeCtx)
+		runErr := processor.Run(subscribeCtx)
 		// Do not log context.Canceled which happens at shutdown
-		if err != nil && !errors.Is(err, context.Canceled) {
-			aeh.logger.Errorf("Error from event processor: %v", err)
+		if runErr != nil && !errors.Is(runErr, context.Canceled) {
+			aeh.logger.Errorf("Error from event processor: %v", runErr)
 		}
 	}()
 
@@ -308,6 +380,13 @@ func (aeh *AzureEventHubs) processEvents(subscribeCtx context.Context, topic str
 }
 
 func (aeh *AzureEventHubs) Close() (err error) {
+	// Stop watching the Geo-DR alias
+	if aeh.geoDRCancel != nil {
+		aeh.geoDRCancel()
+		<-aeh.geoDRDone
+		aeh.geoDRCancel = nil
+	}
+
 	// Acquire locks
 	aeh.checkpointStoreLock.Lock()
 	defer aeh.checkpointStoreLock.Unlock()
@@ -368,6 +447,7 @@ func (aeh *AzureEventHubs) getProducerClientForTopic(ctx context.Context, topic
 
 	clientOpts := &azeventhubs.ProducerClientOptions{
 		ApplicationID: "dapr-" + logger.DaprVersion,
+		RetryOptions:  aeh.retryOptions(),
 	}
 
 	// Check if we're authenticating using a connection string
@@ -427,9 +507,25 @@ func (aeh *AzureEventHubs) getProcessorForTopic(ctx context.Context, topic strin
 	}
 
 	// Create a consumer client
-	var consumerClient *azeventhubs.ConsumerClient
+	consumerClient, err := aeh.getConsumerClientForTopic(topic, aeh.metadata.ConsumerGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the processor from the consumer client and checkpoint store
+	processor, err := azeventhubs.NewProcessor(consumerClient, checkpointStore, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the processor: %w", err)
+	}
+
+	return processor, nil
+}
+
+// Creates a consumer client for a given topic and consumer group.
+func (aeh *AzureEventHubs) getConsumerClientForTopic(topic string, consumerGroup string) (consumerClient *azeventhubs.ConsumerClient, err error) {
 	clientOpts := &azeventhubs.ConsumerClientOptions{
 		ApplicationID: "dapr-" + logger.DaprVersion,
+		RetryOptions:  aeh.retryOptions(),
 	}
 
 	// Check if we're authenticating using a connection string
@@ -439,7 +535,7 @@ func (aeh *AzureEventHubs) getProcessorForTopic(ctx context.Context, topic strin
 		if err != nil {
 			return nil, err
 		}
-		consumerClient, err = azeventhubs.NewConsumerClientFromConnectionString(connString, "", aeh.metadata.ConsumerGroup, clientOpts)
+		consumerClient, err = azeventhubs.NewConsumerClientFromConnectionString(connString, "", consumerGroup, clientOpts)
 		if err != nil {
 			return nil, fmt.Errorf("unable to connect to Azure Event Hub using a connection string: %w", err)
 		}
@@ -449,19 +545,13 @@ func (aeh *AzureEventHubs) getProcessorForTopic(ctx context.Context, topic strin
 		if tokenErr != nil {
 			return nil, fmt.Errorf("failed to get credentials from Azure AD: %w", tokenErr)
 		}
-		consumerClient, err = azeventhubs.NewConsumerClient(aeh.metadata.EventHubNamespace, topic, aeh.metadata.ConsumerGroup, cred, clientOpts)
+		consumerClient, err = azeventhubs.NewConsumerClient(aeh.metadata.EventHubNamespace, topic, consumerGroup, cred, clientOpts)
 		if err != nil {
 			return nil, fmt.Errorf("unable to connect to Azure Event Hub using Azure AD: %w", err)
 		}
 	}
 
-	// Create the processor from the consumer client and checkpoint store
-	processor, err := azeventhubs.NewProcessor(consumerClient, checkpointStore, nil)
-	if err != nil {
-		return nil, fmt.Errorf("unable to create the processor: %w", err)
-	}
-
-	return processor, nil
+	return consumerClient, nil
 }
 
 // Returns the checkpoint store from the object. If it doesn't exist, it lazily initializes it.
@@ -492,8 +582,19 @@ func (aeh *AzureEventHubs) getCheckpointStore(ctx context.Context) (azeventhubs.
 	return aeh.checkpointStoreCache, nil
 }
 
+// SetStateStoreResolver sets the function used to get the state store where checkpoints are stored, when checkpointStateStoreName is set.
+func (aeh *AzureEventHubs) SetStateStoreResolver(resolver state.StoreResolver) {
+	aeh.checkpointStoreLock.Lock()
+	aeh.stateStoreResolver = resolver
+	aeh.checkpointStoreLock.Unlock()
+}
+
 // Initializes a new checkpoint store
 func (aeh *AzureEventHubs) createCheckpointStore(ctx context.Context) (checkpointStore azeventhubs.CheckpointStore, err error) {
+	if aeh.metadata.CheckpointStateStoreName != "" {
+		return aeh.createStateCheckpointStore()
+	}
+
 	if aeh.metadata.StorageAccountName == "" {
 		return nil, errors.New("property storageAccountName is required to subscribe to an Event Hub topic")
 	}
@@ -521,6 +622,20 @@ func (aeh *AzureEventHubs) createCheckpointStore(ctx context.Context) (checkpoin
 	return checkpointStore, nil
 }
 
+// Initializes a new checkpoint store backed by a state store component.
+// This method must be invoked while holding a lock on checkpointStoreLock.
+func (aeh *AzureEventHubs) createStateCheckpointStore() (azeventhubs.CheckpointStore, error) {
+	if aeh.stateStoreResolver == nil {
+		return nil, errors.New("property checkpointStateStoreName is not supported by this version of the runtime")
+	}
+
+	store, err := aeh.stateStoreResolver(aeh.metadata.CheckpointStateStoreName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state store %s for checkpoints: %w", aeh.metadata.CheckpointStateStoreName, err)
+	}
+	return newStateCheckpointStore(store)
+}
+
 // Creates a client to access Azure Blob Storage.
 // TODO(@ItalyPaleAle): Remove ensureContainer option (and default to true) for Dapr 1.13
 func (aeh *AzureEventHubs) createStorageClient(ctx context.Context, ensureContainer bool) (*container.Client, error) {
@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventhubs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs"
+	"golang.org/x/exp/maps"
+)
+
+const defaultGeoDRRefreshInterval = time.Minute
+
+// watchGeoDRAlias periodically resolves the Geo-DR alias, and re-establishes all connections when it points to a different namespace, which happens after a failover.
+// Clients connected to the previous primary namespace would otherwise keep failing until the sidecar is restarted.
+func (aeh *AzureEventHubs) watchGeoDRAlias(ctx context.Context, alias string, interval time.Duration) {
+	lookup := aeh.lookupCNAME
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupCNAME
+	}
+
+	resolve := func() (string, error) {
+		lookupCtx, cancel := context.WithTimeout(ctx, resourceGetTimeout)
+		defer cancel()
+		target, err := lookup(lookupCtx, alias)
+		if err != nil {
+			return "", err
+		}
+		return strings.ToLower(strings.TrimSuffix(target, ".")), nil
+	}
+
+	current, err := resolve()
+	if err != nil {
+		aeh.logger.Warnf("Failed to resolve Geo-DR alias %s: %v", alias, err)
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		target, err := resolve()
+		if err != nil {
+			aeh.logger.Warnf("Failed to resolve Geo-DR alias %s: %v", alias, err)
+			continue
+		}
+		if target == current {
+			continue
+		}
+		if current != "" {
+			aeh.logger.Warnf("Geo-DR alias %s now resolves to %s instead of %s; re-establishing connections", alias, target, current)
+			err = aeh.resetConnections(ctx)
+			if err != nil {
+				aeh.logger.Errorf("Failed to re-establish connections after Geo-DR failover: %v", err)
+			}
+		}
+		current = target
+	}
+}
+
+// resetConnections closes all producer clients, which are re-created on the next publish, and restarts all active subscriptions.
+func (aeh *AzureEventHubs) resetConnections(ctx context.Context) error {
+	aeh.producersLock.Lock()
+	producers := maps.Values(aeh.producers)
+	maps.Clear(aeh.producers)
+	aeh.producersLock.Unlock()
+
+	for _, producer := range producers {
+		closeCtx, closeCancel := context.WithTimeout(ctx, resourceGetTimeout)
+		producer.Close(closeCtx)
+		closeCancel()
+	}
+
+	aeh.subscriptionsLock.Lock()
+	defer aeh.subscriptionsLock.Unlock()
+
+	var errs []error
+	for topic, sub := range aeh.subscriptions {
+		if sub.parentCtx.Err() != nil {
+			delete(aeh.subscriptions, topic)
+			continue
+		}
+
+		sub.stop()
+		newSub, err := aeh.startSubscription(sub.parentCtx, topic, sub.getAllProperties, sub.handler)
+		if err != nil {
+			delete(aeh.subscriptions, topic)
+			errs = append(errs, fmt.Errorf("failed to restart the subscription to topic %s: %w", topic, err))
+			continue
+		}
+		aeh.subscriptions[topic] = newSub
+	}
+
+	return errors.Join(errs...)
+}
+
+// retryOptions returns the retry options for the Event Hubs clients.
+func (aeh *AzureEventHubs) retryOptions() azeventhubs.RetryOptions {
+	return azeventhubs.RetryOptions{
+		MaxRetries:    aeh.metadata.ClientRetryMaxRetries,
+		RetryDelay:    aeh.metadata.ClientRetryDelay,
+		MaxRetryDelay: aeh.metadata.ClientRetryMaxDelay,
+	}
+}
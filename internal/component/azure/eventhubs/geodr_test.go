@@ -0,0 +1,135 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventhubs
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGeoDRMetadata(t *testing.T) {
+	t.Run("connection string endpoint is replaced with the alias", func(t *testing.T) {
+		m, err := parseEventHubsMetadata(map[string]string{
+			"connectionString": "Endpoint=sb://primary.servicebus.windows.net/;SharedAccessKeyName=fakeKey;SharedAccessKey=key",
+			"geoDRAlias":       "myalias",
+		}, false, testLogger)
+		require.NoError(t, err)
+		assert.Equal(t, "myalias.servicebus.windows.net", m.GeoDRAlias)
+		assert.Equal(t, "Endpoint=sb://myalias.servicebus.windows.net/;SharedAccessKeyName=fakeKey;SharedAccessKey=key", m.ConnectionString)
+		assert.Equal(t, defaultGeoDRRefreshInterval, m.GeoDRRefreshInterval)
+	})
+
+	t.Run("alias is used as the namespace with Azure AD", func(t *testing.T) {
+		m, err := parseEventHubsMetadata(map[string]string{
+			"eventHubNamespace":    "primary",
+			"geoDRAlias":           "myalias.servicebus.windows.net",
+			"geoDRRefreshInterval": "10s",
+		}, false, testLogger)
+		require.NoError(t, err)
+		assert.Equal(t, "myalias.servicebus.windows.net", m.EventHubNamespace)
+		assert.Equal(t, "primary", m.namespaceName)
+		assert.Equal(t, 10*time.Second, m.GeoDRRefreshInterval)
+	})
+
+	t.Run("alias without namespace", func(t *testing.T) {
+		m, err := parseEventHubsMetadata(map[string]string{
+			"geoDRAlias": "myalias",
+		}, false, testLogger)
+		require.NoError(t, err)
+		assert.Equal(t, "myalias.servicebus.windows.net", m.EventHubNamespace)
+	})
+
+	t.Run("connection string without endpoint", func(t *testing.T) {
+		_, err := parseEventHubsMetadata(map[string]string{
+			"connectionString": "SharedAccessKeyName=fakeKey;SharedAccessKey=key",
+			"geoDRAlias":       "myalias",
+		}, false, testLogger)
+		require.Error(t, err)
+	})
+
+	t.Run("client retry options", func(t *testing.T) {
+		aeh := &AzureEventHubs{logger: testLogger}
+		err := aeh.Init(map[string]string{
+			"connectionString":      "Endpoint=sb://fake.servicebus.windows.net/;SharedAccessKeyName=fakeKey;SharedAccessKey=key",
+			"clientRetryMaxRetries": "10",
+			"clientRetryDelay":      "1s",
+			"clientRetryMaxDelay":   "30s",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, azeventhubs.RetryOptions{
+			MaxRetries:    10,
+			RetryDelay:    time.Second,
+			MaxRetryDelay: 30 * time.Second,
+		}, aeh.retryOptions())
+	})
+}
+
+func TestWatchGeoDRAlias(t *testing.T) {
+	aeh := NewAzureEventHubs(testLogger, false)
+	err := aeh.Init(map[string]string{
+		"connectionString": "Endpoint=sb://fake.servicebus.windows.net/;SharedAccessKeyName=fakeKey;SharedAccessKey=key",
+	})
+	require.NoError(t, err)
+
+	producer, err := aeh.getProducerClientForTopic(context.Background(), "mytopic")
+	require.NoError(t, err)
+	require.NotNil(t, producer)
+
+	var (
+		lock    sync.Mutex
+		target  = "primary.servicebus.windows.net."
+		lookups atomic.Int32
+	)
+	aeh.lookupCNAME = func(ctx context.Context, host string) (string, error) {
+		assert.Equal(t, "myalias.servicebus.windows.net", host)
+		lookups.Add(1)
+		lock.Lock()
+		defer lock.Unlock()
+		return target, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		aeh.watchGeoDRAlias(ctx, "myalias.servicebus.windows.net", 10*time.Millisecond)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Connections are kept while the alias resolves to the same namespace
+	assert.Eventually(t, func() bool { return lookups.Load() >= 3 }, time.Second, 5*time.Millisecond)
+	aeh.producersLock.RLock()
+	assert.Len(t, aeh.producers, 1)
+	aeh.producersLock.RUnlock()
+
+	// After a failover, producers are closed
+	lock.Lock()
+	target = "secondary.servicebus.windows.net."
+	lock.Unlock()
+	assert.Eventually(t, func() bool {
+		aeh.producersLock.RLock()
+		defer aeh.producersLock.RUnlock()
+		return len(aeh.producers) == 0
+	}, time.Second, 5*time.Millisecond)
+}
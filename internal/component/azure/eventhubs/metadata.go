@@ -17,6 +17,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs"
 
@@ -33,11 +34,22 @@ type AzureEventHubsMetadata struct {
 	StorageAccountName      string `json:"storageAccountName" mapstructure:"storageAccountName"`
 	StorageAccountKey       string `json:"storageAccountKey" mapstructure:"storageAccountKey"`
 	StorageContainerName    string `json:"storageContainerName" mapstructure:"storageContainerName"`
-	EnableEntityManagement  bool   `json:"enableEntityManagement,string" mapstructure:"enableEntityManagement"`
-	MessageRetentionInDays  int32  `json:"messageRetentionInDays,string" mapstructure:"messageRetentionInDays"`
-	PartitionCount          int32  `json:"partitionCount,string" mapstructure:"partitionCount"`
-	SubscriptionID          string `json:"subscriptionID" mapstructure:"subscriptionID"`
-	ResourceGroupName       string `json:"resourceGroupName" mapstructure:"resourceGroupName"`
+	// Name of a state store component where checkpoints are stored, in place of Azure Blob Storage
+	CheckpointStateStoreName string `json:"checkpointStateStoreName" mapstructure:"checkpointStateStoreName"`
+	EnableEntityManagement   bool   `json:"enableEntityManagement,string" mapstructure:"enableEntityManagement"`
+	MessageRetentionInDays   int32  `json:"messageRetentionInDays,string" mapstructure:"messageRetentionInDays"`
+	PartitionCount           int32  `json:"partitionCount,string" mapstructure:"partitionCount"`
+	SubscriptionID           string `json:"subscriptionID" mapstructure:"subscriptionID"`
+	ResourceGroupName        string `json:"resourceGroupName" mapstructure:"resourceGroupName"`
+
+	// Geo-DR alias to connect through; connections are re-established when the alias fails over to a different namespace
+	GeoDRAlias           string        `json:"geoDRAlias" mapstructure:"geoDRAlias"`
+	GeoDRRefreshInterval time.Duration `json:"geoDRRefreshInterval" mapstructure:"geoDRRefreshInterval"`
+
+	// Retry options of the Event Hubs clients, which are used for transient errors including throttling (ServerBusy)
+	ClientRetryMaxRetries int32         `json:"clientRetryMaxRetries,string" mapstructure:"clientRetryMaxRetries"`
+	ClientRetryDelay      time.Duration `json:"clientRetryDelay" mapstructure:"clientRetryDelay"`
+	ClientRetryMaxDelay   time.Duration `json:"clientRetryMaxDelay" mapstructure:"clientRetryMaxDelay"`
 
 	// Binding only
 	EventHub      string `json:"eventHub" mapstructure:"eventHub" only:"bindings"`
@@ -52,7 +64,9 @@ type AzureEventHubsMetadata struct {
 }
 
 func parseEventHubsMetadata(meta map[string]string, isBinding bool, log logger.Logger) (*AzureEventHubsMetadata, error) {
-	var m AzureEventHubsMetadata
+	m := AzureEventHubsMetadata{
+		GeoDRRefreshInterval: defaultGeoDRRefreshInterval,
+	}
 	err := metadata.DecodeMetadata(meta, &m)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode metada: %w", err)
@@ -61,6 +75,11 @@ func parseEventHubsMetadata(meta map[string]string, isBinding bool, log logger.L
 	// Store the raw properties in the object
 	m.properties = meta
 
+	// With Azure AD, the Geo-DR alias can be used in place of the namespace
+	if m.ConnectionString == "" && m.EventHubNamespace == "" && m.GeoDRAlias != "" {
+		m.EventHubNamespace = m.GeoDRAlias
+	}
+
 	// One and only one of connectionString and eventHubNamespace is required
 	if m.ConnectionString == "" && m.EventHubNamespace == "" {
 		return nil, errors.New("one of connectionString or eventHubNamespace is required")
@@ -138,9 +157,46 @@ func parseEventHubsMetadata(meta map[string]string, isBinding bool, log logger.L
 		m.namespaceName = m.EventHubNamespace[0:strings.IndexRune(m.EventHubNamespace, '.')]
 	}
 
+	if m.GeoDRAlias != "" {
+		if !strings.ContainsRune(m.GeoDRAlias, '.') {
+			m.GeoDRAlias += ".servicebus.windows.net"
+		}
+		if m.GeoDRRefreshInterval <= 0 {
+			return nil, errors.New("property geoDRRefreshInterval must be a positive duration")
+		}
+
+		// Connect through the alias, so clients reach the namespace that is currently the primary
+		// The namespace name, which is used for entity management, is not changed
+		if m.ConnectionString != "" {
+			m.ConnectionString, err = replaceConnStringEndpoint(m.ConnectionString, m.GeoDRAlias)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			m.EventHubNamespace = m.GeoDRAlias
+		}
+	}
+
 	return &m, nil
 }
 
+// Returns the connection string with the endpoint replaced with the given host.
+func replaceConnStringEndpoint(connString string, host string) (string, error) {
+	parts := strings.Split(connString, ";")
+	found := false
+	for i, part := range parts {
+		key, _, ok := strings.Cut(part, "=")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "Endpoint") {
+			parts[i] = "Endpoint=sb://" + host + "/"
+			found = true
+		}
+	}
+	if !found {
+		return "", errors.New("the provided connection string does not contain an endpoint")
+	}
+	return strings.Join(parts, ";"), nil
+}
+
 // Returns the hub name (topic) from the connection string.
 func hubNameFromConnString(connString string) string {
 	props, err := azeventhubs.ParseConnectionString(connString)
@@ -0,0 +1,195 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventhubs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs"
+
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/ptr"
+)
+
+// Replay overwrites the checkpoints of a consumer group on all the partitions of an Event Hub.
+// The offset is the sequence number of the first event to deliver.
+// The subscription of this component to the topic is restarted; other processors pick up the new checkpoints when they claim the partitions again, which happens after the ownership of the partitions expires.
+func (aeh *AzureEventHubs) Replay(ctx context.Context, req pubsub.ReplayRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	startPosition, err := replayStartPosition(req)
+	if err != nil {
+		return err
+	}
+
+	group := req.ConsumerGroup
+	if group == "" {
+		group = aeh.metadata.ConsumerGroup
+	}
+	if group == "" {
+		return errors.New("property consumerID is required to replay events from an Event Hub topic")
+	}
+
+	aeh.subscriptionsLock.Lock()
+	defer aeh.subscriptionsLock.Unlock()
+
+	sub := aeh.subscriptions[req.Topic]
+	restart := sub != nil && sub.parentCtx.Err() == nil && group == aeh.metadata.ConsumerGroup
+	if restart {
+		sub.stop()
+	}
+
+	err = aeh.resetCheckpoints(ctx, req.Topic, group, startPosition)
+	if restart {
+		newSub, serr := aeh.startSubscription(sub.parentCtx, req.Topic, sub.getAllProperties, sub.handler)
+		if serr != nil {
+			delete(aeh.subscriptions, req.Topic)
+			err = errors.Join(err, fmt.Errorf("failed to restart the subscription to topic %s: %w", req.Topic, serr))
+		} else {
+			aeh.subscriptions[req.Topic] = newSub
+		}
+	}
+
+	return err
+}
+
+func (aeh *AzureEventHubs) resetCheckpoints(ctx context.Context, topic string, group string, startPosition azeventhubs.StartPosition) error {
+	checkpointStore, err := aeh.getCheckpointStore(ctx)
+	if err != nil {
+		return err
+	}
+
+	namespace, err := aeh.fullyQualifiedNamespace()
+	if err != nil {
+		return err
+	}
+
+	consumerClient, err := aeh.getConsumerClientForTopic(topic, group)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeCtx, closeCancel := context.WithTimeout(context.Background(), resourceGetTimeout)
+		defer closeCancel()
+		consumerClient.Close(closeCtx)
+	}()
+
+	props, err := consumerClient.GetEventHubProperties(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get properties of Event Hub %s: %w", topic, err)
+	}
+
+	for _, partitionID := range props.PartitionIDs {
+		checkpoint, err := replayCheckpoint(ctx, consumerClient, partitionID, startPosition)
+		if err != nil {
+			return fmt.Errorf("failed to get the replay position of partition %s: %w", partitionID, err)
+		}
+		checkpoint.ConsumerGroup = group
+		checkpoint.EventHubName = topic
+		checkpoint.FullyQualifiedNamespace = namespace
+		checkpoint.PartitionID = partitionID
+
+		err = checkpointStore.SetCheckpoint(ctx, checkpoint, nil)
+		if err != nil {
+			return fmt.Errorf("failed to update checkpoint of partition %s: %w", partitionID, err)
+		}
+	}
+
+	aeh.logger.Infof("Reset checkpoints of consumer group %s on topic %s", group, topic)
+	return nil
+}
+
+// replayCheckpoint returns a checkpoint for the event that precedes the first event to deliver in a partition.
+// Because checkpoints are exclusive, this is obtained by receiving the first event to deliver.
+func replayCheckpoint(ctx context.Context, consumerClient *azeventhubs.ConsumerClient, partitionID string, startPosition azeventhubs.StartPosition) (azeventhubs.Checkpoint, error) {
+	partitionClient, err := consumerClient.NewPartitionClient(partitionID, &azeventhubs.PartitionClientOptions{
+		StartPosition: startPosition,
+	})
+	if err != nil {
+		return azeventhubs.Checkpoint{}, err
+	}
+	defer func() {
+		closeCtx, closeCancel := context.WithTimeout(context.Background(), resourceGetTimeout)
+		defer closeCancel()
+		partitionClient.Close(closeCtx)
+	}()
+
+	receiveCtx, receiveCancel := context.WithTimeout(ctx, resourceGetTimeout)
+	events, err := partitionClient.ReceiveEvents(receiveCtx, 1, nil)
+	receiveCancel()
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return azeventhubs.Checkpoint{}, err
+	}
+	if len(events) > 0 {
+		return checkpointBefore(events[0].Offset, events[0].SequenceNumber), nil
+	}
+
+	// There are no events to replay, so start after the last one
+	props, err := consumerClient.GetPartitionProperties(ctx, partitionID, nil)
+	if err != nil {
+		return azeventhubs.Checkpoint{}, err
+	}
+	return azeventhubs.Checkpoint{
+		Offset:         ptr.Of(props.LastEnqueuedOffset),
+		SequenceNumber: ptr.Of(props.LastEnqueuedSequenceNumber),
+	}, nil
+}
+
+// checkpointBefore returns a checkpoint that makes processors start from the event with the given offset and sequence number.
+func checkpointBefore(offset int64, sequenceNumber int64) azeventhubs.Checkpoint {
+	return azeventhubs.Checkpoint{
+		Offset:         ptr.Of(offset - 1),
+		SequenceNumber: ptr.Of(sequenceNumber - 1),
+	}
+}
+
+// replayStartPosition returns the position of the first event to deliver.
+func replayStartPosition(req pubsub.ReplayRequest) (azeventhubs.StartPosition, error) {
+	switch {
+	case !req.Timestamp.IsZero():
+		return azeventhubs.StartPosition{
+			EnqueuedTime: ptr.Of(req.Timestamp),
+			Inclusive:    true,
+		}, nil
+	case req.Offset == pubsub.ReplayOffsetEarliest:
+		return azeventhubs.StartPosition{
+			Earliest: ptr.Of(true),
+		}, nil
+	default:
+		seq, err := strconv.ParseInt(req.Offset, 10, 64)
+		if err != nil || seq < 0 {
+			return azeventhubs.StartPosition{}, fmt.Errorf("invalid offset '%s': must be a sequence number", req.Offset)
+		}
+		return azeventhubs.StartPosition{
+			SequenceNumber: ptr.Of(seq),
+			Inclusive:      true,
+		}, nil
+	}
+}
+
+// fullyQualifiedNamespace returns the namespace that the checkpoints are stored for.
+func (aeh *AzureEventHubs) fullyQualifiedNamespace() (string, error) {
+	if aeh.metadata.ConnectionString == "" {
+		return aeh.metadata.EventHubNamespace, nil
+	}
+	props, err := azeventhubs.ParseConnectionString(aeh.metadata.ConnectionString)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse connection string: %w", err)
+	}
+	return props.FullyQualifiedNamespace, nil
+}
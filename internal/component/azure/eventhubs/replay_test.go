@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventhubs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/ptr"
+)
+
+func TestReplayStartPosition(t *testing.T) {
+	t.Run("timestamp", func(t *testing.T) {
+		ts := time.Date(2023, 4, 1, 10, 0, 0, 0, time.UTC)
+		pos, err := replayStartPosition(pubsub.ReplayRequest{Topic: "hub", Timestamp: ts})
+		require.NoError(t, err)
+		assert.Equal(t, azeventhubs.StartPosition{EnqueuedTime: &ts, Inclusive: true}, pos)
+	})
+
+	t.Run("earliest", func(t *testing.T) {
+		pos, err := replayStartPosition(pubsub.ReplayRequest{Topic: "hub", Offset: pubsub.ReplayOffsetEarliest})
+		require.NoError(t, err)
+		assert.Equal(t, azeventhubs.StartPosition{Earliest: ptr.Of(true)}, pos)
+	})
+
+	t.Run("sequence number", func(t *testing.T) {
+		pos, err := replayStartPosition(pubsub.ReplayRequest{Topic: "hub", Offset: "42"})
+		require.NoError(t, err)
+		assert.Equal(t, azeventhubs.StartPosition{SequenceNumber: ptr.Of(int64(42)), Inclusive: true}, pos)
+	})
+
+	t.Run("invalid offset", func(t *testing.T) {
+		_, err := replayStartPosition(pubsub.ReplayRequest{Topic: "hub", Offset: "foo"})
+		assert.Error(t, err)
+		_, err = replayStartPosition(pubsub.ReplayRequest{Topic: "hub", Offset: "-1"})
+		assert.Error(t, err)
+	})
+}
+
+func TestCheckpointBefore(t *testing.T) {
+	cp := checkpointBefore(1024, 10)
+	assert.Equal(t, int64(1023), *cp.Offset)
+	assert.Equal(t, int64(9), *cp.SequenceNumber)
+
+	// The first event of a partition
+	cp = checkpointBefore(0, 0)
+	assert.Equal(t, int64(-1), *cp.Offset)
+	assert.Equal(t, int64(-1), *cp.SequenceNumber)
+}
+
+func TestFullyQualifiedNamespace(t *testing.T) {
+	aeh := NewAzureEventHubs(testLogger, false)
+
+	aeh.metadata = &AzureEventHubsMetadata{EventHubNamespace: "myns.servicebus.windows.net"}
+	ns, err := aeh.fullyQualifiedNamespace()
+	require.NoError(t, err)
+	assert.Equal(t, "myns.servicebus.windows.net", ns)
+
+	aeh.metadata = &AzureEventHubsMetadata{ConnectionString: "Endpoint=sb://other.servicebus.windows.net/;SharedAccessKeyName=key;SharedAccessKey=secret;EntityPath=hub"}
+	ns, err = aeh.fullyQualifiedNamespace()
+	require.NoError(t, err)
+	assert.Equal(t, "other.servicebus.windows.net", ns)
+}
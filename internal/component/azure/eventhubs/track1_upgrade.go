@@ -33,6 +33,11 @@ import (
 // These conflicts should be transient anyways, as mixed versions of Dapr should only happen during a rollout of a new version of Dapr.
 // TODO(@ItalyPaleAle): Remove this (entire file) for Dapr 1.13
 func (aeh *AzureEventHubs) ensureNoTrack1Subscribers(parentCtx context.Context, topic string) error {
+	// The old SDK only stored checkpoints in Azure Blob Storage
+	if aeh.metadata.CheckpointStateStoreName != "" {
+		return nil
+	}
+
 	// Get a client to Azure Blob Storage
 	// Because we are not using "ensureContainer=true", we can pass a nil context
 	client, err := aeh.createStorageClient(nil, false) //nolint:staticcheck
@@ -15,6 +15,7 @@ package servicebus
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -22,12 +23,17 @@ import (
 	servicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
 	sbadmin "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus/admin"
 	"github.com/cenkalti/backoff/v4"
-	"golang.org/x/exp/maps"
 
 	azauth "github.com/dapr/components-contrib/internal/authentication/azure"
 	"github.com/dapr/kit/logger"
 )
 
+// Bounds for the interval at which idle senders are checked.
+const (
+	minSenderReaperInterval = time.Second
+	maxSenderReaperInterval = time.Minute
+)
+
 // Type that matches Client.EnsureTopic and Client.EnsureSubscription
 type ensureFn func(context.Context, string) error
 
@@ -36,16 +42,24 @@ type Client struct {
 	client      *servicebus.Client
 	adminClient *sbadmin.Client
 	metadata    *Metadata
+	logger      logger.Logger
 	lock        *sync.RWMutex
-	senders     map[string]*servicebus.Sender
+	senders     *senderCache
+	// Channel used to stop the goroutine that closes idle senders; nil if it's not running.
+	reaperStopCh chan struct{}
+	// Cache of whether queues and topics require sessions.
+	sessionEntities map[string]sessionRequirement
 }
 
 // NewClient creates a new Client object.
-func NewClient(metadata *Metadata, rawMetadata map[string]string) (*Client, error) {
+func NewClient(metadata *Metadata, rawMetadata map[string]string, log logger.Logger) (*Client, error) {
 	client := &Client{
 		metadata: metadata,
+		logger:   log,
 		lock:     &sync.RWMutex{},
-		senders:  make(map[string]*servicebus.Sender),
+		senders:  newSenderCache(metadata.MaxSenders),
+
+		sessionEntities: make(map[string]sessionRequirement),
 	}
 
 	clientOpts := &servicebus.ClientOptions{
@@ -57,6 +71,11 @@ func NewClient(metadata *Metadata, rawMetadata map[string]string) (*Client, erro
 		},*/
 	}
 
+	if metadata.UseDevelopmentEmulator {
+		log.Warn("Connecting to the Service Bus development emulator without TLS: this must not be used in production")
+		clientOpts.NewWebSocketConn = emulatorDialer()
+	}
+
 	if metadata.ConnectionString != "" {
 		var err error
 		client.client, err = servicebus.NewClientFromConnectionString(metadata.ConnectionString, clientOpts)
@@ -102,21 +121,49 @@ func (c *Client) GetClient() *servicebus.Client {
 	return c.client
 }
 
-// GetSenderForTopic returns the sender for a queue or topic, or creates a new one if it doesn't exist
-func (c *Client) GetSender(ctx context.Context, queueOrTopic string, ensureFn ensureFn) (*servicebus.Sender, error) {
-	c.lock.RLock()
-	sender, ok := c.senders[queueOrTopic]
-	c.lock.RUnlock()
-	if ok && sender != nil {
-		return sender, nil
+// GetAdminClient returns the admin client, or nil if entity management is disabled.
+func (c *Client) GetAdminClient() *sbadmin.Client {
+	return c.adminClient
+}
+
+// Ping checks the connection to the Service Bus namespace.
+// When entity management is enabled, it retrieves the properties of the namespace from the management plane.
+// Otherwise, it checks the AMQP link of the sender for queueOrTopic, establishing it if needed; nothing is checked if queueOrTopic is empty.
+func (c *Client) Ping(ctx context.Context, queueOrTopic string) error {
+	if c.client == nil {
+		return errors.New("client is closed")
+	}
+
+	if c.adminClient != nil {
+		_, err := c.adminClient.GetNamespaceProperties(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get the properties of the namespace: %w", err)
+		}
+		return nil
 	}
 
+	if queueOrTopic == "" {
+		return nil
+	}
+	sender, err := c.GetSender(ctx, queueOrTopic, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create sender for %s: %w", queueOrTopic, err)
+	}
+	// Creating a batch requires an active link, as it retrieves the maximum message size from the broker
+	_, err = sender.NewMessageBatch(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", queueOrTopic, err)
+	}
+	return nil
+}
+
+// GetSenderForTopic returns the sender for a queue or topic, or creates a new one if it doesn't exist
+func (c *Client) GetSender(ctx context.Context, queueOrTopic string, ensureFn ensureFn) (*servicebus.Sender, error) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	// Check again after acquiring a write lock in case another goroutine created the sender
-	sender, ok = c.senders[queueOrTopic]
-	if ok && sender != nil {
+	sender := c.senders.Get(queueOrTopic, time.Now())
+	if sender != nil {
 		return sender, nil
 	}
 
@@ -130,12 +177,18 @@ func (c *Client) GetSender(ctx context.Context, queueOrTopic string, ensureFn en
 		}
 	}
 
+	if c.client == nil {
+		return nil, errors.New("client is closed")
+	}
+
 	// Create the sender
 	sender, err := c.client.NewSender(queueOrTopic, nil)
 	if err != nil {
 		return nil, err
 	}
-	c.senders[queueOrTopic] = sender
+	evicted := c.senders.Add(queueOrTopic, sender, time.Now())
+	c.closeRemovedSenders(evicted, "evicted")
+	c.startSenderReaper()
 
 	return sender, nil
 }
@@ -143,10 +196,7 @@ func (c *Client) GetSender(ctx context.Context, queueOrTopic string, ensureFn en
 // CloseSender closes a sender for a queue or topic.
 func (c *Client) CloseSender(queueOrTopic string, log logger.Logger) {
 	c.lock.Lock()
-	sender, ok := c.senders[queueOrTopic]
-	if ok {
-		delete(c.senders, queueOrTopic)
-	}
+	sender := c.senders.Remove(queueOrTopic)
 	c.lock.Unlock()
 
 	if sender != nil {
@@ -168,9 +218,11 @@ func (c *Client) CloseAllSenders(log logger.Logger) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
+	c.stopSenderReaper()
+
 	// Close all senders, up to 3 in parallel
 	workersCh := make(chan bool, 3)
-	for k, t := range c.senders {
+	for _, e := range c.senders.RemoveAll() {
 		// Blocks if we have too many goroutines
 		workersCh <- true
 		go func(k string, t *servicebus.Sender) {
@@ -183,16 +235,13 @@ func (c *Client) CloseAllSenders(log logger.Logger) {
 				log.Warnf("Error closing sender %s: %v", k, err)
 			}
 			<-workersCh
-		}(k, t)
+		}(e.name, e.sender)
 	}
 	for i := 0; i < cap(workersCh); i++ {
 		// Wait for all workers to be done
 		workersCh <- true
 	}
 	close(workersCh)
-
-	// Clear the map
-	maps.Clear(c.senders)
 }
 
 // Close the client and every sender or consumer created by the connnection.
@@ -200,6 +249,8 @@ func (c *Client) Close(log logger.Logger) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
+	c.stopSenderReaper()
+
 	if c.client != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.metadata.TimeoutInSec)*time.Second)
 		err := c.client.Close(ctx)
@@ -211,8 +262,99 @@ func (c *Client) Close(log logger.Logger) {
 		c.client = nil
 	}
 
-	// Clear the map of senders
-	maps.Clear(c.senders)
+	// Clear the cache of senders; they were closed together with the client
+	c.senders.RemoveAll()
+}
+
+// startSenderReaper starts the background goroutine that closes idle senders, if it's enabled and not already running.
+// It must be invoked while holding a write lock.
+func (c *Client) startSenderReaper() {
+	if c.metadata.SenderIdleTimeout <= 0 || c.reaperStopCh != nil {
+		return
+	}
+
+	// Check for idle senders at twice the frequency of the timeout, within reasonable bounds
+	interval := c.metadata.SenderIdleTimeout / 2
+	if interval < minSenderReaperInterval {
+		interval = minSenderReaperInterval
+	} else if interval > maxSenderReaperInterval {
+		interval = maxSenderReaperInterval
+	}
+
+	stopCh := make(chan struct{})
+	c.reaperStopCh = stopCh
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				if !c.reapIdleSenders(stopCh) {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// stopSenderReaper stops the background goroutine that closes idle senders, if it's running.
+// It must be invoked while holding a write lock.
+func (c *Client) stopSenderReaper() {
+	if c.reaperStopCh != nil {
+		close(c.reaperStopCh)
+		c.reaperStopCh = nil
+	}
+}
+
+// reapIdleSenders closes the senders that haven't been used within the idle timeout.
+// It returns false when the reaper should stop, because there are no senders left or because it was stopped.
+func (c *Client) reapIdleSenders(stopCh chan struct{}) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// Another reaper may have been started after this one was stopped
+	if c.reaperStopCh != stopCh {
+		return false
+	}
+
+	idle := c.senders.RemoveIdle(time.Now().Add(-c.metadata.SenderIdleTimeout))
+	c.closeRemovedSenders(idle, "idle")
+
+	// Stop the reaper when there are no senders left; it's restarted when a new sender is created
+	if c.senders.Len() == 0 {
+		c.reaperStopCh = nil
+		return false
+	}
+	return true
+}
+
+// closeRemovedSenders closes the senders that were removed from the cache in background.
+// Senders that were used recently are closed once the timeout for operations has elapsed, so publish operations that are in progress can complete.
+func (c *Client) closeRemovedSenders(entries []*senderCacheEntry, reason string) {
+	now := time.Now()
+	timeout := time.Duration(c.metadata.TimeoutInSec) * time.Second
+	for _, e := range entries {
+		e := e
+		closeFn := func() {
+			c.logger.Debugf("Closing %s sender: %s", reason, e.name)
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			err := e.sender.Close(ctx)
+			cancel()
+			if err != nil {
+				// Log only
+				c.logger.Warnf("Error closing %s sender %s: %v", reason, e.name, err)
+			}
+		}
+
+		delay := e.lastUsed.Add(timeout).Sub(now)
+		if delay > 0 {
+			time.AfterFunc(delay, closeFn)
+		} else {
+			go closeFn()
+		}
+	}
 }
 
 // EnsureTopic creates the topic if it doesn't exist.
@@ -240,6 +382,12 @@ func (c *Client) EnsureTopic(ctx context.Context, topic string) error {
 type SubscribeOptions struct {
 	RequireSessions      bool
 	MaxConcurrentSesions int
+	// MaxDeliveryCount overrides the maxDeliveryCount from the component's metadata when creating the subscription.
+	MaxDeliveryCount *int32
+	// ForwardDeadLetteredMessagesTo is the name of the topic or queue where dead-lettered messages are forwarded to.
+	ForwardDeadLetteredMessagesTo string
+	// Rules are the filter rules of the topic subscription. If empty, the existing rules are not changed.
+	Rules []sbadmin.RuleProperties
 }
 
 // EnsureSubscription creates the topic subscription if it doesn't exist.
@@ -266,12 +414,18 @@ func (c *Client) EnsureSubscription(ctx context.Context, name string, topic stri
 		}
 	}
 
-	return nil
+	return c.EnsureSubscriptionRules(ctx, topic, name, opts.Rules)
 }
 
 // EnsureTopic creates the queue if it doesn't exist.
 // Returns with nil error if the admin client doesn't exist.
 func (c *Client) EnsureQueue(ctx context.Context, queue string) error {
+	return c.EnsureQueueWithOptions(ctx, queue, SubscribeOptions{})
+}
+
+// EnsureQueueWithOptions creates the queue if it doesn't exist, applying the options that are used when subscribing.
+// Returns with nil error if the admin client doesn't exist.
+func (c *Client) EnsureQueueWithOptions(ctx context.Context, queue string, opts SubscribeOptions) error {
 	if c.adminClient == nil {
 		return nil
 	}
@@ -282,7 +436,7 @@ func (c *Client) EnsureQueue(ctx context.Context, queue string) error {
 	}
 
 	if shouldCreate {
-		err = c.createQueue(ctx, queue)
+		err = c.createQueue(ctx, queue, opts)
 		if err != nil {
 			return err
 		}
@@ -365,12 +519,12 @@ func (c *Client) shouldCreateQueue(parentCtx context.Context, queue string) (boo
 	return false, nil
 }
 
-func (c *Client) createQueue(parentCtx context.Context, queue string) error {
+func (c *Client) createQueue(parentCtx context.Context, queue string, opts SubscribeOptions) error {
 	ctx, cancel := context.WithTimeout(parentCtx, time.Second*time.Duration(c.metadata.TimeoutInSec))
 	defer cancel()
 
 	_, err := c.adminClient.CreateQueue(ctx, queue, &sbadmin.CreateQueueOptions{
-		Properties: c.metadata.CreateQueueProperties(),
+		Properties: c.metadata.CreateQueueProperties(opts),
 	})
 	if err != nil {
 		return fmt.Errorf("could not create queue %s: %w", queue, err)
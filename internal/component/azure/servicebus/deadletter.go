@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebus
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	azservicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+
+	"github.com/dapr/components-contrib/internal/utils"
+	"github.com/dapr/kit/ptr"
+)
+
+const (
+	// MaxDeliveryCountMetadataKey is the subscribe metadata key for the maximum number of deliveries of a message.
+	// When the app fails to process a message that reached this count, the message is dead-lettered explicitly.
+	MaxDeliveryCountMetadataKey = "maxDeliveryCount"
+	// DeadLetterTopicMetadataKey is the subscribe metadata key for the topic or queue where dead-lettered messages are forwarded to.
+	DeadLetterTopicMetadataKey = "deadLetterTopic"
+	// DeadLetterQueueMetadataKey is the subscribe metadata key that makes the subscription receive messages from the dead-letter sub-queue.
+	DeadLetterQueueMetadataKey = "deadLetterQueue"
+	// DeadLetterQueueSuffix can be appended to a topic or queue name to subscribe to its dead-letter sub-queue.
+	DeadLetterQueueSuffix = "/$DeadLetterQueue"
+
+	// DeadLetterReasonMaxDeliveryCount is the reason set on messages that are dead-lettered after exhausting their deliveries.
+	DeadLetterReasonMaxDeliveryCount = "MaxDeliveryCountExceeded"
+)
+
+// DeadLetterOptions contains the dead-letter options of a subscription.
+type DeadLetterOptions struct {
+	// Entity is the name of the topic or queue, without the dead-letter sub-queue suffix.
+	Entity string
+	// ReceiveDeadLetters is true when messages are received from the dead-letter sub-queue.
+	ReceiveDeadLetters bool
+	// MaxDeliveryCount is the number of deliveries after which failed messages are dead-lettered explicitly.
+	MaxDeliveryCount *int32
+	// ForwardTo is the name of the topic or queue where dead-lettered messages are forwarded to.
+	ForwardTo string
+}
+
+// ParseDeadLetterOptions parses the dead-letter options from the metadata of a subscribe request.
+func ParseDeadLetterOptions(entity string, md map[string]string) (DeadLetterOptions, error) {
+	opts := DeadLetterOptions{
+		Entity:             entity,
+		ReceiveDeadLetters: utils.IsTruthy(md[DeadLetterQueueMetadataKey]),
+		ForwardTo:          md[DeadLetterTopicMetadataKey],
+	}
+
+	if strings.HasSuffix(entity, DeadLetterQueueSuffix) {
+		opts.Entity = strings.TrimSuffix(entity, DeadLetterQueueSuffix)
+		opts.ReceiveDeadLetters = true
+	}
+
+	if val := md[MaxDeliveryCountMetadataKey]; val != "" {
+		n, err := strconv.ParseInt(val, 10, 32)
+		if err != nil || n < 1 {
+			return opts, fmt.Errorf("invalid %s '%s': must be a positive integer", MaxDeliveryCountMetadataKey, val)
+		}
+		opts.MaxDeliveryCount = ptr.Of(int32(n))
+	}
+
+	if opts.ForwardTo != "" && opts.ForwardTo == opts.Entity {
+		return opts, fmt.Errorf("%s must not be the same as the subscribed entity %s", DeadLetterTopicMetadataKey, opts.Entity)
+	}
+
+	return opts, nil
+}
+
+// ReceiverOptions returns the options to use when creating a receiver.
+func (o DeadLetterOptions) ReceiverOptions() *azservicebus.ReceiverOptions {
+	if !o.ReceiveDeadLetters {
+		return nil
+	}
+	return &azservicebus.ReceiverOptions{
+		SubQueue: azservicebus.SubQueueDeadLetter,
+	}
+}
+
+// DeadLetterThreshold returns the delivery count after which failed messages are dead-lettered explicitly, or 0 if disabled.
+// Explicit dead-lettering is enabled only when the subscribe request sets maxDeliveryCount: the maxDeliveryCount in the
+// component's metadata is applied to the entities it creates, and Service Bus dead-letters those messages on its own.
+// Messages received from the dead-letter sub-queue are never dead-lettered again.
+func (o DeadLetterOptions) DeadLetterThreshold() int32 {
+	if o.ReceiveDeadLetters || o.MaxDeliveryCount == nil {
+		return 0
+	}
+	return *o.MaxDeliveryCount
+}
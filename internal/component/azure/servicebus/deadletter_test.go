@@ -0,0 +1,100 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebus
+
+import (
+	"testing"
+
+	azservicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/ptr"
+)
+
+func TestParseDeadLetterOptions(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		opts, err := ParseDeadLetterOptions("orders", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "orders", opts.Entity)
+		assert.False(t, opts.ReceiveDeadLetters)
+		assert.Nil(t, opts.MaxDeliveryCount)
+		assert.Nil(t, opts.ReceiverOptions())
+		assert.Equal(t, int32(0), opts.DeadLetterThreshold())
+	})
+
+	t.Run("dead-letter queue suffix", func(t *testing.T) {
+		opts, err := ParseDeadLetterOptions("orders/$DeadLetterQueue", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "orders", opts.Entity)
+		assert.True(t, opts.ReceiveDeadLetters)
+		assert.Equal(t, azservicebus.SubQueueDeadLetter, opts.ReceiverOptions().SubQueue)
+	})
+
+	t.Run("dead-letter queue metadata", func(t *testing.T) {
+		opts, err := ParseDeadLetterOptions("orders", map[string]string{DeadLetterQueueMetadataKey: "true"})
+		require.NoError(t, err)
+		assert.Equal(t, "orders", opts.Entity)
+		assert.True(t, opts.ReceiveDeadLetters)
+		assert.Equal(t, int32(0), opts.DeadLetterThreshold())
+	})
+
+	t.Run("max delivery count and forwarding", func(t *testing.T) {
+		opts, err := ParseDeadLetterOptions("orders", map[string]string{
+			MaxDeliveryCountMetadataKey: "3",
+			DeadLetterTopicMetadataKey:  "poison",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), *opts.MaxDeliveryCount)
+		assert.Equal(t, "poison", opts.ForwardTo)
+		assert.Equal(t, int32(3), opts.DeadLetterThreshold())
+	})
+
+	t.Run("component max delivery count is not used", func(t *testing.T) {
+		opts, err := ParseDeadLetterOptions("orders", nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(0), opts.DeadLetterThreshold())
+	})
+
+	t.Run("invalid max delivery count", func(t *testing.T) {
+		_, err := ParseDeadLetterOptions("orders", map[string]string{MaxDeliveryCountMetadataKey: "0"})
+		assert.Error(t, err)
+		_, err = ParseDeadLetterOptions("orders", map[string]string{MaxDeliveryCountMetadataKey: "a"})
+		assert.Error(t, err)
+	})
+
+	t.Run("forwarding to itself", func(t *testing.T) {
+		_, err := ParseDeadLetterOptions("orders", map[string]string{DeadLetterTopicMetadataKey: "orders"})
+		assert.Error(t, err)
+	})
+}
+
+func TestCreateSubscriptionPropertiesDeadLetter(t *testing.T) {
+	md := Metadata{MaxDeliveryCount: ptr.Of(int32(10))}
+
+	props := md.CreateSubscriptionProperties(SubscribeOptions{})
+	assert.Equal(t, int32(10), *props.MaxDeliveryCount)
+	assert.Nil(t, props.ForwardDeadLetteredMessagesTo)
+
+	props = md.CreateSubscriptionProperties(SubscribeOptions{
+		MaxDeliveryCount:              ptr.Of(int32(3)),
+		ForwardDeadLetteredMessagesTo: "poison",
+	})
+	assert.Equal(t, int32(3), *props.MaxDeliveryCount)
+	assert.Equal(t, "poison", *props.ForwardDeadLetteredMessagesTo)
+
+	queueProps := md.CreateQueueProperties(SubscribeOptions{ForwardDeadLetteredMessagesTo: "poison"})
+	assert.Equal(t, int32(10), *queueProps.MaxDeliveryCount)
+	assert.Equal(t, "poison", *queueProps.ForwardDeadLetteredMessagesTo)
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebus
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	servicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+
+	"github.com/dapr/components-contrib/internal/utils"
+)
+
+const (
+	// Key in the connection string that enables the development emulator, as in the connection strings of the official emulator.
+	connStrEmulatorKey = "UseDevelopmentEmulator"
+	// Port of the AMQP endpoint of the emulator, which doesn't use TLS.
+	emulatorAMQPPort = "5672"
+	// Default host of the emulator.
+	defaultEmulatorHost = "localhost"
+	// Connection string for the emulator, whose shared access key is the same for all installations.
+	emulatorConnectionStringFormat = "Endpoint=sb://%s;SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=SAS_KEY_VALUE"
+)
+
+// parseEmulatorConnectionString returns the connection string without the UseDevelopmentEmulator key, which is not supported by the SDK, and true if the key is set to a truthy value.
+func parseEmulatorConnectionString(connStr string) (string, bool) {
+	var emulator bool
+	parts := strings.Split(connStr, ";")
+	res := make([]string, 0, len(parts))
+	for _, part := range parts {
+		// The connection strings of the emulator end with a ";"
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		key, val, _ := strings.Cut(part, "=")
+		if strings.EqualFold(strings.TrimSpace(key), connStrEmulatorKey) {
+			emulator = utils.IsTruthy(val)
+			continue
+		}
+		res = append(res, part)
+	}
+	return strings.Join(res, ";"), emulator
+}
+
+// emulatorConnectionString returns the connection string for the emulator at the given host.
+func emulatorConnectionString(host string) string {
+	if host == "" {
+		host = defaultEmulatorHost
+	}
+	return fmt.Sprintf(emulatorConnectionStringFormat, host)
+}
+
+// emulatorDialer returns a function that opens plain TCP connections to the AMQP endpoint of the emulator.
+// The SDK always uses TLS, unless it's given a function to create the connections for WebSockets: the AMQP connection is then established over the returned connection, so it can be used to connect without TLS.
+func emulatorDialer() func(ctx context.Context, args servicebus.NewWebSocketConnArgs) (net.Conn, error) {
+	return func(ctx context.Context, args servicebus.NewWebSocketConnArgs) (net.Conn, error) {
+		// The host is passed as a WebSocket URL: "wss://<host>/$servicebus/websocket"
+		u, err := url.Parse(args.Host)
+		if err != nil {
+			return nil, fmt.Errorf("invalid emulator host %s: %w", args.Host, err)
+		}
+		addr := u.Host
+		if u.Port() == "" {
+			addr = net.JoinHostPort(u.Hostname(), emulatorAMQPPort)
+		}
+
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", addr)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebus
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	azservicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEmulatorConnectionString(t *testing.T) {
+	tests := []struct {
+		connStr  string
+		expected string
+		emulator bool
+	}{
+		{
+			connStr:  "Endpoint=sb://localhost;SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=SAS_KEY_VALUE;UseDevelopmentEmulator=true;",
+			expected: "Endpoint=sb://localhost;SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=SAS_KEY_VALUE",
+			emulator: true,
+		},
+		{
+			connStr:  "Endpoint=sb://localhost;usedevelopmentemulator=false;SharedAccessKeyName=key;SharedAccessKey=a=b",
+			expected: "Endpoint=sb://localhost;SharedAccessKeyName=key;SharedAccessKey=a=b",
+			emulator: false,
+		},
+		{
+			connStr:  "Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=key;SharedAccessKey=secret",
+			expected: "Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=key;SharedAccessKey=secret",
+			emulator: false,
+		},
+	}
+
+	for _, tt := range tests {
+		connStr, emulator := parseEmulatorConnectionString(tt.connStr)
+		assert.Equal(t, tt.expected, connStr)
+		assert.Equal(t, tt.emulator, emulator)
+	}
+}
+
+func TestEmulatorDialer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			conn.Write([]byte("AMQP"))
+			conn.Close()
+		}
+	}()
+
+	dial := emulatorDialer()
+	conn, err := dial(context.Background(), azservicebus.NewWebSocketConnArgs{
+		Host: "wss://" + ln.Addr().String() + "/$servicebus/websocket",
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	buf := make([]byte, 4)
+	_, err = conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "AMQP", string(buf))
+}
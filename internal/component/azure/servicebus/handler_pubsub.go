@@ -24,7 +24,7 @@ func GetPubSubHandlerFunc(topic string, handler pubsub.Handler, log logger.Logge
 			return nil, fmt.Errorf("failed to get pubsub message from azure service bus message: %+v", err)
 		}
 
-		handleCtx, handleCancel := context.WithTimeout(ctx, timeout)
+		handleCtx, handleCancel := handlerContext(ctx, timeout)
 		defer handleCancel()
 		log.Debugf("Calling app's handler for message %s on topic %s", asbMsgs[0].MessageID, topic)
 		return nil, handler(handleCtx, pubsubMsg)
@@ -51,7 +51,7 @@ func GetBulkPubSubHandlerFunc(topic string, handler pubsub.BulkHandler, log logg
 			Topic:    topic,
 		}
 
-		handleCtx, handleCancel := context.WithTimeout(ctx, timeout)
+		handleCtx, handleCancel := handlerContext(ctx, timeout)
 		defer handleCancel()
 		log.Debugf("Calling app's handler for %d messages on topic %s", len(asbMsgs), topic)
 		resps, err := handler(handleCtx, bulkMessage)
@@ -67,3 +67,11 @@ func GetBulkPubSubHandlerFunc(topic string, handler pubsub.BulkHandler, log logg
 		return implResps, err
 	}
 }
+
+// handlerContext returns the context for invoking a handler with the timeout; a timeout of 0 means no timeout.
+func handlerContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -16,6 +16,7 @@ package servicebus
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	azservicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
@@ -39,6 +40,8 @@ const (
 
 	// MessageKeySessionID defines the metadata key for the session id.
 	MessageKeySessionID = "SessionId" // read, write.
+	// MessageKeySessionIDAlias is an alias for "SessionId" for write only.
+	MessageKeySessionIDAlias = "sessionId"
 
 	// MessageKeyLabel defines the metadata key for the label.
 	MessageKeyLabel = "Label" // read, write.
@@ -51,6 +54,8 @@ const (
 
 	// MessageKeyPartitionKey defines the metadata key for the partition key.
 	MessageKeyPartitionKey = "PartitionKey" // read, write.
+	// MessageKeyPartitionKeyAlias is an alias for "PartitionKey" for write only.
+	MessageKeyPartitionKeyAlias = "partitionKey"
 
 	// MessageKeyContentType defines the metadata key for the content type.
 	MessageKeyContentType = "ContentType" // read, write.
@@ -72,6 +77,11 @@ const (
 
 	// MessageKeyScheduledEnqueueTimeUtc defines the metadata key for the scheduled enqueue time utc value.
 	MessageKeyScheduledEnqueueTimeUtc = "ScheduledEnqueueTimeUtc" // read, write.
+	// MessageKeyScheduledEnqueueTimeUtcAlias is an alias for "ScheduledEnqueueTimeUtc" for write only.
+	MessageKeyScheduledEnqueueTimeUtcAlias = "scheduledEnqueueTimeUtc"
+	// MessageKeyDelaySeconds defines the metadata key for delaying the delivery of a message by a number of seconds.
+	// It cannot be used together with "ScheduledEnqueueTimeUtc".
+	MessageKeyDelaySeconds = "delaySeconds" // write.
 
 	// MessageKeyReplyToSessionID defines the metadata key for the reply to session id.
 	// Currently unused.
@@ -116,7 +126,7 @@ func addMetadataToMessage(asbMsg *azservicebus.Message, metadata map[string]stri
 
 	for k, v := range metadata {
 		// Note: do not just do &v because we're in a loop
-		if v == "" && k != MessageKeySessionID { // blank session ID is valid
+		if v == "" && k != MessageKeySessionID && k != MessageKeySessionIDAlias { // blank session ID is valid
 			continue
 		}
 
@@ -141,7 +151,7 @@ func addMetadataToMessage(asbMsg *azservicebus.Message, metadata map[string]stri
 			}
 
 		// String types
-		case MessageKeySessionID:
+		case MessageKeySessionID, MessageKeySessionIDAlias:
 			asbMsg.SessionID = ptr.Of(v)
 		case MessageKeyLabel:
 			asbMsg.Subject = ptr.Of(v)
@@ -149,13 +159,16 @@ func addMetadataToMessage(asbMsg *azservicebus.Message, metadata map[string]stri
 			asbMsg.ReplyTo = ptr.Of(v)
 		case MessageKeyTo:
 			asbMsg.To = ptr.Of(v)
-		case MessageKeyPartitionKey:
+		case MessageKeyPartitionKey, MessageKeyPartitionKeyAlias:
 			asbMsg.PartitionKey = ptr.Of(v)
 		case MessageKeyContentType:
 			asbMsg.ContentType = ptr.Of(v)
 
 		// Time
-		case MessageKeyScheduledEnqueueTimeUtc:
+		case MessageKeyScheduledEnqueueTimeUtc, MessageKeyScheduledEnqueueTimeUtcAlias:
+			if _, ok := metadata[MessageKeyDelaySeconds]; ok {
+				return fmt.Errorf("%s and %s cannot be set at the same time", k, MessageKeyDelaySeconds)
+			}
 			timeVal, err := time.Parse(http.TimeFormat, v)
 			if err == nil {
 				asbMsg.ScheduledEnqueueTime = &timeVal
@@ -167,6 +180,12 @@ func addMetadataToMessage(asbMsg *azservicebus.Message, metadata map[string]stri
 					return fmt.Errorf("invalid time format for %s; expected HTTP time format or RFC3339", k)
 				}
 			}
+		case MessageKeyDelaySeconds:
+			delay, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || delay < 0 {
+				return fmt.Errorf("invalid value for %s: '%s'; expected a non-negative integer", k, v)
+			}
+			asbMsg.ScheduledEnqueueTime = ptr.Of(time.Now().UTC().Add(time.Duration(delay) * time.Second))
 
 		// Fallback: set as application property
 		default:
@@ -15,6 +15,7 @@ package servicebus
 
 import (
 	"encoding/base64"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -26,9 +27,14 @@ import (
 
 // NewPubsubMessageFromASBMessage returns a pubsub.NewMessage from a message received from ASB.
 func NewPubsubMessageFromASBMessage(asbMsg *azservicebus.ReceivedMessage, topic string) (*pubsub.NewMessage, error) {
+	data, err := decompressMessage(asbMsg)
+	if err != nil {
+		return nil, err
+	}
+
 	pubsubMsg := &pubsub.NewMessage{
 		Topic: topic,
-		Data:  asbMsg.Body,
+		Data:  data,
 	}
 
 	pubsubMsg.Metadata = addMessageAttributesToMetadata(pubsubMsg.Metadata, asbMsg)
@@ -43,9 +49,14 @@ func NewBulkMessageEntryFromASBMessage(asbMsg *azservicebus.ReceivedMessage) (pu
 		return pubsub.BulkMessageEntry{}, err
 	}
 
+	data, err := decompressMessage(asbMsg)
+	if err != nil {
+		return pubsub.BulkMessageEntry{}, err
+	}
+
 	bulkMsgEntry := pubsub.BulkMessageEntry{
 		EntryId: entryId.String(),
-		Event:   asbMsg.Body,
+		Event:   data,
 	}
 
 	bulkMsgEntry.Metadata = addMessageAttributesToMetadata(bulkMsgEntry.Metadata, asbMsg)
@@ -105,17 +116,32 @@ func addMessageAttributesToMetadata(metadata map[string]string, asbMsg *azservic
 		metadata["metadata."+MessageKeyLockedUntilUtc] = asbMsg.LockedUntil.UTC().Format(http.TimeFormat)
 	}
 
+	// Propagate the trace context set by the publisher as application properties.
+	metadata = pubsub.AddTraceContextToMetadata(metadata, func(key string) string {
+		v, _ := asbMsg.ApplicationProperties[key].(string)
+		return v
+	})
+
 	return metadata
 }
 
 // UpdateASBBatchMessageWithBulkPublishRequest updates the batch message with messages from the bulk publish request.
-func UpdateASBBatchMessageWithBulkPublishRequest(asbMsgBatch *azservicebus.MessageBatch, req *pubsub.BulkPublishRequest) error {
+// The body of the messages is compressed with the given algorithm.
+func UpdateASBBatchMessageWithBulkPublishRequest(asbMsgBatch *azservicebus.MessageBatch, req *pubsub.BulkPublishRequest, compression pubsub.Compression) error {
 	// Add entries from bulk request to batch.
 	for _, entry := range req.Entries {
 		asbMsg, err := NewASBMessageFromBulkMessageEntry(entry)
 		if err != nil {
 			return err
 		}
+		err = compressMessage(asbMsg, compression)
+		if err != nil {
+			return err
+		}
+		// The trace context of the request applies to the entries that don't have their own.
+		for k, v := range pubsub.TraceContextFromMetadata(req.Metadata, entry.Metadata) {
+			asbMsg.ApplicationProperties[k] = v
+		}
 
 		err = asbMsgBatch.AddMessage(asbMsg, nil)
 		if err != nil {
@@ -125,3 +151,27 @@ func UpdateASBBatchMessageWithBulkPublishRequest(asbMsgBatch *azservicebus.Messa
 
 	return nil
 }
+
+// compressMessage compresses the body of a message, storing the algorithm in its application properties.
+func compressMessage(asbMsg *azservicebus.Message, compression pubsub.Compression) error {
+	if compression == pubsub.CompressionNone {
+		return nil
+	}
+
+	body, err := compression.Compress(asbMsg.Body)
+	if err != nil {
+		return fmt.Errorf("failed to compress message: %w", err)
+	}
+	asbMsg.Body = body
+	if asbMsg.ApplicationProperties == nil {
+		asbMsg.ApplicationProperties = make(map[string]interface{}, 1)
+	}
+	asbMsg.ApplicationProperties[pubsub.ContentEncodingKey] = string(compression)
+	return nil
+}
+
+// decompressMessage returns the body of a received message, decompressing it if it has a content encoding in its application properties.
+func decompressMessage(asbMsg *azservicebus.ReceivedMessage) ([]byte, error) {
+	contentEncoding, _ := asbMsg.ApplicationProperties[pubsub.ContentEncodingKey].(string)
+	return pubsub.Decompress(contentEncoding, asbMsg.Body)
+}
@@ -19,6 +19,9 @@ import (
 
 	azservicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
 )
 
 func TestAddMessageAttributesToMetadata(t *testing.T) {
@@ -62,6 +65,24 @@ func TestAddMessageAttributesToMetadata(t *testing.T) {
 				"metadata." + MessageKeyLockedUntilUtc:          testSampleTimeHTTPFormat,
 			},
 		},
+		{
+			name: "Metadata must contain the trace context from the application properties",
+			ASBMessage: azservicebus.ReceivedMessage{
+				MessageID:     testMessageID,
+				DeliveryCount: testDeliveryCount,
+				ApplicationProperties: map[string]interface{}{
+					"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+					"tracestate":  "congo=t61rcWkgMzE",
+					"foo":         "bar",
+				},
+			},
+			expectedMetadata: map[string]string{
+				"metadata." + MessageKeyMessageID:     testMessageID,
+				"metadata." + MessageKeyDeliveryCount: "1",
+				"traceparent":                         "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+				"tracestate":                          "congo=t61rcWkgMzE",
+			},
+		},
 	}
 
 	metadataMap := map[string]map[string]string{
@@ -72,9 +93,56 @@ func TestAddMessageAttributesToMetadata(t *testing.T) {
 	for _, tc := range testCases {
 		for mType, mMap := range metadataMap {
 			t.Run(fmt.Sprintf("%s, metadata is %s", tc.name, mType), func(t *testing.T) {
+				// Don't share the empty map across test cases
+				if mMap != nil {
+					mMap = map[string]string{}
+				}
 				actual := addMessageAttributesToMetadata(mMap, &tc.ASBMessage)
 				assert.Equal(t, tc.expectedMetadata, actual)
 			})
 		}
 	}
 }
+
+func TestMessageCompression(t *testing.T) {
+	data := []byte(`{"orderId":"1","items":["a","b","c"]}`)
+
+	for _, compression := range []pubsub.Compression{pubsub.CompressionGzip, pubsub.CompressionZstd, pubsub.CompressionSnappy} {
+		t.Run(string(compression), func(t *testing.T) {
+			msg, err := NewASBMessageFromPubsubRequest(&pubsub.PublishRequest{Data: data})
+			require.NoError(t, err)
+			require.NoError(t, compressMessage(msg, compression))
+			assert.Equal(t, string(compression), msg.ApplicationProperties[pubsub.ContentEncodingKey])
+			assert.NotEqual(t, data, msg.Body)
+
+			received := &azservicebus.ReceivedMessage{
+				Body:                  msg.Body,
+				ApplicationProperties: msg.ApplicationProperties,
+			}
+			pubsubMsg, err := NewPubsubMessageFromASBMessage(received, "topic")
+			require.NoError(t, err)
+			assert.Equal(t, data, pubsubMsg.Data)
+
+			entry, err := NewBulkMessageEntryFromASBMessage(received)
+			require.NoError(t, err)
+			assert.Equal(t, data, entry.Event)
+		})
+	}
+
+	t.Run("no compression", func(t *testing.T) {
+		msg, err := NewASBMessageFromPubsubRequest(&pubsub.PublishRequest{Data: data})
+		require.NoError(t, err)
+		require.NoError(t, compressMessage(msg, pubsub.CompressionNone))
+		assert.Equal(t, data, msg.Body)
+		assert.NotContains(t, msg.ApplicationProperties, pubsub.ContentEncodingKey)
+	})
+
+	t.Run("unknown content encoding", func(t *testing.T) {
+		pubsubMsg, err := NewPubsubMessageFromASBMessage(&azservicebus.ReceivedMessage{
+			Body:                  data,
+			ApplicationProperties: map[string]any{pubsub.ContentEncodingKey: "utf-8"},
+		}, "topic")
+		require.NoError(t, err)
+		assert.Equal(t, data, pubsubMsg.Data)
+	})
+}
@@ -129,3 +129,62 @@ func TestAddMetadataToMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestAddMetadataToMessageScheduledDelivery(t *testing.T) {
+	t.Run("absolute time alias", func(t *testing.T) {
+		msg := &azservicebus.Message{}
+		err := addMetadataToMessage(msg, map[string]string{
+			MessageKeyScheduledEnqueueTimeUtcAlias: testSampleTime.Format(time.RFC3339),
+		})
+		require.NoError(t, err)
+		require.NotNil(t, msg.ScheduledEnqueueTime)
+		assert.Equal(t, testSampleTime.Unix(), msg.ScheduledEnqueueTime.Unix())
+	})
+
+	t.Run("relative delay", func(t *testing.T) {
+		msg := &azservicebus.Message{}
+		start := time.Now()
+		err := addMetadataToMessage(msg, map[string]string{
+			MessageKeyDelaySeconds: "30",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, msg.ScheduledEnqueueTime)
+		assert.WithinDuration(t, start.Add(30*time.Second), *msg.ScheduledEnqueueTime, 5*time.Second)
+		assert.Empty(t, msg.ApplicationProperties)
+	})
+
+	t.Run("invalid delay", func(t *testing.T) {
+		for _, v := range []string{"abc", "-1", "1.5"} {
+			err := addMetadataToMessage(&azservicebus.Message{}, map[string]string{
+				MessageKeyDelaySeconds: v,
+			})
+			assert.Error(t, err, v)
+		}
+	})
+
+	t.Run("delay and absolute time are exclusive", func(t *testing.T) {
+		err := addMetadataToMessage(&azservicebus.Message{}, map[string]string{
+			MessageKeyDelaySeconds:            "30",
+			MessageKeyScheduledEnqueueTimeUtc: testScheduledEnqueueTimeUtc,
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestAddMetadataToMessageSessionAliases(t *testing.T) {
+	msg := &azservicebus.Message{}
+	err := addMetadataToMessage(msg, map[string]string{
+		MessageKeySessionIDAlias:    testSessionID,
+		MessageKeyPartitionKeyAlias: testSessionID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &testSessionID, msg.SessionID)
+	assert.Equal(t, &testSessionID, msg.PartitionKey)
+	assert.Empty(t, msg.ApplicationProperties)
+
+	err = addMetadataToMessage(&azservicebus.Message{}, map[string]string{
+		MessageKeySessionIDAlias:    testSessionID,
+		MessageKeyPartitionKeyAlias: testPartitionKeyUnique,
+	})
+	assert.Error(t, err)
+}
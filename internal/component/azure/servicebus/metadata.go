@@ -21,6 +21,7 @@ import (
 	sbadmin "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus/admin"
 
 	mdutils "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/ptr"
 )
@@ -29,27 +30,41 @@ import (
 // Note: AzureAD-related keys are handled separately.
 type Metadata struct {
 	/** For bindings and pubsubs **/
-	ConnectionString                string `mapstructure:"connectionString"`
-	ConsumerID                      string `mapstructure:"consumerID"` // Only topics
-	TimeoutInSec                    int    `mapstructure:"timeoutInSec"`
-	HandlerTimeoutInSec             int    `mapstructure:"handlerTimeoutInSec"`
-	LockRenewalInSec                int    `mapstructure:"lockRenewalInSec"`
-	MaxActiveMessages               int    `mapstructure:"maxActiveMessages"`
-	MaxConnectionRecoveryInSec      int    `mapstructure:"maxConnectionRecoveryInSec"`
-	MinConnectionRecoveryInSec      int    `mapstructure:"minConnectionRecoveryInSec"`
-	DisableEntityManagement         bool   `mapstructure:"disableEntityManagement"`
-	MaxRetriableErrorsPerSec        int    `mapstructure:"maxRetriableErrorsPerSec"`
-	MaxDeliveryCount                *int32 `mapstructure:"maxDeliveryCount"`              // Only used during subscription creation - default is set by the server (10)
-	LockDurationInSec               *int   `mapstructure:"lockDurationInSec"`             // Only used during subscription creation - default is set by the server (60s)
-	DefaultMessageTimeToLiveInSec   *int   `mapstructure:"defaultMessageTimeToLiveInSec"` // Only used during subscription creation - default is set by the server (depends on the tier)
-	AutoDeleteOnIdleInSec           *int   `mapstructure:"autoDeleteOnIdleInSec"`         // Only used during subscription creation - default is set by the server (disabled)
-	MaxConcurrentHandlers           int    `mapstructure:"maxConcurrentHandlers"`
-	PublishMaxRetries               int    `mapstructure:"publishMaxRetries"`
-	PublishInitialRetryIntervalInMs int    `mapstructure:"publishInitialRetryIntervalInMs"`
-	NamespaceName                   string `mapstructure:"namespaceName"` // Only for Azure AD
+	ConnectionString                string        `mapstructure:"connectionString"`
+	ConsumerID                      string        `mapstructure:"consumerID"` // Only topics
+	TimeoutInSec                    int           `mapstructure:"timeoutInSec"`
+	HandlerTimeoutInSec             int           `mapstructure:"handlerTimeoutInSec"`
+	LockRenewalInSec                int           `mapstructure:"lockRenewalInSec"`
+	MaxActiveMessages               int           `mapstructure:"maxActiveMessages"`
+	MaxConnectionRecoveryInSec      int           `mapstructure:"maxConnectionRecoveryInSec"`
+	MinConnectionRecoveryInSec      int           `mapstructure:"minConnectionRecoveryInSec"`
+	DisableEntityManagement         bool          `mapstructure:"disableEntityManagement"`
+	MaxRetriableErrorsPerSec        int           `mapstructure:"maxRetriableErrorsPerSec"`
+	MaxDeliveryCount                *int32        `mapstructure:"maxDeliveryCount"`              // Only used during subscription creation - default is set by the server (10)
+	LockDurationInSec               *int          `mapstructure:"lockDurationInSec"`             // Only used during subscription creation - default is set by the server (60s)
+	DefaultMessageTimeToLiveInSec   *int          `mapstructure:"defaultMessageTimeToLiveInSec"` // Only used during subscription creation - default is set by the server (depends on the tier)
+	AutoDeleteOnIdleInSec           *int          `mapstructure:"autoDeleteOnIdleInSec"`         // Only used during subscription creation - default is set by the server (disabled)
+	MaxConcurrentHandlers           int           `mapstructure:"maxConcurrentHandlers"`
+	PublishMaxRetries               int           `mapstructure:"publishMaxRetries"`
+	PublishInitialRetryIntervalInMs int           `mapstructure:"publishInitialRetryIntervalInMs"`
+	NamespaceName                   string        `mapstructure:"namespaceName"` // Only for Azure AD
+	MaxSenders                      int           `mapstructure:"maxSenders"`
+	SenderIdleTimeout               time.Duration `mapstructure:"senderIdleTimeout"`
+	UseDevelopmentEmulator          bool          `mapstructure:"useDevelopmentEmulator"`
+	EmulatorHost                    string        `mapstructure:"emulatorHost"`
+
+	/** For pubsubs only **/
+	Compression pubsub.Compression `mapstructure:"compression" only:"pubsub"`
+	// If true, checks that messages published to entities that require sessions have a session ID
+	ValidatePublishSessions bool `mapstructure:"validatePublishSessions" only:"pubsub"`
+	// Topics or queues whose permissions are checked at Init
+	pubsub.PreflightProperties `mapstructure:",squash" only:"pubsub"`
 
 	/** For bindings only **/
-	QueueName string `mapstructure:"queueName" only:"bindings"` // Only queues
+	QueueName             string        `mapstructure:"queueName" only:"bindings"` // Only queues
+	RequireSessions       bool          `mapstructure:"requireSessions" only:"bindings"`
+	MaxConcurrentSessions int           `mapstructure:"maxConcurrentSessions" only:"bindings"`
+	SessionIdleTimeout    time.Duration `mapstructure:"sessionIdleTimeout" only:"bindings"`
 }
 
 // Keys.
@@ -72,7 +87,14 @@ const (
 	keyPublishMaxRetries               = "publishMaxRetries"
 	keyPublishInitialRetryIntervalInMs = "publishInitialRetryIntervalInMs" // Alias: "publishInitialRetryInternalInMs" (backwards compatibility due to typo)
 	keyNamespaceName                   = "namespaceName"
+	keyMaxSenders                      = "maxSenders"
+	keySenderIdleTimeout               = "senderIdleTimeout"
+	keyUseDevelopmentEmulator          = "useDevelopmentEmulator"
+	keyEmulatorHost                    = "emulatorHost"
 	keyQueueName                       = "queueName"
+	keyRequireSessions                 = "requireSessions"
+	keyMaxConcurrentSessions           = "maxConcurrentSessions"
+	keySessionIdleTimeout              = "sessionIdleTimeout"
 )
 
 // Defaults.
@@ -106,6 +128,12 @@ const (
 
 	defaultPublishMaxRetries               = 5
 	defaultPublishInitialRetryIntervalInMs = 500
+
+	// Max number of cached senders.
+	defaultMaxSenders = 0 // No limit.
+
+	// Senders that are not used for this long are closed.
+	defaultSenderIdleTimeout = 10 * time.Minute
 )
 
 // Modes for ParseMetadata.
@@ -129,6 +157,10 @@ func ParseMetadata(md map[string]string, logger logger.Logger, mode byte) (m *Me
 		MaxConcurrentHandlers:           defaultMaxConcurrentHandlersPubSub,
 		PublishMaxRetries:               defaultPublishMaxRetries,
 		PublishInitialRetryIntervalInMs: defaultPublishInitialRetryIntervalInMs,
+		MaxConcurrentSessions:           DefaultMaxConcurrentSessions,
+		SessionIdleTimeout:              DefaultSesssionIdleTimeoutInSec * time.Second,
+		MaxSenders:                      defaultMaxSenders,
+		SenderIdleTimeout:               defaultSenderIdleTimeout,
 	}
 
 	if (mode & MetadataModeBinding) != 0 {
@@ -155,6 +187,22 @@ func ParseMetadata(md map[string]string, logger logger.Logger, mode byte) (m *Me
 		return m, mdErr
 	}
 
+	// The development emulator can be enabled in the connection string too, like in the ones of the official emulator
+	if m.ConnectionString != "" {
+		var emulator bool
+		m.ConnectionString, emulator = parseEmulatorConnectionString(m.ConnectionString)
+		m.UseDevelopmentEmulator = m.UseDevelopmentEmulator || emulator
+	} else if m.UseDevelopmentEmulator && m.NamespaceName == "" {
+		m.ConnectionString = emulatorConnectionString(m.EmulatorHost)
+	}
+	if m.UseDevelopmentEmulator {
+		if m.NamespaceName != "" {
+			return m, errors.New("namespaceName cannot be used with the development emulator")
+		}
+		// The emulator doesn't support the management APIs: entities are defined in its configuration file
+		m.DisableEntityManagement = true
+	}
+
 	/* Required configuration settings - no defaults. */
 	if m.ConnectionString != "" {
 		// The connection string and the namespace cannot both be present.
@@ -177,6 +225,15 @@ func ParseMetadata(md map[string]string, logger logger.Logger, mode byte) (m *Me
 		}
 	}
 
+	if m.RequireSessions {
+		if m.MaxConcurrentSessions < 1 {
+			return m, errors.New("maxConcurrentSessions must be 1 or greater")
+		}
+		if m.SessionIdleTimeout < 0 {
+			return m, errors.New("sessionIdleTimeout must not be negative")
+		}
+	}
+
 	if m.MaxActiveMessages < 1 {
 		err = errors.New("must be 1 or greater")
 		return m, err
@@ -187,6 +244,19 @@ func ParseMetadata(md map[string]string, logger logger.Logger, mode byte) (m *Me
 		return m, err
 	}
 
+	m.Compression, err = pubsub.ParseCompression(md)
+	if err != nil {
+		return m, err
+	}
+
+	if m.MaxSenders < 0 {
+		return m, errors.New("maxSenders must not be negative")
+	}
+
+	if m.SenderIdleTimeout < 0 {
+		return m, errors.New("senderIdleTimeout must not be negative")
+	}
+
 	/* Nullable configuration settings - defaults will be set by the server. */
 
 	if m.DefaultMessageTimeToLiveInSec == nil {
@@ -206,11 +276,22 @@ func ParseMetadata(md map[string]string, logger logger.Logger, mode byte) (m *Me
 	return m, nil
 }
 
+// HandlerSettings returns the settings for processing the messages of a subscription, from the metadata of the subscribe request.
+// The component's maxConcurrentHandlers and handlerTimeoutInSec are used as defaults.
+func (a Metadata) HandlerSettings(reqMetadata map[string]string) (pubsub.HandlerSettings, error) {
+	return pubsub.HandlerSettingsFromMetadata(reqMetadata, pubsub.HandlerSettings{
+		MaxConcurrentHandlers: a.MaxConcurrentHandlers,
+		HandlerTimeout:        time.Duration(a.HandlerTimeoutInSec) * time.Second,
+	})
+}
+
 // CreateSubscriptionProperties returns the SubscriptionProperties object to create new Subscriptions to Service Bus topics.
 func (a Metadata) CreateSubscriptionProperties(opts SubscribeOptions) *sbadmin.SubscriptionProperties {
 	properties := &sbadmin.SubscriptionProperties{}
 
-	if a.MaxDeliveryCount != nil {
+	if opts.MaxDeliveryCount != nil {
+		properties.MaxDeliveryCount = opts.MaxDeliveryCount
+	} else if a.MaxDeliveryCount != nil {
 		properties.MaxDeliveryCount = a.MaxDeliveryCount
 	}
 
@@ -230,17 +311,31 @@ func (a Metadata) CreateSubscriptionProperties(opts SubscribeOptions) *sbadmin.S
 		properties.RequiresSession = ptr.Of(true)
 	}
 
+	if opts.ForwardDeadLetteredMessagesTo != "" {
+		properties.ForwardDeadLetteredMessagesTo = ptr.Of(opts.ForwardDeadLetteredMessagesTo)
+	}
+
 	return properties
 }
 
 // CreateQueueProperties returns the QueueProperties object to create new Queues in Service Bus.
-func (a Metadata) CreateQueueProperties() *sbadmin.QueueProperties {
+func (a Metadata) CreateQueueProperties(opts SubscribeOptions) *sbadmin.QueueProperties {
 	properties := &sbadmin.QueueProperties{}
 
-	if a.MaxDeliveryCount != nil {
+	if opts.MaxDeliveryCount != nil {
+		properties.MaxDeliveryCount = opts.MaxDeliveryCount
+	} else if a.MaxDeliveryCount != nil {
 		properties.MaxDeliveryCount = a.MaxDeliveryCount
 	}
 
+	if opts.ForwardDeadLetteredMessagesTo != "" {
+		properties.ForwardDeadLetteredMessagesTo = ptr.Of(opts.ForwardDeadLetteredMessagesTo)
+	}
+
+	if opts.RequireSessions {
+		properties.RequiresSession = ptr.Of(true)
+	}
+
 	if a.LockDurationInSec != nil {
 		properties.LockDuration = toDurationISOString(*a.LockDurationInSec)
 	}
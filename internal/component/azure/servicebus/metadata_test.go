@@ -15,9 +15,12 @@ package servicebus
 
 import (
 	"testing"
+	"time"
 
 	azservicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/dapr/components-contrib/pubsub"
 )
 
 const invalidNumber = "invalid_number"
@@ -152,6 +155,40 @@ func TestParseServiceBusMetadata(t *testing.T) {
 		assert.Equal(t, 1, m.MaxConcurrentHandlers)
 	})
 
+	t.Run("sessions for binding queues", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+
+		// act.
+		m, err := ParseMetadata(fakeProperties, nil, MetadataModeBinding)
+
+		// assert.
+		assert.NoError(t, err)
+		assert.False(t, m.RequireSessions)
+		assert.Equal(t, DefaultMaxConcurrentSessions, m.MaxConcurrentSessions)
+		assert.Equal(t, DefaultSesssionIdleTimeoutInSec*time.Second, m.SessionIdleTimeout)
+
+		fakeProperties[keyRequireSessions] = "true"
+		fakeProperties[keyMaxConcurrentSessions] = "4"
+		fakeProperties[keySessionIdleTimeout] = "30"
+
+		// act.
+		m, err = ParseMetadata(fakeProperties, nil, MetadataModeBinding)
+
+		// assert.
+		assert.NoError(t, err)
+		assert.True(t, m.RequireSessions)
+		assert.Equal(t, 4, m.MaxConcurrentSessions)
+		assert.Equal(t, 30*time.Second, m.SessionIdleTimeout)
+
+		fakeProperties[keyMaxConcurrentSessions] = "0"
+
+		// act.
+		_, err = ParseMetadata(fakeProperties, nil, MetadataModeBinding)
+
+		// assert.
+		assert.Error(t, err)
+	})
+
 	t.Run("missing required connectionString or namespaceName", func(t *testing.T) {
 		fakeProperties := getFakeProperties()
 		fakeProperties[keyConnectionString] = ""
@@ -507,6 +544,157 @@ func TestParseServiceBusMetadata(t *testing.T) {
 		assert.Nil(t, err)
 	})
 
+	t.Run("missing optional sender settings", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+
+		// act.
+		m, err := ParseMetadata(fakeProperties, nil, 0)
+
+		// assert.
+		assert.Equal(t, defaultMaxSenders, m.MaxSenders)
+		assert.Equal(t, defaultSenderIdleTimeout, m.SenderIdleTimeout)
+		assert.Nil(t, err)
+	})
+
+	t.Run("valid optional sender settings", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeProperties[keyMaxSenders] = "50"
+		fakeProperties[keySenderIdleTimeout] = "2m"
+
+		// act.
+		m, err := ParseMetadata(fakeProperties, nil, 0)
+
+		// assert.
+		assert.Equal(t, 50, m.MaxSenders)
+		assert.Equal(t, 2*time.Minute, m.SenderIdleTimeout)
+		assert.Nil(t, err)
+	})
+
+	t.Run("invalid optional sender settings", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeProperties[keyMaxSenders] = "-1"
+
+		// act.
+		_, err := ParseMetadata(fakeProperties, nil, 0)
+
+		// assert.
+		assert.Error(t, err)
+
+		fakeProperties = getFakeProperties()
+		fakeProperties[keySenderIdleTimeout] = "-1s"
+
+		// act.
+		_, err = ParseMetadata(fakeProperties, nil, 0)
+
+		// assert.
+		assert.Error(t, err)
+	})
+
+	t.Run("development emulator in the connection string", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeProperties[keyDisableEntityManagement] = "false"
+		fakeProperties[keyConnectionString] = "Endpoint=sb://localhost;SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=SAS_KEY_VALUE;UseDevelopmentEmulator=true;"
+
+		// act.
+		m, err := ParseMetadata(fakeProperties, nil, 0)
+
+		// assert.
+		assert.NoError(t, err)
+		assert.True(t, m.UseDevelopmentEmulator)
+		assert.True(t, m.DisableEntityManagement)
+		assert.Equal(t, "Endpoint=sb://localhost;SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=SAS_KEY_VALUE", m.ConnectionString)
+	})
+
+	t.Run("development emulator without a connection string", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeProperties[keyConnectionString] = ""
+		fakeProperties[keyUseDevelopmentEmulator] = "true"
+
+		// act.
+		m, err := ParseMetadata(fakeProperties, nil, 0)
+
+		// assert.
+		assert.NoError(t, err)
+		assert.True(t, m.DisableEntityManagement)
+		assert.Equal(t, "Endpoint=sb://localhost;SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=SAS_KEY_VALUE", m.ConnectionString)
+
+		fakeProperties[keyEmulatorHost] = "emulator:5673"
+
+		// act.
+		m, err = ParseMetadata(fakeProperties, nil, 0)
+
+		// assert.
+		assert.NoError(t, err)
+		assert.Equal(t, "Endpoint=sb://emulator:5673;SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=SAS_KEY_VALUE", m.ConnectionString)
+
+		fakeProperties[keyNamespaceName] = "fakeNamespace"
+
+		// act.
+		_, err = ParseMetadata(fakeProperties, nil, 0)
+
+		// assert.
+		assert.Error(t, err)
+	})
+
+	t.Run("compression", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+
+		// act.
+		m, err := ParseMetadata(fakeProperties, nil, 0)
+
+		// assert.
+		assert.NoError(t, err)
+		assert.Equal(t, pubsub.CompressionNone, m.Compression)
+
+		fakeProperties[pubsub.CompressionKey] = "Zstd"
+
+		// act.
+		m, err = ParseMetadata(fakeProperties, nil, 0)
+
+		// assert.
+		assert.NoError(t, err)
+		assert.Equal(t, pubsub.CompressionZstd, m.Compression)
+
+		fakeProperties[pubsub.CompressionKey] = "lz4"
+
+		// act.
+		_, err = ParseMetadata(fakeProperties, nil, 0)
+
+		// assert.
+		assert.Error(t, err)
+	})
+
+	t.Run("handler settings", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		m, err := ParseMetadata(fakeProperties, nil, 0)
+		assert.NoError(t, err)
+
+		// act.
+		settings, err := m.HandlerSettings(map[string]string{})
+
+		// assert.
+		assert.NoError(t, err)
+		assert.Equal(t, 1, settings.MaxConcurrentHandlers)
+		assert.Equal(t, 30*time.Second, settings.HandlerTimeout)
+
+		// act.
+		settings, err = m.HandlerSettings(map[string]string{
+			pubsub.MaxConcurrentHandlersKey: "8",
+			pubsub.HandlerTimeoutKey:        "2m",
+		})
+
+		// assert.
+		assert.NoError(t, err)
+		assert.Equal(t, 8, settings.MaxConcurrentHandlers)
+		assert.Equal(t, 2*time.Minute, settings.HandlerTimeout)
+
+		// act.
+		_, err = m.HandlerSettings(map[string]string{pubsub.MaxConcurrentHandlersKey: "-2"})
+
+		// assert.
+		assert.Error(t, err)
+	})
+
 	t.Run("Test add system metadata: ScheduledEnqueueTimeUtc", func(t *testing.T) {
 		msg := azservicebus.Message{}
 		metadata := map[string]string{
@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	servicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+// Messages scheduled by the preflight check are canceled right away, so they are never delivered.
+const preflightScheduleDelay = 24 * time.Hour
+
+// PreflightTopics verifies that the identity of the component has the permissions to publish and subscribe to the topics in the preflight metadata properties.
+// Permissions to subscribe are checked on the subscription of the consumer ID.
+func (c *Client) PreflightTopics(ctx context.Context) error {
+	return c.metadata.PreflightCheck(func(topic string) error {
+		return c.checkSend(ctx, topic, func(ctx context.Context) (bool, error) {
+			return c.shouldCreateTopic(ctx, topic)
+		})
+	}, func(topic string) error {
+		entity := topic + "/subscriptions/" + c.metadata.ConsumerID
+		receiver, err := c.client.NewReceiverForSubscription(topic, c.metadata.ConsumerID, nil)
+		if err != nil {
+			return fmt.Errorf("preflight check of %s failed: %w", entity, err)
+		}
+		return c.checkReceive(ctx, entity, receiver, func(ctx context.Context) (bool, error) {
+			res, err := c.adminClient.GetSubscription(ctx, topic, c.metadata.ConsumerID, nil)
+			return res == nil, err
+		})
+	})
+}
+
+// PreflightQueues verifies that the identity of the component has the permissions to send to and receive from the queues in the preflight metadata properties.
+func (c *Client) PreflightQueues(ctx context.Context) error {
+	return c.metadata.PreflightCheck(func(queue string) error {
+		return c.checkSend(ctx, queue, func(ctx context.Context) (bool, error) {
+			return c.shouldCreateQueue(ctx, queue)
+		})
+	}, func(queue string) error {
+		receiver, err := c.client.NewReceiverForQueue(queue, nil)
+		if err != nil {
+			return fmt.Errorf("preflight check of %s failed: %w", queue, err)
+		}
+		return c.checkReceive(ctx, queue, receiver, func(ctx context.Context) (bool, error) {
+			return c.shouldCreateQueue(ctx, queue)
+		})
+	})
+}
+
+// checkSend verifies the Send right on a queue or topic by scheduling a message and canceling it.
+// If entity management is enabled and the entity doesn't exist yet, nothing is checked: the entity is created when it's first used.
+func (c *Client) checkSend(parentCtx context.Context, queueOrTopic string, shouldCreate func(ctx context.Context) (bool, error)) error {
+	if c.skipPreflight(parentCtx, queueOrTopic, shouldCreate) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, time.Second*time.Duration(c.metadata.TimeoutInSec))
+	defer cancel()
+
+	sender, err := c.client.NewSender(queueOrTopic, nil)
+	if err != nil {
+		return fmt.Errorf("preflight check of %s failed: %w", queueOrTopic, err)
+	}
+	defer sender.Close(ctx)
+
+	seqs, err := sender.ScheduleMessages(ctx, []*servicebus.Message{{}}, time.Now().Add(preflightScheduleDelay), nil)
+	if err != nil {
+		return preflightError(queueOrTopic, "Send", err)
+	}
+	err = sender.CancelScheduledMessages(ctx, seqs, nil)
+	if err != nil {
+		return fmt.Errorf("preflight check of %s failed: could not cancel the scheduled message with sequence number %v, which will be delivered in %v: %w", queueOrTopic, seqs, preflightScheduleDelay, err)
+	}
+	return nil
+}
+
+// checkReceive verifies the Listen right on a queue or subscription by peeking a message, which doesn't lock it.
+func (c *Client) checkReceive(parentCtx context.Context, entity string, receiver *servicebus.Receiver, shouldCreate func(ctx context.Context) (bool, error)) error {
+	ctx, cancel := context.WithTimeout(parentCtx, time.Second*time.Duration(c.metadata.TimeoutInSec))
+	defer cancel()
+	defer receiver.Close(ctx)
+
+	if c.skipPreflight(parentCtx, entity, shouldCreate) {
+		return nil
+	}
+
+	_, err := receiver.PeekMessages(ctx, 1, nil)
+	if err != nil {
+		return preflightError(entity, "Listen", err)
+	}
+	return nil
+}
+
+// skipPreflight returns true if entity management is enabled and the entity doesn't exist.
+func (c *Client) skipPreflight(ctx context.Context, entity string, shouldCreate func(ctx context.Context) (bool, error)) bool {
+	if c.adminClient == nil {
+		return false
+	}
+	create, err := shouldCreate(ctx)
+	if err != nil {
+		// The check of the entity itself returns a more specific error
+		c.logger.Debugf("Could not check if %s exists: %v", entity, err)
+		return false
+	}
+	if create {
+		c.logger.Debugf("Skipping the preflight check of %s as it doesn't exist yet", entity)
+	}
+	return create
+}
+
+func preflightError(entity string, right string, err error) error {
+	var sbErr *servicebus.Error
+	if errors.As(err, &sbErr) && sbErr.Code == servicebus.CodeUnauthorizedAccess {
+		return &pubsub.PermissionError{
+			Entity:      entity,
+			Permissions: []string{right},
+		}
+	}
+	return fmt.Errorf("preflight check of %s failed: %w", entity, err)
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebus
+
+import (
+	"errors"
+	"testing"
+
+	servicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+func TestPreflightError(t *testing.T) {
+	err := preflightError("orders", "Send", &servicebus.Error{Code: servicebus.CodeUnauthorizedAccess})
+	var permErr *pubsub.PermissionError
+	require.True(t, errors.As(err, &permErr))
+	assert.Equal(t, "orders", permErr.Entity)
+	assert.Equal(t, []string{"Send"}, permErr.Permissions)
+
+	err = preflightError("orders", "Send", &servicebus.Error{Code: servicebus.CodeTimeout})
+	require.False(t, errors.As(err, &permErr))
+	require.ErrorContains(t, err, "preflight check of orders failed")
+}
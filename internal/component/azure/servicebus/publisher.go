@@ -40,6 +40,10 @@ func (c *Client) PublishPubSub(ctx context.Context, req *pubsub.PublishRequest,
 	if err != nil {
 		return err
 	}
+	err = compressMessage(msg, c.metadata.Compression)
+	if err != nil {
+		return err
+	}
 
 	bo := c.publishBackOff(ctx)
 
@@ -116,7 +120,7 @@ func (c *Client) PublishPubSubBulk(ctx context.Context, req *pubsub.BulkPublishR
 	}
 
 	// Add messages from the bulk publish request to the batch.
-	err = UpdateASBBatchMessageWithBulkPublishRequest(batchMsg, req)
+	err = UpdateASBBatchMessageWithBulkPublishRequest(batchMsg, req, c.metadata.Compression)
 	if err != nil {
 		return pubsub.NewBulkPublishResponse(req.Entries, err), err
 	}
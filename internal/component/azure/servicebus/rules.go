@@ -0,0 +1,230 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebus
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	sbadmin "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus/admin"
+
+	"github.com/dapr/kit/ptr"
+)
+
+const (
+	// SQLFilterMetadataKey is the subscribe metadata key for a SQL filter expression applied to the topic subscription.
+	SQLFilterMetadataKey = "sqlFilter"
+	// CorrelationFilterMetadataPrefix is the prefix of subscribe metadata keys that define a correlation filter.
+	// For example: "correlationFilter.label", "correlationFilter.correlationId" or "correlationFilter.properties.<name>".
+	CorrelationFilterMetadataPrefix = "correlationFilter."
+
+	// DefaultRuleName is the name of the rule that Service Bus creates with every subscription, which matches all messages.
+	DefaultRuleName = "$Default"
+	// SQLFilterRuleName is the name of the rule created for the SQL filter.
+	SQLFilterRuleName = "dapr-sql-filter"
+	// CorrelationFilterRuleName is the name of the rule created for the correlation filter.
+	CorrelationFilterRuleName = "dapr-correlation-filter"
+
+	// Prefix of the names of the rules managed by Dapr.
+	managedRulePrefix      = "dapr-"
+	correlationPropsPrefix = "properties."
+)
+
+// ParseSubscriptionRules parses the filter rules to apply to a topic subscription from the metadata of a subscribe request.
+// When both a SQL filter and a correlation filter are set, messages matching either of them are delivered.
+func ParseSubscriptionRules(md map[string]string) ([]sbadmin.RuleProperties, error) {
+	rules := []sbadmin.RuleProperties{}
+
+	if expr := strings.TrimSpace(md[SQLFilterMetadataKey]); expr != "" {
+		rules = append(rules, sbadmin.RuleProperties{
+			Name:   SQLFilterRuleName,
+			Filter: &sbadmin.SQLFilter{Expression: expr},
+		})
+	}
+
+	var filter *sbadmin.CorrelationFilter
+	for k, v := range md {
+		if !strings.HasPrefix(k, CorrelationFilterMetadataPrefix) || v == "" {
+			continue
+		}
+		if filter == nil {
+			filter = &sbadmin.CorrelationFilter{}
+		}
+
+		field := k[len(CorrelationFilterMetadataPrefix):]
+		if strings.HasPrefix(field, correlationPropsPrefix) {
+			name := field[len(correlationPropsPrefix):]
+			if name == "" {
+				return nil, fmt.Errorf("invalid correlation filter key %s", k)
+			}
+			if filter.ApplicationProperties == nil {
+				filter.ApplicationProperties = map[string]any{}
+			}
+			filter.ApplicationProperties[name] = v
+			continue
+		}
+
+		switch strings.ToLower(field) {
+		case "label", "subject":
+			filter.Subject = ptr.Of(v)
+		case "correlationid":
+			filter.CorrelationID = ptr.Of(v)
+		case "messageid":
+			filter.MessageID = ptr.Of(v)
+		case "to":
+			filter.To = ptr.Of(v)
+		case "replyto":
+			filter.ReplyTo = ptr.Of(v)
+		case "replytosessionid":
+			filter.ReplyToSessionID = ptr.Of(v)
+		case "sessionid":
+			filter.SessionID = ptr.Of(v)
+		case "contenttype":
+			filter.ContentType = ptr.Of(v)
+		default:
+			return nil, fmt.Errorf("invalid correlation filter key %s", k)
+		}
+	}
+	if filter != nil {
+		rules = append(rules, sbadmin.RuleProperties{
+			Name:   CorrelationFilterRuleName,
+			Filter: filter,
+		})
+	}
+
+	return rules, nil
+}
+
+// ruleChanges contains the operations needed to reconcile the rules of a subscription.
+type ruleChanges struct {
+	create []sbadmin.RuleProperties
+	update []sbadmin.RuleProperties
+	delete []string
+}
+
+// planRuleChanges computes the changes that turn the existing rules into the desired ones.
+// The default rule and the rules managed by Dapr that are not desired are deleted; other rules are left untouched.
+func planRuleChanges(existing []sbadmin.RuleProperties, desired []sbadmin.RuleProperties) ruleChanges {
+	changes := ruleChanges{}
+
+	existingByName := make(map[string]sbadmin.RuleProperties, len(existing))
+	for _, r := range existing {
+		existingByName[r.Name] = r
+	}
+
+	desiredNames := make(map[string]struct{}, len(desired))
+	for _, r := range desired {
+		desiredNames[r.Name] = struct{}{}
+		cur, ok := existingByName[r.Name]
+		switch {
+		case !ok:
+			changes.create = append(changes.create, r)
+		case !ruleEqual(cur, r):
+			changes.update = append(changes.update, r)
+		}
+	}
+
+	for _, r := range existing {
+		if _, ok := desiredNames[r.Name]; ok {
+			continue
+		}
+		if r.Name == DefaultRuleName || strings.HasPrefix(r.Name, managedRulePrefix) {
+			changes.delete = append(changes.delete, r.Name)
+		}
+	}
+
+	return changes
+}
+
+func ruleEqual(a, b sbadmin.RuleProperties) bool {
+	if sa, ok := a.Filter.(*sbadmin.SQLFilter); ok {
+		sb, ok := b.Filter.(*sbadmin.SQLFilter)
+		return ok && sa.Expression == sb.Expression && len(sa.Parameters) == 0 && len(sb.Parameters) == 0 && a.Action == nil && b.Action == nil
+	}
+	if ca, ok := a.Filter.(*sbadmin.CorrelationFilter); ok {
+		cb, ok := b.Filter.(*sbadmin.CorrelationFilter)
+		if !ok || a.Action != nil || b.Action != nil {
+			return false
+		}
+		// Treat nil and empty application properties as equal
+		ca, cb = ptr.Of(*ca), ptr.Of(*cb)
+		if len(ca.ApplicationProperties) == 0 {
+			ca.ApplicationProperties = nil
+		}
+		if len(cb.ApplicationProperties) == 0 {
+			cb.ApplicationProperties = nil
+		}
+		return reflect.DeepEqual(ca, cb)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// EnsureSubscriptionRules reconciles the filter rules of a topic subscription with the desired ones.
+// If no rules are desired, the rules managed by Dapr are removed and the default rule, which matches all messages, is restored.
+// Returns with nil error if the admin client doesn't exist.
+func (c *Client) EnsureSubscriptionRules(parentCtx context.Context, topic, subscription string, rules []sbadmin.RuleProperties) error {
+	if c.adminClient == nil {
+		return nil
+	}
+
+	if len(rules) == 0 {
+		rules = []sbadmin.RuleProperties{{
+			Name:   DefaultRuleName,
+			Filter: &sbadmin.TrueFilter{},
+		}}
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, time.Second*time.Duration(c.metadata.TimeoutInSec))
+	defer cancel()
+
+	existing := []sbadmin.RuleProperties{}
+	pager := c.adminClient.NewListRulesPager(topic, subscription, nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("could not list rules of subscription %s: %w", subscription, err)
+		}
+		existing = append(existing, page.Rules...)
+	}
+
+	// Create and update rules before deleting the others, so the subscription is never left without rules
+	changes := planRuleChanges(existing, rules)
+	for _, r := range changes.create {
+		_, err := c.adminClient.CreateRule(ctx, topic, subscription, &sbadmin.CreateRuleOptions{
+			Name:   ptr.Of(r.Name),
+			Filter: r.Filter,
+			Action: r.Action,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create rule %s on subscription %s: %w", r.Name, subscription, err)
+		}
+	}
+	for _, r := range changes.update {
+		_, err := c.adminClient.UpdateRule(ctx, topic, subscription, r)
+		if err != nil {
+			return fmt.Errorf("could not update rule %s on subscription %s: %w", r.Name, subscription, err)
+		}
+	}
+	for _, name := range changes.delete {
+		_, err := c.adminClient.DeleteRule(ctx, topic, subscription, name, nil)
+		if err != nil {
+			return fmt.Errorf("could not delete rule %s on subscription %s: %w", name, subscription, err)
+		}
+	}
+
+	return nil
+}
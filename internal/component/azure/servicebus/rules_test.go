@@ -0,0 +1,139 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebus
+
+import (
+	"testing"
+
+	sbadmin "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus/admin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/ptr"
+)
+
+func TestParseSubscriptionRules(t *testing.T) {
+	t.Run("no filters", func(t *testing.T) {
+		rules, err := ParseSubscriptionRules(map[string]string{"other": "value"})
+		require.NoError(t, err)
+		assert.Empty(t, rules)
+	})
+
+	t.Run("sql filter", func(t *testing.T) {
+		rules, err := ParseSubscriptionRules(map[string]string{SQLFilterMetadataKey: " color = 'red' "})
+		require.NoError(t, err)
+		require.Len(t, rules, 1)
+		assert.Equal(t, SQLFilterRuleName, rules[0].Name)
+		assert.Equal(t, &sbadmin.SQLFilter{Expression: "color = 'red'"}, rules[0].Filter)
+	})
+
+	t.Run("correlation filter", func(t *testing.T) {
+		rules, err := ParseSubscriptionRules(map[string]string{
+			"correlationFilter.label":            "orders",
+			"correlationFilter.correlationId":    "abc",
+			"correlationFilter.properties.color": "red",
+		})
+		require.NoError(t, err)
+		require.Len(t, rules, 1)
+		assert.Equal(t, CorrelationFilterRuleName, rules[0].Name)
+		assert.Equal(t, &sbadmin.CorrelationFilter{
+			Subject:               ptr.Of("orders"),
+			CorrelationID:         ptr.Of("abc"),
+			ApplicationProperties: map[string]any{"color": "red"},
+		}, rules[0].Filter)
+	})
+
+	t.Run("invalid correlation filter key", func(t *testing.T) {
+		_, err := ParseSubscriptionRules(map[string]string{"correlationFilter.foo": "bar"})
+		assert.Error(t, err)
+		_, err = ParseSubscriptionRules(map[string]string{"correlationFilter.properties.": "bar"})
+		assert.Error(t, err)
+	})
+}
+
+func TestPlanRuleChanges(t *testing.T) {
+	sqlRule := sbadmin.RuleProperties{
+		Name:   SQLFilterRuleName,
+		Filter: &sbadmin.SQLFilter{Expression: "color = 'red'"},
+	}
+	defaultRule := sbadmin.RuleProperties{
+		Name:   DefaultRuleName,
+		Filter: &sbadmin.TrueFilter{},
+	}
+	userRule := sbadmin.RuleProperties{
+		Name:   "custom",
+		Filter: &sbadmin.SQLFilter{Expression: "1=1"},
+	}
+
+	t.Run("new subscription", func(t *testing.T) {
+		changes := planRuleChanges([]sbadmin.RuleProperties{defaultRule}, []sbadmin.RuleProperties{sqlRule})
+		assert.Equal(t, []sbadmin.RuleProperties{sqlRule}, changes.create)
+		assert.Empty(t, changes.update)
+		assert.Equal(t, []string{DefaultRuleName}, changes.delete)
+	})
+
+	t.Run("already reconciled", func(t *testing.T) {
+		existing := sbadmin.RuleProperties{
+			Name:   SQLFilterRuleName,
+			Filter: &sbadmin.SQLFilter{Expression: "color = 'red'", Parameters: map[string]any{}},
+		}
+		changes := planRuleChanges([]sbadmin.RuleProperties{existing, userRule}, []sbadmin.RuleProperties{sqlRule})
+		assert.Empty(t, changes.create)
+		assert.Empty(t, changes.update)
+		assert.Empty(t, changes.delete)
+	})
+
+	t.Run("changed filters", func(t *testing.T) {
+		correlationRule := sbadmin.RuleProperties{
+			Name:   CorrelationFilterRuleName,
+			Filter: &sbadmin.CorrelationFilter{Subject: ptr.Of("orders")},
+		}
+		existing := sbadmin.RuleProperties{
+			Name:   SQLFilterRuleName,
+			Filter: &sbadmin.SQLFilter{Expression: "color = 'blue'"},
+		}
+		changes := planRuleChanges(
+			[]sbadmin.RuleProperties{existing, {Name: CorrelationFilterRuleName, Filter: &sbadmin.CorrelationFilter{Subject: ptr.Of("old")}}},
+			[]sbadmin.RuleProperties{sqlRule, correlationRule},
+		)
+		assert.Empty(t, changes.create)
+		assert.Equal(t, []sbadmin.RuleProperties{sqlRule, correlationRule}, changes.update)
+		assert.Empty(t, changes.delete)
+	})
+
+	t.Run("removed managed filter", func(t *testing.T) {
+		correlationRule := sbadmin.RuleProperties{
+			Name:   CorrelationFilterRuleName,
+			Filter: &sbadmin.CorrelationFilter{Subject: ptr.Of("orders")},
+		}
+		changes := planRuleChanges([]sbadmin.RuleProperties{sqlRule, correlationRule}, []sbadmin.RuleProperties{sqlRule})
+		assert.Empty(t, changes.create)
+		assert.Empty(t, changes.update)
+		assert.Equal(t, []string{CorrelationFilterRuleName}, changes.delete)
+	})
+
+	t.Run("all filters removed", func(t *testing.T) {
+		changes := planRuleChanges([]sbadmin.RuleProperties{sqlRule, userRule}, []sbadmin.RuleProperties{defaultRule})
+		assert.Equal(t, []sbadmin.RuleProperties{defaultRule}, changes.create)
+		assert.Empty(t, changes.update)
+		assert.Equal(t, []string{SQLFilterRuleName}, changes.delete)
+	})
+
+	t.Run("default rule already present", func(t *testing.T) {
+		changes := planRuleChanges([]sbadmin.RuleProperties{defaultRule, userRule}, []sbadmin.RuleProperties{defaultRule})
+		assert.Empty(t, changes.create)
+		assert.Empty(t, changes.update)
+		assert.Empty(t, changes.delete)
+	})
+}
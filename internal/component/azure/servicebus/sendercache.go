@@ -0,0 +1,126 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebus
+
+import (
+	"container/list"
+	"time"
+
+	servicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+)
+
+// senderCache is a cache of senders, keyed by the name of the queue or topic, that keeps track of when each sender was last used.
+// When the cache holds more than maxSize senders, the least-recently-used ones are evicted; a maxSize of 0 means no limit.
+// senderCache is not safe for concurrent use: callers must synchronize access.
+type senderCache struct {
+	maxSize int
+	items   map[string]*list.Element
+	// List of *senderCacheEntry, from the most-recently-used to the least-recently-used.
+	order *list.List
+}
+
+// senderCacheEntry is an entry in senderCache.
+type senderCacheEntry struct {
+	name     string
+	sender   *servicebus.Sender
+	lastUsed time.Time
+}
+
+func newSenderCache(maxSize int) *senderCache {
+	return &senderCache{
+		maxSize: maxSize,
+		items:   make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Len returns the number of senders in the cache.
+func (sc *senderCache) Len() int {
+	return len(sc.items)
+}
+
+// Get returns the sender for name and marks it as used at time now.
+// It returns nil if there's no sender in the cache.
+func (sc *senderCache) Get(name string, now time.Time) *servicebus.Sender {
+	el, ok := sc.items[name]
+	if !ok {
+		return nil
+	}
+	entry := el.Value.(*senderCacheEntry)
+	entry.lastUsed = now
+	sc.order.MoveToFront(el)
+	return entry.sender
+}
+
+// Add adds the sender for name to the cache, replacing any existing one, and marks it as used at time now.
+// It returns the entries that were removed from the cache, which must be closed by the caller.
+func (sc *senderCache) Add(name string, sender *servicebus.Sender, now time.Time) (removed []*senderCacheEntry) {
+	if el, ok := sc.items[name]; ok {
+		entry := el.Value.(*senderCacheEntry)
+		if entry.sender != sender {
+			removed = append(removed, &senderCacheEntry{name: name, sender: entry.sender, lastUsed: entry.lastUsed})
+		}
+		entry.sender = sender
+		entry.lastUsed = now
+		sc.order.MoveToFront(el)
+		return removed
+	}
+
+	sc.items[name] = sc.order.PushFront(&senderCacheEntry{
+		name:     name,
+		sender:   sender,
+		lastUsed: now,
+	})
+
+	for sc.maxSize > 0 && len(sc.items) > sc.maxSize {
+		removed = append(removed, sc.removeElement(sc.order.Back()))
+	}
+	return removed
+}
+
+// Remove removes the sender for name from the cache and returns it, or returns nil if there's no sender in the cache.
+func (sc *senderCache) Remove(name string) *servicebus.Sender {
+	el, ok := sc.items[name]
+	if !ok {
+		return nil
+	}
+	return sc.removeElement(el).sender
+}
+
+// RemoveIdle removes all senders that were last used before the deadline and returns them.
+func (sc *senderCache) RemoveIdle(deadline time.Time) (removed []*senderCacheEntry) {
+	// The list is sorted by last use, so we can stop at the first sender that isn't idle
+	for el := sc.order.Back(); el != nil; el = sc.order.Back() {
+		if !el.Value.(*senderCacheEntry).lastUsed.Before(deadline) {
+			break
+		}
+		removed = append(removed, sc.removeElement(el))
+	}
+	return removed
+}
+
+// RemoveAll removes all senders from the cache and returns them.
+func (sc *senderCache) RemoveAll() (removed []*senderCacheEntry) {
+	removed = make([]*senderCacheEntry, 0, len(sc.items))
+	for el := sc.order.Back(); el != nil; el = sc.order.Back() {
+		removed = append(removed, sc.removeElement(el))
+	}
+	return removed
+}
+
+func (sc *senderCache) removeElement(el *list.Element) *senderCacheEntry {
+	entry := sc.order.Remove(el).(*senderCacheEntry)
+	delete(sc.items, entry.name)
+	return entry
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	servicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/logger"
+)
+
+func TestSenderCache(t *testing.T) {
+	now := time.Now()
+	s1 := &servicebus.Sender{}
+	s2 := &servicebus.Sender{}
+	s3 := &servicebus.Sender{}
+
+	t.Run("get and add", func(t *testing.T) {
+		sc := newSenderCache(0)
+		assert.Nil(t, sc.Get("q1", now))
+
+		removed := sc.Add("q1", s1, now)
+		assert.Empty(t, removed)
+		assert.Same(t, s1, sc.Get("q1", now))
+		assert.Equal(t, 1, sc.Len())
+
+		// Replacing a sender returns the previous one
+		removed = sc.Add("q1", s2, now)
+		require.Len(t, removed, 1)
+		assert.Same(t, s1, removed[0].sender)
+		assert.Same(t, s2, sc.Get("q1", now))
+		assert.Equal(t, 1, sc.Len())
+	})
+
+	t.Run("evicts least-recently-used senders", func(t *testing.T) {
+		sc := newSenderCache(2)
+		sc.Add("q1", s1, now)
+		sc.Add("q2", s2, now.Add(time.Second))
+
+		// Using q1 makes q2 the least-recently-used sender
+		sc.Get("q1", now.Add(2*time.Second))
+
+		removed := sc.Add("q3", s3, now.Add(3*time.Second))
+		require.Len(t, removed, 1)
+		assert.Equal(t, "q2", removed[0].name)
+		assert.Same(t, s2, removed[0].sender)
+		assert.Equal(t, 2, sc.Len())
+		assert.Nil(t, sc.Get("q2", now))
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		sc := newSenderCache(0)
+		sc.Add("q1", s1, now)
+
+		assert.Nil(t, sc.Remove("q2"))
+		assert.Same(t, s1, sc.Remove("q1"))
+		assert.Equal(t, 0, sc.Len())
+	})
+
+	t.Run("remove idle senders", func(t *testing.T) {
+		sc := newSenderCache(0)
+		sc.Add("q1", s1, now)
+		sc.Add("q2", s2, now.Add(time.Minute))
+		sc.Add("q3", s3, now.Add(2*time.Minute))
+
+		removed := sc.RemoveIdle(now.Add(90 * time.Second))
+		require.Len(t, removed, 2)
+		assert.Equal(t, "q1", removed[0].name)
+		assert.Equal(t, "q2", removed[1].name)
+		assert.Equal(t, 1, sc.Len())
+		assert.Same(t, s3, sc.Get("q3", now))
+	})
+
+	t.Run("remove all", func(t *testing.T) {
+		sc := newSenderCache(0)
+		sc.Add("q1", s1, now)
+		sc.Add("q2", s2, now)
+
+		removed := sc.RemoveAll()
+		assert.Len(t, removed, 2)
+		assert.Equal(t, 0, sc.Len())
+	})
+}
+
+func TestClientSenders(t *testing.T) {
+	log := logger.NewLogger("test")
+	newClient := func(t *testing.T, maxSenders int, idleTimeout time.Duration) *Client {
+		t.Helper()
+		client, err := NewClient(&Metadata{
+			ConnectionString:        "Endpoint=sb://fake.servicebus.windows.net/;SharedAccessKeyName=fake;SharedAccessKey=fake",
+			DisableEntityManagement: true,
+			TimeoutInSec:            1,
+			MaxSenders:              maxSenders,
+			SenderIdleTimeout:       idleTimeout,
+		}, nil, log)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			client.Close(log)
+		})
+		return client
+	}
+
+	t.Run("senders are cached", func(t *testing.T) {
+		client := newClient(t, 0, 0)
+
+		s1, err := client.GetSender(context.Background(), "q1", nil)
+		require.NoError(t, err)
+		s2, err := client.GetSender(context.Background(), "q1", nil)
+		require.NoError(t, err)
+		assert.Same(t, s1, s2)
+
+		// The reaper is disabled
+		assert.Nil(t, client.reaperStopCh)
+	})
+
+	t.Run("number of senders is limited", func(t *testing.T) {
+		client := newClient(t, 2, 0)
+
+		for _, name := range []string{"q1", "q2", "q3"} {
+			_, err := client.GetSender(context.Background(), name, nil)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, client.senders.Len())
+	})
+
+	t.Run("idle senders are reaped", func(t *testing.T) {
+		client := newClient(t, 0, 10*time.Millisecond)
+
+		s1, err := client.GetSender(context.Background(), "q1", nil)
+		require.NoError(t, err)
+		stopCh := client.reaperStopCh
+		require.NotNil(t, stopCh)
+
+		time.Sleep(20 * time.Millisecond)
+		assert.False(t, client.reapIdleSenders(stopCh))
+		assert.Equal(t, 0, client.senders.Len())
+		assert.Nil(t, client.reaperStopCh)
+
+		// Senders are re-created when needed, which restarts the reaper
+		s2, err := client.GetSender(context.Background(), "q1", nil)
+		require.NoError(t, err)
+		assert.NotSame(t, s1, s2)
+		assert.NotNil(t, client.reaperStopCh)
+	})
+
+	t.Run("close all senders stops the reaper", func(t *testing.T) {
+		client := newClient(t, 0, time.Minute)
+
+		_, err := client.GetSender(context.Background(), "q1", nil)
+		require.NoError(t, err)
+		require.NotNil(t, client.reaperStopCh)
+
+		client.CloseAllSenders(log)
+		assert.Nil(t, client.reaperStopCh)
+		assert.Equal(t, 0, client.senders.Len())
+	})
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	azservicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+// How long the session requirement of an entity is cached for.
+const sessionRequirementCacheTTL = 5 * time.Minute
+
+type sessionRequirement struct {
+	requiresSession bool
+	expires         time.Time
+}
+
+// QueueRequiresSession returns true if the queue exists and requires sessions.
+// Returns false if the validation of published sessions is disabled or the admin client doesn't exist.
+func (c *Client) QueueRequiresSession(parentCtx context.Context, queue string) (bool, error) {
+	return c.requiresSession(parentCtx, "queue/"+queue, func(ctx context.Context) (bool, bool, error) {
+		res, err := c.adminClient.GetQueue(ctx, queue, nil)
+		if err != nil {
+			return false, false, fmt.Errorf("could not get queue %s: %w", queue, err)
+		}
+		if res == nil {
+			return false, false, nil
+		}
+		return res.RequiresSession != nil && *res.RequiresSession, true, nil
+	})
+}
+
+// TopicRequiresSession returns true if any subscription of the topic requires sessions.
+// Returns false if the validation of published sessions is disabled or the admin client doesn't exist.
+func (c *Client) TopicRequiresSession(parentCtx context.Context, topic string) (bool, error) {
+	return c.requiresSession(parentCtx, "topic/"+topic, func(ctx context.Context) (bool, bool, error) {
+		res, err := c.adminClient.GetTopic(ctx, topic, nil)
+		if err != nil {
+			return false, false, fmt.Errorf("could not get topic %s: %w", topic, err)
+		}
+		if res == nil {
+			return false, false, nil
+		}
+
+		pager := c.adminClient.NewListSubscriptionsPager(topic, nil)
+		for pager.More() {
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return false, false, fmt.Errorf("could not list subscriptions of topic %s: %w", topic, err)
+			}
+			for _, s := range page.Subscriptions {
+				if s.RequiresSession != nil && *s.RequiresSession {
+					return true, true, nil
+				}
+			}
+		}
+		return false, true, nil
+	})
+}
+
+// requiresSession returns the session requirement of an entity, caching it.
+// Entities that don't exist yet are cached as not requiring sessions, as they are created without sessions when the message is published.
+func (c *Client) requiresSession(parentCtx context.Context, key string, getFn func(ctx context.Context) (requiresSession bool, exists bool, err error)) (bool, error) {
+	if c.adminClient == nil || !c.metadata.ValidatePublishSessions {
+		return false, nil
+	}
+
+	c.lock.RLock()
+	cached, ok := c.sessionEntities[key]
+	c.lock.RUnlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.requiresSession, nil
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, time.Second*time.Duration(c.metadata.TimeoutInSec))
+	defer cancel()
+	res, exists, err := getFn(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		res = false
+	}
+
+	c.lock.Lock()
+	c.sessionEntities[key] = sessionRequirement{
+		requiresSession: res,
+		expires:         time.Now().Add(sessionRequirementCacheTTL),
+	}
+	c.lock.Unlock()
+	return res, nil
+}
+
+// ValidateSessionMetadata returns an error if the entity requires sessions but the metadata of a message doesn't contain a session ID.
+func ValidateSessionMetadata(entity string, requiresSession bool, md map[string]string) error {
+	if !requiresSession {
+		return nil
+	}
+	_, ok := md[MessageKeySessionID]
+	if !ok {
+		_, ok = md[MessageKeySessionIDAlias]
+	}
+	if !ok {
+		return fmt.Errorf("%s requires sessions: messages must have the %s metadata set", entity, MessageKeySessionIDAlias)
+	}
+	return nil
+}
+
+// ValidateBulkSessionMetadata validates the session ID of all the entries of a bulk publish request.
+func ValidateBulkSessionMetadata(requiresSession bool, req *pubsub.BulkPublishRequest) error {
+	if !requiresSession {
+		return nil
+	}
+	for _, entry := range req.Entries {
+		if err := ValidateSessionMetadata(req.Topic, requiresSession, entry.Metadata); err != nil {
+			return fmt.Errorf("entry %s: %w", entry.EntryId, err)
+		}
+	}
+	return nil
+}
+
+// AcceptSessionFn accepts the next available session of a queue or subscription.
+type AcceptSessionFn func(ctx context.Context) (*azservicebus.SessionReceiver, error)
+
+// ConnectAndReceiveWithSessions accepts up to maxConcurrentSessions sessions at a time and receives messages from each of them.
+// It blocks until the context is canceled, then waits for the receivers of the active sessions to be closed.
+// Parameter "logMsg" describes the queue or subscription and it's only used for logging.
+func (s *Subscription) ConnectAndReceiveWithSessions(ctx context.Context, handler HandlerFn, acceptSessionFn AcceptSessionFn, onFirstSuccess func(), maxConcurrentSessions int, logMsg string) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	sessionsChan := make(chan struct{}, maxConcurrentSessions)
+	for i := 0; i < maxConcurrentSessions; i++ {
+		sessionsChan <- struct{}{}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sessionsChan:
+			// nop - continue
+		}
+
+		// Check again if the context was canceled
+		if ctx.Err() != nil {
+			return
+		}
+
+		acceptCtx, acceptCancel := context.WithCancel(ctx)
+
+		// Blocks until a successful connection (or until context is canceled)
+		receiver, err := s.Connect(ctx, func() (Receiver, error) {
+			s.logger.Debug("Accepting next available session for " + logMsg)
+			r, rErr := acceptSessionFn(acceptCtx)
+			if rErr != nil {
+				return nil, rErr
+			}
+			return NewSessionReceiver(r), nil
+		})
+		acceptCancel()
+		if err != nil {
+			// Realistically, the only time we should get to this point is if the context was canceled, but let's log any other error we may get.
+			if !errors.Is(err, context.Canceled) {
+				s.logger.Error("Could not instantiate session receiver for " + logMsg)
+			}
+			return
+		}
+
+		// Receive messages for the session in a goroutine
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sessionLogMsg := fmt.Sprintf("session %s for %s", receiver.(*SessionReceiver).SessionID(), logMsg)
+
+			defer func() {
+				// Return the session to the pool
+				sessionsChan <- struct{}{}
+			}()
+
+			s.logger.Debug("Receiving messages for " + sessionLogMsg)
+
+			// ReceiveBlocking will only return with an error that it cannot handle internally. The session receiver is closed when this method returns.
+			// If that occurs, we will log the error and accept the next available session.
+			rErr := s.ReceiveBlocking(ctx, handler, receiver, onFirstSuccess, sessionLogMsg)
+			if rErr != nil && !errors.Is(rErr, context.Canceled) {
+				s.logger.Error(rErr)
+			}
+		}()
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicebus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	sbadmin "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus/admin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+func TestValidateSessionMetadata(t *testing.T) {
+	assert.NoError(t, ValidateSessionMetadata("orders", false, nil))
+	assert.NoError(t, ValidateSessionMetadata("orders", true, map[string]string{"sessionId": "s1"}))
+	assert.NoError(t, ValidateSessionMetadata("orders", true, map[string]string{"SessionId": "s1"}))
+	assert.Error(t, ValidateSessionMetadata("orders", true, map[string]string{"partitionKey": "p1"}))
+}
+
+func TestValidateBulkSessionMetadata(t *testing.T) {
+	req := &pubsub.BulkPublishRequest{
+		Topic: "orders",
+		Entries: []pubsub.BulkMessageEntry{
+			{EntryId: "1", Metadata: map[string]string{"sessionId": "s1"}},
+			{EntryId: "2"},
+		},
+	}
+	assert.NoError(t, ValidateBulkSessionMetadata(false, req))
+	err := ValidateBulkSessionMetadata(true, req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "entry 2")
+}
+
+func TestRequiresSessionCache(t *testing.T) {
+	c := &Client{
+		adminClient:     &sbadmin.Client{},
+		metadata:        &Metadata{TimeoutInSec: 5, ValidatePublishSessions: true},
+		lock:            &sync.RWMutex{},
+		sessionEntities: make(map[string]sessionRequirement),
+	}
+
+	t.Run("existing entities are cached", func(t *testing.T) {
+		calls := 0
+		getFn := func(context.Context) (bool, bool, error) {
+			calls++
+			return true, true, nil
+		}
+		for i := 0; i < 3; i++ {
+			res, err := c.requiresSession(context.Background(), "queue/a", getFn)
+			require.NoError(t, err)
+			assert.True(t, res)
+		}
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("missing entities are cached", func(t *testing.T) {
+		calls := 0
+		getFn := func(context.Context) (bool, bool, error) {
+			calls++
+			return false, false, nil
+		}
+		for i := 0; i < 2; i++ {
+			res, err := c.requiresSession(context.Background(), "queue/b", getFn)
+			require.NoError(t, err)
+			assert.False(t, res)
+		}
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		calls := 0
+		getFn := func(context.Context) (bool, bool, error) {
+			calls++
+			return false, false, errors.New("unauthorized")
+		}
+		for i := 0; i < 2; i++ {
+			_, err := c.requiresSession(context.Background(), "queue/d", getFn)
+			require.Error(t, err)
+		}
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("validation disabled", func(t *testing.T) {
+		disabled := &Client{
+			adminClient: &sbadmin.Client{},
+			metadata:    &Metadata{TimeoutInSec: 5},
+		}
+		res, err := disabled.requiresSession(context.Background(), "queue/e", nil)
+		require.NoError(t, err)
+		assert.False(t, res)
+	})
+
+	t.Run("no admin client", func(t *testing.T) {
+		res, err := (&Client{}).requiresSession(context.Background(), "queue/c", nil)
+		require.NoError(t, err)
+		assert.False(t, res)
+	})
+}
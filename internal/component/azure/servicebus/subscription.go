@@ -25,6 +25,8 @@ import (
 	"go.uber.org/multierr"
 	"go.uber.org/ratelimit"
 
+	mdutils "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/ptr"
 	"github.com/dapr/kit/retry"
@@ -42,6 +44,23 @@ const (
 	maxConcurrentOps = 20
 )
 
+// SessionSettings contains the settings for sessions, from the metadata of a subscribe request.
+type SessionSettings struct {
+	RequireSessions       bool          `mapstructure:"requireSessions"`
+	SessionIdleTimeout    time.Duration `mapstructure:"sessionIdleTimeoutInSec" default:"60" minValue:"0"`
+	MaxConcurrentSessions int           `mapstructure:"maxConcurrentSessions" default:"8" minValue:"1"`
+}
+
+// ParseSessionSettings parses the settings for sessions from the metadata of a subscribe request.
+func ParseSessionSettings(reqMetadata map[string]string) (SessionSettings, error) {
+	var settings SessionSettings
+	err := mdutils.DecodeMetadata(reqMetadata, &settings)
+	if err != nil {
+		return settings, fmt.Errorf("invalid session settings: %w", err)
+	}
+	return settings, nil
+}
+
 // HandlerResponseItem represents a response from the handler for each message.
 type HandlerResponseItem struct {
 	EntryId string //nolint:stylecheck
@@ -62,8 +81,12 @@ type Subscription struct {
 	timeout              time.Duration
 	lockRenewalInterval  time.Duration
 	maxBulkSubCount      int
+	maxDeliveryCount     int32
 	retriableErrLimiter  ratelimit.Limiter
 	handleChan           chan struct{}
+	metrics              *pubsub.Metrics
+	metricsTopic         string
+	readiness            *pubsub.TrackedSubscription
 	logger               logger.Logger
 }
 
@@ -77,6 +100,11 @@ type SubscriptionOptions struct {
 	LockRenewalInSec      int
 	RequireSessions       bool
 	SessionIdleTimeout    time.Duration
+	// If greater than 0, messages that fail processing after being delivered this many times are dead-lettered explicitly.
+	MaxDeliveryCount int32
+	// If set, redeliveries and dead-lettered messages are reported to Metrics, using MetricsTopic as topic name.
+	Metrics      *pubsub.Metrics
+	MetricsTopic string
 }
 
 // NewBulkSubscription returns a new Subscription object.
@@ -104,7 +132,10 @@ func NewSubscription(opts SubscriptionOptions, logger logger.Logger) *Subscripti
 		lockRenewalInterval: time.Duration(opts.LockRenewalInSec) * time.Second,
 		sessionIdleTimeout:  opts.SessionIdleTimeout,
 		maxBulkSubCount:     *opts.MaxBulkSubCount,
+		maxDeliveryCount:    opts.MaxDeliveryCount,
 		requireSessions:     opts.RequireSessions,
+		metrics:             opts.Metrics,
+		metricsTopic:        opts.MetricsTopic,
 		logger:              logger,
 		// This is a pessimistic estimate of the number of total operations that can be active at any given time.
 		// In case of a non-bulk subscription, one operation is one message.
@@ -125,6 +156,24 @@ func NewSubscription(opts SubscriptionOptions, logger logger.Logger) *Subscripti
 	return s
 }
 
+// ReportReadiness makes the subscription report the state of its connection to the Service Bus entity to tracked.
+// It must be called before Connect.
+func (s *Subscription) ReportReadiness(tracked *pubsub.TrackedSubscription) {
+	s.readiness = tracked
+}
+
+func (s *Subscription) reportReady() {
+	if s.readiness != nil {
+		s.readiness.Ready()
+	}
+}
+
+func (s *Subscription) reportPending(err error) {
+	if s.readiness != nil {
+		s.readiness.Pending(err)
+	}
+}
+
 // Connect to a Service Bus topic or queue, blocking until it succeeds; it can retry forever (until the context is canceled).
 func (s *Subscription) Connect(ctx context.Context, newReceiverFunc func() (Receiver, error)) (Receiver, error) {
 	// Connections need to retry forever with a maximum backoff of 5 minutes and exponential scaling.
@@ -134,16 +183,19 @@ func (s *Subscription) Connect(ctx context.Context, newReceiverFunc func() (Rece
 	config.MaxElapsedTime = 0
 	backoff := config.NewBackOffWithContext(ctx)
 
-	return retry.NotifyRecoverWithData(
+	receiver, err := retry.NotifyRecoverWithData(
 		func() (Receiver, error) {
 			receiver, innerErr := newReceiverFunc()
 			if innerErr != nil {
 				if s.requireSessions {
 					var sbErr *azservicebus.Error
 					if errors.As(innerErr, &sbErr) && sbErr.Code == azservicebus.CodeTimeout {
+						// The entity is reachable, there are just no messages to receive
+						s.reportReady()
 						return nil, errors.New("no sessions available")
 					}
 				}
+				s.reportPending(innerErr)
 				return nil, innerErr
 			}
 			if _, ok := receiver.(*SessionReceiver); !ok && s.requireSessions {
@@ -161,6 +213,10 @@ func (s *Subscription) Connect(ctx context.Context, newReceiverFunc func() (Rece
 			s.logger.Infof("Successfully reconnected to Azure Service Bus %s", s.entity)
 		},
 	)
+	if err == nil {
+		s.reportReady()
+	}
+	return receiver, err
 }
 
 // ReceiveBlocking is a blocking call to receive messages on an Azure Service Bus subscription from a topic or queue.
@@ -231,6 +287,10 @@ func (s *Subscription) ReceiveBlocking(parentCtx context.Context, handler Handle
 		if err != nil {
 			if err != context.Canceled {
 				s.logger.Errorf("Error reading from %s. %s", s.entity, err.Error())
+				// Session receivers are expected to fail when the session is idle; the next session is accepted with Connect
+				if !s.requireSessions {
+					s.reportPending(err)
+				}
 			}
 			<-s.activeOperationsChan
 			// Return the error. This will cause the Service Bus component to try and reconnect.
@@ -444,6 +504,12 @@ func (s *Subscription) handleAsync(ctx context.Context, msgs []*azservicebus.Rec
 		}
 	}
 
+	for _, msg := range msgs {
+		if msg.DeliveryCount > 1 {
+			s.metrics.Retried(s.metricsTopic)
+		}
+	}
+
 	// Invoke the handler to process the message.
 	resps, err := handler(ctx, msgs)
 	if err != nil {
@@ -455,7 +521,7 @@ func (s *Subscription) handleAsync(ctx context.Context, msgs []*azservicebus.Rec
 			// Log the error only, as we're running asynchronously
 			s.logger.Errorf("App handler returned an error for message %s on %s: %s", msgs[0].MessageID, s.entity, err)
 			finalizeCtx, finalizeCancel := context.WithTimeout(context.Background(), s.timeout)
-			s.AbandonMessage(finalizeCtx, receiver, msgs[0])
+			s.abandonOrDeadLetterMessage(finalizeCtx, receiver, msgs[0], err)
 			finalizeCancel()
 			return
 		}
@@ -483,7 +549,7 @@ func (s *Subscription) handleAsync(ctx context.Context, msgs []*azservicebus.Rec
 				if resps[i].Error != nil {
 					// Log the error only, as we're running asynchronously.
 					s.logger.Errorf("App handler returned an error for message %s on %s: %s", msgs[i].MessageID, s.entity, resps[i].Error)
-					s.AbandonMessage(finalizeCtx, receiver, msgs[i])
+					s.abandonOrDeadLetterMessage(finalizeCtx, receiver, msgs[i], resps[i].Error)
 				} else {
 					s.CompleteMessage(finalizeCtx, receiver, msgs[i])
 				}
@@ -534,6 +600,31 @@ func (s *Subscription) AbandonMessage(ctx context.Context, receiver Receiver, m
 	}
 }
 
+// DeadLetterMessage moves a message to the dead-letter sub-queue, with the given reason and error description.
+func (s *Subscription) DeadLetterMessage(ctx context.Context, receiver Receiver, m *azservicebus.ReceivedMessage, reason string, description string) {
+	s.logger.Debugf("Dead-lettering message %s on %s with reason %s", m.MessageID, s.entity, reason)
+
+	err := receiver.DeadLetterMessage(ctx, m, &azservicebus.DeadLetterOptions{
+		Reason:           &reason,
+		ErrorDescription: &description,
+	})
+	if err != nil {
+		// Log only
+		s.logger.Warnf("Error dead-lettering message %s on %s: %s", m.MessageID, s.entity, err.Error())
+		return
+	}
+	s.metrics.DeadLettered(s.metricsTopic)
+}
+
+// abandonOrDeadLetterMessage abandons a message that the app failed to process, or dead-letters it if it has exhausted its deliveries.
+func (s *Subscription) abandonOrDeadLetterMessage(ctx context.Context, receiver Receiver, m *azservicebus.ReceivedMessage, handlerErr error) {
+	if s.maxDeliveryCount > 0 && int64(m.DeliveryCount) >= int64(s.maxDeliveryCount) {
+		s.DeadLetterMessage(ctx, receiver, m, DeadLetterReasonMaxDeliveryCount, handlerErr.Error())
+		return
+	}
+	s.AbandonMessage(ctx, receiver, m)
+}
+
 // CompleteMessage marks a message as complete.
 func (s *Subscription) CompleteMessage(ctx context.Context, receiver Receiver, m *azservicebus.ReceivedMessage) {
 	s.logger.Debugf("Completing message %s on %s", m.MessageID, s.entity)
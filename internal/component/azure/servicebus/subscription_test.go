@@ -14,8 +14,16 @@ limitations under the License.
 package servicebus
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
+	azservicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/ptr"
 )
@@ -91,3 +99,135 @@ func TestNewSubscription(t *testing.T) {
 		})
 	}
 }
+
+type fakeReceiver struct {
+	Receiver
+	abandoned    []string
+	deadLettered []string
+	reasons      []string
+}
+
+func (f *fakeReceiver) AbandonMessage(_ context.Context, m *azservicebus.ReceivedMessage, _ *azservicebus.AbandonMessageOptions) error {
+	f.abandoned = append(f.abandoned, m.MessageID)
+	return nil
+}
+
+func (f *fakeReceiver) DeadLetterMessage(_ context.Context, m *azservicebus.ReceivedMessage, opts *azservicebus.DeadLetterOptions) error {
+	f.deadLettered = append(f.deadLettered, m.MessageID)
+	f.reasons = append(f.reasons, *opts.Reason)
+	return nil
+}
+
+func TestAbandonOrDeadLetterMessage(t *testing.T) {
+	newSub := func(maxDeliveryCount int32) *Subscription {
+		return NewSubscription(SubscriptionOptions{
+			MaxActiveMessages: 10,
+			TimeoutInSec:      1,
+			Entity:            "test",
+			MaxDeliveryCount:  maxDeliveryCount,
+		}, logger.NewLogger("test"))
+	}
+	handlerErr := errors.New("handler error")
+
+	t.Run("abandons when dead-lettering is disabled", func(t *testing.T) {
+		r := &fakeReceiver{}
+		newSub(0).abandonOrDeadLetterMessage(context.Background(), r, &azservicebus.ReceivedMessage{MessageID: "1", DeliveryCount: 100}, handlerErr)
+		assert.Equal(t, []string{"1"}, r.abandoned)
+		assert.Empty(t, r.deadLettered)
+	})
+
+	t.Run("abandons before reaching the max delivery count", func(t *testing.T) {
+		r := &fakeReceiver{}
+		newSub(3).abandonOrDeadLetterMessage(context.Background(), r, &azservicebus.ReceivedMessage{MessageID: "1", DeliveryCount: 2}, handlerErr)
+		assert.Equal(t, []string{"1"}, r.abandoned)
+		assert.Empty(t, r.deadLettered)
+	})
+
+	t.Run("dead-letters when reaching the max delivery count", func(t *testing.T) {
+		r := &fakeReceiver{}
+		newSub(3).abandonOrDeadLetterMessage(context.Background(), r, &azservicebus.ReceivedMessage{MessageID: "1", DeliveryCount: 3}, handlerErr)
+		assert.Empty(t, r.abandoned)
+		assert.Equal(t, []string{"1"}, r.deadLettered)
+		assert.Equal(t, []string{DeadLetterReasonMaxDeliveryCount}, r.reasons)
+	})
+}
+
+func TestConnectReportsReadiness(t *testing.T) {
+	var tracker pubsub.SubscriptionTracker
+	sub := NewSubscription(SubscriptionOptions{
+		MaxActiveMessages: 10,
+		TimeoutInSec:      1,
+		Entity:            "test",
+	}, logger.NewLogger("test"))
+	tracked := tracker.Track("mytopic")
+	defer tracked.Close()
+	sub.ReportReadiness(tracked)
+
+	connectErr := errors.New("connection refused")
+	proceed := make(chan struct{})
+	attempts := 0
+	connected := make(chan error, 1)
+	go func() {
+		_, err := sub.Connect(context.Background(), func() (Receiver, error) {
+			attempts++
+			if attempts == 1 {
+				return nil, connectErr
+			}
+			<-proceed
+			return NewMessageReceiver(nil), nil
+		})
+		connected <- err
+	}()
+
+	// The subscription is pending with the error of the failed attempt
+	require.Eventually(t, func() bool {
+		status := tracker.SubscriptionsStatus()
+		return len(status) == 1 && status[0].State == pubsub.SubscriptionPending && errors.Is(status[0].Err, connectErr)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	close(proceed)
+	require.NoError(t, <-connected)
+	require.NoError(t, tracker.WaitSubscriptionsReady(context.Background()))
+	assert.Equal(t, []pubsub.SubscriptionStatus{{Topic: "mytopic", State: pubsub.SubscriptionReady}}, tracker.SubscriptionsStatus())
+}
+
+func TestParseSessionSettings(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		settings, err := ParseSessionSettings(map[string]string{})
+		require.NoError(t, err)
+		assert.False(t, settings.RequireSessions)
+		assert.Equal(t, DefaultSesssionIdleTimeoutInSec*time.Second, settings.SessionIdleTimeout)
+		assert.Equal(t, DefaultMaxConcurrentSessions, settings.MaxConcurrentSessions)
+	})
+
+	t.Run("durations with and without units", func(t *testing.T) {
+		for _, val := range []string{"90", "90s", "1m30s"} {
+			settings, err := ParseSessionSettings(map[string]string{
+				RequireSessionsMetadataKey:       "true",
+				SessionIdleTimeoutMetadataKey:    val,
+				MaxConcurrentSessionsMetadataKey: "2",
+			})
+			require.NoError(t, err)
+			assert.True(t, settings.RequireSessions)
+			assert.Equal(t, 90*time.Second, settings.SessionIdleTimeout)
+			assert.Equal(t, 2, settings.MaxConcurrentSessions)
+		}
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		_, err := ParseSessionSettings(map[string]string{
+			MaxConcurrentSessionsMetadataKey: "0",
+		})
+		require.Error(t, err)
+
+		_, err = ParseSessionSettings(map[string]string{
+			SessionIdleTimeoutMetadataKey: "-1",
+		})
+		require.Error(t, err)
+
+		_, err = ParseSessionSettings(map[string]string{
+			SessionIdleTimeoutMetadataKey: "soon",
+		})
+		require.Error(t, err)
+	})
+}
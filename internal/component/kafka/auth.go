@@ -22,14 +22,14 @@ import (
 	"github.com/Shopify/sarama"
 )
 
-func updatePasswordAuthInfo(config *sarama.Config, metadata *KafkaMetadata, saslUsername, saslPassword string) {
+func updatePasswordAuthInfo(config *sarama.Config, sasl saslCredentials) {
 	config.Net.SASL.Enable = true
-	config.Net.SASL.User = saslUsername
-	config.Net.SASL.Password = saslPassword
-	if metadata.SaslMechanism == "SHA-256" {
+	config.Net.SASL.User = sasl.Username
+	config.Net.SASL.Password = sasl.Password
+	if sasl.Mechanism == "SHA-256" {
 		config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &XDGSCRAMClient{HashGeneratorFcn: SHA256} }
 		config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
-	} else if metadata.SaslMechanism == "SHA-512" {
+	} else if sasl.Mechanism == "SHA-512" {
 		config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &XDGSCRAMClient{HashGeneratorFcn: SHA512} }
 		config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
 	} else {
@@ -37,14 +37,17 @@ func updatePasswordAuthInfo(config *sarama.Config, metadata *KafkaMetadata, sasl
 	}
 }
 
-func updateMTLSAuthInfo(config *sarama.Config, metadata *KafkaMetadata) error {
+func updateMTLSAuthInfo(config *sarama.Config, metadata *KafkaMetadata, clientCert, clientKey string) error {
 	if metadata.TLSDisable {
 		return fmt.Errorf("kafka: cannot configure mTLS authentication when TLSDisable is 'true'")
 	}
-	cert, err := tls.X509KeyPair([]byte(metadata.TLSClientCert), []byte(metadata.TLSClientKey))
+	cert, err := tls.X509KeyPair([]byte(clientCert), []byte(clientKey))
 	if err != nil {
 		return fmt.Errorf("unable to load client certificate and key pair. Err: %w", err)
 	}
+	if config.Net.TLS.Config == nil {
+		config.Net.TLS.Config = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
 	config.Net.TLS.Config.Certificates = []tls.Certificate{cert}
 	return nil
 }
@@ -86,7 +89,9 @@ func updateOidcAuthInfo(config *sarama.Config, metadata *KafkaMetadata) error {
 
 	config.Net.SASL.Enable = true
 	config.Net.SASL.Mechanism = sarama.SASLTypeOAuth
-	config.Net.SASL.TokenProvider = &tokenProvider
+	config.Net.SASL.TokenProvider = tokenProvider
+	// Version 1 of the handshake is required for brokers to report the lifetime of the session (KIP-368), so connections re-authenticate with a fresh token before it expires
+	config.Net.SASL.Version = sarama.SASLHandshakeV1
 
 	return nil
 }
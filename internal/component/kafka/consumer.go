@@ -17,6 +17,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -25,20 +26,56 @@ import (
 	"github.com/Shopify/sarama"
 	"github.com/cenkalti/backoff/v4"
 
+	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/retry"
 )
 
 type consumer struct {
 	k       *Kafka
+	cg      sarama.ConsumerGroup
 	ready   chan bool
 	running chan struct{}
 	stopped atomic.Bool
 	once    sync.Once
 	mutex   sync.Mutex
+
+	// Partitions claimed by the consumer in the current session, which can be paused and resumed.
+	claims     map[string]map[int32]struct{}
+	claimsLock sync.Mutex
+
+	// Readiness of the subscriptions to the topics of the consumer.
+	subscriptions []*pubsub.TrackedSubscription
+}
+
+// setSubscriptionsState reports the state of the subscriptions to the topics of the consumer.
+func (consumer *consumer) setSubscriptionsState(state pubsub.SubscriptionState, err error) {
+	for _, s := range consumer.subscriptions {
+		switch state {
+		case pubsub.SubscriptionReady:
+			s.Ready()
+		case pubsub.SubscriptionFailed:
+			s.Failed(err)
+		default:
+			s.Pending(err)
+		}
+	}
+}
+
+// closeSubscriptions stops tracking the readiness of the subscriptions to the topics of the consumer.
+func (consumer *consumer) closeSubscriptions() {
+	for _, s := range consumer.subscriptions {
+		s.Close()
+	}
 }
 
 func (consumer *consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	consumer.addClaim(claim.Topic(), claim.Partition())
+	defer consumer.removeClaim(claim.Topic(), claim.Partition())
+
 	b := consumer.k.backOffConfig.NewBackOffWithContext(session.Context())
+	if consumer.k.retryPolicy.Enabled() {
+		b = consumer.k.retryPolicy.NewBackOff(session.Context())
+	}
 	isBulkSubscribe := consumer.k.checkBulkSubscribe(claim.Topic())
 
 	handlerConfig, err := consumer.k.GetTopicHandlerConfig(claim.Topic())
@@ -52,20 +89,20 @@ func (consumer *consumer) ConsumeClaim(session sarama.ConsumerGroupSession, clai
 		for {
 			select {
 			case <-session.Context().Done():
-				return consumer.flushBulkMessages(claim, messages, session, handlerConfig.BulkHandler, b)
+				return consumer.flushBulkMessages(claim, messages, session, handlerConfig, b)
 			case message := <-claim.Messages():
 				consumer.mutex.Lock()
 				if message != nil {
 					messages = append(messages, message)
 					if len(messages) >= handlerConfig.SubscribeConfig.MaxMessagesCount {
-						consumer.flushBulkMessages(claim, messages, session, handlerConfig.BulkHandler, b)
+						consumer.flushBulkMessages(claim, messages, session, handlerConfig, b)
 						messages = messages[:0]
 					}
 				}
 				consumer.mutex.Unlock()
 			case <-ticker.C:
 				consumer.mutex.Lock()
-				consumer.flushBulkMessages(claim, messages, session, handlerConfig.BulkHandler, b)
+				consumer.flushBulkMessages(claim, messages, session, handlerConfig, b)
 				messages = messages[:0]
 				consumer.mutex.Unlock()
 			}
@@ -78,20 +115,23 @@ func (consumer *consumer) ConsumeClaim(session sarama.ConsumerGroupSession, clai
 					return nil
 				}
 
-				if consumer.k.consumeRetryEnabled {
+				if consumer.k.retryEnabled() {
 					if err := retry.NotifyRecover(func() error {
 						return consumer.doCallback(session, message)
 					}, b, func(err error, d time.Duration) {
 						consumer.k.logger.Warnf("Error processing Kafka message: %s/%d/%d [key=%s]. Error: %v. Retrying...", message.Topic, message.Partition, message.Offset, asBase64String(message.Key), err)
+						consumer.k.Metrics.Retried(message.Topic)
 					}, func() {
 						consumer.k.logger.Infof("Successfully processed Kafka message after it previously failed: %s/%d/%d [key=%s]", message.Topic, message.Partition, message.Offset, asBase64String(message.Key))
 					}); err != nil {
 						consumer.k.logger.Errorf("Too many failed attempts at processing Kafka message: %s/%d/%d [key=%s]. Error: %v.", message.Topic, message.Partition, message.Offset, asBase64String(message.Key), err)
+						consumer.deadLetter(session, message, err)
 					}
 				} else {
 					err := consumer.doCallback(session, message)
 					if err != nil {
 						consumer.k.logger.Errorf("Error processing Kafka message: %s/%d/%d [key=%s]. Error: %v.", message.Topic, message.Partition, message.Offset, asBase64String(message.Key), err)
+						consumer.deadLetter(session, message, err)
 					}
 				}
 			// Should return when `session.Context()` is done.
@@ -106,21 +146,24 @@ func (consumer *consumer) ConsumeClaim(session sarama.ConsumerGroupSession, clai
 
 func (consumer *consumer) flushBulkMessages(claim sarama.ConsumerGroupClaim,
 	messages []*sarama.ConsumerMessage, session sarama.ConsumerGroupSession,
-	handler BulkEventHandler, b backoff.BackOff,
+	handlerConfig SubscriptionHandlerConfig, b backoff.BackOff,
 ) error {
 	if len(messages) > 0 {
-		if consumer.k.consumeRetryEnabled {
+		if consumer.k.retryEnabled() {
 			if err := retry.NotifyRecover(func() error {
-				return consumer.doBulkCallback(session, messages, handler, claim.Topic())
+				return consumer.doBulkCallback(session, messages, handlerConfig, claim.Topic())
 			}, b, func(err error, d time.Duration) {
 				consumer.k.logger.Warnf("Error processing Kafka bulk messages: %s. Error: %v. Retrying...", claim.Topic(), err)
+				for range messages {
+					consumer.k.Metrics.Retried(claim.Topic())
+				}
 			}, func() {
 				consumer.k.logger.Infof("Successfully processed Kafka message after it previously failed: %s", claim.Topic())
 			}); err != nil {
 				consumer.k.logger.Errorf("Too many failed attempts at processing Kafka message: %s. Error: %v.", claim.Topic(), err)
 			}
 		} else {
-			err := consumer.doBulkCallback(session, messages, handler, claim.Topic())
+			err := consumer.doBulkCallback(session, messages, handlerConfig, claim.Topic())
 			if err != nil {
 				consumer.k.logger.Errorf("Error processing Kafka message: %s. Error: %v.", claim.Topic(), err)
 			}
@@ -131,10 +174,13 @@ func (consumer *consumer) flushBulkMessages(claim sarama.ConsumerGroupClaim,
 }
 
 func (consumer *consumer) doBulkCallback(session sarama.ConsumerGroupSession,
-	messages []*sarama.ConsumerMessage, handler BulkEventHandler, topic string,
+	messages []*sarama.ConsumerMessage, handlerConfig SubscriptionHandlerConfig, topic string,
 ) error {
 	consumer.k.logger.Debugf("Processing Kafka bulk message: %s", topic)
-	messageValues := make([]KafkaBulkMessageEntry, (len(messages)))
+	messageValues := make([]KafkaBulkMessageEntry, 0, len(messages))
+	// Messages whose TTL has elapsed are not delivered, but they are marked as consumed with the others
+	expired := make([]bool, len(messages))
+	now := time.Now()
 
 	for i, message := range messages {
 		if message != nil {
@@ -144,30 +190,58 @@ func (consumer *consumer) doBulkCallback(session sarama.ConsumerGroupSession,
 					metadata[string(t.Key)] = string(t.Value)
 				}
 			}
+			if pubsub.IsMessageExpired(metadata, now) {
+				consumer.k.logger.Debugf("Dropping expired Kafka message: %s/%d/%d", message.Topic, message.Partition, message.Offset)
+				expired[i] = true
+				continue
+			}
+			data, err := pubsub.DecompressMessage(message.Value, metadata)
+			if err != nil {
+				return err
+			}
+			data, err = consumer.k.deserializeValue(session.Context(), data, handlerConfig.ValueSchemaType)
+			if err != nil {
+				return fmt.Errorf("failed to deserialize message: %w", err)
+			}
 			childMessage := KafkaBulkMessageEntry{
 				EntryId:  strconv.Itoa(i),
-				Event:    message.Value,
+				Event:    data,
 				Metadata: metadata,
 			}
-			messageValues[i] = childMessage
+			messageValues = append(messageValues, childMessage)
+		}
+	}
+	if len(messageValues) == 0 {
+		for _, message := range messages {
+			session.MarkMessage(message, "")
 		}
+		return nil
 	}
 	event := KafkaBulkMessage{
 		Topic:   topic,
 		Entries: messageValues,
 	}
-	responses, err := handler(session.Context(), &event)
+	responses, err := handlerConfig.BulkHandler(session.Context(), &event)
 
 	if err != nil {
-		for i, resp := range responses {
-			// An extra check to confirm that runtime returned responses are in order
-			if resp.EntryId != messageValues[i].EntryId {
-				return errors.New("entry id mismatch while processing bulk messages")
-			}
-			if resp.Error != nil {
-				break
+		// Mark the messages in order until the first one that failed
+		r := 0
+		for i, message := range messages {
+			if !expired[i] {
+				if r >= len(responses) {
+					break
+				}
+				resp := responses[r]
+				// An extra check to confirm that runtime returned responses are in order
+				if resp.EntryId != messageValues[r].EntryId {
+					return errors.New("entry id mismatch while processing bulk messages")
+				}
+				if resp.Error != nil {
+					break
+				}
+				r++
 			}
-			session.MarkMessage(messages[i], "")
+			session.MarkMessage(message, "")
 		}
 	} else {
 		for _, message := range messages {
@@ -196,6 +270,19 @@ func (consumer *consumer) doCallback(session sarama.ConsumerGroupSession, messag
 		for _, header := range message.Headers {
 			event.Metadata[string(header.Key)] = string(header.Value)
 		}
+		if pubsub.IsMessageExpired(event.Metadata, time.Now()) {
+			consumer.k.logger.Debugf("Dropping expired Kafka message: %s/%d/%d", message.Topic, message.Partition, message.Offset)
+			session.MarkMessage(message, "")
+			return nil
+		}
+		event.Data, err = pubsub.DecompressMessage(event.Data, event.Metadata)
+		if err != nil {
+			return err
+		}
+	}
+	event.Data, err = consumer.k.deserializeValue(session.Context(), event.Data, handlerConfig.ValueSchemaType)
+	if err != nil {
+		return fmt.Errorf("failed to deserialize message: %w", err)
 	}
 	err = handlerConfig.Handler(session.Context(), &event)
 	if err == nil {
@@ -204,11 +291,59 @@ func (consumer *consumer) doCallback(session sarama.ConsumerGroupSession, messag
 	return err
 }
 
+// deadLetter publishes a message that failed processing to the dead letter topic, if configured, and marks it as consumed.
+// The original topic, partition and offset, and the processing error, are added to the headers of the message.
+func (consumer *consumer) deadLetter(session sarama.ConsumerGroupSession, message *sarama.ConsumerMessage, processErr error) {
+	deadLetterTopic := consumer.k.deadLetterTopic()
+	if deadLetterTopic == "" {
+		return
+	}
+
+	producer, err := consumer.k.producerForTopic(deadLetterTopic)
+	if err != nil {
+		consumer.k.logger.Errorf("Error publishing Kafka message %s/%d/%d to dead letter topic %s: %v", message.Topic, message.Partition, message.Offset, deadLetterTopic, err)
+		return
+	}
+
+	headers := make([]sarama.RecordHeader, 0, len(message.Headers)+4)
+	for _, h := range message.Headers {
+		if h != nil {
+			headers = append(headers, *h)
+		}
+	}
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte(deadLetterTopicHeader), Value: []byte(message.Topic)},
+		sarama.RecordHeader{Key: []byte(deadLetterPartitionHeader), Value: []byte(strconv.FormatInt(int64(message.Partition), 10))},
+		sarama.RecordHeader{Key: []byte(deadLetterOffsetHeader), Value: []byte(strconv.FormatInt(message.Offset, 10))},
+		sarama.RecordHeader{Key: []byte(deadLetterErrorHeader), Value: []byte(processErr.Error())},
+	)
+	msg := &sarama.ProducerMessage{
+		Topic:   deadLetterTopic,
+		Value:   sarama.ByteEncoder(message.Value),
+		Headers: headers,
+	}
+	if message.Key != nil {
+		msg.Key = sarama.ByteEncoder(message.Key)
+	}
+
+	_, _, err = producer.SendMessage(msg)
+	if err != nil {
+		// The message isn't marked, so it's delivered again after a restart or a rebalance
+		consumer.k.logger.Errorf("Error publishing Kafka message %s/%d/%d to dead letter topic %s: %v", message.Topic, message.Partition, message.Offset, deadLetterTopic, err)
+		return
+	}
+
+	consumer.k.logger.Infof("Published Kafka message %s/%d/%d to dead letter topic %s", message.Topic, message.Partition, message.Offset, deadLetterTopic)
+	consumer.k.Metrics.DeadLettered(message.Topic)
+	session.MarkMessage(message, "")
+}
+
 func (consumer *consumer) Cleanup(sarama.ConsumerGroupSession) error {
 	return nil
 }
 
 func (consumer *consumer) Setup(sarama.ConsumerGroupSession) error {
+	consumer.setSubscriptionsState(pubsub.SubscriptionReady, nil)
 	consumer.once.Do(func() {
 		close(consumer.ready)
 	})
@@ -252,37 +387,97 @@ func (k *Kafka) GetTopicHandlerConfig(topic string) (SubscriptionHandlerConfig,
 		fmt.Errorf("any handler for messages of topic %s not found", topic)
 }
 
-// Subscribe to topic in the Kafka cluster, in a background goroutine
-func (k *Kafka) Subscribe(ctx context.Context) error {
-	if k.consumerGroup == "" {
-		return errors.New("kafka: consumerGroup must be set to subscribe")
+// subscriptionGroup is a set of topics consumed by the same consumer group on the same cluster.
+type subscriptionGroup struct {
+	cluster       string
+	consumerGroup string
+	topics        []string
+}
+
+// subscriptionGroups groups the subscribed topics by cluster and consumer group.
+func (k *Kafka) subscriptionGroups() []subscriptionGroup {
+	type groupKey struct{ cluster, consumerGroup string }
+	indexes := map[groupKey]int{}
+	groups := []subscriptionGroup{}
+
+	topics := k.subscribeTopics.TopicList()
+	sort.Strings(topics)
+	for _, topic := range topics {
+		key := groupKey{
+			cluster:       k.topicClusters[topic],
+			consumerGroup: k.subscribeTopics[topic].ConsumerGroup,
+		}
+		if key.consumerGroup == "" {
+			key.consumerGroup = k.consumerGroup
+		}
+		i, ok := indexes[key]
+		if !ok {
+			i = len(groups)
+			indexes[key] = i
+			groups = append(groups, subscriptionGroup{cluster: key.cluster, consumerGroup: key.consumerGroup})
+		}
+		groups[i].topics = append(groups[i].topics, topic)
 	}
+	return groups
+}
 
+// Subscribe to topics in the Kafka clusters, in background goroutines.
+// A consumer group is started for each cluster and consumer group the topics are subscribed with.
+func (k *Kafka) Subscribe(ctx context.Context) error {
 	k.subscribeLock.Lock()
 	defer k.subscribeLock.Unlock()
 
+	return k.subscribe(ctx)
+}
+
+// subscribe starts the consumers; it must be called while holding subscribeLock.
+func (k *Kafka) subscribe(ctx context.Context) error {
+	k.subscribeCtx = ctx
+
 	// Close resources and reset synchronization primitives
 	k.closeSubscriptionResources()
 
-	topics := k.subscribeTopics.TopicList()
-	if len(topics) == 0 {
+	groups := k.subscriptionGroups()
+	if len(groups) == 0 {
 		// Nothing to subscribe to
 		return nil
 	}
 
-	cg, err := sarama.NewConsumerGroup(k.brokers, k.consumerGroup, k.config)
-	if err != nil {
-		return err
+	for _, g := range groups {
+		if g.consumerGroup == "" {
+			return fmt.Errorf("kafka: consumerGroup must be set to subscribe to topics %v", g.topics)
+		}
 	}
 
-	k.cg = cg
+	for _, g := range groups {
+		c, err := k.startConsumer(ctx, g)
+		if err != nil {
+			k.closeSubscriptionResources()
+			return err
+		}
+		k.consumers = append(k.consumers, c)
+	}
 
-	ready := make(chan bool)
-	k.consumer = consumer{
+	return nil
+}
+
+// startConsumer creates a consumer group for the subscription group and starts consuming in a background goroutine.
+func (k *Kafka) startConsumer(ctx context.Context, g subscriptionGroup) (*consumer, error) {
+	cg, err := sarama.NewConsumerGroup(k.brokersForCluster(g.cluster), g.consumerGroup, k.configForCluster(g.cluster))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &consumer{
 		k:       k,
-		ready:   ready,
+		cg:      cg,
+		ready:   make(chan bool),
 		running: make(chan struct{}),
 	}
+	topics := g.topics
+	for _, topic := range topics {
+		c.subscriptions = append(c.subscriptions, k.Subscriptions.Track(topic))
+	}
 
 	go func() {
 		k.logger.Debugf("Subscribed and listening to topics: %s", topics)
@@ -302,47 +497,52 @@ func (k *Kafka) Subscribe(ctx context.Context) error {
 				if ctxErr := ctx.Err(); ctxErr != nil {
 					return backoff.Permanent(ctxErr)
 				}
-				return k.cg.Consume(ctx, topics, &(k.consumer))
+				return cg.Consume(ctx, topics, c)
 			}, bo, func(err error, t time.Duration) {
 				k.logger.Errorf("Error consuming %v. Retrying...: %v", topics, err)
+				c.setSubscriptionsState(pubsub.SubscriptionPending, err)
 			}, func() {
 				k.logger.Infof("Recovered consuming %v", topics)
 			})
 			if innerErr != nil && !errors.Is(innerErr, context.Canceled) {
 				k.logger.Errorf("Permanent error consuming %v: %v", topics, innerErr)
+				c.setSubscriptionsState(pubsub.SubscriptionFailed, innerErr)
 			}
 		}
+		c.closeSubscriptions()
 
 		k.logger.Debugf("Closing ConsumerGroup for topics: %v", topics)
-		err := k.cg.Close()
+		err := cg.Close()
 		if err != nil {
 			k.logger.Errorf("Error closing consumer group: %v", err)
 		}
 
 		// Ensure running channel is only closed once.
-		if k.consumer.stopped.CompareAndSwap(false, true) {
-			close(k.consumer.running)
+		if c.stopped.CompareAndSwap(false, true) {
+			close(c.running)
 		}
 	}()
 
-	<-ready
+	<-c.ready
 
-	return nil
+	return c, nil
 }
 
 // Close down consumer group resources, refresh once.
 func (k *Kafka) closeSubscriptionResources() {
-	if k.cg != nil {
-		err := k.cg.Close()
+	for _, c := range k.consumers {
+		c.closeSubscriptions()
+		err := c.cg.Close()
 		if err != nil {
 			k.logger.Errorf("Error closing consumer group: %v", err)
 		}
 
-		k.consumer.once.Do(func() {
+		c.once.Do(func() {
 			// Wait for shutdown to be complete
-			<-k.consumer.running
-			close(k.consumer.ready)
-			k.consumer.once = sync.Once{}
+			<-c.running
+			close(c.ready)
+			c.once = sync.Once{}
 		})
 	}
+	k.consumers = nil
 }
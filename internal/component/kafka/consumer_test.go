@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+func TestSubscriptionGroups(t *testing.T) {
+	k := getKafka()
+	k.consumerGroup = "default"
+	k.topicClusters = map[string]string{"orders": "west", "payments": "west"}
+	k.subscribeTopics = TopicHandlerConfig{
+		"a":        {},
+		"b":        {ConsumerGroup: "other"},
+		"c":        {},
+		"orders":   {},
+		"payments": {ConsumerGroup: "other"},
+	}
+
+	groups := k.subscriptionGroups()
+	assert.Equal(t, []subscriptionGroup{
+		{cluster: "", consumerGroup: "default", topics: []string{"a", "c"}},
+		{cluster: "", consumerGroup: "other", topics: []string{"b"}},
+		{cluster: "west", consumerGroup: "default", topics: []string{"orders"}},
+		{cluster: "west", consumerGroup: "other", topics: []string{"payments"}},
+	}, groups)
+}
+
+// fakeSession is a consumer group session that records the marked messages.
+type fakeSession struct {
+	sarama.ConsumerGroupSession
+	marked []*sarama.ConsumerMessage
+}
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.marked = append(s.marked, msg)
+}
+
+func (s *fakeSession) Context() context.Context {
+	return context.Background()
+}
+
+func TestDeadLetter(t *testing.T) {
+	message := &sarama.ConsumerMessage{
+		Topic:     "orders",
+		Partition: 2,
+		Offset:    42,
+		Key:       []byte("order-1"),
+		Value:     []byte("data"),
+		Headers:   []*sarama.RecordHeader{{Key: []byte("source"), Value: []byte("app")}},
+	}
+
+	t.Run("no dead letter topic", func(t *testing.T) {
+		k := getKafka()
+		session := &fakeSession{}
+		c := &consumer{k: k}
+		c.deadLetter(session, message, errors.New("failed"))
+		assert.Empty(t, session.marked)
+	})
+
+	t.Run("published to dead letter topic", func(t *testing.T) {
+		k := getKafka()
+		k.DeadLetterTopic = "orders-dlq"
+		producer := mocks.NewSyncProducer(t, nil)
+		k.producer = producer
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+			assert.Equal(t, "orders-dlq", msg.Topic)
+			key, _ := msg.Key.Encode()
+			assert.Equal(t, "order-1", string(key))
+			value, _ := msg.Value.Encode()
+			assert.Equal(t, "data", string(value))
+			headers := map[string]string{}
+			for _, h := range msg.Headers {
+				headers[string(h.Key)] = string(h.Value)
+			}
+			assert.Equal(t, map[string]string{
+				"source":                  "app",
+				deadLetterTopicHeader:     "orders",
+				deadLetterPartitionHeader: "2",
+				deadLetterOffsetHeader:    "42",
+				deadLetterErrorHeader:     "failed",
+			}, headers)
+			return nil
+		})
+
+		session := &fakeSession{}
+		c := &consumer{k: k}
+		c.deadLetter(session, message, errors.New("failed"))
+		assert.Equal(t, []*sarama.ConsumerMessage{message}, session.marked)
+		assert.NoError(t, producer.Close())
+	})
+
+	t.Run("failed to publish", func(t *testing.T) {
+		k := getKafka()
+		k.DeadLetterTopic = "orders-dlq"
+		producer := mocks.NewSyncProducer(t, nil)
+		k.producer = producer
+		producer.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+
+		session := &fakeSession{}
+		c := &consumer{k: k}
+		c.deadLetter(session, message, errors.New("failed"))
+		// The message is delivered again
+		assert.Empty(t, session.marked)
+		assert.NoError(t, producer.Close())
+	})
+}
+
+func TestExpiredMessages(t *testing.T) {
+	ttlHeaders := func(enqueueTime time.Time) []*sarama.RecordHeader {
+		return []*sarama.RecordHeader{
+			{Key: []byte("ttlInSeconds"), Value: []byte("10")},
+			{Key: []byte(pubsub.EnqueueTimeKey), Value: []byte(strconv.FormatInt(enqueueTime.UnixMilli(), 10))},
+		}
+	}
+	expired := &sarama.ConsumerMessage{Topic: "orders", Offset: 1, Value: []byte("old"), Headers: ttlHeaders(time.Now().Add(-time.Minute))}
+	valid := &sarama.ConsumerMessage{Topic: "orders", Offset: 2, Value: []byte("new"), Headers: ttlHeaders(time.Now())}
+
+	t.Run("single message", func(t *testing.T) {
+		k := getKafka()
+		var delivered []string
+		k.subscribeTopics = TopicHandlerConfig{"orders": {Handler: func(_ context.Context, event *NewEvent) error {
+			delivered = append(delivered, string(event.Data))
+			return nil
+		}}}
+		session := &fakeSession{}
+		c := &consumer{k: k}
+
+		require.NoError(t, c.doCallback(session, expired))
+		require.NoError(t, c.doCallback(session, valid))
+		assert.Equal(t, []string{"new"}, delivered)
+		assert.Equal(t, []*sarama.ConsumerMessage{expired, valid}, session.marked)
+	})
+
+	t.Run("bulk messages", func(t *testing.T) {
+		k := getKafka()
+		var delivered []string
+		handlerConfig := SubscriptionHandlerConfig{IsBulkSubscribe: true, BulkHandler: func(_ context.Context, msg *KafkaBulkMessage) ([]pubsub.BulkSubscribeResponseEntry, error) {
+			for _, e := range msg.Entries {
+				delivered = append(delivered, string(e.Event))
+			}
+			return nil, nil
+		}}
+		session := &fakeSession{}
+		c := &consumer{k: k}
+
+		require.NoError(t, c.doBulkCallback(session, []*sarama.ConsumerMessage{expired, valid}, handlerConfig, "orders"))
+		assert.Equal(t, []string{"new"}, delivered)
+		assert.Equal(t, []*sarama.ConsumerMessage{expired, valid}, session.marked)
+
+		// The handler is not invoked if all the messages expired
+		delivered = nil
+		session = &fakeSession{}
+		require.NoError(t, c.doBulkCallback(session, []*sarama.ConsumerMessage{expired}, handlerConfig, "orders"))
+		assert.Empty(t, delivered)
+		assert.Equal(t, []*sarama.ConsumerMessage{expired}, session.marked)
+	})
+}
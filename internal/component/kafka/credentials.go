@@ -0,0 +1,287 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+const (
+	defaultCredentialsRefreshInterval = 5 * time.Minute
+)
+
+// saslCredentials are the credentials used for SASL password authentication.
+type saslCredentials struct {
+	Username  string
+	Password  string
+	Mechanism string
+}
+
+// clusterSasl are the SASL credentials of a named cluster, which override the ones of the component.
+type clusterSasl struct {
+	saslCredentials
+	// Files with the username and password, if they are not set in the metadata.
+	UsernamePath string
+	PasswordPath string
+}
+
+// credentials are the client certificate and SASL credentials used to connect to the brokers.
+type credentials struct {
+	ClientCert string
+	ClientKey  string
+	Sasl       saslCredentials
+	// SASL credentials of the named clusters that override the ones of the component.
+	ClusterSasl map[string]saslCredentials
+}
+
+// usesFiles returns true if any credential is read from a file.
+func (m *KafkaMetadata) usesFiles() bool {
+	if m.ClientCertPath != "" || m.SaslUsernamePath != "" || m.SaslPasswordPath != "" {
+		return true
+	}
+	for _, c := range m.internalClusterSasl {
+		if c.UsernamePath != "" || c.PasswordPath != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// loadCredentials returns the credentials set in the metadata, reading the ones that reference a file from the file system.
+func loadCredentials(meta *KafkaMetadata) (*credentials, error) {
+	creds := &credentials{
+		ClientCert: meta.TLSClientCert,
+		ClientKey:  meta.TLSClientKey,
+		Sasl: saslCredentials{
+			Username:  meta.SaslUsername,
+			Password:  meta.SaslPassword,
+			Mechanism: meta.SaslMechanism,
+		},
+	}
+
+	var err error
+	if meta.ClientCertPath != "" {
+		creds.ClientCert, err = readCredentialFile(meta.ClientCertPath)
+		if err != nil {
+			return nil, err
+		}
+		creds.ClientKey, err = readCredentialFile(meta.ClientKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		if !isValidPEM(creds.ClientCert) {
+			return nil, fmt.Errorf("kafka error: invalid client certificate in file %s", meta.ClientCertPath)
+		}
+		if !isValidPEM(creds.ClientKey) {
+			return nil, fmt.Errorf("kafka error: invalid client key in file %s", meta.ClientKeyPath)
+		}
+	}
+
+	creds.Sasl.Username, err = valueOrFile(creds.Sasl.Username, meta.SaslUsernamePath)
+	if err != nil {
+		return nil, err
+	}
+	creds.Sasl.Password, err = valueOrFile(creds.Sasl.Password, meta.SaslPasswordPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(meta.internalClusterSasl) > 0 {
+		creds.ClusterSasl = make(map[string]saslCredentials, len(meta.internalClusterSasl))
+		for name, c := range meta.internalClusterSasl {
+			sasl := c.saslCredentials
+			sasl.Username, err = valueOrFile(sasl.Username, c.UsernamePath)
+			if err != nil {
+				return nil, err
+			}
+			sasl.Password, err = valueOrFile(sasl.Password, c.PasswordPath)
+			if err != nil {
+				return nil, err
+			}
+			if sasl.Mechanism == "" {
+				sasl.Mechanism = meta.SaslMechanism
+			}
+			creds.ClusterSasl[name] = sasl
+		}
+	}
+
+	return creds, nil
+}
+
+// valueOrFile returns the content of the file at path if it is set, or val otherwise.
+func valueOrFile(val string, path string) (string, error) {
+	if path == "" {
+		return val, nil
+	}
+	return readCredentialFile(path)
+}
+
+// readCredentialFile reads a credential from a file, such as a mounted Kubernetes secret, removing the trailing newline.
+func readCredentialFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("kafka error: failed to read credentials file: %w", err)
+	}
+	val := strings.TrimRight(string(data), "\r\n")
+	if val == "" {
+		return "", fmt.Errorf("kafka error: credentials file %s is empty", path)
+	}
+	return val, nil
+}
+
+// newClientConfigs returns the configuration of the clients of the default cluster, and of the named clusters that override the SASL credentials.
+func (k *Kafka) newClientConfigs(meta *KafkaMetadata, creds *credentials) (*sarama.Config, map[string]*sarama.Config, error) {
+	config, err := k.newClientConfig(meta, creds, creds.Sasl)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clusterConfigs := make(map[string]*sarama.Config, len(creds.ClusterSasl))
+	for name, sasl := range creds.ClusterSasl {
+		clusterConfigs[name], err = k.newClientConfig(meta, creds, sasl)
+		if err != nil {
+			return nil, nil, fmt.Errorf("kafka error: invalid configuration for cluster %s: %w", name, err)
+		}
+	}
+
+	return config, clusterConfigs, nil
+}
+
+func (k *Kafka) newClientConfig(meta *KafkaMetadata, creds *credentials, sasl saslCredentials) (*sarama.Config, error) {
+	config := sarama.NewConfig()
+	config.Version = meta.internalVersion
+	config.Consumer.Offsets.Initial = meta.internalInitialOffset
+
+	if meta.ClientID != "" {
+		config.ClientID = meta.ClientID
+	}
+
+	err := updateTLSConfig(config, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	switch meta.AuthType {
+	case oidcAuthType:
+		k.logger.Info("Configuring SASL OAuth2/OIDC authentication")
+		err = updateOidcAuthInfo(config, meta)
+		if err != nil {
+			return nil, err
+		}
+	case passwordAuthType:
+		k.logger.Info("Configuring SASL Password authentication")
+		updatePasswordAuthInfo(config, sasl)
+	case mtlsAuthType:
+		k.logger.Info("Configuring mTLS authentcation")
+		err = updateMTLSAuthInfo(config, meta, creds.ClientCert, creds.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+	case certificateAuthType:
+		// already handled in updateTLSConfig
+	}
+
+	return config, nil
+}
+
+// configForCluster returns the client configuration of a named cluster, or of the default cluster if the name is empty.
+func (k *Kafka) configForCluster(cluster string) *sarama.Config {
+	if config, ok := k.clusterConfigs[cluster]; ok {
+		return config
+	}
+	return k.config
+}
+
+// rotateCredentials reloads the credentials from the files every interval, until the context is canceled.
+func (k *Kafka) rotateCredentials(ctx context.Context, interval time.Duration) {
+	defer k.rotationWg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := k.refreshCredentials()
+		if err != nil {
+			k.logger.Errorf("Failed to refresh the Kafka credentials: %v", err)
+		}
+	}
+}
+
+// refreshCredentials reloads the credentials from the files and, if they changed, reconnects to the brokers so new connections are authenticated with them.
+// If the credentials cannot be read, the component keeps using the current connections.
+func (k *Kafka) refreshCredentials() error {
+	creds, err := loadCredentials(k.meta)
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(creds, k.credentials) {
+		return nil
+	}
+
+	k.logger.Info("Kafka credentials changed, reconnecting to the brokers")
+	return k.reconnect(creds)
+}
+
+// reconnect replaces the producers and restarts the consumers with clients that use the credentials.
+func (k *Kafka) reconnect(creds *credentials) error {
+	config, clusterConfigs, err := k.newClientConfigs(k.meta, creds)
+	if err != nil {
+		return err
+	}
+	producer, clusterProducers, err := newProducers(config, clusterConfigs, k.brokers, k.clusters, k.meta.MaxMessageBytes)
+	if err != nil {
+		return err
+	}
+
+	k.subscribeLock.Lock()
+	defer k.subscribeLock.Unlock()
+
+	k.producerLock.Lock()
+	if k.producer == nil {
+		// The component was closed
+		k.producerLock.Unlock()
+		return errors.Join(closeProducers(producer, clusterProducers)...)
+	}
+	oldProducer, oldClusterProducers := k.producer, k.clusterProducers
+	k.producer, k.clusterProducers = producer, clusterProducers
+	k.config, k.clusterConfigs = config, clusterConfigs
+	k.credentials = creds
+	k.producerLock.Unlock()
+
+	for _, cerr := range closeProducers(oldProducer, oldClusterProducers) {
+		k.logger.Warnf("Error closing Kafka producer: %v", cerr)
+	}
+
+	if len(k.consumers) > 0 {
+		err = k.subscribe(k.subscribeCtx)
+		if err != nil {
+			return fmt.Errorf("kafka error: failed to restart consumers: %w", err)
+		}
+	}
+
+	return nil
+}
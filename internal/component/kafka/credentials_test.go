@@ -0,0 +1,340 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile writes a credential to a file in dir and returns its path.
+func writeFile(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// generateCertificate returns a self-signed PEM-encoded certificate and key with the given common name.
+func generateCertificate(t *testing.T, cn string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}))
+}
+
+func TestClusterSaslMetadata(t *testing.T) {
+	k := getKafka()
+
+	getMetadata := func() map[string]string {
+		m := getBaseMetadata()
+		m[authType] = passwordAuthType
+		m["saslUsername"] = "user"
+		m["saslPassword"] = "pass"
+		m["clusters.west.brokers"] = "w1:9092"
+		return m
+	}
+
+	t.Run("cluster credentials", func(t *testing.T) {
+		m := getMetadata()
+		m["clusters.west.saslUsername"] = "west-user"
+		m["clusters.west.saslPassword"] = "west-pass"
+		m["clusters.west.saslMechanism"] = "SHA-512"
+		meta, err := k.getKafkaMetadata(m)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]clusterSasl{
+			"west": {saslCredentials: saslCredentials{Username: "west-user", Password: "west-pass", Mechanism: "SHA-512"}},
+		}, meta.internalClusterSasl)
+		assert.False(t, meta.usesFiles())
+	})
+
+	t.Run("cluster credentials from files", func(t *testing.T) {
+		m := getMetadata()
+		m["clusters.west.saslUsernamePath"] = "/creds/west/username"
+		m["clusters.west.saslPasswordPath"] = "/creds/west/password"
+		meta, err := k.getKafkaMetadata(m)
+		require.NoError(t, err)
+		assert.Equal(t, "/creds/west/username", meta.internalClusterSasl["west"].UsernamePath)
+		assert.Equal(t, "/creds/west/password", meta.internalClusterSasl["west"].PasswordPath)
+		assert.True(t, meta.usesFiles())
+	})
+
+	t.Run("username without password", func(t *testing.T) {
+		m := getMetadata()
+		m["clusters.west.saslUsername"] = "west-user"
+		_, err := k.getKafkaMetadata(m)
+		require.ErrorContains(t, err, "must be set together")
+	})
+
+	t.Run("undefined cluster", func(t *testing.T) {
+		m := getMetadata()
+		m["clusters.east.saslUsername"] = "east-user"
+		_, err := k.getKafkaMetadata(m)
+		require.ErrorContains(t, err, "undefined cluster 'east'")
+	})
+
+	t.Run("authType is not password", func(t *testing.T) {
+		m := getMetadata()
+		m[authType] = noAuthType
+		m["clusters.west.saslUsername"] = "west-user"
+		m["clusters.west.saslPassword"] = "west-pass"
+		_, err := k.getKafkaMetadata(m)
+		require.ErrorContains(t, err, "only be set with authType 'password'")
+	})
+
+	t.Run("SASL credentials from files", func(t *testing.T) {
+		m := getBaseMetadata()
+		m[authType] = passwordAuthType
+		m["saslUsernamePath"] = "/creds/username"
+		m["saslPasswordPath"] = "/creds/password"
+		meta, err := k.getKafkaMetadata(m)
+		require.NoError(t, err)
+		assert.Equal(t, defaultCredentialsRefreshInterval, meta.CredentialsRefreshInterval)
+		assert.True(t, meta.usesFiles())
+	})
+
+	t.Run("client certificate file with inline certificate", func(t *testing.T) {
+		cert, key := generateCertificate(t, "client")
+		m := getBaseMetadata()
+		m[clientCert] = cert
+		m[clientKey] = key
+		m["clientCertPath"] = "/creds/tls.crt"
+		m["clientKeyPath"] = "/creds/tls.key"
+		_, err := k.getKafkaMetadata(m)
+		require.ErrorContains(t, err, "cannot be set together")
+	})
+
+	t.Run("client certificate file without key file", func(t *testing.T) {
+		m := getBaseMetadata()
+		m["clientCertPath"] = "/creds/tls.crt"
+		_, err := k.getKafkaMetadata(m)
+		require.ErrorContains(t, err, "clientKeyPath or clientCertPath is missing")
+	})
+}
+
+func TestLoadCredentials(t *testing.T) {
+	cert, key := generateCertificate(t, "client")
+	dir := t.TempDir()
+	certPath := writeFile(t, dir, "tls.crt", cert)
+	keyPath := writeFile(t, dir, "tls.key", key)
+	usernamePath := writeFile(t, dir, "username", "user\n")
+	passwordPath := writeFile(t, dir, "password", "pass\n")
+	westPasswordPath := writeFile(t, dir, "west-password", "west-pass")
+
+	t.Run("SASL credentials", func(t *testing.T) {
+		k := getKafka()
+		m := getBaseMetadata()
+		m[authType] = passwordAuthType
+		m["saslMechanism"] = "SHA-256"
+		m["saslUsernamePath"] = usernamePath
+		m["saslPasswordPath"] = passwordPath
+		m["clusters.west.brokers"] = "w1:9092"
+		m["clusters.west.saslUsername"] = "west-user"
+		m["clusters.west.saslPasswordPath"] = westPasswordPath
+		m["clusters.west.saslMechanism"] = "SHA-512"
+		m["clusters.east.brokers"] = "e1:9092"
+		m["clusters.east.saslUsername"] = "east-user"
+		m["clusters.east.saslPassword"] = "east-pass"
+		meta, err := k.getKafkaMetadata(m)
+		require.NoError(t, err)
+
+		creds, err := loadCredentials(meta)
+		require.NoError(t, err)
+		assert.Equal(t, saslCredentials{Username: "user", Password: "pass", Mechanism: "SHA-256"}, creds.Sasl)
+		assert.Equal(t, map[string]saslCredentials{
+			"west": {Username: "west-user", Password: "west-pass", Mechanism: "SHA-512"},
+			"east": {Username: "east-user", Password: "east-pass", Mechanism: "SHA-256"},
+		}, creds.ClusterSasl)
+
+		config, clusterConfigs, err := k.newClientConfigs(meta, creds)
+		require.NoError(t, err)
+		assert.Equal(t, "user", config.Net.SASL.User)
+		assert.Equal(t, sarama.SASLMechanism(sarama.SASLTypeSCRAMSHA256), config.Net.SASL.Mechanism)
+		assert.Equal(t, "west-user", clusterConfigs["west"].Net.SASL.User)
+		assert.Equal(t, "west-pass", clusterConfigs["west"].Net.SASL.Password)
+		assert.Equal(t, sarama.SASLMechanism(sarama.SASLTypeSCRAMSHA512), clusterConfigs["west"].Net.SASL.Mechanism)
+		assert.Equal(t, "east-user", clusterConfigs["east"].Net.SASL.User)
+	})
+
+	t.Run("client certificate", func(t *testing.T) {
+		k := getKafka()
+		m := getBaseMetadata()
+		m["disableTls"] = "false"
+		m["clientCertPath"] = certPath
+		m["clientKeyPath"] = keyPath
+		meta, err := k.getKafkaMetadata(m)
+		require.NoError(t, err)
+
+		creds, err := loadCredentials(meta)
+		require.NoError(t, err)
+		assert.Equal(t, strings.TrimRight(cert, "\n"), creds.ClientCert)
+		assert.Equal(t, strings.TrimRight(key, "\n"), creds.ClientKey)
+
+		config, _, err := k.newClientConfigs(meta, creds)
+		require.NoError(t, err)
+		assert.Len(t, config.Net.TLS.Config.Certificates, 1)
+	})
+
+	t.Run("invalid certificate file", func(t *testing.T) {
+		k := getKafka()
+		m := getBaseMetadata()
+		m["disableTls"] = "false"
+		m["clientCertPath"] = usernamePath
+		m["clientKeyPath"] = keyPath
+		meta, err := k.getKafkaMetadata(m)
+		require.NoError(t, err)
+
+		_, err = loadCredentials(meta)
+		require.ErrorContains(t, err, "invalid client certificate")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		k := getKafka()
+		m := getBaseMetadata()
+		m[authType] = passwordAuthType
+		m["saslUsername"] = "user"
+		m["saslPasswordPath"] = filepath.Join(dir, "missing")
+		meta, err := k.getKafkaMetadata(m)
+		require.NoError(t, err)
+
+		_, err = loadCredentials(meta)
+		require.ErrorContains(t, err, "failed to read credentials file")
+	})
+}
+
+// clientCertListener is a TLS listener that records the common name of the client certificate of each connection.
+type clientCertListener struct {
+	net.Listener
+
+	lock        sync.Mutex
+	commonNames []string
+}
+
+func (l *clientCertListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	tlsConn := conn.(*tls.Conn)
+	if err = tlsConn.Handshake(); err == nil {
+		if certs := tlsConn.ConnectionState().PeerCertificates; len(certs) > 0 {
+			l.lock.Lock()
+			l.commonNames = append(l.commonNames, certs[0].Subject.CommonName)
+			l.lock.Unlock()
+		}
+	}
+	return conn, nil
+}
+
+func (l *clientCertListener) seen(cn string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, name := range l.commonNames {
+		if name == cn {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRefreshCredentials(t *testing.T) {
+	serverCert, serverKey := generateCertificate(t, "broker")
+	serverPair, err := tls.X509KeyPair([]byte(serverCert), []byte(serverKey))
+	require.NoError(t, err)
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	listener := &clientCertListener{
+		Listener: tls.NewListener(tcpListener, &tls.Config{
+			Certificates: []tls.Certificate{serverPair},
+			ClientAuth:   tls.RequireAnyClientCert,
+			MinVersion:   tls.VersionTLS12,
+		}),
+	}
+	broker := sarama.NewMockBrokerListener(t, 1, listener)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).SetBroker(broker.Addr(), broker.BrokerID()),
+	})
+
+	certA, keyA := generateCertificate(t, "client-a")
+	certB, keyB := generateCertificate(t, "client-b")
+	dir := t.TempDir()
+	certPath := writeFile(t, dir, "tls.crt", certA)
+	keyPath := writeFile(t, dir, "tls.key", keyA)
+
+	k := NewKafka(getKafka().logger)
+	err = k.Init(context.Background(), map[string]string{
+		"brokers":                    broker.Addr(),
+		"consumerGroup":              "a",
+		"authType":                   mtlsAuthType,
+		"skipVerify":                 "true",
+		"clientCertPath":             certPath,
+		"clientKeyPath":              keyPath,
+		"credentialsRefreshInterval": "0",
+	})
+	require.NoError(t, err)
+	defer k.Close()
+	assert.True(t, listener.seen("client-a"))
+
+	// Nothing changed, so the producer is not replaced
+	producer := k.producer
+	require.NoError(t, k.refreshCredentials())
+	assert.Same(t, producer, k.producer)
+
+	// A failure to read the files keeps the current producer
+	writeFile(t, dir, "tls.crt", "")
+	require.Error(t, k.refreshCredentials())
+	assert.Same(t, producer, k.producer)
+
+	// The rotated certificate is used for new connections
+	writeFile(t, dir, "tls.crt", certB)
+	writeFile(t, dir, "tls.key", keyB)
+	require.NoError(t, k.refreshCredentials())
+	assert.NotSame(t, producer, k.producer)
+	assert.True(t, listener.seen("client-b"))
+}
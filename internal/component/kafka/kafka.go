@@ -15,16 +15,21 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 
 	"github.com/Shopify/sarama"
 
 	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/components-contrib/pubsub/retrypolicy"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/retry"
 )
 
+// ConsumerGroupMetadataKey is the key of the subscribe request metadata that overrides the consumer group for a topic.
+const ConsumerGroupMetadataKey = "consumerGroup"
+
 // Kafka allows reading/writing to a Kafka consumer group.
 type Kafka struct {
 	producer        sarama.SyncProducer
@@ -32,15 +37,28 @@ type Kafka struct {
 	brokers         []string
 	logger          logger.Logger
 	authType        string
-	saslUsername    string
-	saslPassword    string
 	initialOffset   int64
-	cg              sarama.ConsumerGroup
-	consumer        consumer
+	consumers       []*consumer
+	subscribeCtx    context.Context
 	config          *sarama.Config
 	subscribeTopics TopicHandlerConfig
 	subscribeLock   sync.Mutex
 
+	// Named clusters, in addition to the default one defined by brokers, and the routing of topics to them
+	clusters         map[string][]string
+	topicClusters    map[string]string
+	clusterProducers map[string]sarama.SyncProducer
+	// Client configuration of the named clusters that override the SASL credentials
+	clusterConfigs map[string]*sarama.Config
+	// Protects the producers and the client configuration, which are replaced when the credentials change
+	producerLock sync.RWMutex
+
+	// Credentials can be read from files, and are reloaded periodically to pick up rotated secrets
+	meta           *KafkaMetadata
+	credentials    *credentials
+	rotationCancel context.CancelFunc
+	rotationWg     sync.WaitGroup
+
 	backOffConfig retry.Config
 
 	// The default value should be true for kafka pubsub component and false for kafka binding component
@@ -48,6 +66,29 @@ type Kafka struct {
 	DefaultConsumeRetryEnabled bool
 	consumeRetryEnabled        bool
 	consumeRetryInterval       time.Duration
+
+	// Topic where the messages that fail processing are published, after retries if enabled.
+	// This is used by the kafka binding component, as dead letter topics of the pubsub component are handled by the runtime.
+	DeadLetterTopic string
+
+	// Algorithm used to compress the payloads of published messages.
+	compression pubsub.Compression
+
+	// Metrics of published, retried and dead-lettered messages.
+	Metrics pubsub.Metrics
+
+	// Readiness of the subscriptions of the consumer groups, which are established in the background.
+	Subscriptions pubsub.SubscriptionTracker
+
+	// Retry budget for messages that fail processing; when enabled, it replaces the backOff settings and consumeRetryEnabled.
+	retryPolicy *retrypolicy.Policy
+
+	// Client of the schema registry used for values with a schema; nil if not configured.
+	schemaRegistry *schemaRegistry
+
+	// Paused partitions of the topics, which are paused again when they are claimed after a rebalance.
+	paused    map[string]*pausedTopic
+	pauseLock sync.Mutex
 }
 
 func NewKafka(logger logger.Logger) *Kafka {
@@ -74,46 +115,27 @@ func (k *Kafka) Init(_ context.Context, metadata map[string]string) error {
 	k.consumerGroup = meta.ConsumerGroup
 	k.initialOffset = meta.internalInitialOffset
 	k.authType = meta.AuthType
-
-	config := sarama.NewConfig()
-	config.Version = meta.internalVersion
-	config.Consumer.Offsets.Initial = k.initialOffset
-
-	if meta.ClientID != "" {
-		config.ClientID = meta.ClientID
+	k.clusters = meta.internalClusters
+	k.topicClusters = meta.internalTopicClusters
+	k.compression = meta.internalCompression
+	if meta.SchemaRegistryURL != "" {
+		k.schemaRegistry = newSchemaRegistry(meta)
 	}
 
-	err = updateTLSConfig(config, meta)
+	k.meta = meta
+	k.credentials, err = loadCredentials(meta)
 	if err != nil {
 		return err
 	}
 
-	switch k.authType {
-	case oidcAuthType:
-		k.logger.Info("Configuring SASL OAuth2/OIDC authentication")
-		err = updateOidcAuthInfo(config, meta)
-		if err != nil {
-			return err
-		}
-	case passwordAuthType:
-		k.logger.Info("Configuring SASL Password authentication")
-		k.saslUsername = meta.SaslUsername
-		k.saslPassword = meta.SaslPassword
-		updatePasswordAuthInfo(config, meta, k.saslUsername, k.saslPassword)
-	case mtlsAuthType:
-		k.logger.Info("Configuring mTLS authentcation")
-		err = updateMTLSAuthInfo(config, meta)
-		if err != nil {
-			return err
-		}
-	case certificateAuthType:
-		// already handled in updateTLSConfig
+	k.config, k.clusterConfigs, err = k.newClientConfigs(meta, k.credentials)
+	if err != nil {
+		return err
 	}
-
-	k.config = config
 	sarama.Logger = SaramaLogBridge{daprLogger: k.logger}
 
-	k.producer, err = getSyncProducer(*k.config, k.brokers, meta.MaxMessageBytes)
+	// Authentication and TLS settings are shared by all clusters, unless they override the SASL credentials
+	k.producer, k.clusterProducers, err = newProducers(k.config, k.clusterConfigs, k.brokers, k.clusters, meta.MaxMessageBytes)
 	if err != nil {
 		return err
 	}
@@ -129,20 +151,61 @@ func (k *Kafka) Init(_ context.Context, metadata map[string]string) error {
 	k.consumeRetryEnabled = meta.ConsumeRetryEnabled
 	k.consumeRetryInterval = meta.ConsumeRetryInterval
 
+	retrySettings, err := retrypolicy.ParseSettings(metadata)
+	if err != nil {
+		return err
+	}
+	k.retryPolicy = retrypolicy.New(retrySettings)
+
+	if meta.usesFiles() && meta.CredentialsRefreshInterval > 0 {
+		var rotationCtx context.Context
+		rotationCtx, k.rotationCancel = context.WithCancel(context.Background())
+		k.rotationWg.Add(1)
+		go k.rotateCredentials(rotationCtx, meta.CredentialsRefreshInterval)
+	}
+
 	k.logger.Debug("Kafka message bus initialization complete")
 
 	return nil
 }
 
-func (k *Kafka) Close() (err error) {
-	k.closeSubscriptionResources()
+// deadLetterTopic returns the topic where the messages that fail processing are published, if any.
+func (k *Kafka) deadLetterTopic() string {
+	if topic := k.retryPolicy.DeadLetterTopic(); topic != "" {
+		return topic
+	}
+	return k.DeadLetterTopic
+}
+
+// retryEnabled returns true if the messages that fail processing are retried.
+func (k *Kafka) retryEnabled() bool {
+	return k.consumeRetryEnabled || k.retryPolicy.Enabled()
+}
 
-	if k.producer != nil {
-		err = k.producer.Close()
-		k.producer = nil
+func (k *Kafka) Close() error {
+	if k.rotationCancel != nil {
+		k.rotationCancel()
+		k.rotationWg.Wait()
 	}
 
-	return err
+	k.closeSubscriptionResources()
+
+	k.producerLock.Lock()
+	defer k.producerLock.Unlock()
+
+	errs := closeProducers(k.producer, k.clusterProducers)
+	k.producer = nil
+	k.clusterProducers = nil
+
+	return errors.Join(errs...)
+}
+
+// brokersForCluster returns the brokers of a named cluster, or the default brokers if the name is empty.
+func (k *Kafka) brokersForCluster(cluster string) []string {
+	if cluster == "" {
+		return k.brokers
+	}
+	return k.clusters[cluster]
 }
 
 // EventHandler is the handler used to handle the subscribed event.
@@ -157,6 +220,10 @@ type SubscriptionHandlerConfig struct {
 	SubscribeConfig pubsub.BulkSubscribeConfig
 	BulkHandler     BulkEventHandler
 	Handler         EventHandler
+	// ConsumerGroup overrides the consumer group of the component for the topic, if set.
+	ConsumerGroup string
+	// ValueSchemaType is the format of the values of the messages; values with a schema are converted to JSON before they are delivered to the handler.
+	ValueSchemaType SchemaType
 }
 
 // NewEvent is an event arriving from a message bus instance.
@@ -16,6 +16,7 @@ package kafka
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -23,6 +24,7 @@ import (
 	"github.com/Shopify/sarama"
 
 	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
 )
 
 const (
@@ -39,6 +41,16 @@ const (
 	oidcAuthType         = "oidc"
 	mtlsAuthType         = "mtls"
 	noAuthType           = "none"
+
+	// Keys "clusters.<name>.brokers" define additional named clusters.
+	clustersPrefix       = "clusters."
+	clusterBrokersSuffix = ".brokers"
+	// Keys "clusters.<name>.saslUsername", "clusters.<name>.saslPassword", "clusters.<name>.saslMechanism", "clusters.<name>.saslUsernamePath" and "clusters.<name>.saslPasswordPath" override the SASL credentials for a named cluster.
+	clusterSaslUsernameSuffix     = ".saslUsername"
+	clusterSaslPasswordSuffix     = ".saslPassword"
+	clusterSaslMechanismSuffix    = ".saslMechanism"
+	clusterSaslUsernamePathSuffix = ".saslUsernamePath"
+	clusterSaslPasswordPathSuffix = ".saslPasswordPath"
 )
 
 type KafkaMetadata struct {
@@ -67,6 +79,28 @@ type KafkaMetadata struct {
 	ConsumeRetryInterval  time.Duration       `mapstructure:"consumeRetryInterval"`
 	Version               string              `mapstructure:"version"`
 	internalVersion       sarama.KafkaVersion `mapstructure:"-"`
+	// TopicClusters routes topics to named clusters, in the format "topic1=cluster1,topic2=cluster2".
+	// Topics that are not listed use the cluster defined by Brokers.
+	TopicClusters         string              `mapstructure:"topicClusters"`
+	internalClusters      map[string][]string `mapstructure:"-"`
+	internalTopicClusters map[string]string   `mapstructure:"-"`
+	// Compression is the algorithm used to compress the payloads of the messages that are published: "gzip", "zstd" or "snappy".
+	Compression         string             `mapstructure:"compression"`
+	internalCompression pubsub.Compression `mapstructure:"-"`
+	// Confluent Schema Registry used to serialize and deserialize values whose valueSchemaType is "Avro".
+	SchemaRegistryURL           string        `mapstructure:"schemaRegistryURL"`
+	SchemaRegistryAPIKey        string        `mapstructure:"schemaRegistryAPIKey"`
+	SchemaRegistryAPISecret     string        `mapstructure:"schemaRegistryAPISecret"`
+	SchemaCachingEnabled        bool          `mapstructure:"schemaCachingEnabled"`
+	SchemaLatestVersionCacheTTL time.Duration `mapstructure:"schemaLatestVersionCacheTTL"`
+	// Files with the client certificate and key, or the SASL credentials, such as a mounted Kubernetes secret.
+	// Files are read again every CredentialsRefreshInterval, and the component reconnects to the brokers when they change.
+	ClientCertPath             string                 `mapstructure:"clientCertPath"`
+	ClientKeyPath              string                 `mapstructure:"clientKeyPath"`
+	SaslUsernamePath           string                 `mapstructure:"saslUsernamePath"`
+	SaslPasswordPath           string                 `mapstructure:"saslPasswordPath"`
+	CredentialsRefreshInterval time.Duration          `mapstructure:"credentialsRefreshInterval"`
+	internalClusterSasl        map[string]clusterSasl `mapstructure:"-"`
 }
 
 // upgradeMetadata updates metadata properties based on deprecated usage.
@@ -108,8 +142,11 @@ func (k *Kafka) upgradeMetadata(metadata map[string]string) (map[string]string,
 // getKafkaMetadata returns new Kafka metadata.
 func (k *Kafka) getKafkaMetadata(meta map[string]string) (*KafkaMetadata, error) {
 	m := KafkaMetadata{
-		ConsumeRetryInterval: 100 * time.Millisecond,
-		internalVersion:      sarama.V2_0_0_0, //nolint:nosnakecase
+		ConsumeRetryInterval:        100 * time.Millisecond,
+		internalVersion:             sarama.V2_0_0_0, //nolint:nosnakecase
+		SchemaCachingEnabled:        true,
+		SchemaLatestVersionCacheTTL: defaultSchemaLatestVersionCacheTTL,
+		CredentialsRefreshInterval:  defaultCredentialsRefreshInterval,
 	}
 
 	err := metadata.DecodeMetadata(meta, &m)
@@ -143,6 +180,11 @@ func (k *Kafka) getKafkaMetadata(meta map[string]string) (*KafkaMetadata, error)
 
 	k.logger.Debugf("Found brokers: %v", m.internalBrokers)
 
+	m.internalClusters, m.internalTopicClusters, err = parseClusters(meta, m.TopicClusters)
+	if err != nil {
+		return nil, err
+	}
+
 	if val, ok := meta[caCert]; ok && val != "" {
 		if !isValidPEM(val) {
 			return nil, errors.New("kafka error: invalid ca certificate")
@@ -156,11 +198,11 @@ func (k *Kafka) getKafkaMetadata(meta map[string]string) (*KafkaMetadata, error)
 
 	switch strings.ToLower(m.AuthType) {
 	case passwordAuthType:
-		if m.SaslUsername == "" {
+		if m.SaslUsername == "" && m.SaslUsernamePath == "" {
 			return nil, errors.New("kafka error: missing SASL Username for authType 'password'")
 		}
 
-		if m.SaslPassword == "" {
+		if m.SaslPassword == "" && m.SaslPasswordPath == "" {
 			return nil, errors.New("kafka error: missing SASL Password for authType 'password'")
 		}
 		k.logger.Debug("Configuring SASL password authentication.")
@@ -196,6 +238,12 @@ func (k *Kafka) getKafkaMetadata(meta map[string]string) (*KafkaMetadata, error)
 		if (m.TLSClientKey == "") != (m.TLSClientCert == "") {
 			return nil, errors.New("kafka error: clientKey or clientCert is missing")
 		}
+		if (m.ClientCertPath == "") != (m.ClientKeyPath == "") {
+			return nil, errors.New("kafka error: clientKeyPath or clientCertPath is missing")
+		}
+		if m.ClientCertPath != "" && m.TLSClientCert != "" {
+			return nil, errors.New("kafka error: clientCert and clientCertPath cannot be set together")
+		}
 		k.logger.Debug("Configuring mTLS authentication.")
 	case noAuthType:
 		k.logger.Debug("No authentication configured.")
@@ -208,6 +256,24 @@ func (k *Kafka) getKafkaMetadata(meta map[string]string) (*KafkaMetadata, error)
 		return nil, errors.New("kafka error: invalid value for 'authType' attribute")
 	}
 
+	m.internalClusterSasl, err = parseClusterSasl(meta, m.internalClusters)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.internalClusterSasl) > 0 && !strings.EqualFold(m.AuthType, passwordAuthType) {
+		return nil, errors.New("kafka error: SASL credentials of named clusters can only be set with authType 'password'")
+	}
+
+	if m.ClientCertPath != "" && !strings.EqualFold(m.AuthType, mtlsAuthType) {
+		return nil, errors.New("kafka error: clientCertPath can only be set with authType 'mtls'")
+	}
+	if (m.SaslUsernamePath != "" || m.SaslPasswordPath != "") && !strings.EqualFold(m.AuthType, passwordAuthType) {
+		return nil, errors.New("kafka error: saslUsernamePath and saslPasswordPath can only be set with authType 'password'")
+	}
+	if m.CredentialsRefreshInterval < 0 {
+		return nil, errors.New("kafka error: invalid value for 'credentialsRefreshInterval' attribute")
+	}
+
 	if m.TLSDisable {
 		k.logger.Info("kafka: TLS connectivity to broker disabled")
 	}
@@ -229,6 +295,21 @@ func (k *Kafka) getKafkaMetadata(meta map[string]string) (*KafkaMetadata, error)
 		}
 	}
 
+	m.internalCompression, err = pubsub.ParseCompression(meta)
+	if err != nil {
+		return nil, fmt.Errorf("kafka error: %w", err)
+	}
+
+	if m.SchemaRegistryURL != "" {
+		_, err = url.Parse(m.SchemaRegistryURL)
+		if err != nil {
+			return nil, fmt.Errorf("kafka error: invalid value for 'schemaRegistryURL' attribute: %w", err)
+		}
+		if (m.SchemaRegistryAPIKey == "") != (m.SchemaRegistryAPISecret == "") {
+			return nil, errors.New("kafka error: schemaRegistryAPIKey and schemaRegistryAPISecret must be set together")
+		}
+	}
+
 	if m.Version != "" {
 		version, err := sarama.ParseKafkaVersion(m.Version)
 		if err != nil {
@@ -239,3 +320,80 @@ func (k *Kafka) getKafkaMetadata(meta map[string]string) (*KafkaMetadata, error)
 
 	return &m, nil
 }
+
+// parseClusters parses the named clusters and the routing of topics to clusters.
+func parseClusters(meta map[string]string, topicClusters string) (map[string][]string, map[string]string, error) {
+	clusters := map[string][]string{}
+	for key, val := range meta {
+		if !strings.HasPrefix(key, clustersPrefix) || !strings.HasSuffix(key, clusterBrokersSuffix) {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(key, clustersPrefix), clusterBrokersSuffix)
+		if name == "" || val == "" {
+			return nil, nil, fmt.Errorf("kafka error: invalid cluster definition '%s'", key)
+		}
+		clusters[name] = strings.Split(val, ",")
+	}
+
+	routes := map[string]string{}
+	for _, route := range strings.Split(topicClusters, ",") {
+		route = strings.TrimSpace(route)
+		if route == "" {
+			continue
+		}
+		topic, cluster, ok := strings.Cut(route, "=")
+		topic = strings.TrimSpace(topic)
+		cluster = strings.TrimSpace(cluster)
+		if !ok || topic == "" || cluster == "" {
+			return nil, nil, fmt.Errorf("kafka error: invalid value for 'topicClusters' attribute: '%s'", route)
+		}
+		if _, ok := clusters[cluster]; !ok {
+			return nil, nil, fmt.Errorf("kafka error: topic '%s' is routed to undefined cluster '%s'", topic, cluster)
+		}
+		routes[topic] = cluster
+	}
+
+	return clusters, routes, nil
+}
+
+// parseClusterSasl parses the SASL credentials that override the ones of the component for named clusters.
+func parseClusterSasl(meta map[string]string, clusters map[string][]string) (map[string]clusterSasl, error) {
+	suffixes := []string{clusterSaslUsernameSuffix, clusterSaslPasswordSuffix, clusterSaslMechanismSuffix, clusterSaslUsernamePathSuffix, clusterSaslPasswordPathSuffix}
+	res := map[string]clusterSasl{}
+	for key, val := range meta {
+		if !strings.HasPrefix(key, clustersPrefix) || val == "" {
+			continue
+		}
+		for _, suffix := range suffixes {
+			if !strings.HasSuffix(key, suffix) {
+				continue
+			}
+			name := strings.TrimSuffix(strings.TrimPrefix(key, clustersPrefix), suffix)
+			if _, ok := clusters[name]; !ok {
+				return nil, fmt.Errorf("kafka error: '%s' is set for undefined cluster '%s'", key, name)
+			}
+			c := res[name]
+			switch suffix {
+			case clusterSaslUsernameSuffix:
+				c.Username = val
+			case clusterSaslPasswordSuffix:
+				c.Password = val
+			case clusterSaslMechanismSuffix:
+				c.Mechanism = val
+			case clusterSaslUsernamePathSuffix:
+				c.UsernamePath = val
+			case clusterSaslPasswordPathSuffix:
+				c.PasswordPath = val
+			}
+			res[name] = c
+		}
+	}
+
+	for name, c := range res {
+		if (c.Username == "" && c.UsernamePath == "") || (c.Password == "" && c.PasswordPath == "") {
+			return nil, fmt.Errorf("kafka error: SASL username and password of cluster '%s' must be set together", name)
+		}
+	}
+
+	return res, nil
+}
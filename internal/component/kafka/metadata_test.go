@@ -360,3 +360,77 @@ func TestTls(t *testing.T) {
 		require.Equal(t, "missing CA certificate property 'caCert' for authType 'certificate'", err.Error())
 	})
 }
+
+func TestClusters(t *testing.T) {
+	k := getKafka()
+
+	t.Run("named clusters and topic routing", func(t *testing.T) {
+		m := getBaseMetadata()
+		m["clusters.west.brokers"] = "w1:9092,w2:9092"
+		m["clusters.east.brokers"] = "e1:9092"
+		m["topicClusters"] = "orders=west, payments = east"
+		meta, err := k.getKafkaMetadata(m)
+		require.NoError(t, err)
+		require.Equal(t, map[string][]string{
+			"west": {"w1:9092", "w2:9092"},
+			"east": {"e1:9092"},
+		}, meta.internalClusters)
+		require.Equal(t, map[string]string{"orders": "west", "payments": "east"}, meta.internalTopicClusters)
+	})
+
+	t.Run("topic routed to undefined cluster", func(t *testing.T) {
+		m := getBaseMetadata()
+		m["topicClusters"] = "orders=west"
+		_, err := k.getKafkaMetadata(m)
+		require.ErrorContains(t, err, "undefined cluster 'west'")
+	})
+
+	t.Run("invalid topic route", func(t *testing.T) {
+		m := getBaseMetadata()
+		m["clusters.west.brokers"] = "w1:9092"
+		m["topicClusters"] = "orders"
+		_, err := k.getKafkaMetadata(m)
+		require.Error(t, err)
+	})
+
+	t.Run("empty cluster brokers", func(t *testing.T) {
+		m := getBaseMetadata()
+		m["clusters.west.brokers"] = ""
+		_, err := k.getKafkaMetadata(m)
+		require.Error(t, err)
+	})
+}
+
+func TestSchemaRegistryMetadata(t *testing.T) {
+	k := getKafka()
+
+	t.Run("defaults", func(t *testing.T) {
+		meta, err := k.getKafkaMetadata(getBaseMetadata())
+		require.NoError(t, err)
+		require.Empty(t, meta.SchemaRegistryURL)
+		require.True(t, meta.SchemaCachingEnabled)
+		require.Equal(t, 5*time.Minute, meta.SchemaLatestVersionCacheTTL)
+	})
+
+	t.Run("custom values", func(t *testing.T) {
+		m := getBaseMetadata()
+		m["schemaRegistryURL"] = "http://localhost:8081"
+		m["schemaRegistryAPIKey"] = "key"
+		m["schemaRegistryAPISecret"] = "secret"
+		m["schemaCachingEnabled"] = "false"
+		m["schemaLatestVersionCacheTTL"] = "30s"
+		meta, err := k.getKafkaMetadata(m)
+		require.NoError(t, err)
+		require.Equal(t, "http://localhost:8081", meta.SchemaRegistryURL)
+		require.False(t, meta.SchemaCachingEnabled)
+		require.Equal(t, 30*time.Second, meta.SchemaLatestVersionCacheTTL)
+	})
+
+	t.Run("API key without secret", func(t *testing.T) {
+		m := getBaseMetadata()
+		m["schemaRegistryURL"] = "http://localhost:8081"
+		m["schemaRegistryAPIKey"] = "key"
+		_, err := k.getKafkaMetadata(m)
+		require.ErrorContains(t, err, "must be set together")
+	})
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+// pausedTopic is the set of paused partitions of a topic.
+type pausedTopic struct {
+	// If true, all the partitions of the topic are paused.
+	all        bool
+	partitions map[int32]struct{}
+}
+
+// Pause stops fetching the messages of a topic, or of some of its partitions, without leaving the consumer groups.
+// Partitions stay paused across rebalances and restarts of the consumers, until they are resumed.
+func (k *Kafka) Pause(_ context.Context, req pubsub.PauseRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	k.pauseLock.Lock()
+	if k.paused == nil {
+		k.paused = make(map[string]*pausedTopic)
+	}
+	pt, ok := k.paused[req.Topic]
+	if !ok {
+		pt = &pausedTopic{partitions: make(map[int32]struct{})}
+		k.paused[req.Topic] = pt
+	}
+	if len(req.Partitions) == 0 {
+		pt.all = true
+		pt.partitions = make(map[int32]struct{})
+	} else if !pt.all {
+		for _, p := range req.Partitions {
+			pt.partitions[p] = struct{}{}
+		}
+	}
+	k.pauseLock.Unlock()
+
+	k.subscribeLock.Lock()
+	for _, c := range k.consumers {
+		c.pauseClaims(req.Topic, req.Partitions, true)
+	}
+	k.subscribeLock.Unlock()
+
+	k.logger.Infof("Paused consumption of topic %s, partitions %v", req.Topic, partitionsString(req.Partitions))
+	return nil
+}
+
+// Resume fetches again the messages of a topic, or of some of its partitions, that were paused.
+// Some partitions can't be resumed if all the partitions of the topic are paused.
+func (k *Kafka) Resume(_ context.Context, req pubsub.PauseRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	k.pauseLock.Lock()
+	pt, ok := k.paused[req.Topic]
+	if ok {
+		if len(req.Partitions) == 0 {
+			delete(k.paused, req.Topic)
+		} else if pt.all {
+			k.pauseLock.Unlock()
+			return fmt.Errorf("kafka: all the partitions of topic %s are paused, and they can only be resumed together", req.Topic)
+		} else {
+			for _, p := range req.Partitions {
+				delete(pt.partitions, p)
+			}
+			if len(pt.partitions) == 0 {
+				delete(k.paused, req.Topic)
+			}
+		}
+	}
+	k.pauseLock.Unlock()
+
+	k.subscribeLock.Lock()
+	for _, c := range k.consumers {
+		c.pauseClaims(req.Topic, req.Partitions, false)
+	}
+	k.subscribeLock.Unlock()
+
+	k.logger.Infof("Resumed consumption of topic %s, partitions %v", req.Topic, partitionsString(req.Partitions))
+	return nil
+}
+
+// isPaused returns true if a partition of a topic is paused.
+func (k *Kafka) isPaused(topic string, partition int32) bool {
+	k.pauseLock.Lock()
+	defer k.pauseLock.Unlock()
+
+	pt, ok := k.paused[topic]
+	if !ok {
+		return false
+	}
+	if pt.all {
+		return true
+	}
+	_, ok = pt.partitions[partition]
+	return ok
+}
+
+// addClaim records a partition claimed by the consumer, and pauses it if it's paused.
+// Sarama creates the consumers of the partitions after each rebalance, so they must be paused again.
+func (consumer *consumer) addClaim(topic string, partition int32) {
+	consumer.claimsLock.Lock()
+	if consumer.claims == nil {
+		consumer.claims = make(map[string]map[int32]struct{})
+	}
+	if consumer.claims[topic] == nil {
+		consumer.claims[topic] = make(map[int32]struct{})
+	}
+	consumer.claims[topic][partition] = struct{}{}
+	consumer.claimsLock.Unlock()
+
+	if consumer.k.isPaused(topic, partition) {
+		consumer.cg.Pause(map[string][]int32{topic: {partition}})
+	}
+}
+
+// removeClaim removes a partition that isn't claimed by the consumer anymore.
+func (consumer *consumer) removeClaim(topic string, partition int32) {
+	consumer.claimsLock.Lock()
+	defer consumer.claimsLock.Unlock()
+
+	delete(consumer.claims[topic], partition)
+	if len(consumer.claims[topic]) == 0 {
+		delete(consumer.claims, topic)
+	}
+}
+
+// pauseClaims pauses or resumes the partitions of a topic claimed by the consumer; if partitions is empty, all the claimed partitions of the topic are paused or resumed.
+func (consumer *consumer) pauseClaims(topic string, partitions []int32, pause bool) {
+	consumer.claimsLock.Lock()
+	defer consumer.claimsLock.Unlock()
+
+	claimed := consumer.claims[topic]
+	selected := make([]int32, 0, len(claimed))
+	if len(partitions) == 0 {
+		for p := range claimed {
+			selected = append(selected, p)
+		}
+	} else {
+		for _, p := range partitions {
+			if _, ok := claimed[p]; ok {
+				selected = append(selected, p)
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return
+	}
+
+	if pause {
+		consumer.cg.Pause(map[string][]int32{topic: selected})
+	} else {
+		consumer.cg.Resume(map[string][]int32{topic: selected})
+	}
+}
+
+func partitionsString(partitions []int32) string {
+	if len(partitions) == 0 {
+		return "(all)"
+	}
+	return fmt.Sprint(partitions)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+// pauseRecorder is a consumer group that records the partitions paused.
+type pauseRecorder struct {
+	sarama.ConsumerGroup
+	paused map[string][]int32
+}
+
+func (r *pauseRecorder) Pause(partitions map[string][]int32) {
+	for topic, ps := range partitions {
+		r.paused[topic] = append(r.paused[topic], ps...)
+		sort.Slice(r.paused[topic], func(i, j int) bool { return r.paused[topic][i] < r.paused[topic][j] })
+	}
+}
+
+func (r *pauseRecorder) Resume(partitions map[string][]int32) {
+	for topic, ps := range partitions {
+		for _, p := range ps {
+			for i, pp := range r.paused[topic] {
+				if pp == p {
+					r.paused[topic] = append(r.paused[topic][:i], r.paused[topic][i+1:]...)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	k := getKafka()
+	cg := &pauseRecorder{paused: map[string][]int32{}}
+	c := &consumer{k: k, cg: cg}
+	k.consumers = []*consumer{c}
+	c.addClaim("a", 0)
+	c.addClaim("a", 1)
+	c.addClaim("b", 0)
+
+	t.Run("invalid request", func(t *testing.T) {
+		assert.Error(t, k.Pause(context.Background(), pubsub.PauseRequest{}))
+		assert.Error(t, k.Resume(context.Background(), pubsub.PauseRequest{Topic: "a", Partitions: []int32{-1}}))
+	})
+
+	t.Run("pause and resume some partitions", func(t *testing.T) {
+		require.NoError(t, k.Pause(context.Background(), pubsub.PauseRequest{Topic: "a", Partitions: []int32{1, 5}}))
+		assert.Equal(t, []int32{1}, cg.paused["a"])
+		assert.True(t, k.isPaused("a", 1))
+		assert.True(t, k.isPaused("a", 5))
+		assert.False(t, k.isPaused("a", 0))
+
+		require.NoError(t, k.Resume(context.Background(), pubsub.PauseRequest{Topic: "a", Partitions: []int32{1, 5}}))
+		assert.Empty(t, cg.paused["a"])
+		assert.False(t, k.isPaused("a", 1))
+	})
+
+	t.Run("pause all partitions", func(t *testing.T) {
+		require.NoError(t, k.Pause(context.Background(), pubsub.PauseRequest{Topic: "a"}))
+		assert.Equal(t, []int32{0, 1}, cg.paused["a"])
+		assert.Empty(t, cg.paused["b"])
+
+		// Partitions claimed after a rebalance are paused again
+		c.removeClaim("a", 1)
+		c.addClaim("a", 2)
+		assert.Equal(t, []int32{0, 1, 2}, cg.paused["a"])
+
+		// Partitions can't be resumed one by one
+		assert.Error(t, k.Resume(context.Background(), pubsub.PauseRequest{Topic: "a", Partitions: []int32{0}}))
+
+		// Partition 1 isn't claimed anymore, so it's not resumed
+		require.NoError(t, k.Resume(context.Background(), pubsub.PauseRequest{Topic: "a"}))
+		assert.Equal(t, []int32{1}, cg.paused["a"])
+		assert.False(t, k.isPaused("a", 2))
+	})
+}
@@ -16,6 +16,8 @@ package kafka
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/Shopify/sarama"
 
@@ -40,23 +42,98 @@ func getSyncProducer(config sarama.Config, brokers []string, maxMessageBytes int
 	return producer, nil
 }
 
+// newProducers creates the producers of the default cluster and of the named clusters.
+// The producers of the named clusters use the configuration in clusterConfigs if present, or the one of the default cluster otherwise.
+func newProducers(config *sarama.Config, clusterConfigs map[string]*sarama.Config, brokers []string, clusters map[string][]string, maxMessageBytes int) (sarama.SyncProducer, map[string]sarama.SyncProducer, error) {
+	producer, err := getSyncProducer(*config, brokers, maxMessageBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clusterProducers := make(map[string]sarama.SyncProducer, len(clusters))
+	for name, clusterBrokers := range clusters {
+		clusterConfig, ok := clusterConfigs[name]
+		if !ok {
+			clusterConfig = config
+		}
+		clusterProducers[name], err = getSyncProducer(*clusterConfig, clusterBrokers, maxMessageBytes)
+		if err != nil {
+			closeProducers(producer, clusterProducers)
+			return nil, nil, fmt.Errorf("kafka error: failed to create producer for cluster %s: %w", name, err)
+		}
+	}
+
+	return producer, clusterProducers, nil
+}
+
+// closeProducers closes the producers, returning the errors.
+func closeProducers(producer sarama.SyncProducer, clusterProducers map[string]sarama.SyncProducer) []error {
+	var errs []error
+	if producer != nil {
+		if err := producer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, p := range clusterProducers {
+		if p == nil {
+			continue
+		}
+		if err := p.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// producerForTopic returns the producer of the cluster the topic is routed to.
+// It must be called while holding producerLock.
+func (k *Kafka) producerForTopic(topic string) (sarama.SyncProducer, error) {
+	producer := k.producer
+	if cluster, ok := k.topicClusters[topic]; ok {
+		producer = k.clusterProducers[cluster]
+	}
+	if producer == nil {
+		return nil, errors.New("component is closed")
+	}
+	return producer, nil
+}
+
 // Publish message to Kafka cluster.
-func (k *Kafka) Publish(_ context.Context, topic string, data []byte, metadata map[string]string) error {
-	if k.producer == nil {
-		return errors.New("component is closed")
+func (k *Kafka) Publish(ctx context.Context, topic string, data []byte, metadata map[string]string) error {
+	k.producerLock.RLock()
+	defer k.producerLock.RUnlock()
+
+	producer, err := k.producerForTopic(topic)
+	if err != nil {
+		return err
 	}
 	// k.logger.Debugf("Publishing topic %v with data: %v", topic, string(data))
 	k.logger.Debugf("Publishing on topic %v", topic)
 
+	schemaType, err := ParseSchemaType(metadata[ValueSchemaTypeMetadataKey])
+	if err != nil {
+		return err
+	}
+	data, err = k.serializeValue(ctx, topic, data, schemaType)
+	if err != nil {
+		return fmt.Errorf("failed to serialize message: %w", err)
+	}
+
+	data, err = k.compression.Compress(data)
+	if err != nil {
+		return fmt.Errorf("failed to compress message: %w", err)
+	}
+
 	msg := &sarama.ProducerMessage{
 		Topic: topic,
 		Value: sarama.ByteEncoder(data),
 	}
+	k.addContentEncodingHeader(msg)
 
 	for name, value := range metadata {
 		if name == key {
 			msg.Key = sarama.StringEncoder(value)
-		} else {
+		} else if name != ValueSchemaTypeMetadataKey {
 			if msg.Headers == nil {
 				msg.Headers = make([]sarama.RecordHeader, 0, len(metadata))
 			}
@@ -67,7 +144,9 @@ func (k *Kafka) Publish(_ context.Context, topic string, data []byte, metadata m
 		}
 	}
 
-	partition, offset, err := k.producer.SendMessage(msg)
+	start := time.Now()
+	partition, offset, err := producer.SendMessage(msg)
+	k.Metrics.Published(topic, err, start)
 
 	k.logger.Debugf("Partition: %v, offset: %v", partition, offset)
 
@@ -78,19 +157,38 @@ func (k *Kafka) Publish(_ context.Context, topic string, data []byte, metadata m
 	return nil
 }
 
-func (k *Kafka) BulkPublish(_ context.Context, topic string, entries []pubsub.BulkMessageEntry, metadata map[string]string) (pubsub.BulkPublishResponse, error) {
-	if k.producer == nil {
-		err := errors.New("component is closed")
+func (k *Kafka) BulkPublish(ctx context.Context, topic string, entries []pubsub.BulkMessageEntry, metadata map[string]string) (pubsub.BulkPublishResponse, error) {
+	k.producerLock.RLock()
+	defer k.producerLock.RUnlock()
+
+	producer, err := k.producerForTopic(topic)
+	if err != nil {
 		return pubsub.NewBulkPublishResponse(entries, err), err
 	}
 	k.logger.Debugf("Bulk Publishing on topic %v", topic)
 
+	schemaType, err := ParseSchemaType(metadata[ValueSchemaTypeMetadataKey])
+	if err != nil {
+		return pubsub.NewBulkPublishResponse(entries, err), err
+	}
+
 	msgs := []*sarama.ProducerMessage{}
 	for _, entry := range entries {
+		data, err := k.serializeValue(ctx, topic, entry.Event, schemaType)
+		if err != nil {
+			err = fmt.Errorf("failed to serialize message: %w", err)
+			return pubsub.NewBulkPublishResponse(entries, err), err
+		}
+		data, err = k.compression.Compress(data)
+		if err != nil {
+			err = fmt.Errorf("failed to compress message: %w", err)
+			return pubsub.NewBulkPublishResponse(entries, err), err
+		}
 		msg := &sarama.ProducerMessage{
 			Topic: topic,
-			Value: sarama.ByteEncoder(entry.Event),
+			Value: sarama.ByteEncoder(data),
 		}
+		k.addContentEncodingHeader(msg)
 		// From Sarama documentation
 		// This field is used to hold arbitrary data you wish to include so it
 		// will be available when receiving on the Successes and Errors channels.
@@ -102,9 +200,14 @@ func (k *Kafka) BulkPublish(_ context.Context, topic string, entries []pubsub.Bu
 		msg.Metadata = entry.EntryId
 
 		for name, value := range metadata {
-			if name == key {
+			switch name {
+			case key:
 				msg.Key = sarama.StringEncoder(value)
-			} else {
+			case pubsub.TraceParentField, pubsub.TraceStateField:
+				// Added below, as the entries can have their own trace context
+			case ValueSchemaTypeMetadataKey:
+				// Not sent as a header
+			default:
 				if msg.Headers == nil {
 					msg.Headers = make([]sarama.RecordHeader, 0, len(metadata))
 				}
@@ -114,17 +217,38 @@ func (k *Kafka) BulkPublish(_ context.Context, topic string, entries []pubsub.Bu
 				})
 			}
 		}
+		for name, value := range pubsub.TraceContextFromMetadata(metadata, entry.Metadata) {
+			msg.Headers = append(msg.Headers, sarama.RecordHeader{
+				Key:   []byte(name),
+				Value: []byte(value),
+			})
+		}
 		msgs = append(msgs, msg)
 	}
 
-	if err := k.producer.SendMessages(msgs); err != nil {
+	start := time.Now()
+	if err := producer.SendMessages(msgs); err != nil {
 		// map the returned error to different entries
-		return k.mapKafkaProducerErrors(err, entries), err
+		res := k.mapKafkaProducerErrors(err, entries)
+		k.Metrics.BulkPublished(topic, entries, res, start)
+		return res, err
 	}
 
+	k.Metrics.BulkPublished(topic, entries, pubsub.BulkPublishResponse{}, start)
 	return pubsub.BulkPublishResponse{}, nil
 }
 
+// addContentEncodingHeader adds the header with the algorithm the payload is compressed with, if compression is enabled.
+func (k *Kafka) addContentEncodingHeader(msg *sarama.ProducerMessage) {
+	if k.compression == pubsub.CompressionNone {
+		return
+	}
+	msg.Headers = append(msg.Headers, sarama.RecordHeader{
+		Key:   []byte(pubsub.ContentEncodingKey),
+		Value: []byte(k.compression),
+	})
+}
+
 // mapKafkaProducerErrors to correct response statuses
 func (k *Kafka) mapKafkaProducerErrors(err error, entries []pubsub.BulkMessageEntry) pubsub.BulkPublishResponse {
 	var pErrs sarama.ProducerErrors
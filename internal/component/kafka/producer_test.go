@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+func TestBulkPublishTraceContext(t *testing.T) {
+	const (
+		requestTraceParent = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+		entryTraceParent   = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	)
+
+	k := getKafka()
+	producer := mocks.NewSyncProducer(t, nil)
+	k.producer = producer
+
+	headersOf := func(msg *sarama.ProducerMessage) map[string]string {
+		headers := map[string]string{}
+		for _, h := range msg.Headers {
+			headers[string(h.Key)] = string(h.Value)
+		}
+		return headers
+	}
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, map[string]string{
+			"source":      "app",
+			"traceparent": requestTraceParent,
+			"tracestate":  "congo=t61rcWkgMzE",
+		}, headersOf(msg))
+		return nil
+	})
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, map[string]string{
+			"source":      "app",
+			"traceparent": entryTraceParent,
+		}, headersOf(msg))
+		return nil
+	})
+
+	_, err := k.BulkPublish(context.Background(), "orders", []pubsub.BulkMessageEntry{
+		{EntryId: "1", Event: []byte("a")},
+		{EntryId: "2", Event: []byte("b"), Metadata: map[string]string{"traceparent": entryTraceParent}},
+	}, map[string]string{
+		"source":      "app",
+		"traceparent": requestTraceParent,
+		"tracestate":  "congo=t61rcWkgMzE",
+	})
+	require.NoError(t, err)
+	assert.NoError(t, producer.Close())
+}
+
+func TestPublishCompression(t *testing.T) {
+	k := getKafka()
+	k.compression = pubsub.CompressionGzip
+	producer := mocks.NewSyncProducer(t, nil)
+	k.producer = producer
+
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		var encoding string
+		for _, h := range msg.Headers {
+			if string(h.Key) == pubsub.ContentEncodingKey {
+				encoding = string(h.Value)
+			}
+		}
+		assert.Equal(t, "gzip", encoding)
+
+		data, err := msg.Value.Encode()
+		require.NoError(t, err)
+		decompressed, err := pubsub.Decompress(encoding, data)
+		require.NoError(t, err)
+		assert.Equal(t, `{"hello":"world"}`, string(decompressed))
+		return nil
+	})
+
+	err := k.Publish(context.Background(), "orders", []byte(`{"hello":"world"}`), nil)
+	require.NoError(t, err)
+	assert.NoError(t, producer.Close())
+}
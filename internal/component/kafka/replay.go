@@ -0,0 +1,130 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+// Replay resets the committed offsets of a consumer group on all the partitions of a topic.
+// Kafka doesn't accept offset commits from outside of a consumer group while it has active members, so the consumers of this component are stopped while the offsets are reset, then restarted.
+// Consumers in other processes must be stopped too.
+func (k *Kafka) Replay(_ context.Context, req pubsub.ReplayRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	k.subscribeLock.Lock()
+	defer k.subscribeLock.Unlock()
+
+	group := req.ConsumerGroup
+	if group == "" {
+		group = k.subscribeTopics[req.Topic].ConsumerGroup
+	}
+	if group == "" {
+		group = k.consumerGroup
+	}
+	if group == "" {
+		return errors.New("kafka: consumerGroup must be set to replay messages")
+	}
+
+	restart := len(k.consumers) > 0
+	k.closeSubscriptionResources()
+
+	err := k.resetOffsets(req, group)
+	if restart {
+		if serr := k.subscribe(k.subscribeCtx); serr != nil {
+			err = errors.Join(err, fmt.Errorf("kafka: failed to restart consumers: %w", serr))
+		}
+	}
+
+	return err
+}
+
+func (k *Kafka) resetOffsets(req pubsub.ReplayRequest, group string) error {
+	cluster := k.topicClusters[req.Topic]
+	client, err := sarama.NewClient(k.brokersForCluster(cluster), k.configForCluster(cluster))
+	if err != nil {
+		return fmt.Errorf("kafka: failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	partitions, err := client.Partitions(req.Topic)
+	if err != nil {
+		return fmt.Errorf("kafka: failed to get partitions of topic %s: %w", req.Topic, err)
+	}
+
+	commitReq := &sarama.OffsetCommitRequest{
+		Version:                 1,
+		ConsumerGroup:           group,
+		ConsumerGroupGeneration: sarama.GroupGenerationUndefined,
+	}
+	for _, partition := range partitions {
+		offset, err := replayOffset(client, req, partition)
+		if err != nil {
+			return err
+		}
+		commitReq.AddBlock(req.Topic, partition, offset, 0, sarama.ReceiveTime, "")
+	}
+
+	coordinator, err := client.Coordinator(group)
+	if err != nil {
+		return fmt.Errorf("kafka: failed to get coordinator of consumer group %s: %w", group, err)
+	}
+	res, err := coordinator.CommitOffset(commitReq)
+	if err != nil {
+		return fmt.Errorf("kafka: failed to commit offsets of consumer group %s: %w", group, err)
+	}
+	for _, partitionErrs := range res.Errors {
+		for partition, kerr := range partitionErrs {
+			if kerr != sarama.ErrNoError {
+				return fmt.Errorf("kafka: failed to commit offset of partition %d of topic %s for consumer group %s: %w", partition, req.Topic, group, kerr)
+			}
+		}
+	}
+
+	k.logger.Infof("Reset offsets of consumer group %s on topic %s", group, req.Topic)
+	return nil
+}
+
+// replayOffset returns the offset to reset a partition to.
+func replayOffset(client sarama.Client, req pubsub.ReplayRequest, partition int32) (int64, error) {
+	switch {
+	case !req.Timestamp.IsZero():
+		offset, err := client.GetOffset(req.Topic, partition, req.Timestamp.UnixMilli())
+		if err != nil {
+			return 0, fmt.Errorf("kafka: failed to get offset of partition %d at %s: %w", partition, req.Timestamp, err)
+		}
+		// There are no messages after the timestamp
+		if offset == sarama.OffsetNewest {
+			return client.GetOffset(req.Topic, partition, sarama.OffsetNewest)
+		}
+		return offset, nil
+	case req.Offset == pubsub.ReplayOffsetEarliest:
+		return client.GetOffset(req.Topic, partition, sarama.OffsetOldest)
+	default:
+		offset, err := strconv.ParseInt(req.Offset, 10, 64)
+		if err != nil || offset < 0 {
+			return 0, fmt.Errorf("kafka: invalid offset '%s'", req.Offset)
+		}
+		return offset, nil
+	}
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+func TestReplayOffset(t *testing.T) {
+	offset, err := replayOffset(nil, pubsub.ReplayRequest{Topic: "t", Offset: "42"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), offset)
+
+	_, err = replayOffset(nil, pubsub.ReplayRequest{Topic: "t", Offset: "abc"}, 0)
+	assert.Error(t, err)
+	_, err = replayOffset(nil, pubsub.ReplayRequest{Topic: "t", Offset: "-1"}, 0)
+	assert.Error(t, err)
+}
+
+func TestReplayValidation(t *testing.T) {
+	k := getKafka()
+	k.subscribeTopics = TopicHandlerConfig{}
+
+	err := k.Replay(context.Background(), pubsub.ReplayRequest{Topic: "t"})
+	assert.Error(t, err)
+
+	err = k.Replay(context.Background(), pubsub.ReplayRequest{Topic: "t", Offset: "1"})
+	assert.ErrorContains(t, err, "consumerGroup must be set")
+}
@@ -20,6 +20,7 @@ import (
 	"encoding/pem"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/Shopify/sarama"
@@ -37,14 +38,18 @@ type OAuthTokenSource struct {
 	httpClient    *http.Client
 	trustedCas    []*x509.Certificate
 	skipCaVerify  bool
+	lock          sync.Mutex
 }
 
-func newOAuthTokenSource(oidcTokenEndpoint, oidcClientID, oidcClientSecret string, oidcScopes []string) OAuthTokenSource {
-	return OAuthTokenSource{TokenEndpoint: oauth2.Endpoint{TokenURL: oidcTokenEndpoint}, ClientID: oidcClientID, ClientSecret: oidcClientSecret, Scopes: oidcScopes}
+func newOAuthTokenSource(oidcTokenEndpoint, oidcClientID, oidcClientSecret string, oidcScopes []string) *OAuthTokenSource {
+	return &OAuthTokenSource{TokenEndpoint: oauth2.Endpoint{TokenURL: oidcTokenEndpoint}, ClientID: oidcClientID, ClientSecret: oidcClientSecret, Scopes: oidcScopes}
 }
 
 var tokenRequestTimeout, _ = time.ParseDuration("30s")
 
+// Tokens are refreshed when they are this close to expiring, so connections that (re-)authenticate don't present a token that expires shortly after.
+var tokenRefreshMargin = time.Minute
+
 func (ts *OAuthTokenSource) addCa(caPem string) error {
 	pemBytes := []byte(caPem)
 
@@ -96,8 +101,13 @@ func (ts *OAuthTokenSource) configureClient() {
 	}
 }
 
+// Token returns the cached token, or requests a new one from the token endpoint if the cached one is about to expire.
+// It is invoked by sarama every time a connection to a broker authenticates, including re-authentications, and is safe for concurrent use.
 func (ts *OAuthTokenSource) Token() (*sarama.AccessToken, error) {
-	if ts.CachedToken.Valid() {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	if ts.tokenValid() {
 		return ts.asSaramaToken(), nil
 	}
 
@@ -123,6 +133,14 @@ func (ts *OAuthTokenSource) Token() (*sarama.AccessToken, error) {
 	return ts.asSaramaToken(), nil
 }
 
+func (ts *OAuthTokenSource) tokenValid() bool {
+	if !ts.CachedToken.Valid() {
+		return false
+	}
+	// Tokens without an expiration never need to be refreshed
+	return ts.CachedToken.Expiry.IsZero() || time.Until(ts.CachedToken.Expiry) > tokenRefreshMargin
+}
+
 func (ts *OAuthTokenSource) asSaramaToken() *sarama.AccessToken {
 	return &(sarama.AccessToken{Token: ts.CachedToken.AccessToken, Extensions: ts.Extensions})
 }
@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuthTokenSource(t *testing.T) {
+	var (
+		requests  atomic.Int32
+		expiresIn atomic.Int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":%d}`, n, expiresIn.Load())
+	}))
+	defer srv.Close()
+
+	ts := newOAuthTokenSource(srv.URL, "client", "secret", []string{"openid"})
+
+	t.Run("token is cached while valid", func(t *testing.T) {
+		expiresIn.Store(3600)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				token, err := ts.Token()
+				assert.NoError(t, err)
+				assert.Equal(t, "token-1", token.Token)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("token is refreshed before it expires", func(t *testing.T) {
+		// Expires within the refresh margin
+		expiresIn.Store(30)
+		ts.CachedToken.Expiry = time.Now().Add(30 * time.Second)
+
+		token, err := ts.Token()
+		require.NoError(t, err)
+		assert.Equal(t, "token-2", token.Token)
+
+		// The new token is also within the refresh margin, so it is refreshed again
+		token, err = ts.Token()
+		require.NoError(t, err)
+		assert.Equal(t, "token-3", token.Token)
+	})
+}
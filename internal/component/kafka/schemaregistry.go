@@ -0,0 +1,263 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"k8s.io/utils/clock"
+)
+
+// ValueSchemaTypeMetadataKey is the key of the publish and subscribe request metadata that sets the format of the values of the messages.
+const ValueSchemaTypeMetadataKey = "valueSchemaType"
+
+const (
+	defaultSchemaLatestVersionCacheTTL = 5 * time.Minute
+	schemaRegistryRequestTimeout       = 30 * time.Second
+
+	// Values in the Confluent wire format start with a magic byte, followed by the ID of the schema as a 4-byte big-endian integer.
+	schemaRegistryMagicByte    byte = 0
+	schemaRegistryHeaderLength      = 5
+)
+
+// SchemaType is the format of the values of the messages.
+type SchemaType string
+
+const (
+	// SchemaTypeNone means the values are sent as they are.
+	SchemaTypeNone SchemaType = "None"
+	// SchemaTypeAvro means the values are JSON documents encoded to Avro with the latest schema registered for the topic.
+	SchemaTypeAvro SchemaType = "Avro"
+)
+
+// ParseSchemaType parses the value of the valueSchemaType metadata property.
+func ParseSchemaType(val string) (SchemaType, error) {
+	switch strings.ToLower(val) {
+	case "", "none":
+		return SchemaTypeNone, nil
+	case "avro":
+		return SchemaTypeAvro, nil
+	default:
+		return SchemaTypeNone, fmt.Errorf("kafka error: invalid value for '%s': %s", ValueSchemaTypeMetadataKey, val)
+	}
+}
+
+// registeredSchema is an Avro schema retrieved from the registry.
+type registeredSchema struct {
+	id    int
+	codec *goavro.Codec
+}
+
+type latestSchemaEntry struct {
+	schema  *registeredSchema
+	expires time.Time
+}
+
+// schemaRegistry is a client for the REST API of a Confluent Schema Registry.
+// Schemas are immutable, so schemas retrieved by ID are cached forever; the latest version of a subject is cached for a limited time.
+type schemaRegistry struct {
+	url            string
+	apiKey         string
+	apiSecret      string
+	cachingEnabled bool
+	latestTTL      time.Duration
+	client         *http.Client
+	clock          clock.Clock
+
+	lock   sync.Mutex
+	byID   map[int]*registeredSchema
+	latest map[string]latestSchemaEntry
+}
+
+func newSchemaRegistry(meta *KafkaMetadata) *schemaRegistry {
+	return &schemaRegistry{
+		url:            strings.TrimSuffix(meta.SchemaRegistryURL, "/"),
+		apiKey:         meta.SchemaRegistryAPIKey,
+		apiSecret:      meta.SchemaRegistryAPISecret,
+		cachingEnabled: meta.SchemaCachingEnabled,
+		latestTTL:      meta.SchemaLatestVersionCacheTTL,
+		client:         &http.Client{Timeout: schemaRegistryRequestTimeout},
+		clock:          clock.RealClock{},
+		byID:           map[int]*registeredSchema{},
+		latest:         map[string]latestSchemaEntry{},
+	}
+}
+
+// getSchemaByID returns the schema with the given ID.
+func (r *schemaRegistry) getSchemaByID(ctx context.Context, id int) (*registeredSchema, error) {
+	if r.cachingEnabled {
+		r.lock.Lock()
+		schema, ok := r.byID[id]
+		r.lock.Unlock()
+		if ok {
+			return schema, nil
+		}
+	}
+
+	schema, err := r.fetchSchema(ctx, "/schemas/ids/"+strconv.Itoa(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve schema %d: %w", id, err)
+	}
+	schema.id = id
+
+	if r.cachingEnabled {
+		r.lock.Lock()
+		r.byID[id] = schema
+		r.lock.Unlock()
+	}
+	return schema, nil
+}
+
+// getLatestSchema returns the latest version of the schema registered for the subject.
+func (r *schemaRegistry) getLatestSchema(ctx context.Context, subject string) (*registeredSchema, error) {
+	if r.cachingEnabled {
+		r.lock.Lock()
+		entry, ok := r.latest[subject]
+		r.lock.Unlock()
+		if ok && r.clock.Now().Before(entry.expires) {
+			return entry.schema, nil
+		}
+	}
+
+	schema, err := r.fetchSchema(ctx, "/subjects/"+url.PathEscape(subject)+"/versions/latest")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the latest schema for subject %s: %w", subject, err)
+	}
+
+	if r.cachingEnabled {
+		r.lock.Lock()
+		r.latest[subject] = latestSchemaEntry{
+			schema:  schema,
+			expires: r.clock.Now().Add(r.latestTTL),
+		}
+		r.byID[schema.id] = schema
+		r.lock.Unlock()
+	}
+	return schema, nil
+}
+
+func (r *schemaRegistry) fetchSchema(ctx context.Context, path string) (*registeredSchema, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if r.apiKey != "" {
+		req.SetBasicAuth(r.apiKey, r.apiSecret)
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("schema registry returned status code %d: %s", res.StatusCode, string(body))
+	}
+
+	var payload struct {
+		ID         int    `json:"id"`
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType"`
+	}
+	err = json.Unmarshal(body, &payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	// The registry omits the schema type for Avro schemas
+	if payload.SchemaType != "" && !strings.EqualFold(payload.SchemaType, "AVRO") {
+		return nil, fmt.Errorf("unsupported schema type %s", payload.SchemaType)
+	}
+
+	codec, err := goavro.NewCodec(payload.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Avro schema: %w", err)
+	}
+	return &registeredSchema{id: payload.ID, codec: codec}, nil
+}
+
+// serializeValue encodes a JSON value to Avro in the Confluent wire format, using the latest schema registered for the topic's values.
+func (k *Kafka) serializeValue(ctx context.Context, topic string, data []byte, schemaType SchemaType) ([]byte, error) {
+	if schemaType != SchemaTypeAvro {
+		return data, nil
+	}
+	if k.schemaRegistry == nil {
+		return nil, errors.New("kafka error: 'schemaRegistryURL' must be set to use a value schema")
+	}
+
+	// Subjects follow the default TopicNameStrategy
+	schema, err := k.schemaRegistry.getLatestSchema(ctx, topic+"-value")
+	if err != nil {
+		return nil, err
+	}
+
+	native, _, err := schema.codec.NativeFromTextual(data)
+	if err != nil {
+		return nil, fmt.Errorf("value does not match the schema: %w", err)
+	}
+
+	res := make([]byte, schemaRegistryHeaderLength, schemaRegistryHeaderLength+len(data))
+	res[0] = schemaRegistryMagicByte
+	binary.BigEndian.PutUint32(res[1:], uint32(schema.id))
+	res, err = schema.codec.BinaryFromNative(res, native)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode value: %w", err)
+	}
+	return res, nil
+}
+
+// deserializeValue decodes a value in the Confluent wire format to JSON, using the schema whose ID is in the value.
+func (k *Kafka) deserializeValue(ctx context.Context, data []byte, schemaType SchemaType) ([]byte, error) {
+	if schemaType != SchemaTypeAvro {
+		return data, nil
+	}
+	if k.schemaRegistry == nil {
+		return nil, errors.New("kafka error: 'schemaRegistryURL' must be set to use a value schema")
+	}
+
+	if len(data) < schemaRegistryHeaderLength || data[0] != schemaRegistryMagicByte {
+		return nil, errors.New("value is not in the schema registry format")
+	}
+	schema, err := k.schemaRegistry.getSchemaByID(ctx, int(binary.BigEndian.Uint32(data[1:schemaRegistryHeaderLength])))
+	if err != nil {
+		return nil, err
+	}
+
+	native, _, err := schema.codec.NativeFromBinary(data[schemaRegistryHeaderLength:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode value: %w", err)
+	}
+	res, err := schema.codec.TextualFromNative(nil, native)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert value to JSON: %w", err)
+	}
+	return res, nil
+}
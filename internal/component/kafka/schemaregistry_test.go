@@ -0,0 +1,198 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+const testAvroSchema = `{"type":"record","name":"Order","fields":[{"name":"id","type":"long"},{"name":"item","type":"string"}]}`
+
+// newTestSchemaRegistry starts a schema registry that serves testAvroSchema with ID 7 as the latest version of every subject.
+func newTestSchemaRegistry(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if user, pass, ok := r.BasicAuth(); !ok || user != "key" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/subjects/orders-value/versions/latest", "/schemas/ids/7":
+			json.NewEncoder(w).Encode(map[string]any{
+				"id":     7,
+				"schema": testAvroSchema,
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error_code":40401,"message":"Subject not found."}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &requests
+}
+
+func getSchemaRegistryKafka(url string) *Kafka {
+	k := getKafka()
+	k.schemaRegistry = newSchemaRegistry(&KafkaMetadata{
+		SchemaRegistryURL:           url,
+		SchemaRegistryAPIKey:        "key",
+		SchemaRegistryAPISecret:     "secret",
+		SchemaCachingEnabled:        true,
+		SchemaLatestVersionCacheTTL: time.Minute,
+	})
+	return k
+}
+
+func TestParseSchemaType(t *testing.T) {
+	for val, expect := range map[string]SchemaType{
+		"":     SchemaTypeNone,
+		"None": SchemaTypeNone,
+		"Avro": SchemaTypeAvro,
+		"avro": SchemaTypeAvro,
+	} {
+		st, err := ParseSchemaType(val)
+		require.NoError(t, err)
+		assert.Equal(t, expect, st)
+	}
+
+	_, err := ParseSchemaType("protobuf")
+	require.Error(t, err)
+}
+
+func TestSerializeValue(t *testing.T) {
+	srv, _ := newTestSchemaRegistry(t)
+	k := getSchemaRegistryKafka(srv.URL)
+
+	t.Run("round trip", func(t *testing.T) {
+		data, err := k.serializeValue(context.Background(), "orders", []byte(`{"id": 42, "item": "book"}`), SchemaTypeAvro)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0, 0, 0, 0, 7}, data[:5])
+
+		res, err := k.deserializeValue(context.Background(), data, SchemaTypeAvro)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id": 42, "item": "book"}`, string(res))
+	})
+
+	t.Run("no schema type", func(t *testing.T) {
+		data, err := k.serializeValue(context.Background(), "orders", []byte("hello"), SchemaTypeNone)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+
+		data, err = k.deserializeValue(context.Background(), []byte("hello"), SchemaTypeNone)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+	})
+
+	t.Run("value does not match the schema", func(t *testing.T) {
+		_, err := k.serializeValue(context.Background(), "orders", []byte(`{"id": "foo"}`), SchemaTypeAvro)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "value does not match the schema")
+	})
+
+	t.Run("subject not found", func(t *testing.T) {
+		_, err := k.serializeValue(context.Background(), "payments", []byte(`{"id": 1, "item": "book"}`), SchemaTypeAvro)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "status code 404")
+	})
+
+	t.Run("value without schema ID", func(t *testing.T) {
+		_, err := k.deserializeValue(context.Background(), []byte(`{"id": 1}`), SchemaTypeAvro)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not in the schema registry format")
+	})
+
+	t.Run("schema registry not configured", func(t *testing.T) {
+		_, err := getKafka().serializeValue(context.Background(), "orders", []byte(`{}`), SchemaTypeAvro)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "schemaRegistryURL")
+	})
+}
+
+func TestSchemaRegistryCache(t *testing.T) {
+	srv, requests := newTestSchemaRegistry(t)
+	k := getSchemaRegistryKafka(srv.URL)
+	clock := clocktesting.NewFakeClock(time.Now())
+	k.schemaRegistry.clock = clock
+
+	value := []byte(`{"id": 1, "item": "book"}`)
+	data, err := k.serializeValue(context.Background(), "orders", value, SchemaTypeAvro)
+	require.NoError(t, err)
+	_, err = k.serializeValue(context.Background(), "orders", value, SchemaTypeAvro)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), requests.Load())
+
+	// The schema was cached by ID too
+	_, err = k.deserializeValue(context.Background(), data, SchemaTypeAvro)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), requests.Load())
+
+	// The latest version expires
+	clock.Step(2 * time.Minute)
+	_, err = k.serializeValue(context.Background(), "orders", value, SchemaTypeAvro)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+
+	// Caching disabled
+	k.schemaRegistry.cachingEnabled = false
+	_, err = k.deserializeValue(context.Background(), data, SchemaTypeAvro)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestPublishWithSchema(t *testing.T) {
+	srv, _ := newTestSchemaRegistry(t)
+	k := getSchemaRegistryKafka(srv.URL)
+	producer := mocks.NewSyncProducer(t, nil)
+	k.producer = producer
+
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		for _, h := range msg.Headers {
+			assert.NotEqual(t, ValueSchemaTypeMetadataKey, string(h.Key))
+		}
+
+		data, err := msg.Value.Encode()
+		require.NoError(t, err)
+		res, err := k.deserializeValue(context.Background(), data, SchemaTypeAvro)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id": 42, "item": "book"}`, string(res))
+		return nil
+	})
+
+	err := k.Publish(context.Background(), "orders", []byte(`{"id": 42, "item": "book"}`), map[string]string{
+		ValueSchemaTypeMetadataKey: "Avro",
+	})
+	require.NoError(t, err)
+
+	err = k.Publish(context.Background(), "orders", []byte(`{}`), map[string]string{
+		ValueSchemaTypeMetadataKey: "protobuf",
+	})
+	require.Error(t, err)
+	assert.NoError(t, producer.Close())
+}
@@ -29,6 +29,12 @@ const (
 	// DefaultMaxBulkSubAwaitDurationMs is the default max bulk await duration for kafka pubsub component
 	// if the MaxBulkAwaitDurationKey is not set in the metadata.
 	DefaultMaxBulkSubAwaitDurationMs = 10000
+
+	// Headers added to the messages published to the dead letter topic.
+	deadLetterTopicHeader     = "__dapr.dlq.topic"
+	deadLetterPartitionHeader = "__dapr.dlq.partition"
+	deadLetterOffsetHeader    = "__dapr.dlq.offset"
+	deadLetterErrorHeader     = "__dapr.dlq.error"
 )
 
 // asBase64String implements the `fmt.Stringer` interface in order to print
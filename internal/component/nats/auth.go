@@ -0,0 +1,212 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package nats contains the connection options shared by the NATS components.
+package nats
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+
+	"github.com/dapr/kit/logger"
+)
+
+// ConnectionMetadata contains the metadata properties for authenticating with the NATS server.
+// Components embed it in their metadata with `mapstructure:",squash"`.
+type ConnectionMetadata struct {
+	Jwt     string `mapstructure:"jwt"`
+	SeedKey string `mapstructure:"seedKey"`
+	Token   string `mapstructure:"token"`
+
+	// Content of a NATS credentials (.creds) file, containing the user JWT and nkey seed.
+	Credentials string `mapstructure:"credentials"`
+	// Path to a NATS credentials (.creds) file.
+	CredentialsFile string `mapstructure:"credentialsFile"`
+	// User nkey seed, for nkey authentication without a JWT.
+	NkeySeed string `mapstructure:"nkeySeed"`
+
+	TLSClientCert string `mapstructure:"tls_client_cert"`
+	TLSClientKey  string `mapstructure:"tls_client_key"`
+	// Perform the TLS handshake before the server sends the INFO message, for servers configured with "handshake_first".
+	TLSHandshakeFirst bool `mapstructure:"tlsHandshakeFirst"`
+}
+
+// Validate returns an error if the connection metadata is invalid.
+func (m ConnectionMetadata) Validate() error {
+	if m.Jwt != "" && m.SeedKey == "" {
+		return errors.New("missing seed key")
+	}
+
+	if m.Jwt == "" && m.SeedKey != "" {
+		return errors.New("missing jwt")
+	}
+
+	authMethods := 0
+	for _, v := range []string{m.Jwt, m.Credentials, m.CredentialsFile, m.NkeySeed} {
+		if v != "" {
+			authMethods++
+		}
+	}
+	if authMethods > 1 {
+		return errors.New("only one of jwt, credentials, credentialsFile and nkeySeed can be set")
+	}
+
+	if m.Credentials != "" {
+		if _, err := nkeys.ParseDecoratedUserNKey([]byte(m.Credentials)); err != nil {
+			return fmt.Errorf("invalid credentials: %w", err)
+		}
+	}
+
+	if m.NkeySeed != "" {
+		if _, err := nkeys.FromSeed([]byte(m.NkeySeed)); err != nil {
+			return fmt.Errorf("invalid nkey seed: %w", err)
+		}
+	}
+
+	if m.TLSClientCert != "" && m.TLSClientKey == "" {
+		return errors.New("missing tls client key")
+	}
+
+	if m.TLSClientCert == "" && m.TLSClientKey != "" {
+		return errors.New("missing tls client cert")
+	}
+
+	return nil
+}
+
+// ConnectOptions returns the options for connecting to the NATS server, including the authentication method.
+func (m ConnectionMetadata) ConnectOptions(name string, log logger.Logger) ([]natsgo.Option, error) {
+	opts := []natsgo.Option{natsgo.Name(name)}
+
+	switch {
+	case m.Jwt != "" && m.SeedKey != "":
+		// Set nats.UserJWT options when jwt and seed key is provided.
+		opts = append(opts, natsgo.UserJWT(func() (string, error) {
+			return m.Jwt, nil
+		}, func(nonce []byte) ([]byte, error) {
+			return SigHandler(m.SeedKey, nonce)
+		}))
+	case m.Credentials != "":
+		log.Debug("Configure nats for credentials authentication")
+		creds := []byte(m.Credentials)
+		opts = append(opts, natsgo.UserJWT(func() (string, error) {
+			return nkeys.ParseDecoratedJWT(creds)
+		}, func(nonce []byte) ([]byte, error) {
+			kp, err := nkeys.ParseDecoratedUserNKey(creds)
+			if err != nil {
+				return nil, err
+			}
+			// Wipe our key on exit.
+			defer kp.Wipe()
+			return kp.Sign(nonce)
+		}))
+	case m.CredentialsFile != "":
+		log.Debug("Configure nats for credentials file authentication")
+		opts = append(opts, natsgo.UserCredentials(m.CredentialsFile))
+	case m.NkeySeed != "":
+		log.Debug("Configure nats for nkey authentication")
+		kp, err := nkeys.FromSeed([]byte(m.NkeySeed))
+		if err != nil {
+			return nil, fmt.Errorf("invalid nkey seed: %w", err)
+		}
+		pub, err := kp.PublicKey()
+		kp.Wipe()
+		if err != nil {
+			return nil, fmt.Errorf("invalid nkey seed: %w", err)
+		}
+		opts = append(opts, natsgo.Nkey(pub, func(nonce []byte) ([]byte, error) {
+			return SigHandler(m.NkeySeed, nonce)
+		}))
+	case m.Token != "":
+		log.Debug("Configure nats for token authentication")
+		opts = append(opts, natsgo.Token(m.Token))
+	}
+
+	if m.TLSHandshakeFirst {
+		log.Debug("Configure nats for TLS handshake first")
+		tlsConfig := &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+		if m.TLSClientCert != "" && m.TLSClientKey != "" {
+			cert, err := tls.LoadX509KeyPair(m.TLSClientCert, m.TLSClientKey)
+			if err != nil {
+				return nil, fmt.Errorf("error loading tls client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		opts = append(opts, natsgo.SetCustomDialer(&tlsFirstDialer{
+			dialer: &net.Dialer{Timeout: natsgo.GetDefaultOptions().Timeout},
+			config: tlsConfig,
+		}))
+	} else if m.TLSClientCert != "" && m.TLSClientKey != "" {
+		log.Debug("Configure nats for tls client authentication")
+		opts = append(opts, natsgo.ClientCert(m.TLSClientCert, m.TLSClientKey))
+	}
+
+	return opts, nil
+}
+
+// SigHandler handles the nats signature request for challenge response authentication.
+func SigHandler(seedKey string, nonce []byte) ([]byte, error) {
+	kp, err := nkeys.FromSeed([]byte(seedKey))
+	if err != nil {
+		return nil, err
+	}
+	// Wipe our key on exit.
+	defer kp.Wipe()
+
+	sig, _ := kp.Sign(nonce)
+	return sig, nil
+}
+
+// tlsFirstDialer performs the TLS handshake as soon as the connection is established, instead of after receiving the INFO message from the server.
+// This is required by servers and leaf nodes configured with "handshake_first", such as the ones behind TLS-terminating proxies.
+type tlsFirstDialer struct {
+	dialer *net.Dialer
+	config *tls.Config
+}
+
+func (d *tlsFirstDialer) Dial(network, address string) (net.Conn, error) {
+	conn, err := d.dialer.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	config := d.config.Clone()
+	if config.ServerName == "" {
+		config.ServerName, _, _ = net.SplitHostPort(address)
+	}
+	tlsConn := tls.Client(conn, config)
+	if d.dialer.Timeout > 0 {
+		// Ignore errors setting the deadline, as the handshake would fail anyway
+		_ = conn.SetDeadline(time.Now().Add(d.dialer.Timeout))
+	}
+	err = tlsConn.Handshake()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return tlsConn, nil
+}
+
+// SkipTLSHandshake tells the NATS client that the connection is already secured, so it must not perform the TLS handshake again.
+func (d *tlsFirstDialer) SkipTLSHandshake() bool {
+	return true
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nats
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/logger"
+)
+
+func testCredentials(t *testing.T) (string, nkeys.KeyPair) {
+	t.Helper()
+
+	kp, err := nkeys.CreateUser()
+	require.NoError(t, err)
+	seed, err := kp.Seed()
+	require.NoError(t, err)
+
+	creds := "-----BEGIN NATS USER JWT-----\n" +
+		"eyJ0eXAiOiJKV1QiLCJhbGciOiJlZDI1NTE5LW5rZXkifQ.e30.c2ln\n" +
+		"------END NATS USER JWT------\n\n" +
+		"-----BEGIN USER NKEY SEED-----\n" +
+		string(seed) + "\n" +
+		"------END USER NKEY SEED------\n"
+	return creds, kp
+}
+
+func applyOptions(t *testing.T, opts []natsgo.Option) natsgo.Options {
+	t.Helper()
+
+	o := natsgo.GetDefaultOptions()
+	for _, opt := range opts {
+		require.NoError(t, opt(&o))
+	}
+	return o
+}
+
+func TestValidate(t *testing.T) {
+	creds, _ := testCredentials(t)
+	testCases := []struct {
+		desc      string
+		meta      ConnectionMetadata
+		expectErr bool
+	}{
+		{desc: "no authentication", meta: ConnectionMetadata{}},
+		{desc: "jwt and seed key", meta: ConnectionMetadata{Jwt: "jwt", SeedKey: "seed"}},
+		{desc: "jwt without seed key", meta: ConnectionMetadata{Jwt: "jwt"}, expectErr: true},
+		{desc: "seed key without jwt", meta: ConnectionMetadata{SeedKey: "seed"}, expectErr: true},
+		{desc: "several authentication methods", meta: ConnectionMetadata{Credentials: creds, CredentialsFile: "user.creds"}, expectErr: true},
+		{desc: "invalid credentials", meta: ConnectionMetadata{Credentials: "invalid"}, expectErr: true},
+		{desc: "invalid nkey seed", meta: ConnectionMetadata{NkeySeed: "invalid"}, expectErr: true},
+		{desc: "tls client cert without key", meta: ConnectionMetadata{TLSClientCert: "cert.pem"}, expectErr: true},
+		{desc: "tls client key without cert", meta: ConnectionMetadata{TLSClientKey: "key.pem"}, expectErr: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			err := tC.meta.Validate()
+			if tC.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestConnectOptions(t *testing.T) {
+	t.Run("credentials", func(t *testing.T) {
+		creds, kp := testCredentials(t)
+		m := ConnectionMetadata{Credentials: creds}
+		require.NoError(t, m.Validate())
+
+		opts, err := m.ConnectOptions("test", logger.NewLogger("test"))
+		require.NoError(t, err)
+		o := applyOptions(t, opts)
+
+		require.NotNil(t, o.UserJWT)
+		jwt, err := o.UserJWT()
+		require.NoError(t, err)
+		assert.Equal(t, "eyJ0eXAiOiJKV1QiLCJhbGciOiJlZDI1NTE5LW5rZXkifQ.e30.c2ln", jwt)
+
+		sig, err := o.SignatureCB([]byte("nonce"))
+		require.NoError(t, err)
+		assert.NoError(t, kp.Verify([]byte("nonce"), sig))
+	})
+
+	t.Run("nkey seed", func(t *testing.T) {
+		kp, err := nkeys.CreateUser()
+		require.NoError(t, err)
+		seed, _ := kp.Seed()
+		pub, _ := kp.PublicKey()
+		m := ConnectionMetadata{NkeySeed: string(seed)}
+		require.NoError(t, m.Validate())
+
+		opts, err := m.ConnectOptions("test", logger.NewLogger("test"))
+		require.NoError(t, err)
+		o := applyOptions(t, opts)
+
+		assert.Equal(t, pub, o.Nkey)
+		assert.Nil(t, o.UserJWT)
+		sig, err := o.SignatureCB([]byte("nonce"))
+		require.NoError(t, err)
+		assert.NoError(t, kp.Verify([]byte("nonce"), sig))
+	})
+
+	t.Run("TLS handshake first", func(t *testing.T) {
+		m := ConnectionMetadata{TLSHandshakeFirst: true}
+
+		opts, err := m.ConnectOptions("test", logger.NewLogger("test"))
+		require.NoError(t, err)
+		o := applyOptions(t, opts)
+
+		require.IsType(t, &tlsFirstDialer{}, o.CustomDialer)
+		assert.True(t, o.CustomDialer.(*tlsFirstDialer).SkipTLSHandshake())
+	})
+}
+
+func TestTLSFirstDialer(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	srvTLSConfig := srv.Client().Transport.(*http.Transport).TLSClientConfig
+
+	t.Run("handshake on dial", func(t *testing.T) {
+		d := &tlsFirstDialer{
+			dialer: &net.Dialer{},
+			config: &tls.Config{RootCAs: srvTLSConfig.RootCAs, ServerName: "example.com", MinVersion: tls.VersionTLS12},
+		}
+		conn, err := d.Dial("tcp", srv.Listener.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		require.IsType(t, &tls.Conn{}, conn)
+		assert.True(t, conn.(*tls.Conn).ConnectionState().HandshakeComplete)
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		d := &tlsFirstDialer{
+			dialer: &net.Dialer{},
+			config: &tls.Config{MinVersion: tls.VersionTLS12},
+		}
+		_, err := d.Dial("tcp", srv.Listener.Addr().String())
+		assert.Error(t, err)
+	})
+}
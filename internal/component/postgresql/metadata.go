@@ -23,8 +23,10 @@ import (
 )
 
 const (
-	cleanupIntervalKey = "cleanupIntervalInSeconds"
-	timeoutKey         = "timeoutInSeconds"
+	cleanupIntervalKey  = "cleanupIntervalInSeconds"
+	cleanupBatchSizeKey = "cleanupBatchSize"
+	timeoutKey          = "timeoutInSeconds"
+	slowQueryKey        = "slowQueryThreshold"
 
 	defaultTableName         = "state"
 	defaultMetadataTableName = "dapr_metadata"
@@ -40,6 +42,12 @@ type postgresMetadataStruct struct {
 
 	Timeout         time.Duration  `mapstructure:"timeoutInSeconds"`
 	CleanupInterval *time.Duration `mapstructure:"cleanupIntervalInSeconds"`
+	// Maximum number of expired rows deleted in each transaction by the garbage collector; 0 deletes all of them at once.
+	CleanupBatchSize int64 `mapstructure:"cleanupBatchSize"`
+	// Operations taking longer than this are logged; 0 disables logging.
+	SlowQueryThreshold time.Duration `mapstructure:"slowQueryThreshold"`
+	// If true, the state table is distributed with Citus, such as in Azure Cosmos DB for PostgreSQL, using the partition key of the requests as distribution column.
+	CitusMode bool `mapstructure:"citusMode"`
 }
 
 func (m *postgresMetadataStruct) InitWithMetadata(meta state.Metadata) error {
@@ -48,7 +56,10 @@ func (m *postgresMetadataStruct) InitWithMetadata(meta state.Metadata) error {
 	m.TableName = defaultTableName
 	m.MetadataTableName = defaultMetadataTableName
 	m.CleanupInterval = ptr.Of(defaultCleanupInternal * time.Second)
+	m.CleanupBatchSize = 0
 	m.Timeout = defaultTimeout * time.Second
+	m.SlowQueryThreshold = 0
+	m.CitusMode = false
 
 	// Decode the metadata
 	err := metadata.DecodeMetadata(meta.Properties, &m)
@@ -66,6 +77,16 @@ func (m *postgresMetadataStruct) InitWithMetadata(meta state.Metadata) error {
 		return fmt.Errorf("invalid value for '%s': must be greater than 0", timeoutKey)
 	}
 
+	// Slow query threshold
+	if m.SlowQueryThreshold < 0 {
+		return fmt.Errorf("invalid value for '%s': must be greater than or equal to 0", slowQueryKey)
+	}
+
+	// Cleanup batch size
+	if m.CleanupBatchSize < 0 {
+		return fmt.Errorf("invalid value for '%s': must be greater than or equal to 0", cleanupBatchSizeKey)
+	}
+
 	// Cleanup interval
 	if m.CleanupInterval != nil {
 		// Non-positive value from meta means disable auto cleanup.
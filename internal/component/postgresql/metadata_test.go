@@ -111,6 +111,22 @@ func TestMetadata(t *testing.T) {
 		assert.Error(t, err)
 	})
 
+	t.Run("slow query threshold", func(t *testing.T) {
+		m := postgresMetadataStruct{}
+		props := map[string]string{
+			"connectionString":   "foo",
+			"slowQueryThreshold": "500ms",
+		}
+
+		err := m.InitWithMetadata(state.Metadata{Base: metadata.Base{Properties: props}})
+		assert.NoError(t, err)
+		assert.Equal(t, 500*time.Millisecond, m.SlowQueryThreshold)
+
+		props["slowQueryThreshold"] = "-1s"
+		err = m.InitWithMetadata(state.Metadata{Base: metadata.Base{Properties: props}})
+		assert.Error(t, err)
+	})
+
 	t.Run("default cleanupIntervalInSeconds", func(t *testing.T) {
 		m := postgresMetadataStruct{}
 		props := map[string]string{
@@ -158,4 +174,54 @@ func TestMetadata(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Nil(t, m.CleanupInterval)
 	})
+
+	t.Run("default cleanupBatchSize", func(t *testing.T) {
+		m := postgresMetadataStruct{}
+		props := map[string]string{
+			"connectionString": "foo",
+		}
+
+		err := m.InitWithMetadata(state.Metadata{Base: metadata.Base{Properties: props}})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), m.CleanupBatchSize)
+	})
+
+	t.Run("positive cleanupBatchSize", func(t *testing.T) {
+		m := postgresMetadataStruct{}
+		props := map[string]string{
+			"connectionString": "foo",
+			"cleanupBatchSize": "500",
+		}
+
+		err := m.InitWithMetadata(state.Metadata{Base: metadata.Base{Properties: props}})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(500), m.CleanupBatchSize)
+	})
+
+	t.Run("negative cleanupBatchSize", func(t *testing.T) {
+		m := postgresMetadataStruct{}
+		props := map[string]string{
+			"connectionString": "foo",
+			"cleanupBatchSize": "-1",
+		}
+
+		err := m.InitWithMetadata(state.Metadata{Base: metadata.Base{Properties: props}})
+		assert.Error(t, err)
+	})
+
+	t.Run("citusMode", func(t *testing.T) {
+		m := postgresMetadataStruct{}
+		props := map[string]string{
+			"connectionString": "foo",
+		}
+
+		err := m.InitWithMetadata(state.Metadata{Base: metadata.Base{Properties: props}})
+		assert.NoError(t, err)
+		assert.False(t, m.CitusMode)
+
+		props["citusMode"] = "true"
+		err = m.InitWithMetadata(state.Metadata{Base: metadata.Base{Properties: props}})
+		assert.NoError(t, err)
+		assert.True(t, m.CitusMode)
+	})
 }
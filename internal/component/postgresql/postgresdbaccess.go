@@ -28,6 +28,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	internalsql "github.com/dapr/components-contrib/internal/component/sql"
+	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/components-contrib/state/query"
 	stateutils "github.com/dapr/components-contrib/state/utils"
@@ -37,6 +38,9 @@ import (
 
 var errMissingConnectionString = errors.New("missing connection string")
 
+// Metadata property of the requests with the value of the distribution column in Citus mode.
+const metadataPartitionKey = "partitionKey"
+
 // Interface that applies to *pgxpool.Pool.
 // We need this to be able to mock the connection in tests.
 type PGXPoolConn interface {
@@ -55,11 +59,13 @@ type PostgresDBAccess struct {
 	metadata postgresMetadataStruct
 	db       PGXPoolConn
 
-	gc internalsql.GarbageCollector
+	gc          internalsql.GarbageCollector
+	slowQueries internalsql.SlowQueryLogger
 
-	migrateFn  func(context.Context, PGXPoolConn, MigrateOptions) error
-	setQueryFn func(*state.SetRequest, SetQueryOptions) string
-	etagColumn string
+	migrateFn     func(context.Context, PGXPoolConn, MigrateOptions) error
+	setQueryFn    func(*state.SetRequest, SetQueryOptions) string
+	etagColumn    string
+	supportsCitus bool
 }
 
 // newPostgresDBAccess creates a new instance of postgresAccess.
@@ -67,10 +73,11 @@ func newPostgresDBAccess(logger logger.Logger, opts Options) *PostgresDBAccess {
 	logger.Debug("Instantiating new Postgres state store")
 
 	return &PostgresDBAccess{
-		logger:     logger,
-		migrateFn:  opts.MigrateFn,
-		setQueryFn: opts.SetQueryFn,
-		etagColumn: opts.ETagColumn,
+		logger:        logger,
+		migrateFn:     opts.MigrateFn,
+		setQueryFn:    opts.SetQueryFn,
+		etagColumn:    opts.ETagColumn,
+		supportsCitus: opts.SupportsCitus,
 	}
 }
 
@@ -83,6 +90,13 @@ func (p *PostgresDBAccess) Init(ctx context.Context, meta state.Metadata) error
 		p.logger.Errorf("Failed to parse metadata: %v", err)
 		return err
 	}
+	if p.metadata.CitusMode && !p.supportsCitus {
+		return errors.New("citusMode is not supported by this state store")
+	}
+	p.slowQueries = internalsql.SlowQueryLogger{
+		Logger:    p.logger,
+		Threshold: p.metadata.SlowQueryThreshold,
+	}
 
 	config, err := pgxpool.ParseConfig(p.metadata.ConnectionString)
 	if err != nil {
@@ -116,12 +130,34 @@ func (p *PostgresDBAccess) Init(ctx context.Context, meta state.Metadata) error
 		Logger:            p.logger,
 		StateTableName:    p.metadata.TableName,
 		MetadataTableName: p.metadata.MetadataTableName,
+		Citus:             p.metadata.CitusMode,
 	})
 	if err != nil {
 		return err
 	}
 
 	if p.metadata.CleanupInterval != nil {
+		deleteExpiredValuesQuery := fmt.Sprintf(
+			`DELETE FROM %s WHERE expiredate IS NOT NULL AND expiredate < CURRENT_TIMESTAMP`,
+			p.metadata.TableName,
+		)
+		if p.metadata.CleanupBatchSize > 0 && p.metadata.CitusMode {
+			// Rows are identified by both the partition key and the key in distributed tables
+			deleteExpiredValuesQuery = fmt.Sprintf(
+				`DELETE FROM %[1]s WHERE (partitionkey, key) IN (
+					SELECT partitionkey, key FROM %[1]s WHERE expiredate IS NOT NULL AND expiredate < CURRENT_TIMESTAMP LIMIT %[2]d
+				)`,
+				p.metadata.TableName, p.metadata.CleanupBatchSize,
+			)
+		} else if p.metadata.CleanupBatchSize > 0 {
+			// PostgreSQL doesn't support LIMIT in DELETE statements
+			deleteExpiredValuesQuery = fmt.Sprintf(
+				`DELETE FROM %[1]s WHERE key IN (
+					SELECT key FROM %[1]s WHERE expiredate IS NOT NULL AND expiredate < CURRENT_TIMESTAMP LIMIT %[2]d
+				)`,
+				p.metadata.TableName, p.metadata.CleanupBatchSize,
+			)
+		}
 		gc, err := internalsql.ScheduleGarbageCollector(internalsql.GCOptions{
 			Logger: p.logger,
 			UpdateLastCleanupQuery: fmt.Sprintf(
@@ -132,12 +168,10 @@ func (p *PostgresDBAccess) Init(ctx context.Context, meta state.Metadata) error
 				WHERE (EXTRACT('epoch' FROM CURRENT_TIMESTAMP - %[1]s.value::timestamp with time zone) * 1000)::bigint > $1`,
 				p.metadata.MetadataTableName,
 			),
-			DeleteExpiredValuesQuery: fmt.Sprintf(
-				`DELETE FROM %s WHERE expiredate IS NOT NULL AND expiredate < CURRENT_TIMESTAMP`,
-				p.metadata.TableName,
-			),
-			CleanupInterval: *p.metadata.CleanupInterval,
-			DBPgx:           p.db,
+			DeleteExpiredValuesQuery:     deleteExpiredValuesQuery,
+			DeleteExpiredValuesBatchSize: p.metadata.CleanupBatchSize,
+			CleanupInterval:              *p.metadata.CleanupInterval,
+			DBPgx:                        p.db,
 		})
 		if err != nil {
 			return err
@@ -155,10 +189,12 @@ func (p *PostgresDBAccess) GetDB() *pgxpool.Pool {
 
 // Set makes an insert or update to the database.
 func (p *PostgresDBAccess) Set(ctx context.Context, req *state.SetRequest) error {
-	return p.doSet(ctx, p.db, req)
+	return p.doSet(ctx, p.db, req, p.partitionKey(req.Key, req.Metadata))
 }
 
-func (p *PostgresDBAccess) doSet(parentCtx context.Context, db dbquerier, req *state.SetRequest) error {
+// doSet saves the value of a key.
+// partitionKey is the value of the distribution column if the table is distributed with Citus, or an empty string otherwise.
+func (p *PostgresDBAccess) doSet(parentCtx context.Context, db dbquerier, req *state.SetRequest, partitionKey string) error {
 	err := state.CheckRequestOptions(req.Options)
 	if err != nil {
 		return err
@@ -210,12 +246,25 @@ func (p *PostgresDBAccess) doSet(parentCtx context.Context, db dbquerier, req *s
 		queryExpiredate = "NULL"
 	}
 
+	var partitionKeyParam string
+	if partitionKey != "" {
+		params = append(params, partitionKey)
+		partitionKeyParam = "$" + strconv.Itoa(len(params))
+	}
+
 	query := p.setQueryFn(req, SetQueryOptions{
-		TableName:       p.metadata.TableName,
-		ExpireDateValue: queryExpiredate,
+		TableName:         p.metadata.TableName,
+		ExpireDateValue:   queryExpiredate,
+		PartitionKeyParam: partitionKeyParam,
 	})
 
-	result, err := db.Exec(parentCtx, query, params...)
+	ctx, cancel, err := internalsql.OperationContext(parentCtx, req.Metadata, p.metadata.Timeout)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer p.slowQueries.Track("Set", req.Key, time.Now())
+	result, err := db.Exec(ctx, query, params...)
 	if err != nil {
 		return err
 	}
@@ -241,9 +290,19 @@ func (p *PostgresDBAccess) Get(parentCtx context.Context, req *state.GetRequest)
 			WHERE
 				key = $1
 				AND (expiredate IS NULL OR expiredate >= CURRENT_TIMESTAMP)`
-	ctx, cancel := context.WithTimeout(parentCtx, p.metadata.Timeout)
+	params := []any{req.Key}
+	if partitionKey := p.partitionKey(req.Key, req.Metadata); partitionKey != "" {
+		// Route the query to the node with the shard of the partition
+		query += " AND partitionkey = $2"
+		params = append(params, partitionKey)
+	}
+	ctx, cancel, err := internalsql.OperationContext(parentCtx, req.Metadata, p.metadata.Timeout)
+	if err != nil {
+		return nil, err
+	}
 	defer cancel()
-	row := p.db.QueryRow(ctx, query, req.Key)
+	defer p.slowQueries.Track("Get", req.Key, time.Now())
+	row := p.db.QueryRow(ctx, query, params...)
 	_, value, etag, expireTime, err := readRow(row)
 	if err != nil {
 		// If no rows exist, return an empty response, otherwise return the error.
@@ -285,9 +344,19 @@ func (p *PostgresDBAccess) BulkGet(parentCtx context.Context, req []state.GetReq
 			WHERE
 				key = ANY($1)
 				AND (expiredate IS NULL OR expiredate >= CURRENT_TIMESTAMP)`
+	params := []any{keys}
+	if p.metadata.CitusMode {
+		partitionKeys := make([]string, len(req))
+		for i, r := range req {
+			partitionKeys[i] = p.partitionKey(r.Key, r.Metadata)
+		}
+		query += " AND partitionkey = ANY($2)"
+		params = append(params, partitionKeys)
+	}
 	ctx, cancel := context.WithTimeout(parentCtx, p.metadata.Timeout)
 	defer cancel()
-	rows, err := p.db.Query(ctx, query, keys)
+	defer p.slowQueries.Track("BulkGet", "", time.Now())
+	rows, err := p.db.Query(ctx, query, params...)
 	if err != nil {
 		// If no rows exist, return an empty response, otherwise return the error.
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -386,20 +455,19 @@ func readRow(row pgx.Row) (key string, value []byte, etagS *string, expireTime *
 
 // Delete removes an item from the state store.
 func (p *PostgresDBAccess) Delete(ctx context.Context, req *state.DeleteRequest) (err error) {
-	return p.doDelete(ctx, p.db, req)
+	return p.doDelete(ctx, p.db, req, p.partitionKey(req.Key, req.Metadata))
 }
 
-func (p *PostgresDBAccess) doDelete(parentCtx context.Context, db dbquerier, req *state.DeleteRequest) (err error) {
+// doDelete removes a key.
+// partitionKey is the value of the distribution column if the table is distributed with Citus, or an empty string otherwise.
+func (p *PostgresDBAccess) doDelete(parentCtx context.Context, db dbquerier, req *state.DeleteRequest, partitionKey string) (err error) {
 	if req.Key == "" {
 		return errors.New("missing key in delete operation")
 	}
 
-	ctx, cancel := context.WithTimeout(parentCtx, p.metadata.Timeout)
-	defer cancel()
-	var result pgconn.CommandTag
-	if !req.HasETag() {
-		result, err = db.Exec(ctx, "DELETE FROM "+p.metadata.TableName+" WHERE key = $1", req.Key)
-	} else {
+	query := "DELETE FROM " + p.metadata.TableName + " WHERE key = $1"
+	params := []any{req.Key}
+	if req.HasETag() {
 		// Convert req.ETag to uint32 for postgres XID compatibility
 		var etag64 uint64
 		etag64, err = strconv.ParseUint(*req.ETag, 10, 32)
@@ -407,8 +475,21 @@ func (p *PostgresDBAccess) doDelete(parentCtx context.Context, db dbquerier, req
 			return state.NewETagError(state.ETagInvalid, err)
 		}
 
-		result, err = db.Exec(ctx, "DELETE FROM "+p.metadata.TableName+" WHERE key = $1 AND $2 = "+p.etagColumn, req.Key, uint32(etag64))
+		query += " AND $2 = " + p.etagColumn
+		params = append(params, uint32(etag64))
+	}
+	if partitionKey != "" {
+		params = append(params, partitionKey)
+		query += " AND partitionkey = $" + strconv.Itoa(len(params))
 	}
+
+	ctx, cancel, err := internalsql.OperationContext(parentCtx, req.Metadata, p.metadata.Timeout)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer p.slowQueries.Track("Delete", req.Key, time.Now())
+	result, err := db.Exec(ctx, query, params...)
 	if err != nil {
 		return err
 	}
@@ -422,6 +503,14 @@ func (p *PostgresDBAccess) doDelete(parentCtx context.Context, db dbquerier, req
 }
 
 func (p *PostgresDBAccess) ExecuteMulti(parentCtx context.Context, request *state.TransactionalStateRequest) error {
+	// The timeout in the metadata of the transaction, if any, applies to the whole transaction
+	parentCtx, parentCancel, err := internalsql.OperationContext(parentCtx, request.Metadata, 0)
+	if err != nil {
+		return err
+	}
+	defer parentCancel()
+	defer p.slowQueries.Track("Transaction", "", time.Now())
+
 	tx, err := p.beginTx(parentCtx)
 	if err != nil {
 		return err
@@ -431,13 +520,13 @@ func (p *PostgresDBAccess) ExecuteMulti(parentCtx context.Context, request *stat
 	for _, o := range request.Operations {
 		switch x := o.(type) {
 		case state.SetRequest:
-			err = p.doSet(parentCtx, tx, &x)
+			err = p.doSet(parentCtx, tx, &x, p.partitionKey(x.Key, x.Metadata, request.Metadata))
 			if err != nil {
 				return err
 			}
 
 		case state.DeleteRequest:
-			err = p.doDelete(parentCtx, tx, &x)
+			err = p.doDelete(parentCtx, tx, &x, p.partitionKey(x.Key, x.Metadata, request.Metadata))
 			if err != nil {
 				return err
 			}
@@ -465,11 +554,27 @@ func (p *PostgresDBAccess) Query(parentCtx context.Context, req *state.QueryRequ
 		tableName:  p.metadata.TableName,
 		etagColumn: p.etagColumn,
 	}
+	if p.metadata.CitusMode {
+		// Queries are routed to a single node if they have a partition key; otherwise, they run on all nodes
+		q.partitionKey = req.Metadata[metadataPartitionKey]
+	}
 	qbuilder := query.NewQueryBuilder(q)
 	if err := qbuilder.BuildQuery(&req.Query); err != nil {
 		return &state.QueryResponse{}, err
 	}
-	data, token, err := q.execute(parentCtx, p.logger, p.db)
+	if metadata.IsQueryExplain(req.Metadata) {
+		return &state.QueryResponse{
+			Results:  []state.QueryItem{},
+			Metadata: q.Explain().Metadata(),
+		}, nil
+	}
+	ctx, cancel, err := internalsql.OperationContext(parentCtx, req.Metadata, p.metadata.Timeout)
+	if err != nil {
+		return &state.QueryResponse{}, err
+	}
+	defer cancel()
+	defer p.slowQueries.Track("Query", "", time.Now())
+	data, token, err := q.execute(ctx, p.logger, p.db)
 	if err != nil {
 		return &state.QueryResponse{}, err
 	}
@@ -480,6 +585,21 @@ func (p *PostgresDBAccess) Query(parentCtx context.Context, req *state.QueryRequ
 	}, nil
 }
 
+// partitionKey returns the partition key of a request if the table is distributed with Citus, or an empty string otherwise.
+// The partition key is read from the "partitionKey" metadata property of the request, or of the transaction it belongs to, and it defaults to the key.
+// Actors set the partition key of their state, so the state of an actor is stored in a single shard.
+func (p *PostgresDBAccess) partitionKey(key string, reqMetadata ...map[string]string) string {
+	if !p.metadata.CitusMode {
+		return ""
+	}
+	for _, md := range reqMetadata {
+		if pk := md[metadataPartitionKey]; pk != "" {
+			return pk
+		}
+	}
+	return key
+}
+
 func (p *PostgresDBAccess) CleanupExpired() error {
 	if p.gc != nil {
 		return p.gc.CleanupExpired()
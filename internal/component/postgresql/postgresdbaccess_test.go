@@ -25,8 +25,10 @@ import (
 	pgxmock "github.com/pashagolub/pgxmock/v2"
 	"github.com/stretchr/testify/assert"
 
+	internalsql "github.com/dapr/components-contrib/internal/component/sql"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
 )
 
 type mocks struct {
@@ -180,6 +182,89 @@ func TestMultiOperationOrder(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestCleanupExpiredInBatches(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+
+	gc, err := internalsql.ScheduleGarbageCollector(internalsql.GCOptions{
+		Logger:                       logger.NewLogger("test"),
+		UpdateLastCleanupQuery:       "INSERT INTO dapr_metadata",
+		DeleteExpiredValuesQuery:     "DELETE FROM state WHERE key IN",
+		DeleteExpiredValuesBatchSize: 2,
+		CleanupInterval:              time.Hour,
+		DBPgx:                        m.db,
+	})
+	assert.NoError(t, err)
+	defer gc.Close()
+
+	m.db.ExpectExec("INSERT INTO dapr_metadata").
+		WithArgs(time.Hour.Milliseconds() - 100).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	// The query is repeated until it deletes fewer rows than the batch size
+	for _, n := range []int64{2, 2, 1} {
+		m.db.ExpectBegin()
+		m.db.ExpectExec("DELETE FROM state WHERE key IN").
+			WillReturnResult(pgxmock.NewResult("DELETE", n))
+		m.db.ExpectCommit()
+		m.db.ExpectRollback()
+	}
+
+	// Act
+	err = gc.CleanupExpired()
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, m.db.ExpectationsWereMet())
+}
+
+func TestCitusModeRoutesByPartitionKey(t *testing.T) {
+	// Arrange
+	m, _ := mockDatabase(t)
+	defer m.db.Close()
+	m.pgDba.metadata.CitusMode = true
+	m.pgDba.etagColumn = "xmin"
+
+	actorMetadata := map[string]string{metadataPartitionKey: "app||actor||1"}
+
+	m.db.ExpectExec("INSERT INTO").
+		WithArgs("app||actor||1||key1", `"value1"`, false, "app||actor||1").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	m.db.ExpectQuery(`key = \$1\s+AND \(expiredate IS NULL OR expiredate >= CURRENT_TIMESTAMP\) AND partitionkey = \$2`).
+		WithArgs("app||actor||1||key1", "app||actor||1").
+		WillReturnRows(pgxmock.NewRows([]string{"key", "value", "isbinary", "etag", "expiredate"}))
+	// Without the partitionKey metadata, the partition key is the key
+	m.db.ExpectExec(`DELETE FROM state WHERE key = \$1 AND \$2 = xmin AND partitionkey = \$3`).
+		WithArgs("key2", uint32(42), "key2").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	// In transactions, the partition key of the transaction is the default
+	m.db.ExpectBegin()
+	m.db.ExpectExec(`DELETE FROM state WHERE key = \$1 AND partitionkey = \$2`).
+		WithArgs("app||actor||1||key1", "app||actor||1").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	m.db.ExpectCommit()
+	m.db.ExpectRollback()
+
+	// Act
+	err := m.pgDba.Set(context.Background(), &state.SetRequest{Key: "app||actor||1||key1", Value: "value1", Metadata: actorMetadata})
+	assert.NoError(t, err)
+	res, err := m.pgDba.Get(context.Background(), &state.GetRequest{Key: "app||actor||1||key1", Metadata: actorMetadata})
+	assert.NoError(t, err)
+	assert.Nil(t, res.Data)
+	err = m.pgDba.Delete(context.Background(), &state.DeleteRequest{Key: "key2", ETag: ptr.Of("42")})
+	assert.NoError(t, err)
+	err = m.pgDba.ExecuteMulti(context.Background(), &state.TransactionalStateRequest{
+		Operations: []state.TransactionalStateOperation{
+			state.DeleteRequest{Key: "app||actor||1||key1"},
+		},
+		Metadata: actorMetadata,
+	})
+	assert.NoError(t, err)
+
+	// Assert
+	assert.NoError(t, m.db.ExpectationsWereMet())
+}
+
 func createSetRequest() state.SetRequest {
 	return state.SetRequest{
 		Key:   randomKey(),
@@ -34,17 +34,24 @@ type Options struct {
 	MigrateFn  func(context.Context, PGXPoolConn, MigrateOptions) error
 	SetQueryFn func(*state.SetRequest, SetQueryOptions) string
 	ETagColumn string
+	// If true, the state table can be distributed with Citus, with the "citusMode" metadata option.
+	SupportsCitus bool
 }
 
 type MigrateOptions struct {
 	Logger            logger.Logger
 	StateTableName    string
 	MetadataTableName string
+	// If true, the state table must be distributed with Citus on the "partitionkey" column.
+	Citus bool
 }
 
 type SetQueryOptions struct {
 	TableName       string
 	ExpireDateValue string
+	// Placeholder of the parameter with the value of the "partitionkey" column, such as "$4".
+	// It's empty if the state table isn't distributed with Citus.
+	PartitionKeyParam string
 }
 
 // NewPostgreSQLStateStore creates a new instance of PostgreSQL state store.
@@ -71,7 +78,7 @@ func (p *PostgreSQL) Init(ctx context.Context, metadata state.Metadata) error {
 
 // Features returns the features available in this state store.
 func (p *PostgreSQL) Features() []state.Feature {
-	return []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI}
+	return []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI, state.FeatureTTL, state.FeatureBulkNative}
 }
 
 // Delete removes an entity from the store.
@@ -33,6 +33,8 @@ type Query struct {
 	skip       *int64
 	tableName  string
 	etagColumn string
+	// If not empty, only the rows of the partition are returned.
+	partitionKey string
 }
 
 func (q *Query) VisitEQ(f *query.EQ) (string, error) {
@@ -105,8 +107,13 @@ func (q *Query) VisitOR(f *query.OR) (string, error) {
 func (q *Query) Finalize(filters string, qq *query.Query) error {
 	q.query = fmt.Sprintf("SELECT key, value, %s as etag FROM "+q.tableName, q.etagColumn)
 
+	// Exclude the expired rows that haven't been deleted by the garbage collector yet
+	q.query += " WHERE (expiredate IS NULL OR expiredate >= CURRENT_TIMESTAMP)"
+	if q.partitionKey != "" {
+		q.query += " AND partitionkey=$" + strconv.Itoa(q.addParamValueAndReturnPosition(q.partitionKey))
+	}
 	if filters != "" {
-		q.query += " WHERE " + filters
+		q.query += " AND " + filters
 	}
 
 	if len(qq.Sort) > 0 {
@@ -140,6 +147,15 @@ func (q *Query) Finalize(filters string, qq *query.Query) error {
 	return nil
 }
 
+// Explain returns the SQL query and its parameters.
+func (q *Query) Explain() query.Explanation {
+	return query.Explanation{
+		Backend:    "postgresql",
+		Query:      q.query,
+		Parameters: q.params,
+	}
+}
+
 func (q *Query) execute(ctx context.Context, logger logger.Logger, db dbquerier) ([]state.QueryItem, string, error) {
 	rows, err := db.Query(ctx, q.query, q.params...)
 	if err != nil {
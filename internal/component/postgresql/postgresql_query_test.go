@@ -30,27 +30,27 @@ func TestPostgresqlQueryBuildQuery(t *testing.T) {
 	}{
 		{
 			input: "../../../tests/state/query/q1.json",
-			query: "SELECT key, value, xmin as etag FROM state LIMIT 2",
+			query: "SELECT key, value, xmin as etag FROM state WHERE (expiredate IS NULL OR expiredate >= CURRENT_TIMESTAMP) LIMIT 2",
 		},
 		{
 			input: "../../../tests/state/query/q2.json",
-			query: "SELECT key, value, xmin as etag FROM state WHERE value->>'state'=$1 LIMIT 2",
+			query: "SELECT key, value, xmin as etag FROM state WHERE (expiredate IS NULL OR expiredate >= CURRENT_TIMESTAMP) AND value->>'state'=$1 LIMIT 2",
 		},
 		{
 			input: "../../../tests/state/query/q2-token.json",
-			query: "SELECT key, value, xmin as etag FROM state WHERE value->>'state'=$1 LIMIT 2 OFFSET 2",
+			query: "SELECT key, value, xmin as etag FROM state WHERE (expiredate IS NULL OR expiredate >= CURRENT_TIMESTAMP) AND value->>'state'=$1 LIMIT 2 OFFSET 2",
 		},
 		{
 			input: "../../../tests/state/query/q3.json",
-			query: "SELECT key, value, xmin as etag FROM state WHERE (value->'person'->>'org'=$1 AND (value->>'state'=$2 OR value->>'state'=$3)) ORDER BY value->>'state' DESC, value->'person'->>'name'",
+			query: "SELECT key, value, xmin as etag FROM state WHERE (expiredate IS NULL OR expiredate >= CURRENT_TIMESTAMP) AND (value->'person'->>'org'=$1 AND (value->>'state'=$2 OR value->>'state'=$3)) ORDER BY value->>'state' DESC, value->'person'->>'name'",
 		},
 		{
 			input: "../../../tests/state/query/q4.json",
-			query: "SELECT key, value, xmin as etag FROM state WHERE (value->'person'->>'org'=$1 OR (value->'person'->>'org'=$2 AND (value->>'state'=$3 OR value->>'state'=$4))) ORDER BY value->>'state' DESC, value->'person'->>'name' LIMIT 2",
+			query: "SELECT key, value, xmin as etag FROM state WHERE (expiredate IS NULL OR expiredate >= CURRENT_TIMESTAMP) AND (value->'person'->>'org'=$1 OR (value->'person'->>'org'=$2 AND (value->>'state'=$3 OR value->>'state'=$4))) ORDER BY value->>'state' DESC, value->'person'->>'name' LIMIT 2",
 		},
 		{
 			input: "../../../tests/state/query/q5.json",
-			query: "SELECT key, value, xmin as etag FROM state WHERE (value->'person'->>'org'=$1 AND (value->'person'->>'name'=$2 OR (value->>'state'=$3 OR value->>'state'=$4))) ORDER BY value->>'state' DESC, value->'person'->>'name' LIMIT 2",
+			query: "SELECT key, value, xmin as etag FROM state WHERE (expiredate IS NULL OR expiredate >= CURRENT_TIMESTAMP) AND (value->'person'->>'org'=$1 AND (value->'person'->>'name'=$2 OR (value->>'state'=$3 OR value->>'state'=$4))) ORDER BY value->>'state' DESC, value->'person'->>'name' LIMIT 2",
 		},
 	}
 	for _, test := range tests {
@@ -70,3 +70,24 @@ func TestPostgresqlQueryBuildQuery(t *testing.T) {
 		assert.Equal(t, test.query, q.query)
 	}
 }
+
+func TestPostgresqlQueryExplain(t *testing.T) {
+	data, err := os.ReadFile("../../../tests/state/query/q2.json")
+	assert.NoError(t, err)
+	var qq query.Query
+	err = json.Unmarshal(data, &qq)
+	assert.NoError(t, err)
+
+	q := &Query{
+		tableName:  defaultTableName,
+		etagColumn: "xmin",
+	}
+	err = query.NewQueryBuilder(q).BuildQuery(&qq)
+	assert.NoError(t, err)
+
+	md := q.Explain().Metadata()
+	assert.Equal(t, "postgresql", md[query.ExplainBackendKey])
+	assert.Equal(t, "SELECT key, value, xmin as etag FROM state WHERE (expiredate IS NULL OR expiredate >= CURRENT_TIMESTAMP) AND value->>'state'=$1 LIMIT 2", md[query.ExplainQueryKey])
+	assert.Equal(t, `["CA"]`, md[query.ExplainParametersKey])
+	assert.NotContains(t, md, query.ExplainIndexHintsKey)
+}
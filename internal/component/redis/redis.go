@@ -58,6 +58,11 @@ type RedisXPendingExt struct {
 
 type RedisPipeliner interface {
 	Exec(ctx context.Context) error
+	// ExecErrors executes the queued commands and returns the error of each of them, in the order they were queued.
+	ExecErrors(ctx context.Context) []error
+	// ExecResults executes the queued commands and returns the result and the error of each of them, in the order they were queued.
+	// Commands that return a nil value, for example for a missing key, have a nil result and no error.
+	ExecResults(ctx context.Context) ([]interface{}, []error)
 	Do(ctx context.Context, args ...interface{})
 }
 
@@ -81,7 +86,11 @@ type RedisClient interface {
 	XReadGroupResult(ctx context.Context, group string, consumer string, streams []string, count int64, block time.Duration) ([]RedisXStream, error)
 	XPendingExtResult(ctx context.Context, stream string, group string, start string, end string, count int64) ([]RedisXPendingExt, error)
 	XClaimResult(ctx context.Context, stream string, group string, consumer string, minIdleTime time.Duration, messageIDs []string) ([]RedisXMessage, error)
+	// XAutoClaimResult claims up to count messages that have been pending for at least minIdleTime, starting from the ID start, and returns them with the ID to start the next call from ("0-0" when the scan is complete).
+	XAutoClaimResult(ctx context.Context, stream string, group string, consumer string, minIdleTime time.Duration, start string, count int64) ([]RedisXMessage, string, error)
+	XTrimMaxLenApprox(ctx context.Context, stream string, maxLen int64) error
 	TxPipeline() RedisPipeliner
+	Pipeline() RedisPipeliner
 	TTLResult(ctx context.Context, key string) (time.Duration, error)
 }
 
@@ -131,6 +140,7 @@ func ParseClientFromProperties(properties map[string]string, componentType metad
 		settings.RedeliverInterval = 15 * time.Second
 		settings.QueueDepth = 100
 		settings.Concurrency = 10
+		settings.TrimInterval = time.Minute
 	}
 
 	err = settings.Decode(properties)
@@ -81,6 +81,14 @@ type Settings struct {
 	// == state only properties ==
 	TTLInSeconds *int   `mapstructure:"ttlInSeconds" only:"state"`
 	QueryIndexes string `mapstructure:"queryIndexes" only:"state"`
+	// The maximum number of operations sent in a single pipeline by bulk operations and allowed in a transaction (0 for no limit)
+	MaxBatchSize int `mapstructure:"maxBatchSize" only:"state"`
+	// The number of replicas that must acknowledge writes before they are considered successful (0 disables waiting)
+	WaitReplicas int `mapstructure:"waitReplicas" only:"state"`
+	// The maximum time to wait for replicas to acknowledge writes
+	WaitTimeout Duration `mapstructure:"waitTimeout" only:"state"`
+	// Wrap the key prefix in a hash tag so that all the keys of an app are stored in the same cluster slot
+	HashTagKeys bool `mapstructure:"hashTagKeys" only:"state"`
 
 	// == pubsub only properties ==
 	// The consumer identifier
@@ -96,6 +104,18 @@ type Settings struct {
 
 	// the max len of stream
 	MaxLenApprox int64 `mapstructure:"maxLenApprox" only:"pubsub"`
+	// The amount of time a message must be pending, for any consumer of the group, before it's claimed with XAUTOCLAIM (0 disables auto-claiming and uses processingTimeout to reclaim messages of this consumer)
+	PendingIdleTimeout time.Duration `mapstructure:"pendingIdleTimeout" only:"pubsub"`
+	// The maximum number of messages claimed with each XAUTOCLAIM call (0 uses queueDepth)
+	ClaimBatchSize int64 `mapstructure:"claimBatchSize" only:"pubsub"`
+	// The approximate maximum length streams are trimmed to periodically (0 disables trimming)
+	MaxLen int64 `mapstructure:"maxLen" only:"pubsub"`
+	// The interval between trimming streams
+	TrimInterval time.Duration `mapstructure:"trimInterval" only:"pubsub"`
+	// The interval between reporting the lag of the consumer group of each stream (0 disables reporting)
+	LagReportInterval time.Duration `mapstructure:"lagReportInterval" only:"pubsub"`
+	// The amount of time other consumers of the group must be idle before their pending messages are claimed and they're deleted from the group (0 disables the cleanup)
+	ConsumerIdleTimeout time.Duration `mapstructure:"consumerIdleTimeout" only:"pubsub"`
 }
 
 func (s *Settings) Decode(in interface{}) error {
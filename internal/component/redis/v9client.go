@@ -16,6 +16,8 @@ package redis
 import (
 	"context"
 	"crypto/tls"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -32,11 +34,39 @@ func (p v9Pipeliner) Exec(ctx context.Context) error {
 	return err
 }
 
+func (p v9Pipeliner) ExecErrors(ctx context.Context) []error {
+	cmds, _ := p.pipeliner.Exec(ctx)
+	errs := make([]error, len(cmds))
+	for i, cmd := range cmds {
+		errs[i] = cmd.Err()
+	}
+	return errs
+}
+
+func (p v9Pipeliner) ExecResults(ctx context.Context) ([]interface{}, []error) {
+	cmds, _ := p.pipeliner.Exec(ctx)
+	vals := make([]interface{}, len(cmds))
+	errs := make([]error, len(cmds))
+	for i, cmd := range cmds {
+		c, ok := cmd.(*v9.Cmd)
+		if !ok {
+			errs[i] = fmt.Errorf("unexpected command type %T", cmd)
+			continue
+		}
+		vals[i], errs[i] = c.Result()
+		if errors.Is(errs[i], v9.Nil) {
+			errs[i] = nil
+		}
+	}
+	return vals, errs
+}
+
 func (p v9Pipeliner) Do(ctx context.Context, args ...interface{}) {
 	if p.writeTimeout > 0 {
 		timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(p.writeTimeout))
 		defer cancel()
 		p.pipeliner.Do(timeoutCtx, args...)
+		return
 	}
 	p.pipeliner.Do(ctx, args...)
 }
@@ -298,6 +328,48 @@ func (c v9Client) XClaimResult(ctx context.Context, stream string, group string,
 	return redisXMessages, nil
 }
 
+func (c v9Client) XAutoClaimResult(ctx context.Context, stream string, group string, consumer string, minIdleTime time.Duration, start string, count int64) ([]RedisXMessage, string, error) {
+	var writeCtx context.Context
+	if c.writeTimeout > 0 {
+		timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(c.writeTimeout))
+		defer cancel()
+		writeCtx = timeoutCtx
+	} else {
+		writeCtx = ctx
+	}
+	res, next, err := c.client.XAutoClaim(writeCtx, &v9.XAutoClaimArgs{
+		Stream:   stream,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdleTime,
+		Start:    start,
+		Count:    count,
+	}).Result()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// convert res to []RedisXMessage
+	redisXMessages := make([]RedisXMessage, len(res))
+	for i, xMessage := range res {
+		redisXMessages[i] = RedisXMessage(xMessage)
+	}
+
+	return redisXMessages, next, nil
+}
+
+func (c v9Client) XTrimMaxLenApprox(ctx context.Context, stream string, maxLen int64) error {
+	var writeCtx context.Context
+	if c.writeTimeout > 0 {
+		timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(c.writeTimeout))
+		defer cancel()
+		writeCtx = timeoutCtx
+	} else {
+		writeCtx = ctx
+	}
+	return c.client.XTrimMaxLenApprox(writeCtx, stream, maxLen, 0).Err()
+}
+
 func (c v9Client) TxPipeline() RedisPipeliner {
 	return v9Pipeliner{
 		pipeliner:    c.client.TxPipeline(),
@@ -305,6 +377,13 @@ func (c v9Client) TxPipeline() RedisPipeliner {
 	}
 }
 
+func (c v9Client) Pipeline() RedisPipeliner {
+	return v9Pipeliner{
+		pipeliner:    c.client.Pipeline(),
+		writeTimeout: c.writeTimeout,
+	}
+}
+
 func (c v9Client) TTLResult(ctx context.Context, key string) (time.Duration, error) {
 	var writeCtx context.Context
 	if c.writeTimeout > 0 {
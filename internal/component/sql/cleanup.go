@@ -50,6 +50,10 @@ type GCOptions struct {
 	// Query that performs the cleanup of all expired rows.
 	DeleteExpiredValuesQuery string
 
+	// If greater than zero, DeleteExpiredValuesQuery deletes at most this number of rows at once.
+	// The query is then executed repeatedly, each time in its own transaction, until it deletes fewer rows.
+	DeleteExpiredValuesBatchSize int64
+
 	// Interval to perfm the cleanup.
 	CleanupInterval time.Duration
 
@@ -76,6 +80,7 @@ type gc struct {
 	updateLastCleanupQuery   string
 	ulcqParamName            string
 	deleteExpiredValuesQuery string
+	batchSize                int64
 	cleanupInterval          time.Duration
 	dbPgx                    PgxConn
 	dbSQL                    DatabaseSQLConn
@@ -102,6 +107,7 @@ func ScheduleGarbageCollector(opts GCOptions) (GarbageCollector, error) {
 		updateLastCleanupQuery:   opts.UpdateLastCleanupQuery,
 		ulcqParamName:            opts.UpdateLastCleanupQueryParameterName,
 		deleteExpiredValuesQuery: opts.DeleteExpiredValuesQuery,
+		batchSize:                opts.DeleteExpiredValuesBatchSize,
 		cleanupInterval:          opts.CleanupInterval,
 		dbPgx:                    opts.DBPgx,
 		dbSQL:                    opts.DBSql,
@@ -167,21 +173,40 @@ func (g *gc) CleanupExpired() error {
 		return nil
 	}
 
+	var rowsAffected int64
+	for {
+		n, err := g.deleteExpired(ctx)
+		rowsAffected += n
+		if err != nil {
+			return err
+		}
+		if g.batchSize <= 0 || n < g.batchSize {
+			break
+		}
+	}
+
+	g.log.Infof("Removed %d expired rows", rowsAffected)
+	return nil
+}
+
+// deleteExpired executes the query that deletes the expired rows in a transaction, and returns the number of deleted rows.
+func (g *gc) deleteExpired(ctx context.Context) (int64, error) {
 	var (
 		tx   pgx.Tx
 		txwc *sql.Tx
+		err  error
 	)
 
 	if g.dbPgx != nil {
 		tx, err = g.dbPgx.Begin(ctx)
 		if err != nil {
-			return fmt.Errorf("failed to start transaction: %w", err)
+			return 0, fmt.Errorf("failed to start transaction: %w", err)
 		}
 		defer tx.Rollback(ctx)
 	} else {
 		txwc, err = g.dbSQL.BeginTx(ctx, nil)
 		if err != nil {
-			return fmt.Errorf("failed to start transaction: %w", err)
+			return 0, fmt.Errorf("failed to start transaction: %w", err)
 		}
 		defer txwc.Rollback()
 	}
@@ -191,18 +216,18 @@ func (g *gc) CleanupExpired() error {
 		var res pgconn.CommandTag
 		res, err = tx.Exec(ctx, g.deleteExpiredValuesQuery)
 		if err != nil {
-			return fmt.Errorf("failed to execute query: %w", err)
+			return 0, fmt.Errorf("failed to execute query: %w", err)
 		}
 		rowsAffected = res.RowsAffected()
 	} else {
 		var res sql.Result
 		res, err = txwc.ExecContext(ctx, g.deleteExpiredValuesQuery)
 		if err != nil {
-			return fmt.Errorf("failed to execute query: %w", err)
+			return 0, fmt.Errorf("failed to execute query: %w", err)
 		}
 		rowsAffected, err = res.RowsAffected()
 		if err != nil {
-			return fmt.Errorf("failed to get rows affected: %w", err)
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
 		}
 	}
 
@@ -213,11 +238,10 @@ func (g *gc) CleanupExpired() error {
 		err = txwc.Commit()
 	}
 	if err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	g.log.Infof("Removed %d expired rows", rowsAffected)
-	return nil
+	return rowsAffected, nil
 }
 
 // updateLastCleanup sets the 'last-cleanup' value only if it's less than cleanupInterval.
@@ -0,0 +1,61 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"time"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+// TimeoutMetadataKey is the key of the request metadata that overrides the timeout of an operation, in seconds.
+const TimeoutMetadataKey = state.TimeoutMetadataKey
+
+// OperationTimeout returns the timeout of an operation: the value of TimeoutMetadataKey in the metadata of the request if set, or defaultTimeout otherwise.
+func OperationTimeout(reqMetadata map[string]string, defaultTimeout time.Duration) (time.Duration, error) {
+	return state.RequestTimeout(reqMetadata, defaultTimeout)
+}
+
+// OperationContext returns a context for an operation that is canceled when parentCtx is, or when the timeout of the operation returned by OperationTimeout elapses.
+// A zero timeout means no timeout.
+func OperationContext(parentCtx context.Context, reqMetadata map[string]string, defaultTimeout time.Duration) (context.Context, context.CancelFunc, error) {
+	return state.RequestContext(parentCtx, reqMetadata, defaultTimeout)
+}
+
+// SlowQueryLogger logs the operations that take longer than a threshold.
+type SlowQueryLogger struct {
+	Logger logger.Logger
+	// Operations taking longer than this are logged; zero disables logging.
+	Threshold time.Duration
+}
+
+// Track logs the operation if the time elapsed since start exceeds the threshold.
+// It's meant to be deferred at the beginning of the operation: `defer l.Track("Get", key, time.Now())`.
+// key is empty for operations that aren't on a single key.
+func (l SlowQueryLogger) Track(operation string, key string, start time.Time) {
+	if l.Threshold <= 0 || l.Logger == nil {
+		return
+	}
+	elapsed := time.Since(start)
+	if elapsed <= l.Threshold {
+		return
+	}
+	if key != "" {
+		l.Logger.Warnf("Slow %s operation on key '%s': took %v, which exceeds the threshold of %v", operation, key, elapsed, l.Threshold)
+	} else {
+		l.Logger.Warnf("Slow %s operation: took %v, which exceeds the threshold of %v", operation, elapsed, l.Threshold)
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperationTimeout(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		timeout, err := OperationTimeout(nil, 20*time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, 20*time.Second, timeout)
+	})
+
+	t.Run("overridden by the request", func(t *testing.T) {
+		timeout, err := OperationTimeout(map[string]string{TimeoutMetadataKey: "5"}, 20*time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Second, timeout)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, v := range []string{"0", "-1", "abc"} {
+			_, err := OperationTimeout(map[string]string{TimeoutMetadataKey: v}, 20*time.Second)
+			assert.Error(t, err, v)
+		}
+	})
+}
+
+func TestOperationContext(t *testing.T) {
+	t.Run("with timeout", func(t *testing.T) {
+		ctx, cancel, err := OperationContext(context.Background(), map[string]string{TimeoutMetadataKey: "5"}, 20*time.Second)
+		require.NoError(t, err)
+		defer cancel()
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(5*time.Second), deadline, time.Second)
+	})
+
+	t.Run("without timeout", func(t *testing.T) {
+		ctx, cancel, err := OperationContext(context.Background(), nil, 0)
+		require.NoError(t, err)
+		defer cancel()
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+	})
+
+	t.Run("parent canceled", func(t *testing.T) {
+		parentCtx, parentCancel := context.WithCancel(context.Background())
+		ctx, cancel, err := OperationContext(parentCtx, nil, 20*time.Second)
+		require.NoError(t, err)
+		defer cancel()
+		parentCancel()
+		<-ctx.Done()
+		assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	})
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/lock"
+	"github.com/dapr/components-contrib/metadata"
+)
+
+const (
+	defaultTableName         = "dapr_lock"
+	defaultMetadataTableName = "dapr_metadata"
+	defaultTimeout           = 20 * time.Second
+	defaultCleanupInterval   = 5 * time.Minute
+)
+
+type postgresLockMetadata struct {
+	// Connection string for the PostgreSQL database.
+	ConnectionString string `mapstructure:"connectionString"`
+	// Maximum time a connection can be idle before it's closed.
+	ConnectionMaxIdleTime time.Duration `mapstructure:"connectionMaxIdleTime"`
+	// Name of the table where locks are stored; could be in the format "schema.table" or just "table".
+	TableName string `mapstructure:"tableName"`
+	// Name of the table where the time of the last cleanup is stored; could be in the format "schema.table" or just "table".
+	MetadataTableName string `mapstructure:"metadataTableName"`
+	// Timeout for operations on the database.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Interval at which expired locks are deleted; set to 0 to disable the cleanup.
+	CleanupInterval time.Duration `mapstructure:"cleanupInterval"`
+}
+
+func (m *postgresLockMetadata) InitWithMetadata(meta lock.Metadata) error {
+	// Set defaults
+	m.TableName = defaultTableName
+	m.MetadataTableName = defaultMetadataTableName
+	m.Timeout = defaultTimeout
+	m.CleanupInterval = defaultCleanupInterval
+
+	// Decode the metadata
+	err := metadata.DecodeMetadata(meta.Properties, m)
+	if err != nil {
+		return err
+	}
+
+	// Validate and sanitize input
+	if m.ConnectionString == "" {
+		return errors.New("missing connection string")
+	}
+	if !isValidTableName(m.TableName) {
+		return fmt.Errorf("invalid table name '%s'", m.TableName)
+	}
+	if !isValidTableName(m.MetadataTableName) {
+		return fmt.Errorf("invalid metadata table name '%s'", m.MetadataTableName)
+	}
+	if m.Timeout < time.Second {
+		return errors.New("invalid value for 'timeout': must be at least 1s")
+	}
+	if m.CleanupInterval < 0 {
+		m.CleanupInterval = 0
+	}
+
+	return nil
+}
+
+// isValidTableName returns true if the name is a valid table name, optionally prefixed by the schema.
+func isValidTableName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, part := range strings.SplitN(name, ".", 2) {
+		if part == "" {
+			return false
+		}
+		for _, c := range part {
+			if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
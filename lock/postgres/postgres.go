@@ -0,0 +1,266 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	pginterfaces "github.com/dapr/components-contrib/internal/component/postgresql"
+	internalsql "github.com/dapr/components-contrib/internal/component/sql"
+	"github.com/dapr/components-contrib/lock"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+// Key in the metadata table with the time of the last cleanup of expired locks.
+const lastCleanupKey = "last-lock-cleanup"
+
+// PostgresLock is a lock store backed by PostgreSQL.
+// Locks are rows in a table, with the owner, an expiration time, and a fencing token that increases every time a lock is acquired.
+// Transaction-level advisory locks serialize concurrent attempts to acquire the same lock, so they don't need to wait on row locks.
+type PostgresLock struct {
+	metadata postgresLockMetadata
+	db       pginterfaces.PGXPoolConn
+	gc       internalsql.GarbageCollector
+	logger   logger.Logger
+}
+
+// NewPostgresLock returns a new PostgreSQL lock store.
+func NewPostgresLock(logger logger.Logger) lock.Store {
+	return &PostgresLock{
+		logger: logger,
+	}
+}
+
+// InitLockStore connects to the database and creates the tables if needed.
+func (p *PostgresLock) InitLockStore(ctx context.Context, metadata lock.Metadata) error {
+	err := p.metadata.InitWithMetadata(metadata)
+	if err != nil {
+		return err
+	}
+
+	config, err := pgxpool.ParseConfig(p.metadata.ConnectionString)
+	if err != nil {
+		return fmt.Errorf("failed to parse connection string: %w", err)
+	}
+	if p.metadata.ConnectionMaxIdleTime > 0 {
+		config.MaxConnIdleTime = p.metadata.ConnectionMaxIdleTime
+	}
+
+	connCtx, connCancel := context.WithTimeout(ctx, p.metadata.Timeout)
+	p.db, err = pgxpool.NewWithConfig(connCtx, config)
+	connCancel()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the database: %w", err)
+	}
+
+	pingCtx, pingCancel := context.WithTimeout(ctx, p.metadata.Timeout)
+	err = p.db.Ping(pingCtx)
+	pingCancel()
+	if err != nil {
+		return fmt.Errorf("failed to ping the database: %w", err)
+	}
+
+	err = p.ensureTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	return p.startGC()
+}
+
+func (p *PostgresLock) ensureTables(parentCtx context.Context) error {
+	ctx, cancel := context.WithTimeout(parentCtx, p.metadata.Timeout)
+	defer cancel()
+
+	_, err := p.db.Exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+			resource_id text NOT NULL PRIMARY KEY,
+			lock_owner text NOT NULL,
+			fencing_token bigint NOT NULL,
+			expiredate timestamp with time zone NOT NULL
+		);
+		CREATE SEQUENCE IF NOT EXISTS %[2]s;
+		CREATE TABLE IF NOT EXISTS %[3]s (
+			key text NOT NULL PRIMARY KEY,
+			value text NOT NULL
+		);`,
+		p.metadata.TableName, p.sequenceName(), p.metadata.MetadataTableName,
+	))
+	if err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+	return nil
+}
+
+// sequenceName returns the name of the sequence used for fencing tokens.
+func (p *PostgresLock) sequenceName() string {
+	return p.metadata.TableName + "_fencing_seq"
+}
+
+// startGC schedules the deletion of expired locks.
+// Expired locks can be acquired again even before they're deleted; this only prevents the table from growing.
+func (p *PostgresLock) startGC() error {
+	if p.metadata.CleanupInterval <= 0 {
+		return nil
+	}
+
+	gc, err := internalsql.ScheduleGarbageCollector(internalsql.GCOptions{
+		Logger: p.logger,
+		UpdateLastCleanupQuery: fmt.Sprintf(
+			`INSERT INTO %[1]s (key, value)
+			VALUES ('%[2]s', CURRENT_TIMESTAMP::text)
+			ON CONFLICT (key)
+			DO UPDATE SET value = CURRENT_TIMESTAMP::text
+				WHERE (EXTRACT('epoch' FROM CURRENT_TIMESTAMP - %[1]s.value::timestamp with time zone) * 1000)::bigint > $1`,
+			p.metadata.MetadataTableName, lastCleanupKey,
+		),
+		DeleteExpiredValuesQuery: fmt.Sprintf(
+			`DELETE FROM %s WHERE expiredate < CURRENT_TIMESTAMP`,
+			p.metadata.TableName,
+		),
+		CleanupInterval: p.metadata.CleanupInterval,
+		DBPgx:           p.db,
+	})
+	if err != nil {
+		return err
+	}
+	p.gc = gc
+	return nil
+}
+
+// TryLock tries to acquire a lock.
+// The lock is acquired if it doesn't exist or it has expired; if the owner already holds the lock, it isn't extended.
+func (p *PostgresLock) TryLock(parentCtx context.Context, req *lock.TryLockRequest) (*lock.TryLockResponse, error) {
+	if req.ResourceID == "" || req.LockOwner == "" {
+		return nil, errors.New("resourceId and lockOwner are required")
+	}
+	if req.ExpiryInSeconds <= 0 {
+		return nil, errors.New("expiryInSeconds must be greater than 0")
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, p.metadata.Timeout)
+	defer cancel()
+
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// If another transaction is trying to acquire the same lock, do not wait for it
+	var acquired bool
+	err = tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock(hashtextextended($1, 0))", req.ResourceID).Scan(&acquired)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+	if !acquired {
+		return &lock.TryLockResponse{Success: false}, nil
+	}
+
+	var fencingToken int64
+	err = tx.QueryRow(ctx, fmt.Sprintf(
+		`INSERT INTO %[1]s (resource_id, lock_owner, fencing_token, expiredate)
+		VALUES ($1, $2, nextval('%[2]s'), CURRENT_TIMESTAMP + $3::bigint * interval '1 second')
+		ON CONFLICT (resource_id)
+		DO UPDATE SET
+			lock_owner = EXCLUDED.lock_owner,
+			fencing_token = EXCLUDED.fencing_token,
+			expiredate = EXCLUDED.expiredate
+		WHERE %[1]s.expiredate < CURRENT_TIMESTAMP
+		RETURNING fencing_token`,
+		p.metadata.TableName, p.sequenceName(),
+	), req.ResourceID, req.LockOwner, req.ExpiryInSeconds).Scan(&fencingToken)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The lock is held by someone and has not expired
+		return &lock.TryLockResponse{Success: false}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	p.logger.Debugf("Acquired lock %s for owner %s with fencing token %d", req.ResourceID, req.LockOwner, fencingToken)
+	return &lock.TryLockResponse{Success: true}, nil
+}
+
+// Unlock releases a lock if it's held by the owner.
+func (p *PostgresLock) Unlock(parentCtx context.Context, req *lock.UnlockRequest) (*lock.UnlockResponse, error) {
+	if req.ResourceID == "" || req.LockOwner == "" {
+		return nil, errors.New("resourceId and lockOwner are required")
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, p.metadata.Timeout)
+	defer cancel()
+
+	// The sub-query on the table sees the row as it was before the deletion
+	var (
+		deleted int64
+		owner   *string
+	)
+	err := p.db.QueryRow(ctx, fmt.Sprintf(
+		`WITH deleted AS (
+			DELETE FROM %[1]s
+			WHERE resource_id = $1 AND lock_owner = $2 AND expiredate >= CURRENT_TIMESTAMP
+			RETURNING 1
+		)
+		SELECT
+			(SELECT count(*) FROM deleted),
+			(SELECT lock_owner FROM %[1]s WHERE resource_id = $1 AND expiredate >= CURRENT_TIMESTAMP)`,
+		p.metadata.TableName,
+	), req.ResourceID, req.LockOwner).Scan(&deleted, &owner)
+	if err != nil {
+		return &lock.UnlockResponse{Status: lock.InternalError}, fmt.Errorf("failed to release lock: %w", err)
+	}
+
+	switch {
+	case deleted > 0:
+		return &lock.UnlockResponse{Status: lock.Success}, nil
+	case owner == nil:
+		return &lock.UnlockResponse{Status: lock.LockDoesNotExist}, nil
+	default:
+		return &lock.UnlockResponse{Status: lock.LockBelongsToOthers}, nil
+	}
+}
+
+// Close stops the garbage collector and closes the connections to the database.
+func (p *PostgresLock) Close() error {
+	var errs []error
+	if p.gc != nil {
+		errs = append(errs, p.gc.Close())
+		p.gc = nil
+	}
+	if p.db != nil {
+		p.db.Close()
+		p.db = nil
+	}
+	return errors.Join(errs...)
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (p *PostgresLock) GetComponentMetadata() map[string]string {
+	metadataStruct := postgresLockMetadata{}
+	metadataInfo := map[string]string{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.LockStoreType)
+	return metadataInfo
+}
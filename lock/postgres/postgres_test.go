@@ -0,0 +1,197 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	pgxmock "github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/lock"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/logger"
+)
+
+func mockLockStore(t *testing.T) (*PostgresLock, pgxmock.PgxPoolIface) {
+	t.Helper()
+
+	db, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	store := &PostgresLock{
+		metadata: postgresLockMetadata{
+			TableName:         defaultTableName,
+			MetadataTableName: defaultMetadataTableName,
+			Timeout:           defaultTimeout,
+		},
+		db:     db,
+		logger: logger.NewLogger("test"),
+	}
+	return store, db
+}
+
+func TestMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := postgresLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"connectionString": "postgres://localhost",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, defaultTableName, m.TableName)
+		assert.Equal(t, defaultMetadataTableName, m.MetadataTableName)
+		assert.Equal(t, defaultTimeout, m.Timeout)
+		assert.Equal(t, defaultCleanupInterval, m.CleanupInterval)
+	})
+
+	t.Run("custom values", func(t *testing.T) {
+		m := postgresLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"connectionString": "postgres://localhost",
+			"tableName":        "myschema.locks",
+			"timeout":          "5s",
+			"cleanupInterval":  "-1",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "myschema.locks", m.TableName)
+		assert.Equal(t, 5*time.Second, m.Timeout)
+		assert.Equal(t, time.Duration(0), m.CleanupInterval)
+	})
+
+	t.Run("missing connection string", func(t *testing.T) {
+		m := postgresLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid table name", func(t *testing.T) {
+		m := postgresLockMetadata{}
+		err := m.InitWithMetadata(lock.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"connectionString": "postgres://localhost",
+			"tableName":        "locks; DROP TABLE foo",
+		}}})
+		require.Error(t, err)
+	})
+}
+
+func TestTryLock(t *testing.T) {
+	req := &lock.TryLockRequest{
+		ResourceID:      "resource",
+		LockOwner:       "owner",
+		ExpiryInSeconds: 10,
+	}
+
+	t.Run("acquired", func(t *testing.T) {
+		store, db := mockLockStore(t)
+		defer db.Close()
+
+		db.ExpectBegin()
+		db.ExpectQuery("SELECT pg_try_advisory_xact_lock").
+			WithArgs("resource").
+			WillReturnRows(pgxmock.NewRows([]string{"acquired"}).AddRow(true))
+		db.ExpectQuery("INSERT INTO dapr_lock").
+			WithArgs("resource", "owner", int32(10)).
+			WillReturnRows(pgxmock.NewRows([]string{"fencing_token"}).AddRow(int64(1)))
+		db.ExpectCommit()
+		// There's also a rollback called after a commit, which is expected and will not have effect
+		db.ExpectRollback()
+
+		res, err := store.TryLock(context.Background(), req)
+		require.NoError(t, err)
+		assert.True(t, res.Success)
+		assert.NoError(t, db.ExpectationsWereMet())
+	})
+
+	t.Run("advisory lock busy", func(t *testing.T) {
+		store, db := mockLockStore(t)
+		defer db.Close()
+
+		db.ExpectBegin()
+		db.ExpectQuery("SELECT pg_try_advisory_xact_lock").
+			WithArgs("resource").
+			WillReturnRows(pgxmock.NewRows([]string{"acquired"}).AddRow(false))
+		db.ExpectRollback()
+
+		res, err := store.TryLock(context.Background(), req)
+		require.NoError(t, err)
+		assert.False(t, res.Success)
+		assert.NoError(t, db.ExpectationsWereMet())
+	})
+
+	t.Run("held by another owner", func(t *testing.T) {
+		store, db := mockLockStore(t)
+		defer db.Close()
+
+		db.ExpectBegin()
+		db.ExpectQuery("SELECT pg_try_advisory_xact_lock").
+			WithArgs("resource").
+			WillReturnRows(pgxmock.NewRows([]string{"acquired"}).AddRow(true))
+		db.ExpectQuery("INSERT INTO dapr_lock").
+			WithArgs("resource", "owner", int32(10)).
+			WillReturnError(pgx.ErrNoRows)
+		db.ExpectRollback()
+
+		res, err := store.TryLock(context.Background(), req)
+		require.NoError(t, err)
+		assert.False(t, res.Success)
+		assert.NoError(t, db.ExpectationsWereMet())
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		store, db := mockLockStore(t)
+		defer db.Close()
+
+		_, err := store.TryLock(context.Background(), &lock.TryLockRequest{ResourceID: "resource", LockOwner: "owner"})
+		require.Error(t, err)
+	})
+}
+
+func TestUnlock(t *testing.T) {
+	req := &lock.UnlockRequest{
+		ResourceID: "resource",
+		LockOwner:  "owner",
+	}
+	other := "other"
+
+	tests := []struct {
+		name    string
+		deleted int64
+		owner   *string
+		status  lock.Status
+	}{
+		{name: "released", deleted: 1, owner: &req.LockOwner, status: lock.Success},
+		{name: "does not exist", deleted: 0, owner: nil, status: lock.LockDoesNotExist},
+		{name: "belongs to others", deleted: 0, owner: &other, status: lock.LockBelongsToOthers},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, db := mockLockStore(t)
+			defer db.Close()
+
+			db.ExpectQuery("WITH deleted AS").
+				WithArgs("resource", "owner").
+				WillReturnRows(pgxmock.NewRows([]string{"count", "lock_owner"}).AddRow(tt.deleted, tt.owner))
+
+			res, err := store.Unlock(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, res.Status)
+			assert.NoError(t, db.ExpectationsWereMet())
+		})
+	}
+}
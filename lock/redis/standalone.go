@@ -15,12 +15,15 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
 	"time"
 
+	"golang.org/x/exp/slices"
+
 	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
 	"github.com/dapr/components-contrib/lock"
 	contribMetadata "github.com/dapr/components-contrib/metadata"
@@ -28,7 +31,11 @@ import (
 )
 
 const (
-	unlockScript             = "local v = redis.call(\"get\",KEYS[1]); if v==false then return -1 end; if v~=ARGV[1] then return -2 else return redis.call(\"del\",KEYS[1]) end"
+	unlockScript = "local v = redis.call(\"get\",KEYS[1]); if v==false then return -1 end; if v~=ARGV[1] then return -2 else return redis.call(\"del\",KEYS[1]) end"
+	// Returns the 1-based index of the first key that is already locked, or 0 after locking all keys.
+	bulkLockScript = "for i, k in ipairs(KEYS) do if redis.call(\"exists\",k)==1 then return i end end; for _, k in ipairs(KEYS) do redis.call(\"set\",k,ARGV[1],\"PX\",ARGV[2]) end; return 0"
+	// Returns -i if the i-th key (1-based) doesn't exist, i if it belongs to others, or 0 after releasing all keys.
+	bulkUnlockScript         = "for i, k in ipairs(KEYS) do local v = redis.call(\"get\",k); if v==false then return -i end; if v~=ARGV[1] then return i end end; redis.call(\"del\",unpack(KEYS)); return 0"
 	connectedSlavesReplicas  = "connected_slaves:"
 	infoReplicationDelimiter = "\r\n"
 )
@@ -166,6 +173,85 @@ func (r *StandaloneRedisLock) Unlock(ctx context.Context, req *lock.UnlockReques
 	}, nil
 }
 
+// Try to acquire a group of redis locks atomically.
+func (r *StandaloneRedisLock) BulkTryLock(ctx context.Context, req *lock.BulkTryLockRequest) (*lock.BulkTryLockResponse, error) {
+	keys, err := sortedResourceIDs(req.ResourceIDs)
+	if err != nil {
+		return &lock.BulkTryLockResponse{}, err
+	}
+	if req.ExpiryInSeconds <= 0 {
+		return &lock.BulkTryLockResponse{}, errors.New("[standaloneRedisLock]: expiryInSeconds must be greater than 0")
+	}
+
+	// 1. delegate to client.eval lua script, which locks the keys in order
+	expiry := (time.Second * time.Duration(req.ExpiryInSeconds)).Milliseconds()
+	evalInt, parseErr, err := r.client.EvalInt(ctx, bulkLockScript, keys, req.LockOwner, expiry)
+	// 2. check error
+	if evalInt == nil {
+		return &lock.BulkTryLockResponse{}, fmt.Errorf("[standaloneRedisLock]: Eval bulk lock script returned nil.ResourceIDs: %v", keys)
+	}
+	if parseErr != nil {
+		return &lock.BulkTryLockResponse{}, err
+	}
+	// 3. parse result
+	i := *evalInt
+	if i == 0 {
+		return &lock.BulkTryLockResponse{
+			Success: true,
+		}, nil
+	}
+	if i < 0 || i > len(keys) {
+		return &lock.BulkTryLockResponse{}, fmt.Errorf("[standaloneRedisLock]: Eval bulk lock script returned unexpected value %d", i)
+	}
+	return &lock.BulkTryLockResponse{
+		ConflictingResourceID: keys[i-1],
+	}, nil
+}
+
+// Try to release a group of redis locks atomically.
+func (r *StandaloneRedisLock) BulkUnlock(ctx context.Context, req *lock.BulkUnlockRequest) (*lock.BulkUnlockResponse, error) {
+	keys, err := sortedResourceIDs(req.ResourceIDs)
+	if err != nil {
+		return &lock.BulkUnlockResponse{Status: lock.InternalError}, err
+	}
+
+	// 1. delegate to client.eval lua script
+	evalInt, parseErr, err := r.client.EvalInt(ctx, bulkUnlockScript, keys, req.LockOwner)
+	// 2. check error
+	if evalInt == nil {
+		return &lock.BulkUnlockResponse{Status: lock.InternalError}, fmt.Errorf("[standaloneRedisLock]: Eval bulk unlock script returned nil.ResourceIDs: %v", keys)
+	}
+	if parseErr != nil {
+		return &lock.BulkUnlockResponse{Status: lock.InternalError}, err
+	}
+	// 3. parse result
+	i := *evalInt
+	switch {
+	case i == 0:
+		return &lock.BulkUnlockResponse{Status: lock.Success}, nil
+	case i < 0 && -i <= len(keys):
+		return &lock.BulkUnlockResponse{Status: lock.LockDoesNotExist, FailedResourceID: keys[-i-1]}, nil
+	case i > 0 && i <= len(keys):
+		return &lock.BulkUnlockResponse{Status: lock.LockBelongsToOthers, FailedResourceID: keys[i-1]}, nil
+	default:
+		return &lock.BulkUnlockResponse{Status: lock.InternalError}, fmt.Errorf("[standaloneRedisLock]: Eval bulk unlock script returned unexpected value %d", i)
+	}
+}
+
+// sortedResourceIDs returns the resource IDs sorted and without duplicates, so groups of locks are always acquired in the same order.
+func sortedResourceIDs(resourceIDs []string) ([]string, error) {
+	if len(resourceIDs) == 0 {
+		return nil, errors.New("[standaloneRedisLock]: resourceIds is empty")
+	}
+	keys := slices.Clone(resourceIDs)
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+	if keys[0] == "" {
+		return nil, errors.New("[standaloneRedisLock]: resourceIds contains an empty ID")
+	}
+	return keys, nil
+}
+
 func newInternalErrorUnlockResponse() *lock.UnlockResponse {
 	return &lock.UnlockResponse{
 		Status: lock.InternalError,
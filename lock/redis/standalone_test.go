@@ -17,10 +17,12 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
 
 	miniredis "github.com/alicebob/miniredis/v2"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/lock"
 	"github.com/dapr/components-contrib/metadata"
@@ -151,3 +153,97 @@ func TestStandaloneRedisLock_TryLock(t *testing.T) {
 	}()
 	wg.Wait()
 }
+
+func TestStandaloneRedisLock_BulkTryLock(t *testing.T) {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	defer s.Close()
+
+	comp := NewStandaloneRedisLock(logger.NewLogger("test")).(*StandaloneRedisLock)
+	defer comp.Close()
+
+	cfg := lock.Metadata{Base: metadata.Base{
+		Properties: map[string]string{
+			"redisHost": s.Addr(),
+		},
+	}}
+	err = comp.InitLockStore(context.Background(), cfg)
+	require.NoError(t, err)
+
+	owner1 := uuid.New().String()
+	owner2 := uuid.New().String()
+
+	t.Run("acquire group", func(t *testing.T) {
+		resp, err := comp.BulkTryLock(context.Background(), &lock.BulkTryLockRequest{
+			ResourceIDs:     []string{"res-b", "res-a", "res-b"},
+			LockOwner:       owner1,
+			ExpiryInSeconds: 10,
+		})
+		require.NoError(t, err)
+		assert.True(t, resp.Success)
+
+		v, err := s.Get("res-a")
+		require.NoError(t, err)
+		assert.Equal(t, owner1, v)
+		assert.Equal(t, 10*time.Second, s.TTL("res-b"))
+	})
+
+	t.Run("all or nothing", func(t *testing.T) {
+		resp, err := comp.BulkTryLock(context.Background(), &lock.BulkTryLockRequest{
+			ResourceIDs:     []string{"res-c", "res-b"},
+			LockOwner:       owner2,
+			ExpiryInSeconds: 10,
+		})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Equal(t, "res-b", resp.ConflictingResourceID)
+		assert.False(t, s.Exists("res-c"))
+	})
+
+	t.Run("unlock by others", func(t *testing.T) {
+		resp, err := comp.BulkUnlock(context.Background(), &lock.BulkUnlockRequest{
+			ResourceIDs: []string{"res-a", "res-b"},
+			LockOwner:   owner2,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, lock.LockBelongsToOthers, resp.Status)
+		assert.Equal(t, "res-a", resp.FailedResourceID)
+		assert.True(t, s.Exists("res-a"))
+	})
+
+	t.Run("unlock missing lock", func(t *testing.T) {
+		resp, err := comp.BulkUnlock(context.Background(), &lock.BulkUnlockRequest{
+			ResourceIDs: []string{"res-a", "res-c"},
+			LockOwner:   owner1,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, lock.LockDoesNotExist, resp.Status)
+		assert.Equal(t, "res-c", resp.FailedResourceID)
+		assert.True(t, s.Exists("res-a"))
+	})
+
+	t.Run("unlock group", func(t *testing.T) {
+		resp, err := comp.BulkUnlock(context.Background(), &lock.BulkUnlockRequest{
+			ResourceIDs: []string{"res-a", "res-b"},
+			LockOwner:   owner1,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, lock.Success, resp.Status)
+		assert.False(t, s.Exists("res-a"))
+		assert.False(t, s.Exists("res-b"))
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, err := comp.BulkTryLock(context.Background(), &lock.BulkTryLockRequest{
+			LockOwner:       owner1,
+			ExpiryInSeconds: 10,
+		})
+		assert.Error(t, err)
+
+		_, err = comp.BulkTryLock(context.Background(), &lock.BulkTryLockRequest{
+			ResourceIDs: []string{"res-a"},
+			LockOwner:   owner1,
+		})
+		assert.Error(t, err)
+	})
+}
@@ -25,3 +25,16 @@ type UnlockRequest struct {
 	ResourceID string `json:"resourceId"`
 	LockOwner  string `json:"lockOwner"`
 }
+
+// BulkTryLockRequest is a request to acquire a group of locks at once.
+type BulkTryLockRequest struct {
+	ResourceIDs     []string `json:"resourceIds"`
+	LockOwner       string   `json:"lockOwner"`
+	ExpiryInSeconds int32    `json:"expiryInSeconds"`
+}
+
+// BulkUnlockRequest is a request to release a group of locks at once.
+type BulkUnlockRequest struct {
+	ResourceIDs []string `json:"resourceIds"`
+	LockOwner   string   `json:"lockOwner"`
+}
@@ -18,11 +18,25 @@ type TryLockResponse struct {
 	Success bool `json:"success"`
 }
 
+// Group of locks acquire request was successful or not.
+type BulkTryLockResponse struct {
+	Success bool `json:"success"`
+	// ID of a resource that was already locked, when the request was not successful.
+	ConflictingResourceID string `json:"conflictingResourceId,omitempty"`
+}
+
 // Status when releasing the lock.
 type UnlockResponse struct {
 	Status Status `json:"status"`
 }
 
+// Status when releasing a group of locks.
+type BulkUnlockResponse struct {
+	Status Status `json:"status"`
+	// ID of the resource that caused the status, when the locks were not released.
+	FailedResourceID string `json:"failedResourceId,omitempty"`
+}
+
 type Status int32
 
 // lock status.
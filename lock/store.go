@@ -28,3 +28,23 @@ type Store interface {
 	// GetComponentMetadata returns information on the component's metadata.
 	GetComponentMetadata() map[string]string
 }
+
+// BulkStore is implemented by lock stores that can acquire and release several locks at once.
+type BulkStore interface {
+	// BulkTryLock tries to acquire all the locks in the request.
+	// Either all locks are acquired, or none is.
+	BulkTryLock(ctx context.Context, req *BulkTryLockRequest) (*BulkTryLockResponse, error)
+
+	// BulkUnlock tries to release all the locks in the request.
+	// Either all locks are released, or none is.
+	BulkUnlock(ctx context.Context, req *BulkUnlockRequest) (*BulkUnlockResponse, error)
+}
+
+// StoreResolver returns the lock store component with the given name.
+type StoreResolver func(name string) (Store, error)
+
+// StoreResolverSetter is implemented by components that use other lock store components, for example to coordinate replicas.
+// The runtime sets the resolver after creating the component.
+type StoreResolverSetter interface {
+	SetLockStoreResolver(resolver StoreResolver)
+}
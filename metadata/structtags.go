@@ -0,0 +1,352 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapr/kit/logger"
+)
+
+// Struct tags that control how DecodeMetadata parses a field, in addition to "mapstructure".
+const (
+	// TagRequired makes the property required: `required:"true"`.
+	TagRequired = "required"
+	// TagDefault contains the value used when the property is not set or is empty: `default:"10s"`.
+	TagDefault = "default"
+	// TagMinValue contains the minimum value, inclusive, of a numeric or duration property: `minValue:"1"`.
+	TagMinValue = "minValue"
+	// TagMaxValue contains the maximum value, inclusive, of a numeric or duration property: `maxValue:"1m"`.
+	TagMaxValue = "maxValue"
+	// TagDeprecatedAliases contains a comma-separated list of keys that were previously used for the property.
+	// They are still accepted when the property is not set, but a deprecation warning is logged.
+	TagDeprecatedAliases = "deprecatedAliases"
+)
+
+var log = logger.NewLogger("dapr.contrib.metadata")
+
+// MetadataFieldSchema is the machine-readable description of a metadata property, generated from the struct that the property is decoded into.
+// The names of the fields match those used in the metadata.yaml files.
+type MetadataFieldSchema struct {
+	Name              string   `json:"name" yaml:"name"`
+	Type              string   `json:"type,omitempty" yaml:"type,omitempty"`
+	Required          bool     `json:"required,omitempty" yaml:"required,omitempty"`
+	Default           string   `json:"default,omitempty" yaml:"default,omitempty"`
+	MinValue          string   `json:"minValue,omitempty" yaml:"minValue,omitempty"`
+	MaxValue          string   `json:"maxValue,omitempty" yaml:"maxValue,omitempty"`
+	DeprecatedAliases []string `json:"deprecatedAliases,omitempty" yaml:"deprecatedAliases,omitempty"`
+}
+
+// SchemaReporter is the interface implemented by components that report the schema of their metadata properties.
+// The metadata analyzer in the build tools uses it to check that the metadata.yaml file of the component matches the struct tags.
+type SchemaReporter interface {
+	// GetComponentMetadataSchema returns the schema of the metadata properties of the component, usually generated with GetMetadataSchemaFromStructType.
+	GetComponentMetadataSchema() []MetadataFieldSchema
+}
+
+// GetMetadataSchemaFromStructType returns the schema of the metadata properties of a struct, including the constraints set with struct tags.
+// This is used to generate metadata documentation and validation rules for components.
+func GetMetadataSchemaFromStructType(t reflect.Type, componentType ComponentType) ([]MetadataFieldSchema, error) {
+	fields, err := metadataFields(t, componentType)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]MetadataFieldSchema, len(fields))
+	for i, f := range fields {
+		res[i] = MetadataFieldSchema{
+			Name:              f.name,
+			Type:              schemaType(f.field.Type),
+			Required:          f.required,
+			MinValue:          f.minValue,
+			MaxValue:          f.maxValue,
+			DeprecatedAliases: f.deprecatedAliases,
+		}
+		if f.defaultValue != nil {
+			res[i].Default = *f.defaultValue
+		}
+	}
+	return res, nil
+}
+
+// schemaType returns the type of a property as used in the metadata.yaml files, or an empty string for strings.
+func schemaType(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == reflect.TypeOf(time.Duration(0)) || t == reflect.TypeOf(Duration{}):
+		return "duration"
+	case t.Kind() == reflect.Bool:
+		return "bool"
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Float64:
+		return "number"
+	default:
+		return ""
+	}
+}
+
+// metadataField is a field of a struct that can be set from the metadata.
+type metadataField struct {
+	name  string
+	index []int
+	field reflect.StructField
+
+	required          bool
+	defaultValue      *string
+	minValue          string
+	maxValue          string
+	deprecatedAliases []string
+}
+
+// hasTags returns true if any of the struct tags that are processed by DecodeMetadata is set on the field.
+func (f metadataField) hasTags() bool {
+	return f.required || f.defaultValue != nil || f.minValue != "" || f.maxValue != "" || len(f.deprecatedAliases) > 0
+}
+
+// metadataFields returns the fields of a struct that can be set via the mapstructure metadata decoding mechanism, including those of squashed embedded structs.
+// Fields with an "only" tag that doesn't include componentType are skipped; if componentType is empty, all of them are.
+func metadataFields(t reflect.Type, componentType ComponentType) ([]metadataField, error) {
+	return collectMetadataFields(t, componentType, false)
+}
+
+// decodableMetadataFields returns the fields of a struct that can be set via the mapstructure metadata decoding mechanism, including those with an "only" tag.
+func decodableMetadataFields(t reflect.Type) ([]metadataField, error) {
+	return collectMetadataFields(t, "", true)
+}
+
+func collectMetadataFields(t reflect.Type, componentType ComponentType, allTypes bool) ([]metadataField, error) {
+	// Return if not struct or pointer to struct.
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("not a struct: %s", t.Kind().String())
+	}
+
+	res := []metadataField{}
+	for i := 0; i < t.NumField(); i++ {
+		currentField := t.Field(i)
+		// fields that are not exported cannot be set via the mapstructure metadata decoding mechanism
+		if !currentField.IsExported() {
+			continue
+		}
+		mapStructureTag := currentField.Tag.Get("mapstructure")
+		// we are not exporting this field using the mapstructure tag mechanism
+		if mapStructureTag == "-" {
+			continue
+		}
+		onlyTag := currentField.Tag.Get("only")
+		if !allTypes && onlyTag != "" {
+			include := false
+			onlyTags := strings.Split(onlyTag, ",")
+			for _, tag := range onlyTags {
+				if tag == string(componentType) {
+					include = true
+					break
+				}
+			}
+			if !include {
+				continue
+			}
+		}
+		mapStructureTags := strings.Split(mapStructureTag, ",")
+		numTags := len(mapStructureTags)
+		if numTags > 1 && mapStructureTags[numTags-1] == "squash" && currentField.Anonymous {
+			// traverse embedded struct
+			embedded, err := collectMetadataFields(currentField.Type, componentType, allTypes)
+			if err != nil {
+				continue
+			}
+			for _, f := range embedded {
+				f.index = append([]int{i}, f.index...)
+				res = append(res, f)
+			}
+			continue
+		}
+
+		f := metadataField{
+			name:     currentField.Name,
+			index:    []int{i},
+			field:    currentField,
+			required: currentField.Tag.Get(TagRequired) == "true",
+			minValue: currentField.Tag.Get(TagMinValue),
+			maxValue: currentField.Tag.Get(TagMaxValue),
+		}
+		if numTags > 0 && mapStructureTags[0] != "" {
+			f.name = mapStructureTags[0]
+		}
+		if val, ok := currentField.Tag.Lookup(TagDefault); ok {
+			f.defaultValue = &val
+		}
+		for _, alias := range strings.Split(currentField.Tag.Get(TagDeprecatedAliases), ",") {
+			alias = strings.TrimSpace(alias)
+			if alias != "" {
+				f.deprecatedAliases = append(f.deprecatedAliases, alias)
+			}
+		}
+		res = append(res, f)
+	}
+
+	return res, nil
+}
+
+// applyFieldTags returns a copy of the properties with the deprecated aliases replaced and the default values set.
+// It returns an error if a required property is not set.
+func applyFieldTags(props map[string]string, fields []metadataField) (map[string]string, error) {
+	res := make(map[string]string, len(props))
+	// Keys are matched case-insensitively, like mapstructure does
+	keys := make(map[string]string, len(props))
+	for k, v := range props {
+		res[k] = v
+		keys[strings.ToLower(k)] = k
+	}
+	isSet := func(name string) bool {
+		k, ok := keys[strings.ToLower(name)]
+		return ok && res[k] != ""
+	}
+
+	var errs []error
+	for _, f := range fields {
+		if !isSet(f.name) {
+			for _, alias := range f.deprecatedAliases {
+				if !isSet(alias) {
+					continue
+				}
+				log.Warnf("[DEPRECATION NOTICE] The metadata property '%s' is deprecated and will be removed in a future version; use '%s' instead", alias, f.name)
+				delete(res, keys[strings.ToLower(f.name)])
+				res[f.name] = res[keys[strings.ToLower(alias)]]
+				keys[strings.ToLower(f.name)] = f.name
+				break
+			}
+		}
+
+		if !isSet(f.name) && f.defaultValue != nil {
+			delete(res, keys[strings.ToLower(f.name)])
+			res[f.name] = *f.defaultValue
+			keys[strings.ToLower(f.name)] = f.name
+		}
+
+		if f.required && !isSet(f.name) {
+			errs = append(errs, fmt.Errorf("metadata property '%s' is required", f.name))
+		}
+	}
+
+	return res, errors.Join(errs...)
+}
+
+// validateFieldRanges checks that the decoded values of the properties that are set are within the range specified by the struct tags.
+func validateFieldRanges(props map[string]string, result any, fields []metadataField) error {
+	v := reflect.Indirect(reflect.ValueOf(result))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs []error
+	for _, f := range fields {
+		if f.minValue == "" && f.maxValue == "" {
+			continue
+		}
+		if val, ok := GetMetadataProperty(props, f.name); !ok || val == "" {
+			continue
+		}
+
+		fv, err := v.FieldByIndexErr(f.index)
+		if err != nil {
+			continue
+		}
+		for fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				break
+			}
+			fv = fv.Elem()
+		}
+
+		err = f.checkRange(fv)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkRange checks that the value is within the range specified by the minValue and maxValue tags.
+func (f metadataField) checkRange(v reflect.Value) error {
+	var (
+		val   float64
+		parse func(string) (float64, error)
+	)
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		val = float64(v.Int())
+		parse = parseDurationBound
+	case v.Type() == reflect.TypeOf(Duration{}):
+		val = float64(v.Interface().(Duration).Duration)
+		parse = parseDurationBound
+	case v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64:
+		val = float64(v.Int())
+		parse = parseNumberBound
+	case v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uint64:
+		val = float64(v.Uint())
+		parse = parseNumberBound
+	case v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64:
+		val = v.Float()
+		parse = parseNumberBound
+	default:
+		return fmt.Errorf("metadata property '%s' has type %s, which does not support %s and %s", f.name, v.Type(), TagMinValue, TagMaxValue)
+	}
+
+	if f.minValue != "" {
+		minValue, err := parse(f.minValue)
+		if err != nil {
+			return fmt.Errorf("invalid %s for metadata property '%s': %w", TagMinValue, f.name, err)
+		}
+		if val < minValue {
+			return fmt.Errorf("invalid value for metadata property '%s': must be greater than or equal to %s", f.name, f.minValue)
+		}
+	}
+	if f.maxValue != "" {
+		maxValue, err := parse(f.maxValue)
+		if err != nil {
+			return fmt.Errorf("invalid %s for metadata property '%s': %w", TagMaxValue, f.name, err)
+		}
+		if val > maxValue {
+			return fmt.Errorf("invalid value for metadata property '%s': must be less than or equal to %s", f.name, f.maxValue)
+		}
+	}
+	return nil
+}
+
+func parseNumberBound(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseDurationBound parses a duration, accepting integers as seconds like the decoder does.
+func parseDurationBound(s string) (float64, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		seconds, errParse := strconv.ParseInt(s, 10, 0)
+		if errParse != nil {
+			return 0, errors.Join(err, errParse)
+		}
+		d = time.Duration(seconds) * time.Second
+	}
+	return float64(d), nil
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type taggedTestMetadata struct {
+	TaggedTestEmbedded `mapstructure:",squash"`
+
+	Name        string        `mapstructure:"name" required:"true"`
+	Timeout     time.Duration `mapstructure:"timeout" default:"60" minValue:"1s" maxValue:"1h"`
+	Retries     int           `mapstructure:"retries" default:"3" minValue:"0" maxValue:"10"`
+	Ratio       float64       `mapstructure:"ratio" maxValue:"1"`
+	Interval    *Duration     `mapstructure:"interval" minValue:"1m"`
+	QueueName   string        `mapstructure:"queueName" deprecatedAliases:"queue,queue_name"`
+	Plain       string        `mapstructure:"plain"`
+	BindingOnly bool          `mapstructure:"bindingOnly" only:"bindings"`
+}
+
+type TaggedTestEmbedded struct {
+	Region string `mapstructure:"region" required:"true" default:"westus"`
+}
+
+func TestDecodeMetadataStructTags(t *testing.T) {
+	t.Run("defaults are applied", func(t *testing.T) {
+		var m taggedTestMetadata
+		err := DecodeMetadata(map[string]string{"name": "foo"}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, "foo", m.Name)
+		assert.Equal(t, time.Minute, m.Timeout)
+		assert.Equal(t, 3, m.Retries)
+		assert.Equal(t, "westus", m.Region)
+		assert.Nil(t, m.Interval)
+	})
+
+	t.Run("values override the defaults", func(t *testing.T) {
+		for _, timeout := range []string{"60", "60s", "1m"} {
+			var m taggedTestMetadata
+			err := DecodeMetadata(map[string]string{
+				"name":     "foo",
+				"Timeout":  timeout,
+				"retries":  "0",
+				"region":   "eastus",
+				"interval": "2m",
+			}, &m)
+			require.NoError(t, err)
+			assert.Equal(t, time.Minute, m.Timeout)
+			assert.Equal(t, 0, m.Retries)
+			assert.Equal(t, "eastus", m.Region)
+			assert.Equal(t, 2*time.Minute, m.Interval.Duration)
+		}
+	})
+
+	t.Run("empty values use the defaults", func(t *testing.T) {
+		var m taggedTestMetadata
+		err := DecodeMetadata(map[string]string{"name": "foo", "retries": ""}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, 3, m.Retries)
+	})
+
+	t.Run("required properties", func(t *testing.T) {
+		var m taggedTestMetadata
+		err := DecodeMetadata(map[string]string{}, &m)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "metadata property 'name' is required")
+
+		err = DecodeMetadata(map[string]string{"NAME": "foo"}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, "foo", m.Name)
+	})
+
+	t.Run("values out of range", func(t *testing.T) {
+		tests := map[string]string{
+			"timeout":  "500ms",
+			"retries":  "11",
+			"ratio":    "1.5",
+			"interval": "10s",
+		}
+		for key, val := range tests {
+			t.Run(key, func(t *testing.T) {
+				var m taggedTestMetadata
+				err := DecodeMetadata(map[string]string{"name": "foo", key: val}, &m)
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "invalid value for metadata property '"+key+"'")
+			})
+		}
+	})
+
+	t.Run("deprecated aliases", func(t *testing.T) {
+		var m taggedTestMetadata
+		err := DecodeMetadata(map[string]string{"name": "foo", "queue_name": "old"}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, "old", m.QueueName)
+
+		// The current key takes precedence
+		m = taggedTestMetadata{}
+		err = DecodeMetadata(map[string]string{"name": "foo", "queue": "old", "queueName": "new"}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, "new", m.QueueName)
+	})
+
+	t.Run("input properties are not modified", func(t *testing.T) {
+		props := map[string]string{"name": "foo", "queue": "old"}
+		var m taggedTestMetadata
+		err := DecodeMetadata(props, &m)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"name": "foo", "queue": "old"}, props)
+	})
+}
+
+func TestGetMetadataSchemaFromStructType(t *testing.T) {
+	schema, err := GetMetadataSchemaFromStructType(reflect.TypeOf(taggedTestMetadata{}), PubSubType)
+	require.NoError(t, err)
+
+	assert.Equal(t, []MetadataFieldSchema{
+		{Name: "region", Required: true, Default: "westus"},
+		{Name: "name", Required: true},
+		{Name: "timeout", Type: "duration", Default: "60", MinValue: "1s", MaxValue: "1h"},
+		{Name: "retries", Type: "number", Default: "3", MinValue: "0", MaxValue: "10"},
+		{Name: "ratio", Type: "number", MaxValue: "1"},
+		{Name: "interval", Type: "duration", MinValue: "1m"},
+		{Name: "queueName", DeprecatedAliases: []string{"queue", "queue_name"}},
+		{Name: "plain"},
+	}, schema)
+
+	schema, err = GetMetadataSchemaFromStructType(reflect.TypeOf(&taggedTestMetadata{}), BindingType)
+	require.NoError(t, err)
+	assert.Len(t, schema, 9)
+	assert.Equal(t, MetadataFieldSchema{Name: "bindingOnly", Type: "bool"}, schema[8])
+
+	// Without a component type, properties limited to some component types are skipped
+	schema, err = GetMetadataSchemaFromStructType(reflect.TypeOf(taggedTestMetadata{}), "")
+	require.NoError(t, err)
+	assert.Len(t, schema, 8)
+
+	_, err = GetMetadataSchemaFromStructType(reflect.TypeOf(""), BindingType)
+	require.Error(t, err)
+}
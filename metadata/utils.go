@@ -44,6 +44,9 @@ const (
 	// QueryIndexName defines the metadata key for the name of query indexing schema (for redis).
 	QueryIndexName = "queryIndexName"
 
+	// QueryExplainKey defines the metadata key for returning the translated native query instead of executing it.
+	QueryExplainKey = "queryExplain"
+
 	// MaxBulkPubBytesKey defines the maximum bytes to publish in a bulk publish request metadata.
 	MaxBulkPubBytesKey string = "maxBulkPubBytes"
 )
@@ -123,6 +126,11 @@ func TryGetQueryIndexName(props map[string]string) (string, bool) {
 	return "", false
 }
 
+// IsQueryExplain returns true if the metadata of a query request enables the diagnostics mode.
+func IsQueryExplain(props map[string]string) bool {
+	return utils.IsTruthy(props[QueryExplainKey])
+}
+
 // GetMetadataProperty returns a property from the metadata map, with support for aliases
 func GetMetadataProperty(props map[string]string, keys ...string) (val string, ok bool) {
 	lcProps := make(map[string]string, len(props))
@@ -140,6 +148,7 @@ func GetMetadataProperty(props map[string]string, keys ...string) (val string, o
 
 // DecodeMetadata decodes metadata into a struct
 // This is an extension of mitchellh/mapstructure which also supports decoding durations
+// When decoding a map of properties, the struct tags "required", "default", "minValue", "maxValue", and "deprecatedAliases" are processed too; see TagRequired and the other Tag constants.
 func DecodeMetadata(input interface{}, result interface{}) error {
 	// avoids a common mistake of passing the metadata struct, instead of the properties map
 	// if input is of type struct, case it to metadata.Base and access the Properties instead
@@ -152,6 +161,14 @@ func DecodeMetadata(input interface{}, result interface{}) error {
 		}
 	}
 
+	props, fields, err := prepareProperties(input, result)
+	if err != nil {
+		return err
+	}
+	if props != nil {
+		input = props
+	}
+
 	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
 		DecodeHook: mapstructure.ComposeDecodeHookFunc(
 			toTimeDurationArrayHookFunc(),
@@ -167,7 +184,44 @@ func DecodeMetadata(input interface{}, result interface{}) error {
 		return err
 	}
 	err = decoder.Decode(input)
-	return err
+	if err != nil {
+		return err
+	}
+
+	if props != nil {
+		return validateFieldRanges(props, result, fields)
+	}
+	return nil
+}
+
+// prepareProperties processes the struct tags of the result before the properties are decoded.
+// It returns a nil map if the input is not a map of properties or if no field has any of the tags.
+func prepareProperties(input interface{}, result interface{}) (map[string]string, []metadataField, error) {
+	props, ok := input.(map[string]string)
+	if !ok {
+		return nil, nil, nil
+	}
+	t := reflect.TypeOf(result)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil, nil, nil
+	}
+
+	fields, err := decodableMetadataFields(t)
+	if err != nil {
+		return nil, nil, err
+	}
+	tagged := make([]metadataField, 0, len(fields))
+	for _, f := range fields {
+		if f.hasTags() {
+			tagged = append(tagged, f)
+		}
+	}
+	if len(tagged) == 0 {
+		return nil, nil, nil
+	}
+
+	props, err = applyFieldTags(props, tagged)
+	return props, tagged, err
 }
 
 func toTruthyBoolHookFunc() mapstructure.DecodeHookFunc {
@@ -299,53 +353,13 @@ func (t ComponentType) BuiltInMetadataProperties() []string {
 // GetMetadataInfoFromStructType converts a struct to a map of field name (or struct tag) to field type.
 // This is used to generate metadata documentation for components.
 func GetMetadataInfoFromStructType(t reflect.Type, metadataMap *map[string]string, componentType ComponentType) error {
-	// Return if not struct or pointer to struct.
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
-	}
-	if t.Kind() != reflect.Struct {
-		return fmt.Errorf("not a struct: %s", t.Kind().String())
+	fields, err := metadataFields(t, componentType)
+	if err != nil {
+		return err
 	}
 
-	for i := 0; i < t.NumField(); i++ {
-		currentField := t.Field(i)
-		// fields that are not exported cannot be set via the mapstructure metadata decoding mechanism
-		if !currentField.IsExported() {
-			continue
-		}
-		mapStructureTag := currentField.Tag.Get("mapstructure")
-		// we are not exporting this field using the mapstructure tag mechanism
-		if mapStructureTag == "-" {
-			continue
-		}
-		onlyTag := currentField.Tag.Get("only")
-		if onlyTag != "" {
-			include := false
-			onlyTags := strings.Split(onlyTag, ",")
-			for _, tag := range onlyTags {
-				if tag == string(componentType) {
-					include = true
-					break
-				}
-			}
-			if !include {
-				continue
-			}
-		}
-		mapStructureTags := strings.Split(mapStructureTag, ",")
-		numTags := len(mapStructureTags)
-		if numTags > 1 && mapStructureTags[numTags-1] == "squash" && currentField.Anonymous {
-			// traverse embedded struct
-			GetMetadataInfoFromStructType(currentField.Type, metadataMap, componentType)
-			continue
-		}
-		var fieldName string
-		if numTags > 0 && mapStructureTags[0] != "" {
-			fieldName = mapStructureTags[0]
-		} else {
-			fieldName = currentField.Name
-		}
-		(*metadataMap)[fieldName] = currentField.Type.String()
+	for _, f := range fields {
+		(*metadataMap)[f.name] = f.field.Type.String()
 	}
 
 	return nil
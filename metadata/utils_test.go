@@ -273,5 +273,12 @@ func TestMetadataStructToStringMap(t *testing.T) {
 		assert.Equal(t, "[]time.Duration", metadatainfo["MyDurationArray"])
 		assert.NotContains(t, metadatainfo, "NotExportedByMapStructure")
 		assert.NotContains(t, metadatainfo, "notExported")
+
+		metadatainfo = map[string]string{}
+		GetMetadataInfoFromStructType(reflect.TypeOf(m), &metadatainfo, "")
+		assert.Equal(t, "string", metadatainfo["Mystring"])
+		assert.NotContains(t, metadatainfo, "pubsub_only_property")
+		assert.NotContains(t, metadatainfo, "binding_only_property")
+		assert.NotContains(t, metadatainfo, "pubsub_and_binding_property")
 	})
 }
@@ -0,0 +1,233 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+
+	mdutils "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+// ReplayedHeader is the header added to responses that are replayed from the store.
+const ReplayedHeader = "Idempotent-Replayed"
+
+// Middleware is an idempotency-key middleware.
+// It stores the response to requests that include an idempotency key, and replays it when a request with the same key is received again.
+type Middleware struct {
+	logger logger.Logger
+}
+
+// NewMiddleware returns a new idempotency middleware.
+func NewMiddleware(logger logger.Logger) middleware.Middleware {
+	return &Middleware{logger: logger}
+}
+
+// GetHandler returns the HTTP handler provided by the middleware.
+// Responses are stored in Redis, which is configured with the same metadata properties as the Redis state store, such as "redisHost".
+func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
+	h := &idempotencyHandler{logger: m.logger}
+	err := h.meta.fromMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	h.store, err = newRedisResponseStore(metadata.Properties)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.handler, nil
+}
+
+// idempotencyHandler contains the configuration of a handler returned by GetHandler.
+type idempotencyHandler struct {
+	logger logger.Logger
+	meta   idempotencyMiddlewareMetadata
+	store  responseStore
+}
+
+func (h *idempotencyHandler) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := h.meta.methods[r.Method]; !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		idempotencyKey := r.Header.Get(h.meta.HeaderName)
+		if idempotencyKey == "" {
+			if h.meta.RequireKey {
+				http.Error(w, "Missing header "+h.meta.HeaderName, http.StatusBadRequest)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := readBody(r, h.meta.MaxBodySize)
+		if err != nil {
+			if errors.Is(err, errBodyTooLarge) {
+				http.Error(w, "Request body is too large", http.StatusRequestEntityTooLarge)
+			} else {
+				http.Error(w, "Failed to read request body", http.StatusBadRequest)
+			}
+			return
+		}
+
+		key := h.meta.KeyPrefix + "||" + idempotencyKey
+		fingerprint := requestFingerprint(r, body)
+		reserved, existing, err := h.store.Reserve(r.Context(), key, &storedResponse{
+			InProgress:  true,
+			Fingerprint: fingerprint,
+		}, h.meta.LockTTL)
+		if err != nil {
+			// If the store is unavailable, process the request without protection rather than causing an outage
+			h.logger.Warnf("Failed to check idempotency key, processing request: %v", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !reserved {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				http.Error(w, "Idempotency key was already used for a different request", http.StatusUnprocessableEntity)
+			case existing.InProgress:
+				http.Error(w, "A request with the same idempotency key is being processed", http.StatusConflict)
+			default:
+				replay(w, existing)
+			}
+			return
+		}
+
+		rec := &responseRecorder{
+			ResponseWriter: w,
+			maxSize:        h.meta.MaxBodySize,
+		}
+		next.ServeHTTP(rec, r)
+
+		// Do not store server errors, so clients can retry
+		// Responses that are too large are not stored either, and the key is released
+		// Use a new context as the request's one may be canceled once the response is sent
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		defer cancel()
+		switch {
+		case rec.tooLarge:
+			h.logger.Warnf("Not storing response for idempotency key: body is larger than %d bytes", h.meta.MaxBodySize)
+			err = h.store.Delete(ctx, key)
+		case rec.statusCode() >= http.StatusInternalServerError:
+			err = h.store.Delete(ctx, key)
+		default:
+			err = h.store.Save(ctx, key, &storedResponse{
+				Fingerprint: fingerprint,
+				StatusCode:  rec.statusCode(),
+				Header:      rec.Header().Clone(),
+				Body:        rec.body.Bytes(),
+			}, h.meta.TTL)
+		}
+		if err != nil {
+			h.logger.Warnf("Failed to store response for idempotency key: %v", err)
+		}
+	})
+}
+
+var errBodyTooLarge = errors.New("body is too large")
+
+// readBody reads the body of the request, up to maxSize bytes, and replaces it so it can still be read by the next handler.
+func readBody(r *http.Request, maxSize int64) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxSize {
+		return nil, errBodyTooLarge
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// requestFingerprint returns a value that identifies the request, to detect idempotency keys re-used for different requests.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replay writes a stored response.
+func replay(w http.ResponseWriter, res *storedResponse) {
+	for k, v := range res.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(res.StatusCode)
+	w.Write(res.Body)
+}
+
+// responseRecorder is a ResponseWriter that keeps a copy of the response it writes, up to maxSize bytes.
+type responseRecorder struct {
+	http.ResponseWriter
+	code     int
+	body     bytes.Buffer
+	maxSize  int64
+	tooLarge bool
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	if !r.tooLarge {
+		if int64(r.body.Len()+len(b)) > r.maxSize {
+			// Stop buffering the response, as it won't be stored
+			r.tooLarge = true
+			r.body = bytes.Buffer{}
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) statusCode() int {
+	if r.code == 0 {
+		return http.StatusOK
+	}
+	return r.code
+}
+
+func (m *Middleware) GetComponentMetadata() map[string]string {
+	metadataStruct := idempotencyMiddlewareMetadata{}
+	metadataInfo := map[string]string{}
+	mdutils.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, mdutils.MiddlewareType)
+	return metadataInfo
+}
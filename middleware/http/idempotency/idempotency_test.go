@@ -0,0 +1,212 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package idempotency
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mdutils "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		md := idempotencyMiddlewareMetadata{}
+		err := md.fromMetadata(middleware.Metadata{})
+		require.NoError(t, err)
+		assert.Equal(t, defaultHeaderName, md.HeaderName)
+		assert.Equal(t, defaultTTL, md.TTL)
+		assert.Len(t, md.methods, 2)
+		assert.Contains(t, md.methods, http.MethodPost)
+		assert.Contains(t, md.methods, http.MethodPatch)
+	})
+
+	t.Run("rejects safe methods", func(t *testing.T) {
+		md := idempotencyMiddlewareMetadata{}
+		err := md.fromMetadata(middleware.Metadata{Base: mdutils.Base{Properties: map[string]string{
+			"methods": "post,get",
+		}}})
+		require.Error(t, err)
+	})
+
+	t.Run("rejects invalid TTL", func(t *testing.T) {
+		md := idempotencyMiddlewareMetadata{}
+		err := md.fromMetadata(middleware.Metadata{Base: mdutils.Base{Properties: map[string]string{
+			"ttl": "0",
+		}}})
+		require.Error(t, err)
+
+		err = md.fromMetadata(middleware.Metadata{Base: mdutils.Base{Properties: map[string]string{
+			"lockTTL": "0",
+		}}})
+		require.Error(t, err)
+	})
+}
+
+func TestIdempotency(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	var calls atomic.Int32
+	var status atomic.Int32
+	status.Store(http.StatusCreated)
+
+	m := NewMiddleware(logger.NewLogger("test"))
+	handlerFn, err := m.GetHandler(context.Background(), middleware.Metadata{Base: mdutils.Base{Properties: map[string]string{
+		"redisHost":  s.Addr(),
+		"requireKey": "true",
+	}}})
+	require.NoError(t, err)
+	var lockTTL atomic.Int64
+	handler := handlerFn(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		lockTTL.Store(int64(s.TTL(defaultKeyPrefix + "||" + r.Header.Get(defaultHeaderName))))
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Custom", "foo")
+		w.WriteHeader(int(status.Load()))
+		if len(body) > 0 {
+			w.Write(body)
+		} else {
+			w.Write([]byte("created"))
+		}
+	}))
+
+	serveWithBody := func(method, path, key string, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if key != "" {
+			r.Header.Set(defaultHeaderName, key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	serve := func(method, path, key string) *httptest.ResponseRecorder {
+		return serveWithBody(method, path, key, "")
+	}
+
+	t.Run("first request is processed", func(t *testing.T) {
+		w := serve(http.MethodPost, "/orders", "k1")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "created", w.Body.String())
+		assert.Empty(t, w.Header().Get(ReplayedHeader))
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Equal(t, int64(defaultLockTTL), lockTTL.Load())
+	})
+
+	t.Run("duplicate request is replayed", func(t *testing.T) {
+		w := serve(http.MethodPost, "/orders", "k1")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "created", w.Body.String())
+		assert.Equal(t, "foo", w.Header().Get("X-Custom"))
+		assert.Equal(t, "true", w.Header().Get(ReplayedHeader))
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("key used for a different request", func(t *testing.T) {
+		w := serve(http.MethodPost, "/payments", "k1")
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("key used for a request with a different body", func(t *testing.T) {
+		w := serveWithBody(http.MethodPost, "/orders", "k1", `{"amount":10}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("request in progress", func(t *testing.T) {
+		s.Set(defaultKeyPrefix+"||k2", `{"inProgress":true,"fingerprint":"`+requestFingerprint(httptest.NewRequest(http.MethodPost, "/orders", nil), nil)+`"}`)
+		w := serve(http.MethodPost, "/orders", "k2")
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("missing key is rejected", func(t *testing.T) {
+		w := serve(http.MethodPost, "/orders", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("other methods are not affected", func(t *testing.T) {
+		serve(http.MethodGet, "/orders", "k1")
+		serve(http.MethodGet, "/orders", "k1")
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("server errors are not stored", func(t *testing.T) {
+		calls.Store(0)
+		status.Store(http.StatusInternalServerError)
+		serve(http.MethodPost, "/orders", "k3")
+		assert.False(t, s.Exists(defaultKeyPrefix+"||k3"))
+
+		status.Store(http.StatusCreated)
+		w := serve(http.MethodPost, "/orders", "k3")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, int32(2), calls.Load())
+		assert.True(t, s.Exists(defaultKeyPrefix+"||k3"))
+	})
+
+	t.Run("request body is forwarded", func(t *testing.T) {
+		w := serveWithBody(http.MethodPost, "/orders", "k4", `{"amount":10}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, `{"amount":10}`, w.Body.String())
+	})
+
+	t.Run("large requests are rejected", func(t *testing.T) {
+		calls.Store(0)
+		w := serveWithBody(http.MethodPost, "/orders", "k5", strings.Repeat("a", defaultMaxBodySize+1))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Equal(t, int32(0), calls.Load())
+	})
+}
+
+func TestLargeResponsesAreNotStored(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	m := NewMiddleware(logger.NewLogger("test"))
+	handlerFn, err := m.GetHandler(context.Background(), middleware.Metadata{Base: mdutils.Base{Properties: map[string]string{
+		"redisHost":   s.Addr(),
+		"maxBodySize": "8",
+	}}})
+	require.NoError(t, err)
+
+	// Handlers from another GetHandler call on the same middleware keep their own configuration
+	_, err = m.GetHandler(context.Background(), middleware.Metadata{Base: mdutils.Base{Properties: map[string]string{
+		"redisHost":   s.Addr(),
+		"maxBodySize": "1048576",
+	}}})
+	require.NoError(t, err)
+
+	handler := handlerFn(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	r.Header.Set(defaultHeaderName, "k1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0123456789", w.Body.String())
+	assert.False(t, s.Exists(defaultKeyPrefix+"||k1"))
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package idempotency
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	mdutils "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+)
+
+const (
+	defaultHeaderName  = "Idempotency-Key"
+	defaultTTL         = 24 * time.Hour
+	defaultMethods     = "POST,PATCH"
+	defaultKeyPrefix   = "dapr-idempotency"
+	defaultLockTTL     = time.Minute
+	defaultMaxBodySize = 1 << 20 // 1MiB
+
+	// Timeout for storing responses
+	storeTimeout = 5 * time.Second
+)
+
+type idempotencyMiddlewareMetadata struct {
+	// Name of the header that contains the idempotency key.
+	HeaderName string `json:"headerName" mapstructure:"headerName"`
+	// How long responses are stored and replayed for.
+	TTL time.Duration `json:"ttl" mapstructure:"ttl"`
+	// Comma-separated list of HTTP methods the middleware applies to.
+	Methods string `json:"methods" mapstructure:"methods"`
+	// Prefix of the keys in the store.
+	KeyPrefix string `json:"keyPrefix" mapstructure:"keyPrefix"`
+	// If true, requests with the methods above but without an idempotency key are rejected.
+	RequireKey bool `json:"requireKey" mapstructure:"requireKey"`
+	// How long a key is locked for while the first request with it is processed.
+	LockTTL time.Duration `json:"lockTTL" mapstructure:"lockTTL"`
+	// Maximum size of the bodies of requests and of the responses that are stored, in bytes.
+	MaxBodySize int64 `json:"maxBodySize" mapstructure:"maxBodySize"`
+
+	// Internal properties
+	methods map[string]struct{} `json:"-" mapstructure:"-"`
+}
+
+// Parse the component's metadata into the object.
+func (md *idempotencyMiddlewareMetadata) fromMetadata(metadata middleware.Metadata) error {
+	// Set defaults
+	md.HeaderName = defaultHeaderName
+	md.TTL = defaultTTL
+	md.Methods = defaultMethods
+	md.KeyPrefix = defaultKeyPrefix
+	md.LockTTL = defaultLockTTL
+	md.MaxBodySize = defaultMaxBodySize
+
+	// Decode the properties
+	err := mdutils.DecodeMetadata(metadata.Properties, md)
+	if err != nil {
+		return err
+	}
+
+	// Validate properties
+	if md.HeaderName == "" {
+		return errors.New("metadata property 'headerName' must not be empty")
+	}
+	if md.TTL <= 0 {
+		return errors.New("metadata property 'ttl' must be a positive duration")
+	}
+	if md.LockTTL <= 0 {
+		return errors.New("metadata property 'lockTTL' must be a positive duration")
+	}
+	if md.MaxBodySize <= 0 {
+		return errors.New("metadata property 'maxBodySize' must be a positive number")
+	}
+	md.methods = map[string]struct{}{}
+	for _, m := range strings.Split(md.Methods, ",") {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		if m == "" {
+			continue
+		}
+		if m == http.MethodGet || m == http.MethodHead || m == http.MethodOptions {
+			return errors.New("metadata property 'methods' must not include safe methods such as " + m)
+		}
+		md.methods[m] = struct{}{}
+	}
+	if len(md.methods) == 0 {
+		return errors.New("metadata property 'methods' must not be empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
+	mdutils "github.com/dapr/components-contrib/metadata"
+)
+
+// storedResponse is a response stored for an idempotency key.
+type storedResponse struct {
+	// If true, the first request with the key is still being processed and there's no response yet.
+	InProgress bool `json:"inProgress,omitempty"`
+	// Fingerprint of the request that used the key.
+	Fingerprint string      `json:"fingerprint"`
+	StatusCode  int         `json:"statusCode,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// responseStore stores responses for idempotency keys.
+type responseStore interface {
+	// Reserve stores res for the key if there's no value for it yet.
+	// It returns true if the key was reserved, or false and the existing value otherwise.
+	Reserve(ctx context.Context, key string, res *storedResponse, ttl time.Duration) (bool, *storedResponse, error)
+	// Save stores res for the key, replacing any existing value.
+	Save(ctx context.Context, key string, res *storedResponse, ttl time.Duration) error
+	// Delete removes the value for the key.
+	Delete(ctx context.Context, key string) error
+}
+
+// redisResponseStore stores responses in Redis.
+type redisResponseStore struct {
+	client rediscomponent.RedisClient
+}
+
+func newRedisResponseStore(properties map[string]string) (*redisResponseStore, error) {
+	client, _, err := rediscomponent.ParseClientFromProperties(properties, mdutils.MiddlewareType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Redis client: %w", err)
+	}
+	return &redisResponseStore{client: client}, nil
+}
+
+func (s *redisResponseStore) Reserve(ctx context.Context, key string, res *storedResponse, ttl time.Duration) (bool, *storedResponse, error) {
+	data, err := json.Marshal(res)
+	if err != nil {
+		return false, nil, err
+	}
+
+	ok, err := s.client.SetNX(ctx, key, data, ttl)
+	if err != nil {
+		return false, nil, err
+	}
+	if ok != nil && *ok {
+		return true, nil, nil
+	}
+
+	val, err := s.client.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, s.client.GetNilValueError()) {
+			// The key expired in the meanwhile: try again
+			return s.Reserve(ctx, key, res, ttl)
+		}
+		return false, nil, err
+	}
+	existing := &storedResponse{}
+	err = json.Unmarshal([]byte(val), existing)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to decode stored response: %w", err)
+	}
+	return false, existing, nil
+}
+
+func (s *redisResponseStore) Save(ctx context.Context, key string, res *storedResponse, ttl time.Duration) error {
+	data, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return s.client.DoWrite(ctx, "SET", key, data, "PX", ttl.Milliseconds())
+}
+
+func (s *redisResponseStore) Delete(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key)
+}
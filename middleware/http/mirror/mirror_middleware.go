@@ -0,0 +1,306 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/internal/httputils"
+	mdutils "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// MirroredHeader is the header added to the mirrored requests, so the shadow service can tell them apart.
+	MirroredHeader = "X-Dapr-Mirrored"
+
+	defaultPercentage       = 100
+	defaultTimeout          = 5 * time.Second
+	defaultMaxBodySize      = 1 << 20 // 1 MiB
+	defaultMaxConcurrency   = 100
+	defaultBindingOperation = "create"
+	defaultDaprHTTPPort     = "3500"
+)
+
+// Headers that are removed from the mirrored requests unless scrubHeaders is set.
+var defaultScrubHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Dapr-Api-Token"}
+
+// Metadata is the mirror middleware config.
+type Metadata struct {
+	// Base URL of the shadow endpoint; the path and query string of the request are appended to it.
+	ShadowURL string `json:"shadowURL" mapstructure:"shadowURL"`
+	// Name of the output binding that receives the mirrored requests, invoked through the Dapr HTTP API.
+	ShadowBinding string `json:"shadowBinding" mapstructure:"shadowBinding"`
+	// Operation used when invoking the output binding.
+	BindingOperation string `json:"bindingOperation" mapstructure:"bindingOperation"`
+	// Percentage of the requests that are mirrored, from 0 to 100.
+	Percentage float64 `json:"percentage" mapstructure:"percentage"`
+	// Names of the headers that are removed from the mirrored requests.
+	ScrubHeaders []string `json:"scrubHeaders" mapstructure:"scrubHeaders"`
+	// Timeout for the mirrored requests.
+	Timeout time.Duration `json:"timeout" mapstructure:"timeout"`
+	// Requests with a larger body are not mirrored.
+	MaxBodySize int64 `json:"maxBodySize" mapstructure:"maxBodySize"`
+	// Maximum number of mirrored requests in flight; additional requests are not mirrored.
+	MaxConcurrency int `json:"maxConcurrency" mapstructure:"maxConcurrency"`
+}
+
+// mirroredRequest is the data sent to the output binding for each mirrored request.
+type mirroredRequest struct {
+	Method string              `json:"method"`
+	URI    string              `json:"uri"`
+	Header map[string][]string `json:"header,omitempty"`
+	Body   []byte              `json:"body,omitempty"`
+}
+
+// bindingRequest is the body of a request to the bindings endpoint of the Dapr HTTP API.
+type bindingRequest struct {
+	Data      mirroredRequest   `json:"data"`
+	Operation string            `json:"operation"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// NewMiddleware returns a new mirror middleware.
+func NewMiddleware(logger logger.Logger) middleware.Middleware {
+	return &Middleware{logger: logger}
+}
+
+// Middleware is a mirror middleware.
+type Middleware struct {
+	logger logger.Logger
+}
+
+// mirror sends copies of requests to the shadow endpoint.
+type mirror struct {
+	meta   *Metadata
+	logger logger.Logger
+	client *http.Client
+	// URL of the Dapr HTTP API for the output binding, if shadowBinding is set.
+	bindingURL string
+	// Semaphore that limits the number of mirrored requests in flight.
+	inflight chan struct{}
+	// Returns a random number in [0, 100).
+	randFn func() float64
+}
+
+// GetHandler returns the HTTP handler provided by the middleware.
+func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
+	meta, err := m.getNativeMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	mr := &mirror{
+		meta:     meta,
+		logger:   m.logger,
+		client:   &http.Client{Timeout: meta.Timeout},
+		inflight: make(chan struct{}, meta.MaxConcurrency),
+		randFn: func() float64 {
+			//nolint:gosec
+			return rand.Float64() * 100
+		},
+	}
+	if meta.ShadowBinding != "" {
+		port := os.Getenv("DAPR_HTTP_PORT")
+		if port == "" {
+			port = defaultDaprHTTPPort
+		}
+		mr.bindingURL = "http://localhost:" + port + "/v1.0/bindings/" + url.PathEscape(meta.ShadowBinding)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Never mirror requests that are mirrored already
+			if r.Header.Get(MirroredHeader) == "" && mr.shouldMirror() {
+				mr.mirror(r)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+func (mr *mirror) shouldMirror() bool {
+	return mr.meta.Percentage >= 100 || (mr.meta.Percentage > 0 && mr.randFn() < mr.meta.Percentage)
+}
+
+// mirror sends a copy of the request to the shadow endpoint in background.
+// The body of the request is buffered, and replaced so it can still be read by the next handler.
+func (mr *mirror) mirror(r *http.Request) {
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(r.Body, mr.meta.MaxBodySize+1))
+		if err != nil {
+			mr.logger.Warnf("Failed to read the body of the request to mirror: %v", err)
+			r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+			return
+		}
+		if int64(len(body)) > mr.meta.MaxBodySize {
+			mr.logger.Debugf("Not mirroring request to %s: body is larger than %d bytes", r.URL.Path, mr.meta.MaxBodySize)
+			r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+			return
+		}
+		r.Body = readCloser{Reader: bytes.NewReader(body), Closer: r.Body}
+	}
+
+	// Drop the mirrored request if there are too many in flight already, so the traffic that is served isn't affected
+	select {
+	case mr.inflight <- struct{}{}:
+	default:
+		mr.logger.Debugf("Not mirroring request to %s: too many mirrored requests in flight", r.URL.Path)
+		return
+	}
+
+	header := r.Header.Clone()
+	for _, h := range mr.meta.ScrubHeaders {
+		header.Del(h)
+	}
+	header.Set(MirroredHeader, "true")
+
+	shadow := mirroredRequest{
+		Method: r.Method,
+		URI:    httputils.RequestURI(r),
+		Header: header,
+		Body:   body,
+	}
+
+	// The API token is needed to invoke the output binding even if it's scrubbed from the mirrored request
+	apiToken := r.Header.Get("Dapr-Api-Token")
+
+	go func() {
+		defer func() {
+			<-mr.inflight
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), mr.meta.Timeout)
+		defer cancel()
+
+		err := mr.send(ctx, shadow, apiToken)
+		if err != nil {
+			mr.logger.Debugf("Failed to mirror request to %s: %v", shadow.URI, err)
+		}
+	}()
+}
+
+// send sends the mirrored request to the shadow URL or to the output binding.
+// Responses are discarded.
+func (mr *mirror) send(ctx context.Context, shadow mirroredRequest, apiToken string) error {
+	var (
+		req *http.Request
+		err error
+	)
+	if mr.bindingURL != "" {
+		payload, jErr := json.Marshal(bindingRequest{
+			Data:      shadow,
+			Operation: mr.meta.BindingOperation,
+		})
+		if jErr != nil {
+			return jErr
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, mr.bindingURL, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(MirroredHeader, "true")
+		if apiToken != "" {
+			req.Header.Set("Dapr-Api-Token", apiToken)
+		}
+	} else {
+		req, err = http.NewRequestWithContext(ctx, shadow.Method, strings.TrimSuffix(mr.meta.ShadowURL, "/")+shadow.URI, bytes.NewReader(shadow.Body))
+		if err != nil {
+			return err
+		}
+		req.Header = shadow.Header
+	}
+
+	res, err := mr.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("shadow endpoint responded with status code %d", res.StatusCode)
+	}
+	return nil
+}
+
+// readCloser replaces the body of a request, closing the original body when it's closed.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func (m *Middleware) getNativeMetadata(metadata middleware.Metadata) (*Metadata, error) {
+	middlewareMetadata := Metadata{
+		BindingOperation: defaultBindingOperation,
+		Percentage:       defaultPercentage,
+		ScrubHeaders:     defaultScrubHeaders,
+		Timeout:          defaultTimeout,
+		MaxBodySize:      defaultMaxBodySize,
+		MaxConcurrency:   defaultMaxConcurrency,
+	}
+	err := mdutils.DecodeMetadata(metadata.Properties, &middlewareMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case middlewareMetadata.ShadowURL == "" && middlewareMetadata.ShadowBinding == "":
+		return nil, errors.New("one of shadowURL or shadowBinding is required")
+	case middlewareMetadata.ShadowURL != "" && middlewareMetadata.ShadowBinding != "":
+		return nil, errors.New("shadowURL and shadowBinding cannot both be specified")
+	case middlewareMetadata.ShadowURL != "":
+		u, err := url.Parse(middlewareMetadata.ShadowURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid shadowURL '%s': must be an absolute HTTP or HTTPS URL", middlewareMetadata.ShadowURL)
+		}
+	}
+
+	if middlewareMetadata.Percentage < 0 || middlewareMetadata.Percentage > 100 {
+		return nil, fmt.Errorf("invalid percentage %v: must be between 0 and 100", middlewareMetadata.Percentage)
+	}
+	if middlewareMetadata.Timeout <= 0 {
+		return nil, errors.New("timeout must be a positive value")
+	}
+	if middlewareMetadata.MaxBodySize < 0 {
+		return nil, errors.New("maxBodySize must not be negative")
+	}
+	if middlewareMetadata.MaxConcurrency < 1 {
+		return nil, errors.New("maxConcurrency must be 1 or greater")
+	}
+
+	return &middlewareMetadata, nil
+}
+
+func (m *Middleware) GetComponentMetadata() map[string]string {
+	metadataStruct := Metadata{}
+	metadataInfo := map[string]string{}
+	mdutils.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, mdutils.MiddlewareType)
+	return metadataInfo
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+type receivedRequest struct {
+	method string
+	uri    string
+	header http.Header
+	body   string
+}
+
+func startShadowServer(t *testing.T) (*httptest.Server, chan receivedRequest) {
+	t.Helper()
+	received := make(chan receivedRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- receivedRequest{
+			method: r.Method,
+			uri:    r.URL.RequestURI(),
+			header: r.Header,
+			body:   string(body),
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, received
+}
+
+func getHandler(t *testing.T, props map[string]string) http.Handler {
+	t.Helper()
+	m := NewMiddleware(logger.NewLogger("mirror.test"))
+	handler, err := m.GetHandler(context.Background(), middleware.Metadata{Base: metadata.Base{Properties: props}})
+	require.NoError(t, err)
+
+	return handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The next handler must still be able to read the body
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}))
+}
+
+func TestMirrorToURL(t *testing.T) {
+	srv, received := startShadowServer(t)
+	handler := getHandler(t, map[string]string{
+		"shadowURL": srv.URL + "/shadow",
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "http://localhost:3500/v1.0/invoke/myapp/method/orders?id=1", strings.NewReader("hello"))
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("X-Tenant", "contoso")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello", w.Body.String())
+
+	select {
+	case req := <-received:
+		assert.Equal(t, http.MethodPost, req.method)
+		assert.Equal(t, "/shadow/v1.0/invoke/myapp/method/orders?id=1", req.uri)
+		assert.Equal(t, "hello", req.body)
+		assert.Equal(t, "contoso", req.header.Get("X-Tenant"))
+		assert.Equal(t, "true", req.header.Get(MirroredHeader))
+		assert.Empty(t, req.header.Get("Authorization"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not mirrored")
+	}
+}
+
+func TestMirrorToBinding(t *testing.T) {
+	srv, received := startShadowServer(t)
+	t.Setenv("DAPR_HTTP_PORT", srv.URL[strings.LastIndex(srv.URL, ":")+1:])
+	handler := getHandler(t, map[string]string{
+		"shadowBinding": "shadow",
+		"scrubHeaders":  "X-Secret",
+	})
+
+	r := httptest.NewRequest(http.MethodPut, "http://localhost:3500/v1.0/invoke/myapp/method/orders", strings.NewReader("hello"))
+	r.Header.Set("X-Secret", "secret")
+	r.Header.Set("Dapr-Api-Token", "token")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	select {
+	case req := <-received:
+		assert.Equal(t, http.MethodPost, req.method)
+		assert.Equal(t, "/v1.0/bindings/shadow", req.uri)
+		assert.Equal(t, "token", req.header.Get("Dapr-Api-Token"))
+
+		var payload bindingRequest
+		require.NoError(t, json.Unmarshal([]byte(req.body), &payload))
+		assert.Equal(t, "create", payload.Operation)
+		assert.Equal(t, http.MethodPut, payload.Data.Method)
+		assert.Equal(t, "/v1.0/invoke/myapp/method/orders", payload.Data.URI)
+		assert.Equal(t, "hello", string(payload.Data.Body))
+		assert.Empty(t, payload.Data.Header["X-Secret"])
+		assert.Equal(t, []string{"true"}, payload.Data.Header[MirroredHeader])
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not mirrored")
+	}
+}
+
+func TestNotMirrored(t *testing.T) {
+	srv, received := startShadowServer(t)
+
+	t.Run("percentage is 0", func(t *testing.T) {
+		handler := getHandler(t, map[string]string{
+			"shadowURL":  srv.URL,
+			"percentage": "0",
+		})
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://localhost/foo", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("body is too large", func(t *testing.T) {
+		handler := getHandler(t, map[string]string{
+			"shadowURL":   srv.URL,
+			"maxBodySize": "3",
+		})
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "http://localhost/foo", strings.NewReader("hello")))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "hello", w.Body.String())
+	})
+
+	t.Run("request is mirrored already", func(t *testing.T) {
+		handler := getHandler(t, map[string]string{
+			"shadowURL": srv.URL,
+		})
+
+		r := httptest.NewRequest(http.MethodGet, "http://localhost/foo", nil)
+		r.Header.Set(MirroredHeader, "true")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	select {
+	case req := <-received:
+		t.Fatalf("unexpected mirrored request: %s %s", req.method, req.uri)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestShouldMirror(t *testing.T) {
+	mr := &mirror{
+		meta:   &Metadata{Percentage: 25},
+		randFn: func() float64 { return 24.9 },
+	}
+	assert.True(t, mr.shouldMirror())
+
+	mr.randFn = func() float64 { return 25 }
+	assert.False(t, mr.shouldMirror())
+}
+
+func TestGetNativeMetadata(t *testing.T) {
+	m := &Middleware{}
+
+	t.Run("defaults", func(t *testing.T) {
+		meta, err := m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"shadowURL": "http://shadow:8080",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, float64(defaultPercentage), meta.Percentage)
+		assert.Equal(t, defaultScrubHeaders, meta.ScrubHeaders)
+		assert.Equal(t, defaultTimeout, meta.Timeout)
+		assert.Equal(t, int64(defaultMaxBodySize), meta.MaxBodySize)
+		assert.Equal(t, defaultMaxConcurrency, meta.MaxConcurrency)
+	})
+
+	invalid := map[string]map[string]string{
+		"missing shadow":       {},
+		"both shadows":         {"shadowURL": "http://shadow", "shadowBinding": "shadow"},
+		"relative URL":         {"shadowURL": "/shadow"},
+		"negative percentage":  {"shadowURL": "http://shadow", "percentage": "-1"},
+		"percentage over 100":  {"shadowURL": "http://shadow", "percentage": "101"},
+		"zero timeout":         {"shadowURL": "http://shadow", "timeout": "0"},
+		"zero maxConcurrency":  {"shadowURL": "http://shadow", "maxConcurrency": "0"},
+		"negative maxBodySize": {"shadowURL": "http://shadow", "maxBodySize": "-1"},
+	}
+	for name, props := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: props}})
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"errors"
+	"time"
+
+	mdutils "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+)
+
+const (
+	defaultHeaderName = "X-API-Key"
+	defaultKeyPrefix  = "dapr-quota"
+
+	// Timeout for updating the counters
+	storeTimeout = 5 * time.Second
+)
+
+type quotaMiddlewareMetadata struct {
+	// Name of the header that contains the API key.
+	HeaderName string `json:"headerName" mapstructure:"headerName"`
+	// Maximum number of requests per API key in each day; 0 for no daily quota.
+	DailyLimit int64 `json:"dailyLimit" mapstructure:"dailyLimit"`
+	// Maximum number of requests per API key in each month; 0 for no monthly quota.
+	MonthlyLimit int64 `json:"monthlyLimit" mapstructure:"monthlyLimit"`
+	// Prefix of the keys in the store.
+	KeyPrefix string `json:"keyPrefix" mapstructure:"keyPrefix"`
+	// Time zone in which days and months start, such as "America/New_York". Defaults to UTC.
+	TimeZone string `json:"timeZone" mapstructure:"timeZone"`
+
+	// Internal properties
+	location *time.Location `json:"-" mapstructure:"-"`
+}
+
+// Parse the component's metadata into the object.
+func (md *quotaMiddlewareMetadata) fromMetadata(metadata middleware.Metadata) error {
+	// Set defaults
+	md.HeaderName = defaultHeaderName
+	md.KeyPrefix = defaultKeyPrefix
+
+	// Decode the properties
+	err := mdutils.DecodeMetadata(metadata.Properties, md)
+	if err != nil {
+		return err
+	}
+
+	// Validate properties
+	if md.HeaderName == "" {
+		return errors.New("metadata property 'headerName' must not be empty")
+	}
+	if md.DailyLimit < 0 || md.MonthlyLimit < 0 {
+		return errors.New("metadata properties 'dailyLimit' and 'monthlyLimit' must not be negative")
+	}
+	if md.DailyLimit == 0 && md.MonthlyLimit == 0 {
+		return errors.New("at least one of the metadata properties 'dailyLimit' and 'monthlyLimit' is required")
+	}
+	if md.KeyPrefix == "" {
+		md.KeyPrefix = defaultKeyPrefix
+	}
+	md.location = time.UTC
+	if md.TimeZone != "" {
+		md.location, err = time.LoadLocation(md.TimeZone)
+		if err != nil {
+			return errors.New("metadata property 'timeZone' is not a valid time zone: " + err.Error())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	mdutils "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+// Prefixes of the headers added to the responses, followed by the name of the period ("Day" or "Month").
+const (
+	// LimitHeaderPrefix is the prefix of the headers with the maximum number of requests in the period.
+	LimitHeaderPrefix = "X-Quota-Limit-"
+	// RemainingHeaderPrefix is the prefix of the headers with the number of requests left in the period.
+	RemainingHeaderPrefix = "X-Quota-Remaining-"
+	// ResetHeaderPrefix is the prefix of the headers with the number of seconds until the period ends and the quota is reset.
+	ResetHeaderPrefix = "X-Quota-Reset-"
+)
+
+// Middleware is a request quota middleware.
+// It counts the requests made with each API key, and rejects them once the daily or monthly quota of the key is exhausted.
+type Middleware struct {
+	logger logger.Logger
+	meta   quotaMiddlewareMetadata
+	store  counterStore
+	now    func() time.Time
+}
+
+// NewMiddleware returns a new quota middleware.
+func NewMiddleware(logger logger.Logger) middleware.Middleware {
+	return &Middleware{
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// GetHandler returns the HTTP handler provided by the middleware.
+// Counters are stored in Redis, which is configured with the same metadata properties as the Redis state store, such as "redisHost".
+func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
+	err := m.meta.fromMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	m.store, err = newRedisCounterStore(metadata.Properties)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.handler, nil
+}
+
+// period is a quota period, such as a day, that contains the current time.
+type period struct {
+	name  string
+	id    string
+	limit int64
+	reset time.Time
+}
+
+// currentPeriods returns the periods with a quota that contain now.
+func (m *Middleware) currentPeriods(now time.Time) []period {
+	now = now.In(m.meta.location)
+	periods := make([]period, 0, 2)
+	if m.meta.DailyLimit > 0 {
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, m.meta.location)
+		periods = append(periods, period{
+			name:  "Day",
+			id:    start.Format("2006-01-02"),
+			limit: m.meta.DailyLimit,
+			reset: start.AddDate(0, 0, 1),
+		})
+	}
+	if m.meta.MonthlyLimit > 0 {
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, m.meta.location)
+		periods = append(periods, period{
+			name:  "Month",
+			id:    start.Format("2006-01"),
+			limit: m.meta.MonthlyLimit,
+			reset: start.AddDate(0, 1, 0),
+		})
+	}
+	return periods
+}
+
+func (m *Middleware) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey := r.Header.Get(m.meta.HeaderName)
+		if apiKey == "" {
+			http.Error(w, "Missing header "+m.meta.HeaderName, http.StatusUnauthorized)
+			return
+		}
+
+		// API keys are secrets, so they are not stored as-is
+		// The hash is in braces so all the counters of a key are in the same slot when using Redis Cluster
+		h := sha256.Sum256([]byte(apiKey))
+		keyHash := hex.EncodeToString(h[:])
+
+		now := m.now()
+		periods := m.currentPeriods(now)
+		counters := make([]counter, len(periods))
+		for i, p := range periods {
+			counters[i] = counter{
+				key:       m.meta.KeyPrefix + "||{" + keyHash + "}||" + strings.ToLower(p.name) + "||" + p.id,
+				limit:     p.limit,
+				expiresAt: p.reset,
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), storeTimeout)
+		allowed, used, err := m.store.Consume(ctx, counters)
+		cancel()
+		if err != nil {
+			// If the store is unavailable, let requests through rather than causing an outage
+			m.logger.Warnf("Failed to check quota, allowing request: %v", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var retryAfter time.Duration
+		for i, p := range periods {
+			remaining := p.limit - used[i]
+			if remaining <= 0 {
+				remaining = 0
+				if p.reset.Sub(now) > retryAfter {
+					retryAfter = p.reset.Sub(now)
+				}
+			}
+			untilReset := strconv.FormatInt(int64(math.Ceil(p.reset.Sub(now).Seconds())), 10)
+			w.Header().Set(LimitHeaderPrefix+p.name, strconv.FormatInt(p.limit, 10))
+			w.Header().Set(RemainingHeaderPrefix+p.name, strconv.FormatInt(remaining, 10))
+			w.Header().Set(ResetHeaderPrefix+p.name, untilReset)
+		}
+
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(retryAfter.Seconds())), 10))
+			http.Error(w, "Quota exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (m *Middleware) GetComponentMetadata() map[string]string {
+	metadataStruct := quotaMiddlewareMetadata{}
+	metadataInfo := map[string]string{}
+	mdutils.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, mdutils.MiddlewareType)
+	return metadataInfo
+}
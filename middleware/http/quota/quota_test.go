@@ -0,0 +1,146 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mdutils "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		md := quotaMiddlewareMetadata{}
+		err := md.fromMetadata(middleware.Metadata{Base: mdutils.Base{Properties: map[string]string{
+			"dailyLimit": "10",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, defaultHeaderName, md.HeaderName)
+		assert.Equal(t, defaultKeyPrefix, md.KeyPrefix)
+		assert.Equal(t, time.UTC, md.location)
+	})
+
+	t.Run("requires a limit", func(t *testing.T) {
+		md := quotaMiddlewareMetadata{}
+		err := md.fromMetadata(middleware.Metadata{})
+		require.Error(t, err)
+	})
+
+	t.Run("rejects negative limits", func(t *testing.T) {
+		md := quotaMiddlewareMetadata{}
+		err := md.fromMetadata(middleware.Metadata{Base: mdutils.Base{Properties: map[string]string{
+			"dailyLimit":   "10",
+			"monthlyLimit": "-1",
+		}}})
+		require.Error(t, err)
+	})
+
+	t.Run("rejects invalid time zone", func(t *testing.T) {
+		md := quotaMiddlewareMetadata{}
+		err := md.fromMetadata(middleware.Metadata{Base: mdutils.Base{Properties: map[string]string{
+			"monthlyLimit": "10",
+			"timeZone":     "Not/AZone",
+		}}})
+		require.Error(t, err)
+	})
+}
+
+func TestQuota(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	m := NewMiddleware(logger.NewLogger("test")).(*Middleware)
+	now := time.Date(2023, 5, 31, 23, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	s.SetTime(now)
+	handlerFn, err := m.GetHandler(context.Background(), middleware.Metadata{Base: mdutils.Base{Properties: map[string]string{
+		"redisHost":    s.Addr(),
+		"dailyLimit":   "2",
+		"monthlyLimit": "3",
+	}}})
+	require.NoError(t, err)
+	handler := handlerFn(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	do := func(apiKey string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if apiKey != "" {
+			r.Header.Set(defaultHeaderName, apiKey)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("missing API key", func(t *testing.T) {
+		w := do("")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("reports usage", func(t *testing.T) {
+		w := do("key1")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-Quota-Limit-Day"))
+		assert.Equal(t, "1", w.Header().Get("X-Quota-Remaining-Day"))
+		assert.Equal(t, "3600", w.Header().Get("X-Quota-Reset-Day"))
+		assert.Equal(t, "3", w.Header().Get("X-Quota-Limit-Month"))
+		assert.Equal(t, "2", w.Header().Get("X-Quota-Remaining-Month"))
+		assert.Equal(t, "3600", w.Header().Get("X-Quota-Reset-Month"))
+	})
+
+	t.Run("daily quota exhausted", func(t *testing.T) {
+		w := do("key1")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "0", w.Header().Get("X-Quota-Remaining-Day"))
+
+		w = do("key1")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "3600", w.Header().Get("Retry-After"))
+		assert.Equal(t, "0", w.Header().Get("X-Quota-Remaining-Day"))
+		// Rejected requests are not counted
+		assert.Equal(t, "1", w.Header().Get("X-Quota-Remaining-Month"))
+	})
+
+	t.Run("other keys are not affected", func(t *testing.T) {
+		w := do("key2")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "1", w.Header().Get("X-Quota-Remaining-Day"))
+	})
+
+	t.Run("quota is reset in the next period", func(t *testing.T) {
+		now = now.Add(2 * time.Hour)
+		s.SetTime(now)
+		s.FastForward(2 * time.Hour)
+		w := do("key1")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "1", w.Header().Get("X-Quota-Remaining-Day"))
+		assert.Equal(t, "2", w.Header().Get("X-Quota-Remaining-Month"))
+	})
+
+	t.Run("fails open when the store is unavailable", func(t *testing.T) {
+		s.Close()
+		w := do("key1")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
+	mdutils "github.com/dapr/components-contrib/metadata"
+)
+
+// counter is the usage counter of an API key in a quota period.
+type counter struct {
+	key       string
+	limit     int64
+	expiresAt time.Time
+}
+
+// counterStore stores usage counters.
+type counterStore interface {
+	// Consume increments all the counters, unless any of them already reached its limit.
+	// It returns whether the counters were incremented, and the value of each counter after the operation.
+	Consume(ctx context.Context, counters []counter) (bool, []int64, error)
+}
+
+// Lua script that atomically checks the counters in KEYS against their limits, and increments all of them if none reached its limit.
+// For each key, ARGV contains the limit followed by the Unix time, in milliseconds, at which the counter expires.
+// Returns an array with 1 if the counters were incremented (or 0 otherwise), followed by the value of each counter.
+const consumeScript = `
+local allowed = 1
+local used = {}
+for i = 1, #KEYS do
+	used[i] = tonumber(redis.call("GET", KEYS[i]) or "0")
+	if used[i] >= tonumber(ARGV[2 * i - 1]) then
+		allowed = 0
+	end
+end
+if allowed == 1 then
+	for i = 1, #KEYS do
+		used[i] = redis.call("INCR", KEYS[i])
+		if used[i] == 1 then
+			redis.call("PEXPIREAT", KEYS[i], ARGV[2 * i])
+		end
+	end
+end
+table.insert(used, 1, allowed)
+return used
+`
+
+// redisCounterStore stores counters in Redis, so quotas are shared by all instances.
+type redisCounterStore struct {
+	client rediscomponent.RedisClient
+}
+
+func newRedisCounterStore(properties map[string]string) (*redisCounterStore, error) {
+	client, _, err := rediscomponent.ParseClientFromProperties(properties, mdutils.MiddlewareType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Redis client: %w", err)
+	}
+	return &redisCounterStore{client: client}, nil
+}
+
+func (s *redisCounterStore) Consume(ctx context.Context, counters []counter) (bool, []int64, error) {
+	args := make([]any, 0, 3+3*len(counters))
+	args = append(args, "EVAL", consumeScript, len(counters))
+	for _, c := range counters {
+		args = append(args, c.key)
+	}
+	for _, c := range counters {
+		args = append(args, strconv.FormatInt(c.limit, 10), strconv.FormatInt(c.expiresAt.UnixMilli(), 10))
+	}
+
+	res, err := s.client.DoRead(ctx, args...)
+	if err != nil {
+		return false, nil, err
+	}
+	values, ok := res.([]any)
+	if !ok || len(values) != len(counters)+1 {
+		return false, nil, fmt.Errorf("quota script returned an unexpected result: %v", res)
+	}
+	used := make([]int64, len(counters))
+	for i, v := range values[1:] {
+		used[i], ok = v.(int64)
+		if !ok {
+			return false, nil, fmt.Errorf("quota script returned an unexpected result: %v", res)
+		}
+	}
+	return values[0] == int64(1), used, nil
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	tollbooth "github.com/didip/tollbooth/v7"
+	libstring "github.com/didip/tollbooth/v7/libstring"
+	"github.com/didip/tollbooth/v7/limiter"
+
+	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+)
+
+// bucketStore consumes tokens from rate-limiting buckets.
+type bucketStore interface {
+	// Take consumes a token from the bucket with the given key, and returns false if the bucket is empty.
+	Take(ctx context.Context, key string) (bool, error)
+}
+
+// memoryBucketStore stores buckets in memory, so limits are applied to each instance separately.
+type memoryBucketStore struct {
+	limiter *limiter.Limiter
+}
+
+func (s *memoryBucketStore) Take(_ context.Context, key string) (bool, error) {
+	return tollbooth.LimitByKeys(s.limiter, []string{key}) == nil, nil
+}
+
+// Lua script that atomically refills the bucket in KEYS[1] based on the time elapsed since the last request, then consumes a token if available.
+// ARGV[1] is the refill rate in tokens per second and ARGV[2] is the capacity of the bucket.
+// The server's time is used so instances with skewed clocks share consistent buckets.
+// Returns 1 if the token was consumed, or 0 if the bucket is empty.
+const takeTokenScript = `
+local rate = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+if now > ts then
+	tokens = math.min(capacity, tokens + (now - ts) * rate)
+end
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(now))
+redis.call("EXPIRE", KEYS[1], math.ceil(capacity / rate) + 1)
+return allowed
+`
+
+// redisBucketStore stores buckets in Redis, so limits are shared by all instances.
+type redisBucketStore struct {
+	client    rediscomponent.RedisClient
+	keyPrefix string
+	rate      string
+	capacity  string
+}
+
+func newRedisBucketStore(properties map[string]string, meta *rateLimitMiddlewareMetadata) (*redisBucketStore, error) {
+	client, _, err := rediscomponent.ParseClientFromProperties(properties, contribMetadata.MiddlewareType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Redis client: %w", err)
+	}
+
+	return &redisBucketStore{
+		client:    client,
+		keyPrefix: meta.KeyPrefix,
+		rate:      strconv.FormatFloat(meta.MaxRequestsPerSecond, 'f', -1, 64),
+		capacity:  strconv.Itoa(meta.Burst),
+	}, nil
+}
+
+// Close closes the connection to Redis.
+func (s *redisBucketStore) Close() error {
+	return s.client.Close()
+}
+
+func (s *redisBucketStore) Take(ctx context.Context, key string) (bool, error) {
+	res, parseErr, err := s.client.EvalInt(ctx, takeTokenScript, []string{s.keyPrefix + "||" + key}, s.rate, s.capacity)
+	if err != nil {
+		return false, err
+	}
+	if parseErr != nil {
+		return false, parseErr
+	}
+	if res == nil {
+		return false, fmt.Errorf("rate limiting script returned no result")
+	}
+	return *res == 1, nil
+}
+
+// requestKey returns the key of the bucket for the request, according to the key strategy.
+func requestKey(meta *rateLimitMiddlewareMetadata, lmt *limiter.Limiter, r *http.Request) string {
+	switch meta.KeyStrategy {
+	case keyStrategyHeader:
+		// Requests without the header share a single bucket
+		return "header|" + r.Header.Get(meta.KeyHeader)
+	case keyStrategyRoute:
+		return "route|" + r.URL.Path
+	default:
+		remoteIP := libstring.RemoteIP(lmt.GetIPLookups(), lmt.GetForwardedForIndexFromBehind(), r)
+		remoteIP = libstring.CanonicalizeIP(remoteIP)
+		if remoteIP == "" {
+			remoteIP = "0.0.0.0"
+		}
+		return "ip|" + remoteIP
+	}
+}
+
+// keyedHandler returns a handler that limits requests using buckets in the store, keyed by the configured strategy.
+func (m *Middleware) keyedHandler(meta *rateLimitMiddlewareMetadata, lmt *limiter.Limiter, store bucketStore) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowed, err := store.Take(r.Context(), requestKey(meta, lmt, r))
+			if err != nil {
+				if meta.FailOpen {
+					// Let requests through rather than causing an outage
+					m.logger.Warnf("Failed to check rate limit, allowing request: %v", err)
+					allowed = true
+				} else {
+					m.logger.Warnf("Failed to check rate limit, rejecting request: %v", err)
+				}
+			}
+			if !allowed {
+				w.Header().Add("Content-Type", lmt.GetMessageContentType())
+				w.WriteHeader(lmt.GetStatusCode())
+				w.Write([]byte(lmt.GetMessage()))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
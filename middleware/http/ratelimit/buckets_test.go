@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+func getTestHandler(t *testing.T, properties map[string]string) http.Handler {
+	t.Helper()
+
+	m := NewRateLimitMiddleware(logger.NewLogger("test"))
+	handler, err := m.GetHandler(context.Background(), middleware.Metadata{Base: metadata.Base{Properties: properties}})
+	require.NoError(t, err)
+
+	return handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func serveRequest(handler http.Handler, path string, headers map[string]string) int {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestRedisBackend(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	properties := map[string]string{
+		maxRequestsPerSecondKey: "0.001",
+		"burst":                 "2",
+		"backend":               "redis",
+		"keyStrategy":           "header",
+		"keyHeader":             "x-api-key",
+		"redisHost":             s.Addr(),
+	}
+
+	// Two handlers sharing the same Redis simulate two instances
+	handler1 := getTestHandler(t, properties)
+	handler2 := getTestHandler(t, properties)
+
+	assert.Equal(t, http.StatusOK, serveRequest(handler1, "/", map[string]string{"x-api-key": "a"}))
+	assert.Equal(t, http.StatusOK, serveRequest(handler2, "/", map[string]string{"x-api-key": "a"}))
+	assert.Equal(t, http.StatusTooManyRequests, serveRequest(handler1, "/", map[string]string{"x-api-key": "a"}))
+	assert.Equal(t, http.StatusTooManyRequests, serveRequest(handler2, "/", map[string]string{"x-api-key": "a"}))
+
+	// Other keys have their own bucket
+	assert.Equal(t, http.StatusOK, serveRequest(handler1, "/", map[string]string{"x-api-key": "b"}))
+	assert.True(t, s.Exists(defaultKeyPrefix+"||header|a"))
+	assert.True(t, s.Exists(defaultKeyPrefix+"||header|b"))
+
+	t.Run("allows requests when Redis is unavailable", func(t *testing.T) {
+		s.Close()
+		assert.Equal(t, http.StatusOK, serveRequest(handler1, "/", map[string]string{"x-api-key": "a"}))
+	})
+}
+
+func TestRedisBackendFailClosed(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	handler := getTestHandler(t, map[string]string{
+		"backend":   "redis",
+		"failOpen":  "false",
+		"redisHost": s.Addr(),
+	})
+	assert.Equal(t, http.StatusOK, serveRequest(handler, "/", nil))
+
+	s.Close()
+	assert.Equal(t, http.StatusTooManyRequests, serveRequest(handler, "/", nil))
+}
+
+func TestMiddlewareClose(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	m := NewRateLimitMiddleware(logger.NewLogger("test")).(*Middleware)
+	for i := 0; i < 2; i++ {
+		_, err := m.GetHandler(context.Background(), middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"backend":   "redis",
+			"redisHost": s.Addr(),
+		}}})
+		require.NoError(t, err)
+	}
+	require.Len(t, m.stores, 2)
+	stores := m.stores
+
+	require.NoError(t, m.Close())
+	assert.Empty(t, m.stores)
+	for _, store := range stores {
+		_, err := store.Take(context.Background(), "key")
+		assert.Error(t, err)
+	}
+}
+
+func TestMemoryBackendKeyStrategies(t *testing.T) {
+	t.Run("route", func(t *testing.T) {
+		handler := getTestHandler(t, map[string]string{
+			maxRequestsPerSecondKey: "1",
+			"keyStrategy":           "route",
+		})
+
+		assert.Equal(t, http.StatusOK, serveRequest(handler, "/foo", nil))
+		assert.Equal(t, http.StatusTooManyRequests, serveRequest(handler, "/foo", nil))
+		assert.Equal(t, http.StatusOK, serveRequest(handler, "/bar", nil))
+	})
+
+	t.Run("header", func(t *testing.T) {
+		handler := getTestHandler(t, map[string]string{
+			maxRequestsPerSecondKey: "1",
+			"keyStrategy":           "header",
+			"keyHeader":             "x-tenant",
+		})
+
+		assert.Equal(t, http.StatusOK, serveRequest(handler, "/", map[string]string{"x-tenant": "a"}))
+		assert.Equal(t, http.StatusTooManyRequests, serveRequest(handler, "/", map[string]string{"x-tenant": "a"}))
+		assert.Equal(t, http.StatusOK, serveRequest(handler, "/", map[string]string{"x-tenant": "b"}))
+	})
+}
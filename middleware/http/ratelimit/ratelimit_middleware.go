@@ -15,9 +15,13 @@ package ratelimit
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"reflect"
+	"strings"
+	"sync"
 
 	tollbooth "github.com/didip/tollbooth/v7"
 	libstring "github.com/didip/tollbooth/v7/libstring"
@@ -30,22 +34,53 @@ import (
 // Metadata is the ratelimit middleware config.
 type rateLimitMiddlewareMetadata struct {
 	MaxRequestsPerSecond float64 `json:"maxRequestsPerSecond"`
+	// Where the token buckets are stored: "memory" (per-instance, the default) or "redis" (shared by all instances).
+	// When using "redis", the connection is configured with the same metadata properties as the Redis state store, such as "redisHost".
+	// Other state stores are not supported, as middlewares can't reference state store components.
+	Backend string `json:"backend"`
+	// Whether requests are allowed when the "redis" backend is unavailable (the default), or rejected as if the limit was reached.
+	FailOpen bool `json:"failOpen"`
+	// Maximum number of requests that can be served in a burst, for the "redis" backend.
+	// Defaults to maxRequestsPerSecond, rounded up.
+	Burst int `json:"burst"`
+	// How requests are grouped into buckets: "ip" (the default), "header", or "route".
+	KeyStrategy string `json:"keyStrategy"`
+	// Name of the header whose value is the key of the bucket, for the "header" key strategy.
+	KeyHeader string `json:"keyHeader"`
+	// Prefix of the keys in Redis, for the "redis" backend.
+	KeyPrefix string `json:"keyPrefix"`
 }
 
 const (
 	maxRequestsPerSecondKey = "maxRequestsPerSecond"
 
+	backendMemory = "memory"
+	backendRedis  = "redis"
+
+	keyStrategyIP     = "ip"
+	keyStrategyHeader = "header"
+	keyStrategyRoute  = "route"
+
 	// Defaults.
 	defaultMaxRequestsPerSecond = 100
+	defaultKeyPrefix            = "dapr-ratelimit"
 )
 
 // NewRateLimitMiddleware returns a new ratelimit middleware.
-func NewRateLimitMiddleware(_ logger.Logger) middleware.Middleware {
-	return &Middleware{}
+func NewRateLimitMiddleware(logger logger.Logger) middleware.Middleware {
+	return &Middleware{
+		logger: logger,
+	}
 }
 
 // Middleware is an ratelimit middleware.
-type Middleware struct{}
+type Middleware struct {
+	logger logger.Logger
+
+	// Redis bucket stores created by GetHandler, closed by Close
+	stores     []*redisBucketStore
+	storesLock sync.Mutex
+}
 
 // GetHandler returns the HTTP handler provided by the middleware.
 func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
@@ -56,6 +91,23 @@ func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata)
 
 	limiter := tollbooth.NewLimiter(meta.MaxRequestsPerSecond, nil)
 
+	if meta.Backend == backendRedis || meta.KeyStrategy != keyStrategyIP {
+		var store bucketStore
+		if meta.Backend == backendRedis {
+			redisStore, err := newRedisBucketStore(metadata.Properties, meta)
+			if err != nil {
+				return nil, err
+			}
+			m.storesLock.Lock()
+			m.stores = append(m.stores, redisStore)
+			m.storesLock.Unlock()
+			store = redisStore
+		} else {
+			store = &memoryBucketStore{limiter: limiter}
+		}
+		return m.keyedHandler(meta, limiter, store), nil
+	}
+
 	return func(next http.Handler) http.Handler {
 		// Adapted from toolbooth.LimitHandler
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -88,6 +140,7 @@ func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata)
 func (m *Middleware) getNativeMetadata(metadata middleware.Metadata) (*rateLimitMiddlewareMetadata, error) {
 	middlewareMetadata := rateLimitMiddlewareMetadata{
 		MaxRequestsPerSecond: defaultMaxRequestsPerSecond,
+		FailOpen:             true,
 	}
 	err := contribMetadata.DecodeMetadata(metadata.Properties, &middlewareMetadata)
 	if err != nil {
@@ -98,9 +151,56 @@ func (m *Middleware) getNativeMetadata(metadata middleware.Metadata) (*rateLimit
 		return nil, fmt.Errorf("metadata property %s must be a positive value", maxRequestsPerSecondKey)
 	}
 
+	middlewareMetadata.Backend = strings.ToLower(middlewareMetadata.Backend)
+	switch middlewareMetadata.Backend {
+	case "":
+		middlewareMetadata.Backend = backendMemory
+	case backendMemory, backendRedis:
+		// Nop
+	default:
+		return nil, fmt.Errorf("metadata property backend must be one of: %s, %s", backendMemory, backendRedis)
+	}
+
+	middlewareMetadata.KeyStrategy = strings.ToLower(middlewareMetadata.KeyStrategy)
+	switch middlewareMetadata.KeyStrategy {
+	case "":
+		middlewareMetadata.KeyStrategy = keyStrategyIP
+	case keyStrategyIP, keyStrategyRoute:
+		// Nop
+	case keyStrategyHeader:
+		if middlewareMetadata.KeyHeader == "" {
+			return nil, fmt.Errorf("metadata property keyHeader is required when keyStrategy is %s", keyStrategyHeader)
+		}
+	default:
+		return nil, fmt.Errorf("metadata property keyStrategy must be one of: %s, %s, %s", keyStrategyIP, keyStrategyHeader, keyStrategyRoute)
+	}
+
+	if middlewareMetadata.Burst < 0 {
+		return nil, fmt.Errorf("metadata property burst must not be negative")
+	}
+	if middlewareMetadata.Burst == 0 {
+		middlewareMetadata.Burst = int(math.Ceil(middlewareMetadata.MaxRequestsPerSecond))
+	}
+	if middlewareMetadata.KeyPrefix == "" {
+		middlewareMetadata.KeyPrefix = defaultKeyPrefix
+	}
+
 	return &middlewareMetadata, nil
 }
 
+// Close closes the connections to Redis opened by the handlers.
+func (m *Middleware) Close() error {
+	m.storesLock.Lock()
+	defer m.storesLock.Unlock()
+
+	errs := make([]error, 0, len(m.stores))
+	for _, store := range m.stores {
+		errs = append(errs, store.Close())
+	}
+	m.stores = nil
+	return errors.Join(errs...)
+}
+
 func (m *Middleware) GetComponentMetadata() map[string]string {
 	metadataStruct := rateLimitMiddlewareMetadata{}
 	metadataInfo := map[string]string{}
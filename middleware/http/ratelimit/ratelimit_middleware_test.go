@@ -70,4 +70,32 @@ func TestMiddlewareGetNativeMetadata(t *testing.T) {
 		require.NotNil(t, res)
 		assert.Equal(t, float64(42.42), res.MaxRequestsPerSecond)
 	})
+
+	t.Run("defaults", func(t *testing.T) {
+		res, err := m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+			maxRequestsPerSecondKey: "42.42",
+		}}})
+		require.NoError(t, err)
+		require.NotNil(t, res)
+		assert.Equal(t, backendMemory, res.Backend)
+		assert.Equal(t, keyStrategyIP, res.KeyStrategy)
+		assert.Equal(t, 43, res.Burst)
+		assert.Equal(t, defaultKeyPrefix, res.KeyPrefix)
+	})
+
+	t.Run("invalid backend", func(t *testing.T) {
+		_, err := m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"backend": "foo",
+		}}})
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "metadata property backend")
+	})
+
+	t.Run("header key strategy requires keyHeader", func(t *testing.T) {
+		_, err := m.getNativeMetadata(middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"keyStrategy": "header",
+		}}})
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "metadata property keyHeader is required")
+	})
 }
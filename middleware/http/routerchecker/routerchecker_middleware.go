@@ -19,6 +19,9 @@ import (
 	"net/http"
 	"reflect"
 	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 
 	"github.com/dapr/components-contrib/internal/httputils"
 	mdutils "github.com/dapr/components-contrib/metadata"
@@ -26,9 +29,34 @@ import (
 	"github.com/dapr/kit/logger"
 )
 
+const (
+	// ActionReject responds to the requests that don't match the conditions with an error.
+	ActionReject = "reject"
+	// ActionTag forwards the requests that don't match the conditions, adding a header with the name of the failed condition.
+	ActionTag = "tag"
+
+	defaultRejectStatusCode = http.StatusBadRequest
+	defaultRejectBody       = "invalid router"
+	defaultTagHeader        = "X-Dapr-Router-Check-Failed"
+)
+
 // Metadata is the routerchecker middleware config.
 type Metadata struct {
+	// Regular expression that the URI of the request must match.
 	Rule string `json:"rule" mapstructure:"rule"`
+	// Comma-separated list of the allowed HTTP methods.
+	Methods string `json:"methods" mapstructure:"methods"`
+	// Dictionary of header names and regular expressions that their values must match, as a JSON or YAML-encoded string.
+	Headers string `json:"headers" mapstructure:"headers"`
+	// Dictionary of query parameter names and regular expressions that their values must match, as a JSON or YAML-encoded string.
+	QueryParameters string `json:"queryParameters" mapstructure:"queryParameters"`
+	// Action for the requests that don't match: "reject" (default) or "tag".
+	Action string `json:"action" mapstructure:"action"`
+	// Status code and body of the response to rejected requests.
+	RejectStatusCode int    `json:"rejectStatusCode" mapstructure:"rejectStatusCode"`
+	RejectBody       string `json:"rejectBody" mapstructure:"rejectBody"`
+	// Name of the header added to tagged requests.
+	TagHeader string `json:"tagHeader" mapstructure:"tagHeader"`
 }
 
 // NewRouterCheckerMiddleware returns a new routerchecker middleware.
@@ -41,6 +69,14 @@ type Middleware struct {
 	logger logger.Logger
 }
 
+// checker contains the compiled conditions of the middleware.
+type checker struct {
+	rule            *regexp.Regexp
+	methods         map[string]struct{}
+	headers         map[string]*regexp.Regexp
+	queryParameters map[string]*regexp.Regexp
+}
+
 // GetHandler retruns the HTTP handler provided by the middleware.
 func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
 	meta, err := m.getNativeMetadata(metadata)
@@ -48,16 +84,23 @@ func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata)
 		return nil, err
 	}
 
-	re, err := regexp.Compile(meta.Rule)
+	c, err := newChecker(meta)
 	if err != nil {
-		return nil, fmt.Errorf("failed to compile rule regexp: %w", err)
+		return nil, err
 	}
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			isPass := re.MatchString(httputils.RequestURI(r))
-			if !isPass {
-				httputils.RespondWithErrorAndMessage(w, http.StatusBadRequest, "invalid router")
+			failed := c.check(r)
+			switch {
+			case meta.Action == ActionTag:
+				// Don't trust the tag header sent by the client
+				r.Header.Del(meta.TagHeader)
+				if failed != "" {
+					r.Header.Set(meta.TagHeader, failed)
+				}
+			case failed != "":
+				httputils.RespondWithErrorAndMessage(w, meta.RejectStatusCode, meta.RejectBody)
 				return
 			}
 			next.ServeHTTP(w, r)
@@ -65,12 +108,116 @@ func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata)
 	}, nil
 }
 
+func newChecker(meta *Metadata) (*checker, error) {
+	c := &checker{}
+
+	var err error
+	c.rule, err = regexp.Compile(meta.Rule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile rule regexp: %w", err)
+	}
+
+	if meta.Methods != "" {
+		c.methods = map[string]struct{}{}
+		for _, method := range strings.Split(meta.Methods, ",") {
+			method = strings.ToUpper(strings.TrimSpace(method))
+			if method != "" {
+				c.methods[method] = struct{}{}
+			}
+		}
+	}
+
+	c.headers, err = compileConditions("headers", meta.Headers)
+	if err != nil {
+		return nil, err
+	}
+	c.queryParameters, err = compileConditions("queryParameters", meta.QueryParameters)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// compileConditions parses a dictionary of names and regular expressions.
+func compileConditions(property string, val string) (map[string]*regexp.Regexp, error) {
+	if val == "" {
+		return nil, nil
+	}
+
+	conditions := map[string]string{}
+	err := yaml.Unmarshal([]byte(val), &conditions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode '%s' property as JSON or YAML: %w", property, err)
+	}
+
+	res := make(map[string]*regexp.Regexp, len(conditions))
+	for name, expr := range conditions {
+		res[name], err = regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile regexp for '%s' in '%s' property: %w", name, property, err)
+		}
+	}
+	return res, nil
+}
+
+// check returns the name of the first condition that the request doesn't match, or an empty string if the request matches all of them.
+// Missing headers and query parameters are matched as empty strings.
+func (c *checker) check(r *http.Request) string {
+	if !c.rule.MatchString(httputils.RequestURI(r)) {
+		return "rule"
+	}
+
+	if c.methods != nil {
+		if _, ok := c.methods[r.Method]; !ok {
+			return "method"
+		}
+	}
+
+	for name, re := range c.headers {
+		if !re.MatchString(r.Header.Get(name)) {
+			return "header:" + name
+		}
+	}
+
+	if len(c.queryParameters) > 0 {
+		query := r.URL.Query()
+		for name, re := range c.queryParameters {
+			if !re.MatchString(query.Get(name)) {
+				return "query:" + name
+			}
+		}
+	}
+
+	return ""
+}
+
 func (m *Middleware) getNativeMetadata(metadata middleware.Metadata) (*Metadata, error) {
-	var middlewareMetadata Metadata
+	middlewareMetadata := Metadata{
+		Action:           ActionReject,
+		RejectStatusCode: defaultRejectStatusCode,
+		RejectBody:       defaultRejectBody,
+		TagHeader:        defaultTagHeader,
+	}
 	err := mdutils.DecodeMetadata(metadata.Properties, &middlewareMetadata)
 	if err != nil {
 		return nil, err
 	}
+
+	middlewareMetadata.Action = strings.ToLower(middlewareMetadata.Action)
+	switch middlewareMetadata.Action {
+	case ActionReject:
+		if middlewareMetadata.RejectStatusCode < 400 || middlewareMetadata.RejectStatusCode > 599 {
+			return nil, fmt.Errorf("invalid rejectStatusCode %d: must be an HTTP error status code", middlewareMetadata.RejectStatusCode)
+		}
+	case ActionTag:
+		if middlewareMetadata.TagHeader == "" {
+			return nil, fmt.Errorf("tagHeader is required when action is '%s'", ActionTag)
+		}
+	default:
+		return nil, fmt.Errorf("invalid action '%s': must be one of '%s' or '%s'", middlewareMetadata.Action, ActionReject, ActionTag)
+	}
+
 	return &middlewareMetadata, nil
 }
 
@@ -64,3 +64,115 @@ func TestRequestHandlerWithLegalRouterRule(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+func TestRequestHandlerWithConditions(t *testing.T) {
+	meta := middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"rule":            "^[A-Za-z0-9/._?=&-]+$",
+		"methods":         "get, POST",
+		"headers":         `{"X-Tenant": "^[a-z]+$"}`,
+		"queryParameters": `{"version": "^(|v[0-9]+)$"}`,
+	}}}
+	log := logger.NewLogger("routerchecker.test")
+	rchecker := NewMiddleware(log)
+	handler, err := rchecker.GetHandler(context.Background(), meta)
+	assert.Nil(t, err)
+
+	testCases := []struct {
+		name    string
+		method  string
+		target  string
+		tenant  string
+		expCode int
+	}{
+		{name: "all conditions match", method: http.MethodGet, target: "/v1.0/invoke/app/method/foo?version=v1", tenant: "acme", expCode: http.StatusOK},
+		{name: "missing query parameter", method: http.MethodPost, target: "/v1.0/invoke/app/method/foo", tenant: "acme", expCode: http.StatusOK},
+		{name: "method not allowed", method: http.MethodDelete, target: "/v1.0/invoke/app/method/foo", tenant: "acme", expCode: http.StatusBadRequest},
+		{name: "header doesn't match", method: http.MethodGet, target: "/v1.0/invoke/app/method/foo", tenant: "ACME", expCode: http.StatusBadRequest},
+		{name: "missing header", method: http.MethodGet, target: "/v1.0/invoke/app/method/foo", expCode: http.StatusBadRequest},
+		{name: "query parameter doesn't match", method: http.MethodGet, target: "/v1.0/invoke/app/method/foo?version=latest", tenant: "acme", expCode: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, "http://localhost:5001"+tc.target, nil)
+			if tc.tenant != "" {
+				r.Header.Set("X-Tenant", tc.tenant)
+			}
+			w := httptest.NewRecorder()
+			handler(http.HandlerFunc(mockedRequestHandler)).ServeHTTP(w, r)
+
+			assert.Equal(t, tc.expCode, w.Code)
+		})
+	}
+}
+
+func TestRequestHandlerWithRejectResponse(t *testing.T) {
+	meta := middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"methods":          "GET",
+		"rejectStatusCode": "405",
+		"rejectBody":       "method not allowed",
+	}}}
+	log := logger.NewLogger("routerchecker.test")
+	rchecker := NewMiddleware(log)
+	handler, err := rchecker.GetHandler(context.Background(), meta)
+	assert.Nil(t, err)
+
+	r := httptest.NewRequest(http.MethodPut, "http://localhost:5001/v1.0/invoke/app/method/foo", nil)
+	w := httptest.NewRecorder()
+	handler(http.HandlerFunc(mockedRequestHandler)).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "method not allowed", w.Body.String())
+}
+
+func TestRequestHandlerWithTagAction(t *testing.T) {
+	meta := middleware.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"headers":   `{"X-Tenant": "^[a-z]+$"}`,
+		"action":    "tag",
+		"tagHeader": "X-Check",
+	}}}
+	log := logger.NewLogger("routerchecker.test")
+	rchecker := NewMiddleware(log)
+	handler, err := rchecker.GetHandler(context.Background(), meta)
+	assert.Nil(t, err)
+
+	var tag string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tag = r.Header.Get("X-Check")
+		mockedRequestHandler(w, r)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "http://localhost:5001/v1.0/invoke/app/method/foo", nil)
+	r.Header.Set("X-Tenant", "ACME")
+	w := httptest.NewRecorder()
+	handler(next).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "header:X-Tenant", tag)
+
+	// The tag sent by the client is removed
+	r = httptest.NewRequest(http.MethodGet, "http://localhost:5001/v1.0/invoke/app/method/foo", nil)
+	r.Header.Set("X-Tenant", "acme")
+	r.Header.Set("X-Check", "spoofed")
+	w = httptest.NewRecorder()
+	handler(next).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "", tag)
+}
+
+func TestInvalidMetadata(t *testing.T) {
+	log := logger.NewLogger("routerchecker.test")
+	rchecker := NewMiddleware(log)
+
+	for name, props := range map[string]map[string]string{
+		"invalid rule":        {"rule": "["},
+		"invalid headers":     {"headers": "[not a dictionary"},
+		"invalid header rule": {"headers": `{"X-Tenant": "["}`},
+		"invalid action":      {"action": "drop"},
+		"invalid status code": {"rejectStatusCode": "200"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			meta := middleware.Metadata{Base: metadata.Base{Properties: props}}
+			_, err := rchecker.GetHandler(context.Background(), meta)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/google/cel-go/cel"
+	"github.com/itchyny/gojq"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Maximum cost of the evaluation of a CEL expression.
+const celCostLimit = 1_000_000
+
+// expression is a compiled expression that can be evaluated against a document.
+type expression interface {
+	// Eval evaluates the expression against the document, which contains JSON-compatible values.
+	Eval(ctx context.Context, doc map[string]any) (any, error)
+}
+
+func compileExpression(language string, src string) (expression, error) {
+	switch language {
+	case languageJQ:
+		return compileJQ(src)
+	case languageCEL:
+		return compileCEL(src)
+	default:
+		return nil, fmt.Errorf("unsupported language %s", language)
+	}
+}
+
+type jqExpression struct {
+	code *gojq.Code
+}
+
+func compileJQ(src string) (*jqExpression, error) {
+	query, err := gojq.Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse jq expression: %w", err)
+	}
+	// Do not expose the environment variables of the process
+	code, err := gojq.Compile(query, gojq.WithEnvironLoader(func() []string { return nil }))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile jq expression: %w", err)
+	}
+	return &jqExpression{code: code}, nil
+}
+
+func (e *jqExpression) Eval(ctx context.Context, doc map[string]any) (any, error) {
+	iter := e.code.RunWithContext(ctx, doc)
+	res, ok := iter.Next()
+	if !ok {
+		return nil, errors.New("jq expression did not return a value")
+	}
+	if err, ok := res.(error); ok {
+		return nil, err
+	}
+	// Only the first value is used
+	return res, nil
+}
+
+type celExpression struct {
+	program cel.Program
+}
+
+func compileCEL(src string) (*celExpression, error) {
+	env, err := cel.NewEnv(
+		cel.Variable(fieldMethod, cel.StringType),
+		cel.Variable(fieldPath, cel.StringType),
+		cel.Variable(fieldStatus, cel.IntType),
+		cel.Variable(fieldHeaders, cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable(fieldBody, cel.DynType),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Compile(src)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("failed to compile CEL expression: %w", issues.Err())
+	}
+	program, err := env.Program(ast,
+		cel.CostLimit(celCostLimit),
+		cel.InterruptCheckFrequency(100),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile CEL expression: %w", err)
+	}
+	return &celExpression{program: program}, nil
+}
+
+func (e *celExpression) Eval(ctx context.Context, doc map[string]any) (any, error) {
+	vars := make(map[string]any, len(doc)+1)
+	for k, v := range doc {
+		vars[k] = v
+	}
+	// Status is an int in CEL
+	if status, ok := vars[fieldStatus].(float64); ok {
+		vars[fieldStatus] = int64(status)
+	} else {
+		vars[fieldStatus] = int64(0)
+	}
+	if _, ok := vars[fieldMethod]; !ok {
+		vars[fieldMethod] = ""
+	}
+	if _, ok := vars[fieldPath]; !ok {
+		vars[fieldPath] = ""
+	}
+
+	out, _, err := e.program.ContextEval(ctx, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert the result to JSON-compatible values
+	native, err := out.ConvertToNative(reflect.TypeOf(&structpb.Value{}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert result of CEL expression: %w", err)
+	}
+	return native.(*structpb.Value).AsInterface(), nil
+}
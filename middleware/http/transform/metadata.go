@@ -0,0 +1,77 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	mdutils "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+)
+
+const (
+	languageCEL = "cel"
+	languageJQ  = "jq"
+
+	defaultMaxBodySize = 1 << 20 // 1MiB
+	defaultTimeout     = 100 * time.Millisecond
+)
+
+type transformMiddlewareMetadata struct {
+	// Language of the expressions: "jq" (the default) or "cel".
+	Language string `json:"language" mapstructure:"language"`
+	// Expression evaluated against requests.
+	RequestExpression string `json:"requestExpression" mapstructure:"requestExpression"`
+	// Expression evaluated against responses.
+	ResponseExpression string `json:"responseExpression" mapstructure:"responseExpression"`
+	// Maximum size of the bodies that are transformed, in bytes.
+	// Larger requests are rejected, while larger responses are sent unmodified.
+	MaxBodySize int64 `json:"maxBodySize" mapstructure:"maxBodySize"`
+	// Maximum time the evaluation of an expression can take.
+	Timeout time.Duration `json:"timeout" mapstructure:"timeout"`
+}
+
+// Parse the component's metadata into the object.
+func (md *transformMiddlewareMetadata) fromMetadata(metadata middleware.Metadata) error {
+	// Set defaults
+	md.Language = languageJQ
+	md.MaxBodySize = defaultMaxBodySize
+	md.Timeout = defaultTimeout
+
+	// Decode the properties
+	err := mdutils.DecodeMetadata(metadata.Properties, md)
+	if err != nil {
+		return err
+	}
+
+	// Validate properties
+	md.Language = strings.ToLower(md.Language)
+	if md.Language != languageJQ && md.Language != languageCEL {
+		return fmt.Errorf("metadata property 'language' must be one of: %s, %s", languageJQ, languageCEL)
+	}
+	if md.RequestExpression == "" && md.ResponseExpression == "" {
+		return errors.New("at least one of the metadata properties 'requestExpression' and 'responseExpression' is required")
+	}
+	if md.MaxBodySize <= 0 {
+		return errors.New("metadata property 'maxBodySize' must be a positive number")
+	}
+	if md.Timeout <= 0 {
+		return errors.New("metadata property 'timeout' must be a positive duration")
+	}
+
+	return nil
+}
@@ -0,0 +1,397 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	mdutils "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+// Fields of the documents that expressions are evaluated against.
+// Requests have method, path, headers, and body; responses have status, headers, and body.
+// Expressions return either a boolean, where false rejects the request or response, or an object whose fields replace the ones in the document.
+// If the returned object has a "reject" field set to true, the request or response is rejected with the status code in the "status" field (default: 403) and the text in the "message" field.
+const (
+	fieldMethod  = "method"
+	fieldPath    = "path"
+	fieldStatus  = "status"
+	fieldHeaders = "headers"
+	fieldBody    = "body"
+	fieldReject  = "reject"
+	fieldMessage = "message"
+)
+
+var errBodyTooLarge = errors.New("body is too large")
+
+// Middleware is a middleware that transforms the body and headers of requests and responses using expressions.
+type Middleware struct {
+	logger             logger.Logger
+	meta               transformMiddlewareMetadata
+	requestExpression  expression
+	responseExpression expression
+}
+
+// NewMiddleware returns a new transform middleware.
+func NewMiddleware(logger logger.Logger) middleware.Middleware {
+	return &Middleware{logger: logger}
+}
+
+// GetHandler returns the HTTP handler provided by the middleware.
+func (m *Middleware) GetHandler(_ context.Context, metadata middleware.Metadata) (func(next http.Handler) http.Handler, error) {
+	err := m.meta.fromMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.meta.RequestExpression != "" {
+		m.requestExpression, err = compileExpression(m.meta.Language, m.meta.RequestExpression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request expression: %w", err)
+		}
+	}
+	if m.meta.ResponseExpression != "" {
+		m.responseExpression, err = compileExpression(m.meta.Language, m.meta.ResponseExpression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid response expression: %w", err)
+		}
+	}
+
+	return m.handler, nil
+}
+
+func (m *Middleware) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.requestExpression != nil {
+			ok := m.transformRequest(w, r)
+			if !ok {
+				return
+			}
+		}
+
+		if m.responseExpression == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &responseRecorder{
+			ResponseWriter: w,
+			maxSize:        m.meta.MaxBodySize,
+		}
+		next.ServeHTTP(rec, r)
+		if rec.passthrough {
+			// The response was too large and was sent unmodified
+			return
+		}
+		m.transformResponse(w, r, rec)
+	})
+}
+
+// transformRequest evaluates the request expression and applies the result to the request.
+// It returns false if the request was rejected and a response was sent.
+func (m *Middleware) transformRequest(w http.ResponseWriter, r *http.Request) bool {
+	body, err := readBody(r.Body, m.meta.MaxBodySize)
+	if err != nil {
+		if errors.Is(err, errBodyTooLarge) {
+			http.Error(w, "Request body is too large", http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		}
+		return false
+	}
+
+	doc := map[string]any{
+		fieldMethod:  r.Method,
+		fieldPath:    r.URL.Path,
+		fieldHeaders: headersToDoc(r.Header),
+		fieldBody:    bodyToDoc(body),
+	}
+	res, err := m.evaluate(r.Context(), m.requestExpression, doc)
+	if err != nil {
+		m.logger.Warnf("Failed to evaluate request expression: %v", err)
+		http.Error(w, "Failed to transform request", http.StatusInternalServerError)
+		return false
+	}
+	if res.reject {
+		http.Error(w, res.message, res.statusOr(http.StatusForbidden))
+		return false
+	}
+
+	if res.headers != nil {
+		replaceHeaders(r.Header, res.headers)
+	}
+	if res.hasBody {
+		body, err = docToBody(res.body, json.Valid(body))
+		if err != nil {
+			m.logger.Warnf("Failed to encode transformed request body: %v", err)
+			http.Error(w, "Failed to transform request", http.StatusInternalServerError)
+			return false
+		}
+		r.Header.Del("Content-Length")
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	return true
+}
+
+// transformResponse evaluates the response expression against the recorded response and sends the result.
+func (m *Middleware) transformResponse(w http.ResponseWriter, r *http.Request, rec *responseRecorder) {
+	body := rec.body.Bytes()
+	doc := map[string]any{
+		fieldStatus:  float64(rec.statusCode()),
+		fieldHeaders: headersToDoc(w.Header()),
+		fieldBody:    bodyToDoc(body),
+	}
+	res, err := m.evaluate(r.Context(), m.responseExpression, doc)
+	if err != nil {
+		m.logger.Warnf("Failed to evaluate response expression: %v", err)
+		http.Error(w, "Failed to transform response", http.StatusInternalServerError)
+		return
+	}
+	if res.reject {
+		http.Error(w, res.message, res.statusOr(http.StatusForbidden))
+		return
+	}
+
+	if res.headers != nil {
+		replaceHeaders(w.Header(), res.headers)
+	}
+	if res.hasBody {
+		body, err = docToBody(res.body, json.Valid(body))
+		if err != nil {
+			m.logger.Warnf("Failed to encode transformed response body: %v", err)
+			http.Error(w, "Failed to transform response", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(res.statusOr(rec.statusCode()))
+	w.Write(body)
+}
+
+// evaluationResult is the result of an expression, applied to a request or response.
+type evaluationResult struct {
+	reject  bool
+	message string
+	status  int
+	headers map[string]string
+	hasBody bool
+	body    any
+}
+
+func (r evaluationResult) statusOr(def int) int {
+	if r.status == 0 {
+		return def
+	}
+	return r.status
+}
+
+// evaluate evaluates the expression against the document, with the configured timeout.
+func (m *Middleware) evaluate(parentCtx context.Context, expr expression, doc map[string]any) (res evaluationResult, err error) {
+	ctx, cancel := context.WithTimeout(parentCtx, m.meta.Timeout)
+	defer cancel()
+
+	out, err := expr.Eval(ctx, doc)
+	if err != nil {
+		return res, err
+	}
+
+	if allowed, ok := out.(bool); ok {
+		res.reject = !allowed
+		res.message = "Rejected"
+		return res, nil
+	}
+	obj, ok := out.(map[string]any)
+	if !ok {
+		return res, fmt.Errorf("expression returned a value of type %T: must be a boolean or an object", out)
+	}
+
+	if reject, ok := obj[fieldReject].(bool); ok && reject {
+		res.reject = true
+		res.message, _ = obj[fieldMessage].(string)
+		if res.message == "" {
+			res.message = "Rejected"
+		}
+	}
+	if status, ok := obj[fieldStatus]; ok {
+		res.status, err = toStatusCode(status)
+		if err != nil {
+			return res, err
+		}
+	}
+	if headers, ok := obj[fieldHeaders]; ok {
+		res.headers, err = docToHeaders(headers)
+		if err != nil {
+			return res, err
+		}
+	}
+	res.body, res.hasBody = obj[fieldBody]
+	return res, nil
+}
+
+func toStatusCode(v any) (int, error) {
+	var code int
+	switch n := v.(type) {
+	case float64:
+		code = int(n)
+	case int:
+		code = n
+	case int64:
+		code = int(n)
+	default:
+		return 0, fmt.Errorf("field %s must be a number", fieldStatus)
+	}
+	if code < 100 || code > 599 {
+		return 0, fmt.Errorf("field %s contains invalid status code %d", fieldStatus, code)
+	}
+	return code, nil
+}
+
+// readBody reads the body up to maxSize bytes.
+func readBody(body io.Reader, maxSize int64) ([]byte, error) {
+	if body == nil {
+		return nil, nil
+	}
+	b, err := io.ReadAll(io.LimitReader(body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > maxSize {
+		return nil, errBodyTooLarge
+	}
+	return b, nil
+}
+
+// bodyToDoc returns the body as parsed JSON if it's valid JSON, or as a string otherwise.
+func bodyToDoc(body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+	var v any
+	if json.Unmarshal(body, &v) == nil {
+		return v
+	}
+	return string(body)
+}
+
+// docToBody encodes a body returned by an expression.
+// Strings are returned as-is unless the original body was JSON.
+func docToBody(v any, isJSON bool) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if s, ok := v.(string); ok && !isJSON {
+		return []byte(s), nil
+	}
+	return json.Marshal(v)
+}
+
+func headersToDoc(h http.Header) map[string]any {
+	res := make(map[string]any, len(h))
+	for k, v := range h {
+		res[k] = strings.Join(v, ", ")
+	}
+	return res
+}
+
+func docToHeaders(v any) (map[string]string, error) {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("field %s must be an object", fieldHeaders)
+	}
+	res := make(map[string]string, len(obj))
+	for k, val := range obj {
+		s, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("value of header %s must be a string", k)
+		}
+		res[k] = s
+	}
+	return res, nil
+}
+
+// replaceHeaders replaces all headers in h with the given ones.
+func replaceHeaders(h http.Header, headers map[string]string) {
+	for k := range h {
+		delete(h, k)
+	}
+	for k, v := range headers {
+		h.Set(k, v)
+	}
+}
+
+// responseRecorder is a ResponseWriter that buffers the response so it can be transformed.
+// If the body exceeds maxSize, the buffered data is flushed and the rest of the response is sent unmodified.
+type responseRecorder struct {
+	http.ResponseWriter
+	maxSize     int64
+	code        int
+	body        bytes.Buffer
+	passthrough bool
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.passthrough {
+		r.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if r.code == 0 {
+		r.code = code
+	}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.passthrough {
+		return r.ResponseWriter.Write(b)
+	}
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	if int64(r.body.Len()+len(b)) > r.maxSize {
+		r.passthrough = true
+		r.ResponseWriter.WriteHeader(r.code)
+		_, err := r.ResponseWriter.Write(r.body.Bytes())
+		if err != nil {
+			return 0, err
+		}
+		r.body.Reset()
+		return r.ResponseWriter.Write(b)
+	}
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) statusCode() int {
+	if r.code == 0 {
+		return http.StatusOK
+	}
+	return r.code
+}
+
+func (m *Middleware) GetComponentMetadata() map[string]string {
+	metadataStruct := transformMiddlewareMetadata{}
+	metadataInfo := map[string]string{}
+	mdutils.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, mdutils.MiddlewareType)
+	return metadataInfo
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transform
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mdutils "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/middleware"
+	"github.com/dapr/kit/logger"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		md := transformMiddlewareMetadata{}
+		err := md.fromMetadata(middleware.Metadata{Base: mdutils.Base{Properties: map[string]string{
+			"requestExpression": ".",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, languageJQ, md.Language)
+		assert.Equal(t, int64(defaultMaxBodySize), md.MaxBodySize)
+		assert.Equal(t, defaultTimeout, md.Timeout)
+	})
+
+	t.Run("no expressions", func(t *testing.T) {
+		md := transformMiddlewareMetadata{}
+		err := md.fromMetadata(middleware.Metadata{})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid language", func(t *testing.T) {
+		md := transformMiddlewareMetadata{}
+		err := md.fromMetadata(middleware.Metadata{Base: mdutils.Base{Properties: map[string]string{
+			"requestExpression": ".",
+			"language":          "lua",
+		}}})
+		require.Error(t, err)
+	})
+}
+
+// echoHandler is a handler that returns the request body, with header X-Echo set to the value of the request's header X-Input.
+var echoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	b, _ := io.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Echo", r.Header.Get("X-Input"))
+	w.Header().Set("X-Secret", "s3cr3t")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+})
+
+func getHandler(t *testing.T, properties map[string]string) http.Handler {
+	t.Helper()
+
+	m := NewMiddleware(logger.NewLogger("test"))
+	handler, err := m.GetHandler(context.Background(), middleware.Metadata{Base: mdutils.Base{Properties: properties}})
+	require.NoError(t, err)
+	return handler(echoHandler)
+}
+
+func serve(handler http.Handler, body string, headers map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestJQ(t *testing.T) {
+	t.Run("transform request", func(t *testing.T) {
+		handler := getHandler(t, map[string]string{
+			"requestExpression": `.body.password = "***" | .headers["X-Input"] = .method + " " + .path`,
+		})
+
+		w := serve(handler, `{"user":"a","password":"hunter2"}`, nil)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.JSONEq(t, `{"user":"a","password":"***"}`, w.Body.String())
+		assert.Equal(t, "POST /orders", w.Header().Get("X-Echo"))
+	})
+
+	t.Run("transform response", func(t *testing.T) {
+		handler := getHandler(t, map[string]string{
+			"responseExpression": `{body: {wrapped: .body}, status: 200, headers: (.headers | del(.["X-Secret"]))}`,
+		})
+
+		w := serve(handler, `{"a":1}`, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"wrapped":{"a":1}}`, w.Body.String())
+		assert.Empty(t, w.Header().Get("X-Secret"))
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("reject with boolean", func(t *testing.T) {
+		handler := getHandler(t, map[string]string{
+			"requestExpression": `.body.amount < 100`,
+		})
+
+		assert.Equal(t, http.StatusCreated, serve(handler, `{"amount":10}`, nil).Code)
+		assert.Equal(t, http.StatusForbidden, serve(handler, `{"amount":1000}`, nil).Code)
+	})
+
+	t.Run("reject with object", func(t *testing.T) {
+		handler := getHandler(t, map[string]string{
+			"requestExpression": `if .headers["X-Input"] == null then {reject: true, status: 400, message: "missing header"} else . end`,
+		})
+
+		w := serve(handler, `{}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "missing header")
+		assert.Equal(t, http.StatusCreated, serve(handler, `{}`, map[string]string{"X-Input": "1"}).Code)
+	})
+
+	t.Run("non-JSON body", func(t *testing.T) {
+		handler := getHandler(t, map[string]string{
+			"requestExpression": `.body |= ascii_upcase`,
+		})
+
+		w := serve(handler, `hello`, nil)
+		assert.Equal(t, "HELLO", w.Body.String())
+	})
+
+	t.Run("environment is not exposed", func(t *testing.T) {
+		t.Setenv("TRANSFORM_TEST_SECRET", "foo")
+		handler := getHandler(t, map[string]string{
+			"requestExpression": `.body = ($ENV.TRANSFORM_TEST_SECRET // "none")`,
+		})
+
+		w := serve(handler, `{}`, nil)
+		assert.Equal(t, `"none"`, w.Body.String())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		handler := getHandler(t, map[string]string{
+			"requestExpression": `def f: f; f`,
+			"timeout":           "50ms",
+		})
+
+		assert.Equal(t, http.StatusInternalServerError, serve(handler, `{}`, nil).Code)
+	})
+
+	t.Run("request too large", func(t *testing.T) {
+		handler := getHandler(t, map[string]string{
+			"requestExpression": `.`,
+			"maxBodySize":       "4",
+		})
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, serve(handler, `{"a":1}`, nil).Code)
+	})
+
+	t.Run("response too large is sent unmodified", func(t *testing.T) {
+		handler := getHandler(t, map[string]string{
+			"responseExpression": `.body = "changed"`,
+			"maxBodySize":        "4",
+		})
+
+		w := serve(handler, `{"a":1}`, nil)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, `{"a":1}`, w.Body.String())
+	})
+
+	t.Run("invalid expression", func(t *testing.T) {
+		m := NewMiddleware(logger.NewLogger("test"))
+		_, err := m.GetHandler(context.Background(), middleware.Metadata{Base: mdutils.Base{Properties: map[string]string{
+			"requestExpression": `.[`,
+		}}})
+		require.Error(t, err)
+	})
+}
+
+func TestCEL(t *testing.T) {
+	t.Run("transform request", func(t *testing.T) {
+		handler := getHandler(t, map[string]string{
+			"language":          "cel",
+			"requestExpression": `{"body": {"user": body.user}, "headers": {"X-Input": method + " " + path}}`,
+		})
+
+		w := serve(handler, `{"user":"a","password":"hunter2"}`, nil)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.JSONEq(t, `{"user":"a"}`, w.Body.String())
+		assert.Equal(t, "POST /orders", w.Header().Get("X-Echo"))
+	})
+
+	t.Run("transform response", func(t *testing.T) {
+		handler := getHandler(t, map[string]string{
+			"language":           "cel",
+			"responseExpression": `{"status": status == 201 ? 200 : status}`,
+		})
+
+		w := serve(handler, `{"a":1}`, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"a":1}`, w.Body.String())
+	})
+
+	t.Run("reject with boolean", func(t *testing.T) {
+		handler := getHandler(t, map[string]string{
+			"language":          "cel",
+			"requestExpression": `has(body.amount) && body.amount < 100.0`,
+		})
+
+		assert.Equal(t, http.StatusCreated, serve(handler, `{"amount":10}`, nil).Code)
+		assert.Equal(t, http.StatusForbidden, serve(handler, `{"amount":1000}`, nil).Code)
+		assert.Equal(t, http.StatusForbidden, serve(handler, `{}`, nil).Code)
+	})
+
+	t.Run("invalid expression", func(t *testing.T) {
+		m := NewMiddleware(logger.NewLogger("test"))
+		_, err := m.GetHandler(context.Background(), middleware.Metadata{Base: mdutils.Base{Properties: map[string]string{
+			"language":          "cel",
+			"requestExpression": `body.`,
+		}}})
+		require.Error(t, err)
+	})
+}
@@ -1,9 +1,9 @@
 package wasm
 
 import (
-	"bytes"
 	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
@@ -36,6 +36,14 @@ func (m *middleware) GetHandler(ctx context.Context, metadata dapr.Metadata) (fu
 	return rh.requestHandler, nil
 }
 
+// poolMetadata contains the metadata properties of the pool of guest instances.
+type poolMetadata struct {
+	// Number of guest instances instantiated at initialization and kept ready to serve requests.
+	PoolSize int `mapstructure:"poolSize"`
+	// Isolation of requests: "none" to reuse instances across requests, or "request" to serve every request with a fresh instance.
+	Isolation string `mapstructure:"isolation"`
+}
+
 // getHandler is extracted for unit testing.
 func (m *middleware) getHandler(ctx context.Context, metadata dapr.Metadata) (*requestHandler, error) {
 	meta, err := wasm.GetInitMetadata(ctx, metadata.Base)
@@ -43,23 +51,54 @@ func (m *middleware) getHandler(ctx context.Context, metadata dapr.Metadata) (*r
 		return nil, fmt.Errorf("wasm: failed to parse metadata: %w", err)
 	}
 
-	var stdout, stderr bytes.Buffer
-	mw, err := wasmnethttp.NewMiddleware(ctx, meta.Guest,
-		handler.Logger(m),
-		handler.ModuleConfig(wazero.NewModuleConfig().
-			WithName(meta.GuestName).
-			WithStdout(&stdout). // reset per request
-			WithStderr(&stderr). // reset per request
-			// The below violate sand-boxing, but allow code to behave as expected.
-			WithRandSource(rand.Reader).
-			WithSysNanosleep().
-			WithSysWalltime().
-			WithSysNanosleep()))
+	poolMeta := poolMetadata{
+		PoolSize:  defaultPoolSize,
+		Isolation: isolationNone,
+	}
+	err = mdutils.DecodeMetadata(metadata.Properties, &poolMeta)
 	if err != nil {
+		return nil, fmt.Errorf("wasm: failed to parse metadata: %w", err)
+	}
+	if poolMeta.PoolSize < 0 {
+		return nil, fmt.Errorf("wasm: poolSize must not be negative")
+	}
+	if poolMeta.Isolation != isolationNone && poolMeta.Isolation != isolationRequest {
+		return nil, fmt.Errorf("wasm: invalid isolation %q: must be %q or %q", poolMeta.Isolation, isolationNone, isolationRequest)
+	}
+
+	// Every instance has its own runtime, but the guest is compiled once
+	cache := wazero.NewCompilationCache()
+	newRuntime := func(ctx context.Context) (wazero.Runtime, error) {
+		return wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCompilationCache(cache)), nil
+	}
+	newInstance := func(ctx context.Context) (*instance, error) {
+		i := &instance{}
+		mw, err := wasmnethttp.NewMiddleware(ctx, meta.Guest,
+			handler.Logger(m),
+			handler.Runtime(newRuntime),
+			handler.ModuleConfig(wazero.NewModuleConfig().
+				WithName(meta.GuestName).
+				WithStdout(&i.stdout). // reset per request
+				WithStderr(&i.stderr). // reset per request
+				// The below violate sand-boxing, but allow code to behave as expected.
+				WithRandSource(rand.Reader).
+				WithSysNanosleep().
+				WithSysWalltime().
+				WithSysNanosleep()))
+		if err != nil {
+			return nil, err
+		}
+		i.mw = mw
+		return i, nil
+	}
+
+	pool, err := newInstancePool(ctx, poolMeta.PoolSize, poolMeta.Isolation == isolationRequest, m.logger, newInstance)
+	if err != nil {
+		_ = cache.Close(ctx)
 		return nil, err
 	}
 
-	return &requestHandler{mw: mw, logger: m.logger, stdout: &stdout, stderr: &stderr}, nil
+	return &requestHandler{pool: pool, cache: cache, logger: m.logger}, nil
 }
 
 // IsEnabled implements the same method as documented on api.Logger.
@@ -97,25 +136,33 @@ func (m *middleware) Log(_ context.Context, level api.LogLevel, message string)
 }
 
 type requestHandler struct {
-	mw             wasmnethttp.Middleware
-	logger         logger.Logger
-	stdout, stderr *bytes.Buffer
+	pool   *instancePool
+	cache  wazero.CompilationCache
+	logger logger.Logger
 }
 
 func (rh *requestHandler) requestHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		h := rh.mw.NewHandler(r.Context(), next)
+		i, err := rh.pool.get(r.Context())
+		if err != nil {
+			rh.logger.Errorf("wasm: failed to get guest instance: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		// If the guest panics, the instance may be in an inconsistent state, so it's discarded
+		completed := false
 		defer func() {
-			rh.stdout.Reset()
-			rh.stderr.Reset()
+			rh.pool.put(i, !completed)
 		}()
 
-		h.ServeHTTP(w, r)
+		i.mw.NewHandler(r.Context(), next).ServeHTTP(w, r)
+		completed = true
 
-		if stdout := rh.stdout.String(); len(stdout) > 0 {
+		if stdout := i.stdout.String(); len(stdout) > 0 {
 			rh.logger.Debugf("wasm stdout: %s", stdout)
 		}
-		if stderr := rh.stderr.String(); len(stderr) > 0 {
+		if stderr := i.stderr.String(); len(stderr) > 0 {
 			rh.logger.Debugf("wasm stderr: %s", stderr)
 		}
 	})
@@ -123,9 +170,11 @@ func (rh *requestHandler) requestHandler(next http.Handler) http.Handler {
 
 // Close implements io.Closer
 func (rh *requestHandler) Close() error {
+	err := rh.pool.Close()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	return rh.mw.Close(ctx)
+	return errors.Join(err, rh.cache.Close(ctx))
 }
 
 func (m *middleware) GetComponentMetadata() map[string]string {
@@ -61,6 +61,22 @@ func Test_middleware_getHandler(t *testing.T) {
 			}},
 			expectedErr: "wasm: error compiling guest: invalid magic number",
 		},
+		{
+			name: "invalid isolation",
+			metadata: metadata.Base{Properties: map[string]string{
+				"url":       "file://example/router.wasm",
+				"isolation": "module",
+			}},
+			expectedErr: `wasm: invalid isolation "module": must be "none" or "request"`,
+		},
+		{
+			name: "negative pool size",
+			metadata: metadata.Base{Properties: map[string]string{
+				"url":      "file://example/router.wasm",
+				"poolSize": "-1",
+			}},
+			expectedErr: "wasm: poolSize must not be negative",
+		},
 		{
 			name: "ok",
 			metadata: metadata.Base{Properties: map[string]string{
@@ -75,7 +91,9 @@ func Test_middleware_getHandler(t *testing.T) {
 			h, err := m.getHandler(context.Background(), dapr.Metadata{Base: tc.metadata})
 			if tc.expectedErr == "" {
 				require.NoError(t, err)
-				require.NotNil(t, h.mw)
+				require.NotNil(t, h.pool)
+				require.Equal(t, defaultPoolSize, h.pool.idleCount())
+				require.NoError(t, h.Close())
 			} else {
 				require.EqualError(t, err, tc.expectedErr)
 			}
@@ -0,0 +1,166 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	wasmnethttp "github.com/http-wasm/http-wasm-host-go/handler/nethttp"
+
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// isolationNone reuses guest instances across requests, so guest memory persists between them.
+	isolationNone = "none"
+	// isolationRequest serves every request with a fresh guest instance.
+	isolationRequest = "request"
+
+	defaultPoolSize = 4
+)
+
+// instance is a guest instantiated by a dedicated http-wasm middleware, with its own output buffers.
+type instance struct {
+	mw             wasmnethttp.Middleware
+	stdout, stderr bytes.Buffer
+}
+
+func (i *instance) close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return i.mw.Close(ctx)
+}
+
+// instancePool keeps guest instances ready to serve requests, so that requests don't wait for a guest to be instantiated.
+// An instance serves a single request at a time, so the output of concurrent requests never mixes.
+// The instance that served the last request is reused first, as its memory is the most likely to be warm.
+// When all instances are busy, an additional instance is created for the request, and it's kept only if the pool isn't full when the request completes.
+type instancePool struct {
+	newInstance func(ctx context.Context) (*instance, error)
+	isolate     bool
+	logger      logger.Logger
+
+	size   int
+	idle   []*instance
+	lock   sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// newInstancePool creates a pool with size instances.
+// If isolate is true, instances serve a single request, and are replaced in the background after it.
+func newInstancePool(ctx context.Context, size int, isolate bool, logger logger.Logger, newInstance func(ctx context.Context) (*instance, error)) (*instancePool, error) {
+	p := &instancePool{
+		newInstance: newInstance,
+		isolate:     isolate,
+		logger:      logger,
+		size:        size,
+		idle:        make([]*instance, 0, size),
+	}
+
+	// Instantiate the guests upfront, which also fails fast if the guest is invalid
+	for n := 0; n < size; n++ {
+		i, err := newInstance(ctx)
+		if err != nil {
+			_ = p.Close()
+			return nil, err
+		}
+		p.idle = append(p.idle, i)
+	}
+	return p, nil
+}
+
+// get returns an idle instance, or a new one if all instances are busy.
+func (p *instancePool) get(ctx context.Context) (*instance, error) {
+	p.lock.Lock()
+	if n := len(p.idle); n > 0 {
+		i := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.lock.Unlock()
+		return i, nil
+	}
+	p.lock.Unlock()
+
+	return p.newInstance(ctx)
+}
+
+// put returns an instance to the pool after a request.
+// If discard is true, or if requests are isolated, the instance is closed and replaced.
+func (p *instancePool) put(i *instance, discard bool) {
+	if discard || p.isolate {
+		p.closeInstance(i)
+		p.replenish()
+		return
+	}
+
+	i.stdout.Reset()
+	i.stderr.Reset()
+	p.add(i)
+}
+
+// replenish instantiates a new guest in the background and adds it to the pool.
+func (p *instancePool) replenish() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.closed {
+		return
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		i, err := p.newInstance(context.Background())
+		if err != nil {
+			p.logger.Errorf("wasm: failed to instantiate guest: %v", err)
+			return
+		}
+		p.add(i)
+	}()
+}
+
+// add adds an idle instance to the pool, or closes it if the pool is full or closed.
+func (p *instancePool) add(i *instance) {
+	p.lock.Lock()
+	if !p.closed && len(p.idle) < p.size {
+		p.idle = append(p.idle, i)
+		p.lock.Unlock()
+		return
+	}
+	p.lock.Unlock()
+
+	p.closeInstance(i)
+}
+
+// idleCount returns the number of idle instances.
+func (p *instancePool) idleCount() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.idle)
+}
+
+func (p *instancePool) closeInstance(i *instance) {
+	if err := i.close(); err != nil {
+		p.logger.Warnf("wasm: failed to close guest: %v", err)
+	}
+}
+
+// Close closes the idle instances; instances serving requests are closed when the requests complete.
+func (p *instancePool) Close() error {
+	p.lock.Lock()
+	p.closed = true
+	p.lock.Unlock()
+
+	// Wait for the guests that are being instantiated
+	p.wg.Wait()
+
+	p.lock.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.lock.Unlock()
+
+	for _, i := range idle {
+		p.closeInstance(i)
+	}
+	return nil
+}
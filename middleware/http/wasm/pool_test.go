@@ -0,0 +1,113 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/logger"
+)
+
+type fakeMiddleware struct {
+	closed atomic.Bool
+}
+
+func (f *fakeMiddleware) NewHandler(_ context.Context, next http.Handler) http.Handler {
+	return next
+}
+
+func (f *fakeMiddleware) Close(context.Context) error {
+	f.closed.Store(true)
+	return nil
+}
+
+func newTestPool(t *testing.T, size int, isolate bool) (*instancePool, *atomic.Int32) {
+	var created atomic.Int32
+	p, err := newInstancePool(context.Background(), size, isolate, logger.NewLogger(t.Name()), func(context.Context) (*instance, error) {
+		created.Add(1)
+		return &instance{mw: &fakeMiddleware{}}, nil
+	})
+	require.NoError(t, err)
+	return p, &created
+}
+
+func Test_instancePool(t *testing.T) {
+	t.Run("instances are created upfront and reused", func(t *testing.T) {
+		p, created := newTestPool(t, 2, false)
+		assert.Equal(t, int32(2), created.Load())
+
+		i, err := p.get(context.Background())
+		require.NoError(t, err)
+		i.stdout.WriteString("out")
+		p.put(i, false)
+		assert.Equal(t, int32(2), created.Load())
+		assert.Equal(t, 2, p.idleCount())
+		assert.Zero(t, i.stdout.Len())
+
+		require.NoError(t, p.Close())
+		assert.True(t, i.mw.(*fakeMiddleware).closed.Load())
+	})
+
+	t.Run("additional instances are created when all are busy", func(t *testing.T) {
+		p, created := newTestPool(t, 1, false)
+
+		i1, err := p.get(context.Background())
+		require.NoError(t, err)
+		i2, err := p.get(context.Background())
+		require.NoError(t, err)
+		assert.NotSame(t, i1, i2)
+		assert.Equal(t, int32(2), created.Load())
+
+		// The pool is full after the first instance is returned, so the second one is closed
+		p.put(i1, false)
+		p.put(i2, false)
+		assert.Equal(t, 1, p.idleCount())
+		assert.False(t, i1.mw.(*fakeMiddleware).closed.Load())
+		assert.True(t, i2.mw.(*fakeMiddleware).closed.Load())
+		require.NoError(t, p.Close())
+	})
+
+	t.Run("instances are replaced with request isolation", func(t *testing.T) {
+		p, created := newTestPool(t, 1, true)
+
+		i, err := p.get(context.Background())
+		require.NoError(t, err)
+		p.put(i, false)
+		assert.True(t, i.mw.(*fakeMiddleware).closed.Load())
+		assert.Eventually(t, func() bool {
+			return p.idleCount() == 1
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, int32(2), created.Load())
+
+		next, err := p.get(context.Background())
+		require.NoError(t, err)
+		assert.NotSame(t, i, next)
+		require.NoError(t, p.Close())
+	})
+
+	t.Run("discarded instances are replaced", func(t *testing.T) {
+		p, _ := newTestPool(t, 1, false)
+
+		i, err := p.get(context.Background())
+		require.NoError(t, err)
+		p.put(i, true)
+		assert.True(t, i.mw.(*fakeMiddleware).closed.Load())
+		assert.Eventually(t, func() bool {
+			return p.idleCount() == 1
+		}, time.Second, 10*time.Millisecond)
+		require.NoError(t, p.Close())
+	})
+
+	t.Run("instantiation errors fail the creation of the pool", func(t *testing.T) {
+		_, err := newInstancePool(context.Background(), 1, false, logger.NewLogger(t.Name()), func(context.Context) (*instance, error) {
+			return nil, errors.New("invalid guest")
+		})
+		require.EqualError(t, err, "invalid guest")
+	})
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consul
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// Minimum interval between blocking queries for the same service, to avoid hammering Consul when the index changes frequently
+	defaultCacheMinWatchInterval = time.Second
+	// Maximum delay before retrying a failed query
+	cacheMaxRetryDelay = 30 * time.Second
+	// Services that are not resolved for this long are removed from the cache
+	defaultCacheIdleTimeout = 10 * time.Minute
+)
+
+var errCacheStale = errors.New("cached services are stale")
+
+// resolverCache keeps a local copy of the healthy instances of each service, which is refreshed in background using blocking queries.
+type resolverCache struct {
+	client           clientInterface
+	queryOptions     *consul.QueryOptions
+	maxStale         time.Duration
+	minWatchInterval time.Duration
+	idleTimeout      time.Duration
+	logger           logger.Logger
+
+	lock    sync.Mutex
+	entries map[cacheKey]*cacheEntry
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+type cacheKey struct {
+	service    string
+	datacenter string
+}
+
+type cacheEntry struct {
+	// Closed when the first query has completed, successfully or not
+	ready chan struct{}
+	// Time of the last access, in Unix nanoseconds
+	lastAccess atomic.Int64
+
+	lock       sync.RWMutex
+	services   []*consul.ServiceEntry
+	err        error
+	lastUpdate time.Time
+}
+
+func newResolverCache(client clientInterface, queryOptions *consul.QueryOptions, maxStale time.Duration, logger logger.Logger) *resolverCache {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &resolverCache{
+		client:           client,
+		queryOptions:     queryOptions,
+		maxStale:         maxStale,
+		minWatchInterval: defaultCacheMinWatchInterval,
+		idleTimeout:      defaultCacheIdleTimeout,
+		logger:           logger,
+		entries:          make(map[cacheKey]*cacheEntry),
+		ctx:              ctx,
+		cancel:           cancel,
+	}
+}
+
+// Get returns the healthy instances of the service in the datacenter.
+// The first time a service is requested, this blocks until the results are retrieved from Consul; the results are then kept up-to-date in background.
+// If the cached results were last updated more than maxStale ago (because of errors refreshing them), returns errCacheStale.
+func (c *resolverCache) Get(service string, datacenter string) ([]*consul.ServiceEntry, error) {
+	key := cacheKey{service: service, datacenter: datacenter}
+
+	c.lock.Lock()
+	if c.ctx.Err() != nil {
+		c.lock.Unlock()
+		return nil, errors.New("cache is closed")
+	}
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &cacheEntry{
+			ready: make(chan struct{}),
+		}
+		entry.lastAccess.Store(time.Now().UnixNano())
+		c.entries[key] = entry
+		c.wg.Add(1)
+		go c.watch(key, entry)
+	}
+	c.lock.Unlock()
+
+	entry.lastAccess.Store(time.Now().UnixNano())
+
+	select {
+	case <-entry.ready:
+	case <-c.ctx.Done():
+		return nil, errors.New("cache is closed")
+	}
+
+	entry.lock.RLock()
+	defer entry.lock.RUnlock()
+	if entry.lastUpdate.IsZero() {
+		return nil, entry.err
+	}
+	if c.maxStale > 0 && time.Since(entry.lastUpdate) > c.maxStale {
+		return nil, errCacheStale
+	}
+	return entry.services, nil
+}
+
+// Close stops all background watches.
+func (c *resolverCache) Close() {
+	c.lock.Lock()
+	c.cancel()
+	c.lock.Unlock()
+
+	c.wg.Wait()
+}
+
+// watch keeps the entry updated with blocking queries until the cache is closed or the entry is idle.
+func (c *resolverCache) watch(key cacheKey, entry *cacheEntry) {
+	defer c.wg.Done()
+
+	var (
+		ready     bool
+		lastIndex uint64
+		retry     time.Duration
+	)
+	for {
+		// Stop watching services that haven't been requested in a while
+		if time.Since(time.Unix(0, entry.lastAccess.Load())) > c.idleTimeout {
+			c.lock.Lock()
+			delete(c.entries, key)
+			c.lock.Unlock()
+			c.logger.Debugf("Stopped watching idle service %s", key.service)
+			return
+		}
+
+		opts := consul.QueryOptions{}
+		if c.queryOptions != nil {
+			opts = *c.queryOptions
+		}
+		if key.datacenter != "" {
+			opts.Datacenter = key.datacenter
+		}
+		opts.WaitIndex = lastIndex
+
+		start := time.Now()
+		services, meta, err := c.client.Health().Service(key.service, "", true, opts.WithContext(c.ctx))
+		if c.ctx.Err() != nil {
+			return
+		}
+
+		var wait time.Duration
+		if err != nil {
+			c.logger.Warnf("Failed to refresh healthy instances of service %s: %v", key.service, err)
+			entry.lock.Lock()
+			entry.err = err
+			entry.lock.Unlock()
+
+			// Exponential backoff
+			switch {
+			case retry == 0:
+				retry = c.minWatchInterval
+			case retry < cacheMaxRetryDelay:
+				retry *= 2
+			}
+			wait = retry
+		} else {
+			retry = 0
+			entry.lock.Lock()
+			entry.services = services
+			entry.err = nil
+			entry.lastUpdate = time.Now()
+			entry.lock.Unlock()
+
+			// Per Consul's docs, if the index goes backwards it must be reset
+			if meta != nil && meta.LastIndex >= lastIndex {
+				lastIndex = meta.LastIndex
+			} else {
+				lastIndex = 0
+			}
+			wait = c.minWatchInterval - time.Since(start)
+		}
+
+		if !ready {
+			close(entry.ready)
+			ready = true
+		}
+
+		if wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-c.ctx.Done():
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consul
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	nr "github.com/dapr/components-contrib/nameresolution"
+	"github.com/dapr/kit/logger"
+)
+
+// watchMockClient is a client that is safe for concurrent use and returns results per datacenter.
+type watchMockClient struct {
+	mockAgent
+
+	lock    sync.Mutex
+	calls   map[string]int
+	results map[string][]*consul.ServiceEntry
+	index   uint64
+	err     error
+}
+
+func newWatchMockClient() *watchMockClient {
+	return &watchMockClient{
+		calls:   map[string]int{},
+		results: map[string][]*consul.ServiceEntry{},
+		index:   1,
+	}
+}
+
+func (m *watchMockClient) InitClient(config *consul.Config) error {
+	return nil
+}
+
+func (m *watchMockClient) Health() healthInterface {
+	return m
+}
+
+func (m *watchMockClient) Agent() agentInterface {
+	return &m.mockAgent
+}
+
+func (m *watchMockClient) Service(service, tag string, passingOnly bool, q *consul.QueryOptions) ([]*consul.ServiceEntry, *consul.QueryMeta, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.calls[q.Datacenter]++
+	if m.err != nil {
+		return nil, nil, m.err
+	}
+	return m.results[q.Datacenter], &consul.QueryMeta{LastIndex: m.index}, nil
+}
+
+func (m *watchMockClient) set(datacenter string, services []*consul.ServiceEntry, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.results[datacenter] = services
+	m.err = err
+	m.index++
+}
+
+func (m *watchMockClient) callCount(datacenter string) int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.calls[datacenter]
+}
+
+func testServiceEntry(address string) *consul.ServiceEntry {
+	return &consul.ServiceEntry{
+		Service: &consul.AgentService{
+			Address: address,
+			Meta: map[string]string{
+				"DAPR_PORT": "50005",
+			},
+		},
+	}
+}
+
+func TestResolverCache(t *testing.T) {
+	t.Run("results are cached and refreshed in background", func(t *testing.T) {
+		mock := newWatchMockClient()
+		mock.set("", []*consul.ServiceEntry{testServiceEntry("10.0.0.1")}, nil)
+
+		cache := newResolverCache(mock, nil, 0, logger.NewLogger("test"))
+		cache.minWatchInterval = 10 * time.Millisecond
+		defer cache.Close()
+
+		services, err := cache.Get("test-app", "")
+		require.NoError(t, err)
+		require.Len(t, services, 1)
+		assert.Equal(t, "10.0.0.1", services[0].Service.Address)
+
+		mock.set("", []*consul.ServiceEntry{testServiceEntry("10.0.0.2")}, nil)
+		assert.Eventually(t, func() bool {
+			services, err = cache.Get("test-app", "")
+			return err == nil && len(services) == 1 && services[0].Service.Address == "10.0.0.2"
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("stale results are not returned", func(t *testing.T) {
+		mock := newWatchMockClient()
+		mock.set("", []*consul.ServiceEntry{testServiceEntry("10.0.0.1")}, nil)
+
+		cache := newResolverCache(mock, nil, 100*time.Millisecond, logger.NewLogger("test"))
+		cache.minWatchInterval = 10 * time.Millisecond
+		defer cache.Close()
+
+		_, err := cache.Get("test-app", "")
+		require.NoError(t, err)
+
+		// Results are still served while not older than maxStale
+		mock.set("", nil, errors.New("simulated"))
+		_, err = cache.Get("test-app", "")
+		require.NoError(t, err)
+
+		assert.Eventually(t, func() bool {
+			_, err = cache.Get("test-app", "")
+			return errors.Is(err, errCacheStale)
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("error on first query", func(t *testing.T) {
+		mock := newWatchMockClient()
+		mock.set("", nil, errors.New("simulated"))
+
+		cache := newResolverCache(mock, nil, 0, logger.NewLogger("test"))
+		defer cache.Close()
+
+		_, err := cache.Get("test-app", "")
+		require.Error(t, err)
+	})
+
+	t.Run("idle services are removed", func(t *testing.T) {
+		mock := newWatchMockClient()
+		mock.set("", []*consul.ServiceEntry{testServiceEntry("10.0.0.1")}, nil)
+
+		cache := newResolverCache(mock, nil, 0, logger.NewLogger("test"))
+		cache.minWatchInterval = 10 * time.Millisecond
+		cache.idleTimeout = 50 * time.Millisecond
+		defer cache.Close()
+
+		_, err := cache.Get("test-app", "")
+		require.NoError(t, err)
+
+		assert.Eventually(t, func() bool {
+			cache.lock.Lock()
+			defer cache.lock.Unlock()
+			return len(cache.entries) == 0
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestResolveIDWithCacheAndFailover(t *testing.T) {
+	mock := newWatchMockClient()
+	mock.set("dc2", []*consul.ServiceEntry{testServiceEntry("10.0.1.1")}, nil)
+
+	resolver := newResolver(logger.NewLogger("test"), mock)
+	err := resolver.Init(nr.Metadata{
+		Base: metadata.Base{
+			Properties: getTestPropsWithoutKey(""),
+		},
+		Configuration: configSpec{
+			UseResolverCache:    true,
+			FailoverDatacenters: []string{"dc2"},
+		},
+	})
+	require.NoError(t, err)
+	defer resolver.Close()
+
+	for i := 0; i < 3; i++ {
+		addr, err := resolver.ResolveID(nr.ResolveRequest{ID: "test-app"})
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.1.1:50005", addr)
+	}
+
+	// Consul was queried only once per datacenter, as results are cached
+	assert.Equal(t, 1, mock.callCount(""))
+	assert.Equal(t, 1, mock.callCount("dc2"))
+}
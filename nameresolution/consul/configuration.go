@@ -28,25 +28,31 @@ import (
 // deserialized into this type before being converted to the equivalent consul types
 // that way breaking changes in future versions of the consul api cannot break user configuration.
 type intermediateConfig struct {
-	Client               *Config
-	Checks               []*AgentServiceCheck
-	Tags                 []string
-	Meta                 map[string]string
-	QueryOptions         *QueryOptions
-	AdvancedRegistration *AgentServiceRegistration // advanced use-case
-	SelfRegister         bool
-	DaprPortMetaKey      string
+	Client                *Config
+	Checks                []*AgentServiceCheck
+	Tags                  []string
+	Meta                  map[string]string
+	QueryOptions          *QueryOptions
+	AdvancedRegistration  *AgentServiceRegistration // advanced use-case
+	SelfRegister          bool
+	DaprPortMetaKey       string
+	UseResolverCache      bool
+	ResolverCacheMaxStale time.Duration
+	FailoverDatacenters   []string
 }
 
 type configSpec struct {
-	Client               *consul.Config
-	Checks               []*consul.AgentServiceCheck
-	Tags                 []string
-	Meta                 map[string]string
-	QueryOptions         *consul.QueryOptions
-	AdvancedRegistration *consul.AgentServiceRegistration // advanced use-case
-	SelfRegister         bool
-	DaprPortMetaKey      string
+	Client                *consul.Config
+	Checks                []*consul.AgentServiceCheck
+	Tags                  []string
+	Meta                  map[string]string
+	QueryOptions          *consul.QueryOptions
+	AdvancedRegistration  *consul.AgentServiceRegistration // advanced use-case
+	SelfRegister          bool
+	DaprPortMetaKey       string
+	UseResolverCache      bool
+	ResolverCacheMaxStale time.Duration
+	FailoverDatacenters   []string
 }
 
 func parseConfig(rawConfig interface{}) (configSpec, error) {
@@ -76,14 +82,17 @@ func parseConfig(rawConfig interface{}) (configSpec, error) {
 
 func mapConfig(config intermediateConfig) configSpec {
 	return configSpec{
-		Client:               mapClientConfig(config.Client),
-		Checks:               mapChecks(config.Checks),
-		Tags:                 config.Tags,
-		Meta:                 config.Meta,
-		QueryOptions:         mapQueryOptions(config.QueryOptions),
-		AdvancedRegistration: mapAdvancedRegistration(config.AdvancedRegistration),
-		SelfRegister:         config.SelfRegister,
-		DaprPortMetaKey:      config.DaprPortMetaKey,
+		Client:                mapClientConfig(config.Client),
+		Checks:                mapChecks(config.Checks),
+		Tags:                  config.Tags,
+		Meta:                  config.Meta,
+		QueryOptions:          mapQueryOptions(config.QueryOptions),
+		AdvancedRegistration:  mapAdvancedRegistration(config.AdvancedRegistration),
+		SelfRegister:          config.SelfRegister,
+		DaprPortMetaKey:       config.DaprPortMetaKey,
+		UseResolverCache:      config.UseResolverCache,
+		ResolverCacheMaxStale: config.ResolverCacheMaxStale,
+		FailoverDatacenters:   config.FailoverDatacenters,
 	}
 }
 
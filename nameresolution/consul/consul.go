@@ -14,10 +14,12 @@ limitations under the License.
 package consul
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
 	"net"
 	"strconv"
+	"time"
 
 	consul "github.com/hashicorp/consul/api"
 
@@ -69,13 +71,17 @@ type resolver struct {
 	config resolverConfig
 	logger logger.Logger
 	client clientInterface
+	cache  *resolverCache
 }
 
 type resolverConfig struct {
-	Client          *consul.Config
-	QueryOptions    *consul.QueryOptions
-	Registration    *consul.AgentServiceRegistration
-	DaprPortMetaKey string
+	Client                *consul.Config
+	QueryOptions          *consul.QueryOptions
+	Registration          *consul.AgentServiceRegistration
+	DaprPortMetaKey       string
+	UseResolverCache      bool
+	ResolverCacheMaxStale time.Duration
+	FailoverDatacenters   []string
 }
 
 // NewResolver creates Consul name resolver.
@@ -119,29 +125,88 @@ func (r *resolver) Init(metadata nr.Metadata) (err error) {
 		}
 	}
 
+	if r.config.UseResolverCache {
+		r.cache = newResolverCache(r.client, r.config.QueryOptions, r.config.ResolverCacheMaxStale, r.logger)
+	}
+
+	return nil
+}
+
+// Close stops the background refresh of cached services, if any.
+func (r *resolver) Close() error {
+	if r.cache != nil {
+		r.cache.Close()
+	}
+
 	return nil
 }
 
 // ResolveID resolves name to address via consul.
+// If no healthy instances are found in the configured datacenter, the failover datacenters are tried in order.
 func (r *resolver) ResolveID(req nr.ResolveRequest) (addr string, err error) {
-	cfg := r.config
-	services, _, err := r.client.Health().Service(req.ID, "", true, cfg.QueryOptions)
-	if err != nil {
-		return "", fmt.Errorf("failed to query healthy consul services: %w", err)
+	datacenters := append([]string{""}, r.config.FailoverDatacenters...)
+
+	var errs []error
+	for _, dc := range datacenters {
+		services, err := r.getServices(req.ID, dc)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if len(services) > 0 {
+			if dc != "" {
+				r.logger.Debugf("Resolving AppID '%s' using failover datacenter %s", req.ID, dc)
+			}
+			return r.pickAddress(req.ID, services)
+		}
 	}
 
-	if len(services) == 0 {
-		return "", fmt.Errorf("no healthy services found with AppID '%s'", req.ID)
+	if len(errs) > 0 {
+		return "", errors.Join(errs...)
 	}
 
+	return "", fmt.Errorf("no healthy services found with AppID '%s'", req.ID)
+}
+
+// getServices returns the healthy instances of the service in the given datacenter, or in the default one if empty.
+func (r *resolver) getServices(service string, datacenter string) ([]*consul.ServiceEntry, error) {
+	if r.cache != nil {
+		services, err := r.cache.Get(service, datacenter)
+		if err == nil {
+			return services, nil
+		}
+		r.logger.Debugf("Cached services for AppID '%s' are not available, querying consul: %v", service, err)
+	}
+
+	opts := r.config.QueryOptions
+	if datacenter != "" {
+		dcOpts := consul.QueryOptions{}
+		if opts != nil {
+			dcOpts = *opts
+		}
+		dcOpts.Datacenter = datacenter
+		opts = &dcOpts
+	}
+
+	services, _, err := r.client.Health().Service(service, "", true, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query healthy consul services: %w", err)
+	}
+
+	return services, nil
+}
+
+// pickAddress returns the address of a random instance in the list.
+func (r *resolver) pickAddress(appID string, services []*consul.ServiceEntry) (addr string, err error) {
 	// Pick a random service from the result
 	// Note: we're using math/random here as PRNG and that's ok since we're just using this for selecting a random address from a list for load-balancing, so we don't need a CSPRNG
 	//nolint:gosec
 	svc := services[rand.Int()%len(services)]
 
-	port := svc.Service.Meta[cfg.DaprPortMetaKey]
+	port := svc.Service.Meta[r.config.DaprPortMetaKey]
 	if port == "" {
-		return "", fmt.Errorf("target service AppID '%s' found but DAPR_PORT missing from meta", req.ID)
+		return "", fmt.Errorf("target service AppID '%s' found but DAPR_PORT missing from meta", appID)
 	}
 
 	if svc.Service.Address != "" {
@@ -149,7 +214,7 @@ func (r *resolver) ResolveID(req nr.ResolveRequest) (addr string, err error) {
 	} else if svc.Node.Address != "" {
 		addr = svc.Node.Address + ":" + port
 	} else {
-		return "", fmt.Errorf("no healthy services found with AppID '%s'", req.ID)
+		return "", fmt.Errorf("no healthy services found with AppID '%s'", appID)
 	}
 
 	return addr, nil
@@ -179,6 +244,9 @@ func getConfig(metadata nr.Metadata) (resolverCfg resolverConfig, err error) {
 		return resolverCfg, err
 	}
 	resolverCfg.QueryOptions = getQueryOptionsConfig(cfg)
+	resolverCfg.UseResolverCache = cfg.UseResolverCache
+	resolverCfg.ResolverCacheMaxStale = cfg.ResolverCacheMaxStale
+	resolverCfg.FailoverDatacenters = cfg.FailoverDatacenters
 
 	// if registering, set DaprPort in meta, needed for resolution
 	if resolverCfg.Registration != nil {
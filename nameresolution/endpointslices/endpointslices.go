@@ -0,0 +1,273 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package endpointslices contains a name resolver that watches the EndpointSlices of the Dapr services in Kubernetes, instead of relying on cluster DNS.
+// Endpoints in the same zone as the caller are preferred, and endpoints in other zones are used only when there are no ready endpoints in the same zone.
+package endpointslices
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+
+	kubeclient "github.com/dapr/components-contrib/internal/authentication/kubernetes"
+	"github.com/dapr/components-contrib/nameresolution"
+	"github.com/dapr/kit/config"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// Suffix appended to the app ID to get the name of the service created by Dapr for the sidecars.
+	defaultServiceSuffix = "-dapr"
+	defaultSyncTimeout   = 10 * time.Second
+	// Environment variable with the name of the node, usually set with the downward API.
+	nodeNameEnvVar = "NODE_NAME"
+)
+
+type resolverConfig struct {
+	// Zone of this instance. Endpoints in the same zone are preferred.
+	Zone string `mapstructure:"zone"`
+	// Name of the node this instance runs on, used to look up the zone when it's not set. Defaults to the value of the NODE_NAME environment variable.
+	NodeName string `mapstructure:"nodeName"`
+	// Suffix appended to the app ID to get the name of the service.
+	ServiceSuffix string `mapstructure:"serviceSuffix"`
+	// Maximum time to wait for the EndpointSlices of a service to be listed the first time it's resolved.
+	SyncTimeout time.Duration `mapstructure:"syncTimeout"`
+}
+
+type resolver struct {
+	logger    logger.Logger
+	config    resolverConfig
+	getClient func() (kubernetes.Interface, error)
+	client    kubernetes.Interface
+
+	lock     sync.Mutex
+	services map[string]*serviceWatcher
+	closed   atomic.Bool
+	closeCh  chan struct{}
+}
+
+// serviceWatcher watches the EndpointSlices of a service.
+type serviceWatcher struct {
+	lister    discoverylisters.EndpointSliceNamespaceLister
+	hasSynced cache.InformerSynced
+	// Counter used to pick endpoints in round-robin order
+	next atomic.Uint64
+}
+
+// NewResolver creates a name resolver that uses Kubernetes EndpointSlices.
+func NewResolver(logger logger.Logger) nameresolution.Resolver {
+	return &resolver{
+		logger: logger,
+		getClient: func() (kubernetes.Interface, error) {
+			return kubeclient.GetKubeClient()
+		},
+		services: map[string]*serviceWatcher{},
+		closeCh:  make(chan struct{}),
+	}
+}
+
+// Init initializes the name resolver.
+func (r *resolver) Init(metadata nameresolution.Metadata) error {
+	r.config = resolverConfig{
+		NodeName:      os.Getenv(nodeNameEnvVar),
+		ServiceSuffix: defaultServiceSuffix,
+		SyncTimeout:   defaultSyncTimeout,
+	}
+	if metadata.Configuration != nil {
+		cfg, err := config.Normalize(metadata.Configuration)
+		if err != nil {
+			return err
+		}
+		err = config.Decode(cfg, &r.config)
+		if err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+	if r.config.SyncTimeout <= 0 {
+		return errors.New("syncTimeout must be greater than zero")
+	}
+
+	var err error
+	r.client, err = r.getClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	if r.config.Zone == "" && r.config.NodeName != "" {
+		r.config.Zone, err = r.nodeZone(r.config.NodeName)
+		if err != nil {
+			r.logger.Warnf("Failed to get the zone of node %s, endpoints in all zones will be used: %v", r.config.NodeName, err)
+		}
+	}
+	if r.config.Zone != "" {
+		r.logger.Infof("Preferring endpoints in zone %s", r.config.Zone)
+	}
+
+	return nil
+}
+
+func (r *resolver) nodeZone(nodeName string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.config.SyncTimeout)
+	defer cancel()
+
+	node, err := r.client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	return node.Labels[corev1.LabelTopologyZone], nil
+}
+
+// ResolveID resolves the app ID to the address of one of the ready endpoints of its service.
+func (r *resolver) ResolveID(req nameresolution.ResolveRequest) (string, error) {
+	w, err := r.watchService(req.Namespace, req.ID+r.config.ServiceSuffix)
+	if err != nil {
+		return "", err
+	}
+
+	slices, err := w.lister.List(labels.Everything())
+	if err != nil {
+		return "", fmt.Errorf("failed to list endpoints of app %s: %w", req.ID, err)
+	}
+	addresses := selectAddresses(slices, r.config.Zone)
+	if len(addresses) == 0 {
+		return "", fmt.Errorf("no ready endpoints found for app %s in namespace %s", req.ID, req.Namespace)
+	}
+
+	addr := addresses[(w.next.Add(1)-1)%uint64(len(addresses))]
+	return net.JoinHostPort(addr, strconv.Itoa(req.Port)), nil
+}
+
+// watchService returns the watcher of the service, starting it the first time the service is resolved.
+func (r *resolver) watchService(namespace string, service string) (*serviceWatcher, error) {
+	if r.closed.Load() {
+		return nil, errors.New("resolver is closed")
+	}
+	if r.client == nil {
+		return nil, errors.New("resolver is not initialized")
+	}
+
+	key := namespace + "/" + service
+	r.lock.Lock()
+	w, ok := r.services[key]
+	if !ok {
+		factory := informers.NewSharedInformerFactoryWithOptions(r.client, 0,
+			informers.WithNamespace(namespace),
+			informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+				opts.LabelSelector = discoveryv1.LabelServiceName + "=" + service
+			}),
+		)
+		informer := factory.Discovery().V1().EndpointSlices()
+		w = &serviceWatcher{
+			lister:    informer.Lister().EndpointSlices(namespace),
+			hasSynced: informer.Informer().HasSynced,
+		}
+		factory.Start(r.closeCh)
+		r.services[key] = w
+	}
+	r.lock.Unlock()
+
+	if !w.hasSynced() {
+		ctx, cancel := context.WithTimeout(context.Background(), r.config.SyncTimeout)
+		defer cancel()
+		if !cache.WaitForCacheSync(ctx.Done(), w.hasSynced) {
+			return nil, fmt.Errorf("timed out waiting for the endpoints of service %s", key)
+		}
+	}
+
+	return w, nil
+}
+
+// selectAddresses returns the addresses of the ready endpoints, sorted.
+// If zone is set and there are ready endpoints in that zone, only those are returned.
+func selectAddresses(slices []*discoveryv1.EndpointSlice, zone string) []string {
+	var (
+		local []string
+		all   []string
+		seen  = map[string]struct{}{}
+	)
+	for _, slice := range slices {
+		if slice.AddressType == discoveryv1.AddressTypeFQDN {
+			continue
+		}
+		for _, ep := range slice.Endpoints {
+			if !endpointReady(ep) || len(ep.Addresses) == 0 {
+				continue
+			}
+			addr := ep.Addresses[0]
+			if _, ok := seen[addr]; ok {
+				continue
+			}
+			seen[addr] = struct{}{}
+
+			all = append(all, addr)
+			if zone != "" && endpointInZone(ep, zone) {
+				local = append(local, addr)
+			}
+		}
+	}
+
+	res := all
+	if len(local) > 0 {
+		res = local
+	}
+	// Listers return objects in random order, so sort them for round-robin to be fair
+	sort.Strings(res)
+	return res
+}
+
+func endpointReady(ep discoveryv1.Endpoint) bool {
+	// A nil ready condition means the state is unknown, which should be interpreted as ready
+	if ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+		return false
+	}
+	return ep.Conditions.Terminating == nil || !*ep.Conditions.Terminating
+}
+
+// endpointInZone returns true if the endpoint should be used by clients in the zone.
+// Topology-aware hints take precedence over the zone of the endpoint.
+func endpointInZone(ep discoveryv1.Endpoint, zone string) bool {
+	if ep.Hints != nil && len(ep.Hints.ForZones) > 0 {
+		for _, z := range ep.Hints.ForZones {
+			if z.Name == zone {
+				return true
+			}
+		}
+		return false
+	}
+	return ep.Zone != nil && *ep.Zone == zone
+}
+
+// Close stops watching the EndpointSlices.
+func (r *resolver) Close() error {
+	if r.closed.CompareAndSwap(false, true) {
+		close(r.closeCh)
+	}
+	return nil
+}
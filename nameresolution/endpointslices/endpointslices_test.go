@@ -0,0 +1,192 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpointslices
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+
+	"github.com/dapr/components-contrib/nameresolution"
+	"github.com/dapr/kit/logger"
+)
+
+func endpoint(addr string, zone string, ready bool) discoveryv1.Endpoint {
+	return discoveryv1.Endpoint{
+		Addresses:  []string{addr},
+		Zone:       pointer.String(zone),
+		Conditions: discoveryv1.EndpointConditions{Ready: pointer.Bool(ready)},
+	}
+}
+
+func endpointSlice(name string, service string, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: service},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints:   endpoints,
+	}
+}
+
+func newTestResolver(t *testing.T, cfg map[string]any, client kubernetes.Interface) *resolver {
+	t.Helper()
+
+	r := NewResolver(logger.NewLogger("test")).(*resolver)
+	r.getClient = func() (kubernetes.Interface, error) {
+		return client, nil
+	}
+	err := r.Init(nameresolution.Metadata{Configuration: cfg})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		r.Close()
+	})
+	return r
+}
+
+func TestInit(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		r := newTestResolver(t, nil, fake.NewSimpleClientset())
+		assert.Equal(t, "-dapr", r.config.ServiceSuffix)
+		assert.Equal(t, 10*time.Second, r.config.SyncTimeout)
+		assert.Empty(t, r.config.Zone)
+	})
+
+	t.Run("zone of the node", func(t *testing.T) {
+		client := fake.NewSimpleClientset(&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "node1",
+				Labels: map[string]string{corev1.LabelTopologyZone: "zone-b"},
+			},
+		})
+		r := newTestResolver(t, map[string]any{"nodeName": "node1"}, client)
+		assert.Equal(t, "zone-b", r.config.Zone)
+	})
+
+	t.Run("explicit zone", func(t *testing.T) {
+		r := newTestResolver(t, map[string]any{"zone": "zone-a", "nodeName": "node1", "syncTimeout": "2s"}, fake.NewSimpleClientset())
+		assert.Equal(t, "zone-a", r.config.Zone)
+		assert.Equal(t, 2*time.Second, r.config.SyncTimeout)
+	})
+
+	t.Run("missing node", func(t *testing.T) {
+		r := newTestResolver(t, map[string]any{"nodeName": "node1"}, fake.NewSimpleClientset())
+		assert.Empty(t, r.config.Zone)
+	})
+}
+
+func TestResolveID(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		endpointSlice("myapp-dapr-1", "myapp-dapr",
+			endpoint("10.0.0.1", "zone-a", true),
+			endpoint("10.0.0.2", "zone-b", true),
+		),
+		endpointSlice("myapp-dapr-2", "myapp-dapr",
+			endpoint("10.0.0.3", "zone-a", true),
+			endpoint("10.0.0.4", "zone-a", false),
+		),
+		endpointSlice("other-dapr-1", "other-dapr",
+			endpoint("10.0.1.1", "zone-a", true),
+		),
+	)
+	req := nameresolution.ResolveRequest{ID: "myapp", Namespace: "default", Port: 50002}
+
+	t.Run("prefers the same zone in round-robin", func(t *testing.T) {
+		r := newTestResolver(t, map[string]any{"zone": "zone-a"}, client)
+
+		var got []string
+		for i := 0; i < 4; i++ {
+			addr, err := r.ResolveID(req)
+			require.NoError(t, err)
+			got = append(got, addr)
+		}
+		assert.Equal(t, []string{"10.0.0.1:50002", "10.0.0.3:50002", "10.0.0.1:50002", "10.0.0.3:50002"}, got)
+	})
+
+	t.Run("fails over to other zones", func(t *testing.T) {
+		r := newTestResolver(t, map[string]any{"zone": "zone-c"}, client)
+
+		seen := map[string]bool{}
+		for i := 0; i < 3; i++ {
+			addr, err := r.ResolveID(req)
+			require.NoError(t, err)
+			seen[addr] = true
+		}
+		assert.Equal(t, map[string]bool{"10.0.0.1:50002": true, "10.0.0.2:50002": true, "10.0.0.3:50002": true}, seen)
+	})
+
+	t.Run("picks up changes", func(t *testing.T) {
+		r := newTestResolver(t, map[string]any{"zone": "zone-a"}, client)
+		addr, err := r.ResolveID(nameresolution.ResolveRequest{ID: "other", Namespace: "default", Port: 50002})
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.1.1:50002", addr)
+
+		slice := endpointSlice("other-dapr-1", "other-dapr", endpoint("10.0.1.1", "zone-a", false))
+		_, err = client.DiscoveryV1().EndpointSlices("default").Update(context.Background(), slice, metav1.UpdateOptions{})
+		require.NoError(t, err)
+
+		assert.Eventually(t, func() bool {
+			_, err = r.ResolveID(nameresolution.ResolveRequest{ID: "other", Namespace: "default", Port: 50002})
+			return err != nil
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Contains(t, err.Error(), "no ready endpoints found for app other")
+	})
+
+	t.Run("unknown app", func(t *testing.T) {
+		r := newTestResolver(t, nil, client)
+		_, err := r.ResolveID(nameresolution.ResolveRequest{ID: "unknown", Namespace: "default", Port: 50002})
+		require.Error(t, err)
+	})
+}
+
+func TestSelectAddresses(t *testing.T) {
+	terminating := endpoint("10.0.0.5", "zone-a", true)
+	terminating.Conditions.Terminating = pointer.Bool(true)
+	unknown := endpoint("10.0.0.6", "zone-b", true)
+	unknown.Conditions.Ready = nil
+	hinted := endpoint("10.0.0.7", "zone-b", true)
+	hinted.Hints = &discoveryv1.EndpointHints{ForZones: []discoveryv1.ForZone{{Name: "zone-a"}}}
+
+	slices := []*discoveryv1.EndpointSlice{
+		endpointSlice("s1", "svc", terminating, unknown, hinted, endpoint("10.0.0.8", "zone-a", true)),
+		// Duplicate address in another slice
+		endpointSlice("s2", "svc", endpoint("10.0.0.8", "zone-a", true)),
+	}
+
+	assert.Equal(t, []string{"10.0.0.7", "10.0.0.8"}, selectAddresses(slices, "zone-a"))
+	assert.Equal(t, []string{"10.0.0.6", "10.0.0.7", "10.0.0.8"}, selectAddresses(slices, ""))
+	// Endpoints with hints are only used by the hinted zones, unless there are no endpoints in the zone
+	assert.Equal(t, []string{"10.0.0.6"}, selectAddresses(slices, "zone-b"))
+}
+
+func TestResolveIPv6(t *testing.T) {
+	slice := endpointSlice("myapp-dapr-1", "myapp-dapr", endpoint("fd00::1", "zone-a", true))
+	slice.AddressType = discoveryv1.AddressTypeIPv6
+	r := newTestResolver(t, nil, fake.NewSimpleClientset(slice))
+
+	addr, err := r.ResolveID(nameresolution.ResolveRequest{ID: "myapp", Namespace: "default", Port: 50002})
+	require.NoError(t, err)
+	assert.Equal(t, "[fd00::1]:50002", addr)
+}
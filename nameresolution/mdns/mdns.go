@@ -18,8 +18,10 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"net"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -28,6 +30,7 @@ import (
 	"github.com/grandcat/zeroconf"
 
 	"github.com/dapr/components-contrib/nameresolution"
+	"github.com/dapr/kit/config"
 	"github.com/dapr/kit/logger"
 )
 
@@ -53,6 +56,24 @@ const (
 	addressTTL = time.Second * 60
 )
 
+// Values of the ipTraffic configuration option.
+const (
+	ipTrafficIPv4        = "ipv4"
+	ipTrafficIPv6        = "ipv6"
+	ipTrafficIPv4AndIPv6 = "ipv4+ipv6"
+)
+
+type resolverConfig struct {
+	// IP protocols used to announce and browse: "ipv4", "ipv6" or "ipv4+ipv6".
+	// When not set, IPv4 and IPv6 are attempted first, then each of them alone.
+	IPTraffic string `mapstructure:"ipTraffic"`
+	// Names of the network interfaces to announce and browse on. Defaults to all the multicast interfaces.
+	Interfaces []string `mapstructure:"interfaces"`
+	// Subnets, in CIDR notation, of the addresses to announce and resolve.
+	// When set, the addresses of the interfaces in these subnets are announced instead of the host address, and resolved addresses outside of them are ignored.
+	Subnets []string `mapstructure:"subnets"`
+}
+
 // address is used to store an ip address along with
 // an expiry time at which point the address is considered
 // too stale to trust.
@@ -228,6 +249,12 @@ type Resolver struct {
 	serversRunning sync.WaitGroup
 	refreshRunning atomic.Bool
 	logger         logger.Logger
+	// ipTraffic is the IP protocol used to announce and browse; zero if not configured.
+	ipTraffic zeroconf.IPType
+	// ifaces are the network interfaces to announce and browse on; nil for all.
+	ifaces []net.Interface
+	// subnets limit the addresses announced and resolved; nil for any.
+	subnets []*net.IPNet
 }
 
 func (m *Resolver) startRefreshers() {
@@ -291,19 +318,144 @@ func (m *Resolver) Init(metadata nameresolution.Metadata) error {
 		return errors.New("port is invalid")
 	}
 
-	err = m.registerMDNS("", appID, []string{hostAddress}, port)
+	err = m.applyConfig(metadata.Configuration)
+	if err != nil {
+		return err
+	}
+
+	ips := []string{hostAddress}
+	if len(m.subnets) > 0 {
+		ips, err = m.interfaceAddresses()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = m.registerMDNS("", appID, ips, port)
 	if err != nil {
 		return err
 	}
 
-	m.logger.Infof("local service entry announced: %s -> %s:%d", appID, hostAddress, port)
+	m.logger.Infof("local service entry announced: %s -> %s:%d", appID, strings.Join(ips, ","), port)
 
 	go m.startRefreshers()
 
 	return nil
 }
 
+// applyConfig parses the configuration of the resolver and applies it.
+func (m *Resolver) applyConfig(rawConfig interface{}) error {
+	var cfg resolverConfig
+	if rawConfig != nil {
+		normalized, err := config.Normalize(rawConfig)
+		if err != nil {
+			return err
+		}
+		err = config.Decode(normalized, &cfg)
+		if err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+
+	switch cfg.IPTraffic {
+	case "":
+		m.ipTraffic = 0
+	case ipTrafficIPv4:
+		m.ipTraffic = zeroconf.IPv4
+	case ipTrafficIPv6:
+		m.ipTraffic = zeroconf.IPv6
+	case ipTrafficIPv4AndIPv6:
+		m.ipTraffic = zeroconf.IPv4AndIPv6
+	default:
+		return fmt.Errorf("invalid ipTraffic %q: must be one of %s, %s or %s", cfg.IPTraffic, ipTrafficIPv4, ipTrafficIPv6, ipTrafficIPv4AndIPv6)
+	}
+
+	m.ifaces = nil
+	for _, name := range cfg.Interfaces {
+		iface, err := net.InterfaceByName(name)
+		if err != nil {
+			return fmt.Errorf("invalid interface %s: %w", name, err)
+		}
+		m.ifaces = append(m.ifaces, *iface)
+	}
+
+	m.subnets = nil
+	for _, cidr := range cfg.Subnets {
+		_, subnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid subnet %s: %w", cidr, err)
+		}
+		m.subnets = append(m.subnets, subnet)
+	}
+
+	return nil
+}
+
+// interfaceAddresses returns the addresses of the selected network
+// interfaces that are allowed to be announced.
+func (m *Resolver) interfaceAddresses() ([]string, error) {
+	ifaces := m.ifaces
+	if len(ifaces) == 0 {
+		var err error
+		ifaces, err = net.Interfaces()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the network interfaces: %w", err)
+		}
+	}
+
+	var ips []string
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the addresses of interface %s: %w", iface.Name, err)
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if ok && m.isAllowedIP(ipNet.IP) {
+				ips = append(ips, ipNet.IP.String())
+			}
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no address in subnets %v found on the network interfaces", m.subnets)
+	}
+
+	return ips, nil
+}
+
+// isAllowedIP returns true if the address belongs to the configured
+// IP protocol and subnets.
+func (m *Resolver) isAllowedIP(ip net.IP) bool {
+	isIPv4 := ip.To4() != nil
+	if (m.ipTraffic == zeroconf.IPv4 && !isIPv4) || (m.ipTraffic == zeroconf.IPv6 && isIPv4) {
+		return false
+	}
+	if len(m.subnets) == 0 {
+		return true
+	}
+	for _, subnet := range m.subnets {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (m *Resolver) getZeroconfResolver() (resolver *zeroconf.Resolver, err error) {
+	var ifacesOpts []zeroconf.ClientOption
+	if len(m.ifaces) > 0 {
+		ifacesOpts = append(ifacesOpts, zeroconf.SelectIfaces(m.ifaces))
+	}
+
+	if m.ipTraffic != 0 {
+		resolver, err = zeroconf.NewResolver(append(ifacesOpts, zeroconf.SelectIPTraffic(m.ipTraffic))...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize resolver: %w", err)
+		}
+		return resolver, nil
+	}
+
 	// Try with IPv4 + IPv6 first, then IPv4-only, then IPv6-only
 	opts := []zeroconf.ClientOption{
 		zeroconf.SelectIPTraffic(zeroconf.IPv4AndIPv6),
@@ -311,7 +463,7 @@ func (m *Resolver) getZeroconfResolver() (resolver *zeroconf.Resolver, err error
 		zeroconf.SelectIPTraffic(zeroconf.IPv6),
 	}
 	for i := 0; i < len(opts); i++ {
-		resolver, err = zeroconf.NewResolver(opts[i])
+		resolver, err = zeroconf.NewResolver(append(ifacesOpts, opts[i])...)
 		if err == nil {
 			break
 		}
@@ -382,9 +534,9 @@ func (m *Resolver) registerMDNS(instanceID string, appID string, ips []string, p
 		}
 
 		if len(ips) > 0 {
-			server, err = zeroconf.RegisterProxy(instanceID, appID, "local.", port, host, ips, info, nil)
+			server, err = zeroconf.RegisterProxy(instanceID, appID, "local.", port, host, ips, info, m.ifaces)
 		} else {
-			server, err = zeroconf.Register(instanceID, appID, "local.", port, info, nil)
+			server, err = zeroconf.Register(instanceID, appID, "local.", port, info, m.ifaces)
 		}
 
 		if err != nil {
@@ -709,27 +861,22 @@ func (m *Resolver) browse(ctx context.Context, appID string, onEach func(ip stri
 
 			m.logger.Debugf("mDNS response for app id %s received.", appID)
 
-			hasIPv4Address := len(entry.AddrIPv4) > 0
-			hasIPv6Address := len(entry.AddrIPv6) > 0
-
-			if !hasIPv4Address && !hasIPv6Address {
-				m.logger.Debugf("mDNS response for app id %s doesn't contain any IPv4 or IPv6 addresses, skipping.", appID)
+			ipv4Addr, ipv6Addr := m.entryAddresses(entry)
+			if ipv4Addr == "" && ipv6Addr == "" {
+				m.logger.Debugf("mDNS response for app id %s doesn't contain any allowed IPv4 or IPv6 addresses, skipping.", appID)
 				break
 			}
 
-			var addr string
-			port := entry.Port
-
-			// TODO: we currently only use the first IPv4 and IPv6 address.
-			// We should understand the cases in which additional addresses
-			// are returned and whether we need to support them.
-			if hasIPv4Address {
-				addr = entry.AddrIPv4[0].String() + ":" + strconv.Itoa(port)
-				m.addAppAddressIPv4(appID, addr)
+			// IPv4 addresses are preferred, consistently with the cache lookups.
+			addr := ipv4Addr
+			if ipv4Addr != "" {
+				m.addAppAddressIPv4(appID, ipv4Addr)
 			}
-			if hasIPv6Address {
-				addr = entry.AddrIPv6[0].String() + ":" + strconv.Itoa(port)
-				m.addAppAddressIPv6(appID, addr)
+			if ipv6Addr != "" {
+				m.addAppAddressIPv6(appID, ipv6Addr)
+				if addr == "" {
+					addr = ipv6Addr
+				}
 			}
 
 			if onEach != nil {
@@ -771,6 +918,29 @@ func (m *Resolver) browse(ctx context.Context, appID string, onEach func(ip stri
 	return resolver.Browse(ctx, appID, "local.", entries)
 }
 
+// entryAddresses returns the first allowed IPv4 and IPv6 addresses, with
+// the port, of a service entry. Either is empty if there is none.
+// TODO: we currently only use the first allowed IPv4 and IPv6 address.
+// We should understand the cases in which additional addresses
+// are returned and whether we need to support them.
+func (m *Resolver) entryAddresses(entry *zeroconf.ServiceEntry) (ipv4Addr string, ipv6Addr string) {
+	port := strconv.Itoa(entry.Port)
+	for _, ip := range entry.AddrIPv4 {
+		if m.isAllowedIP(ip) {
+			ipv4Addr = net.JoinHostPort(ip.String(), port)
+			break
+		}
+	}
+	for _, ip := range entry.AddrIPv6 {
+		if m.isAllowedIP(ip) {
+			ipv6Addr = net.JoinHostPort(ip.String(), port)
+			break
+		}
+	}
+
+	return ipv4Addr, ipv6Addr
+}
+
 // addAppAddressIPv4 adds an IPv4 address to the
 // cache for the provided app id.
 func (m *Resolver) addAppAddressIPv4(appID string, addr string) {
@@ -16,6 +16,7 @@ package mdns
 import (
 	"fmt"
 	"math"
+	"net"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -24,6 +25,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grandcat/zeroconf"
+
 	"github.com/dapr/components-contrib/metadata"
 	nr "github.com/dapr/components-contrib/nameresolution"
 	"github.com/dapr/kit/logger"
@@ -742,3 +745,123 @@ func TestUnion(t *testing.T) {
 		require.Equal(t, len(tt.expected), matches)
 	}
 }
+
+func TestInitConfiguration(t *testing.T) {
+	props := map[string]string{
+		nr.AppID:       "testAppID",
+		nr.HostAddress: localhost,
+		nr.DaprPort:    "1234",
+	}
+	tests := []struct {
+		name          string
+		configuration map[string]interface{}
+		expectedError string
+	}{
+		{
+			"invalid ipTraffic",
+			map[string]interface{}{"ipTraffic": "ipv5"},
+			"invalid ipTraffic",
+		},
+		{
+			"invalid interface",
+			map[string]interface{}{"interfaces": []interface{}{"doesnotexist0"}},
+			"invalid interface doesnotexist0",
+		},
+		{
+			"invalid subnet",
+			map[string]interface{}{"subnets": []interface{}{"10.0.0.0"}},
+			"invalid subnet 10.0.0.0",
+		},
+		{
+			"no address in subnets",
+			map[string]interface{}{"subnets": []interface{}{"233.252.0.0/24"}},
+			"no address in subnets",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			resolver := NewResolver(logger.NewLogger("test")).(*Resolver)
+			defer resolver.Close()
+
+			// act
+			err := resolver.Init(nr.Metadata{Base: metadata.Base{Properties: props}, Configuration: tt.configuration})
+
+			// assert
+			require.ErrorContains(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestInterfaceAddresses(t *testing.T) {
+	// arrange
+	resolver := NewResolver(logger.NewLogger("test")).(*Resolver)
+	defer resolver.Close()
+	err := resolver.applyConfig(map[string]interface{}{
+		"ipTraffic": "ipv4",
+		"subnets":   []interface{}{"127.0.0.0/8"},
+	})
+	require.NoError(t, err)
+
+	// act
+	ips, err := resolver.interfaceAddresses()
+
+	// assert
+	require.NoError(t, err)
+	require.Contains(t, ips, localhost)
+	for _, ip := range ips {
+		require.True(t, net.ParseIP(ip).IsLoopback())
+	}
+}
+
+func TestEntryAddresses(t *testing.T) {
+	entry := &zeroconf.ServiceEntry{
+		AddrIPv4: []net.IP{net.ParseIP("172.17.0.2"), net.ParseIP("192.168.1.10")},
+		AddrIPv6: []net.IP{net.ParseIP("fe80::1"), net.ParseIP("fd00::10")},
+	}
+	entry.Port = 1234
+
+	testCases := []struct {
+		name          string
+		configuration map[string]interface{}
+		expectedIPv4  string
+		expectedIPv6  string
+	}{
+		{
+			name:         "no filter",
+			expectedIPv4: "172.17.0.2:1234",
+			expectedIPv6: "[fe80::1]:1234",
+		},
+		{
+			name:          "subnets",
+			configuration: map[string]interface{}{"subnets": []interface{}{"192.168.0.0/16", "fd00::/8"}},
+			expectedIPv4:  "192.168.1.10:1234",
+			expectedIPv6:  "[fd00::10]:1234",
+		},
+		{
+			name:          "ipv6 only",
+			configuration: map[string]interface{}{"ipTraffic": "ipv6"},
+			expectedIPv6:  "[fe80::1]:1234",
+		},
+		{
+			name:          "no match",
+			configuration: map[string]interface{}{"subnets": []interface{}{"10.0.0.0/8"}},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			resolver := NewResolver(logger.NewLogger("test")).(*Resolver)
+			defer resolver.Close()
+			require.NoError(t, resolver.applyConfig(tt.configuration))
+
+			// act
+			ipv4Addr, ipv6Addr := resolver.entryAddresses(entry)
+
+			// assert
+			require.Equal(t, tt.expectedIPv4, ipv4Addr)
+			require.Equal(t, tt.expectedIPv6, ipv6Addr)
+		})
+	}
+}
@@ -13,6 +13,12 @@ import (
 type snsSqsMetadata struct {
 	// aws endpoint for the component to use.
 	Endpoint string `mapstructure:"endpoint"`
+	// aws endpoint for STS, which is used to get the account ID and to assume roles. Defaults to the AWS endpoint of the region, even when endpoint is set.
+	STSEndpoint string `mapstructure:"stsEndpoint"`
+	// use the FIPS endpoints of the AWS services.
+	UseFIPSEndpoint bool `mapstructure:"useFipsEndpoint"`
+	// use the dual-stack (IPv4 and IPv6) endpoints of the AWS services.
+	UseDualStackEndpoint bool `mapstructure:"useDualStackEndpoint"`
 	// access key to use for accessing sqs/sns.
 	AccessKey string `mapstructure:"accessKey"`
 	// secret key to use for accessing sqs/sns.
@@ -49,12 +55,16 @@ type snsSqsMetadata struct {
 	MessageMaxNumber int64 `mapstructure:"messageMaxNumber"`
 	// disable resource provisioning of SNS and SQS.
 	DisableEntityManagement bool `mapstructure:"disableEntityManagement"`
+	// publish and subscribe directly to pre-existing SQS queues, addressed by the topic name as a queue name, URL or ARN, without using SNS.
+	SqsOnly bool `mapstructure:"sqsOnly"`
 	// assets creation timeout.
 	AssetsManagementTimeoutSeconds float64 `mapstructure:"assetsManagementTimeoutSeconds"`
 	// aws account ID. internally resolved if not given.
 	AccountID string `mapstructure:"accountID"`
 	// processing concurrency mode
 	ConcurrencyMode pubsub.ConcurrencyMode `mapstructure:"concurrencyMode"`
+	// topics whose permissions are checked at Init
+	pubsub.PreflightProperties `mapstructure:",squash"`
 }
 
 func maskLeft(s string) string {
@@ -80,11 +90,7 @@ func (s *snsSqs) getSnsSqsMetatdata(meta pubsub.Metadata) (*snsSqsMetadata, erro
 	}
 
 	if md.Region != "" {
-		if partition, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), md.Region); ok {
-			md.internalPartition = partition.ID()
-		} else {
-			md.internalPartition = "aws"
-		}
+		md.internalPartition = partitionForRegion(md.Region)
 	}
 
 	if md.SqsQueueName == "" {
@@ -108,6 +114,10 @@ func (s *snsSqs) getSnsSqsMetatdata(meta pubsub.Metadata) (*snsSqsMetadata, erro
 		return nil, errors.New("configuration conflict: 'disableDeleteOnRetryLimit' cannot be set to 'true' when 'sqsDeadLettersQueueName' is set to a value. either remove this configuration or set 'disableDeleteOnRetryLimit' to 'false'")
 	}
 
+	if md.SqsOnly && len(md.SqsDeadLettersQueueName) > 0 {
+		return nil, errors.New("configuration conflict: 'sqsDeadLettersQueueName' cannot be set when 'sqsOnly' is set to 'true'. configure the redrive policy of the queues instead")
+	}
+
 	if md.MessageWaitTimeSeconds < 1 {
 		return nil, errors.New("messageWaitTimeSeconds must be greater than 0")
 	}
@@ -167,3 +177,11 @@ func upgradeMetadata(m *pubsub.Metadata) {
 		}
 	}
 }
+
+// partitionForRegion returns the partition of a region, which is part of the ARNs of the resources.
+func partitionForRegion(region string) string {
+	if partition, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), region); ok {
+		return partition.ID()
+	}
+	return "aws"
+}
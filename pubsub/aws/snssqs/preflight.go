@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snssqs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+// preflight verifies that the identity of the component has the permissions to publish and subscribe to the topics in the preflight metadata properties.
+// Permissions are evaluated with the IAM policy simulator, so the identity needs the iam:SimulatePrincipalPolicy permission on itself.
+func (s *snsSqs) preflight(parentCtx context.Context, iamClient iamiface.IAMAPI) error {
+	ctx, cancelFn := context.WithTimeout(parentCtx, s.opsTimeout)
+	callerIDOutput, err := s.stsClient.GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{})
+	cancelFn()
+	if err != nil {
+		return fmt.Errorf("preflight check failed: error fetching sts caller ID: %w", err)
+	}
+	principal := principalARN(aws.StringValue(callerIDOutput.Arn))
+
+	if s.metadata.SqsOnly {
+		// Topics are queues, which the component sends messages to and receives messages from
+		return s.metadata.PreflightCheck(func(topic string) error {
+			queueArn, err := s.queueARNForTopic(topic)
+			if err != nil {
+				return err
+			}
+			return s.checkPermissions(parentCtx, iamClient, principal, queueArn, "sqs:SendMessage")
+		}, func(topic string) error {
+			queueArn, err := s.queueARNForTopic(topic)
+			if err != nil {
+				return err
+			}
+			return s.checkPermissions(parentCtx, iamClient, principal, queueArn, "sqs:ReceiveMessage", "sqs:DeleteMessage", "sqs:ChangeMessageVisibility")
+		})
+	}
+
+	queueArn := s.buildARN("sqs", nameToAWSSanitizedName(s.metadata.SqsQueueName, s.metadata.Fifo))
+	return s.metadata.PreflightCheck(func(topic string) error {
+		topicArn := s.buildARN("sns", nameToAWSSanitizedName(topic, s.metadata.Fifo))
+		return s.checkPermissions(parentCtx, iamClient, principal, topicArn, "sns:Publish")
+	}, func(topic string) error {
+		if !s.metadata.DisableEntityManagement {
+			// The component subscribes its queue to the topic
+			topicArn := s.buildARN("sns", nameToAWSSanitizedName(topic, s.metadata.Fifo))
+			err := s.checkPermissions(parentCtx, iamClient, principal, topicArn, "sns:Subscribe")
+			if err != nil {
+				return err
+			}
+		}
+		return s.checkPermissions(parentCtx, iamClient, principal, queueArn, "sqs:ReceiveMessage", "sqs:DeleteMessage", "sqs:ChangeMessageVisibility")
+	})
+}
+
+// checkPermissions returns a PermissionError if the principal is not allowed to perform any of the actions on the resource.
+func (s *snsSqs) checkPermissions(parentCtx context.Context, iamClient iamiface.IAMAPI, principal string, resource string, actions ...string) error {
+	ctx, cancelFn := context.WithTimeout(parentCtx, s.opsTimeout)
+	out, err := iamClient.SimulatePrincipalPolicyWithContext(ctx, &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(principal),
+		ActionNames:     aws.StringSlice(actions),
+		ResourceArns:    []*string{aws.String(resource)},
+	})
+	cancelFn()
+	if err != nil {
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == "AccessDenied" {
+			return fmt.Errorf("preflight check failed: identity '%s' is not allowed to simulate its IAM policies; grant it iam:SimulatePrincipalPolicy or remove the preflight metadata properties: %w", principal, err)
+		}
+		return fmt.Errorf("preflight check of '%s' failed: %w", resource, err)
+	}
+
+	missing := make([]string, 0, len(actions))
+	for _, res := range out.EvaluationResults {
+		if aws.StringValue(res.EvalDecision) != iam.PolicyEvaluationDecisionTypeAllowed {
+			missing = append(missing, aws.StringValue(res.EvalActionName))
+		}
+	}
+	if len(missing) > 0 {
+		return &pubsub.PermissionError{
+			Identity:    principal,
+			Entity:      resource,
+			Permissions: missing,
+		}
+	}
+	return nil
+}
+
+// principalARN returns the ARN of the IAM principal of a caller identity.
+// The policy simulator doesn't accept the ARN of an STS session, so it's converted to the ARN of the assumed role.
+// Roles with a path are not supported, because the path is not part of the session ARN.
+func principalARN(callerArn string) string {
+	// arn:aws:sts::123456789012:assumed-role/role-name/session-name
+	parts := strings.SplitN(callerArn, ":", 6)
+	if len(parts) != 6 || parts[2] != "sts" || !strings.HasPrefix(parts[5], "assumed-role/") {
+		return callerArn
+	}
+	role := strings.Split(strings.TrimPrefix(parts[5], "assumed-role/"), "/")[0]
+	return fmt.Sprintf("arn:%s:iam::%s:role/%s", parts[1], parts[4], role)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snssqs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+type mockIAM struct {
+	iamiface.IAMAPI
+	denied []string
+	err    error
+}
+
+func (m *mockIAM) SimulatePrincipalPolicyWithContext(ctx aws.Context, input *iam.SimulatePrincipalPolicyInput, opts ...request.Option) (*iam.SimulatePolicyResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	out := &iam.SimulatePolicyResponse{}
+	for _, action := range input.ActionNames {
+		decision := iam.PolicyEvaluationDecisionTypeAllowed
+		for _, d := range m.denied {
+			if d == aws.StringValue(action) {
+				decision = iam.PolicyEvaluationDecisionTypeImplicitDeny
+			}
+		}
+		out.EvaluationResults = append(out.EvaluationResults, &iam.EvaluationResult{
+			EvalActionName:   action,
+			EvalResourceName: input.ResourceArns[0],
+			EvalDecision:     aws.String(decision),
+		})
+	}
+	return out, nil
+}
+
+func Test_checkPermissions(t *testing.T) {
+	s := &snsSqs{
+		metadata:   &snsSqsMetadata{},
+		opsTimeout: time.Second,
+	}
+	principal := "arn:aws:iam::123456789012:role/dapr"
+	resource := "arn:aws:sqs:us-east-1:123456789012:queue"
+	actions := []string{"sqs:ReceiveMessage", "sqs:DeleteMessage"}
+
+	t.Run("allowed", func(t *testing.T) {
+		err := s.checkPermissions(context.Background(), &mockIAM{}, principal, resource, actions...)
+		require.NoError(t, err)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		err := s.checkPermissions(context.Background(), &mockIAM{denied: []string{"sqs:DeleteMessage"}}, principal, resource, actions...)
+		var permErr *pubsub.PermissionError
+		require.True(t, errors.As(err, &permErr))
+		require.Equal(t, principal, permErr.Identity)
+		require.Equal(t, resource, permErr.Entity)
+		require.Equal(t, []string{"sqs:DeleteMessage"}, permErr.Permissions)
+	})
+
+	t.Run("simulation not allowed", func(t *testing.T) {
+		err := s.checkPermissions(context.Background(), &mockIAM{err: awserr.New("AccessDenied", "denied", nil)}, principal, resource, actions...)
+		require.ErrorContains(t, err, "iam:SimulatePrincipalPolicy")
+	})
+}
+
+func Test_principalARN(t *testing.T) {
+	require.Equal(t, "arn:aws:iam::123456789012:role/dapr", principalARN("arn:aws:sts::123456789012:assumed-role/dapr/session"))
+	require.Equal(t, "arn:aws-cn:iam::123456789012:role/dapr", principalARN("arn:aws-cn:sts::123456789012:assumed-role/dapr/session"))
+	require.Equal(t, "arn:aws:iam::123456789012:user/dapr", principalARN("arn:aws:iam::123456789012:user/dapr"))
+}
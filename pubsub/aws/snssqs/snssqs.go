@@ -27,6 +27,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/aws/aws-sdk-go/service/sts"
@@ -71,6 +72,7 @@ type snsSqs struct {
 	closeCh chan struct{}
 	closed  atomic.Bool
 	wg      sync.WaitGroup
+	metrics pubsub.Metrics
 }
 
 type sqsQueueInfo struct {
@@ -104,13 +106,13 @@ func NewSnsSqs(l logger.Logger) pubsub.PubSub {
 		l.Fatalf("failed generating unique nano id: %s", err)
 	}
 
-	return &snsSqs{
+	return pubsub.WithSchemaValidation(&snsSqs{
 		logger:        l,
 		id:            id,
 		topicsLock:    sync.RWMutex{},
 		pollerRunning: make(chan struct{}, 1),
 		closeCh:       make(chan struct{}),
-	}
+	})
 }
 
 // sanitize topic/queue name to conform with:
@@ -167,10 +169,24 @@ func (s *snsSqs) Init(ctx context.Context, metadata pubsub.Metadata) error {
 	s.queues = sync.Map{}
 	s.subscriptions = sync.Map{}
 
-	sess, err := awsAuth.GetClient(md.AccessKey, md.SecretKey, md.SessionToken, md.Region, md.Endpoint)
+	sess, err := awsAuth.GetClient(awsAuth.Options{
+		AccessKey:            md.AccessKey,
+		SecretKey:            md.SecretKey,
+		SessionToken:         md.SessionToken,
+		Region:               md.Region,
+		Endpoint:             md.Endpoint,
+		STSEndpoint:          md.STSEndpoint,
+		UseFIPSEndpoint:      md.UseFIPSEndpoint,
+		UseDualStackEndpoint: md.UseDualStackEndpoint,
+	})
 	if err != nil {
 		return fmt.Errorf("error creating an AWS client: %w", err)
 	}
+	// The region is needed to build ARNs, and it may come from the environment or the shared config
+	if md.Region == "" {
+		md.Region = aws.StringValue(sess.Config.Region)
+		md.internalPartition = partitionForRegion(md.Region)
+	}
 	// AWS sns,sqs,sts client.
 	s.snsClient = sns.New(sess)
 	s.sqsClient = sqs.New(sess)
@@ -190,6 +206,13 @@ func (s *snsSqs) Init(ctx context.Context, metadata pubsub.Metadata) error {
 		return fmt.Errorf("error decoding backOff config: %w", err)
 	}
 
+	if md.PreflightEnabled() {
+		err = s.preflight(ctx, iam.New(sess))
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -543,7 +566,7 @@ func (s *snsSqs) validateMessage(ctx context.Context, message *sqs.Message, queu
 	return nil
 }
 
-func (s *snsSqs) callHandler(ctx context.Context, message *sqs.Message, queueInfo *sqsQueueInfo) error {
+func (s *snsSqs) callHandler(ctx context.Context, message *sqs.Message, queueInfo, deadLettersQueueInfo *sqsQueueInfo) error {
 	// otherwise, try to handle the message.
 	var snsMessagePayload snsMessage
 	err := json.Unmarshal([]byte(*(message.Body)), &snsMessagePayload)
@@ -564,11 +587,21 @@ func (s *snsSqs) callHandler(ctx context.Context, message *sqs.Message, queueInf
 
 	s.logger.Debugf("Processing SNS message id: %s of topic: %s", *message.MessageId, sanitizedTopic)
 
+	// validateMessage already made sure the receive count can be parsed
+	recvCount, _ := s.parseReceiveCount(message)
+	if recvCount > 1 {
+		s.metrics.Retried(handler.topicName)
+	}
+
 	err = handler.handler(handler.ctx, &pubsub.NewMessage{
 		Data:  []byte(snsMessagePayload.Message),
 		Topic: handler.topicName,
 	})
 	if err != nil {
+		// SQS moves the message to the dead-letters queue when it's received again
+		if deadLettersQueueInfo != nil && recvCount >= s.metadata.MessageReceiveLimit {
+			s.metrics.DeadLettered(handler.topicName)
+		}
 		return fmt.Errorf("error handling message: %w", err)
 	}
 	// otherwise, there was no error, acknowledge the message.
@@ -576,6 +609,16 @@ func (s *snsSqs) callHandler(ctx context.Context, message *sqs.Message, queueInf
 }
 
 func (s *snsSqs) consumeSubscription(ctx context.Context, queueInfo, deadLettersQueueInfo *sqsQueueInfo) {
+	s.consumeQueue(ctx, queueInfo, deadLettersQueueInfo, func(ctx context.Context, message *sqs.Message) error {
+		return s.callHandler(ctx, message, queueInfo, deadLettersQueueInfo)
+	})
+
+	// Signal that the poller stopped
+	<-s.pollerRunning
+}
+
+// consumeQueue receives messages from a queue and processes them with handle, until ctx is canceled.
+func (s *snsSqs) consumeQueue(ctx context.Context, queueInfo, deadLettersQueueInfo *sqsQueueInfo, handle func(ctx context.Context, message *sqs.Message) error) {
 	sqsPullExponentialBackoff := s.backOffConfig.NewBackOffWithContext(ctx)
 
 	receiveMessageInput := &sqs.ReceiveMessageInput{
@@ -632,7 +675,7 @@ func (s *snsSqs) consumeSubscription(ctx context.Context, queueInfo, deadLetters
 			}
 
 			f := func(message *sqs.Message) {
-				if err := s.callHandler(ctx, message, queueInfo); err != nil {
+				if err := handle(ctx, message); err != nil {
 					s.logger.Errorf("error while handling received message. error is: %v", err)
 				}
 
@@ -653,9 +696,6 @@ func (s *snsSqs) consumeSubscription(ctx context.Context, queueInfo, deadLetters
 		}
 		wg.Wait()
 	}
-
-	// Signal that the poller stopped
-	<-s.pollerRunning
 }
 
 func (s *snsSqs) createDeadLettersQueueAttributes(queueInfo, deadLettersQueueInfo *sqsQueueInfo) (*sqs.SetQueueAttributesInput, error) {
@@ -763,6 +803,15 @@ func (s *snsSqs) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, han
 		return errors.New("component is closed")
 	}
 
+	handlerSettings, err := pubsub.HandlerSettingsFromMetadata(req.Metadata, pubsub.HandlerSettings{})
+	if err != nil {
+		return err
+	}
+
+	if s.metadata.SqsOnly {
+		return s.subscribeToQueue(ctx, req, handlerSettings.Handler(s.metrics.InstrumentHandler(handler)))
+	}
+
 	// subscribers declare a topic ARN and declare a SQS queue to use
 	// these should be idempotent - queues should not be created if they exist.
 	topicArn, sanitizedName, err := s.getOrCreateTopic(ctx, req.Topic)
@@ -829,7 +878,7 @@ func (s *snsSqs) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, han
 	defer s.topicsLock.Unlock()
 	s.topicHandlers[sanitizedName] = topicHandler{
 		topicName: req.Topic,
-		handler:   handler,
+		handler:   handlerSettings.Handler(s.metrics.InstrumentHandler(handler)),
 		ctx:       ctx,
 	}
 
@@ -890,6 +939,10 @@ func (s *snsSqs) Publish(ctx context.Context, req *pubsub.PublishRequest) error
 		return errors.New("component is closed")
 	}
 
+	if s.metadata.SqsOnly {
+		return s.publishToQueue(ctx, req)
+	}
+
 	topicArn, _, err := s.getOrCreateTopic(ctx, req.Topic)
 	if err != nil {
 		s.logger.Errorf("error getting topic ARN for %s: %v", req.Topic, err)
@@ -905,7 +958,9 @@ func (s *snsSqs) Publish(ctx context.Context, req *pubsub.PublishRequest) error
 	}
 
 	// sns client has internal exponential backoffs.
+	start := time.Now()
 	_, err = s.snsClient.PublishWithContext(ctx, snsPublishInput)
+	s.metrics.Published(req.Topic, err, start)
 	if err != nil {
 		wrappedErr := fmt.Errorf("error publishing to topic: %s with topic ARN %s: %w", req.Topic, topicArn, err)
 		s.logger.Error(wrappedErr)
@@ -926,6 +981,11 @@ func (s *snsSqs) Close() error {
 	return nil
 }
 
+// SetMetricsHook sets the hook that receives the metrics of the component.
+func (s *snsSqs) SetMetricsHook(hook pubsub.MetricsHook) {
+	s.metrics.SetMetricsHook(hook)
+}
+
 func (s *snsSqs) Features() []pubsub.Feature {
 	return nil
 }
@@ -50,6 +50,7 @@ func Test_getSnsSqsMetatdata_AllConfiguration(t *testing.T) {
 	md, err := ps.getSnsSqsMetatdata(pubsub.Metadata{Base: metadata.Base{Properties: map[string]string{
 		"consumerID":               "consumer",
 		"Endpoint":                 "endpoint",
+		"stsEndpoint":              "sts-endpoint",
 		"concurrencyMode":          string(pubsub.Single),
 		"accessKey":                "a",
 		"secretKey":                "s",
@@ -67,6 +68,7 @@ func Test_getSnsSqsMetatdata_AllConfiguration(t *testing.T) {
 
 	r.Equal("consumer", md.SqsQueueName)
 	r.Equal("endpoint", md.Endpoint)
+	r.Equal("sts-endpoint", md.STSEndpoint)
 	r.Equal(pubsub.Single, md.ConcurrencyMode)
 	r.Equal("a", md.AccessKey)
 	r.Equal("s", md.SecretKey)
@@ -185,6 +187,16 @@ func Test_getSnsSqsMetatdata_invalidMetadataSetup(t *testing.T) {
 			}}},
 			name: "deadletters message queue without deadletters receive limit",
 		},
+		{
+			metadata: pubsub.Metadata{Base: metadata.Base{Properties: map[string]string{
+				"consumerID":              "consumer",
+				"Region":                  "region",
+				"sqsDeadLettersQueueName": "my-queue",
+				"messageReceiveLimit":     "9",
+				"sqsOnly":                 "true",
+			}}},
+			name: "deadletters queue in sqs only mode",
+		},
 		{
 			metadata: pubsub.Metadata{Base: metadata.Base{Properties: map[string]string{
 				"consumerID":                "consumer",
@@ -0,0 +1,164 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snssqs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+
+	sqsqueue "github.com/dapr/components-contrib/internal/component/aws/sqs"
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+// In SQS-only mode, each topic is a pre-existing SQS queue, addressed by name, URL or ARN.
+// The component doesn't create any resource and doesn't use SNS: messages are sent to the queue of the topic and received from it.
+
+// getQueueForTopic returns the queue of a topic in SQS-only mode.
+func (s *snsSqs) getQueueForTopic(parentCtx context.Context, topic string) (*sqsQueueInfo, sqsqueue.Queue, error) {
+	queue, err := sqsqueue.ParseQueue(topic)
+	if err != nil {
+		return nil, queue, err
+	}
+	if cachedQueueInfo, ok := s.queues.Load(topic); ok {
+		return cachedQueueInfo.(*sqsQueueInfo), queue, nil
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, s.opsTimeout)
+	url, err := queue.ResolveURL(ctx, s.sqsClient)
+	cancel()
+	if err != nil {
+		return nil, queue, err
+	}
+
+	queueInfo := &sqsQueueInfo{
+		arn: queue.ARN(s.metadata.internalPartition, s.metadata.Region, s.metadata.AccountID),
+		url: url,
+	}
+	s.queues.Store(topic, queueInfo)
+	return queueInfo, queue, nil
+}
+
+// queueARNForTopic returns the ARN of the queue of a topic in SQS-only mode, without looking up the queue.
+func (s *snsSqs) queueARNForTopic(topic string) (string, error) {
+	queue, err := sqsqueue.ParseQueue(topic)
+	if err != nil {
+		return "", err
+	}
+	return queue.ARN(s.metadata.internalPartition, s.metadata.Region, s.metadata.AccountID), nil
+}
+
+func (s *snsSqs) publishToQueue(ctx context.Context, req *pubsub.PublishRequest) error {
+	queueInfo, queue, err := s.getQueueForTopic(ctx, req.Topic)
+	if err != nil {
+		wrappedErr := fmt.Errorf("error getting queue for topic %s: %w", req.Topic, err)
+		s.logger.Error(wrappedErr)
+
+		return wrappedErr
+	}
+
+	sendMessageInput := &sqs.SendMessageInput{
+		MessageBody: aws.String(string(req.Data)),
+		QueueUrl:    aws.String(queueInfo.url),
+	}
+	// FIFO queues must have content-based deduplication enabled, as no deduplication ID is set.
+	if s.metadata.Fifo || queue.IsFifo() {
+		sendMessageInput.MessageGroupId = s.getMessageGroupID(req)
+	}
+
+	start := time.Now()
+	_, err = s.sqsClient.SendMessageWithContext(ctx, sendMessageInput)
+	s.metrics.Published(req.Topic, err, start)
+	if err != nil {
+		wrappedErr := fmt.Errorf("error publishing to topic: %s with queue URL %s: %w", req.Topic, queueInfo.url, err)
+		s.logger.Error(wrappedErr)
+
+		return wrappedErr
+	}
+
+	return nil
+}
+
+func (s *snsSqs) subscribeToQueue(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	queueInfo, _, err := s.getQueueForTopic(ctx, req.Topic)
+	if err != nil {
+		wrappedErr := fmt.Errorf("error getting queue for topic %s: %w", req.Topic, err)
+		s.logger.Error(wrappedErr)
+
+		return wrappedErr
+	}
+
+	// Each topic has its own queue, so it has its own poller, which stops when the subscription is canceled
+	th := topicHandler{
+		topicName: req.Topic,
+		handler:   handler,
+		ctx:       ctx,
+	}
+	subctx, cancel := context.WithCancel(context.Background())
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+		select {
+		case <-ctx.Done():
+		case <-s.closeCh:
+		}
+	}()
+	go func() {
+		defer s.wg.Done()
+		s.consumeQueue(subctx, queueInfo, nil, func(msgCtx context.Context, message *sqs.Message) error {
+			return s.callQueueHandler(msgCtx, message, queueInfo, th)
+		})
+	}()
+
+	return nil
+}
+
+func (s *snsSqs) callQueueHandler(ctx context.Context, message *sqs.Message, queueInfo *sqsQueueInfo, handler topicHandler) error {
+	s.logger.Debugf("Processing SQS message id: %s of topic: %s", aws.StringValue(message.MessageId), handler.topicName)
+
+	// validateMessage already made sure the receive count can be parsed
+	recvCount, _ := s.parseReceiveCount(message)
+	if recvCount > 1 {
+		s.metrics.Retried(handler.topicName)
+	}
+
+	err := handler.handler(handler.ctx, &pubsub.NewMessage{
+		Data:  queueMessageData(aws.StringValue(message.Body)),
+		Topic: handler.topicName,
+	})
+	if err != nil {
+		return fmt.Errorf("error handling message: %w", err)
+	}
+	return s.acknowledgeMessage(ctx, queueInfo.url, message.ReceiptHandle)
+}
+
+// queueMessageData returns the data of a message received from a queue.
+// Queues can be subscribed to SNS topics outside of the component: notifications of SNS topics without raw message delivery are unwrapped.
+func queueMessageData(body string) []byte {
+	var notification struct {
+		Type     string
+		Message  *string
+		TopicArn string
+	}
+	if json.Unmarshal([]byte(body), &notification) == nil &&
+		notification.Type == "Notification" && notification.TopicArn != "" && notification.Message != nil {
+		return []byte(*notification.Message)
+	}
+	return []byte(body)
+}
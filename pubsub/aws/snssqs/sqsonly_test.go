@@ -0,0 +1,63 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snssqs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+func Test_queueARNForTopic(t *testing.T) {
+	ps := snsSqs{
+		logger: logger.NewLogger("SnsSqs unit test"),
+	}
+	md, err := ps.getSnsSqsMetatdata(pubsub.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"consumerID": "c",
+		"region":     "us-east-1",
+		"sqsOnly":    "true",
+	}}})
+	require.NoError(t, err)
+	assert.True(t, md.SqsOnly)
+	md.AccountID = "123456789012"
+	ps.metadata = md
+
+	arn, err := ps.queueARNForTopic("orders")
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:sqs:us-east-1:123456789012:orders", arn)
+
+	arn, err = ps.queueARNForTopic("https://sqs.us-east-1.amazonaws.com/210987654321/orders")
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:sqs:us-east-1:210987654321:orders", arn)
+
+	arn, err = ps.queueARNForTopic("arn:aws:sqs:eu-west-1:210987654321:orders.fifo")
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:sqs:eu-west-1:210987654321:orders.fifo", arn)
+
+	_, err = ps.queueARNForTopic("arn:aws:sns:eu-west-1:210987654321:orders")
+	require.Error(t, err)
+}
+
+func Test_queueMessageData(t *testing.T) {
+	assert.Equal(t, []byte(`{"id":1}`), queueMessageData(`{"id":1}`))
+	assert.Equal(t, []byte("hello"), queueMessageData("hello"))
+	assert.Equal(t, []byte(`{"id":1}`), queueMessageData(`{"Type":"Notification","TopicArn":"arn:aws:sns:us-east-1:123456789012:orders","Message":"{\"id\":1}"}`))
+	// Not an SNS notification
+	assert.Equal(t, []byte(`{"Type":"Notification","Message":"x"}`), queueMessageData(`{"Type":"Notification","Message":"x"}`))
+}
@@ -36,9 +36,9 @@ type AzureEventHubs struct {
 
 // NewAzureEventHubs returns a new Azure Event hubs instance.
 func NewAzureEventHubs(logger logger.Logger) pubsub.PubSub {
-	return &AzureEventHubs{
+	return pubsub.WithSchemaValidation(&AzureEventHubs{
 		AzureEventHubs: impl.NewAzureEventHubs(logger, false),
-	}
+	})
 }
 
 // Init the object.
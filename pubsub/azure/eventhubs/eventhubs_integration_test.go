@@ -68,7 +68,7 @@ func createIotHubPubsubMetadata() pubsub.Metadata {
 func testReadIotHubEvents(t *testing.T) {
 	logger := kitLogger.NewLogger("pubsub.azure.eventhubs.integration.test")
 	logger.SetOutputLevel(kitLogger.DebugLevel)
-	eh := NewAzureEventHubs(logger).(*AzureEventHubs)
+	eh, _ := pubsub.As[*AzureEventHubs](NewAzureEventHubs(logger))
 	err := eh.Init(context.Background(), createIotHubPubsubMetadata())
 	assert.NoError(t, err)
 
@@ -41,27 +41,38 @@ type azureServiceBus struct {
 	closed   atomic.Bool
 	closeCh  chan struct{}
 	wg       sync.WaitGroup
+	metrics  pubsub.Metrics
+
+	subscriptions pubsub.SubscriptionTracker
 }
 
 // NewAzureServiceBusQueues returns a new implementation.
 func NewAzureServiceBusQueues(logger logger.Logger) pubsub.PubSub {
-	return &azureServiceBus{
+	return pubsub.WithSchemaValidation(&azureServiceBus{
 		logger:  logger,
 		closeCh: make(chan struct{}),
-	}
+	})
 }
 
-func (a *azureServiceBus) Init(_ context.Context, metadata pubsub.Metadata) (err error) {
+func (a *azureServiceBus) Init(ctx context.Context, metadata pubsub.Metadata) (err error) {
 	a.metadata, err = impl.ParseMetadata(metadata.Properties, a.logger, impl.MetadataModeQueues)
 	if err != nil {
 		return err
 	}
 
-	a.client, err = impl.NewClient(a.metadata, metadata.Properties)
+	a.client, err = impl.NewClient(a.metadata, metadata.Properties, a.logger)
 	if err != nil {
 		return err
 	}
 
+	if a.metadata.PreflightEnabled() {
+		err = a.client.PreflightQueues(ctx)
+		if err != nil {
+			a.client.Close(a.logger)
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -70,7 +81,18 @@ func (a *azureServiceBus) Publish(ctx context.Context, req *pubsub.PublishReques
 		return errors.New("component is closed")
 	}
 
-	return a.client.PublishPubSub(ctx, req, a.client.EnsureQueue, a.logger)
+	requiresSession, err := a.client.QueueRequiresSession(ctx, req.Topic)
+	if err != nil {
+		return err
+	}
+	if err = impl.ValidateSessionMetadata(req.Topic, requiresSession, req.Metadata); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err = a.client.PublishPubSub(ctx, req, a.client.EnsureQueue, a.logger)
+	a.metrics.Published(req.Topic, err, start)
+	return err
 }
 
 func (a *azureServiceBus) BulkPublish(ctx context.Context, req *pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
@@ -78,7 +100,18 @@ func (a *azureServiceBus) BulkPublish(ctx context.Context, req *pubsub.BulkPubli
 		return pubsub.BulkPublishResponse{}, errors.New("component is closed")
 	}
 
-	return a.client.PublishPubSubBulk(ctx, req, a.client.EnsureQueue, a.logger)
+	requiresSession, err := a.client.QueueRequiresSession(ctx, req.Topic)
+	if err != nil {
+		return pubsub.NewBulkPublishResponse(req.Entries, err), err
+	}
+	if err = impl.ValidateBulkSessionMetadata(requiresSession, req); err != nil {
+		return pubsub.NewBulkPublishResponse(req.Entries, err), err
+	}
+
+	start := time.Now()
+	res, err := a.client.PublishPubSubBulk(ctx, req, a.client.EnsureQueue, a.logger)
+	a.metrics.BulkPublished(req.Topic, req.Entries, res, start)
+	return res, err
 }
 
 func (a *azureServiceBus) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
@@ -86,21 +119,33 @@ func (a *azureServiceBus) Subscribe(ctx context.Context, req pubsub.SubscribeReq
 		return errors.New("component is closed")
 	}
 
+	dlOpts, err := impl.ParseDeadLetterOptions(req.Topic, req.Metadata)
+	if err != nil {
+		return err
+	}
+	handlerSettings, err := a.metadata.HandlerSettings(req.Metadata)
+	if err != nil {
+		return err
+	}
+
 	sub := impl.NewSubscription(
 		impl.SubscriptionOptions{
 			MaxActiveMessages:     a.metadata.MaxActiveMessages,
 			TimeoutInSec:          a.metadata.TimeoutInSec,
 			MaxBulkSubCount:       nil,
 			MaxRetriableEPS:       a.metadata.MaxRetriableErrorsPerSec,
-			MaxConcurrentHandlers: a.metadata.MaxConcurrentHandlers,
+			MaxConcurrentHandlers: handlerSettings.MaxConcurrentHandlers,
 			Entity:                "queue " + req.Topic,
 			LockRenewalInSec:      a.metadata.LockRenewalInSec,
 			RequireSessions:       false,
+			MaxDeliveryCount:      dlOpts.DeadLetterThreshold(),
+			Metrics:               &a.metrics,
+			MetricsTopic:          req.Topic,
 		},
 		a.logger,
 	)
 
-	return a.doSubscribe(ctx, req, sub, impl.GetPubSubHandlerFunc(req.Topic, handler, a.logger, time.Duration(a.metadata.HandlerTimeoutInSec)*time.Second))
+	return a.doSubscribe(ctx, req, sub, impl.GetPubSubHandlerFunc(req.Topic, a.metrics.InstrumentHandler(handler), a.logger, handlerSettings.HandlerTimeout), dlOpts)
 }
 
 func (a *azureServiceBus) BulkSubscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.BulkHandler) error {
@@ -108,6 +153,15 @@ func (a *azureServiceBus) BulkSubscribe(ctx context.Context, req pubsub.Subscrib
 		return errors.New("component is closed")
 	}
 
+	dlOpts, err := impl.ParseDeadLetterOptions(req.Topic, req.Metadata)
+	if err != nil {
+		return err
+	}
+	handlerSettings, err := a.metadata.HandlerSettings(req.Metadata)
+	if err != nil {
+		return err
+	}
+
 	maxBulkSubCount := utils.GetIntValOrDefault(req.BulkSubscribeConfig.MaxMessagesCount, defaultMaxBulkSubCount)
 	sub := impl.NewSubscription(
 		impl.SubscriptionOptions{
@@ -115,15 +169,18 @@ func (a *azureServiceBus) BulkSubscribe(ctx context.Context, req pubsub.Subscrib
 			TimeoutInSec:          a.metadata.TimeoutInSec,
 			MaxBulkSubCount:       &maxBulkSubCount,
 			MaxRetriableEPS:       a.metadata.MaxRetriableErrorsPerSec,
-			MaxConcurrentHandlers: a.metadata.MaxConcurrentHandlers,
+			MaxConcurrentHandlers: handlerSettings.MaxConcurrentHandlers,
 			Entity:                "queue " + req.Topic,
 			LockRenewalInSec:      a.metadata.LockRenewalInSec,
 			RequireSessions:       false,
+			MaxDeliveryCount:      dlOpts.DeadLetterThreshold(),
+			Metrics:               &a.metrics,
+			MetricsTopic:          req.Topic,
 		},
 		a.logger,
 	)
 
-	return a.doSubscribe(ctx, req, sub, impl.GetBulkPubSubHandlerFunc(req.Topic, handler, a.logger, time.Duration(a.metadata.HandlerTimeoutInSec)*time.Second))
+	return a.doSubscribe(ctx, req, sub, impl.GetBulkPubSubHandlerFunc(req.Topic, a.metrics.InstrumentBulkHandler(handler), a.logger, handlerSettings.HandlerTimeout), dlOpts)
 }
 
 // doSubscribe is a helper function that handles the common logic for both Subscribe and BulkSubscribe.
@@ -133,6 +190,7 @@ func (a *azureServiceBus) doSubscribe(
 	req pubsub.SubscribeRequest,
 	sub *impl.Subscription,
 	handlerFn impl.HandlerFn,
+	dlOpts impl.DeadLetterOptions,
 ) error {
 	subscribeCtx, cancel := context.WithCancel(parentCtx)
 	a.wg.Add(1)
@@ -146,17 +204,25 @@ func (a *azureServiceBus) doSubscribe(
 	}()
 
 	// Does nothing if DisableEntityManagement is true
-	err := a.client.EnsureQueue(subscribeCtx, req.Topic)
+	err := a.client.EnsureQueueWithOptions(subscribeCtx, dlOpts.Entity, impl.SubscribeOptions{
+		MaxDeliveryCount:              dlOpts.MaxDeliveryCount,
+		ForwardDeadLetteredMessagesTo: dlOpts.ForwardTo,
+	})
 	if err != nil {
 		return err
 	}
 
+	// The receiver is connected in the background, so the subscription is pending until Connect succeeds
+	tracked := a.subscriptions.Track(req.Topic)
+	sub.ReportReadiness(tracked)
+
 	// Reconnection backoff policy
 	bo := a.client.ReconnectionBackoff()
 
 	a.wg.Add(1)
 	go func() {
 		defer a.wg.Done()
+		defer tracked.Close()
 
 		logMsg := fmt.Sprintf("subscription %s to queue %s", a.metadata.ConsumerID, req.Topic)
 
@@ -165,7 +231,7 @@ func (a *azureServiceBus) doSubscribe(
 			// Blocks until a successful connection (or until context is canceled)
 			receiver, err := sub.Connect(subscribeCtx, func() (impl.Receiver, error) {
 				a.logger.Debug("Connecting to " + logMsg)
-				r, rErr := a.client.GetClient().NewReceiverForQueue(req.Topic, nil)
+				r, rErr := a.client.GetClient().NewReceiverForQueue(dlOpts.Entity, dlOpts.ReceiverOptions())
 				if rErr != nil {
 					return nil, rErr
 				}
@@ -207,6 +273,15 @@ func (a *azureServiceBus) doSubscribe(
 	return nil
 }
 
+// Ping checks the connection to the Service Bus namespace.
+// This is a no-op when entity management is disabled, as the component doesn't know which entities it can access before publishing or subscribing.
+func (a *azureServiceBus) Ping(ctx context.Context) error {
+	if a.client == nil {
+		return errors.New("component is not initialized")
+	}
+	return a.client.Ping(ctx, "")
+}
+
 func (a *azureServiceBus) Close() (err error) {
 	defer a.wg.Wait()
 
@@ -219,10 +294,26 @@ func (a *azureServiceBus) Close() (err error) {
 	return nil
 }
 
+// SetMetricsHook sets the hook that receives the metrics of the component.
+func (a *azureServiceBus) SetMetricsHook(hook pubsub.MetricsHook) {
+	a.metrics.SetMetricsHook(hook)
+}
+
+// WaitSubscriptionsReady blocks until the subscriptions are connected to Service Bus, or until ctx is done.
+func (a *azureServiceBus) WaitSubscriptionsReady(ctx context.Context) error {
+	return a.subscriptions.WaitSubscriptionsReady(ctx)
+}
+
+// SubscriptionsStatus returns the status of the subscriptions of the component.
+func (a *azureServiceBus) SubscriptionsStatus() []pubsub.SubscriptionStatus {
+	return a.subscriptions.SubscriptionsStatus()
+}
+
 func (a *azureServiceBus) Features() []pubsub.Feature {
 	return []pubsub.Feature{
 		pubsub.FeatureMessageTTL,
 		pubsub.FeatureBulkPublish,
+		pubsub.FeatureDelayedDelivery,
 	}
 }
 
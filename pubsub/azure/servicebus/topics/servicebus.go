@@ -22,6 +22,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	servicebus "github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+
 	impl "github.com/dapr/components-contrib/internal/component/azure/servicebus"
 	"github.com/dapr/components-contrib/internal/utils"
 	"github.com/dapr/components-contrib/metadata"
@@ -41,27 +43,38 @@ type azureServiceBus struct {
 	closed   atomic.Bool
 	closeCh  chan struct{}
 	wg       sync.WaitGroup
+	metrics  pubsub.Metrics
+
+	subscriptions pubsub.SubscriptionTracker
 }
 
 // NewAzureServiceBusTopics returns a new pub-sub implementation.
 func NewAzureServiceBusTopics(logger logger.Logger) pubsub.PubSub {
-	return &azureServiceBus{
+	return pubsub.WithSchemaValidation(&azureServiceBus{
 		logger:  logger,
 		closeCh: make(chan struct{}),
-	}
+	})
 }
 
-func (a *azureServiceBus) Init(_ context.Context, metadata pubsub.Metadata) (err error) {
+func (a *azureServiceBus) Init(ctx context.Context, metadata pubsub.Metadata) (err error) {
 	a.metadata, err = impl.ParseMetadata(metadata.Properties, a.logger, impl.MetadataModeTopics)
 	if err != nil {
 		return err
 	}
 
-	a.client, err = impl.NewClient(a.metadata, metadata.Properties)
+	a.client, err = impl.NewClient(a.metadata, metadata.Properties, a.logger)
 	if err != nil {
 		return err
 	}
 
+	if a.metadata.PreflightEnabled() {
+		err = a.client.PreflightTopics(ctx)
+		if err != nil {
+			a.client.Close(a.logger)
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -69,14 +82,38 @@ func (a *azureServiceBus) Publish(ctx context.Context, req *pubsub.PublishReques
 	if a.closed.Load() {
 		return errors.New("component is closed")
 	}
-	return a.client.PublishPubSub(ctx, req, a.client.EnsureTopic, a.logger)
+
+	requiresSession, err := a.client.TopicRequiresSession(ctx, req.Topic)
+	if err != nil {
+		return err
+	}
+	if err = impl.ValidateSessionMetadata(req.Topic, requiresSession, req.Metadata); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err = a.client.PublishPubSub(ctx, req, a.client.EnsureTopic, a.logger)
+	a.metrics.Published(req.Topic, err, start)
+	return err
 }
 
 func (a *azureServiceBus) BulkPublish(ctx context.Context, req *pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
 	if a.closed.Load() {
 		return pubsub.BulkPublishResponse{}, errors.New("component is closed")
 	}
-	return a.client.PublishPubSubBulk(ctx, req, a.client.EnsureTopic, a.logger)
+
+	requiresSession, err := a.client.TopicRequiresSession(ctx, req.Topic)
+	if err != nil {
+		return pubsub.NewBulkPublishResponse(req.Entries, err), err
+	}
+	if err = impl.ValidateBulkSessionMetadata(requiresSession, req); err != nil {
+		return pubsub.NewBulkPublishResponse(req.Entries, err), err
+	}
+
+	start := time.Now()
+	res, err := a.client.PublishPubSubBulk(ctx, req, a.client.EnsureTopic, a.logger)
+	a.metrics.BulkPublished(req.Topic, req.Entries, res, start)
+	return res, err
 }
 
 func (a *azureServiceBus) Subscribe(subscribeCtx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
@@ -84,9 +121,25 @@ func (a *azureServiceBus) Subscribe(subscribeCtx context.Context, req pubsub.Sub
 		return errors.New("component is closed")
 	}
 
-	requireSessions := utils.IsTruthy(req.Metadata[impl.RequireSessionsMetadataKey])
-	sessionIdleTimeout := time.Duration(utils.GetElemOrDefaultFromMap(req.Metadata, impl.SessionIdleTimeoutMetadataKey, impl.DefaultSesssionIdleTimeoutInSec)) * time.Second
-	maxConcurrentSessions := utils.GetElemOrDefaultFromMap(req.Metadata, impl.MaxConcurrentSessionsMetadataKey, impl.DefaultMaxConcurrentSessions)
+	sessions, err := impl.ParseSessionSettings(req.Metadata)
+	if err != nil {
+		return err
+	}
+	dlOpts, err := impl.ParseDeadLetterOptions(req.Topic, req.Metadata)
+	if err != nil {
+		return err
+	}
+	handlerSettings, err := a.metadata.HandlerSettings(req.Metadata)
+	if err != nil {
+		return err
+	}
+	if dlOpts.ReceiveDeadLetters && sessions.RequireSessions {
+		return errors.New("sessions are not supported when receiving from the dead-letter queue")
+	}
+	rules, err := impl.ParseSubscriptionRules(req.Metadata)
+	if err != nil {
+		return err
+	}
 
 	sub := impl.NewSubscription(
 		impl.SubscriptionOptions{
@@ -94,20 +147,26 @@ func (a *azureServiceBus) Subscribe(subscribeCtx context.Context, req pubsub.Sub
 			TimeoutInSec:          a.metadata.TimeoutInSec,
 			MaxBulkSubCount:       nil,
 			MaxRetriableEPS:       a.metadata.MaxRetriableErrorsPerSec,
-			MaxConcurrentHandlers: a.metadata.MaxConcurrentHandlers,
+			MaxConcurrentHandlers: handlerSettings.MaxConcurrentHandlers,
 			Entity:                "topic " + req.Topic,
 			LockRenewalInSec:      a.metadata.LockRenewalInSec,
-			RequireSessions:       requireSessions,
-			SessionIdleTimeout:    sessionIdleTimeout,
+			RequireSessions:       sessions.RequireSessions,
+			SessionIdleTimeout:    sessions.SessionIdleTimeout,
+			MaxDeliveryCount:      dlOpts.DeadLetterThreshold(),
+			Metrics:               &a.metrics,
+			MetricsTopic:          req.Topic,
 		},
 		a.logger,
 	)
 
-	handlerFn := impl.GetPubSubHandlerFunc(req.Topic, handler, a.logger, time.Duration(a.metadata.HandlerTimeoutInSec)*time.Second)
+	handlerFn := impl.GetPubSubHandlerFunc(req.Topic, a.metrics.InstrumentHandler(handler), a.logger, handlerSettings.HandlerTimeout)
 	return a.doSubscribe(subscribeCtx, req, sub, handlerFn, impl.SubscribeOptions{
-		RequireSessions:      requireSessions,
-		MaxConcurrentSesions: maxConcurrentSessions,
-	})
+		RequireSessions:               sessions.RequireSessions,
+		MaxConcurrentSesions:          sessions.MaxConcurrentSessions,
+		MaxDeliveryCount:              dlOpts.MaxDeliveryCount,
+		ForwardDeadLetteredMessagesTo: dlOpts.ForwardTo,
+		Rules:                         rules,
+	}, dlOpts)
 }
 
 func (a *azureServiceBus) BulkSubscribe(subscribeCtx context.Context, req pubsub.SubscribeRequest, handler pubsub.BulkHandler) error {
@@ -115,9 +174,25 @@ func (a *azureServiceBus) BulkSubscribe(subscribeCtx context.Context, req pubsub
 		return errors.New("component is closed")
 	}
 
-	requireSessions := utils.IsTruthy(req.Metadata[impl.RequireSessionsMetadataKey])
-	sessionIdleTimeout := time.Duration(utils.GetElemOrDefaultFromMap(req.Metadata, impl.SessionIdleTimeoutMetadataKey, impl.DefaultSesssionIdleTimeoutInSec)) * time.Second
-	maxConcurrentSessions := utils.GetElemOrDefaultFromMap(req.Metadata, impl.MaxConcurrentSessionsMetadataKey, impl.DefaultMaxConcurrentSessions)
+	sessions, err := impl.ParseSessionSettings(req.Metadata)
+	if err != nil {
+		return err
+	}
+	dlOpts, err := impl.ParseDeadLetterOptions(req.Topic, req.Metadata)
+	if err != nil {
+		return err
+	}
+	handlerSettings, err := a.metadata.HandlerSettings(req.Metadata)
+	if err != nil {
+		return err
+	}
+	if dlOpts.ReceiveDeadLetters && sessions.RequireSessions {
+		return errors.New("sessions are not supported when receiving from the dead-letter queue")
+	}
+	rules, err := impl.ParseSubscriptionRules(req.Metadata)
+	if err != nil {
+		return err
+	}
 
 	maxBulkSubCount := utils.GetIntValOrDefault(req.BulkSubscribeConfig.MaxMessagesCount, defaultMaxBulkSubCount)
 	sub := impl.NewSubscription(
@@ -126,20 +201,26 @@ func (a *azureServiceBus) BulkSubscribe(subscribeCtx context.Context, req pubsub
 			TimeoutInSec:          a.metadata.TimeoutInSec,
 			MaxBulkSubCount:       &maxBulkSubCount,
 			MaxRetriableEPS:       a.metadata.MaxRetriableErrorsPerSec,
-			MaxConcurrentHandlers: a.metadata.MaxConcurrentHandlers,
+			MaxConcurrentHandlers: handlerSettings.MaxConcurrentHandlers,
 			Entity:                "topic " + req.Topic,
 			LockRenewalInSec:      a.metadata.LockRenewalInSec,
-			RequireSessions:       requireSessions,
-			SessionIdleTimeout:    sessionIdleTimeout,
+			RequireSessions:       sessions.RequireSessions,
+			SessionIdleTimeout:    sessions.SessionIdleTimeout,
+			MaxDeliveryCount:      dlOpts.DeadLetterThreshold(),
+			Metrics:               &a.metrics,
+			MetricsTopic:          req.Topic,
 		},
 		a.logger,
 	)
 
-	handlerFn := impl.GetBulkPubSubHandlerFunc(req.Topic, handler, a.logger, time.Duration(a.metadata.HandlerTimeoutInSec)*time.Second)
+	handlerFn := impl.GetBulkPubSubHandlerFunc(req.Topic, a.metrics.InstrumentBulkHandler(handler), a.logger, handlerSettings.HandlerTimeout)
 	return a.doSubscribe(subscribeCtx, req, sub, handlerFn, impl.SubscribeOptions{
-		RequireSessions:      requireSessions,
-		MaxConcurrentSesions: maxConcurrentSessions,
-	})
+		RequireSessions:               sessions.RequireSessions,
+		MaxConcurrentSesions:          sessions.MaxConcurrentSessions,
+		MaxDeliveryCount:              dlOpts.MaxDeliveryCount,
+		ForwardDeadLetteredMessagesTo: dlOpts.ForwardTo,
+		Rules:                         rules,
+	}, dlOpts)
 }
 
 // doSubscribe is a helper function that handles the common logic for both Subscribe and BulkSubscribe.
@@ -150,6 +231,7 @@ func (a *azureServiceBus) doSubscribe(
 	sub *impl.Subscription,
 	handlerFn impl.HandlerFn,
 	opts impl.SubscribeOptions,
+	dlOpts impl.DeadLetterOptions,
 ) error {
 	subscribeCtx, cancel := context.WithCancel(parentCtx)
 	a.wg.Add(1)
@@ -163,25 +245,33 @@ func (a *azureServiceBus) doSubscribe(
 	}()
 
 	// Does nothing if DisableEntityManagement is true
-	err := a.client.EnsureSubscription(subscribeCtx, a.metadata.ConsumerID, req.Topic, opts)
+	err := a.client.EnsureSubscription(subscribeCtx, a.metadata.ConsumerID, dlOpts.Entity, opts)
 	if err != nil {
 		return err
 	}
 
+	// The receiver is connected in the background, so the subscription is pending until Connect succeeds
+	tracked := a.subscriptions.Track(req.Topic)
+	sub.ReportReadiness(tracked)
+
 	// Reconnection backoff policy
 	bo := a.client.ReconnectionBackoff()
 
 	a.wg.Add(1)
 	go func() {
 		defer a.wg.Done()
+		defer tracked.Close()
 
 		// Reconnect loop.
 		for {
 			// Reset the backoff when the subscription is successful and we have received the first message
 			if opts.RequireSessions {
-				a.connectAndReceiveWithSessions(subscribeCtx, req, sub, handlerFn, bo.Reset, opts.MaxConcurrentSesions)
+				logMsg := fmt.Sprintf("subscription %s to topic %s", a.metadata.ConsumerID, req.Topic)
+				sub.ConnectAndReceiveWithSessions(subscribeCtx, handlerFn, func(acceptCtx context.Context) (*servicebus.SessionReceiver, error) {
+					return a.client.GetClient().AcceptNextSessionForSubscription(acceptCtx, dlOpts.Entity, a.metadata.ConsumerID, nil)
+				}, bo.Reset, opts.MaxConcurrentSesions, logMsg)
 			} else {
-				a.connectAndReceive(subscribeCtx, req, sub, handlerFn, bo.Reset)
+				a.connectAndReceive(subscribeCtx, req, dlOpts, sub, handlerFn, bo.Reset)
 			}
 
 			// If context was canceled, do not attempt to reconnect
@@ -204,6 +294,15 @@ func (a *azureServiceBus) doSubscribe(
 	return nil
 }
 
+// Ping checks the connection to the Service Bus namespace.
+// This is a no-op when entity management is disabled, as the component doesn't know which entities it can access before publishing or subscribing.
+func (a *azureServiceBus) Ping(ctx context.Context) error {
+	if a.client == nil {
+		return errors.New("component is not initialized")
+	}
+	return a.client.Ping(ctx, "")
+}
+
 func (a *azureServiceBus) Close() (err error) {
 	defer a.wg.Wait()
 	if !a.closed.CompareAndSwap(false, true) {
@@ -216,20 +315,36 @@ func (a *azureServiceBus) Close() (err error) {
 	return nil
 }
 
+// SetMetricsHook sets the hook that receives the metrics of the component.
+func (a *azureServiceBus) SetMetricsHook(hook pubsub.MetricsHook) {
+	a.metrics.SetMetricsHook(hook)
+}
+
+// WaitSubscriptionsReady blocks until the subscriptions are connected to Service Bus, or until ctx is done.
+func (a *azureServiceBus) WaitSubscriptionsReady(ctx context.Context) error {
+	return a.subscriptions.WaitSubscriptionsReady(ctx)
+}
+
+// SubscriptionsStatus returns the status of the subscriptions of the component.
+func (a *azureServiceBus) SubscriptionsStatus() []pubsub.SubscriptionStatus {
+	return a.subscriptions.SubscriptionsStatus()
+}
+
 func (a *azureServiceBus) Features() []pubsub.Feature {
 	return []pubsub.Feature{
 		pubsub.FeatureMessageTTL,
 		pubsub.FeatureBulkPublish,
+		pubsub.FeatureDelayedDelivery,
 	}
 }
 
-func (a *azureServiceBus) connectAndReceive(ctx context.Context, req pubsub.SubscribeRequest, sub *impl.Subscription, handlerFn impl.HandlerFn, onFirstSuccess func()) {
+func (a *azureServiceBus) connectAndReceive(ctx context.Context, req pubsub.SubscribeRequest, dlOpts impl.DeadLetterOptions, sub *impl.Subscription, handlerFn impl.HandlerFn, onFirstSuccess func()) {
 	logMsg := fmt.Sprintf("subscription %s to topic %s", a.metadata.ConsumerID, req.Topic)
 
 	// Blocks until a successful connection (or until context is canceled)
 	receiver, err := sub.Connect(ctx, func() (impl.Receiver, error) {
 		a.logger.Debug("Connecting to " + logMsg)
-		r, rErr := a.client.GetClient().NewReceiverForSubscription(req.Topic, a.metadata.ConsumerID, nil)
+		r, rErr := a.client.GetClient().NewReceiverForSubscription(dlOpts.Entity, a.metadata.ConsumerID, dlOpts.ReceiverOptions())
 		if rErr != nil {
 			return nil, rErr
 		}
@@ -253,69 +368,6 @@ func (a *azureServiceBus) connectAndReceive(ctx context.Context, req pubsub.Subs
 	}
 }
 
-func (a *azureServiceBus) connectAndReceiveWithSessions(ctx context.Context, req pubsub.SubscribeRequest, sub *impl.Subscription, handlerFn impl.HandlerFn, onFirstSuccess func(), maxConcurrentSessions int) {
-	sessionsChan := make(chan struct{}, maxConcurrentSessions)
-	for i := 0; i < maxConcurrentSessions; i++ {
-		sessionsChan <- struct{}{}
-	}
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-sessionsChan:
-			// nop - continue
-		}
-
-		// Check again if the context was canceled
-		if ctx.Err() != nil {
-			return
-		}
-
-		acceptCtx, acceptCancel := context.WithCancel(ctx)
-
-		// Blocks until a successful connection (or until context is canceled)
-		receiver, err := sub.Connect(ctx, func() (impl.Receiver, error) {
-			a.logger.Debugf("Accepting next available session subscription %s to topic %s", a.metadata.ConsumerID, req.Topic)
-			r, rErr := a.client.GetClient().AcceptNextSessionForSubscription(acceptCtx, req.Topic, a.metadata.ConsumerID, nil)
-			if rErr != nil {
-				return nil, rErr
-			}
-			return impl.NewSessionReceiver(r), nil
-		})
-		acceptCancel()
-		if err != nil {
-			// Realistically, the only time we should get to this point is if the context was canceled, but let's log any other error we may get.
-			if !errors.Is(err, context.Canceled) {
-				a.logger.Errorf("Could not instantiate session subscription %s to topic %s", a.metadata.ConsumerID, req.Topic)
-			}
-			return
-		}
-
-		// Receive messages for the session in a goroutine
-		a.wg.Add(1)
-		go func() {
-			defer a.wg.Done()
-
-			logMsg := fmt.Sprintf("session %s for subscription %s to topic %s", receiver.(*impl.SessionReceiver).SessionID(), a.metadata.ConsumerID, req.Topic)
-
-			defer func() {
-				// Return the session to the pool
-				sessionsChan <- struct{}{}
-			}()
-
-			a.logger.Debug("Receiving messages for " + logMsg)
-
-			// ReceiveBlocking will only return with an error that it cannot handle internally. The subscription connection is closed when this method returns.
-			// If that occurs, we will log the error and attempt to re-establish the subscription connection until we exhaust the number of reconnect attempts.
-			err = sub.ReceiveBlocking(ctx, handlerFn, receiver, onFirstSuccess, logMsg)
-			if err != nil && !errors.Is(err, context.Canceled) {
-				a.logger.Error(err)
-			}
-		}()
-	}
-}
-
 // GetComponentMetadata returns the metadata of the component.
 func (a *azureServiceBus) GetComponentMetadata() map[string]string {
 	metadataStruct := impl.Metadata{}
@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+)
+
+const (
+	// CompressionKey is the component metadata key for the algorithm used to compress the payloads of outgoing messages.
+	CompressionKey = "compression"
+	// ContentEncodingKey is the name of the property of the messages that contains the algorithm their payload is compressed with.
+	// It is specific to Dapr so that encodings set by other producers, such as "utf-8", are not mistaken for a compression algorithm.
+	ContentEncodingKey = "dapr-content-encoding"
+	// MaxDecompressedSize is the maximum size, in bytes, of a decompressed payload.
+	MaxDecompressedSize = 64 << 20
+)
+
+// ErrDecompressedTooLarge is returned when a payload is larger than MaxDecompressedSize once decompressed.
+var ErrDecompressedTooLarge = fmt.Errorf("decompressed payload is larger than %d bytes", MaxDecompressedSize)
+
+// Compression is an algorithm used to compress the payloads of messages.
+type Compression string
+
+// Supported compression algorithms.
+const (
+	CompressionNone   Compression = ""
+	CompressionGzip   Compression = "gzip"
+	CompressionZstd   Compression = "zstd"
+	CompressionSnappy Compression = "snappy"
+)
+
+// Encoders and decoders for zstd are safe for concurrent use when using EncodeAll and DecodeAll.
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(MaxDecompressedSize))
+)
+
+// ParseCompression returns the compression algorithm from the component metadata.
+// Payloads are not compressed if the metadata key is not set or is "none".
+func ParseCompression(props map[string]string) (Compression, error) {
+	val, _ := contribMetadata.GetMetadataProperty(props, CompressionKey)
+	val = strings.ToLower(strings.TrimSpace(val))
+	switch Compression(val) {
+	case CompressionNone, "none":
+		return CompressionNone, nil
+	case CompressionGzip, CompressionZstd, CompressionSnappy:
+		return Compression(val), nil
+	default:
+		return CompressionNone, fmt.Errorf("invalid %s '%s': must be one of 'none', 'gzip', 'zstd' or 'snappy'", CompressionKey, val)
+	}
+}
+
+// Compress compresses the data.
+// It returns the data unchanged if c is CompressionNone.
+func (c Compression) Compress(data []byte) ([]byte, error) {
+	switch c {
+	case CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write(data)
+		if err != nil {
+			return nil, err
+		}
+		err = w.Close()
+		if err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionZstd:
+		return zstdEncoder.EncodeAll(data, make([]byte, 0, len(data))), nil
+	case CompressionSnappy:
+		return snappy.Encode(nil, data), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression '%s'", c)
+	}
+}
+
+// Decompress decompresses data that was compressed with the algorithm in contentEncoding, which is read from the properties of a message.
+// It returns the data unchanged if contentEncoding is not one of the supported compression algorithms.
+// Decompressed payloads larger than MaxDecompressedSize are rejected with ErrDecompressedTooLarge.
+func Decompress(contentEncoding string, data []byte) ([]byte, error) {
+	switch c := Compression(strings.ToLower(contentEncoding)); c {
+	case CompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress gzip payload: %w", err)
+		}
+		defer r.Close()
+		res, err := io.ReadAll(io.LimitReader(r, MaxDecompressedSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress gzip payload: %w", err)
+		}
+		if len(res) > MaxDecompressedSize {
+			return nil, ErrDecompressedTooLarge
+		}
+		return res, nil
+	case CompressionZstd:
+		res, err := zstdDecoder.DecodeAll(data, nil)
+		if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+			return nil, ErrDecompressedTooLarge
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decompress zstd payload: %w", err)
+		}
+		return res, nil
+	case CompressionSnappy:
+		n, err := snappy.DecodedLen(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress snappy payload: %w", err)
+		}
+		if n > MaxDecompressedSize {
+			return nil, ErrDecompressedTooLarge
+		}
+		res, err := snappy.Decode(nil, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress snappy payload: %w", err)
+		}
+		return res, nil
+	default:
+		return data, nil
+	}
+}
+
+// DecompressMessage decompresses the data of a message received from a broker if its metadata contains a content encoding.
+// The content encoding is removed from the metadata after the data is decompressed.
+func DecompressMessage(data []byte, metadata map[string]string) ([]byte, error) {
+	contentEncoding, ok := metadata[ContentEncodingKey]
+	if !ok {
+		return data, nil
+	}
+	res, err := Decompress(contentEncoding, data)
+	if err != nil {
+		return nil, err
+	}
+	delete(metadata, ContentEncodingKey)
+	return res, nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCompression(t *testing.T) {
+	tests := map[string]struct {
+		value   string
+		want    Compression
+		wantErr bool
+	}{
+		"not set":     {value: "", want: CompressionNone},
+		"none":        {value: "none", want: CompressionNone},
+		"gzip":        {value: "gzip", want: CompressionGzip},
+		"zstd":        {value: "ZSTD", want: CompressionZstd},
+		"snappy":      {value: " snappy ", want: CompressionSnappy},
+		"unsupported": {value: "lz4", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseCompression(map[string]string{CompressionKey: tc.value})
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestCompressDecompress(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"orderId":"1234","status":"shipped"}`), 100)
+
+	for _, c := range []Compression{CompressionGzip, CompressionZstd, CompressionSnappy} {
+		t.Run(string(c), func(t *testing.T) {
+			compressed, err := c.Compress(data)
+			require.NoError(t, err)
+			assert.Less(t, len(compressed), len(data))
+
+			decompressed, err := Decompress(string(c), compressed)
+			require.NoError(t, err)
+			assert.Equal(t, data, decompressed)
+
+			_, err = Decompress(string(c), []byte("not compressed"))
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("none", func(t *testing.T) {
+		compressed, err := CompressionNone.Compress(data)
+		require.NoError(t, err)
+		assert.Equal(t, data, compressed)
+
+		decompressed, err := Decompress("", data)
+		require.NoError(t, err)
+		assert.Equal(t, data, decompressed)
+
+		decompressed, err = Decompress("identity", data)
+		require.NoError(t, err)
+		assert.Equal(t, data, decompressed)
+	})
+
+	t.Run("unknown content encodings are passed through", func(t *testing.T) {
+		for _, contentEncoding := range []string{"utf-8", "binary", "br"} {
+			decompressed, err := Decompress(contentEncoding, data)
+			require.NoError(t, err)
+			assert.Equal(t, data, decompressed)
+		}
+	})
+
+	t.Run("decompressed size is limited", func(t *testing.T) {
+		large := make([]byte, MaxDecompressedSize+1)
+		for _, c := range []Compression{CompressionGzip, CompressionZstd, CompressionSnappy} {
+			compressed, err := c.Compress(large)
+			require.NoError(t, err)
+
+			_, err = Decompress(string(c), compressed)
+			require.ErrorIs(t, err, ErrDecompressedTooLarge, string(c))
+		}
+	})
+}
+
+func TestDecompressMessage(t *testing.T) {
+	compressed, err := CompressionZstd.Compress([]byte("hello"))
+	require.NoError(t, err)
+
+	metadata := map[string]string{
+		ContentEncodingKey: "zstd",
+		"source":           "app",
+	}
+	data, err := DecompressMessage(compressed, metadata)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+	assert.Equal(t, map[string]string{"source": "app"}, metadata)
+
+	data, err = DecompressMessage([]byte("hello"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+}
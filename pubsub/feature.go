@@ -23,6 +23,8 @@ const (
 	// FeatureSubscribeWildcards is the feature to allow subscribing to topics/queues using a wildcard.
 	FeatureSubscribeWildcards Feature = "SUBSCRIBE_WILDCARDS"
 	FeatureBulkPublish        Feature = "BULK_PUBSUB"
+	// FeatureDelayedDelivery is the feature to schedule the delivery of a message at a later time.
+	FeatureDelayedDelivery Feature = "DELAYED_DELIVERY"
 )
 
 // Feature names a feature that can be implemented by PubSub components.
@@ -0,0 +1,259 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	gcppubsub "cloud.google.com/go/pubsub"
+
+	"github.com/dapr/components-contrib/internal/utils"
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultMaxBulkSubCount           = 100
+	defaultMaxBulkSubAwaitDurationMs = 1000
+)
+
+// BulkPublish publishes multiple messages to the topic, batching them in as few publish requests as possible.
+func (g *GCPPubSub) BulkPublish(ctx context.Context, req *pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+	if g.closed.Load() {
+		return pubsub.BulkPublishResponse{}, errors.New("component is closed")
+	}
+
+	if !g.metadata.DisableEntityManagement {
+		err := g.ensureTopic(ctx, req.Topic)
+		if err != nil {
+			err = fmt.Errorf("%s could not get valid topic %s, %w", errorMessagePrefix, req.Topic, err)
+			return pubsub.NewBulkPublishResponse(req.Entries, err), err
+		}
+	}
+
+	topic := g.getTopic(req.Topic)
+	defer topic.Stop()
+
+	g.applyPublishSettings(topic)
+	// Send all the entries in a single bundle when possible
+	topic.PublishSettings.CountThreshold = len(req.Entries)
+	if topic.PublishSettings.CountThreshold > gcppubsub.MaxPublishRequestCount {
+		topic.PublishSettings.CountThreshold = gcppubsub.MaxPublishRequestCount
+	}
+	topic.EnableMessageOrdering = g.metadata.EnableMessageOrdering
+
+	results := make([]*gcppubsub.PublishResult, len(req.Entries))
+	for i, entry := range req.Entries {
+		msg := &gcppubsub.Message{
+			Data: entry.Event,
+		}
+		if g.metadata.EnableMessageOrdering {
+			msg.OrderingKey = g.orderingKey(req.Metadata, entry.Metadata)
+		}
+		results[i] = topic.Publish(ctx, msg)
+	}
+
+	res := pubsub.BulkPublishResponse{}
+	for i, result := range results {
+		if _, err := result.Get(ctx); err != nil {
+			res.FailedEntries = append(res.FailedEntries, pubsub.BulkPublishResponseFailedEntry{
+				EntryId: req.Entries[i].EntryId,
+				Error:   err,
+			})
+		}
+	}
+	if len(res.FailedEntries) > 0 {
+		return res, fmt.Errorf("%s failed to publish %d of %d messages to topic %s", errorMessagePrefix, len(res.FailedEntries), len(req.Entries), req.Topic)
+	}
+
+	return res, nil
+}
+
+// orderingKey returns the ordering key of a message, giving preference to the metadata of the entry, then of the request, then of the component.
+func (g *GCPPubSub) orderingKey(mds ...map[string]string) string {
+	key := g.metadata.OrderingKey
+	for _, md := range mds {
+		if md[metedataOrderingKeyKey] != "" {
+			key = md[metedataOrderingKeyKey]
+		}
+	}
+	return key
+}
+
+// BulkSubscribe subscribes to the topic and delivers messages to the handler in batches.
+// A batch is delivered when it contains maxMessagesCount messages or when maxAwaitDurationMs elapsed since the last delivery.
+func (g *GCPPubSub) BulkSubscribe(parentCtx context.Context, req pubsub.SubscribeRequest, handler pubsub.BulkHandler) error {
+	if g.closed.Load() {
+		return errors.New("component is closed")
+	}
+
+	if !g.metadata.DisableEntityManagement {
+		topicErr := g.ensureTopic(parentCtx, req.Topic)
+		if topicErr != nil {
+			return fmt.Errorf("%s could not get valid topic - topic:%q, error: %v", errorMessagePrefix, req.Topic, topicErr)
+		}
+
+		subError := g.ensureSubscription(parentCtx, g.metadata.ConsumerID, req.Topic)
+		if subError != nil {
+			return fmt.Errorf("%s could not get valid subscription - consumerID:%q, error: %v", errorMessagePrefix, g.metadata.ConsumerID, subError)
+		}
+	}
+
+	c := newBulkCollector(req.Topic, req.BulkSubscribeConfig, handler, g.logger)
+	sub := g.getSubscription(BuildSubscriptionID(g.metadata.ConsumerID, req.Topic))
+	// Messages count towards flow control until they are acknowledged, so the subscription must be able to hold a full batch
+	g.applyReceiveSettings(sub, c.maxCount)
+
+	subscribeCtx, cancel := context.WithCancel(parentCtx)
+	g.wg.Add(3)
+	go func() {
+		defer g.wg.Done()
+		defer cancel()
+		select {
+		case <-subscribeCtx.Done():
+		case <-g.closeCh:
+		}
+	}()
+	go func() {
+		defer g.wg.Done()
+		c.run(subscribeCtx)
+	}()
+	go func() {
+		defer g.wg.Done()
+		g.handleSubscriptionMessages(subscribeCtx, sub, func(ctx context.Context, m *gcppubsub.Message) {
+			c.add(ctx, m)
+		})
+	}()
+
+	return nil
+}
+
+// ackMessage is implemented by *gcppubsub.Message.
+type ackMessage interface {
+	Ack()
+	Nack()
+}
+
+type bulkItem struct {
+	data       []byte
+	attributes map[string]string
+	msg        ackMessage
+}
+
+// bulkCollector collects received messages and delivers them to a bulk handler in batches.
+type bulkCollector struct {
+	topic    string
+	maxCount int
+	maxAwait time.Duration
+	handler  pubsub.BulkHandler
+	itemCh   chan bulkItem
+	logger   logger.Logger
+}
+
+func newBulkCollector(topic string, cfg pubsub.BulkSubscribeConfig, handler pubsub.BulkHandler, logger logger.Logger) *bulkCollector {
+	maxCount := utils.GetIntValOrDefault(cfg.MaxMessagesCount, defaultMaxBulkSubCount)
+	return &bulkCollector{
+		topic:    topic,
+		maxCount: maxCount,
+		maxAwait: time.Duration(utils.GetIntValOrDefault(cfg.MaxAwaitDurationMs, defaultMaxBulkSubAwaitDurationMs)) * time.Millisecond,
+		handler:  handler,
+		itemCh:   make(chan bulkItem, maxCount),
+		logger:   logger,
+	}
+}
+
+// add queues a received message, which is negatively acknowledged if the subscription is stopped first.
+func (c *bulkCollector) add(ctx context.Context, m *gcppubsub.Message) {
+	select {
+	case c.itemCh <- bulkItem{data: m.Data, attributes: m.Attributes, msg: m}:
+	case <-ctx.Done():
+		m.Nack()
+	}
+}
+
+// run delivers the queued messages in batches until the context is canceled.
+func (c *bulkCollector) run(ctx context.Context) {
+	ticker := time.NewTicker(c.maxAwait)
+	defer ticker.Stop()
+
+	batch := make([]bulkItem, 0, c.maxCount)
+	for {
+		select {
+		case <-ctx.Done():
+			// Messages that were not delivered are redelivered by GCP Pub/Sub
+			for _, item := range batch {
+				item.msg.Nack()
+			}
+			return
+		case item := <-c.itemCh:
+			batch = append(batch, item)
+			if len(batch) >= c.maxCount {
+				c.flush(ctx, batch)
+				batch = batch[:0]
+				ticker.Reset(c.maxAwait)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				c.flush(ctx, batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// flush delivers a batch to the handler, then acknowledges the messages that were processed successfully and negatively acknowledges the others.
+func (c *bulkCollector) flush(ctx context.Context, batch []bulkItem) {
+	entries := make([]pubsub.BulkMessageEntry, len(batch))
+	for i, item := range batch {
+		entries[i] = pubsub.BulkMessageEntry{
+			EntryId:  strconv.Itoa(i),
+			Event:    item.data,
+			Metadata: item.attributes,
+		}
+	}
+
+	responses, err := c.handler(ctx, &pubsub.BulkMessage{
+		Topic:   c.topic,
+		Entries: entries,
+	})
+	if err == nil {
+		for _, item := range batch {
+			item.msg.Ack()
+		}
+		return
+	}
+
+	c.logger.Warnf("%s error processing bulk messages from topic %s: %v", errorMessagePrefix, c.topic, err)
+	failed := make(map[string]bool, len(responses))
+	processed := make(map[string]bool, len(responses))
+	for _, res := range responses {
+		if res.Error != nil {
+			failed[res.EntryId] = true
+		} else {
+			processed[res.EntryId] = true
+		}
+	}
+	for i, item := range batch {
+		id := entries[i].EntryId
+		if processed[id] && !failed[id] {
+			item.msg.Ack()
+		} else {
+			item.msg.Nack()
+		}
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+type fakeAckMessage struct {
+	lock  sync.Mutex
+	acked *bool
+}
+
+func (m *fakeAckMessage) Ack() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.acked = ptr.Of(true)
+}
+
+func (m *fakeAckMessage) Nack() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.acked = ptr.Of(false)
+}
+
+func (m *fakeAckMessage) result() *bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.acked
+}
+
+func TestBulkCollector(t *testing.T) {
+	log := logger.NewLogger("test")
+
+	t.Run("defaults", func(t *testing.T) {
+		c := newBulkCollector("topic", pubsub.BulkSubscribeConfig{}, nil, log)
+		assert.Equal(t, defaultMaxBulkSubCount, c.maxCount)
+		assert.Equal(t, time.Duration(defaultMaxBulkSubAwaitDurationMs)*time.Millisecond, c.maxAwait)
+	})
+
+	t.Run("flushes full batches", func(t *testing.T) {
+		batches := make(chan *pubsub.BulkMessage, 10)
+		c := newBulkCollector("topic", pubsub.BulkSubscribeConfig{MaxMessagesCount: 2, MaxAwaitDurationMs: 60000},
+			func(ctx context.Context, msg *pubsub.BulkMessage) ([]pubsub.BulkSubscribeResponseEntry, error) {
+				batches <- msg
+				return nil, nil
+			}, log)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go c.run(ctx)
+
+		msgs := []*fakeAckMessage{{}, {}}
+		c.itemCh <- bulkItem{data: []byte("a"), msg: msgs[0]}
+		c.itemCh <- bulkItem{data: []byte("b"), msg: msgs[1]}
+
+		select {
+		case batch := <-batches:
+			assert.Equal(t, "topic", batch.Topic)
+			require.Len(t, batch.Entries, 2)
+			assert.Equal(t, []byte("a"), batch.Entries[0].Event)
+			assert.Equal(t, []byte("b"), batch.Entries[1].Event)
+		case <-time.After(5 * time.Second):
+			t.Fatal("batch was not delivered")
+		}
+		assert.Eventually(t, func() bool {
+			return msgs[1].result() != nil && *msgs[1].result()
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.True(t, *msgs[0].result())
+	})
+
+	t.Run("flushes partial batches after the await duration", func(t *testing.T) {
+		batches := make(chan *pubsub.BulkMessage, 10)
+		c := newBulkCollector("topic", pubsub.BulkSubscribeConfig{MaxMessagesCount: 10, MaxAwaitDurationMs: 50},
+			func(ctx context.Context, msg *pubsub.BulkMessage) ([]pubsub.BulkSubscribeResponseEntry, error) {
+				batches <- msg
+				return nil, nil
+			}, log)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go c.run(ctx)
+
+		c.itemCh <- bulkItem{data: []byte("a"), msg: &fakeAckMessage{}}
+
+		select {
+		case batch := <-batches:
+			require.Len(t, batch.Entries, 1)
+		case <-time.After(5 * time.Second):
+			t.Fatal("batch was not delivered")
+		}
+	})
+
+	t.Run("partial failures", func(t *testing.T) {
+		c := newBulkCollector("topic", pubsub.BulkSubscribeConfig{MaxMessagesCount: 3}, func(ctx context.Context, msg *pubsub.BulkMessage) ([]pubsub.BulkSubscribeResponseEntry, error) {
+			return []pubsub.BulkSubscribeResponseEntry{
+				{EntryId: msg.Entries[0].EntryId},
+				{EntryId: msg.Entries[1].EntryId, Error: errors.New("failed")},
+			}, errors.New("failed")
+		}, log)
+
+		msgs := []*fakeAckMessage{{}, {}, {}}
+		c.flush(context.Background(), []bulkItem{{msg: msgs[0]}, {msg: msgs[1]}, {msg: msgs[2]}})
+
+		assert.True(t, *msgs[0].result())
+		assert.False(t, *msgs[1].result())
+		// Entries without a response are retried
+		assert.False(t, *msgs[2].result())
+	})
+}
+
+func TestOrderingKey(t *testing.T) {
+	g := &GCPPubSub{metadata: &metadata{OrderingKey: "component"}}
+	assert.Equal(t, "component", g.orderingKey(nil, map[string]string{}))
+	assert.Equal(t, "request", g.orderingKey(map[string]string{metedataOrderingKeyKey: "request"}, nil))
+	assert.Equal(t, "entry", g.orderingKey(map[string]string{metedataOrderingKeyKey: "request"}, map[string]string{metedataOrderingKeyKey: "entry"}))
+}
@@ -13,26 +13,44 @@ limitations under the License.
 
 package pubsub
 
+import (
+	"time"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
 // GCPPubSubMetaData pubsub metadata.
 type metadata struct {
-	ConsumerID              string `mapstructure:"consumerID"`
-	Type                    string `mapstructure:"type"`
-	IdentityProjectID       string `mapstructure:"identityProjectID"`
-	ProjectID               string `mapstructure:"projectID"`
-	PrivateKeyID            string `mapstructure:"privateKeyID"`
-	PrivateKey              string `mapstructure:"privateKey"`
-	ClientEmail             string `mapstructure:"clientEmail"`
-	ClientID                string `mapstructure:"clientID"`
-	AuthURI                 string `mapstructure:"authURI"`
-	TokenURI                string `mapstructure:"tokenURI"`
-	AuthProviderCertURL     string `mapstructure:"authProviderX509CertUrl"`
-	ClientCertURL           string `mapstructure:"clientX509CertUrl"`
-	DisableEntityManagement bool   `mapstructure:"disableEntityManagement"`
-	EnableMessageOrdering   bool   `mapstructure:"enableMessageOrdering"`
-	MaxReconnectionAttempts int    `mapstructure:"maxReconnectionAttempts"`
-	ConnectionRecoveryInSec int    `mapstructure:"connectionRecoveryInSec"`
-	ConnectionEndpoint      string `mapstructure:"endpoint"`
-	OrderingKey             string `mapstructure:"orderingKey"`
-	DeadLetterTopic         string `mapstructure:"deadLetterTopic"`
-	MaxDeliveryAttempts     int    `mapstructure:"maxDeliveryAttempts"`
+	ConsumerID               string `mapstructure:"consumerID"`
+	Type                     string `mapstructure:"type"`
+	IdentityProjectID        string `mapstructure:"identityProjectID"`
+	ProjectID                string `mapstructure:"projectID"`
+	PrivateKeyID             string `mapstructure:"privateKeyID"`
+	PrivateKey               string `mapstructure:"privateKey"`
+	ClientEmail              string `mapstructure:"clientEmail"`
+	ClientID                 string `mapstructure:"clientID"`
+	AuthURI                  string `mapstructure:"authURI"`
+	TokenURI                 string `mapstructure:"tokenURI"`
+	AuthProviderCertURL      string `mapstructure:"authProviderX509CertUrl"`
+	ClientCertURL            string `mapstructure:"clientX509CertUrl"`
+	DisableEntityManagement  bool   `mapstructure:"disableEntityManagement"`
+	EnableMessageOrdering    bool   `mapstructure:"enableMessageOrdering"`
+	MaxReconnectionAttempts  int    `mapstructure:"maxReconnectionAttempts"`
+	ConnectionRecoveryInSec  int    `mapstructure:"connectionRecoveryInSec"`
+	ConnectionEndpoint       string `mapstructure:"endpoint"`
+	OrderingKey              string `mapstructure:"orderingKey"`
+	DeadLetterTopic          string `mapstructure:"deadLetterTopic"`
+	MaxDeliveryAttempts      int    `mapstructure:"maxDeliveryAttempts"`
+	MaxOutstandingMessages   int    `mapstructure:"maxOutstandingMessages"`
+	MaxOutstandingBytes      int    `mapstructure:"maxOutstandingBytes"`
+	MaxConcurrentConnections int    `mapstructure:"maxConcurrentConnections"`
+
+	// Publisher batching: a batch is sent when any of the thresholds is reached (0 to use the client default)
+	PublishCountThreshold int           `mapstructure:"publishCountThreshold"`
+	PublishByteThreshold  int           `mapstructure:"publishByteThreshold"`
+	PublishDelayThreshold time.Duration `mapstructure:"publishDelayThreshold"`
+	// gRPC compression of the requests: "gzip", or "none" (the default)
+	Compression string `mapstructure:"compression"`
+
+	pubsub.PreflightProperties `mapstructure:",squash"`
 }
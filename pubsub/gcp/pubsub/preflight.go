@@ -0,0 +1,93 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+const (
+	permissionTopicsPublish        = "pubsub.topics.publish"
+	permissionSubscriptionsConsume = "pubsub.subscriptions.consume"
+)
+
+// permissionsTester is implemented by the IAM handles of topics and subscriptions.
+type permissionsTester interface {
+	TestPermissions(ctx context.Context, permissions []string) ([]string, error)
+}
+
+// preflight verifies that the identity of the component has the permissions to publish and subscribe to the topics in the preflight metadata properties.
+func (g *GCPPubSub) preflight(ctx context.Context) error {
+	// The emulator doesn't implement the IAM API
+	if g.metadata.ConnectionEndpoint != "" {
+		g.logger.Warn("Skipping the preflight check of permissions as it's not supported by the GCP Pub/Sub emulator")
+		return nil
+	}
+
+	return g.metadata.PreflightCheck(func(topic string) error {
+		entity := g.getTopic(topic)
+		return g.checkPermissions(ctx, entity.String(), entity.IAM(), permissionTopicsPublish)
+	}, func(topic string) error {
+		if g.metadata.ConsumerID == "" {
+			return fmt.Errorf("%s preflight check failed: consumerID is required to check the permissions to subscribe to '%s'", errorMessagePrefix, topic)
+		}
+		entity := g.getSubscription(BuildSubscriptionID(g.metadata.ConsumerID, topic))
+		return g.checkPermissions(ctx, entity.String(), entity.IAM(), permissionSubscriptionsConsume)
+	})
+}
+
+func (g *GCPPubSub) checkPermissions(ctx context.Context, entity string, tester permissionsTester, permissions ...string) error {
+	granted, err := tester.TestPermissions(ctx, permissions)
+	if status.Code(err) == codes.NotFound {
+		if g.metadata.DisableEntityManagement {
+			return fmt.Errorf("%s preflight check failed: '%s' does not exist and entity management is disabled", errorMessagePrefix, entity)
+		}
+		// The entity is created when it's first used, and inherits the permissions of the project
+		g.logger.Debugf("Skipping the preflight check of '%s' as it doesn't exist yet", entity)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s preflight check of '%s' failed: %w", errorMessagePrefix, entity, err)
+	}
+
+	missing := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		if !contains(granted, p) {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) > 0 {
+		return &pubsub.PermissionError{
+			Identity:    g.metadata.ClientEmail,
+			Entity:      entity,
+			Permissions: missing,
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
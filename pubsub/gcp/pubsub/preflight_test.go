@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+type fakePermissionsTester struct {
+	granted []string
+	err     error
+}
+
+func (f fakePermissionsTester) TestPermissions(ctx context.Context, permissions []string) ([]string, error) {
+	return f.granted, f.err
+}
+
+func TestCheckPermissions(t *testing.T) {
+	g, _ := pubsub.As[*GCPPubSub](NewGCPPubSub(logger.NewLogger("test")))
+	g.metadata = &metadata{ClientEmail: "dapr@superproject.iam.gserviceaccount.com"}
+	entity := "projects/superproject/topics/orders"
+
+	t.Run("permissions granted", func(t *testing.T) {
+		err := g.checkPermissions(context.Background(), entity, fakePermissionsTester{
+			granted: []string{permissionTopicsPublish},
+		}, permissionTopicsPublish)
+		require.NoError(t, err)
+	})
+
+	t.Run("permissions missing", func(t *testing.T) {
+		err := g.checkPermissions(context.Background(), entity, fakePermissionsTester{}, permissionTopicsPublish)
+		var permErr *pubsub.PermissionError
+		require.True(t, errors.As(err, &permErr))
+		assert.Equal(t, "dapr@superproject.iam.gserviceaccount.com", permErr.Identity)
+		assert.Equal(t, entity, permErr.Entity)
+		assert.Equal(t, []string{permissionTopicsPublish}, permErr.Permissions)
+	})
+
+	t.Run("entity not found", func(t *testing.T) {
+		tester := fakePermissionsTester{err: status.Error(codes.NotFound, "not found")}
+		require.NoError(t, g.checkPermissions(context.Background(), entity, tester, permissionTopicsPublish))
+
+		g.metadata.DisableEntityManagement = true
+		defer func() { g.metadata.DisableEntityManagement = false }()
+		require.ErrorContains(t, g.checkPermissions(context.Background(), entity, tester, permissionTopicsPublish), "does not exist")
+	})
+
+	t.Run("request failed", func(t *testing.T) {
+		tester := fakePermissionsTester{err: status.Error(codes.Unavailable, "unavailable")}
+		require.Error(t, g.checkPermissions(context.Background(), entity, tester, permissionTopicsPublish))
+	})
+}
@@ -26,7 +26,9 @@ import (
 
 	gcppubsub "cloud.google.com/go/pubsub"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/grpc/status"
 
 	contribMetadata "github.com/dapr/components-contrib/metadata"
@@ -41,6 +43,10 @@ const (
 	metadataProjectIDKey   = "projectId"
 	metedataOrderingKeyKey = "orderingKey"
 
+	// Compression values.
+	compressionNone = "none"
+	compressionGzip = "gzip"
+
 	// Defaults.
 	defaultMaxReconnectionAttempts = 30
 	defaultConnectionRecoveryInSec = 2
@@ -53,6 +59,10 @@ type GCPPubSub struct {
 	metadata *metadata
 	logger   logger.Logger
 
+	// Topics used to publish, cached so that messages of concurrent requests are batched
+	topics     map[string]*gcppubsub.Topic
+	topicsLock sync.Mutex
+
 	closed  atomic.Bool
 	closeCh chan struct{}
 	wg      sync.WaitGroup
@@ -77,7 +87,7 @@ type WhatNow struct {
 
 // NewGCPPubSub returns a new GCPPubSub instance.
 func NewGCPPubSub(logger logger.Logger) pubsub.PubSub {
-	return &GCPPubSub{logger: logger, closeCh: make(chan struct{})}
+	return pubsub.WithSchemaValidation(&GCPPubSub{logger: logger, closeCh: make(chan struct{}), topics: map[string]*gcppubsub.Topic{}})
 }
 
 func createMetadata(pubSubMetadata pubsub.Metadata) (*metadata, error) {
@@ -99,6 +109,21 @@ func createMetadata(pubSubMetadata pubsub.Metadata) (*metadata, error) {
 		return &result, fmt.Errorf("%s missing attribute %s", errorMessagePrefix, metadataProjectIDKey)
 	}
 
+	if result.PublishCountThreshold < 0 || result.PublishCountThreshold > gcppubsub.MaxPublishRequestCount {
+		return &result, fmt.Errorf("%s publishCountThreshold must be between 0 and %d", errorMessagePrefix, gcppubsub.MaxPublishRequestCount)
+	}
+	if result.PublishByteThreshold < 0 {
+		return &result, fmt.Errorf("%s publishByteThreshold must not be negative", errorMessagePrefix)
+	}
+	if result.PublishDelayThreshold < 0 {
+		return &result, fmt.Errorf("%s publishDelayThreshold must not be negative", errorMessagePrefix)
+	}
+	switch result.Compression {
+	case "", compressionNone, compressionGzip:
+	default:
+		return &result, fmt.Errorf("%s invalid compression %q: must be %q or %q", errorMessagePrefix, result.Compression, compressionGzip, compressionNone)
+	}
+
 	return &result, nil
 }
 
@@ -117,6 +142,14 @@ func (g *GCPPubSub) Init(ctx context.Context, meta pubsub.Metadata) error {
 	g.client = pubsubClient
 	g.metadata = metadata
 
+	if metadata.PreflightEnabled() {
+		err = g.preflight(ctx)
+		if err != nil {
+			g.client.Close()
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -124,6 +157,11 @@ func (g *GCPPubSub) getPubSubClient(ctx context.Context, metadata *metadata) (*g
 	var pubsubClient *gcppubsub.Client
 	var err error
 
+	var opts []option.ClientOption
+	if metadata.Compression == compressionGzip {
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name))))
+	}
+
 	if metadata.PrivateKeyID != "" {
 		// TODO: validate that all auth json fields are filled
 		authJSON := &GCPAuthJSON{
@@ -140,8 +178,8 @@ func (g *GCPPubSub) getPubSubClient(ctx context.Context, metadata *metadata) (*g
 		}
 		gcpCompatibleJSON, _ := json.Marshal(authJSON)
 		g.logger.Debugf("Using explicit credentials for GCP")
-		clientOptions := option.WithCredentialsJSON(gcpCompatibleJSON)
-		pubsubClient, err = gcppubsub.NewClient(ctx, metadata.ProjectID, clientOptions)
+		opts = append(opts, option.WithCredentialsJSON(gcpCompatibleJSON))
+		pubsubClient, err = gcppubsub.NewClient(ctx, metadata.ProjectID, opts...)
 		if err != nil {
 			return pubsubClient, err
 		}
@@ -156,7 +194,7 @@ func (g *GCPPubSub) getPubSubClient(ctx context.Context, metadata *metadata) (*g
 			g.logger.Debugf("setting GCP PubSub Emulator environment variable to 'PUBSUB_EMULATOR_HOST=%s'", metadata.ConnectionEndpoint)
 			os.Setenv("PUBSUB_EMULATOR_HOST", metadata.ConnectionEndpoint)
 		}
-		pubsubClient, err = gcppubsub.NewClient(ctx, metadata.ProjectID)
+		pubsubClient, err = gcppubsub.NewClient(ctx, metadata.ProjectID, opts...)
 		if err != nil {
 			return pubsubClient, err
 		}
@@ -178,7 +216,7 @@ func (g *GCPPubSub) Publish(ctx context.Context, req *pubsub.PublishRequest) err
 		}
 	}
 
-	topic := g.getTopic(req.Topic)
+	topic := g.getPublishTopic(req.Topic)
 
 	msg := &gcppubsub.Message{
 		Data: req.Data,
@@ -188,19 +226,46 @@ func (g *GCPPubSub) Publish(ctx context.Context, req *pubsub.PublishRequest) err
 	// use the provided OrderingKey giving
 	// preference to the OrderingKey at the request level
 	if g.metadata.EnableMessageOrdering {
-		topic.EnableMessageOrdering = g.metadata.EnableMessageOrdering
-		msgOrderingKey := g.metadata.OrderingKey
-		if req.Metadata != nil && req.Metadata[metedataOrderingKeyKey] != "" {
-			msgOrderingKey = req.Metadata[metedataOrderingKeyKey]
-		}
-		msg.OrderingKey = msgOrderingKey
+		msg.OrderingKey = g.orderingKey(req.Metadata)
 		g.logger.Infof("Message Ordering Key: %s", msg.OrderingKey)
 	}
 	_, err := topic.Publish(ctx, msg).Get(ctx)
+	if err != nil && msg.OrderingKey != "" {
+		// Publishing with an ordering key is paused after a failure until resumed
+		topic.ResumePublish(msg.OrderingKey)
+	}
 
 	return err
 }
 
+// getPublishTopic returns the cached topic used to publish messages, creating it if needed.
+func (g *GCPPubSub) getPublishTopic(topic string) *gcppubsub.Topic {
+	g.topicsLock.Lock()
+	defer g.topicsLock.Unlock()
+
+	entity, ok := g.topics[topic]
+	if !ok {
+		entity = g.getTopic(topic)
+		g.applyPublishSettings(entity)
+		entity.EnableMessageOrdering = g.metadata.EnableMessageOrdering
+		g.topics[topic] = entity
+	}
+	return entity
+}
+
+// applyPublishSettings configures the batching of the messages published to a topic.
+func (g *GCPPubSub) applyPublishSettings(topic *gcppubsub.Topic) {
+	if g.metadata.PublishCountThreshold > 0 {
+		topic.PublishSettings.CountThreshold = g.metadata.PublishCountThreshold
+	}
+	if g.metadata.PublishByteThreshold > 0 {
+		topic.PublishSettings.ByteThreshold = g.metadata.PublishByteThreshold
+	}
+	if g.metadata.PublishDelayThreshold > 0 {
+		topic.PublishSettings.DelayThreshold = g.metadata.PublishDelayThreshold
+	}
+}
+
 // Subscribe to the GCP Pubsub topic.
 func (g *GCPPubSub) Subscribe(parentCtx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
 	if g.closed.Load() {
@@ -221,6 +286,7 @@ func (g *GCPPubSub) Subscribe(parentCtx context.Context, req pubsub.SubscribeReq
 
 	topic := g.getTopic(req.Topic)
 	sub := g.getSubscription(BuildSubscriptionID(g.metadata.ConsumerID, req.Topic))
+	g.applyReceiveSettings(sub, 0)
 
 	subscribeCtx, cancel := context.WithCancel(parentCtx)
 	g.wg.Add(2)
@@ -234,17 +300,48 @@ func (g *GCPPubSub) Subscribe(parentCtx context.Context, req pubsub.SubscribeReq
 	}()
 	go func() {
 		defer g.wg.Done()
-		g.handleSubscriptionMessages(subscribeCtx, topic, sub, handler)
+		g.handleSubscriptionMessages(subscribeCtx, sub, func(ctx context.Context, m *gcppubsub.Message) {
+			msg := &pubsub.NewMessage{
+				Data:  m.Data,
+				Topic: topic.ID(),
+			}
+
+			err := handler(ctx, msg)
+
+			if err == nil {
+				m.Ack()
+			} else {
+				m.Nack()
+			}
+		})
 	}()
 
 	return nil
 }
 
+// applyReceiveSettings configures the flow control of a subscription.
+// minOutstandingMessages is the minimum number of unacknowledged messages the subscription must be able to hold.
+func (g *GCPPubSub) applyReceiveSettings(sub *gcppubsub.Subscription, minOutstandingMessages int) {
+	if g.metadata.MaxOutstandingMessages != 0 {
+		sub.ReceiveSettings.MaxOutstandingMessages = g.metadata.MaxOutstandingMessages
+	}
+	if minOutstandingMessages > 0 && sub.ReceiveSettings.MaxOutstandingMessages > 0 &&
+		sub.ReceiveSettings.MaxOutstandingMessages < minOutstandingMessages {
+		sub.ReceiveSettings.MaxOutstandingMessages = minOutstandingMessages
+	}
+	if g.metadata.MaxOutstandingBytes != 0 {
+		sub.ReceiveSettings.MaxOutstandingBytes = g.metadata.MaxOutstandingBytes
+	}
+	if g.metadata.MaxConcurrentConnections > 0 {
+		sub.ReceiveSettings.NumGoroutines = g.metadata.MaxConcurrentConnections
+	}
+}
+
 func BuildSubscriptionID(consumerID, topic string) string {
 	return fmt.Sprintf("%s-%s", consumerID, topic)
 }
 
-func (g *GCPPubSub) handleSubscriptionMessages(parentCtx context.Context, topic *gcppubsub.Topic, sub *gcppubsub.Subscription, handler pubsub.Handler) error {
+func (g *GCPPubSub) handleSubscriptionMessages(parentCtx context.Context, sub *gcppubsub.Subscription, receive func(ctx context.Context, m *gcppubsub.Message)) error {
 	// Limit the number of attempted reconnects we make.
 	reconnAttempts := make(chan struct{}, g.metadata.MaxReconnectionAttempts)
 	for i := 0; i < g.metadata.MaxReconnectionAttempts; i++ {
@@ -283,20 +380,7 @@ func (g *GCPPubSub) handleSubscriptionMessages(parentCtx context.Context, topic
 	// Reconnect loop.
 	var receiveErr error = nil
 	for {
-		receiveErr = sub.Receive(parentCtx, func(ctx context.Context, m *gcppubsub.Message) {
-			msg := &pubsub.NewMessage{
-				Data:  m.Data,
-				Topic: topic.ID(),
-			}
-
-			err := handler(ctx, msg)
-
-			if err == nil {
-				m.Ack()
-			} else {
-				m.Nack()
-			}
-		})
+		receiveErr = sub.Receive(parentCtx, receive)
 
 		g.logger.Infof("Lost connection to subscription %s", sub.ID())
 		// Exit out of reconnect loop if Receive method returns without error.
@@ -394,6 +478,15 @@ func (g *GCPPubSub) Close() error {
 	if g.closed.CompareAndSwap(false, true) {
 		close(g.closeCh)
 	}
+
+	// Flush the messages waiting to be published
+	g.topicsLock.Lock()
+	for _, topic := range g.topics {
+		topic.Stop()
+	}
+	g.topics = map[string]*gcppubsub.Topic{}
+	g.topicsLock.Unlock()
+
 	return g.client.Close()
 }
 
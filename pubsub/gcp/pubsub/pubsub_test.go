@@ -14,11 +14,20 @@ limitations under the License.
 package pubsub
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	gcppubsub "cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
 )
 
 const (
@@ -127,4 +136,79 @@ func TestInit(t *testing.T) {
 		assert.Error(t, err)
 		assert.ErrorContains(t, err, "connectionRecoveryInSec")
 	})
+
+	t.Run("publish settings", func(t *testing.T) {
+		m := pubsub.Metadata{}
+		m.Properties = map[string]string{
+			"projectId":             "superproject",
+			"publishCountThreshold": "500",
+			"publishByteThreshold":  "2048",
+			"publishDelayThreshold": "50ms",
+			"compression":           "gzip",
+		}
+
+		b, err := createMetadata(m)
+		require.NoError(t, err)
+		assert.Equal(t, 500, b.PublishCountThreshold)
+		assert.Equal(t, 2048, b.PublishByteThreshold)
+		assert.Equal(t, 50*time.Millisecond, b.PublishDelayThreshold)
+		assert.Equal(t, "gzip", b.Compression)
+	})
+
+	t.Run("invalid publish settings", func(t *testing.T) {
+		for k, v := range map[string]string{
+			"publishCountThreshold": "5000",
+			"publishByteThreshold":  "-1",
+			"publishDelayThreshold": "-1s",
+			"compression":           "zstd",
+		} {
+			m := pubsub.Metadata{}
+			m.Properties = map[string]string{
+				"projectId": "superproject",
+				k:           v,
+			}
+
+			_, err := createMetadata(m)
+			assert.Error(t, err, k)
+		}
+	})
+}
+
+func TestPublishTopic(t *testing.T) {
+	srv := pstest.NewServer()
+	defer srv.Close()
+	conn, err := grpc.Dial(srv.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	client, err := gcppubsub.NewClient(context.Background(), "superproject", option.WithGRPCConn(conn))
+	require.NoError(t, err)
+
+	g, _ := pubsub.As[*GCPPubSub](NewGCPPubSub(logger.NewLogger("test")))
+	g.client = client
+	g.metadata = &metadata{
+		ProjectID:             "superproject",
+		EnableMessageOrdering: true,
+		PublishByteThreshold:  2048,
+		PublishDelayThreshold: 50 * time.Millisecond,
+	}
+
+	topic := g.getPublishTopic("orders")
+	assert.Same(t, topic, g.getPublishTopic("orders"))
+	assert.NotSame(t, topic, g.getPublishTopic("payments"))
+	assert.True(t, topic.EnableMessageOrdering)
+	assert.Equal(t, 2048, topic.PublishSettings.ByteThreshold)
+	assert.Equal(t, 50*time.Millisecond, topic.PublishSettings.DelayThreshold)
+	assert.Equal(t, gcppubsub.DefaultPublishSettings.CountThreshold, topic.PublishSettings.CountThreshold)
+
+	err = g.Publish(context.Background(), &pubsub.PublishRequest{
+		Topic:    "orders",
+		Data:     []byte("hello"),
+		Metadata: map[string]string{"orderingKey": "key"},
+	})
+	require.NoError(t, err)
+	msgs := srv.Messages()
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "key", msgs[0].OrderingKey)
+
+	require.NoError(t, g.Close())
+	assert.Empty(t, g.topics)
 }
@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"errors"
+	"time"
+)
+
+// PermanentError is returned by handlers for messages that can never be processed, such as malformed messages.
+// Components that support it stop redelivering the message, instead of retrying it until the maximum number of deliveries is reached.
+type PermanentError struct {
+	Err error
+}
+
+// NewPermanentError returns a PermanentError wrapping err.
+func NewPermanentError(err error) error {
+	return &PermanentError{Err: err}
+}
+
+func (e *PermanentError) Error() string {
+	return "permanent error: " + e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// IsPermanentError returns true if err is, or wraps, a PermanentError.
+func IsPermanentError(err error) bool {
+	var pErr *PermanentError
+	return errors.As(err, &pErr)
+}
+
+// RetriableError is returned by handlers for messages that could not be processed, but can be once the delay has elapsed.
+// Components that support it redeliver the message after the delay; if the delay is 0, they use their own redelivery policy.
+type RetriableError struct {
+	Err   error
+	Delay time.Duration
+}
+
+// NewRetriableError returns a RetriableError wrapping err, for a message that is redelivered after the delay.
+func NewRetriableError(err error, delay time.Duration) error {
+	return &RetriableError{Err: err, Delay: delay}
+}
+
+func (e *RetriableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetriableError) Unwrap() error {
+	return e.Err
+}
+
+// RetryDelay returns the delay of err if it is, or wraps, a RetriableError with a delay.
+func RetryDelay(err error) (time.Duration, bool) {
+	var rErr *RetriableError
+	if errors.As(err, &rErr) && rErr.Delay > 0 {
+		return rErr.Delay, true
+	}
+	return 0, false
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerErrors(t *testing.T) {
+	errMalformed := errors.New("malformed message")
+
+	t.Run("permanent error", func(t *testing.T) {
+		err := fmt.Errorf("handler failed: %w", NewPermanentError(errMalformed))
+		assert.True(t, IsPermanentError(err))
+		assert.ErrorIs(t, err, errMalformed)
+		assert.False(t, IsPermanentError(errMalformed))
+
+		_, ok := RetryDelay(err)
+		assert.False(t, ok)
+	})
+
+	t.Run("retriable error", func(t *testing.T) {
+		err := fmt.Errorf("handler failed: %w", NewRetriableError(errMalformed, time.Minute))
+		delay, ok := RetryDelay(err)
+		assert.True(t, ok)
+		assert.Equal(t, time.Minute, delay)
+		assert.ErrorIs(t, err, errMalformed)
+		assert.False(t, IsPermanentError(err))
+
+		_, ok = RetryDelay(NewRetriableError(errMalformed, 0))
+		assert.False(t, ok)
+		_, ok = RetryDelay(errMalformed)
+		assert.False(t, ok)
+	})
+}
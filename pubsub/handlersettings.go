@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// MaxConcurrentHandlersKey is the subscribe request metadata key for the maximum number of messages that are processed concurrently.
+	MaxConcurrentHandlersKey = "maxConcurrentHandlers"
+	// HandlerTimeoutKey is the subscribe request metadata key for the timeout of each invocation of the handler.
+	// Values can be durations such as "30s", or numbers of seconds.
+	HandlerTimeoutKey = "handlerTimeout"
+)
+
+// HandlerSettings contains the settings for processing the messages of a subscription.
+// They allow topics within the same component to have different processing profiles.
+type HandlerSettings struct {
+	// Maximum number of messages that are processed concurrently; 0 means no limit.
+	MaxConcurrentHandlers int
+	// Timeout for each invocation of the handler; 0 means no timeout.
+	HandlerTimeout time.Duration
+}
+
+// HandlerSettingsFromMetadata returns the handler settings from the metadata of a subscribe request.
+// Settings that are not present in the metadata have the values in defaults, which are normally the component-level settings.
+func HandlerSettingsFromMetadata(metadata map[string]string, defaults HandlerSettings) (HandlerSettings, error) {
+	s := defaults
+
+	if val := metadata[MaxConcurrentHandlersKey]; val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 {
+			return s, fmt.Errorf("invalid %s '%s': must be a non-negative integer", MaxConcurrentHandlersKey, val)
+		}
+		s.MaxConcurrentHandlers = n
+	}
+
+	if val := metadata[HandlerTimeoutKey]; val != "" {
+		var (
+			d   time.Duration
+			err error
+		)
+		if n, nErr := strconv.Atoi(val); nErr == nil {
+			d = time.Duration(n) * time.Second
+		} else {
+			d, err = time.ParseDuration(val)
+		}
+		if err != nil || d < 0 {
+			return s, fmt.Errorf("invalid %s '%s': must be a non-negative duration or number of seconds", HandlerTimeoutKey, val)
+		}
+		s.HandlerTimeout = d
+	}
+
+	return s, nil
+}
+
+// Handler wraps handler so it's invoked with the timeout, and at most MaxConcurrentHandlers invocations are in progress at the same time.
+// When the limit is reached, invocations wait until one of the handlers in progress returns.
+func (s HandlerSettings) Handler(handler Handler) Handler {
+	sem := s.semaphore()
+	return func(ctx context.Context, msg *NewMessage) error {
+		ctx, release, err := s.acquire(ctx, sem)
+		if err != nil {
+			return err
+		}
+		defer release()
+		return handler(ctx, msg)
+	}
+}
+
+// BulkHandler wraps handler so it's invoked with the timeout, and at most MaxConcurrentHandlers invocations are in progress at the same time.
+// When the limit is reached, invocations wait until one of the handlers in progress returns.
+func (s HandlerSettings) BulkHandler(handler BulkHandler) BulkHandler {
+	sem := s.semaphore()
+	return func(ctx context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error) {
+		ctx, release, err := s.acquire(ctx, sem)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return handler(ctx, msg)
+	}
+}
+
+func (s HandlerSettings) semaphore() chan struct{} {
+	if s.MaxConcurrentHandlers <= 0 {
+		return nil
+	}
+	return make(chan struct{}, s.MaxConcurrentHandlers)
+}
+
+// acquire waits for a slot in the semaphore, if any, and returns the context for the handler and the function that releases the slot.
+func (s HandlerSettings) acquire(ctx context.Context, sem chan struct{}) (context.Context, func(), error) {
+	if sem != nil {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+
+	cancel := context.CancelFunc(func() {})
+	if s.HandlerTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.HandlerTimeout)
+	}
+	return ctx, func() {
+		cancel()
+		if sem != nil {
+			<-sem
+		}
+	}, nil
+}
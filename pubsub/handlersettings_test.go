@@ -0,0 +1,145 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerSettingsFromMetadata(t *testing.T) {
+	defaults := HandlerSettings{
+		MaxConcurrentHandlers: 10,
+		HandlerTimeout:        time.Minute,
+	}
+
+	tests := map[string]struct {
+		metadata map[string]string
+		want     HandlerSettings
+		wantErr  bool
+	}{
+		"defaults": {
+			metadata: map[string]string{},
+			want:     defaults,
+		},
+		"overrides": {
+			metadata: map[string]string{MaxConcurrentHandlersKey: "2", HandlerTimeoutKey: "30s"},
+			want:     HandlerSettings{MaxConcurrentHandlers: 2, HandlerTimeout: 30 * time.Second},
+		},
+		"timeout in seconds": {
+			metadata: map[string]string{HandlerTimeoutKey: "5"},
+			want:     HandlerSettings{MaxConcurrentHandlers: 10, HandlerTimeout: 5 * time.Second},
+		},
+		"no limits": {
+			metadata: map[string]string{MaxConcurrentHandlersKey: "0", HandlerTimeoutKey: "0"},
+			want:     HandlerSettings{},
+		},
+		"negative maxConcurrentHandlers": {
+			metadata: map[string]string{MaxConcurrentHandlersKey: "-1"},
+			wantErr:  true,
+		},
+		"invalid maxConcurrentHandlers": {
+			metadata: map[string]string{MaxConcurrentHandlersKey: "many"},
+			wantErr:  true,
+		},
+		"negative handlerTimeout": {
+			metadata: map[string]string{HandlerTimeoutKey: "-1s"},
+			wantErr:  true,
+		},
+		"invalid handlerTimeout": {
+			metadata: map[string]string{HandlerTimeoutKey: "soon"},
+			wantErr:  true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := HandlerSettingsFromMetadata(tc.metadata, defaults)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestHandlerSettingsHandler(t *testing.T) {
+	t.Run("limits concurrency", func(t *testing.T) {
+		var inProgress, maxInProgress atomic.Int32
+		handler := HandlerSettings{MaxConcurrentHandlers: 2}.Handler(func(ctx context.Context, msg *NewMessage) error {
+			n := inProgress.Add(1)
+			for {
+				m := maxInProgress.Load()
+				if n <= m || maxInProgress.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			inProgress.Add(-1)
+			return nil
+		})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, handler(context.Background(), &NewMessage{}))
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(2), maxInProgress.Load())
+	})
+
+	t.Run("applies timeout", func(t *testing.T) {
+		handler := HandlerSettings{HandlerTimeout: 10 * time.Millisecond}.Handler(func(ctx context.Context, msg *NewMessage) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		assert.ErrorIs(t, handler(context.Background(), &NewMessage{}), context.DeadlineExceeded)
+	})
+
+	t.Run("no timeout", func(t *testing.T) {
+		handler := HandlerSettings{}.Handler(func(ctx context.Context, msg *NewMessage) error {
+			_, ok := ctx.Deadline()
+			assert.False(t, ok)
+			return nil
+		})
+		assert.NoError(t, handler(context.Background(), &NewMessage{}))
+	})
+
+	t.Run("stops waiting when the context is canceled", func(t *testing.T) {
+		release := make(chan struct{})
+		handler := HandlerSettings{MaxConcurrentHandlers: 1}.BulkHandler(func(ctx context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error) {
+			<-release
+			return nil, nil
+		})
+
+		go handler(context.Background(), &BulkMessage{})
+		time.Sleep(10 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := handler(ctx, &BulkMessage{})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		close(release)
+	})
+}
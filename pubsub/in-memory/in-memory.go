@@ -16,17 +16,20 @@ package inmemory
 import (
 	"context"
 	"errors"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/dapr/components-contrib/internal/eventbus"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 )
 
 type bus struct {
 	bus     eventbus.Bus
+	topics  *pubsub.TopicMapper
 	log     logger.Logger
 	closed  atomic.Bool
 	closeCh chan struct{}
@@ -34,10 +37,10 @@ type bus struct {
 }
 
 func New(logger logger.Logger) pubsub.PubSub {
-	return &bus{
+	return pubsub.WithSchemaValidation(&bus{
 		log:     logger,
 		closeCh: make(chan struct{}),
-	}
+	})
 }
 
 func (a *bus) Close() error {
@@ -55,6 +58,12 @@ func (a *bus) Features() []pubsub.Feature {
 func (a *bus) Init(_ context.Context, metadata pubsub.Metadata) error {
 	a.bus = eventbus.New(true)
 
+	var err error
+	a.topics, err = pubsub.ParseTopicMapping(metadata.Properties)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -63,7 +72,7 @@ func (a *bus) Publish(_ context.Context, req *pubsub.PublishRequest) error {
 		return errors.New("component is closed")
 	}
 
-	a.bus.Publish(req.Topic, req.Data)
+	a.bus.Publish(a.topics.Physical(req.Topic), req.Data)
 
 	return nil
 }
@@ -89,7 +98,8 @@ func (a *bus) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handle
 			}
 		}
 	}
-	err := a.bus.SubscribeAsync(req.Topic, retryHandler, true)
+	topic := a.topics.Physical(req.Topic)
+	err := a.bus.SubscribeAsync(topic, retryHandler, true)
 	if err != nil {
 		return err
 	}
@@ -102,7 +112,7 @@ func (a *bus) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handle
 		case <-ctx.Done():
 		case <-a.closeCh:
 		}
-		err := a.bus.Unsubscribe(req.Topic, retryHandler)
+		err := a.bus.Unsubscribe(topic, retryHandler)
 		if err != nil {
 			a.log.Errorf("error while unsubscribing from topic %s: %v", req.Topic, err)
 		}
@@ -113,5 +123,7 @@ func (a *bus) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handle
 
 // GetComponentMetadata returns the metadata of the component.
 func (a *bus) GetComponentMetadata() map[string]string {
-	return map[string]string{}
+	metadataInfo := map[string]string{}
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(pubsub.TopicMappingProperties{}), &metadataInfo, contribMetadata.PubSubType)
+	return metadataInfo
 }
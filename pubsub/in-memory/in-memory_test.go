@@ -19,7 +19,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 )
@@ -107,3 +109,28 @@ func publish(ch chan []byte, msg *pubsub.NewMessage) error {
 
 	return nil
 }
+
+func TestTopicMapping(t *testing.T) {
+	b := New(logger.NewLogger("test"))
+	err := b.Init(context.Background(), pubsub.Metadata{Base: contribMetadata.Base{
+		Properties: map[string]string{
+			"topicPrefix": "{tenant}.",
+			"topicTenant": "contoso",
+		},
+	}})
+	require.NoError(t, err)
+
+	ch := make(chan *pubsub.NewMessage, 1)
+	b.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "orders"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		ch <- msg
+		return nil
+	})
+
+	// Messages are published to the physical topic, and delivered with the logical topic
+	inner, _ := pubsub.As[*bus](b)
+	inner.bus.Publish("orders", []byte("skipped"))
+	b.Publish(context.Background(), &pubsub.PublishRequest{Data: []byte("ABCD"), Topic: "orders"})
+	msg := <-ch
+	assert.Equal(t, "ABCD", string(msg.Data))
+	assert.Equal(t, "orders", msg.Topic)
+}
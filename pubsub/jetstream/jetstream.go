@@ -15,20 +15,42 @@ package jetstream
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"reflect"
+	"strconv"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/nats-io/nats.go"
-	"github.com/nats-io/nkeys"
+	"golang.org/x/exp/slices"
 
+	"github.com/dapr/components-contrib/internal/utils"
 	mdutils "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/retry"
 )
 
+const (
+	// Keys of the metadata of subscribe requests that override the ones of the component.
+	subscribeDurableNameKey         = "durableName"
+	subscribeQueueGroupNameKey      = "queueGroupName"
+	subscribeOrderedConsumerKey     = "orderedConsumer"
+	subscribeNakDelayKey            = "nakDelay"
+	subscribeNakMaxDelayKey         = "nakMaxDelay"
+	subscribeTermAfterDeliveriesKey = "termAfterDeliveries"
+
+	// Key of the metadata of publish requests with the ID of the message, used for deduplication.
+	publishMessageIDKey = "messageId"
+
+	// Same as the default duplicate window of JetStream streams.
+	defaultDedupTTL = 2 * time.Minute
+)
+
 type jetstreamPubSub struct {
 	nc   *nats.Conn
 	jsc  nats.JetStreamContext
@@ -36,17 +58,38 @@ type jetstreamPubSub struct {
 	meta metadata
 
 	backOffConfig retry.Config
+	dedup         nats.KeyValue
+
+	subs     map[string][]*jetstreamSubscription
+	subsLock sync.Mutex
 
 	closed  atomic.Bool
 	closeCh chan struct{}
 	wg      sync.WaitGroup
 }
 
+// jetstreamSubscription is an active subscription to a topic.
+type jetstreamSubscription struct {
+	ctx         context.Context
+	topic       string
+	stream      string
+	durableName string
+	queueGroup  string
+	ordered     bool
+	handler     pubsub.Handler
+	sub         *nats.Subscription
+
+	nakDelay            time.Duration
+	nakMaxDelay         time.Duration
+	termAfterDeliveries int
+}
+
 func NewJetStream(logger logger.Logger) pubsub.PubSub {
-	return &jetstreamPubSub{
+	return pubsub.WithSchemaValidation(&jetstreamPubSub{
 		l:       logger,
+		subs:    make(map[string][]*jetstreamSubscription),
 		closeCh: make(chan struct{}),
-	}
+	})
 }
 
 func (js *jetstreamPubSub) Init(_ context.Context, metadata pubsub.Metadata) error {
@@ -56,22 +99,9 @@ func (js *jetstreamPubSub) Init(_ context.Context, metadata pubsub.Metadata) err
 		return err
 	}
 
-	var opts []nats.Option
-	opts = append(opts, nats.Name(js.meta.Name))
-
-	// Set nats.UserJWT options when jwt and seed key is provided.
-	if js.meta.Jwt != "" && js.meta.SeedKey != "" {
-		opts = append(opts, nats.UserJWT(func() (string, error) {
-			return js.meta.Jwt, nil
-		}, func(nonce []byte) ([]byte, error) {
-			return sigHandler(js.meta.SeedKey, nonce)
-		}))
-	} else if js.meta.TLSClientCert != "" && js.meta.TLSClientKey != "" {
-		js.l.Debug("Configure nats for tls client authentication")
-		opts = append(opts, nats.ClientCert(js.meta.TLSClientCert, js.meta.TLSClientKey))
-	} else if js.meta.Token != "" {
-		js.l.Debug("Configure nats for token authentication")
-		opts = append(opts, nats.Token(js.meta.Token))
+	opts, err := js.meta.ConnectOptions(js.meta.Name, js.l)
+	if err != nil {
+		return err
 	}
 
 	js.nc, err = nats.Connect(js.meta.NatsURL, opts...)
@@ -103,13 +133,20 @@ func (js *jetstreamPubSub) Init(_ context.Context, metadata pubsub.Metadata) err
 		return err
 	}
 
+	if js.meta.DedupBucket != "" {
+		js.dedup, err = js.dedupBucket()
+		if err != nil {
+			return err
+		}
+	}
+
 	js.l.Debug("JetStream initialization complete")
 
 	return nil
 }
 
 func (js *jetstreamPubSub) Features() []pubsub.Feature {
-	return nil
+	return []pubsub.Feature{pubsub.FeatureBulkPublish}
 }
 
 func (js *jetstreamPubSub) Publish(ctx context.Context, req *pubsub.PublishRequest) error {
@@ -117,28 +154,84 @@ func (js *jetstreamPubSub) Publish(ctx context.Context, req *pubsub.PublishReque
 		return errors.New("component is closed")
 	}
 
-	var opts []nats.PubOpt
-	var msgID string
-
-	event, err := pubsub.FromCloudEvent(req.Data, "", "", "", "")
+	opts, msgID := js.publishOptions(req.Data, req.Metadata)
+	js.l.Debugf("Publishing to topic %v id: %s", req.Topic, msgID)
+	ack, err := js.jsc.Publish(req.Topic, req.Data, append(opts, nats.Context(ctx))...)
 	if err != nil {
-		js.l.Debugf("error unmarshalling cloudevent: %v", err)
-	} else {
-		// Use the cloudevent id as the Nats-MsgId for deduplication
-		if id, ok := event["id"].(string); ok {
+		return err
+	}
+	if ack.Duplicate {
+		js.l.Debugf("Message %s published to topic %s is a duplicate and was discarded by JetStream", msgID, req.Topic)
+	}
+
+	return nil
+}
+
+// BulkPublish publishes all the entries asynchronously, then waits for the acknowledgement of each of them.
+func (js *jetstreamPubSub) BulkPublish(ctx context.Context, req *pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+	if js.closed.Load() {
+		return pubsub.BulkPublishResponse{}, errors.New("component is closed")
+	}
+
+	res := pubsub.BulkPublishResponse{}
+	futures := make([]nats.PubAckFuture, len(req.Entries))
+	for i, entry := range req.Entries {
+		opts, _ := js.publishOptions(entry.Event, entry.Metadata)
+		future, err := js.jsc.PublishAsync(req.Topic, entry.Event, opts...)
+		if err != nil {
+			res.FailedEntries = append(res.FailedEntries, pubsub.BulkPublishResponseFailedEntry{
+				EntryId: entry.EntryId,
+				Error:   err,
+			})
+			continue
+		}
+		futures[i] = future
+	}
+
+	for i, future := range futures {
+		if future == nil {
+			continue
+		}
+		var err error
+		select {
+		case <-future.Ok():
+		case err = <-future.Err():
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		if err != nil {
+			res.FailedEntries = append(res.FailedEntries, pubsub.BulkPublishResponseFailedEntry{
+				EntryId: req.Entries[i].EntryId,
+				Error:   err,
+			})
+		}
+	}
+
+	if len(res.FailedEntries) > 0 {
+		return res, fmt.Errorf("nats: failed to publish %d of %d messages to topic %s", len(res.FailedEntries), len(req.Entries), req.Topic)
+	}
+	return res, nil
+}
+
+// publishOptions returns the options to publish a message with its ID, which JetStream uses to discard duplicates within the duplicate window of the stream.
+// The ID is taken from the metadata of the request, or from the ID of the cloud event.
+func (js *jetstreamPubSub) publishOptions(data []byte, md map[string]string) ([]nats.PubOpt, string) {
+	msgID := md[publishMessageIDKey]
+	if msgID == "" {
+		event, err := pubsub.FromCloudEvent(data, "", "", "", "")
+		if err != nil {
+			js.l.Debugf("error unmarshalling cloudevent: %v", err)
+		} else if id, ok := event["id"].(string); ok {
+			// Use the cloudevent id as the Nats-MsgId for deduplication
 			msgID = id
-			opts = append(opts, nats.MsgId(msgID))
 		}
 	}
 
 	if msgID == "" {
 		js.l.Warn("empty message ID, Jetstream deduplication will not be possible")
+		return nil, ""
 	}
-
-	js.l.Debugf("Publishing to topic %v id: %s", req.Topic, msgID)
-	_, err = js.jsc.Publish(req.Topic, req.Data, opts...)
-
-	return err
+	return []nats.PubOpt{nats.MsgId(msgID)}, msgID
 }
 
 func (js *jetstreamPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
@@ -146,14 +239,119 @@ func (js *jetstreamPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRe
 		return errors.New("component is closed")
 	}
 
+	var err error
+	streamName := js.meta.StreamName
+	if streamName == "" {
+		streamName, err = js.jsc.StreamNameBySubject(req.Topic)
+		if err != nil {
+			return err
+		}
+	}
+
+	s, err := js.newSubscription(ctx, req, streamName, handler)
+	if err != nil {
+		return err
+	}
+	s.sub, err = js.subscribe(s, js.consumerConfig(s))
+	if err != nil {
+		return err
+	}
+
+	js.subsLock.Lock()
+	js.subs[req.Topic] = append(js.subs[req.Topic], s)
+	js.subsLock.Unlock()
+
+	js.wg.Add(1)
+	go func() {
+		defer js.wg.Done()
+		select {
+		case <-ctx.Done():
+		case <-js.closeCh:
+		}
+
+		js.subsLock.Lock()
+		if i := slices.Index(js.subs[req.Topic], s); i >= 0 {
+			js.subs[req.Topic] = slices.Delete(js.subs[req.Topic], i, i+1)
+		}
+		if len(js.subs[req.Topic]) == 0 {
+			delete(js.subs, req.Topic)
+		}
+		// The subscription may have been replaced by a replay
+		err := s.sub.Unsubscribe()
+		js.subsLock.Unlock()
+		if err != nil {
+			js.l.Warnf("nats: error while unsubscribing from topic %s: %v", req.Topic, err)
+		}
+	}()
+
+	return nil
+}
+
+// newSubscription returns a subscription to a topic, applying the consumer options from the metadata of the request.
+func (js *jetstreamPubSub) newSubscription(ctx context.Context, req pubsub.SubscribeRequest, stream string, handler pubsub.Handler) (*jetstreamSubscription, error) {
+	s := &jetstreamSubscription{
+		ctx:         ctx,
+		topic:       req.Topic,
+		stream:      stream,
+		durableName: js.meta.DurableName,
+		queueGroup:  js.meta.QueueGroupName,
+		ordered:     js.meta.OrderedConsumer,
+		handler:     handler,
+
+		nakDelay:            js.meta.NakDelay,
+		nakMaxDelay:         js.meta.NakMaxDelay,
+		termAfterDeliveries: js.meta.TermAfterDeliveries,
+	}
+
+	if v := req.Metadata[subscribeDurableNameKey]; v != "" {
+		s.durableName = v
+	}
+	if v := req.Metadata[subscribeQueueGroupNameKey]; v != "" {
+		s.queueGroup = v
+	}
+	if v := req.Metadata[subscribeOrderedConsumerKey]; v != "" {
+		s.ordered = utils.IsTruthy(v)
+	}
+	if s.ordered && (s.durableName != "" || s.queueGroup != "") {
+		return nil, fmt.Errorf("nats: ordered consumer for topic %s can't have a durable name or a queue group", req.Topic)
+	}
+
+	var err error
+	if v := req.Metadata[subscribeNakDelayKey]; v != "" {
+		s.nakDelay, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("nats: invalid %s for topic %s: %w", subscribeNakDelayKey, req.Topic, err)
+		}
+	}
+	if v := req.Metadata[subscribeNakMaxDelayKey]; v != "" {
+		s.nakMaxDelay, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("nats: invalid %s for topic %s: %w", subscribeNakMaxDelayKey, req.Topic, err)
+		}
+	}
+	if v := req.Metadata[subscribeTermAfterDeliveriesKey]; v != "" {
+		s.termAfterDeliveries, err = strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("nats: invalid %s for topic %s: %w", subscribeTermAfterDeliveriesKey, req.Topic, err)
+		}
+	}
+	if err = validateNakSettings(s.nakDelay, s.nakMaxDelay, s.termAfterDeliveries); err != nil {
+		return nil, fmt.Errorf("nats: invalid subscription to topic %s: %w", req.Topic, err)
+	}
+
+	return s, nil
+}
+
+// consumerConfig returns the configuration of the consumer of a subscription.
+func (js *jetstreamPubSub) consumerConfig(s *jetstreamSubscription) nats.ConsumerConfig {
 	var consumerConfig nats.ConsumerConfig
 
 	consumerConfig.DeliverSubject = nats.NewInbox()
 
-	if v := js.meta.DurableName; v != "" {
+	if v := s.durableName; v != "" {
 		consumerConfig.Durable = v
 	}
-	if v := js.meta.QueueGroupName; v != "" {
+	if v := s.queueGroup; v != "" {
 		consumerConfig.DeliverGroup = v
 	}
 
@@ -193,7 +391,15 @@ func (js *jetstreamPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRe
 		consumerConfig.Heartbeat = js.meta.Heartbeat
 	}
 	consumerConfig.AckPolicy = js.meta.internalAckPolicy
-	consumerConfig.FilterSubject = req.Topic
+	consumerConfig.FilterSubject = s.topic
+
+	return consumerConfig
+}
+
+// subscribe creates the consumer and subscribes to it.
+func (js *jetstreamPubSub) subscribe(s *jetstreamSubscription, consumerConfig nats.ConsumerConfig) (*nats.Subscription, error) {
+	// Ordered consumers don't use acks
+	ack := !s.ordered && (js.meta.internalAckPolicy == nats.AckExplicitPolicy || js.meta.internalAckPolicy == nats.AckAllPolicy)
 
 	natsHandler := func(m *nats.Msg) {
 		jsm, err := m.Metadata()
@@ -205,9 +411,22 @@ func (js *jetstreamPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRe
 			return
 		}
 
+		dedupKey := js.dedupKey(s.topic, m.Header.Get(nats.MsgIdHdr))
+		if dedupKey != "" && js.isDuplicate(dedupKey) {
+			js.l.Debugf("Skipping duplicate JetStream message %s/%d", m.Subject, jsm.Sequence)
+			if ack {
+				err = m.Ack()
+				if err != nil {
+					js.l.Errorf("Error while sending ACK for JetStream message %s/%d: %v", m.Subject, jsm.Sequence, err)
+				}
+			}
+
+			return
+		}
+
 		js.l.Debugf("Processing JetStream message %s/%d", m.Subject, jsm.Sequence)
-		err = handler(ctx, &pubsub.NewMessage{
-			Topic: req.Topic,
+		err = s.handler(s.ctx, &pubsub.NewMessage{
+			Topic: s.topic,
 			Data:  m.Data,
 			Metadata: map[string]string{
 				"Topic": m.Subject,
@@ -216,17 +435,18 @@ func (js *jetstreamPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRe
 		if err != nil {
 			js.l.Errorf("Error processing JetStream message %s/%d: %v", m.Subject, jsm.Sequence, err)
 
-			if js.meta.internalAckPolicy == nats.AckExplicitPolicy || js.meta.internalAckPolicy == nats.AckAllPolicy {
-				nakErr := m.Nak()
-				if nakErr != nil {
-					js.l.Errorf("Error while sending NAK for JetStream message %s/%d: %v", m.Subject, jsm.Sequence, nakErr)
-				}
+			if ack {
+				js.negativeAck(s, m, jsm, err)
 			}
 
 			return
 		}
 
-		if js.meta.internalAckPolicy == nats.AckExplicitPolicy || js.meta.internalAckPolicy == nats.AckAllPolicy {
+		if dedupKey != "" {
+			js.markProcessed(dedupKey)
+		}
+
+		if ack {
 			err = m.Ack()
 			if err != nil {
 				js.l.Errorf("Error while sending ACK for JetStream message %s/%d: %v", m.Subject, jsm.Sequence, err)
@@ -234,68 +454,236 @@ func (js *jetstreamPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRe
 		}
 	}
 
-	var err error
-	streamName := js.meta.StreamName
-	if streamName == "" {
-		streamName, err = js.jsc.StreamNameBySubject(req.Topic)
-		if err != nil {
-			return err
-		}
+	if s.ordered {
+		js.l.Debugf("nats: subscribed to subject %s with an ordered consumer", s.topic)
+		return js.jsc.Subscribe(s.topic, natsHandler, orderedConsumerOptions(s.stream, consumerConfig)...)
 	}
-	var subscription *nats.Subscription
 
-	consumerInfo, err := js.jsc.AddConsumer(streamName, &consumerConfig)
+	consumerInfo, err := js.jsc.AddConsumer(s.stream, &consumerConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if queue := js.meta.QueueGroupName; queue != "" {
+	if queue := s.queueGroup; queue != "" {
 		js.l.Debugf("nats: subscribed to subject %s with queue group %s",
-			req.Topic, js.meta.QueueGroupName)
-		subscription, err = js.jsc.QueueSubscribe(req.Topic, queue, natsHandler, nats.Bind(streamName, consumerInfo.Name))
-	} else {
-		js.l.Debugf("nats: subscribed to subject %s", req.Topic)
-		subscription, err = js.jsc.Subscribe(req.Topic, natsHandler, nats.Bind(streamName, consumerInfo.Name))
+			s.topic, queue)
+		return js.jsc.QueueSubscribe(s.topic, queue, natsHandler, nats.Bind(s.stream, consumerInfo.Name))
+	}
+	js.l.Debugf("nats: subscribed to subject %s", s.topic)
+	return js.jsc.Subscribe(s.topic, natsHandler, nats.Bind(s.stream, consumerInfo.Name))
+}
+
+// negativeAck tells JetStream that the handler of a message failed.
+// Messages are terminated, so that they're not redelivered, if the error is permanent or they failed too many times; otherwise, they're redelivered after a delay.
+func (js *jetstreamPubSub) negativeAck(s *jetstreamSubscription, m *nats.Msg, jsm *nats.MsgMetadata, handlerErr error) {
+	var err error
+	switch {
+	case pubsub.IsPermanentError(handlerErr):
+		js.l.Warnf("Terminating JetStream message %s/%d after a permanent error", m.Subject, jsm.Sequence)
+		err = m.Term()
+	case s.termAfterDeliveries > 0 && jsm.NumDelivered >= uint64(s.termAfterDeliveries):
+		js.l.Warnf("Terminating JetStream message %s/%d after %d failed deliveries", m.Subject, jsm.Sequence, jsm.NumDelivered)
+		err = m.Term()
+	default:
+		if delay := s.redeliveryDelay(jsm.NumDelivered, handlerErr); delay > 0 {
+			err = m.NakWithDelay(delay)
+		} else {
+			err = m.Nak()
+		}
 	}
 	if err != nil {
+		js.l.Errorf("Error while sending NAK for JetStream message %s/%d: %v", m.Subject, jsm.Sequence, err)
+	}
+}
+
+// redeliveryDelay returns the delay before redelivering a message whose handler failed.
+// The delay of retriable errors takes precedence over the one of the subscription, which doubles with each delivery if there's a maximum delay.
+func (s *jetstreamSubscription) redeliveryDelay(numDelivered uint64, handlerErr error) time.Duration {
+	if delay, ok := pubsub.RetryDelay(handlerErr); ok {
+		return delay
+	}
+	if s.nakMaxDelay == 0 || numDelivered <= 1 {
+		return s.nakDelay
+	}
+
+	delay := s.nakDelay
+	for i := uint64(1); i < numDelivered && delay < s.nakMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.nakMaxDelay {
+		delay = s.nakMaxDelay
+	}
+	return delay
+}
+
+// orderedConsumerOptions returns the options to subscribe with an ordered consumer, which is managed by the client and delivers messages in order without acks.
+func orderedConsumerOptions(stream string, consumerConfig nats.ConsumerConfig) []nats.SubOpt {
+	opts := []nats.SubOpt{
+		nats.OrderedConsumer(),
+		nats.BindStream(stream),
+	}
+	switch consumerConfig.DeliverPolicy {
+	case nats.DeliverLastPolicy:
+		opts = append(opts, nats.DeliverLast())
+	case nats.DeliverNewPolicy:
+		opts = append(opts, nats.DeliverNew())
+	case nats.DeliverByStartSequencePolicy:
+		opts = append(opts, nats.StartSequence(consumerConfig.OptStartSeq))
+	case nats.DeliverByStartTimePolicy:
+		if consumerConfig.OptStartTime != nil {
+			opts = append(opts, nats.StartTime(*consumerConfig.OptStartTime))
+		}
+	default:
+		opts = append(opts, nats.DeliverAll())
+	}
+	if consumerConfig.Heartbeat != 0 {
+		opts = append(opts, nats.IdleHeartbeat(consumerConfig.Heartbeat))
+	}
+	return opts
+}
+
+// Replay recreates the durable consumer of the active subscriptions to a topic so that it delivers messages from the requested position.
+// The consumer group is the durable name of the consumer; if empty, the consumer of the first subscription to the topic is used.
+// The offset is the sequence number in the stream of the first message to deliver.
+func (js *jetstreamPubSub) Replay(_ context.Context, req pubsub.ReplayRequest) error {
+	if js.closed.Load() {
+		return errors.New("component is closed")
+	}
+	if err := req.Validate(); err != nil {
 		return err
 	}
 
-	js.wg.Add(1)
-	go func() {
-		defer js.wg.Done()
-		select {
-		case <-ctx.Done():
-		case <-js.closeCh:
+	js.subsLock.Lock()
+	defer js.subsLock.Unlock()
+
+	if len(js.subs[req.Topic]) == 0 {
+		return fmt.Errorf("nats: no active subscription to topic %s", req.Topic)
+	}
+
+	durableName := req.ConsumerGroup
+	subs := make([]*jetstreamSubscription, 0, len(js.subs[req.Topic]))
+	for _, s := range js.subs[req.Topic] {
+		if s.durableName == "" || (durableName != "" && s.durableName != durableName) {
+			continue
 		}
-		err := subscription.Unsubscribe()
-		if err != nil {
+		durableName = s.durableName
+		subs = append(subs, s)
+	}
+	if len(subs) == 0 {
+		return fmt.Errorf("nats: no active subscription to topic %s with a durable consumer %s", req.Topic, req.ConsumerGroup)
+	}
+
+	consumerConfig := js.consumerConfig(subs[0])
+	if err := applyReplayPosition(&consumerConfig, req); err != nil {
+		return err
+	}
+
+	// The position of an existing consumer can't be changed, so it's deleted and created again
+	for _, s := range subs {
+		if err := s.sub.Unsubscribe(); err != nil {
 			js.l.Warnf("nats: error while unsubscribing from topic %s: %v", req.Topic, err)
 		}
-	}()
+	}
+	err := js.jsc.DeleteConsumer(subs[0].stream, durableName)
+	if err != nil && !errors.Is(err, nats.ErrConsumerNotFound) {
+		return fmt.Errorf("nats: error deleting consumer %s: %w", durableName, err)
+	}
+
+	for _, s := range subs {
+		// All the subscriptions share the same consumer, which is created by the first one
+		s.sub, err = js.subscribe(s, consumerConfig)
+		if err != nil {
+			return fmt.Errorf("nats: error re-subscribing to topic %s: %w", req.Topic, err)
+		}
+	}
 
 	return nil
 }
 
-func (js *jetstreamPubSub) Close() error {
-	defer js.wg.Wait()
-	if js.closed.CompareAndSwap(false, true) {
-		close(js.closeCh)
+// applyReplayPosition sets the deliver policy of a consumer to start from the position of a replay request.
+func applyReplayPosition(consumerConfig *nats.ConsumerConfig, req pubsub.ReplayRequest) error {
+	consumerConfig.OptStartSeq = 0
+	consumerConfig.OptStartTime = nil
+
+	switch {
+	case !req.Timestamp.IsZero():
+		consumerConfig.DeliverPolicy = nats.DeliverByStartTimePolicy
+		consumerConfig.OptStartTime = &req.Timestamp
+	case req.Offset == pubsub.ReplayOffsetEarliest:
+		consumerConfig.DeliverPolicy = nats.DeliverAllPolicy
+	default:
+		seq, err := strconv.ParseUint(req.Offset, 10, 64)
+		if err != nil || seq == 0 {
+			return fmt.Errorf("nats: invalid sequence number '%s'", req.Offset)
+		}
+		consumerConfig.DeliverPolicy = nats.DeliverByStartSequencePolicy
+		consumerConfig.OptStartSeq = seq
 	}
-	return js.nc.Drain()
+	return nil
 }
 
-// Handle nats signature request for challenge response authentication.
-func sigHandler(seedKey string, nonce []byte) ([]byte, error) {
-	kp, err := nkeys.FromSeed([]byte(seedKey))
+// dedupBucket returns the key-value bucket where the IDs of the processed messages are stored, creating it if it doesn't exist.
+func (js *jetstreamPubSub) dedupBucket() (nats.KeyValue, error) {
+	kv, err := js.jsc.KeyValue(js.meta.DedupBucket)
+	if err == nil {
+		return kv, nil
+	}
+	if !errors.Is(err, nats.ErrBucketNotFound) {
+		return nil, fmt.Errorf("nats: error getting deduplication bucket %s: %w", js.meta.DedupBucket, err)
+	}
+
+	ttl := js.meta.DedupTTL
+	if ttl == 0 {
+		ttl = defaultDedupTTL
+	}
+	kv, err = js.jsc.CreateKeyValue(&nats.KeyValueConfig{
+		Bucket:      js.meta.DedupBucket,
+		Description: "Dapr deduplication of processed messages",
+		TTL:         ttl,
+	})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("nats: error creating deduplication bucket %s: %w", js.meta.DedupBucket, err)
 	}
-	// Wipe our key on exit.
-	defer kp.Wipe()
+	return kv, nil
+}
+
+// dedupKey returns the key of a message in the deduplication bucket, or an empty string if deduplication is disabled or the message has no ID.
+// Message IDs can contain characters that aren't valid in keys, so they are hashed.
+func (js *jetstreamPubSub) dedupKey(topic string, msgID string) string {
+	if js.dedup == nil || msgID == "" {
+		return ""
+	}
+	h := sha256.Sum256([]byte(topic + "\x00" + msgID))
+	return hex.EncodeToString(h[:])
+}
 
-	sig, _ := kp.Sign(nonce)
-	return sig, nil
+// isDuplicate returns true if a message was already processed.
+// If the bucket can't be read, the message is processed again.
+func (js *jetstreamPubSub) isDuplicate(key string) bool {
+	_, err := js.dedup.Get(key)
+	if err == nil {
+		return true
+	}
+	if !errors.Is(err, nats.ErrKeyNotFound) {
+		js.l.Warnf("nats: error reading deduplication bucket: %v", err)
+	}
+	return false
+}
+
+// markProcessed records that a message was processed.
+func (js *jetstreamPubSub) markProcessed(key string) {
+	_, err := js.dedup.Put(key, nil)
+	if err != nil {
+		js.l.Warnf("nats: error writing deduplication bucket: %v", err)
+	}
+}
+
+func (js *jetstreamPubSub) Close() error {
+	defer js.wg.Wait()
+	if js.closed.CompareAndSwap(false, true) {
+		close(js.closeCh)
+	}
+	return js.nc.Drain()
 }
 
 // GetComponentMetadata returns the metadata of the component.
@@ -15,6 +15,8 @@ package jetstream
 
 import (
 	"context"
+	"errors"
+	"strconv"
 	"testing"
 	"time"
 
@@ -164,3 +166,394 @@ func TestNewJetStream_DurableQueuePushConsumer(t *testing.T) {
 	case <-time.After(10 * time.Millisecond):
 	}
 }
+
+func TestJetStreamReplay(t *testing.T) {
+	ns, nc := setupServerAndStream(t)
+	defer ns.Shutdown()
+	defer nc.Drain()
+
+	bus := NewJetStream(logger.NewLogger("test"))
+	defer bus.Close()
+
+	err := bus.Init(context.Background(), pubsub.Metadata{
+		Base: mdata.Base{
+			Properties: map[string]string{
+				"natsURL":     ns.ClientURL(),
+				"durableName": "test",
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	replayer, ok := pubsub.As[pubsub.Replayer](bus)
+	assert.True(t, ok)
+
+	ctx := context.Background()
+	err = replayer.Replay(ctx, pubsub.ReplayRequest{Topic: "test", Offset: pubsub.ReplayOffsetEarliest})
+	assert.ErrorContains(t, err, "no active subscription")
+
+	ch := make(chan []byte, 10)
+	err = bus.Subscribe(ctx, pubsub.SubscribeRequest{Topic: "test"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		ch <- msg.Data
+		return nil
+	})
+	assert.NoError(t, err)
+
+	payloads := [][]byte{[]byte(`{"id": "R-1", "data": "1"}`), []byte(`{"id": "R-2", "data": "2"}`)}
+	for _, payload := range payloads {
+		err = bus.Publish(ctx, &pubsub.PublishRequest{Data: payload, Topic: "test"})
+		assert.NoError(t, err)
+	}
+
+	receive := func() []byte {
+		select {
+		case output := <-ch:
+			return output
+		case <-time.After(time.Second):
+			t.Fatal("receive timeout")
+			return nil
+		}
+	}
+	assert.Equal(t, payloads[0], receive())
+	assert.Equal(t, payloads[1], receive())
+
+	// Replay from the second message
+	err = replayer.Replay(ctx, pubsub.ReplayRequest{Topic: "test", Offset: "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, payloads[1], receive())
+
+	// Replay all messages
+	err = replayer.Replay(ctx, pubsub.ReplayRequest{Topic: "test", Offset: pubsub.ReplayOffsetEarliest})
+	assert.NoError(t, err)
+	assert.Equal(t, payloads[0], receive())
+	assert.Equal(t, payloads[1], receive())
+}
+
+func TestJetStreamBulkPublish(t *testing.T) {
+	ns, nc := setupServerAndStream(t)
+	defer ns.Shutdown()
+	defer nc.Drain()
+
+	bus := NewJetStream(logger.NewLogger("test"))
+	defer bus.Close()
+
+	err := bus.Init(context.Background(), pubsub.Metadata{
+		Base: mdata.Base{
+			Properties: map[string]string{
+				"natsURL": ns.ClientURL(),
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	ch := make(chan []byte, 10)
+	ctx := context.Background()
+	err = bus.Subscribe(ctx, pubsub.SubscribeRequest{Topic: "test"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		ch <- msg.Data
+		return nil
+	})
+	assert.NoError(t, err)
+
+	bulkPublisher, ok := bus.(pubsub.BulkPublisher)
+	assert.True(t, ok)
+
+	res, err := bulkPublisher.BulkPublish(ctx, &pubsub.BulkPublishRequest{
+		Topic: "test",
+		Entries: []pubsub.BulkMessageEntry{
+			{EntryId: "1", Event: []byte("one"), Metadata: map[string]string{"messageId": "B-1"}},
+			{EntryId: "2", Event: []byte("two"), Metadata: map[string]string{"messageId": "B-2"}},
+			// Discarded by the duplicate window of the stream
+			{EntryId: "3", Event: []byte("one again"), Metadata: map[string]string{"messageId": "B-1"}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, res.FailedEntries)
+
+	for _, expected := range []string{"one", "two"} {
+		select {
+		case output := <-ch:
+			assert.Equal(t, expected, string(output))
+		case <-time.After(time.Second):
+			t.Fatal("receive timeout")
+		}
+	}
+	select {
+	case output := <-ch:
+		t.Fatalf("unexpected message received: %s", string(output))
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestJetStreamOrderedConsumer(t *testing.T) {
+	ns, nc := setupServerAndStream(t)
+	defer ns.Shutdown()
+	defer nc.Drain()
+
+	bus := NewJetStream(logger.NewLogger("test"))
+	defer bus.Close()
+
+	err := bus.Init(context.Background(), pubsub.Metadata{
+		Base: mdata.Base{
+			Properties: map[string]string{
+				"natsURL": ns.ClientURL(),
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		err = bus.Publish(ctx, &pubsub.PublishRequest{Data: []byte(strconv.Itoa(i)), Topic: "test"})
+		assert.NoError(t, err)
+	}
+
+	// Ordered consumers can't be durable
+	err = bus.Subscribe(ctx, pubsub.SubscribeRequest{
+		Topic:    "test",
+		Metadata: map[string]string{"orderedConsumer": "true", "durableName": "ordered"},
+	}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return nil
+	})
+	assert.Error(t, err)
+
+	ch := make(chan []byte, 10)
+	err = bus.Subscribe(ctx, pubsub.SubscribeRequest{
+		Topic:    "test",
+		Metadata: map[string]string{"orderedConsumer": "true"},
+	}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		ch <- msg.Data
+		return nil
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		select {
+		case output := <-ch:
+			assert.Equal(t, strconv.Itoa(i), string(output))
+		case <-time.After(time.Second):
+			t.Fatal("receive timeout")
+		}
+	}
+
+	// No consumer is created on the server for ordered consumers
+	js, err := nc.JetStream()
+	assert.NoError(t, err)
+	for name := range js.ConsumerNames("test") {
+		info, err := js.ConsumerInfo("test", name)
+		assert.NoError(t, err)
+		assert.Equal(t, nats.AckNonePolicy, info.Config.AckPolicy)
+	}
+}
+
+func TestJetStreamSubscribeDurableName(t *testing.T) {
+	ns, nc := setupServerAndStream(t)
+	defer ns.Shutdown()
+	defer nc.Drain()
+
+	bus := NewJetStream(logger.NewLogger("test"))
+	defer bus.Close()
+
+	err := bus.Init(context.Background(), pubsub.Metadata{
+		Base: mdata.Base{
+			Properties: map[string]string{
+				"natsURL":     ns.ClientURL(),
+				"durableName": "component",
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	err = bus.Subscribe(ctx, pubsub.SubscribeRequest{
+		Topic:    "test",
+		Metadata: map[string]string{"durableName": "subscription"},
+	}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return nil
+	})
+	assert.NoError(t, err)
+
+	js, err := nc.JetStream()
+	assert.NoError(t, err)
+	_, err = js.ConsumerInfo("test", "subscription")
+	assert.NoError(t, err)
+	_, err = js.ConsumerInfo("test", "component")
+	assert.ErrorIs(t, err, nats.ErrConsumerNotFound)
+
+	// Replay uses the durable name of the subscription
+	replayer, _ := pubsub.As[pubsub.Replayer](bus)
+	err = replayer.Replay(ctx, pubsub.ReplayRequest{Topic: "test", ConsumerGroup: "component", Offset: pubsub.ReplayOffsetEarliest})
+	assert.ErrorContains(t, err, "durable consumer component")
+	err = replayer.Replay(ctx, pubsub.ReplayRequest{Topic: "test", ConsumerGroup: "subscription", Offset: pubsub.ReplayOffsetEarliest})
+	assert.NoError(t, err)
+}
+
+func TestJetStreamDedupBucket(t *testing.T) {
+	ns, nc := setupServerAndStream(t)
+	defer ns.Shutdown()
+	defer nc.Drain()
+
+	bus := NewJetStream(logger.NewLogger("test"))
+	defer bus.Close()
+
+	err := bus.Init(context.Background(), pubsub.Metadata{
+		Base: mdata.Base{
+			Properties: map[string]string{
+				"natsURL":     ns.ClientURL(),
+				"dedupBucket": "dedup",
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	js, err := nc.JetStream()
+	assert.NoError(t, err)
+	kv, err := js.KeyValue("dedup")
+	assert.NoError(t, err)
+	status, err := kv.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, defaultDedupTTL, status.TTL())
+
+	// Shorten the duplicate window of the stream so that it doesn't discard the redelivery
+	_, err = js.UpdateStream(&nats.StreamConfig{
+		Name:       "test",
+		Subjects:   []string{"test"},
+		Storage:    nats.MemoryStorage,
+		Duplicates: 100 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+
+	ch := make(chan []byte, 10)
+	ctx := context.Background()
+	err = bus.Subscribe(ctx, pubsub.SubscribeRequest{Topic: "test"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		ch <- msg.Data
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = bus.Publish(ctx, &pubsub.PublishRequest{Data: []byte("first"), Topic: "test", Metadata: map[string]string{"messageId": "D-1"}})
+	assert.NoError(t, err)
+	select {
+	case output := <-ch:
+		assert.Equal(t, "first", string(output))
+	case <-time.After(time.Second):
+		t.Fatal("receive timeout")
+	}
+
+	// A message with the same ID published after the duplicate window of the stream is skipped
+	time.Sleep(200 * time.Millisecond)
+	ack, err := js.Publish("test", []byte("redelivered"), nats.MsgId("D-1"))
+	assert.NoError(t, err)
+	assert.False(t, ack.Duplicate)
+	err = bus.Publish(ctx, &pubsub.PublishRequest{Data: []byte("second"), Topic: "test", Metadata: map[string]string{"messageId": "D-2"}})
+	assert.NoError(t, err)
+
+	select {
+	case output := <-ch:
+		assert.Equal(t, "second", string(output))
+	case <-time.After(time.Second):
+		t.Fatal("receive timeout")
+	}
+}
+
+func TestJetStreamNakAndTerm(t *testing.T) {
+	ns, nc := setupServerAndStream(t)
+	defer ns.Shutdown()
+	defer nc.Drain()
+
+	bus := NewJetStream(logger.NewLogger("test"))
+	defer bus.Close()
+
+	err := bus.Init(context.Background(), pubsub.Metadata{
+		Base: mdata.Base{
+			Properties: map[string]string{
+				"natsURL":     ns.ClientURL(),
+				"durableName": "nak",
+				"nakDelay":    "100ms",
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	type delivery struct {
+		data string
+		at   time.Time
+	}
+	ch := make(chan delivery, 10)
+	ctx := context.Background()
+	err = bus.Subscribe(ctx, pubsub.SubscribeRequest{
+		Topic:    "test",
+		Metadata: map[string]string{"termAfterDeliveries": "3"},
+	}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		ch <- delivery{data: string(msg.Data), at: time.Now()}
+		switch string(msg.Data) {
+		case "poison":
+			return pubsub.NewPermanentError(errors.New("malformed message"))
+		case "delayed":
+			return pubsub.NewRetriableError(errors.New("not ready"), 300*time.Millisecond)
+		default:
+			return errors.New("failed")
+		}
+	})
+	assert.NoError(t, err)
+
+	receive := func() delivery {
+		select {
+		case d := <-ch:
+			return d
+		case <-time.After(2 * time.Second):
+			t.Fatal("receive timeout")
+			return delivery{}
+		}
+	}
+	assertNoDelivery := func() {
+		select {
+		case d := <-ch:
+			t.Fatalf("unexpected delivery of %s", d.data)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+
+	// Messages with permanent errors are terminated after the first delivery
+	err = bus.Publish(ctx, &pubsub.PublishRequest{Data: []byte("poison"), Topic: "test"})
+	assert.NoError(t, err)
+	assert.Equal(t, "poison", receive().data)
+	assertNoDelivery()
+
+	// Other failed messages are redelivered after the delay, and terminated after termAfterDeliveries
+	err = bus.Publish(ctx, &pubsub.PublishRequest{Data: []byte("failed"), Topic: "test"})
+	assert.NoError(t, err)
+	first := receive()
+	second := receive()
+	third := receive()
+	assert.Equal(t, "failed", third.data)
+	assert.GreaterOrEqual(t, second.at.Sub(first.at), 100*time.Millisecond)
+	assert.GreaterOrEqual(t, third.at.Sub(second.at), 100*time.Millisecond)
+	assertNoDelivery()
+
+	// The delay of retriable errors overrides the one of the subscription
+	err = bus.Publish(ctx, &pubsub.PublishRequest{Data: []byte("delayed"), Topic: "test"})
+	assert.NoError(t, err)
+	first = receive()
+	second = receive()
+	assert.GreaterOrEqual(t, second.at.Sub(first.at), 300*time.Millisecond)
+}
+
+func TestRedeliveryDelay(t *testing.T) {
+	s := &jetstreamSubscription{nakDelay: time.Second}
+	assert.Equal(t, time.Second, s.redeliveryDelay(1, errors.New("failed")))
+	assert.Equal(t, time.Second, s.redeliveryDelay(5, errors.New("failed")))
+	assert.Equal(t, time.Minute, s.redeliveryDelay(5, pubsub.NewRetriableError(errors.New("failed"), time.Minute)))
+
+	s.nakMaxDelay = 10 * time.Second
+	assert.Equal(t, time.Second, s.redeliveryDelay(1, errors.New("failed")))
+	assert.Equal(t, 2*time.Second, s.redeliveryDelay(2, errors.New("failed")))
+	assert.Equal(t, 8*time.Second, s.redeliveryDelay(4, errors.New("failed")))
+	assert.Equal(t, 10*time.Second, s.redeliveryDelay(50, errors.New("failed")))
+
+	js, _ := pubsub.As[*jetstreamPubSub](NewJetStream(logger.NewLogger("test")))
+	_, err := js.newSubscription(context.Background(), pubsub.SubscribeRequest{
+		Topic:    "test",
+		Metadata: map[string]string{"nakDelay": "forever"},
+	}, "test", nil)
+	assert.Error(t, err)
+}
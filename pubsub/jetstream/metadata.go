@@ -19,6 +19,7 @@ import (
 
 	"github.com/nats-io/nats.go"
 
+	natscomponent "github.com/dapr/components-contrib/internal/component/nats"
 	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 )
@@ -26,12 +27,7 @@ import (
 type metadata struct {
 	NatsURL string `mapstructure:"natsURL"`
 
-	Jwt     string `mapstructure:"jwt"`
-	SeedKey string `mapstructure:"seedKey"`
-	Token   string `mapstructure:"token"`
-
-	TLSClientCert string `mapstructure:"tls_client_cert"`
-	TLSClientKey  string `mapstructure:"tls_client_key"`
+	natscomponent.ConnectionMetadata `mapstructure:",squash"`
 
 	Name                  string             `mapstructure:"name"`
 	StreamName            string             `mapstructure:"streamName"`
@@ -55,6 +51,16 @@ type metadata struct {
 	internalAckPolicy     nats.AckPolicy     `mapstructure:"-"`
 	Domain                string             `mapstructure:"domain"`
 	APIPrefix             string             `mapstructure:"apiPrefix"`
+	OrderedConsumer       bool               `mapstructure:"orderedConsumer"`
+	DedupBucket           string             `mapstructure:"dedupBucket"`
+	DedupTTL              time.Duration      `mapstructure:"dedupTTL"`
+
+	// Delay before messages whose handler failed are redelivered; if 0, they're redelivered immediately.
+	NakDelay time.Duration `mapstructure:"nakDelay"`
+	// If set, the delay before redelivering failed messages doubles with each delivery, up to this value.
+	NakMaxDelay time.Duration `mapstructure:"nakMaxDelay"`
+	// Number of failed deliveries after which a message is terminated, so that it's not redelivered; if 0, messages are redelivered until maxDeliver is reached.
+	TermAfterDeliveries int `mapstructure:"termAfterDeliveries"`
 }
 
 func parseMetadata(psm pubsub.Metadata) (metadata, error) {
@@ -66,24 +72,20 @@ func parseMetadata(psm pubsub.Metadata) (metadata, error) {
 		return metadata{}, fmt.Errorf("missing nats URL")
 	}
 
-	if m.Jwt != "" && m.SeedKey == "" {
-		return metadata{}, fmt.Errorf("missing seed key")
-	}
-
-	if m.Jwt == "" && m.SeedKey != "" {
-		return metadata{}, fmt.Errorf("missing jwt")
+	if err := m.ConnectionMetadata.Validate(); err != nil {
+		return metadata{}, err
 	}
 
-	if m.TLSClientCert != "" && m.TLSClientKey == "" {
-		return metadata{}, fmt.Errorf("missing tls client key")
+	if m.Name == "" {
+		m.Name = "dapr.io - pubsub.jetstream"
 	}
 
-	if m.TLSClientCert == "" && m.TLSClientKey != "" {
-		return metadata{}, fmt.Errorf("missing tls client cert")
+	if m.OrderedConsumer && (m.DurableName != "" || m.QueueGroupName != "") {
+		return metadata{}, fmt.Errorf("ordered consumers can't have a durable name or a queue group")
 	}
 
-	if m.Name == "" {
-		m.Name = "dapr.io - pubsub.jetstream"
+	if err := validateNakSettings(m.NakDelay, m.NakMaxDelay, m.TermAfterDeliveries); err != nil {
+		return metadata{}, err
 	}
 
 	if m.StartTime != nil {
@@ -118,3 +120,17 @@ func parseMetadata(psm pubsub.Metadata) (metadata, error) {
 
 	return m, nil
 }
+
+// validateNakSettings validates the settings for redelivering messages whose handler failed.
+func validateNakSettings(nakDelay, nakMaxDelay time.Duration, termAfterDeliveries int) error {
+	if nakDelay < 0 || nakMaxDelay < 0 {
+		return fmt.Errorf("nakDelay and nakMaxDelay can't be negative")
+	}
+	if nakMaxDelay != 0 && nakMaxDelay < nakDelay {
+		return fmt.Errorf("nakMaxDelay can't be less than nakDelay")
+	}
+	if termAfterDeliveries < 0 {
+		return fmt.Errorf("termAfterDeliveries can't be negative")
+	}
+	return nil
+}
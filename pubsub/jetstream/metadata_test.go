@@ -20,6 +20,7 @@ import (
 
 	"github.com/nats-io/nats.go"
 
+	natscomponent "github.com/dapr/components-contrib/internal/component/nats"
 	mdata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/kit/ptr"
@@ -119,7 +120,7 @@ func TestParseMetadata(t *testing.T) {
 				MemoryStorage:         true,
 				RateLimit:             20000,
 				Heartbeat:             time.Second * 1,
-				Token:                 "myToken",
+				ConnectionMetadata:    natscomponent.ConnectionMetadata{Token: "myToken"},
 				DeliverPolicy:         "sequence",
 				AckPolicy:             "all",
 				internalDeliverPolicy: nats.DeliverByStartSequencePolicy,
@@ -196,6 +197,85 @@ func TestParseMetadata(t *testing.T) {
 			want:      metadata{},
 			expectErr: true,
 		},
+		{
+			desc: "Invalid metadata with multiple authentication methods",
+			input: pubsub.Metadata{Base: mdata.Base{
+				Properties: map[string]string{
+					"natsURL":         "nats://localhost:4222",
+					"credentialsFile": "/path/to/user.creds",
+					"nkeySeed":        "SUACS34K232OKPRDOMKC6QEWXWUDJTT6R6RZM2WPMURUS5Z3POU7BNIL4Y",
+				},
+			}},
+			want:      metadata{},
+			expectErr: true,
+		},
+		{
+			desc: "Invalid metadata with invalid credentials",
+			input: pubsub.Metadata{Base: mdata.Base{
+				Properties: map[string]string{
+					"natsURL":     "nats://localhost:4222",
+					"credentials": "foo",
+				},
+			}},
+			want:      metadata{},
+			expectErr: true,
+		},
+		{
+			desc: "Invalid metadata with invalid nkey seed",
+			input: pubsub.Metadata{Base: mdata.Base{
+				Properties: map[string]string{
+					"natsURL":  "nats://localhost:4222",
+					"nkeySeed": "foo",
+				},
+			}},
+			want:      metadata{},
+			expectErr: true,
+		},
+		{
+			desc: "Valid metadata with nak settings",
+			input: pubsub.Metadata{Base: mdata.Base{
+				Properties: map[string]string{
+					"natsURL":             "nats://localhost:4222",
+					"nakDelay":            "1s",
+					"nakMaxDelay":         "1m",
+					"termAfterDeliveries": "5",
+				},
+			}},
+			want: metadata{
+				NatsURL:               "nats://localhost:4222",
+				Name:                  "dapr.io - pubsub.jetstream",
+				internalDeliverPolicy: nats.DeliverAllPolicy,
+				internalAckPolicy:     nats.AckExplicitPolicy,
+				NakDelay:              time.Second,
+				NakMaxDelay:           time.Minute,
+				TermAfterDeliveries:   5,
+			},
+			expectErr: false,
+		},
+		{
+			desc: "Invalid metadata with nak max delay less than nak delay",
+			input: pubsub.Metadata{Base: mdata.Base{
+				Properties: map[string]string{
+					"natsURL":     "nats://localhost:4222",
+					"nakDelay":    "1m",
+					"nakMaxDelay": "1s",
+				},
+			}},
+			want:      metadata{},
+			expectErr: true,
+		},
+		{
+			desc: "Invalid metadata with ordered consumer and durable name",
+			input: pubsub.Metadata{Base: mdata.Base{
+				Properties: map[string]string{
+					"natsURL":         "nats://localhost:4222",
+					"durableName":     "myDurable",
+					"orderedConsumer": "true",
+				},
+			}},
+			want:      metadata{},
+			expectErr: true,
+		},
 	}
 	for _, tC := range testCases {
 		t.Run(tC.desc, func(t *testing.T) {
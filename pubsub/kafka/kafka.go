@@ -19,6 +19,7 @@ import (
 	"reflect"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/dapr/kit/logger"
 
@@ -27,11 +28,14 @@ import (
 	"github.com/dapr/components-contrib/metadata"
 
 	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/components-contrib/pubsub/retrypolicy"
 )
 
 type PubSub struct {
-	kafka  *kafka.Kafka
-	logger logger.Logger
+	kafka      *kafka.Kafka
+	topics     *pubsub.TopicMapper
+	rawPayload pubsub.RawPayloadSettings
+	logger     logger.Logger
 
 	closed  atomic.Bool
 	closeCh chan struct{}
@@ -39,6 +43,15 @@ type PubSub struct {
 }
 
 func (p *PubSub) Init(ctx context.Context, metadata pubsub.Metadata) error {
+	var err error
+	p.topics, err = pubsub.ParseTopicMapping(metadata.Properties)
+	if err != nil {
+		return err
+	}
+	p.rawPayload, err = pubsub.ParseRawPayloadSettings(metadata)
+	if err != nil {
+		return err
+	}
 	return p.kafka.Init(ctx, metadata.Properties)
 }
 
@@ -47,9 +60,14 @@ func (p *PubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, han
 		return errors.New("component is closed")
 	}
 
+	handlerSettings, err := pubsub.HandlerSettingsFromMetadata(req.Metadata, pubsub.HandlerSettings{})
+	if err != nil {
+		return err
+	}
+
 	handlerConfig := kafka.SubscriptionHandlerConfig{
 		IsBulkSubscribe: false,
-		Handler:         adaptHandler(handler),
+		Handler:         adaptHandler(handlerSettings.Handler(p.kafka.Metrics.InstrumentHandler(p.topics.Handler(req.Topic, p.rawPayload.Handler(req.Metadata, handler))))),
 	}
 	return p.subscribeUtil(ctx, req, handlerConfig)
 }
@@ -61,6 +79,11 @@ func (p *PubSub) BulkSubscribe(ctx context.Context, req pubsub.SubscribeRequest,
 		return errors.New("component is closed")
 	}
 
+	handlerSettings, err := pubsub.HandlerSettingsFromMetadata(req.Metadata, pubsub.HandlerSettings{})
+	if err != nil {
+		return err
+	}
+
 	subConfig := pubsub.BulkSubscribeConfig{
 		MaxMessagesCount:   utils.GetIntValOrDefault(req.BulkSubscribeConfig.MaxMessagesCount, kafka.DefaultMaxBulkSubCount),
 		MaxAwaitDurationMs: utils.GetIntValOrDefault(req.BulkSubscribeConfig.MaxAwaitDurationMs, kafka.DefaultMaxBulkSubAwaitDurationMs),
@@ -68,13 +91,20 @@ func (p *PubSub) BulkSubscribe(ctx context.Context, req pubsub.SubscribeRequest,
 	handlerConfig := kafka.SubscriptionHandlerConfig{
 		IsBulkSubscribe: true,
 		SubscribeConfig: subConfig,
-		BulkHandler:     adaptBulkHandler(handler),
+		BulkHandler:     adaptBulkHandler(handlerSettings.BulkHandler(p.kafka.Metrics.InstrumentBulkHandler(p.topics.BulkHandler(req.Topic, p.rawPayload.BulkHandler(req.Metadata, handler))))),
 	}
 	return p.subscribeUtil(ctx, req, handlerConfig)
 }
 
 func (p *PubSub) subscribeUtil(ctx context.Context, req pubsub.SubscribeRequest, handlerConfig kafka.SubscriptionHandlerConfig) error {
-	p.kafka.AddTopicHandler(req.Topic, handlerConfig)
+	handlerConfig.ConsumerGroup = req.Metadata[kafka.ConsumerGroupMetadataKey]
+	valueSchemaType, err := kafka.ParseSchemaType(req.Metadata[kafka.ValueSchemaTypeMetadataKey])
+	if err != nil {
+		return err
+	}
+	handlerConfig.ValueSchemaType = valueSchemaType
+	topic := p.topics.Physical(req.Topic)
+	p.kafka.AddTopicHandler(topic, handlerConfig)
 
 	p.wg.Add(1)
 	go func() {
@@ -86,7 +116,7 @@ func (p *PubSub) subscribeUtil(ctx context.Context, req pubsub.SubscribeRequest,
 		}
 
 		// Remove the topic handler before restarting the subscriber
-		p.kafka.RemoveTopicHandler(req.Topic)
+		p.kafka.RemoveTopicHandler(topic)
 
 		// If the component's context has been canceled, do not re-subscribe
 		if ctx.Err() != nil {
@@ -107,11 +137,11 @@ func NewKafka(logger logger.Logger) pubsub.PubSub {
 	k := kafka.NewKafka(logger)
 	// in kafka pubsub component, enable consumer retry by default
 	k.DefaultConsumeRetryEnabled = true
-	return &PubSub{
+	return pubsub.WithSchemaValidation(&PubSub{
 		kafka:   k,
 		logger:  logger,
 		closeCh: make(chan struct{}),
-	}
+	})
 }
 
 // Publish message to Kafka cluster.
@@ -120,7 +150,18 @@ func (p *PubSub) Publish(ctx context.Context, req *pubsub.PublishRequest) error
 		return errors.New("component is closed")
 	}
 
-	return p.kafka.Publish(ctx, req.Topic, req.Data, req.Metadata)
+	// Kafka doesn't support a TTL per message: it's enforced when the messages are delivered
+	md, err := pubsub.WithMessageTTLMetadata(req.Metadata, time.Now())
+	if err != nil {
+		return err
+	}
+
+	data, err := p.rawPayload.Payload(req.Data, req.ContentType, req.Metadata)
+	if err != nil {
+		return err
+	}
+
+	return p.kafka.Publish(ctx, p.topics.Physical(req.Topic), data, md)
 }
 
 // BatchPublish messages to Kafka cluster.
@@ -129,7 +170,21 @@ func (p *PubSub) BulkPublish(ctx context.Context, req *pubsub.BulkPublishRequest
 		return pubsub.BulkPublishResponse{}, errors.New("component is closed")
 	}
 
-	return p.kafka.BulkPublish(ctx, req.Topic, req.Entries, req.Metadata)
+	md, err := pubsub.WithMessageTTLMetadata(req.Metadata, time.Now())
+	if err != nil {
+		return pubsub.NewBulkPublishResponse(req.Entries, err), err
+	}
+
+	entries := make([]pubsub.BulkMessageEntry, len(req.Entries))
+	for i, entry := range req.Entries {
+		entry.Event, err = p.rawPayload.Payload(entry.Event, &entry.ContentType, req.Metadata)
+		if err != nil {
+			return pubsub.NewBulkPublishResponse(req.Entries, err), err
+		}
+		entries[i] = entry
+	}
+
+	return p.kafka.BulkPublish(ctx, p.topics.Physical(req.Topic), entries, md)
 }
 
 func (p *PubSub) Close() (err error) {
@@ -140,8 +195,23 @@ func (p *PubSub) Close() (err error) {
 	return p.kafka.Close()
 }
 
+// SetMetricsHook sets the hook that receives the metrics of the component.
+func (p *PubSub) SetMetricsHook(hook pubsub.MetricsHook) {
+	p.kafka.Metrics.SetMetricsHook(hook)
+}
+
+// WaitSubscriptionsReady blocks until the consumer groups of the subscriptions joined the Kafka clusters, or until ctx is done.
+func (p *PubSub) WaitSubscriptionsReady(ctx context.Context) error {
+	return p.kafka.Subscriptions.WaitSubscriptionsReady(ctx)
+}
+
+// SubscriptionsStatus returns the status of the subscriptions of the component.
+func (p *PubSub) SubscriptionsStatus() []pubsub.SubscriptionStatus {
+	return p.kafka.Subscriptions.SubscriptionsStatus()
+}
+
 func (p *PubSub) Features() []pubsub.Feature {
-	return []pubsub.Feature{pubsub.FeatureBulkPublish}
+	return []pubsub.Feature{pubsub.FeatureBulkPublish, pubsub.FeatureMessageTTL}
 }
 
 func adaptHandler(handler pubsub.Handler) kafka.EventHandler {
@@ -181,5 +251,38 @@ func (p *PubSub) GetComponentMetadata() map[string]string {
 	metadataStruct := kafka.KafkaMetadata{}
 	metadataInfo := map[string]string{}
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.PubSubType)
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(retrypolicy.Settings{}), &metadataInfo, metadata.PubSubType)
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(pubsub.TopicMappingProperties{}), &metadataInfo, metadata.PubSubType)
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(pubsub.RawPayloadSettings{}), &metadataInfo, metadata.PubSubType)
 	return metadataInfo
 }
+
+// Replay resets the offsets of a consumer group on a topic.
+func (p *PubSub) Replay(ctx context.Context, req pubsub.ReplayRequest) error {
+	if p.closed.Load() {
+		return errors.New("component is closed")
+	}
+
+	req.Topic = p.topics.Physical(req.Topic)
+	return p.kafka.Replay(ctx, req)
+}
+
+// Pause stops the consumption of a topic, or of some of its partitions, without leaving the consumer group.
+func (p *PubSub) Pause(ctx context.Context, req pubsub.PauseRequest) error {
+	if p.closed.Load() {
+		return errors.New("component is closed")
+	}
+
+	req.Topic = p.topics.Physical(req.Topic)
+	return p.kafka.Pause(ctx, req)
+}
+
+// Resume restarts the consumption of a topic, or of some of its partitions, that was paused.
+func (p *PubSub) Resume(ctx context.Context, req pubsub.PauseRequest) error {
+	if p.closed.Load() {
+		return errors.New("component is closed")
+	}
+
+	req.Topic = p.topics.Physical(req.Topic)
+	return p.kafka.Resume(ctx, req)
+}
@@ -21,9 +21,9 @@ type kubeMQ struct {
 }
 
 func NewKubeMQ(logger logger.Logger) pubsub.PubSub {
-	return &kubeMQ{
+	return pubsub.WithSchemaValidation(&kubeMQ{
 		logger: logger,
-	}
+	})
 }
 
 func (k *kubeMQ) Init(_ context.Context, metadata pubsub.Metadata) error {
@@ -0,0 +1,168 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MetricsHook receives the metrics of a pub/sub component.
+// Methods are invoked synchronously on the publishing or receiving goroutine, so implementations must be safe for concurrent use and must not block.
+type MetricsHook interface {
+	// MessagePublished is invoked after a message is published to a topic.
+	// err is the error returned by the broker, if any.
+	MessagePublished(topic string, err error, latency time.Duration)
+	// MessageDelivered is invoked after a message is delivered to the app handler.
+	// err is the error returned by the handler, if any, and latency is the time spent in the handler.
+	MessageDelivered(topic string, err error, latency time.Duration)
+	// MessageRetried is invoked when a message is delivered again after a failure.
+	MessageRetried(topic string)
+	// MessageDeadLettered is invoked when a message is moved to a dead-letter queue.
+	MessageDeadLettered(topic string)
+}
+
+// LagMetricsHook is an extension to MetricsHook implemented by hooks that receive the lag of consumer groups.
+type LagMetricsHook interface {
+	// ConsumerGroupLag is invoked periodically with the number of messages of a topic that were not delivered yet to a consumer group,
+	// and the number of messages that were delivered but not acknowledged yet.
+	// lag is -1 if the broker can't compute it.
+	ConsumerGroupLag(topic string, group string, lag int64, pending int64)
+}
+
+// MetricsReporter is the interface implemented by pub/sub components that report metrics.
+type MetricsReporter interface {
+	// SetMetricsHook sets the hook that receives the metrics of the component.
+	// A nil hook disables the metrics.
+	SetMetricsHook(hook MetricsHook)
+}
+
+// SetMetricsHook sets the hook on the component if it supports reporting metrics, and returns true if it does.
+func SetMetricsHook(pubsub PubSub, hook MetricsHook) bool {
+	reporter, ok := As[MetricsReporter](pubsub)
+	if !ok {
+		return false
+	}
+	reporter.SetMetricsHook(hook)
+	return true
+}
+
+// Metrics is used by components to report metrics to a MetricsHook.
+// The zero value is ready to use and discards all metrics until a hook is set; a nil *Metrics discards all metrics too.
+type Metrics struct {
+	hook MetricsHook
+	lock sync.RWMutex
+}
+
+// SetMetricsHook sets the hook that receives the metrics.
+func (m *Metrics) SetMetricsHook(hook MetricsHook) {
+	m.lock.Lock()
+	m.hook = hook
+	m.lock.Unlock()
+}
+
+func (m *Metrics) getHook() MetricsHook {
+	if m == nil {
+		return nil
+	}
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.hook
+}
+
+// Published reports that a message was published, with the latency computed from start.
+func (m *Metrics) Published(topic string, err error, start time.Time) {
+	if hook := m.getHook(); hook != nil {
+		hook.MessagePublished(topic, err, time.Since(start))
+	}
+}
+
+// BulkPublished reports that a batch of messages was published, with the latency computed from start.
+// Each entry is reported as a separate message, with the error of its failed entry in res if any.
+func (m *Metrics) BulkPublished(topic string, entries []BulkMessageEntry, res BulkPublishResponse, start time.Time) {
+	hook := m.getHook()
+	if hook == nil {
+		return
+	}
+
+	latency := time.Since(start)
+	failed := make(map[string]error, len(res.FailedEntries))
+	for _, f := range res.FailedEntries {
+		failed[f.EntryId] = f.Error
+	}
+	for _, entry := range entries {
+		hook.MessagePublished(topic, failed[entry.EntryId], latency)
+	}
+}
+
+// Retried reports that a message is being delivered again.
+func (m *Metrics) Retried(topic string) {
+	if hook := m.getHook(); hook != nil {
+		hook.MessageRetried(topic)
+	}
+}
+
+// DeadLettered reports that a message was moved to a dead-letter queue.
+func (m *Metrics) DeadLettered(topic string) {
+	if hook := m.getHook(); hook != nil {
+		hook.MessageDeadLettered(topic)
+	}
+}
+
+// ConsumerGroupLag reports the lag of a consumer group, if the hook implements LagMetricsHook.
+func (m *Metrics) ConsumerGroupLag(topic string, group string, lag int64, pending int64) {
+	if hook, ok := m.getHook().(LagMetricsHook); ok {
+		hook.ConsumerGroupLag(topic, group, lag, pending)
+	}
+}
+
+// InstrumentHandler returns a Handler that reports the deliveries of messages to handler.
+func (m *Metrics) InstrumentHandler(handler Handler) Handler {
+	return func(ctx context.Context, msg *NewMessage) error {
+		start := time.Now()
+		err := handler(ctx, msg)
+		if hook := m.getHook(); hook != nil {
+			hook.MessageDelivered(msg.Topic, err, time.Since(start))
+		}
+		return err
+	}
+}
+
+// InstrumentBulkHandler returns a BulkHandler that reports the deliveries of batches of messages to handler.
+// Each message in the batch is reported as a separate delivery, with the error of its own response entry if any.
+func (m *Metrics) InstrumentBulkHandler(handler BulkHandler) BulkHandler {
+	return func(ctx context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error) {
+		start := time.Now()
+		res, err := handler(ctx, msg)
+		hook := m.getHook()
+		if hook == nil {
+			return res, err
+		}
+
+		latency := time.Since(start)
+		entryErrs := make(map[string]error, len(res))
+		for _, r := range res {
+			entryErrs[r.EntryId] = r.Error
+		}
+		for _, entry := range msg.Entries {
+			entryErr, ok := entryErrs[entry.EntryId]
+			if !ok {
+				entryErr = err
+			}
+			hook.MessageDelivered(msg.Topic, entryErr, latency)
+		}
+		return res, err
+	}
+}
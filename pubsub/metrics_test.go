@@ -0,0 +1,164 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingHook struct {
+	published    map[string][]error
+	delivered    map[string][]error
+	retried      map[string]int
+	deadLettered map[string]int
+	lock         sync.Mutex
+}
+
+func newRecordingHook() *recordingHook {
+	return &recordingHook{
+		published:    map[string][]error{},
+		delivered:    map[string][]error{},
+		retried:      map[string]int{},
+		deadLettered: map[string]int{},
+	}
+}
+
+func (h *recordingHook) MessagePublished(topic string, err error, _ time.Duration) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.published[topic] = append(h.published[topic], err)
+}
+
+func (h *recordingHook) MessageDelivered(topic string, err error, _ time.Duration) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.delivered[topic] = append(h.delivered[topic], err)
+}
+
+func (h *recordingHook) MessageRetried(topic string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.retried[topic]++
+}
+
+func (h *recordingHook) MessageDeadLettered(topic string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.deadLettered[topic]++
+}
+
+type metricsPubSub struct {
+	PubSub
+	Metrics
+}
+
+func TestMetrics(t *testing.T) {
+	errTest := errors.New("test")
+
+	t.Run("no hook", func(t *testing.T) {
+		var m Metrics
+		m.Published("topic", nil, time.Now())
+		m.Retried("topic")
+		m.DeadLettered("topic")
+		err := m.InstrumentHandler(func(ctx context.Context, msg *NewMessage) error {
+			return errTest
+		})(context.Background(), &NewMessage{Topic: "topic"})
+		require.ErrorIs(t, err, errTest)
+
+		var nilMetrics *Metrics
+		nilMetrics.Retried("topic")
+	})
+
+	t.Run("counters", func(t *testing.T) {
+		hook := newRecordingHook()
+		var m Metrics
+		m.SetMetricsHook(hook)
+
+		m.Published("topic", nil, time.Now())
+		m.Published("topic", errTest, time.Now())
+		m.Retried("topic")
+		m.DeadLettered("other")
+
+		assert.Equal(t, []error{nil, errTest}, hook.published["topic"])
+		assert.Equal(t, 1, hook.retried["topic"])
+		assert.Equal(t, 1, hook.deadLettered["other"])
+	})
+
+	t.Run("bulk published", func(t *testing.T) {
+		hook := newRecordingHook()
+		var m Metrics
+		m.SetMetricsHook(hook)
+
+		entries := []BulkMessageEntry{{EntryId: "1"}, {EntryId: "2"}}
+		m.BulkPublished("topic", entries, BulkPublishResponse{
+			FailedEntries: []BulkPublishResponseFailedEntry{{EntryId: "2", Error: errTest}},
+		}, time.Now())
+
+		assert.Equal(t, []error{nil, errTest}, hook.published["topic"])
+	})
+
+	t.Run("instrument handler", func(t *testing.T) {
+		hook := newRecordingHook()
+		var m Metrics
+		m.SetMetricsHook(hook)
+
+		handler := m.InstrumentHandler(func(ctx context.Context, msg *NewMessage) error {
+			if string(msg.Data) == "fail" {
+				return errTest
+			}
+			return nil
+		})
+		require.NoError(t, handler(context.Background(), &NewMessage{Topic: "topic", Data: []byte("ok")}))
+		require.ErrorIs(t, handler(context.Background(), &NewMessage{Topic: "topic", Data: []byte("fail")}), errTest)
+
+		assert.Equal(t, []error{nil, errTest}, hook.delivered["topic"])
+	})
+
+	t.Run("instrument bulk handler", func(t *testing.T) {
+		hook := newRecordingHook()
+		var m Metrics
+		m.SetMetricsHook(hook)
+
+		handler := m.InstrumentBulkHandler(func(ctx context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error) {
+			return []BulkSubscribeResponseEntry{
+				{EntryId: "1"},
+				{EntryId: "2", Error: errTest},
+			}, errTest
+		})
+		_, err := handler(context.Background(), &BulkMessage{
+			Topic:   "topic",
+			Entries: []BulkMessageEntry{{EntryId: "1"}, {EntryId: "2"}},
+		})
+		require.ErrorIs(t, err, errTest)
+
+		assert.Equal(t, []error{nil, errTest}, hook.delivered["topic"])
+	})
+
+	t.Run("set hook on component", func(t *testing.T) {
+		hook := newRecordingHook()
+		ps := &metricsPubSub{}
+		assert.True(t, SetMetricsHook(ps, hook))
+		ps.Retried("topic")
+		assert.Equal(t, 1, hook.retried["topic"])
+
+		assert.False(t, SetMetricsHook(struct{ PubSub }{}, hook))
+	})
+}
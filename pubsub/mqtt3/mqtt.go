@@ -37,7 +37,12 @@ import (
 
 const (
 	// Keys for request metadata
-	unsubscribeOnCloseKey = "unsubscribeOnClose"
+	unsubscribeOnCloseKey      = "unsubscribeOnClose"
+	sharedSubscriptionGroupKey = "sharedSubscriptionGroup"
+	skipRetainedKey            = "skipRetained"
+
+	// Prefix for shared subscriptions
+	sharedSubscriptionPrefix = "$share/"
 )
 
 // mqttPubSub type allows sending and receiving data to/from MQTT broker.
@@ -54,18 +59,20 @@ type mqttPubSub struct {
 }
 
 type mqttPubSubSubscription struct {
-	handler pubsub.Handler
-	alias   string
-	matcher func(topic string) bool
+	handler      pubsub.Handler
+	alias        string
+	matcher      func(topic string) bool
+	qos          byte
+	skipRetained bool
 }
 
 // NewMQTTPubSub returns a new mqttPubSub instance.
 func NewMQTTPubSub(logger logger.Logger) pubsub.PubSub {
-	return &mqttPubSub{
+	return pubsub.WithSchemaValidation(&mqttPubSub{
 		logger:      logger,
 		reconnectCh: make(chan struct{}),
 		closeCh:     make(chan struct{}),
-	}
+	})
 }
 
 // Init parses metadata and creates a new Pub Sub client.
@@ -131,6 +138,9 @@ func (m *mqttPubSub) Publish(ctx context.Context, req *pubsub.PublishRequest) (e
 // Subscribe to the topic on MQTT.
 // Request metadata includes:
 // - "unsubscribeOnClose": if true, when the subscription is stopped (context canceled), then an Unsubscribe message is sent to the MQTT broker, which will stop delivering messages to this consumer ID until the subscription is explicitly re-started with a new Subscribe call. Otherwise, messages continue to be delivered but are not handled and are NACK'd automatically. "unsubscribeOnClose" should be used with dynamic subscriptions.
+// - "qos": QoS level to use for this subscription, overriding the one set in the component's metadata.
+// - "sharedSubscriptionGroup": if set, subscribes to the topic as part of the shared subscription group with this name (i.e. "$share/<group>/<topic>"), so messages are load-balanced across all consumers in the group.
+// - "skipRetained": if true, retained messages delivered by the broker are acknowledged without being passed to the handler.
 func (m *mqttPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
 	if m.closed.Load() {
 		return errors.New("component is closed")
@@ -142,13 +152,32 @@ func (m *mqttPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRequest,
 	}
 	unsubscribeOnClose := utils.IsTruthy(req.Metadata[unsubscribeOnCloseKey])
 
+	qos := m.metadata.Qos
+	if val := req.Metadata[mqttQOS]; val != "" {
+		parsed, err := strconv.ParseUint(val, 10, 8)
+		if err != nil || parsed > 2 {
+			return fmt.Errorf("mqtt invalid qos %s: must be 0, 1, or 2", val)
+		}
+		qos = byte(parsed)
+	}
+
+	if group := req.Metadata[sharedSubscriptionGroupKey]; group != "" {
+		if strings.HasPrefix(topic, sharedSubscriptionPrefix) {
+			return fmt.Errorf("mqtt cannot use %s with topic %s, which is already a shared subscription", sharedSubscriptionGroupKey, topic)
+		}
+		if strings.ContainsAny(group, "/+#") {
+			return fmt.Errorf("mqtt invalid %s %s: must not contain '/', '+', or '#'", sharedSubscriptionGroupKey, group)
+		}
+		topic = sharedSubscriptionPrefix + group + "/" + topic
+	}
+
 	m.subscribingLock.Lock()
 	defer m.subscribingLock.Unlock()
 
 	// Add the topic then start the subscription
-	m.addTopic(topic, handler)
+	m.addTopic(topic, handler, qos, utils.IsTruthy(req.Metadata[skipRetainedKey]))
 
-	token := m.conn.Subscribe(topic, m.metadata.Qos, m.onMessage(ctx))
+	token := m.conn.Subscribe(topic, qos, m.onMessage(ctx))
 	var err error
 	select {
 	case <-token.Done():
@@ -165,7 +194,7 @@ func (m *mqttPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRequest,
 		return fmt.Errorf("mqtt error from subscribe: %v", err)
 	}
 
-	m.logger.Infof("MQTT is subscribed to topic %s (qos: %d)", topic, m.metadata.Qos)
+	m.logger.Infof("MQTT is subscribed to topic %s (qos: %d)", topic, qos)
 
 	// Listen for context cancelation to remove the subscription
 	m.wg.Add(1)
@@ -217,14 +246,20 @@ func (m *mqttPubSub) onMessage(ctx context.Context) func(client mqtt.Client, mqt
 			Metadata: map[string]string{"retained": strconv.FormatBool(mqttMsg.Retained())},
 		}
 
-		topicHandler := m.handlerForTopic(msg.Topic)
-		if topicHandler == nil {
+		sub, ok := m.subscriptionForTopic(msg.Topic)
+		if !ok {
 			m.logger.Warnf("No handler defined for messages received on topic %s", msg.Topic)
 			return
 		}
 
+		if sub.skipRetained && mqttMsg.Retained() {
+			m.logger.Debugf("Skipping retained MQTT message %s#%d; sending ACK", mqttMsg.Topic(), mqttMsg.MessageID())
+			mqttMsg.Ack()
+			return
+		}
+
 		m.logger.Debugf("Processing MQTT message %s#%d (retained=%v)", mqttMsg.Topic(), mqttMsg.MessageID(), mqttMsg.Retained())
-		err := topicHandler(ctx, &msg)
+		err := sub.handler(ctx, &msg)
 		if err != nil {
 			m.logger.Errorf("Failed processing MQTT message %s#%d: %v", mqttMsg.Topic(), mqttMsg.MessageID(), err)
 			return
@@ -235,28 +270,31 @@ func (m *mqttPubSub) onMessage(ctx context.Context) func(client mqtt.Client, mqt
 	}
 }
 
-// Returns the handler for a message sent to a given topic, supporting wildcards and other special syntaxes.
-func (m *mqttPubSub) handlerForTopic(topic string) pubsub.Handler {
+// Returns the subscription for a message sent to a given topic, supporting wildcards and other special syntaxes.
+func (m *mqttPubSub) subscriptionForTopic(topic string) (mqttPubSubSubscription, bool) {
 	m.subscribingLock.RLock()
 	defer m.subscribingLock.RUnlock()
 
 	// First, try to see if we have a handler for the exact topic (no wildcards etc)
-	topicHandler, ok := m.topics[topic]
-	if ok && topicHandler.handler != nil {
-		return topicHandler.handler
+	obj, ok := m.topics[topic]
+	if ok && obj.handler != nil {
+		return obj, true
 	}
 
 	// Iterate through the topics and run the matchers
 	for _, obj := range m.topics {
+		if obj.handler == nil {
+			continue
+		}
 		if obj.alias == topic {
-			return obj.handler
+			return obj, true
 		}
 		if obj.matcher != nil && obj.matcher(topic) {
-			return obj.handler
+			return obj, true
 		}
 	}
 
-	return nil
+	return mqttPubSubSubscription{}, false
 }
 
 func (m *mqttPubSub) doConnect(ctx context.Context, clientID string) (mqtt.Client, error) {
@@ -332,8 +370,8 @@ func (m *mqttPubSub) createClientOptions(uri *url.URL, clientID string) *mqtt.Cl
 
 		// Create the list of topics to subscribe to
 		subscribeTopics := make(map[string]byte, len(m.topics))
-		for k := range m.topics {
-			subscribeTopics[k] = m.metadata.Qos
+		for k, obj := range m.topics {
+			subscribeTopics[k] = obj.qos
 		}
 
 		// Note that this is a bit unusual for a pubsub component as we're using a background context for the handler.
@@ -423,9 +461,11 @@ func (m *mqttPubSub) Features() []pubsub.Feature {
 var sharedSubscriptionMatch = regexp.MustCompile(`^\$share\/(.*?)\/.`)
 
 // Adds a topic to the list of subscriptions.
-func (m *mqttPubSub) addTopic(origTopicName string, handler pubsub.Handler) {
+func (m *mqttPubSub) addTopic(origTopicName string, handler pubsub.Handler, qos byte, skipRetained bool) {
 	obj := mqttPubSubSubscription{
-		handler: handler,
+		handler:      handler,
+		qos:          qos,
+		skipRetained: skipRetained,
 	}
 
 	// Shared subscriptions begin with "$share/GROUPID/" and we can remove that prefix
@@ -712,3 +712,101 @@ func Test_mqttPubSub_Publish(t *testing.T) {
 		})
 	}
 }
+
+func Test_mqttPubSub_Subscribe(t *testing.T) {
+	newPubSub := func(msgCh chan mqttMessage) *mqttPubSub {
+		return &mqttPubSub{
+			conn:    newMockedMQTTClient(msgCh),
+			logger:  logger.NewLogger("mqtt-test"),
+			topics:  map[string]mqttPubSubSubscription{},
+			closeCh: make(chan struct{}),
+			metadata: &mqttMetadata{
+				Qos: 1,
+			},
+		}
+	}
+
+	t.Run("uses the component's qos by default", func(t *testing.T) {
+		msgCh := make(chan mqttMessage)
+		defer close(msgCh)
+		m := newPubSub(msgCh)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		err := m.Subscribe(ctx, pubsub.SubscribeRequest{Topic: "test"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+			return nil
+		})
+		require.NoError(t, err)
+
+		require.Contains(t, m.topics, "test")
+		assert.Equal(t, byte(1), m.topics["test"].qos)
+		assert.False(t, m.topics["test"].skipRetained)
+	})
+
+	t.Run("shared subscription with qos override and skipRetained", func(t *testing.T) {
+		msgCh := make(chan mqttMessage)
+		defer close(msgCh)
+		m := newPubSub(msgCh)
+
+		received := make(chan *pubsub.NewMessage, 2)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		err := m.Subscribe(ctx, pubsub.SubscribeRequest{
+			Topic: "test",
+			Metadata: map[string]string{
+				"qos":                     "2",
+				"sharedSubscriptionGroup": "mygroup",
+				"skipRetained":            "true",
+			},
+		}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+			received <- msg
+			return nil
+		})
+		require.NoError(t, err)
+
+		require.Contains(t, m.topics, "$share/mygroup/test")
+		sub := m.topics["$share/mygroup/test"]
+		assert.Equal(t, byte(2), sub.qos)
+		assert.True(t, sub.skipRetained)
+		assert.Equal(t, "test", sub.alias)
+
+		msgCh <- mqttMessage{topic: "test", data: []byte("retained"), retained: true}
+		msgCh <- mqttMessage{topic: "test", data: []byte("hello")}
+
+		select {
+		case msg := <-received:
+			assert.Equal(t, "test", msg.Topic)
+			assert.Equal(t, []byte("hello"), msg.Data)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for message")
+		}
+		assert.Empty(t, received)
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		m := newPubSub(make(chan mqttMessage))
+		handler := func(ctx context.Context, msg *pubsub.NewMessage) error {
+			return nil
+		}
+
+		err := m.Subscribe(context.Background(), pubsub.SubscribeRequest{
+			Topic:    "test",
+			Metadata: map[string]string{"qos": "3"},
+		}, handler)
+		require.Error(t, err)
+
+		err = m.Subscribe(context.Background(), pubsub.SubscribeRequest{
+			Topic:    "test",
+			Metadata: map[string]string{"sharedSubscriptionGroup": "a/b"},
+		}, handler)
+		require.Error(t, err)
+
+		err = m.Subscribe(context.Background(), pubsub.SubscribeRequest{
+			Topic:    "$share/other/test",
+			Metadata: map[string]string{"sharedSubscriptionGroup": "mygroup"},
+		}, handler)
+		require.Error(t, err)
+
+		assert.Empty(t, m.topics)
+	})
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nats
+
+import (
+	"fmt"
+
+	natscomponent "github.com/dapr/components-contrib/internal/component/nats"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+type metadata struct {
+	NatsURL string `mapstructure:"natsURL"`
+
+	natscomponent.ConnectionMetadata `mapstructure:",squash"`
+
+	Name string `mapstructure:"name"`
+	// Subscribers in the same queue group share the messages of a topic, each message being delivered to only one of them.
+	// Without a queue group, every subscriber receives all the messages.
+	QueueGroupName string `mapstructure:"queueGroupName"`
+}
+
+func parseMetadata(psm pubsub.Metadata) (metadata, error) {
+	var m metadata
+
+	err := contribMetadata.DecodeMetadata(psm.Properties, &m)
+	if err != nil {
+		return metadata{}, err
+	}
+
+	if m.NatsURL == "" {
+		return metadata{}, fmt.Errorf("missing nats URL")
+	}
+
+	if err := m.ConnectionMetadata.Validate(); err != nil {
+		return metadata{}, err
+	}
+
+	if m.Name == "" {
+		m.Name = "dapr.io - pubsub.nats"
+	}
+
+	return m, nil
+}
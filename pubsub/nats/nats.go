@@ -0,0 +1,186 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package nats implements a pubsub component for NATS core, without JetStream.
+// Messages aren't persisted and are delivered at most once: messages published while there are no subscribers, or that fail to be processed, are lost.
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	mdutils "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+// Key of the metadata of subscribe requests that overrides the queue group of the component.
+const subscribeQueueGroupNameKey = "queueGroupName"
+
+type natsPubSub struct {
+	nc   *nats.Conn
+	l    logger.Logger
+	meta metadata
+
+	closed  atomic.Bool
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewNATSPubSub returns a new NATS core pubsub.
+func NewNATSPubSub(logger logger.Logger) pubsub.PubSub {
+	return pubsub.WithSchemaValidation(&natsPubSub{
+		l:       logger,
+		closeCh: make(chan struct{}),
+	})
+}
+
+func (n *natsPubSub) Init(_ context.Context, metadata pubsub.Metadata) error {
+	var err error
+	n.meta, err = parseMetadata(metadata)
+	if err != nil {
+		return err
+	}
+
+	opts, err := n.meta.ConnectOptions(n.meta.Name, n.l)
+	if err != nil {
+		return err
+	}
+
+	n.nc, err = nats.Connect(n.meta.NatsURL, opts...)
+	if err != nil {
+		return err
+	}
+	n.l.Debugf("Connected to nats at %s", n.meta.NatsURL)
+
+	return nil
+}
+
+func (n *natsPubSub) Features() []pubsub.Feature {
+	return []pubsub.Feature{pubsub.FeatureMessageTTL}
+}
+
+// Publish sends a message to the subject of the topic.
+// NATS core doesn't support a TTL per message, so the TTL is sent in the headers of the message and enforced by the subscribers.
+func (n *natsPubSub) Publish(_ context.Context, req *pubsub.PublishRequest) error {
+	if n.closed.Load() {
+		return errors.New("component is closed")
+	}
+
+	msg := nats.NewMsg(req.Topic)
+	msg.Data = req.Data
+	ttlMetadata, err := pubsub.MessageTTLMetadata(req.Metadata, time.Now())
+	if err != nil {
+		return err
+	}
+	for k, v := range ttlMetadata {
+		msg.Header.Set(k, v)
+	}
+
+	n.l.Debugf("Publishing to topic %s", req.Topic)
+	return n.nc.PublishMsg(msg)
+}
+
+// Subscribe subscribes to the subject of the topic, in the queue group of the component or of the request if any.
+func (n *natsPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	if n.closed.Load() {
+		return errors.New("component is closed")
+	}
+
+	queueGroup := n.meta.QueueGroupName
+	if v := req.Metadata[subscribeQueueGroupNameKey]; v != "" {
+		queueGroup = v
+	}
+
+	natsHandler := func(m *nats.Msg) {
+		md := make(map[string]string, len(m.Header)+1)
+		for k := range m.Header {
+			md[k] = m.Header.Get(k)
+		}
+		if pubsub.IsMessageExpired(md, time.Now()) {
+			n.l.Debugf("Dropping expired message on topic %s", m.Subject)
+			return
+		}
+		md["Topic"] = m.Subject
+
+		err := handler(ctx, &pubsub.NewMessage{
+			Topic:    req.Topic,
+			Data:     m.Data,
+			Metadata: md,
+		})
+		if err != nil {
+			// Messages are delivered at most once, so they can't be retried
+			n.l.Errorf("Error processing message on topic %s, the message is dropped: %v", m.Subject, err)
+		}
+	}
+
+	var (
+		sub *nats.Subscription
+		err error
+	)
+	if queueGroup != "" {
+		sub, err = n.nc.QueueSubscribe(req.Topic, queueGroup, natsHandler)
+	} else {
+		sub, err = n.nc.Subscribe(req.Topic, natsHandler)
+	}
+	if err != nil {
+		return fmt.Errorf("nats: error subscribing to topic %s: %w", req.Topic, err)
+	}
+	if queueGroup != "" {
+		n.l.Debugf("nats: subscribed to subject %s with queue group %s", req.Topic, queueGroup)
+	} else {
+		n.l.Debugf("nats: subscribed to subject %s", req.Topic)
+	}
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		select {
+		case <-ctx.Done():
+		case <-n.closeCh:
+		}
+
+		err := sub.Unsubscribe()
+		if err != nil && !errors.Is(err, nats.ErrConnectionClosed) {
+			n.l.Warnf("nats: error while unsubscribing from topic %s: %v", req.Topic, err)
+		}
+	}()
+
+	return nil
+}
+
+func (n *natsPubSub) Close() error {
+	if n.closed.CompareAndSwap(false, true) {
+		close(n.closeCh)
+	}
+	n.wg.Wait()
+	if n.nc == nil {
+		return nil
+	}
+	return n.nc.Drain()
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (n *natsPubSub) GetComponentMetadata() map[string]string {
+	metadataStruct := metadata{}
+	metadataInfo := map[string]string{}
+	mdutils.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, mdutils.PubSubType)
+	return metadataInfo
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nats
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mdata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+func runServer(t *testing.T) *server.Server {
+	ns, err := server.NewServer(&server.Options{
+		Host: "127.0.0.1",
+		Port: -1,
+	})
+	require.NoError(t, err)
+	go ns.Start()
+	require.True(t, ns.ReadyForConnections(time.Second))
+	t.Cleanup(ns.Shutdown)
+	return ns
+}
+
+func newPubSub(t *testing.T, ns *server.Server, props map[string]string) pubsub.PubSub {
+	properties := map[string]string{"natsURL": ns.ClientURL()}
+	for k, v := range props {
+		properties[k] = v
+	}
+	bus := NewNATSPubSub(logger.NewLogger("test"))
+	err := bus.Init(context.Background(), pubsub.Metadata{Base: mdata.Base{Properties: properties}})
+	require.NoError(t, err)
+	t.Cleanup(func() { bus.Close() })
+	return bus
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m, err := parseMetadata(pubsub.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"natsURL": "nats://localhost:4222",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "dapr.io - pubsub.nats", m.Name)
+		assert.Empty(t, m.QueueGroupName)
+	})
+
+	t.Run("missing URL", func(t *testing.T) {
+		_, err := parseMetadata(pubsub.Metadata{})
+		require.Error(t, err)
+	})
+
+	t.Run("jwt without seed key", func(t *testing.T) {
+		_, err := parseMetadata(pubsub.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"natsURL": "nats://localhost:4222",
+			"jwt":     "jwt",
+		}}})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid nkey seed", func(t *testing.T) {
+		_, err := parseMetadata(pubsub.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"natsURL":  "nats://localhost:4222",
+			"nkeySeed": "invalid",
+		}}})
+		require.Error(t, err)
+	})
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	ns := runServer(t)
+	bus := newPubSub(t, ns, nil)
+
+	ch := make(chan *pubsub.NewMessage, 1)
+	err := bus.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "test"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		ch <- msg
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = bus.Publish(context.Background(), &pubsub.PublishRequest{Topic: "test", Data: []byte("hello")})
+	require.NoError(t, err)
+
+	select {
+	case msg := <-ch:
+		assert.Equal(t, []byte("hello"), msg.Data)
+		assert.Equal(t, "test", msg.Topic)
+		assert.Equal(t, "test", msg.Metadata["Topic"])
+	case <-time.After(time.Second):
+		t.Fatal("receive timeout")
+	}
+}
+
+func TestQueueGroups(t *testing.T) {
+	ns := runServer(t)
+	bus := newPubSub(t, ns, map[string]string{"queueGroupName": "workers"})
+
+	var group, other atomic.Int32
+	for i := 0; i < 2; i++ {
+		err := bus.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "test"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+			group.Add(1)
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	// A subscription in another queue group receives all the messages too
+	err := bus.Subscribe(context.Background(), pubsub.SubscribeRequest{
+		Topic:    "test",
+		Metadata: map[string]string{"queueGroupName": "auditors"},
+	}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		other.Add(1)
+		return nil
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		err = bus.Publish(context.Background(), &pubsub.PublishRequest{Topic: "test", Data: []byte(strconv.Itoa(i))})
+		require.NoError(t, err)
+	}
+
+	assert.Eventually(t, func() bool {
+		return group.Load() == 10 && other.Load() == 10
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestMessageTTL(t *testing.T) {
+	ns := runServer(t)
+	bus := newPubSub(t, ns, nil)
+
+	ch := make(chan []byte, 2)
+	err := bus.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "test"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		ch <- msg.Data
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Publish a message that has already expired, bypassing the component
+	nc, err := nats.Connect(ns.ClientURL())
+	require.NoError(t, err)
+	defer nc.Close()
+	expired := nats.NewMsg("test")
+	expired.Data = []byte("expired")
+	expired.Header.Set(mdata.TTLMetadataKey, "1")
+	expired.Header.Set(pubsub.EnqueueTimeKey, strconv.FormatInt(time.Now().Add(-time.Minute).UnixMilli(), 10))
+	require.NoError(t, nc.PublishMsg(expired))
+
+	err = bus.Publish(context.Background(), &pubsub.PublishRequest{
+		Topic:    "test",
+		Data:     []byte("valid"),
+		Metadata: map[string]string{mdata.TTLMetadataKey: "60"},
+	})
+	require.NoError(t, err)
+
+	select {
+	case data := <-ch:
+		assert.Equal(t, []byte("valid"), data)
+	case <-time.After(time.Second):
+		t.Fatal("receive timeout")
+	}
+	assert.Empty(t, ch)
+}
+
+func TestUnsubscribe(t *testing.T) {
+	ns := runServer(t)
+	bus := newPubSub(t, ns, nil)
+
+	var received atomic.Int32
+	subs := ns.NumSubscriptions()
+	ctx, cancel := context.WithCancel(context.Background())
+	err := bus.Subscribe(ctx, pubsub.SubscribeRequest{Topic: "test"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		received.Add(1)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return ns.NumSubscriptions() > subs
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		return ns.NumSubscriptions() == subs
+	}, time.Second, 10*time.Millisecond)
+
+	err = bus.Publish(context.Background(), &pubsub.PublishRequest{Topic: "test", Data: []byte("hello")})
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(0), received.Load())
+}
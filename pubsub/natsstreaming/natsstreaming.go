@@ -85,7 +85,7 @@ type natsStreamingPubSub struct {
 
 // NewNATSStreamingPubSub returns a new NATS Streaming pub-sub implementation.
 func NewNATSStreamingPubSub(logger logger.Logger) pubsub.PubSub {
-	return &natsStreamingPubSub{logger: logger, closeCh: make(chan struct{})}
+	return pubsub.WithSchemaValidation(&natsStreamingPubSub{logger: logger, closeCh: make(chan struct{})})
 }
 
 func parseNATSStreamingMetadata(meta pubsub.Metadata) (natsMetadata, error) {
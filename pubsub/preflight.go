@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PreflightProperties contains the metadata properties of the permissions check that components perform at Init.
+// When both lists are empty, the check is disabled.
+type PreflightProperties struct {
+	// Topics the identity of the component must be allowed to publish to.
+	PreflightPublishTopics []string `mapstructure:"preflightPublishTopics"`
+	// Topics the identity of the component must be allowed to subscribe to.
+	PreflightSubscribeTopics []string `mapstructure:"preflightSubscribeTopics"`
+}
+
+// PreflightEnabled returns true if there are topics to check.
+func (p PreflightProperties) PreflightEnabled() bool {
+	return len(p.publishTopics()) > 0 || len(p.subscribeTopics()) > 0
+}
+
+// PreflightCheck invokes checkPublish for each topic in PreflightPublishTopics, then checkSubscribe for each topic in PreflightSubscribeTopics.
+// It stops at the first error.
+func (p PreflightProperties) PreflightCheck(checkPublish func(topic string) error, checkSubscribe func(topic string) error) error {
+	for _, topic := range p.publishTopics() {
+		if err := checkPublish(topic); err != nil {
+			return err
+		}
+	}
+	for _, topic := range p.subscribeTopics() {
+		if err := checkSubscribe(topic); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p PreflightProperties) publishTopics() []string {
+	return trimTopics(p.PreflightPublishTopics)
+}
+
+func (p PreflightProperties) subscribeTopics() []string {
+	return trimTopics(p.PreflightSubscribeTopics)
+}
+
+func trimTopics(topics []string) []string {
+	res := make([]string, 0, len(topics))
+	for _, t := range topics {
+		t = strings.TrimSpace(t)
+		if t != "" {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// PermissionError is returned by the preflight check when the identity of a component is missing permissions on an entity.
+type PermissionError struct {
+	// Identity of the component, if known.
+	Identity string
+	// Entity the permissions are missing on, for example a topic or a queue.
+	Entity string
+	// Missing permissions, in the format used by the cloud provider.
+	Permissions []string
+}
+
+func (e *PermissionError) Error() string {
+	identity := "the identity of the component"
+	if e.Identity != "" {
+		identity = "identity '" + e.Identity + "'"
+	}
+	return fmt.Sprintf("preflight check failed: %s is missing the permissions %s on '%s'; grant them or remove the entity from the preflight metadata properties",
+		identity, strings.Join(e.Permissions, ", "), e.Entity)
+}
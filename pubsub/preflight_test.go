@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+func TestPreflightProperties(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		var p PreflightProperties
+		require.NoError(t, metadata.DecodeMetadata(map[string]string{}, &p))
+		assert.False(t, p.PreflightEnabled())
+
+		require.NoError(t, metadata.DecodeMetadata(map[string]string{"preflightPublishTopics": " , "}, &p))
+		assert.False(t, p.PreflightEnabled())
+	})
+
+	t.Run("check topics", func(t *testing.T) {
+		var p PreflightProperties
+		require.NoError(t, metadata.DecodeMetadata(map[string]string{
+			"preflightPublishTopics":   "orders, payments",
+			"preflightSubscribeTopics": "orders",
+		}, &p))
+		assert.True(t, p.PreflightEnabled())
+
+		var checked []string
+		err := p.PreflightCheck(func(topic string) error {
+			checked = append(checked, "publish:"+topic)
+			return nil
+		}, func(topic string) error {
+			checked = append(checked, "subscribe:"+topic)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"publish:orders", "publish:payments", "subscribe:orders"}, checked)
+	})
+
+	t.Run("stop at first error", func(t *testing.T) {
+		p := PreflightProperties{
+			PreflightPublishTopics:   []string{"orders", "payments"},
+			PreflightSubscribeTopics: []string{"orders"},
+		}
+		permErr := &PermissionError{Entity: "orders", Permissions: []string{"publish"}}
+		err := p.PreflightCheck(func(topic string) error {
+			return permErr
+		}, func(topic string) error {
+			t.Fatal("subscribe permissions should not be checked")
+			return nil
+		})
+		var target *PermissionError
+		require.True(t, errors.As(err, &target))
+		assert.Equal(t, "preflight check failed: the identity of the component is missing the permissions publish on 'orders'; grant them or remove the entity from the preflight metadata properties", err.Error())
+	})
+}
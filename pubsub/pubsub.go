@@ -47,6 +47,22 @@ type BulkSubscriber interface {
 	BulkSubscribe(ctx context.Context, req SubscribeRequest, bulkHandler BulkHandler) error
 }
 
+// Replayer is the interface implemented by message buses backed by a log that can replay messages.
+type Replayer interface {
+	// Replay moves the position of a consumer group on a topic, so that messages are delivered again starting from that position.
+	// Subscriptions of the component to the topic are restarted from the new position.
+	Replay(ctx context.Context, req ReplayRequest) error
+}
+
+// Pauser is the interface implemented by message buses that can pause the consumption of a topic without unsubscribing.
+type Pauser interface {
+	// Pause stops delivering the messages of a topic, or of some of its partitions, to the subscriptions of the component.
+	// Subscriptions keep their membership in the consumer group, so messages aren't assigned to other consumers.
+	Pause(ctx context.Context, req PauseRequest) error
+	// Resume delivers again the messages of a topic, or of some of its partitions, that was paused.
+	Resume(ctx context.Context, req PauseRequest) error
+}
+
 // Handler is the handler used to invoke the app handler.
 type Handler func(ctx context.Context, msg *NewMessage) error
 
@@ -65,9 +81,33 @@ type Handler func(ctx context.Context, msg *NewMessage) error
 // orderly fashion.
 type BulkHandler func(ctx context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error)
 
+// Unwrapper is the interface implemented by decorators of message buses, such as the one returned by WithSchemaValidation.
+type Unwrapper interface {
+	// Unwrap returns the wrapped message bus.
+	Unwrap() PubSub
+}
+
+// As returns the first message bus in the chain of decorators starting at pubsub that implements T.
+// It should be used instead of a type assertion to check if a message bus implements an optional interface.
+func As[T any](pubsub PubSub) (T, bool) {
+	for pubsub != nil {
+		if t, ok := pubsub.(T); ok {
+			return t, true
+		}
+		u, ok := pubsub.(Unwrapper)
+		if !ok {
+			break
+		}
+		pubsub = u.Unwrap()
+	}
+
+	var zero T
+	return zero, false
+}
+
 func Ping(ctx context.Context, pubsub PubSub) error {
 	// checks if this pubsub has the ping option then executes
-	if pubsubWithPing, ok := pubsub.(health.Pinger); ok {
+	if pubsubWithPing, ok := As[health.Pinger](pubsub); ok {
 		return pubsubWithPing.Ping(ctx)
 	} else {
 		return fmt.Errorf("ping is not implemented by this pubsub")
@@ -32,6 +32,8 @@ type pulsarMetadata struct {
 	PublicKey               string                    `mapstructure:"publicKey"`
 	PrivateKey              string                    `mapstructure:"privateKey"`
 	Keys                    string                    `mapstructure:"keys"`
+	EnableTransactions      bool                      `mapstructure:"enableTransactions"`
+	TransactionTimeout      time.Duration             `mapstructure:"transactionTimeout"`
 }
 
 type schemaMetadata struct {
@@ -20,6 +20,8 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -66,6 +68,8 @@ const (
 	nonPersistentStr          = "non-persistent"
 	topicJSONSchemaIdentifier = ".jsonschema"
 	topicAvroSchemaIdentifier = ".avroschema"
+	// partitionSuffix is added by pulsar to the names of the partitions of partitioned topics.
+	partitionSuffix = "-partition-"
 
 	// defaultBatchingMaxPublishDelay init default for maximum delay to batch messages.
 	defaultBatchingMaxPublishDelay = 10 * time.Millisecond
@@ -75,6 +79,8 @@ const (
 	defaultMaxBatchSize = 128 * 1024
 	// defaultRedeliveryDelay init default for redelivery delay.
 	defaultRedeliveryDelay = 30 * time.Second
+	// defaultTransactionTimeout init default for the timeout of the transactions used by bulk publish.
+	defaultTransactionTimeout = time.Minute
 
 	subscribeTypeKey = "subscribeType"
 
@@ -87,6 +93,14 @@ const (
 
 	processModeAsync = "async"
 	processModeSync  = "sync"
+
+	// Subscribe request metadata to consume a list of topics, or the topics in the namespace that match a regular expression, with one subscription.
+	topicsKey                    = "topics"
+	topicsPatternKey             = "topicsPattern"
+	topicsAutoDiscoveryPeriodKey = "topicsAutoDiscoveryPeriod"
+
+	// Metadata of messages received by subscriptions to multiple topics with the topic the message was published to.
+	sourceTopicKey = "sourceTopic"
 )
 
 type ProcessMode string
@@ -102,10 +116,10 @@ type Pulsar struct {
 }
 
 func NewPulsar(l logger.Logger) pubsub.PubSub {
-	return &Pulsar{
+	return pubsub.WithSchemaValidation(&Pulsar{
 		logger:  l,
 		closeCh: make(chan struct{}),
-	}
+	})
 }
 
 func parsePulsarMetadata(meta pubsub.Metadata) (*pulsarMetadata, error) {
@@ -119,6 +133,7 @@ func parsePulsarMetadata(meta pubsub.Metadata) (*pulsarMetadata, error) {
 		BatchingMaxMessages:     defaultMaxMessages,
 		BatchingMaxSize:         defaultMaxBatchSize,
 		RedeliveryDelay:         defaultRedeliveryDelay,
+		TransactionTimeout:      defaultTransactionTimeout,
 	}
 
 	if err := metadata.DecodeMetadata(meta.Properties, &m); err != nil {
@@ -128,6 +143,9 @@ func parsePulsarMetadata(meta pubsub.Metadata) (*pulsarMetadata, error) {
 	if m.Host == "" {
 		return nil, errors.New("pulsar error: missing pulsar host")
 	}
+	if m.EnableTransactions && m.TransactionTimeout <= 0 {
+		return nil, errors.New("pulsar error: transactionTimeout must be greater than 0")
+	}
 
 	for k, v := range meta.Properties {
 		if strings.HasSuffix(k, topicJSONSchemaIdentifier) {
@@ -163,6 +181,7 @@ func (p *Pulsar) Init(_ context.Context, metadata pubsub.Metadata) error {
 		OperationTimeout:           30 * time.Second,
 		ConnectionTimeout:          30 * time.Second,
 		TLSAllowInsecureConnection: !m.EnableTLS,
+		EnableTransaction:          m.EnableTransactions,
 	}
 	if m.Token != "" {
 		options.Authentication = pulsar.NewAuthenticationToken(m.Token)
@@ -204,17 +223,148 @@ func (p *Pulsar) Publish(ctx context.Context, req *pubsub.PublishRequest) error
 		return errors.New("component is closed")
 	}
 
-	var (
-		msg *pulsar.ProducerMessage
-		err error
-	)
-	topic := p.formatTopic(req.Topic)
+	producer, sm, err := p.getProducer(req.Topic)
+	if err != nil {
+		return err
+	}
+
+	msg, err := parsePublishMetadata(req, sm)
+	if err != nil {
+		return err
+	}
+
+	if _, err = producer.Send(ctx, msg); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// BulkPublish publishes multiple messages to a topic.
+// When transactions are enabled, the messages are published in a single transaction, so either all of them are published or none is.
+func (p *Pulsar) BulkPublish(ctx context.Context, req *pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+	if p.closed.Load() {
+		return pubsub.BulkPublishResponse{}, errors.New("component is closed")
+	}
+
+	producer, sm, err := p.getProducer(req.Topic)
+	if err != nil {
+		return pubsub.NewBulkPublishResponse(req.Entries, err), err
+	}
+
+	res := pubsub.BulkPublishResponse{}
+	msgs := make([]*pulsar.ProducerMessage, len(req.Entries))
+	for i, entry := range req.Entries {
+		msgs[i], err = parsePublishMetadata(&pubsub.PublishRequest{
+			Data:     entry.Event,
+			Topic:    req.Topic,
+			Metadata: bulkEntryMetadata(req.Metadata, entry.Metadata),
+		}, sm)
+		if err != nil {
+			if p.metadata.EnableTransactions {
+				return pubsub.NewBulkPublishResponse(req.Entries, err), err
+			}
+			res.FailedEntries = append(res.FailedEntries, pubsub.BulkPublishResponseFailedEntry{
+				EntryId: entry.EntryId,
+				Error:   err,
+			})
+		}
+	}
+
+	if p.metadata.EnableTransactions {
+		if err = p.sendInTransaction(ctx, producer, msgs); err != nil {
+			return pubsub.NewBulkPublishResponse(req.Entries, err), err
+		}
+		return res, nil
+	}
+
+	for i, err := range sendAll(ctx, producer, msgs) {
+		if err != nil {
+			res.FailedEntries = append(res.FailedEntries, pubsub.BulkPublishResponseFailedEntry{
+				EntryId: req.Entries[i].EntryId,
+				Error:   err,
+			})
+		}
+	}
+	if len(res.FailedEntries) > 0 {
+		return res, fmt.Errorf("pulsar error: failed to publish %d of %d messages to topic %s", len(res.FailedEntries), len(req.Entries), req.Topic)
+	}
+
+	return res, nil
+}
+
+// sendInTransaction sends all the messages in a new transaction, which is committed only if all of them were sent successfully.
+func (p *Pulsar) sendInTransaction(ctx context.Context, producer pulsar.Producer, msgs []*pulsar.ProducerMessage) error {
+	txn, err := p.client.NewTransaction(p.metadata.TransactionTimeout)
+	if err != nil {
+		return fmt.Errorf("pulsar error: failed to begin transaction: %w", err)
+	}
+
+	for _, msg := range msgs {
+		msg.Transaction = txn
+	}
+	for _, err = range sendAll(ctx, producer, msgs) {
+		if err != nil {
+			if abortErr := txn.Abort(ctx); abortErr != nil {
+				p.logger.Warnf("Failed to abort pulsar transaction: %v", abortErr)
+			}
+			return err
+		}
+	}
+
+	if err = txn.Commit(ctx); err != nil {
+		return fmt.Errorf("pulsar error: failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// sendAll sends the messages asynchronously, and returns the error of each of them once all the sends are completed.
+// Nil messages are skipped.
+func sendAll(ctx context.Context, producer pulsar.Producer, msgs []*pulsar.ProducerMessage) []error {
+	errs := make([]error, len(msgs))
+	var wg sync.WaitGroup
+	for i, msg := range msgs {
+		if msg == nil {
+			continue
+		}
+		wg.Add(1)
+		i := i
+		producer.SendAsync(ctx, msg, func(_ pulsar.MessageID, _ *pulsar.ProducerMessage, err error) {
+			errs[i] = err
+			wg.Done()
+		})
+	}
+	wg.Wait()
+
+	return errs
+}
+
+// bulkEntryMetadata merges the metadata of a bulk publish request with the metadata of one of its entries.
+// Values set on the entry take precedence.
+func bulkEntryMetadata(reqMetadata map[string]string, entryMetadata map[string]string) map[string]string {
+	if len(reqMetadata) == 0 {
+		return entryMetadata
+	}
+	md := make(map[string]string, len(reqMetadata)+len(entryMetadata))
+	for k, v := range reqMetadata {
+		md[k] = v
+	}
+	for k, v := range entryMetadata {
+		md[k] = v
+	}
+	return md
+}
+
+// getProducer returns the cached producer for a topic, creating it if needed, and the schema of the topic.
+func (p *Pulsar) getProducer(reqTopic string) (pulsar.Producer, schemaMetadata, error) {
+	topic := p.formatTopic(reqTopic)
 	producer, ok := p.cache.Get(topic)
 
-	sm, hasSchema := p.metadata.internalTopicSchemas[req.Topic]
+	sm, hasSchema := p.metadata.internalTopicSchemas[reqTopic]
 
 	if !ok || producer == nil {
-		p.logger.Debugf("creating producer for topic %s, full topic name in pulsar is %s", req.Topic, topic)
+		p.logger.Debugf("creating producer for topic %s, full topic name in pulsar is %s", reqTopic, topic)
 		opts := pulsar.ProducerOptions{
 			Topic:                   topic,
 			DisableBatching:         p.metadata.DisableBatching,
@@ -241,24 +391,16 @@ func (p *Pulsar) Publish(ctx context.Context, req *pubsub.PublishRequest) error
 			}
 		}
 
+		var err error
 		producer, err = p.client.CreateProducer(opts)
 		if err != nil {
-			return err
+			return nil, sm, err
 		}
 
 		p.cache.Add(topic, producer)
 	}
 
-	msg, err = parsePublishMetadata(req, sm)
-	if err != nil {
-		return err
-	}
-
-	if _, err = producer.Send(ctx, msg); err != nil {
-		return err
-	}
-
-	return nil
+	return producer, sm, nil
 }
 
 func getPulsarSchema(metadata schemaMetadata) pulsar.Schema {
@@ -363,15 +505,16 @@ func (p *Pulsar) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, han
 
 	channel := make(chan pulsar.ConsumerMessage, 100)
 
-	topic := p.formatTopic(req.Topic)
-
 	options := pulsar.ConsumerOptions{
-		Topic:               topic,
 		SubscriptionName:    p.metadata.ConsumerID,
 		Type:                getSubscribeType(req.Metadata),
 		MessageChannel:      channel,
 		NackRedeliveryDelay: p.metadata.RedeliveryDelay,
 	}
+	err := p.setConsumerTopics(&options, req)
+	if err != nil {
+		return err
+	}
 
 	if p.useConsumerEncryption() {
 		var reader crypto.KeyReader
@@ -391,7 +534,7 @@ func (p *Pulsar) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, han
 	}
 	consumer, err := p.client.Subscribe(options)
 	if err != nil {
-		p.logger.Debugf("Could not subscribe to %s, full topic name in pulsar is %s", req.Topic, topic)
+		p.logger.Debugf("Could not subscribe to %s, full topic name in pulsar is %s", req.Topic, consumerTopicsString(options))
 		return err
 	}
 
@@ -408,13 +551,64 @@ func (p *Pulsar) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, han
 	go func() {
 		defer p.wg.Done()
 		defer cancel()
-		p.listenMessage(listenCtx, req, consumer, handler)
+		p.listenMessage(listenCtx, req, consumer, handler, options.Topic == "")
 	}()
 
 	return nil
 }
 
-func (p *Pulsar) listenMessage(ctx context.Context, req pubsub.SubscribeRequest, consumer pulsar.Consumer, handler pubsub.Handler) {
+// setConsumerTopics sets the topics the consumer subscribes to: the topics listed in the "topics" metadata of the request, the topics that match the regular expression in the "topicsPattern" metadata, or the topic of the request otherwise.
+// Messages of all the topics are delivered with the topic of the request.
+func (p *Pulsar) setConsumerTopics(options *pulsar.ConsumerOptions, req pubsub.SubscribeRequest) error {
+	topics := req.Metadata[topicsKey]
+	pattern := req.Metadata[topicsPatternKey]
+
+	switch {
+	case topics != "" && pattern != "":
+		return fmt.Errorf("pulsar error: '%s' and '%s' cannot be set together", topicsKey, topicsPatternKey)
+	case topics != "":
+		for _, topic := range strings.Split(topics, ",") {
+			topic = strings.TrimSpace(topic)
+			if topic != "" {
+				options.Topics = append(options.Topics, p.formatTopic(topic))
+			}
+		}
+		if len(options.Topics) == 0 {
+			return fmt.Errorf("pulsar error: invalid value for '%s': no topics", topicsKey)
+		}
+	case pattern != "":
+		_, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("pulsar error: invalid value for '%s': %w", topicsPatternKey, err)
+		}
+		options.TopicsPattern = p.formatTopic(pattern)
+		if val := req.Metadata[topicsAutoDiscoveryPeriodKey]; val != "" {
+			options.AutoDiscoveryPeriod, err = time.ParseDuration(val)
+			if err != nil || options.AutoDiscoveryPeriod <= 0 {
+				return fmt.Errorf("pulsar error: invalid value for '%s': %s", topicsAutoDiscoveryPeriodKey, val)
+			}
+		}
+	default:
+		options.Topic = p.formatTopic(req.Topic)
+	}
+
+	return nil
+}
+
+func consumerTopicsString(options pulsar.ConsumerOptions) string {
+	switch {
+	case len(options.Topics) > 0:
+		return strings.Join(options.Topics, ",")
+	case options.TopicsPattern != "":
+		return options.TopicsPattern
+	default:
+		return options.Topic
+	}
+}
+
+// listenMessage delivers the messages received by the consumer to the handler until the context is canceled.
+// If multiTopic is true, the topic each message was published to is added to its metadata.
+func (p *Pulsar) listenMessage(ctx context.Context, req pubsub.SubscribeRequest, consumer pulsar.Consumer, handler pubsub.Handler, multiTopic bool) {
 	defer consumer.Close()
 
 	originTopic := req.Topic
@@ -423,7 +617,7 @@ func (p *Pulsar) listenMessage(ctx context.Context, req pubsub.SubscribeRequest,
 		select {
 		case msg := <-consumer.Chan():
 			if strings.ToLower(req.Metadata[processModeKey]) == processModeSync { //nolint:gocritic
-				err = p.handleMessage(ctx, originTopic, msg, handler)
+				err = p.handleMessage(ctx, originTopic, msg, handler, multiTopic)
 				if err != nil && !errors.Is(err, context.Canceled) {
 					p.logger.Errorf("Error sync processing message: %s/%#v [key=%s]: %v", msg.Topic(), msg.ID(), msg.Key(), err)
 				}
@@ -432,7 +626,7 @@ func (p *Pulsar) listenMessage(ctx context.Context, req pubsub.SubscribeRequest,
 				p.wg.Add(1)
 				go func(msg pulsar.ConsumerMessage) {
 					defer p.wg.Done()
-					err = p.handleMessage(ctx, originTopic, msg, handler)
+					err = p.handleMessage(ctx, originTopic, msg, handler, multiTopic)
 					if err != nil && !errors.Is(err, context.Canceled) {
 						p.logger.Errorf("Error async processing message: %s/%#v [key=%s]: %v", msg.Topic(), msg.ID(), msg.Key(), err)
 					}
@@ -446,12 +640,20 @@ func (p *Pulsar) listenMessage(ctx context.Context, req pubsub.SubscribeRequest,
 	}
 }
 
-func (p *Pulsar) handleMessage(ctx context.Context, originTopic string, msg pulsar.ConsumerMessage, handler pubsub.Handler) error {
+func (p *Pulsar) handleMessage(ctx context.Context, originTopic string, msg pulsar.ConsumerMessage, handler pubsub.Handler, multiTopic bool) error {
 	pubsubMsg := pubsub.NewMessage{
 		Data:     msg.Payload(),
 		Topic:    originTopic,
 		Metadata: msg.Properties(),
 	}
+	if multiTopic {
+		md := make(map[string]string, len(pubsubMsg.Metadata)+1)
+		for k, v := range pubsubMsg.Metadata {
+			md[k] = v
+		}
+		md[sourceTopicKey] = p.sourceTopic(msg.Topic())
+		pubsubMsg.Metadata = md
+	}
 
 	p.logger.Debugf("Processing Pulsar message %s/%#v", msg.Topic(), msg.ID())
 	err := handler(ctx, &pubsubMsg)
@@ -495,6 +697,20 @@ func (p *Pulsar) formatTopic(topic string) string {
 	return fmt.Sprintf(topicFormat, persist, p.metadata.Tenant, p.metadata.Namespace, topic)
 }
 
+// sourceTopic returns the name of the topic a message was received from, without the partition suffix.
+// Topics in the tenant and namespace of the component are returned without the prefix added by formatTopic.
+func (p *Pulsar) sourceTopic(fullTopic string) string {
+	if i := strings.LastIndex(fullTopic, partitionSuffix); i > 0 {
+		if _, err := strconv.Atoi(fullTopic[i+len(partitionSuffix):]); err == nil {
+			fullTopic = fullTopic[:i]
+		}
+	}
+	if prefix := p.formatTopic(""); strings.HasPrefix(fullTopic, prefix) {
+		return strings.TrimPrefix(fullTopic, prefix)
+	}
+	return fullTopic
+}
+
 // GetComponentMetadata returns the metadata of the component.
 func (p *Pulsar) GetComponentMetadata() map[string]string {
 	metadataStruct := pulsarMetadata{}
@@ -14,13 +14,20 @@ limitations under the License.
 package pulsar
 
 import (
+	"context"
+	"errors"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/apache/pulsar-client-go/pulsar"
+	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	mdata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
 )
 
 func TestParsePulsarMetadata(t *testing.T) {
@@ -288,3 +295,308 @@ func TestEncryptionKeys(t *testing.T) {
 		assert.False(t, r)
 	})
 }
+
+func TestParseTransactionMetadata(t *testing.T) {
+	m := pubsub.Metadata{}
+	m.Properties = map[string]string{
+		"host":               "a",
+		"enableTransactions": "true",
+	}
+	meta, err := parsePulsarMetadata(m)
+	require.NoError(t, err)
+	assert.True(t, meta.EnableTransactions)
+	assert.Equal(t, defaultTransactionTimeout, meta.TransactionTimeout)
+
+	m.Properties["transactionTimeout"] = "0"
+	_, err = parsePulsarMetadata(m)
+	assert.Error(t, err)
+}
+
+type fakeProducer struct {
+	pulsar.Producer
+	sent   []*pulsar.ProducerMessage
+	failOn string
+}
+
+func (f *fakeProducer) SendAsync(_ context.Context, msg *pulsar.ProducerMessage, cb func(pulsar.MessageID, *pulsar.ProducerMessage, error)) {
+	if string(msg.Payload) == f.failOn {
+		cb(nil, msg, errors.New("send failed"))
+		return
+	}
+	f.sent = append(f.sent, msg)
+	cb(nil, msg, nil)
+}
+
+type fakeTransaction struct {
+	committed bool
+	aborted   bool
+}
+
+func (f *fakeTransaction) Commit(context.Context) error {
+	f.committed = true
+	return nil
+}
+
+func (f *fakeTransaction) Abort(context.Context) error {
+	f.aborted = true
+	return nil
+}
+
+func (f *fakeTransaction) GetState() pulsar.TxnState {
+	return pulsar.TxnOpen
+}
+
+func (f *fakeTransaction) GetTxnID() pulsar.TxnID {
+	return pulsar.TxnID{}
+}
+
+type fakeClient struct {
+	pulsar.Client
+	txns      []*fakeTransaction
+	consumers []*fakeConsumer
+}
+
+func (f *fakeClient) Subscribe(options pulsar.ConsumerOptions) (pulsar.Consumer, error) {
+	consumer := &fakeConsumer{options: options, acked: make(chan pulsar.Message, 10)}
+	f.consumers = append(f.consumers, consumer)
+	return consumer, nil
+}
+
+type fakeConsumer struct {
+	pulsar.Consumer
+	options pulsar.ConsumerOptions
+	acked   chan pulsar.Message
+}
+
+func (f *fakeConsumer) Chan() <-chan pulsar.ConsumerMessage {
+	return f.options.MessageChannel
+}
+
+func (f *fakeConsumer) Ack(msg pulsar.Message) error {
+	f.acked <- msg
+	return nil
+}
+
+func (f *fakeConsumer) Nack(pulsar.Message) {}
+
+func (f *fakeConsumer) Close() {}
+
+type fakeMessage struct {
+	pulsar.Message
+	topic      string
+	payload    []byte
+	properties map[string]string
+}
+
+func (f *fakeMessage) Topic() string {
+	return f.topic
+}
+
+func (f *fakeMessage) Payload() []byte {
+	return f.payload
+}
+
+func (f *fakeMessage) Properties() map[string]string {
+	return f.properties
+}
+
+func (f *fakeMessage) ID() pulsar.MessageID {
+	return nil
+}
+
+func (f *fakeClient) NewTransaction(time.Duration) (pulsar.Transaction, error) {
+	txn := &fakeTransaction{}
+	f.txns = append(f.txns, txn)
+	return txn, nil
+}
+
+func TestBulkPublish(t *testing.T) {
+	newPulsar := func(t *testing.T, enableTransactions bool, failOn string) (*Pulsar, *fakeClient, *fakeProducer) {
+		meta, err := parsePulsarMetadata(pubsub.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"host":               "a",
+			"enableTransactions": strconv.FormatBool(enableTransactions),
+		}}})
+		require.NoError(t, err)
+
+		client := &fakeClient{}
+		producer := &fakeProducer{failOn: failOn}
+		p := &Pulsar{
+			logger:   logger.NewLogger("test"),
+			client:   client,
+			metadata: *meta,
+		}
+		p.cache, err = lru.New[string, pulsar.Producer](cachedNumProducer)
+		require.NoError(t, err)
+		p.cache.Add(p.formatTopic("orders"), producer)
+		return p, client, producer
+	}
+	req := &pubsub.BulkPublishRequest{
+		Topic:    "orders",
+		Metadata: map[string]string{"source": "bulk"},
+		Entries: []pubsub.BulkMessageEntry{
+			{EntryId: "1", Event: []byte("a"), Metadata: map[string]string{partitionKey: "k1"}},
+			{EntryId: "2", Event: []byte("b"), Metadata: map[string]string{"source": "entry"}},
+			{EntryId: "3", Event: []byte("c")},
+		},
+	}
+
+	t.Run("without transactions", func(t *testing.T) {
+		p, client, producer := newPulsar(t, false, "b")
+		res, err := p.BulkPublish(context.Background(), req)
+		require.Error(t, err)
+		require.Len(t, res.FailedEntries, 1)
+		assert.Equal(t, "2", res.FailedEntries[0].EntryId)
+		require.Len(t, producer.sent, 2)
+		assert.Equal(t, "k1", producer.sent[0].Key)
+		assert.Equal(t, "bulk", producer.sent[0].Properties["source"])
+		assert.Nil(t, producer.sent[0].Transaction)
+		assert.Empty(t, client.txns)
+	})
+
+	t.Run("transaction committed", func(t *testing.T) {
+		p, client, producer := newPulsar(t, true, "")
+		res, err := p.BulkPublish(context.Background(), req)
+		require.NoError(t, err)
+		assert.Empty(t, res.FailedEntries)
+		require.Len(t, producer.sent, 3)
+		assert.Equal(t, "entry", producer.sent[1].Properties["source"])
+		require.Len(t, client.txns, 1)
+		assert.Equal(t, client.txns[0], producer.sent[0].Transaction)
+		assert.True(t, client.txns[0].committed)
+		assert.False(t, client.txns[0].aborted)
+	})
+
+	t.Run("transaction aborted", func(t *testing.T) {
+		p, client, _ := newPulsar(t, true, "c")
+		res, err := p.BulkPublish(context.Background(), req)
+		require.Error(t, err)
+		assert.Len(t, res.FailedEntries, 3)
+		require.Len(t, client.txns, 1)
+		assert.False(t, client.txns[0].committed)
+		assert.True(t, client.txns[0].aborted)
+	})
+}
+
+func TestSubscribeMultipleTopics(t *testing.T) {
+	newPulsar := func(t *testing.T) (*Pulsar, *fakeClient) {
+		meta, err := parsePulsarMetadata(pubsub.Metadata{Base: mdata.Base{Properties: map[string]string{
+			"host":       "a",
+			"consumerID": "sub",
+		}}})
+		require.NoError(t, err)
+
+		client := &fakeClient{}
+		p := &Pulsar{
+			logger:   logger.NewLogger("test"),
+			client:   client,
+			metadata: *meta,
+			closeCh:  make(chan struct{}),
+		}
+		return p, client
+	}
+
+	t.Run("list of topics", func(t *testing.T) {
+		p, client := newPulsar(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		received := make(chan *pubsub.NewMessage, 1)
+		err := p.Subscribe(ctx, pubsub.SubscribeRequest{
+			Topic:    "orders",
+			Metadata: map[string]string{topicsKey: "orders-eu, orders-us", processModeKey: processModeSync},
+		}, func(_ context.Context, msg *pubsub.NewMessage) error {
+			received <- msg
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, client.consumers, 1)
+		consumer := client.consumers[0]
+		assert.Empty(t, consumer.options.Topic)
+		assert.Equal(t, []string{
+			"persistent://public/default/orders-eu",
+			"persistent://public/default/orders-us",
+		}, consumer.options.Topics)
+
+		msg := &fakeMessage{
+			topic:      "persistent://public/default/orders-us-partition-2",
+			payload:    []byte("hello"),
+			properties: map[string]string{"source": "app"},
+		}
+		consumer.options.MessageChannel <- pulsar.ConsumerMessage{Consumer: consumer, Message: msg}
+
+		select {
+		case got := <-received:
+			assert.Equal(t, "orders", got.Topic)
+			assert.Equal(t, map[string]string{"source": "app", sourceTopicKey: "orders-us"}, got.Metadata)
+			assert.Equal(t, map[string]string{"source": "app"}, msg.properties)
+		case <-time.After(5 * time.Second):
+			t.Fatal("message was not delivered")
+		}
+		select {
+		case acked := <-consumer.acked:
+			assert.Equal(t, msg, acked)
+		case <-time.After(5 * time.Second):
+			t.Fatal("message was not acknowledged")
+		}
+
+		cancel()
+		p.wg.Wait()
+	})
+
+	t.Run("topics pattern", func(t *testing.T) {
+		p, client := newPulsar(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		err := p.Subscribe(ctx, pubsub.SubscribeRequest{
+			Topic: "orders",
+			Metadata: map[string]string{
+				topicsPatternKey:             "orders-.*",
+				topicsAutoDiscoveryPeriodKey: "30s",
+			},
+		}, func(context.Context, *pubsub.NewMessage) error { return nil })
+		require.NoError(t, err)
+		require.Len(t, client.consumers, 1)
+		assert.Equal(t, "persistent://public/default/orders-.*", client.consumers[0].options.TopicsPattern)
+		assert.Equal(t, 30*time.Second, client.consumers[0].options.AutoDiscoveryPeriod)
+
+		cancel()
+		p.wg.Wait()
+	})
+
+	t.Run("single topic", func(t *testing.T) {
+		p, client := newPulsar(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		err := p.Subscribe(ctx, pubsub.SubscribeRequest{Topic: "orders"}, func(context.Context, *pubsub.NewMessage) error { return nil })
+		require.NoError(t, err)
+		require.Len(t, client.consumers, 1)
+		assert.Equal(t, "persistent://public/default/orders", client.consumers[0].options.Topic)
+		assert.Empty(t, client.consumers[0].options.Topics)
+
+		cancel()
+		p.wg.Wait()
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		p, _ := newPulsar(t)
+		for _, md := range []map[string]string{
+			{topicsKey: "a", topicsPatternKey: "b.*"},
+			{topicsKey: " , "},
+			{topicsPatternKey: "orders-("},
+			{topicsPatternKey: "orders-.*", topicsAutoDiscoveryPeriodKey: "soon"},
+		} {
+			err := p.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "orders", Metadata: md}, func(context.Context, *pubsub.NewMessage) error { return nil })
+			assert.Error(t, err, md)
+		}
+	})
+}
+
+func TestSourceTopic(t *testing.T) {
+	p := &Pulsar{metadata: pulsarMetadata{Tenant: "tenant", Namespace: "ns", Persistent: true}}
+	assert.Equal(t, "orders", p.sourceTopic("persistent://tenant/ns/orders"))
+	assert.Equal(t, "orders", p.sourceTopic("persistent://tenant/ns/orders-partition-10"))
+	assert.Equal(t, "orders-partition-x", p.sourceTopic("persistent://tenant/ns/orders-partition-x"))
+	assert.Equal(t, "persistent://other/ns/orders", p.sourceTopic("persistent://other/ns/orders-partition-0"))
+}
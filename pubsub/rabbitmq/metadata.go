@@ -16,6 +16,8 @@ package rabbitmq
 import (
 	"fmt"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
@@ -43,6 +45,7 @@ type rabbitmqMetadata struct {
 	DeliveryMode         uint8                  `mapstructure:"deliveryMode"`  // Transient (0 or 1) or Persistent (2)
 	PrefetchCount        uint8                  `mapstructure:"prefetchCount"` // Prefetch deactivated if 0
 	ReconnectWait        time.Duration          `mapstructure:"reconnectWaitSeconds"`
+	ReconnectMaxWait     time.Duration          `mapstructure:"reconnectMaxWaitSeconds"`
 	MaxLen               int64                  `mapstructure:"maxLen"`
 	MaxLenBytes          int64                  `mapstructure:"maxLenBytes"`
 	ExchangeKind         string                 `mapstructure:"exchangeKind"`
@@ -50,6 +53,19 @@ type rabbitmqMetadata struct {
 	SaslExternal         bool                   `mapstructure:"saslExternal"`
 	Concurrency          pubsub.ConcurrencyMode `mapstructure:"concurrency"`
 	DefaultQueueTTL      *time.Duration         `mapstructure:"ttlInSeconds"`
+
+	PublisherConfirmTimeout time.Duration `mapstructure:"publisherConfirmTimeout"`
+	QueueType               string        `mapstructure:"queueType"`
+	ConsumerPriority        int32         `mapstructure:"consumerPriority"`
+
+	// Interval of the heartbeats negotiated with the broker on the connection.
+	Heartbeat time.Duration `mapstructure:"heartbeat"`
+	// Interval at which the health of the channel is checked, so the topology is recovered even when the component is idle.
+	// Disabled if 0.
+	ChannelHeartbeat time.Duration `mapstructure:"channelHeartbeat"`
+
+	// Algorithm used to compress the payloads of published messages.
+	Compression pubsub.Compression `mapstructure:"compression"`
 }
 
 const (
@@ -63,22 +79,34 @@ const (
 	metadataUsernameKey = "username"
 	metadataPasswordKey = "password"
 
-	metadataDurableKey              = "durable"
-	metadataEnableDeadLetterKey     = "enableDeadLetter"
-	metadataDeleteWhenUnusedKey     = "deletedWhenUnused"
-	metadataAutoAckKey              = "autoAck"
-	metadataRequeueInFailureKey     = "requeueInFailure"
-	metadataDeliveryModeKey         = "deliveryMode"
-	metadataPrefetchCountKey        = "prefetchCount"
-	metadataReconnectWaitSecondsKey = "reconnectWaitSeconds"
-	metadataMaxLenKey               = "maxLen"
-	metadataMaxLenBytesKey          = "maxLenBytes"
-	metadataExchangeKindKey         = "exchangeKind"
-	metadataPublisherConfirmKey     = "publisherConfirm"
-	metadataSaslExternal            = "saslExternal"
-	metadataMaxPriority             = "maxPriority"
-
-	defaultReconnectWaitSeconds = 3
+	metadataDurableKey                 = "durable"
+	metadataEnableDeadLetterKey        = "enableDeadLetter"
+	metadataDeleteWhenUnusedKey        = "deletedWhenUnused"
+	metadataAutoAckKey                 = "autoAck"
+	metadataRequeueInFailureKey        = "requeueInFailure"
+	metadataDeliveryModeKey            = "deliveryMode"
+	metadataPrefetchCountKey           = "prefetchCount"
+	metadataReconnectWaitSecondsKey    = "reconnectWaitSeconds"
+	metadataReconnectMaxWaitSecondsKey = "reconnectMaxWaitSeconds"
+	metadataHeartbeatKey               = "heartbeat"
+	metadataChannelHeartbeatKey        = "channelHeartbeat"
+	metadataMaxLenKey                  = "maxLen"
+	metadataMaxLenBytesKey             = "maxLenBytes"
+	metadataExchangeKindKey            = "exchangeKind"
+	metadataPublisherConfirmKey        = "publisherConfirm"
+	metadataPublisherConfirmTimeoutKey = "publisherConfirmTimeout"
+	metadataQueueTypeKey               = "queueType"
+	metadataConsumerPriorityKey        = "consumerPriority"
+	metadataSaslExternal               = "saslExternal"
+	metadataMaxPriority                = "maxPriority"
+
+	defaultReconnectWaitSeconds    = 3
+	defaultReconnectMaxWait        = time.Minute
+	defaultHeartbeat               = 10 * time.Second
+	defaultPublisherConfirmTimeout = 10 * time.Second
+
+	queueTypeClassic = "classic"
+	queueTypeQuorum  = "quorum"
 
 	protocolAMQP  = "amqp"
 	protocolAMQPS = "amqps"
@@ -93,9 +121,13 @@ func createMetadata(pubSubMetadata pubsub.Metadata, log logger.Logger) (*rabbitm
 		DeleteWhenUnused: true,
 		AutoAck:          false,
 		ReconnectWait:    time.Duration(defaultReconnectWaitSeconds) * time.Second,
+		ReconnectMaxWait: defaultReconnectMaxWait,
+		Heartbeat:        defaultHeartbeat,
 		ExchangeKind:     fanoutExchangeKind,
 		PublisherConfirm: false,
 		SaslExternal:     false,
+
+		PublisherConfirmTimeout: defaultPublisherConfirmTimeout,
 	}
 
 	// upgrade metadata
@@ -134,6 +166,37 @@ func createMetadata(pubSubMetadata pubsub.Metadata, log logger.Logger) (*rabbitm
 		return &result, fmt.Errorf("%s invalid RabbitMQ exchange kind %s", errorMessagePrefix, result.ExchangeKind)
 	}
 
+	if result.PublisherConfirmTimeout <= 0 {
+		return &result, fmt.Errorf("%s invalid %s, must be greater than 0", errorMessagePrefix, metadataPublisherConfirmTimeoutKey)
+	}
+
+	if result.ReconnectWait < 0 {
+		return &result, fmt.Errorf("%s invalid %s, must not be negative", errorMessagePrefix, metadataReconnectWaitSecondsKey)
+	}
+	// The backoff never waits less than the initial interval
+	if result.ReconnectMaxWait < result.ReconnectWait {
+		result.ReconnectMaxWait = result.ReconnectWait
+	}
+
+	if result.Heartbeat < 0 {
+		return &result, fmt.Errorf("%s invalid %s, must not be negative", errorMessagePrefix, metadataHeartbeatKey)
+	}
+	if result.ChannelHeartbeat < 0 {
+		return &result, fmt.Errorf("%s invalid %s, must not be negative", errorMessagePrefix, metadataChannelHeartbeatKey)
+	}
+
+	result.QueueType = strings.ToLower(result.QueueType)
+	switch result.QueueType {
+	case "", queueTypeClassic:
+	case queueTypeQuorum:
+		// Quorum queues are always durable
+		if !result.Durable {
+			return &result, fmt.Errorf("%s quorum queues must be durable", errorMessagePrefix)
+		}
+	default:
+		return &result, fmt.Errorf("%s invalid RabbitMQ queue type %s, accepted values are '%s' and '%s'", errorMessagePrefix, result.QueueType, queueTypeClassic, queueTypeQuorum)
+	}
+
 	ttl, ok, err := metadata.TryGetTTL(pubSubMetadata.Properties)
 	if err != nil {
 		return &result, fmt.Errorf("%s parse RabbitMQ ttl metadata with error: %s", errorMessagePrefix, err)
@@ -152,6 +215,11 @@ func createMetadata(pubSubMetadata pubsub.Metadata, log logger.Logger) (*rabbitm
 		return &result, fmt.Errorf("%s can only be set to true, when all these properties are set: %s, %s, %s", metadataSaslExternal, pubsub.CACert, pubsub.ClientCert, pubsub.ClientKey)
 	}
 
+	result.Compression, err = pubsub.ParseCompression(pubSubMetadata.Properties)
+	if err != nil {
+		return &result, fmt.Errorf("%s %w", errorMessagePrefix, err)
+	}
+
 	result.Concurrency, err = pubsub.Concurrency(pubSubMetadata.Properties)
 	return &result, err
 }
@@ -166,10 +234,38 @@ func (m *rabbitmqMetadata) formatQueueDeclareArgs(origin amqp.Table) amqp.Table
 	if m.MaxLenBytes > 0 {
 		origin[argMaxLengthBytes] = m.MaxLenBytes
 	}
+	if m.QueueType != "" {
+		origin[argQueueType] = m.QueueType
+	}
 
 	return origin
 }
 
+// consumeArgs returns the arguments of the consumers of a subscription.
+// The consumer priority can be overridden in the subscription metadata.
+func (m *rabbitmqMetadata) consumeArgs(reqMetadata map[string]string) (amqp.Table, error) {
+	priority := m.ConsumerPriority
+	if val := reqMetadata[metadataConsumerPriorityKey]; val != "" {
+		parsed, err := strconv.ParseInt(val, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s invalid %s %s: %w", errorMessagePrefix, metadataConsumerPriorityKey, val, err)
+		}
+		priority = int32(parsed)
+	}
+
+	// Priority 0 is the default
+	if priority == 0 {
+		return nil, nil
+	}
+	return amqp.Table{argConsumerPriority: priority}, nil
+}
+
+// queueAutoDelete returns true if the queues must be deleted when unused.
+// Quorum queues can't be deleted automatically.
+func (m *rabbitmqMetadata) queueAutoDelete() bool {
+	return m.DeleteWhenUnused && m.QueueType != queueTypeQuorum
+}
+
 func exchangeKindValid(kind string) bool {
 	return kind == amqp.ExchangeFanout || kind == amqp.ExchangeTopic || kind == amqp.ExchangeDirect || kind == amqp.ExchangeHeaders
 }
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/stretchr/testify/assert"
@@ -434,6 +435,141 @@ func TestCreateMetadata(t *testing.T) {
 		// assert
 		assert.Error(t, err)
 	})
+
+	t.Run("publisherConfirmTimeout", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+		fakeMetaData := pubsub.Metadata{
+			Base: mdata.Base{Properties: fakeProperties},
+		}
+
+		m, err := createMetadata(fakeMetaData, log)
+		assert.NoError(t, err)
+		assert.Equal(t, defaultPublisherConfirmTimeout, m.PublisherConfirmTimeout)
+
+		fakeMetaData.Properties[metadataPublisherConfirmTimeoutKey] = "2s"
+		m, err = createMetadata(fakeMetaData, log)
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Second, m.PublisherConfirmTimeout)
+
+		fakeMetaData.Properties[metadataPublisherConfirmTimeoutKey] = "0"
+		_, err = createMetadata(fakeMetaData, log)
+		assert.Error(t, err)
+	})
+
+	for _, queueType := range []string{"classic", "quorum", "Quorum"} {
+		t.Run(fmt.Sprintf("queueType value=%s", queueType), func(t *testing.T) {
+			fakeProperties := getFakeProperties()
+
+			fakeMetaData := pubsub.Metadata{
+				Base: mdata.Base{Properties: fakeProperties},
+			}
+			fakeMetaData.Properties[metadataQueueTypeKey] = queueType
+
+			// act
+			m, err := createMetadata(fakeMetaData, log)
+
+			// assert
+			assert.NoError(t, err)
+			assert.Equal(t, strings.ToLower(queueType), m.QueueType)
+			assert.Equal(t, strings.ToLower(queueType), m.formatQueueDeclareArgs(nil)[argQueueType])
+		})
+	}
+
+	t.Run("queueType is invalid", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+
+		fakeMetaData := pubsub.Metadata{
+			Base: mdata.Base{Properties: fakeProperties},
+		}
+		fakeMetaData.Properties[metadataQueueTypeKey] = "stream"
+
+		// act
+		_, err := createMetadata(fakeMetaData, log)
+
+		// assert
+		assert.Error(t, err)
+	})
+
+	t.Run("quorum queues are not auto-deleted and must be durable", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+
+		fakeMetaData := pubsub.Metadata{
+			Base: mdata.Base{Properties: fakeProperties},
+		}
+		fakeMetaData.Properties[metadataQueueTypeKey] = queueTypeQuorum
+		fakeMetaData.Properties[metadataDeleteWhenUnusedKey] = "true"
+
+		m, err := createMetadata(fakeMetaData, log)
+		assert.NoError(t, err)
+		assert.True(t, m.DeleteWhenUnused)
+		assert.False(t, m.queueAutoDelete())
+
+		fakeMetaData.Properties[metadataDurableKey] = "false"
+		_, err = createMetadata(fakeMetaData, log)
+		assert.Error(t, err)
+	})
+
+	t.Run("consumerPriority", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+
+		fakeMetaData := pubsub.Metadata{
+			Base: mdata.Base{Properties: fakeProperties},
+		}
+
+		m, err := createMetadata(fakeMetaData, log)
+		assert.NoError(t, err)
+		args, err := m.consumeArgs(nil)
+		assert.NoError(t, err)
+		assert.Nil(t, args)
+
+		fakeMetaData.Properties[metadataConsumerPriorityKey] = "5"
+		m, err = createMetadata(fakeMetaData, log)
+		assert.NoError(t, err)
+		args, err = m.consumeArgs(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, amqp.Table{argConsumerPriority: int32(5)}, args)
+
+		// Overridden by the subscription
+		args, err = m.consumeArgs(map[string]string{metadataConsumerPriorityKey: "-2"})
+		assert.NoError(t, err)
+		assert.Equal(t, amqp.Table{argConsumerPriority: int32(-2)}, args)
+
+		_, err = m.consumeArgs(map[string]string{metadataConsumerPriorityKey: "high"})
+		assert.Error(t, err)
+	})
+	t.Run("reconnect and heartbeats", func(t *testing.T) {
+		fakeProperties := getFakeProperties()
+
+		fakeMetaData := pubsub.Metadata{
+			Base: mdata.Base{Properties: fakeProperties},
+		}
+
+		m, err := createMetadata(fakeMetaData, log)
+		assert.NoError(t, err)
+		assert.Equal(t, 3*time.Second, m.ReconnectWait)
+		assert.Equal(t, time.Minute, m.ReconnectMaxWait)
+		assert.Equal(t, 10*time.Second, m.Heartbeat)
+		assert.Equal(t, time.Duration(0), m.ChannelHeartbeat)
+
+		fakeMetaData.Properties[metadataReconnectMaxWaitSecondsKey] = "30"
+		fakeMetaData.Properties[metadataHeartbeatKey] = "5s"
+		fakeMetaData.Properties[metadataChannelHeartbeatKey] = "1s"
+		m, err = createMetadata(fakeMetaData, log)
+		assert.NoError(t, err)
+		assert.Equal(t, 30*time.Second, m.ReconnectMaxWait)
+		assert.Equal(t, 5*time.Second, m.Heartbeat)
+		assert.Equal(t, time.Second, m.ChannelHeartbeat)
+
+		// The maximum wait is never less than the initial one
+		fakeMetaData.Properties[metadataReconnectWaitSecondsKey] = "120"
+		m, err = createMetadata(fakeMetaData, log)
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Minute, m.ReconnectMaxWait)
+
+		fakeMetaData.Properties[metadataHeartbeatKey] = "-1s"
+		_, err = createMetadata(fakeMetaData, log)
+		assert.Error(t, err)
+	})
 }
 
 func TestConnectionURI(t *testing.T) {
@@ -26,10 +26,13 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
+	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/components-contrib/pubsub/retrypolicy"
 	"github.com/dapr/kit/logger"
 )
 
@@ -50,8 +53,12 @@ const (
 	argMaxLengthBytes     = "x-max-length-bytes"
 	argDeadLetterExchange = "x-dead-letter-exchange"
 	argMaxPriority        = "x-max-priority"
+	argQueueType          = "x-queue-type"
+	argConsumerPriority   = "x-priority"
 	queueModeLazy         = "lazy"
 	reqMetadataRoutingKey = "routingKey"
+
+	headerDeliveryCount = "x-delivery-count"
 )
 
 // RabbitMQ allows sending/receiving messages in pub/sub format.
@@ -62,8 +69,9 @@ type rabbitMQ struct {
 	connectionCount   int
 	metadata          *rabbitmqMetadata
 	declaredExchanges map[string]bool
+	retryPolicy       *retrypolicy.Policy
 
-	connectionDial func(protocol, uri string, tlsCfg *tls.Config, externalSasl bool) (rabbitMQConnectionBroker, rabbitMQChannelBroker, error)
+	connectionDial func(protocol, uri string, tlsCfg *tls.Config, externalSasl bool, heartbeat time.Duration) (rabbitMQConnectionBroker, rabbitMQChannelBroker, error)
 	closeCh        chan struct{}
 	closed         atomic.Bool
 	wg             sync.WaitGroup
@@ -96,35 +104,33 @@ type rabbitMQConnectionBroker interface {
 
 // NewRabbitMQ creates a new RabbitMQ pub/sub.
 func NewRabbitMQ(logger logger.Logger) pubsub.PubSub {
-	return &rabbitMQ{
+	return pubsub.WithSchemaValidation(&rabbitMQ{
 		declaredExchanges: make(map[string]bool),
 		logger:            logger,
 		connectionDial:    dial,
 		closeCh:           make(chan struct{}),
-	}
+	})
 }
 
-func dial(protocol, uri string, tlsCfg *tls.Config, externalSasl bool) (rabbitMQConnectionBroker, rabbitMQChannelBroker, error) {
-	var (
-		conn *amqp.Connection
-		ch   *amqp.Channel
-		err  error
-	)
-
+func dial(protocol, uri string, tlsCfg *tls.Config, externalSasl bool, heartbeat time.Duration) (rabbitMQConnectionBroker, rabbitMQChannelBroker, error) {
+	// Same configuration as amqp.Dial, amqp.DialTLS and amqp.DialTLS_ExternalAuth, with a custom heartbeat
+	cfg := amqp.Config{
+		Heartbeat: heartbeat,
+		Locale:    "en_US",
+	}
 	if protocol == protocolAMQPS {
+		cfg.TLSClientConfig = tlsCfg
 		if externalSasl {
-			conn, err = amqp.DialTLS_ExternalAuth(uri, tlsCfg)
-		} else {
-			conn, err = amqp.DialTLS(uri, tlsCfg)
+			cfg.SASL = []amqp.Authentication{&amqp.ExternalAuth{}}
 		}
-	} else {
-		conn, err = amqp.Dial(uri)
 	}
+
+	conn, err := amqp.DialConfig(uri, cfg)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	ch, err = conn.Channel()
+	ch, err := conn.Channel()
 	if err != nil {
 		conn.Close()
 		return nil, nil, err
@@ -142,13 +148,64 @@ func (r *rabbitMQ) Init(_ context.Context, metadata pubsub.Metadata) error {
 
 	r.metadata = meta
 
+	retrySettings, err := retrypolicy.ParseSettings(metadata.Properties)
+	if err != nil {
+		return fmt.Errorf("%s %w", errorMessagePrefix, err)
+	}
+	r.retryPolicy = retrypolicy.New(retrySettings)
+
 	r.reconnect(0)
 	// We do not return error on reconnect because it can cause problems if init() happens
 	// right at the restart window for service. So, we try it now but there is logic in the
 	// code to reconnect as many times as needed.
+
+	if r.metadata.ChannelHeartbeat > 0 {
+		// Not tracked by the wait group, as Close waits for it while holding the channel lock
+		go r.watchChannel()
+	}
+
 	return nil
 }
 
+// watchChannel checks the channel periodically and reconnects as soon as it's closed, for example after a broker restart, without waiting for the next publish.
+// Subscribers re-declare their exchanges, queues and bindings on the new channel when their consumer is closed.
+func (r *rabbitMQ) watchChannel() {
+	t := time.NewTicker(r.metadata.ChannelHeartbeat)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-r.closeCh:
+			return
+		case <-t.C:
+		}
+
+		r.channelMutex.RLock()
+		channel, connectionCount := r.channel, r.connectionCount
+		r.channelMutex.RUnlock()
+		if !mustReconnect(channel, nil) {
+			continue
+		}
+
+		r.logger.Warnf("%s channel is closed, reconnecting ...", logMessagePrefix)
+		err := r.reconnect(connectionCount)
+		if err != nil && !r.isStopped() {
+			r.logger.Errorf("%s failed to reconnect: %v", logMessagePrefix, err)
+		}
+	}
+}
+
+// newReconnectBackOff returns the exponential backoff used to wait between reconnection attempts.
+func (r *rabbitMQ) newReconnectBackOff() backoff.BackOff {
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = r.metadata.ReconnectWait
+	bo.MaxInterval = r.metadata.ReconnectMaxWait
+	// Never stop retrying
+	bo.MaxElapsedTime = 0
+	bo.Reset()
+	return bo
+}
+
 func (r *rabbitMQ) reconnect(connectionCount int) error {
 	r.channelMutex.Lock()
 	defer r.channelMutex.Unlock()
@@ -176,7 +233,7 @@ func (r *rabbitMQ) reconnect(connectionCount int) error {
 		return err
 	}
 
-	r.connection, r.channel, err = r.connectionDial(r.metadata.internalProtocol, r.metadata.connectionURI(), tlsCfg, r.metadata.SaslExternal)
+	r.connection, r.channel, err = r.connectionDial(r.metadata.internalProtocol, r.metadata.connectionURI(), tlsCfg, r.metadata.SaslExternal, r.metadata.Heartbeat)
 	if err != nil {
 		r.reset()
 
@@ -200,17 +257,51 @@ func (r *rabbitMQ) reconnect(connectionCount int) error {
 }
 
 func (r *rabbitMQ) publishSync(ctx context.Context, req *pubsub.PublishRequest) (rabbitMQChannelBroker, int, error) {
+	channel, connectionCount, confirm, err := r.sendMessage(ctx, req)
+	// confirm will be nil if are not requesting publish confirmations
+	if err != nil || confirm == nil {
+		return channel, connectionCount, err
+	}
+
+	// Wait for the confirmation without holding the lock, so other publishers and subscribers are not blocked
+	err = r.waitForConfirm(ctx, channel, confirm)
+	if err != nil {
+		r.logger.Errorf("%s publishing to %s failed: %v", logMessagePrefix, req.Topic, err)
+	}
+	return channel, connectionCount, err
+}
+
+// waitForConfirm blocks until the broker confirms a message, or until the confirm timeout.
+func (r *rabbitMQ) waitForConfirm(ctx context.Context, channel rabbitMQChannelBroker, confirm *amqp.DeferredConfirmation) error {
+	confirmCtx, cancel := context.WithTimeout(ctx, r.metadata.PublisherConfirmTimeout)
+	defer cancel()
+	acked, err := confirm.WaitContext(confirmCtx)
+	switch {
+	case acked:
+		return nil
+	case channel.IsClosed():
+		// Pending confirmations are nacked when the channel is closed, so the message must be published again after reconnecting
+		return fmt.Errorf("did not receive confirmation of publishing: %s", errorChannelConnection)
+	case err != nil:
+		return fmt.Errorf("did not receive confirmation of publishing within %s: %w", r.metadata.PublisherConfirmTimeout, err)
+	default:
+		return errors.New("the broker did not acknowledge the message (nack)")
+	}
+}
+
+// sendMessage publishes a message and returns the deferred confirmation when publisher confirms are enabled.
+func (r *rabbitMQ) sendMessage(ctx context.Context, req *pubsub.PublishRequest) (rabbitMQChannelBroker, int, *amqp.DeferredConfirmation, error) {
 	r.channelMutex.Lock()
 	defer r.channelMutex.Unlock()
 
 	if r.channel == nil {
-		return r.channel, r.connectionCount, errors.New(errorChannelNotInitialized)
+		return r.channel, r.connectionCount, nil, errors.New(errorChannelNotInitialized)
 	}
 
 	if err := r.ensureExchangeDeclared(r.channel, req.Topic, r.metadata.ExchangeKind, r.metadata.Durable, r.metadata.DeleteWhenUnused); err != nil {
 		r.logger.Errorf("%s publishing to %s failed in ensureExchangeDeclared: %v", logMessagePrefix, req.Topic, err)
 
-		return r.channel, r.connectionCount, err
+		return r.channel, r.connectionCount, nil, err
 	}
 	routingKey := ""
 	if val, ok := req.Metadata[reqMetadataRoutingKey]; ok && val != "" {
@@ -229,13 +320,23 @@ func (r *rabbitMQ) publishSync(ctx context.Context, req *pubsub.PublishRequest)
 		expiration = strconv.FormatInt(r.metadata.DefaultQueueTTL.Milliseconds(), 10)
 	}
 
+	body, err := r.metadata.Compression.Compress(req.Data)
+	if err != nil {
+		return r.channel, r.connectionCount, nil, fmt.Errorf("%s failed to compress message: %w", errorMessagePrefix, err)
+	}
+
 	p := amqp.Publishing{
 		ContentType:  "text/plain",
-		Body:         req.Data,
+		Body:         body,
 		DeliveryMode: r.metadata.DeliveryMode,
 		Expiration:   expiration,
 	}
 
+	// The retry policy counts the delivery attempts by message ID when the queue doesn't report them
+	if r.retryPolicy.Enabled() {
+		p.MessageId = uuid.NewString()
+	}
+
 	priority, ok, err := metadata.TryGetPriority(req.Metadata)
 	if err != nil {
 		r.logger.Warnf("%s publishing to %s failed to parse priority: %v, it is ignored.", logMessagePrefix, req.Topic, err)
@@ -245,24 +346,38 @@ func (r *rabbitMQ) publishSync(ctx context.Context, req *pubsub.PublishRequest)
 		p.Priority = priority
 	}
 
-	confirm, err := r.channel.PublishWithDeferredConfirmWithContext(ctx, req.Topic, routingKey, false, false, p)
-	if err != nil {
-		r.logger.Errorf("%s publishing to %s failed in channel.Publish: %v", logMessagePrefix, req.Topic, err)
+	if tc := pubsub.TraceContextFromMetadata(req.Metadata); tc != nil {
+		p.Headers = make(amqp.Table, len(tc))
+		for k, v := range tc {
+			p.Headers[k] = v
+		}
+	}
 
-		return r.channel, r.connectionCount, err
+	if r.metadata.Compression != pubsub.CompressionNone {
+		if p.Headers == nil {
+			p.Headers = amqp.Table{}
+		}
+		p.Headers[pubsub.ContentEncodingKey] = string(r.metadata.Compression)
 	}
 
-	// confirm will be nil if are not requesting publish confirmations
-	if confirm != nil {
-		// Blocks until the server confirms
-		ok := confirm.Wait()
-		if !ok {
-			err = fmt.Errorf("did not receive confirmation of publishing")
-			r.logger.Errorf("%s publishing to %s failed: %v", logMessagePrefix, req.Topic, err)
+	// Messages sent to the dead-letter topic carry the details of the failure as headers
+	for _, k := range []string{retrypolicy.DeadLetterTopicKey, retrypolicy.DeadLetterAttemptsKey, retrypolicy.DeadLetterErrorKey} {
+		if v, ok := req.Metadata[k]; ok {
+			if p.Headers == nil {
+				p.Headers = amqp.Table{}
+			}
+			p.Headers[k] = v
 		}
 	}
 
-	return r.channel, r.connectionCount, nil
+	confirm, err := r.channel.PublishWithDeferredConfirmWithContext(ctx, req.Topic, routingKey, false, false, p)
+	if err != nil {
+		r.logger.Errorf("%s publishing to %s failed in channel.Publish: %v", logMessagePrefix, req.Topic, err)
+
+		return r.channel, r.connectionCount, nil, err
+	}
+
+	return r.channel, r.connectionCount, confirm, nil
 }
 
 func (r *rabbitMQ) Publish(ctx context.Context, req *pubsub.PublishRequest) error {
@@ -272,6 +387,7 @@ func (r *rabbitMQ) Publish(ctx context.Context, req *pubsub.PublishRequest) erro
 
 	r.logger.Debugf("%s publishing message to %s", logMessagePrefix, req.Topic)
 
+	var bo backoff.BackOff
 	attempt := 0
 	for {
 		attempt++
@@ -284,9 +400,13 @@ func (r *rabbitMQ) Publish(ctx context.Context, req *pubsub.PublishRequest) erro
 			return err
 		}
 		if mustReconnect(channel, err) {
-			r.logger.Warnf("%s publisher is reconnecting in %s ...", logMessagePrefix, r.metadata.ReconnectWait.String())
+			if bo == nil {
+				bo = r.newReconnectBackOff()
+			}
+			wait := bo.NextBackOff()
+			r.logger.Warnf("%s publisher is reconnecting in %s ...", logMessagePrefix, wait)
 			select {
-			case <-time.After(r.metadata.ReconnectWait):
+			case <-time.After(wait):
 			case <-ctx.Done():
 				return nil
 			}
@@ -315,6 +435,19 @@ func (r *rabbitMQ) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, h
 	queueName := fmt.Sprintf("%s-%s", r.metadata.ConsumerID, req.Topic)
 	r.logger.Infof("%s subscribe to topic/queue '%s/%s'", logMessagePrefix, req.Topic, queueName)
 
+	if r.metadata.QueueType == queueTypeQuorum && req.Metadata[metadataMaxPriority] != "" {
+		return fmt.Errorf("%s %s is not supported by quorum queues", errorMessagePrefix, metadataMaxPriority)
+	}
+	consumeArgs, err := r.metadata.consumeArgs(req.Metadata)
+	if err != nil {
+		return err
+	}
+	handlerSettings, err := pubsub.HandlerSettingsFromMetadata(req.Metadata, pubsub.HandlerSettings{})
+	if err != nil {
+		return fmt.Errorf("%s %w", errorMessagePrefix, err)
+	}
+	handler = handlerSettings.Handler(handler)
+
 	// Do not set a timeout on the context, as we're just waiting for the first ack; we're using a semaphore instead
 	ackCh := make(chan struct{}, 1)
 	defer close(ackCh)
@@ -323,7 +456,7 @@ func (r *rabbitMQ) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, h
 	r.wg.Add(2)
 	go func() {
 		defer r.wg.Done()
-		r.subscribeForever(subctx, req, queueName, handler, ackCh)
+		r.subscribeForever(subctx, req, queueName, handler, consumeArgs, ackCh)
 	}()
 	go func() {
 		defer r.wg.Done()
@@ -367,9 +500,11 @@ func (r *rabbitMQ) prepareSubscription(channel rabbitMQChannelBroker, req pubsub
 		}
 		var q amqp.Queue
 		dlqArgs := r.metadata.formatQueueDeclareArgs(nil)
-		// dead letter queue use lazy mode, keeping as many messages as possible on disk to reduce RAM usage
-		dlqArgs[argQueueMode] = queueModeLazy
-		q, err = channel.QueueDeclare(dlqName, true, r.metadata.DeleteWhenUnused, false, false, dlqArgs)
+		if r.metadata.QueueType != queueTypeQuorum {
+			// dead letter queue use lazy mode, keeping as many messages as possible on disk to reduce RAM usage
+			dlqArgs[argQueueMode] = queueModeLazy
+		}
+		q, err = channel.QueueDeclare(dlqName, true, r.metadata.queueAutoDelete(), false, false, dlqArgs)
 		if err != nil {
 			r.logger.Errorf("%s prepareSubscription for topic/queue '%s/%s' failed in channel.QueueDeclare: %v", logMessagePrefix, req.Topic, dlqName, err)
 
@@ -402,7 +537,7 @@ func (r *rabbitMQ) prepareSubscription(channel rabbitMQChannelBroker, req pubsub
 		args[argMaxPriority] = mp
 	}
 
-	q, err := channel.QueueDeclare(queueName, r.metadata.Durable, r.metadata.DeleteWhenUnused, false, false, args)
+	q, err := channel.QueueDeclare(queueName, r.metadata.Durable, r.metadata.queueAutoDelete(), false, false, args)
 	if err != nil {
 		r.logger.Errorf("%s prepareSubscription for topic/queue '%s/%s' failed in channel.QueueDeclare: %v", logMessagePrefix, req.Topic, queueName, err)
 
@@ -451,7 +586,8 @@ func (r *rabbitMQ) ensureSubscription(req pubsub.SubscribeRequest, queueName str
 	return r.channel, r.connectionCount, q, err
 }
 
-func (r *rabbitMQ) subscribeForever(ctx context.Context, req pubsub.SubscribeRequest, queueName string, handler pubsub.Handler, ackCh chan struct{}) {
+func (r *rabbitMQ) subscribeForever(ctx context.Context, req pubsub.SubscribeRequest, queueName string, handler pubsub.Handler, consumeArgs amqp.Table, ackCh chan struct{}) {
+	bo := r.newReconnectBackOff()
 	for {
 		var (
 			err             error
@@ -475,7 +611,7 @@ func (r *rabbitMQ) subscribeForever(ctx context.Context, req pubsub.SubscribeReq
 				false,              // exclusive
 				false,              // noLocal
 				false,              // noWait
-				nil,
+				consumeArgs,
 			)
 			if err != nil {
 				errFuncName = "channel.Consume"
@@ -488,6 +624,9 @@ func (r *rabbitMQ) subscribeForever(ctx context.Context, req pubsub.SubscribeReq
 				ackCh = nil
 			}
 
+			// The subscription is restored, so the next reconnection starts from the initial wait
+			bo.Reset()
+
 			err = r.listenMessages(ctx, channel, msgs, req.Topic, handler)
 			if err != nil {
 				errFuncName = "listenMessages"
@@ -512,9 +651,10 @@ func (r *rabbitMQ) subscribeForever(ctx context.Context, req pubsub.SubscribeReq
 		}
 
 		if mustReconnect(channel, err) {
-			r.logger.Warnf("%s subscriber is reconnecting in %s ...", logMessagePrefix, r.metadata.ReconnectWait.String())
+			wait := bo.NextBackOff()
+			r.logger.Warnf("%s subscriber is reconnecting in %s ...", logMessagePrefix, wait)
 			select {
-			case <-time.After(r.metadata.ReconnectWait):
+			case <-time.After(wait):
 			case <-ctx.Done():
 				r.logger.Infof("%s subscription for %s has context canceled", logMessagePrefix, queueName)
 				return
@@ -558,16 +698,29 @@ func (r *rabbitMQ) listenMessages(ctx context.Context, channel rabbitMQChannelBr
 
 func (r *rabbitMQ) handleMessage(ctx context.Context, d amqp.Delivery, topic string, handler pubsub.Handler) error {
 	pubsubMsg := &pubsub.NewMessage{
-		Data:  d.Body,
 		Topic: topic,
 	}
+	pubsubMsg.Metadata = pubsub.AddTraceContextToMetadata(pubsubMsg.Metadata, func(key string) string {
+		v, _ := d.Headers[key].(string)
+		return v
+	})
 
-	err := handler(ctx, pubsubMsg)
+	contentEncoding, _ := d.Headers[pubsub.ContentEncodingKey].(string)
+	data, err := pubsub.Decompress(contentEncoding, d.Body)
+	if err != nil {
+		pubsubMsg.Data = d.Body
+		err = fmt.Errorf("failed to decompress message: %w", err)
+	} else {
+		pubsubMsg.Data = data
+		err = handler(ctx, pubsubMsg)
+	}
 
 	if err != nil {
 		r.logger.Errorf("%s handling message from topic '%s', %s", errorMessagePrefix, topic, err)
 
-		if !r.metadata.AutoAck {
+		if !r.metadata.AutoAck && r.retryPolicy.Enabled() && (d.MessageId != "" || deliveryCount(d) > 0) {
+			err = r.handleFailure(ctx, d, pubsubMsg, err)
+		} else if !r.metadata.AutoAck {
 			// if message is not auto acked we need to ack/nack
 			r.logger.Debugf("%s nacking message '%s' from topic '%s', requeue=%t", logMessagePrefix, d.MessageId, topic, r.metadata.RequeueInFailure)
 			if err = d.Nack(false, r.metadata.RequeueInFailure); err != nil {
@@ -575,6 +728,8 @@ func (r *rabbitMQ) handleMessage(ctx context.Context, d amqp.Delivery, topic str
 			}
 		}
 	} else if !r.metadata.AutoAck {
+		r.retryPolicy.RecordSuccess(d.MessageId)
+
 		// if message is not auto acked we need to ack/nack
 		r.logger.Debugf("%s acking message '%s' from topic '%s'", logMessagePrefix, d.MessageId, topic)
 		if err = d.Ack(false); err != nil {
@@ -585,6 +740,53 @@ func (r *rabbitMQ) handleMessage(ctx context.Context, d amqp.Delivery, topic str
 	return err
 }
 
+// handleFailure applies the retry policy to a message that failed processing.
+// The message is requeued after the backoff of the policy, or it's published to the dead-letter topic and acked once the retry budget is exhausted.
+func (r *rabbitMQ) handleFailure(ctx context.Context, d amqp.Delivery, msg *pubsub.NewMessage, processErr error) error {
+	decision := r.retryPolicy.RecordFailure(d.MessageId, deliveryCount(d))
+	if !decision.DeadLetter {
+		// Wait for the backoff before the message is requeued, as RabbitMQ redelivers it right away
+		r.logger.Debugf("%s requeueing message '%s' from topic '%s' in %s", logMessagePrefix, d.MessageId, msg.Topic, decision.Delay)
+		select {
+		case <-time.After(decision.Delay):
+		case <-ctx.Done():
+		case <-r.closeCh:
+		}
+		err := d.Nack(false, true)
+		if err != nil {
+			r.logger.Errorf("%s error nacking message '%s' from topic '%s', %s", logMessagePrefix, d.MessageId, msg.Topic, err)
+		}
+		return err
+	}
+
+	if topic := r.retryPolicy.DeadLetterTopic(); topic != "" {
+		err := r.Publish(ctx, r.retryPolicy.DeadLetterRequest("", msg, decision.Attempt, processErr))
+		if err != nil {
+			r.logger.Errorf("%s error publishing message '%s' from topic '%s' to dead-letter topic '%s', %s", logMessagePrefix, d.MessageId, msg.Topic, topic, err)
+			if err = d.Nack(false, true); err != nil {
+				r.logger.Errorf("%s error nacking message '%s' from topic '%s', %s", logMessagePrefix, d.MessageId, msg.Topic, err)
+			}
+			return err
+		}
+	}
+
+	r.logger.Warnf("%s message '%s' from topic '%s' was dead-lettered after %d failed deliveries", logMessagePrefix, d.MessageId, msg.Topic, decision.Attempt)
+	err := d.Ack(false)
+	if err != nil {
+		r.logger.Errorf("%s error acking message '%s' from topic '%s', %s", logMessagePrefix, d.MessageId, msg.Topic, err)
+	}
+	return err
+}
+
+// deliveryCount returns the number of times a message has been delivered, as reported by quorum queues, or 0 if unknown.
+func deliveryCount(d amqp.Delivery) int {
+	// The header contains the number of previous deliveries
+	if n, ok := d.Headers[headerDeliveryCount].(int64); ok {
+		return int(n) + 1
+	}
+	return 0
+}
+
 // this function call should be wrapped by channelMutex.
 func (r *rabbitMQ) ensureExchangeDeclared(channel rabbitMQChannelBroker, exchange, exchangeKind string, durable bool, autoDelete bool) error {
 	if !r.containsExchange(exchange) {
@@ -662,7 +864,8 @@ func (r *rabbitMQ) Features() []pubsub.Feature {
 }
 
 func mustReconnect(channel rabbitMQChannelBroker, err error) bool {
-	if channel == nil {
+	// The channel is closed by the library when the connection is lost or the heartbeats time out
+	if channel == nil || channel.IsClosed() {
 		return true
 	}
 
@@ -678,5 +881,6 @@ func (r *rabbitMQ) GetComponentMetadata() map[string]string {
 	metadataStruct := rabbitmqMetadata{}
 	metadataInfo := map[string]string{}
 	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.PubSubType)
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(retrypolicy.Settings{}), &metadataInfo, metadata.PubSubType)
 	return metadataInfo
 }
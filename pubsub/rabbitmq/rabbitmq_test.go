@@ -17,6 +17,7 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -27,6 +28,7 @@ import (
 
 	mdata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/components-contrib/pubsub/retrypolicy"
 	"github.com/dapr/kit/logger"
 )
 
@@ -40,8 +42,9 @@ func newRabbitMQTest(broker *rabbitMQInMemoryBroker) pubsub.PubSub {
 	return &rabbitMQ{
 		declaredExchanges: make(map[string]bool),
 		logger:            logger.NewLogger("test"),
-		connectionDial: func(protocol, uri string, tlsCfg *tls.Config, externalSasl bool) (rabbitMQConnectionBroker, rabbitMQChannelBroker, error) {
+		connectionDial: func(protocol, uri string, tlsCfg *tls.Config, externalSasl bool, heartbeat time.Duration) (rabbitMQConnectionBroker, rabbitMQChannelBroker, error) {
 			broker.connectCount.Add(1)
+			broker.connected.Store(true)
 			return broker, broker, nil
 		},
 		closeCh: make(chan struct{}),
@@ -194,6 +197,73 @@ func TestPublishAndSubscribe(t *testing.T) {
 	assert.Equal(t, "foo bar", lastMessage)
 }
 
+func TestPublishAndSubscribeTraceContext(t *testing.T) {
+	broker := newBroker()
+	pubsubRabbitMQ := newRabbitMQTest(broker)
+	metadata := pubsub.Metadata{Base: mdata.Base{
+		Properties: map[string]string{
+			metadataHostnameKey:   "anyhost",
+			metadataConsumerIDKey: "consumer",
+		},
+	}}
+	err := pubsubRabbitMQ.Init(context.Background(), metadata)
+	assert.Nil(t, err)
+
+	topic := "mytopic"
+	received := make(chan map[string]string, 1)
+	handler := func(ctx context.Context, msg *pubsub.NewMessage) error {
+		received <- msg.Metadata
+		return nil
+	}
+
+	err = pubsubRabbitMQ.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: topic}, handler)
+	assert.Nil(t, err)
+
+	traceContext := map[string]string{
+		"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"tracestate":  "congo=t61rcWkgMzE",
+	}
+	err = pubsubRabbitMQ.Publish(context.Background(), &pubsub.PublishRequest{Topic: topic, Data: []byte("hello world"), Metadata: traceContext})
+	assert.Nil(t, err)
+	assert.Equal(t, traceContext, <-received)
+
+	err = pubsubRabbitMQ.Publish(context.Background(), &pubsub.PublishRequest{Topic: topic, Data: []byte("hello world")})
+	assert.Nil(t, err)
+	assert.Empty(t, <-received)
+}
+
+func TestPublishAndSubscribeCompression(t *testing.T) {
+	broker := newBroker()
+	pubsubRabbitMQ := newRabbitMQTest(broker)
+	metadata := pubsub.Metadata{Base: mdata.Base{
+		Properties: map[string]string{
+			metadataHostnameKey:   "anyhost",
+			metadataConsumerIDKey: "consumer",
+			pubsub.CompressionKey: "gzip",
+		},
+	}}
+	err := pubsubRabbitMQ.Init(context.Background(), metadata)
+	assert.Nil(t, err)
+
+	topic := "mytopic"
+	received := make(chan []byte, 1)
+	handler := func(ctx context.Context, msg *pubsub.NewMessage) error {
+		received <- msg.Data
+		return nil
+	}
+
+	err = pubsubRabbitMQ.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: topic}, handler)
+	assert.Nil(t, err)
+
+	err = pubsubRabbitMQ.Publish(context.Background(), &pubsub.PublishRequest{Topic: topic, Data: []byte("hello world")})
+	assert.Nil(t, err)
+	assert.Equal(t, "hello world", string(<-received))
+
+	// Content encodings set by other producers are not treated as compression
+	broker.buffer <- amqp.Delivery{Body: []byte("plain"), ContentEncoding: "utf-8"}
+	assert.Equal(t, "plain", string(<-received))
+}
+
 func TestPublishReconnect(t *testing.T) {
 	broker := newBroker()
 	pubsubRabbitMQ := newRabbitMQTest(broker)
@@ -380,6 +450,118 @@ func TestSubscribeReconnect(t *testing.T) {
 	assert.Equal(t, int32(4), broker.closeCount.Load())   // two counts for each connection closure - one for connection, one for channel
 }
 
+func TestChannelHeartbeatReconnect(t *testing.T) {
+	broker := newBroker()
+	pubsubRabbitMQ := newRabbitMQTest(broker)
+	metadata := pubsub.Metadata{Base: mdata.Base{
+		Properties: map[string]string{
+			metadataHostnameKey:             "anyhost",
+			metadataConsumerIDKey:           "consumer",
+			metadataReconnectWaitSecondsKey: "0",
+			metadataChannelHeartbeatKey:     "10ms",
+		},
+	}}
+	err := pubsubRabbitMQ.Init(context.Background(), metadata)
+	require.NoError(t, err)
+	defer pubsubRabbitMQ.Close()
+	assert.Equal(t, int32(1), broker.connectCount.Load())
+
+	// The broker closes the channel, for example because it restarted
+	broker.connected.Store(false)
+
+	// The channel is re-created without waiting for a publish
+	assert.Eventually(t, func() bool {
+		return broker.connectCount.Load() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), broker.closeCount.Load())
+	assert.False(t, broker.IsClosed())
+
+	received := make(chan string, 1)
+	err = pubsubRabbitMQ.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "heartbeat"}, func(ctx context.Context, msg *pubsub.NewMessage) error {
+		received <- string(msg.Data)
+		return nil
+	})
+	require.NoError(t, err)
+	err = pubsubRabbitMQ.Publish(context.Background(), &pubsub.PublishRequest{Topic: "heartbeat", Data: []byte("hello world")})
+	require.NoError(t, err)
+	select {
+	case msg := <-received:
+		assert.Equal(t, "hello world", msg)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timeout waiting for message")
+	}
+}
+
+func TestMustReconnect(t *testing.T) {
+	broker := newBroker()
+	assert.True(t, mustReconnect(nil, nil))
+	assert.True(t, mustReconnect(broker, nil))
+
+	broker.connected.Store(true)
+	assert.False(t, mustReconnect(broker, nil))
+	assert.False(t, mustReconnect(broker, errors.New("some error")))
+	assert.True(t, mustReconnect(broker, errors.New(errorChannelConnection)))
+}
+
+func TestSubscribeQuorumQueue(t *testing.T) {
+	broker := newBroker()
+	pubsubRabbitMQ := newRabbitMQTest(broker)
+	metadata := pubsub.Metadata{Base: mdata.Base{
+		Properties: map[string]string{
+			metadataHostnameKey:         "anyhost",
+			metadataConsumerIDKey:       "consumer",
+			metadataQueueTypeKey:        "quorum",
+			metadataConsumerPriorityKey: "10",
+		},
+	}}
+	err := pubsubRabbitMQ.Init(context.Background(), metadata)
+	require.NoError(t, err)
+
+	handler := func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return nil
+	}
+
+	err = pubsubRabbitMQ.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "quorumtopic", Metadata: map[string]string{metadataMaxPriority: "5"}}, handler)
+	assert.ErrorContains(t, err, "not supported by quorum queues")
+
+	err = pubsubRabbitMQ.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "quorumtopic"}, handler)
+	require.NoError(t, err)
+
+	broker.lock.Lock()
+	defer broker.lock.Unlock()
+	assert.Equal(t, amqp.Table{argQueueType: queueTypeQuorum}, broker.queueArgs)
+	assert.False(t, broker.queueAutoDelete)
+	assert.Equal(t, amqp.Table{argConsumerPriority: int32(10)}, broker.consumeArgs)
+}
+
+func TestWaitForConfirm(t *testing.T) {
+	r := &rabbitMQ{
+		logger: logger.NewLogger("test"),
+		metadata: &rabbitmqMetadata{
+			PublisherConfirmTimeout: 10 * time.Millisecond,
+		},
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		broker := newBroker()
+		broker.connected.Store(true)
+
+		// The confirmation is never completed
+		err := r.waitForConfirm(context.Background(), broker, &amqp.DeferredConfirmation{})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.False(t, mustReconnect(broker, err))
+	})
+
+	t.Run("channel closed", func(t *testing.T) {
+		broker := newBroker()
+
+		err := r.waitForConfirm(context.Background(), broker, &amqp.DeferredConfirmation{})
+		require.Error(t, err)
+		assert.True(t, mustReconnect(broker, err))
+	})
+}
+
 func createAMQPMessage(body []byte) amqp.Delivery {
 	return amqp.Delivery{Body: body}
 }
@@ -387,8 +569,14 @@ func createAMQPMessage(body []byte) amqp.Delivery {
 type rabbitMQInMemoryBroker struct {
 	buffer chan amqp.Delivery
 
+	lock            sync.Mutex
+	queueArgs       amqp.Table
+	queueAutoDelete bool
+	consumeArgs     amqp.Table
+
 	connectCount atomic.Int32
 	closeCount   atomic.Int32
+	connected    atomic.Bool
 }
 
 func (r *rabbitMQInMemoryBroker) Qos(prefetchCount, prefetchSize int, global bool) error {
@@ -406,12 +594,20 @@ func (r *rabbitMQInMemoryBroker) PublishWithDeferredConfirmWithContext(ctx conte
 		return nil, errors.New(errorChannelConnection)
 	}
 
-	r.buffer <- createAMQPMessage(msg.Body)
+	d := createAMQPMessage(msg.Body)
+	d.Headers = msg.Headers
+	d.ContentEncoding = msg.ContentEncoding
+	r.buffer <- d
 
 	return nil, nil
 }
 
 func (r *rabbitMQInMemoryBroker) QueueDeclare(name string, durable bool, autoDelete bool, exclusive bool, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	r.lock.Lock()
+	r.queueArgs = args
+	r.queueAutoDelete = autoDelete
+	r.lock.Unlock()
+
 	return amqp.Queue{Name: name}, nil
 }
 
@@ -420,6 +616,10 @@ func (r *rabbitMQInMemoryBroker) QueueBind(name string, key string, exchange str
 }
 
 func (r *rabbitMQInMemoryBroker) Consume(queue string, consumer string, autoAck bool, exclusive bool, noLocal bool, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	r.lock.Lock()
+	r.consumeArgs = args
+	r.lock.Unlock()
+
 	return r.buffer, nil
 }
 
@@ -441,10 +641,51 @@ func (r *rabbitMQInMemoryBroker) Confirm(noWait bool) error {
 
 func (r *rabbitMQInMemoryBroker) Close() error {
 	r.closeCount.Add(1)
+	r.connected.Store(false)
 
 	return nil
 }
 
 func (r *rabbitMQInMemoryBroker) IsClosed() bool {
-	return r.connectCount.Load() <= r.closeCount.Load()
+	return !r.connected.Load()
+}
+
+func TestRetryPolicy(t *testing.T) {
+	broker := newBroker()
+	pubsubRabbitMQ := newRabbitMQTest(broker).(*rabbitMQ)
+	metadata := pubsub.Metadata{Base: mdata.Base{
+		Properties: map[string]string{
+			metadataHostnameKey:         "anyhost",
+			metadataConsumerIDKey:       "consumer",
+			"maxDeliveryAttempts":       "2",
+			"deadLetterTopic":           "mytopic-dlq",
+			"redeliveryInitialInterval": "1ms",
+		},
+	}}
+	require.NoError(t, pubsubRabbitMQ.Init(context.Background(), metadata))
+
+	handlerErr := errors.New("handler failed")
+	handler := func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return handlerErr
+	}
+	d := createAMQPMessage([]byte("hello world"))
+	d.MessageId = "msg-1"
+
+	// The first failure requeues the message, so nothing is published
+	pubsubRabbitMQ.handleMessage(context.Background(), d, "mytopic", handler)
+	assert.Empty(t, broker.buffer)
+
+	// The second failure exhausts the retry budget
+	pubsubRabbitMQ.handleMessage(context.Background(), d, "mytopic", handler)
+	require.Len(t, broker.buffer, 1)
+	dlq := <-broker.buffer
+	assert.Equal(t, "hello world", string(dlq.Body))
+	assert.Equal(t, "mytopic", dlq.Headers[retrypolicy.DeadLetterTopicKey])
+	assert.Equal(t, "2", dlq.Headers[retrypolicy.DeadLetterAttemptsKey])
+	assert.Equal(t, "handler failed", dlq.Headers[retrypolicy.DeadLetterErrorKey])
+}
+
+func TestDeliveryCount(t *testing.T) {
+	assert.Equal(t, 0, deliveryCount(amqp.Delivery{}))
+	assert.Equal(t, 3, deliveryCount(amqp.Delivery{Headers: amqp.Table{headerDeliveryCount: int64(2)}}))
 }
@@ -0,0 +1,206 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	contribContenttype "github.com/dapr/components-contrib/contenttype"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/ptr"
+)
+
+const (
+	// DefaultRawPayloadKey is the component metadata key that sets the default for the "rawPayload" request metadata.
+	// Requests can still override it by setting "rawPayload" explicitly.
+	DefaultRawPayloadKey = "defaultRawPayload"
+	// RawPayloadContentTypeKey is the component metadata key for the content type of raw payloads received by subscribers.
+	// The special value "auto" (RawPayloadContentTypeAuto) infers the content type from the payload.
+	RawPayloadContentTypeKey = "rawPayloadContentType"
+	// RawPayloadContentTypeAuto infers the content type of raw payloads from their content.
+	RawPayloadContentTypeAuto = "auto"
+)
+
+// RawPayloadSettings contains the component-level configuration for raw payloads.
+type RawPayloadSettings struct {
+	// DefaultRawPayload is used when a request does not set "rawPayload".
+	DefaultRawPayload bool `mapstructure:"defaultRawPayload"`
+	// ContentType is the content type of raw payloads received by subscribers.
+	// If empty, raw payloads are treated as binary data.
+	ContentType string `mapstructure:"rawPayloadContentType"`
+	// PubsubName is the name of the component, which is set in the CloudEvents created for raw payloads.
+	PubsubName string `mapstructure:"-"`
+}
+
+// ParseRawPayloadSettings returns the raw payload settings from the component metadata.
+func ParseRawPayloadSettings(metadata Metadata) (RawPayloadSettings, error) {
+	props := metadata.Properties
+	s := RawPayloadSettings{
+		PubsubName: metadata.Name,
+	}
+
+	if val, ok := contribMetadata.GetMetadataProperty(props, DefaultRawPayloadKey); ok && val != "" {
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return s, fmt.Errorf("%s value must be a valid boolean: actual is '%s'", DefaultRawPayloadKey, val)
+		}
+		s.DefaultRawPayload = b
+	}
+
+	if val, ok := contribMetadata.GetMetadataProperty(props, RawPayloadContentTypeKey); ok {
+		s.ContentType = val
+	}
+
+	return s, nil
+}
+
+// IsRawPayload determines if the payload of a request should be used as-is.
+// The "rawPayload" value in the request metadata takes precedence over the component's default.
+func (s RawPayloadSettings) IsRawPayload(reqMetadata map[string]string) (bool, error) {
+	if val, ok := reqMetadata[contribMetadata.RawPayloadKey]; ok && val != "" {
+		return contribMetadata.IsRawPayload(reqMetadata)
+	}
+
+	return s.DefaultRawPayload, nil
+}
+
+// RawPayloadContentType returns the content type of a raw payload received by a subscriber.
+func (s RawPayloadSettings) RawPayloadContentType(data []byte) string {
+	switch s.ContentType {
+	case "":
+		return "application/octet-stream"
+	case RawPayloadContentTypeAuto:
+		return InferContentType(data)
+	default:
+		return s.ContentType
+	}
+}
+
+// FromRawPayload returns a CloudEvent for a raw payload on subscriber's end, using the configured content type.
+// Unlike the package-level FromRawPayload, JSON and text payloads are set in the "data" field rather than being base64-encoded.
+func (s RawPayloadSettings) FromRawPayload(data []byte, topic, pubsub string) map[string]interface{} {
+	contentType := s.RawPayloadContentType(data)
+	ce := FromRawPayload(data, topic, pubsub)
+	ce[DataContentTypeField] = contentType
+
+	switch {
+	case contribContenttype.IsJSONContentType(contentType):
+		var v interface{}
+		if unmarshalPrecise(data, &v) == nil {
+			delete(ce, DataBase64Field)
+			ce[DataField] = v
+		}
+	case contribContenttype.IsStringContentType(contentType) && utf8.Valid(data):
+		delete(ce, DataBase64Field)
+		ce[DataField] = string(data)
+	}
+
+	return ce
+}
+
+// usesDefault returns true if the component's default makes the payloads of a request raw.
+// Requests that set "rawPayload" explicitly are handled by the runtime.
+func (s RawPayloadSettings) usesDefault(reqMetadata map[string]string) bool {
+	if val, ok := reqMetadata[contribMetadata.RawPayloadKey]; ok && val != "" {
+		return false
+	}
+	return s.DefaultRawPayload
+}
+
+// Payload returns the data to publish for a request.
+// If the request uses the component's default for raw payloads, the data of the CloudEvent created by the runtime is published instead of the envelope.
+func (s RawPayloadSettings) Payload(data []byte, contentType *string, reqMetadata map[string]string) ([]byte, error) {
+	if !s.usesDefault(reqMetadata) || contentType == nil || !contribContenttype.IsCloudEventContentType(*contentType) {
+		return data, nil
+	}
+
+	var ce map[string]json.RawMessage
+	err := json.Unmarshal(data, &ce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cloud event: %w", err)
+	}
+	if b64, ok := ce[DataBase64Field]; ok {
+		var encoded string
+		err = json.Unmarshal(b64, &encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in cloud event: %w", DataBase64Field, err)
+		}
+		return base64.StdEncoding.DecodeString(encoded)
+	}
+	ceData, ok := ce[DataField]
+	if !ok {
+		return []byte{}, nil
+	}
+	var str string
+	if json.Unmarshal(ceData, &str) == nil {
+		return []byte(str), nil
+	}
+	return ceData, nil
+}
+
+// Handler wraps handler so raw payloads received by a subscription that uses the component's default are delivered as CloudEvents.
+// Subscriptions that set "rawPayload" explicitly are handled by the runtime, and their messages are delivered unchanged.
+func (s RawPayloadSettings) Handler(reqMetadata map[string]string, handler Handler) Handler {
+	if !s.usesDefault(reqMetadata) {
+		return handler
+	}
+	return func(ctx context.Context, msg *NewMessage) error {
+		data, err := json.Marshal(s.FromRawPayload(msg.Data, msg.Topic, s.PubsubName))
+		if err != nil {
+			return err
+		}
+		msg.Data = data
+		msg.ContentType = ptr.Of(contribContenttype.CloudEventContentType)
+		return handler(ctx, msg)
+	}
+}
+
+// BulkHandler wraps handler so raw payloads received by a subscription that uses the component's default are delivered as CloudEvents.
+// Subscriptions that set "rawPayload" explicitly are handled by the runtime, and their messages are delivered unchanged.
+func (s RawPayloadSettings) BulkHandler(reqMetadata map[string]string, handler BulkHandler) BulkHandler {
+	if !s.usesDefault(reqMetadata) {
+		return handler
+	}
+	return func(ctx context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error) {
+		for i, entry := range msg.Entries {
+			data, err := json.Marshal(s.FromRawPayload(entry.Event, msg.Topic, s.PubsubName))
+			if err != nil {
+				return nil, err
+			}
+			msg.Entries[i].Event = data
+			msg.Entries[i].ContentType = contribContenttype.CloudEventContentType
+		}
+		return handler(ctx, msg)
+	}
+}
+
+// InferContentType returns the content type of a payload based on its content.
+// It detects JSON and UTF-8 text, falling back to "application/octet-stream".
+func InferContentType(data []byte) string {
+	switch {
+	case len(data) == 0:
+		return "application/octet-stream"
+	case json.Valid(data):
+		return "application/json"
+	case utf8.Valid(data):
+		return "text/plain"
+	default:
+		return "application/octet-stream"
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	contribContenttype "github.com/dapr/components-contrib/contenttype"
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/kit/ptr"
+)
+
+func TestParseRawPayloadSettings(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		s, err := ParseRawPayloadSettings(Metadata{})
+		require.NoError(t, err)
+		assert.False(t, s.DefaultRawPayload)
+		assert.Empty(t, s.ContentType)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		s, err := ParseRawPayloadSettings(Metadata{Base: contribMetadata.Base{
+			Name: "mypubsub",
+			Properties: map[string]string{
+				DefaultRawPayloadKey:     "true",
+				RawPayloadContentTypeKey: "auto",
+			},
+		}})
+		require.NoError(t, err)
+		assert.True(t, s.DefaultRawPayload)
+		assert.Equal(t, RawPayloadContentTypeAuto, s.ContentType)
+		assert.Equal(t, "mypubsub", s.PubsubName)
+	})
+
+	t.Run("invalid boolean", func(t *testing.T) {
+		_, err := ParseRawPayloadSettings(Metadata{Base: contribMetadata.Base{
+			Properties: map[string]string{DefaultRawPayloadKey: "maybe"},
+		}})
+		assert.Error(t, err)
+	})
+}
+
+func TestRawPayloadSettingsIsRawPayload(t *testing.T) {
+	s := RawPayloadSettings{DefaultRawPayload: true}
+
+	raw, err := s.IsRawPayload(nil)
+	require.NoError(t, err)
+	assert.True(t, raw)
+
+	raw, err = s.IsRawPayload(map[string]string{"rawPayload": "false"})
+	require.NoError(t, err)
+	assert.False(t, raw)
+
+	raw, err = RawPayloadSettings{}.IsRawPayload(map[string]string{"rawPayload": "true"})
+	require.NoError(t, err)
+	assert.True(t, raw)
+
+	_, err = s.IsRawPayload(map[string]string{"rawPayload": "maybe"})
+	assert.Error(t, err)
+}
+
+func TestInferContentType(t *testing.T) {
+	assert.Equal(t, "application/json", InferContentType([]byte(`{"a":1}`)))
+	assert.Equal(t, "text/plain", InferContentType([]byte("hello world")))
+	assert.Equal(t, "application/octet-stream", InferContentType([]byte{0xff, 0xfe}))
+	assert.Equal(t, "application/octet-stream", InferContentType(nil))
+}
+
+func TestRawPayloadSettingsFromRawPayload(t *testing.T) {
+	t.Run("binary by default", func(t *testing.T) {
+		ce := RawPayloadSettings{}.FromRawPayload([]byte(`{"a":1}`), "topic", "pubsub")
+		assert.Equal(t, "application/octet-stream", ce[DataContentTypeField])
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(`{"a":1}`)), ce[DataBase64Field])
+		assert.Nil(t, ce[DataField])
+	})
+
+	t.Run("auto json", func(t *testing.T) {
+		ce := RawPayloadSettings{ContentType: "auto"}.FromRawPayload([]byte(`{"a":1}`), "topic", "pubsub")
+		assert.Equal(t, "application/json", ce[DataContentTypeField])
+		assert.Nil(t, ce[DataBase64Field])
+		assert.Equal(t, map[string]interface{}{"a": json.Number("1")}, ce[DataField])
+	})
+
+	t.Run("explicit text", func(t *testing.T) {
+		ce := RawPayloadSettings{ContentType: "text/plain"}.FromRawPayload([]byte("hello"), "topic", "pubsub")
+		assert.Equal(t, "text/plain", ce[DataContentTypeField])
+		assert.Equal(t, "hello", ce[DataField])
+	})
+
+	t.Run("invalid json stays binary", func(t *testing.T) {
+		ce := RawPayloadSettings{ContentType: "application/json"}.FromRawPayload([]byte("nope"), "topic", "pubsub")
+		assert.NotNil(t, ce[DataBase64Field])
+		assert.Nil(t, ce[DataField])
+	})
+}
+
+func TestRawPayloadSettingsPayload(t *testing.T) {
+	s := RawPayloadSettings{DefaultRawPayload: true}
+	ceContentType := ptr.Of(contribContenttype.CloudEventContentType)
+
+	t.Run("json data", func(t *testing.T) {
+		data, err := s.Payload([]byte(`{"specversion":"1.0","data":{"a":1}}`), ceContentType, nil)
+		require.NoError(t, err)
+		assert.Equal(t, `{"a":1}`, string(data))
+	})
+
+	t.Run("text data", func(t *testing.T) {
+		data, err := s.Payload([]byte(`{"specversion":"1.0","data":"hello"}`), ceContentType, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+	})
+
+	t.Run("binary data", func(t *testing.T) {
+		data, err := s.Payload([]byte(`{"specversion":"1.0","data_base64":"`+base64.StdEncoding.EncodeToString([]byte{0xff})+`"}`), ceContentType, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0xff}, data)
+	})
+
+	t.Run("explicit rawPayload is unchanged", func(t *testing.T) {
+		ce := []byte(`{"specversion":"1.0","data":"hello"}`)
+		data, err := s.Payload(ce, ceContentType, map[string]string{"rawPayload": "false"})
+		require.NoError(t, err)
+		assert.Equal(t, ce, data)
+	})
+
+	t.Run("not a cloud event", func(t *testing.T) {
+		data, err := s.Payload([]byte("hello"), ptr.Of("text/plain"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+	})
+}
+
+func TestRawPayloadSettingsHandler(t *testing.T) {
+	s := RawPayloadSettings{DefaultRawPayload: true, ContentType: "auto", PubsubName: "mypubsub"}
+
+	var received *NewMessage
+	handler := func(ctx context.Context, msg *NewMessage) error {
+		received = msg
+		return nil
+	}
+
+	t.Run("default wraps payloads in a cloud event", func(t *testing.T) {
+		err := s.Handler(nil, handler)(context.Background(), &NewMessage{Topic: "orders", Data: []byte(`{"a":1}`)})
+		require.NoError(t, err)
+		assert.Equal(t, contribContenttype.CloudEventContentType, *received.ContentType)
+
+		var ce map[string]any
+		require.NoError(t, json.Unmarshal(received.Data, &ce))
+		assert.Equal(t, "orders", ce[TopicField])
+		assert.Equal(t, "mypubsub", ce[PubsubField])
+		assert.Equal(t, map[string]any{"a": float64(1)}, ce[DataField])
+	})
+
+	t.Run("explicit rawPayload is handled by the runtime", func(t *testing.T) {
+		err := s.Handler(map[string]string{"rawPayload": "true"}, handler)(context.Background(), &NewMessage{Topic: "orders", Data: []byte("hello")})
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(received.Data))
+	})
+
+	t.Run("bulk", func(t *testing.T) {
+		bulkHandler := s.BulkHandler(nil, func(ctx context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error) {
+			assert.Equal(t, contribContenttype.CloudEventContentType, msg.Entries[0].ContentType)
+			assert.Contains(t, string(msg.Entries[0].Event), `"data":"hello"`)
+			return nil, nil
+		})
+		_, err := bulkHandler(context.Background(), &BulkMessage{
+			Topic:   "orders",
+			Entries: []BulkMessageEntry{{EntryId: "1", Event: []byte("hello")}},
+		})
+		require.NoError(t, err)
+	})
+}
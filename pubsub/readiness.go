@@ -0,0 +1,197 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// SubscriptionState is the state of a subscription with the broker.
+type SubscriptionState string
+
+const (
+	// SubscriptionPending is the state of a subscription that is being established with the broker, or re-established after an error.
+	SubscriptionPending SubscriptionState = "pending"
+	// SubscriptionReady is the state of a subscription that is established with the broker and receives messages.
+	SubscriptionReady SubscriptionState = "ready"
+	// SubscriptionFailed is the state of a subscription the component gave up establishing: it doesn't receive messages.
+	SubscriptionFailed SubscriptionState = "failed"
+)
+
+// SubscriptionStatus is the status of a subscription of a component.
+type SubscriptionStatus struct {
+	Topic string
+	State SubscriptionState
+	// Last error establishing the subscription; it's nil when the subscription is ready.
+	Err error
+}
+
+// SubscriptionReadinessReporter is the interface implemented by message buses that establish subscriptions in the background, after Subscribe returns.
+type SubscriptionReadinessReporter interface {
+	// WaitSubscriptionsReady blocks until none of the subscriptions of the component is pending, or until ctx is done.
+	// It returns an error if a subscription failed, or if ctx is done while a subscription is still pending.
+	WaitSubscriptionsReady(ctx context.Context) error
+	// SubscriptionsStatus returns the status of the subscriptions of the component, sorted by topic.
+	SubscriptionsStatus() []SubscriptionStatus
+}
+
+// WaitSubscriptionsReady waits for the subscriptions of a message bus to be established with the broker.
+// Message buses that don't implement SubscriptionReadinessReporter establish their subscriptions in Subscribe, so they're ready as soon as it returns.
+func WaitSubscriptionsReady(ctx context.Context, pubsub PubSub) error {
+	if reporter, ok := As[SubscriptionReadinessReporter](pubsub); ok {
+		return reporter.WaitSubscriptionsReady(ctx)
+	}
+	return nil
+}
+
+// SubscriptionTracker tracks the state of the subscriptions of a component, implementing SubscriptionReadinessReporter.
+// The zero value is ready to use.
+type SubscriptionTracker struct {
+	lock sync.Mutex
+	subs map[*TrackedSubscription]struct{}
+	// Closed and replaced when the state of a subscription changes.
+	changed chan struct{}
+}
+
+// TrackedSubscription is a subscription whose state is tracked by a SubscriptionTracker.
+type TrackedSubscription struct {
+	tracker *SubscriptionTracker
+	topic   string
+	state   SubscriptionState
+	err     error
+}
+
+// Track starts tracking a subscription to a topic, which is pending until it's reported as ready.
+// Close must be called on the returned subscription when it ends.
+func (t *SubscriptionTracker) Track(topic string) *TrackedSubscription {
+	s := &TrackedSubscription{
+		tracker: t,
+		topic:   topic,
+		state:   SubscriptionPending,
+	}
+
+	t.lock.Lock()
+	if t.subs == nil {
+		t.subs = make(map[*TrackedSubscription]struct{})
+	}
+	t.subs[s] = struct{}{}
+	t.notifyLocked()
+	t.lock.Unlock()
+
+	return s
+}
+
+// WaitSubscriptionsReady implements SubscriptionReadinessReporter.
+func (t *SubscriptionTracker) WaitSubscriptionsReady(ctx context.Context) error {
+	for {
+		t.lock.Lock()
+		var pending, failed []error
+		for s := range t.subs {
+			switch s.state {
+			case SubscriptionPending:
+				err := fmt.Errorf("subscription to topic %s is pending", s.topic)
+				if s.err != nil {
+					err = fmt.Errorf("%w: %w", err, s.err)
+				}
+				pending = append(pending, err)
+			case SubscriptionFailed:
+				failed = append(failed, fmt.Errorf("subscription to topic %s failed: %w", s.topic, s.err))
+			}
+		}
+		if t.changed == nil {
+			t.changed = make(chan struct{})
+		}
+		changed := t.changed
+		t.lock.Unlock()
+
+		if len(pending) == 0 {
+			return errors.Join(failed...)
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return errors.Join(append(append([]error{ctx.Err()}, pending...), failed...)...)
+		}
+	}
+}
+
+// SubscriptionsStatus implements SubscriptionReadinessReporter.
+func (t *SubscriptionTracker) SubscriptionsStatus() []SubscriptionStatus {
+	t.lock.Lock()
+	res := make([]SubscriptionStatus, 0, len(t.subs))
+	for s := range t.subs {
+		res = append(res, SubscriptionStatus{
+			Topic: s.topic,
+			State: s.state,
+			Err:   s.err,
+		})
+	}
+	t.lock.Unlock()
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Topic < res[j].Topic
+	})
+	return res
+}
+
+// notifyLocked wakes up the goroutines waiting for a change; it must be called while holding the lock.
+func (t *SubscriptionTracker) notifyLocked() {
+	if t.changed != nil {
+		close(t.changed)
+		t.changed = nil
+	}
+}
+
+func (s *TrackedSubscription) setState(state SubscriptionState, err error) {
+	s.tracker.lock.Lock()
+	defer s.tracker.lock.Unlock()
+
+	if s.state == state && s.err == err {
+		return
+	}
+	s.state = state
+	s.err = err
+	s.tracker.notifyLocked()
+}
+
+// Ready reports that the subscription is established with the broker.
+func (s *TrackedSubscription) Ready() {
+	s.setState(SubscriptionReady, nil)
+}
+
+// Pending reports that the subscription isn't established because of err, and that the component is retrying.
+func (s *TrackedSubscription) Pending(err error) {
+	s.setState(SubscriptionPending, err)
+}
+
+// Failed reports that the component gave up establishing the subscription because of err.
+func (s *TrackedSubscription) Failed(err error) {
+	s.setState(SubscriptionFailed, err)
+}
+
+// Close stops tracking the subscription.
+func (s *TrackedSubscription) Close() {
+	s.tracker.lock.Lock()
+	defer s.tracker.lock.Unlock()
+
+	if _, ok := s.tracker.subs[s]; ok {
+		delete(s.tracker.subs, s)
+		s.tracker.notifyLocked()
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionTracker(t *testing.T) {
+	t.Run("no subscriptions", func(t *testing.T) {
+		var tracker SubscriptionTracker
+		require.NoError(t, tracker.WaitSubscriptionsReady(context.Background()))
+		assert.Empty(t, tracker.SubscriptionsStatus())
+	})
+
+	t.Run("waits for pending subscriptions", func(t *testing.T) {
+		var tracker SubscriptionTracker
+		a := tracker.Track("a")
+		b := tracker.Track("b")
+		a.Ready()
+
+		errCh := make(chan error)
+		go func() {
+			errCh <- tracker.WaitSubscriptionsReady(context.Background())
+		}()
+
+		select {
+		case <-errCh:
+			t.Fatal("returned while a subscription is pending")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		b.Pending(errors.New("connection refused"))
+		assert.Equal(t, []SubscriptionStatus{
+			{Topic: "a", State: SubscriptionReady},
+			{Topic: "b", State: SubscriptionPending, Err: errors.New("connection refused")},
+		}, tracker.SubscriptionsStatus())
+
+		b.Ready()
+		select {
+		case err := <-errCh:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("subscriptions not ready")
+		}
+	})
+
+	t.Run("context done while pending", func(t *testing.T) {
+		var tracker SubscriptionTracker
+		tracker.Track("a").Pending(errors.New("connection refused"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := tracker.WaitSubscriptionsReady(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorContains(t, err, "connection refused")
+	})
+
+	t.Run("failed subscription", func(t *testing.T) {
+		var tracker SubscriptionTracker
+		errFailed := errors.New("permission denied")
+		tracker.Track("a").Failed(errFailed)
+		err := tracker.WaitSubscriptionsReady(context.Background())
+		require.ErrorIs(t, err, errFailed)
+	})
+
+	t.Run("closed subscriptions aren't tracked", func(t *testing.T) {
+		var tracker SubscriptionTracker
+		s := tracker.Track("a")
+		s.Close()
+		s.Pending(nil)
+		require.NoError(t, tracker.WaitSubscriptionsReady(context.Background()))
+		assert.Empty(t, tracker.SubscriptionsStatus())
+	})
+}
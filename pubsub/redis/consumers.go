@@ -0,0 +1,261 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+// consumerGroupInfo contains the information on a consumer group returned by XINFO GROUPS.
+type consumerGroupInfo struct {
+	name    string
+	pending int64
+	// Number of entries that weren't delivered yet to the group, or -1 if unknown (Redis < 7).
+	lag int64
+}
+
+// consumerInfo contains the information on a consumer of a group returned by XINFO CONSUMERS.
+type consumerInfo struct {
+	name    string
+	pending int64
+	idle    time.Duration
+}
+
+// reportLagLoop periodically reports the lag of the consumer group of the stream to the metrics.
+func (r *redisStreams) reportLagLoop(ctx context.Context, stream string) {
+	if r.clientSettings.LagReportInterval <= 0 {
+		return
+	}
+
+	lagTicker := time.NewTicker(r.clientSettings.LagReportInterval)
+	defer lagTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-lagTicker.C:
+			err := r.reportLag(ctx, stream)
+			if err != nil && ctx.Err() == nil {
+				r.logger.Errorf("error reporting the lag of Redis stream %s: %v", stream, err)
+			}
+		}
+	}
+}
+
+func (r *redisStreams) reportLag(ctx context.Context, stream string) error {
+	groups, err := r.getConsumerGroups(ctx, stream)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		if g.name == r.clientSettings.ConsumerID {
+			r.metrics.ConsumerGroupLag(stream, g.name, g.lag, g.pending)
+			return nil
+		}
+	}
+	return fmt.Errorf("consumer group %s not found", r.clientSettings.ConsumerID)
+}
+
+// cleanupIdleConsumersLoop periodically deletes the other consumers of the group that have been idle for at least `consumerIdleTimeout`.
+func (r *redisStreams) cleanupIdleConsumersLoop(ctx context.Context, stream string, handler pubsub.Handler) {
+	if r.clientSettings.ConsumerIdleTimeout <= 0 {
+		return
+	}
+
+	// Check twice per timeout, so consumers are deleted at most 1.5 times the timeout after they became idle
+	cleanupTicker := time.NewTicker(r.clientSettings.ConsumerIdleTimeout / 2)
+	defer cleanupTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-cleanupTicker.C:
+			r.cleanupIdleConsumers(ctx, stream, handler)
+		}
+	}
+}
+
+// cleanupIdleConsumers claims the pending messages of the idle consumers of the group, funneling them to the message channel,
+// and then deletes the consumers with `XGROUP DELCONSUMER`.
+func (r *redisStreams) cleanupIdleConsumers(ctx context.Context, stream string, handler pubsub.Handler) {
+	group := r.clientSettings.ConsumerID
+	consumers, err := r.getConsumers(ctx, stream, group)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.logger.Errorf("error retrieving the consumers of Redis stream %s: %v", stream, err)
+		}
+		return
+	}
+
+	for _, c := range consumers {
+		if c.name == r.clientSettings.ConsumerID || c.idle < r.clientSettings.ConsumerIdleTimeout {
+			continue
+		}
+
+		// Deleting a consumer drops its pending messages, so they must be claimed first
+		if c.pending > 0 {
+			err = r.claimConsumerPendingMessages(ctx, stream, c.name, handler)
+			if err != nil {
+				if ctx.Err() == nil {
+					r.logger.Errorf("error claiming the pending messages of idle consumer %s of Redis stream %s: %v", c.name, stream, err)
+				}
+				continue
+			}
+		}
+
+		err = r.client.DoWrite(ctx, "XGROUP", "DELCONSUMER", stream, group, c.name)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.logger.Errorf("error deleting idle consumer %s of Redis stream %s: %v", c.name, stream, err)
+			}
+			continue
+		}
+		r.logger.Infof("Deleted consumer %s of Redis stream %s after being idle for %v", c.name, stream, c.idle)
+	}
+}
+
+// claimConsumerPendingMessages claims all the pending messages of a consumer of the group.
+func (r *redisStreams) claimConsumerPendingMessages(ctx context.Context, stream string, consumer string, handler pubsub.Handler) error {
+	group := r.clientSettings.ConsumerID
+	for {
+		res, err := r.client.DoRead(ctx, "XPENDING", stream, group, "-", "+", int64(r.clientSettings.QueueDepth), consumer)
+		if err != nil {
+			return err
+		}
+		entries, ok := res.([]interface{})
+		if !ok {
+			return fmt.Errorf("unexpected XPENDING reply of type %T", res)
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		msgIDs := make([]string, 0, len(entries))
+		deliveryCounts := make(map[string]int, len(entries))
+		for _, e := range entries {
+			fields, ok := e.([]interface{})
+			if !ok || len(fields) < 4 {
+				return errors.New("unexpected XPENDING reply")
+			}
+			id, _ := fields[0].(string)
+			count, _ := fields[3].(int64)
+			msgIDs = append(msgIDs, id)
+			// Claiming the message counts as a new delivery
+			deliveryCounts[id] = int(count) + 1
+		}
+
+		claimed, err := r.client.XClaimResult(ctx, stream, group, r.clientSettings.ConsumerID, 0, msgIDs)
+		if err != nil && !errors.Is(err, r.client.GetNilValueError()) {
+			return err
+		}
+		for range claimed {
+			r.metrics.Retried(stream)
+		}
+		r.enqueueMessages(ctx, stream, handler, claimed, deliveryCounts)
+
+		// Messages that no longer exist are not claimed, and are dropped when the consumer is deleted
+		if len(claimed) == 0 || len(entries) < int(r.clientSettings.QueueDepth) {
+			return nil
+		}
+	}
+}
+
+// getConsumerGroups returns the consumer groups of the stream, with `XINFO GROUPS`.
+func (r *redisStreams) getConsumerGroups(ctx context.Context, stream string) ([]consumerGroupInfo, error) {
+	res, err := r.client.DoRead(ctx, "XINFO", "GROUPS", stream)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := res.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected XINFO GROUPS reply of type %T", res)
+	}
+
+	groups := make([]consumerGroupInfo, 0, len(items))
+	for _, item := range items {
+		fields, err := replyFields(item)
+		if err != nil {
+			return nil, err
+		}
+		g := consumerGroupInfo{lag: -1}
+		g.name, _ = fields["name"].(string)
+		g.pending, _ = fields["pending"].(int64)
+		if lag, ok := fields["lag"].(int64); ok {
+			g.lag = lag
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+// getConsumers returns the consumers of a group, with `XINFO CONSUMERS`.
+func (r *redisStreams) getConsumers(ctx context.Context, stream string, group string) ([]consumerInfo, error) {
+	res, err := r.client.DoRead(ctx, "XINFO", "CONSUMERS", stream, group)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := res.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected XINFO CONSUMERS reply of type %T", res)
+	}
+
+	consumers := make([]consumerInfo, 0, len(items))
+	for _, item := range items {
+		fields, err := replyFields(item)
+		if err != nil {
+			return nil, err
+		}
+		c := consumerInfo{}
+		c.name, _ = fields["name"].(string)
+		c.pending, _ = fields["pending"].(int64)
+		idle, _ := fields["idle"].(int64)
+		c.idle = time.Duration(idle) * time.Millisecond
+		consumers = append(consumers, c)
+	}
+	return consumers, nil
+}
+
+// replyFields returns the fields of a reply that is a map (RESP3) or a flat list of keys and values (RESP2).
+func replyFields(reply interface{}) (map[string]interface{}, error) {
+	switch v := reply.(type) {
+	case map[interface{}]interface{}:
+		fields := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			k, _ := key.(string)
+			fields[k] = val
+		}
+		return fields, nil
+	case []interface{}:
+		if len(v)%2 != 0 {
+			return nil, errors.New("reply has an odd number of elements")
+		}
+		fields := make(map[string]interface{}, len(v)/2)
+		for i := 0; i < len(v); i += 2 {
+			k, _ := v[i].(string)
+			fields[k] = v[i+1]
+		}
+		return fields, nil
+	default:
+		return nil, fmt.Errorf("unexpected reply of type %T", reply)
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalredis "github.com/dapr/components-contrib/internal/component/redis"
+	mdata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+// idleConsumersClient adds the idle time of consumers to the XINFO CONSUMERS replies, which miniredis doesn't return.
+type idleConsumersClient struct {
+	internalredis.RedisClient
+	idle map[string]int64
+}
+
+func (c *idleConsumersClient) DoRead(ctx context.Context, args ...interface{}) (interface{}, error) {
+	res, err := c.RedisClient.DoRead(ctx, args...)
+	if err != nil || len(args) < 2 || args[0] != "XINFO" || args[1] != "CONSUMERS" {
+		return res, err
+	}
+	items := res.([]interface{})
+	for i, item := range items {
+		fields, err := replyFields(item)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = []interface{}{"name", fields["name"], "pending", fields["pending"], "idle", c.idle[fields["name"].(string)]}
+	}
+	return items, nil
+}
+
+type lagHook struct {
+	lock sync.Mutex
+	lags map[string][2]int64
+}
+
+func (h *lagHook) MessagePublished(topic string, err error, latency time.Duration) {}
+func (h *lagHook) MessageDelivered(topic string, err error, latency time.Duration) {}
+func (h *lagHook) MessageRetried(topic string)                                     {}
+func (h *lagHook) MessageDeadLettered(topic string)                                {}
+
+func (h *lagHook) ConsumerGroupLag(topic string, group string, lag int64, pending int64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.lags[topic+"/"+group] = [2]int64{lag, pending}
+}
+
+func newConsumersTestStreams(t *testing.T, s *miniredis.Miniredis, properties map[string]string) *redisStreams {
+	t.Helper()
+
+	properties["redisHost"] = s.Addr()
+	properties[consumerID] = "fakeConsumer"
+	client, settings, err := internalredis.ParseClientFromProperties(properties, mdata.PubSubType)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	return &redisStreams{
+		client:         client,
+		clientSettings: settings,
+		logger:         logger.NewLogger("test"),
+		queue:          make(chan redisMessageWrapper, 10),
+	}
+}
+
+func TestReportLag(t *testing.T) {
+	s := miniredis.RunT(t)
+	r := newConsumersTestStreams(t, s, map[string]string{
+		"lagReportInterval": "10s",
+	})
+	assert.Equal(t, 10*time.Second, r.clientSettings.LagReportInterval)
+
+	ctx := context.Background()
+	require.NoError(t, r.client.XGroupCreateMkStream(ctx, "mystream", "fakeConsumer", "0"))
+	require.NoError(t, r.client.XGroupCreateMkStream(ctx, "mystream", "otherGroup", "0"))
+	for i := 0; i < 3; i++ {
+		_, err := r.client.XAdd(ctx, "mystream", 0, map[string]interface{}{"data": "testData"})
+		require.NoError(t, err)
+	}
+	_, err := r.client.XReadGroupResult(ctx, "fakeConsumer", "fakeConsumer", []string{"mystream", ">"}, 2, -1)
+	require.NoError(t, err)
+
+	hook := &lagHook{lags: map[string][2]int64{}}
+	r.metrics.SetMetricsHook(hook)
+	require.NoError(t, r.reportLag(ctx, "mystream"))
+
+	require.Len(t, hook.lags, 1)
+	lag := hook.lags["mystream/fakeConsumer"]
+	assert.Equal(t, int64(2), lag[1])
+	// miniredis returns the length of the stream as the lag
+	assert.Equal(t, int64(3), lag[0])
+
+	t.Run("group not found", func(t *testing.T) {
+		_, err := r.client.XAdd(ctx, "otherstream", 0, map[string]interface{}{"data": "testData"})
+		require.NoError(t, err)
+		require.NoError(t, r.client.XGroupCreateMkStream(ctx, "otherstream", "otherGroup", "0"))
+		assert.Error(t, r.reportLag(ctx, "otherstream"))
+	})
+}
+
+func TestCleanupIdleConsumers(t *testing.T) {
+	s := miniredis.RunT(t)
+	r := newConsumersTestStreams(t, s, map[string]string{
+		"consumerIdleTimeout": "1m",
+		"queueDepth":          "2",
+	})
+	assert.Equal(t, time.Minute, r.clientSettings.ConsumerIdleTimeout)
+
+	ctx := context.Background()
+	require.NoError(t, r.client.XGroupCreateMkStream(ctx, "mystream", "fakeConsumer", "0"))
+	for i := 0; i < 5; i++ {
+		_, err := r.client.XAdd(ctx, "mystream", 0, map[string]interface{}{"data": "testData"})
+		require.NoError(t, err)
+	}
+
+	// Messages are delivered to other consumers, one of which was scaled down and is idle
+	_, err := r.client.XReadGroupResult(ctx, "fakeConsumer", "scaledDownConsumer", []string{"mystream", ">"}, 3, -1)
+	require.NoError(t, err)
+	_, err = r.client.XReadGroupResult(ctx, "fakeConsumer", "activeConsumer", []string{"mystream", ">"}, 2, -1)
+	require.NoError(t, err)
+
+	r.client = &idleConsumersClient{
+		RedisClient: r.client,
+		idle: map[string]int64{
+			"scaledDownConsumer": (2 * time.Minute).Milliseconds(),
+			"activeConsumer":     (10 * time.Second).Milliseconds(),
+		},
+	}
+	r.cleanupIdleConsumers(ctx, "mystream", func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return nil
+	})
+
+	// The pending messages of the idle consumer are claimed and delivered again
+	require.Len(t, r.queue, 3)
+	for i := 0; i < 3; i++ {
+		msg := <-r.queue
+		assert.Equal(t, "testData", string(msg.message.Data))
+		assert.Equal(t, 2, msg.deliveryCount)
+	}
+
+	consumers, err := r.getConsumers(ctx, "mystream", "fakeConsumer")
+	require.NoError(t, err)
+	pending := map[string]int64{}
+	for _, c := range consumers {
+		pending[c.name] = c.pending
+	}
+	assert.Equal(t, map[string]int64{
+		"fakeConsumer":   3,
+		"activeConsumer": 2,
+	}, pending)
+}
+
+func TestReplyFields(t *testing.T) {
+	t.Run("RESP2", func(t *testing.T) {
+		fields, err := replyFields([]interface{}{"name", "group", "lag", int64(3)})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"name": "group", "lag": int64(3)}, fields)
+	})
+
+	t.Run("RESP3", func(t *testing.T) {
+		fields, err := replyFields(map[interface{}]interface{}{"name": "group", "lag": nil})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"name": "group", "lag": nil}, fields)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := replyFields([]interface{}{"name"})
+		assert.Error(t, err)
+		_, err = replyFields("name")
+		assert.Error(t, err)
+	})
+}
@@ -25,6 +25,7 @@ import (
 	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
 	contribMetadata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/components-contrib/pubsub/retrypolicy"
 	"github.com/dapr/kit/logger"
 )
 
@@ -51,6 +52,10 @@ type redisStreams struct {
 	wg             sync.WaitGroup
 	closed         atomic.Bool
 	closeCh        chan struct{}
+	metrics        pubsub.Metrics
+	retryPolicy    *retrypolicy.Policy
+	topics         *pubsub.TopicMapper
+	subscriptions  pubsub.SubscriptionTracker
 
 	queue chan redisMessageWrapper
 }
@@ -62,14 +67,16 @@ type redisMessageWrapper struct {
 	messageID string
 	message   pubsub.NewMessage
 	handler   pubsub.Handler
+	// Number of times the message has been delivered, or 0 if unknown.
+	deliveryCount int
 }
 
 // NewRedisStreams returns a new redis streams pub-sub implementation.
 func NewRedisStreams(logger logger.Logger) pubsub.PubSub {
-	return &redisStreams{
+	return pubsub.WithSchemaValidation(&redisStreams{
 		logger:  logger,
 		closeCh: make(chan struct{}),
-	}
+	})
 }
 
 func (r *redisStreams) Init(ctx context.Context, metadata pubsub.Metadata) error {
@@ -82,6 +89,16 @@ func (r *redisStreams) Init(ctx context.Context, metadata pubsub.Metadata) error
 	if _, err = r.client.PingResult(ctx); err != nil {
 		return fmt.Errorf("redis streams: error connecting to redis at %s: %s", r.clientSettings.Host, err)
 	}
+
+	retrySettings, err := retrypolicy.ParseSettings(metadata.Properties)
+	if err != nil {
+		return fmt.Errorf("redis streams: %w", err)
+	}
+	r.retryPolicy = retrypolicy.New(retrySettings)
+	r.topics, err = pubsub.ParseTopicMapping(metadata.Properties)
+	if err != nil {
+		return fmt.Errorf("redis streams: %w", err)
+	}
 	r.queue = make(chan redisMessageWrapper, int(r.clientSettings.QueueDepth))
 
 	for i := uint(0); i < r.clientSettings.Concurrency; i++ {
@@ -100,7 +117,23 @@ func (r *redisStreams) Publish(ctx context.Context, req *pubsub.PublishRequest)
 		return errors.New("component is closed")
 	}
 
-	_, err := r.client.XAdd(ctx, req.Topic, r.clientSettings.MaxLenApprox, map[string]interface{}{"data": req.Data})
+	values := map[string]interface{}{"data": req.Data}
+	for k, v := range pubsub.TraceContextFromMetadata(req.Metadata) {
+		values[k] = v
+	}
+	// Redis Streams don't support a TTL per message: it's enforced when the messages are delivered
+	ttlMetadata, err := pubsub.MessageTTLMetadata(req.Metadata, time.Now())
+	if err != nil {
+		return fmt.Errorf("redis streams: %w", err)
+	}
+	for k, v := range ttlMetadata {
+		values[k] = v
+	}
+
+	stream := r.topics.Physical(req.Topic)
+	start := time.Now()
+	_, err = r.client.XAdd(ctx, stream, r.clientSettings.MaxLenApprox, values)
+	r.metrics.Published(stream, err, start)
 	if err != nil {
 		return fmt.Errorf("redis streams: error from publish: %s", err)
 	}
@@ -113,15 +146,21 @@ func (r *redisStreams) Subscribe(ctx context.Context, req pubsub.SubscribeReques
 		return errors.New("component is closed")
 	}
 
-	err := r.client.XGroupCreateMkStream(ctx, req.Topic, r.clientSettings.ConsumerID, "0")
+	stream := r.topics.Physical(req.Topic)
+	err := r.client.XGroupCreateMkStream(ctx, stream, r.clientSettings.ConsumerID, "0")
 	// Ignore BUSYGROUP errors
 	if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
 		r.logger.Errorf("redis streams: %s", err)
 		return err
 	}
 
+	// The consumer group exists, so the subscription is ready until reading from the stream fails
+	sub := r.subscriptions.Track(req.Topic)
+	sub.Ready()
+
+	handler = r.metrics.InstrumentHandler(r.topics.Handler(req.Topic, handler))
 	loopCtx, cancel := context.WithCancel(ctx)
-	r.wg.Add(3)
+	r.wg.Add(6)
 	go func() {
 		// Add a context which catches the close signal to account for situations
 		// where Close is called, but the context is not cancelled.
@@ -134,11 +173,28 @@ func (r *redisStreams) Subscribe(ctx context.Context, req pubsub.SubscribeReques
 	}()
 	go func() {
 		defer r.wg.Done()
-		r.pollNewMessagesLoop(loopCtx, req.Topic, handler)
+		defer sub.Close()
+		r.pollNewMessagesLoop(loopCtx, stream, handler, sub)
+	}()
+	go func() {
+		defer r.wg.Done()
+		if r.clientSettings.PendingIdleTimeout > 0 {
+			r.autoClaimPendingMessagesLoop(loopCtx, stream, handler)
+		} else {
+			r.reclaimPendingMessagesLoop(loopCtx, stream, handler)
+		}
+	}()
+	go func() {
+		defer r.wg.Done()
+		r.trimStreamLoop(loopCtx, stream)
 	}()
 	go func() {
 		defer r.wg.Done()
-		r.reclaimPendingMessagesLoop(loopCtx, req.Topic, handler)
+		r.reportLagLoop(loopCtx, stream)
+	}()
+	go func() {
+		defer r.wg.Done()
+		r.cleanupIdleConsumersLoop(loopCtx, stream, handler)
 	}()
 
 	return nil
@@ -147,9 +203,11 @@ func (r *redisStreams) Subscribe(ctx context.Context, req pubsub.SubscribeReques
 // enqueueMessages is a shared function that funnels new messages (via polling)
 // and redelivered messages (via reclaiming) to a channel where workers can
 // pick them up for processing.
-func (r *redisStreams) enqueueMessages(ctx context.Context, stream string, handler pubsub.Handler, msgs []rediscomponent.RedisXMessage) {
+// deliveryCounts contains the number of times the messages have been delivered, when known.
+func (r *redisStreams) enqueueMessages(ctx context.Context, stream string, handler pubsub.Handler, msgs []rediscomponent.RedisXMessage, deliveryCounts map[string]int) {
 	for _, msg := range msgs {
 		rmsg := createRedisMessageWrapper(ctx, stream, handler, msg)
+		rmsg.deliveryCount = deliveryCounts[msg.ID]
 
 		select {
 		// Might block if the queue is full so we need the ctx.Done below.
@@ -175,11 +233,25 @@ func createRedisMessageWrapper(ctx context.Context, stream string, handler pubsu
 		}
 	}
 
+	metadata := pubsub.AddTraceContextToMetadata(nil, func(key string) string {
+		v, _ := msg.Values[key].(string)
+		return v
+	})
+	for _, key := range []string{contribMetadata.TTLMetadataKey, pubsub.EnqueueTimeKey} {
+		if v, ok := msg.Values[key].(string); ok {
+			if metadata == nil {
+				metadata = make(map[string]string, 2)
+			}
+			metadata[key] = v
+		}
+	}
+
 	return redisMessageWrapper{
 		ctx: ctx,
 		message: pubsub.NewMessage{
-			Topic: stream,
-			Data:  data,
+			Topic:    stream,
+			Data:     data,
+			Metadata: metadata,
 		},
 		messageID: msg.ID,
 		handler:   handler,
@@ -213,11 +285,28 @@ func (r *redisStreams) processMessage(msg redisMessageWrapper) error {
 		ctx, cancel = context.WithTimeout(ctx, r.clientSettings.ProcessingTimeout)
 		defer cancel()
 	}
+	if pubsub.IsMessageExpired(msg.message.Metadata, time.Now()) {
+		r.logger.Debugf("Dropping expired Redis message %s", msg.messageID)
+		if err := r.client.XAck(context.Background(), msg.message.Topic, r.clientSettings.ConsumerID, msg.messageID); err != nil {
+			r.logger.Errorf("Error acknowledging Redis message %s: %v", msg.messageID, err)
+
+			return err
+		}
+		return nil
+	}
 	if err := msg.handler(ctx, &msg.message); err != nil {
 		r.logger.Errorf("Error processing Redis message %s: %v", msg.messageID, err)
 
+		if r.retryPolicy.Enabled() {
+			decision := r.retryPolicy.RecordFailure(msg.messageID, msg.deliveryCount)
+			if decision.DeadLetter {
+				return r.deadLetter(msg, decision.Attempt, err)
+			}
+		}
+
 		return err
 	}
+	r.retryPolicy.RecordSuccess(msg.messageID)
 
 	// Use the background context in case subscriptionCtx is already closed.
 	if err := r.client.XAck(context.Background(), msg.message.Topic, r.clientSettings.ConsumerID, msg.messageID); err != nil {
@@ -231,7 +320,8 @@ func (r *redisStreams) processMessage(msg redisMessageWrapper) error {
 
 // pollMessagesLoop calls `XReadGroup` for new messages and funnels them to the message channel
 // by calling `enqueueMessages`.
-func (r *redisStreams) pollNewMessagesLoop(ctx context.Context, stream string, handler pubsub.Handler) {
+// pollNewMessagesLoop reads the new messages of the stream, reporting the subscription as pending while reading fails.
+func (r *redisStreams) pollNewMessagesLoop(ctx context.Context, stream string, handler pubsub.Handler, sub *pubsub.TrackedSubscription) {
 	for {
 		// Return on cancelation
 		if ctx.Err() != nil {
@@ -243,13 +333,17 @@ func (r *redisStreams) pollNewMessagesLoop(ctx context.Context, stream string, h
 		if err != nil {
 			if !errors.Is(err, r.client.GetNilValueError()) && err != context.Canceled {
 				r.logger.Errorf("redis streams: error reading from stream %s: %s", stream, err)
+				sub.Pending(err)
+			} else {
+				sub.Ready()
 			}
 			continue
 		}
+		sub.Ready()
 
 		// Enqueue messages for the returned streams
 		for _, s := range streams {
-			r.enqueueMessages(ctx, s.Stream, handler, s.Messages)
+			r.enqueueMessages(ctx, s.Stream, handler, s.Messages, nil)
 		}
 	}
 }
@@ -297,11 +391,14 @@ func (r *redisStreams) reclaimPendingMessages(ctx context.Context, stream string
 			break
 		}
 
-		// Filter out messages that have not timed out yet
+		// Filter out messages that have not timed out yet, or that are waiting for the backoff of the retry policy
 		msgIDs := make([]string, 0, len(pendingResult))
+		deliveryCounts := make(map[string]int, len(pendingResult))
 		for _, msg := range pendingResult {
-			if msg.Idle >= r.clientSettings.ProcessingTimeout {
+			if msg.Idle >= r.clientSettings.ProcessingTimeout && r.retryPolicy.Ready(msg.ID) {
 				msgIDs = append(msgIDs, msg.ID)
+				// Claiming the message counts as a new delivery
+				deliveryCounts[msg.ID] = int(msg.RetryCount) + 1
 			}
 		}
 
@@ -325,7 +422,10 @@ func (r *redisStreams) reclaimPendingMessages(ctx context.Context, stream string
 		}
 
 		// Enqueue claimed messages
-		r.enqueueMessages(ctx, stream, handler, claimResult)
+		for range claimResult {
+			r.metrics.Retried(stream)
+		}
+		r.enqueueMessages(ctx, stream, handler, claimResult, deliveryCounts)
 
 		// If the Redis nil error is returned, it means somes message in the pending
 		// state no longer exist. We need to acknowledge these messages to
@@ -346,6 +446,97 @@ func (r *redisStreams) reclaimPendingMessages(ctx context.Context, stream string
 	}
 }
 
+// autoClaimPendingMessagesLoop periodically claims messages that have been pending for at least `pendingIdleTimeout`,
+// including messages delivered to other consumers of the group that may have crashed.
+func (r *redisStreams) autoClaimPendingMessagesLoop(ctx context.Context, stream string, handler pubsub.Handler) {
+	interval := r.clientSettings.RedeliverInterval
+	if interval <= 0 {
+		interval = r.clientSettings.PendingIdleTimeout
+	}
+
+	// Do an initial claim call
+	r.autoClaimPendingMessages(ctx, stream, handler)
+
+	claimTicker := time.NewTicker(interval)
+	defer claimTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-claimTicker.C:
+			r.autoClaimPendingMessages(ctx, stream, handler)
+		}
+	}
+}
+
+// autoClaimPendingMessages claims all messages in the pending entries list that have been idle for at least `pendingIdleTimeout`
+// with `XAUTOCLAIM`, in batches of `claimBatchSize`, and funnels them to the message channel by calling `enqueueMessages`.
+func (r *redisStreams) autoClaimPendingMessages(ctx context.Context, stream string, handler pubsub.Handler) {
+	count := r.clientSettings.ClaimBatchSize
+	if count <= 0 {
+		count = int64(r.clientSettings.QueueDepth)
+	}
+
+	start := "0-0"
+	for {
+		claimed, next, err := r.client.XAutoClaimResult(ctx,
+			stream,
+			r.clientSettings.ConsumerID,
+			r.clientSettings.ConsumerID,
+			r.clientSettings.PendingIdleTimeout,
+			start,
+			count,
+		)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.logger.Errorf("error auto-claiming pending Redis messages: %v", err)
+			}
+			return
+		}
+
+		// Messages that are waiting for the backoff of the retry policy stay pending, and are claimed again later
+		ready := make([]rediscomponent.RedisXMessage, 0, len(claimed))
+		for _, msg := range claimed {
+			if r.retryPolicy.Ready(msg.ID) {
+				ready = append(ready, msg)
+				r.metrics.Retried(stream)
+			}
+		}
+		r.enqueueMessages(ctx, stream, handler, ready, nil)
+
+		// A cursor of "0-0" means the entire pending entries list has been scanned
+		if next == "" || next == "0-0" {
+			return
+		}
+		start = next
+	}
+}
+
+// trimStreamLoop periodically trims the stream to approximately `maxLen` messages.
+func (r *redisStreams) trimStreamLoop(ctx context.Context, stream string) {
+	if r.clientSettings.MaxLen <= 0 || r.clientSettings.TrimInterval <= 0 {
+		return
+	}
+
+	trimTicker := time.NewTicker(r.clientSettings.TrimInterval)
+	defer trimTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-trimTicker.C:
+			err := r.client.XTrimMaxLenApprox(ctx, stream, r.clientSettings.MaxLen)
+			if err != nil && ctx.Err() == nil {
+				r.logger.Errorf("error trimming Redis stream %s: %v", stream, err)
+			}
+		}
+	}
+}
+
 // removeMessagesThatNoLongerExistFromPending attempts to claim messages individually so that messages in the pending list
 // that no longer exist can be removed from the pending list. This is done by calling `XACK`.
 func (r *redisStreams) removeMessagesThatNoLongerExistFromPending(ctx context.Context, stream string, messageIDs map[string]struct{}, handler pubsub.Handler) {
@@ -372,11 +563,39 @@ func (r *redisStreams) removeMessagesThatNoLongerExistFromPending(ctx context.Co
 			}
 		} else {
 			// This should not happen but if it does the message should be processed.
-			r.enqueueMessages(ctx, stream, handler, claimResultSingleMsg)
+			r.enqueueMessages(ctx, stream, handler, claimResultSingleMsg, nil)
 		}
 	}
 }
 
+// deadLetter adds a message whose retry budget is exhausted to the dead-letter stream, if configured, and acknowledges it.
+func (r *redisStreams) deadLetter(msg redisMessageWrapper, attempt int, processErr error) error {
+	// Use the background context in case subscriptionCtx is already closed.
+	ctx := context.Background()
+	if topic := r.retryPolicy.DeadLetterTopic(); topic != "" {
+		req := r.retryPolicy.DeadLetterRequest("", &msg.message, attempt, processErr)
+		values := map[string]interface{}{"data": req.Data}
+		for k, v := range req.Metadata {
+			values[k] = v
+		}
+		if _, err := r.client.XAdd(ctx, topic, r.clientSettings.MaxLenApprox, values); err != nil {
+			r.logger.Errorf("Error adding Redis message %s to dead-letter stream %s: %v", msg.messageID, topic, err)
+
+			return err
+		}
+	}
+
+	if err := r.client.XAck(ctx, msg.message.Topic, r.clientSettings.ConsumerID, msg.messageID); err != nil {
+		r.logger.Errorf("Error acknowledging Redis message %s: %v", msg.messageID, err)
+
+		return err
+	}
+
+	r.logger.Warnf("Redis message %s was dead-lettered after %d failed deliveries", msg.messageID, attempt)
+	r.metrics.DeadLettered(msg.message.Topic)
+	return nil
+}
+
 func (r *redisStreams) Close() error {
 	defer r.wg.Wait()
 	if r.closed.CompareAndSwap(false, true) {
@@ -389,8 +608,18 @@ func (r *redisStreams) Close() error {
 	return r.client.Close()
 }
 
+// WaitSubscriptionsReady blocks until the subscriptions can read from their streams, or until ctx is done.
+func (r *redisStreams) WaitSubscriptionsReady(ctx context.Context) error {
+	return r.subscriptions.WaitSubscriptionsReady(ctx)
+}
+
+// SubscriptionsStatus returns the status of the subscriptions of the component.
+func (r *redisStreams) SubscriptionsStatus() []pubsub.SubscriptionStatus {
+	return r.subscriptions.SubscriptionsStatus()
+}
+
 func (r *redisStreams) Features() []pubsub.Feature {
-	return nil
+	return []pubsub.Feature{pubsub.FeatureMessageTTL}
 }
 
 func (r *redisStreams) Ping(ctx context.Context) error {
@@ -405,5 +634,12 @@ func (r *redisStreams) GetComponentMetadata() map[string]string {
 	metadataStruct := rediscomponent.Settings{}
 	metadataInfo := map[string]string{}
 	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, contribMetadata.PubSubType)
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(retrypolicy.Settings{}), &metadataInfo, contribMetadata.PubSubType)
+	contribMetadata.GetMetadataInfoFromStructType(reflect.TypeOf(pubsub.TopicMappingProperties{}), &metadataInfo, contribMetadata.PubSubType)
 	return metadataInfo
 }
+
+// SetMetricsHook sets the hook that receives the metrics of the component.
+func (r *redisStreams) SetMetricsHook(hook pubsub.MetricsHook) {
+	r.metrics.SetMetricsHook(hook)
+}
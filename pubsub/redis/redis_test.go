@@ -19,11 +19,15 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	mdata "github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/components-contrib/pubsub/retrypolicy"
 	"github.com/dapr/kit/logger"
 
 	internalredis "github.com/dapr/components-contrib/internal/component/redis"
@@ -103,7 +107,7 @@ func TestProcessStreams(t *testing.T) {
 	}
 	testRedisStream.queue = make(chan redisMessageWrapper, 10)
 	go testRedisStream.worker()
-	testRedisStream.enqueueMessages(context.Background(), fakeConsumerID, fakeHandler, generateRedisStreamTestData(2, 3, expectedData))
+	testRedisStream.enqueueMessages(context.Background(), fakeConsumerID, fakeHandler, generateRedisStreamTestData(2, 3, expectedData), nil)
 
 	// Wait for the handler to finish processing
 	wg.Wait()
@@ -130,3 +134,233 @@ func generateRedisStreamTestData(topicCount, messageCount int, data string) []in
 
 	return xmessageArray
 }
+
+func TestPrecedingStreamID(t *testing.T) {
+	tests := map[string]string{
+		"1526919030474-55": "1526919030474-54",
+		"1526919030474-0":  "1526919030473-18446744073709551615",
+		"1526919030474":    "1526919030473-18446744073709551615",
+		"0-0":              "0",
+		"0":                "0",
+	}
+	for id, expected := range tests {
+		res, err := precedingStreamID(id)
+		assert.NoError(t, err, id)
+		assert.Equal(t, expected, res, id)
+	}
+
+	_, err := precedingStreamID("abc")
+	assert.Error(t, err)
+	_, err = precedingStreamID("1-abc")
+	assert.Error(t, err)
+}
+
+func TestCreateRedisMessageWrapperTraceContext(t *testing.T) {
+	msg := createRedisMessageWrapper(context.Background(), "mystream", nil, internalredis.RedisXMessage{
+		ID: "1",
+		Values: map[string]interface{}{
+			"data":        "testData",
+			"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+			"tracestate":  "congo=t61rcWkgMzE",
+		},
+	})
+	assert.Equal(t, "testData", string(msg.message.Data))
+	assert.Equal(t, map[string]string{
+		"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"tracestate":  "congo=t61rcWkgMzE",
+	}, msg.message.Metadata)
+
+	msg = createRedisMessageWrapper(context.Background(), "mystream", nil, generateRedisStreamTestData(1, 1, "testData")[0])
+	assert.Nil(t, msg.message.Metadata)
+}
+
+func TestAutoClaimPendingMessages(t *testing.T) {
+	s := miniredis.RunT(t)
+	client, settings, err := internalredis.ParseClientFromProperties(map[string]string{
+		"redisHost":          s.Addr(),
+		consumerID:           "fakeConsumer",
+		"pendingIdleTimeout": "1ms",
+		"claimBatchSize":     "10",
+	}, mdata.PubSubType)
+	require.NoError(t, err)
+	defer client.Close()
+	assert.Equal(t, time.Millisecond, settings.PendingIdleTimeout)
+	assert.Equal(t, int64(10), settings.ClaimBatchSize)
+
+	ctx := context.Background()
+	require.NoError(t, client.XGroupCreateMkStream(ctx, "mystream", "fakeConsumer", "0"))
+	for i := 0; i < 3; i++ {
+		_, err = client.XAdd(ctx, "mystream", 0, map[string]interface{}{"data": "testData"})
+		require.NoError(t, err)
+	}
+
+	// Messages are delivered to another consumer that never acknowledges them
+	streams, err := client.XReadGroupResult(ctx, "fakeConsumer", "crashedConsumer", []string{"mystream", ">"}, 10, -1)
+	require.NoError(t, err)
+	require.Len(t, streams, 1)
+	require.Len(t, streams[0].Messages, 3)
+	time.Sleep(10 * time.Millisecond)
+
+	r := &redisStreams{
+		client:         client,
+		clientSettings: settings,
+		logger:         logger.NewLogger("test"),
+		queue:          make(chan redisMessageWrapper, 10),
+	}
+	r.autoClaimPendingMessages(ctx, "mystream", func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return nil
+	})
+
+	require.Len(t, r.queue, 3)
+	for i := 0; i < 3; i++ {
+		msg := <-r.queue
+		assert.Equal(t, "testData", string(msg.message.Data))
+	}
+}
+
+func TestDeadLetterAfterMaxDeliveryAttempts(t *testing.T) {
+	s := miniredis.RunT(t)
+	client, settings, err := internalredis.ParseClientFromProperties(map[string]string{
+		"redisHost": s.Addr(),
+		consumerID:  "fakeConsumer",
+	}, mdata.PubSubType)
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx := context.Background()
+	require.NoError(t, client.XGroupCreateMkStream(ctx, "mystream", "fakeConsumer", "0"))
+	_, err = client.XAdd(ctx, "mystream", 0, map[string]interface{}{"data": "testData"})
+	require.NoError(t, err)
+	streams, err := client.XReadGroupResult(ctx, "fakeConsumer", "fakeConsumer", []string{"mystream", ">"}, 10, -1)
+	require.NoError(t, err)
+	require.Len(t, streams, 1)
+	require.Len(t, streams[0].Messages, 1)
+
+	retrySettings, err := retrypolicy.ParseSettings(map[string]string{
+		"maxDeliveryAttempts": "2",
+		"deadLetterTopic":     "mystream-dlq",
+	})
+	require.NoError(t, err)
+	r := &redisStreams{
+		client:         client,
+		clientSettings: settings,
+		logger:         logger.NewLogger("test"),
+		retryPolicy:    retrypolicy.New(retrySettings),
+	}
+	handlerErr := errors.New("handler failed")
+	msg := createRedisMessageWrapper(ctx, "mystream", func(ctx context.Context, msg *pubsub.NewMessage) error {
+		return handlerErr
+	}, streams[0].Messages[0])
+
+	// The first failure leaves the message pending, and the second one exhausts the retry budget
+	require.ErrorIs(t, r.processMessage(msg), handlerErr)
+	assert.False(t, r.retryPolicy.Ready(msg.messageID))
+	require.NoError(t, r.processMessage(msg))
+
+	pending, err := client.XPendingExtResult(ctx, "mystream", "fakeConsumer", "-", "+", 10)
+	// The client returns the Redis nil error when there are no pending messages
+	if err != nil {
+		assert.Equal(t, client.GetNilValueError().Error(), err.Error())
+	}
+	assert.Empty(t, pending)
+
+	dlq, err := s.Stream("mystream-dlq")
+	require.NoError(t, err)
+	require.Len(t, dlq, 1)
+	values := map[string]string{}
+	for i := 0; i+1 < len(dlq[0].Values); i += 2 {
+		values[dlq[0].Values[i]] = dlq[0].Values[i+1]
+	}
+	assert.Equal(t, "testData", values["data"])
+	assert.Equal(t, "mystream", values[retrypolicy.DeadLetterTopicKey])
+	assert.Equal(t, "2", values[retrypolicy.DeadLetterAttemptsKey])
+	assert.Equal(t, "handler failed", values[retrypolicy.DeadLetterErrorKey])
+}
+
+func TestMessageTTL(t *testing.T) {
+	s := miniredis.RunT(t)
+	client, settings, err := internalredis.ParseClientFromProperties(map[string]string{
+		"redisHost": s.Addr(),
+		consumerID:  "fakeConsumer",
+	}, mdata.PubSubType)
+	require.NoError(t, err)
+	defer client.Close()
+
+	r := &redisStreams{
+		client:         client,
+		clientSettings: settings,
+		logger:         logger.NewLogger("test"),
+	}
+	ctx := context.Background()
+	require.NoError(t, client.XGroupCreateMkStream(ctx, "mystream", "fakeConsumer", "0"))
+	require.NoError(t, r.Publish(ctx, &pubsub.PublishRequest{
+		Topic:    "mystream",
+		Data:     []byte("testData"),
+		Metadata: map[string]string{"ttlInSeconds": "10"},
+	}))
+
+	streams, err := client.XReadGroupResult(ctx, "fakeConsumer", "fakeConsumer", []string{"mystream", ">"}, 10, -1)
+	require.NoError(t, err)
+	require.Len(t, streams, 1)
+	require.Len(t, streams[0].Messages, 1)
+
+	delivered := 0
+	msg := createRedisMessageWrapper(ctx, "mystream", func(ctx context.Context, msg *pubsub.NewMessage) error {
+		delivered++
+		return nil
+	}, streams[0].Messages[0])
+	assert.Equal(t, "10", msg.message.Metadata["ttlInSeconds"])
+	require.Contains(t, msg.message.Metadata, pubsub.EnqueueTimeKey)
+
+	// The message expired: it's acknowledged without being delivered
+	msg.message.Metadata[pubsub.EnqueueTimeKey] = fmt.Sprint(time.Now().Add(-time.Minute).UnixMilli())
+	require.NoError(t, r.processMessage(msg))
+	assert.Equal(t, 0, delivered)
+
+	pending, err := client.XPendingExtResult(ctx, "mystream", "fakeConsumer", "-", "+", 10)
+	if err != nil {
+		assert.Equal(t, client.GetNilValueError().Error(), err.Error())
+	}
+	assert.Empty(t, pending)
+}
+
+func TestSubscriptionReadiness(t *testing.T) {
+	s := miniredis.RunT(t)
+	client, settings, err := internalredis.ParseClientFromProperties(map[string]string{
+		"redisHost": s.Addr(),
+		consumerID:  "fakeConsumer",
+	}, mdata.PubSubType)
+	require.NoError(t, err)
+	defer client.Close()
+
+	r := &redisStreams{
+		client:         client,
+		clientSettings: settings,
+		logger:         logger.NewLogger("test"),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, client.XGroupCreateMkStream(ctx, "mystream", "fakeConsumer", "0"))
+
+	sub := r.subscriptions.Track("mystream")
+	sub.Ready()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.pollNewMessagesLoop(ctx, "mystream", func(ctx context.Context, msg *pubsub.NewMessage) error {
+			return nil
+		}, sub)
+	}()
+
+	require.NoError(t, r.WaitSubscriptionsReady(ctx))
+
+	// Reading fails while the server is down
+	s.Close()
+	assert.Eventually(t, func() bool {
+		status := r.SubscriptionsStatus()
+		return len(status) == 1 && status[0].State == pubsub.SubscriptionPending && status[0].Err != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+// Replay sets the last delivered ID of the consumer group of a stream, so the following messages are delivered again.
+// The offset is the ID of the first message to deliver.
+func (r *redisStreams) Replay(ctx context.Context, req pubsub.ReplayRequest) error {
+	if r.closed.Load() {
+		return errors.New("component is closed")
+	}
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	group := req.ConsumerGroup
+	if group == "" {
+		group = r.clientSettings.ConsumerID
+	}
+
+	var (
+		lastID string
+		err    error
+	)
+	switch {
+	case !req.Timestamp.IsZero():
+		lastID, err = precedingStreamID(strconv.FormatInt(req.Timestamp.UnixMilli(), 10))
+	case req.Offset == pubsub.ReplayOffsetEarliest:
+		lastID = "0"
+	default:
+		lastID, err = precedingStreamID(req.Offset)
+	}
+	if err != nil {
+		return err
+	}
+
+	stream := r.topics.Physical(req.Topic)
+	if err = r.client.DoWrite(ctx, "XGROUP", "SETID", stream, group, lastID); err != nil {
+		return fmt.Errorf("redis streams: error setting the ID of consumer group %s on stream %s: %w", group, stream, err)
+	}
+	return nil
+}
+
+// precedingStreamID returns the greatest stream ID lower than the given one.
+// IDs without a sequence number are treated as having sequence number 0.
+func precedingStreamID(id string) (string, error) {
+	msStr, seqStr, hasSeq := strings.Cut(id, "-")
+	ms, err := strconv.ParseUint(msStr, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("redis streams: invalid stream ID '%s'", id)
+	}
+	var seq uint64
+	if hasSeq {
+		seq, err = strconv.ParseUint(seqStr, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("redis streams: invalid stream ID '%s'", id)
+		}
+	}
+
+	switch {
+	case seq > 0:
+		return fmt.Sprintf("%d-%d", ms, seq-1), nil
+	case ms > 0:
+		return fmt.Sprintf("%d-%d", ms-1, uint64(math.MaxUint64)), nil
+	default:
+		return "0", nil
+	}
+}
@@ -15,8 +15,10 @@ package pubsub
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 )
 
 // PublishRequest is the request to publish a message.
@@ -43,6 +45,54 @@ type SubscribeRequest struct {
 	BulkSubscribeConfig BulkSubscribeConfig `json:"bulkSubscribe,omitempty"`
 }
 
+// ReplayOffsetEarliest is the offset of a ReplayRequest that replays all the messages retained by the broker.
+const ReplayOffsetEarliest = "earliest"
+
+// ReplayRequest is the request to replay the messages of a topic.
+// Exactly one of Offset and Timestamp must be set.
+type ReplayRequest struct {
+	Topic string `json:"topic"`
+	// ConsumerGroup is the consumer group to move. If empty, the consumer group of the component is used.
+	ConsumerGroup string `json:"consumerGroup,omitempty"`
+	// Offset is the broker-specific position of the first message to deliver, or ReplayOffsetEarliest.
+	Offset string `json:"offset,omitempty"`
+	// Timestamp replays the messages published at or after this time.
+	Timestamp time.Time         `json:"timestamp,omitempty"`
+	Metadata  map[string]string `json:"metadata"`
+}
+
+// Validate returns an error if the request doesn't have a topic or doesn't have exactly one of Offset and Timestamp.
+func (r ReplayRequest) Validate() error {
+	if r.Topic == "" {
+		return errors.New("topic is required to replay messages")
+	}
+	if (r.Offset == "") == r.Timestamp.IsZero() {
+		return errors.New("exactly one of offset and timestamp is required to replay messages")
+	}
+	return nil
+}
+
+// PauseRequest is the request to pause or resume the consumption of a topic.
+type PauseRequest struct {
+	Topic string `json:"topic"`
+	// Partitions are the broker-specific partitions of the topic to pause or resume. If empty, all the partitions are paused or resumed.
+	Partitions []int32           `json:"partitions,omitempty"`
+	Metadata   map[string]string `json:"metadata"`
+}
+
+// Validate returns an error if the request doesn't have a topic or has a negative partition.
+func (r PauseRequest) Validate() error {
+	if r.Topic == "" {
+		return errors.New("topic is required to pause or resume consumption")
+	}
+	for _, p := range r.Partitions {
+		if p < 0 {
+			return fmt.Errorf("invalid partition %d", p)
+		}
+	}
+	return nil
+}
+
 // NewMessage is an event arriving from a message bus instance.
 type NewMessage struct {
 	Data        []byte            `json:"data"`
@@ -0,0 +1,36 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayRequestValidate(t *testing.T) {
+	assert.NoError(t, ReplayRequest{Topic: "t", Offset: ReplayOffsetEarliest}.Validate())
+	assert.NoError(t, ReplayRequest{Topic: "t", Timestamp: time.Now()}.Validate())
+	assert.Error(t, ReplayRequest{Offset: "1"}.Validate())
+	assert.Error(t, ReplayRequest{Topic: "t"}.Validate())
+	assert.Error(t, ReplayRequest{Topic: "t", Offset: "1", Timestamp: time.Now()}.Validate())
+}
+
+func TestPauseRequestValidate(t *testing.T) {
+	assert.NoError(t, PauseRequest{Topic: "t"}.Validate())
+	assert.NoError(t, PauseRequest{Topic: "t", Partitions: []int32{0, 2}}.Validate())
+	assert.Error(t, PauseRequest{Partitions: []int32{0}}.Validate())
+	assert.Error(t, PauseRequest{Topic: "t", Partitions: []int32{-1}}.Validate())
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package retrypolicy contains a retry budget for messages that pub/sub components fail to process.
+// Components count the delivery attempts of each message, wait with an exponential backoff between redeliveries, and route the message to a dead-letter topic once the budget is exhausted.
+package retrypolicy
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"k8s.io/utils/clock"
+
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+const (
+	// Metadata keys added to the messages that are sent to the dead-letter topic.
+	DeadLetterTopicKey    = "__dapr.dlq.topic"
+	DeadLetterAttemptsKey = "__dapr.dlq.attempts"
+	DeadLetterErrorKey    = "__dapr.dlq.error"
+
+	defaultInitialInterval = time.Second
+	defaultMaxInterval     = time.Minute
+	defaultMultiplier      = 2
+)
+
+// Settings contains the component metadata for the retry policy.
+type Settings struct {
+	// Maximum number of times a message is delivered before it's sent to the dead-letter topic.
+	// A value of 0 disables the retry policy, and components keep their own behavior.
+	MaxDeliveryAttempts int `mapstructure:"maxDeliveryAttempts"`
+	// Topic that messages are sent to after maxDeliveryAttempts failed deliveries.
+	// If empty, the messages are dropped.
+	DeadLetterTopic string `mapstructure:"deadLetterTopic"`
+	// Delay before the first redelivery of a message.
+	InitialInterval time.Duration `mapstructure:"redeliveryInitialInterval"`
+	// Maximum delay between redeliveries of a message.
+	MaxInterval time.Duration `mapstructure:"redeliveryMaxInterval"`
+	// Factor by which the delay grows after each redelivery.
+	Multiplier float64 `mapstructure:"redeliveryMultiplier"`
+}
+
+// ParseSettings returns the settings of the retry policy from the component metadata.
+func ParseSettings(props map[string]string) (Settings, error) {
+	s := Settings{
+		InitialInterval: defaultInitialInterval,
+		MaxInterval:     defaultMaxInterval,
+		Multiplier:      defaultMultiplier,
+	}
+	err := contribMetadata.DecodeMetadata(props, &s)
+	if err != nil {
+		return s, fmt.Errorf("invalid retry policy: %w", err)
+	}
+
+	switch {
+	case s.MaxDeliveryAttempts < 0:
+		return s, fmt.Errorf("invalid retry policy: maxDeliveryAttempts must not be negative")
+	case s.InitialInterval < 0 || s.MaxInterval < 0:
+		return s, fmt.Errorf("invalid retry policy: redelivery intervals must not be negative")
+	case s.Multiplier < 1:
+		return s, fmt.Errorf("invalid retry policy: redeliveryMultiplier must be at least 1")
+	}
+	return s, nil
+}
+
+// Decision is the outcome of a failed delivery.
+type Decision struct {
+	// Number of times the message has been delivered, including the failed delivery.
+	Attempt int
+	// If true, the retry budget is exhausted and the message must be sent to the dead-letter topic.
+	DeadLetter bool
+	// Delay before the message should be redelivered, if DeadLetter is false.
+	Delay time.Duration
+}
+
+type attempts struct {
+	count       int
+	nextAttempt time.Time
+}
+
+// Policy tracks the delivery attempts of messages and decides when they are redelivered or dead-lettered.
+// It's safe for concurrent use, and a nil Policy is disabled.
+type Policy struct {
+	settings Settings
+	clock    clock.Clock
+
+	// Delivery attempts of the messages that failed, by message ID.
+	// Used when the broker doesn't report the delivery count; entries are removed when the message succeeds or is dead-lettered.
+	attempts map[string]*attempts
+	lock     sync.Mutex
+}
+
+// New returns a new Policy, or nil if the settings disable it.
+func New(settings Settings) *Policy {
+	if settings.MaxDeliveryAttempts <= 0 {
+		return nil
+	}
+	return &Policy{
+		settings: settings,
+		clock:    clock.RealClock{},
+		attempts: map[string]*attempts{},
+	}
+}
+
+// Enabled returns true if the policy is enabled.
+func (p *Policy) Enabled() bool {
+	return p != nil
+}
+
+// DeadLetterTopic returns the topic that messages are sent to after the retry budget is exhausted.
+func (p *Policy) DeadLetterTopic() string {
+	if p == nil {
+		return ""
+	}
+	return p.settings.DeadLetterTopic
+}
+
+// MaxDeliveryAttempts returns the maximum number of times a message is delivered.
+func (p *Policy) MaxDeliveryAttempts() int {
+	if p == nil {
+		return 0
+	}
+	return p.settings.MaxDeliveryAttempts
+}
+
+// RecordFailure records that the delivery of a message failed, and returns whether it must be redelivered or dead-lettered.
+// deliveryCount is the number of times the message has been delivered as reported by the broker, including this delivery; if it's 0, the attempts are counted in memory.
+func (p *Policy) RecordFailure(id string, deliveryCount int) Decision {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	a := p.attempts[id]
+	if a == nil {
+		a = &attempts{}
+		p.attempts[id] = a
+	}
+	if deliveryCount > 0 {
+		a.count = deliveryCount
+	} else {
+		a.count++
+	}
+
+	d := Decision{Attempt: a.count}
+	if a.count >= p.settings.MaxDeliveryAttempts {
+		d.DeadLetter = true
+		delete(p.attempts, id)
+		return d
+	}
+
+	d.Delay = p.delay(a.count)
+	a.nextAttempt = p.clock.Now().Add(d.Delay)
+	return d
+}
+
+// RecordSuccess records that a message was processed, and forgets its delivery attempts.
+func (p *Policy) RecordSuccess(id string) {
+	if p == nil {
+		return
+	}
+
+	p.lock.Lock()
+	delete(p.attempts, id)
+	p.lock.Unlock()
+}
+
+// Ready returns true if the backoff after the last failed delivery of the message has elapsed.
+// Components that redeliver messages on their own schedule use it to skip messages that must not be redelivered yet.
+func (p *Policy) Ready(id string) bool {
+	if p == nil {
+		return true
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	a := p.attempts[id]
+	return a == nil || !p.clock.Now().Before(a.nextAttempt)
+}
+
+// NewBackOff returns a backoff for components that retry messages in process.
+// It allows MaxDeliveryAttempts-1 retries, with the intervals of the policy.
+func (p *Policy) NewBackOff(ctx context.Context) backoff.BackOff {
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = p.settings.InitialInterval
+	bo.MaxInterval = p.settings.MaxInterval
+	bo.Multiplier = p.settings.Multiplier
+	bo.RandomizationFactor = 0
+	bo.MaxElapsedTime = 0
+	bo.Clock = p.clock
+	return backoff.WithContext(backoff.WithMaxRetries(bo, uint64(p.settings.MaxDeliveryAttempts-1)), ctx)
+}
+
+// DeadLetterRequest returns the request that publishes a message to the dead-letter topic.
+// The request has the data and metadata of the message, plus the original topic, the number of attempts, and the last error.
+func (p *Policy) DeadLetterRequest(pubsubName string, msg *pubsub.NewMessage, attempt int, processErr error) *pubsub.PublishRequest {
+	md := make(map[string]string, len(msg.Metadata)+3)
+	for k, v := range msg.Metadata {
+		md[k] = v
+	}
+	md[DeadLetterTopicKey] = msg.Topic
+	md[DeadLetterAttemptsKey] = strconv.Itoa(attempt)
+	if processErr != nil {
+		md[DeadLetterErrorKey] = processErr.Error()
+	}
+
+	return &pubsub.PublishRequest{
+		Data:        msg.Data,
+		PubsubName:  pubsubName,
+		Topic:       p.settings.DeadLetterTopic,
+		Metadata:    md,
+		ContentType: msg.ContentType,
+	}
+}
+
+// delay returns the backoff before the redelivery that follows the given attempt.
+func (p *Policy) delay(attempt int) time.Duration {
+	d := float64(p.settings.InitialInterval)
+	for i := 1; i < attempt; i++ {
+		d *= p.settings.Multiplier
+		if d >= float64(p.settings.MaxInterval) {
+			return p.settings.MaxInterval
+		}
+	}
+	return time.Duration(d)
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retrypolicy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+func TestParseSettings(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		s, err := ParseSettings(map[string]string{})
+		require.NoError(t, err)
+		assert.Equal(t, Settings{
+			InitialInterval: time.Second,
+			MaxInterval:     time.Minute,
+			Multiplier:      2,
+		}, s)
+		assert.Nil(t, New(s))
+	})
+
+	t.Run("all settings", func(t *testing.T) {
+		s, err := ParseSettings(map[string]string{
+			"maxDeliveryAttempts":       "5",
+			"deadLetterTopic":           "dlq",
+			"redeliveryInitialInterval": "100ms",
+			"redeliveryMaxInterval":     "10s",
+			"redeliveryMultiplier":      "1.5",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, Settings{
+			MaxDeliveryAttempts: 5,
+			DeadLetterTopic:     "dlq",
+			InitialInterval:     100 * time.Millisecond,
+			MaxInterval:         10 * time.Second,
+			Multiplier:          1.5,
+		}, s)
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		_, err := ParseSettings(map[string]string{"maxDeliveryAttempts": "-1"})
+		require.Error(t, err)
+		_, err = ParseSettings(map[string]string{"redeliveryMultiplier": "0.5"})
+		require.Error(t, err)
+	})
+}
+
+func TestPolicy(t *testing.T) {
+	newPolicy := func() (*Policy, *clocktesting.FakeClock) {
+		p := New(Settings{
+			MaxDeliveryAttempts: 4,
+			DeadLetterTopic:     "dlq",
+			InitialInterval:     time.Second,
+			MaxInterval:         3 * time.Second,
+			Multiplier:          2,
+		})
+		clock := clocktesting.NewFakeClock(time.Now())
+		p.clock = clock
+		return p, clock
+	}
+
+	t.Run("counts attempts in memory", func(t *testing.T) {
+		p, clock := newPolicy()
+
+		assert.Equal(t, Decision{Attempt: 1, Delay: time.Second}, p.RecordFailure("m1", 0))
+		assert.False(t, p.Ready("m1"))
+		assert.True(t, p.Ready("m2"))
+		clock.Step(time.Second)
+		assert.True(t, p.Ready("m1"))
+
+		assert.Equal(t, Decision{Attempt: 2, Delay: 2 * time.Second}, p.RecordFailure("m1", 0))
+		assert.Equal(t, Decision{Attempt: 3, Delay: 3 * time.Second}, p.RecordFailure("m1", 0))
+		assert.Equal(t, Decision{Attempt: 4, DeadLetter: true}, p.RecordFailure("m1", 0))
+
+		// Dead-lettered messages are forgotten
+		assert.Empty(t, p.attempts)
+	})
+
+	t.Run("uses the delivery count of the broker", func(t *testing.T) {
+		p, _ := newPolicy()
+
+		assert.Equal(t, Decision{Attempt: 2, Delay: 2 * time.Second}, p.RecordFailure("m1", 2))
+		assert.Equal(t, Decision{Attempt: 5, DeadLetter: true}, p.RecordFailure("m1", 5))
+	})
+
+	t.Run("success resets the attempts", func(t *testing.T) {
+		p, _ := newPolicy()
+
+		p.RecordFailure("m1", 0)
+		p.RecordSuccess("m1")
+		assert.True(t, p.Ready("m1"))
+		assert.Equal(t, 1, p.RecordFailure("m1", 0).Attempt)
+	})
+
+	t.Run("backoff allows maxDeliveryAttempts deliveries", func(t *testing.T) {
+		p := New(Settings{
+			MaxDeliveryAttempts: 3,
+			InitialInterval:     time.Millisecond,
+			MaxInterval:         time.Millisecond,
+			Multiplier:          1,
+		})
+
+		calls := 0
+		err := backoff.Retry(func() error {
+			calls++
+			return errors.New("failed")
+		}, p.NewBackOff(context.Background()))
+		require.Error(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("dead-letter request", func(t *testing.T) {
+		p, _ := newPolicy()
+
+		req := p.DeadLetterRequest("mypubsub", &pubsub.NewMessage{
+			Data:     []byte("hello"),
+			Topic:    "orders",
+			Metadata: map[string]string{"traceparent": "00-1"},
+		}, 4, errors.New("handler failed"))
+		assert.Equal(t, &pubsub.PublishRequest{
+			Data:       []byte("hello"),
+			PubsubName: "mypubsub",
+			Topic:      "dlq",
+			Metadata: map[string]string{
+				"traceparent":         "00-1",
+				DeadLetterTopicKey:    "orders",
+				DeadLetterAttemptsKey: "4",
+				DeadLetterErrorKey:    "handler failed",
+			},
+		}, req)
+	})
+
+	t.Run("nil policy is disabled", func(t *testing.T) {
+		var p *Policy
+		assert.False(t, p.Enabled())
+		assert.True(t, p.Ready("m1"))
+		assert.Empty(t, p.DeadLetterTopic())
+		p.RecordSuccess("m1")
+	})
+}
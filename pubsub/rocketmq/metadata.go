@@ -30,6 +30,20 @@ const (
 	metadataRocketmqExpression    = "rocketmq-sub-expression"
 	metadataRocketmqBrokerName    = "rocketmq-broker-name"
 	metadataRocketmqQueueID       = "rocketmq-queue-id"
+	// Message group for FIFO ordering: messages of the same group are sent to the same queue.
+	metadataRocketmqMessageGroup = "rocketmq-messagegroup"
+	// Delay level of the message, from 1 (1s) to 18 (2h) with the default broker configuration.
+	metadataRocketmqDelayLevel = "rocketmq-delaylevel"
+	// Time the message is delivered at, as RFC 3339 or Unix milliseconds; it requires RocketMQ 5 timer messages.
+	metadataRocketmqDeliverAt = "rocketmq-deliverat"
+)
+
+const (
+	// Property of RocketMQ 5 timer messages with the delivery time in Unix milliseconds.
+	propertyTimerDeliverMs = "TIMER_DELIVER_MS"
+
+	minDelayLevel = 1
+	maxDelayLevel = 18
 )
 
 type QueueSelectorType string
@@ -83,13 +83,13 @@ type rocketMQ struct {
 }
 
 func NewRocketMQ(l logger.Logger) pubsub.PubSub {
-	return &rocketMQ{
+	return pubsub.WithSchemaValidation(&rocketMQ{
 		name:         "rocketmq",
 		logger:       l,
 		producerLock: sync.Mutex{},
 		consumerLock: sync.Mutex{},
 		closeCh:      make(chan struct{}),
-	}
+	})
 }
 
 func (r *rocketMQ) Init(_ context.Context, metadata pubsub.Metadata) error {
@@ -346,18 +346,9 @@ func (r *rocketMQ) Publish(ctx context.Context, req *pubsub.PublishRequest) erro
 	}
 
 	r.logger.Debugf("rocketmq publish topic:%s with data:%v", req.Topic, req.Data)
-	msg := primitive.NewMessage(req.Topic, req.Data)
-	for k, v := range req.Metadata {
-		switch strings.ToLower(k) {
-		case metadataRocketmqTag:
-			msg.WithTag(v)
-		case metadataRocketmqKey:
-			msg.WithKeys(strings.Split(v, ","))
-		case metadataRocketmqShardingKey:
-			msg.WithShardingKey(v)
-		default:
-			msg.WithProperty(k, v)
-		}
+	msg, e := buildMessage(req)
+	if e != nil {
+		return e
 	}
 	producer, e := r.getProducer()
 	if e != nil {
@@ -374,6 +365,73 @@ func (r *rocketMQ) Publish(ctx context.Context, req *pubsub.PublishRequest) erro
 	return nil
 }
 
+// buildMessage creates the RocketMQ message of a publish request.
+func buildMessage(req *pubsub.PublishRequest) (*primitive.Message, error) {
+	msg := primitive.NewMessage(req.Topic, req.Data)
+	var shardingKey, messageGroup, delayLevel, deliverAt string
+	for k, v := range req.Metadata {
+		switch strings.ToLower(k) {
+		case metadataRocketmqTag:
+			msg.WithTag(v)
+		case metadataRocketmqKey:
+			msg.WithKeys(strings.Split(v, ","))
+		case metadataRocketmqShardingKey:
+			shardingKey = v
+		case metadataRocketmqMessageGroup:
+			messageGroup = v
+		case metadataRocketmqDelayLevel:
+			delayLevel = v
+		case metadataRocketmqDeliverAt:
+			deliverAt = v
+		default:
+			msg.WithProperty(k, v)
+		}
+	}
+
+	// The message group is the sharding key of the message, so all the messages of a group are sent to the same queue and consumed in order
+	if messageGroup != "" {
+		if shardingKey != "" && shardingKey != messageGroup {
+			return nil, fmt.Errorf("rocketmq metadata %s and %s must have the same value if both are set", metadataRocketmqMessageGroup, metadataRocketmqShardingKey)
+		}
+		shardingKey = messageGroup
+	}
+	if shardingKey != "" {
+		msg.WithShardingKey(shardingKey)
+	}
+
+	if delayLevel != "" && deliverAt != "" {
+		return nil, fmt.Errorf("rocketmq metadata %s and %s cannot be set together", metadataRocketmqDelayLevel, metadataRocketmqDeliverAt)
+	}
+	if delayLevel != "" {
+		level, err := strconv.Atoi(delayLevel)
+		if err != nil || level < minDelayLevel || level > maxDelayLevel {
+			return nil, fmt.Errorf("rocketmq metadata %s is invalid: %s, expected an integer between %d and %d", metadataRocketmqDelayLevel, delayLevel, minDelayLevel, maxDelayLevel)
+		}
+		msg.WithDelayTimeLevel(level)
+	}
+	if deliverAt != "" {
+		deliverTime, err := parseDeliverAt(deliverAt)
+		if err != nil {
+			return nil, fmt.Errorf("rocketmq metadata %s is invalid: %w", metadataRocketmqDeliverAt, err)
+		}
+		msg.WithProperty(propertyTimerDeliverMs, strconv.FormatInt(deliverTime.UnixMilli(), 10))
+	}
+
+	return msg, nil
+}
+
+// parseDeliverAt parses a delivery time, which is either in RFC 3339 format or in Unix milliseconds.
+func parseDeliverAt(val string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected a time in RFC 3339 format or in Unix milliseconds: %s", val)
+	}
+	return t, nil
+}
+
 func (r *rocketMQ) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
 	if r.closed.Load() {
 		return errors.New("component is closed")
@@ -18,6 +18,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/apache/rocketmq-client-go/v2/primitive"
 	"github.com/stretchr/testify/assert"
 
 	mdata "github.com/dapr/components-contrib/metadata"
@@ -165,6 +166,74 @@ func TestRocketMQ_Publish_Orderly(t *testing.T) {
 	assert.NoError(t, r.Close())
 }
 
+func TestBuildMessage(t *testing.T) {
+	t.Run("message group is the sharding key", func(t *testing.T) {
+		msg, err := buildMessage(&pubsub.PublishRequest{
+			Topic: "topic",
+			Metadata: map[string]string{
+				"rocketmq-messagegroup": "group1",
+				"rocketmq-tag":          "tag",
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "group1", msg.GetShardingKey())
+		assert.Equal(t, "tag", msg.GetTags())
+	})
+
+	t.Run("message group conflicts with sharding key", func(t *testing.T) {
+		_, err := buildMessage(&pubsub.PublishRequest{
+			Topic: "topic",
+			Metadata: map[string]string{
+				"rocketmq-messagegroup": "group1",
+				"rocketmq-shardingkey":  "key1",
+			},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("delay level", func(t *testing.T) {
+		msg, err := buildMessage(&pubsub.PublishRequest{
+			Topic:    "topic",
+			Metadata: map[string]string{"rocketmq-delaylevel": "3"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "3", msg.GetProperty(primitive.PropertyDelayTimeLevel))
+
+		for _, v := range []string{"0", "19", "foo"} {
+			_, err = buildMessage(&pubsub.PublishRequest{
+				Topic:    "topic",
+				Metadata: map[string]string{"rocketmq-delaylevel": v},
+			})
+			assert.Error(t, err, v)
+		}
+	})
+
+	t.Run("deliver at", func(t *testing.T) {
+		msg, err := buildMessage(&pubsub.PublishRequest{
+			Topic:    "topic",
+			Metadata: map[string]string{"rocketmq-deliverat": "2023-06-01T10:00:00Z"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "1685613600000", msg.GetProperty("TIMER_DELIVER_MS"))
+
+		msg, err = buildMessage(&pubsub.PublishRequest{
+			Topic:    "topic",
+			Metadata: map[string]string{"rocketmq-deliverat": "1685613600000"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "1685613600000", msg.GetProperty("TIMER_DELIVER_MS"))
+
+		_, err = buildMessage(&pubsub.PublishRequest{
+			Topic: "topic",
+			Metadata: map[string]string{
+				"rocketmq-deliverat":  "1685613600000",
+				"rocketmq-delaylevel": "3",
+			},
+		})
+		assert.Error(t, err)
+	})
+}
+
 func TestRocketMQ_Subscribe_Currently(t *testing.T) {
 	l, r, e := BuildRocketMQ()
 	assert.Nil(t, e)
@@ -53,10 +53,10 @@ type amqpPubSub struct {
 
 // NewAMQPPubsub returns a new AMQPPubSub instance
 func NewAMQPPubsub(logger logger.Logger) pubsub.PubSub {
-	return &amqpPubSub{
+	return pubsub.WithSchemaValidation(&amqpPubSub{
 		logger:  logger,
 		closeCh: make(chan struct{}),
-	}
+	})
 }
 
 // Init parses the metadata and creates a new Pub Sub Client.
@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+// TenantPlaceholder is replaced with the value of the topicTenant metadata property in the topic prefix, suffix and rewrite rules.
+const TenantPlaceholder = "{tenant}"
+
+// TopicMappingProperties contains the metadata properties that map the logical topic names used by apps to the physical names used in the broker.
+// Physical names are obtained by applying the first matching rewrite rule to the logical name, then adding the prefix and suffix.
+type TopicMappingProperties struct {
+	// Prefix added to topic names, for example "{tenant}.prod.".
+	TopicPrefix string `mapstructure:"topicPrefix"`
+	// Suffix added to topic names.
+	TopicSuffix string `mapstructure:"topicSuffix"`
+	// Rewrite rules, as a JSON array of objects with a "match" regular expression and a "replace" template, which can reference the groups of the match (e.g. "$1").
+	TopicRewriteRules string `mapstructure:"topicRewriteRules"`
+	// Value of the "{tenant}" placeholder.
+	TopicTenant string `mapstructure:"topicTenant"`
+}
+
+type topicRewriteRule struct {
+	Match   string `json:"match"`
+	Replace string `json:"replace"`
+
+	re *regexp.Regexp
+}
+
+// TopicMapper maps logical topic names to physical ones.
+// A nil *TopicMapper leaves topic names unchanged.
+type TopicMapper struct {
+	prefix string
+	suffix string
+	rules  []topicRewriteRule
+}
+
+// ParseTopicMapping returns the TopicMapper configured in the component metadata, or nil if topic names are not mapped.
+func ParseTopicMapping(props map[string]string) (*TopicMapper, error) {
+	var p TopicMappingProperties
+	err := metadata.DecodeMetadata(props, &p)
+	if err != nil {
+		return nil, err
+	}
+	return p.TopicMapper()
+}
+
+// TopicMapper returns the TopicMapper configured by the properties, or nil if topic names are not mapped.
+func (p TopicMappingProperties) TopicMapper() (*TopicMapper, error) {
+	tenant := func(s string) (string, error) {
+		if !strings.Contains(s, TenantPlaceholder) {
+			return s, nil
+		}
+		if p.TopicTenant == "" {
+			return "", fmt.Errorf("'%s' contains the %s placeholder but topicTenant is not set", s, TenantPlaceholder)
+		}
+		return strings.ReplaceAll(s, TenantPlaceholder, p.TopicTenant), nil
+	}
+
+	var (
+		m   TopicMapper
+		err error
+	)
+	m.prefix, err = tenant(p.TopicPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("invalid topicPrefix: %w", err)
+	}
+	m.suffix, err = tenant(p.TopicSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("invalid topicSuffix: %w", err)
+	}
+
+	if rules := strings.TrimSpace(p.TopicRewriteRules); rules != "" {
+		err = json.Unmarshal([]byte(rules), &m.rules)
+		if err != nil {
+			return nil, fmt.Errorf("invalid topicRewriteRules: must be a JSON array of objects with 'match' and 'replace' properties: %w", err)
+		}
+		for i := range m.rules {
+			r := &m.rules[i]
+			if r.Match == "" {
+				return nil, fmt.Errorf("invalid topicRewriteRules: rule %d has no 'match' property", i)
+			}
+			r.re, err = regexp.Compile(r.Match)
+			if err != nil {
+				return nil, fmt.Errorf("invalid topicRewriteRules: rule %d: %w", i, err)
+			}
+			r.Replace, err = tenant(r.Replace)
+			if err != nil {
+				return nil, fmt.Errorf("invalid topicRewriteRules: rule %d: %w", i, err)
+			}
+		}
+	}
+
+	if m.prefix == "" && m.suffix == "" && len(m.rules) == 0 {
+		return nil, nil
+	}
+	return &m, nil
+}
+
+// Physical returns the physical name of a topic.
+func (m *TopicMapper) Physical(topic string) string {
+	if m == nil {
+		return topic
+	}
+	for _, r := range m.rules {
+		if r.re.MatchString(topic) {
+			topic = r.re.ReplaceAllString(topic, r.Replace)
+			break
+		}
+	}
+	return m.prefix + topic + m.suffix
+}
+
+// Handler returns a handler that delivers the messages received on the physical topic to handler with the logical topic name.
+func (m *TopicMapper) Handler(topic string, handler Handler) Handler {
+	if m == nil {
+		return handler
+	}
+	return func(ctx context.Context, msg *NewMessage) error {
+		logical := *msg
+		logical.Topic = topic
+		return handler(ctx, &logical)
+	}
+}
+
+// BulkHandler returns a handler that delivers the batches received on the physical topic to handler with the logical topic name.
+func (m *TopicMapper) BulkHandler(topic string, handler BulkHandler) BulkHandler {
+	if m == nil {
+		return handler
+	}
+	return func(ctx context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error) {
+		logical := *msg
+		logical.Topic = topic
+		return handler(ctx, &logical)
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTopicMapping(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		m, err := ParseTopicMapping(map[string]string{})
+		require.NoError(t, err)
+		assert.Nil(t, m)
+		assert.Equal(t, "orders", m.Physical("orders"))
+	})
+
+	t.Run("prefix and suffix with tenant", func(t *testing.T) {
+		m, err := ParseTopicMapping(map[string]string{
+			"topicPrefix": "{tenant}.prod.",
+			"topicSuffix": "-v2",
+			"topicTenant": "contoso",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "contoso.prod.orders-v2", m.Physical("orders"))
+	})
+
+	t.Run("rewrite rules", func(t *testing.T) {
+		m, err := ParseTopicMapping(map[string]string{
+			"topicPrefix": "prod-",
+			"topicRewriteRules": `[
+				{"match": "^orders\\.(.*)$", "replace": "{tenant}-orders-$1"},
+				{"match": "^orders", "replace": "never"},
+				{"match": "\\.", "replace": "_"}
+			]`,
+			"topicTenant": "contoso",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "prod-contoso-orders-eu", m.Physical("orders.eu"))
+		assert.Equal(t, "prod-payments_eu_west", m.Physical("payments.eu.west"))
+		assert.Equal(t, "prod-payments", m.Physical("payments"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := ParseTopicMapping(map[string]string{"topicPrefix": "{tenant}."})
+		require.ErrorContains(t, err, "invalid topicPrefix")
+
+		_, err = ParseTopicMapping(map[string]string{"topicRewriteRules": `{"match": "a"}`})
+		require.ErrorContains(t, err, "must be a JSON array")
+
+		_, err = ParseTopicMapping(map[string]string{"topicRewriteRules": `[{"replace": "a"}]`})
+		require.ErrorContains(t, err, "rule 0 has no 'match' property")
+
+		_, err = ParseTopicMapping(map[string]string{"topicRewriteRules": `[{"match": "("}]`})
+		require.ErrorContains(t, err, "rule 0")
+
+		_, err = ParseTopicMapping(map[string]string{"topicRewriteRules": `[{"match": "a", "replace": "{tenant}"}]`})
+		require.ErrorContains(t, err, "topicTenant is not set")
+	})
+}
+
+func TestTopicMapperHandlers(t *testing.T) {
+	m, err := ParseTopicMapping(map[string]string{"topicPrefix": "prod."})
+	require.NoError(t, err)
+
+	var received *NewMessage
+	handler := m.Handler("orders", func(ctx context.Context, msg *NewMessage) error {
+		received = msg
+		return nil
+	})
+	msg := &NewMessage{Topic: "prod.orders", Data: []byte("data")}
+	require.NoError(t, handler(context.Background(), msg))
+	assert.Equal(t, "orders", received.Topic)
+	assert.Equal(t, []byte("data"), received.Data)
+	// The message of the component is unchanged
+	assert.Equal(t, "prod.orders", msg.Topic)
+
+	var receivedBulk *BulkMessage
+	bulkHandler := m.BulkHandler("orders", func(ctx context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error) {
+		receivedBulk = msg
+		return nil, nil
+	})
+	_, err = bulkHandler(context.Background(), &BulkMessage{Topic: "prod.orders"})
+	require.NoError(t, err)
+	assert.Equal(t, "orders", receivedBulk.Topic)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"strings"
+)
+
+// TraceContextFromMetadata returns the W3C Trace Context (the "traceparent" and "tracestate" keys) found in the metadata,
+// so components can propagate it in the properties of the messages sent to brokers that don't carry CloudEvents envelopes.
+// When multiple maps are passed, such as the metadata of a bulk publish request and of one of its entries, the later ones take precedence.
+// The "tracestate" is only propagated together with a valid "traceparent"; the result is nil if there's no trace context.
+func TraceContextFromMetadata(metadatas ...map[string]string) map[string]string {
+	var traceParent, traceState string
+	for _, md := range metadatas {
+		if tp := md[TraceParentField]; isValidTraceParent(tp) {
+			traceParent = tp
+			traceState = md[TraceStateField]
+		}
+	}
+	if traceParent == "" {
+		return nil
+	}
+
+	res := map[string]string{TraceParentField: traceParent}
+	if traceState != "" {
+		res[TraceStateField] = traceState
+	}
+	return res
+}
+
+// AddTraceContextToMetadata adds the W3C Trace Context read with get from the properties of a message received from a broker
+// to the metadata of the message, allocating the map if needed. Existing keys in the metadata are not overwritten.
+func AddTraceContextToMetadata(metadata map[string]string, get func(key string) string) map[string]string {
+	traceParent := get(TraceParentField)
+	if !isValidTraceParent(traceParent) {
+		return metadata
+	}
+
+	if metadata == nil {
+		metadata = make(map[string]string, 2)
+	}
+	if _, ok := metadata[TraceParentField]; ok {
+		return metadata
+	}
+	metadata[TraceParentField] = traceParent
+	if traceState := get(TraceStateField); traceState != "" {
+		metadata[TraceStateField] = traceState
+	}
+	return metadata
+}
+
+// isValidTraceParent returns true if the value is a "traceparent" in the format defined by the W3C Trace Context specification:
+// version "-" trace-id "-" parent-id "-" trace-flags, with lowercase hex fields and non-zero IDs.
+func isValidTraceParent(val string) bool {
+	parts := strings.Split(val, "-")
+	if len(parts) < 4 {
+		return false
+	}
+	version, traceID, parentID, flags := parts[0], parts[1], parts[2], parts[3]
+	// Future versions may append fields, but version 00 has exactly 4
+	if (version == "00" && len(parts) != 4) || version == "ff" {
+		return false
+	}
+	return isLowerHex(version, 2) &&
+		isLowerHex(traceID, 32) && strings.Trim(traceID, "0") != "" &&
+		isLowerHex(parentID, 16) && strings.Trim(parentID, "0") != "" &&
+		isLowerHex(flags, 2)
+}
+
+func isLowerHex(val string, length int) bool {
+	if len(val) != length {
+		return false
+	}
+	for i := 0; i < len(val); i++ {
+		c := val[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
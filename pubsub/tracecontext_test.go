@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testTraceParent = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	testTraceState  = "congo=t61rcWkgMzE"
+)
+
+func TestTraceContextFromMetadata(t *testing.T) {
+	t.Run("no trace context", func(t *testing.T) {
+		assert.Nil(t, TraceContextFromMetadata(map[string]string{"foo": "bar"}, nil))
+	})
+
+	t.Run("traceparent and tracestate", func(t *testing.T) {
+		tc := TraceContextFromMetadata(map[string]string{
+			TraceParentField: testTraceParent,
+			TraceStateField:  testTraceState,
+			"foo":            "bar",
+		})
+		assert.Equal(t, map[string]string{
+			TraceParentField: testTraceParent,
+			TraceStateField:  testTraceState,
+		}, tc)
+	})
+
+	t.Run("later maps take precedence", func(t *testing.T) {
+		entryTraceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"
+		tc := TraceContextFromMetadata(
+			map[string]string{TraceParentField: testTraceParent, TraceStateField: testTraceState},
+			map[string]string{TraceParentField: entryTraceParent},
+		)
+		assert.Equal(t, map[string]string{TraceParentField: entryTraceParent}, tc)
+
+		// An invalid traceparent in a later map is ignored
+		tc = TraceContextFromMetadata(
+			map[string]string{TraceParentField: testTraceParent},
+			map[string]string{TraceParentField: "foo"},
+		)
+		assert.Equal(t, map[string]string{TraceParentField: testTraceParent}, tc)
+	})
+
+	t.Run("tracestate without traceparent", func(t *testing.T) {
+		assert.Nil(t, TraceContextFromMetadata(map[string]string{TraceStateField: testTraceState}))
+	})
+}
+
+func TestAddTraceContextToMetadata(t *testing.T) {
+	props := map[string]string{
+		TraceParentField: testTraceParent,
+		TraceStateField:  testTraceState,
+	}
+	get := func(key string) string {
+		return props[key]
+	}
+
+	md := AddTraceContextToMetadata(nil, get)
+	assert.Equal(t, props, md)
+
+	md = AddTraceContextToMetadata(map[string]string{"foo": "bar"}, get)
+	assert.Equal(t, map[string]string{
+		"foo":            "bar",
+		TraceParentField: testTraceParent,
+		TraceStateField:  testTraceState,
+	}, md)
+
+	// Existing values are not overwritten
+	md = AddTraceContextToMetadata(map[string]string{TraceParentField: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"}, get)
+	assert.Equal(t, map[string]string{TraceParentField: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"}, md)
+
+	// Invalid traceparent
+	md = AddTraceContextToMetadata(nil, func(string) string { return "foo" })
+	assert.Nil(t, md)
+}
+
+func TestIsValidTraceParent(t *testing.T) {
+	valid := []string{
+		testTraceParent,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+		// Future versions may have additional fields
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-foo",
+	}
+	for _, v := range valid {
+		assert.True(t, isValidTraceParent(v), v)
+	}
+
+	invalid := []string{
+		"",
+		"foo",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-foo",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-00",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-00",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-00",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-00",
+	}
+	for _, v := range invalid {
+		assert.False(t, isValidTraceParent(v), v)
+	}
+}
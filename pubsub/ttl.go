@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"strconv"
+	"time"
+
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+)
+
+// EnqueueTimeKey is the name of the property of the messages that contains the time they were published, in Unix milliseconds.
+// Components of brokers that don't support a TTL per message send it with the TTL of the message, and drop the expired messages when they are delivered.
+const EnqueueTimeKey = "dapr-enqueuetime"
+
+// MessageTTLMetadata returns the properties a message with a TTL must be sent with so that its TTL can be enforced when it's delivered: the TTL and the time the message is published.
+// It returns nil if the metadata of the message doesn't set a TTL.
+func MessageTTLMetadata(metadata map[string]string, now time.Time) (map[string]string, error) {
+	ttl, ok, err := contribMetadata.TryGetTTL(metadata)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return map[string]string{
+		contribMetadata.TTLMetadataKey: strconv.FormatInt(int64(ttl/time.Second), 10),
+		EnqueueTimeKey:                 strconv.FormatInt(now.UnixMilli(), 10),
+	}, nil
+}
+
+// WithMessageTTLMetadata returns a copy of the metadata of a message with the properties returned by MessageTTLMetadata.
+// The metadata is returned as is if it doesn't set a TTL.
+func WithMessageTTLMetadata(metadata map[string]string, now time.Time) (map[string]string, error) {
+	ttlMetadata, err := MessageTTLMetadata(metadata, now)
+	if err != nil || ttlMetadata == nil {
+		return metadata, err
+	}
+	res := make(map[string]string, len(metadata)+len(ttlMetadata))
+	for k, v := range metadata {
+		res[k] = v
+	}
+	for k, v := range ttlMetadata {
+		res[k] = v
+	}
+	return res, nil
+}
+
+// IsMessageExpired returns true if the TTL of a message, sent with the properties returned by MessageTTLMetadata, has elapsed.
+// Messages without a TTL or an enqueue time never expire.
+func IsMessageExpired(metadata map[string]string, now time.Time) bool {
+	ttl, ok, err := contribMetadata.TryGetTTL(metadata)
+	if err != nil || !ok {
+		return false
+	}
+	enqueueTime, err := strconv.ParseInt(metadata[EnqueueTimeKey], 10, 64)
+	if err != nil {
+		return false
+	}
+	return now.After(time.UnixMilli(enqueueTime).Add(ttl))
+}
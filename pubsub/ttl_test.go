@@ -0,0 +1,63 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageTTL(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+
+	t.Run("no ttl", func(t *testing.T) {
+		md, err := MessageTTLMetadata(map[string]string{"a": "b"}, now)
+		require.NoError(t, err)
+		assert.Nil(t, md)
+
+		in := map[string]string{"a": "b"}
+		out, err := WithMessageTTLMetadata(in, now)
+		require.NoError(t, err)
+		assert.Equal(t, in, out)
+		assert.False(t, IsMessageExpired(out, now.Add(time.Hour)))
+	})
+
+	t.Run("invalid ttl", func(t *testing.T) {
+		_, err := MessageTTLMetadata(map[string]string{"ttlInSeconds": "abc"}, now)
+		assert.Error(t, err)
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		in := map[string]string{"a": "b", "ttlInSeconds": "10"}
+		out, err := WithMessageTTLMetadata(in, now)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"a":            "b",
+			"ttlInSeconds": "10",
+			EnqueueTimeKey: "1700000000000",
+		}, out)
+		assert.NotContains(t, in, EnqueueTimeKey)
+
+		assert.False(t, IsMessageExpired(out, now))
+		assert.False(t, IsMessageExpired(out, now.Add(10*time.Second)))
+		assert.True(t, IsMessageExpired(out, now.Add(11*time.Second)))
+	})
+
+	t.Run("missing enqueue time", func(t *testing.T) {
+		assert.False(t, IsMessageExpired(map[string]string{"ttlInSeconds": "10"}, now))
+	})
+}
@@ -0,0 +1,332 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	contribContenttype "github.com/dapr/components-contrib/contenttype"
+)
+
+const (
+	// ValidationSchemaSuffix is the suffix of the component metadata keys holding the JSON Schema for a topic.
+	// For example, "orders.validationSchema" configures the schema used for the "orders" topic.
+	// Like the suffix, topic names in the keys are case-insensitive.
+	ValidationSchemaSuffix = ".validationschema"
+	// ValidationDeadLetterTopicKey is the component metadata key of the topic where received messages that fail validation are forwarded to.
+	ValidationDeadLetterTopicKey = "validationDeadLetterTopic"
+	// ValidationErrorMetadataKey is the metadata key set on dead-lettered messages with the reason of the validation failure.
+	ValidationErrorMetadataKey = "validationError"
+)
+
+// ErrSchemaValidation is returned when a message does not match the JSON Schema configured for its topic.
+var ErrSchemaValidation = errors.New("message does not match the schema configured for the topic")
+
+// SchemaValidator validates message payloads against JSON Schemas configured per topic.
+type SchemaValidator struct {
+	schemas         map[string]*gojsonschema.Schema
+	deadLetterTopic string
+}
+
+// NewSchemaValidator returns a SchemaValidator configured from the component metadata properties.
+// Topics without a schema are never validated.
+func NewSchemaValidator(props map[string]string) (*SchemaValidator, error) {
+	v := &SchemaValidator{
+		schemas: map[string]*gojsonschema.Schema{},
+	}
+	for key, value := range props {
+		if strings.EqualFold(key, ValidationDeadLetterTopicKey) {
+			v.deadLetterTopic = value
+			continue
+		}
+		if len(key) <= len(ValidationSchemaSuffix) || !strings.HasSuffix(strings.ToLower(key), ValidationSchemaSuffix) {
+			continue
+		}
+		// Metadata keys are case-insensitive, so topics are matched case-insensitively too
+		topic := strings.ToLower(key[:len(key)-len(ValidationSchemaSuffix)])
+		schema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON schema for topic %s: %w", topic, err)
+		}
+		v.schemas[topic] = schema
+	}
+
+	return v, nil
+}
+
+// Enabled returns true if at least one topic has a schema configured.
+func (v *SchemaValidator) Enabled() bool {
+	return v != nil && len(v.schemas) > 0
+}
+
+// DeadLetterTopic returns the topic where invalid received messages are forwarded to, if any.
+func (v *SchemaValidator) DeadLetterTopic() string {
+	return v.deadLetterTopic
+}
+
+// Validate checks the payload against the schema of the topic, which is matched case-insensitively.
+// If the payload is a CloudEvent, the "data" field is validated instead of the envelope.
+func (v *SchemaValidator) Validate(topic string, data []byte, contentType *string) error {
+	if v == nil {
+		return nil
+	}
+	schema, ok := v.schemas[strings.ToLower(topic)]
+	if !ok {
+		return nil
+	}
+
+	payload, err := validationPayload(data, contentType)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSchemaValidation, err)
+	}
+	res, err := schema.Validate(gojsonschema.NewBytesLoader(payload))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSchemaValidation, err)
+	}
+	if !res.Valid() {
+		msgs := make([]string, len(res.Errors()))
+		for i, e := range res.Errors() {
+			msgs[i] = e.String()
+		}
+		return fmt.Errorf("%w: %s", ErrSchemaValidation, strings.Join(msgs, "; "))
+	}
+
+	return nil
+}
+
+// ValidateBulkPublish validates all entries of a bulk publish request.
+// It returns the entries that passed validation and a response listing the ones that failed.
+func (v *SchemaValidator) ValidateBulkPublish(req *BulkPublishRequest) ([]BulkMessageEntry, BulkPublishResponse) {
+	res := BulkPublishResponse{}
+	valid := make([]BulkMessageEntry, 0, len(req.Entries))
+	for _, entry := range req.Entries {
+		ct := entry.ContentType
+		err := v.Validate(req.Topic, entry.Event, &ct)
+		if err != nil {
+			res.FailedEntries = append(res.FailedEntries, BulkPublishResponseFailedEntry{
+				EntryId: entry.EntryId,
+				Error:   err,
+			})
+			continue
+		}
+		valid = append(valid, entry)
+	}
+
+	return valid, res
+}
+
+// validationPayload returns the part of the message that is validated against the schema.
+func validationPayload(data []byte, contentType *string) ([]byte, error) {
+	isCloudEvent := contentType != nil && contribContenttype.IsCloudEventContentType(*contentType)
+	if !isCloudEvent && (contentType == nil || *contentType == "" || contribContenttype.IsJSONContentType(*contentType)) {
+		// Messages published by Dapr are wrapped in a CloudEvent even when the content type is not set explicitly
+		var probe map[string]json.RawMessage
+		if json.Unmarshal(data, &probe) == nil {
+			_, hasSpec := probe[SpecVersionField]
+			_, hasData := probe[DataField]
+			isCloudEvent = hasSpec && hasData
+		}
+	}
+	if !isCloudEvent {
+		return data, nil
+	}
+
+	var ce map[string]json.RawMessage
+	err := json.Unmarshal(data, &ce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cloud event: %w", err)
+	}
+	ceData, ok := ce[DataField]
+	if !ok {
+		return nil, errors.New("cloud event does not contain a JSON data field")
+	}
+
+	return ceData, nil
+}
+
+// validatingPubSub is a PubSub decorator that validates messages against the configured schemas.
+type validatingPubSub struct {
+	PubSub
+	validator *SchemaValidator
+}
+
+// validatingBulkPubSub adds validation to the bulk operations of the wrapped PubSub.
+type validatingBulkPubSub struct {
+	*validatingPubSub
+}
+
+// WithSchemaValidation wraps a PubSub so that published messages that don't match the schema of their topic
+// are rejected before reaching the broker, and received messages that don't match are either forwarded
+// to the configured dead-letter topic or returned to the broker as failed.
+// Schemas are configured in the component metadata using keys in the format "<topic>.validationSchema".
+// The returned PubSub implements BulkPublisher and BulkSubscriber only if the wrapped one does; use As to get
+// the other optional interfaces of the wrapped PubSub.
+func WithSchemaValidation(ps PubSub) PubSub {
+	p := &validatingPubSub{PubSub: ps}
+	bulk := validatingBulkPubSub{p}
+	_, isBulkPublisher := ps.(BulkPublisher)
+	_, isBulkSubscriber := ps.(BulkSubscriber)
+	switch {
+	case isBulkPublisher && isBulkSubscriber:
+		return &struct {
+			*validatingPubSub
+			BulkPublisher
+			BulkSubscriber
+		}{p, bulk, bulk}
+	case isBulkPublisher:
+		return &struct {
+			*validatingPubSub
+			BulkPublisher
+		}{p, bulk}
+	case isBulkSubscriber:
+		return &struct {
+			*validatingPubSub
+			BulkSubscriber
+		}{p, bulk}
+	default:
+		return p
+	}
+}
+
+// Unwrap implements Unwrapper.
+func (p *validatingPubSub) Unwrap() PubSub {
+	return p.PubSub
+}
+
+func (p *validatingPubSub) Init(ctx context.Context, metadata Metadata) (err error) {
+	p.validator, err = NewSchemaValidator(metadata.Properties)
+	if err != nil {
+		return err
+	}
+
+	return p.PubSub.Init(ctx, metadata)
+}
+
+func (p *validatingPubSub) Publish(ctx context.Context, req *PublishRequest) error {
+	err := p.validator.Validate(req.Topic, req.Data, req.ContentType)
+	if err != nil {
+		return err
+	}
+
+	return p.PubSub.Publish(ctx, req)
+}
+
+func (p *validatingPubSub) Subscribe(ctx context.Context, req SubscribeRequest, handler Handler) error {
+	if !p.validator.Enabled() {
+		return p.PubSub.Subscribe(ctx, req, handler)
+	}
+
+	return p.PubSub.Subscribe(ctx, req, func(ctx context.Context, msg *NewMessage) error {
+		err := p.validator.Validate(msg.Topic, msg.Data, msg.ContentType)
+		if err == nil {
+			return handler(ctx, msg)
+		}
+
+		return p.deadLetter(ctx, msg.Topic, msg.Data, msg.ContentType, msg.Metadata, err)
+	})
+}
+
+// deadLetter forwards a received message that failed validation to the dead-letter topic.
+// If no dead-letter topic is configured, the validation error is returned so the message is reported as failed.
+func (p *validatingPubSub) deadLetter(ctx context.Context, topic string, data []byte, contentType *string, metadata map[string]string, validationErr error) error {
+	dlt := p.validator.DeadLetterTopic()
+	if dlt == "" || dlt == topic {
+		return validationErr
+	}
+	md := make(map[string]string, len(metadata)+1)
+	for k, v := range metadata {
+		md[k] = v
+	}
+	md[ValidationErrorMetadataKey] = validationErr.Error()
+	err := p.PubSub.Publish(ctx, &PublishRequest{
+		Data:        data,
+		Topic:       dlt,
+		Metadata:    md,
+		ContentType: contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to forward invalid message to dead-letter topic %s: %w", dlt, err)
+	}
+
+	return nil
+}
+
+func (p validatingBulkPubSub) BulkPublish(ctx context.Context, req *BulkPublishRequest) (BulkPublishResponse, error) {
+	bulkPublisher := p.PubSub.(BulkPublisher)
+	if !p.validator.Enabled() {
+		return bulkPublisher.BulkPublish(ctx, req)
+	}
+
+	valid, res := p.validator.ValidateBulkPublish(req)
+	if len(valid) > 0 {
+		validReq := *req
+		validReq.Entries = valid
+		pubRes, err := bulkPublisher.BulkPublish(ctx, &validReq)
+		res.FailedEntries = append(res.FailedEntries, pubRes.FailedEntries...)
+		if err != nil {
+			return res, err
+		}
+	}
+	if len(res.FailedEntries) > 0 {
+		return res, fmt.Errorf("%w: %d of %d entries failed validation", ErrSchemaValidation, len(req.Entries)-len(valid), len(req.Entries))
+	}
+
+	return res, nil
+}
+
+func (p validatingBulkPubSub) BulkSubscribe(ctx context.Context, req SubscribeRequest, handler BulkHandler) error {
+	bulkSubscriber := p.PubSub.(BulkSubscriber)
+	if !p.validator.Enabled() {
+		return bulkSubscriber.BulkSubscribe(ctx, req, handler)
+	}
+
+	return bulkSubscriber.BulkSubscribe(ctx, req, func(ctx context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error) {
+		statuses := make([]BulkSubscribeResponseEntry, 0, len(msg.Entries))
+		valid := make([]BulkMessageEntry, 0, len(msg.Entries))
+		var failedErr error
+		for _, entry := range msg.Entries {
+			ct := entry.ContentType
+			err := p.validator.Validate(msg.Topic, entry.Event, &ct)
+			if err == nil {
+				valid = append(valid, entry)
+				continue
+			}
+			err = p.deadLetter(ctx, msg.Topic, entry.Event, &ct, entry.Metadata, err)
+			if err != nil {
+				failedErr = err
+			}
+			statuses = append(statuses, BulkSubscribeResponseEntry{
+				EntryId: entry.EntryId,
+				Error:   err,
+			})
+		}
+		if len(valid) > 0 {
+			validMsg := *msg
+			validMsg.Entries = valid
+			handlerStatuses, err := handler(ctx, &validMsg)
+			statuses = append(statuses, handlerStatuses...)
+			if err != nil {
+				return statuses, err
+			}
+		}
+
+		return statuses, failedErr
+	})
+}
@@ -0,0 +1,279 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+)
+
+const testOrderSchema = `{
+	"type": "object",
+	"properties": {"id": {"type": "integer"}},
+	"required": ["id"]
+}`
+
+type fakePubSub struct {
+	published []*PublishRequest
+	handler   Handler
+}
+
+func (f *fakePubSub) Init(context.Context, Metadata) error { return nil }
+func (f *fakePubSub) Features() []Feature                  { return nil }
+func (f *fakePubSub) Close() error                         { return nil }
+func (f *fakePubSub) GetComponentMetadata() map[string]string {
+	return nil
+}
+
+func (f *fakePubSub) Publish(_ context.Context, req *PublishRequest) error {
+	f.published = append(f.published, req)
+	return nil
+}
+
+func (f *fakePubSub) Subscribe(_ context.Context, _ SubscribeRequest, handler Handler) error {
+	f.handler = handler
+	return nil
+}
+
+type fakeBulkPubSub struct {
+	fakePubSub
+	bulkPublished []*BulkPublishRequest
+	bulkHandler   BulkHandler
+}
+
+func (f *fakeBulkPubSub) BulkPublish(_ context.Context, req *BulkPublishRequest) (BulkPublishResponse, error) {
+	f.bulkPublished = append(f.bulkPublished, req)
+	return BulkPublishResponse{}, nil
+}
+
+func (f *fakeBulkPubSub) BulkSubscribe(_ context.Context, _ SubscribeRequest, handler BulkHandler) error {
+	f.bulkHandler = handler
+	return nil
+}
+
+func TestSchemaValidator(t *testing.T) {
+	v, err := NewSchemaValidator(map[string]string{
+		"orders.validationSchema": testOrderSchema,
+	})
+	require.NoError(t, err)
+	require.True(t, v.Enabled())
+
+	t.Run("valid raw payload", func(t *testing.T) {
+		assert.NoError(t, v.Validate("orders", []byte(`{"id":1}`), nil))
+	})
+
+	t.Run("invalid raw payload", func(t *testing.T) {
+		err := v.Validate("orders", []byte(`{"id":"a"}`), nil)
+		assert.ErrorIs(t, err, ErrSchemaValidation)
+	})
+
+	t.Run("cloud event data is validated", func(t *testing.T) {
+		ct := "application/cloudevents+json"
+		assert.NoError(t, v.Validate("orders", []byte(`{"specversion":"1.0","data":{"id":1}}`), &ct))
+		assert.ErrorIs(t, v.Validate("orders", []byte(`{"specversion":"1.0","data":{}}`), &ct), ErrSchemaValidation)
+	})
+
+	t.Run("topic without schema", func(t *testing.T) {
+		assert.NoError(t, v.Validate("other", []byte(`not json`), nil))
+	})
+
+	t.Run("topics are case-insensitive", func(t *testing.T) {
+		v, err := NewSchemaValidator(map[string]string{
+			"Orders.ValidationSchema": testOrderSchema,
+		})
+		require.NoError(t, err)
+		assert.ErrorIs(t, v.Validate("orders", []byte(`{}`), nil), ErrSchemaValidation)
+		assert.ErrorIs(t, v.Validate("ORDERS", []byte(`{}`), nil), ErrSchemaValidation)
+	})
+
+	t.Run("bulk publish", func(t *testing.T) {
+		valid, res := v.ValidateBulkPublish(&BulkPublishRequest{
+			Topic: "orders",
+			Entries: []BulkMessageEntry{
+				{EntryId: "1", Event: []byte(`{"id":1}`), ContentType: "application/json"},
+				{EntryId: "2", Event: []byte(`{}`), ContentType: "application/json"},
+			},
+		})
+		require.Len(t, valid, 1)
+		assert.Equal(t, "1", valid[0].EntryId)
+		require.Len(t, res.FailedEntries, 1)
+		assert.Equal(t, "2", res.FailedEntries[0].EntryId)
+	})
+
+	t.Run("invalid schema", func(t *testing.T) {
+		_, err := NewSchemaValidator(map[string]string{"orders.validationSchema": `{"type": 1}`})
+		assert.Error(t, err)
+	})
+}
+
+func TestWithSchemaValidation(t *testing.T) {
+	newPubSub := func(t *testing.T, props map[string]string) (*fakePubSub, PubSub) {
+		inner := &fakePubSub{}
+		ps := WithSchemaValidation(inner)
+		err := ps.Init(context.Background(), Metadata{Base: metadata.Base{Properties: props}})
+		require.NoError(t, err)
+		return inner, ps
+	}
+
+	t.Run("publish rejects invalid messages", func(t *testing.T) {
+		inner, ps := newPubSub(t, map[string]string{"orders.validationSchema": testOrderSchema})
+
+		err := ps.Publish(context.Background(), &PublishRequest{Topic: "orders", Data: []byte(`{}`)})
+		assert.ErrorIs(t, err, ErrSchemaValidation)
+		err = ps.Publish(context.Background(), &PublishRequest{Topic: "orders", Data: []byte(`{"id":1}`)})
+		assert.NoError(t, err)
+		assert.Len(t, inner.published, 1)
+	})
+
+	t.Run("subscribe returns error without dead-letter topic", func(t *testing.T) {
+		inner, ps := newPubSub(t, map[string]string{"orders.validationSchema": testOrderSchema})
+
+		called := false
+		require.NoError(t, ps.Subscribe(context.Background(), SubscribeRequest{Topic: "orders"}, func(context.Context, *NewMessage) error {
+			called = true
+			return nil
+		}))
+		err := inner.handler(context.Background(), &NewMessage{Topic: "orders", Data: []byte(`{}`)})
+		assert.ErrorIs(t, err, ErrSchemaValidation)
+		assert.False(t, called)
+	})
+
+	t.Run("subscribe forwards invalid messages to dead-letter topic", func(t *testing.T) {
+		inner, ps := newPubSub(t, map[string]string{
+			"orders.validationSchema":    testOrderSchema,
+			ValidationDeadLetterTopicKey: "poison",
+		})
+
+		called := 0
+		require.NoError(t, ps.Subscribe(context.Background(), SubscribeRequest{Topic: "orders"}, func(context.Context, *NewMessage) error {
+			called++
+			return nil
+		}))
+		require.NoError(t, inner.handler(context.Background(), &NewMessage{Topic: "orders", Data: []byte(`{}`)}))
+		require.NoError(t, inner.handler(context.Background(), &NewMessage{Topic: "orders", Data: []byte(`{"id":2}`)}))
+		assert.Equal(t, 1, called)
+		require.Len(t, inner.published, 1)
+		assert.Equal(t, "poison", inner.published[0].Topic)
+		assert.NotEmpty(t, inner.published[0].Metadata[ValidationErrorMetadataKey])
+	})
+}
+
+func TestWithSchemaValidationBulk(t *testing.T) {
+	newPubSub := func(t *testing.T, props map[string]string) (*fakeBulkPubSub, PubSub) {
+		inner := &fakeBulkPubSub{}
+		ps := WithSchemaValidation(inner)
+		err := ps.Init(context.Background(), Metadata{Base: metadata.Base{Properties: props}})
+		require.NoError(t, err)
+		return inner, ps
+	}
+
+	t.Run("bulk interfaces follow the wrapped pubsub", func(t *testing.T) {
+		_, ps := newPubSub(t, nil)
+		assert.Implements(t, (*BulkPublisher)(nil), ps)
+		assert.Implements(t, (*BulkSubscriber)(nil), ps)
+
+		ps = WithSchemaValidation(&fakePubSub{})
+		_, ok := ps.(BulkPublisher)
+		assert.False(t, ok)
+		_, ok = ps.(BulkSubscriber)
+		assert.False(t, ok)
+	})
+
+	t.Run("wrapped pubsub is unwrapped by As", func(t *testing.T) {
+		inner, ps := newPubSub(t, nil)
+		unwrapped, ok := As[*fakeBulkPubSub](ps)
+		require.True(t, ok)
+		assert.Same(t, inner, unwrapped)
+		_, ok = As[Replayer](ps)
+		assert.False(t, ok)
+	})
+
+	t.Run("bulk publish rejects invalid entries", func(t *testing.T) {
+		inner, ps := newPubSub(t, map[string]string{"orders.validationSchema": testOrderSchema})
+
+		res, err := ps.(BulkPublisher).BulkPublish(context.Background(), &BulkPublishRequest{
+			Topic: "orders",
+			Entries: []BulkMessageEntry{
+				{EntryId: "1", Event: []byte(`{"id":1}`), ContentType: "application/json"},
+				{EntryId: "2", Event: []byte(`{}`), ContentType: "application/json"},
+			},
+		})
+		require.ErrorIs(t, err, ErrSchemaValidation)
+		require.Len(t, res.FailedEntries, 1)
+		assert.Equal(t, "2", res.FailedEntries[0].EntryId)
+		require.Len(t, inner.bulkPublished, 1)
+		require.Len(t, inner.bulkPublished[0].Entries, 1)
+		assert.Equal(t, "1", inner.bulkPublished[0].Entries[0].EntryId)
+	})
+
+	t.Run("bulk subscribe returns invalid entries as failed", func(t *testing.T) {
+		inner, ps := newPubSub(t, map[string]string{"orders.validationSchema": testOrderSchema})
+
+		var received []BulkMessageEntry
+		require.NoError(t, ps.(BulkSubscriber).BulkSubscribe(context.Background(), SubscribeRequest{Topic: "orders"}, func(_ context.Context, msg *BulkMessage) ([]BulkSubscribeResponseEntry, error) {
+			received = append(received, msg.Entries...)
+			statuses := make([]BulkSubscribeResponseEntry, len(msg.Entries))
+			for i, e := range msg.Entries {
+				statuses[i] = BulkSubscribeResponseEntry{EntryId: e.EntryId}
+			}
+			return statuses, nil
+		}))
+		statuses, err := inner.bulkHandler(context.Background(), &BulkMessage{
+			Topic: "orders",
+			Entries: []BulkMessageEntry{
+				{EntryId: "1", Event: []byte(`{"id":1}`)},
+				{EntryId: "2", Event: []byte(`{}`)},
+			},
+		})
+		require.ErrorIs(t, err, ErrSchemaValidation)
+		require.Len(t, received, 1)
+		assert.Equal(t, "1", received[0].EntryId)
+		require.Len(t, statuses, 2)
+		for _, s := range statuses {
+			if s.EntryId == "2" {
+				assert.ErrorIs(t, s.Error, ErrSchemaValidation)
+			} else {
+				assert.NoError(t, s.Error)
+			}
+		}
+	})
+
+	t.Run("bulk subscribe forwards invalid entries to dead-letter topic", func(t *testing.T) {
+		inner, ps := newPubSub(t, map[string]string{
+			"orders.validationSchema":    testOrderSchema,
+			ValidationDeadLetterTopicKey: "poison",
+		})
+
+		called := false
+		require.NoError(t, ps.(BulkSubscriber).BulkSubscribe(context.Background(), SubscribeRequest{Topic: "orders"}, func(context.Context, *BulkMessage) ([]BulkSubscribeResponseEntry, error) {
+			called = true
+			return nil, nil
+		}))
+		statuses, err := inner.bulkHandler(context.Background(), &BulkMessage{
+			Topic:   "orders",
+			Entries: []BulkMessageEntry{{EntryId: "1", Event: []byte(`{}`)}},
+		})
+		require.NoError(t, err)
+		assert.False(t, called)
+		require.Len(t, statuses, 1)
+		assert.NoError(t, statuses[0].Error)
+		require.Len(t, inner.published, 1)
+		assert.Equal(t, "poison", inner.published[0].Topic)
+	})
+}
@@ -0,0 +1,446 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package akeyless
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// SecretType is the request metadata key for the type of the secret: "static" or "dynamic".
+	// If empty, the type is looked up in Akeyless.
+	SecretType = "secretType"
+
+	secretTypeStatic  = "static"
+	secretTypeDynamic = "dynamic"
+
+	itemTypeStaticSecret  = "STATIC_SECRET"
+	itemTypeDynamicSecret = "DYNAMIC_SECRET"
+
+	// Tokens are renewed after this interval, before they expire
+	tokenRefreshInterval = 10 * time.Minute
+	requestTimeout       = 30 * time.Second
+)
+
+var _ secretstores.SecretStore = (*akeylessSecretStore)(nil)
+
+// ErrNotFound is returned when the requested secret does not exist.
+var ErrNotFound = errors.New("secret not found")
+
+type akeylessSecretStore struct {
+	client   *http.Client
+	metadata akeylessMetadata
+	logger   logger.Logger
+
+	// Function that returns the payload of the authentication request; can be replaced in tests
+	authPayload func() (map[string]any, error)
+
+	tokenLock   sync.Mutex
+	token       string
+	tokenIssued time.Time
+}
+
+// NewAkeylessSecretStore returns a new Akeyless secret store.
+func NewAkeylessSecretStore(logger logger.Logger) secretstores.SecretStore {
+	s := &akeylessSecretStore{
+		client: &http.Client{
+			Timeout: requestTimeout,
+		},
+		logger: logger,
+	}
+	s.authPayload = s.getAuthPayload
+	return s
+}
+
+// Init validates the metadata and authenticates with Akeyless.
+func (s *akeylessSecretStore) Init(ctx context.Context, meta secretstores.Metadata) error {
+	m, err := parseMetadata(meta)
+	if err != nil {
+		return fmt.Errorf("akeyless: invalid metadata: %w", err)
+	}
+	s.metadata = m
+
+	// Authenticate to validate the credentials
+	_, err = s.getToken(ctx, false)
+	if err != nil {
+		return fmt.Errorf("akeyless: %w", err)
+	}
+
+	return nil
+}
+
+// GetSecret retrieves a static or dynamic secret.
+// For static secrets, the response contains a single key with the name of the secret; for dynamic secrets, it contains all values returned by Akeyless.
+func (s *akeylessSecretStore) GetSecret(ctx context.Context, req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
+	secretType := req.Metadata[SecretType]
+	if secretType == "" {
+		var err error
+		secretType, err = s.getSecretType(ctx, req.Name)
+		if err != nil {
+			return secretstores.GetSecretResponse{}, fmt.Errorf("akeyless: failed to get secret %s: %w", req.Name, err)
+		}
+	}
+
+	var (
+		data map[string]string
+		err  error
+	)
+	switch secretType {
+	case secretTypeStatic:
+		data, err = s.getStaticSecrets(ctx, []string{req.Name})
+	case secretTypeDynamic:
+		data, err = s.getDynamicSecret(ctx, req.Name)
+	default:
+		err = fmt.Errorf("unsupported secret type '%s'", secretType)
+	}
+	if err != nil {
+		return secretstores.GetSecretResponse{}, fmt.Errorf("akeyless: failed to get secret %s: %w", req.Name, err)
+	}
+
+	return secretstores.GetSecretResponse{
+		Data: data,
+	}, nil
+}
+
+// BulkGetSecret retrieves all static secrets under the configured path.
+// Dynamic secrets are not included, as retrieving them generates new credentials.
+func (s *akeylessSecretStore) BulkGetSecret(ctx context.Context, req secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
+	filter, err := req.GetFilter()
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{}, err
+	}
+
+	items, err := s.listStaticSecrets(ctx)
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{}, fmt.Errorf("akeyless: failed to list secrets: %w", err)
+	}
+
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		if filter.Match(item.ItemName, tagsToLabels(item.ItemTags)) {
+			names = append(names, item.ItemName)
+		}
+	}
+	names, nextPageToken, err := filter.Paginate(names)
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{}, err
+	}
+
+	resp := secretstores.BulkGetSecretResponse{
+		Data:          make(map[string]map[string]string, len(names)),
+		NextPageToken: nextPageToken,
+	}
+	if len(names) == 0 {
+		return resp, nil
+	}
+
+	values, err := s.getStaticSecrets(ctx, names)
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{}, fmt.Errorf("akeyless: failed to get secrets: %w", err)
+	}
+	for name, value := range values {
+		resp.Data[name] = map[string]string{name: value}
+	}
+
+	return resp, nil
+}
+
+// Features returns the features available in this secret store.
+func (s *akeylessSecretStore) Features() []secretstores.Feature {
+	return []secretstores.Feature{
+		secretstores.FeatureMultipleKeyValuesPerSecret,
+		secretstores.FeatureBulkGetFilter,
+	}
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (s *akeylessSecretStore) GetComponentMetadata() map[string]string {
+	metadataStruct := akeylessMetadata{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.SecretStoreType)
+	return metadataInfo
+}
+
+type akeylessItem struct {
+	ItemName string   `json:"item_name"`
+	ItemType string   `json:"item_type"`
+	ItemTags []string `json:"item_tags"`
+}
+
+func (s *akeylessSecretStore) getSecretType(ctx context.Context, name string) (string, error) {
+	var item akeylessItem
+	err := s.doRequest(ctx, "/describe-item", map[string]any{"name": name}, &item)
+	if err != nil {
+		return "", err
+	}
+
+	switch item.ItemType {
+	case itemTypeStaticSecret:
+		return secretTypeStatic, nil
+	case itemTypeDynamicSecret:
+		return secretTypeDynamic, nil
+	default:
+		return "", fmt.Errorf("item has unsupported type %s", item.ItemType)
+	}
+}
+
+func (s *akeylessSecretStore) getStaticSecrets(ctx context.Context, names []string) (map[string]string, error) {
+	res := map[string]string{}
+	err := s.doRequest(ctx, "/get-secret-value", map[string]any{"names": names}, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *akeylessSecretStore) getDynamicSecret(ctx context.Context, name string) (map[string]string, error) {
+	raw := map[string]any{}
+	err := s.doRequest(ctx, "/get-dynamic-secret-value", map[string]any{"name": name}, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	// Values that are not strings are returned as JSON
+	res := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if str, ok := v.(string); ok {
+			res[k] = str
+			continue
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode value of key %s: %w", k, err)
+		}
+		res[k] = string(b)
+	}
+	return res, nil
+}
+
+func (s *akeylessSecretStore) listStaticSecrets(ctx context.Context) ([]akeylessItem, error) {
+	var items []akeylessItem
+	payload := map[string]any{
+		"path": s.metadata.Path,
+		"type": []string{"static-secret"},
+	}
+	for {
+		var res struct {
+			Items    []akeylessItem `json:"items"`
+			NextPage string         `json:"next_page"`
+		}
+		err := s.doRequest(ctx, "/list-items", payload, &res)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, res.Items...)
+
+		if res.NextPage == "" {
+			return items, nil
+		}
+		payload["pagination-token"] = res.NextPage
+	}
+}
+
+// tagsToLabels converts tags in the "key=value" format to labels. Tags without a "=" are set as labels with an empty value.
+func tagsToLabels(tags []string) map[string]string {
+	labels := make(map[string]string, len(tags))
+	for _, t := range tags {
+		k, v, _ := strings.Cut(t, "=")
+		labels[k] = v
+	}
+	return labels
+}
+
+// doRequest invokes an Akeyless API, adding the token to the payload.
+// If the token is rejected, it authenticates again and retries once.
+func (s *akeylessSecretStore) doRequest(ctx context.Context, path string, payload map[string]any, out any) error {
+	token, err := s.getToken(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	payload["token"] = token
+	status, err := s.post(ctx, path, payload, out)
+	if status == http.StatusUnauthorized {
+		token, err = s.getToken(ctx, true)
+		if err != nil {
+			return err
+		}
+		payload["token"] = token
+		status, err = s.post(ctx, path, payload, out)
+	}
+	if status == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+// getToken returns the token for the Akeyless API, authenticating if there's no valid token or if forceRefresh is set.
+func (s *akeylessSecretStore) getToken(ctx context.Context, forceRefresh bool) (string, error) {
+	s.tokenLock.Lock()
+	defer s.tokenLock.Unlock()
+
+	if !forceRefresh && s.token != "" && time.Since(s.tokenIssued) < tokenRefreshInterval {
+		return s.token, nil
+	}
+
+	payload, err := s.authPayload()
+	if err != nil {
+		return "", fmt.Errorf("failed to build authentication request: %w", err)
+	}
+
+	var res struct {
+		Token string `json:"token"`
+	}
+	_, err = s.post(ctx, "/auth", payload, &res)
+	if err != nil {
+		return "", fmt.Errorf("failed to authenticate: %w", err)
+	}
+	if res.Token == "" {
+		return "", errors.New("failed to authenticate: response did not contain a token")
+	}
+
+	s.token = res.Token
+	s.tokenIssued = time.Now()
+	return s.token, nil
+}
+
+// getAuthPayload returns the payload of the authentication request for the configured authentication method.
+func (s *akeylessSecretStore) getAuthPayload() (map[string]any, error) {
+	payload := map[string]any{
+		"access-id":   s.metadata.AccessID,
+		"access-type": s.metadata.AccessType,
+	}
+
+	switch s.metadata.AccessType {
+	case accessTypeAccessKey:
+		payload["access-key"] = s.metadata.AccessKey
+	case accessTypeAWSIAM:
+		cloudID, err := getAWSCloudID()
+		if err != nil {
+			return nil, err
+		}
+		payload["cloud-id"] = cloudID
+	case accessTypeK8s:
+		token := s.metadata.K8sServiceAccountToken
+		if token == "" {
+			b, err := os.ReadFile(defaultK8sServiceAccountTokenPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read Kubernetes service account token: %w", err)
+			}
+			token = strings.TrimSpace(string(b))
+		}
+		payload["k8s-auth-config-name"] = s.metadata.K8sAuthConfigName
+		payload["k8s-service-account-token"] = base64.StdEncoding.EncodeToString([]byte(token))
+		payload["gateway-url"] = s.metadata.GatewayURL
+	}
+
+	return payload, nil
+}
+
+// getAWSCloudID returns the cloud ID for AWS IAM authentication, which contains a signed STS GetCallerIdentity request that Akeyless uses to verify the identity.
+func getAWSCloudID() (string, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("failed to create AWS session: %w", err)
+	}
+
+	req, _ := sts.New(sess).GetCallerIdentityRequest(nil)
+	err = req.Sign()
+	if err != nil {
+		return "", fmt.Errorf("failed to sign STS request: %w", err)
+	}
+
+	headers, err := json.Marshal(req.HTTPRequest.Header)
+	if err != nil {
+		return "", err
+	}
+	body, err := io.ReadAll(req.HTTPRequest.Body)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(map[string]string{
+		"sts_request_method":  req.HTTPRequest.Method,
+		"sts_request_url":     base64.StdEncoding.EncodeToString([]byte(req.HTTPRequest.URL.String())),
+		"sts_request_body":    base64.StdEncoding.EncodeToString(body),
+		"sts_request_headers": base64.StdEncoding.EncodeToString(headers),
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// post sends a request to the Akeyless API and decodes the JSON response into out.
+// It returns the status code of the response, if any.
+func (s *akeylessSecretStore) post(ctx context.Context, path string, payload any, out any) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.metadata.GatewayURL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return res.StatusCode, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		var errRes struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(resBody, &errRes)
+		if errRes.Error == "" {
+			errRes.Error = string(resBody)
+		}
+		return res.StatusCode, fmt.Errorf("request to %s failed with status code %d: %s", path, res.StatusCode, errRes.Error)
+	}
+
+	err = json.Unmarshal(resBody, out)
+	if err != nil {
+		return res.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return res.StatusCode, nil
+}
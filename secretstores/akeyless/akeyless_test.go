@@ -0,0 +1,258 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package akeyless
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/kit/logger"
+)
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults with access key", func(t *testing.T) {
+		m, err := parseMetadata(secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"accessId":  "p-123",
+			"accessKey": "key",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, defaultGatewayURL, m.GatewayURL)
+		assert.Equal(t, accessTypeAccessKey, m.AccessType)
+		assert.Equal(t, defaultListPath, m.Path)
+	})
+
+	t.Run("missing access ID", func(t *testing.T) {
+		_, err := parseMetadata(secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"accessKey": "key",
+		}}})
+		require.Error(t, err)
+	})
+
+	t.Run("missing access type", func(t *testing.T) {
+		_, err := parseMetadata(secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"accessId": "p-123",
+		}}})
+		require.Error(t, err)
+	})
+
+	t.Run("k8s requires auth config name", func(t *testing.T) {
+		_, err := parseMetadata(secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"accessId":   "p-123",
+			"accessType": "k8s",
+		}}})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid access type", func(t *testing.T) {
+		_, err := parseMetadata(secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"accessId":   "p-123",
+			"accessType": "foo",
+		}}})
+		require.Error(t, err)
+	})
+
+	t.Run("trims gateway URL", func(t *testing.T) {
+		m, err := parseMetadata(secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+			"accessId":   "p-123",
+			"accessType": "aws_iam",
+			"gatewayUrl": "http://gw:8080/",
+		}}})
+		require.NoError(t, err)
+		assert.Equal(t, "http://gw:8080", m.GatewayURL)
+	})
+}
+
+func TestAuthPayload(t *testing.T) {
+	s := NewAkeylessSecretStore(logger.NewLogger("test")).(*akeylessSecretStore)
+	s.metadata = akeylessMetadata{
+		GatewayURL:             "http://gw",
+		AccessID:               "p-123",
+		AccessType:             accessTypeK8s,
+		K8sAuthConfigName:      "cfg",
+		K8sServiceAccountToken: "sa-token",
+	}
+
+	payload, err := s.getAuthPayload()
+	require.NoError(t, err)
+	assert.Equal(t, "p-123", payload["access-id"])
+	assert.Equal(t, "k8s", payload["access-type"])
+	assert.Equal(t, "cfg", payload["k8s-auth-config-name"])
+	assert.Equal(t, "c2EtdG9rZW4=", payload["k8s-service-account-token"])
+	assert.Equal(t, "http://gw", payload["gateway-url"])
+}
+
+// newTestServer returns a server that mocks the Akeyless API.
+func newTestServer(t *testing.T, authCount *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	items := []akeylessItem{
+		{ItemName: "/app/one", ItemType: itemTypeStaticSecret, ItemTags: []string{"env=prod"}},
+		{ItemName: "/app/two", ItemType: itemTypeStaticSecret, ItemTags: []string{"env=dev"}},
+		{ItemName: "/app/three", ItemType: itemTypeStaticSecret, ItemTags: []string{"env=prod"}},
+		{ItemName: "/app/db", ItemType: itemTypeDynamicSecret},
+	}
+	values := map[string]string{
+		"/app/one":   "v1",
+		"/app/two":   "v2",
+		"/app/three": "v3",
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]any{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		if r.URL.Path == "/auth" {
+			authCount.Add(1)
+			if body["access-key"] != "key" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"invalid credentials"}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"token": "t-1"})
+			return
+		}
+		if body["token"] != "t-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/describe-item":
+			for _, item := range items {
+				if item.ItemName == body["name"] {
+					json.NewEncoder(w).Encode(item)
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"item not found"}`))
+		case "/get-secret-value":
+			res := map[string]string{}
+			for _, n := range body["names"].([]any) {
+				res[n.(string)] = values[n.(string)]
+			}
+			json.NewEncoder(w).Encode(res)
+		case "/get-dynamic-secret-value":
+			json.NewEncoder(w).Encode(map[string]any{"user": "admin", "ttl": 3600})
+		case "/list-items":
+			// Return items in two pages
+			if body["pagination-token"] == nil {
+				json.NewEncoder(w).Encode(map[string]any{"items": items[:2], "next_page": "p2"})
+			} else {
+				json.NewEncoder(w).Encode(map[string]any{"items": items[2:3]})
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestAkeylessSecretStore(t *testing.T) {
+	var authCount atomic.Int32
+	server := newTestServer(t, &authCount)
+	defer server.Close()
+
+	s := NewAkeylessSecretStore(logger.NewLogger("test"))
+	err := s.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"gatewayUrl": server.URL,
+		"accessId":   "p-123",
+		"accessKey":  "key",
+		"path":       "/app",
+	}}})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), authCount.Load())
+
+	t.Run("get static secret", func(t *testing.T) {
+		res, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "/app/one"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"/app/one": "v1"}, res.Data)
+	})
+
+	t.Run("get static secret with type in metadata", func(t *testing.T) {
+		res, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			Name:     "/app/two",
+			Metadata: map[string]string{SecretType: "static"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"/app/two": "v2"}, res.Data)
+	})
+
+	t.Run("get dynamic secret", func(t *testing.T) {
+		res, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "/app/db"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"user": "admin", "ttl": "3600"}, res.Data)
+	})
+
+	t.Run("secret not found", func(t *testing.T) {
+		_, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "/app/missing"})
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("bulk get", func(t *testing.T) {
+		res, err := s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]map[string]string{
+			"/app/one":   {"/app/one": "v1"},
+			"/app/two":   {"/app/two": "v2"},
+			"/app/three": {"/app/three": "v3"},
+		}, res.Data)
+	})
+
+	t.Run("bulk get with label filter", func(t *testing.T) {
+		res, err := s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{
+			Metadata: map[string]string{secretstores.BulkGetLabelKeyPrefix + "env": "prod"},
+		})
+		require.NoError(t, err)
+		assert.Len(t, res.Data, 2)
+		assert.Contains(t, res.Data, "/app/one")
+		assert.Contains(t, res.Data, "/app/three")
+	})
+
+	t.Run("re-authenticates when token is rejected", func(t *testing.T) {
+		impl := s.(*akeylessSecretStore)
+		impl.tokenLock.Lock()
+		impl.token = "expired"
+		impl.tokenLock.Unlock()
+
+		before := authCount.Load()
+		res, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "/app/one"})
+		require.NoError(t, err)
+		assert.Equal(t, "v1", res.Data["/app/one"])
+		assert.Equal(t, before+1, authCount.Load())
+	})
+}
+
+func TestInitInvalidCredentials(t *testing.T) {
+	var authCount atomic.Int32
+	server := newTestServer(t, &authCount)
+	defer server.Close()
+
+	s := NewAkeylessSecretStore(logger.NewLogger("test"))
+	err := s.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"gatewayUrl": server.URL,
+		"accessId":   "p-123",
+		"accessKey":  "wrong",
+	}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid credentials")
+}
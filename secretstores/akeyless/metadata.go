@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package akeyless
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+const (
+	defaultGatewayURL = "https://api.akeyless.io"
+	defaultListPath   = "/"
+
+	// Path where Kubernetes mounts the service account token
+	defaultK8sServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token" //nolint:gosec
+
+	accessTypeAccessKey = "access_key"
+	accessTypeAWSIAM    = "aws_iam"
+	accessTypeK8s       = "k8s"
+)
+
+type akeylessMetadata struct {
+	// URL of the Akeyless API or of an Akeyless Gateway.
+	GatewayURL string `mapstructure:"gatewayUrl"`
+	// Access ID of the authentication method.
+	AccessID string `mapstructure:"accessId"`
+	// Authentication method: "access_key", "aws_iam", or "k8s".
+	// If empty, defaults to "access_key" if accessKey is set.
+	AccessType string `mapstructure:"accessType"`
+	// Access key, for the "access_key" authentication method.
+	AccessKey string `mapstructure:"accessKey"`
+	// Name of the Kubernetes auth config in the Akeyless Gateway, for the "k8s" authentication method.
+	K8sAuthConfigName string `mapstructure:"k8sAuthConfigName"`
+	// Kubernetes service account token, for the "k8s" authentication method.
+	// If empty, the token is read from the path where it's mounted in pods.
+	K8sServiceAccountToken string `mapstructure:"k8sServiceAccountToken"`
+	// Path of the items listed by BulkGetSecret.
+	Path string `mapstructure:"path"`
+}
+
+func parseMetadata(meta secretstores.Metadata) (akeylessMetadata, error) {
+	m := akeylessMetadata{
+		GatewayURL: defaultGatewayURL,
+		Path:       defaultListPath,
+	}
+	err := metadata.DecodeMetadata(meta.Properties, &m)
+	if err != nil {
+		return m, err
+	}
+
+	m.GatewayURL = strings.TrimSuffix(m.GatewayURL, "/")
+	if m.GatewayURL == "" {
+		return m, errors.New("gatewayUrl must not be empty")
+	}
+	if m.AccessID == "" {
+		return m, errors.New("accessId is required")
+	}
+
+	if m.AccessType == "" && m.AccessKey != "" {
+		m.AccessType = accessTypeAccessKey
+	}
+	switch m.AccessType {
+	case accessTypeAccessKey:
+		if m.AccessKey == "" {
+			return m, errors.New("accessKey is required for the access_key authentication method")
+		}
+	case accessTypeAWSIAM:
+		// Nothing to validate: credentials are obtained from the environment
+	case accessTypeK8s:
+		if m.K8sAuthConfigName == "" {
+			return m, errors.New("k8sAuthConfigName is required for the k8s authentication method")
+		}
+	case "":
+		return m, errors.New("accessType is required when accessKey is not set")
+	default:
+		return m, fmt.Errorf("invalid accessType '%s': supported values are %s, %s, and %s", m.AccessType, accessTypeAccessKey, accessTypeAWSIAM, accessTypeK8s)
+	}
+
+	return m, nil
+}
@@ -41,11 +41,15 @@ func NewParameterStore(logger logger.Logger) secretstores.SecretStore {
 }
 
 type ParameterStoreMetaData struct {
-	Region       string `json:"region"`
-	AccessKey    string `json:"accessKey"`
-	SecretKey    string `json:"secretKey"`
-	SessionToken string `json:"sessionToken"`
-	Prefix       string `json:"prefix"`
+	Region               string `json:"region"`
+	Endpoint             string `json:"endpoint"`
+	STSEndpoint          string `json:"stsEndpoint"`
+	UseFIPSEndpoint      bool   `json:"useFipsEndpoint"`
+	UseDualStackEndpoint bool   `json:"useDualStackEndpoint"`
+	AccessKey            string `json:"accessKey"`
+	SecretKey            string `json:"secretKey"`
+	SessionToken         string `json:"sessionToken"`
+	Prefix               string `json:"prefix"`
 }
 
 type ssmSecretStore struct {
@@ -153,7 +157,16 @@ func (s *ssmSecretStore) BulkGetSecret(ctx context.Context, req secretstores.Bul
 }
 
 func (s *ssmSecretStore) getClient(metadata *ParameterStoreMetaData) (*ssm.SSM, error) {
-	sess, err := awsAuth.GetClient(metadata.AccessKey, metadata.SecretKey, metadata.SessionToken, metadata.Region, "")
+	sess, err := awsAuth.GetClient(awsAuth.Options{
+		AccessKey:            metadata.AccessKey,
+		SecretKey:            metadata.SecretKey,
+		SessionToken:         metadata.SessionToken,
+		Region:               metadata.Region,
+		Endpoint:             metadata.Endpoint,
+		STSEndpoint:          metadata.STSEndpoint,
+		UseFIPSEndpoint:      metadata.UseFIPSEndpoint,
+		UseDualStackEndpoint: metadata.UseDualStackEndpoint,
+	})
 	if err != nil {
 		return nil, err
 	}
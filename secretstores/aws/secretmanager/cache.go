@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretmanager
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+// awsCurrentStage is the staging label of the version returned when neither a version ID nor a stage is requested.
+const awsCurrentStage = "AWSCURRENT"
+
+type cacheKey struct {
+	name         string
+	versionID    string
+	versionStage string
+}
+
+type cacheEntry struct {
+	output    *secretsmanager.GetSecretValueOutput
+	expiresAt time.Time
+}
+
+// secretCache caches the values of secrets, so that they are not retrieved from the API on every request.
+// Entries expire after a TTL, and entries requested by stage are also invalidated as soon as the stage is found to
+// be attached to a different version, which happens when a secret is rotated.
+// Callers must check the current stages of a secret with invalidate before serving an entry requested by stage.
+type secretCache struct {
+	ttl     time.Duration
+	now     func() time.Time
+	lock    sync.Mutex
+	entries map[cacheKey]cacheEntry
+}
+
+func newSecretCache(ttl time.Duration) *secretCache {
+	return &secretCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[cacheKey]cacheEntry{},
+	}
+}
+
+// get returns the cached value for a key, if it has not expired.
+func (c *secretCache) get(key cacheKey) (*secretsmanager.GetSecretValueOutput, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.output, true
+}
+
+// set caches the value retrieved for a key, and invalidates the entries of the secret whose stage moved to this version.
+func (c *secretCache) set(key cacheKey, output *secretsmanager.GetSecretValueOutput) {
+	if output.VersionId != nil {
+		c.invalidate(key.name, map[string][]*string{*output.VersionId: output.VersionStages})
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries[key] = cacheEntry{
+		output:    output,
+		expiresAt: c.now().Add(c.ttl),
+	}
+}
+
+// invalidate removes the entries of a secret requested by stage whose stage is now attached to another version.
+// versionsToStages maps version IDs to their stages, and doesn't need to contain all the versions of the secret.
+func (c *secretCache) invalidate(name string, versionsToStages map[string][]*string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for key, entry := range c.entries {
+		if key.name != name || key.versionID != "" {
+			// Values requested by version ID never change
+			continue
+		}
+		stage := key.versionStage
+		if stage == "" {
+			stage = awsCurrentStage
+		}
+		for versionID, stages := range versionsToStages {
+			if versionID != aws.StringValue(entry.output.VersionId) && containsStage(stages, stage) {
+				delete(c.entries, key)
+				break
+			}
+		}
+	}
+}
+
+func containsStage(stages []*string, stage string) bool {
+	for _, s := range stages {
+		if aws.StringValue(s) == stage {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretCache(t *testing.T) {
+	now := time.Now()
+	c := newSecretCache(time.Minute)
+	c.now = func() time.Time { return now }
+
+	output := func(versionID string, stages ...string) *secretsmanager.GetSecretValueOutput {
+		return &secretsmanager.GetSecretValueOutput{
+			Name:          aws.String("db"),
+			SecretString:  aws.String(versionID),
+			VersionId:     aws.String(versionID),
+			VersionStages: aws.StringSlice(stages),
+		}
+	}
+
+	current := cacheKey{name: "db"}
+	previous := cacheKey{name: "db", versionStage: "AWSPREVIOUS"}
+	byVersion := cacheKey{name: "db", versionID: "v1"}
+
+	t.Run("entries expire", func(t *testing.T) {
+		c.set(current, output("v1", awsCurrentStage))
+		_, ok := c.get(current)
+		assert.True(t, ok)
+
+		now = now.Add(time.Minute)
+		_, ok = c.get(current)
+		assert.False(t, ok)
+	})
+
+	t.Run("entries are invalidated when their stage moves", func(t *testing.T) {
+		c.set(current, output("v1", awsCurrentStage))
+		c.set(byVersion, output("v1", awsCurrentStage))
+		c.set(previous, output("v0", "AWSPREVIOUS"))
+
+		// Rotation: v2 becomes current and v1 previous
+		c.invalidate("db", map[string][]*string{
+			"v2": aws.StringSlice([]string{awsCurrentStage}),
+			"v1": aws.StringSlice([]string{"AWSPREVIOUS"}),
+		})
+
+		_, ok := c.get(current)
+		assert.False(t, ok)
+		_, ok = c.get(previous)
+		assert.False(t, ok)
+		out, ok := c.get(byVersion)
+		assert.True(t, ok)
+		assert.Equal(t, "v1", *out.SecretString)
+	})
+
+	t.Run("setting a new version invalidates the stage of other keys", func(t *testing.T) {
+		other := cacheKey{name: "db", versionStage: awsCurrentStage}
+		c.set(current, output("v1", awsCurrentStage))
+		c.set(other, output("v2", awsCurrentStage))
+
+		_, ok := c.get(current)
+		assert.False(t, ok)
+		_, ok = c.get(other)
+		assert.True(t, ok)
+	})
+
+	t.Run("other secrets are not invalidated", func(t *testing.T) {
+		key := cacheKey{name: "api"}
+		c.set(key, output("v1", awsCurrentStage))
+		c.invalidate("db", map[string][]*string{"v9": aws.StringSlice([]string{awsCurrentStage})})
+		_, ok := c.get(key)
+		assert.True(t, ok)
+	})
+}
@@ -15,14 +15,16 @@ package secretmanager
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"reflect"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
 
 	awsAuth "github.com/dapr/components-contrib/internal/authentication/aws"
+	"github.com/dapr/components-contrib/internal/utils"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/kit/logger"
@@ -31,6 +33,8 @@ import (
 const (
 	VersionID    = "version_id"
 	VersionStage = "version_stage"
+	// ForceRefresh is the request metadata key to bypass the cache and retrieve the latest values from the service.
+	ForceRefresh = "forceRefresh"
 )
 
 var _ secretstores.SecretStore = (*smSecretStore)(nil)
@@ -41,14 +45,21 @@ func NewSecretManager(logger logger.Logger) secretstores.SecretStore {
 }
 
 type SecretManagerMetaData struct {
-	Region       string `json:"region"`
-	AccessKey    string `json:"accessKey"`
-	SecretKey    string `json:"secretKey"`
-	SessionToken string `json:"sessionToken"`
+	Region               string `json:"region"`
+	Endpoint             string `json:"endpoint"`
+	STSEndpoint          string `json:"stsEndpoint"`
+	UseFIPSEndpoint      bool   `json:"useFipsEndpoint"`
+	UseDualStackEndpoint bool   `json:"useDualStackEndpoint"`
+	AccessKey            string `json:"accessKey"`
+	SecretKey            string `json:"secretKey"`
+	SessionToken         string `json:"sessionToken"`
+	// CacheTTL enables caching the values of secrets for the given duration, such as "5m"
+	CacheTTL string `json:"cacheTTL"`
 }
 
 type smSecretStore struct {
 	client secretsmanageriface.SecretsManagerAPI
+	cache  *secretCache
 	logger logger.Logger
 }
 
@@ -65,6 +76,16 @@ func (s *smSecretStore) Init(_ context.Context, metadata secretstores.Metadata)
 	}
 	s.client = client
 
+	if meta.CacheTTL != "" {
+		ttl, err := time.ParseDuration(meta.CacheTTL)
+		if err != nil {
+			return fmt.Errorf("invalid cacheTTL: %w", err)
+		}
+		if ttl > 0 {
+			s.cache = newSecretCache(ttl)
+		}
+	}
+
 	return nil
 }
 
@@ -79,11 +100,7 @@ func (s *smSecretStore) GetSecret(ctx context.Context, req secretstores.GetSecre
 		versionStage = &value
 	}
 
-	output, err := s.client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
-		SecretId:     &req.Name,
-		VersionId:    versionID,
-		VersionStage: versionStage,
-	})
+	output, err := s.getSecretValue(ctx, req.Name, versionID, versionStage, utils.IsTruthy(req.Metadata[ForceRefresh]), false)
 	if err != nil {
 		return secretstores.GetSecretResponse{Data: nil}, fmt.Errorf("couldn't get secret: %s", err)
 	}
@@ -100,59 +117,155 @@ func (s *smSecretStore) GetSecret(ctx context.Context, req secretstores.GetSecre
 
 // BulkGetSecret retrieves all secrets in the store and returns a map of decrypted string/string values.
 func (s *smSecretStore) BulkGetSecret(ctx context.Context, req secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
+	filter, err := req.GetFilter()
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{}, err
+	}
+
 	resp := secretstores.BulkGetSecretResponse{
 		Data: map[string]map[string]string{},
 	}
 
-	search := true
-	var nextToken *string = nil
-
-	for search {
-		output, err := s.client.ListSecretsWithContext(ctx, &secretsmanager.ListSecretsInput{
-			MaxResults: nil,
-			NextToken:  nextToken,
+	input := &secretsmanager.ListSecretsInput{}
+	if filter.Prefix != "" {
+		// The "name" filter matches secrets whose name starts with the value
+		input.Filters = append(input.Filters, &secretsmanager.Filter{
+			Key:    aws.String(secretsmanager.FilterNameStringTypeName),
+			Values: aws.StringSlice([]string{filter.Prefix}),
 		})
+	}
+	for k, v := range filter.Labels {
+		// Tag filters match keys and values independently, so tags are checked again on the results
+		input.Filters = append(input.Filters,
+			&secretsmanager.Filter{
+				Key:    aws.String(secretsmanager.FilterNameStringTypeTagKey),
+				Values: aws.StringSlice([]string{k}),
+			},
+			&secretsmanager.Filter{
+				Key:    aws.String(secretsmanager.FilterNameStringTypeTagValue),
+				Values: aws.StringSlice([]string{v}),
+			},
+		)
+	}
+	if filter.PageSize > 0 {
+		input.MaxResults = aws.Int64(int64(filter.PageSize))
+	}
+	if filter.PageToken != "" {
+		input.NextToken = aws.String(filter.PageToken)
+	}
+
+	forceRefresh := utils.IsTruthy(req.Metadata[ForceRefresh])
+	for {
+		output, err := s.client.ListSecretsWithContext(ctx, input)
 		if err != nil {
 			return secretstores.BulkGetSecretResponse{Data: nil}, fmt.Errorf("couldn't list secrets: %s", err)
 		}
 
 		for _, entry := range output.SecretList {
-			secrets, err := s.client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
-				SecretId: entry.Name,
-			})
+			if entry.Name == nil || !filter.Match(*entry.Name, tagsToMap(entry.Tags)) {
+				continue
+			}
+
+			if s.cache != nil {
+				s.cache.invalidate(*entry.Name, entry.SecretVersionsToStages)
+			}
+			// The stages were checked with the versions returned by ListSecrets
+			secrets, err := s.getSecretValue(ctx, *entry.Name, nil, nil, forceRefresh, true)
 			if err != nil {
 				return secretstores.BulkGetSecretResponse{Data: nil}, fmt.Errorf("couldn't get secret: %s", *entry.Name)
 			}
 
-			if entry.Name != nil && secrets.SecretString != nil {
+			if secrets.SecretString != nil {
 				resp.Data[*entry.Name] = map[string]string{*entry.Name: *secrets.SecretString}
 			}
 		}
 
-		nextToken = output.NextToken
-		search = output.NextToken != nil
+		if output.NextToken == nil {
+			break
+		}
+		if filter.PageSize > 0 {
+			// Only one page is returned when paginating
+			resp.NextPageToken = *output.NextToken
+			break
+		}
+		input.NextToken = output.NextToken
 	}
 
 	return resp, nil
 }
 
-func (s *smSecretStore) getClient(metadata *SecretManagerMetaData) (*secretsmanager.SecretsManager, error) {
-	sess, err := awsAuth.GetClient(metadata.AccessKey, metadata.SecretKey, metadata.SessionToken, metadata.Region, "")
+// getSecretValue retrieves the value of a secret, from the cache when enabled unless forceRefresh is set.
+// Values cached for a stage are only returned if the stage is still attached to the same version:
+// unless stagesChecked is set, the stages of the secret are retrieved with DescribeSecret first, which doesn't decrypt the value.
+func (s *smSecretStore) getSecretValue(ctx context.Context, name string, versionID *string, versionStage *string, forceRefresh bool, stagesChecked bool) (*secretsmanager.GetSecretValueOutput, error) {
+	key := cacheKey{
+		name:         name,
+		versionID:    aws.StringValue(versionID),
+		versionStage: aws.StringValue(versionStage),
+	}
+	if s.cache != nil && !forceRefresh {
+		if output, ok := s.cache.get(key); ok && key.versionID == "" && !stagesChecked {
+			desc, err := s.client.DescribeSecretWithContext(ctx, &secretsmanager.DescribeSecretInput{
+				SecretId: &name,
+			})
+			if err != nil {
+				return nil, err
+			}
+			s.cache.invalidate(name, desc.VersionIdsToStages)
+			if output, ok = s.cache.get(key); ok {
+				return output, nil
+			}
+		} else if ok {
+			return output, nil
+		}
+	}
+
+	output, err := s.client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId:     &name,
+		VersionId:    versionID,
+		VersionStage: versionStage,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return secretsmanager.New(sess), nil
+	if s.cache != nil {
+		s.cache.set(key, output)
+	}
+	return output, nil
 }
 
-func (s *smSecretStore) getSecretManagerMetadata(spec secretstores.Metadata) (*SecretManagerMetaData, error) {
-	b, err := json.Marshal(spec.Properties)
+func tagsToMap(tags []*secretsmanager.Tag) map[string]string {
+	res := make(map[string]string, len(tags))
+	for _, t := range tags {
+		if t.Key != nil && t.Value != nil {
+			res[*t.Key] = *t.Value
+		}
+	}
+	return res
+}
+
+func (s *smSecretStore) getClient(metadata *SecretManagerMetaData) (*secretsmanager.SecretsManager, error) {
+	sess, err := awsAuth.GetClient(awsAuth.Options{
+		AccessKey:            metadata.AccessKey,
+		SecretKey:            metadata.SecretKey,
+		SessionToken:         metadata.SessionToken,
+		Region:               metadata.Region,
+		Endpoint:             metadata.Endpoint,
+		STSEndpoint:          metadata.STSEndpoint,
+		UseFIPSEndpoint:      metadata.UseFIPSEndpoint,
+		UseDualStackEndpoint: metadata.UseDualStackEndpoint,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	var meta SecretManagerMetaData
-	err = json.Unmarshal(b, &meta)
+	return secretsmanager.New(sess), nil
+}
+
+func (s *smSecretStore) getSecretManagerMetadata(spec secretstores.Metadata) (*SecretManagerMetaData, error) {
+	meta := SecretManagerMetaData{}
+	err := metadata.DecodeMetadata(spec.Properties, &meta)
 	if err != nil {
 		return nil, err
 	}
@@ -162,7 +275,7 @@ func (s *smSecretStore) getSecretManagerMetadata(spec secretstores.Metadata) (*S
 
 // Features returns the features available in this secret store.
 func (s *smSecretStore) Features() []secretstores.Feature {
-	return []secretstores.Feature{} // No Feature supported.
+	return []secretstores.Feature{secretstores.FeatureBulkGetFilter}
 }
 
 func (s *smSecretStore) GetComponentMetadata() map[string]string {
@@ -18,11 +18,14 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/kit/logger"
@@ -32,13 +35,23 @@ const secretValue = "secret"
 
 type mockedSM struct {
 	GetSecretValueFn func(context.Context, *secretsmanager.GetSecretValueInput, ...request.Option) (*secretsmanager.GetSecretValueOutput, error)
+	ListSecretsFn    func(context.Context, *secretsmanager.ListSecretsInput, ...request.Option) (*secretsmanager.ListSecretsOutput, error)
+	DescribeSecretFn func(context.Context, *secretsmanager.DescribeSecretInput, ...request.Option) (*secretsmanager.DescribeSecretOutput, error)
 	secretsmanageriface.SecretsManagerAPI
 }
 
+func (m *mockedSM) DescribeSecretWithContext(ctx context.Context, input *secretsmanager.DescribeSecretInput, option ...request.Option) (*secretsmanager.DescribeSecretOutput, error) {
+	return m.DescribeSecretFn(ctx, input, option...)
+}
+
 func (m *mockedSM) GetSecretValueWithContext(ctx context.Context, input *secretsmanager.GetSecretValueInput, option ...request.Option) (*secretsmanager.GetSecretValueOutput, error) {
 	return m.GetSecretValueFn(ctx, input, option...)
 }
 
+func (m *mockedSM) ListSecretsWithContext(ctx context.Context, input *secretsmanager.ListSecretsInput, option ...request.Option) (*secretsmanager.ListSecretsOutput, error) {
+	return m.ListSecretsFn(ctx, input, option...)
+}
+
 func TestInit(t *testing.T) {
 	m := secretstores.Metadata{}
 	s := NewSecretManager(logger.NewLogger("test"))
@@ -154,8 +167,184 @@ func TestGetSecret(t *testing.T) {
 
 func TestGetFeatures(t *testing.T) {
 	s := smSecretStore{}
-	t.Run("no features are advertised", func(t *testing.T) {
+	t.Run("bulk get filter is advertised", func(t *testing.T) {
 		f := s.Features()
-		assert.Empty(t, f)
+		assert.True(t, secretstores.FeatureBulkGetFilter.IsPresent(f))
+		assert.False(t, secretstores.FeatureMultipleKeyValuesPerSecret.IsPresent(f))
+	})
+}
+
+func TestBulkGetSecret(t *testing.T) {
+	getSecretValueFn := func(_ context.Context, input *secretsmanager.GetSecretValueInput, _ ...request.Option) (*secretsmanager.GetSecretValueOutput, error) {
+		return &secretsmanager.GetSecretValueOutput{
+			Name:         input.SecretId,
+			SecretString: aws.String(secretValue),
+		}, nil
+	}
+
+	t.Run("filters are sent to the service and tags are matched", func(t *testing.T) {
+		var received *secretsmanager.ListSecretsInput
+		s := smSecretStore{
+			client: &mockedSM{
+				GetSecretValueFn: getSecretValueFn,
+				ListSecretsFn: func(_ context.Context, input *secretsmanager.ListSecretsInput, _ ...request.Option) (*secretsmanager.ListSecretsOutput, error) {
+					received = input
+					return &secretsmanager.ListSecretsOutput{
+						SecretList: []*secretsmanager.SecretListEntry{
+							{Name: aws.String("app/a"), Tags: []*secretsmanager.Tag{{Key: aws.String("env"), Value: aws.String("prod")}}},
+							{Name: aws.String("app/b"), Tags: []*secretsmanager.Tag{{Key: aws.String("env"), Value: aws.String("dev")}}},
+						},
+						NextToken: aws.String("next"),
+					}, nil
+				},
+			},
+		}
+
+		resp, err := s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{
+			Metadata: map[string]string{"prefix": "app/", "label.env": "prod", "pageSize": "2"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]map[string]string{"app/a": {"app/a": secretValue}}, resp.Data)
+		assert.Equal(t, "next", resp.NextPageToken)
+		assert.Equal(t, int64(2), *received.MaxResults)
+		assert.Len(t, received.Filters, 3)
+		assert.Equal(t, secretsmanager.FilterNameStringTypeName, *received.Filters[0].Key)
+	})
+
+	t.Run("without page size all pages are read", func(t *testing.T) {
+		calls := 0
+		s := smSecretStore{
+			client: &mockedSM{
+				GetSecretValueFn: getSecretValueFn,
+				ListSecretsFn: func(_ context.Context, input *secretsmanager.ListSecretsInput, _ ...request.Option) (*secretsmanager.ListSecretsOutput, error) {
+					calls++
+					out := &secretsmanager.ListSecretsOutput{
+						SecretList: []*secretsmanager.SecretListEntry{{Name: aws.String(fmt.Sprintf("s%d", calls))}},
+					}
+					if calls == 1 {
+						out.NextToken = aws.String("next")
+					}
+					return out, nil
+				},
+			},
+		}
+
+		resp, err := s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		assert.NoError(t, err)
+		assert.Len(t, resp.Data, 2)
+		assert.Empty(t, resp.NextPageToken)
+	})
+}
+
+func TestCache(t *testing.T) {
+	newStore := func(calls *int, versionID *string) *smSecretStore {
+		versionsToStages := func() map[string][]*string {
+			return map[string][]*string{
+				*versionID: aws.StringSlice([]string{awsCurrentStage}),
+			}
+		}
+		return &smSecretStore{
+			cache: newSecretCache(time.Minute),
+			client: &mockedSM{
+				GetSecretValueFn: func(_ context.Context, input *secretsmanager.GetSecretValueInput, _ ...request.Option) (*secretsmanager.GetSecretValueOutput, error) {
+					*calls++
+					return &secretsmanager.GetSecretValueOutput{
+						Name:          input.SecretId,
+						SecretString:  aws.String("value-" + *versionID),
+						VersionId:     aws.String(*versionID),
+						VersionStages: aws.StringSlice([]string{awsCurrentStage}),
+					}, nil
+				},
+				ListSecretsFn: func(_ context.Context, input *secretsmanager.ListSecretsInput, _ ...request.Option) (*secretsmanager.ListSecretsOutput, error) {
+					return &secretsmanager.ListSecretsOutput{
+						SecretList: []*secretsmanager.SecretListEntry{{
+							Name:                   aws.String("db"),
+							SecretVersionsToStages: versionsToStages(),
+						}},
+					}, nil
+				},
+				DescribeSecretFn: func(_ context.Context, input *secretsmanager.DescribeSecretInput, _ ...request.Option) (*secretsmanager.DescribeSecretOutput, error) {
+					return &secretsmanager.DescribeSecretOutput{
+						Name:               input.SecretId,
+						VersionIdsToStages: versionsToStages(),
+					}, nil
+				},
+			},
+		}
+	}
+
+	t.Run("values are cached until forceRefresh", func(t *testing.T) {
+		calls := 0
+		s := newStore(&calls, aws.String("v1"))
+
+		for i := 0; i < 3; i++ {
+			resp, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "db"})
+			require.NoError(t, err)
+			assert.Equal(t, "value-v1", resp.Data["db"])
+		}
+		assert.Equal(t, 1, calls)
+
+		_, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{
+			Name:     "db",
+			Metadata: map[string]string{ForceRefresh: "true"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("rotation invalidates the cached stage", func(t *testing.T) {
+		calls := 0
+		versionID := aws.String("v1")
+		s := newStore(&calls, versionID)
+
+		_, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "db"})
+		require.NoError(t, err)
+		resp, err := s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "value-v1", resp.Data["db"]["db"])
+		assert.Equal(t, 1, calls)
+
+		// The secret is rotated
+		*versionID = "v2"
+		resp, err = s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "value-v2", resp.Data["db"]["db"])
+		assert.Equal(t, 2, calls)
+
+		getResp, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "db"})
+		require.NoError(t, err)
+		assert.Equal(t, "value-v2", getResp.Data["db"])
+		assert.Equal(t, 2, calls)
+	})
+	t.Run("cached stages are checked on get", func(t *testing.T) {
+		calls := 0
+		versionID := aws.String("v1")
+		s := newStore(&calls, versionID)
+
+		_, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "db"})
+		require.NoError(t, err)
+
+		// The secret is rotated
+		*versionID = "v2"
+		resp, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "db"})
+		require.NoError(t, err)
+		assert.Equal(t, "value-v2", resp.Data["db"])
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("values requested by version ID are not checked", func(t *testing.T) {
+		calls := 0
+		s := newStore(&calls, aws.String("v1"))
+		s.client.(*mockedSM).DescribeSecretFn = nil
+
+		for i := 0; i < 2; i++ {
+			resp, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{
+				Name:     "db",
+				Metadata: map[string]string{VersionID: "v1"},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, "value-v1", resp.Data["db"])
+		}
+		assert.Equal(t, 1, calls)
 	})
 }
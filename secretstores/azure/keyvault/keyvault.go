@@ -15,10 +15,14 @@ package keyvault
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
@@ -28,6 +32,7 @@ import (
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
 )
 
 // Keyvault secret store component metadata properties
@@ -35,6 +40,8 @@ import (
 const (
 	VersionID          = "version_id"
 	secretItemIDPrefix = "/secrets/"
+
+	defaultBulkGetConcurrency = 10
 )
 
 var _ secretstores.SecretStore = (*keyvaultSecretStore)(nil)
@@ -43,12 +50,21 @@ type keyvaultSecretStore struct {
 	vaultName      string
 	vaultClient    *azsecrets.Client
 	vaultDNSSuffix string
+	concurrency    int
+
+	// Optional geo-replicated vault that is used when the primary one is unavailable
+	secondaryVaultName   string
+	secondaryVaultClient *azsecrets.Client
 
 	logger logger.Logger
 }
 
 type KeyvaultMetadata struct {
 	VaultName string
+	// Name of a secondary vault that contains a replica of the secrets; requests fail over to it when the primary vault is unavailable
+	SecondaryVaultName string `mapstructure:"secondaryVaultName"`
+	// Maximum number of secrets retrieved in parallel by BulkGetSecret
+	BulkGetConcurrency int `mapstructure:"bulkGetConcurrency"`
 }
 
 // NewAzureKeyvaultSecretStore returns a new Azure Key Vault secret store.
@@ -62,27 +78,20 @@ func NewAzureKeyvaultSecretStore(logger logger.Logger) secretstores.SecretStore
 
 // Init creates a Azure Key Vault client.
 func (k *keyvaultSecretStore) Init(_ context.Context, meta secretstores.Metadata) error {
-	m := KeyvaultMetadata{}
+	m := KeyvaultMetadata{
+		BulkGetConcurrency: defaultBulkGetConcurrency,
+	}
 	if err := metadata.DecodeMetadata(meta.Properties, &m); err != nil {
 		return err
 	}
+	if m.BulkGetConcurrency <= 0 {
+		return errors.New("bulkGetConcurrency must be greater than 0")
+	}
+	k.concurrency = m.BulkGetConcurrency
 	// Fix for maintaining backwards compatibility with a change introduced in 1.3 that allowed specifying an Azure environment by setting a FQDN for vault name
 	// This should be considered deprecated and users should rely the "azureEnvironment" metadata instead, but it's maintained here for backwards-compatibility
-	if m.VaultName != "" {
-		keyVaultSuffixToEnvironment := map[string]string{
-			".vault.azure.net":         "AzurePublicCloud",
-			".vault.azure.cn":          "AzureChinaCloud",
-			".vault.usgovcloudapi.net": "AzureUSGovernmentCloud",
-		}
-		for suffix, environment := range keyVaultSuffixToEnvironment {
-			if strings.HasSuffix(m.VaultName, suffix) {
-				meta.Properties["azureEnvironment"] = environment
-				m.VaultName = strings.TrimPrefix(strings.TrimSuffix(m.VaultName, suffix), "https://")
-				k.vaultName = m.VaultName
-				break
-			}
-		}
-	}
+	m.VaultName = trimVaultSuffix(m.VaultName, meta.Properties)
+	m.SecondaryVaultName = trimVaultSuffix(m.SecondaryVaultName, meta.Properties)
 
 	// Initialization code
 	settings, err := azauth.NewEnvironmentSettings(meta.Properties)
@@ -102,11 +111,43 @@ func (k *keyvaultSecretStore) Init(_ context.Context, meta secretstores.Metadata
 			ApplicationID: "dapr-" + logger.DaprVersion,
 		},
 	}
-	client, clientErr := azsecrets.NewClient(k.getVaultURI(), cred, &azsecrets.ClientOptions{
+	k.vaultClient, err = azsecrets.NewClient(k.getVaultURI(), cred, &azsecrets.ClientOptions{
 		ClientOptions: coreClientOpts,
 	})
-	k.vaultClient = client
-	return clientErr
+	if err != nil {
+		return err
+	}
+
+	if m.SecondaryVaultName != "" {
+		k.secondaryVaultName = m.SecondaryVaultName
+		k.secondaryVaultClient, err = azsecrets.NewClient(k.getSecondaryVaultURI(), cred, &azsecrets.ClientOptions{
+			ClientOptions: coreClientOpts,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// trimVaultSuffix removes the Key Vault DNS suffix from a vault name, setting the Azure environment that matches it.
+func trimVaultSuffix(vaultName string, properties map[string]string) string {
+	if vaultName == "" {
+		return vaultName
+	}
+	keyVaultSuffixToEnvironment := map[string]string{
+		".vault.azure.net":         "AzurePublicCloud",
+		".vault.azure.cn":          "AzureChinaCloud",
+		".vault.usgovcloudapi.net": "AzureUSGovernmentCloud",
+	}
+	for suffix, environment := range keyVaultSuffixToEnvironment {
+		if strings.HasSuffix(vaultName, suffix) {
+			properties["azureEnvironment"] = environment
+			return strings.TrimPrefix(strings.TrimSuffix(vaultName, suffix), "https://")
+		}
+	}
+	return vaultName
 }
 
 // GetSecret retrieves a secret using a key and returns a map of decrypted string/string values.
@@ -116,7 +157,9 @@ func (k *keyvaultSecretStore) GetSecret(ctx context.Context, req secretstores.Ge
 		version = val
 	}
 
-	secretResp, err := k.vaultClient.GetSecret(ctx, req.Name, version, nil)
+	secretResp, err := withFailover(k, func(client *azsecrets.Client, _ string) (azsecrets.GetSecretResponse, error) {
+		return client.GetSecret(ctx, req.Name, version, nil)
+	})
 	if err != nil {
 		return secretstores.GetSecretResponse{}, err
 	}
@@ -133,6 +176,22 @@ func (k *keyvaultSecretStore) GetSecret(ctx context.Context, req secretstores.Ge
 	}, nil
 }
 
+// Ping checks that the vault is reachable and that the component is authorized to list its secrets.
+// It lists at most one secret, failing over to the secondary vault if the primary one is unavailable.
+func (k *keyvaultSecretStore) Ping(ctx context.Context) error {
+	_, err := withFailover(k, func(client *azsecrets.Client, _ string) (struct{}, error) {
+		pager := client.NewListSecretsPager(&azsecrets.ListSecretsOptions{
+			MaxResults: ptr.Of(int32(1)),
+		})
+		_, err := pager.NextPage(ctx)
+		return struct{}{}, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to Azure Key Vault %s: %w", k.vaultName, err)
+	}
+	return nil
+}
+
 // BulkGetSecret retrieves all secrets in the store and returns a map of decrypted string/string values.
 func (k *keyvaultSecretStore) BulkGetSecret(ctx context.Context, req secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
 	maxResults, err := k.getMaxResultsFromMetadata(req.Metadata)
@@ -140,13 +199,33 @@ func (k *keyvaultSecretStore) BulkGetSecret(ctx context.Context, req secretstore
 		return secretstores.BulkGetSecretResponse{}, err
 	}
 
+	filter, err := req.GetFilter()
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{}, err
+	}
+
+	resp, err := withFailover(k, func(client *azsecrets.Client, vaultURI string) (secretstores.BulkGetSecretResponse, error) {
+		return k.bulkGetSecret(ctx, client, vaultURI, filter, maxResults)
+	})
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{}, err
+	}
+
+	return resp, nil
+}
+
+// bulkGetSecret lists the secrets of a vault, returning the values of those that match the filter.
+func (k *keyvaultSecretStore) bulkGetSecret(ctx context.Context, client *azsecrets.Client, vaultURI string, filter secretstores.BulkGetSecretFilter, maxResults *int32) (secretstores.BulkGetSecretResponse, error) {
 	resp := secretstores.BulkGetSecretResponse{
 		Data: map[string]map[string]string{},
 	}
 
-	secretIDPrefix := k.getVaultURI() + secretItemIDPrefix
+	secretIDPrefix := vaultURI + secretItemIDPrefix
 
-	pager := k.vaultClient.NewListSecretsPager(nil)
+	// Key Vault doesn't support filtering on the server nor resuming a listing, so names and tags are matched
+	// while listing and the values are retrieved only for the secrets in the requested page
+	names := []string{}
+	pager := client.NewListSecretsPager(nil)
 
 out:
 	for pager.More() {
@@ -161,32 +240,153 @@ out:
 			}
 
 			secretName := strings.TrimPrefix(secret.ID.Name(), secretIDPrefix)
-			secretResp, err := k.vaultClient.GetSecret(ctx, secretName, "", nil) // empty string means latest version
-			if err != nil {
-				return secretstores.BulkGetSecretResponse{}, err
-			}
-
-			secretValue := ""
-			if secretResp.Value != nil {
-				secretValue = *secretResp.Value
+			if !filter.Match(secretName, tagsToLabels(secret.Tags)) {
+				continue
 			}
-
-			resp.Data[secretName] = map[string]string{secretName: secretValue}
+			names = append(names, secretName)
 		}
 
-		if maxResults != nil && *maxResults > 0 && len(resp.Data) >= int(*maxResults) {
+		if filter.PageSize == 0 && filter.PageToken == "" && maxResults != nil && *maxResults > 0 && len(names) >= int(*maxResults) {
 			break out
 		}
 	}
 
+	var err error
+	names, resp.NextPageToken, err = filter.Paginate(names)
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{}, err
+	}
+
+	values, err := getSecretValues(ctx, names, k.concurrency, func(ctx context.Context, secretName string) (string, error) {
+		secretResp, err := client.GetSecret(ctx, secretName, "", nil) // empty string means latest version
+		if err != nil {
+			return "", err
+		}
+		if secretResp.Value == nil {
+			return "", nil
+		}
+		return *secretResp.Value, nil
+	})
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{}, err
+	}
+
+	for secretName, secretValue := range values {
+		resp.Data[secretName] = map[string]string{secretName: secretValue}
+	}
+
 	return resp, nil
 }
 
+// withFailover invokes fn with the client of the primary vault.
+// If the primary vault is unavailable and a secondary vault is configured, fn is invoked again with the client of the secondary vault.
+func withFailover[T any](k *keyvaultSecretStore, fn func(client *azsecrets.Client, vaultURI string) (T, error)) (T, error) {
+	res, err := fn(k.vaultClient, k.getVaultURI())
+	if err == nil || k.secondaryVaultClient == nil || !isUnavailableError(err) {
+		return res, err
+	}
+
+	k.logger.Warnf("Azure Key Vault %s is unavailable, failing over to %s: %v", k.vaultName, k.secondaryVaultName, err)
+	res, secondaryErr := fn(k.secondaryVaultClient, k.getSecondaryVaultURI())
+	if secondaryErr != nil {
+		return res, errors.Join(err, secondaryErr)
+	}
+	return res, nil
+}
+
+// isUnavailableError returns true if the error is a server error or a timeout, which indicate that the vault is unavailable.
+func isUnavailableError(err error) bool {
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// getSecretValues invokes getFn for each of the names, with at most concurrency invocations running in parallel.
+// It stops at the first error.
+func getSecretValues(ctx context.Context, names []string, concurrency int, getFn func(ctx context.Context, name string) (string, error)) (map[string]string, error) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		lock     sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	values := make(map[string]string, len(names))
+	sem := make(chan struct{}, concurrency)
+
+loop:
+	for _, name := range names {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break loop
+		}
+
+		wg.Add(1)
+		go func(name string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			value, err := getFn(ctx, name)
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to get secret %s: %w", name, err)
+					cancel()
+				}
+				return
+			}
+			values[name] = value
+		}(name)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	// The parent context may have been canceled while no request was running
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func tagsToLabels(tags map[string]*string) map[string]string {
+	res := make(map[string]string, len(tags))
+	for k, v := range tags {
+		if v != nil {
+			res[k] = *v
+		}
+	}
+	return res
+}
+
 // getVaultURI returns Azure Key Vault URI.
 func (k *keyvaultSecretStore) getVaultURI() string {
 	return fmt.Sprintf("https://%s.%s", k.vaultName, k.vaultDNSSuffix)
 }
 
+// getSecondaryVaultURI returns the URI of the secondary Azure Key Vault.
+func (k *keyvaultSecretStore) getSecondaryVaultURI() string {
+	return fmt.Sprintf("https://%s.%s", k.secondaryVaultName, k.vaultDNSSuffix)
+}
+
 func (k *keyvaultSecretStore) getMaxResultsFromMetadata(metadata map[string]string) (*int32, error) {
 	if s, ok := metadata["maxresults"]; ok && s != "" {
 		val, err := strconv.Atoi(s)
@@ -203,7 +403,7 @@ func (k *keyvaultSecretStore) getMaxResultsFromMetadata(metadata map[string]stri
 
 // Features returns the features available in this secret store.
 func (k *keyvaultSecretStore) Features() []secretstores.Feature {
-	return []secretstores.Feature{} // No Feature supported.
+	return []secretstores.Feature{secretstores.FeatureBulkGetFilter}
 }
 
 func (k *keyvaultSecretStore) GetComponentMetadata() map[string]string {
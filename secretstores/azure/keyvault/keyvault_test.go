@@ -16,9 +16,21 @@ package keyvault
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/secretstores"
 	"github.com/dapr/kit/logger"
@@ -40,8 +52,43 @@ func TestInit(t *testing.T) {
 		assert.True(t, ok)
 		assert.Equal(t, kv.vaultName, "foo")
 		assert.Equal(t, kv.vaultDNSSuffix, "vault.azure.net")
+		assert.Equal(t, defaultBulkGetConcurrency, kv.concurrency)
 		assert.NotNil(t, kv.vaultClient)
 	})
+	t.Run("Init with bulkGetConcurrency", func(t *testing.T) {
+		m.Properties = map[string]string{
+			"vaultName":          "foo",
+			"azureTenantId":      "00000000-0000-0000-0000-000000000000",
+			"azureClientId":      "00000000-0000-0000-0000-000000000000",
+			"azureClientSecret":  "passw0rd",
+			"bulkGetConcurrency": "3",
+		}
+		err := s.Init(context.Background(), m)
+		assert.Nil(t, err)
+		kv, ok := s.(*keyvaultSecretStore)
+		assert.True(t, ok)
+		assert.Equal(t, 3, kv.concurrency)
+
+		m.Properties["bulkGetConcurrency"] = "0"
+		err = s.Init(context.Background(), m)
+		assert.Error(t, err)
+	})
+	t.Run("Init with secondaryVaultName", func(t *testing.T) {
+		m.Properties = map[string]string{
+			"vaultName":          "foo",
+			"secondaryVaultName": "https://bar.vault.azure.net",
+			"azureTenantId":      "00000000-0000-0000-0000-000000000000",
+			"azureClientId":      "00000000-0000-0000-0000-000000000000",
+			"azureClientSecret":  "passw0rd",
+		}
+		err := s.Init(context.Background(), m)
+		assert.Nil(t, err)
+		kv, ok := s.(*keyvaultSecretStore)
+		assert.True(t, ok)
+		assert.Equal(t, "bar", kv.secondaryVaultName)
+		assert.Equal(t, "https://bar.vault.azure.net", kv.getSecondaryVaultURI())
+		assert.NotNil(t, kv.secondaryVaultClient)
+	})
 	t.Run("Init with valid metadata and Azure environment", func(t *testing.T) {
 		m.Properties = map[string]string{
 			"vaultName":         "foo",
@@ -93,8 +140,219 @@ func TestInit(t *testing.T) {
 func TestGetFeatures(t *testing.T) {
 	s := NewAzureKeyvaultSecretStore(logger.NewLogger("test"))
 	// Yes, we are skipping initialization as feature retrieval doesn't depend on it.
-	t.Run("no features are advertised", func(t *testing.T) {
+	t.Run("bulk get filter is advertised", func(t *testing.T) {
 		f := s.Features()
-		assert.Empty(t, f)
+		assert.True(t, secretstores.FeatureBulkGetFilter.IsPresent(f))
+		assert.False(t, secretstores.FeatureMultipleKeyValuesPerSecret.IsPresent(f))
+	})
+}
+
+func TestGetSecretValues(t *testing.T) {
+	names := make([]string, 50)
+	for i := range names {
+		names[i] = "secret" + strconv.Itoa(i)
+	}
+
+	t.Run("retrieves all values with limited concurrency", func(t *testing.T) {
+		var running, maxRunning atomic.Int32
+		values, err := getSecretValues(context.Background(), names, 4, func(ctx context.Context, name string) (string, error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			return "value-" + name, nil
+		})
+		require.NoError(t, err)
+		assert.Len(t, values, len(names))
+		assert.Equal(t, "value-secret7", values["secret7"])
+		assert.LessOrEqual(t, maxRunning.Load(), int32(4))
+	})
+
+	t.Run("stops at the first error", func(t *testing.T) {
+		var calls atomic.Int32
+		_, err := getSecretValues(context.Background(), names, 1, func(ctx context.Context, name string) (string, error) {
+			calls.Add(1)
+			if name == "secret2" {
+				return "", errors.New("forbidden")
+			}
+			return "", nil
+		})
+		require.ErrorContains(t, err, "failed to get secret secret2: forbidden")
+		assert.LessOrEqual(t, calls.Load(), int32(4))
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := getSecretValues(ctx, names, 2, func(ctx context.Context, name string) (string, error) {
+			return "", nil
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestWithFailover(t *testing.T) {
+	primary := &azsecrets.Client{}
+	secondary := &azsecrets.Client{}
+	unavailableErr := &azcore.ResponseError{StatusCode: http.StatusServiceUnavailable}
+
+	newStore := func(withSecondary bool) *keyvaultSecretStore {
+		k := &keyvaultSecretStore{
+			vaultName:      "primary",
+			vaultClient:    primary,
+			vaultDNSSuffix: "vault.azure.net",
+			logger:         logger.NewLogger("test"),
+		}
+		if withSecondary {
+			k.secondaryVaultName = "secondary"
+			k.secondaryVaultClient = secondary
+		}
+		return k
+	}
+
+	t.Run("primary vault is available", func(t *testing.T) {
+		res, err := withFailover(newStore(true), func(client *azsecrets.Client, vaultURI string) (string, error) {
+			assert.Same(t, primary, client)
+			return vaultURI, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "https://primary.vault.azure.net", res)
+	})
+
+	t.Run("fails over to the secondary vault", func(t *testing.T) {
+		res, err := withFailover(newStore(true), func(client *azsecrets.Client, vaultURI string) (string, error) {
+			if client == primary {
+				return "", unavailableErr
+			}
+			return vaultURI, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "https://secondary.vault.azure.net", res)
+	})
+
+	t.Run("both vaults are unavailable", func(t *testing.T) {
+		_, err := withFailover(newStore(true), func(client *azsecrets.Client, _ string) (string, error) {
+			if client == primary {
+				return "", unavailableErr
+			}
+			return "", context.DeadlineExceeded
+		})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, unavailableErr)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("client errors do not fail over", func(t *testing.T) {
+		notFoundErr := &azcore.ResponseError{StatusCode: http.StatusNotFound}
+		_, err := withFailover(newStore(true), func(client *azsecrets.Client, _ string) (string, error) {
+			assert.Same(t, primary, client)
+			return "", notFoundErr
+		})
+		assert.ErrorIs(t, err, notFoundErr)
+	})
+
+	t.Run("no secondary vault", func(t *testing.T) {
+		var calls int
+		_, err := withFailover(newStore(false), func(client *azsecrets.Client, _ string) (string, error) {
+			calls++
+			return "", unavailableErr
+		})
+		assert.ErrorIs(t, err, unavailableErr)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestIsUnavailableError(t *testing.T) {
+	assert.True(t, isUnavailableError(&azcore.ResponseError{StatusCode: http.StatusInternalServerError}))
+	assert.True(t, isUnavailableError(&azcore.ResponseError{StatusCode: http.StatusGatewayTimeout}))
+	assert.True(t, isUnavailableError(fmt.Errorf("request failed: %w", context.DeadlineExceeded)))
+	assert.False(t, isUnavailableError(&azcore.ResponseError{StatusCode: http.StatusForbidden}))
+	assert.False(t, isUnavailableError(&azcore.ResponseError{StatusCode: http.StatusTooManyRequests}))
+	assert.False(t, isUnavailableError(context.Canceled))
+}
+
+type fakeCredential struct{}
+
+func (fakeCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// fakeVaultTransport responds to the authentication challenge of the Key Vault client, then with the given status code.
+type fakeVaultTransport struct {
+	statusCode int
+	requests   atomic.Int32
+}
+
+func (f *fakeVaultTransport) Do(req *http.Request) (*http.Response, error) {
+	f.requests.Add(1)
+	header := http.Header{}
+	statusCode := f.statusCode
+	if req.Header.Get("Authorization") == "" {
+		statusCode = http.StatusUnauthorized
+		header.Set("WWW-Authenticate", `Bearer authorization="https://login.microsoftonline.com/tenant", resource="https://vault.azure.net"`)
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(`{"value":[]}`)),
+		Request:    req,
+	}, nil
+}
+
+func TestPing(t *testing.T) {
+	newClient := func(t *testing.T, vaultName string, transport *fakeVaultTransport) *azsecrets.Client {
+		client, err := azsecrets.NewClient("https://"+vaultName+".vault.azure.net", fakeCredential{}, &azsecrets.ClientOptions{
+			ClientOptions: azcore.ClientOptions{
+				Transport: transport,
+				Retry:     policy.RetryOptions{MaxRetries: -1},
+			},
+		})
+		require.NoError(t, err)
+		return client
+	}
+
+	t.Run("vault is reachable", func(t *testing.T) {
+		k := &keyvaultSecretStore{
+			vaultName:   "primary",
+			vaultClient: newClient(t, "primary", &fakeVaultTransport{statusCode: http.StatusOK}),
+			logger:      logger.NewLogger("test"),
+		}
+		assert.NoError(t, k.Ping(context.Background()))
+	})
+
+	t.Run("vault is unavailable", func(t *testing.T) {
+		k := &keyvaultSecretStore{
+			vaultName:   "primary",
+			vaultClient: newClient(t, "primary", &fakeVaultTransport{statusCode: http.StatusServiceUnavailable}),
+			logger:      logger.NewLogger("test"),
+		}
+		assert.Error(t, k.Ping(context.Background()))
+	})
+
+	t.Run("secondary vault is reachable", func(t *testing.T) {
+		secondary := &fakeVaultTransport{statusCode: http.StatusOK}
+		k := &keyvaultSecretStore{
+			vaultName:            "primary",
+			vaultClient:          newClient(t, "primary", &fakeVaultTransport{statusCode: http.StatusServiceUnavailable}),
+			secondaryVaultName:   "secondary",
+			secondaryVaultClient: newClient(t, "secondary", secondary),
+			logger:               logger.NewLogger("test"),
+		}
+		assert.NoError(t, k.Ping(context.Background()))
+		assert.Greater(t, secondary.requests.Load(), int32(0))
+	})
+
+	t.Run("not authorized", func(t *testing.T) {
+		k := &keyvaultSecretStore{
+			vaultName:   "primary",
+			vaultClient: newClient(t, "primary", &fakeVaultTransport{statusCode: http.StatusForbidden}),
+			logger:      logger.NewLogger("test"),
+		}
+		assert.Error(t, k.Ping(context.Background()))
 	})
 }
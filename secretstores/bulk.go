@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstores
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// BulkGetPrefixKey is the request metadata key for filtering secrets by name prefix.
+	BulkGetPrefixKey = "prefix"
+	// BulkGetLabelKeyPrefix is the prefix of request metadata keys that filter secrets by label.
+	// For example, "label.env=prod" only returns secrets that have the label "env" set to "prod".
+	BulkGetLabelKeyPrefix = "label."
+	// BulkGetPageSizeKey is the request metadata key for the maximum number of secrets returned.
+	BulkGetPageSizeKey = "pageSize"
+	// BulkGetPageTokenKey is the request metadata key for the token of the page to return.
+	BulkGetPageTokenKey = "pageToken"
+)
+
+// BulkGetSecretFilter contains the options for filtering and paginating BulkGetSecret requests.
+type BulkGetSecretFilter struct {
+	// Prefix only returns secrets whose name starts with this value.
+	Prefix string `json:"prefix,omitempty"`
+	// Labels only returns secrets that have all these labels (or tags) set to the given values.
+	Labels map[string]string `json:"labels,omitempty"`
+	// PageSize is the maximum number of secrets to return. A value of 0 returns all secrets.
+	PageSize int `json:"pageSize,omitempty"`
+	// PageToken is the token returned by a previous request as NextPageToken.
+	PageToken string `json:"pageToken,omitempty"`
+}
+
+// GetFilter returns the filter for the request, merging the Filter field with the values set in the request metadata.
+// Values set in the Filter field take precedence.
+func (r BulkGetSecretRequest) GetFilter() (BulkGetSecretFilter, error) {
+	f := BulkGetSecretFilter{
+		Prefix:    r.Filter.Prefix,
+		PageSize:  r.Filter.PageSize,
+		PageToken: r.Filter.PageToken,
+	}
+	if len(r.Filter.Labels) > 0 {
+		f.Labels = make(map[string]string, len(r.Filter.Labels))
+		for k, v := range r.Filter.Labels {
+			f.Labels[k] = v
+		}
+	}
+
+	for k, v := range r.Metadata {
+		switch {
+		case k == BulkGetPrefixKey:
+			if f.Prefix == "" {
+				f.Prefix = v
+			}
+		case k == BulkGetPageTokenKey:
+			if f.PageToken == "" {
+				f.PageToken = v
+			}
+		case k == BulkGetPageSizeKey:
+			if f.PageSize != 0 || v == "" {
+				continue
+			}
+			size, err := strconv.Atoi(v)
+			if err != nil || size < 0 {
+				return f, fmt.Errorf("invalid value for %s: '%s'", BulkGetPageSizeKey, v)
+			}
+			f.PageSize = size
+		case strings.HasPrefix(k, BulkGetLabelKeyPrefix) && len(k) > len(BulkGetLabelKeyPrefix):
+			if f.Labels == nil {
+				f.Labels = map[string]string{}
+			}
+			label := k[len(BulkGetLabelKeyPrefix):]
+			if _, ok := f.Labels[label]; !ok {
+				f.Labels[label] = v
+			}
+		}
+	}
+
+	if f.PageSize < 0 {
+		return f, fmt.Errorf("invalid value for %s: %d", BulkGetPageSizeKey, f.PageSize)
+	}
+
+	return f, nil
+}
+
+// IsEmpty returns true if the filter doesn't limit the results in any way.
+func (f BulkGetSecretFilter) IsEmpty() bool {
+	return f.Prefix == "" && len(f.Labels) == 0 && f.PageSize == 0 && f.PageToken == ""
+}
+
+// Match returns true if a secret with the given name and labels matches the prefix and label conditions of the filter.
+func (f BulkGetSecretFilter) Match(name string, labels map[string]string) bool {
+	if !strings.HasPrefix(name, f.Prefix) {
+		return false
+	}
+	for k, v := range f.Labels {
+		if lv, ok := labels[k]; !ok || lv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Remaining sorts the names and returns the ones that come after the page token.
+// It is meant for secret stores that cannot paginate natively; since names are sorted, tokens
+// remain valid across requests as long as the secrets are not renamed.
+func (f BulkGetSecretFilter) Remaining(names []string) ([]string, error) {
+	sorted := make([]string, len(names))
+	copy(sorted, names)
+	sort.Strings(sorted)
+
+	if f.PageToken == "" {
+		return sorted, nil
+	}
+	last, err := base64.RawURLEncoding.DecodeString(f.PageToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page token: %w", err)
+	}
+	start := sort.Search(len(sorted), func(i int) bool {
+		return sorted[i] > string(last)
+	})
+	return sorted[start:], nil
+}
+
+// NewPageToken returns the token of the page that starts after the secret with the given name.
+// It is the counterpart of Remaining.
+func NewPageToken(lastName string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastName))
+}
+
+// Paginate returns the page of names selected by the filter's page size and token, and the token for the next page.
+func (f BulkGetSecretFilter) Paginate(names []string) (page []string, nextPageToken string, err error) {
+	remaining, err := f.Remaining(names)
+	if err != nil {
+		return nil, "", err
+	}
+	if f.PageSize == 0 || len(remaining) <= f.PageSize {
+		return remaining, "", nil
+	}
+	page = remaining[:f.PageSize]
+	return page, NewPageToken(page[len(page)-1]), nil
+}
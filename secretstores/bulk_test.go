@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstores
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFilter(t *testing.T) {
+	t.Run("from metadata", func(t *testing.T) {
+		f, err := BulkGetSecretRequest{
+			Metadata: map[string]string{
+				"prefix":    "app/",
+				"pageSize":  "10",
+				"pageToken": "abc",
+				"label.env": "prod",
+				"other":     "ignored",
+			},
+		}.GetFilter()
+		require.NoError(t, err)
+		assert.Equal(t, "app/", f.Prefix)
+		assert.Equal(t, 10, f.PageSize)
+		assert.Equal(t, "abc", f.PageToken)
+		assert.Equal(t, map[string]string{"env": "prod"}, f.Labels)
+	})
+
+	t.Run("fields take precedence", func(t *testing.T) {
+		f, err := BulkGetSecretRequest{
+			Metadata: map[string]string{"prefix": "a", "pageSize": "10", "label.env": "prod"},
+			Filter:   BulkGetSecretFilter{Prefix: "b", PageSize: 5, Labels: map[string]string{"env": "dev"}},
+		}.GetFilter()
+		require.NoError(t, err)
+		assert.Equal(t, "b", f.Prefix)
+		assert.Equal(t, 5, f.PageSize)
+		assert.Equal(t, "dev", f.Labels["env"])
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		f, err := BulkGetSecretRequest{}.GetFilter()
+		require.NoError(t, err)
+		assert.True(t, f.IsEmpty())
+	})
+
+	t.Run("invalid page size", func(t *testing.T) {
+		_, err := BulkGetSecretRequest{Metadata: map[string]string{"pageSize": "abc"}}.GetFilter()
+		assert.Error(t, err)
+		_, err = BulkGetSecretRequest{Metadata: map[string]string{"pageSize": "-1"}}.GetFilter()
+		assert.Error(t, err)
+	})
+}
+
+func TestFilterMatch(t *testing.T) {
+	f := BulkGetSecretFilter{Prefix: "app/", Labels: map[string]string{"env": "prod"}}
+	assert.True(t, f.Match("app/db", map[string]string{"env": "prod", "team": "a"}))
+	assert.False(t, f.Match("app/db", map[string]string{"env": "dev"}))
+	assert.False(t, f.Match("app/db", nil))
+	assert.False(t, f.Match("other/db", map[string]string{"env": "prod"}))
+	assert.True(t, BulkGetSecretFilter{}.Match("anything", nil))
+}
+
+func TestFilterPaginate(t *testing.T) {
+	names := []string{"e", "c", "a", "d", "b"}
+
+	t.Run("no page size", func(t *testing.T) {
+		page, next, err := BulkGetSecretFilter{}.Paginate(names)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c", "d", "e"}, page)
+		assert.Empty(t, next)
+	})
+
+	t.Run("walk all pages", func(t *testing.T) {
+		f := BulkGetSecretFilter{PageSize: 2}
+		all := []string{}
+		for i := 0; i < 10; i++ {
+			page, next, err := f.Paginate(names)
+			require.NoError(t, err)
+			all = append(all, page...)
+			if next == "" {
+				break
+			}
+			f.PageToken = next
+		}
+		assert.Equal(t, []string{"a", "b", "c", "d", "e"}, all)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		_, _, err := BulkGetSecretFilter{PageToken: "!!"}.Paginate(names)
+		assert.Error(t, err)
+	})
+}
@@ -23,6 +23,8 @@ type Feature string
 const (
 	// FeatureMultipleKeyValuesPerSecret advertises that this SecretStore supports multiple keys-values under a single secret.
 	FeatureMultipleKeyValuesPerSecret Feature = "MULTIPLE_KEY_VALUES_PER_SECRET"
+	// FeatureBulkGetFilter advertises that this SecretStore supports filtering and paginating the results of BulkGetSecret.
+	FeatureBulkGetFilter Feature = "BULK_GET_FILTER"
 )
 
 // IsPresent checks if a given feature is present in the list.
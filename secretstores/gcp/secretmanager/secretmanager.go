@@ -18,9 +18,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"golang.org/x/exp/maps"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 
@@ -127,6 +130,11 @@ func (s *Store) GetSecret(ctx context.Context, req secretstores.GetSecretRequest
 func (s *Store) BulkGetSecret(ctx context.Context, req secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
 	versionID := "latest"
 
+	filter, err := req.GetFilter()
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{Data: nil}, err
+	}
+
 	response := map[string]map[string]string{}
 
 	if s.client == nil {
@@ -135,21 +143,42 @@ func (s *Store) BulkGetSecret(ctx context.Context, req secretstores.BulkGetSecre
 
 	request := &secretmanagerpb.ListSecretsRequest{
 		Parent: fmt.Sprintf("projects/%s", s.ProjectID),
+		Filter: listSecretsFilter(filter),
 	}
 	it := s.client.ListSecrets(ctx, request)
 
-	for {
-		resp, err := it.Next()
-
-		if err == iterator.Done {
-			break
-		}
-
+	var (
+		secrets       []*secretmanagerpb.Secret
+		nextPageToken string
+	)
+	if filter.PageSize > 0 {
+		nextPageToken, err = iterator.NewPager(it, filter.PageSize, filter.PageToken).NextPage(&secrets)
 		if err != nil {
 			return secretstores.BulkGetSecretResponse{Data: nil}, fmt.Errorf("failed to list secrets: %v", err)
 		}
+	} else {
+		for {
+			resp, err := it.Next()
+
+			if err == iterator.Done {
+				break
+			}
 
+			if err != nil {
+				return secretstores.BulkGetSecretResponse{Data: nil}, fmt.Errorf("failed to list secrets: %v", err)
+			}
+
+			secrets = append(secrets, resp)
+		}
+	}
+
+	for _, resp := range secrets {
 		name := resp.GetName()
+		// The prefix is matched against the short name of the secret, not the full resource name
+		if !filter.Match(name[strings.LastIndex(name, "/")+1:], resp.GetLabels()) {
+			continue
+		}
+
 		secret, err := s.getSecret(ctx, name, versionID)
 		if err != nil {
 			return secretstores.BulkGetSecretResponse{Data: nil}, fmt.Errorf("failed to access secret version: %v", err)
@@ -157,7 +186,22 @@ func (s *Store) BulkGetSecret(ctx context.Context, req secretstores.BulkGetSecre
 		response[name] = map[string]string{name: *secret}
 	}
 
-	return secretstores.BulkGetSecretResponse{Data: response}, nil
+	return secretstores.BulkGetSecretResponse{Data: response, NextPageToken: nextPageToken}, nil
+}
+
+// listSecretsFilter returns the server-side filter expression for the request's filter.
+// Results are matched again by the store since the "name" filter matches substrings rather than prefixes.
+func listSecretsFilter(filter secretstores.BulkGetSecretFilter) string {
+	conditions := make([]string, 0, len(filter.Labels)+1)
+	if filter.Prefix != "" {
+		conditions = append(conditions, "name:"+filter.Prefix)
+	}
+	labels := maps.Keys(filter.Labels)
+	sort.Strings(labels)
+	for _, k := range labels {
+		conditions = append(conditions, fmt.Sprintf("labels.%s=%s", k, filter.Labels[k]))
+	}
+	return strings.Join(conditions, " AND ")
 }
 
 func (s *Store) getSecret(ctx context.Context, secretName string, versionID string) (*string, error) {
@@ -200,7 +244,7 @@ func (s *Store) Close() error {
 
 // Features returns the features available in this secret store.
 func (s *Store) Features() []secretstores.Feature {
-	return []secretstores.Feature{} // No Feature supported.
+	return []secretstores.Feature{secretstores.FeatureBulkGetFilter}
 }
 
 func (s *Store) GetComponentMetadata() map[string]string {
@@ -184,8 +184,17 @@ func TestBulkGetSecret(t *testing.T) {
 func TestGetFeatures(t *testing.T) {
 	s := NewSecreteManager(logger.NewLogger("test"))
 	// Yes, we are skipping initialization as feature retrieval doesn't depend on it.
-	t.Run("no features are advertised", func(t *testing.T) {
+	t.Run("bulk get filter is advertised", func(t *testing.T) {
 		f := s.Features()
-		assert.Empty(t, f)
+		assert.True(t, secretstores.FeatureBulkGetFilter.IsPresent(f))
+		assert.False(t, secretstores.FeatureMultipleKeyValuesPerSecret.IsPresent(f))
 	})
 }
+
+func TestListSecretsFilter(t *testing.T) {
+	assert.Empty(t, listSecretsFilter(secretstores.BulkGetSecretFilter{}))
+	assert.Equal(t, "name:app AND labels.env=prod AND labels.team=a", listSecretsFilter(secretstores.BulkGetSecretFilter{
+		Prefix: "app",
+		Labels: map[string]string{"team": "a", "env": "prod"},
+	}))
+}
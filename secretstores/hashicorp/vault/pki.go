@@ -0,0 +1,200 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultVaultPKIEnginePath string = "pki"
+
+	// Request metadata keys for issuing certificates from the PKI engine.
+	pkiRoleKey       string = "pkiRole"
+	pkiCommonNameKey string = "commonName"
+	pkiTTLKey        string = "ttl"
+	pkiAltNamesKey   string = "altNames"
+	pkiIPSANsKey     string = "ipSans"
+	pkiURISANsKey    string = "uriSans"
+
+	// Keys of the secret returned for an issued certificate.
+	PKICertificateKey    string = "certificate"
+	PKIPrivateKeyKey     string = "private_key"
+	PKIPrivateKeyTypeKey string = "private_key_type"
+	PKIIssuingCAKey      string = "issuing_ca"
+	PKICAChainKey        string = "ca_chain"
+	PKISerialNumberKey   string = "serial_number"
+	PKIExpirationKey     string = "expiration"
+
+	// Issued certificates are renewed after this fraction of their lifetime has elapsed.
+	pkiRenewalFraction = 2.0 / 3.0
+)
+
+// pkiIssueRequest is the request to issue a certificate from Vault PKI.
+type pkiIssueRequest struct {
+	CommonName string `json:"common_name"`
+	TTL        string `json:"ttl,omitempty"`
+	AltNames   string `json:"alt_names,omitempty"`
+	IPSANs     string `json:"ip_sans,omitempty"`
+	URISANs    string `json:"uri_sans,omitempty"`
+}
+
+// pkiIssueResponse is the response data from Vault PKI.
+type pkiIssueResponse struct {
+	Data struct {
+		Certificate    string   `json:"certificate"`
+		PrivateKey     string   `json:"private_key"`
+		PrivateKeyType string   `json:"private_key_type"`
+		IssuingCA      string   `json:"issuing_ca"`
+		CAChain        []string `json:"ca_chain"`
+		SerialNumber   string   `json:"serial_number"`
+		Expiration     int64    `json:"expiration"`
+	} `json:"data"`
+}
+
+// issuedCertificate is a certificate issued by Vault PKI, cached until it's due for renewal.
+type issuedCertificate struct {
+	data    map[string]string
+	renewAt time.Time
+}
+
+// pkiCertificateCache caches the certificates issued by Vault PKI.
+type pkiCertificateCache struct {
+	lock  sync.Mutex
+	certs map[string]*issuedCertificate
+	// Locks held while issuing a certificate, so concurrent requests for the same certificate issue it only once.
+	issuing map[string]*sync.Mutex
+}
+
+func newPKICertificateCache() *pkiCertificateCache {
+	return &pkiCertificateCache{
+		certs:   make(map[string]*issuedCertificate),
+		issuing: make(map[string]*sync.Mutex),
+	}
+}
+
+// isPKIRequest returns true if the metadata of the request asks for a certificate from the PKI engine.
+func isPKIRequest(reqMetadata map[string]string) bool {
+	return reqMetadata[pkiRoleKey] != ""
+}
+
+// getCertificate returns a certificate issued by the PKI engine for the role in the request metadata.
+// The common name is the name of the secret, unless it's overridden with the "commonName" metadata.
+// Certificates are cached and a new one is issued when the cached one has gone through most of its lifetime.
+func (v *vaultSecretStore) getCertificate(ctx context.Context, name string, reqMetadata map[string]string, namespace string) (map[string]string, error) {
+	req := pkiIssueRequest{
+		CommonName: name,
+		TTL:        reqMetadata[pkiTTLKey],
+		AltNames:   reqMetadata[pkiAltNamesKey],
+		IPSANs:     reqMetadata[pkiIPSANsKey],
+		URISANs:    reqMetadata[pkiURISANsKey],
+	}
+	if cn := reqMetadata[pkiCommonNameKey]; cn != "" {
+		req.CommonName = cn
+	}
+	role := reqMetadata[pkiRoleKey]
+
+	cacheKey := strings.Join([]string{namespace, role, req.CommonName, req.TTL, req.AltNames, req.IPSANs, req.URISANs}, "\x00")
+
+	v.pkiCache.lock.Lock()
+	issuingLock, ok := v.pkiCache.issuing[cacheKey]
+	if !ok {
+		issuingLock = &sync.Mutex{}
+		v.pkiCache.issuing[cacheKey] = issuingLock
+	}
+	v.pkiCache.lock.Unlock()
+
+	issuingLock.Lock()
+	defer issuingLock.Unlock()
+
+	v.pkiCache.lock.Lock()
+	cached, ok := v.pkiCache.certs[cacheKey]
+	v.pkiCache.lock.Unlock()
+	if ok && time.Now().Before(cached.renewAt) {
+		return cached.data, nil
+	}
+
+	issued, err := v.issueCertificate(ctx, role, req, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	v.pkiCache.lock.Lock()
+	v.pkiCache.certs[cacheKey] = issued
+	v.pkiCache.lock.Unlock()
+
+	return issued.data, nil
+}
+
+// issueCertificate issues a new certificate from the PKI engine.
+func (v *vaultSecretStore) issueCertificate(ctx context.Context, role string, req pkiIssueRequest, namespace string) (*issuedCertificate, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	issueAddr := v.vaultAddress + "/v1/" + v.vaultPKIEnginePath + "/issue/" + role
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, issueAddr, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't generate request: %w", err)
+	}
+	v.setRequestHeaders(httpReq, namespace)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	issuedAt := time.Now()
+	httpresp, err := v.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't issue certificate: %w", err)
+	}
+
+	defer httpresp.Body.Close()
+
+	if httpresp.StatusCode != http.StatusOK {
+		var b bytes.Buffer
+		io.Copy(&b, httpresp.Body)
+		v.logger.Debugf("issueCertificate %s for role %s couldn't get successful response: %#v, %s", req.CommonName, role, httpresp, b.String())
+		return nil, fmt.Errorf("couldn't issue certificate, status code %d, body %s",
+			httpresp.StatusCode, b.String())
+	}
+
+	var d pkiIssueResponse
+	if err := json.NewDecoder(httpresp.Body).Decode(&d); err != nil {
+		return nil, fmt.Errorf("couldn't decode response body: %s", err)
+	}
+
+	expiration := time.Unix(d.Data.Expiration, 0)
+	lifetime := expiration.Sub(issuedAt)
+
+	return &issuedCertificate{
+		data: map[string]string{
+			PKICertificateKey:    d.Data.Certificate,
+			PKIPrivateKeyKey:     d.Data.PrivateKey,
+			PKIPrivateKeyTypeKey: d.Data.PrivateKeyType,
+			PKIIssuingCAKey:      d.Data.IssuingCA,
+			PKICAChainKey:        strings.Join(d.Data.CAChain, "\n"),
+			PKISerialNumberKey:   d.Data.SerialNumber,
+			PKIExpirationKey:     strconv.FormatInt(d.Data.Expiration, 10),
+		},
+		renewAt: issuedAt.Add(time.Duration(float64(lifetime) * pkiRenewalFraction)),
+	}, nil
+}
@@ -52,9 +52,11 @@ const (
 	defaultVaultKVPrefix         string = "dapr"
 	vaultHTTPHeader              string = "X-Vault-Token"
 	vaultHTTPRequestHeader       string = "X-Vault-Request"
+	vaultHTTPNamespaceHeader     string = "X-Vault-Namespace"
 	vaultEnginePath              string = "enginePath"
 	vaultValueType               string = "vaultValueType"
 	versionID                    string = "version_id"
+	namespaceKey                 string = "namespace"
 
 	DataStr string = "data"
 )
@@ -74,6 +76,8 @@ func (v valueType) isMapType() bool {
 
 var ErrNotFound = errors.New("secret key or version not exist")
 
+var ErrNamespaceNotAllowed = errors.New("vault namespace is not allowed")
+
 // vaultSecretStore is a secret store implementation for HashiCorp Vault.
 type vaultSecretStore struct {
 	client              *http.Client
@@ -83,6 +87,12 @@ type vaultSecretStore struct {
 	vaultKVPrefix       string
 	vaultEnginePath     string
 	vaultValueType      valueType
+	vaultNamespace      string
+	// Namespaces that requests can select with the "namespace" metadata, in addition to vaultNamespace.
+	allowedNamespaces map[string]struct{}
+	// Mount path of the PKI engine that issues certificates.
+	vaultPKIEnginePath string
+	pkiCache           *pkiCertificateCache
 
 	json jsoniter.API
 
@@ -102,6 +112,12 @@ type VaultMetadata struct {
 	VaultTokenMountPath string
 	EnginePath          string
 	VaultValueType      string
+	// Vault Enterprise namespace of the requests.
+	VaultNamespace string
+	// Comma-separated list of the namespaces that requests can select with the "namespace" metadata.
+	AllowedVaultNamespaces string
+	// Mount path of the PKI engine, used when requests ask for a certificate with the "pkiRole" metadata.
+	PKIEnginePath string
 }
 
 // tlsConfig is TLS configuration to interact with HashiCorp Vault.
@@ -116,7 +132,10 @@ type tlsConfig struct {
 // vaultKVResponse is the response data from Vault KV.
 type vaultKVResponse struct {
 	Data struct {
-		Data map[string]string `json:"data"`
+		Data     map[string]string `json:"data"`
+		Metadata struct {
+			CustomMetadata map[string]string `json:"custom_metadata"`
+		} `json:"metadata"`
 	} `json:"data"`
 }
 
@@ -130,9 +149,10 @@ type vaultListKVResponse struct {
 // NewHashiCorpVaultSecretStore returns a new HashiCorp Vault secret store.
 func NewHashiCorpVaultSecretStore(logger logger.Logger) secretstores.SecretStore {
 	return &vaultSecretStore{
-		client: &http.Client{},
-		logger: logger,
-		json:   jsoniter.ConfigFastest,
+		client:   &http.Client{},
+		pkiCache: newPKICertificateCache(),
+		logger:   logger,
+		json:     jsoniter.ConfigFastest,
 	}
 }
 
@@ -159,6 +179,11 @@ func (v *vaultSecretStore) Init(_ context.Context, meta secretstores.Metadata) e
 		v.vaultEnginePath = m.EnginePath
 	}
 
+	v.vaultPKIEnginePath = defaultVaultPKIEnginePath
+	if m.PKIEnginePath != "" {
+		v.vaultPKIEnginePath = strings.Trim(m.PKIEnginePath, "/")
+	}
+
 	v.vaultValueType = valueTypeMap
 	if m.VaultValueType != "" {
 		switch valueType(m.VaultValueType) {
@@ -177,6 +202,15 @@ func (v *vaultSecretStore) Init(_ context.Context, meta secretstores.Metadata) e
 		return initErr
 	}
 
+	v.vaultNamespace = normalizeNamespace(m.VaultNamespace)
+	v.allowedNamespaces = make(map[string]struct{})
+	for _, ns := range strings.Split(m.AllowedVaultNamespaces, ",") {
+		ns = normalizeNamespace(ns)
+		if ns != "" {
+			v.allowedNamespaces[ns] = struct{}{}
+		}
+	}
+
 	vaultKVPrefix := m.VaultKVPrefix
 	if !m.VaultKVUsePrefix {
 		vaultKVPrefix = ""
@@ -216,8 +250,42 @@ func metadataToTLSConfig(meta *VaultMetadata) *tlsConfig {
 	return &tlsConf
 }
 
+// normalizeNamespace removes the whitespaces and the slashes around a namespace path.
+func normalizeNamespace(ns string) string {
+	return strings.Trim(strings.TrimSpace(ns), "/")
+}
+
+// requestNamespace returns the namespace of a request, which is the one of the component unless it's overridden with the "namespace" metadata.
+// Only the namespaces in the allowlist of the component can be selected by requests.
+func (v *vaultSecretStore) requestNamespace(reqMetadata map[string]string) (string, error) {
+	ns, ok := reqMetadata[namespaceKey]
+	if !ok {
+		return v.vaultNamespace, nil
+	}
+	ns = normalizeNamespace(ns)
+	if ns == v.vaultNamespace {
+		return ns, nil
+	}
+	if _, ok = v.allowedNamespaces[ns]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrNamespaceNotAllowed, ns)
+	}
+	return ns, nil
+}
+
+// setRequestHeaders sets the headers of the requests to Vault.
+func (v *vaultSecretStore) setRequestHeaders(httpReq *http.Request, namespace string) {
+	// Set vault token.
+	httpReq.Header.Set(vaultHTTPHeader, v.vaultToken)
+	// Set X-Vault-Request header
+	httpReq.Header.Set(vaultHTTPRequestHeader, "true")
+	// Set X-Vault-Namespace header
+	if namespace != "" {
+		httpReq.Header.Set(vaultHTTPNamespaceHeader, namespace)
+	}
+}
+
 // GetSecret retrieves a secret using a key and returns a map of decrypted string/string values.
-func (v *vaultSecretStore) getSecret(ctx context.Context, secret, version string) (*vaultKVResponse, error) {
+func (v *vaultSecretStore) getSecret(ctx context.Context, secret, version, namespace string) (*vaultKVResponse, error) {
 	// Create get secret url
 	var vaultSecretPathAddr string
 	if v.vaultKVPrefix == "" {
@@ -230,10 +298,7 @@ func (v *vaultSecretStore) getSecret(ctx context.Context, secret, version string
 	if err != nil {
 		return nil, fmt.Errorf("couldn't generate request: %w", err)
 	}
-	// Set vault token.
-	httpReq.Header.Set(vaultHTTPHeader, v.vaultToken)
-	// Set X-Vault-Request header
-	httpReq.Header.Set(vaultHTTPRequestHeader, "true")
+	v.setRequestHeaders(httpReq, namespace)
 
 	httpresp, err := v.client.Do(httpReq)
 	if err != nil {
@@ -272,19 +337,32 @@ func (v *vaultSecretStore) getSecret(ctx context.Context, secret, version string
 		d.Data.Data = map[string]string{
 			secret: res,
 		}
+		v.json.Get(b, DataStr, "metadata", "custom_metadata").ToVal(&d.Data.Metadata.CustomMetadata)
 	}
 
 	return &d, nil
 }
 
 // GetSecret retrieves a secret using a key and returns a map of decrypted string/string values.
+// If the request has the "pkiRole" metadata, it instead returns a certificate issued by the PKI engine for that role.
 func (v *vaultSecretStore) GetSecret(ctx context.Context, req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
 	// version 0 represent for latest version
 	version := "0"
 	if value, ok := req.Metadata[versionID]; ok {
 		version = value
 	}
-	d, err := v.getSecret(ctx, req.Name, version)
+	namespace, err := v.requestNamespace(req.Metadata)
+	if err != nil {
+		return secretstores.GetSecretResponse{Data: nil}, err
+	}
+	if isPKIRequest(req.Metadata) {
+		data, err := v.getCertificate(ctx, req.Name, req.Metadata, namespace)
+		if err != nil {
+			return secretstores.GetSecretResponse{Data: nil}, err
+		}
+		return secretstores.GetSecretResponse{Data: data}, nil
+	}
+	d, err := v.getSecret(ctx, req.Name, version, namespace)
 	if err != nil {
 		return secretstores.GetSecretResponse{Data: nil}, err
 	}
@@ -303,18 +381,43 @@ func (v *vaultSecretStore) BulkGetSecret(ctx context.Context, req secretstores.B
 		version = value
 	}
 
+	namespace, err := v.requestNamespace(req.Metadata)
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{}, err
+	}
+
+	filter, err := req.GetFilter()
+	if err != nil {
+		return secretstores.BulkGetSecretResponse{}, err
+	}
+
 	resp := secretstores.BulkGetSecretResponse{
 		Data: map[string]map[string]string{},
 	}
 
-	keys, err := v.listKeysUnderPath(ctx, "")
+	// Listing is done under the longest folder that contains the prefix, so large trees aren't fully traversed
+	listPath := ""
+	if idx := strings.LastIndex(filter.Prefix, "/"); idx >= 0 {
+		listPath = filter.Prefix[:idx+1]
+	}
+	keys, err := v.listKeysUnderPath(ctx, listPath, namespace)
+	if err != nil {
+		if listPath != "" && errors.Is(err, ErrNotFound) {
+			return resp, nil
+		}
+		return secretstores.BulkGetSecretResponse{}, err
+	}
+	keys, err = filter.Remaining(keys)
 	if err != nil {
 		return secretstores.BulkGetSecretResponse{}, err
 	}
 
 	for _, key := range keys {
-		keyValues := map[string]string{}
-		secrets, err := v.getSecret(ctx, key, version)
+		if !strings.HasPrefix(key, filter.Prefix) {
+			continue
+		}
+
+		secrets, err := v.getSecret(ctx, key, version, namespace)
 		if err != nil {
 			if errors.Is(err, ErrNotFound) {
 				// version not exist skip
@@ -324,6 +427,16 @@ func (v *vaultSecretStore) BulkGetSecret(ctx context.Context, req secretstores.B
 			return secretstores.BulkGetSecretResponse{Data: nil}, err
 		}
 
+		if !filter.Match(key, secrets.Data.Metadata.CustomMetadata) {
+			continue
+		}
+		if filter.PageSize > 0 && len(resp.Data) == filter.PageSize {
+			// There's at least one more matching secret, so the page ends at the last key added
+			resp.NextPageToken = secretstores.NewPageToken(lastKey(resp.Data))
+			break
+		}
+
+		keyValues := map[string]string{}
 		for k, v := range secrets.Data.Data {
 			keyValues[k] = v
 		}
@@ -333,9 +446,20 @@ func (v *vaultSecretStore) BulkGetSecret(ctx context.Context, req secretstores.B
 	return resp, nil
 }
 
+// lastKey returns the greatest key of the map in lexicographical order.
+func lastKey(m map[string]map[string]string) string {
+	last := ""
+	for k := range m {
+		if k > last {
+			last = k
+		}
+	}
+	return last
+}
+
 // listKeysUnderPath get all the keys recursively under a given path.(returned keys including path as prefix)
 // path should not has `/` prefix.
-func (v *vaultSecretStore) listKeysUnderPath(ctx context.Context, path string) ([]string, error) {
+func (v *vaultSecretStore) listKeysUnderPath(ctx context.Context, path, namespace string) ([]string, error) {
 	var vaultSecretsPathAddr string
 
 	// Create list secrets url
@@ -349,10 +473,7 @@ func (v *vaultSecretStore) listKeysUnderPath(ctx context.Context, path string) (
 	if err != nil {
 		return nil, fmt.Errorf("couldn't generate request: %s", err)
 	}
-	// Set vault token.
-	httpReq.Header.Set(vaultHTTPHeader, v.vaultToken)
-	// Set X-Vault-Request header
-	httpReq.Header.Set(vaultHTTPRequestHeader, "true")
+	v.setRequestHeaders(httpReq, namespace)
 	httpresp, err := v.client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("couldn't get secret: %s", err)
@@ -364,6 +485,9 @@ func (v *vaultSecretStore) listKeysUnderPath(ctx context.Context, path string) (
 		var b bytes.Buffer
 		io.Copy(&b, httpresp.Body)
 		v.logger.Debugf("list keys couldn't get successful response: %#v, %s", httpresp, b.String())
+		if httpresp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("list keys under path %s failed %w", path, ErrNotFound)
+		}
 
 		return nil, fmt.Errorf("list keys couldn't get successful response, status code: %d, status: %s, response %s",
 			httpresp.StatusCode, httpresp.Status, b.String())
@@ -379,7 +503,7 @@ func (v *vaultSecretStore) listKeysUnderPath(ctx context.Context, path string) (
 		if v.isSecretPath(key) {
 			res = append(res, path+key)
 		} else {
-			subKeys, err := v.listKeysUnderPath(ctx, path+key)
+			subKeys, err := v.listKeysUnderPath(ctx, path+key, namespace)
 			if err != nil {
 				return nil, err
 			}
@@ -525,10 +649,13 @@ func readCertificateFolder(certPool *x509.CertPool, path string) error {
 // Features returns the features available in this secret store.
 func (v *vaultSecretStore) Features() []secretstores.Feature {
 	if v.vaultValueType == valueTypeText {
-		return []secretstores.Feature{}
+		return []secretstores.Feature{secretstores.FeatureBulkGetFilter}
 	}
 
-	return []secretstores.Feature{secretstores.FeatureMultipleKeyValuesPerSecret}
+	return []secretstores.Feature{
+		secretstores.FeatureMultipleKeyValuesPerSecret,
+		secretstores.FeatureBulkGetFilter,
+	}
 }
 
 func (v *vaultSecretStore) GetComponentMetadata() map[string]string {
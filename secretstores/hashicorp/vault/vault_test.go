@@ -16,11 +16,20 @@ package vault
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	jsoniter "github.com/json-iterator/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/maps"
 
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/secretstores"
@@ -455,3 +464,215 @@ func TestGetFeatures(t *testing.T) {
 		assert.False(t, secretstores.FeatureMultipleKeyValuesPerSecret.IsPresent(f))
 	})
 }
+
+func TestBulkGetSecretFilter(t *testing.T) {
+	secrets := map[string]map[string]string{
+		"app/a":   {"env": "prod"},
+		"app/b":   {"env": "dev"},
+		"app/c":   {"env": "prod"},
+		"other/d": {"env": "prod"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "LIST" && r.URL.Path == "/v1/secret/metadata/dapr/":
+			w.Write([]byte(`{"data":{"keys":["app/","other/"]}}`))
+		case r.Method == "LIST" && r.URL.Path == "/v1/secret/metadata/dapr/app/":
+			w.Write([]byte(`{"data":{"keys":["a","b","c"]}}`))
+		case r.Method == "LIST" && r.URL.Path == "/v1/secret/metadata/dapr/other/":
+			w.Write([]byte(`{"data":{"keys":["d"]}}`))
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/secret/data/dapr/"):
+			name := strings.TrimPrefix(r.URL.Path, "/v1/secret/data/dapr/")
+			labels, ok := secrets[name]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"data": map[string]any{
+					"data":     map[string]string{"value": name},
+					"metadata": map[string]any{"custom_metadata": labels},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	store := &vaultSecretStore{
+		client:          server.Client(),
+		vaultAddress:    server.URL,
+		vaultKVPrefix:   defaultVaultKVPrefix,
+		vaultEnginePath: defaultVaultEnginePath,
+		vaultValueType:  valueTypeMap,
+		json:            jsoniter.ConfigFastest,
+		logger:          logger.NewLogger("test"),
+	}
+
+	t.Run("prefix and labels", func(t *testing.T) {
+		resp, err := store.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{
+			Metadata: map[string]string{"prefix": "app/", "label.env": "prod"},
+		})
+		require.NoError(t, err)
+		assert.Len(t, resp.Data, 2)
+		assert.Contains(t, resp.Data, "app/a")
+		assert.Contains(t, resp.Data, "app/c")
+		assert.Empty(t, resp.NextPageToken)
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		req := secretstores.BulkGetSecretRequest{
+			Filter: secretstores.BulkGetSecretFilter{PageSize: 3},
+		}
+		resp, err := store.BulkGetSecret(context.Background(), req)
+		require.NoError(t, err)
+		assert.Len(t, resp.Data, 3)
+		require.NotEmpty(t, resp.NextPageToken)
+
+		req.Filter.PageToken = resp.NextPageToken
+		resp, err = store.BulkGetSecret(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"other/d"}, maps.Keys(resp.Data))
+		assert.Empty(t, resp.NextPageToken)
+	})
+
+	t.Run("missing prefix folder", func(t *testing.T) {
+		resp, err := store.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{
+			Metadata: map[string]string{"prefix": "missing/"},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Data)
+	})
+}
+
+func TestGetSecretNamespace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{
+				"data": map[string]string{"namespace": r.Header.Get(vaultHTTPNamespaceHeader)},
+			},
+		})
+	}))
+	defer server.Close()
+
+	store := NewHashiCorpVaultSecretStore(logger.NewLogger("test")).(*vaultSecretStore)
+	err := store.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+		componentVaultAddress:    server.URL,
+		componentVaultToken:      expectedTok,
+		"vaultNamespace":         "default",
+		"allowedVaultNamespaces": "tenant1, /tenant2/team-a/",
+	}}})
+	require.NoError(t, err)
+	store.client = server.Client()
+
+	getNamespace := func(md map[string]string) (string, error) {
+		resp, err := store.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "secret", Metadata: md})
+		return resp.Data["namespace"], err
+	}
+
+	t.Run("namespace of the component", func(t *testing.T) {
+		ns, err := getNamespace(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "default", ns)
+
+		ns, err = getNamespace(map[string]string{"namespace": "default"})
+		require.NoError(t, err)
+		assert.Equal(t, "default", ns)
+	})
+
+	t.Run("allowed namespaces", func(t *testing.T) {
+		ns, err := getNamespace(map[string]string{"namespace": "tenant1"})
+		require.NoError(t, err)
+		assert.Equal(t, "tenant1", ns)
+
+		ns, err = getNamespace(map[string]string{"namespace": "tenant2/team-a/"})
+		require.NoError(t, err)
+		assert.Equal(t, "tenant2/team-a", ns)
+	})
+
+	t.Run("namespace not allowed", func(t *testing.T) {
+		_, err := getNamespace(map[string]string{"namespace": "tenant3"})
+		assert.ErrorIs(t, err, ErrNamespaceNotAllowed)
+
+		_, err = store.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{
+			Metadata: map[string]string{"namespace": "tenant2"},
+		})
+		assert.ErrorIs(t, err, ErrNamespaceNotAllowed)
+	})
+}
+
+func TestGetSecretPKICertificate(t *testing.T) {
+	var issued atomic.Int32
+	var lastRequest pkiIssueRequest
+	var lastPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		issued.Add(1)
+		lastPath = r.URL.Path
+		lastRequest = pkiIssueRequest{}
+		json.NewDecoder(r.Body).Decode(&lastRequest)
+		json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{
+				"certificate":      "cert-" + strconv.Itoa(int(issued.Load())),
+				"private_key":      "key",
+				"private_key_type": "rsa",
+				"issuing_ca":       "ca",
+				"ca_chain":         []string{"ca", "root"},
+				"serial_number":    "01:02",
+				"expiration":       time.Now().Add(time.Hour).Unix(),
+			},
+		})
+	}))
+	defer server.Close()
+
+	store := NewHashiCorpVaultSecretStore(logger.NewLogger("test")).(*vaultSecretStore)
+	err := store.Init(context.Background(), secretstores.Metadata{Base: metadata.Base{Properties: map[string]string{
+		componentVaultAddress: server.URL,
+		componentVaultToken:   expectedTok,
+		"pkiEnginePath":       "/pki_int/",
+	}}})
+	require.NoError(t, err)
+	store.client = server.Client()
+
+	md := map[string]string{
+		"pkiRole":  "web",
+		"ttl":      "1h",
+		"altNames": "a.example.com,b.example.com",
+	}
+
+	t.Run("issues a certificate", func(t *testing.T) {
+		resp, err := store.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "svc.example.com", Metadata: md})
+		require.NoError(t, err)
+		assert.Equal(t, "/v1/pki_int/issue/web", lastPath)
+		assert.Equal(t, pkiIssueRequest{CommonName: "svc.example.com", TTL: "1h", AltNames: "a.example.com,b.example.com"}, lastRequest)
+		assert.Equal(t, "cert-1", resp.Data[PKICertificateKey])
+		assert.Equal(t, "key", resp.Data[PKIPrivateKeyKey])
+		assert.Equal(t, "ca\nroot", resp.Data[PKICAChainKey])
+		assert.Equal(t, "01:02", resp.Data[PKISerialNumberKey])
+	})
+
+	t.Run("returns the cached certificate", func(t *testing.T) {
+		resp, err := store.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "svc.example.com", Metadata: md})
+		require.NoError(t, err)
+		assert.Equal(t, "cert-1", resp.Data[PKICertificateKey])
+		assert.Equal(t, int32(1), issued.Load())
+	})
+
+	t.Run("renews the certificate", func(t *testing.T) {
+		for _, c := range store.pkiCache.certs {
+			c.renewAt = time.Now().Add(-time.Second)
+		}
+		resp, err := store.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "svc.example.com", Metadata: md})
+		require.NoError(t, err)
+		assert.Equal(t, "cert-2", resp.Data[PKICertificateKey])
+	})
+
+	t.Run("common name from the metadata", func(t *testing.T) {
+		resp, err := store.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "cert", Metadata: map[string]string{
+			"pkiRole":    "web",
+			"commonName": "other.example.com",
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, "other.example.com", lastRequest.CommonName)
+		assert.Equal(t, "cert-3", resp.Data[PKICertificateKey])
+	})
+}
@@ -16,9 +16,13 @@ package env
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/dapr/components-contrib/metadata"
@@ -28,15 +32,35 @@ import (
 
 var _ secretstores.SecretStore = (*envSecretStore)(nil)
 
+const defaultNestedSeparator = ":"
+
 type Metadata struct {
 	// Prefix to add to the env vars when reading them.
 	// This is case sensitive on Linux and macOS, and case-insensitive on Windows.
 	Prefix string
+	// Comma-separated list of "ENV_PREFIX=secretPrefix" pairs.
+	// If set, only the env vars that start with one of the prefixes are secrets, and their prefix is replaced with the secret prefix in the secret names.
+	PrefixMappings string
+	// If true, the names of the secrets are the names of the env vars in lowercase, and secret names are converted to uppercase to read the env vars.
+	LowercaseNames bool
+	// If set, underscores in the names of the env vars are replaced with this separator in the names of the secrets, and vice versa.
+	NameSeparator string
+	// If true, env vars with a JSON object as value are expanded into a secret with a key for each property.
+	ExpandJSON bool
+	// Separator of the keys of nested properties in expanded JSON values. Defaults to ":".
+	NestedSeparator string
+}
+
+// prefixMapping maps env vars that start with envPrefix to secrets whose names start with secretPrefix.
+type prefixMapping struct {
+	envPrefix    string
+	secretPrefix string
 }
 
 type envSecretStore struct {
-	logger   logger.Logger
-	metadata Metadata
+	logger         logger.Logger
+	metadata       Metadata
+	prefixMappings []prefixMapping
 }
 
 // NewEnvSecretStore returns a new env var secret store.
@@ -48,25 +72,64 @@ func NewEnvSecretStore(logger logger.Logger) secretstores.SecretStore {
 
 // Init creates a Local secret store.
 func (s *envSecretStore) Init(_ context.Context, meta secretstores.Metadata) error {
-	if err := metadata.DecodeMetadata(meta.Properties, &s.metadata); err != nil {
+	m := Metadata{
+		NestedSeparator: defaultNestedSeparator,
+	}
+	if err := metadata.DecodeMetadata(meta.Properties, &m); err != nil {
 		return err
 	}
+
+	mappings, err := parsePrefixMappings(m.PrefixMappings)
+	if err != nil {
+		return err
+	}
+	if m.NameSeparator == "_" {
+		m.NameSeparator = ""
+	}
+	if m.NestedSeparator == "" {
+		m.NestedSeparator = defaultNestedSeparator
+	}
+
+	s.metadata = m
+	s.prefixMappings = mappings
 	return nil
 }
 
+func parsePrefixMappings(val string) ([]prefixMapping, error) {
+	var mappings []prefixMapping
+	for _, pair := range strings.Split(val, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		envPrefix, secretPrefix, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid prefix mapping '%s': the format is ENV_PREFIX=secretPrefix", pair)
+		}
+		mappings = append(mappings, prefixMapping{
+			envPrefix:    strings.TrimSpace(envPrefix),
+			secretPrefix: strings.TrimSpace(secretPrefix),
+		})
+	}
+	return mappings, nil
+}
+
 // GetSecret retrieves a secret from env var using provided key.
 func (s *envSecretStore) GetSecret(ctx context.Context, req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
 	var value string
-	name := s.metadata.Prefix + req.Name
-	if s.isKeyAllowed(name) {
+	name, ok := s.envName(req.Name)
+	if ok && s.isKeyAllowed(name) {
 		value = os.Getenv(name)
-	} else {
+	} else if ok {
 		s.logger.Warnf("Access to env var %s is forbidden", req.Name)
 	}
+
+	data, err := s.secretData(req.Name, value)
+	if err != nil {
+		return secretstores.GetSecretResponse{}, fmt.Errorf("failed to expand the JSON value of secret %s: %w", req.Name, err)
+	}
 	return secretstores.GetSecretResponse{
-		Data: map[string]string{
-			req.Name: value,
-		},
+		Data: data,
 	}, nil
 }
 
@@ -101,7 +164,15 @@ func (s *envSecretStore) BulkGetSecret(ctx context.Context, req secretstores.Bul
 			continue
 		}
 
-		r[key[lp:]] = map[string]string{key[lp:]: envVariable[1]}
+		name, ok := s.secretName(key[lp:])
+		if !ok {
+			continue
+		}
+		data, err := s.secretData(name, envVariable[1])
+		if err != nil {
+			return secretstores.BulkGetSecretResponse{}, fmt.Errorf("failed to expand the JSON value of secret %s: %w", name, err)
+		}
+		r[name] = data
 	}
 
 	return secretstores.BulkGetSecretResponse{
@@ -121,6 +192,138 @@ func (s *envSecretStore) GetComponentMetadata() map[string]string {
 	return metadataInfo
 }
 
+// envName returns the name of the env var of a secret, applying the prefix mappings and name conversions.
+// It returns false if no prefix mapping matches the name of the secret.
+func (s *envSecretStore) envName(secretName string) (string, bool) {
+	envPrefix, rest := "", secretName
+	if len(s.prefixMappings) > 0 {
+		m, ok := longestMatch(s.prefixMappings, secretName, func(m prefixMapping) string { return m.secretPrefix }, strings.HasPrefix)
+		if !ok {
+			return "", false
+		}
+		envPrefix, rest = m.envPrefix, secretName[len(m.secretPrefix):]
+	}
+
+	if s.metadata.NameSeparator != "" {
+		rest = strings.ReplaceAll(rest, s.metadata.NameSeparator, "_")
+	}
+	if s.metadata.LowercaseNames {
+		rest = strings.ToUpper(rest)
+	}
+
+	return s.metadata.Prefix + envPrefix + rest, true
+}
+
+// secretName returns the name of the secret of an env var, whose name doesn't include the prefix.
+// It returns false if no prefix mapping matches the name of the env var.
+func (s *envSecretStore) secretName(key string) (string, bool) {
+	secretPrefix, rest := "", key
+	if len(s.prefixMappings) > 0 {
+		m, ok := longestMatch(s.prefixMappings, key, func(m prefixMapping) string { return m.envPrefix }, hasEnvPrefix)
+		if !ok {
+			return "", false
+		}
+		secretPrefix, rest = m.secretPrefix, key[len(m.envPrefix):]
+	}
+
+	if s.metadata.LowercaseNames {
+		rest = strings.ToLower(rest)
+	}
+	if s.metadata.NameSeparator != "" {
+		rest = strings.ReplaceAll(rest, "_", s.metadata.NameSeparator)
+	}
+
+	return secretPrefix + rest, true
+}
+
+// longestMatch returns the prefix mapping with the longest prefix that matches the name.
+func longestMatch(mappings []prefixMapping, name string, prefixOf func(prefixMapping) string, hasPrefix func(s, prefix string) bool) (prefixMapping, bool) {
+	var (
+		res   prefixMapping
+		found bool
+	)
+	for _, m := range mappings {
+		prefix := prefixOf(m)
+		if hasPrefix(name, prefix) && (!found || len(prefix) > len(prefixOf(res))) {
+			res, found = m, true
+		}
+	}
+	return res, found
+}
+
+// hasEnvPrefix returns true if the name of the env var starts with the prefix, in a case-insensitive way on Windows.
+func hasEnvPrefix(key string, prefix string) bool {
+	if runtime.GOOS == "windows" {
+		return len(key) >= len(prefix) && strings.EqualFold(key[:len(prefix)], prefix)
+	}
+	return strings.HasPrefix(key, prefix)
+}
+
+// secretData returns the data of the secret with the value of the env var.
+// If ExpandJSON is set and the value is a JSON object, the data has a key for each property, with nested properties joined by NestedSeparator.
+func (s *envSecretStore) secretData(name string, value string) (map[string]string, error) {
+	trimmed := strings.TrimSpace(value)
+	if !s.metadata.ExpandJSON || !strings.HasPrefix(trimmed, "{") {
+		return map[string]string{name: value}, nil
+	}
+
+	var obj map[string]interface{}
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err != nil {
+		// Not a JSON object, so the value is returned as is
+		return map[string]string{name: value}, nil //nolint:nilerr
+	}
+
+	data := make(map[string]string, len(obj))
+	err := s.flatten(data, "", obj)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *envSecretStore) flatten(data map[string]string, path string, value interface{}) error {
+	key := func(k string) string {
+		if path == "" {
+			return k
+		}
+		return path + s.metadata.NestedSeparator + k
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			if err := s.flatten(data, key(k), item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		for i, item := range v {
+			if err := s.flatten(data, key(strconv.Itoa(i)), item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if _, exists := data[path]; exists {
+		return errors.New("duplicate key " + path)
+	}
+	switch v := value.(type) {
+	case string:
+		data[path] = v
+	case json.Number:
+		data[path] = v.String()
+	case bool:
+		data[path] = strconv.FormatBool(v)
+	case nil:
+		data[path] = ""
+	}
+	return nil
+}
+
 func (s *envSecretStore) isKeyAllowed(key string) bool {
 	key = strings.ToUpper(key)
 	switch {
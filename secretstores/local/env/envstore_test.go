@@ -209,6 +209,121 @@ func TestEnvStoreWithPrefix(t *testing.T) {
 	})
 }
 
+func TestEnvStoreWithPrefixMappings(t *testing.T) {
+	s := envSecretStore{logger: logger.NewLogger("test")}
+
+	t.Setenv("MYAPP_DB_PASSWORD", "db1")
+	t.Setenv("MYAPP_CACHE_DB_PASSWORD", "cache1")
+	t.Setenv("OTHER_SECRET", "other1")
+
+	err := s.Init(context.Background(), secretstores.Metadata{
+		Base: metadata.Base{Properties: map[string]string{
+			"prefixMappings": "MYAPP_=app/, MYAPP_CACHE_=cache/",
+			"lowercaseNames": "true",
+			"nameSeparator":  ".",
+		}},
+	})
+	require.NoError(t, err)
+
+	t.Run("Get", func(t *testing.T) {
+		resp, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "app/db.password"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"app/db.password": "db1"}, resp.Data)
+
+		resp, err = s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "cache/db.password"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"cache/db.password": "cache1"}, resp.Data)
+	})
+
+	t.Run("Get without matching mapping", func(t *testing.T) {
+		resp, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "OTHER_SECRET"})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Data["OTHER_SECRET"])
+	})
+
+	t.Run("Bulk get", func(t *testing.T) {
+		resp, err := s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]map[string]string{
+			"app/db.password":   {"app/db.password": "db1"},
+			"cache/db.password": {"cache/db.password": "cache1"},
+		}, resp.Data)
+	})
+
+	t.Run("Invalid mapping", func(t *testing.T) {
+		err := s.Init(context.Background(), secretstores.Metadata{
+			Base: metadata.Base{Properties: map[string]string{
+				"prefixMappings": "MYAPP_",
+			}},
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestEnvStoreExpandJSON(t *testing.T) {
+	s := envSecretStore{logger: logger.NewLogger("test")}
+
+	t.Setenv("TESTJSON_DB", `{"user": "admin", "port": 5432, "tls": true, "hosts": ["a", "b"], "options": {"timeout": 1.5, "extra": null}}`)
+	t.Setenv("TESTJSON_PLAIN", "plain")
+	t.Setenv("TESTJSON_INVALID", "{not json")
+
+	err := s.Init(context.Background(), secretstores.Metadata{
+		Base: metadata.Base{Properties: map[string]string{
+			"prefix":     "TESTJSON_",
+			"expandJSON": "true",
+		}},
+	})
+	require.NoError(t, err)
+
+	expected := map[string]string{
+		"user":            "admin",
+		"port":            "5432",
+		"tls":             "true",
+		"hosts:0":         "a",
+		"hosts:1":         "b",
+		"options:timeout": "1.5",
+		"options:extra":   "",
+	}
+
+	t.Run("Get", func(t *testing.T) {
+		resp, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "DB"})
+		require.NoError(t, err)
+		assert.Equal(t, expected, resp.Data)
+
+		resp, err = s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "PLAIN"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"PLAIN": "plain"}, resp.Data)
+
+		resp, err = s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "INVALID"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"INVALID": "{not json"}, resp.Data)
+	})
+
+	t.Run("Bulk get", func(t *testing.T) {
+		resp, err := s.BulkGetSecret(context.Background(), secretstores.BulkGetSecretRequest{})
+		require.NoError(t, err)
+		assert.Len(t, resp.Data, 3)
+		assert.Equal(t, expected, resp.Data["DB"])
+		assert.Equal(t, "plain", resp.Data["PLAIN"]["PLAIN"])
+	})
+
+	t.Run("Nested separator", func(t *testing.T) {
+		err := s.Init(context.Background(), secretstores.Metadata{
+			Base: metadata.Base{Properties: map[string]string{
+				"prefix":          "TESTJSON_",
+				"expandJSON":      "true",
+				"nestedSeparator": "__",
+			}},
+		})
+		require.NoError(t, err)
+
+		resp, err := s.GetSecret(context.Background(), secretstores.GetSecretRequest{Name: "DB"})
+		require.NoError(t, err)
+		assert.Equal(t, "1.5", resp.Data["options__timeout"])
+		assert.Equal(t, "b", resp.Data["hosts__1"])
+	})
+}
+
 func TestGetFeatures(t *testing.T) {
 	s := envSecretStore{logger: logger.NewLogger("test")}
 	// Yes, we are skipping initialization as feature retrieval doesn't depend on it.
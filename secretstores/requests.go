@@ -22,4 +22,7 @@ type GetSecretRequest struct {
 // BulkGetSecretRequest describes a bulk get secret request from a secret store.
 type BulkGetSecretRequest struct {
 	Metadata map[string]string `json:"metadata"`
+	// Filter limits and paginates the secrets returned.
+	// It is only honored by secret stores that advertise FeatureBulkGetFilter.
+	Filter BulkGetSecretFilter `json:"filter,omitempty"`
 }
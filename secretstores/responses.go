@@ -21,4 +21,7 @@ type GetSecretResponse struct {
 // BulkGetSecretResponse describes the response object for all the secrets returned from a secret store.
 type BulkGetSecretResponse struct {
 	Data map[string]map[string]string `json:"data"`
+	// NextPageToken is set when more secrets are available.
+	// It can be passed as page token in a subsequent request to retrieve the next page.
+	NextPageToken string `json:"nextPageToken,omitempty"`
 }
@@ -21,9 +21,12 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
@@ -43,28 +46,50 @@ type StateStore struct {
 	table            string
 	ttlAttributeName string
 	partitionKey     string
+	logger           logger.Logger
+
+	maxThrottlingRetries int
+	throttlingDelay      adaptiveDelay
+	billingMode          string
+	billingModeRetryAt   time.Time
+	billingModeLock      sync.Mutex
+
+	statsHook StatsHook
+	statsLock sync.RWMutex
 }
 
 type dynamoDBMetadata struct {
-	Region           string `json:"region"`
-	Endpoint         string `json:"endpoint"`
-	AccessKey        string `json:"accessKey"`
-	SecretKey        string `json:"secretKey"`
-	SessionToken     string `json:"sessionToken"`
-	Table            string `json:"table"`
-	TTLAttributeName string `json:"ttlAttributeName"`
-	PartitionKey     string `json:"partitionKey"`
+	Region               string `json:"region"`
+	Endpoint             string `json:"endpoint"`
+	STSEndpoint          string `json:"stsEndpoint"`
+	UseFIPSEndpoint      bool   `json:"useFipsEndpoint"`
+	UseDualStackEndpoint bool   `json:"useDualStackEndpoint"`
+	AccessKey            string `json:"accessKey"`
+	SecretKey            string `json:"secretKey"`
+	SessionToken         string `json:"sessionToken"`
+	Table                string `json:"table"`
+	TTLAttributeName     string `json:"ttlAttributeName"`
+	PartitionKey         string `json:"partitionKey"`
+	// Billing mode of the table: "provisioned" or "onDemand".
+	// If empty, it's retrieved from DynamoDB when a request is throttled for the first time.
+	BillingMode string `json:"billingMode"`
+	// Maximum number of times a request throttled by DynamoDB is retried. 0 disables the retries.
+	MaxThrottlingRetries int `json:"maxThrottlingRetries"`
 }
 
 const (
 	defaultPartitionKeyName = "key"
 	metadataPartitionKey    = "partitionKey"
+
+	// Maximum number of items in a single TransactWriteItems request
+	maxTransactionItems = 100
 )
 
 // NewDynamoDBStateStore returns a new dynamoDB state store.
-func NewDynamoDBStateStore(_ logger.Logger) state.Store {
+func NewDynamoDBStateStore(logger logger.Logger) state.Store {
 	s := &StateStore{
 		partitionKey: defaultPartitionKeyName,
+		logger:       logger,
 	}
 	s.BulkStore = state.NewDefaultBulkStore(s)
 	return s
@@ -86,31 +111,53 @@ func (d *StateStore) Init(_ context.Context, metadata state.Metadata) error {
 	d.table = meta.Table
 	d.ttlAttributeName = meta.TTLAttributeName
 	d.partitionKey = meta.PartitionKey
+	d.maxThrottlingRetries = meta.MaxThrottlingRetries
+	d.billingMode = meta.BillingMode
 
 	return nil
 }
 
 // Features returns the features available in this state store.
 func (d *StateStore) Features() []state.Feature {
+	// TTLs are only supported if the table has a TTL attribute
+	if d.ttlAttributeName != "" {
+		return []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureTTL}
+	}
 	return []state.Feature{state.FeatureETag, state.FeatureTransactional}
 }
 
 // Get retrieves a dynamoDB item.
 func (d *StateStore) Get(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+	consistency, err := state.RequestConsistency(req.Options.Consistency, req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel, maxRetries, err := d.requestContext(ctx, req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
 	input := &dynamodb.GetItemInput{
-		ConsistentRead: aws.Bool(req.Options.Consistency == state.Strong),
+		ConsistentRead: aws.Bool(consistency == state.Strong),
 		TableName:      aws.String(d.table),
 		Key: map[string]*dynamodb.AttributeValue{
 			d.partitionKey: {
 				S: aws.String(req.Key),
 			},
 		},
+		ReturnConsumedCapacity: d.returnConsumedCapacity(),
 	}
 
-	result, err := d.client.GetItemWithContext(ctx, input)
+	var result *dynamodb.GetItemOutput
+	err = d.withThrottlingRetries(ctx, "get", maxRetries, func() (err error) {
+		result, err = d.client.GetItemWithContext(ctx, input)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	d.reportCapacity("get", result.ConsumedCapacity)
 
 	if len(result.Item) == 0 {
 		return &state.GetResponse{}, nil
@@ -163,24 +210,25 @@ func (d *StateStore) Set(ctx context.Context, req *state.SetRequest) error {
 	}
 
 	input := &dynamodb.PutItemInput{
-		Item:      item,
-		TableName: &d.table,
+		Item:                   item,
+		TableName:              &d.table,
+		ReturnConsumedCapacity: d.returnConsumedCapacity(),
 	}
+	input.ConditionExpression, input.ExpressionAttributeValues = setCondition(req)
 
-	if req.HasETag() {
-		condExpr := "etag = :etag"
-		input.ConditionExpression = &condExpr
-		exprAttrValues := make(map[string]*dynamodb.AttributeValue)
-		exprAttrValues[":etag"] = &dynamodb.AttributeValue{
-			S: req.ETag,
-		}
-		input.ExpressionAttributeValues = exprAttrValues
-	} else if req.Options.Concurrency == state.FirstWrite {
-		condExpr := "attribute_not_exists(etag)"
-		input.ConditionExpression = &condExpr
+	ctx, cancel, maxRetries, err := d.requestContext(ctx, req.Metadata)
+	if err != nil {
+		return err
 	}
+	defer cancel()
 
-	_, err = d.client.PutItemWithContext(ctx, input)
+	err = d.withThrottlingRetries(ctx, "set", maxRetries, func() error {
+		result, err := d.client.PutItemWithContext(ctx, input)
+		if err == nil && result != nil {
+			d.reportCapacity("set", result.ConsumedCapacity)
+		}
+		return err
+	})
 	if err != nil && req.HasETag() {
 		switch cErr := err.(type) {
 		case *dynamodb.ConditionalCheckFailedException:
@@ -199,20 +247,27 @@ func (d *StateStore) Delete(ctx context.Context, req *state.DeleteRequest) error
 				S: aws.String(req.Key),
 			},
 		},
-		TableName: aws.String(d.table),
+		TableName:              aws.String(d.table),
+		ReturnConsumedCapacity: d.returnConsumedCapacity(),
 	}
 
 	if req.HasETag() {
-		condExpr := "etag = :etag"
-		input.ConditionExpression = &condExpr
-		exprAttrValues := make(map[string]*dynamodb.AttributeValue)
-		exprAttrValues[":etag"] = &dynamodb.AttributeValue{
-			S: req.ETag,
-		}
-		input.ExpressionAttributeValues = exprAttrValues
+		input.ConditionExpression, input.ExpressionAttributeValues = etagCondition(req.ETag)
+	}
+
+	ctx, cancel, maxRetries, err := d.requestContext(ctx, req.Metadata)
+	if err != nil {
+		return err
 	}
+	defer cancel()
 
-	_, err := d.client.DeleteItemWithContext(ctx, input)
+	err = d.withThrottlingRetries(ctx, "delete", maxRetries, func() error {
+		result, err := d.client.DeleteItemWithContext(ctx, input)
+		if err == nil && result != nil {
+			d.reportCapacity("delete", result.ConsumedCapacity)
+		}
+		return err
+	})
 	if err != nil {
 		switch cErr := err.(type) {
 		case *dynamodb.ConditionalCheckFailedException:
@@ -231,21 +286,47 @@ func (d *StateStore) GetComponentMetadata() map[string]string {
 }
 
 func (d *StateStore) getDynamoDBMetadata(meta state.Metadata) (*dynamoDBMetadata, error) {
-	var m dynamoDBMetadata
+	m := dynamoDBMetadata{
+		MaxThrottlingRetries: defaultMaxThrottlingRetries,
+	}
 	err := metadata.DecodeMetadata(meta.Properties, &m)
 	if m.Table == "" {
 		return nil, errors.New("missing dynamodb table name")
 	}
+	var billingErr error
+	m.BillingMode, billingErr = parseBillingMode(m.BillingMode)
+	if billingErr != nil {
+		return nil, billingErr
+	}
 	m.PartitionKey = populatePartitionMetadata(meta.Properties, defaultPartitionKeyName)
 	return &m, err
 }
 
 func (d *StateStore) getClient(metadata *dynamoDBMetadata) (*dynamodb.DynamoDB, error) {
-	sess, err := awsAuth.GetClient(metadata.AccessKey, metadata.SecretKey, metadata.SessionToken, metadata.Region, metadata.Endpoint)
+	sess, err := awsAuth.GetClient(awsAuth.Options{
+		AccessKey:            metadata.AccessKey,
+		SecretKey:            metadata.SecretKey,
+		SessionToken:         metadata.SessionToken,
+		Region:               metadata.Region,
+		Endpoint:             metadata.Endpoint,
+		STSEndpoint:          metadata.STSEndpoint,
+		UseFIPSEndpoint:      metadata.UseFIPSEndpoint,
+		UseDualStackEndpoint: metadata.UseDualStackEndpoint,
+	})
 	if err != nil {
 		return nil, err
 	}
-	c := dynamodb.New(sess)
+	cfg := aws.NewConfig()
+	if metadata.MaxThrottlingRetries > 0 {
+		// Throttled requests are retried by the store, with a backoff that adapts to the capacity of the table
+		cfg = request.WithRetryer(cfg, throttlingRetryer{
+			DefaultRetryer: client.DefaultRetryer{
+				NumMaxRetries: 10,
+				MinRetryDelay: minThrottlingDelay,
+			},
+		})
+	}
+	c := dynamodb.New(sess, cfg)
 
 	return c, nil
 }
@@ -288,6 +369,25 @@ func (d *StateStore) getItemFromReq(req *state.SetRequest) (map[string]*dynamodb
 	return item, nil
 }
 
+// setCondition returns the condition expression and its attribute values for a set request, if any.
+func setCondition(req *state.SetRequest) (*string, map[string]*dynamodb.AttributeValue) {
+	if req.HasETag() {
+		return etagCondition(req.ETag)
+	} else if req.Options.Concurrency == state.FirstWrite {
+		return aws.String("attribute_not_exists(etag)"), nil
+	}
+	return nil, nil
+}
+
+// etagCondition returns the condition expression and its attribute values to match an item's etag.
+func etagCondition(etag *string) (*string, map[string]*dynamodb.AttributeValue) {
+	return aws.String("etag = :etag"), map[string]*dynamodb.AttributeValue{
+		":etag": {
+			S: etag,
+		},
+	}
+}
+
 func getRand64() (uint64, error) {
 	randBuf := make([]byte, 8)
 	_, err := rand.Read(randBuf)
@@ -333,7 +433,8 @@ func (d *StateStore) Multi(ctx context.Context, request *state.TransactionalStat
 	}
 
 	twinput := &dynamodb.TransactWriteItemsInput{
-		TransactItems: make([]*dynamodb.TransactWriteItem, 0, opns),
+		TransactItems:          make([]*dynamodb.TransactWriteItem, 0, opns),
+		ReturnConsumedCapacity: d.returnConsumedCapacity(),
 	}
 
 	// Note: The following is a DynamoDB logic to avoid errors like following,
@@ -347,6 +448,11 @@ func (d *StateStore) Multi(ctx context.Context, request *state.TransactionalStat
 		txs[o.GetKey()] = i
 	}
 
+	// DynamoDB does not allow more than 100 items in a transaction, and splitting it in multiple requests would not be atomic
+	if len(txs) > maxTransactionItems {
+		return fmt.Errorf("dynamodb error: transaction contains %d operations, but the maximum is %d", len(txs), maxTransactionItems)
+	}
+
 	for i, o := range request.Operations {
 		// skip operations removed in simulated set
 		if txs[o.GetKey()] != i {
@@ -356,21 +462,15 @@ func (d *StateStore) Multi(ctx context.Context, request *state.TransactionalStat
 		twi := &dynamodb.TransactWriteItem{}
 		switch req := o.(type) {
 		case state.SetRequest:
-			value, err := d.marshalToString(req.Value)
+			item, err := d.getItemFromReq(&req)
 			if err != nil {
-				return fmt.Errorf("dynamodb error: failed to marshal value for key %s: %w", req.Key, err)
+				return err
 			}
 			twi.Put = &dynamodb.Put{
 				TableName: aws.String(d.table),
-				Item: map[string]*dynamodb.AttributeValue{
-					d.partitionKey: {
-						S: aws.String(req.Key),
-					},
-					"value": {
-						S: aws.String(value),
-					},
-				},
+				Item:      item,
 			}
+			twi.Put.ConditionExpression, twi.Put.ExpressionAttributeValues = setCondition(&req)
 
 		case state.DeleteRequest:
 			twi.Delete = &dynamodb.Delete{
@@ -381,15 +481,55 @@ func (d *StateStore) Multi(ctx context.Context, request *state.TransactionalStat
 					},
 				},
 			}
+			if req.HasETag() {
+				twi.Delete.ConditionExpression, twi.Delete.ExpressionAttributeValues = etagCondition(req.ETag)
+			}
 		}
 		twinput.TransactItems = append(twinput.TransactItems, twi)
 	}
 
-	_, err := d.client.TransactWriteItemsWithContext(ctx, twinput)
+	ctx, cancel, maxRetries, err := d.requestContext(ctx, request.Metadata)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	// Retrying is safe because a canceled transaction has no effects
+	err = d.withThrottlingRetries(ctx, "transaction", maxRetries, func() error {
+		result, err := d.client.TransactWriteItemsWithContext(ctx, twinput)
+		if err == nil && result != nil {
+			d.reportCapacity("transaction", result.ConsumedCapacity...)
+		}
+		return err
+	})
+	if err != nil {
+		// If the transaction was canceled because a condition failed, return an ETag error
+		var cErr *dynamodb.TransactionCanceledException
+		if errors.As(err, &cErr) {
+			for _, reason := range cErr.CancellationReasons {
+				if reason != nil && aws.StringValue(reason.Code) == "ConditionalCheckFailed" {
+					return state.NewETagError(state.ETagMismatch, err)
+				}
+			}
+		}
+	}
 
 	return err
 }
 
+// requestContext returns the context of a request, with the timeout in its metadata, and the maximum number of times the request is retried when it's throttled.
+func (d *StateStore) requestContext(ctx context.Context, reqMetadata map[string]string) (context.Context, context.CancelFunc, int, error) {
+	maxRetries, err := state.RequestMaxRetries(reqMetadata, d.maxThrottlingRetries)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	ctx, cancel, err := state.RequestContext(ctx, reqMetadata, 0)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return ctx, cancel, maxRetries, nil
+}
+
 // This is a helper to return the partition key to use.  If if metadata["partitionkey"] is present,
 // use that, otherwise use default primay key "key".
 func populatePartitionMetadata(requestMetadata map[string]string, defaultPartitionKeyName string) string {
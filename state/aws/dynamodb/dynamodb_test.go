@@ -17,7 +17,9 @@ package dynamodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -27,6 +29,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/state"
 )
@@ -37,6 +40,7 @@ type mockedDynamoDB struct {
 	DeleteItemWithContextFn         func(ctx context.Context, input *dynamodb.DeleteItemInput, op ...request.Option) (*dynamodb.DeleteItemOutput, error)
 	BatchWriteItemWithContextFn     func(ctx context.Context, input *dynamodb.BatchWriteItemInput, op ...request.Option) (*dynamodb.BatchWriteItemOutput, error)
 	TransactWriteItemsWithContextFn func(aws.Context, *dynamodb.TransactWriteItemsInput, ...request.Option) (*dynamodb.TransactWriteItemsOutput, error)
+	DescribeTableWithContextFn      func(aws.Context, *dynamodb.DescribeTableInput, ...request.Option) (*dynamodb.DescribeTableOutput, error)
 	dynamodbiface.DynamoDBAPI
 }
 
@@ -71,6 +75,10 @@ func (m *mockedDynamoDB) TransactWriteItemsWithContext(ctx context.Context, inpu
 	return m.TransactWriteItemsWithContextFn(ctx, input, op...)
 }
 
+func (m *mockedDynamoDB) DescribeTableWithContext(ctx context.Context, input *dynamodb.DescribeTableInput, op ...request.Option) (*dynamodb.DescribeTableOutput, error) {
+	return m.DescribeTableWithContextFn(ctx, input, op...)
+}
+
 func TestInit(t *testing.T) {
 	m := state.Metadata{}
 	s := &StateStore{
@@ -837,4 +845,330 @@ func TestMultiTx(t *testing.T) {
 		err := ss.Multi(context.Background(), req)
 		assert.NoError(t, err)
 	})
+
+	t.Run("Transaction operations with etags and first-write concurrency", func(t *testing.T) {
+		ss := &StateStore{
+			partitionKey: defaultPartitionKeyName,
+			table:        tableName,
+		}
+		etag := "abc"
+		ops := []state.TransactionalStateOperation{
+			state.SetRequest{
+				Key:   "key1",
+				Value: "value1",
+				ETag:  &etag,
+			},
+			state.SetRequest{
+				Key:     "key2",
+				Value:   "value2",
+				Options: state.SetStateOption{Concurrency: state.FirstWrite},
+			},
+			state.DeleteRequest{
+				Key:  "key3",
+				ETag: &etag,
+			},
+		}
+
+		ss.client = &mockedDynamoDB{
+			TransactWriteItemsWithContextFn: func(ctx context.Context, input *dynamodb.TransactWriteItemsInput, op ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+				require.Len(t, input.TransactItems, 3)
+
+				put := input.TransactItems[0].Put
+				require.NotNil(t, put)
+				assert.Equal(t, "etag = :etag", *put.ConditionExpression)
+				assert.Equal(t, etag, *put.ExpressionAttributeValues[":etag"].S)
+				assert.NotNil(t, put.Item["etag"])
+				assert.Equal(t, `"value1"`, *put.Item["value"].S)
+
+				put = input.TransactItems[1].Put
+				require.NotNil(t, put)
+				assert.Equal(t, "attribute_not_exists(etag)", *put.ConditionExpression)
+				assert.NotNil(t, put.Item["etag"])
+
+				del := input.TransactItems[2].Delete
+				require.NotNil(t, del)
+				assert.Equal(t, "etag = :etag", *del.ConditionExpression)
+				assert.Equal(t, etag, *del.ExpressionAttributeValues[":etag"].S)
+
+				return &dynamodb.TransactWriteItemsOutput{}, nil
+			},
+		}
+
+		err := ss.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: ops,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Transaction canceled because of a failed condition", func(t *testing.T) {
+		ss := &StateStore{
+			partitionKey: defaultPartitionKeyName,
+			table:        tableName,
+		}
+		etag := "abc"
+
+		ss.client = &mockedDynamoDB{
+			TransactWriteItemsWithContextFn: func(ctx context.Context, input *dynamodb.TransactWriteItemsInput, op ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+				return nil, &dynamodb.TransactionCanceledException{
+					Message_: aws.String("Transaction cancelled"),
+					CancellationReasons: []*dynamodb.CancellationReason{
+						{Code: aws.String("None")},
+						{Code: aws.String("ConditionalCheckFailed")},
+					},
+				}
+			},
+		}
+
+		err := ss.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "key1", Value: "value1"},
+				state.SetRequest{Key: "key2", Value: "value2", ETag: &etag},
+			},
+		})
+		require.Error(t, err)
+		var etagErr *state.ETagError
+		require.ErrorAs(t, err, &etagErr)
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+	})
+
+	t.Run("Too many operations", func(t *testing.T) {
+		ss := &StateStore{
+			partitionKey: defaultPartitionKeyName,
+			table:        tableName,
+			client: &mockedDynamoDB{
+				TransactWriteItemsWithContextFn: func(ctx context.Context, input *dynamodb.TransactWriteItemsInput, op ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+					t.Fatal("TransactWriteItems should not be invoked")
+					return nil, nil
+				},
+			},
+		}
+
+		ops := make([]state.TransactionalStateOperation, maxTransactionItems+1)
+		for i := range ops {
+			ops[i] = state.DeleteRequest{Key: fmt.Sprintf("key%d", i)}
+		}
+		err := ss.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: ops,
+		})
+		require.ErrorContains(t, err, "maximum is 100")
+
+		// Duplicate keys are counted only once
+		ops = append(ops[:maxTransactionItems], state.DeleteRequest{Key: "key0"})
+		ss.client.(*mockedDynamoDB).TransactWriteItemsWithContextFn = func(ctx context.Context, input *dynamodb.TransactWriteItemsInput, op ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+			assert.Len(t, input.TransactItems, maxTransactionItems)
+			return &dynamodb.TransactWriteItemsOutput{}, nil
+		}
+		err = ss.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: ops,
+		})
+		require.NoError(t, err)
+	})
+}
+
+type mockStatsHook struct {
+	capacity  map[string]float64
+	throttled []int
+	lock      sync.Mutex
+}
+
+func (h *mockStatsHook) CapacityConsumed(operation string, table string, capacityUnits float64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.capacity[operation+"/"+table] += capacityUnits
+}
+
+func (h *mockStatsHook) RequestThrottled(operation string, table string, attempt int) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.throttled = append(h.throttled, attempt)
+}
+
+func TestThrottling(t *testing.T) {
+	throttledErr := &dynamodb.ProvisionedThroughputExceededException{}
+	req := &state.SetRequest{
+		Key:   "someKey",
+		Value: "someValue",
+	}
+
+	t.Run("Retry throttled request and report consumed capacity", func(t *testing.T) {
+		calls := 0
+		ss := &StateStore{
+			partitionKey:         defaultPartitionKeyName,
+			table:                tableName,
+			maxThrottlingRetries: 3,
+			billingMode:          billingModeOnDemand,
+			client: &mockedDynamoDB{
+				PutItemWithContextFn: func(ctx context.Context, input *dynamodb.PutItemInput, op ...request.Option) (*dynamodb.PutItemOutput, error) {
+					assert.Equal(t, dynamodb.ReturnConsumedCapacityTotal, aws.StringValue(input.ReturnConsumedCapacity))
+					calls++
+					if calls <= 2 {
+						return nil, throttledErr
+					}
+					return &dynamodb.PutItemOutput{
+						ConsumedCapacity: &dynamodb.ConsumedCapacity{
+							TableName:     aws.String(tableName),
+							CapacityUnits: aws.Float64(1),
+						},
+					}, nil
+				},
+			},
+		}
+		hook := &mockStatsHook{capacity: map[string]float64{}}
+		ss.SetStatsHook(hook)
+
+		err := ss.Set(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, []int{1, 2}, hook.throttled)
+		assert.Equal(t, map[string]float64{"set/" + tableName: 1}, hook.capacity)
+		// On-demand tables don't slow down other requests
+		assert.Equal(t, time.Duration(0), ss.throttlingDelay.delay)
+	})
+
+	t.Run("Return error when retries are exhausted", func(t *testing.T) {
+		calls := 0
+		describeCalls := 0
+		ss := &StateStore{
+			partitionKey:         defaultPartitionKeyName,
+			table:                tableName,
+			maxThrottlingRetries: 2,
+			client: &mockedDynamoDB{
+				PutItemWithContextFn: func(ctx context.Context, input *dynamodb.PutItemInput, op ...request.Option) (*dynamodb.PutItemOutput, error) {
+					assert.Nil(t, input.ReturnConsumedCapacity)
+					calls++
+					return nil, throttledErr
+				},
+				DescribeTableWithContextFn: func(ctx context.Context, input *dynamodb.DescribeTableInput, op ...request.Option) (*dynamodb.DescribeTableOutput, error) {
+					describeCalls++
+					assert.Equal(t, tableName, aws.StringValue(input.TableName))
+					return &dynamodb.DescribeTableOutput{
+						Table: &dynamodb.TableDescription{
+							BillingModeSummary: &dynamodb.BillingModeSummary{
+								BillingMode: aws.String(dynamodb.BillingModeProvisioned),
+							},
+						},
+					}, nil
+				},
+			},
+		}
+
+		err := ss.Set(context.Background(), req)
+		require.ErrorIs(t, err, throttledErr)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, 1, describeCalls)
+		assert.Equal(t, billingModeProvisioned, ss.billingMode)
+		// Provisioned tables slow down all requests
+		assert.Equal(t, 2*minThrottlingDelay, ss.throttlingDelay.delay)
+	})
+
+	t.Run("Billing mode lookup is retried after a failure", func(t *testing.T) {
+		describeCalls := 0
+		ss := &StateStore{
+			table: tableName,
+			client: &mockedDynamoDB{
+				DescribeTableWithContextFn: func(ctx context.Context, input *dynamodb.DescribeTableInput, op ...request.Option) (*dynamodb.DescribeTableOutput, error) {
+					describeCalls++
+					// The lookup must not be bound to the context of the request that triggered it
+					require.NoError(t, ctx.Err())
+					if describeCalls == 1 {
+						return nil, errors.New("access denied")
+					}
+					return &dynamodb.DescribeTableOutput{
+						Table: &dynamodb.TableDescription{
+							BillingModeSummary: &dynamodb.BillingModeSummary{
+								BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+							},
+						},
+					}, nil
+				},
+			},
+		}
+
+		assert.Equal(t, "", ss.getBillingMode())
+		// Failures are not retried before the retry interval
+		assert.Equal(t, "", ss.getBillingMode())
+		assert.Equal(t, 1, describeCalls)
+
+		ss.billingModeRetryAt = time.Now().Add(-time.Second)
+		assert.Equal(t, billingModeOnDemand, ss.getBillingMode())
+		assert.Equal(t, billingModeOnDemand, ss.getBillingMode())
+		assert.Equal(t, 2, describeCalls)
+	})
+
+	t.Run("Retry transaction canceled because of throttling", func(t *testing.T) {
+		calls := 0
+		ss := &StateStore{
+			partitionKey:         defaultPartitionKeyName,
+			table:                tableName,
+			maxThrottlingRetries: 1,
+			billingMode:          billingModeOnDemand,
+			client: &mockedDynamoDB{
+				TransactWriteItemsWithContextFn: func(ctx context.Context, input *dynamodb.TransactWriteItemsInput, op ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+					calls++
+					if calls == 1 {
+						return nil, &dynamodb.TransactionCanceledException{
+							CancellationReasons: []*dynamodb.CancellationReason{
+								{Code: aws.String("None")},
+								{Code: aws.String("ThrottlingError")},
+							},
+						}
+					}
+					return &dynamodb.TransactWriteItemsOutput{}, nil
+				},
+			},
+		}
+
+		err := ss.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "key1", Value: "value1"},
+				state.DeleteRequest{Key: "key2"},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("Don't retry other errors", func(t *testing.T) {
+		calls := 0
+		ss := &StateStore{
+			partitionKey:         defaultPartitionKeyName,
+			table:                tableName,
+			maxThrottlingRetries: 3,
+			client: &mockedDynamoDB{
+				DeleteItemWithContextFn: func(ctx context.Context, input *dynamodb.DeleteItemInput, op ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+					calls++
+					return nil, fmt.Errorf("unable to delete item")
+				},
+			},
+		}
+
+		err := ss.Delete(context.Background(), &state.DeleteRequest{Key: "key"})
+		require.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestParseBillingMode(t *testing.T) {
+	for val, expected := range map[string]string{
+		"":                "",
+		"provisioned":     billingModeProvisioned,
+		"ondemand":        billingModeOnDemand,
+		"PAY_PER_REQUEST": billingModeOnDemand,
+	} {
+		res, err := parseBillingMode(val)
+		require.NoError(t, err)
+		assert.Equal(t, expected, res)
+	}
+
+	_, err := parseBillingMode("free")
+	require.Error(t, err)
+}
+
+func TestFeatures(t *testing.T) {
+	s := &StateStore{}
+	assert.False(t, state.FeatureTTL.IsPresent(s.Features()))
+	assert.True(t, state.FeatureETag.IsPresent(s.Features()))
+
+	s.ttlAttributeName = "expiresAt"
+	assert.True(t, state.FeatureTTL.IsPresent(s.Features()))
 }
@@ -0,0 +1,262 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	billingModeProvisioned = "provisioned"
+	billingModeOnDemand    = "onDemand"
+
+	defaultMaxThrottlingRetries = 5
+	minThrottlingDelay          = 50 * time.Millisecond
+	maxThrottlingDelay          = 5 * time.Second
+
+	billingModeLookupTimeout = 10 * time.Second
+	billingModeRetryInterval = time.Minute
+)
+
+// StatsHook receives statistics about the requests of the DynamoDB state store.
+// Methods are invoked synchronously on the requesting goroutine, so implementations must be safe for concurrent use and must not block.
+type StatsHook interface {
+	// CapacityConsumed is invoked after a request succeeds, with the capacity units it consumed on a table.
+	CapacityConsumed(operation string, table string, capacityUnits float64)
+	// RequestThrottled is invoked every time a request is throttled by DynamoDB.
+	RequestThrottled(operation string, table string, attempt int)
+}
+
+// SetStatsHook sets the hook that receives the statistics of the requests.
+// When a hook is set, requests ask DynamoDB to return the capacity they consumed.
+// A nil hook disables the statistics.
+func (d *StateStore) SetStatsHook(hook StatsHook) {
+	d.statsLock.Lock()
+	d.statsHook = hook
+	d.statsLock.Unlock()
+}
+
+func (d *StateStore) getStatsHook() StatsHook {
+	d.statsLock.RLock()
+	defer d.statsLock.RUnlock()
+	return d.statsHook
+}
+
+// returnConsumedCapacity returns the ReturnConsumedCapacity parameter of requests.
+func (d *StateStore) returnConsumedCapacity() *string {
+	if d.getStatsHook() == nil {
+		return nil
+	}
+	return aws.String(dynamodb.ReturnConsumedCapacityTotal)
+}
+
+// reportCapacity reports the capacity consumed by a request to the stats hook.
+func (d *StateStore) reportCapacity(operation string, capacities ...*dynamodb.ConsumedCapacity) {
+	hook := d.getStatsHook()
+	if hook == nil {
+		return
+	}
+	for _, c := range capacities {
+		if c == nil || c.CapacityUnits == nil {
+			continue
+		}
+		hook.CapacityConsumed(operation, aws.StringValue(c.TableName), aws.Float64Value(c.CapacityUnits))
+	}
+}
+
+// withThrottlingRetries invokes fn, retrying it with an exponential backoff as long as DynamoDB throttles it, up to maxRetries times.
+// On tables with provisioned capacity, throttled requests also increase a delay applied to all requests, which decreases when requests succeed:
+// this way the store adapts its request rate to the provisioned throughput instead of retrying in a loop.
+func (d *StateStore) withThrottlingRetries(ctx context.Context, operation string, maxRetries int, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := d.throttlingDelay.wait(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = fn()
+		if err == nil {
+			d.throttlingDelay.decrease()
+			return nil
+		}
+		if !isThrottlingError(err) {
+			return err
+		}
+
+		if hook := d.getStatsHook(); hook != nil {
+			hook.RequestThrottled(operation, d.table, attempt)
+		}
+		if attempt > maxRetries {
+			return err
+		}
+		if d.getBillingMode() == billingModeProvisioned {
+			d.throttlingDelay.increase()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoffDelay(attempt)):
+		}
+	}
+}
+
+// getBillingMode returns the billing mode of the table.
+// If it wasn't set in the metadata, it's retrieved from DynamoDB the first time it's needed.
+// The lookup doesn't use the context of the request that triggered it, so canceling that request doesn't fail it.
+// If the lookup fails, an empty string is returned and the lookup is retried after billingModeRetryInterval.
+func (d *StateStore) getBillingMode() string {
+	d.billingModeLock.Lock()
+	defer d.billingModeLock.Unlock()
+
+	if d.billingMode != "" || time.Now().Before(d.billingModeRetryAt) {
+		return d.billingMode
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), billingModeLookupTimeout)
+	defer cancel()
+	out, err := d.client.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(d.table),
+	})
+	if err != nil {
+		d.billingModeRetryAt = time.Now().Add(billingModeRetryInterval)
+		if d.logger != nil {
+			d.logger.Warnf("Failed to retrieve the billing mode of DynamoDB table %s: %v", d.table, err)
+		}
+		return ""
+	}
+
+	// Tables created before on-demand capacity was introduced have no billing mode summary
+	if out.Table != nil && out.Table.BillingModeSummary != nil &&
+		aws.StringValue(out.Table.BillingModeSummary.BillingMode) == dynamodb.BillingModePayPerRequest {
+		d.billingMode = billingModeOnDemand
+	} else {
+		d.billingMode = billingModeProvisioned
+	}
+	return d.billingMode
+}
+
+// parseBillingMode validates the billingMode metadata property.
+func parseBillingMode(val string) (string, error) {
+	switch strings.ToLower(val) {
+	case "":
+		return "", nil
+	case strings.ToLower(billingModeProvisioned):
+		return billingModeProvisioned, nil
+	case strings.ToLower(billingModeOnDemand), strings.ToLower(dynamodb.BillingModePayPerRequest):
+		return billingModeOnDemand, nil
+	default:
+		return "", fmt.Errorf("invalid dynamodb billing mode '%s': supported values are '%s' and '%s'", val, billingModeProvisioned, billingModeOnDemand)
+	}
+}
+
+// isThrottlingError returns true if the request failed because DynamoDB throttled it.
+func isThrottlingError(err error) bool {
+	if request.IsErrorThrottle(err) {
+		return true
+	}
+
+	// Transactions are canceled if any of their items is throttled
+	var cErr *dynamodb.TransactionCanceledException
+	if errors.As(err, &cErr) {
+		for _, reason := range cErr.CancellationReasons {
+			switch aws.StringValue(reason.Code) {
+			case "ThrottlingError", "ProvisionedThroughputExceeded":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// backoffDelay returns the delay before a retry, with jitter.
+func backoffDelay(attempt int) time.Duration {
+	delay := maxThrottlingDelay
+	if attempt < 10 {
+		delay = minThrottlingDelay << (attempt - 1)
+		if delay > maxThrottlingDelay {
+			delay = maxThrottlingDelay
+		}
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2))) //nolint:gosec
+}
+
+// adaptiveDelay is a delay applied before all requests, which grows when requests are throttled and shrinks when they succeed.
+// The zero value applies no delay.
+type adaptiveDelay struct {
+	delay time.Duration
+	lock  sync.Mutex
+}
+
+func (a *adaptiveDelay) wait(ctx context.Context) error {
+	a.lock.Lock()
+	delay := a.delay
+	a.lock.Unlock()
+	if delay == 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+func (a *adaptiveDelay) increase() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.delay == 0 {
+		a.delay = minThrottlingDelay
+	} else if a.delay < maxThrottlingDelay {
+		a.delay *= 2
+		if a.delay > maxThrottlingDelay {
+			a.delay = maxThrottlingDelay
+		}
+	}
+}
+
+func (a *adaptiveDelay) decrease() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.delay /= 2
+	if a.delay < minThrottlingDelay {
+		a.delay = 0
+	}
+}
+
+// throttlingRetryer is the retryer of the DynamoDB client when the store retries throttled requests itself.
+// It retries the other errors like the default DynamoDB retryer.
+type throttlingRetryer struct {
+	client.DefaultRetryer
+}
+
+func (r throttlingRetryer) ShouldRetry(req *request.Request) bool {
+	if req.IsErrorThrottle() {
+		return false
+	}
+	return r.DefaultRetryer.ShouldRetry(req)
+}
@@ -26,6 +26,7 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 	"github.com/google/uuid"
 	jsoniter "github.com/json-iterator/go"
@@ -81,6 +82,8 @@ const (
 	metadataPartitionKey = "partitionKey"
 	defaultTimeout       = 20 * time.Second
 	statusNotFound       = "NotFound"
+	// Maximum number of operations in a transactional batch
+	maxBatchOperations = 100
 )
 
 // Policy that makes all queries cross-partition
@@ -205,6 +208,8 @@ func (c *StateStore) Features() []state.Feature {
 		state.FeatureETag,
 		state.FeatureTransactional,
 		state.FeatureQueryAPI,
+		state.FeatureTTL,
+		state.FeatureBulkNative,
 	}
 }
 
@@ -213,13 +218,16 @@ func (c *StateStore) Get(ctx context.Context, req *state.GetRequest) (*state.Get
 	partitionKey := populatePartitionMetadata(req.Key, req.Metadata)
 
 	options := azcosmos.ItemOptions{}
-	if req.Options.Consistency == state.Strong {
-		options.ConsistencyLevel = azcosmos.ConsistencyLevelSession.ToPtr()
-	} else if req.Options.Consistency == state.Eventual {
-		options.ConsistencyLevel = azcosmos.ConsistencyLevelEventual.ToPtr()
+	var err error
+	options.ConsistencyLevel, err = itemConsistencyLevel(req.Options.Consistency, req.Metadata)
+	if err != nil {
+		return nil, err
 	}
 
-	readCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	readCtx, cancel, err := requestContext(ctx, req.Metadata)
+	if err != nil {
+		return nil, err
+	}
 	defer cancel()
 	readItem, err := c.client.ReadItem(readCtx, azcosmos.NewPartitionKeyString(partitionKey), req.Key, &options)
 	if err != nil {
@@ -264,9 +272,13 @@ func (c *StateStore) getMulti(ctx context.Context, req []state.GetRequest) ([]st
 
 		// Use the specified consistency if empty
 		// If there's a strong consistency, it overrides any eventual
-		if r.Options.Consistency == state.Strong {
+		rc, err := state.RequestConsistency(r.Options.Consistency, r.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		if rc == state.Strong {
 			consistency = azcosmos.ConsistencyLevelStrong
-		} else if r.Options.Consistency == state.Eventual && consistency == "" {
+		} else if rc == state.Eventual && consistency == "" {
 			consistency = azcosmos.ConsistencyLevelEventual
 		}
 	}
@@ -285,6 +297,12 @@ func (c *StateStore) getMulti(ctx context.Context, req []state.GetRequest) ([]st
 		"SELECT * FROM r WHERE ARRAY_CONTAINS(@keys, r.id)",
 		pk, queryOpts,
 	)
+	// All the requests are executed at once, so the timeout and retries of the first one apply
+	ctx, cancel, err := requestContext(ctx, req[0].Metadata)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
 	result := make([]state.BulkGetResponse, len(req))
 	n := 0
 	for pager.More() {
@@ -413,11 +431,9 @@ func (c *StateStore) Set(ctx context.Context, req *state.SetRequest) error {
 		}
 		options.IfMatchEtag = ptr.Of(azcore.ETag(u.String()))
 	}
-	// Consistency levels can only be relaxed so the session level is used here
-	if req.Options.Consistency == state.Strong {
-		options.ConsistencyLevel = azcosmos.ConsistencyLevelSession.ToPtr()
-	} else if req.Options.Consistency == state.Eventual {
-		options.ConsistencyLevel = azcosmos.ConsistencyLevelEventual.ToPtr()
+	options.ConsistencyLevel, err = itemConsistencyLevel(req.Options.Consistency, req.Metadata)
+	if err != nil {
+		return err
 	}
 
 	doc, err := createUpsertItem(c.contentType, *req, partitionKey)
@@ -430,7 +446,10 @@ func (c *StateStore) Set(ctx context.Context, req *state.SetRequest) error {
 		return err
 	}
 
-	upsertCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	upsertCtx, cancel, err := requestContext(ctx, req.Metadata)
+	if err != nil {
+		return err
+	}
 	defer cancel()
 	pk := azcosmos.NewPartitionKeyString(partitionKey)
 	_, err = c.client.UpsertItem(upsertCtx, pk, marsh, &options)
@@ -465,13 +484,15 @@ func (c *StateStore) Delete(ctx context.Context, req *state.DeleteRequest) error
 		options.IfMatchEtag = ptr.Of(azcore.ETag(u.String()))
 	}
 
-	if req.Options.Consistency == state.Strong {
-		options.ConsistencyLevel = azcosmos.ConsistencyLevelSession.ToPtr()
-	} else if req.Options.Consistency == state.Eventual {
-		options.ConsistencyLevel = azcosmos.ConsistencyLevelEventual.ToPtr()
+	options.ConsistencyLevel, err = itemConsistencyLevel(req.Options.Consistency, req.Metadata)
+	if err != nil {
+		return err
 	}
 
-	deleteCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	deleteCtx, cancel, err := requestContext(ctx, req.Metadata)
+	if err != nil {
+		return err
+	}
 	defer cancel()
 	pk := azcosmos.NewPartitionKeyString(partitionKey)
 	_, err = c.client.DeleteItem(deleteCtx, pk, req.Key, &options)
@@ -497,62 +518,74 @@ func (c *StateStore) Multi(ctx context.Context, request *state.TransactionalStat
 	partitionKey := request.Metadata[metadataPartitionKey]
 	batch := c.client.NewTransactionalBatch(azcosmos.NewPartitionKeyString(partitionKey))
 
-	numOperations := 0
 	// Loop through the list of operations. Create and add the operation to the batch
 	for _, o := range request.Operations {
-		options := &azcosmos.TransactionalBatchItemOptions{}
+		err = c.addBatchOperation(&batch, o, partitionKey)
+		if err != nil {
+			return err
+		}
+	}
 
-		switch req := o.(type) {
-		case state.SetRequest:
-			var doc CosmosItem
-			doc, err = createUpsertItem(c.contentType, req, partitionKey)
+	c.logger.Debugf("#operations=%d,partitionkey=%s", len(request.Operations), partitionKey)
+
+	return c.executeBatch(ctx, &batch, request.Metadata)
+}
+
+// addBatchOperation adds a set or delete operation to the transactional batch.
+func (c *StateStore) addBatchOperation(batch *azcosmos.TransactionalBatch, o state.TransactionalStateOperation, partitionKey string) error {
+	options := &azcosmos.TransactionalBatchItemOptions{}
+
+	switch req := o.(type) {
+	case state.SetRequest:
+		doc, err := createUpsertItem(c.contentType, req, partitionKey)
+		if err != nil {
+			return err
+		}
+		doc.PartitionKey = partitionKey
+
+		if req.HasETag() {
+			etag := azcore.ETag(*req.ETag)
+			options.IfMatchETag = &etag
+		} else if req.Options.Concurrency == state.FirstWrite {
+			u, err := uuid.NewRandom()
 			if err != nil {
 				return err
 			}
-			doc.PartitionKey = partitionKey
-
-			if req.HasETag() {
-				etag := azcore.ETag(*req.ETag)
-				options.IfMatchETag = &etag
-			} else if req.Options.Concurrency == state.FirstWrite {
-				var u uuid.UUID
-				u, err = uuid.NewRandom()
-				if err != nil {
-					return err
-				}
-				options.IfMatchETag = ptr.Of(azcore.ETag(u.String()))
-			}
+			options.IfMatchETag = ptr.Of(azcore.ETag(u.String()))
+		}
 
-			var marsh []byte
-			marsh, err = json.Marshal(doc)
+		marsh, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		batch.UpsertItem(marsh, options)
+	case state.DeleteRequest:
+		if req.HasETag() {
+			etag := azcore.ETag(*req.ETag)
+			options.IfMatchETag = &etag
+		} else if req.Options.Concurrency == state.FirstWrite {
+			u, err := uuid.NewRandom()
 			if err != nil {
 				return err
 			}
-			batch.UpsertItem(marsh, options)
-			numOperations++
-		case state.DeleteRequest:
-			if req.HasETag() {
-				etag := azcore.ETag(*req.ETag)
-				options.IfMatchETag = &etag
-			} else if req.Options.Concurrency == state.FirstWrite {
-				var u uuid.UUID
-				u, err = uuid.NewRandom()
-				if err != nil {
-					return err
-				}
-				options.IfMatchETag = ptr.Of(azcore.ETag(u.String()))
-			}
-
-			batch.DeleteItem(req.Key, options)
-			numOperations++
+			options.IfMatchETag = ptr.Of(azcore.ETag(u.String()))
 		}
+
+		batch.DeleteItem(req.Key, options)
 	}
 
-	c.logger.Debugf("#operations=%d,partitionkey=%s", numOperations, partitionKey)
+	return nil
+}
 
-	execCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+// executeBatch executes a transactional batch, returning an error if any operation failed.
+// reqMetadata is the metadata of the request, which can set its timeout and retries.
+func (c *StateStore) executeBatch(ctx context.Context, batch *azcosmos.TransactionalBatch, reqMetadata map[string]string) error {
+	execCtx, cancel, err := requestContext(ctx, reqMetadata)
+	if err != nil {
+		return err
+	}
 	defer cancel()
-	batchResponse, err := c.client.ExecuteTransactionalBatch(execCtx, batch, nil)
+	batchResponse, err := c.client.ExecuteTransactionalBatch(execCtx, *batch, nil)
 	if err != nil {
 		return err
 	}
@@ -579,6 +612,127 @@ func (c *StateStore) Multi(ctx context.Context, request *state.TransactionalStat
 	return nil
 }
 
+// BulkSet performs a Set operation in bulk.
+// Requests are grouped by partition key and stored with transactional batches.
+func (c *StateStore) BulkSet(ctx context.Context, req []state.SetRequest, opts state.BulkStoreOpts) error {
+	ops := make([]state.TransactionalStateOperation, len(req))
+	for i, r := range req {
+		ops[i] = r
+	}
+	return c.doBulkSetDelete(ctx, ops, opts)
+}
+
+// BulkDelete performs a Delete operation in bulk.
+// Requests are grouped by partition key and deleted with transactional batches.
+func (c *StateStore) BulkDelete(ctx context.Context, req []state.DeleteRequest, opts state.BulkStoreOpts) error {
+	ops := make([]state.TransactionalStateOperation, len(req))
+	for i, r := range req {
+		ops[i] = r
+	}
+	return c.doBulkSetDelete(ctx, ops, opts)
+}
+
+func (c *StateStore) doBulkSetDelete(ctx context.Context, ops []state.TransactionalStateOperation, opts state.BulkStoreOpts) error {
+	groups := groupBulkOperations(ops)
+
+	// If parallelism isn't set, run all groups in parallel
+	var limitCh chan struct{}
+	if opts.Parallelism > 0 {
+		limitCh = make(chan struct{}, opts.Parallelism)
+	}
+	errCh := make(chan error, len(groups))
+	for _, g := range groups {
+		// Limit concurrency
+		if limitCh != nil {
+			limitCh <- struct{}{}
+		}
+
+		go func(g bulkOperationGroup) {
+			errCh <- c.executeBulkGroup(ctx, g)
+
+			// Release the token for concurrency
+			if limitCh != nil {
+				<-limitCh
+			}
+		}(g)
+	}
+
+	errs := make([]error, len(groups))
+	for i := range groups {
+		errs[i] = <-errCh
+	}
+
+	return errors.Join(errs...)
+}
+
+// executeBulkGroup executes a group of operations on the same partition as a transactional batch.
+// If the batch fails, operations are executed individually so errors can be reported for each key, with the same result as if they had not been batched.
+func (c *StateStore) executeBulkGroup(ctx context.Context, g bulkOperationGroup) error {
+	if len(g.operations) > 1 {
+		batch := c.client.NewTransactionalBatch(azcosmos.NewPartitionKeyString(g.partitionKey))
+		var err error
+		for _, o := range g.operations {
+			err = c.addBatchOperation(&batch, o, g.partitionKey)
+			if err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = c.executeBatch(ctx, &batch, nil)
+			if err == nil {
+				return nil
+			}
+		}
+		c.logger.Debugf("Bulk operation on partition %s failed as a batch, retrying operations individually: %v", g.partitionKey, err)
+	}
+
+	errs := make([]error, len(g.operations))
+	for i, o := range g.operations {
+		var err error
+		switch req := o.(type) {
+		case state.SetRequest:
+			err = c.Set(ctx, &req)
+		case state.DeleteRequest:
+			err = c.Delete(ctx, &req)
+		}
+		if err != nil {
+			errs[i] = state.NewBulkStoreError(o.GetKey(), err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type bulkOperationGroup struct {
+	partitionKey string
+	operations   []state.TransactionalStateOperation
+}
+
+// groupBulkOperations groups operations by partition key, in groups of up to maxBatchOperations.
+// The order of operations is preserved within each partition.
+func groupBulkOperations(ops []state.TransactionalStateOperation) []bulkOperationGroup {
+	groups := []bulkOperationGroup{}
+	// Index of the last group for each partition key
+	last := map[string]int{}
+	for _, o := range ops {
+		var partitionKey string
+		switch req := o.(type) {
+		case state.SetRequest:
+			partitionKey = populatePartitionMetadata(req.Key, req.Metadata)
+		case state.DeleteRequest:
+			partitionKey = populatePartitionMetadata(req.Key, req.Metadata)
+		}
+
+		i, ok := last[partitionKey]
+		if !ok || len(groups[i].operations) >= maxBatchOperations {
+			groups = append(groups, bulkOperationGroup{partitionKey: partitionKey})
+			i = len(groups) - 1
+			last[partitionKey] = i
+		}
+		groups[i].operations = append(groups[i].operations, o)
+	}
+	return groups
+}
+
 func (c *StateStore) Query(ctx context.Context, req *state.QueryRequest) (*state.QueryResponse, error) {
 	q := &Query{}
 
@@ -586,8 +740,32 @@ func (c *StateStore) Query(ctx context.Context, req *state.QueryRequest) (*state
 	if err := qbuilder.BuildQuery(&req.Query); err != nil {
 		return &state.QueryResponse{}, err
 	}
+	if contribmeta.IsQueryExplain(req.Metadata) {
+		return &state.QueryResponse{
+			Results:  []state.QueryItem{},
+			Metadata: q.Explain().Metadata(),
+		}, nil
+	}
+
+	timeout, err := state.RequestTimeout(req.Metadata, defaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err = withRequestRetries(ctx, req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	consistency, err := state.RequestConsistency("", req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	if consistency == state.Strong {
+		q.consistency = azcosmos.ConsistencyLevelStrong.ToPtr()
+	} else if consistency == state.Eventual {
+		q.consistency = azcosmos.ConsistencyLevelEventual.ToPtr()
+	}
 
-	data, token, err := q.execute(ctx, c.client)
+	data, token, err := q.execute(ctx, c.client, timeout)
 	if err != nil {
 		return nil, err
 	}
@@ -659,6 +837,48 @@ func createUpsertItem(contentType string, req state.SetRequest, partitionKey str
 
 // This is a helper to return the partition key to use.  If if metadata["partitionkey"] is present,
 // use that, otherwise use what's in "key".
+// itemConsistencyLevel returns the consistency level of an operation on an item, from its consistency option or metadata.
+// Consistency levels can only be relaxed, so the session level is used for strong consistency.
+func itemConsistencyLevel(option string, reqMetadata map[string]string) (*azcosmos.ConsistencyLevel, error) {
+	consistency, err := state.RequestConsistency(option, reqMetadata)
+	if err != nil {
+		return nil, err
+	}
+	switch consistency {
+	case state.Strong:
+		return azcosmos.ConsistencyLevelSession.ToPtr(), nil
+	case state.Eventual:
+		return azcosmos.ConsistencyLevelEventual.ToPtr(), nil
+	default:
+		return nil, nil
+	}
+}
+
+// requestContext returns the context of a request, with the timeout and the maximum number of retries in its metadata.
+func requestContext(ctx context.Context, reqMetadata map[string]string) (context.Context, context.CancelFunc, error) {
+	ctx, err := withRequestRetries(ctx, reqMetadata)
+	if err != nil {
+		return nil, nil, err
+	}
+	return state.RequestContext(ctx, reqMetadata, defaultTimeout)
+}
+
+// withRequestRetries returns a context that sets the maximum number of retries of the SDK, if the metadata of the request sets it.
+func withRequestRetries(ctx context.Context, reqMetadata map[string]string) (context.Context, error) {
+	if reqMetadata[state.MaxRetriesMetadataKey] == "" {
+		return ctx, nil
+	}
+	maxRetries, err := state.RequestMaxRetries(reqMetadata, 0)
+	if err != nil {
+		return nil, err
+	}
+	// For the Azure SDK, 0 means the default number of retries and -1 means no retry
+	if maxRetries == 0 {
+		maxRetries = -1
+	}
+	return runtime.WithRetryOptions(ctx, policy.RetryOptions{MaxRetries: int32(maxRetries)}), nil
+}
+
 func populatePartitionMetadata(key string, requestMetadata map[string]string) string {
 	if val, found := requestMetadata[metadataPartitionKey]; found {
 		return val
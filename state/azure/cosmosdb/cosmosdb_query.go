@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 	jsoniter "github.com/json-iterator/go"
@@ -34,9 +35,10 @@ type InternalQuery struct {
 }
 
 type Query struct {
-	query InternalQuery
-	limit int
-	token string
+	query       InternalQuery
+	limit       int
+	token       string
+	consistency *azcosmos.ConsistencyLevel
 }
 
 func (q *Query) VisitEQ(f *query.EQ) (string, error) {
@@ -137,6 +139,19 @@ func (q *Query) Finalize(filters string, qq *query.Query) error {
 	return nil
 }
 
+// Explain returns the Cosmos DB SQL query and its parameters.
+func (q *Query) Explain() query.Explanation {
+	params := make([]any, len(q.query.parameters))
+	for i, p := range q.query.parameters {
+		params[i] = p.Value
+	}
+	return query.Explanation{
+		Backend:    "azure.cosmosdb",
+		Query:      q.query.query,
+		Parameters: params,
+	}
+}
+
 func (q *Query) setNextParameter(val string) string {
 	pname := fmt.Sprintf("@__param__%d__", len(q.query.parameters))
 	q.query.parameters = append(q.query.parameters, azcosmos.QueryParameter{Name: pname, Value: val})
@@ -144,43 +159,60 @@ func (q *Query) setNextParameter(val string) string {
 	return pname
 }
 
-func (q *Query) execute(ctx context.Context, client *azcosmos.ContainerClient) ([]state.QueryItem, string, error) {
-	opts := &azcosmos.QueryOptions{}
+// queryPageFetcher retrieves a page of results for the query, starting at the continuation token (if not empty) and with at most pageSize items (if greater than 0).
+type queryPageFetcher func(ctx context.Context, token string, pageSize int) (items [][]byte, nextToken string, err error)
 
-	resultLimit := q.limit
-	opts.QueryParameters = append(opts.QueryParameters, q.query.parameters...)
+func (q *Query) execute(ctx context.Context, client *azcosmos.ContainerClient, timeout time.Duration) ([]state.QueryItem, string, error) {
+	pk := azcosmos.NewPartitionKeyBool(true)
+	fetch := func(ctx context.Context, token string, pageSize int) ([][]byte, string, error) {
+		opts := &azcosmos.QueryOptions{
+			QueryParameters:   q.query.parameters,
+			ContinuationToken: token,
+			PageSizeHint:      int32(pageSize),
+			ConsistencyLevel:  q.consistency,
+		}
+		queryPager := client.NewQueryItemsPager(q.query.query, pk, opts)
 
-	if len(q.token) != 0 {
-		opts.ContinuationToken = q.token
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		queryResponse, err := queryPager.NextPage(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		return queryResponse.Items, queryResponse.ContinuationToken, nil
 	}
 
+	return q.collect(ctx, fetch)
+}
+
+// collect retrieves pages of results until the limit is reached or there are no more results.
+// When there's a limit, each page is requested with the number of items that are still needed, so the returned continuation token points exactly after the last returned item.
+func (q *Query) collect(ctx context.Context, fetch queryPageFetcher) ([]state.QueryItem, string, error) {
+	resultLimit := q.limit
 	items := []CosmosItem{}
-	pk := azcosmos.NewPartitionKeyBool(true)
-	queryPager := client.NewQueryItemsPager(q.query.query, pk, opts)
-
-	token := ""
-	for queryPager.More() {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
-		queryResponse, innerErr := queryPager.NextPage(ctx)
-		cancel()
-		if innerErr != nil {
-			return nil, "", innerErr
+	token := q.token
+	for {
+		pageSize := 0
+		if resultLimit > 0 {
+			pageSize = resultLimit - len(items)
 		}
 
-		token = queryResponse.ContinuationToken
-		for _, item := range queryResponse.Items {
+		page, nextToken, err := fetch(ctx, token, pageSize)
+		if err != nil {
+			return nil, "", err
+		}
+		token = nextToken
+
+		for _, item := range page {
 			tempItem := CosmosItem{}
-			err := json.Unmarshal(item, &tempItem)
+			err = json.Unmarshal(item, &tempItem)
 			if err != nil {
 				return nil, "", err
 			}
-			if (resultLimit != 0) && (len(items) >= resultLimit) {
-				break
-			}
 			items = append(items, tempItem)
 		}
-		if (resultLimit != 0) && (len(items) >= resultLimit) {
+
+		if token == "" || (resultLimit > 0 && len(items) >= resultLimit) {
 			break
 		}
 	}
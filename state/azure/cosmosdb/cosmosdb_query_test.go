@@ -14,12 +14,16 @@ limitations under the License.
 package cosmosdb
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"os"
+	"strconv"
 	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/state/query"
 )
@@ -140,3 +144,70 @@ func TestCosmosDbQuery(t *testing.T) {
 		assert.Equal(t, test.query, q.query)
 	}
 }
+
+func TestCosmosDbQueryCollect(t *testing.T) {
+	// Fake server with 10 items, returning at most 4 items per page
+	const total = 10
+	var requests []int
+	fetch := func(ctx context.Context, token string, pageSize int) ([][]byte, string, error) {
+		requests = append(requests, pageSize)
+		start := 0
+		if token != "" {
+			start, _ = strconv.Atoi(token)
+		}
+		end := start + 4
+		if pageSize > 0 && start+pageSize < end {
+			end = start + pageSize
+		}
+		if end > total {
+			end = total
+		}
+		items := make([][]byte, 0, end-start)
+		for i := start; i < end; i++ {
+			items = append(items, []byte(fmt.Sprintf(`{"id":"key%d","value":%d}`, i, i)))
+		}
+		next := ""
+		if end < total {
+			next = strconv.Itoa(end)
+		}
+		return items, next, nil
+	}
+
+	t.Run("no limit", func(t *testing.T) {
+		requests = nil
+		q := &Query{}
+		res, token, err := q.collect(context.Background(), fetch)
+		require.NoError(t, err)
+		assert.Len(t, res, total)
+		assert.Empty(t, token)
+		assert.Equal(t, []int{0, 0, 0}, requests)
+	})
+
+	t.Run("limit spanning multiple pages", func(t *testing.T) {
+		requests = nil
+		q := &Query{limit: 6}
+		res, token, err := q.collect(context.Background(), fetch)
+		require.NoError(t, err)
+		require.Len(t, res, 6)
+		assert.Equal(t, "key5", res[5].Key)
+		assert.Equal(t, "6", token)
+		assert.Equal(t, []int{6, 2}, requests)
+
+		// Continue from the token
+		requests = nil
+		q = &Query{limit: 6, token: token}
+		res, token, err = q.collect(context.Background(), fetch)
+		require.NoError(t, err)
+		require.Len(t, res, 4)
+		assert.Equal(t, "key6", res[0].Key)
+		assert.Empty(t, token)
+	})
+
+	t.Run("fetch error", func(t *testing.T) {
+		q := &Query{}
+		_, _, err := q.collect(context.Background(), func(ctx context.Context, token string, pageSize int) ([][]byte, string, error) {
+			return nil, "", fmt.Errorf("simulated")
+		})
+		require.Error(t, err)
+	})
+}
@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/state"
 	stateutils "github.com/dapr/components-contrib/state/utils"
@@ -356,3 +357,35 @@ func TestCreateCosmosItemWithTTL(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestGroupBulkOperations(t *testing.T) {
+	t.Run("group by partition key", func(t *testing.T) {
+		ops := []state.TransactionalStateOperation{
+			state.SetRequest{Key: "a", Metadata: map[string]string{metadataPartitionKey: "p1"}},
+			state.DeleteRequest{Key: "b", Metadata: map[string]string{metadataPartitionKey: "p2"}},
+			state.SetRequest{Key: "c", Metadata: map[string]string{metadataPartitionKey: "p1"}},
+			// Without a partition key, the key is used as partition key
+			state.SetRequest{Key: "d"},
+		}
+		groups := groupBulkOperations(ops)
+		require.Len(t, groups, 3)
+		assert.Equal(t, "p1", groups[0].partitionKey)
+		assert.Equal(t, []state.TransactionalStateOperation{ops[0], ops[2]}, groups[0].operations)
+		assert.Equal(t, "p2", groups[1].partitionKey)
+		assert.Equal(t, []state.TransactionalStateOperation{ops[1]}, groups[1].operations)
+		assert.Equal(t, "d", groups[2].partitionKey)
+		assert.Equal(t, []state.TransactionalStateOperation{ops[3]}, groups[2].operations)
+	})
+
+	t.Run("split groups larger than the batch limit", func(t *testing.T) {
+		ops := make([]state.TransactionalStateOperation, maxBatchOperations+5)
+		for i := range ops {
+			ops[i] = state.DeleteRequest{Key: strconv.Itoa(i), Metadata: map[string]string{metadataPartitionKey: "p"}}
+		}
+		groups := groupBulkOperations(ops)
+		require.Len(t, groups, 2)
+		assert.Len(t, groups[0].operations, maxBatchOperations)
+		assert.Len(t, groups[1].operations, 5)
+		assert.Equal(t, strconv.Itoa(maxBatchOperations), groups[1].operations[0].GetKey())
+	})
+}
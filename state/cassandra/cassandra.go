@@ -115,7 +115,7 @@ func (c *Cassandra) Init(_ context.Context, metadata state.Metadata) error {
 
 // Features returns the features available in this state store.
 func (c *Cassandra) Features() []state.Feature {
-	return nil
+	return []state.Feature{state.FeatureTTL}
 }
 
 func (c *Cassandra) tryCreateKeyspace(keyspace string, replicationFactor int) error {
@@ -217,30 +217,34 @@ func getCassandraMetadata(meta state.Metadata) (*cassandraMetadata, error) {
 
 // Delete performs a delete operation.
 func (c *Cassandra) Delete(ctx context.Context, req *state.DeleteRequest) error {
-	return c.session.Query(fmt.Sprintf("DELETE FROM %s WHERE key = ?", c.table), req.Key).WithContext(ctx).Exec()
+	ctx, cancel, err := state.RequestContext(ctx, req.Metadata, 0)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	q := c.session.Query(fmt.Sprintf("DELETE FROM %s WHERE key = ?", c.table), req.Key).WithContext(ctx)
+	err = applyRequestOptions(q, req.Options.Consistency, req.Metadata, gocql.Quorum, gocql.Any)
+	if err != nil {
+		return err
+	}
+	return q.Exec()
 }
 
 // Get retrieves state from cassandra with a key.
 func (c *Cassandra) Get(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
-	session := c.session
-
-	if req.Options.Consistency == state.Strong {
-		sess, err := c.createSession(gocql.All)
-		if err != nil {
-			return nil, err
-		}
-		defer sess.Close()
-		session = sess
-	} else if req.Options.Consistency == state.Eventual {
-		sess, err := c.createSession(gocql.One)
-		if err != nil {
-			return nil, err
-		}
-		defer sess.Close()
-		session = sess
+	ctx, cancel, err := state.RequestContext(ctx, req.Metadata, 0)
+	if err != nil {
+		return nil, err
 	}
+	defer cancel()
 
-	results, err := session.Query(fmt.Sprintf("SELECT value FROM %s WHERE key = ?", c.table), req.Key).WithContext(ctx).Iter().SliceMap()
+	q := c.session.Query(fmt.Sprintf("SELECT value FROM %s WHERE key = ?", c.table), req.Key).WithContext(ctx)
+	err = applyRequestOptions(q, req.Options.Consistency, req.Metadata, gocql.All, gocql.One)
+	if err != nil {
+		return nil, err
+	}
+	results, err := q.Iter().SliceMap()
 	if err != nil {
 		return nil, err
 	}
@@ -264,45 +268,53 @@ func (c *Cassandra) Set(ctx context.Context, req *state.SetRequest) error {
 		bt, _ = jsoniter.ConfigFastest.Marshal(req.Value)
 	}
 
-	session := c.session
-
-	if req.Options.Consistency == state.Strong {
-		sess, err := c.createSession(gocql.Quorum)
-		if err != nil {
-			return err
-		}
-		defer sess.Close()
-		session = sess
-	} else if req.Options.Consistency == state.Eventual {
-		sess, err := c.createSession(gocql.Any)
-		if err != nil {
-			return err
-		}
-		defer sess.Close()
-		session = sess
-	}
-
 	ttl, err := stateutils.ParseTTL(req.Metadata)
 	if err != nil {
 		return fmt.Errorf("error parsing TTL from Metadata: %s", err)
 	}
 
-	if ttl != nil {
-		return session.Query(fmt.Sprintf("INSERT INTO %s (key, value) VALUES (?, ?) USING TTL ?", c.table), req.Key, bt, *ttl).WithContext(ctx).Exec()
+	ctx, cancel, err := state.RequestContext(ctx, req.Metadata, 0)
+	if err != nil {
+		return err
 	}
+	defer cancel()
 
-	return session.Query(fmt.Sprintf("INSERT INTO %s (key, value) VALUES (?, ?)", c.table), req.Key, bt).WithContext(ctx).Exec()
+	var q *gocql.Query
+	if ttl != nil {
+		q = c.session.Query(fmt.Sprintf("INSERT INTO %s (key, value) VALUES (?, ?) USING TTL ?", c.table), req.Key, bt, *ttl)
+	} else {
+		q = c.session.Query(fmt.Sprintf("INSERT INTO %s (key, value) VALUES (?, ?)", c.table), req.Key, bt)
+	}
+	err = applyRequestOptions(q.WithContext(ctx), req.Options.Consistency, req.Metadata, gocql.Quorum, gocql.Any)
+	if err != nil {
+		return err
+	}
+	return q.Exec()
 }
 
-func (c *Cassandra) createSession(consistency gocql.Consistency) (*gocql.Session, error) {
-	session, err := c.cluster.CreateSession()
+// applyRequestOptions sets the consistency level and the retry policy of a query from the options and the metadata of the request.
+// strong and eventual are the consistency levels used for the "strong" and "eventual" consistencies; otherwise the one of the component is used.
+func applyRequestOptions(q *gocql.Query, consistencyOption string, reqMetadata map[string]string, strong, eventual gocql.Consistency) error {
+	consistency, err := state.RequestConsistency(consistencyOption, reqMetadata)
 	if err != nil {
-		return nil, fmt.Errorf("error creating session: %s", err)
+		return err
+	}
+	switch consistency {
+	case state.Strong:
+		q.Consistency(strong)
+	case state.Eventual:
+		q.Consistency(eventual)
 	}
 
-	session.SetConsistency(consistency)
+	if reqMetadata[state.MaxRetriesMetadataKey] != "" {
+		maxRetries, err := state.RequestMaxRetries(reqMetadata, 0)
+		if err != nil {
+			return err
+		}
+		q.RetryPolicy(&gocql.SimpleRetryPolicy{NumRetries: maxRetries})
+	}
 
-	return session, nil
+	return nil
 }
 
 func (c *Cassandra) GetComponentMetadata() map[string]string {
@@ -59,7 +59,7 @@ type etcdConfig struct {
 func NewEtcdStateStore(logger logger.Logger) state.Store {
 	s := &Etcd{
 		logger:   logger,
-		features: []state.Feature{state.FeatureETag, state.FeatureTransactional},
+		features: []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureTTL},
 	}
 	s.BulkStore = state.NewDefaultBulkStore(s)
 	return s
@@ -14,6 +14,10 @@ limitations under the License.
 package state
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	"golang.org/x/exp/slices"
 )
 
@@ -24,8 +28,16 @@ const (
 	FeatureTransactional Feature = "TRANSACTIONAL"
 	// FeatureQueryAPI is the feature that performs query operations.
 	FeatureQueryAPI Feature = "QUERY_API"
+	// FeatureTTL is the feature that supports TTLs.
+	FeatureTTL Feature = "TTL"
+	// FeatureBulkNative is the feature that performs BulkGet natively, with a single request or batches of requests.
+	// Without it, BulkGet performs a request for each key. BulkSet and BulkDelete can perform a request for each key even with it.
+	FeatureBulkNative Feature = "BULK_NATIVE"
 )
 
+// ErrFeatureNotSupported is returned when a state store doesn't have a feature that is required.
+var ErrFeatureNotSupported = errors.New("feature not supported by the state store")
+
 // Feature names a feature that can be implemented by PubSub components.
 type Feature string
 
@@ -33,3 +45,18 @@ type Feature string
 func (f Feature) IsPresent(features []Feature) bool {
 	return slices.Contains(features, f)
 }
+
+// CheckFeatures returns an error wrapping ErrFeatureNotSupported if any of the required features is not in the list.
+// It allows failing fast when an application relies on a feature that the state store doesn't support.
+func CheckFeatures(features []Feature, required ...Feature) error {
+	var missing []string
+	for _, f := range required {
+		if !f.IsPresent(features) {
+			missing = append(missing, string(f))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrFeatureNotSupported, strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckFeatures(t *testing.T) {
+	features := []Feature{FeatureETag, FeatureTTL}
+
+	assert.NoError(t, CheckFeatures(features))
+	assert.NoError(t, CheckFeatures(features, FeatureETag, FeatureTTL))
+
+	err := CheckFeatures(features, FeatureETag, FeatureTransactional, FeatureBulkNative)
+	assert.ErrorIs(t, err, ErrFeatureNotSupported)
+	assert.ErrorContains(t, err, "TRANSACTIONAL, BULK_NATIVE")
+
+	assert.ErrorIs(t, CheckFeatures(nil, FeatureQueryAPI), ErrFeatureNotSupported)
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spanner
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	gcpspanner "cloud.google.com/go/spanner"
+	"google.golang.org/api/iterator"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+	"github.com/dapr/kit/ptr"
+)
+
+var (
+	// Fields that can be used in JSON paths without quotes.
+	unquotedFieldRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// Fields that can be used in JSON paths with double quotes.
+	quotedFieldRegex = regexp.MustCompile(`^[^"'\\]+$`)
+)
+
+// Query translates a query request to Spanner SQL.
+// Fields of the values are read with JSON_VALUE, so values are compared as strings.
+type Query struct {
+	table  string
+	query  string
+	params map[string]any
+	limit  int
+	skip   *int64
+}
+
+func (q *Query) VisitEQ(f *query.EQ) (string, error) {
+	return q.whereFieldEqual(f.Key, f.Val)
+}
+
+func (q *Query) VisitIN(f *query.IN) (string, error) {
+	if len(f.Vals) == 0 {
+		return "", fmt.Errorf("empty IN operator for key %q", f.Key)
+	}
+
+	field, err := translateFieldToFilter(f.Key)
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, len(f.Vals))
+	for i, v := range f.Vals {
+		names[i] = "@" + q.addParam(v)
+	}
+	return field + " IN (" + strings.Join(names, ", ") + ")", nil
+}
+
+func (q *Query) visitFilters(op string, filters []query.Filter) (string, error) {
+	arr := make([]string, 0, len(filters))
+	for _, fil := range filters {
+		var (
+			str string
+			err error
+		)
+		switch f := fil.(type) {
+		case *query.EQ:
+			str, err = q.VisitEQ(f)
+		case *query.IN:
+			str, err = q.VisitIN(f)
+		case *query.OR:
+			str, err = q.VisitOR(f)
+		case *query.AND:
+			str, err = q.VisitAND(f)
+		default:
+			err = fmt.Errorf("unsupported filter type %#v", f)
+		}
+		if err != nil {
+			return "", err
+		}
+		arr = append(arr, str)
+	}
+
+	return "(" + strings.Join(arr, " "+op+" ") + ")", nil
+}
+
+func (q *Query) VisitAND(f *query.AND) (string, error) {
+	return q.visitFilters("AND", f.Filters)
+}
+
+func (q *Query) VisitOR(f *query.OR) (string, error) {
+	return q.visitFilters("OR", f.Filters)
+}
+
+func (q *Query) Finalize(filters string, qq *query.Query) error {
+	q.query = "SELECT key, value, is_binary, update_time FROM " + q.table + " WHERE " + notExpired
+	if filters != "" {
+		q.query += " AND " + filters
+	}
+
+	if len(qq.Sort) > 0 {
+		order := make([]string, len(qq.Sort))
+		for i, sortItem := range qq.Sort {
+			field, err := translateFieldToFilter(sortItem.Key)
+			if err != nil {
+				return err
+			}
+			order[i] = field
+			if sortItem.Order != "" {
+				order[i] += " " + sortItem.Order
+			}
+		}
+		q.query += " ORDER BY " + strings.Join(order, ", ")
+	}
+
+	if qq.Page.Limit > 0 {
+		q.query += " LIMIT " + strconv.Itoa(qq.Page.Limit)
+		q.limit = qq.Page.Limit
+	}
+
+	if len(qq.Page.Token) != 0 {
+		// Spanner supports OFFSET only after LIMIT
+		if q.limit == 0 {
+			return errors.New("a page token requires a page limit")
+		}
+		skip, err := strconv.ParseInt(qq.Page.Token, 10, 64)
+		if err != nil {
+			return err
+		}
+		q.query += " OFFSET " + strconv.FormatInt(skip, 10)
+		q.skip = &skip
+	}
+
+	return nil
+}
+
+// Explain returns the SQL query and its parameters.
+func (q *Query) Explain() query.Explanation {
+	params := make([]any, len(q.params))
+	for i := range params {
+		params[i] = q.params[paramName(i+1)]
+	}
+	return query.Explanation{
+		Backend:    "spanner",
+		Query:      q.query,
+		Parameters: params,
+	}
+}
+
+// results returns the items of the result set of the query, and the token of the next page.
+func (q *Query) results(iter *gcpspanner.RowIterator) ([]state.QueryItem, string, error) {
+	defer iter.Stop()
+
+	ret := []state.QueryItem{}
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		var (
+			key        string
+			value      gcpspanner.GenericColumnValue
+			isBinary   gcpspanner.NullBool
+			updateTime time.Time
+		)
+		err = row.Columns(&key, &value, &isBinary, &updateTime)
+		if err != nil {
+			return nil, "", err
+		}
+		data, err := decodeValue(value, isBinary.Bool)
+		if err != nil {
+			return nil, "", err
+		}
+		ret = append(ret, state.QueryItem{
+			Key:  key,
+			Data: data,
+			ETag: ptr.Of(formatETag(updateTime)),
+		})
+	}
+
+	var token string
+	if q.limit != 0 {
+		var skip int64
+		if q.skip != nil {
+			skip = *q.skip
+		}
+		token = strconv.FormatInt(skip+int64(len(ret)), 10)
+	}
+
+	return ret, token, nil
+}
+
+func (q *Query) addParam(value any) string {
+	name := paramName(len(q.params) + 1)
+	q.params[name] = fmt.Sprintf("%v", value)
+	return name
+}
+
+func paramName(n int) string {
+	return "p" + strconv.Itoa(n)
+}
+
+// translateFieldToFilter returns the expression that reads a field of the values, such as "person.name".
+func translateFieldToFilter(key string) (string, error) {
+	fieldParts := strings.Split(key, ".")
+	path := "$"
+	for _, part := range fieldParts {
+		switch {
+		case unquotedFieldRegex.MatchString(part):
+			path += "." + part
+		case quotedFieldRegex.MatchString(part):
+			path += `."` + part + `"`
+		default:
+			return "", fmt.Errorf("invalid key %q: fields must not be empty or contain quotes or backslashes", key)
+		}
+	}
+	return "JSON_VALUE(value, '" + path + "')", nil
+}
+
+func (q *Query) whereFieldEqual(key string, value any) (string, error) {
+	field, err := translateFieldToFilter(key)
+	if err != nil {
+		return "", err
+	}
+	return field + " = @" + q.addParam(value), nil
+}
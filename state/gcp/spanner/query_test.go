@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spanner
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state/query"
+)
+
+const baseQuery = "SELECT key, value, is_binary, update_time FROM `dapr_state` WHERE " + notExpired
+
+func TestQueryBuildQuery(t *testing.T) {
+	tests := []struct {
+		input  string
+		query  string
+		params map[string]any
+	}{
+		{
+			input: "../../../tests/state/query/q1.json",
+			query: baseQuery + " LIMIT 2",
+		},
+		{
+			input:  "../../../tests/state/query/q2.json",
+			query:  baseQuery + " AND JSON_VALUE(value, '$.state') = @p1 LIMIT 2",
+			params: map[string]any{"p1": "CA"},
+		},
+		{
+			input:  "../../../tests/state/query/q2-token.json",
+			query:  baseQuery + " AND JSON_VALUE(value, '$.state') = @p1 LIMIT 2 OFFSET 2",
+			params: map[string]any{"p1": "CA"},
+		},
+		{
+			input:  "../../../tests/state/query/q3.json",
+			query:  baseQuery + " AND (JSON_VALUE(value, '$.person.org') = @p1 AND JSON_VALUE(value, '$.state') IN (@p2, @p3)) ORDER BY JSON_VALUE(value, '$.state') DESC, JSON_VALUE(value, '$.person.name')",
+			params: map[string]any{"p1": "A", "p2": "CA", "p3": "WA"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			data, err := os.ReadFile(test.input)
+			require.NoError(t, err)
+			var qq query.Query
+			err = json.Unmarshal(data, &qq)
+			require.NoError(t, err)
+
+			q := &Query{
+				table:  "`dapr_state`",
+				params: map[string]any{},
+			}
+			qbuilder := query.NewQueryBuilder(q)
+			err = qbuilder.BuildQuery(&qq)
+			require.NoError(t, err)
+			assert.Equal(t, test.query, q.query)
+			if test.params == nil {
+				test.params = map[string]any{}
+			}
+			assert.Equal(t, test.params, q.params)
+		})
+	}
+}
+
+func TestTranslateFieldToFilter(t *testing.T) {
+	t.Run("quotes fields that aren't identifiers", func(t *testing.T) {
+		res, err := translateFieldToFilter("person.first-name")
+		require.NoError(t, err)
+		assert.Equal(t, `JSON_VALUE(value, '$.person."first-name"')`, res)
+	})
+
+	t.Run("rejects quotes", func(t *testing.T) {
+		for _, key := range []string{"person.name'", `person."name"`, `person.na\me`, "person..name"} {
+			_, err := translateFieldToFilter(key)
+			assert.Error(t, err, key)
+		}
+	})
+}
+
+func TestQueryPageToken(t *testing.T) {
+	q := &Query{
+		table:  "`dapr_state`",
+		params: map[string]any{},
+	}
+	qq := &query.Query{}
+	qq.Page.Token = "2"
+	err := query.NewQueryBuilder(q).BuildQuery(qq)
+	require.Error(t, err)
+}
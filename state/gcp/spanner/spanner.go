@@ -0,0 +1,485 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spanner
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"time"
+
+	gcpspanner "cloud.google.com/go/spanner"
+	database "cloud.google.com/go/spanner/admin/database/apiv1"
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+	stateutils "github.com/dapr/components-contrib/state/utils"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+const (
+	defaultTableName = "dapr_state"
+
+	// Maximum staleness of the reads with eventual consistency.
+	eventualMaxStaleness = 10 * time.Second
+)
+
+var tableNameRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,127}$`)
+
+// Columns of the state table.
+var stateColumns = []string{"key", "value", "is_binary", "update_time", "expiration_time"}
+
+type spannerMetadata struct {
+	ProjectID  string `mapstructure:"projectID"`
+	InstanceID string `mapstructure:"instanceID"`
+	DatabaseID string `mapstructure:"databaseID"`
+	// Name of the table that stores the state.
+	TableName string `mapstructure:"tableName"`
+	// If true, the table is not created at Init, for example because the identity of the component doesn't have the permissions to update the schema.
+	DisableTableCreation bool `mapstructure:"disableTableCreation"`
+	// Endpoint of the gRPC API of Spanner, such as "localhost:9010" for the Spanner emulator.
+	// Setting an endpoint disables authentication and TLS.
+	ConnectionEndpoint string `mapstructure:"endpoint"`
+
+	// Service account credentials. If not set, the Application Default Credentials are used.
+	Type                string `mapstructure:"type"`
+	PrivateKeyID        string `mapstructure:"privateKeyID"`
+	PrivateKey          string `mapstructure:"privateKey"`
+	ClientEmail         string `mapstructure:"clientEmail"`
+	ClientID            string `mapstructure:"clientID"`
+	AuthURI             string `mapstructure:"authURI"`
+	TokenURI            string `mapstructure:"tokenURI"`
+	AuthProviderCertURL string `mapstructure:"authProviderX509CertUrl"`
+	ClientCertURL       string `mapstructure:"clientX509CertUrl"`
+}
+
+type gcpAuthJSON struct {
+	ProjectID           string `json:"project_id"`
+	PrivateKeyID        string `json:"private_key_id"`
+	PrivateKey          string `json:"private_key"`
+	ClientEmail         string `json:"client_email"`
+	ClientID            string `json:"client_id"`
+	AuthURI             string `json:"auth_uri"`
+	TokenURI            string `json:"token_uri"`
+	AuthProviderCertURL string `json:"auth_provider_x509_cert_url"`
+	ClientCertURL       string `json:"client_x509_cert_url"`
+	Type                string `json:"type"`
+}
+
+// Spanner is a state store backed by a table of a Google Cloud Spanner database.
+// ETags are the commit timestamps of the rows, and expired rows are deleted by a row deletion policy.
+type Spanner struct {
+	state.BulkStore
+
+	metadata spannerMetadata
+	client   *gcpspanner.Client
+	database string
+	table    string
+	logger   logger.Logger
+}
+
+// NewSpannerStateStore returns a new Spanner state store.
+func NewSpannerStateStore(logger logger.Logger) state.Store {
+	s := &Spanner{
+		logger: logger,
+	}
+	s.BulkStore = state.NewDefaultBulkStore(s)
+	return s
+}
+
+// Init connects to the database and creates the state table if it doesn't exist.
+func (s *Spanner) Init(ctx context.Context, meta state.Metadata) error {
+	m, err := parseMetadata(meta.Properties)
+	if err != nil {
+		return err
+	}
+	s.metadata = m
+	s.database = fmt.Sprintf("projects/%s/instances/%s/databases/%s", m.ProjectID, m.InstanceID, m.DatabaseID)
+	s.table = "`" + m.TableName + "`"
+
+	var opts []option.ClientOption
+	if m.ConnectionEndpoint != "" {
+		// The emulator doesn't require authentication
+		s.logger.Debugf("Using the Spanner endpoint %s", m.ConnectionEndpoint)
+		opts = append(opts,
+			option.WithEndpoint(m.ConnectionEndpoint),
+			option.WithoutAuthentication(),
+			option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		)
+	} else if m.PrivateKeyID != "" {
+		s.logger.Debugf("Using explicit credentials for GCP")
+		authJSON, err := json.Marshal(gcpAuthJSON{
+			ProjectID:           m.ProjectID,
+			PrivateKeyID:        m.PrivateKeyID,
+			PrivateKey:          m.PrivateKey,
+			ClientEmail:         m.ClientEmail,
+			ClientID:            m.ClientID,
+			AuthURI:             m.AuthURI,
+			TokenURI:            m.TokenURI,
+			AuthProviderCertURL: m.AuthProviderCertURL,
+			ClientCertURL:       m.ClientCertURL,
+			Type:                m.Type,
+		})
+		if err != nil {
+			return err
+		}
+		opts = append(opts, option.WithCredentialsJSON(authJSON))
+	} else {
+		s.logger.Debugf("Using implicit credentials for GCP")
+	}
+
+	if !m.DisableTableCreation {
+		err = s.createTable(ctx, opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.client, err = gcpspanner.NewClient(ctx, s.database, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create the Spanner client: %w", err)
+	}
+
+	return nil
+}
+
+func parseMetadata(props map[string]string) (spannerMetadata, error) {
+	m := spannerMetadata{
+		TableName: defaultTableName,
+	}
+	err := metadata.DecodeMetadata(props, &m)
+	if err != nil {
+		return m, err
+	}
+
+	if m.ProjectID == "" {
+		return m, errors.New("missing projectID")
+	}
+	if m.InstanceID == "" {
+		return m, errors.New("missing instanceID")
+	}
+	if m.DatabaseID == "" {
+		return m, errors.New("missing databaseID")
+	}
+	if !tableNameRegex.MatchString(m.TableName) {
+		return m, fmt.Errorf("invalid tableName '%s': must start with a letter and contain only letters, numbers and underscores", m.TableName)
+	}
+	return m, nil
+}
+
+// createTable creates the state table if it doesn't exist, and waits for the schema update to complete.
+// Expired rows are deleted by the row deletion policy of the table, usually within 3 days of their expiration.
+func (s *Spanner) createTable(ctx context.Context, opts []option.ClientOption) error {
+	admin, err := database.NewDatabaseAdminClient(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create the Spanner database admin client: %w", err)
+	}
+	defer admin.Close()
+
+	ddl := "CREATE TABLE IF NOT EXISTS " + s.table + ` (
+	key STRING(MAX) NOT NULL,
+	value JSON,
+	is_binary BOOL,
+	update_time TIMESTAMP NOT NULL OPTIONS (allow_commit_timestamp=true),
+	expiration_time TIMESTAMP,
+) PRIMARY KEY (key), ROW DELETION POLICY (OLDER_THAN(expiration_time, INTERVAL 0 DAY))`
+
+	op, err := admin.UpdateDatabaseDdl(ctx, &databasepb.UpdateDatabaseDdlRequest{
+		Database:   s.database,
+		Statements: []string{ddl},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create table %s: %w", s.metadata.TableName, err)
+	}
+	err = op.Wait(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create table %s: %w", s.metadata.TableName, err)
+	}
+	return nil
+}
+
+// Features returns the features available in this state store.
+func (s *Spanner) Features() []state.Feature {
+	return []state.Feature{
+		state.FeatureETag,
+		state.FeatureTransactional,
+		state.FeatureQueryAPI,
+		state.FeatureTTL,
+	}
+}
+
+// Get returns the value of a key.
+func (s *Spanner) Get(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+	if req.Key == "" {
+		return nil, errors.New("missing key in get operation")
+	}
+
+	tx := s.client.Single()
+	if req.Options.Consistency == state.Eventual {
+		tx = tx.WithTimestampBound(gcpspanner.MaxStaleness(eventualMaxStaleness))
+	}
+
+	iter := tx.Query(ctx, gcpspanner.Statement{
+		SQL:    "SELECT value, is_binary, update_time, expiration_time FROM " + s.table + " WHERE key = @key AND " + notExpired,
+		Params: map[string]any{"key": req.Key},
+	})
+	defer iter.Stop()
+	row, err := iter.Next()
+	if errors.Is(err, iterator.Done) {
+		return &state.GetResponse{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		value      gcpspanner.GenericColumnValue
+		isBinary   gcpspanner.NullBool
+		updateTime time.Time
+		expiration gcpspanner.NullTime
+	)
+	err = row.Columns(&value, &isBinary, &updateTime, &expiration)
+	if err != nil {
+		return nil, err
+	}
+	data, err := decodeValue(value, isBinary.Bool)
+	if err != nil {
+		return nil, err
+	}
+	res := &state.GetResponse{
+		Data: data,
+		ETag: ptr.Of(formatETag(updateTime)),
+	}
+	if expiration.Valid {
+		res.Metadata = map[string]string{
+			state.GetRespMetaKeyTTLExpireTime: expiration.Time.UTC().Format(time.RFC3339),
+		}
+	}
+	return res, nil
+}
+
+// Set saves the value of a key.
+func (s *Spanner) Set(ctx context.Context, req *state.SetRequest) error {
+	mutation, err := s.setMutation(req)
+	if err != nil {
+		return err
+	}
+	if !needsETagCheck(req.ETag, req.Options.Concurrency) {
+		_, err = s.client.Apply(ctx, []*gcpspanner.Mutation{mutation})
+		return err
+	}
+	return s.runTransaction(ctx, func(ctx context.Context, txn *gcpspanner.ReadWriteTransaction) ([]*gcpspanner.Mutation, error) {
+		err := s.checkETag(ctx, txn, req.Key, req.ETag)
+		if err != nil {
+			return nil, err
+		}
+		return []*gcpspanner.Mutation{mutation}, nil
+	})
+}
+
+// Delete removes a key.
+func (s *Spanner) Delete(ctx context.Context, req *state.DeleteRequest) error {
+	mutation, err := s.deleteMutation(req)
+	if err != nil {
+		return err
+	}
+	if !needsETagCheck(req.ETag, req.Options.Concurrency) {
+		_, err = s.client.Apply(ctx, []*gcpspanner.Mutation{mutation})
+		return err
+	}
+	return s.runTransaction(ctx, func(ctx context.Context, txn *gcpspanner.ReadWriteTransaction) ([]*gcpspanner.Mutation, error) {
+		err := s.checkETag(ctx, txn, req.Key, req.ETag)
+		if err != nil {
+			return nil, err
+		}
+		return []*gcpspanner.Mutation{mutation}, nil
+	})
+}
+
+// Multi applies the operations atomically, with a read-write transaction.
+func (s *Spanner) Multi(ctx context.Context, request *state.TransactionalStateRequest) error {
+	return s.runTransaction(ctx, func(ctx context.Context, txn *gcpspanner.ReadWriteTransaction) ([]*gcpspanner.Mutation, error) {
+		mutations := make([]*gcpspanner.Mutation, 0, len(request.Operations))
+		for _, o := range request.Operations {
+			var (
+				mutation    *gcpspanner.Mutation
+				etag        *string
+				concurrency string
+				err         error
+			)
+			switch req := o.(type) {
+			case state.SetRequest:
+				mutation, err = s.setMutation(&req)
+				etag, concurrency = req.ETag, req.Options.Concurrency
+			case state.DeleteRequest:
+				mutation, err = s.deleteMutation(&req)
+				etag, concurrency = req.ETag, req.Options.Concurrency
+			default:
+				err = fmt.Errorf("unsupported operation: %s", o.Operation())
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if needsETagCheck(etag, concurrency) {
+				err = s.checkETag(ctx, txn, o.GetKey(), etag)
+				if err != nil {
+					return nil, err
+				}
+			}
+			mutations = append(mutations, mutation)
+		}
+		return mutations, nil
+	})
+}
+
+func (s *Spanner) setMutation(req *state.SetRequest) (*gcpspanner.Mutation, error) {
+	err := state.CheckRequestOptions(req.Options)
+	if err != nil {
+		return nil, err
+	}
+	if req.Key == "" {
+		return nil, errors.New("missing key in set operation")
+	}
+
+	// Binary values are stored as base64-encoded JSON strings
+	v := req.Value
+	byteArray, isBinary := req.Value.([]uint8)
+	if isBinary {
+		v = base64.StdEncoding.EncodeToString(byteArray)
+	}
+	value, err := stateutils.Marshal(v, json.Marshal)
+	if err != nil {
+		return nil, err
+	}
+
+	var expiration gcpspanner.NullTime
+	ttl, err := stateutils.ParseTTL(req.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing TTL: %w", err)
+	}
+	if ttl != nil && *ttl > 0 {
+		expiration = gcpspanner.NullTime{Time: time.Now().Add(time.Duration(*ttl) * time.Second), Valid: true}
+	}
+
+	return gcpspanner.InsertOrUpdate(s.metadata.TableName, stateColumns, []any{
+		req.Key,
+		gcpspanner.NullJSON{Value: json.RawMessage(value), Valid: true},
+		isBinary,
+		gcpspanner.CommitTimestamp,
+		expiration,
+	}), nil
+}
+
+func (s *Spanner) deleteMutation(req *state.DeleteRequest) (*gcpspanner.Mutation, error) {
+	err := state.CheckRequestOptions(req.Options)
+	if err != nil {
+		return nil, err
+	}
+	if req.Key == "" {
+		return nil, errors.New("missing key in delete operation")
+	}
+
+	return gcpspanner.Delete(s.metadata.TableName, gcpspanner.Key{req.Key}), nil
+}
+
+// Query executes a query against store.
+func (s *Spanner) Query(ctx context.Context, req *state.QueryRequest) (*state.QueryResponse, error) {
+	q := &Query{
+		table:  s.table,
+		params: map[string]any{},
+	}
+	qbuilder := query.NewQueryBuilder(q)
+	if err := qbuilder.BuildQuery(&req.Query); err != nil {
+		return &state.QueryResponse{}, err
+	}
+	if metadata.IsQueryExplain(req.Metadata) {
+		return &state.QueryResponse{
+			Results:  []state.QueryItem{},
+			Metadata: q.Explain().Metadata(),
+		}, nil
+	}
+
+	iter := s.client.Single().Query(ctx, gcpspanner.Statement{
+		SQL:    q.query,
+		Params: q.params,
+	})
+	data, token, err := q.results(iter)
+	if err != nil {
+		return &state.QueryResponse{}, err
+	}
+
+	return &state.QueryResponse{
+		Results: data,
+		Token:   token,
+	}, nil
+}
+
+// Close closes the client, deleting its sessions.
+func (s *Spanner) Close() error {
+	if s.client != nil {
+		s.client.Close()
+	}
+	return nil
+}
+
+// GetComponentMetadata returns the metadata of the component.
+func (s *Spanner) GetComponentMetadata() map[string]string {
+	metadataStruct := spannerMetadata{}
+	metadataInfo := map[string]string{}
+	metadata.GetMetadataInfoFromStructType(reflect.TypeOf(metadataStruct), &metadataInfo, metadata.StateStoreType)
+	return metadataInfo
+}
+
+// Condition that excludes the expired rows that haven't been deleted by the row deletion policy yet.
+const notExpired = "(expiration_time IS NULL OR expiration_time > CURRENT_TIMESTAMP())"
+
+// decodeValue returns the data of a value read from the state table.
+// The JSON column is read as a generic value, so the data is returned as stored instead of being decoded and encoded again.
+func decodeValue(value gcpspanner.GenericColumnValue, isBinary bool) ([]byte, error) {
+	var data []byte
+	if value.Value != nil {
+		data = []byte(value.Value.GetStringValue())
+	}
+	if !isBinary {
+		return data, nil
+	}
+
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON data: %w", err)
+	}
+	res, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64 data: %w", err)
+	}
+	return res, nil
+}
+
+// formatETag returns the ETag of a row, which is its commit timestamp.
+func formatETag(updateTime time.Time) string {
+	return updateTime.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,496 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spanner
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/longrunning/autogen/longrunningpb"
+	gcpspanner "cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m, err := parseMetadata(map[string]string{
+			"projectID":  "myproject",
+			"instanceID": "myinstance",
+			"databaseID": "mydb",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "myproject", m.ProjectID)
+		assert.Equal(t, "myinstance", m.InstanceID)
+		assert.Equal(t, "mydb", m.DatabaseID)
+		assert.Equal(t, defaultTableName, m.TableName)
+		assert.False(t, m.DisableTableCreation)
+	})
+
+	t.Run("all fields", func(t *testing.T) {
+		m, err := parseMetadata(map[string]string{
+			"projectID":            "myproject",
+			"instanceID":           "myinstance",
+			"databaseID":           "mydb",
+			"tableName":            "my_state",
+			"disableTableCreation": "true",
+			"endpoint":             "localhost:9010",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "my_state", m.TableName)
+		assert.True(t, m.DisableTableCreation)
+		assert.Equal(t, "localhost:9010", m.ConnectionEndpoint)
+	})
+
+	t.Run("missing or invalid fields", func(t *testing.T) {
+		tests := map[string]map[string]string{
+			"missing projectID":  {"instanceID": "myinstance", "databaseID": "mydb"},
+			"missing instanceID": {"projectID": "myproject", "databaseID": "mydb"},
+			"missing databaseID": {"projectID": "myproject", "instanceID": "myinstance"},
+			"invalid tableName":  {"projectID": "myproject", "instanceID": "myinstance", "databaseID": "mydb", "tableName": "state`; DROP TABLE x"},
+		}
+		for name, props := range tests {
+			_, err := parseMetadata(props)
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestDecodeValue(t *testing.T) {
+	jsonValue := func(v string) gcpspanner.GenericColumnValue {
+		return gcpspanner.GenericColumnValue{
+			Type:  &sppb.Type{Code: sppb.TypeCode_JSON},
+			Value: structpb.NewStringValue(v),
+		}
+	}
+
+	data, err := decodeValue(jsonValue(`{"a":1}`), false)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"a":1}`), data)
+
+	data, err = decodeValue(jsonValue(`"aGVsbG8="`), true)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), data)
+
+	data, err = decodeValue(gcpspanner.GenericColumnValue{Value: structpb.NewNullValue()}, false)
+	require.NoError(t, err)
+	assert.Empty(t, data)
+
+	_, err = decodeValue(jsonValue(`"not base64!"`), true)
+	assert.Error(t, err)
+}
+
+func TestSpanner(t *testing.T) {
+	fake := newFakeSpanner()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	sppb.RegisterSpannerServer(server, fake)
+	databasepb.RegisterDatabaseAdminServer(server, &fakeDatabaseAdmin{spanner: fake})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	s := NewSpannerStateStore(logger.NewLogger("test")).(*Spanner)
+	err = s.Init(context.Background(), state.Metadata{Base: metadata.Base{Properties: map[string]string{
+		"projectID":  "myproject",
+		"instanceID": "myinstance",
+		"databaseID": "mydb",
+		"endpoint":   lis.Addr().String(),
+	}}})
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.Len(t, fake.ddl(), 1)
+	assert.True(t, strings.HasPrefix(fake.ddl()[0], "CREATE TABLE IF NOT EXISTS `dapr_state`"))
+
+	ctx := context.Background()
+
+	t.Run("set and get", func(t *testing.T) {
+		err := s.Set(ctx, &state.SetRequest{Key: "k1", Value: map[string]string{"name": "dapr"}})
+		require.NoError(t, err)
+
+		res, err := s.Get(ctx, &state.GetRequest{Key: "k1"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"dapr"}`, string(res.Data))
+		require.NotNil(t, res.ETag)
+		assert.NotEmpty(t, *res.ETag)
+		assert.Empty(t, res.Metadata)
+	})
+
+	t.Run("get missing key", func(t *testing.T) {
+		res, err := s.Get(ctx, &state.GetRequest{Key: "missing"})
+		require.NoError(t, err)
+		assert.Nil(t, res.Data)
+		assert.Nil(t, res.ETag)
+	})
+
+	t.Run("binary values", func(t *testing.T) {
+		err := s.Set(ctx, &state.SetRequest{Key: "bin", Value: []byte{0x00, 0xff}})
+		require.NoError(t, err)
+
+		res, err := s.Get(ctx, &state.GetRequest{Key: "bin"})
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0x00, 0xff}, res.Data)
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		err := s.Set(ctx, &state.SetRequest{Key: "ttl", Value: "v", Metadata: map[string]string{"ttlInSeconds": "3600"}})
+		require.NoError(t, err)
+
+		res, err := s.Get(ctx, &state.GetRequest{Key: "ttl"})
+		require.NoError(t, err)
+		expiration, err := time.Parse(time.RFC3339, res.Metadata[state.GetRespMetaKeyTTLExpireTime])
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), expiration, time.Minute)
+	})
+
+	t.Run("etags", func(t *testing.T) {
+		err := s.Set(ctx, &state.SetRequest{Key: "etag", Value: "v1"})
+		require.NoError(t, err)
+		res, err := s.Get(ctx, &state.GetRequest{Key: "etag"})
+		require.NoError(t, err)
+		etag := res.ETag
+
+		// Wrong ETag
+		err = s.Set(ctx, &state.SetRequest{Key: "etag", Value: "v2", ETag: ptr.Of("bad")})
+		var etagErr *state.ETagError
+		require.ErrorAs(t, err, &etagErr)
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+
+		// Right ETag
+		err = s.Set(ctx, &state.SetRequest{Key: "etag", Value: "v2", ETag: etag})
+		require.NoError(t, err)
+		res, err = s.Get(ctx, &state.GetRequest{Key: "etag"})
+		require.NoError(t, err)
+		assert.Equal(t, `"v2"`, string(res.Data))
+		assert.NotEqual(t, *etag, *res.ETag)
+
+		// The old ETag doesn't match anymore
+		err = s.Delete(ctx, &state.DeleteRequest{Key: "etag", ETag: etag})
+		require.ErrorAs(t, err, &etagErr)
+		err = s.Delete(ctx, &state.DeleteRequest{Key: "etag", ETag: res.ETag})
+		require.NoError(t, err)
+	})
+
+	t.Run("first-write", func(t *testing.T) {
+		firstWrite := state.SetStateOption{Concurrency: state.FirstWrite}
+		err := s.Set(ctx, &state.SetRequest{Key: "fw", Value: "v1", Options: firstWrite})
+		require.NoError(t, err)
+		err = s.Set(ctx, &state.SetRequest{Key: "fw", Value: "v2", Options: firstWrite})
+		var etagErr *state.ETagError
+		require.ErrorAs(t, err, &etagErr)
+	})
+
+	t.Run("multi", func(t *testing.T) {
+		err := s.Set(ctx, &state.SetRequest{Key: "m1", Value: "v1"})
+		require.NoError(t, err)
+
+		err = s.Multi(ctx, &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "m2", Value: "v2"},
+				state.DeleteRequest{Key: "m1"},
+			},
+		})
+		require.NoError(t, err)
+
+		res, err := s.Get(ctx, &state.GetRequest{Key: "m1"})
+		require.NoError(t, err)
+		assert.Nil(t, res.Data)
+		res, err = s.Get(ctx, &state.GetRequest{Key: "m2"})
+		require.NoError(t, err)
+		assert.Equal(t, `"v2"`, string(res.Data))
+
+		// A failed ETag check rolls back the whole transaction
+		rollbacks := fake.rollbackCount()
+		err = s.Multi(ctx, &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "m3", Value: "v3"},
+				state.DeleteRequest{Key: "m2", ETag: ptr.Of("bad")},
+			},
+		})
+		var etagErr *state.ETagError
+		require.ErrorAs(t, err, &etagErr)
+		assert.Equal(t, rollbacks+1, fake.rollbackCount())
+		res, err = s.Get(ctx, &state.GetRequest{Key: "m3"})
+		require.NoError(t, err)
+		assert.Nil(t, res.Data)
+	})
+
+	t.Run("retries aborted transactions", func(t *testing.T) {
+		fake.abortCommits(2)
+		err := s.Set(ctx, &state.SetRequest{Key: "aborted", Value: "v", Options: state.SetStateOption{Concurrency: state.FirstWrite}})
+		require.NoError(t, err)
+	})
+
+	t.Run("query", func(t *testing.T) {
+		qq := state.QueryRequest{}
+		qq.Query.Page.Limit = 2
+		res, err := s.Query(ctx, &qq)
+		require.NoError(t, err)
+		assert.Len(t, res.Results, 2)
+		assert.Equal(t, "2", res.Token)
+		assert.True(t, strings.HasSuffix(fake.lastQuery(), " LIMIT 2"))
+	})
+}
+
+type fakeRow struct {
+	value      *structpb.Value
+	isBinary   *structpb.Value
+	updateTime time.Time
+	expiration *structpb.Value
+}
+
+// fakeSpanner implements the subset of the gRPC API of Spanner used by the client of the state store.
+// Queries are recognized by their prefix; queries of the Query API ignore filters, and return the first 2 keys.
+type fakeSpanner struct {
+	sppb.UnimplementedSpannerServer
+
+	lock       sync.Mutex
+	rows       map[string]fakeRow
+	sessions   int
+	txs        int
+	clock      time.Time
+	statements []string
+	rollbacks  int
+	aborts     int
+	lastSQL    string
+}
+
+func newFakeSpanner() *fakeSpanner {
+	return &fakeSpanner{
+		rows:  map[string]fakeRow{},
+		clock: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (f *fakeSpanner) abortCommits(n int) {
+	f.lock.Lock()
+	f.aborts = n
+	f.lock.Unlock()
+}
+
+func (f *fakeSpanner) ddl() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.statements
+}
+
+func (f *fakeSpanner) rollbackCount() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.rollbacks
+}
+
+func (f *fakeSpanner) lastQuery() string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.lastSQL
+}
+
+func (f *fakeSpanner) newSession(database string) *sppb.Session {
+	f.sessions++
+	return &sppb.Session{Name: database + "/sessions/" + strconv.Itoa(f.sessions)}
+}
+
+func (f *fakeSpanner) newTransaction() *sppb.Transaction {
+	f.txs++
+	return &sppb.Transaction{Id: []byte(strconv.Itoa(f.txs))}
+}
+
+func (f *fakeSpanner) CreateSession(ctx context.Context, req *sppb.CreateSessionRequest) (*sppb.Session, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.newSession(req.GetDatabase()), nil
+}
+
+func (f *fakeSpanner) BatchCreateSessions(ctx context.Context, req *sppb.BatchCreateSessionsRequest) (*sppb.BatchCreateSessionsResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	res := &sppb.BatchCreateSessionsResponse{}
+	for i := int32(0); i < req.GetSessionCount(); i++ {
+		res.Session = append(res.Session, f.newSession(req.GetDatabase()))
+	}
+	return res, nil
+}
+
+func (f *fakeSpanner) GetSession(ctx context.Context, req *sppb.GetSessionRequest) (*sppb.Session, error) {
+	return &sppb.Session{Name: req.GetName()}, nil
+}
+
+func (f *fakeSpanner) DeleteSession(ctx context.Context, req *sppb.DeleteSessionRequest) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeSpanner) BeginTransaction(ctx context.Context, req *sppb.BeginTransactionRequest) (*sppb.Transaction, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.newTransaction(), nil
+}
+
+func (f *fakeSpanner) Rollback(ctx context.Context, req *sppb.RollbackRequest) (*emptypb.Empty, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.rollbacks++
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeSpanner) ExecuteStreamingSql(req *sppb.ExecuteSqlRequest, stream sppb.Spanner_ExecuteStreamingSqlServer) error {
+	return stream.Send(f.executeSQL(req))
+}
+
+func (f *fakeSpanner) ExecuteSql(ctx context.Context, req *sppb.ExecuteSqlRequest) (*sppb.ResultSet, error) {
+	res := f.executeSQL(req)
+	rs := &sppb.ResultSet{Metadata: res.GetMetadata()}
+	n := len(res.GetMetadata().GetRowType().GetFields())
+	for i := 0; n > 0 && i < len(res.GetValues()); i += n {
+		rs.Rows = append(rs.Rows, &structpb.ListValue{Values: res.GetValues()[i : i+n]})
+	}
+	return rs, nil
+}
+
+func (f *fakeSpanner) executeSQL(req *sppb.ExecuteSqlRequest) *sppb.PartialResultSet {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.lastSQL = req.GetSql()
+	key := req.GetParams().GetFields()["key"].GetStringValue()
+
+	var (
+		columns []*sppb.StructType_Field
+		values  []*structpb.Value
+	)
+	column := func(name string, code sppb.TypeCode) {
+		columns = append(columns, &sppb.StructType_Field{Name: name, Type: &sppb.Type{Code: code}})
+	}
+	timestamp := func(t time.Time) *structpb.Value {
+		return structpb.NewStringValue(t.Format(time.RFC3339Nano))
+	}
+	switch {
+	case strings.HasPrefix(req.GetSql(), "SELECT value, is_binary, update_time, expiration_time FROM"):
+		column("value", sppb.TypeCode_JSON)
+		column("is_binary", sppb.TypeCode_BOOL)
+		column("update_time", sppb.TypeCode_TIMESTAMP)
+		column("expiration_time", sppb.TypeCode_TIMESTAMP)
+		if row, ok := f.rows[key]; ok {
+			values = append(values, row.value, row.isBinary, timestamp(row.updateTime), row.expiration)
+		}
+	case strings.HasPrefix(req.GetSql(), "SELECT update_time FROM"):
+		column("update_time", sppb.TypeCode_TIMESTAMP)
+		if row, ok := f.rows[key]; ok {
+			values = append(values, timestamp(row.updateTime))
+		}
+	case strings.HasPrefix(req.GetSql(), "SELECT key, value, is_binary, update_time FROM"):
+		column("key", sppb.TypeCode_STRING)
+		column("value", sppb.TypeCode_JSON)
+		column("is_binary", sppb.TypeCode_BOOL)
+		column("update_time", sppb.TypeCode_TIMESTAMP)
+		keys := make([]string, 0, len(f.rows))
+		for k := range f.rows {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		if len(keys) > 2 {
+			keys = keys[:2]
+		}
+		for _, k := range keys {
+			row := f.rows[k]
+			values = append(values, structpb.NewStringValue(k), row.value, row.isBinary, timestamp(row.updateTime))
+		}
+	default:
+		// Health checks of the sessions
+		column("", sppb.TypeCode_INT64)
+		values = append(values, structpb.NewStringValue("1"))
+	}
+
+	res := &sppb.PartialResultSet{
+		Metadata: &sppb.ResultSetMetadata{RowType: &sppb.StructType{Fields: columns}},
+		Values:   values,
+	}
+	if req.GetTransaction().GetBegin() != nil {
+		res.Metadata.Transaction = f.newTransaction()
+	}
+	return res
+}
+
+func (f *fakeSpanner) Commit(ctx context.Context, req *sppb.CommitRequest) (*sppb.CommitResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.aborts > 0 {
+		f.aborts--
+		return nil, status.Error(codes.Aborted, "Transaction was aborted")
+	}
+
+	f.clock = f.clock.Add(time.Second)
+	for _, m := range req.GetMutations() {
+		switch {
+		case m.GetInsertOrUpdate() != nil:
+			for _, v := range m.GetInsertOrUpdate().GetValues() {
+				f.rows[v.GetValues()[0].GetStringValue()] = fakeRow{
+					value:      v.GetValues()[1],
+					isBinary:   v.GetValues()[2],
+					updateTime: f.clock,
+					expiration: v.GetValues()[4],
+				}
+			}
+		case m.GetDelete() != nil:
+			for _, k := range m.GetDelete().GetKeySet().GetKeys() {
+				delete(f.rows, k.GetValues()[0].GetStringValue())
+			}
+		}
+	}
+	return &sppb.CommitResponse{CommitTimestamp: timestamppb.New(f.clock)}, nil
+}
+
+// fakeDatabaseAdmin implements the schema updates of the gRPC API of Spanner, which complete immediately.
+type fakeDatabaseAdmin struct {
+	databasepb.UnimplementedDatabaseAdminServer
+
+	spanner *fakeSpanner
+}
+
+func (f *fakeDatabaseAdmin) UpdateDatabaseDdl(ctx context.Context, req *databasepb.UpdateDatabaseDdlRequest) (*longrunningpb.Operation, error) {
+	f.spanner.lock.Lock()
+	f.spanner.statements = append(f.spanner.statements, req.GetStatements()...)
+	f.spanner.lock.Unlock()
+
+	res, err := anypb.New(&emptypb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	return &longrunningpb.Operation{
+		Name:   req.GetDatabase() + "/operations/1",
+		Done:   true,
+		Result: &longrunningpb.Operation_Response{Response: res},
+	}, nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spanner
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	gcpspanner "cloud.google.com/go/spanner"
+	"google.golang.org/api/iterator"
+
+	"github.com/dapr/components-contrib/state"
+)
+
+// runTransaction runs fn in a read-write transaction, then commits the mutations it returns.
+// If the transaction is aborted by Spanner because of a conflict with another transaction, the client retries it.
+func (s *Spanner) runTransaction(ctx context.Context, fn func(ctx context.Context, txn *gcpspanner.ReadWriteTransaction) ([]*gcpspanner.Mutation, error)) error {
+	_, err := s.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *gcpspanner.ReadWriteTransaction) error {
+		mutations, err := fn(ctx, txn)
+		if err != nil {
+			return err
+		}
+		return txn.BufferWrite(mutations)
+	})
+	return err
+}
+
+// checkETag returns an ETag error if the ETag of the row of key doesn't match etag, or, for first-write requests without an ETag, if the row exists.
+func (s *Spanner) checkETag(ctx context.Context, txn *gcpspanner.ReadWriteTransaction, key string, etag *string) error {
+	iter := txn.Query(ctx, gcpspanner.Statement{
+		SQL:    "SELECT update_time FROM " + s.table + " WHERE key = @key AND " + notExpired,
+		Params: map[string]any{"key": key},
+	})
+	defer iter.Stop()
+
+	var current string
+	row, err := iter.Next()
+	switch {
+	case errors.Is(err, iterator.Done):
+		// The row doesn't exist
+	case err != nil:
+		return err
+	default:
+		var updateTime time.Time
+		err = row.Columns(&updateTime)
+		if err != nil {
+			return err
+		}
+		current = formatETag(updateTime)
+	}
+
+	if etag == nil || *etag == "" {
+		// First-write without ETag: the key must not exist
+		if current != "" {
+			return state.NewETagError(state.ETagMismatch, errors.New("the key already exists"))
+		}
+		return nil
+	}
+	if current != *etag {
+		return state.NewETagError(state.ETagMismatch, nil)
+	}
+	return nil
+}
+
+// needsETagCheck returns true if a request must be checked against the current ETag of the row.
+func needsETagCheck(etag *string, concurrency string) bool {
+	return (etag != nil && *etag != "") || concurrency == state.FirstWrite
+}
@@ -18,29 +18,60 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hazelcast/hazelcast-go-client"
-	"github.com/hazelcast/hazelcast-go-client/core"
+	"github.com/hazelcast/hazelcast-go-client/nearcache"
+	"github.com/hazelcast/hazelcast-go-client/types"
 	jsoniter "github.com/json-iterator/go"
 
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
+	stateutils "github.com/dapr/components-contrib/state/utils"
 	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
 )
 
+// Lease of the lock of a key held while an ETag is checked, so the lock is released if the process crashes.
+const keyLockLease = 30 * time.Second
+
+// hazelcastMap contains the methods of the Hazelcast map used by the state store.
+type hazelcastMap interface {
+	NewLockContext(ctx context.Context) context.Context
+	LockWithLease(ctx context.Context, key interface{}, leaseTime time.Duration) error
+	Unlock(ctx context.Context, key interface{}) error
+	Get(ctx context.Context, key interface{}) (interface{}, error)
+	GetEntryView(ctx context.Context, key interface{}) (*types.SimpleEntryView, error)
+	Set(ctx context.Context, key interface{}, value interface{}) error
+	SetWithTTL(ctx context.Context, key interface{}, value interface{}, ttl time.Duration) error
+	PutIfAbsent(ctx context.Context, key interface{}, value interface{}) (interface{}, error)
+	PutIfAbsentWithTTL(ctx context.Context, key interface{}, value interface{}, ttl time.Duration) (interface{}, error)
+	Delete(ctx context.Context, key interface{}) error
+}
+
 // Hazelcast state store.
 type Hazelcast struct {
 	state.BulkStore
 
-	hzMap  core.Map
-	json   jsoniter.API
-	logger logger.Logger
+	client    *hazelcast.Client
+	hzMap     hazelcastMap
+	nearCache bool
+	json      jsoniter.API
+	logger    logger.Logger
 }
 
 type hazelcastMetadata struct {
-	HazelcastServers string
-	HazelcastMap     string
+	HazelcastServers     string
+	HazelcastMap         string
+	HazelcastClusterName string
+	// Near cache of the map in the client, which serves the reads with eventual consistency.
+	NearCache                   bool
+	NearCacheTTL                time.Duration
+	NearCacheMaxIdle            time.Duration
+	NearCacheMaxSize            int
+	NearCacheInvalidateOnChange *bool
 }
 
 // NewHazelcastStore returns a new hazelcast backed state store.
@@ -65,37 +96,62 @@ func validateAndParseMetadata(meta state.Metadata) (*hazelcastMetadata, error) {
 	if m.HazelcastMap == "" {
 		return nil, errors.New("missing hazelcast map name")
 	}
+	if m.NearCacheTTL < 0 || m.NearCacheMaxIdle < 0 || m.NearCacheMaxSize < 0 {
+		return nil, errors.New("near cache TTL, max idle time and max size must not be negative")
+	}
 
 	return m, nil
 }
 
+// clientConfig returns the configuration of the client.
+func (m *hazelcastMetadata) clientConfig() hazelcast.Config {
+	hzConfig := hazelcast.NewConfig()
+	hzConfig.Cluster.Network.SetAddresses(strings.Split(m.HazelcastServers, ",")...)
+	if m.HazelcastClusterName != "" {
+		hzConfig.Cluster.Name = m.HazelcastClusterName
+	}
+
+	if m.NearCache {
+		ncConfig := nearcache.Config{
+			Name:              m.HazelcastMap,
+			TimeToLiveSeconds: int(m.NearCacheTTL.Seconds()),
+			MaxIdleSeconds:    int(m.NearCacheMaxIdle.Seconds()),
+		}
+		if m.NearCacheMaxSize > 0 {
+			ncConfig.Eviction.SetSize(m.NearCacheMaxSize)
+		}
+		if m.NearCacheInvalidateOnChange != nil {
+			ncConfig.SetInvalidateOnChange(*m.NearCacheInvalidateOnChange)
+		}
+		hzConfig.AddNearCache(ncConfig)
+	}
+
+	return hzConfig
+}
+
 // Init does metadata and connection parsing.
-func (store *Hazelcast) Init(_ context.Context, metadata state.Metadata) error {
+func (store *Hazelcast) Init(ctx context.Context, metadata state.Metadata) error {
 	meta, err := validateAndParseMetadata(metadata)
 	if err != nil {
 		return err
 	}
-	servers := meta.HazelcastServers
-
-	hzConfig := hazelcast.NewConfig()
-	hzConfig.NetworkConfig().AddAddress(strings.Split(servers, ",")...)
 
-	client, err := hazelcast.NewClientWithConfig(hzConfig)
+	store.client, err = hazelcast.StartNewClientWithConfig(ctx, meta.clientConfig())
 	if err != nil {
 		return err
 	}
-	store.hzMap, err = client.GetMap(meta.HazelcastMap)
-
+	store.hzMap, err = store.client.GetMap(ctx, meta.HazelcastMap)
 	if err != nil {
-		return err
+		return errors.Join(err, store.client.Shutdown(ctx))
 	}
+	store.nearCache = meta.NearCache
 
 	return nil
 }
 
 // Features returns the features available in this state store.
 func (store *Hazelcast) Features() []state.Feature {
-	return nil
+	return []state.Feature{state.FeatureETag, state.FeatureTTL}
 }
 
 // Set stores value for a key to Hazelcast.
@@ -105,6 +161,11 @@ func (store *Hazelcast) Set(ctx context.Context, req *state.SetRequest) error {
 		return err
 	}
 
+	ttl, err := stateutils.ParseTTL(req.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to parse TTL: %w", err)
+	}
+
 	var value string
 	b, ok := req.Value.([]byte)
 	if ok {
@@ -115,26 +176,143 @@ func (store *Hazelcast) Set(ctx context.Context, req *state.SetRequest) error {
 			return fmt.Errorf("failed to set key %s: %w", req.Key, err)
 		}
 	}
-	_, err = store.hzMap.Put(req.Key, value)
 
+	switch {
+	case req.HasETag():
+		err = store.replaceIfVersion(ctx, req.Key, *req.ETag, value, ttl)
+	case req.Options.Concurrency == state.FirstWrite:
+		var old interface{}
+		if ttl != nil {
+			old, err = store.hzMap.PutIfAbsentWithTTL(ctx, req.Key, value, ttlDuration(*ttl))
+		} else {
+			old, err = store.hzMap.PutIfAbsent(ctx, req.Key, value)
+		}
+		if err == nil && old != nil {
+			return state.NewETagError(state.ETagMismatch, errors.New("item already exists and no etag was passed"))
+		}
+	default:
+		err = store.set(ctx, req.Key, value, ttl)
+	}
 	if err != nil {
+		var etagErr *state.ETagError
+		if errors.As(err, &etagErr) {
+			return err
+		}
 		return fmt.Errorf("failed to set key %s: %w", req.Key, err)
 	}
 
 	return nil
 }
 
+// set stores the value of the key, with the TTL if it's not nil.
+func (store *Hazelcast) set(ctx context.Context, key string, value string, ttl *int) error {
+	if ttl != nil {
+		return store.hzMap.SetWithTTL(ctx, key, value, ttlDuration(*ttl))
+	}
+	return store.hzMap.Set(ctx, key, value)
+}
+
+// ttlDuration returns the TTL of an entry from the TTL in seconds of a request.
+// A TTL of 0 means that the entry never expires.
+func ttlDuration(ttl int) time.Duration {
+	if ttl < 0 {
+		return 0
+	}
+	return time.Duration(ttl) * time.Second
+}
+
+// getEntryView returns the entry view of the key, or nil if it doesn't exist.
+func (store *Hazelcast) getEntryView(ctx context.Context, key string) (*types.SimpleEntryView, error) {
+	entryView, err := store.hzMap.GetEntryView(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if entryView == nil || entryView.Value == nil {
+		return nil, nil
+	}
+	return entryView, nil
+}
+
+// checkETag locks the key in the map and returns an error if the ETag doesn't match its entry.
+// It returns the lock context, with which the caller writes the key, and the function that unlocks it.
+// Other clients can't write the key until it's unlocked, so the check and the write are atomic.
+func (store *Hazelcast) checkETag(ctx context.Context, key string, etag string) (context.Context, func(), error) {
+	lockCtx := store.hzMap.NewLockContext(ctx)
+	err := store.hzMap.LockWithLease(lockCtx, key, keyLockLease)
+	if err != nil {
+		return nil, nil, err
+	}
+	unlock := func() {
+		if uerr := store.hzMap.Unlock(lockCtx, key); uerr != nil {
+			store.logger.Warnf("Failed to unlock key %s: %v", key, uerr)
+		}
+	}
+
+	entryView, err := store.getEntryView(lockCtx, key)
+	if err != nil {
+		unlock()
+		return nil, nil, err
+	}
+	if entryView == nil {
+		unlock()
+		return nil, nil, state.NewETagError(state.ETagMismatch, fmt.Errorf("state not exist or expired for key=%s", key))
+	}
+	if current := entryETag(entryView); current != etag {
+		unlock()
+		return nil, nil, state.NewETagError(state.ETagMismatch, fmt.Errorf("state etag not match for key=%s: current=%s, expect=%s", key, current, etag))
+	}
+	return lockCtx, unlock, nil
+}
+
+// entryETag returns the ETag of an entry.
+// The version restarts from 0 when a key is deleted and created again, so it's combined with the creation time of the entry.
+func entryETag(entryView *types.SimpleEntryView) string {
+	return strconv.FormatInt(entryView.CreationTime, 10) + "-" + strconv.FormatInt(entryView.Version, 10)
+}
+
+// replaceIfVersion replaces the value of the key if its entry matches the ETag.
+func (store *Hazelcast) replaceIfVersion(ctx context.Context, key string, etag string, value string, ttl *int) error {
+	lockCtx, unlock, err := store.checkETag(ctx, key, etag)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return store.set(lockCtx, key, value, ttl)
+}
+
 // Get retrieves state from Hazelcast with a key.
+// With the near cache enabled, reads with eventual consistency are served from it and don't return an ETag.
 func (store *Hazelcast) Get(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
-	resp, err := store.hzMap.Get(req.Key)
+	consistency, err := state.RequestConsistency(req.Options.Consistency, req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	if store.nearCache && consistency != state.Strong {
+		resp, err := store.hzMap.Get(ctx, req.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get value for %s: %w", req.Key, err)
+		}
+		if resp == nil {
+			return &state.GetResponse{}, nil
+		}
+		value, err := store.json.Marshal(&resp)
+		if err != nil {
+			return nil, err
+		}
+		return &state.GetResponse{Data: value}, nil
+	}
+
+	entryView, err := store.getEntryView(ctx, req.Key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get value for %s: %w", req.Key, err)
 	}
 
-	// HZ Get API returns nil response if key does not exist in the map
-	if resp == nil {
+	// The key does not exist in the map
+	if entryView == nil {
 		return &state.GetResponse{}, nil
 	}
+	resp := entryView.Value
 	value, err := store.json.Marshal(&resp)
 	if err != nil {
 		return nil, err
@@ -142,6 +320,7 @@ func (store *Hazelcast) Get(ctx context.Context, req *state.GetRequest) (*state.
 
 	return &state.GetResponse{
 		Data: value,
+		ETag: ptr.Of(entryETag(entryView)),
 	}, nil
 }
 
@@ -151,14 +330,45 @@ func (store *Hazelcast) Delete(ctx context.Context, req *state.DeleteRequest) er
 	if err != nil {
 		return err
 	}
-	err = store.hzMap.Delete(req.Key)
+	if req.HasETag() {
+		return store.removeIfVersion(ctx, req.Key, *req.ETag)
+	}
+
+	err = store.hzMap.Delete(ctx, req.Key)
+	if err != nil {
+		return fmt.Errorf("failed to delete key: %w", err)
+	}
+
+	return nil
+}
+
+// removeIfVersion removes the key if its entry matches the ETag.
+func (store *Hazelcast) removeIfVersion(ctx context.Context, key string, etag string) error {
+	lockCtx, unlock, err := store.checkETag(ctx, key, etag)
 	if err != nil {
+		var etagErr *state.ETagError
+		if errors.As(err, &etagErr) {
+			return err
+		}
 		return fmt.Errorf("failed to delete key: %w", err)
 	}
+	defer unlock()
 
+	err = store.hzMap.Delete(lockCtx, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete key: %w", err)
+	}
 	return nil
 }
 
+// Close shuts down the client.
+func (store *Hazelcast) Close() error {
+	if store.client == nil {
+		return nil
+	}
+	return store.client.Shutdown(context.Background())
+}
+
 func (store *Hazelcast) GetComponentMetadata() map[string]string {
 	metadataStruct := hazelcastMetadata{}
 	metadataInfo := map[string]string{}
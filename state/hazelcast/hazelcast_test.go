@@ -14,12 +14,21 @@ limitations under the License.
 package hazelcast
 
 import (
+	"context"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/hazelcast/hazelcast-go-client/types"
+	jsoniter "github.com/json-iterator/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
 )
 
 func TestValidateMetadata(t *testing.T) {
@@ -66,4 +75,349 @@ func TestValidateMetadata(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, properties["hazelcastServers"], meta.HazelcastServers)
 	})
+
+	t.Run("with near cache", func(t *testing.T) {
+		properties := map[string]string{
+			"hazelcastServers":            "hz1:5701,hz2:5701",
+			"hazelcastMap":                "foo-map",
+			"hazelcastClusterName":        "prod",
+			"nearCache":                   "true",
+			"nearCacheTTL":                "1m",
+			"nearCacheMaxIdle":            "30s",
+			"nearCacheMaxSize":            "1000",
+			"nearCacheInvalidateOnChange": "false",
+		}
+		meta, err := validateAndParseMetadata(state.Metadata{Base: metadata.Base{Properties: properties}})
+		require.NoError(t, err)
+
+		config := meta.clientConfig()
+		assert.Equal(t, []string{"hz1:5701", "hz2:5701"}, config.Cluster.Network.Addresses)
+		assert.Equal(t, "prod", config.Cluster.Name)
+		nc, ok, err := config.GetNearCache("foo-map")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, "foo-map", nc.Name)
+		assert.Equal(t, 60, nc.TimeToLiveSeconds)
+		assert.Equal(t, 30, nc.MaxIdleSeconds)
+		assert.Equal(t, 1000, nc.Eviction.Size())
+		assert.False(t, nc.InvalidateOnChange())
+	})
+
+	t.Run("with negative near cache TTL", func(t *testing.T) {
+		properties := map[string]string{
+			"hazelcastServers": "hz1:5701",
+			"hazelcastMap":     "foo-map",
+			"nearCache":        "true",
+			"nearCacheTTL":     "-1s",
+		}
+		_, err := validateAndParseMetadata(state.Metadata{Base: metadata.Base{Properties: properties}})
+		assert.Error(t, err)
+	})
+}
+
+type fakeEntry struct {
+	value   interface{}
+	version int64
+	created int64
+	ttl     time.Duration
+}
+
+type lockOwnerKey struct{}
+
+// fakeMap is an in-memory map implementing the methods used by the state store.
+// Like the map of the cluster, writes to a key locked by another lock context wait until it's unlocked.
+type fakeMap struct {
+	lock     sync.Mutex
+	unlocked *sync.Cond
+	entries  map[interface{}]fakeEntry
+	// Lock context that holds the lock of each key.
+	locks  map[interface{}]int64
+	owners int64
+	clock  int64
+}
+
+func newFakeMap() *fakeMap {
+	m := &fakeMap{entries: map[interface{}]fakeEntry{}, locks: map[interface{}]int64{}}
+	m.unlocked = sync.NewCond(&m.lock)
+	return m
+}
+
+func owner(ctx context.Context) int64 {
+	id, _ := ctx.Value(lockOwnerKey{}).(int64)
+	return id
+}
+
+// waitUnlocked waits until the key isn't locked by another lock context than the one of ctx.
+func (m *fakeMap) waitUnlocked(ctx context.Context, key interface{}) {
+	for {
+		holder, ok := m.locks[key]
+		if !ok || holder == owner(ctx) {
+			return
+		}
+		m.unlocked.Wait()
+	}
+}
+
+func (m *fakeMap) put(ctx context.Context, key, value interface{}, ttl time.Duration) {
+	m.waitUnlocked(ctx, key)
+	e, ok := m.entries[key]
+	if ok {
+		e.version++
+	} else {
+		m.clock++
+		e.created = m.clock
+	}
+	e.value = value
+	e.ttl = ttl
+	m.entries[key] = e
+}
+
+func (m *fakeMap) NewLockContext(ctx context.Context) context.Context {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.owners++
+	return context.WithValue(ctx, lockOwnerKey{}, m.owners)
+}
+
+func (m *fakeMap) LockWithLease(ctx context.Context, key interface{}, leaseTime time.Duration) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.waitUnlocked(ctx, key)
+	m.locks[key] = owner(ctx)
+	return nil
+}
+
+func (m *fakeMap) Unlock(ctx context.Context, key interface{}) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if holder, ok := m.locks[key]; !ok || holder != owner(ctx) {
+		return errors.New("key is not locked by this lock context")
+	}
+	delete(m.locks, key)
+	m.unlocked.Broadcast()
+	return nil
+}
+
+func (m *fakeMap) Get(ctx context.Context, key interface{}) (interface{}, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.entries[key].value, nil
+}
+
+func (m *fakeMap) GetEntryView(ctx context.Context, key interface{}) (*types.SimpleEntryView, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	return &types.SimpleEntryView{Key: key, Value: e.value, Version: e.version, CreationTime: e.created}, nil
+}
+
+func (m *fakeMap) Set(ctx context.Context, key, value interface{}) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.put(ctx, key, value, 0)
+	return nil
+}
+
+func (m *fakeMap) SetWithTTL(ctx context.Context, key, value interface{}, ttl time.Duration) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.put(ctx, key, value, ttl)
+	return nil
+}
+
+func (m *fakeMap) PutIfAbsent(ctx context.Context, key, value interface{}) (interface{}, error) {
+	return m.PutIfAbsentWithTTL(ctx, key, value, 0)
+}
+
+func (m *fakeMap) PutIfAbsentWithTTL(ctx context.Context, key, value interface{}, ttl time.Duration) (interface{}, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if e, ok := m.entries[key]; ok {
+		return e.value, nil
+	}
+	m.put(ctx, key, value, ttl)
+	return nil, nil
+}
+
+func (m *fakeMap) Delete(ctx context.Context, key interface{}) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.waitUnlocked(ctx, key)
+	delete(m.entries, key)
+	return nil
+}
+
+func TestOperations(t *testing.T) {
+	hzMap := newFakeMap()
+	store := &Hazelcast{
+		hzMap:  hzMap,
+		json:   jsoniter.ConfigFastest,
+		logger: logger.NewLogger("test"),
+	}
+	ctx := context.Background()
+
+	t.Run("features", func(t *testing.T) {
+		assert.True(t, state.FeatureETag.IsPresent(store.Features()))
+		assert.True(t, state.FeatureTTL.IsPresent(store.Features()))
+	})
+
+	t.Run("get missing key", func(t *testing.T) {
+		res, err := store.Get(ctx, &state.GetRequest{Key: "missing"})
+		require.NoError(t, err)
+		assert.Nil(t, res.Data)
+		assert.Nil(t, res.ETag)
+	})
+
+	t.Run("set and get with etag", func(t *testing.T) {
+		require.NoError(t, store.Set(ctx, &state.SetRequest{Key: "k1", Value: "v1"}))
+		res, err := store.Get(ctx, &state.GetRequest{Key: "k1"})
+		require.NoError(t, err)
+		assert.Equal(t, `"\"v1\""`, string(res.Data))
+		require.NotNil(t, res.ETag)
+		etag := *res.ETag
+		assert.Regexp(t, `^\d+-0$`, etag)
+
+		require.NoError(t, store.Set(ctx, &state.SetRequest{Key: "k1", Value: "v2", ETag: res.ETag}))
+		res, err = store.Get(ctx, &state.GetRequest{Key: "k1"})
+		require.NoError(t, err)
+		assert.Regexp(t, `^\d+-1$`, *res.ETag)
+		assert.Empty(t, hzMap.locks)
+
+		// The old etag doesn't match anymore
+		err = store.Set(ctx, &state.SetRequest{Key: "k1", Value: "v3", ETag: &etag})
+		var etagErr *state.ETagError
+		require.True(t, errors.As(err, &etagErr))
+		assert.Equal(t, state.ETagMismatch, etagErr.Kind())
+
+		assert.Empty(t, hzMap.locks)
+
+		err = store.Set(ctx, &state.SetRequest{Key: "missing", Value: "v3", ETag: ptr.Of("1-0")})
+		require.True(t, errors.As(err, &etagErr))
+	})
+
+	t.Run("etag of a value written again", func(t *testing.T) {
+		require.NoError(t, store.Set(ctx, &state.SetRequest{Key: "k6", Value: "v1"}))
+		res, err := store.Get(ctx, &state.GetRequest{Key: "k6"})
+		require.NoError(t, err)
+
+		// Writing the same value changes the etag
+		require.NoError(t, store.Set(ctx, &state.SetRequest{Key: "k6", Value: "v1"}))
+		err = store.Set(ctx, &state.SetRequest{Key: "k6", Value: "v2", ETag: res.ETag})
+		var etagErr *state.ETagError
+		require.True(t, errors.As(err, &etagErr))
+
+		// A key deleted and created again doesn't match the etags of the old entry
+		res, err = store.Get(ctx, &state.GetRequest{Key: "k6"})
+		require.NoError(t, err)
+		require.NoError(t, store.Delete(ctx, &state.DeleteRequest{Key: "k6"}))
+		require.NoError(t, store.Set(ctx, &state.SetRequest{Key: "k6", Value: "v1"}))
+		require.NoError(t, store.Set(ctx, &state.SetRequest{Key: "k6", Value: "v1"}))
+		err = store.Set(ctx, &state.SetRequest{Key: "k6", Value: "v2", ETag: res.ETag})
+		require.True(t, errors.As(err, &etagErr))
+	})
+
+	t.Run("concurrent writes with the same etag", func(t *testing.T) {
+		require.NoError(t, store.Set(ctx, &state.SetRequest{Key: "k7", Value: "v1"}))
+		res, err := store.Get(ctx, &state.GetRequest{Key: "k7"})
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- store.Set(ctx, &state.SetRequest{Key: "k7", Value: "v1", ETag: res.ETag})
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+			}
+		}
+		assert.Equal(t, 1, succeeded)
+		assert.Empty(t, hzMap.locks)
+	})
+
+	t.Run("first write", func(t *testing.T) {
+		req := &state.SetRequest{Key: "k2", Value: "v1", Options: state.SetStateOption{Concurrency: state.FirstWrite}}
+		require.NoError(t, store.Set(ctx, req))
+		err := store.Set(ctx, req)
+		var etagErr *state.ETagError
+		assert.True(t, errors.As(err, &etagErr))
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		require.NoError(t, store.Set(ctx, &state.SetRequest{Key: "k3", Value: "v1", Metadata: map[string]string{"ttlInSeconds": "10"}}))
+		assert.Equal(t, 10*time.Second, hzMap.entries["k3"].ttl)
+
+		require.NoError(t, store.Set(ctx, &state.SetRequest{Key: "k3", Value: "v1", Metadata: map[string]string{"ttlInSeconds": "-1"}}))
+		assert.Equal(t, time.Duration(0), hzMap.entries["k3"].ttl)
+
+		// A TTL can be set together with an etag or first-write concurrency
+		res, err := store.Get(ctx, &state.GetRequest{Key: "k3", Options: state.GetStateOption{Consistency: state.Strong}})
+		require.NoError(t, err)
+		require.NoError(t, store.Set(ctx, &state.SetRequest{Key: "k3", Value: "v2", ETag: res.ETag, Metadata: map[string]string{"ttlInSeconds": "20"}}))
+		assert.Equal(t, 20*time.Second, hzMap.entries["k3"].ttl)
+
+		require.NoError(t, store.Set(ctx, &state.SetRequest{
+			Key: "k8", Value: "v1", Options: state.SetStateOption{Concurrency: state.FirstWrite}, Metadata: map[string]string{"ttlInSeconds": "30"},
+		}))
+		assert.Equal(t, 30*time.Second, hzMap.entries["k8"].ttl)
+
+		err = store.Set(ctx, &state.SetRequest{Key: "k3", Value: "v1", Metadata: map[string]string{"ttlInSeconds": "foo"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("delete with etag", func(t *testing.T) {
+		require.NoError(t, store.Set(ctx, &state.SetRequest{Key: "k4", Value: "v1"}))
+
+		res, err := store.Get(ctx, &state.GetRequest{Key: "k4"})
+		require.NoError(t, err)
+
+		err = store.Delete(ctx, &state.DeleteRequest{Key: "k4", ETag: ptr.Of("5")})
+		var etagErr *state.ETagError
+		require.True(t, errors.As(err, &etagErr))
+
+		require.NoError(t, store.Delete(ctx, &state.DeleteRequest{Key: "k4", ETag: res.ETag}))
+		assert.Empty(t, hzMap.locks)
+		res, err = store.Get(ctx, &state.GetRequest{Key: "k4"})
+		require.NoError(t, err)
+		assert.Nil(t, res.Data)
+	})
+
+	t.Run("near cache", func(t *testing.T) {
+		store.nearCache = true
+		defer func() { store.nearCache = false }()
+		require.NoError(t, store.Set(ctx, &state.SetRequest{Key: "k9", Value: "v1"}))
+
+		// Reads with eventual consistency are served from the near cache, without an etag
+		res, err := store.Get(ctx, &state.GetRequest{Key: "k9"})
+		require.NoError(t, err)
+		assert.Equal(t, `"\"v1\""`, string(res.Data))
+		assert.Nil(t, res.ETag)
+
+		res, err = store.Get(ctx, &state.GetRequest{Key: "k9", Options: state.GetStateOption{Consistency: state.Strong}})
+		require.NoError(t, err)
+		assert.Equal(t, `"\"v1\""`, string(res.Data))
+		assert.NotNil(t, res.ETag)
+
+		res, err = store.Get(ctx, &state.GetRequest{Key: "missing"})
+		require.NoError(t, err)
+		assert.Nil(t, res.Data)
+	})
+
+	t.Run("delete without etag", func(t *testing.T) {
+		require.NoError(t, store.Set(ctx, &state.SetRequest{Key: "k5", Value: "v1"}))
+		require.NoError(t, store.Delete(ctx, &state.DeleteRequest{Key: "k5"}))
+		_, ok := hzMap.entries["k5"]
+		assert.False(t, ok)
+	})
 }
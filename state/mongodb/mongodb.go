@@ -32,6 +32,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 
 	"github.com/dapr/components-contrib/metadata"
@@ -56,7 +57,11 @@ const (
 	etag             = "_etag"
 	ttl              = "_ttl"
 
+	// Metadata of query requests with the index to use, either by name or as a JSON document with its keys.
+	queryIndexHint = "queryIndexHint"
+
 	defaultTimeout        = 5 * time.Second
+	retryInitialDelay     = 100 * time.Millisecond
 	defaultDatabaseName   = "daprStore"
 	defaultCollectionName = "daprCollection"
 
@@ -112,7 +117,7 @@ type Item struct {
 // NewMongoDB returns a new MongoDB state store.
 func NewMongoDB(logger logger.Logger) state.Store {
 	s := &MongoDB{
-		features: []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI},
+		features: []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI, state.FeatureTTL, state.FeatureBulkNative},
 		logger:   logger,
 	}
 	s.BulkStore = state.NewDefaultBulkStore(s)
@@ -180,12 +185,19 @@ func (m *MongoDB) Features() []state.Feature {
 
 // Set saves state into MongoDB.
 func (m *MongoDB) Set(ctx context.Context, req *state.SetRequest) error {
-	err := m.setInternal(ctx, req)
+	coll, err := m.requestCollection(req.Options.Consistency, req.Metadata, true)
 	if err != nil {
 		return err
 	}
+	ctx, cancel, err := state.RequestContext(ctx, req.Metadata, m.operationTimeout)
+	if err != nil {
+		return err
+	}
+	defer cancel()
 
-	return nil
+	return withRetries(ctx, req.Metadata, func() error {
+		return m.setInternal(ctx, coll, req)
+	})
 }
 
 func (m *MongoDB) Ping(ctx context.Context) error {
@@ -196,7 +208,7 @@ func (m *MongoDB) Ping(ctx context.Context) error {
 	return nil
 }
 
-func (m *MongoDB) setInternal(ctx context.Context, req *state.SetRequest) error {
+func (m *MongoDB) setInternal(ctx context.Context, coll *mongo.Collection, req *state.SetRequest) error {
 	var v interface{}
 	switch obj := req.Value.(type) {
 	case []byte:
@@ -259,7 +271,7 @@ func (m *MongoDB) setInternal(ctx context.Context, req *state.SetRequest) error
 		}
 	}
 
-	_, err = m.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	_, err = coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err) {
 			return state.NewETagError(state.ETagMismatch, err)
@@ -272,6 +284,16 @@ func (m *MongoDB) setInternal(ctx context.Context, req *state.SetRequest) error
 
 // Get retrieves state from MongoDB with a key.
 func (m *MongoDB) Get(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+	coll, err := m.requestCollection(req.Options.Consistency, req.Metadata, false)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel, err := state.RequestContext(ctx, req.Metadata, m.operationTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
 	filter := bson.D{
 		{Key: "$and", Value: bson.A{
 			bson.D{{Key: id, Value: bson.M{"$eq": req.Key}}},
@@ -279,9 +301,9 @@ func (m *MongoDB) Get(ctx context.Context, req *state.GetRequest) (*state.GetRes
 		}},
 	}
 	var result Item
-	err := m.collection.
-		FindOne(ctx, filter).
-		Decode(&result)
+	err = withRetries(ctx, req.Metadata, func() error {
+		return coll.FindOne(ctx, filter).Decode(&result)
+	})
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			// Key not found, not an error.
@@ -317,10 +339,20 @@ func (m *MongoDB) BulkGet(ctx context.Context, req []state.GetRequest, _ state.B
 	}
 
 	// Get all the keys
+	// The requests are executed at once, so the consistency, timeout and retries of the first one apply
 	keys := make(bson.A, len(req))
 	for i, r := range req {
 		keys[i] = r.Key
 	}
+	coll, err := m.requestCollection(req[0].Options.Consistency, req[0].Metadata, false)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel, err := state.RequestContext(ctx, req[0].Metadata, m.operationTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
 
 	// Perform the query
 	filter := bson.D{
@@ -331,7 +363,11 @@ func (m *MongoDB) BulkGet(ctx context.Context, req []state.GetRequest, _ state.B
 			getFilterTTL(),
 		}},
 	}
-	cur, err := m.collection.Find(ctx, filter)
+	var cur *mongo.Cursor
+	err = withRetries(ctx, req[0].Metadata, func() (findErr error) {
+		cur, findErr = coll.Find(ctx, filter)
+		return findErr
+	})
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			// No documents found, just return an empty list
@@ -469,20 +505,27 @@ func (m *MongoDB) decodeData(resValue any) (data []byte, err error) {
 
 // Delete performs a delete operation.
 func (m *MongoDB) Delete(ctx context.Context, req *state.DeleteRequest) error {
-	err := m.deleteInternal(ctx, req)
+	coll, err := m.requestCollection(req.Options.Consistency, req.Metadata, true)
 	if err != nil {
 		return err
 	}
+	ctx, cancel, err := state.RequestContext(ctx, req.Metadata, m.operationTimeout)
+	if err != nil {
+		return err
+	}
+	defer cancel()
 
-	return nil
+	return withRetries(ctx, req.Metadata, func() error {
+		return m.deleteInternal(ctx, coll, req)
+	})
 }
 
-func (m *MongoDB) deleteInternal(ctx context.Context, req *state.DeleteRequest) error {
+func (m *MongoDB) deleteInternal(ctx context.Context, coll *mongo.Collection, req *state.DeleteRequest) error {
 	filter := bson.M{id: req.Key}
 	if req.HasETag() {
 		filter[etag] = *req.ETag
 	}
-	result, err := m.collection.DeleteOne(ctx, filter)
+	result, err := coll.DeleteOne(ctx, filter)
 	if err != nil {
 		return err
 	}
@@ -500,6 +543,12 @@ func (m *MongoDB) Multi(ctx context.Context, request *state.TransactionalStateRe
 		return errors.New("using transactions with MongoDB requires connecting to a replica set")
 	}
 
+	ctx, cancel, err := state.RequestContext(ctx, request.Metadata, m.operationTimeout)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
 	sess, err := m.client.StartSession()
 	if err != nil {
 		return fmt.Errorf("error starting the transaction: %w", err)
@@ -522,9 +571,9 @@ func (m *MongoDB) doTransaction(sessCtx mongo.SessionContext, operations []state
 		var err error
 		switch req := o.(type) {
 		case state.SetRequest:
-			err = m.setInternal(sessCtx, &req)
+			err = m.setInternal(sessCtx, m.collection, &req)
 		case state.DeleteRequest:
-			err = m.deleteInternal(sessCtx, &req)
+			err = m.deleteInternal(sessCtx, m.collection, &req)
 		}
 
 		if err != nil {
@@ -543,7 +592,27 @@ func (m *MongoDB) Query(ctx context.Context, req *state.QueryRequest) (*state.Qu
 	if err := qbuilder.BuildQuery(&req.Query); err != nil {
 		return &state.QueryResponse{}, err
 	}
-	data, token, err := q.execute(ctx, m.collection)
+	if hint := req.Metadata[queryIndexHint]; hint != "" {
+		if err := q.setHint(hint); err != nil {
+			return &state.QueryResponse{}, err
+		}
+	}
+	if metadata.IsQueryExplain(req.Metadata) {
+		return &state.QueryResponse{
+			Results:  []state.QueryItem{},
+			Metadata: q.Explain().Metadata(),
+		}, nil
+	}
+	coll, err := m.requestCollection("", req.Metadata, false)
+	if err != nil {
+		return &state.QueryResponse{}, err
+	}
+	ctx, cancel, err := state.RequestContext(ctx, req.Metadata, m.operationTimeout)
+	if err != nil {
+		return &state.QueryResponse{}, err
+	}
+	defer cancel()
+	data, token, err := q.execute(ctx, coll)
 	if err != nil {
 		return &state.QueryResponse{}, err
 	}
@@ -554,6 +623,66 @@ func (m *MongoDB) Query(ctx context.Context, req *state.QueryRequest) (*state.Qu
 	}, nil
 }
 
+// requestCollection returns the collection to use for a request, with the read or write concern of the consistency in its options or metadata.
+// Strong consistency reads from the primary with a majority read concern, and writes with a majority write concern.
+// Eventual consistency reads from secondaries when available, and writes with an acknowledgement from the primary only.
+func (m *MongoDB) requestCollection(consistencyOption string, reqMetadata map[string]string, write bool) (*mongo.Collection, error) {
+	consistency, err := state.RequestConsistency(consistencyOption, reqMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts *options.CollectionOptions
+	switch {
+	case consistency == state.Strong && write:
+		opts = options.Collection().SetWriteConcern(writeconcern.New(writeconcern.WMajority(), writeconcern.J(true), writeconcern.WTimeout(defaultTimeout)))
+	case consistency == state.Strong:
+		opts = options.Collection().SetReadPreference(readpref.Primary()).SetReadConcern(readconcern.Majority())
+	case consistency == state.Eventual && write:
+		opts = options.Collection().SetWriteConcern(writeconcern.New(writeconcern.W(1)))
+	case consistency == state.Eventual:
+		opts = options.Collection().SetReadPreference(readpref.SecondaryPreferred()).SetReadConcern(readconcern.Local())
+	default:
+		return m.collection, nil
+	}
+	return m.collection.Clone(opts)
+}
+
+// withRetries invokes fn, retrying it with an exponential backoff when it fails with a transient error, up to the maximum number of retries in the metadata of the request.
+// By default requests aren't retried, besides the retries of the driver.
+func withRetries(ctx context.Context, reqMetadata map[string]string, fn func() error) error {
+	maxRetries, err := state.RequestMaxRetries(reqMetadata, 0)
+	if err != nil {
+		return err
+	}
+	delay := retryInitialDelay
+	for attempt := 0; ; attempt++ {
+		err = fn()
+		if err == nil || attempt >= maxRetries || !isTransientError(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// isTransientError returns true if an operation failed because of an error that may not happen again, such as a network error.
+func isTransientError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if mongo.IsNetworkError(err) {
+		return true
+	}
+	var labeledErr mongo.LabeledError
+	return errors.As(err, &labeledErr) &&
+		(labeledErr.HasErrorLabel("RetryableWriteError") || labeledErr.HasErrorLabel("TransientTransactionError"))
+}
+
 func (metadata *mongoDBMetadata) getMongoConnectionString() string {
 	if metadata.ConnectionString != "" {
 		return metadata.ConnectionString
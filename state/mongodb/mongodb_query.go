@@ -15,12 +15,15 @@ package mongodb
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -33,6 +36,19 @@ type Query struct {
 	query  string
 	filter interface{}
 	opts   *options.FindOptions
+	// Keys of the sort order, which are used to build the token of the next page.
+	sort []query.Sorting
+	// True when the page token is a number of documents to skip, as in previous versions.
+	skipToken bool
+}
+
+// queryCursor is the position after the last document of a page, used as the token of the next page.
+// Paginating with a cursor instead of skipping documents is stable when documents are added or removed.
+type queryCursor struct {
+	// Values of the sort keys in the last document.
+	Values bson.A `bson:"v"`
+	// Key of the last document, which is always the last sort key.
+	Key string `bson:"k"`
 }
 
 func (q *Query) VisitEQ(f *query.EQ) (string, error) {
@@ -124,44 +140,202 @@ func (q *Query) Finalize(filters string, qq *query.Query) error {
 	q.opts = options.Find()
 
 	// sorting
-	if len(qq.Sort) > 0 {
-		sort := bson.D{}
-		for _, s := range qq.Sort {
-			order := 1 // ascending
-			if s.Order == query.DESC {
-				order = -1
-			}
-			sort = append(sort, bson.E{Key: "value." + s.Key, Value: order})
+	// The key is always the last sort key, so the order is stable
+	q.sort = qq.Sort
+	sort := bson.D{}
+	for _, s := range qq.Sort {
+		order := 1 // ascending
+		if s.Order == query.DESC {
+			order = -1
 		}
-		q.opts.SetSort(sort)
+		sort = append(sort, bson.E{Key: "value." + s.Key, Value: order})
 	}
+	sort = append(sort, bson.E{Key: id, Value: 1})
+	q.opts.SetSort(sort)
+
 	// pagination
 	if qq.Page.Limit > 0 {
 		q.opts.SetLimit(int64(qq.Page.Limit))
 	}
 	if len(qq.Page.Token) != 0 {
-		skip, err := strconv.ParseInt(qq.Page.Token, 10, 64)
+		// Tokens that are numbers were returned by previous versions
+		if skip, err := strconv.ParseInt(qq.Page.Token, 10, 64); err == nil {
+			q.opts.SetSkip(skip)
+			q.skipToken = true
+			return nil
+		}
+
+		cursor, err := decodeQueryCursor(qq.Page.Token, len(qq.Sort))
 		if err != nil {
 			return err
 		}
-		q.opts.SetSkip(skip)
+		q.filter = bson.D{{Key: "$and", Value: bson.A{q.filter, cursor.filter(qq.Sort)}}}
+	}
+
+	return nil
+}
+
+// filter returns the filter that matches the documents after the cursor in the sort order.
+// For sort keys k1..kn, this is: k1 after v1, or k1 = v1 and k2 after v2, ..., or k1..kn = v1..vn and the key after the last key.
+// Documents without a sort key are ordered as null values, which come first in ascending order.
+func (c queryCursor) filter(sort []query.Sorting) bson.D {
+	branches := bson.A{}
+	equal := bson.A{}
+	for i, s := range sort {
+		key := "value." + s.Key
+		if after := afterValue(key, c.Values[i], s.Order == query.DESC); after != nil {
+			branches = append(branches, bson.D{{Key: "$and", Value: append(bson.A{after}, equal...)}})
+		}
+		equal = append(equal, bson.D{{Key: key, Value: bson.D{{Key: "$eq", Value: c.Values[i]}}}})
+	}
+	after := bson.D{{Key: id, Value: bson.D{{Key: "$gt", Value: c.Key}}}}
+	branches = append(branches, bson.D{{Key: "$and", Value: append(bson.A{after}, equal...)}})
+
+	return bson.D{{Key: "$or", Value: branches}}
+}
+
+// afterValue returns the filter that matches the values of a key after a value in the sort order, or nil if there are none.
+func afterValue(key string, val any, desc bool) bson.D {
+	isNull := val == nil
+	if rv, ok := val.(bson.RawValue); ok {
+		isNull = rv.Type == bsontype.Null || rv.Type == bsontype.Undefined
+	}
+	if _, ok := val.(primitive.Null); ok {
+		isNull = true
+	}
+
+	switch {
+	case isNull && desc:
+		// Null values come last in descending order
+		return nil
+	case isNull:
+		return bson.D{{Key: key, Value: bson.D{{Key: "$ne", Value: nil}}}}
+	case desc:
+		return bson.D{{Key: "$or", Value: bson.A{
+			bson.D{{Key: key, Value: bson.D{{Key: "$lt", Value: val}}}},
+			bson.D{{Key: key, Value: nil}},
+		}}}
+	default:
+		return bson.D{{Key: key, Value: bson.D{{Key: "$gt", Value: val}}}}
+	}
+}
+
+// encodeQueryCursor returns the token for the page after a document.
+func encodeQueryCursor(doc bson.Raw, sort []query.Sorting) (string, error) {
+	cursor := queryCursor{
+		Values: make(bson.A, len(sort)),
+	}
+	for i, s := range sort {
+		path := append([]string{value}, strings.Split(s.Key, ".")...)
+		val, err := doc.LookupErr(path...)
+		if err != nil {
+			// Missing values are sorted as null
+			cursor.Values[i] = primitive.Null{}
+			continue
+		}
+		cursor.Values[i] = val
+	}
+	key, ok := doc.Lookup(id).StringValueOK()
+	if !ok {
+		return "", errors.New("document without a key")
 	}
+	cursor.Key = key
 
+	b, err := bson.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeQueryCursor parses the token of a page.
+func decodeQueryCursor(token string, sortKeys int) (queryCursor, error) {
+	var cursor queryCursor
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		err = bson.Unmarshal(b, &cursor)
+	}
+	if err != nil || len(cursor.Values) != sortKeys {
+		return queryCursor{}, fmt.Errorf("invalid page token %q", token)
+	}
+	return cursor, nil
+}
+
+// setHint sets the index to use for the query.
+// The hint is either the name of an index or a JSON document with the keys of the index, such as {"value.state": 1}.
+func (q *Query) setHint(hint string) error {
+	if !strings.HasPrefix(strings.TrimSpace(hint), "{") {
+		q.opts.SetHint(hint)
+		return nil
+	}
+	var keys bson.D
+	if err := bson.UnmarshalExtJSON([]byte(hint), false, &keys); err != nil {
+		return fmt.Errorf("invalid index hint %q: %w", hint, err)
+	}
+	q.opts.SetHint(keys)
 	return nil
 }
 
+// findFilter returns the filter passed to Find, which excludes the expired documents that haven't been deleted yet.
+func (q *Query) findFilter() bson.D {
+	return bson.D{{Key: "$and", Value: bson.A{q.filter, getFilterTTL()}}}
+}
+
+// Explain returns the Find command in the syntax of the mongo shell, and the index hint if any.
+func (q *Query) Explain() query.Explanation {
+	var b strings.Builder
+	b.WriteString("find(" + marshalExplainValue(q.findFilter()) + ")")
+	if q.opts.Sort != nil {
+		b.WriteString(".sort(" + marshalExplainValue(q.opts.Sort) + ")")
+	}
+	if q.opts.Skip != nil {
+		b.WriteString(".skip(" + strconv.FormatInt(*q.opts.Skip, 10) + ")")
+	}
+	if q.opts.Limit != nil {
+		b.WriteString(".limit(" + strconv.FormatInt(*q.opts.Limit, 10) + ")")
+	}
+
+	res := query.Explanation{
+		Backend: "mongodb",
+		Query:   b.String(),
+	}
+	if q.opts.Hint != nil {
+		hint, ok := q.opts.Hint.(string)
+		if ok {
+			b.WriteString(".hint(" + strconv.Quote(hint) + ")")
+		} else {
+			hint = marshalExplainValue(q.opts.Hint)
+			b.WriteString(".hint(" + hint + ")")
+		}
+		res.Query = b.String()
+		res.IndexHints = []string{hint}
+	}
+	return res
+}
+
+func marshalExplainValue(val any) string {
+	b, err := bson.MarshalExtJSON(val, false, false)
+	if err != nil {
+		return fmt.Sprintf("%v", val)
+	}
+	return string(b)
+}
+
 func (q *Query) execute(ctx context.Context, collection *mongo.Collection) ([]state.QueryItem, string, error) {
-	cur, err := collection.Find(ctx, q.filter, []*options.FindOptions{q.opts}...)
+	cur, err := collection.Find(ctx, q.findFilter(), []*options.FindOptions{q.opts}...)
 	if err != nil {
 		return nil, "", err
 	}
 	defer cur.Close(ctx)
 	ret := []state.QueryItem{}
+	var last bson.Raw
 	for cur.Next(ctx) {
 		var item Item
 		if err = cur.Decode(&item); err != nil {
 			return nil, "", err
 		}
+		// The current document is only valid until the next call to Next
+		last = append(last[:0], cur.Current...)
 		result := state.QueryItem{
 			Key:  item.Key,
 			ETag: &item.Etag,
@@ -194,11 +368,19 @@ func (q *Query) execute(ctx context.Context, collection *mongo.Collection) ([]st
 	// set next query token only if limit is specified
 	var token string
 	if q.opts.Limit != nil && *q.opts.Limit != 0 {
-		var skip int64
-		if q.opts.Skip != nil {
-			skip = *q.opts.Skip
+		switch {
+		case q.skipToken:
+			var skip int64
+			if q.opts.Skip != nil {
+				skip = *q.opts.Skip
+			}
+			token = strconv.FormatInt(skip+int64(len(ret)), 10)
+		case last != nil:
+			token, err = encodeQueryCursor(last, q.sort)
+			if err != nil {
+				return nil, "", err
+			}
 		}
-		token = strconv.FormatInt(skip+int64(len(ret)), 10)
 	}
 
 	return ret, token, nil
@@ -19,6 +19,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/dapr/components-contrib/state/query"
 )
@@ -63,3 +66,133 @@ func TestMongoQuery(t *testing.T) {
 		assert.Equal(t, test.query, q.query)
 	}
 }
+
+func TestMongoQueryPagination(t *testing.T) {
+	sort := []query.Sorting{
+		{Key: "state", Order: query.ASC},
+		{Key: "person.id", Order: query.DESC},
+	}
+
+	t.Run("sort is stable", func(t *testing.T) {
+		q := &Query{}
+		err := q.Finalize("", &query.Query{QueryFields: query.QueryFields{Sort: sort}})
+		require.NoError(t, err)
+		assert.Equal(t, bson.D{
+			{Key: "value.state", Value: 1},
+			{Key: "value.person.id", Value: -1},
+			{Key: "_id", Value: 1},
+		}, q.opts.Sort)
+	})
+
+	t.Run("numeric token", func(t *testing.T) {
+		q := &Query{}
+		err := q.Finalize("", &query.Query{QueryFields: query.QueryFields{Page: query.Pagination{Limit: 2, Token: "4"}}})
+		require.NoError(t, err)
+		assert.True(t, q.skipToken)
+		assert.Equal(t, int64(4), *q.opts.Skip)
+		assert.Equal(t, bson.D{}, q.filter)
+	})
+
+	t.Run("cursor token", func(t *testing.T) {
+		doc, err := bson.Marshal(bson.D{
+			{Key: "_id", Value: "key1"},
+			{Key: "value", Value: bson.D{
+				{Key: "state", Value: "CA"},
+				{Key: "person", Value: bson.D{{Key: "id", Value: int32(5)}}},
+			}},
+		})
+		require.NoError(t, err)
+		token, err := encodeQueryCursor(doc, sort)
+		require.NoError(t, err)
+
+		q := &Query{}
+		err = q.Finalize(`{ "value.org": "A" }`, &query.Query{QueryFields: query.QueryFields{Sort: sort, Page: query.Pagination{Limit: 2, Token: token}}})
+		require.NoError(t, err)
+		assert.False(t, q.skipToken)
+		assert.Nil(t, q.opts.Skip)
+
+		filter, err := bson.MarshalExtJSON(q.filter, false, false)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"$and": [
+			{"value.org": "A"},
+			{"$or": [
+				{"$and": [{"value.state": {"$gt": "CA"}}]},
+				{"$and": [
+					{"$or": [{"value.person.id": {"$lt": 5}}, {"value.person.id": null}]},
+					{"value.state": {"$eq": "CA"}}
+				]},
+				{"$and": [
+					{"_id": {"$gt": "key1"}},
+					{"value.state": {"$eq": "CA"}},
+					{"value.person.id": {"$eq": 5}}
+				]}
+			]}
+		]}`, string(filter))
+	})
+
+	t.Run("cursor token with missing values", func(t *testing.T) {
+		doc, err := bson.Marshal(bson.D{
+			{Key: "_id", Value: "key1"},
+			{Key: "value", Value: bson.D{}},
+		})
+		require.NoError(t, err)
+		token, err := encodeQueryCursor(doc, sort)
+		require.NoError(t, err)
+
+		cursor, err := decodeQueryCursor(token, len(sort))
+		require.NoError(t, err)
+		filter, err := bson.MarshalExtJSON(cursor.filter(sort), false, false)
+		require.NoError(t, err)
+		// Nothing comes after null values in descending order
+		assert.JSONEq(t, `{"$or": [
+			{"$and": [{"value.state": {"$ne": null}}]},
+			{"$and": [
+				{"_id": {"$gt": "key1"}},
+				{"value.state": {"$eq": null}},
+				{"value.person.id": {"$eq": null}}
+			]}
+		]}`, string(filter))
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		q := &Query{}
+		err := q.Finalize("", &query.Query{QueryFields: query.QueryFields{Sort: sort, Page: query.Pagination{Limit: 2, Token: "not-a-token"}}})
+		assert.Error(t, err)
+
+		// The sort order doesn't match the token
+		doc, err := bson.Marshal(bson.D{{Key: "_id", Value: "key1"}})
+		require.NoError(t, err)
+		token, err := encodeQueryCursor(doc, nil)
+		require.NoError(t, err)
+		err = q.Finalize("", &query.Query{QueryFields: query.QueryFields{Sort: sort, Page: query.Pagination{Limit: 2, Token: token}}})
+		assert.Error(t, err)
+	})
+}
+
+func TestMongoQueryHint(t *testing.T) {
+	q := &Query{opts: options.Find()}
+	require.NoError(t, q.setHint("state_idx"))
+	assert.Equal(t, "state_idx", q.opts.Hint)
+
+	require.NoError(t, q.setHint(`{"value.state": 1}`))
+	assert.Equal(t, bson.D{{Key: "value.state", Value: int32(1)}}, q.opts.Hint)
+
+	assert.Error(t, q.setHint(`{"value.state": `))
+}
+
+func TestMongoQueryExplain(t *testing.T) {
+	data, err := os.ReadFile("../../tests/state/query/q2.json")
+	require.NoError(t, err)
+	var qq query.Query
+	require.NoError(t, json.Unmarshal(data, &qq))
+
+	q := &Query{}
+	require.NoError(t, query.NewQueryBuilder(q).BuildQuery(&qq))
+	require.NoError(t, q.setHint("state_idx"))
+
+	res := q.Explain()
+	assert.Equal(t, "mongodb", res.Backend)
+	assert.Contains(t, res.Query, `{"value.state":"CA"}`)
+	assert.Contains(t, res.Query, `.sort({"_id":1}).limit(2).hint("state_idx")`)
+	assert.Equal(t, []string{"state_idx"}, res.IndexHints)
+}
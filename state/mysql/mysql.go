@@ -27,7 +27,7 @@ import (
 
 	"github.com/google/uuid"
 
-	sqlCleanup "github.com/dapr/components-contrib/internal/component/sql"
+	internalsql "github.com/dapr/components-contrib/internal/component/sql"
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/components-contrib/state/utils"
@@ -81,6 +81,7 @@ type MySQL struct {
 	schemaName        string
 	connectionString  string
 	timeout           time.Duration
+	slowQueries       internalsql.SlowQueryLogger
 
 	// Instance of the database to issue commands to
 	db *sql.DB
@@ -89,7 +90,7 @@ type MySQL struct {
 	logger logger.Logger
 
 	factory iMySQLFactory
-	gc      sqlCleanup.GarbageCollector
+	gc      internalsql.GarbageCollector
 }
 
 type mySQLMetadata struct {
@@ -100,6 +101,8 @@ type mySQLMetadata struct {
 	PemPath           string
 	MetadataTableName string
 	CleanupInterval   *time.Duration
+	// Operations taking longer than this are logged; 0 disables logging.
+	SlowQueryThreshold time.Duration
 }
 
 // NewMySQLStateStore creates a new instance of MySQL state store.
@@ -220,12 +223,20 @@ func (m *MySQL) parseMetadata(md map[string]string) error {
 		m.timeout = time.Duration(defaultTimeoutInSeconds) * time.Second
 	}
 
+	if meta.SlowQueryThreshold < 0 {
+		return errors.New("slowQueryThreshold must be greater than or equal to 0")
+	}
+	m.slowQueries = internalsql.SlowQueryLogger{
+		Logger:    m.logger,
+		Threshold: meta.SlowQueryThreshold,
+	}
+
 	return nil
 }
 
 // Features returns the features available in this state store.
 func (m *MySQL) Features() []state.Feature {
-	return []state.Feature{state.FeatureETag, state.FeatureTransactional}
+	return []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureTTL, state.FeatureBulkNative}
 }
 
 // Ping the database.
@@ -270,7 +281,7 @@ func (m *MySQL) finishInit(ctx context.Context, db *sql.DB) error {
 	}
 
 	if m.cleanupInterval != nil {
-		gc, err := sqlCleanup.ScheduleGarbageCollector(sqlCleanup.GCOptions{
+		gc, err := internalsql.ScheduleGarbageCollector(internalsql.GCOptions{
 			Logger: m.logger,
 			UpdateLastCleanupQuery: fmt.Sprintf(`INSERT INTO %[1]s (id, value)
 			VALUES ('last-cleanup', CURRENT_TIMESTAMP)
@@ -492,8 +503,12 @@ func (m *MySQL) deleteValue(parentCtx context.Context, querier querier, req *sta
 		result sql.Result
 	)
 
-	execCtx, cancel := context.WithTimeout(parentCtx, m.timeout)
+	execCtx, cancel, err := internalsql.OperationContext(parentCtx, req.Metadata, m.timeout)
+	if err != nil {
+		return err
+	}
 	defer cancel()
+	defer m.slowQueries.Track("Delete", req.Key, time.Now())
 
 	if !req.HasETag() {
 		result, err = querier.ExecContext(execCtx,
@@ -528,8 +543,12 @@ func (m *MySQL) Get(parentCtx context.Context, req *state.GetRequest) (*state.Ge
 		return nil, errors.New("missing key in get operation")
 	}
 
-	ctx, cancel := context.WithTimeout(parentCtx, m.timeout)
+	ctx, cancel, err := internalsql.OperationContext(parentCtx, req.Metadata, m.timeout)
+	if err != nil {
+		return nil, err
+	}
 	defer cancel()
+	defer m.slowQueries.Track("Get", req.Key, time.Now())
 	// Concatenation is required for table name because sql.DB does not substitute parameters for table names
 	query := `SELECT id, value, eTag, isbinary, IFNULL(expiredate, "") FROM ` + m.tableName + ` WHERE id = ?
 			AND (expiredate IS NULL OR expiredate > CURRENT_TIMESTAMP)`
@@ -618,6 +637,13 @@ func (m *MySQL) setValue(parentCtx context.Context, querier querier, req *state.
 		ttlQuery = "NULL"
 	}
 
+	ctx, cancel, err := internalsql.OperationContext(parentCtx, req.Metadata, m.timeout)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer m.slowQueries.Track("Set", req.Key, time.Now())
+
 	mustCommit := false
 	hasEtag := req.ETag != nil && *req.ETag != ""
 
@@ -632,7 +658,7 @@ func (m *MySQL) setValue(parentCtx context.Context, querier querier, req *state.
 	} else if req.Options.Concurrency == state.FirstWrite {
 		// If we're not in a transaction already, start one as we need to ensure consistency
 		if querier == m.db {
-			querier, err = m.db.BeginTx(parentCtx, nil)
+			querier, err = m.db.BeginTx(ctx, nil)
 			if err != nil {
 				return fmt.Errorf("failed to begin transaction: %w", err)
 			}
@@ -656,8 +682,6 @@ func (m *MySQL) setValue(parentCtx context.Context, querier querier, req *state.
 		params = []any{req.Key, enc, eTag, isBinary, enc, eTag, isBinary}
 	}
 
-	ctx, cancel := context.WithTimeout(parentCtx, m.timeout)
-	defer cancel()
 	result, err = querier.ExecContext(ctx, query, params...)
 
 	if err != nil {
@@ -722,6 +746,7 @@ func (m *MySQL) BulkGet(parentCtx context.Context, req []state.GetRequest, _ sta
 			AND (expiredate IS NULL OR expiredate > CURRENT_TIMESTAMP)`
 	ctx, cancel := context.WithTimeout(parentCtx, m.timeout)
 	defer cancel()
+	defer m.slowQueries.Track("BulkGet", "", time.Now())
 	rows, err := m.db.QueryContext(ctx, stmt, params...)
 	if err != nil {
 		return nil, err
@@ -816,8 +841,20 @@ func readRow(row interface{ Scan(dest ...any) error }) (key string, value []byte
 
 // Multi handles multiple transactions.
 // TransactionalStore Interface.
-func (m *MySQL) Multi(ctx context.Context, request *state.TransactionalStateRequest) error {
-	tx, err := m.db.Begin()
+func (m *MySQL) Multi(parentCtx context.Context, request *state.TransactionalStateRequest) error {
+	if request == nil {
+		request = &state.TransactionalStateRequest{}
+	}
+
+	// The timeout in the metadata of the transaction, if any, applies to the whole transaction
+	ctx, cancel, err := internalsql.OperationContext(parentCtx, request.Metadata, 0)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer m.slowQueries.Track("Transaction", "", time.Now())
+
+	tx, err := m.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -169,7 +169,7 @@ func (r *StateStore) Ping(ctx context.Context) error {
 func NewOCIObjectStorageStore(logger logger.Logger) state.Store {
 	s := &StateStore{
 		json:     jsoniter.ConfigFastest,
-		features: []state.Feature{state.FeatureETag},
+		features: []state.Feature{state.FeatureETag, state.FeatureTTL},
 		logger:   logger,
 		client:   nil,
 	}
@@ -125,6 +125,64 @@ func performMigration(ctx context.Context, db postgresql.PGXPoolConn, opts postg
 		}
 	}
 
+	if opts.Citus {
+		err = m.distributeStateTable(ctx, db)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// distributeStateTable distributes the state table with Citus if it's not distributed yet.
+// The distribution column is "partitionkey", which is added to the primary key of the table; existing rows use their key as partition key.
+func (m migrations) distributeStateTable(ctx context.Context, db postgresql.PGXPoolConn) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	var distributed bool
+	err := db.QueryRow(queryCtx,
+		`SELECT EXISTS (SELECT 1 FROM pg_dist_partition WHERE logicalrelid = $1::regclass)`,
+		m.stateTableName,
+	).Scan(&distributed)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to check if the state table is distributed, check that the Citus extension is installed: %w", err)
+	}
+	if distributed {
+		return nil
+	}
+
+	table, _, err := m.tableSchemaName(m.stateTableName)
+	if err != nil {
+		return err
+	}
+
+	m.logger.Infof("Distributing state table '%s' with Citus", m.stateTableName)
+	// The statements are not in a transaction, as Citus distributes the table with its own transactions; each of them can be repeated if a later one fails
+	statements := []string{
+		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS partitionkey text`, m.stateTableName),
+		fmt.Sprintf(`UPDATE %s SET partitionkey = key WHERE partitionkey IS NULL`, m.stateTableName),
+		fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN partitionkey SET NOT NULL`, m.stateTableName),
+		// The primary key of distributed tables must include the distribution column
+		fmt.Sprintf(`ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s_pkey, ADD PRIMARY KEY (partitionkey, key)`, m.stateTableName, table),
+	}
+	for _, stmt := range statements {
+		// Long timeout here as existing rows are updated
+		queryCtx, cancel = context.WithTimeout(ctx, 10*time.Minute)
+		_, err = db.Exec(queryCtx, stmt)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to update state table: %w", err)
+		}
+	}
+
+	// Long timeout here as existing rows are moved to the shards
+	queryCtx, cancel = context.WithTimeout(ctx, 10*time.Minute)
+	_, err = db.Exec(queryCtx, `SELECT create_distributed_table($1, 'partitionkey')`, m.stateTableName)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to distribute state table: %w", err)
+	}
 	return nil
 }
 
@@ -193,7 +251,7 @@ func (m migrations) tableSchemaName(tableName string) (table string, schema stri
 	}
 }
 
-var allMigrations = [2]func(ctx context.Context, db postgresql.PGXPoolConn, m *migrations) error{
+var allMigrations = [3]func(ctx context.Context, db postgresql.PGXPoolConn, m *migrations) error{
 	// Migration 0: create the state table
 	func(ctx context.Context, db postgresql.PGXPoolConn, m *migrations) error {
 		// We need to add an "IF NOT EXISTS" because we may be migrating from when we did not use a metadata table
@@ -229,4 +287,21 @@ var allMigrations = [2]func(ctx context.Context, db postgresql.PGXPoolConn, m *m
 		}
 		return nil
 	},
+
+	// Migration 2: add an index on the "expiredate" column, used by the garbage collector
+	func(ctx context.Context, db postgresql.PGXPoolConn, m *migrations) error {
+		table, _, err := m.tableSchemaName(m.stateTableName)
+		if err != nil {
+			return err
+		}
+		m.logger.Infof("Adding index on expiredate column to state table '%s'", m.stateTableName)
+		_, err = db.Exec(ctx, fmt.Sprintf(
+			`CREATE INDEX IF NOT EXISTS %s_expiredate_idx ON %s (expiredate) WHERE expiredate IS NOT NULL`,
+			table, m.stateTableName,
+		))
+		if err != nil {
+			return fmt.Errorf("failed to create index on state table: %w", err)
+		}
+		return nil
+	},
 }
@@ -22,9 +22,24 @@ import (
 // NewPostgreSQLStateStore creates a new instance of PostgreSQL state store.
 func NewPostgreSQLStateStore(logger logger.Logger) state.Store {
 	return postgresql.NewPostgreSQLStateStore(logger, postgresql.Options{
-		ETagColumn: "xmin",
-		MigrateFn:  performMigration,
+		ETagColumn:    "xmin",
+		MigrateFn:     performMigration,
+		SupportsCitus: true,
 		SetQueryFn: func(req *state.SetRequest, opts postgresql.SetQueryOptions) string {
+			// In Citus mode, rows are identified by both the partition key and the key, and queries are routed with the partition key
+			var (
+				partitionKeyColumn string
+				partitionKeyValue  string
+				partitionKeyWhere  string
+				conflictColumns    = "key"
+			)
+			if opts.PartitionKeyParam != "" {
+				partitionKeyColumn = ", partitionkey"
+				partitionKeyValue = ", " + opts.PartitionKeyParam
+				partitionKeyWhere = " AND partitionkey = " + opts.PartitionKeyParam
+				conflictColumns = "partitionkey, key"
+			}
+
 			// Sprintf is required for table name because the driver does not substitute parameters for table names.
 			if !req.HasETag() {
 				// We do an upsert in both cases, even when concurrency is first-write, because the row may exist but be expired (and not yet garbage collected)
@@ -35,10 +50,10 @@ func NewPostgreSQLStateStore(logger logger.Logger) state.Store {
 				}
 
 				return `INSERT INTO ` + opts.TableName + ` AS t
-					(key, value, isbinary, expiredate)
+					(key, value, isbinary, expiredate` + partitionKeyColumn + `)
 				VALUES
-					($1, $2, $3, ` + opts.ExpireDateValue + `)
-				ON CONFLICT (key)
+					($1, $2, $3, ` + opts.ExpireDateValue + partitionKeyValue + `)
+				ON CONFLICT (` + conflictColumns + `)
 				DO UPDATE SET
 					value = excluded.value,
 					isbinary = excluded.isBinary,
@@ -56,7 +71,8 @@ func NewPostgreSQLStateStore(logger logger.Logger) state.Store {
 			WHERE
 				key = $1
 				AND xmin = $4
-				AND (expiredate IS NULL OR expiredate > CURRENT_TIMESTAMP)`
+				AND (expiredate IS NULL OR expiredate > CURRENT_TIMESTAMP)` +
+				partitionKeyWhere
 		},
 	})
 }
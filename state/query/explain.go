@@ -0,0 +1,62 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package query
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Keys of the explanation in the metadata of a query response.
+const (
+	ExplainBackendKey    = "explain.backend"
+	ExplainQueryKey      = "explain.query"
+	ExplainParametersKey = "explain.parameters"
+	ExplainIndexHintsKey = "explain.indexHints"
+)
+
+// Explanation describes the native query that a state store built from a query request.
+type Explanation struct {
+	// Name of the backend, such as "postgresql" or "mongodb".
+	Backend string
+	// Native query, in the syntax of the backend.
+	Query string
+	// Values of the parameters bound to the native query, in order.
+	Parameters []any
+	// Indexes that the query is bound to or that were given as hints.
+	IndexHints []string
+}
+
+// Explainer is implemented by the query visitors that can describe the native query they built.
+type Explainer interface {
+	Explain() Explanation
+}
+
+// Metadata returns the explanation as metadata of a query response.
+func (e Explanation) Metadata() map[string]string {
+	md := map[string]string{
+		ExplainBackendKey: e.Backend,
+		ExplainQueryKey:   e.Query,
+	}
+	if len(e.Parameters) > 0 {
+		params, err := json.Marshal(e.Parameters)
+		if err == nil {
+			md[ExplainParametersKey] = string(params)
+		}
+	}
+	if len(e.IndexHints) > 0 {
+		md[ExplainIndexHintsKey] = strings.Join(e.IndexHints, ",")
+	}
+	return md
+}
@@ -21,6 +21,7 @@ import (
 	"strconv"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	jsoniter "github.com/json-iterator/go"
 
@@ -82,12 +83,82 @@ const (
 	else
 	  return error("failed to delete " .. KEYS[1])
 	end`
+	// multiQuery executes the set and delete operations of a transaction atomically.
+	// Each key has 6 arguments: the operation ("set" or "del"), whether the value is JSON ("1" or "0"),
+	// the etag, the value, the first-write flag and the TTL (empty to leave it unchanged).
+	// All the concurrency checks are performed before any write, so either all operations are applied or none is.
+	multiQuery = `
+	local etags, stale = {}, {};
+	for i = 1, #KEYS do
+	  local a = (i - 1) * 6;
+	  local op, isJSON, ver = ARGV[a + 1], ARGV[a + 2], ARGV[a + 3];
+	  local etag, fwr;
+	  if isJSON == "1" then
+	    etag = redis.pcall("JSON.GET", KEYS[i], ".version");
+	    fwr = redis.pcall("JSON.GET", KEYS[i], ".first-write");
+	  else
+	    etag = redis.pcall("HGET", KEYS[i], "version");
+	    fwr = redis.pcall("HGET", KEYS[i], "first-write");
+	  end;
+	  local missing = not etag or type(etag) == "table" or etag == "";
+	  if op == "del" then
+	    if not (missing or etag == ver or ver == "0") then
+	      return error("failed to delete " .. KEYS[i])
+	    end;
+	  elseif isJSON == "1" then
+	    if not (missing or etag == ver or ((not fwr or type(fwr) == "table") and ver == "0")) then
+	      return error("failed to set key " .. KEYS[i])
+	    end;
+	  else
+	    if not (missing or etag == ver or (not fwr and ver == "0")) then
+	      return error("failed to set key " .. KEYS[i])
+	    end;
+	  end;
+	  if missing then
+	    etags[i] = ver;
+	  else
+	    etags[i] = etag;
+	  end;
+	  stale[i] = type(etag) == "table";
+	end;
+	for i = 1, #KEYS do
+	  local a = (i - 1) * 6;
+	  local op, isJSON, val, fw, ttl = ARGV[a + 1], ARGV[a + 2], ARGV[a + 4], ARGV[a + 5], ARGV[a + 6];
+	  if op == "del" or stale[i] then
+	    redis.call("DEL", KEYS[i]);
+	  end;
+	  if op == "set" then
+	    if isJSON == "1" then
+	      redis.call("JSON.SET", KEYS[i], "$", val);
+	      if fw == "0" then
+	        redis.call("JSON.SET", KEYS[i], ".first-write", 0);
+	      end;
+	      redis.call("JSON.SET", KEYS[i], ".version", (etags[i] + 1));
+	    else
+	      redis.call("HSET", KEYS[i], "data", val);
+	      if fw == "0" then
+	        redis.call("HSET", KEYS[i], "first-write", 0);
+	      end;
+	      redis.call("HINCRBY", KEYS[i], "version", 1);
+	    end;
+	    if ttl ~= "" then
+	      if tonumber(ttl) > 0 then
+	        redis.call("EXPIRE", KEYS[i], ttl);
+	      else
+	        redis.call("PERSIST", KEYS[i]);
+	      end;
+	    end;
+	  end;
+	end;
+	return #KEYS`
 	connectedSlavesReplicas  = "connected_slaves:"
 	infoReplicationDelimiter = "\r\n"
 	ttlInSeconds             = "ttlInSeconds"
 	defaultBase              = 10
 	defaultBitSize           = 0
 	defaultDB                = 0
+	defaultWaitTimeout       = time.Second
+	keyDelimiter             = "||"
 )
 
 // StateStore is a Redis state store.
@@ -145,6 +216,13 @@ func (r *StateStore) Init(ctx context.Context, metadata state.Metadata) error {
 		return fmt.Errorf("redis store: error connecting to redis at %s: %w", r.clientSettings.Host, err)
 	}
 
+	if r.clientSettings.MaxBatchSize < 0 {
+		return errors.New("redis store: maxBatchSize must not be negative")
+	}
+	if r.clientSettings.WaitReplicas < 0 {
+		return errors.New("redis store: waitReplicas must not be negative")
+	}
+
 	if r.replicas, err = r.getConnectedSlaves(ctx); err != nil {
 		return err
 	}
@@ -161,9 +239,9 @@ func (r *StateStore) Init(ctx context.Context, metadata state.Metadata) error {
 // Features returns the features available in this state store.
 func (r *StateStore) Features() []state.Feature {
 	if r.clientHasJSON {
-		return []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI}
+		return []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureQueryAPI, state.FeatureTTL, state.FeatureBulkNative}
 	} else {
-		return []state.Feature{state.FeatureETag, state.FeatureTransactional}
+		return []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureTTL, state.FeatureBulkNative}
 	}
 }
 
@@ -208,19 +286,19 @@ func (r *StateStore) Delete(ctx context.Context, req *state.DeleteRequest) error
 	}
 
 	if req.Metadata[daprmetadata.ContentType] == contenttype.JSONContentType && r.clientHasJSON {
-		err = r.client.DoWrite(ctx, "EVAL", delJSONQuery, 1, req.Key, *req.ETag)
+		err = r.client.DoWrite(ctx, "EVAL", delJSONQuery, 1, r.redisKey(req.Key), *req.ETag)
 	} else {
-		err = r.client.DoWrite(ctx, "EVAL", delDefaultQuery, 1, req.Key, *req.ETag)
+		err = r.client.DoWrite(ctx, "EVAL", delDefaultQuery, 1, r.redisKey(req.Key), *req.ETag)
 	}
 	if err != nil {
 		return state.NewETagError(state.ETagMismatch, err)
 	}
 
-	return nil
+	return r.waitForReplicas(ctx)
 }
 
 func (r *StateStore) directGet(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
-	res, err := r.client.DoRead(ctx, "GET", req.Key)
+	res, err := r.client.DoRead(ctx, "GET", r.redisKey(req.Key))
 	if err != nil {
 		return nil, err
 	}
@@ -237,10 +315,15 @@ func (r *StateStore) directGet(ctx context.Context, req *state.GetRequest) (*sta
 }
 
 func (r *StateStore) getDefault(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
-	res, err := r.client.DoRead(ctx, "HGETALL", req.Key) // Prefer values with ETags
+	res, err := r.client.DoRead(ctx, "HGETALL", r.redisKey(req.Key)) // Prefer values with ETags
 	if err != nil {
 		return r.directGet(ctx, req) // Falls back to original get for backward compats.
 	}
+	return r.parseDefaultResult(res)
+}
+
+// parseDefaultResult parses the result of HGETALL for a key.
+func (r *StateStore) parseDefaultResult(res any) (*state.GetResponse, error) {
 	if res == nil {
 		return &state.GetResponse{}, nil
 	}
@@ -270,11 +353,15 @@ func (r *StateStore) getDefault(ctx context.Context, req *state.GetRequest) (*st
 }
 
 func (r *StateStore) getJSON(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
-	res, err := r.client.DoRead(ctx, "JSON.GET", req.Key)
+	res, err := r.client.DoRead(ctx, "JSON.GET", r.redisKey(req.Key))
 	if err != nil {
 		return nil, err
 	}
+	return r.parseJSONResult(res)
+}
 
+// parseJSONResult parses the result of JSON.GET for a key.
+func (r *StateStore) parseJSONResult(res any) (*state.GetResponse, error) {
 	if res == nil {
 		return &state.GetResponse{}, nil
 	}
@@ -285,7 +372,7 @@ func (r *StateStore) getJSON(ctx context.Context, req *state.GetRequest) (*state
 	}
 
 	var entry jsonEntry
-	if err = r.json.UnmarshalFromString(str, &entry); err != nil {
+	if err := r.json.UnmarshalFromString(str, &entry); err != nil {
 		return nil, err
 	}
 
@@ -308,7 +395,7 @@ func (r *StateStore) getJSON(ctx context.Context, req *state.GetRequest) (*state
 
 // Get retrieves state from redis with a key.
 func (r *StateStore) Get(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
-	if req.Metadata[daprmetadata.ContentType] == contenttype.JSONContentType && r.clientHasJSON {
+	if r.isJSONRequest(req.Metadata, false) {
 		return r.getJSON(ctx, req)
 	}
 
@@ -344,12 +431,13 @@ func (r *StateStore) Set(ctx context.Context, req *state.SetRequest) error {
 		firstWrite = 0
 	}
 
+	key := r.redisKey(req.Key)
 	if req.Metadata[daprmetadata.ContentType] == contenttype.JSONContentType && r.clientHasJSON {
 		bt, _ := utils.Marshal(&jsonEntry{Data: req.Value}, r.json.Marshal)
-		err = r.client.DoWrite(ctx, "EVAL", setJSONQuery, 1, req.Key, ver, bt, firstWrite)
+		err = r.client.DoWrite(ctx, "EVAL", setJSONQuery, 1, key, ver, bt, firstWrite)
 	} else {
 		bt, _ := utils.Marshal(req.Value, r.json.Marshal)
-		err = r.client.DoWrite(ctx, "EVAL", setDefaultQuery, 1, req.Key, ver, bt, firstWrite)
+		err = r.client.DoWrite(ctx, "EVAL", setDefaultQuery, 1, key, ver, bt, firstWrite)
 	}
 
 	if err != nil {
@@ -361,19 +449,23 @@ func (r *StateStore) Set(ctx context.Context, req *state.SetRequest) error {
 	}
 
 	if ttl != nil && *ttl > 0 {
-		err = r.client.DoWrite(ctx, "EXPIRE", req.Key, *ttl)
+		err = r.client.DoWrite(ctx, "EXPIRE", key, *ttl)
 		if err != nil {
 			return fmt.Errorf("failed to set key %s ttl: %w", req.Key, err)
 		}
 	}
 
 	if ttl != nil && *ttl <= 0 {
-		err = r.client.DoWrite(ctx, "PERSIST", req.Key)
+		err = r.client.DoWrite(ctx, "PERSIST", key)
 		if err != nil {
 			return fmt.Errorf("failed to persist key %s: %w", req.Key, err)
 		}
 	}
 
+	if r.clientSettings.WaitReplicas > 0 {
+		return r.waitForReplicas(ctx)
+	}
+
 	if req.Options.Consistency == state.Strong && r.replicas > 0 {
 		err = r.client.DoWrite(ctx, "WAIT", r.replicas, 1000)
 		if err != nil {
@@ -385,64 +477,212 @@ func (r *StateStore) Set(ctx context.Context, req *state.SetRequest) error {
 }
 
 // Multi performs a transactional operation. succeeds only if all operations succeed, and fails if one or more operations fail.
+// The operations are executed atomically by a single Lua script, so all the keys of a transaction must be in the same slot
+// when using Redis Cluster: this is guaranteed by enabling hashTagKeys.
+// When maxBatchSize is set, transactions with more operations are rejected.
 func (r *StateStore) Multi(ctx context.Context, request *state.TransactionalStateRequest) error {
 	if r.suppressActorStateStoreWarning.CompareAndSwap(false, true) {
 		r.logger.Warn("Redis does not support transaction rollbacks and should not be used in production as an actor state store.")
 	}
 
+	ops := request.Operations
+	if maxOps := r.clientSettings.MaxBatchSize; maxOps > 0 && len(ops) > maxOps {
+		return fmt.Errorf("redis store: transaction has %d operations, more than the maximum of %d set by maxBatchSize", len(ops), maxOps)
+	}
+
 	// Check if the entire transaction is using JSON based on the transactional request's metadata
 	isJSON := request.Metadata[daprmetadata.ContentType] == contenttype.JSONContentType && r.clientHasJSON
 
-	pipe := r.client.TxPipeline()
-	for _, o := range request.Operations {
+	keys := make([]any, 0, len(ops))
+	args := make([]any, 0, 6*len(ops))
+	for _, o := range ops {
 		switch req := o.(type) {
 		case state.SetRequest:
-			ver, err := r.parseETag(&req)
+			opArgs, err := r.multiSetArgs(&req, isJSON)
 			if err != nil {
 				return err
 			}
-			ttl, err := r.parseTTL(&req)
-			if err != nil {
-				return fmt.Errorf("failed to parse ttl from metadata: %w", err)
-			}
-			// apply global TTL
-			if ttl == nil {
-				ttl = r.clientSettings.TTLInSeconds
-			}
-			var bt []byte
-			isReqJSON := isJSON ||
-				(len(req.Metadata) > 0 && req.Metadata[daprmetadata.ContentType] == contenttype.JSONContentType)
-			if isReqJSON {
-				bt, _ = utils.Marshal(&jsonEntry{Data: req.Value}, r.json.Marshal)
-				pipe.Do(ctx, "EVAL", setJSONQuery, 1, req.Key, ver, bt)
-			} else {
-				bt, _ = utils.Marshal(req.Value, r.json.Marshal)
-				pipe.Do(ctx, "EVAL", setDefaultQuery, 1, req.Key, ver, bt)
-			}
-			if ttl != nil && *ttl > 0 {
-				pipe.Do(ctx, "EXPIRE", req.Key, *ttl)
-			}
-			if ttl != nil && *ttl <= 0 {
-				pipe.Do(ctx, "PERSIST", req.Key)
-			}
-
+			keys = append(keys, r.redisKey(req.Key))
+			args = append(args, opArgs...)
 		case state.DeleteRequest:
-			if !req.HasETag() {
-				req.ETag = ptr.Of("0")
-			}
-			isReqJSON := isJSON ||
-				(len(req.Metadata) > 0 && req.Metadata[daprmetadata.ContentType] == contenttype.JSONContentType)
-			if isReqJSON {
-				pipe.Do(ctx, "EVAL", delJSONQuery, 1, req.Key, *req.ETag)
-			} else {
-				pipe.Do(ctx, "EVAL", delDefaultQuery, 1, req.Key, *req.ETag)
-			}
+			keys = append(keys, r.redisKey(req.Key))
+			args = append(args, r.multiDeleteArgs(&req, isJSON)...)
 		}
 	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	cmd := make([]any, 0, 3+len(keys)+len(args))
+	cmd = append(cmd, "EVAL", multiQuery, len(keys))
+	cmd = append(cmd, keys...)
+	cmd = append(cmd, args...)
+	if err := r.client.DoWrite(ctx, cmd...); err != nil {
+		return err
+	}
+
+	return r.waitForReplicas(ctx)
+}
+
+// multiSetArgs returns the arguments of multiQuery for a set request.
+func (r *StateStore) multiSetArgs(req *state.SetRequest, isJSON bool) ([]any, error) {
+	ver, err := r.parseETag(req)
+	if err != nil {
+		return nil, err
+	}
+	ttl, err := r.parseTTL(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ttl from metadata: %w", err)
+	}
+	// apply global TTL
+	if ttl == nil {
+		ttl = r.clientSettings.TTLInSeconds
+	}
+
+	firstWrite := 1
+	if req.Options.Concurrency == state.FirstWrite {
+		firstWrite = 0
+	}
+
+	ttlArg := ""
+	if ttl != nil {
+		ttlArg = strconv.Itoa(*ttl)
+	}
+
+	if r.isJSONRequest(req.Metadata, isJSON) {
+		bt, _ := utils.Marshal(&jsonEntry{Data: req.Value}, r.json.Marshal)
+		return []any{"set", "1", ver, bt, firstWrite, ttlArg}, nil
+	}
+	bt, _ := utils.Marshal(req.Value, r.json.Marshal)
+	return []any{"set", "0", ver, bt, firstWrite, ttlArg}, nil
+}
+
+// multiDeleteArgs returns the arguments of multiQuery for a delete request.
+func (r *StateStore) multiDeleteArgs(req *state.DeleteRequest, isJSON bool) []any {
+	etag := "0"
+	if req.HasETag() {
+		etag = *req.ETag
+	}
+	jsonArg := "0"
+	if r.isJSONRequest(req.Metadata, isJSON) {
+		jsonArg = "1"
+	}
+	return []any{"del", jsonArg, etag, "", "", ""}
+}
+
+// isJSONRequest returns true if the value of a request is stored as JSON.
+func (r *StateStore) isJSONRequest(reqMetadata map[string]string, isJSON bool) bool {
+	return isJSON ||
+		(len(reqMetadata) > 0 && reqMetadata[daprmetadata.ContentType] == contenttype.JSONContentType && r.clientHasJSON)
+}
+
+// redisKey returns the name of the Redis key storing a state key.
+// When hashTagKeys is enabled, the prefix of the key (the part before the first "||", usually the app ID) is wrapped in a hash tag,
+// so that all the keys sharing a prefix are stored in the same cluster slot.
+func (r *StateStore) redisKey(key string) string {
+	if r.clientSettings == nil || !r.clientSettings.HashTagKeys {
+		return key
+	}
+	prefix, rest, ok := strings.Cut(key, keyDelimiter)
+	if !ok || prefix == "" {
+		return key
+	}
+	return "{" + prefix + "}" + keyDelimiter + rest
+}
+
+// stateKey is the inverse of redisKey.
+func (r *StateStore) stateKey(key string) string {
+	if r.clientSettings == nil || !r.clientSettings.HashTagKeys || !strings.HasPrefix(key, "{") {
+		return key
+	}
+	prefix, rest, ok := strings.Cut(key[1:], "}"+keyDelimiter)
+	if !ok {
+		return key
+	}
+	return prefix + keyDelimiter + rest
+}
+
+// queueSet queues the commands of a set request on a pipeline, and returns the number of commands queued.
+func (r *StateStore) queueSet(ctx context.Context, pipe rediscomponent.RedisPipeliner, req *state.SetRequest, isJSON bool) (int, error) {
+	ver, err := r.parseETag(req)
+	if err != nil {
+		return 0, err
+	}
+	ttl, err := r.parseTTL(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse ttl from metadata: %w", err)
+	}
+	// apply global TTL
+	if ttl == nil {
+		ttl = r.clientSettings.TTLInSeconds
+	}
+
+	firstWrite := 1
+	if req.Options.Concurrency == state.FirstWrite {
+		firstWrite = 0
+	}
 
-	err := pipe.Exec(ctx)
+	var bt []byte
+	key := r.redisKey(req.Key)
+	if r.isJSONRequest(req.Metadata, isJSON) {
+		bt, _ = utils.Marshal(&jsonEntry{Data: req.Value}, r.json.Marshal)
+		pipe.Do(ctx, "EVAL", setJSONQuery, 1, key, ver, bt, firstWrite)
+	} else {
+		bt, _ = utils.Marshal(req.Value, r.json.Marshal)
+		pipe.Do(ctx, "EVAL", setDefaultQuery, 1, key, ver, bt, firstWrite)
+	}
+	if ttl != nil && *ttl > 0 {
+		pipe.Do(ctx, "EXPIRE", key, *ttl)
+		return 2, nil
+	}
+	if ttl != nil && *ttl <= 0 {
+		pipe.Do(ctx, "PERSIST", key)
+		return 2, nil
+	}
+	return 1, nil
+}
 
-	return err
+// queueDelete queues the command of a delete request on a pipeline.
+func (r *StateStore) queueDelete(ctx context.Context, pipe rediscomponent.RedisPipeliner, req *state.DeleteRequest, isJSON bool) {
+	if !req.HasETag() {
+		req.ETag = ptr.Of("0")
+	}
+	if r.isJSONRequest(req.Metadata, isJSON) {
+		pipe.Do(ctx, "EVAL", delJSONQuery, 1, r.redisKey(req.Key), *req.ETag)
+	} else {
+		pipe.Do(ctx, "EVAL", delDefaultQuery, 1, r.redisKey(req.Key), *req.ETag)
+	}
+}
+
+// batchSize returns the number of operations to send in each pipeline.
+func (r *StateStore) batchSize(n int) int {
+	if r.clientSettings.MaxBatchSize > 0 {
+		return r.clientSettings.MaxBatchSize
+	}
+	return n
+}
+
+// waitForReplicas blocks until the number of replicas configured with waitReplicas acknowledged the previous writes.
+func (r *StateStore) waitForReplicas(ctx context.Context) error {
+	replicas := r.clientSettings.WaitReplicas
+	if replicas <= 0 {
+		return nil
+	}
+	timeout := time.Duration(r.clientSettings.WaitTimeout)
+	if timeout <= 0 {
+		timeout = defaultWaitTimeout
+	}
+
+	res, err := r.client.DoRead(ctx, "WAIT", replicas, timeout.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("redis waiting for %v replicas to acknowledge write, err: %w", replicas, err)
+	}
+	acked, _ := res.(int64)
+	if acked < int64(replicas) {
+		return fmt.Errorf("redis waiting for %v replicas to acknowledge write: only %v acknowledged within %v", replicas, acked, timeout)
+	}
+
+	return nil
 }
 
 func (r *StateStore) registerSchemas(ctx context.Context) error {
@@ -532,10 +772,19 @@ func (r *StateStore) Query(ctx context.Context, req *state.QueryRequest) (*state
 	if err := qbuilder.BuildQuery(&req.Query); err != nil {
 		return &state.QueryResponse{}, err
 	}
+	if daprmetadata.IsQueryExplain(req.Metadata) {
+		return &state.QueryResponse{
+			Results:  []state.QueryItem{},
+			Metadata: q.Explain().Metadata(),
+		}, nil
+	}
 	data, token, err := q.execute(ctx, r.client)
 	if err != nil {
 		return &state.QueryResponse{}, err
 	}
+	for i := range data {
+		data[i].Key = r.stateKey(data[i].Key)
+	}
 
 	return &state.QueryResponse{
 		Results: data,
@@ -0,0 +1,165 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
+	"github.com/dapr/components-contrib/state"
+)
+
+// BulkGet retrieves the states of multiple keys, sending the reads in pipelines of at most maxBatchSize commands.
+func (r *StateStore) BulkGet(ctx context.Context, req []state.GetRequest, _ state.BulkGetOpts) ([]state.BulkGetResponse, error) {
+	res := make([]state.BulkGetResponse, len(req))
+	batchSize := r.batchSize(len(req))
+	for start := 0; start < len(req); start += batchSize {
+		end := start + batchSize
+		if end > len(req) {
+			end = len(req)
+		}
+
+		pipe := r.client.Pipeline()
+		for i := start; i < end; i++ {
+			if r.isJSONRequest(req[i].Metadata, false) {
+				pipe.Do(ctx, "JSON.GET", r.redisKey(req[i].Key))
+			} else {
+				pipe.Do(ctx, "HGETALL", r.redisKey(req[i].Key))
+			}
+		}
+		vals, errs := pipe.ExecResults(ctx)
+		if len(vals) != end-start {
+			return nil, fmt.Errorf("redis store: expected %d results from pipeline, got %d", end-start, len(vals))
+		}
+
+		for i := start; i < end; i++ {
+			var (
+				getRes *state.GetResponse
+				err    = errs[i-start]
+			)
+			switch {
+			case r.isJSONRequest(req[i].Metadata, false):
+				if err == nil {
+					getRes, err = r.parseJSONResult(vals[i-start])
+				}
+			case err != nil:
+				// Falls back to original get for backward compats, like Get does
+				getRes, err = r.directGet(ctx, &req[i])
+			default:
+				getRes, err = r.parseDefaultResult(vals[i-start])
+			}
+
+			res[i].Key = req[i].Key
+			if err != nil {
+				res[i].Error = err.Error()
+				continue
+			}
+			res[i].Data = getRes.Data
+			res[i].ETag = getRes.ETag
+			res[i].Metadata = getRes.Metadata
+		}
+	}
+
+	return res, nil
+}
+
+// BulkSet saves multiple states, sending them in pipelines of at most maxBatchSize operations.
+// Unlike Multi, the operations are not executed in a transaction, and each of them can fail independently.
+func (r *StateStore) BulkSet(ctx context.Context, req []state.SetRequest, _ state.BulkStoreOpts) error {
+	return r.bulkExec(ctx, len(req), func(pipe rediscomponent.RedisPipeliner, i int) (int, error) {
+		if err := state.CheckRequestOptions(req[i].Options); err != nil {
+			return 0, err
+		}
+		return r.queueSet(ctx, pipe, &req[i], false)
+	}, func(i int, err error) error {
+		if req[i].HasETag() {
+			return state.NewETagError(state.ETagMismatch, err)
+		}
+		return fmt.Errorf("failed to set key %s: %w", req[i].Key, err)
+	}, func(i int) string {
+		return req[i].Key
+	})
+}
+
+// BulkDelete deletes multiple states, sending them in pipelines of at most maxBatchSize operations.
+// Unlike Multi, the operations are not executed in a transaction, and each of them can fail independently.
+func (r *StateStore) BulkDelete(ctx context.Context, req []state.DeleteRequest, _ state.BulkStoreOpts) error {
+	return r.bulkExec(ctx, len(req), func(pipe rediscomponent.RedisPipeliner, i int) (int, error) {
+		if err := state.CheckRequestOptions(req[i].Options); err != nil {
+			return 0, err
+		}
+		r.queueDelete(ctx, pipe, &req[i], false)
+		return 1, nil
+	}, func(i int, err error) error {
+		return state.NewETagError(state.ETagMismatch, err)
+	}, func(i int) string {
+		return req[i].Key
+	})
+}
+
+// bulkExec queues n operations in non-transactional pipelines and returns a state.BulkStoreError for each operation that failed.
+// queue queues the commands of the i-th operation and returns how many were queued; wrapErr wraps the error of a failed command.
+func (r *StateStore) bulkExec(ctx context.Context, n int,
+	queue func(pipe rediscomponent.RedisPipeliner, i int) (int, error),
+	wrapErr func(i int, err error) error,
+	key func(i int) string,
+) error {
+	errs := []error{}
+	batchSize := r.batchSize(n)
+	for start := 0; start < n; start += batchSize {
+		end := start + batchSize
+		if end > n {
+			end = n
+		}
+
+		pipe := r.client.Pipeline()
+		counts := make([]int, end-start)
+		queued := 0
+		for i := start; i < end; i++ {
+			count, err := queue(pipe, i)
+			if err != nil {
+				errs = append(errs, state.NewBulkStoreError(key(i), err))
+				continue
+			}
+			counts[i-start] = count
+			queued += count
+		}
+		if queued == 0 {
+			continue
+		}
+
+		cmdErrs := pipe.ExecErrors(ctx)
+		if len(cmdErrs) != queued {
+			return errors.Join(append(errs, fmt.Errorf("redis store: expected %d results from pipeline, got %d", queued, len(cmdErrs)))...)
+		}
+		pos := 0
+		for i := start; i < end; i++ {
+			for _, err := range cmdErrs[pos : pos+counts[i-start]] {
+				if err != nil {
+					errs = append(errs, state.NewBulkStoreError(key(i), wrapErr(i, err)))
+					break
+				}
+			}
+			pos += counts[i-start]
+		}
+	}
+
+	if err := r.waitForReplicas(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+func TestBulkSetDelete(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	ss := &StateStore{
+		client:         c,
+		clientSettings: &rediscomponent.Settings{MaxBatchSize: 2},
+		json:           jsoniter.ConfigFastest,
+		logger:         logger.NewLogger("test"),
+	}
+
+	t.Run("set in batches", func(t *testing.T) {
+		err := ss.BulkSet(context.Background(), []state.SetRequest{
+			{Key: "k1", Value: "v1"},
+			{Key: "k2", Value: "v2", Metadata: map[string]string{"ttlInSeconds": "100"}},
+			{Key: "k3", Value: "v3"},
+		}, state.BulkStoreOpts{})
+		require.NoError(t, err)
+
+		for _, key := range []string{"k1", "k2", "k3"} {
+			res, err := c.DoRead(context.Background(), "HGETALL", key)
+			require.NoError(t, err)
+			_, version, err := ss.getKeyVersion(res.([]interface{}))
+			require.NoError(t, err)
+			assert.Equal(t, ptr.Of("1"), version)
+		}
+		res, err := c.DoRead(context.Background(), "TTL", "k2")
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), res)
+	})
+
+	t.Run("etag mismatch fails only that key", func(t *testing.T) {
+		err := ss.BulkSet(context.Background(), []state.SetRequest{
+			{Key: "k1", Value: "v1", ETag: ptr.Of("1")},
+			{Key: "k2", Value: "v2", ETag: ptr.Of("42")},
+			{Key: "k3", Value: "v3"},
+		}, state.BulkStoreOpts{})
+		require.Error(t, err)
+
+		var bulkErr state.BulkStoreError
+		require.True(t, errors.As(err, &bulkErr))
+		assert.Equal(t, "k2", bulkErr.Key())
+		require.NotNil(t, bulkErr.ETagError())
+		assert.Equal(t, state.ETagMismatch, bulkErr.ETagError().Kind())
+
+		res, err := c.DoRead(context.Background(), "HGET", "k1", "version")
+		require.NoError(t, err)
+		assert.Equal(t, "2", res)
+	})
+
+	t.Run("delete in batches", func(t *testing.T) {
+		err := ss.BulkDelete(context.Background(), []state.DeleteRequest{
+			{Key: "k1"},
+			{Key: "k2"},
+			{Key: "k3", ETag: ptr.Of("42")},
+		}, state.BulkStoreOpts{})
+		require.Error(t, err)
+
+		var bulkErr state.BulkStoreError
+		require.True(t, errors.As(err, &bulkErr))
+		assert.Equal(t, "k3", bulkErr.Key())
+
+		for key, exists := range map[string]int64{"k1": 0, "k2": 0, "k3": 1} {
+			res, err := c.DoRead(context.Background(), "EXISTS", key)
+			require.NoError(t, err)
+			assert.Equal(t, exists, res, key)
+		}
+	})
+}
+
+func TestBulkGet(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	ss := &StateStore{
+		client:         c,
+		clientSettings: &rediscomponent.Settings{MaxBatchSize: 2},
+		json:           jsoniter.ConfigFastest,
+		logger:         logger.NewLogger("test"),
+	}
+
+	err := ss.BulkSet(context.Background(), []state.SetRequest{
+		{Key: "k1", Value: "v1"},
+		{Key: "k2", Value: "v2"},
+	}, state.BulkStoreOpts{})
+	require.NoError(t, err)
+	// Values stored as plain strings by older versions are still returned
+	require.NoError(t, c.DoWrite(context.Background(), "SET", "k3", "v3"))
+
+	res, err := ss.BulkGet(context.Background(), []state.GetRequest{
+		{Key: "k1"},
+		{Key: "k2"},
+		{Key: "k3"},
+		{Key: "missing"},
+	}, state.BulkGetOpts{})
+	require.NoError(t, err)
+	require.Len(t, res, 4)
+
+	assert.Equal(t, "k1", res[0].Key)
+	assert.Equal(t, `"v1"`, string(res[0].Data))
+	assert.Equal(t, ptr.Of("1"), res[0].ETag)
+	assert.Equal(t, "k2", res[1].Key)
+	assert.Equal(t, `"v2"`, string(res[1].Data))
+	assert.Equal(t, "k3", res[2].Key)
+	assert.Equal(t, "v3", string(res[2].Data))
+	assert.Nil(t, res[2].ETag)
+	assert.Equal(t, "missing", res[3].Key)
+	assert.Empty(t, res[3].Data)
+	for _, r := range res {
+		assert.Empty(t, r.Error)
+	}
+}
+
+func TestTransactionalMaxBatchSize(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	ss := &StateStore{
+		client:         c,
+		clientSettings: &rediscomponent.Settings{MaxBatchSize: 2},
+		json:           jsoniter.ConfigFastest,
+		logger:         logger.NewLogger("test"),
+	}
+
+	t.Run("transactions within the limit are executed", func(t *testing.T) {
+		err := ss.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "weapon", Value: "deathstar"},
+				state.SetRequest{Key: "weapon2", Value: "deathstar2"},
+			},
+		})
+		require.NoError(t, err)
+
+		res, err := c.DoRead(context.Background(), "EXISTS", "weapon", "weapon2")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), res)
+	})
+
+	t.Run("larger transactions are rejected", func(t *testing.T) {
+		err := ss.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "weapon3", Value: "deathstar3"},
+				state.DeleteRequest{Key: "weapon"},
+				state.DeleteRequest{Key: "weapon2"},
+			},
+		})
+		require.Error(t, err)
+
+		// No operation is applied
+		res, err := c.DoRead(context.Background(), "EXISTS", "weapon", "weapon2", "weapon3")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), res)
+	})
+}
@@ -189,9 +189,27 @@ func (q *Query) Finalize(filters string, qq *query.Query) error {
 	return nil
 }
 
+// command returns the arguments of the FT.SEARCH command for the query.
+func (q *Query) command() []interface{} {
+	return append(append([]interface{}{"FT.SEARCH", q.schemaName}, q.query...), "RETURN", "2", "$.data", "$.version")
+}
+
+// Explain returns the FT.SEARCH command and the index it runs on.
+func (q *Query) Explain() query.Explanation {
+	cmd := q.command()
+	args := make([]string, len(cmd))
+	for i, arg := range cmd {
+		args[i] = fmt.Sprintf("%v", arg)
+	}
+	return query.Explanation{
+		Backend:    "redis",
+		Query:      strings.Join(args, " "),
+		IndexHints: []string{q.schemaName},
+	}
+}
+
 func (q *Query) execute(ctx context.Context, client rediscomponent.RedisClient) ([]state.QueryItem, string, error) {
-	query := append(append([]interface{}{"FT.SEARCH", q.schemaName}, q.query...), "RETURN", "2", "$.data", "$.version")
-	ret, err := client.DoRead(ctx, query...)
+	ret, err := client.DoRead(ctx, q.command()...)
 	if err != nil {
 		return nil, "", err
 	}
@@ -23,6 +23,7 @@ import (
 	redis "github.com/go-redis/redis/v8"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	rediscomponent "github.com/dapr/components-contrib/internal/component/redis"
 	"github.com/dapr/components-contrib/state"
@@ -494,6 +495,128 @@ func TestTransactionalDeleteNoEtag(t *testing.T) {
 	assert.Equal(t, 0, len(vals))
 }
 
+func TestTransactionalConcurrency(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	ss := &StateStore{
+		client:         c,
+		clientSettings: &rediscomponent.Settings{},
+		json:           jsoniter.ConfigFastest,
+		logger:         logger.NewLogger("test"),
+	}
+
+	err := ss.Set(context.Background(), &state.SetRequest{
+		Key:   "weapon",
+		Value: "deathstar",
+	})
+	require.NoError(t, err)
+
+	t.Run("etag mismatch aborts the whole transaction", func(t *testing.T) {
+		err := ss.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				state.SetRequest{
+					Key:   "weapon2",
+					Value: "deathstar2",
+				},
+				state.DeleteRequest{
+					Key:  "weapon",
+					ETag: ptr.Of("2"),
+				},
+			},
+		})
+		assert.Error(t, err)
+
+		res, err := c.DoRead(context.Background(), "EXISTS", "weapon2")
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), res)
+		res, err = c.DoRead(context.Background(), "EXISTS", "weapon")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), res)
+	})
+
+	t.Run("matching etags", func(t *testing.T) {
+		err := ss.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				state.SetRequest{
+					Key:     "weapon",
+					Value:   "deathstar4",
+					ETag:    ptr.Of("1"),
+					Options: state.SetStateOption{Concurrency: state.FirstWrite},
+				},
+				state.SetRequest{
+					Key:     "weapon5",
+					Value:   "deathstar5",
+					Options: state.SetStateOption{Concurrency: state.FirstWrite},
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		res, err := ss.Get(context.Background(), &state.GetRequest{Key: "weapon"})
+		require.NoError(t, err)
+		assert.Equal(t, `"deathstar4"`, string(res.Data))
+		assert.Equal(t, ptr.Of("2"), res.ETag)
+
+		res, err = ss.Get(context.Background(), &state.GetRequest{Key: "weapon5"})
+		require.NoError(t, err)
+		assert.Equal(t, `"deathstar5"`, string(res.Data))
+		assert.Equal(t, ptr.Of("1"), res.ETag)
+	})
+}
+
+func TestHashTagKeys(t *testing.T) {
+	s, c := setupMiniredis()
+	defer s.Close()
+
+	ss := &StateStore{
+		client:         c,
+		clientSettings: &rediscomponent.Settings{HashTagKeys: true},
+		json:           jsoniter.ConfigFastest,
+		logger:         logger.NewLogger("test"),
+	}
+
+	t.Run("key names", func(t *testing.T) {
+		assert.Equal(t, "{myapp}||weapon", ss.redisKey("myapp||weapon"))
+		assert.Equal(t, "{myapp}||actor||1||weapon", ss.redisKey("myapp||actor||1||weapon"))
+		assert.Equal(t, "weapon", ss.redisKey("weapon"))
+		assert.Equal(t, "myapp||weapon", ss.stateKey("{myapp}||weapon"))
+		assert.Equal(t, "myapp||actor||1||weapon", ss.stateKey("{myapp}||actor||1||weapon"))
+		assert.Equal(t, "weapon", ss.stateKey("weapon"))
+
+		disabled := &StateStore{clientSettings: &rediscomponent.Settings{}}
+		assert.Equal(t, "myapp||weapon", disabled.redisKey("myapp||weapon"))
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		err := ss.Multi(context.Background(), &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				state.SetRequest{
+					Key:   "myapp||weapon",
+					Value: "deathstar",
+				},
+				state.SetRequest{
+					Key:   "myapp||weapon2",
+					Value: "deathstar2",
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		assert.True(t, s.Exists("{myapp}||weapon"))
+		assert.True(t, s.Exists("{myapp}||weapon2"))
+		assert.False(t, s.Exists("myapp||weapon"))
+
+		res, err := ss.Get(context.Background(), &state.GetRequest{Key: "myapp||weapon"})
+		require.NoError(t, err)
+		assert.Equal(t, `"deathstar"`, string(res.Data))
+
+		err = ss.Delete(context.Background(), &state.DeleteRequest{Key: "myapp||weapon2"})
+		require.NoError(t, err)
+		assert.False(t, s.Exists("{myapp}||weapon2"))
+	})
+}
+
 func TestGetMetadata(t *testing.T) {
 	s, c := setupMiniredis()
 	defer s.Close()
@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Keys of the metadata of the requests that control how state stores execute them.
+// They are honored by the stores that support them, and ignored by the others.
+const (
+	// ConsistencyMetadataKey is the consistency of the request: "strong" or "eventual".
+	// It applies to the requests without a consistency option, such as bulk gets and queries.
+	ConsistencyMetadataKey = "consistency"
+	// TimeoutMetadataKey is the timeout of the request, in seconds, overriding the one of the component.
+	TimeoutMetadataKey = "timeoutInSeconds"
+	// MaxRetriesMetadataKey is the maximum number of times the request is retried after transient failures, overriding the one of the component.
+	// 0 disables retries.
+	MaxRetriesMetadataKey = "maxRetries"
+)
+
+// RequestConsistency returns the consistency of a request: the consistency option if set, or the value of ConsistencyMetadataKey in the metadata otherwise.
+// It returns an empty string if neither is set.
+func RequestConsistency(option string, reqMetadata map[string]string) (string, error) {
+	if option != "" {
+		return option, validateConsistencyOption(option)
+	}
+	c := reqMetadata[ConsistencyMetadataKey]
+	if err := validateConsistencyOption(c); err != nil {
+		return "", fmt.Errorf("invalid value for '%s' in the request metadata: %w", ConsistencyMetadataKey, err)
+	}
+	return c, nil
+}
+
+// RequestTimeout returns the timeout of a request: the value of TimeoutMetadataKey in the metadata if set, or defaultTimeout otherwise.
+func RequestTimeout(reqMetadata map[string]string, defaultTimeout time.Duration) (time.Duration, error) {
+	val := reqMetadata[TimeoutMetadataKey]
+	if val == "" {
+		return defaultTimeout, nil
+	}
+	seconds, err := strconv.Atoi(val)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("invalid value for '%s' in the request metadata: must be a positive integer", TimeoutMetadataKey)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// RequestContext returns a context for a request that is canceled when parentCtx is, or when the timeout returned by RequestTimeout elapses.
+// A zero timeout means no timeout.
+func RequestContext(parentCtx context.Context, reqMetadata map[string]string, defaultTimeout time.Duration) (context.Context, context.CancelFunc, error) {
+	timeout, err := RequestTimeout(reqMetadata, defaultTimeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	if timeout <= 0 {
+		ctx, cancel := context.WithCancel(parentCtx)
+		return ctx, cancel, nil
+	}
+	ctx, cancel := context.WithTimeout(parentCtx, timeout)
+	return ctx, cancel, nil
+}
+
+// RequestMaxRetries returns the maximum number of retries of a request: the value of MaxRetriesMetadataKey in the metadata if set, or defaultMaxRetries otherwise.
+func RequestMaxRetries(reqMetadata map[string]string, defaultMaxRetries int) (int, error) {
+	val := reqMetadata[MaxRetriesMetadataKey]
+	if val == "" {
+		return defaultMaxRetries, nil
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid value for '%s' in the request metadata: must be a non-negative integer", MaxRetriesMetadataKey)
+	}
+	return n, nil
+}
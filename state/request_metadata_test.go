@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestConsistency(t *testing.T) {
+	t.Run("option takes precedence", func(t *testing.T) {
+		c, err := RequestConsistency(Strong, map[string]string{ConsistencyMetadataKey: Eventual})
+		require.NoError(t, err)
+		assert.Equal(t, Strong, c)
+	})
+	t.Run("from metadata", func(t *testing.T) {
+		c, err := RequestConsistency("", map[string]string{ConsistencyMetadataKey: Eventual})
+		require.NoError(t, err)
+		assert.Equal(t, Eventual, c)
+	})
+	t.Run("not set", func(t *testing.T) {
+		c, err := RequestConsistency("", nil)
+		require.NoError(t, err)
+		assert.Empty(t, c)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := RequestConsistency("", map[string]string{ConsistencyMetadataKey: "invalid"})
+		assert.Error(t, err)
+		_, err = RequestConsistency("invalid", nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestRequestTimeout(t *testing.T) {
+	timeout, err := RequestTimeout(nil, 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, timeout)
+
+	timeout, err = RequestTimeout(map[string]string{TimeoutMetadataKey: "30"}, 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, timeout)
+
+	for _, v := range []string{"0", "-1", "1s", "foo"} {
+		_, err = RequestTimeout(map[string]string{TimeoutMetadataKey: v}, 5*time.Second)
+		assert.Error(t, err, v)
+	}
+}
+
+func TestRequestContext(t *testing.T) {
+	t.Run("with timeout", func(t *testing.T) {
+		ctx, cancel, err := RequestContext(context.Background(), map[string]string{TimeoutMetadataKey: "10"}, 0)
+		require.NoError(t, err)
+		defer cancel()
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(10*time.Second), deadline, time.Second)
+	})
+	t.Run("no timeout", func(t *testing.T) {
+		ctx, cancel, err := RequestContext(context.Background(), nil, 0)
+		require.NoError(t, err)
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+		cancel()
+		assert.Error(t, ctx.Err())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, _, err := RequestContext(context.Background(), map[string]string{TimeoutMetadataKey: "x"}, 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestRequestMaxRetries(t *testing.T) {
+	n, err := RequestMaxRetries(nil, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	n, err = RequestMaxRetries(map[string]string{MaxRetriesMetadataKey: "0"}, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	_, err = RequestMaxRetries(map[string]string{MaxRetriesMetadataKey: "-1"}, 3)
+	assert.Error(t, err)
+}
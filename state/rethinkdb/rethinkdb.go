@@ -61,7 +61,7 @@ type stateRecord struct {
 // NewRethinkDBStateStore returns a new RethinkDB state store.
 func NewRethinkDBStateStore(logger logger.Logger) state.Store {
 	s := &RethinkDB{
-		features: []state.Feature{},
+		features: []state.Feature{state.FeatureBulkNative},
 		logger:   logger,
 	}
 	return s
@@ -183,23 +183,69 @@ func (s *RethinkDB) Get(ctx context.Context, req *state.GetRequest) (*state.GetR
 		return nil, fmt.Errorf("error parsing database content: %w", err)
 	}
 
-	resp := &state.GetResponse{ETag: ptr.Of(doc.Hash)}
-	b, ok := doc.Data.([]byte)
-	if ok {
-		resp.Data = b
-	} else {
-		data, err := json.Marshal(doc.Data)
+	data, err := doc.data()
+	if err != nil {
+		return nil, err
+	}
+	return &state.GetResponse{Data: data, ETag: ptr.Of(doc.Hash)}, nil
+}
+
+// BulkGet retrieves multiple RethinkDB KV items with a single query.
+func (s *RethinkDB) BulkGet(ctx context.Context, req []state.GetRequest, _ state.BulkGetOpts) ([]state.BulkGetResponse, error) {
+	if len(req) == 0 {
+		return []state.BulkGetResponse{}, nil
+	}
+
+	keys := make([]string, len(req))
+	for i, item := range req {
+		keys[i] = item.Key
+	}
+
+	c, err := r.Table(s.config.Table).GetAll(r.Args(keys)).Run(s.session, r.RunOpts{Context: ctx})
+	if err != nil {
+		return nil, fmt.Errorf("error getting records from the database: %w", err)
+	}
+	defer c.Close()
+
+	var docs []stateRecord
+	err = c.All(&docs)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing database content: %w", err)
+	}
+	found := make(map[string]*stateRecord, len(docs))
+	for i := range docs {
+		found[docs[i].ID] = &docs[i]
+	}
+
+	res := make([]state.BulkGetResponse, len(req))
+	for i, item := range req {
+		res[i].Key = item.Key
+		doc, ok := found[item.Key]
+		if !ok {
+			continue
+		}
+		data, err := doc.data()
 		if err != nil {
-			return nil, errors.New("error serializing data from database")
+			res[i].Error = err.Error()
+			continue
 		}
-		resp.Data = data
+		res[i].Data = data
+		res[i].ETag = ptr.Of(doc.Hash)
 	}
 
-	return resp, nil
+	return res, nil
 }
 
-func (s *RethinkDB) BulkGet(ctx context.Context, req []state.GetRequest, opts state.BulkGetOpts) ([]state.BulkGetResponse, error) {
-	return state.DoBulkGet(ctx, req, opts, s.Get)
+// data returns the serialized data of the record.
+func (doc *stateRecord) data() ([]byte, error) {
+	if b, ok := doc.Data.([]byte); ok {
+		return b, nil
+	}
+	data, err := json.Marshal(doc.Data)
+	if err != nil {
+		return nil, errors.New("error serializing data from database")
+	}
+	return data, nil
 }
 
 // Set saves a state KV item.
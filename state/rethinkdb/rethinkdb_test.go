@@ -193,6 +193,25 @@ func testBulk(t *testing.T, db state.Store, i int) {
 		assert.NotNil(t, resp.Data)
 	}
 
+	// bulk get it, including a missing key
+	getList := []state.GetRequest{{Key: "test-id-missing"}}
+	for _, v := range deleteList {
+		getList = append(getList, state.GetRequest{Key: v.Key})
+	}
+	bulkResp, err := db.BulkGet(context.Background(), getList, state.BulkGetOpts{})
+	assert.NoErrorf(t, err, " -- run %d", i)
+	assert.Len(t, bulkResp, len(getList))
+	for j, v := range bulkResp {
+		assert.Equal(t, getList[j].Key, v.Key)
+		assert.Empty(t, v.Error)
+		if j == 0 {
+			assert.Nil(t, v.Data)
+		} else {
+			assert.Equal(t, "test", string(v.Data))
+			assert.NotNil(t, v.ETag)
+		}
+	}
+
 	// delete data
 	if err := db.BulkDelete(context.Background(), deleteList, state.BulkStoreOpts{}); err != nil {
 		t.Fatalf("error on data deletion: %v -- run %d", err, i)
@@ -48,6 +48,8 @@ func newSQLiteStateStore(logger logger.Logger, dba DBAccess) *SQLiteStore {
 		features: []state.Feature{
 			state.FeatureETag,
 			state.FeatureTransactional,
+			state.FeatureTTL,
+			state.FeatureBulkNative,
 		},
 		dbaccess: dba,
 	}
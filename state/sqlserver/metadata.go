@@ -42,6 +42,8 @@ const (
 	rowVersionColumnName = "RowVersion"
 	databaseNameKey      = "databaseName"
 	cleanupIntervalKey   = "cleanupIntervalInSeconds"
+	notifyKey            = "notify"
+	timeoutKey           = "timeoutInSeconds"
 
 	defaultKeyLength       = 200
 	defaultSchema          = "dbo"
@@ -49,6 +51,12 @@ const (
 	defaultTable           = "state"
 	defaultMetaTable       = "dapr_metadata"
 	defaultCleanupInterval = time.Hour
+	defaultTimeout         = 20 * time.Second
+
+	defaultNotifyPollInterval = time.Second
+	defaultNotifyBatchSize    = 100
+	defaultNotifyConsumerID   = "default"
+	defaultNotifyRetention    = 24 * time.Hour
 )
 
 type sqlServerMetadata struct {
@@ -62,6 +70,18 @@ type sqlServerMetadata struct {
 	IndexedProperties string
 	CleanupInterval   *time.Duration `mapstructure:"cleanupIntervalInSeconds"`
 	UseAzureAD        bool           `mapstructure:"useAzureAD"`
+	// Timeout of the database operations.
+	Timeout time.Duration `mapstructure:"timeoutInSeconds"`
+	// Operations taking longer than this are logged; 0 disables logging.
+	SlowQueryThreshold time.Duration `mapstructure:"slowQueryThreshold"`
+
+	// Change notifications
+	Notify             bool          `mapstructure:"notify"`
+	OutboxTableName    string        `mapstructure:"outboxTableName"`
+	NotifyPollInterval time.Duration `mapstructure:"notifyPollIntervalInSeconds"`
+	NotifyBatchSize    int           `mapstructure:"notifyBatchSize"`
+	NotifyConsumerID   string        `mapstructure:"notifyConsumerID"`
+	NotifyRetention    time.Duration `mapstructure:"notifyRetentionInSeconds"`
 
 	// Internal properties
 	keyTypeParsed           KeyType
@@ -78,6 +98,12 @@ func newMetadata() sqlServerMetadata {
 		KeyLength:         defaultKeyLength,
 		MetadataTableName: defaultMetaTable,
 		CleanupInterval:   ptr.Of(defaultCleanupInterval),
+		Timeout:           defaultTimeout,
+
+		NotifyPollInterval: defaultNotifyPollInterval,
+		NotifyBatchSize:    defaultNotifyBatchSize,
+		NotifyConsumerID:   defaultNotifyConsumerID,
+		NotifyRetention:    defaultNotifyRetention,
 	}
 }
 
@@ -116,6 +142,13 @@ func (m *sqlServerMetadata) Parse(meta map[string]string) error {
 		return err
 	}
 
+	if m.Timeout <= 0 {
+		return fmt.Errorf("invalid value for '%s': must be greater than 0", timeoutKey)
+	}
+	if m.SlowQueryThreshold < 0 {
+		return errors.New("slowQueryThreshold must be greater than or equal to 0")
+	}
+
 	// Cleanup interval
 	if m.CleanupInterval != nil {
 		// Non-positive value from meta means disable auto cleanup.
@@ -129,6 +162,11 @@ func (m *sqlServerMetadata) Parse(meta map[string]string) error {
 		}
 	}
 
+	err = m.setNotify()
+	if err != nil {
+		return err
+	}
+
 	// If using Azure AD
 	if m.UseAzureAD {
 		m.azureEnv, err = azure.NewEnvironmentSettings(meta)
@@ -183,6 +221,40 @@ func (m *sqlServerMetadata) GetConnector(setDatabase bool) (*mssql.Connector, bo
 	return conn, hasDatabase, nil
 }
 
+// Validates the options for change notifications.
+func (m *sqlServerMetadata) setNotify() error {
+	if !m.Notify {
+		return nil
+	}
+
+	if m.OutboxTableName == "" {
+		m.OutboxTableName = m.TableName + "_outbox"
+	}
+	if !isValidSQLName(m.OutboxTableName) {
+		return fmt.Errorf("invalid outbox table name, accepted characters are (A-Z, a-z, 0-9, _)")
+	}
+	if m.NotifyPollInterval <= 0 {
+		return errors.New("notifyPollIntervalInSeconds must be greater than zero")
+	}
+	if m.NotifyBatchSize <= 0 {
+		return errors.New("notifyBatchSize must be greater than zero")
+	}
+	if m.NotifyConsumerID == "" {
+		m.NotifyConsumerID = defaultNotifyConsumerID
+	}
+
+	return nil
+}
+
+func (m *sqlServerMetadata) outboxTriggerName() string {
+	return "trg_" + m.OutboxTableName
+}
+
+// Key in the metadata table where the last change delivered to the consumer is stored.
+func (m *sqlServerMetadata) notifyWatermarkKey() string {
+	return "notify-watermark-" + m.NotifyConsumerID
+}
+
 // Validates and returns the key type.
 func (m *sqlServerMetadata) setKeyType() error {
 	if m.KeyType != "" {
@@ -127,6 +127,13 @@ func (m *migration) executeMigrations(ctx context.Context) (migrationResult, err
 		}
 	}
 
+	if m.metadata.Notify {
+		err = m.ensureOutboxExists(ctx, db, r)
+		if err != nil {
+			return r, fmt.Errorf("failed to create outbox: %w", err)
+		}
+	}
+
 	return r, nil
 }
 
@@ -354,3 +361,37 @@ func (m *migration) ensureUpsertStoredProcedureExists(ctx context.Context, db *s
 
 	return m.createStoredProcedureIfNotExists(ctx, db, mr.upsertProcName, tsql)
 }
+
+/* #nosec. */
+func (m *migration) ensureOutboxExists(ctx context.Context, db *sql.DB, mr migrationResult) error {
+	tsql := fmt.Sprintf(`
+	IF NOT EXISTS (SELECT * FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = '%[1]s' AND TABLE_NAME = '%[2]s')
+		CREATE TABLE [%[1]s].[%[2]s] (
+			[Id]			BIGINT IDENTITY(1,1) CONSTRAINT PK_%[2]s PRIMARY KEY,
+			[Key]			%[3]s NOT NULL,
+			[Operation]		NVARCHAR(10) NOT NULL,
+			[Data]			NVARCHAR(MAX) NULL,
+			[RowVersion]	BINARY(8) NULL,
+			[CreatedAt]		DateTime2 NOT NULL DEFAULT(GETUTCDATE())
+		)`, m.metadata.Schema, m.metadata.OutboxTableName, mr.pkColumnType)
+	if err := runCommand(ctx, db, tsql); err != nil {
+		return err
+	}
+
+	// Deletes are recorded only for keys that are not re-inserted by the same statement
+	// SET NOCOUNT ON is required so the trigger does not change the rows affected reported to the store
+	tsql = fmt.Sprintf(`
+	IF OBJECT_ID(N'[%[1]s].[%[3]s]', N'TR') IS NULL
+	BEGIN
+		execute ('CREATE TRIGGER [%[1]s].[%[3]s] ON [%[1]s].[%[2]s] AFTER INSERT, UPDATE, DELETE AS
+		BEGIN
+			SET NOCOUNT ON;
+			INSERT INTO [%[1]s].[%[4]s] ([Key], [Operation], [Data], [RowVersion])
+				SELECT i.[Key], ''upsert'', i.[Data], i.[RowVersion] FROM inserted i;
+			INSERT INTO [%[1]s].[%[4]s] ([Key], [Operation])
+				SELECT d.[Key], ''delete'' FROM deleted d WHERE NOT EXISTS (SELECT 1 FROM inserted i WHERE i.[Key] = d.[Key]);
+		END')
+	END`, m.metadata.Schema, m.metadata.TableName, m.metadata.outboxTriggerName(), m.metadata.OutboxTableName)
+
+	return runCommand(ctx, db, tsql)
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/ptr"
+)
+
+// Minimum time between two purges of the outbox table.
+const notifyPurgeInterval = time.Minute
+
+// SubscribeChanges starts delivering the changes recorded in the outbox table to the handler, in order.
+// Changes are delivered at least once: the position of the last change processed is saved in the metadata table after each batch, so after a restart delivery resumes from there.
+// Requires the "notify" metadata option.
+func (s *SQLServer) SubscribeChanges(ctx context.Context, handler state.StateChangeHandler) error {
+	if !s.metadata.Notify {
+		return errors.New("change notifications are not enabled: set the metadata option 'notify' to true")
+	}
+	if s.db == nil || s.closed.Load() {
+		return errors.New("state store is not initialized or is closed")
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.pollChanges(ctx, handler)
+	}()
+
+	return nil
+}
+
+func (s *SQLServer) pollChanges(parentCtx context.Context, handler state.StateChangeHandler) {
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+	go func() {
+		// Stop when the store is closed
+		select {
+		case <-ctx.Done():
+		case <-s.closeCh:
+			cancel()
+		}
+	}()
+
+	s.logger.Infof("Delivering changes from outbox table %s every %v", s.metadata.OutboxTableName, s.metadata.NotifyPollInterval)
+
+	var (
+		watermark int64
+		loaded    bool
+		lastPurge time.Time
+		n         int
+		err       error
+	)
+	for {
+		n = 0
+		if !loaded {
+			watermark, err = s.loadWatermark(ctx)
+			loaded = err == nil
+		}
+		if loaded {
+			n, err = s.deliverChanges(ctx, handler, &watermark)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.logger.Errorf("Error delivering state changes: %v", err)
+		}
+
+		if err == nil && s.metadata.NotifyRetention > 0 && time.Since(lastPurge) > notifyPurgeInterval {
+			err = s.purgeOutbox(ctx, watermark)
+			if err != nil {
+				s.logger.Warnf("Failed to purge the outbox table: %v", err)
+			}
+			lastPurge = time.Now()
+		}
+
+		// If the batch was full, there may be more changes waiting
+		if err != nil || n < s.metadata.NotifyBatchSize {
+			select {
+			case <-ctx.Done():
+				s.logger.Debug("Stopping delivery of state changes")
+				return
+			case <-time.After(s.metadata.NotifyPollInterval):
+			}
+		} else if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// deliverChanges sends the next batch of changes to the handler and saves the new watermark.
+// Returns the number of changes read from the outbox.
+func (s *SQLServer) deliverChanges(ctx context.Context, handler state.StateChangeHandler, watermark *int64) (int, error) {
+	changes, err := s.fetchChanges(ctx, *watermark)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read changes from the outbox: %w", err)
+	}
+
+	delivered := *watermark
+	var handlerErr error
+	for _, c := range changes {
+		handlerErr = handler(ctx, c)
+		if handlerErr != nil {
+			handlerErr = fmt.Errorf("handler failed to process change %d for key %s: %w", c.Sequence, c.Key, handlerErr)
+			break
+		}
+		delivered = c.Sequence
+	}
+
+	if delivered > *watermark {
+		err = s.saveWatermark(ctx, delivered)
+		if err != nil {
+			// Changes after the saved watermark will be delivered again
+			return len(changes), errors.Join(handlerErr, fmt.Errorf("failed to save watermark: %w", err))
+		}
+		*watermark = delivered
+	}
+
+	return len(changes), handlerErr
+}
+
+/* #nosec. */
+func (s *SQLServer) fetchChanges(ctx context.Context, watermark int64) ([]*state.StateChange, error) {
+	// READCOMMITTEDLOCK makes the query wait for transactions that are still writing to the outbox even when read committed snapshot is enabled.
+	// Otherwise, a change with a lower Id committed after one with a higher Id would be skipped.
+	query := fmt.Sprintf(`SELECT TOP (@BatchSize) [Id], CONVERT(NVARCHAR(MAX), [Key]), [Operation], [Data], [RowVersion], [CreatedAt]
+FROM [%s].[%s] WITH (READCOMMITTEDLOCK)
+WHERE [Id] > @Watermark
+ORDER BY [Id]`, s.metadata.Schema, s.metadata.OutboxTableName)
+
+	rows, err := s.db.QueryContext(ctx, query,
+		sql.Named("BatchSize", s.metadata.NotifyBatchSize),
+		sql.Named("Watermark", watermark),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*state.StateChange, 0, s.metadata.NotifyBatchSize)
+	for rows.Next() {
+		var (
+			c          state.StateChange
+			operation  string
+			data       sql.NullString
+			rowVersion []byte
+		)
+		err = rows.Scan(&c.Sequence, &c.Key, &operation, &data, &rowVersion, &c.Time)
+		if err != nil {
+			return nil, err
+		}
+
+		c.Operation = state.OperationType(operation)
+		if c.Operation == state.OperationUpsert {
+			c.Value = []byte(data.String)
+			if len(rowVersion) > 0 {
+				c.ETag = ptr.Of(hex.EncodeToString(rowVersion))
+			}
+		}
+		res = append(res, &c)
+	}
+
+	return res, rows.Err()
+}
+
+/* #nosec. */
+func (s *SQLServer) loadWatermark(ctx context.Context) (int64, error) {
+	query := fmt.Sprintf(`SELECT [Value] FROM [%s].[%s] WHERE [Key] = @Key`, s.metadata.Schema, s.metadata.MetadataTableName)
+
+	var value string
+	err := s.db.QueryRowContext(ctx, query, sql.Named("Key", s.metadata.notifyWatermarkKey())).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to load watermark: %w", err)
+	}
+
+	watermark, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid watermark '%s' in the metadata table: %w", value, err)
+	}
+	return watermark, nil
+}
+
+/* #nosec. */
+func (s *SQLServer) saveWatermark(ctx context.Context, watermark int64) error {
+	query := fmt.Sprintf(`MERGE [%[1]s].[%[2]s] WITH (HOLDLOCK) AS t
+USING (SELECT @Key AS [Key]) AS s ON t.[Key] = s.[Key]
+WHEN MATCHED THEN UPDATE SET [Value] = @Value
+WHEN NOT MATCHED THEN INSERT ([Key], [Value]) VALUES (@Key, @Value);`, s.metadata.Schema, s.metadata.MetadataTableName)
+
+	_, err := s.db.ExecContext(ctx, query,
+		sql.Named("Key", s.metadata.notifyWatermarkKey()),
+		sql.Named("Value", strconv.FormatInt(watermark, 10)),
+	)
+	return err
+}
+
+// purgeOutbox deletes the changes that were delivered and are older than the retention period.
+/* #nosec. */
+func (s *SQLServer) purgeOutbox(ctx context.Context, watermark int64) error {
+	query := fmt.Sprintf(`DELETE FROM [%s].[%s] WHERE [Id] <= @Watermark AND [CreatedAt] < DATEADD(SECOND, -@Retention, GETUTCDATE())`,
+		s.metadata.Schema, s.metadata.OutboxTableName)
+
+	res, err := s.db.ExecContext(ctx, query,
+		sql.Named("Watermark", watermark),
+		sql.Named("Retention", int64(s.metadata.NotifyRetention.Seconds())),
+	)
+	if err != nil {
+		return err
+	}
+
+	n, _ := res.RowsAffected()
+	if n > 0 {
+		s.logger.Debugf("Purged %d changes from the outbox table", n)
+	}
+	return nil
+}
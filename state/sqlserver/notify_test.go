@@ -0,0 +1,288 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+func mockNotifyStore(t *testing.T) (*SQLServer, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	s := &SQLServer{
+		logger:  logger.NewLogger("test"),
+		db:      db,
+		closeCh: make(chan struct{}),
+	}
+	s.metadata = newMetadata()
+	require.NoError(t, s.metadata.Parse(map[string]string{
+		connectionStringKey: sampleConnectionString,
+		notifyKey:           "true",
+	}))
+
+	return s, mock
+}
+
+func TestNotifyMetadata(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		m := newMetadata()
+		err := m.Parse(map[string]string{connectionStringKey: sampleConnectionString, tableNameKey: "items", notifyKey: "true"})
+		require.NoError(t, err)
+		assert.True(t, m.Notify)
+		assert.Equal(t, "items_outbox", m.OutboxTableName)
+		assert.Equal(t, time.Second, m.NotifyPollInterval)
+		assert.Equal(t, 100, m.NotifyBatchSize)
+		assert.Equal(t, 24*time.Hour, m.NotifyRetention)
+		assert.Equal(t, "notify-watermark-default", m.notifyWatermarkKey())
+		assert.Equal(t, "trg_items_outbox", m.outboxTriggerName())
+	})
+
+	t.Run("custom values", func(t *testing.T) {
+		m := newMetadata()
+		err := m.Parse(map[string]string{
+			connectionStringKey:           sampleConnectionString,
+			notifyKey:                     "true",
+			"outboxTableName":             "changes",
+			"notifyPollIntervalInSeconds": "5",
+			"notifyBatchSize":             "10",
+			"notifyConsumerID":            "indexer",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "changes", m.OutboxTableName)
+		assert.Equal(t, 5*time.Second, m.NotifyPollInterval)
+		assert.Equal(t, 10, m.NotifyBatchSize)
+		assert.Equal(t, "notify-watermark-indexer", m.notifyWatermarkKey())
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		tests := map[string]struct {
+			props       map[string]string
+			expectedErr string
+		}{
+			"outbox table name": {
+				props:       map[string]string{"outboxTableName": "[changes]"},
+				expectedErr: "invalid outbox table name",
+			},
+			"poll interval": {
+				props:       map[string]string{"notifyPollIntervalInSeconds": "0"},
+				expectedErr: "notifyPollIntervalInSeconds must be greater than zero",
+			},
+			"batch size": {
+				props:       map[string]string{"notifyBatchSize": "-1"},
+				expectedErr: "notifyBatchSize must be greater than zero",
+			},
+		}
+
+		for name, tt := range tests {
+			t.Run(name, func(t *testing.T) {
+				tt.props[connectionStringKey] = sampleConnectionString
+				tt.props[notifyKey] = "true"
+				m := newMetadata()
+				err := m.Parse(tt.props)
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+			})
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		m := newMetadata()
+		err := m.Parse(map[string]string{connectionStringKey: sampleConnectionString, "outboxTableName": "[changes]"})
+		require.NoError(t, err)
+		assert.False(t, m.Notify)
+	})
+}
+
+func TestSubscribeChangesNotEnabled(t *testing.T) {
+	s := &SQLServer{}
+	err := s.SubscribeChanges(context.Background(), func(ctx context.Context, change *state.StateChange) error {
+		return nil
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "change notifications are not enabled")
+}
+
+func TestLoadWatermark(t *testing.T) {
+	s, mock := mockNotifyStore(t)
+	defer s.Close()
+
+	t.Run("no watermark", func(t *testing.T) {
+		mock.ExpectQuery("SELECT \\[Value\\] FROM \\[dbo\\].\\[dapr_metadata\\]").
+			WithArgs(sql.Named("Key", "notify-watermark-default")).
+			WillReturnRows(sqlmock.NewRows([]string{"Value"}))
+
+		watermark, err := s.loadWatermark(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), watermark)
+	})
+
+	t.Run("saved watermark", func(t *testing.T) {
+		mock.ExpectQuery("SELECT \\[Value\\] FROM \\[dbo\\].\\[dapr_metadata\\]").
+			WithArgs(sql.Named("Key", "notify-watermark-default")).
+			WillReturnRows(sqlmock.NewRows([]string{"Value"}).AddRow("42"))
+
+		watermark, err := s.loadWatermark(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(42), watermark)
+	})
+
+	t.Run("invalid watermark", func(t *testing.T) {
+		mock.ExpectQuery("SELECT \\[Value\\] FROM \\[dbo\\].\\[dapr_metadata\\]").
+			WillReturnRows(sqlmock.NewRows([]string{"Value"}).AddRow("foo"))
+
+		_, err := s.loadWatermark(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid watermark")
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func outboxRows() *sqlmock.Rows {
+	now := time.Now()
+	return sqlmock.NewRows([]string{"Id", "Key", "Operation", "Data", "RowVersion", "CreatedAt"}).
+		AddRow(int64(3), "k1", "upsert", `{"a":1}`, []byte{0, 0, 0, 0, 0, 0, 0, 1}, now).
+		AddRow(int64(4), "k2", "delete", nil, nil, now)
+}
+
+func TestDeliverChanges(t *testing.T) {
+	s, mock := mockNotifyStore(t)
+	defer s.Close()
+
+	t.Run("delivers changes and saves the watermark", func(t *testing.T) {
+		mock.ExpectQuery("SELECT TOP \\(@BatchSize\\) (.+) FROM \\[dbo\\].\\[state_outbox\\] WITH \\(READCOMMITTEDLOCK\\)").
+			WithArgs(sql.Named("BatchSize", 100), sql.Named("Watermark", int64(2))).
+			WillReturnRows(outboxRows())
+		mock.ExpectExec("MERGE \\[dbo\\].\\[dapr_metadata\\]").
+			WithArgs(sql.Named("Key", "notify-watermark-default"), sql.Named("Value", "4")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		var received []*state.StateChange
+		watermark := int64(2)
+		n, err := s.deliverChanges(context.Background(), func(ctx context.Context, change *state.StateChange) error {
+			received = append(received, change)
+			return nil
+		}, &watermark)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, int64(4), watermark)
+
+		require.Len(t, received, 2)
+		assert.Equal(t, int64(3), received[0].Sequence)
+		assert.Equal(t, "k1", received[0].Key)
+		assert.Equal(t, state.OperationUpsert, received[0].Operation)
+		assert.Equal(t, `{"a":1}`, string(received[0].Value))
+		require.NotNil(t, received[0].ETag)
+		assert.Equal(t, "0000000000000001", *received[0].ETag)
+		assert.Equal(t, "k2", received[1].Key)
+		assert.Equal(t, state.OperationDelete, received[1].Operation)
+		assert.Nil(t, received[1].Value)
+		assert.Nil(t, received[1].ETag)
+	})
+
+	t.Run("handler error stops delivery", func(t *testing.T) {
+		mock.ExpectQuery("SELECT TOP \\(@BatchSize\\)").
+			WithArgs(sql.Named("BatchSize", 100), sql.Named("Watermark", int64(2))).
+			WillReturnRows(outboxRows())
+		mock.ExpectExec("MERGE \\[dbo\\].\\[dapr_metadata\\]").
+			WithArgs(sql.Named("Key", "notify-watermark-default"), sql.Named("Value", "3")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		watermark := int64(2)
+		_, err := s.deliverChanges(context.Background(), func(ctx context.Context, change *state.StateChange) error {
+			if change.Key == "k2" {
+				return errors.New("simulated")
+			}
+			return nil
+		}, &watermark)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "handler failed to process change 4 for key k2")
+		assert.Equal(t, int64(3), watermark)
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		mock.ExpectQuery("SELECT TOP \\(@BatchSize\\)").
+			WillReturnRows(sqlmock.NewRows([]string{"Id", "Key", "Operation", "Data", "RowVersion", "CreatedAt"}))
+
+		watermark := int64(4)
+		n, err := s.deliverChanges(context.Background(), func(ctx context.Context, change *state.StateChange) error {
+			t.Fatal("handler must not be invoked")
+			return nil
+		}, &watermark)
+		require.NoError(t, err)
+		assert.Equal(t, 0, n)
+		assert.Equal(t, int64(4), watermark)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscribeChanges(t *testing.T) {
+	s, mock := mockNotifyStore(t)
+	s.metadata.NotifyPollInterval = 10 * time.Millisecond
+	s.metadata.NotifyRetention = 0
+
+	mock.ExpectQuery("SELECT \\[Value\\] FROM \\[dbo\\].\\[dapr_metadata\\]").
+		WillReturnRows(sqlmock.NewRows([]string{"Value"}).AddRow("2"))
+	mock.ExpectQuery("SELECT TOP \\(@BatchSize\\)").
+		WithArgs(sql.Named("BatchSize", 100), sql.Named("Watermark", int64(2))).
+		WillReturnRows(outboxRows())
+	mock.ExpectExec("MERGE \\[dbo\\].\\[dapr_metadata\\]").
+		WithArgs(sql.Named("Key", "notify-watermark-default"), sql.Named("Value", "4")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT TOP \\(@BatchSize\\)").
+		WithArgs(sql.Named("BatchSize", 100), sql.Named("Watermark", int64(4))).
+		WillReturnRows(sqlmock.NewRows([]string{"Id", "Key", "Operation", "Data", "RowVersion", "CreatedAt"}))
+
+	received := make(chan string, 2)
+	err := s.SubscribeChanges(context.Background(), func(ctx context.Context, change *state.StateChange) error {
+		received <- change.Key
+		return nil
+	})
+	require.NoError(t, err)
+
+	for _, expect := range []string{"k1", "k2"} {
+		select {
+		case key := <-received:
+			assert.Equal(t, expect, key)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for change")
+		}
+	}
+
+	assert.Eventually(t, func() bool {
+		return mock.ExpectationsWereMet() == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Close stops the poller
+	require.NoError(t, s.Close())
+	err = s.SubscribeChanges(context.Background(), func(ctx context.Context, change *state.StateChange) error {
+		return nil
+	})
+	require.Error(t, err)
+}
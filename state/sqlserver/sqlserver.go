@@ -20,6 +20,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	internalsql "github.com/dapr/components-contrib/internal/component/sql"
@@ -63,7 +65,7 @@ const (
 // New creates a new instance of a SQL Server transaction store.
 func New(logger logger.Logger) state.Store {
 	s := &SQLServer{
-		features:        []state.Feature{state.FeatureETag, state.FeatureTransactional},
+		features:        []state.Feature{state.FeatureETag, state.FeatureTransactional, state.FeatureTTL},
 		logger:          logger,
 		migratorFactory: newMigration,
 	}
@@ -96,6 +98,12 @@ type SQLServer struct {
 	logger   logger.Logger
 	db       *sql.DB
 	gc       internalsql.GarbageCollector
+
+	slowQueries internalsql.SlowQueryLogger
+
+	closed  atomic.Bool
+	closeCh chan struct{}
+	wg      sync.WaitGroup
 }
 
 // Init initializes the SQL server state store.
@@ -105,6 +113,10 @@ func (s *SQLServer) Init(ctx context.Context, metadata state.Metadata) error {
 	if err != nil {
 		return err
 	}
+	s.slowQueries = internalsql.SlowQueryLogger{
+		Logger:    s.logger,
+		Threshold: s.metadata.SlowQueryThreshold,
+	}
 
 	migration := s.migratorFactory(&s.metadata)
 	mr, err := migration.executeMigrations(ctx)
@@ -123,6 +135,7 @@ func (s *SQLServer) Init(ctx context.Context, metadata state.Metadata) error {
 		return err
 	}
 	s.db = sql.OpenDB(conn)
+	s.closeCh = make(chan struct{})
 
 	if s.metadata.CleanupInterval != nil {
 		err = s.startGC()
@@ -167,7 +180,15 @@ func (s *SQLServer) Features() []state.Feature {
 }
 
 // Multi performs multiple updates on a Sql server store.
-func (s *SQLServer) Multi(ctx context.Context, request *state.TransactionalStateRequest) error {
+func (s *SQLServer) Multi(parentCtx context.Context, request *state.TransactionalStateRequest) error {
+	// The timeout in the metadata of the transaction, if any, applies to the whole transaction
+	ctx, cancel, err := internalsql.OperationContext(parentCtx, request.Metadata, 0)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer s.slowQueries.Track("Transaction", "", time.Now())
+
 	tx, err := s.db.BeginTx(ctx, nil)
 	defer tx.Rollback()
 	if err != nil {
@@ -201,8 +222,14 @@ func (s *SQLServer) Delete(ctx context.Context, req *state.DeleteRequest) error
 	return s.executeDelete(ctx, s.db, req)
 }
 
-func (s *SQLServer) executeDelete(ctx context.Context, db dbExecutor, req *state.DeleteRequest) error {
-	var err error
+func (s *SQLServer) executeDelete(parentCtx context.Context, db dbExecutor, req *state.DeleteRequest) error {
+	ctx, cancel, err := internalsql.OperationContext(parentCtx, req.Metadata, s.metadata.Timeout)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer s.slowQueries.Track("Delete", req.Key, time.Now())
+
 	var res sql.Result
 	if req.HasETag() {
 		var b []byte
@@ -243,7 +270,14 @@ type TvpDeleteTableStringKey struct {
 }
 
 // Get returns an entity from store.
-func (s *SQLServer) Get(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+func (s *SQLServer) Get(parentCtx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+	ctx, cancel, err := internalsql.OperationContext(parentCtx, req.Metadata, s.metadata.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	defer s.slowQueries.Track("Get", req.Key, time.Now())
+
 	rows, err := s.db.QueryContext(ctx, s.getCommand, sql.Named(keyColumnName, req.Key))
 	if err != nil {
 		return nil, err
@@ -295,7 +329,7 @@ type dbExecutor interface {
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
-func (s *SQLServer) executeSet(ctx context.Context, db dbExecutor, req *state.SetRequest) error {
+func (s *SQLServer) executeSet(parentCtx context.Context, db dbExecutor, req *state.SetRequest) error {
 	var err error
 	var bytes []byte
 	bytes, err = utils.Marshal(req.Value, json.Marshal)
@@ -317,6 +351,13 @@ func (s *SQLServer) executeSet(ctx context.Context, db dbExecutor, req *state.Se
 		return fmt.Errorf("error parsing TTL: %w", ttlerr)
 	}
 
+	ctx, cancel, err := internalsql.OperationContext(parentCtx, req.Metadata, s.metadata.Timeout)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer s.slowQueries.Track("Set", req.Key, time.Now())
+
 	var res sql.Result
 	if req.Options.Concurrency == state.FirstWrite {
 		res, err = db.ExecContext(ctx, s.upsertCommand, sql.Named(keyColumnName, req.Key),
@@ -353,6 +394,12 @@ func (s *SQLServer) GetComponentMetadata() map[string]string {
 
 // Close implements io.Closer.
 func (s *SQLServer) Close() error {
+	// Stop delivering changes before closing the database
+	if s.closeCh != nil && s.closed.CompareAndSwap(false, true) {
+		close(s.closeCh)
+	}
+	s.wg.Wait()
+
 	if s.db != nil {
 		s.db.Close()
 		s.db = nil
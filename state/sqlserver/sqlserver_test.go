@@ -51,11 +51,11 @@ func (m *mockFailingMigrator) executeMigrations(context.Context) (migrationResul
 func TestValidConfiguration(t *testing.T) {
 	tests := map[string]struct {
 		props    map[string]string
-		expected SQLServer
+		expected *SQLServer
 	}{
 		"No schema": {
 			props: map[string]string{connectionStringKey: sampleConnectionString, tableNameKey: sampleUserTableName},
-			expected: SQLServer{
+			expected: &SQLServer{
 				metadata: sqlServerMetadata{
 					ConnectionString:  sampleConnectionString,
 					TableName:         sampleUserTableName,
@@ -69,7 +69,7 @@ func TestValidConfiguration(t *testing.T) {
 		},
 		"Custom schema": {
 			props: map[string]string{connectionStringKey: sampleConnectionString, tableNameKey: sampleUserTableName, schemaKey: "mytest"},
-			expected: SQLServer{
+			expected: &SQLServer{
 				metadata: sqlServerMetadata{
 					ConnectionString:  sampleConnectionString,
 					TableName:         sampleUserTableName,
@@ -83,7 +83,7 @@ func TestValidConfiguration(t *testing.T) {
 		},
 		"String key type": {
 			props: map[string]string{connectionStringKey: sampleConnectionString, tableNameKey: sampleUserTableName, keyTypeKey: "string"},
-			expected: SQLServer{
+			expected: &SQLServer{
 				metadata: sqlServerMetadata{
 					ConnectionString:  sampleConnectionString,
 					Schema:            defaultSchema,
@@ -97,7 +97,7 @@ func TestValidConfiguration(t *testing.T) {
 		},
 		"Unique identifier key type": {
 			props: map[string]string{connectionStringKey: sampleConnectionString, tableNameKey: sampleUserTableName, keyTypeKey: "uuid"},
-			expected: SQLServer{
+			expected: &SQLServer{
 				metadata: sqlServerMetadata{
 					ConnectionString:  sampleConnectionString,
 					Schema:            defaultSchema,
@@ -111,7 +111,7 @@ func TestValidConfiguration(t *testing.T) {
 		},
 		"Integer identifier key type": {
 			props: map[string]string{connectionStringKey: sampleConnectionString, tableNameKey: sampleUserTableName, keyTypeKey: "integer"},
-			expected: SQLServer{
+			expected: &SQLServer{
 				metadata: sqlServerMetadata{
 					ConnectionString:  sampleConnectionString,
 					Schema:            defaultSchema,
@@ -125,7 +125,7 @@ func TestValidConfiguration(t *testing.T) {
 		},
 		"Custom key length": {
 			props: map[string]string{connectionStringKey: sampleConnectionString, tableNameKey: sampleUserTableName, keyLengthKey: "100"},
-			expected: SQLServer{
+			expected: &SQLServer{
 				metadata: sqlServerMetadata{
 					ConnectionString:  sampleConnectionString,
 					Schema:            defaultSchema,
@@ -139,7 +139,7 @@ func TestValidConfiguration(t *testing.T) {
 		},
 		"Single indexed property": {
 			props: map[string]string{connectionStringKey: sampleConnectionString, tableNameKey: sampleUserTableName, indexedPropertiesKey: `[{"column": "Age","property":"age", "type":"int"}]`},
-			expected: SQLServer{
+			expected: &SQLServer{
 				metadata: sqlServerMetadata{
 					ConnectionString: sampleConnectionString,
 					Schema:           defaultSchema,
@@ -156,7 +156,7 @@ func TestValidConfiguration(t *testing.T) {
 		},
 		"Multiple indexed properties": {
 			props: map[string]string{connectionStringKey: sampleConnectionString, tableNameKey: sampleUserTableName, indexedPropertiesKey: `[{"column": "Age","property":"age", "type":"int"}, {"column": "Name","property":"name", "type":"nvarchar(100)"}]`},
-			expected: SQLServer{
+			expected: &SQLServer{
 				metadata: sqlServerMetadata{
 					ConnectionString: sampleConnectionString,
 					Schema:           defaultSchema,
@@ -174,7 +174,7 @@ func TestValidConfiguration(t *testing.T) {
 		},
 		"Custom database": {
 			props: map[string]string{connectionStringKey: sampleConnectionString, tableNameKey: sampleUserTableName, databaseNameKey: "dapr_test_table"},
-			expected: SQLServer{
+			expected: &SQLServer{
 				metadata: sqlServerMetadata{
 					ConnectionString:  sampleConnectionString,
 					Schema:            defaultSchema,
@@ -188,7 +188,7 @@ func TestValidConfiguration(t *testing.T) {
 		},
 		"No table": {
 			props: map[string]string{connectionStringKey: sampleConnectionString},
-			expected: SQLServer{
+			expected: &SQLServer{
 				metadata: sqlServerMetadata{
 					ConnectionString:  sampleConnectionString,
 					TableName:         defaultTable,
@@ -202,7 +202,7 @@ func TestValidConfiguration(t *testing.T) {
 		},
 		"Custom meta table": {
 			props: map[string]string{connectionStringKey: sampleConnectionString, "metadataTableName": "dapr_test_meta_table"},
-			expected: SQLServer{
+			expected: &SQLServer{
 				metadata: sqlServerMetadata{
 					ConnectionString:  sampleConnectionString,
 					TableName:         defaultTable,
@@ -344,6 +344,14 @@ func TestInvalidConfiguration(t *testing.T) {
 			props:       map[string]string{connectionStringKey: sampleConnectionString, tableNameKey: "test", keyTypeKey: "invalid"},
 			expectedErr: "invalid key type",
 		},
+		"Invalid timeout": {
+			props:       map[string]string{connectionStringKey: sampleConnectionString, timeoutKey: "0"},
+			expectedErr: "invalid value for 'timeoutInSeconds'",
+		},
+		"Negative slow query threshold": {
+			props:       map[string]string{connectionStringKey: sampleConnectionString, "slowQueryThreshold": "-1s"},
+			expectedErr: "slowQueryThreshold must be greater than or equal to 0",
+		},
 	}
 
 	for name, tt := range tests {
@@ -16,6 +16,7 @@ package state
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/dapr/components-contrib/health"
 )
@@ -46,6 +47,41 @@ type Querier interface {
 	Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error)
 }
 
+// StoreResolver returns the state store component with the given name.
+type StoreResolver func(name string) (Store, error)
+
+// StoreResolverSetter is implemented by components that use other state store components, for example to persist checkpoints.
+// The runtime sets the resolver after creating the component.
+type StoreResolverSetter interface {
+	SetStateStoreResolver(resolver StoreResolver)
+}
+
+// StateChange is a change to a key of a state store, delivered to a StateChangeHandler.
+type StateChange struct {
+	// Position of the change in the store's change feed; changes are delivered in increasing order.
+	Sequence int64
+	// Key that was changed.
+	Key string
+	// Operation that changed the key: OperationUpsert or OperationDelete.
+	Operation OperationType
+	// New value of the key; nil for deletes.
+	Value []byte
+	// New ETag of the key; nil for deletes.
+	ETag *string
+	// Time the change was recorded by the store.
+	Time time.Time
+}
+
+// StateChangeHandler handles changes to a state store.
+// If the handler returns an error, the change is delivered again later.
+type StateChangeHandler func(ctx context.Context, change *StateChange) error
+
+// ChangeSubscriber is implemented by state stores that can deliver a feed of the changes to their keys.
+type ChangeSubscriber interface {
+	// SubscribeChanges starts delivering changes to the handler in the background, until the context is canceled or the store is closed.
+	SubscribeChanges(ctx context.Context, handler StateChangeHandler) error
+}
+
 func Ping(ctx context.Context, store Store) error {
 	// checks if this store has the ping option then executes
 	if storeWithPing, ok := store.(health.Pinger); ok {
@@ -0,0 +1,360 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package versioned contains a decorator for state stores that keeps a bounded history of the values written to each key.
+// Deletes are recorded as tombstones in the history, so a key that was deleted by accident can be restored to any of the retained versions.
+package versioned
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+
+	"github.com/dapr/components-contrib/state"
+	stateutils "github.com/dapr/components-contrib/state/utils"
+)
+
+const (
+	// DefaultMaxVersions is the number of versions retained per key when Options.MaxVersions is not set.
+	DefaultMaxVersions = 10
+
+	// Separator between the key and the history suffix.
+	historySeparator = "||__history__||"
+	// Suffix of the record that holds the latest version number of a key.
+	historyIndexSuffix = "index"
+)
+
+// ErrVersionNotFound is returned when the requested version does not exist or is no longer retained.
+var ErrVersionNotFound = errors.New("version not found")
+
+// Options contains the options for the versioned store.
+type Options struct {
+	// Maximum number of versions retained per key, including tombstones.
+	// Older versions are overwritten as new ones are written.
+	MaxVersions int
+}
+
+// Version is a historical version of a key.
+type Version struct {
+	// Monotonically-increasing version number, starting from 1.
+	Version int64 `json:"version"`
+	// If true, the version is a tombstone recorded when the key was deleted.
+	Deleted bool `json:"deleted,omitempty"`
+	// Value of the key; empty for tombstones.
+	Data []byte `json:"data,omitempty"`
+	// Content type of the value, if any.
+	ContentType *string `json:"contentType,omitempty"`
+	// Time the version was recorded.
+	Time time.Time `json:"time"`
+}
+
+type historyIndex struct {
+	Latest int64 `json:"latest"`
+}
+
+// Store is a state store decorator that records every write and delete in a per-key history kept in the same backend.
+// History records are stored under the key "<key>||__history__||<slot>", where slot cycles through MaxVersions values, and the latest version number is stored under "<key>||__history__||index".
+// History starts when the key is first written through the decorator.
+type Store struct {
+	state.BulkStore
+
+	store       state.Store
+	maxVersions int64
+	clock       clock.Clock
+	lock        sync.Mutex
+}
+
+// NewStore returns a new versioned store that wraps the given store.
+func NewStore(store state.Store, opts Options) *Store {
+	if opts.MaxVersions <= 0 {
+		opts.MaxVersions = DefaultMaxVersions
+	}
+	s := &Store{
+		store:       store,
+		maxVersions: int64(opts.MaxVersions),
+		clock:       clock.RealClock{},
+	}
+	s.BulkStore = state.NewDefaultBulkStore(s)
+	return s
+}
+
+// Init initializes the wrapped store.
+func (s *Store) Init(ctx context.Context, metadata state.Metadata) error {
+	return s.store.Init(ctx, metadata)
+}
+
+// Features returns the features of the wrapped store.
+func (s *Store) Features() []state.Feature {
+	return s.store.Features()
+}
+
+// GetComponentMetadata returns the metadata of the wrapped store.
+func (s *Store) GetComponentMetadata() map[string]string {
+	return s.store.GetComponentMetadata()
+}
+
+// Get retrieves the current value of a key from the wrapped store.
+func (s *Store) Get(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+	return s.store.Get(ctx, req)
+}
+
+// BulkGet retrieves the current values of multiple keys from the wrapped store.
+func (s *Store) BulkGet(ctx context.Context, req []state.GetRequest, opts state.BulkGetOpts) ([]state.BulkGetResponse, error) {
+	return s.store.BulkGet(ctx, req, opts)
+}
+
+// Set saves the value in the wrapped store and records it as a new version.
+func (s *Store) Set(ctx context.Context, req *state.SetRequest) error {
+	err := s.store.Set(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	data, err := stateutils.Marshal(req.Value, json.Marshal)
+	if err != nil {
+		return fmt.Errorf("failed to marshal value for the history of key %s: %w", req.Key, err)
+	}
+	return s.appendVersion(ctx, req.Key, Version{
+		Data:        data,
+		ContentType: req.ContentType,
+	})
+}
+
+// Delete removes the key from the wrapped store and records a tombstone in its history.
+func (s *Store) Delete(ctx context.Context, req *state.DeleteRequest) error {
+	err := s.store.Delete(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return s.appendVersion(ctx, req.Key, Version{
+		Deleted: true,
+	})
+}
+
+// Multi executes the transaction in the wrapped store, recording a version for each operation in the same transaction.
+// Returns an error if the wrapped store is not transactional.
+func (s *Store) Multi(ctx context.Context, request *state.TransactionalStateRequest) error {
+	tx, ok := s.store.(state.TransactionalStore)
+	if !ok {
+		return errors.New("wrapped state store does not support transactions")
+	}
+
+	// The lock serializes updates to the indexes within this process only
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Build a new request, as some stores replace the operations in the request
+	ops := make([]state.TransactionalStateOperation, len(request.Operations), 2*len(request.Operations)+1)
+	copy(ops, request.Operations)
+
+	now := s.clock.Now()
+	indexes := map[string]*historyIndex{}
+	keys := []string{}
+	for _, op := range request.Operations {
+		var v Version
+		switch req := op.(type) {
+		case state.SetRequest:
+			data, err := stateutils.Marshal(req.Value, json.Marshal)
+			if err != nil {
+				return fmt.Errorf("failed to marshal value for the history of key %s: %w", req.Key, err)
+			}
+			v.Data = data
+			v.ContentType = req.ContentType
+		case state.DeleteRequest:
+			v.Deleted = true
+		default:
+			continue
+		}
+
+		key := op.GetKey()
+		idx, ok := indexes[key]
+		if !ok {
+			cur, err := s.getIndex(ctx, key)
+			if err != nil {
+				return err
+			}
+			idx = &cur
+			indexes[key] = idx
+			keys = append(keys, key)
+		}
+
+		idx.Latest++
+		v.Version = idx.Latest
+		v.Time = now
+		rec, err := newRecord(s.versionKey(key, v.Version), v)
+		if err != nil {
+			return fmt.Errorf("failed to save version %d of key %s: %w", v.Version, key, err)
+		}
+		ops = append(ops, rec)
+	}
+
+	for _, key := range keys {
+		rec, err := newRecord(indexKey(key), indexes[key])
+		if err != nil {
+			return fmt.Errorf("failed to save history index of key %s: %w", key, err)
+		}
+		ops = append(ops, rec)
+	}
+
+	return tx.Multi(ctx, &state.TransactionalStateRequest{
+		Operations: ops,
+		Metadata:   request.Metadata,
+	})
+}
+
+// ListVersions returns the versions retained for the key, newest first.
+func (s *Store) ListVersions(ctx context.Context, key string) ([]Version, error) {
+	idx, err := s.getIndex(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Version, 0, s.maxVersions)
+	for n := idx.Latest; n > 0 && n > idx.Latest-s.maxVersions; n-- {
+		v, err := s.getVersion(ctx, key, n)
+		if errors.Is(err, ErrVersionNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		res = append(res, *v)
+	}
+	return res, nil
+}
+
+// GetVersion returns a specific version of the key.
+// Returns ErrVersionNotFound if the version doesn't exist or has been overwritten by newer ones.
+func (s *Store) GetVersion(ctx context.Context, key string, version int64) (*Version, error) {
+	return s.getVersion(ctx, key, version)
+}
+
+// Restore sets the key to the value it had at the given version.
+// Restoring a tombstone deletes the key.
+// The restore is itself recorded as a new version, so it can be undone.
+func (s *Store) Restore(ctx context.Context, key string, version int64) error {
+	v, err := s.getVersion(ctx, key, version)
+	if err != nil {
+		return err
+	}
+
+	if v.Deleted {
+		return s.Delete(ctx, &state.DeleteRequest{Key: key})
+	}
+	return s.Set(ctx, &state.SetRequest{
+		Key:         key,
+		Value:       v.Data,
+		ContentType: v.ContentType,
+	})
+}
+
+func (s *Store) appendVersion(ctx context.Context, key string, v Version) error {
+	// The lock serializes updates to the index within this process only
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	idx, err := s.getIndex(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	v.Version = idx.Latest + 1
+	v.Time = s.clock.Now()
+	err = s.setRecord(ctx, s.versionKey(key, v.Version), v)
+	if err != nil {
+		return fmt.Errorf("failed to save version %d of key %s: %w", v.Version, key, err)
+	}
+
+	idx.Latest = v.Version
+	err = s.setRecord(ctx, indexKey(key), idx)
+	if err != nil {
+		return fmt.Errorf("failed to save history index of key %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (s *Store) getIndex(ctx context.Context, key string) (idx historyIndex, err error) {
+	res, err := s.store.Get(ctx, &state.GetRequest{Key: indexKey(key)})
+	if err != nil {
+		return idx, fmt.Errorf("failed to read history index of key %s: %w", key, err)
+	}
+	if res == nil || len(res.Data) == 0 {
+		return idx, nil
+	}
+
+	err = json.Unmarshal(res.Data, &idx)
+	if err != nil {
+		return idx, fmt.Errorf("invalid history index for key %s: %w", key, err)
+	}
+	return idx, nil
+}
+
+func (s *Store) getVersion(ctx context.Context, key string, version int64) (*Version, error) {
+	if version <= 0 {
+		return nil, ErrVersionNotFound
+	}
+
+	res, err := s.store.Get(ctx, &state.GetRequest{Key: s.versionKey(key, version)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version %d of key %s: %w", version, key, err)
+	}
+	if res == nil || len(res.Data) == 0 {
+		return nil, ErrVersionNotFound
+	}
+
+	v := &Version{}
+	err = json.Unmarshal(res.Data, v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version record for key %s: %w", key, err)
+	}
+
+	// The slot may have been overwritten by a newer version
+	if v.Version != version {
+		return nil, ErrVersionNotFound
+	}
+	return v, nil
+}
+
+func (s *Store) setRecord(ctx context.Context, key string, val any) error {
+	rec, err := newRecord(key, val)
+	if err != nil {
+		return err
+	}
+	return s.store.Set(ctx, &rec)
+}
+
+func newRecord(key string, val any) (state.SetRequest, error) {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return state.SetRequest{}, err
+	}
+	return state.SetRequest{
+		Key:   key,
+		Value: data,
+	}, nil
+}
+
+func (s *Store) versionKey(key string, version int64) string {
+	return key + historySeparator + strconv.FormatInt(version%s.maxVersions, 10)
+}
+
+func indexKey(key string) string {
+	return key + historySeparator + historyIndexSuffix
+}
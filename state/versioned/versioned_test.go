@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versioned
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state"
+	inmemory "github.com/dapr/components-contrib/state/in-memory"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+func newTestStore(t *testing.T, maxVersions int) *Store {
+	t.Helper()
+
+	s := NewStore(inmemory.NewInMemoryStateStore(logger.NewLogger("test")), Options{MaxVersions: maxVersions})
+	require.NoError(t, s.Init(context.Background(), state.Metadata{}))
+	return s
+}
+
+func TestVersionedStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("delete records a tombstone and restore recovers the value", func(t *testing.T) {
+		s := newTestStore(t, 5)
+
+		require.NoError(t, s.Set(ctx, &state.SetRequest{Key: "k", Value: "v1"}))
+		require.NoError(t, s.Set(ctx, &state.SetRequest{Key: "k", Value: "v2"}))
+		require.NoError(t, s.Delete(ctx, &state.DeleteRequest{Key: "k"}))
+
+		res, err := s.Get(ctx, &state.GetRequest{Key: "k"})
+		require.NoError(t, err)
+		assert.Empty(t, res.Data)
+
+		versions, err := s.ListVersions(ctx, "k")
+		require.NoError(t, err)
+		require.Len(t, versions, 3)
+		assert.Equal(t, int64(3), versions[0].Version)
+		assert.True(t, versions[0].Deleted)
+		assert.Equal(t, `"v2"`, string(versions[1].Data))
+		assert.Equal(t, `"v1"`, string(versions[2].Data))
+
+		require.NoError(t, s.Restore(ctx, "k", 2))
+
+		res, err = s.Get(ctx, &state.GetRequest{Key: "k"})
+		require.NoError(t, err)
+		assert.Equal(t, `"v2"`, string(res.Data))
+
+		versions, err = s.ListVersions(ctx, "k")
+		require.NoError(t, err)
+		require.Len(t, versions, 4)
+		assert.Equal(t, int64(4), versions[0].Version)
+		assert.False(t, versions[0].Deleted)
+	})
+
+	t.Run("only the most recent versions are retained", func(t *testing.T) {
+		s := newTestStore(t, 2)
+
+		for _, v := range []string{"a", "b", "c"} {
+			require.NoError(t, s.Set(ctx, &state.SetRequest{Key: "k", Value: v}))
+		}
+
+		versions, err := s.ListVersions(ctx, "k")
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		assert.Equal(t, int64(3), versions[0].Version)
+		assert.Equal(t, int64(2), versions[1].Version)
+
+		_, err = s.GetVersion(ctx, "k", 1)
+		require.ErrorIs(t, err, ErrVersionNotFound)
+		err = s.Restore(ctx, "k", 1)
+		require.ErrorIs(t, err, ErrVersionNotFound)
+	})
+
+	t.Run("transactions record versions", func(t *testing.T) {
+		s := newTestStore(t, 5)
+
+		err := s.Multi(ctx, &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "a", Value: "1"},
+				state.DeleteRequest{Key: "b"},
+			},
+		})
+		require.NoError(t, err)
+
+		versions, err := s.ListVersions(ctx, "a")
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+		assert.Equal(t, `"1"`, string(versions[0].Data))
+
+		versions, err = s.ListVersions(ctx, "b")
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+		assert.True(t, versions[0].Deleted)
+	})
+
+	t.Run("transactions record a version for each operation on the same key", func(t *testing.T) {
+		s := newTestStore(t, 5)
+
+		require.NoError(t, s.Set(ctx, &state.SetRequest{Key: "a", Value: "1"}))
+		err := s.Multi(ctx, &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "a", Value: "2"},
+				state.SetRequest{Key: "a", Value: "3"},
+			},
+		})
+		require.NoError(t, err)
+
+		versions, err := s.ListVersions(ctx, "a")
+		require.NoError(t, err)
+		require.Len(t, versions, 3)
+		assert.Equal(t, int64(3), versions[0].Version)
+		assert.Equal(t, `"3"`, string(versions[0].Data))
+		assert.Equal(t, `"2"`, string(versions[1].Data))
+	})
+
+	t.Run("failed transactions record no versions", func(t *testing.T) {
+		s := newTestStore(t, 5)
+
+		err := s.Multi(ctx, &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "a", Value: "1"},
+				state.DeleteRequest{Key: "b", ETag: ptr.Of("invalid")},
+			},
+		})
+		require.Error(t, err)
+
+		versions, err := s.ListVersions(ctx, "a")
+		require.NoError(t, err)
+		assert.Empty(t, versions)
+	})
+
+	t.Run("unknown key has no versions", func(t *testing.T) {
+		s := newTestStore(t, 0)
+
+		versions, err := s.ListVersions(ctx, "missing")
+		require.NoError(t, err)
+		assert.Empty(t, versions)
+	})
+}
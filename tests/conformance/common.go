@@ -73,6 +73,7 @@ import (
 	p_kafka "github.com/dapr/components-contrib/pubsub/kafka"
 	p_kubemq "github.com/dapr/components-contrib/pubsub/kubemq"
 	p_mqtt3 "github.com/dapr/components-contrib/pubsub/mqtt3"
+	p_nats "github.com/dapr/components-contrib/pubsub/nats"
 	p_natsstreaming "github.com/dapr/components-contrib/pubsub/natsstreaming"
 	p_pulsar "github.com/dapr/components-contrib/pubsub/pulsar"
 	p_rabbitmq "github.com/dapr/components-contrib/pubsub/rabbitmq"
@@ -387,6 +388,9 @@ func (tc *TestConfiguration) Run(t *testing.T) {
 				pubsubConfig, err := conf_pubsub.NewTestConfig(comp.Component, comp.Operations, comp.Config)
 				require.NoErrorf(t, err, "error running conformance test for component %s", comp.Component)
 				conf_pubsub.ConformanceTests(t, props, pubsub, pubsubConfig)
+				if pubsubConfig.HasOperation("ordering") {
+					conf_pubsub.OrderingTests(t, props, pubSubFactory(comp), pubsubConfig)
+				}
 			case "bindings":
 				filepath := fmt.Sprintf("../config/bindings/%s", componentConfigPath)
 				props, err := tc.loadComponentsAndProperties(t, filepath)
@@ -448,6 +452,13 @@ func loadConfigurationStore(tc TestComponent) (configuration.Store, configupdate
 	return store, updater
 }
 
+// pubSubFactory returns a function that creates new instances of the pubsub component.
+func pubSubFactory(tc TestComponent) func() pubsub.PubSub {
+	return func() pubsub.PubSub {
+		return loadPubSub(tc)
+	}
+}
+
 func loadPubSub(tc TestComponent) pubsub.PubSub {
 	var pubsub pubsub.PubSub
 	switch tc.Component {
@@ -461,6 +472,8 @@ func loadPubSub(tc TestComponent) pubsub.PubSub {
 		pubsub = p_servicebustopics.NewAzureServiceBusTopics(testLogger)
 	case "azure.servicebus.queues":
 		pubsub = p_servicebusqueues.NewAzureServiceBusQueues(testLogger)
+	case "nats":
+		pubsub = p_nats.NewNATSPubSub(testLogger)
 	case "natsstreaming":
 		pubsub = p_natsstreaming.NewNATSStreamingPubSub(testLogger)
 	case "jetstream":
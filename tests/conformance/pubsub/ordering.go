@@ -0,0 +1,281 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/pubsub"
+)
+
+const (
+	defaultOrderingTopicName      = "orderingTopic"
+	defaultOrderingKeyMetadataKey = "partitionKey"
+	defaultOrderingKeyCount       = 3
+	defaultOrderingMessagesPerKey = 10
+	// Sequence number of the message of each key that fails the first time it's delivered.
+	orderingFailingSequence = 3
+)
+
+// OrderingTests verifies the ordering guarantees of components that deliver the messages with the same ordering key in order,
+// such as Azure Service Bus with sessions, Kafka with partition keys, and Pulsar with key-shared subscriptions.
+// The scenarios are:
+//   - "in order": messages with the same key are delivered in the order they were published, including when one is redelivered after a failure.
+//   - "slow handler": a handler slower than the lock duration doesn't cause redeliveries, because the lock is renewed. Runs only if orderingSlowHandlerDelay is set.
+//   - "reconnect": after the connection is dropped while messages are processed, a new connection receives the remaining messages in order.
+//
+// newPubSub must return a new, uninitialized instance of the component each time it's invoked.
+func OrderingTests(t *testing.T, props map[string]string, newPubSub func() pubsub.PubSub, config TestConfig) {
+	t.Run("in order", func(t *testing.T) {
+		ps := initOrderingPubSub(t, props, newPubSub)
+		defer ps.Close()
+
+		checker := newOrderingChecker(config, 0)
+		subscribeOrdering(t, ps, config, checker)
+		checker.publish(t, ps, 1, config.OrderingMessagesPerKey)
+		checker.wait(t, config.OrderingKeyCount*config.OrderingMessagesPerKey, config.MaxReadDuration)
+		checker.assertInOrder(t)
+	})
+
+	t.Run("slow handler", func(t *testing.T) {
+		if config.OrderingSlowHandlerDelay <= 0 {
+			t.Skip("orderingSlowHandlerDelay is not set")
+		}
+
+		ps := initOrderingPubSub(t, props, newPubSub)
+		defer ps.Close()
+
+		checker := newOrderingChecker(config, config.OrderingSlowHandlerDelay)
+		subscribeOrdering(t, ps, config, checker)
+		checker.publish(t, ps, 1, config.OrderingMessagesPerKey)
+		checker.wait(t, config.OrderingKeyCount*config.OrderingMessagesPerKey, config.MaxReadDuration+config.OrderingSlowHandlerDelay)
+		checker.assertInOrder(t)
+		// If the lock wasn't renewed while the handler was running, the message would be delivered again
+		checker.assertNoRedeliveries(t)
+	})
+
+	t.Run("reconnect", func(t *testing.T) {
+		ps := initOrderingPubSub(t, props, newPubSub)
+
+		checker := newOrderingChecker(config, 0)
+		subscribeCtx, subscribeCancel := context.WithCancel(context.Background())
+		subscribeOrderingWithContext(t, subscribeCtx, ps, config, checker)
+		checker.publish(t, ps, 1, config.OrderingMessagesPerKey)
+
+		// Drop the connection once about half of the messages were processed
+		half := config.OrderingKeyCount * config.OrderingMessagesPerKey / 2
+		checker.wait(t, half, config.MaxReadDuration)
+		t.Logf("Dropping the connection after %d messages were processed", checker.processedCount())
+		subscribeCancel()
+		require.NoError(t, ps.Close())
+
+		// The remaining messages are received by a new connection, in order
+		ps = initOrderingPubSub(t, props, newPubSub)
+		defer ps.Close()
+		subscribeOrdering(t, ps, config, checker)
+		checker.wait(t, config.OrderingKeyCount*config.OrderingMessagesPerKey, config.MaxReadDuration)
+		checker.assertInOrder(t)
+	})
+}
+
+func initOrderingPubSub(t *testing.T, props map[string]string, newPubSub func() pubsub.PubSub) pubsub.PubSub {
+	ps := newPubSub()
+	require.NotNil(t, ps)
+	err := ps.Init(context.Background(), pubsub.Metadata{
+		Base: metadata.Base{Properties: props},
+	})
+	require.NoError(t, err, "expected no error on setting up pubsub")
+	return ps
+}
+
+func subscribeOrdering(t *testing.T, ps pubsub.PubSub, config TestConfig, checker *orderingChecker) {
+	subscribeOrderingWithContext(t, context.Background(), ps, config, checker)
+}
+
+func subscribeOrderingWithContext(t *testing.T, ctx context.Context, ps pubsub.PubSub, config TestConfig, checker *orderingChecker) {
+	err := ps.Subscribe(ctx, pubsub.SubscribeRequest{
+		Topic:    config.OrderingTopicName,
+		Metadata: config.OrderingSubscribeMetadata,
+	}, checker.handle)
+	require.NoError(t, err, "expected no error on subscribe")
+
+	// Some pubsub, like Kafka need to wait for Subscriber to be up before messages can be consumed.
+	time.Sleep(config.WaitDurationToPublish)
+}
+
+// orderingChecker is the handler of the ordering tests, which records the order in which the messages of each key are processed.
+type orderingChecker struct {
+	config     TestConfig
+	dataPrefix string
+	keys       []string
+	slowDelay  time.Duration
+
+	lock        sync.Mutex
+	cond        *sync.Cond
+	last        map[string]int
+	processed   int
+	failed      map[string]struct{}
+	outOfOrder  []string
+	redelivered map[string]int
+}
+
+func newOrderingChecker(config TestConfig, slowDelay time.Duration) *orderingChecker {
+	// Keys are unique to the run, so messages left in the broker by previous runs are ignored and sessions are new
+	runID := uuid.Must(uuid.NewRandom()).String()
+	keys := make([]string, config.OrderingKeyCount)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d-%s", i, runID)
+	}
+
+	c := &orderingChecker{
+		config:      config,
+		dataPrefix:  "ordering-" + runID + "|",
+		keys:        keys,
+		slowDelay:   slowDelay,
+		last:        make(map[string]int, len(keys)),
+		failed:      make(map[string]struct{}, len(keys)),
+		redelivered: map[string]int{},
+	}
+	c.cond = sync.NewCond(&c.lock)
+	return c
+}
+
+// publish publishes the messages with the sequence numbers from start to end (inclusive) for each key, interleaving the keys.
+func (c *orderingChecker) publish(t *testing.T, ps pubsub.PubSub, start, end int) {
+	for seq := start; seq <= end; seq++ {
+		for _, key := range c.keys {
+			md := make(map[string]string, len(c.config.OrderingPublishMetadata)+1)
+			for k, v := range c.config.OrderingPublishMetadata {
+				md[k] = v
+			}
+			md[c.config.OrderingKeyMetadataKey] = key
+
+			data := c.dataPrefix + key + "|" + strconv.Itoa(seq)
+			err := ps.Publish(context.Background(), &pubsub.PublishRequest{
+				Data:       []byte(data),
+				PubsubName: c.config.PubsubName,
+				Topic:      c.config.OrderingTopicName,
+				Metadata:   md,
+			})
+			require.NoError(t, err, "expected no error on publishing data %s on topic %s", data, c.config.OrderingTopicName)
+		}
+	}
+}
+
+func (c *orderingChecker) handle(ctx context.Context, msg *pubsub.NewMessage) error {
+	data := string(msg.Data)
+	if !strings.HasPrefix(data, c.dataPrefix) {
+		return nil
+	}
+	key, seqStr, ok := strings.Cut(data[len(c.dataPrefix):], "|")
+	if !ok {
+		return fmt.Errorf("invalid message: %s", data)
+	}
+	seq, err := strconv.Atoi(seqStr)
+	if err != nil {
+		return fmt.Errorf("invalid message: %s", data)
+	}
+
+	c.lock.Lock()
+	last := c.last[key]
+	if seq <= last {
+		// Redelivery of a message that was processed already
+		c.redelivered[data]++
+		c.lock.Unlock()
+		return nil
+	}
+	if seq != last+1 {
+		c.outOfOrder = append(c.outOfOrder, fmt.Sprintf("key %s: expected sequence %d, got %d", key, last+1, seq))
+	}
+	_, failedBefore := c.failed[data]
+	if seq == orderingFailingSequence && !failedBefore {
+		// The message must be redelivered before the following messages with the same key
+		c.failed[data] = struct{}{}
+		c.lock.Unlock()
+		return errors.New("conf test simulated error")
+	}
+	c.lock.Unlock()
+
+	if c.slowDelay > 0 && seq == 1 {
+		select {
+		case <-time.After(c.slowDelay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	c.lock.Lock()
+	if seq > c.last[key] {
+		c.last[key] = seq
+		c.processed++
+		c.cond.Broadcast()
+	}
+	c.lock.Unlock()
+	return nil
+}
+
+func (c *orderingChecker) processedCount() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.processed
+}
+
+// wait waits until count messages were processed, or the timeout has elapsed.
+func (c *orderingChecker) wait(t *testing.T, count int, timeout time.Duration) {
+	t.Logf("waiting for %v for %d messages to be processed", timeout, count)
+	timer := time.AfterFunc(timeout, func() {
+		c.lock.Lock()
+		c.cond.Broadcast()
+		c.lock.Unlock()
+	})
+	defer timer.Stop()
+	deadline := time.Now().Add(timeout)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for c.processed < count && time.Now().Before(deadline) {
+		c.cond.Wait()
+	}
+	assert.GreaterOrEqual(t, c.processed, count, "expected to process %d messages before the timeout", count)
+}
+
+func (c *orderingChecker) assertInOrder(t *testing.T) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	assert.Empty(t, c.outOfOrder, "received messages out of order")
+	for _, key := range c.keys {
+		assert.Equal(t, c.config.OrderingMessagesPerKey, c.last[key], "expected all messages of key %s to be processed", key)
+	}
+	if len(c.redelivered) > 0 {
+		t.Logf("Messages delivered again after they were processed: %v", c.redelivered)
+	}
+}
+
+func (c *orderingChecker) assertNoRedeliveries(t *testing.T) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	assert.Empty(t, c.redelivered, "expected no messages to be delivered again after they were processed")
+}
@@ -67,6 +67,15 @@ type TestConfig struct {
 	WaitDurationToPublish  time.Duration     `mapstructure:"waitDurationToPublish"`
 	CheckInOrderProcessing bool              `mapstructure:"checkInOrderProcessing"`
 	TestProjectID          string            `mapstructure:"testProjectID"`
+
+	// Configuration of the ordering tests, which run when the "ordering" operation is set.
+	OrderingTopicName         string            `mapstructure:"orderingTopicName"`
+	OrderingKeyMetadataKey    string            `mapstructure:"orderingKeyMetadataKey"`
+	OrderingPublishMetadata   map[string]string `mapstructure:"orderingPublishMetadata"`
+	OrderingSubscribeMetadata map[string]string `mapstructure:"orderingSubscribeMetadata"`
+	OrderingKeyCount          int               `mapstructure:"orderingKeyCount"`
+	OrderingMessagesPerKey    int               `mapstructure:"orderingMessagesPerKey"`
+	OrderingSlowHandlerDelay  time.Duration     `mapstructure:"orderingSlowHandlerDelay"`
 }
 
 func NewTestConfig(componentName string, operations []string, configMap map[string]interface{}) (TestConfig, error) {
@@ -90,6 +99,13 @@ func NewTestConfig(componentName string, operations []string, configMap map[stri
 		CheckInOrderProcessing: defaultCheckInOrderProcessing,
 		TestTopicForBulkSub:    defaultTopicNameBulk,
 		TestProjectID:          defaultProjectID,
+
+		OrderingTopicName:         defaultOrderingTopicName,
+		OrderingKeyMetadataKey:    defaultOrderingKeyMetadataKey,
+		OrderingPublishMetadata:   map[string]string{},
+		OrderingSubscribeMetadata: map[string]string{},
+		OrderingKeyCount:          defaultOrderingKeyCount,
+		OrderingMessagesPerKey:    defaultOrderingMessagesPerKey,
 	}
 
 	err := config.Decode(configMap, &tc)
@@ -991,6 +991,10 @@ func ConformanceTests(t *testing.T, props map[string]string, statestore state.St
 
 	if config.HasOperation("ttl") {
 		t.Run("set and get with TTL", func(t *testing.T) {
+			// Check if TTL feature is listed
+			features := statestore.Features()
+			require.True(t, state.FeatureTTL.IsPresent(features))
+
 			err := statestore.Set(context.Background(), &state.SetRequest{
 				Key:   key + "-ttl",
 				Value: "⏱️",